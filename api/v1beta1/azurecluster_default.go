@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"k8s.io/utils/ptr"
+	azureutil "sigs.k8s.io/cluster-api-provider-azure/util/azure"
 )
 
 const (
@@ -43,6 +44,13 @@ const (
 	DefaultOutboundRuleIdleTimeoutInMinutes = 4
 	// DefaultAzureCloud is the public cloud that will be used by most users.
 	DefaultAzureCloud = "AzurePublicCloud"
+	// AzureStackCloud is the custom cloud whose environment is read from the file set in the AZURE_ENVIRONMENT_FILEPATH
+	// environment variable.
+	AzureStackCloud = "AzureStackCloud"
+	// DefaultTrafficManagerTTL is the default DNS time-to-live of the Traffic Manager profile in seconds.
+	DefaultTrafficManagerTTL = 30
+	// DefaultTrafficManagerMonitorPath is the default path of the health probe of the Traffic Manager endpoints.
+	DefaultTrafficManagerMonitorPath = "/readyz"
 )
 
 func (c *AzureCluster) setDefaults() {
@@ -59,6 +67,9 @@ func (c *AzureCluster) setNetworkSpecDefaults() {
 	c.setAPIServerLBDefaults()
 	c.SetNodeOutboundLBDefaults()
 	c.SetControlPlaneOutboundLBDefaults()
+	c.setOutboundPublicIPPrefixDefaults()
+	c.setTrafficManagerDefaults()
+	c.setEgressValidationDefaults()
 }
 
 func (c *AzureCluster) setResourceGroupDefault() {
@@ -165,6 +176,15 @@ func (c *AzureCluster) setSubnetDefaults() {
 
 func (c *AzureCluster) setVnetPeeringDefaults() {
 	for i, peering := range c.Spec.NetworkSpec.Vnet.Peerings {
+		if resourceID, err := azureutil.ParseResourceID(peering.RemoteVnetID); err == nil {
+			if peering.ResourceGroup == "" {
+				c.Spec.NetworkSpec.Vnet.Peerings[i].ResourceGroup = resourceID.ResourceGroupName
+				peering.ResourceGroup = resourceID.ResourceGroupName
+			}
+			if peering.RemoteVnetName == "" {
+				c.Spec.NetworkSpec.Vnet.Peerings[i].RemoteVnetName = resourceID.Name
+			}
+		}
 		if peering.ResourceGroup == "" {
 			c.Spec.NetworkSpec.Vnet.Peerings[i].ResourceGroup = c.Spec.ResourceGroup
 		}
@@ -326,6 +346,39 @@ func (c *AzureCluster) setOutboundLBFrontendIPs(lb *LoadBalancerSpec, generatePu
 	}
 }
 
+func (c *AzureCluster) setOutboundPublicIPPrefixDefaults() {
+	if prefix := c.Spec.NetworkSpec.OutboundPublicIPPrefix; prefix != nil && prefix.Name == "" {
+		prefix.Name = generateOutboundPublicIPPrefixName(c.ObjectMeta.Name)
+	}
+}
+
+func (c *AzureCluster) setEgressValidationDefaults() {
+	egressValidation := c.Spec.NetworkSpec.EgressValidation
+	if egressValidation == nil || len(egressValidation.RequiredDestinations) > 0 {
+		return
+	}
+	egressValidation.RequiredDestinations = []string{"MicrosoftContainerRegistry", "AzureActiveDirectory"}
+}
+
+func (c *AzureCluster) setTrafficManagerDefaults() {
+	trafficManager := c.Spec.NetworkSpec.TrafficManager
+	if trafficManager == nil {
+		return
+	}
+	if trafficManager.Name == "" {
+		trafficManager.Name = generateTrafficManagerProfileName(c.ObjectMeta.Name)
+	}
+	if trafficManager.RoutingMethod == "" {
+		trafficManager.RoutingMethod = TrafficManagerRoutingMethodPriority
+	}
+	if trafficManager.TTL == 0 {
+		trafficManager.TTL = DefaultTrafficManagerTTL
+	}
+	if trafficManager.MonitorPath == "" {
+		trafficManager.MonitorPath = DefaultTrafficManagerMonitorPath
+	}
+}
+
 func (c *AzureCluster) setBastionDefaults() {
 	if c.Spec.BastionSpec.AzureBastion != nil {
 		if c.Spec.BastionSpec.AzureBastion.Name == "" {
@@ -470,6 +523,16 @@ func generateControlPlaneOutboundIPName(clusterName string) string {
 	return fmt.Sprintf("pip-%s-controlplane-outbound", clusterName)
 }
 
+// generateOutboundPublicIPPrefixName generates the name of the outbound public IP prefix.
+func generateOutboundPublicIPPrefixName(clusterName string) string {
+	return fmt.Sprintf("ippre-%s-outbound", clusterName)
+}
+
+// generateTrafficManagerProfileName generates the name of the Traffic Manager profile fronting the API server.
+func generateTrafficManagerProfileName(clusterName string) string {
+	return fmt.Sprintf("traf-%s-apiserver", clusterName)
+}
+
 // generateNatGatewayName generates a NAT gateway name.
 func generateNatGatewayName(clusterName string) string {
 	return fmt.Sprintf("%s-%s", clusterName, "node-natgw")
@@ -951,6 +951,49 @@ func TestVnetPeeringDefaults(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "peering with remote vnet ID",
+			cluster: &AzureCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cluster-test",
+				},
+				Spec: AzureClusterSpec{
+					ResourceGroup: "cluster-test",
+					NetworkSpec: NetworkSpec{
+						Vnet: VnetSpec{
+							Peerings: VnetPeerings{
+								{
+									VnetPeeringClassSpec: VnetPeeringClassSpec{
+										RemoteVnetID: "/subscriptions/456/resourceGroups/hub-rg/providers/Microsoft.Network/virtualNetworks/hub-vnet",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			output: &AzureCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cluster-test",
+				},
+				Spec: AzureClusterSpec{
+					ResourceGroup: "cluster-test",
+					NetworkSpec: NetworkSpec{
+						Vnet: VnetSpec{
+							Peerings: VnetPeerings{
+								{
+									VnetPeeringClassSpec: VnetPeeringClassSpec{
+										RemoteVnetName: "hub-vnet",
+										ResourceGroup:  "hub-rg",
+										RemoteVnetID:   "/subscriptions/456/resourceGroups/hub-rg/providers/Microsoft.Network/virtualNetworks/hub-vnet",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -967,6 +1010,94 @@ func TestVnetPeeringDefaults(t *testing.T) {
 	}
 }
 
+func TestOutboundPublicIPPrefixDefaults(t *testing.T) {
+	cases := []struct {
+		name   string
+		prefix *PublicIPPrefixSpec
+		output *PublicIPPrefixSpec
+	}{
+		{
+			name:   "no public IP prefix",
+			prefix: nil,
+			output: nil,
+		},
+		{
+			name:   "public IP prefix without name",
+			prefix: &PublicIPPrefixSpec{PrefixLength: 30},
+			output: &PublicIPPrefixSpec{Name: "ippre-cluster-test-outbound", PrefixLength: 30},
+		},
+		{
+			name:   "public IP prefix with name",
+			prefix: &PublicIPPrefixSpec{Name: "my-prefix", PrefixLength: 30},
+			output: &PublicIPPrefixSpec{Name: "my-prefix", PrefixLength: 30},
+		},
+	}
+	for _, c := range cases {
+		tc := c
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cluster := &AzureCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cluster-test",
+				},
+				Spec: AzureClusterSpec{
+					NetworkSpec: NetworkSpec{OutboundPublicIPPrefix: tc.prefix},
+				},
+			}
+			cluster.setOutboundPublicIPPrefixDefaults()
+			if !reflect.DeepEqual(cluster.Spec.NetworkSpec.OutboundPublicIPPrefix, tc.output) {
+				expected, _ := json.MarshalIndent(tc.output, "", "\t")
+				actual, _ := json.MarshalIndent(cluster.Spec.NetworkSpec.OutboundPublicIPPrefix, "", "\t")
+				t.Errorf("Expected %s, got %s", string(expected), string(actual))
+			}
+		})
+	}
+}
+
+func TestEgressValidationDefaults(t *testing.T) {
+	cases := []struct {
+		name             string
+		egressValidation *EgressValidationSpec
+		output           *EgressValidationSpec
+	}{
+		{
+			name:             "no egress validation",
+			egressValidation: nil,
+			output:           nil,
+		},
+		{
+			name:             "egress validation without required destinations",
+			egressValidation: &EgressValidationSpec{},
+			output:           &EgressValidationSpec{RequiredDestinations: []string{"MicrosoftContainerRegistry", "AzureActiveDirectory"}},
+		},
+		{
+			name:             "egress validation with required destinations",
+			egressValidation: &EgressValidationSpec{RequiredDestinations: []string{"AzureCloud"}},
+			output:           &EgressValidationSpec{RequiredDestinations: []string{"AzureCloud"}},
+		},
+	}
+	for _, c := range cases {
+		tc := c
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cluster := &AzureCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cluster-test",
+				},
+				Spec: AzureClusterSpec{
+					NetworkSpec: NetworkSpec{EgressValidation: tc.egressValidation},
+				},
+			}
+			cluster.setEgressValidationDefaults()
+			if !reflect.DeepEqual(cluster.Spec.NetworkSpec.EgressValidation, tc.output) {
+				expected, _ := json.MarshalIndent(tc.output, "", "\t")
+				actual, _ := json.MarshalIndent(cluster.Spec.NetworkSpec.EgressValidation, "", "\t")
+				t.Errorf("Expected %s, got %s", string(expected), string(actual))
+			}
+		})
+	}
+}
+
 func TestAPIServerLBDefaults(t *testing.T) {
 	cases := []struct {
 		name    string
@@ -2282,3 +2413,69 @@ func TestBastionDefault(t *testing.T) {
 		})
 	}
 }
+
+func TestTrafficManagerDefaults(t *testing.T) {
+	endpoints := []TrafficManagerEndpoint{{Name: "westeurope", Target: "my-cluster-westeurope.westeurope.cloudapp.azure.com"}}
+	cases := []struct {
+		name           string
+		trafficManager *TrafficManagerSpec
+		output         *TrafficManagerSpec
+	}{
+		{
+			name:           "no traffic manager",
+			trafficManager: nil,
+			output:         nil,
+		},
+		{
+			name:           "traffic manager without defaults",
+			trafficManager: &TrafficManagerSpec{RelativeDNSName: "my-cluster", Endpoints: endpoints},
+			output: &TrafficManagerSpec{
+				Name:            "traf-cluster-test-apiserver",
+				RelativeDNSName: "my-cluster",
+				RoutingMethod:   TrafficManagerRoutingMethodPriority,
+				TTL:             30,
+				MonitorPath:     "/readyz",
+				Endpoints:       endpoints,
+			},
+		},
+		{
+			name: "traffic manager with all fields set",
+			trafficManager: &TrafficManagerSpec{
+				Name:            "my-profile",
+				RelativeDNSName: "my-cluster",
+				RoutingMethod:   TrafficManagerRoutingMethodWeighted,
+				TTL:             60,
+				MonitorPath:     "/livez",
+				Endpoints:       endpoints,
+			},
+			output: &TrafficManagerSpec{
+				Name:            "my-profile",
+				RelativeDNSName: "my-cluster",
+				RoutingMethod:   TrafficManagerRoutingMethodWeighted,
+				TTL:             60,
+				MonitorPath:     "/livez",
+				Endpoints:       endpoints,
+			},
+		},
+	}
+	for _, c := range cases {
+		tc := c
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cluster := &AzureCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cluster-test",
+				},
+				Spec: AzureClusterSpec{
+					NetworkSpec: NetworkSpec{TrafficManager: tc.trafficManager},
+				},
+			}
+			cluster.setTrafficManagerDefaults()
+			if !reflect.DeepEqual(cluster.Spec.NetworkSpec.TrafficManager, tc.output) {
+				expected, _ := json.MarshalIndent(tc.output, "", "\t")
+				actual, _ := json.MarshalIndent(cluster.Spec.NetworkSpec.TrafficManager, "", "\t")
+				t.Errorf("Expected %s, got %s", string(expected), string(actual))
+			}
+		})
+	}
+}
@@ -45,6 +45,19 @@ type AzureClusterSpec struct {
 	// +optional
 	BastionSpec BastionSpec `json:"bastionSpec,omitempty"`
 
+	// DataCollectionRuleID is the Azure resource ID of an Azure Monitor data collection rule to associate with the
+	// VMs of the cluster, e.g. to collect metrics with Azure Monitor managed service for Prometheus. The associations
+	// are deleted when it is removed.
+	// +optional
+	DataCollectionRuleID string `json:"dataCollectionRuleID,omitempty"`
+
+	// ResourceGroupDeletionProtection applies a CanNotDelete management lock to the resource group of the cluster. The
+	// lock prevents the deletion of the resource group and of the resources in it, including the VMs of the machines of
+	// the cluster. It is only removed when the AzureCluster has the capz.io/remove-resource-group-lock annotation set
+	// to "true": the deletion of the AzureCluster doesn't proceed until then, and protection can only be disabled with it.
+	// +optional
+	ResourceGroupDeletionProtection bool `json:"resourceGroupDeletionProtection,omitempty"`
+
 	// ControlPlaneEndpoint represents the endpoint used to communicate with the control plane. It is not recommended to set
 	// this when creating an AzureCluster as CAPZ will set this for you. However, if it is set, CAPZ will not change it.
 	// +optional
@@ -19,8 +19,10 @@ package v1beta1
 import (
 	"fmt"
 	"net"
+	"net/url"
 	"reflect"
 	"regexp"
+	"strings"
 
 	valid "github.com/asaskevich/govalidator"
 	corev1 "k8s.io/api/core/v1"
@@ -29,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/cluster-api-provider-azure/feature"
+	azureutil "sigs.k8s.io/cluster-api-provider-azure/util/azure"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
@@ -50,6 +53,18 @@ const (
 	MinLBIdleTimeoutInMinutes = 4
 	// MaxLBIdleTimeoutInMinutes is the maximum number of minutes for the LB idle timeout.
 	MaxLBIdleTimeoutInMinutes = 30
+	// MinVnetFlowTimeoutInMinutes is the minimum number of minutes for the Vnet flow timeout.
+	MinVnetFlowTimeoutInMinutes = 4
+	// MaxVnetFlowTimeoutInMinutes is the maximum number of minutes for the Vnet flow timeout.
+	MaxVnetFlowTimeoutInMinutes = 30
+	// MinPublicIPPrefixLength is the shortest length of an IPv4 public IP prefix.
+	MinPublicIPPrefixLength = 28
+	// MaxPublicIPPrefixLength is the longest length of an IPv4 public IP prefix.
+	MaxPublicIPPrefixLength = 31
+	// MinTrafficManagerEndpointPriority is the lowest priority or weight of a Traffic Manager endpoint.
+	MinTrafficManagerEndpointPriority = 1
+	// MaxTrafficManagerEndpointPriority is the highest priority or weight of a Traffic Manager endpoint.
+	MaxTrafficManagerEndpointPriority = 1000
 	// Network security rules should be a number between 100 and 4096.
 	// https://learn.microsoft.com/azure/virtual-network/network-security-groups-overview#security-rules
 	minRulePriority = 100
@@ -60,13 +75,25 @@ const (
 	serviceEndpointLocationRegexPattern = `^([a-z]{1,42}\d{0,5}|[*])$`
 	// described in https://learn.microsoft.com/azure/azure-resource-manager/management/resource-name-rules.
 	privateEndpointRegex = `^[-\w\._]+$`
+	// Service tags, such as AzureCloud or Storage.WestUS, start with a letter and may have a regional suffix.
+	serviceTagRegexPattern = `^[A-Za-z][A-Za-z0-9]*(\.[A-Za-z0-9]+)?$`
 	// resource ID Pattern.
 	resourceIDPattern = `(?i)subscriptions/(.+)/resourceGroups/(.+)/providers/(.+?)/(.+?)/(.+)`
+	// described in https://learn.microsoft.com/azure/azure-resource-manager/management/resource-name-rules.
+	trafficManagerProfileNameRegexPattern = `^[a-zA-Z0-9]([a-zA-Z0-9.-]{0,61}[a-zA-Z0-9])?$`
+	// The relative DNS name of a Traffic Manager profile is a DNS label under trafficmanager.net.
+	trafficManagerRelativeDNSNameRegexPattern = `^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`
+	// The DNS label of a public IP is the first label of its DNS name, e.g. <label>.<location>.cloudapp.azure.com.
+	publicIPDNSLabelRegexPattern = `^[a-z][a-z0-9-]{1,61}[a-z0-9]$`
 )
 
 var (
-	serviceEndpointServiceRegex  = regexp.MustCompile(serviceEndpointServiceRegexPattern)
-	serviceEndpointLocationRegex = regexp.MustCompile(serviceEndpointLocationRegexPattern)
+	serviceEndpointServiceRegex        = regexp.MustCompile(serviceEndpointServiceRegexPattern)
+	serviceEndpointLocationRegex       = regexp.MustCompile(serviceEndpointLocationRegexPattern)
+	serviceTagRegex                    = regexp.MustCompile(serviceTagRegexPattern)
+	trafficManagerProfileNameRegex     = regexp.MustCompile(trafficManagerProfileNameRegexPattern)
+	trafficManagerRelativeDNSNameRegex = regexp.MustCompile(trafficManagerRelativeDNSNameRegexPattern)
+	publicIPDNSLabelRegex              = regexp.MustCompile(publicIPDNSLabelRegexPattern)
 )
 
 // validateCluster validates a cluster.
@@ -74,11 +101,12 @@ func (c *AzureCluster) validateCluster(old *AzureCluster) (admission.Warnings, e
 	var allErrs field.ErrorList
 	allErrs = append(allErrs, c.validateClusterName()...)
 	allErrs = append(allErrs, c.validateClusterSpec(old)...)
+	warnings := inboundNATRulesFloatingIPWarnings(c.Spec.NetworkSpec.APIServerLB.InboundNATRules)
 	if len(allErrs) == 0 {
-		return nil, nil
+		return warnings, nil
 	}
 
-	return nil, apierrors.NewInvalid(
+	return warnings, apierrors.NewInvalid(
 		schema.GroupKind{Group: "infrastructure.cluster.x-k8s.io", Kind: "AzureCluster"},
 		c.Name, allErrs)
 }
@@ -104,7 +132,15 @@ func (c *AzureCluster) validateClusterSpec(old *AzureCluster) field.ErrorList {
 		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "ExtendedLocation"), "can be set only if the EdgeZone feature flag is enabled"))
 	}
 
-	if err := validateBastionSpec(c.Spec.BastionSpec, field.NewPath("spec").Child("azureBastion").Child("bastionSpec")); err != nil {
+	allErrs = append(allErrs, validateExtendedLocationNetwork(c.Spec.ExtendedLocation, c.Spec.NetworkSpec, c.Spec.BastionSpec, field.NewPath("spec"))...)
+
+	if err := validateSecondaryLocation(c.Spec.SecondaryLocation, c.Spec.Location, field.NewPath("spec").Child("secondaryLocation")); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	allErrs = append(allErrs, validateBastionSpec(c.Spec.BastionSpec, field.NewPath("spec").Child("azureBastion").Child("bastionSpec"))...)
+
+	if err := validateDataCollectionRuleID(c.Spec.DataCollectionRuleID, field.NewPath("spec").Child("dataCollectionRuleID")); err != nil {
 		allErrs = append(allErrs, err)
 	}
 
@@ -112,9 +148,74 @@ func (c *AzureCluster) validateClusterSpec(old *AzureCluster) field.ErrorList {
 		allErrs = append(allErrs, err)
 	}
 
+	if err := validateTokenAudience(c.Spec.TokenAudience, c.Spec.AzureEnvironment, field.NewPath("spec").Child("tokenAudience")); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	return allErrs
+}
+
+// validateExtendedLocationNetwork validates that the network resources of a cluster in an extended location, e.g. an
+// edge zone, can be created in it. Edge zones don't have availability zones nor Azure Bastion.
+func validateExtendedLocationNetwork(extendedLocation *ExtendedLocationSpec, networkSpec NetworkSpec, bastionSpec BastionSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if extendedLocation == nil {
+		return allErrs
+	}
+
+	if bastionSpec.AzureBastion != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("bastionSpec", "azureBastion"), "Azure Bastion is not supported in an extended location"))
+	}
+
+	lbs := []struct {
+		name string
+		lb   *LoadBalancerSpec
+	}{
+		{name: "apiServerLB", lb: &networkSpec.APIServerLB},
+		{name: "nodeOutboundLB", lb: networkSpec.NodeOutboundLB},
+		{name: "controlPlaneOutboundLB", lb: networkSpec.ControlPlaneOutboundLB},
+	}
+	for _, lb := range lbs {
+		if lb.lb == nil {
+			continue
+		}
+		for i, frontendIP := range lb.lb.FrontendIPs {
+			if frontendIP.PublicIP != nil && len(frontendIP.PublicIP.Zones) > 0 {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("networkSpec", lb.name, "frontendIPs").Index(i).Child("publicIP", "zones"),
+					"zones are not supported on a public IP in an extended location"))
+			}
+		}
+	}
 	return allErrs
 }
 
+// validateTokenAudience validates that a token audience is only set for a custom cloud, as the token audiences of the
+// other clouds are fixed, and that it is an HTTPS URL.
+func validateTokenAudience(audience, environment string, fldPath *field.Path) *field.Error {
+	if audience == "" {
+		return nil
+	}
+	if !strings.EqualFold(environment, AzureStackCloud) {
+		return field.Forbidden(fldPath, fmt.Sprintf("can only be set for the %s environment, the token audience of %s is fixed", AzureStackCloud, environment))
+	}
+	if u, err := url.Parse(audience); err != nil || u.Scheme != "https" || u.Host == "" {
+		return field.Invalid(fldPath, audience, "must be an HTTPS URL")
+	}
+	return nil
+}
+
+// validateSecondaryLocation validates that the secondary location of a cluster, if any, is a different location than
+// its location.
+func validateSecondaryLocation(secondaryLocation, location string, fldPath *field.Path) *field.Error {
+	if secondaryLocation == "" {
+		return nil
+	}
+	if normalizeLocation(secondaryLocation) == normalizeLocation(location) {
+		return field.Invalid(fldPath, secondaryLocation, "must be a different location than the location of the cluster")
+	}
+	return nil
+}
+
 // validateClusterName validates ClusterName.
 func (c *AzureCluster) validateClusterName() field.ErrorList {
 	var allErrs field.ErrorList
@@ -134,12 +235,33 @@ func (c *AzureCluster) validateClusterName() field.ErrorList {
 }
 
 // validateBastionSpec validates a BastionSpec.
-func validateBastionSpec(bastionSpec BastionSpec, fldPath *field.Path) *field.Error {
-	if bastionSpec.AzureBastion != nil && bastionSpec.AzureBastion.Sku != StandardBastionHostSku && bastionSpec.AzureBastion.EnableTunneling {
-		return field.Invalid(fldPath.Child("sku"), bastionSpec.AzureBastion.Sku,
-			"sku must be Standard if tunneling is enabled")
+func validateBastionSpec(bastionSpec BastionSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	bastion := bastionSpec.AzureBastion
+	if bastion == nil {
+		return allErrs
 	}
-	return nil
+	if bastion.Sku != StandardBastionHostSku && bastion.EnableTunneling {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("sku"), bastion.Sku,
+			"sku must be Standard if tunneling is enabled"))
+	}
+	if len(bastion.PublicIP.Zones) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("publicIP", "zones"), "zones are not supported on the Azure Bastion public IP"))
+	}
+	if err := validatePublicIPDNSName(bastion.PublicIP.DNSName, fldPath.Child("publicIP", "dnsName")); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if bastion.ScaleUnits != nil {
+		if bastion.Sku != StandardBastionHostSku {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("scaleUnits"), *bastion.ScaleUnits,
+				"scaleUnits can only be set when sku is Standard"))
+		}
+		if *bastion.ScaleUnits < MinBastionHostScaleUnits || *bastion.ScaleUnits > MaxBastionHostScaleUnits {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("scaleUnits"), *bastion.ScaleUnits,
+				fmt.Sprintf("scaleUnits must be between %d and %d", MinBastionHostScaleUnits, MaxBastionHostScaleUnits)))
+		}
+	}
+	return allErrs
 }
 
 // validateIdentityRef validates an IdentityRef.
@@ -172,6 +294,8 @@ func validateNetworkSpec(networkSpec NetworkSpec, old NetworkSpec, fldPath *fiel
 		allErrs = append(allErrs, validateVnetPeerings(networkSpec.Vnet.Peerings, fldPath.Child("peerings"))...)
 	}
 
+	allErrs = append(allErrs, validateVnetFlowTimeout(networkSpec.Vnet.FlowTimeoutInMinutes, fldPath.Child("vnet").Child("flowTimeoutInMinutes"))...)
+
 	var cidrBlocks []string
 	controlPlaneSubnet, err := networkSpec.GetControlPlaneSubnet()
 	if err != nil {
@@ -192,11 +316,33 @@ func validateNetworkSpec(networkSpec NetworkSpec, old NetworkSpec, fldPath *fiel
 	if needOutboundLB {
 		allErrs = append(allErrs, validateNodeOutboundLB(networkSpec.NodeOutboundLB, old.NodeOutboundLB, networkSpec.APIServerLB, fldPath.Child("nodeOutboundLB"))...)
 	}
+	if networkSpec.NodeOutboundLB != nil {
+		allErrs = append(allErrs, validateAdditionalBackendPools(networkSpec.NodeOutboundLB.BackendPool, networkSpec.NodeOutboundLB.AdditionalBackendPools,
+			fldPath.Child("nodeOutboundLB").Child("additionalBackendPools"))...)
+		if networkSpec.NodeOutboundLB.EnableFloatingIP != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("nodeOutboundLB").Child("enableFloatingIP"), "floating IP is only supported for the API server load balancer"))
+		}
+		if networkSpec.NodeOutboundLB.HealthProbe != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("nodeOutboundLB").Child("healthProbe"), "health probes are only supported for the API server load balancer"))
+		}
+	}
 
 	allErrs = append(allErrs, validateControlPlaneOutboundLB(networkSpec.ControlPlaneOutboundLB, networkSpec.APIServerLB, fldPath.Child("controlPlaneOutboundLB"))...)
 
 	allErrs = append(allErrs, validatePrivateDNSZoneName(networkSpec.PrivateDNSZoneName, networkSpec.APIServerLB.Type, fldPath.Child("privateDNSZoneName"))...)
 
+	allErrs = append(allErrs, validateOutboundPublicIPPrefix(networkSpec, fldPath.Child("outboundPublicIPPrefix"))...)
+
+	allErrs = append(allErrs, validateOutboundDeny(networkSpec, fldPath)...)
+
+	allErrs = append(allErrs, validateEgressValidation(networkSpec, fldPath)...)
+
+	allErrs = append(allErrs, validateTrafficManager(networkSpec, fldPath)...)
+
+	allErrs = append(allErrs, validatePublicIPZones(networkSpec, fldPath)...)
+
+	allErrs = append(allErrs, validatePublicIPDNSNames(networkSpec, fldPath)...)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -220,6 +366,8 @@ func validateSubnets(subnets Subnets, vnet VnetSpec, fldPath *field.Path) field.
 		"control-plane": false,
 		"node":          false,
 	}
+	routeTables := make(map[string][]Route)
+	natGateways := make(map[string]SubnetSpec)
 
 	for i, subnet := range subnets {
 		if err := validateSubnetName(subnet.Name, fldPath.Index(i).Child("name")); err != nil {
@@ -234,6 +382,24 @@ func validateSubnets(subnets Subnets, vnet VnetSpec, fldPath *field.Path) field.
 				requiredSubnetRoles[role] = true
 			}
 		}
+		allErrs = append(allErrs, validateRouteTable(subnet.RouteTable, fldPath.Index(i).Child("routeTable"))...)
+		if routes, ok := routeTables[subnet.RouteTable.Name]; ok && !reflect.DeepEqual(routes, subnet.RouteTable.Routes) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("routeTable").Child("routes"), subnet.RouteTable.Routes,
+				fmt.Sprintf("subnets sharing route table %s must specify the same routes", subnet.RouteTable.Name)))
+		} else if subnet.RouteTable.Name != "" {
+			routeTables[subnet.RouteTable.Name] = subnet.RouteTable.Routes
+		}
+		if subnet.NatGateway.Name != "" {
+			if shared, ok := natGateways[subnet.NatGateway.Name]; !ok {
+				natGateways[subnet.NatGateway.Name] = subnet
+			} else if subnet.Role != SubnetNode || shared.Role != SubnetNode {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Index(i).Child("natGateway").Child("name"),
+					fmt.Sprintf("NAT gateway %s can only be shared by node subnets", subnet.NatGateway.Name)))
+			} else if subnet.NatGateway.NatGatewayIP.Name != shared.NatGateway.NatGatewayIP.Name {
+				allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("natGateway").Child("ip").Child("name"), subnet.NatGateway.NatGatewayIP.Name,
+					fmt.Sprintf("subnets sharing NAT gateway %s must specify the same public IP %s", subnet.NatGateway.Name, shared.NatGateway.NatGatewayIP.Name)))
+			}
+		}
 		for _, rule := range subnet.SecurityGroup.SecurityRules {
 			if err := validateSecurityRule(
 				rule,
@@ -242,7 +408,12 @@ func validateSubnets(subnets Subnets, vnet VnetSpec, fldPath *field.Path) field.
 				allErrs = append(allErrs, err)
 			}
 		}
+		allErrs = append(allErrs, validateSecurityRulePriorities(subnet.SecurityGroup.SecurityGroupClass, fldPath.Index(i).Child("securityGroup"))...)
 		allErrs = append(allErrs, validateSubnetCIDR(subnet.CIDRBlocks, vnet.CIDRBlocks, fldPath.Index(i).Child("cidrBlocks"))...)
+		if subnet.Role == SubnetNode && subnet.IsIPv6Enabled() && !subnet.IsDualStack() {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("cidrBlocks"), subnet.CIDRBlocks,
+				"node subnets with an IPv6 CIDR block must also have an IPv4 CIDR block"))
+		}
 
 		if len(subnet.ServiceEndpoints) > 0 {
 			allErrs = append(allErrs, validateServiceEndpoints(subnet.ServiceEndpoints, fldPath.Index(i).Child("serviceEndpoints"))...)
@@ -258,6 +429,9 @@ func validateSubnets(subnets Subnets, vnet VnetSpec, fldPath *field.Path) field.
 				fmt.Sprintf("required role %s not included in provided subnets", k)))
 		}
 	}
+	allErrs = append(allErrs, validateGatewaySubnet(subnets, fldPath)...)
+	allErrs = append(allErrs, validateRouteServerSubnet(subnets, fldPath)...)
+	allErrs = append(allErrs, validatePodSubnet(subnets, fldPath)...)
 	return allErrs
 }
 
@@ -270,6 +444,191 @@ func validateSubnetName(name string, fldPath *field.Path) *field.Error {
 	return nil
 }
 
+// validateRouteTable validates the user-defined routes of a RouteTable.
+func validateRouteTable(routeTable RouteTable, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if len(routeTable.Routes) > 0 && routeTable.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "name is required when routes are specified"))
+	}
+
+	routeNames := make(map[string]bool, len(routeTable.Routes))
+	for i, route := range routeTable.Routes {
+		routePath := fldPath.Child("routes").Index(i)
+		if route.Name == "" {
+			allErrs = append(allErrs, field.Required(routePath.Child("name"), "route name is required"))
+		} else if routeNames[strings.ToLower(route.Name)] {
+			allErrs = append(allErrs, field.Duplicate(routePath.Child("name"), route.Name))
+		}
+		routeNames[strings.ToLower(route.Name)] = true
+
+		if route.AddressPrefix == "" {
+			allErrs = append(allErrs, field.Required(routePath.Child("addressPrefix"), "address prefix is required"))
+		} else if strings.Contains(route.AddressPrefix, "/") {
+			// Address prefixes without a mask are service tags.
+			if _, _, err := net.ParseCIDR(route.AddressPrefix); err != nil {
+				allErrs = append(allErrs, field.Invalid(routePath.Child("addressPrefix"), route.AddressPrefix, "invalid CIDR format"))
+			}
+		}
+
+		switch route.NextHopType {
+		case RouteNextHopTypeVirtualAppliance:
+			if route.NextHopIPAddress == "" {
+				allErrs = append(allErrs, field.Required(routePath.Child("nextHopIPAddress"), "next hop IP address is required when next hop type is VirtualAppliance"))
+			} else if net.ParseIP(route.NextHopIPAddress) == nil {
+				allErrs = append(allErrs, field.Invalid(routePath.Child("nextHopIPAddress"), route.NextHopIPAddress, "must be a valid IP address"))
+			}
+		case RouteNextHopTypeVirtualNetworkGateway, RouteNextHopTypeVnetLocal, RouteNextHopTypeInternet, RouteNextHopTypeNone:
+			if route.NextHopIPAddress != "" {
+				allErrs = append(allErrs, field.Forbidden(routePath.Child("nextHopIPAddress"), "next hop IP address is only allowed when next hop type is VirtualAppliance"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(routePath.Child("nextHopType"), route.NextHopType, []string{
+				string(RouteNextHopTypeVirtualNetworkGateway),
+				string(RouteNextHopTypeVnetLocal),
+				string(RouteNextHopTypeInternet),
+				string(RouteNextHopTypeVirtualAppliance),
+				string(RouteNextHopTypeNone),
+			}))
+		}
+	}
+
+	return allErrs
+}
+
+// validateGatewaySubnet validates the placement of the virtual network gateway subnet, if any.
+// The gateway subnet must follow the Azure naming and sizing requirements for ExpressRoute gateways and must not
+// overlap with the address space of the other subnets, which can otherwise be placed right next to it.
+func validateGatewaySubnet(subnets Subnets, fldPath *field.Path) field.ErrorList {
+	return validateReservedSubnet(subnets, SubnetGateway, GatewaySubnetName, GatewaySubnetMaxPrefixLength, "gateway", fldPath)
+}
+
+// validateRouteServerSubnet validates the placement of the Azure Route Server subnet, if any.
+// The route server subnet must follow the Azure naming and sizing requirements for Route Servers, which don't support
+// network security groups, and must not overlap with the address space of the other subnets.
+func validateRouteServerSubnet(subnets Subnets, fldPath *field.Path) field.ErrorList {
+	return validateReservedSubnet(subnets, SubnetRouteServer, RouteServerSubnetName, RouteServerSubnetMaxPrefixLength, "route server", fldPath)
+}
+
+// validateReservedSubnet validates the placement of the subnet with a role reserved for an Azure service, if any. The
+// subnet must have the name Azure requires, be large enough for the service, have neither a network security group nor
+// a NAT gateway, and not overlap with the other subnets. There can only be one subnet with the role.
+func validateReservedSubnet(subnets Subnets, role SubnetRole, name string, maxPrefixLength int, description string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var reservedNetworks []*net.IPNet
+	reservedIndex := -1
+
+	for i, subnet := range subnets {
+		if subnet.Role != role {
+			continue
+		}
+		if reservedIndex >= 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Index(i).Child("role"), fmt.Sprintf("only one subnet can have the %s role", role)))
+			continue
+		}
+		reservedIndex = i
+
+		if subnet.Name != name {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("name"), subnet.Name, fmt.Sprintf("%s subnet must be named %s", description, name)))
+		}
+		if subnet.SecurityGroup.Name != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Index(i).Child("securityGroup"), fmt.Sprintf("network security groups are not supported on the %s subnet", description)))
+		}
+		if subnet.NatGateway.Name != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Index(i).Child("natGateway"), fmt.Sprintf("NAT gateways are not supported on the %s subnet", description)))
+		}
+		for _, cidr := range subnet.CIDRBlocks {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				// Invalid CIDRs are reported by validateSubnetCIDR.
+				continue
+			}
+			if ones, bits := ipNet.Mask.Size(); bits == 32 && ones > maxPrefixLength {
+				allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("cidrBlocks"), cidr, fmt.Sprintf("%s subnet prefix length must be /%d or shorter", description, maxPrefixLength)))
+			}
+			reservedNetworks = append(reservedNetworks, ipNet)
+		}
+	}
+
+	if reservedIndex < 0 {
+		return allErrs
+	}
+
+	for i, subnet := range subnets {
+		if i == reservedIndex {
+			continue
+		}
+		for _, cidr := range subnet.CIDRBlocks {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				continue
+			}
+			for _, reservedNetwork := range reservedNetworks {
+				if reservedNetwork.Contains(ipNet.IP) || ipNet.Contains(reservedNetwork.IP) {
+					allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("cidrBlocks"), cidr, fmt.Sprintf("subnet CIDR overlaps with the %s subnet CIDR %s", description, reservedNetwork.String())))
+				}
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validatePodSubnet validates the pod subnet used by a self-managed Azure CNI, if any.
+// The pod subnet must be large enough to hold the IPs Azure CNI pre-allocates for the pods of every node, and must not
+// overlap with the address space of the other subnets.
+func validatePodSubnet(subnets Subnets, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var podNetworks []*net.IPNet
+	podIndex := -1
+
+	for i, subnet := range subnets {
+		if subnet.Role != SubnetPod {
+			continue
+		}
+		if podIndex >= 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Index(i).Child("role"), "only one subnet can have the pod role"))
+			continue
+		}
+		podIndex = i
+
+		for _, cidr := range subnet.CIDRBlocks {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				// Invalid CIDRs are reported by validateSubnetCIDR.
+				continue
+			}
+			if ones, bits := ipNet.Mask.Size(); bits == 32 && ones > PodSubnetMaxPrefixLength {
+				allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("cidrBlocks"), cidr, fmt.Sprintf("pod subnet prefix length must be /%d or shorter", PodSubnetMaxPrefixLength)))
+			}
+			podNetworks = append(podNetworks, ipNet)
+		}
+	}
+
+	if podIndex < 0 {
+		return allErrs
+	}
+
+	for i, subnet := range subnets {
+		if i == podIndex {
+			continue
+		}
+		for _, cidr := range subnet.CIDRBlocks {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				continue
+			}
+			for _, podNetwork := range podNetworks {
+				if podNetwork.Contains(ipNet.IP) || ipNet.Contains(podNetwork.IP) {
+					allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("cidrBlocks"), cidr, fmt.Sprintf("subnet CIDR overlaps with the pod subnet CIDR %s", podNetwork.String())))
+				}
+			}
+		}
+	}
+
+	return allErrs
+}
+
 // validateSubnetCIDR validates the CIDR blocks of a Subnet.
 func validateSubnetCIDR(subnetCidrBlocks []string, vnetCidrBlocks []string, fldPath *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
@@ -319,16 +678,341 @@ func validateVnetPeerings(peerings VnetPeerings, fldPath *field.Path) field.Erro
 	var allErrs field.ErrorList
 	vnetIdentifiers := make(map[string]bool, len(peerings))
 
-	for _, peering := range peerings {
+	for i, peering := range peerings {
 		vnetIdentifier := peering.ResourceGroup + "/" + peering.RemoteVnetName
 		if _, ok := vnetIdentifiers[vnetIdentifier]; ok {
 			allErrs = append(allErrs, field.Duplicate(fldPath, vnetIdentifier))
 		}
 		vnetIdentifiers[vnetIdentifier] = true
+		allErrs = append(allErrs, validateRemoteVnetID(peering.VnetPeeringClassSpec, fldPath.Index(i))...)
+	}
+	return allErrs
+}
+
+// validateRemoteVnetID validates that the remote virtual network ID of a peering is a virtual network resource ID
+// consistent with the resource group and name of the remote virtual network.
+func validateRemoteVnetID(peering VnetPeeringClassSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if peering.RemoteVnetID == "" {
+		return allErrs
+	}
+	resourceID, err := azureutil.ParseResourceID(peering.RemoteVnetID)
+	if err != nil {
+		return append(allErrs, field.Invalid(fldPath.Child("remoteVnetID"), peering.RemoteVnetID, "must be a valid Azure resource ID"))
+	}
+	if !strings.EqualFold(resourceID.ResourceType.String(), "Microsoft.Network/virtualNetworks") {
+		return append(allErrs, field.Invalid(fldPath.Child("remoteVnetID"), peering.RemoteVnetID, "must be a virtual network resource ID"))
+	}
+	if !strings.EqualFold(resourceID.ResourceGroupName, peering.ResourceGroup) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("resourceGroup"), peering.ResourceGroup,
+			"must match the resource group of the remote virtual network ID"))
+	}
+	if !strings.EqualFold(resourceID.Name, peering.RemoteVnetName) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("remoteVnetName"), peering.RemoteVnetName,
+			"must match the name of the remote virtual network ID"))
+	}
+	return allErrs
+}
+
+// validateVnetFlowTimeout validates the flow timeout of a Vnet.
+func validateVnetFlowTimeout(flowTimeoutInMinutes *int32, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if flowTimeoutInMinutes != nil && (*flowTimeoutInMinutes < MinVnetFlowTimeoutInMinutes || *flowTimeoutInMinutes > MaxVnetFlowTimeoutInMinutes) {
+		allErrs = append(allErrs, field.Invalid(fldPath, *flowTimeoutInMinutes,
+			fmt.Sprintf("flow timeout must be between %d and %d minutes", MinVnetFlowTimeoutInMinutes, MaxVnetFlowTimeoutInMinutes)))
+	}
+	return allErrs
+}
+
+// validateOutboundPublicIPPrefix validates the outbound public IP prefix, which must be large enough for the public
+// IPs of the node outbound load balancer and of the node NAT gateways allocated from it.
+func validateOutboundPublicIPPrefix(networkSpec NetworkSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	prefix := networkSpec.OutboundPublicIPPrefix
+	if prefix == nil {
+		return allErrs
+	}
+	if prefix.PrefixLength < MinPublicIPPrefixLength || prefix.PrefixLength > MaxPublicIPPrefixLength {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("prefixLength"), prefix.PrefixLength,
+			fmt.Sprintf("prefix length must be between %d and %d", MinPublicIPPrefixLength, MaxPublicIPPrefixLength)))
+		return allErrs
+	}
+	if count, size := networkSpec.OutboundPublicIPCount(), 1<<(32-prefix.PrefixLength); count > size {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("prefixLength"), prefix.PrefixLength,
+			fmt.Sprintf("a /%d prefix contains %d public IPs, but %d outbound public IPs are needed", prefix.PrefixLength, size, count)))
+	}
+	return allErrs
+}
+
+// loadBalancerPublicIP is the public IP of a load balancer frontend with its field path.
+type loadBalancerPublicIP struct {
+	publicIP *PublicIPSpec
+	fldPath  *field.Path
+}
+
+// loadBalancerPublicIPs returns the public IPs of the frontends of the load balancers of a network spec.
+func loadBalancerPublicIPs(networkSpec NetworkSpec, fldPath *field.Path) []loadBalancerPublicIP {
+	var publicIPs []loadBalancerPublicIP
+	appendPublicIPs := func(lb *LoadBalancerSpec, lbPath *field.Path) {
+		if lb == nil {
+			return
+		}
+		for i, frontendIP := range lb.FrontendIPs {
+			if frontendIP.PublicIP != nil {
+				publicIPs = append(publicIPs, loadBalancerPublicIP{
+					publicIP: frontendIP.PublicIP,
+					fldPath:  lbPath.Child("frontendIPs").Index(i).Child("publicIP"),
+				})
+			}
+		}
+	}
+	appendPublicIPs(&networkSpec.APIServerLB, fldPath.Child("apiServerLB"))
+	appendPublicIPs(networkSpec.ControlPlaneOutboundLB, fldPath.Child("controlPlaneOutboundLB"))
+	appendPublicIPs(networkSpec.NodeOutboundLB, fldPath.Child("nodeOutboundLB"))
+	return publicIPs
+}
+
+// validatePublicIPZones validates the availability zones of the public IPs. Zones can only be set on the public IPs
+// of load balancer frontends, as NAT gateway public IPs must be in the zone of their NAT gateway.
+func validatePublicIPZones(networkSpec NetworkSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, lbPublicIP := range loadBalancerPublicIPs(networkSpec, fldPath) {
+		zones := make(map[string]struct{}, len(lbPublicIP.publicIP.Zones))
+		for i, zone := range lbPublicIP.publicIP.Zones {
+			zonePath := lbPublicIP.fldPath.Child("zones").Index(i)
+			if zone == "" {
+				allErrs = append(allErrs, field.Required(zonePath, "zone must not be empty"))
+				continue
+			}
+			if _, ok := zones[zone]; ok {
+				allErrs = append(allErrs, field.Duplicate(zonePath, zone))
+			}
+			zones[zone] = struct{}{}
+		}
+	}
+	for i, subnet := range networkSpec.Subnets {
+		if len(subnet.NatGateway.NatGatewayIP.Zones) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("subnets").Index(i).Child("natGateway", "ip", "zones"),
+				"zones are not supported on NAT gateway public IPs"))
+		}
+	}
+	return allErrs
+}
+
+// validatePublicIPDNSNames validates the DNS names of the public IPs of the load balancer frontends and of the NAT
+// gateways.
+func validatePublicIPDNSNames(networkSpec NetworkSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, lbPublicIP := range loadBalancerPublicIPs(networkSpec, fldPath) {
+		if err := validatePublicIPDNSName(lbPublicIP.publicIP.DNSName, lbPublicIP.fldPath.Child("dnsName")); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	for i, subnet := range networkSpec.Subnets {
+		if err := validatePublicIPDNSName(subnet.NatGateway.NatGatewayIP.DNSName, fldPath.Child("subnets").Index(i).Child("natGateway", "ip", "dnsName")); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	return allErrs
+}
+
+// validatePublicIPDNSName validates that the first label of the DNS name of a public IP, which is set as its DNS label,
+// follows the Azure rules for public IP DNS labels. The uniqueness of the DNS label in the location is not validated.
+func validatePublicIPDNSName(dnsName string, fldPath *field.Path) *field.Error {
+	if dnsName == "" {
+		return nil
+	}
+	label := strings.Split(dnsName, ".")[0]
+	if !publicIPDNSLabelRegex.MatchString(label) {
+		return field.Invalid(fldPath, dnsName, fmt.Sprintf("DNS label %s must match the regular expression %s", label, publicIPDNSLabelRegexPattern))
+	}
+	return nil
+}
+
+// ValidatePublicIPZonesAvailability validates that the availability zones of the public IPs of the load balancer
+// frontends are available in the location of the cluster. An empty list of available zones means the location
+// doesn't support availability zones.
+func ValidatePublicIPZonesAvailability(networkSpec NetworkSpec, location string, availableZones []string) field.ErrorList {
+	var allErrs field.ErrorList
+	available := make(map[string]struct{}, len(availableZones))
+	for _, zone := range availableZones {
+		available[zone] = struct{}{}
+	}
+	for _, lbPublicIP := range loadBalancerPublicIPs(networkSpec, field.NewPath("spec", "networkSpec")) {
+		zones := lbPublicIP.publicIP.Zones
+		if len(zones) == 0 {
+			continue
+		}
+		if len(availableZones) == 0 {
+			allErrs = append(allErrs, field.Invalid(lbPublicIP.fldPath.Child("zones"), zones,
+				fmt.Sprintf("location %s does not support availability zones", location)))
+			continue
+		}
+		for i, zone := range zones {
+			if _, ok := available[zone]; !ok {
+				allErrs = append(allErrs, field.NotSupported(lbPublicIP.fldPath.Child("zones").Index(i), zone, availableZones))
+			}
+		}
 	}
 	return allErrs
 }
 
+// validateOutboundDeny validates the outbound deny configuration, which is only supported by private clusters and
+// reserves the highest security rule priorities of the node subnets.
+func validateOutboundDeny(networkSpec NetworkSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	outboundDeny := networkSpec.OutboundDeny
+	if outboundDeny == nil {
+		return allErrs
+	}
+	if networkSpec.APIServerLB.Type != Internal {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("outboundDeny"), "outbound deny can only be configured for private clusters"))
+	}
+
+	destinationsPath := fldPath.Child("outboundDeny", "allowedDestinations")
+	if len(outboundDeny.AllowedDestinations) > MaxOutboundAllowedDestinations {
+		allErrs = append(allErrs, field.TooMany(destinationsPath, len(outboundDeny.AllowedDestinations), MaxOutboundAllowedDestinations))
+	}
+	destinations := make(map[string]struct{}, len(outboundDeny.AllowedDestinations))
+	for i, destination := range outboundDeny.AllowedDestinations {
+		if err := validateOutboundAllowedDestination(destination, destinationsPath.Index(i)); err != nil {
+			allErrs = append(allErrs, err)
+		}
+		if _, ok := destinations[strings.ToLower(destination)]; ok {
+			allErrs = append(allErrs, field.Duplicate(destinationsPath.Index(i), destination))
+		}
+		destinations[strings.ToLower(destination)] = struct{}{}
+	}
+
+	for i, subnet := range networkSpec.Subnets {
+		if subnet.Role != SubnetNode {
+			continue
+		}
+		for j, rule := range subnet.SecurityGroup.SecurityRules {
+			if rule.Direction == SecurityRuleDirectionOutbound && rule.Priority >= OutboundDenyMinRulePriority {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("subnets").Index(i).Child("securityGroup", "securityRules").Index(j).Child("priority"), rule.Priority,
+					fmt.Sprintf("outbound security rule priorities from %d are reserved when outbound deny is configured", OutboundDenyMinRulePriority)))
+			}
+		}
+		if band := subnet.SecurityGroup.RulePriorityBand; band != nil && band.Max >= OutboundDenyMinRulePriority {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("subnets").Index(i).Child("securityGroup", "rulePriorityBand"), *band,
+				fmt.Sprintf("security rule priorities from %d are reserved when outbound deny is configured", OutboundDenyMinRulePriority)))
+		}
+	}
+	return allErrs
+}
+
+// validateEgressValidation validates the egress pre-flight validation, which is only supported by private clusters.
+func validateEgressValidation(networkSpec NetworkSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	egressValidation := networkSpec.EgressValidation
+	if egressValidation == nil {
+		return allErrs
+	}
+	if networkSpec.APIServerLB.Type != Internal {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("egressValidation"), "egress validation can only be configured for private clusters"))
+	}
+
+	destinationsPath := fldPath.Child("egressValidation", "requiredDestinations")
+	destinations := make(map[string]struct{}, len(egressValidation.RequiredDestinations))
+	for i, destination := range egressValidation.RequiredDestinations {
+		if err := validateOutboundAllowedDestination(destination, destinationsPath.Index(i)); err != nil {
+			allErrs = append(allErrs, err)
+		}
+		if _, ok := destinations[strings.ToLower(destination)]; ok {
+			allErrs = append(allErrs, field.Duplicate(destinationsPath.Index(i), destination))
+		}
+		destinations[strings.ToLower(destination)] = struct{}{}
+	}
+	return allErrs
+}
+
+// validateTrafficManager validates the Traffic Manager profile fronting the API server endpoints, which is only
+// supported by public clusters as Traffic Manager resolves to public endpoints and probes them over the internet.
+func validateTrafficManager(networkSpec NetworkSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	trafficManager := networkSpec.TrafficManager
+	if trafficManager == nil {
+		return allErrs
+	}
+	fldPath = fldPath.Child("trafficManager")
+	if networkSpec.APIServerLB.Type == Internal {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "a Traffic Manager profile can only front the API server of public clusters"))
+	}
+	if !trafficManagerProfileNameRegex.MatchString(trafficManager.Name) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), trafficManager.Name,
+			fmt.Sprintf("name must match the regular expression %s", trafficManagerProfileNameRegexPattern)))
+	}
+	if !trafficManagerRelativeDNSNameRegex.MatchString(trafficManager.RelativeDNSName) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("relativeDNSName"), trafficManager.RelativeDNSName,
+			fmt.Sprintf("relative DNS name must match the regular expression %s", trafficManagerRelativeDNSNameRegexPattern)))
+	}
+	if trafficManager.TTL < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("ttl"), trafficManager.TTL, "TTL must not be negative"))
+	}
+	if !strings.HasPrefix(trafficManager.MonitorPath, "/") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("monitorPath"), trafficManager.MonitorPath, "monitor path must start with /"))
+	}
+
+	endpointsPath := fldPath.Child("endpoints")
+	if len(trafficManager.Endpoints) == 0 {
+		allErrs = append(allErrs, field.Required(endpointsPath, "at least one endpoint is required"))
+	}
+	names := make(map[string]struct{}, len(trafficManager.Endpoints))
+	priorities := make(map[int64]struct{}, len(trafficManager.Endpoints))
+	for i, endpoint := range trafficManager.Endpoints {
+		endpointPath := endpointsPath.Index(i)
+		if endpoint.Name == "" {
+			allErrs = append(allErrs, field.Required(endpointPath.Child("name"), "endpoint name is required"))
+		} else if _, ok := names[strings.ToLower(endpoint.Name)]; ok {
+			allErrs = append(allErrs, field.Duplicate(endpointPath.Child("name"), endpoint.Name))
+		}
+		names[strings.ToLower(endpoint.Name)] = struct{}{}
+
+		if net.ParseIP(endpoint.Target) == nil && !valid.IsDNSName(endpoint.Target) {
+			allErrs = append(allErrs, field.Invalid(endpointPath.Child("target"), endpoint.Target, "target must be an FQDN or an IP address"))
+		}
+		if trafficManager.RoutingMethod == TrafficManagerRoutingMethodPerformance && endpoint.Location == "" {
+			allErrs = append(allErrs, field.Required(endpointPath.Child("location"),
+				fmt.Sprintf("endpoint location is required with the %s routing method", TrafficManagerRoutingMethodPerformance)))
+		}
+		if endpoint.Priority != nil && (*endpoint.Priority < MinTrafficManagerEndpointPriority || *endpoint.Priority > MaxTrafficManagerEndpointPriority) {
+			allErrs = append(allErrs, field.Invalid(endpointPath.Child("priority"), *endpoint.Priority,
+				fmt.Sprintf("priority must be between %d and %d", MinTrafficManagerEndpointPriority, MaxTrafficManagerEndpointPriority)))
+		} else if trafficManager.RoutingMethod == TrafficManagerRoutingMethodPriority {
+			// endpoints without a priority get their position in the list, which must not collide with other priorities.
+			priority := TrafficManagerEndpointPriority(trafficManager.Endpoints, i)
+			if _, ok := priorities[priority]; ok {
+				allErrs = append(allErrs, field.Duplicate(endpointPath.Child("priority"), priority))
+			}
+			priorities[priority] = struct{}{}
+		}
+		if endpoint.Weight != nil && (*endpoint.Weight < MinTrafficManagerEndpointPriority || *endpoint.Weight > MaxTrafficManagerEndpointPriority) {
+			allErrs = append(allErrs, field.Invalid(endpointPath.Child("weight"), *endpoint.Weight,
+				fmt.Sprintf("weight must be between %d and %d", MinTrafficManagerEndpointPriority, MaxTrafficManagerEndpointPriority)))
+		}
+	}
+	return allErrs
+}
+
+// validateOutboundAllowedDestination validates that an allowlisted outbound destination is a CIDR, an IP address or
+// a service tag that doesn't allow all outbound traffic.
+func validateOutboundAllowedDestination(destination string, fldPath *field.Path) *field.Error {
+	if strings.EqualFold(destination, "Internet") || destination == "*" {
+		return field.Invalid(fldPath, destination, "destination would allow all outbound traffic")
+	}
+	if _, ipNet, err := net.ParseCIDR(destination); err == nil {
+		if ones, _ := ipNet.Mask.Size(); ones == 0 {
+			return field.Invalid(fldPath, destination, "destination would allow all outbound traffic")
+		}
+		return nil
+	}
+	if net.ParseIP(destination) != nil || serviceTagRegex.MatchString(destination) {
+		return nil
+	}
+	return field.Invalid(fldPath, destination, "destination must be a CIDR, an IP address or a service tag")
+}
+
 // validateLoadBalancerName validates the Name of a Load Balancer.
 func validateLoadBalancerName(name string, fldPath *field.Path) *field.Error {
 	if success, _ := regexp.Match(loadBalancerRegex, []byte(name)); !success {
@@ -357,10 +1041,106 @@ func validateInternalLBIPAddress(address string, cidrs []string, fldPath *field.
 
 // validateSecurityRule validates a SecurityRule.
 func validateSecurityRule(rule SecurityRule, fldPath *field.Path) *field.Error {
-	if rule.Priority < minRulePriority || rule.Priority > maxRulePriority {
+	// A rule without a priority gets one from the rule priority band of its security group, see
+	// validateSecurityRulePriorities.
+	if rule.Priority != 0 && (rule.Priority < minRulePriority || rule.Priority > maxRulePriority) {
 		return field.Invalid(fldPath, rule.Priority, fmt.Sprintf("security rule priorities should be between %d and %d", minRulePriority, maxRulePriority))
 	}
 
+	if rule.Source != nil && len(rule.Sources) > 0 {
+		return field.Forbidden(fldPath.Child("sources"), "source and sources are mutually exclusive")
+	}
+
+	if rule.Destination != nil && len(rule.Destinations) > 0 {
+		return field.Forbidden(fldPath.Child("destinations"), "destination and destinations are mutually exclusive")
+	}
+
+	if len(rule.SourceApplicationSecurityGroups) > 0 && (rule.Source != nil || len(rule.Sources) > 0) {
+		return field.Forbidden(fldPath.Child("sourceApplicationSecurityGroups"), "sourceApplicationSecurityGroups is mutually exclusive with source and sources")
+	}
+
+	if len(rule.DestinationApplicationSecurityGroups) > 0 && (rule.Destination != nil || len(rule.Destinations) > 0) {
+		return field.Forbidden(fldPath.Child("destinationApplicationSecurityGroups"), "destinationApplicationSecurityGroups is mutually exclusive with destination and destinations")
+	}
+
+	for i, id := range rule.SourceApplicationSecurityGroups {
+		if err := validateApplicationSecurityGroupID(id, fldPath.Child("sourceApplicationSecurityGroups").Index(i)); err != nil {
+			return err
+		}
+	}
+
+	for i, id := range rule.DestinationApplicationSecurityGroups {
+		if err := validateApplicationSecurityGroupID(id, fldPath.Child("destinationApplicationSecurityGroups").Index(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateSecurityRulePriorities validates the rule priority band of a security group and the priorities of its
+// rules: only the rules of a security group with a band can omit their priority, and the priorities of the band are
+// reserved for them.
+func validateSecurityRulePriorities(sg SecurityGroupClass, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	band := sg.RulePriorityBand
+	rulesPath := fldPath.Child("securityRules")
+	if band == nil {
+		for i, rule := range sg.SecurityRules {
+			if rule.Priority == 0 {
+				allErrs = append(allErrs, field.Required(rulesPath.Index(i).Child("priority"),
+					"a priority is required unless the security group has a rulePriorityBand"))
+			}
+		}
+		return allErrs
+	}
+
+	bandPath := fldPath.Child("rulePriorityBand")
+	if band.Min < minRulePriority || band.Max > maxRulePriority || band.Min > band.Max {
+		return append(allErrs, field.Invalid(bandPath, *band,
+			fmt.Sprintf("the rule priority band should be a range of priorities between %d and %d", minRulePriority, maxRulePriority)))
+	}
+	var unassigned int32
+	for i, rule := range sg.SecurityRules {
+		switch {
+		case rule.Priority == 0:
+			unassigned++
+		case rule.Priority >= band.Min && rule.Priority <= band.Max:
+			allErrs = append(allErrs, field.Invalid(rulesPath.Index(i).Child("priority"), rule.Priority,
+				fmt.Sprintf("priorities from %d to %d are reserved for the rules without a priority", band.Min, band.Max)))
+		}
+	}
+	if size := band.Max - band.Min + 1; unassigned > size {
+		allErrs = append(allErrs, field.Invalid(bandPath, *band,
+			fmt.Sprintf("the band has %d priorities but %d rules have no priority", size, unassigned)))
+	}
+	return allErrs
+}
+
+// validateApplicationSecurityGroupID validates that an application security group reference is a valid Azure resource ID.
+func validateApplicationSecurityGroupID(id string, fldPath *field.Path) *field.Error {
+	resourceID, err := azureutil.ParseResourceID(id)
+	if err != nil {
+		return field.Invalid(fldPath, id, "must be a valid Azure resource ID")
+	}
+	if !strings.EqualFold(resourceID.ResourceType.String(), "Microsoft.Network/applicationSecurityGroups") {
+		return field.Invalid(fldPath, id, "must be an application security group resource ID")
+	}
+	return nil
+}
+
+// validateDataCollectionRuleID validates the ID of the data collection rule to associate with the cluster.
+func validateDataCollectionRuleID(id string, fldPath *field.Path) *field.Error {
+	if id == "" {
+		return nil
+	}
+	resourceID, err := azureutil.ParseResourceID(id)
+	if err != nil {
+		return field.Invalid(fldPath, id, "must be a valid Azure resource ID")
+	}
+	if !strings.EqualFold(resourceID.ResourceType.String(), "Microsoft.Insights/dataCollectionRules") {
+		return field.Invalid(fldPath, id, "must be a data collection rule resource ID")
+	}
 	return nil
 }
 
@@ -391,7 +1171,12 @@ func validateAPIServerLB(lb LoadBalancerSpec, old LoadBalancerSpec, cidrs []stri
 				allErrs = append(allErrs, field.Forbidden(fldPath.Child("frontendIPConfigs").Index(0).Child("publicIP"),
 					"Internal Load Balancers cannot have a Public IP"))
 			}
-			if lb.FrontendIPs[0].PrivateIPAddress != "" {
+			// The private IP of an internal load balancer frontend is statically allocated, including when the
+			// frontend is zone-redundant, so it must be set and be within the control plane subnet.
+			if lb.FrontendIPs[0].PrivateIPAddress == "" {
+				allErrs = append(allErrs, field.Required(fldPath.Child("frontendIPConfigs").Index(0).Child("privateIP"),
+					"Internal Load Balancers need a static private IP in the control plane subnet"))
+			} else {
 				if err := validateInternalLBIPAddress(lb.FrontendIPs[0].PrivateIPAddress, cidrs,
 					fldPath.Child("frontendIPConfigs").Index(0).Child("privateIP")); err != nil {
 					allErrs = append(allErrs, err)
@@ -411,6 +1196,64 @@ func validateAPIServerLB(lb LoadBalancerSpec, old LoadBalancerSpec, cidrs []stri
 		}
 	}
 
+	allErrs = append(allErrs, validateInboundNATRules(lb.InboundNATRules, fldPath.Child("inboundNATRules"))...)
+
+	allErrs = append(allErrs, validateHealthProbe(lb.HealthProbe, fldPath.Child("healthProbe"))...)
+
+	if len(lb.AdditionalBackendPools) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("additionalBackendPools"), "additional backend pools are only supported for the node outbound load balancer"))
+	}
+
+	return allErrs
+}
+
+// inboundNATRulesFloatingIPWarnings warns that floating IP requires matching frontend and backend ports, which the
+// inbound NAT rules of the control plane machines after the first one don't have.
+func inboundNATRulesFloatingIPWarnings(natRules *InboundNATRulesSpec) admission.Warnings {
+	if natRules == nil || !ptr.Deref(natRules.EnableFloatingIP, false) {
+		return nil
+	}
+	return admission.Warnings{
+		"spec.networkSpec.apiServerLB.inboundNATRules.enableFloatingIP: floating IP requires the frontend port of an inbound NAT rule to match its backend port 22, " +
+			"the inbound NAT rules of control plane machines using frontend ports 2201 and up won't forward SSH traffic",
+	}
+}
+
+// validateInboundNATRules validates the configuration of the inbound NAT rules of the API server load balancer.
+func validateInboundNATRules(natRules *InboundNATRulesSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if natRules == nil || natRules.IdleTimeoutInMinutes == nil {
+		return allErrs
+	}
+	if *natRules.IdleTimeoutInMinutes < MinLBIdleTimeoutInMinutes || *natRules.IdleTimeoutInMinutes > MaxLBIdleTimeoutInMinutes {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("idleTimeoutInMinutes"), *natRules.IdleTimeoutInMinutes,
+			fmt.Sprintf("inbound NAT rules idle timeout should be between %d and %d minutes", MinLBIdleTimeoutInMinutes, MaxLBIdleTimeoutInMinutes)))
+	}
+	return allErrs
+}
+
+// validateHealthProbe validates the health probe of the API server load balancer. HTTP and HTTPS probes request a
+// path, which TCP probes don't have.
+func validateHealthProbe(probe *HealthProbeSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if probe == nil {
+		return allErrs
+	}
+	switch probe.Protocol {
+	case ProbeProtocolHTTP, ProbeProtocolHTTPS:
+		if probe.RequestPath == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("requestPath"), fmt.Sprintf("request path is required for the %s protocol", probe.Protocol)))
+		} else if !strings.HasPrefix(probe.RequestPath, "/") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("requestPath"), probe.RequestPath, "request path must start with /"))
+		}
+	case ProbeProtocolTCP:
+		if probe.RequestPath != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("requestPath"), fmt.Sprintf("request path is not supported for the %s protocol", probe.Protocol)))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("protocol"), probe.Protocol,
+			[]string{string(ProbeProtocolTCP), string(ProbeProtocolHTTP), string(ProbeProtocolHTTPS)}))
+	}
 	return allErrs
 }
 
@@ -461,6 +1304,28 @@ func validateNodeOutboundLB(lb *LoadBalancerSpec, old *LoadBalancerSpec, apiserv
 			fmt.Sprintf("Max front end ips allowed is %d", MaxLoadBalancerOutboundIPs)))
 	}
 
+	if lb.InboundNATRules != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("inboundNATRules"), "inbound NAT rules are only supported for the API server load balancer"))
+	}
+
+	return allErrs
+}
+
+// validateAdditionalBackendPools validates that the additional backend pools of the node outbound load balancer have
+// names which are unique across all the backend pools of the load balancer.
+func validateAdditionalBackendPools(pool BackendPool, additionalPools []BackendPool, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	names := map[string]bool{pool.Name: true}
+	for i, additionalPool := range additionalPools {
+		if additionalPool.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Index(i).Child("name"), "additional backend pools must have a name"))
+			continue
+		}
+		if names[additionalPool.Name] {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("name"), additionalPool.Name))
+		}
+		names[additionalPool.Name] = true
+	}
 	return allErrs
 }
 
@@ -482,6 +1347,22 @@ func validateControlPlaneOutboundLB(lb *LoadBalancerSpec, apiserverLB LoadBalanc
 		}
 	}
 
+	if lb != nil && lb.InboundNATRules != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("inboundNATRules"), "inbound NAT rules are only supported for the API server load balancer"))
+	}
+
+	if lb != nil && len(lb.AdditionalBackendPools) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("additionalBackendPools"), "additional backend pools are only supported for the node outbound load balancer"))
+	}
+
+	if lb != nil && lb.EnableFloatingIP != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("enableFloatingIP"), "floating IP is only supported for the API server load balancer"))
+	}
+
+	if lb != nil && lb.HealthProbe != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("healthProbe"), "health probes are only supported for the API server load balancer"))
+	}
+
 	return allErrs
 }
 
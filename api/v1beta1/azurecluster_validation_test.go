@@ -17,6 +17,7 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -454,6 +455,956 @@ func TestValidateVnetCIDR(t *testing.T) {
 	}
 }
 
+func TestValidateVnetPeerings(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name        string
+		peerings    VnetPeerings
+		wantErr     bool
+		expectedErr field.Error
+	}{
+		{
+			name: "peerings with remote vnet names",
+			peerings: VnetPeerings{
+				{VnetPeeringClassSpec: VnetPeeringClassSpec{ResourceGroup: "rg2", RemoteVnetName: "vnet2"}},
+				{VnetPeeringClassSpec: VnetPeeringClassSpec{ResourceGroup: "rg3", RemoteVnetName: "vnet3"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "duplicate peerings",
+			peerings: VnetPeerings{
+				{VnetPeeringClassSpec: VnetPeeringClassSpec{ResourceGroup: "rg2", RemoteVnetName: "vnet2"}},
+				{VnetPeeringClassSpec: VnetPeeringClassSpec{ResourceGroup: "rg2", RemoteVnetName: "vnet2"}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueDuplicate",
+				Field:    "peerings",
+				BadValue: "rg2/vnet2",
+			},
+		},
+		{
+			name: "peering with a remote vnet ID",
+			peerings: VnetPeerings{
+				{VnetPeeringClassSpec: VnetPeeringClassSpec{
+					ResourceGroup:  "hub-rg",
+					RemoteVnetName: "hub-vnet",
+					RemoteVnetID:   "/subscriptions/456/resourceGroups/hub-rg/providers/Microsoft.Network/virtualNetworks/hub-vnet",
+				}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "peering with an invalid remote vnet ID",
+			peerings: VnetPeerings{
+				{VnetPeeringClassSpec: VnetPeeringClassSpec{ResourceGroup: "hub-rg", RemoteVnetName: "hub-vnet", RemoteVnetID: "hub-vnet"}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "peerings[0].remoteVnetID",
+				BadValue: "hub-vnet",
+				Detail:   "must be a valid Azure resource ID",
+			},
+		},
+		{
+			name: "peering with a remote ID that is not a vnet",
+			peerings: VnetPeerings{
+				{VnetPeeringClassSpec: VnetPeeringClassSpec{
+					ResourceGroup:  "hub-rg",
+					RemoteVnetName: "hub-vnet",
+					RemoteVnetID:   "/subscriptions/456/resourceGroups/hub-rg/providers/Microsoft.Network/networkSecurityGroups/hub-vnet",
+				}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "peerings[0].remoteVnetID",
+				BadValue: "/subscriptions/456/resourceGroups/hub-rg/providers/Microsoft.Network/networkSecurityGroups/hub-vnet",
+				Detail:   "must be a virtual network resource ID",
+			},
+		},
+		{
+			name: "peering with a remote vnet name not matching the remote vnet ID",
+			peerings: VnetPeerings{
+				{VnetPeeringClassSpec: VnetPeeringClassSpec{
+					ResourceGroup:  "hub-rg",
+					RemoteVnetName: "other-vnet",
+					RemoteVnetID:   "/subscriptions/456/resourceGroups/hub-rg/providers/Microsoft.Network/virtualNetworks/hub-vnet",
+				}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "peerings[0].remoteVnetName",
+				BadValue: "other-vnet",
+				Detail:   "must match the name of the remote virtual network ID",
+			},
+		},
+		{
+			name: "peering with a resource group not matching the remote vnet ID",
+			peerings: VnetPeerings{
+				{VnetPeeringClassSpec: VnetPeeringClassSpec{
+					ResourceGroup:  "cluster-rg",
+					RemoteVnetName: "hub-vnet",
+					RemoteVnetID:   "/subscriptions/456/resourceGroups/hub-rg/providers/Microsoft.Network/virtualNetworks/hub-vnet",
+				}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "peerings[0].resourceGroup",
+				BadValue: "cluster-rg",
+				Detail:   "must match the resource group of the remote virtual network ID",
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateVnetPeerings(testCase.peerings, field.NewPath("peerings"))
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateVnetFlowTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name                 string
+		flowTimeoutInMinutes *int32
+		wantErr              bool
+		expectedErr          field.Error
+	}{
+		{
+			name:                 "flow timeout not set",
+			flowTimeoutInMinutes: nil,
+			wantErr:              false,
+		},
+		{
+			name:                 "valid minimum flow timeout",
+			flowTimeoutInMinutes: ptr.To[int32](4),
+			wantErr:              false,
+		},
+		{
+			name:                 "valid maximum flow timeout",
+			flowTimeoutInMinutes: ptr.To[int32](30),
+			wantErr:              false,
+		},
+		{
+			name:                 "flow timeout too low",
+			flowTimeoutInMinutes: ptr.To[int32](3),
+			wantErr:              true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "vnet.flowTimeoutInMinutes",
+				BadValue: int32(3),
+				Detail:   "flow timeout must be between 4 and 30 minutes",
+			},
+		},
+		{
+			name:                 "flow timeout too high",
+			flowTimeoutInMinutes: ptr.To[int32](31),
+			wantErr:              true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "vnet.flowTimeoutInMinutes",
+				BadValue: int32(31),
+				Detail:   "flow timeout must be between 4 and 30 minutes",
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateVnetFlowTimeout(testCase.flowTimeoutInMinutes, field.NewPath("vnet", "flowTimeoutInMinutes"))
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateOutboundPublicIPPrefix(t *testing.T) {
+	g := NewWithT(t)
+
+	nodeOutboundLB := &LoadBalancerSpec{
+		FrontendIPs: []FrontendIP{
+			{Name: "frontend-1", PublicIP: &PublicIPSpec{Name: "pip-1"}},
+			{Name: "frontend-2", PublicIP: &PublicIPSpec{Name: "pip-2"}},
+		},
+	}
+	natGatewaySubnets := Subnets{
+		{SubnetClassSpec: SubnetClassSpec{Role: SubnetNode, Name: "node-1"}, NatGateway: NatGateway{NatGatewayIP: PublicIPSpec{Name: "pip-natgw"}, NatGatewayClassSpec: NatGatewayClassSpec{Name: "natgw"}}},
+		{SubnetClassSpec: SubnetClassSpec{Role: SubnetNode, Name: "node-2"}, NatGateway: NatGateway{NatGatewayIP: PublicIPSpec{Name: "pip-natgw"}, NatGatewayClassSpec: NatGatewayClassSpec{Name: "natgw"}}},
+		{SubnetClassSpec: SubnetClassSpec{Role: SubnetNode, Name: "node-3"}, NatGateway: NatGateway{NatGatewayIP: PublicIPSpec{Name: "pip-natgw-2"}, NatGatewayClassSpec: NatGatewayClassSpec{Name: "natgw-2"}}},
+	}
+
+	tests := []struct {
+		name        string
+		networkSpec NetworkSpec
+		wantErr     bool
+		expectedErr field.Error
+	}{
+		{
+			name:        "public IP prefix not set",
+			networkSpec: NetworkSpec{NodeOutboundLB: nodeOutboundLB},
+			wantErr:     false,
+		},
+		{
+			name: "public IP prefix large enough for the outbound public IPs",
+			networkSpec: NetworkSpec{
+				NodeOutboundLB:         nodeOutboundLB,
+				Subnets:                natGatewaySubnets,
+				OutboundPublicIPPrefix: &PublicIPPrefixSpec{Name: "my-prefix", PrefixLength: 30},
+			},
+			wantErr: false,
+		},
+		{
+			name: "public IP prefix too small for the outbound public IPs",
+			networkSpec: NetworkSpec{
+				NodeOutboundLB:         nodeOutboundLB,
+				Subnets:                natGatewaySubnets,
+				OutboundPublicIPPrefix: &PublicIPPrefixSpec{Name: "my-prefix", PrefixLength: 31},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "outboundPublicIPPrefix.prefixLength",
+				BadValue: int32(31),
+				Detail:   "a /31 prefix contains 2 public IPs, but 4 outbound public IPs are needed",
+			},
+		},
+		{
+			name: "public IP prefix length too short",
+			networkSpec: NetworkSpec{
+				OutboundPublicIPPrefix: &PublicIPPrefixSpec{Name: "my-prefix", PrefixLength: 27},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "outboundPublicIPPrefix.prefixLength",
+				BadValue: int32(27),
+				Detail:   "prefix length must be between 28 and 31",
+			},
+		},
+		{
+			name: "public IP prefix length too long",
+			networkSpec: NetworkSpec{
+				OutboundPublicIPPrefix: &PublicIPPrefixSpec{Name: "my-prefix", PrefixLength: 32},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "outboundPublicIPPrefix.prefixLength",
+				BadValue: int32(32),
+				Detail:   "prefix length must be between 28 and 31",
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateOutboundPublicIPPrefix(testCase.networkSpec, field.NewPath("outboundPublicIPPrefix"))
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateOutboundDeny(t *testing.T) {
+	g := NewWithT(t)
+
+	privateLB := LoadBalancerSpec{LoadBalancerClassSpec: LoadBalancerClassSpec{Type: Internal}}
+
+	tests := []struct {
+		name        string
+		networkSpec NetworkSpec
+		wantErr     bool
+		expectedErr field.Error
+	}{
+		{
+			name:        "outbound deny not set",
+			networkSpec: NetworkSpec{APIServerLB: LoadBalancerSpec{LoadBalancerClassSpec: LoadBalancerClassSpec{Type: Public}}},
+			wantErr:     false,
+		},
+		{
+			name: "outbound deny with valid allowed destinations",
+			networkSpec: NetworkSpec{
+				APIServerLB:  privateLB,
+				OutboundDeny: &OutboundDenySpec{AllowedDestinations: []string{"10.1.0.0/16", "20.30.40.50", "AzureCloud", "Storage.WestUS"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "outbound deny on a public cluster",
+			networkSpec: NetworkSpec{
+				APIServerLB:  LoadBalancerSpec{LoadBalancerClassSpec: LoadBalancerClassSpec{Type: Public}},
+				OutboundDeny: &OutboundDenySpec{},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueForbidden",
+				Field:  "networkSpec.outboundDeny",
+				Detail: "outbound deny can only be configured for private clusters",
+			},
+		},
+		{
+			name: "allowed destination allowing all outbound traffic",
+			networkSpec: NetworkSpec{
+				APIServerLB:  privateLB,
+				OutboundDeny: &OutboundDenySpec{AllowedDestinations: []string{"AzureCloud", "0.0.0.0/0"}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.outboundDeny.allowedDestinations[1]",
+				BadValue: "0.0.0.0/0",
+				Detail:   "destination would allow all outbound traffic",
+			},
+		},
+		{
+			name: "allowed destination set to the Internet service tag",
+			networkSpec: NetworkSpec{
+				APIServerLB:  privateLB,
+				OutboundDeny: &OutboundDenySpec{AllowedDestinations: []string{"Internet"}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.outboundDeny.allowedDestinations[0]",
+				BadValue: "Internet",
+				Detail:   "destination would allow all outbound traffic",
+			},
+		},
+		{
+			name: "invalid allowed destination",
+			networkSpec: NetworkSpec{
+				APIServerLB:  privateLB,
+				OutboundDeny: &OutboundDenySpec{AllowedDestinations: []string{"10.1.0.0/33"}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.outboundDeny.allowedDestinations[0]",
+				BadValue: "10.1.0.0/33",
+				Detail:   "destination must be a CIDR, an IP address or a service tag",
+			},
+		},
+		{
+			name: "duplicate allowed destinations",
+			networkSpec: NetworkSpec{
+				APIServerLB:  privateLB,
+				OutboundDeny: &OutboundDenySpec{AllowedDestinations: []string{"AzureCloud", "azurecloud"}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueDuplicate",
+				Field:    "networkSpec.outboundDeny.allowedDestinations[1]",
+				BadValue: "azurecloud",
+			},
+		},
+		{
+			name: "too many allowed destinations",
+			networkSpec: NetworkSpec{
+				APIServerLB:  privateLB,
+				OutboundDeny: &OutboundDenySpec{AllowedDestinations: make([]string, MaxOutboundAllowedDestinations+1)},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueTooMany",
+				Field:    "networkSpec.outboundDeny.allowedDestinations",
+				BadValue: MaxOutboundAllowedDestinations + 1,
+				Detail:   fmt.Sprintf("must have at most %d items", MaxOutboundAllowedDestinations),
+			},
+		},
+		{
+			name: "node subnet outbound rule with a reserved priority",
+			networkSpec: NetworkSpec{
+				APIServerLB:  privateLB,
+				OutboundDeny: &OutboundDenySpec{},
+				Subnets: Subnets{
+					{
+						SubnetClassSpec: SubnetClassSpec{Role: SubnetNode, Name: "node"},
+						SecurityGroup: SecurityGroup{
+							SecurityGroupClass: SecurityGroupClass{
+								SecurityRules: SecurityRules{
+									{Name: "allow_inbound", Direction: SecurityRuleDirectionInbound, Priority: 4050},
+									{Name: "allow_outbound", Direction: SecurityRuleDirectionOutbound, Priority: 4050},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.subnets[0].securityGroup.securityRules[1].priority",
+				BadValue: int32(4050),
+				Detail:   "outbound security rule priorities from 4000 are reserved when outbound deny is configured",
+			},
+		},
+		{
+			name: "node subnet rule priority band overlapping the reserved priorities",
+			networkSpec: NetworkSpec{
+				APIServerLB:  privateLB,
+				OutboundDeny: &OutboundDenySpec{},
+				Subnets: Subnets{
+					{
+						SubnetClassSpec: SubnetClassSpec{Role: SubnetNode, Name: "node"},
+						SecurityGroup: SecurityGroup{
+							SecurityGroupClass: SecurityGroupClass{
+								RulePriorityBand: &SecurityRulePriorityBand{Min: 3900, Max: 4000},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.subnets[0].securityGroup.rulePriorityBand",
+				BadValue: SecurityRulePriorityBand{Min: 3900, Max: 4000},
+				Detail:   "security rule priorities from 4000 are reserved when outbound deny is configured",
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateOutboundDeny(testCase.networkSpec, field.NewPath("networkSpec"))
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateEgressValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	privateLB := LoadBalancerSpec{LoadBalancerClassSpec: LoadBalancerClassSpec{Type: Internal}}
+
+	tests := []struct {
+		name        string
+		networkSpec NetworkSpec
+		wantErr     bool
+		expectedErr field.Error
+	}{
+		{
+			name:        "egress validation not set",
+			networkSpec: NetworkSpec{APIServerLB: LoadBalancerSpec{LoadBalancerClassSpec: LoadBalancerClassSpec{Type: Public}}},
+			wantErr:     false,
+		},
+		{
+			name: "egress validation with valid required destinations",
+			networkSpec: NetworkSpec{
+				APIServerLB:      privateLB,
+				EgressValidation: &EgressValidationSpec{RequiredDestinations: []string{"MicrosoftContainerRegistry", "AzureActiveDirectory", "10.1.0.0/16"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "egress validation on a public cluster",
+			networkSpec: NetworkSpec{
+				APIServerLB:      LoadBalancerSpec{LoadBalancerClassSpec: LoadBalancerClassSpec{Type: Public}},
+				EgressValidation: &EgressValidationSpec{},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueForbidden",
+				Field:  "networkSpec.egressValidation",
+				Detail: "egress validation can only be configured for private clusters",
+			},
+		},
+		{
+			name: "invalid required destination",
+			networkSpec: NetworkSpec{
+				APIServerLB:      privateLB,
+				EgressValidation: &EgressValidationSpec{RequiredDestinations: []string{"10.1.0.0/33"}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.egressValidation.requiredDestinations[0]",
+				BadValue: "10.1.0.0/33",
+				Detail:   "destination must be a CIDR, an IP address or a service tag",
+			},
+		},
+		{
+			name: "duplicate required destinations",
+			networkSpec: NetworkSpec{
+				APIServerLB:      privateLB,
+				EgressValidation: &EgressValidationSpec{RequiredDestinations: []string{"AzureActiveDirectory", "azureactivedirectory"}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueDuplicate",
+				Field:    "networkSpec.egressValidation.requiredDestinations[1]",
+				BadValue: "azureactivedirectory",
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateEgressValidation(testCase.networkSpec, field.NewPath("networkSpec"))
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateTrafficManager(t *testing.T) {
+	g := NewWithT(t)
+
+	publicLB := LoadBalancerSpec{LoadBalancerClassSpec: LoadBalancerClassSpec{Type: Public}}
+	trafficManager := func(mutate func(*TrafficManagerSpec)) *TrafficManagerSpec {
+		tm := &TrafficManagerSpec{
+			Name:            "traf-my-cluster-apiserver",
+			RelativeDNSName: "my-cluster",
+			RoutingMethod:   TrafficManagerRoutingMethodPriority,
+			TTL:             30,
+			MonitorPath:     "/readyz",
+			Endpoints: []TrafficManagerEndpoint{
+				{Name: "westeurope", Target: "my-cluster-westeurope.westeurope.cloudapp.azure.com", Location: "westeurope"},
+				{Name: "northeurope", Target: "20.30.40.50", Location: "northeurope"},
+			},
+		}
+		if mutate != nil {
+			mutate(tm)
+		}
+		return tm
+	}
+
+	tests := []struct {
+		name        string
+		networkSpec NetworkSpec
+		wantErr     bool
+		expectedErr field.Error
+	}{
+		{
+			name:        "traffic manager not set",
+			networkSpec: NetworkSpec{APIServerLB: publicLB},
+			wantErr:     false,
+		},
+		{
+			name:        "valid traffic manager",
+			networkSpec: NetworkSpec{APIServerLB: publicLB, TrafficManager: trafficManager(nil)},
+			wantErr:     false,
+		},
+		{
+			name: "traffic manager on a private cluster",
+			networkSpec: NetworkSpec{
+				APIServerLB:    LoadBalancerSpec{LoadBalancerClassSpec: LoadBalancerClassSpec{Type: Internal}},
+				TrafficManager: trafficManager(nil),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueForbidden",
+				Field:  "networkSpec.trafficManager",
+				Detail: "a Traffic Manager profile can only front the API server of public clusters",
+			},
+		},
+		{
+			name: "invalid relative DNS name",
+			networkSpec: NetworkSpec{
+				APIServerLB:    publicLB,
+				TrafficManager: trafficManager(func(tm *TrafficManagerSpec) { tm.RelativeDNSName = "my.cluster" }),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.trafficManager.relativeDNSName",
+				BadValue: "my.cluster",
+				Detail:   "relative DNS name must match the regular expression ^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$",
+			},
+		},
+		{
+			name: "monitor path without a leading slash",
+			networkSpec: NetworkSpec{
+				APIServerLB:    publicLB,
+				TrafficManager: trafficManager(func(tm *TrafficManagerSpec) { tm.MonitorPath = "readyz" }),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.trafficManager.monitorPath",
+				BadValue: "readyz",
+				Detail:   "monitor path must start with /",
+			},
+		},
+		{
+			name: "no endpoints",
+			networkSpec: NetworkSpec{
+				APIServerLB:    publicLB,
+				TrafficManager: trafficManager(func(tm *TrafficManagerSpec) { tm.Endpoints = nil }),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueRequired",
+				Field:  "networkSpec.trafficManager.endpoints",
+				Detail: "at least one endpoint is required",
+			},
+		},
+		{
+			name: "duplicate endpoint names",
+			networkSpec: NetworkSpec{
+				APIServerLB:    publicLB,
+				TrafficManager: trafficManager(func(tm *TrafficManagerSpec) { tm.Endpoints[1].Name = "WestEurope" }),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueDuplicate",
+				Field:    "networkSpec.trafficManager.endpoints[1].name",
+				BadValue: "WestEurope",
+			},
+		},
+		{
+			name: "invalid endpoint target",
+			networkSpec: NetworkSpec{
+				APIServerLB:    publicLB,
+				TrafficManager: trafficManager(func(tm *TrafficManagerSpec) { tm.Endpoints[0].Target = "https://my-cluster:6443" }),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.trafficManager.endpoints[0].target",
+				BadValue: "https://my-cluster:6443",
+				Detail:   "target must be an FQDN or an IP address",
+			},
+		},
+		{
+			name: "endpoint without location with the Performance routing method",
+			networkSpec: NetworkSpec{
+				APIServerLB: publicLB,
+				TrafficManager: trafficManager(func(tm *TrafficManagerSpec) {
+					tm.RoutingMethod = TrafficManagerRoutingMethodPerformance
+					tm.Endpoints[1].Location = ""
+				}),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueRequired",
+				Field:  "networkSpec.trafficManager.endpoints[1].location",
+				Detail: "endpoint location is required with the Performance routing method",
+			},
+		},
+		{
+			name: "endpoint priority out of range",
+			networkSpec: NetworkSpec{
+				APIServerLB:    publicLB,
+				TrafficManager: trafficManager(func(tm *TrafficManagerSpec) { tm.Endpoints[0].Priority = ptr.To[int64](1001) }),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.trafficManager.endpoints[0].priority",
+				BadValue: int64(1001),
+				Detail:   "priority must be between 1 and 1000",
+			},
+		},
+		{
+			name: "endpoint priority colliding with the default priority of another endpoint",
+			networkSpec: NetworkSpec{
+				APIServerLB:    publicLB,
+				TrafficManager: trafficManager(func(tm *TrafficManagerSpec) { tm.Endpoints[1].Priority = ptr.To[int64](1) }),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueDuplicate",
+				Field:    "networkSpec.trafficManager.endpoints[1].priority",
+				BadValue: int64(1),
+			},
+		},
+		{
+			name: "endpoint weight out of range",
+			networkSpec: NetworkSpec{
+				APIServerLB: publicLB,
+				TrafficManager: trafficManager(func(tm *TrafficManagerSpec) {
+					tm.RoutingMethod = TrafficManagerRoutingMethodWeighted
+					tm.Endpoints[0].Weight = ptr.To[int64](0)
+				}),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.trafficManager.endpoints[0].weight",
+				BadValue: int64(0),
+				Detail:   "weight must be between 1 and 1000",
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateTrafficManager(testCase.networkSpec, field.NewPath("networkSpec"))
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidatePublicIPZones(t *testing.T) {
+	g := NewWithT(t)
+
+	apiServerLBWithZones := func(zones ...string) LoadBalancerSpec {
+		lb := createValidAPIServerLB()
+		lb.FrontendIPs[0].PublicIP.Zones = zones
+		return lb
+	}
+
+	tests := []struct {
+		name        string
+		networkSpec NetworkSpec
+		wantErr     bool
+		expectedErr field.Error
+	}{
+		{
+			name:        "zone-redundant public IP",
+			networkSpec: NetworkSpec{APIServerLB: apiServerLBWithZones()},
+			wantErr:     false,
+		},
+		{
+			name:        "zonal public IP",
+			networkSpec: NetworkSpec{APIServerLB: apiServerLBWithZones("1")},
+			wantErr:     false,
+		},
+		{
+			name:        "duplicate zone",
+			networkSpec: NetworkSpec{APIServerLB: apiServerLBWithZones("1", "2", "1")},
+			wantErr:     true,
+			expectedErr: field.Error{
+				Type:     "FieldValueDuplicate",
+				Field:    "networkSpec.apiServerLB.frontendIPs[0].publicIP.zones[2]",
+				BadValue: "1",
+			},
+		},
+		{
+			name:        "empty zone",
+			networkSpec: NetworkSpec{APIServerLB: apiServerLBWithZones("")},
+			wantErr:     true,
+			expectedErr: field.Error{
+				Type:   "FieldValueRequired",
+				Field:  "networkSpec.apiServerLB.frontendIPs[0].publicIP.zones[0]",
+				Detail: "zone must not be empty",
+			},
+		},
+		{
+			name: "zones on a NAT gateway public IP",
+			networkSpec: NetworkSpec{
+				APIServerLB: apiServerLBWithZones(),
+				Subnets: Subnets{
+					{
+						SubnetClassSpec: SubnetClassSpec{Role: SubnetNode, Name: "node"},
+						NatGateway: NatGateway{
+							NatGatewayIP: PublicIPSpec{Name: "nat-ip", Zones: []string{"1"}},
+						},
+					},
+				},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueForbidden",
+				Field:  "networkSpec.subnets[0].natGateway.ip.zones",
+				Detail: "zones are not supported on NAT gateway public IPs",
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validatePublicIPZones(testCase.networkSpec, field.NewPath("networkSpec"))
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidatePublicIPDNSNames(t *testing.T) {
+	g := NewWithT(t)
+
+	apiServerLBWithDNSName := func(dnsName string) LoadBalancerSpec {
+		lb := createValidAPIServerLB()
+		lb.FrontendIPs[0].PublicIP.DNSName = dnsName
+		return lb
+	}
+
+	tests := []struct {
+		name        string
+		networkSpec NetworkSpec
+		wantErr     bool
+		expectedErr field.Error
+	}{
+		{
+			name:        "no DNS name",
+			networkSpec: NetworkSpec{APIServerLB: apiServerLBWithDNSName("")},
+			wantErr:     false,
+		},
+		{
+			name:        "valid DNS label",
+			networkSpec: NetworkSpec{APIServerLB: apiServerLBWithDNSName("my-cluster-986b4408.eastus.cloudapp.azure.com")},
+			wantErr:     false,
+		},
+		{
+			name:        "DNS label starting with a number",
+			networkSpec: NetworkSpec{APIServerLB: apiServerLBWithDNSName("1-cluster.eastus.cloudapp.azure.com")},
+			wantErr:     true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.apiServerLB.frontendIPs[0].publicIP.dnsName",
+				BadValue: "1-cluster.eastus.cloudapp.azure.com",
+				Detail:   "DNS label 1-cluster must match the regular expression " + publicIPDNSLabelRegexPattern,
+			},
+		},
+		{
+			name:        "DNS label with upper case letters",
+			networkSpec: NetworkSpec{APIServerLB: apiServerLBWithDNSName("My-Cluster.eastus.cloudapp.azure.com")},
+			wantErr:     true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.apiServerLB.frontendIPs[0].publicIP.dnsName",
+				BadValue: "My-Cluster.eastus.cloudapp.azure.com",
+				Detail:   "DNS label My-Cluster must match the regular expression " + publicIPDNSLabelRegexPattern,
+			},
+		},
+		{
+			name:        "DNS label ending with a hyphen",
+			networkSpec: NetworkSpec{APIServerLB: apiServerLBWithDNSName("my-cluster-")},
+			wantErr:     true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.apiServerLB.frontendIPs[0].publicIP.dnsName",
+				BadValue: "my-cluster-",
+				Detail:   "DNS label my-cluster- must match the regular expression " + publicIPDNSLabelRegexPattern,
+			},
+		},
+		{
+			name:        "DNS label too short",
+			networkSpec: NetworkSpec{APIServerLB: apiServerLBWithDNSName("ab.eastus.cloudapp.azure.com")},
+			wantErr:     true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.apiServerLB.frontendIPs[0].publicIP.dnsName",
+				BadValue: "ab.eastus.cloudapp.azure.com",
+				Detail:   "DNS label ab must match the regular expression " + publicIPDNSLabelRegexPattern,
+			},
+		},
+		{
+			name: "invalid DNS label of a NAT gateway public IP",
+			networkSpec: NetworkSpec{
+				APIServerLB: apiServerLBWithDNSName(""),
+				Subnets: Subnets{
+					{
+						SubnetClassSpec: SubnetClassSpec{Role: SubnetNode, Name: "node"},
+						NatGateway: NatGateway{
+							NatGatewayIP: PublicIPSpec{Name: "nat-ip", DNSName: "nat_ip.eastus.cloudapp.azure.com"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "networkSpec.subnets[0].natGateway.ip.dnsName",
+				BadValue: "nat_ip.eastus.cloudapp.azure.com",
+				Detail:   "DNS label nat_ip must match the regular expression " + publicIPDNSLabelRegexPattern,
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validatePublicIPDNSNames(testCase.networkSpec, field.NewPath("networkSpec"))
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidatePublicIPZonesAvailability(t *testing.T) {
+	g := NewWithT(t)
+
+	networkSpecWithZones := func(zones ...string) NetworkSpec {
+		networkSpec := createValidNetworkSpec()
+		networkSpec.APIServerLB.FrontendIPs[0].PublicIP.Zones = zones
+		return networkSpec
+	}
+
+	tests := []struct {
+		name           string
+		networkSpec    NetworkSpec
+		availableZones []string
+		wantErr        bool
+		expectedErr    field.Error
+	}{
+		{
+			name:           "zonal public IP in an available zone",
+			networkSpec:    networkSpecWithZones("2"),
+			availableZones: []string{"1", "2", "3"},
+			wantErr:        false,
+		},
+		{
+			name:           "zone-redundant public IP",
+			networkSpec:    networkSpecWithZones(),
+			availableZones: []string{"1", "2", "3"},
+			wantErr:        false,
+		},
+		{
+			name:        "zone-redundant public IP in a location without availability zones",
+			networkSpec: networkSpecWithZones(),
+			wantErr:     false,
+		},
+		{
+			name:           "zonal public IP in an unavailable zone",
+			networkSpec:    networkSpecWithZones("1", "4"),
+			availableZones: []string{"1", "2", "3"},
+			wantErr:        true,
+			expectedErr:    *field.NotSupported(field.NewPath("spec", "networkSpec", "apiServerLB", "frontendIPs").Index(0).Child("publicIP", "zones").Index(1), "4", []string{"1", "2", "3"}),
+		},
+		{
+			name:        "zonal public IP in a location without availability zones",
+			networkSpec: networkSpecWithZones("1"),
+			wantErr:     true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "spec.networkSpec.apiServerLB.frontendIPs[0].publicIP.zones",
+				BadValue: []string{"1"},
+				Detail:   "location westcentralus does not support availability zones",
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := ValidatePublicIPZonesAvailability(testCase.networkSpec, "westcentralus", testCase.availableZones)
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
 func TestSubnetsValid(t *testing.T) {
 	g := NewWithT(t)
 
@@ -461,189 +1412,1086 @@ func TestSubnetsValid(t *testing.T) {
 		name    string
 		subnets Subnets
 	}
-
-	testCase := test{
-		name:    "subnets - valid",
-		subnets: createValidSubnets(),
+
+	testCase := test{
+		name:    "subnets - valid",
+		subnets: createValidSubnets(),
+	}
+
+	t.Run(testCase.name, func(t *testing.T) {
+		errs := validateSubnets(testCase.subnets, createValidVnet(),
+			field.NewPath("spec").Child("networkSpec").Child("subnets"))
+		g.Expect(errs).To(BeNil())
+	})
+}
+
+func TestSubnetsInvalidSubnetName(t *testing.T) {
+	g := NewWithT(t)
+
+	type test struct {
+		name    string
+		subnets Subnets
+	}
+
+	testCase := test{
+		name:    "subnets - invalid subnet name",
+		subnets: createValidSubnets(),
+	}
+
+	testCase.subnets[0].Name = "invalid-subnet-name-due-to-bracket)"
+
+	t.Run(testCase.name, func(t *testing.T) {
+		errs := validateSubnets(testCase.subnets, createValidVnet(),
+			field.NewPath("spec").Child("networkSpec").Child("subnets"))
+		g.Expect(errs).To(HaveLen(1))
+		g.Expect(errs[0].Type).To(Equal(field.ErrorTypeInvalid))
+		g.Expect(errs[0].Field).To(Equal("spec.networkSpec.subnets[0].name"))
+		g.Expect(errs[0].BadValue).To(BeEquivalentTo("invalid-subnet-name-due-to-bracket)"))
+	})
+}
+
+func TestSubnetsInvalidLackRequiredSubnet(t *testing.T) {
+	g := NewWithT(t)
+
+	type test struct {
+		name    string
+		subnets Subnets
+	}
+
+	testCase := test{
+		name:    "subnets - lack required subnet",
+		subnets: createValidSubnets(),
+	}
+
+	testCase.subnets[0].Role = "random-role"
+
+	t.Run(testCase.name, func(t *testing.T) {
+		errs := validateSubnets(testCase.subnets, createValidVnet(),
+			field.NewPath("spec").Child("networkSpec").Child("subnets"))
+		g.Expect(errs).To(HaveLen(1))
+		g.Expect(errs[0].Type).To(Equal(field.ErrorTypeRequired))
+		g.Expect(errs[0].Field).To(Equal("spec.networkSpec.subnets"))
+		g.Expect(errs[0].Detail).To(ContainSubstring("required role control-plane not included"))
+	})
+}
+
+func TestSubnetsNodeSubnetIPFamilies(t *testing.T) {
+	g := NewWithT(t)
+
+	vnet := createValidVnet()
+	vnet.CIDRBlocks = []string{DefaultVnetCIDR, "2001:1234:5678:9a00::/56"}
+
+	tests := []struct {
+		name       string
+		cidrBlocks []string
+		wantErr    bool
+	}{
+		{
+			name:       "dual-stack node subnet",
+			cidrBlocks: []string{"10.1.0.0/16", "2001:1234:5678:9abc::/64"},
+			wantErr:    false,
+		},
+		{
+			name:       "IPv4 only node subnet",
+			cidrBlocks: []string{"10.1.0.0/16"},
+			wantErr:    false,
+		},
+		{
+			name:       "IPv6 only node subnet",
+			cidrBlocks: []string{"2001:1234:5678:9abc::/64"},
+			wantErr:    true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			subnets := createValidSubnets()
+			subnets[1].CIDRBlocks = tc.cidrBlocks
+			errs := validateSubnets(subnets, vnet, field.NewPath("spec").Child("networkSpec").Child("subnets"))
+			if tc.wantErr {
+				g.Expect(errs).To(HaveLen(1))
+				g.Expect(errs[0].Type).To(Equal(field.ErrorTypeInvalid))
+				g.Expect(errs[0].Field).To(Equal("spec.networkSpec.subnets[1].cidrBlocks"))
+				g.Expect(errs[0].Detail).To(ContainSubstring("must also have an IPv4 CIDR block"))
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestSubnetNamesNotUnique(t *testing.T) {
+	g := NewWithT(t)
+
+	type test struct {
+		name    string
+		subnets Subnets
+	}
+
+	testCase := test{
+		name:    "subnets - names not unique",
+		subnets: createValidSubnets(),
+	}
+
+	testCase.subnets[0].Name = "subnet-name"
+	testCase.subnets[1].Name = "subnet-name"
+
+	t.Run(testCase.name, func(t *testing.T) {
+		errs := validateSubnets(testCase.subnets, createValidVnet(),
+			field.NewPath("spec").Child("networkSpec").Child("subnets"))
+		g.Expect(errs).To(HaveLen(1))
+		g.Expect(errs[0].Type).To(Equal(field.ErrorTypeDuplicate))
+		g.Expect(errs[0].Field).To(Equal("spec.networkSpec.subnets"))
+	})
+}
+
+func TestSubnetNameValid(t *testing.T) {
+	g := NewWithT(t)
+
+	type test struct {
+		name       string
+		subnetName string
+	}
+
+	testCase := test{
+		name:       "subnet name - valid",
+		subnetName: "control-plane-subnet",
+	}
+
+	t.Run(testCase.name, func(t *testing.T) {
+		err := validateSubnetName(testCase.subnetName,
+			field.NewPath("spec").Child("networkSpec").Child("subnets").Index(0).Child("name"))
+		g.Expect(err).To(BeNil())
+	})
+}
+
+func TestSubnetNameInvalid(t *testing.T) {
+	g := NewWithT(t)
+
+	type test struct {
+		name       string
+		subnetName string
+	}
+
+	testCase := test{
+		name:       "subnet name - invalid",
+		subnetName: "inv@lid-subnet-name",
+	}
+
+	t.Run(testCase.name, func(t *testing.T) {
+		err := validateSubnetName(testCase.subnetName,
+			field.NewPath("spec").Child("networkSpec").Child("subnets").Index(0).Child("name"))
+		g.Expect(err).NotTo(BeNil())
+		g.Expect(err.Type).To(Equal(field.ErrorTypeInvalid))
+		g.Expect(err.Field).To(Equal("spec.networkSpec.subnets[0].name"))
+		g.Expect(err.BadValue).To(BeEquivalentTo(testCase.subnetName))
+	})
+}
+
+func TestValidateSubnetCIDR(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name             string
+		vnetCidrBlocks   []string
+		subnetCidrBlocks []string
+		wantErr          bool
+		expectedErr      field.Error
+	}{
+		{
+			name:             "valid subnet cidr",
+			vnetCidrBlocks:   []string{"10.0.0.0/8"},
+			subnetCidrBlocks: []string{"10.1.0.0/16", "10.0.0.0/16"},
+			wantErr:          false,
+		},
+		{
+			name:             "invalid subnet cidr not in the right format",
+			vnetCidrBlocks:   []string{"10.0.0.0/8"},
+			subnetCidrBlocks: []string{"10.1.0.0/16", "10.0.0.0/16", "foo/bar"},
+			wantErr:          true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "subnets.cidrBlocks",
+				BadValue: "foo/bar",
+				Detail:   "invalid CIDR format",
+			},
+		},
+		{
+			name:             "subnet cidr not in vnet range",
+			vnetCidrBlocks:   []string{"10.0.0.0/8"},
+			subnetCidrBlocks: []string{"10.1.0.0/16", "10.0.0.0/16", "11.1.0.0/16"},
+			wantErr:          true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "subnets.cidrBlocks",
+				BadValue: "11.1.0.0/16",
+				Detail:   "subnet CIDR not in vnet address space: [10.0.0.0/8]",
+			},
+		},
+		{
+			name:             "subnet cidr in at least one vnet's range in case of multiple vnet cidr blocks",
+			vnetCidrBlocks:   []string{"10.0.0.0/8", "11.0.0.0/8"},
+			subnetCidrBlocks: []string{"10.1.0.0/16", "10.0.0.0/16", "11.1.0.0/16"},
+			wantErr:          false,
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateSubnetCIDR(testCase.subnetCidrBlocks, testCase.vnetCidrBlocks, field.NewPath("subnets.cidrBlocks"))
+			if testCase.wantErr {
+				// Searches for expected error in list of thrown errors
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateRouteTable(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name        string
+		routeTable  RouteTable
+		wantErr     bool
+		expectedErr field.Error
+	}{
+		{
+			name: "valid routes",
+			routeTable: RouteTable{
+				Name: "my-route-table",
+				Routes: []Route{
+					{
+						Name:             "to-firewall",
+						AddressPrefix:    "0.0.0.0/0",
+						NextHopType:      RouteNextHopTypeVirtualAppliance,
+						NextHopIPAddress: "10.0.100.4",
+					},
+					{
+						Name:          "to-storage",
+						AddressPrefix: "Storage",
+						NextHopType:   RouteNextHopTypeInternet,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "routes without a route table name",
+			routeTable: RouteTable{
+				Routes: []Route{
+					{
+						Name:          "to-on-prem",
+						AddressPrefix: "192.168.0.0/16",
+						NextHopType:   RouteNextHopTypeVirtualNetworkGateway,
+					},
+				},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueRequired",
+				Field:  "routeTable.name",
+				Detail: "name is required when routes are specified",
+			},
+		},
+		{
+			name: "unsupported next hop type",
+			routeTable: RouteTable{
+				Name: "my-route-table",
+				Routes: []Route{
+					{
+						Name:          "to-on-prem",
+						AddressPrefix: "192.168.0.0/16",
+						NextHopType:   "Firewall",
+					},
+				},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueNotSupported",
+				Field:    "routeTable.routes[0].nextHopType",
+				BadValue: RouteNextHopType("Firewall"),
+				Detail:   `supported values: "VirtualNetworkGateway", "VnetLocal", "Internet", "VirtualAppliance", "None"`,
+			},
+		},
+		{
+			name: "virtual appliance without a next hop IP address",
+			routeTable: RouteTable{
+				Name: "my-route-table",
+				Routes: []Route{
+					{
+						Name:          "to-firewall",
+						AddressPrefix: "0.0.0.0/0",
+						NextHopType:   RouteNextHopTypeVirtualAppliance,
+					},
+				},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueRequired",
+				Field:  "routeTable.routes[0].nextHopIPAddress",
+				Detail: "next hop IP address is required when next hop type is VirtualAppliance",
+			},
+		},
+		{
+			name: "next hop IP address with a next hop type other than virtual appliance",
+			routeTable: RouteTable{
+				Name: "my-route-table",
+				Routes: []Route{
+					{
+						Name:             "to-internet",
+						AddressPrefix:    "0.0.0.0/0",
+						NextHopType:      RouteNextHopTypeInternet,
+						NextHopIPAddress: "10.0.100.4",
+					},
+				},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueForbidden",
+				Field:  "routeTable.routes[0].nextHopIPAddress",
+				Detail: "next hop IP address is only allowed when next hop type is VirtualAppliance",
+			},
+		},
+		{
+			name: "invalid address prefix",
+			routeTable: RouteTable{
+				Name: "my-route-table",
+				Routes: []Route{
+					{
+						Name:          "to-on-prem",
+						AddressPrefix: "192.168.0.0/99",
+						NextHopType:   RouteNextHopTypeVirtualNetworkGateway,
+					},
+				},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "routeTable.routes[0].addressPrefix",
+				BadValue: "192.168.0.0/99",
+				Detail:   "invalid CIDR format",
+			},
+		},
+		{
+			name: "duplicate route names",
+			routeTable: RouteTable{
+				Name: "my-route-table",
+				Routes: []Route{
+					{
+						Name:          "to-on-prem",
+						AddressPrefix: "192.168.0.0/16",
+						NextHopType:   RouteNextHopTypeVirtualNetworkGateway,
+					},
+					{
+						Name:          "to-on-prem",
+						AddressPrefix: "172.16.0.0/12",
+						NextHopType:   RouteNextHopTypeVirtualNetworkGateway,
+					},
+				},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueDuplicate",
+				Field:    "routeTable.routes[1].name",
+				BadValue: "to-on-prem",
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateRouteTable(testCase.routeTable, field.NewPath("routeTable"))
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
 	}
-
-	t.Run(testCase.name, func(t *testing.T) {
-		errs := validateSubnets(testCase.subnets, createValidVnet(),
-			field.NewPath("spec").Child("networkSpec").Child("subnets"))
-		g.Expect(errs).To(BeNil())
-	})
 }
 
-func TestSubnetsInvalidSubnetName(t *testing.T) {
+func TestSubnetsSharingRouteTableWithDifferentRoutes(t *testing.T) {
 	g := NewWithT(t)
 
-	type test struct {
-		name    string
-		subnets Subnets
-	}
-
-	testCase := test{
-		name:    "subnets - invalid subnet name",
-		subnets: createValidSubnets(),
+	subnets := createValidSubnets()
+	subnets[0].RouteTable = RouteTable{
+		Name: "shared-route-table",
+		Routes: []Route{
+			{
+				Name:          "to-on-prem",
+				AddressPrefix: "192.168.0.0/16",
+				NextHopType:   RouteNextHopTypeVirtualNetworkGateway,
+			},
+		},
 	}
+	subnets[1].RouteTable = RouteTable{Name: "shared-route-table"}
 
-	testCase.subnets[0].Name = "invalid-subnet-name-due-to-bracket)"
-
-	t.Run(testCase.name, func(t *testing.T) {
-		errs := validateSubnets(testCase.subnets, createValidVnet(),
-			field.NewPath("spec").Child("networkSpec").Child("subnets"))
-		g.Expect(errs).To(HaveLen(1))
-		g.Expect(errs[0].Type).To(Equal(field.ErrorTypeInvalid))
-		g.Expect(errs[0].Field).To(Equal("spec.networkSpec.subnets[0].name"))
-		g.Expect(errs[0].BadValue).To(BeEquivalentTo("invalid-subnet-name-due-to-bracket)"))
-	})
+	errs := validateSubnets(subnets, createValidVnet(), field.NewPath("subnets"))
+	g.Expect(errs).To(HaveLen(1))
+	g.Expect(errs[0].Type).To(Equal(field.ErrorTypeInvalid))
+	g.Expect(errs[0].Field).To(Equal("subnets[1].routeTable.routes"))
 }
 
-func TestSubnetsInvalidLackRequiredSubnet(t *testing.T) {
+func TestSubnetsSharingNatGateway(t *testing.T) {
 	g := NewWithT(t)
 
-	type test struct {
-		name    string
-		subnets Subnets
+	natGateway := func(name, ipName string) NatGateway {
+		return NatGateway{
+			NatGatewayIP:        PublicIPSpec{Name: ipName},
+			NatGatewayClassSpec: NatGatewayClassSpec{Name: name},
+		}
 	}
-
-	testCase := test{
-		name:    "subnets - lack required subnet",
-		subnets: createValidSubnets(),
+	nodeSubnet := func(name string, natGateway NatGateway) SubnetSpec {
+		return SubnetSpec{
+			SubnetClassSpec: SubnetClassSpec{
+				Role: SubnetNode,
+				Name: name,
+			},
+			NatGateway: natGateway,
+		}
 	}
 
-	testCase.subnets[0].Role = "random-role"
-
-	t.Run(testCase.name, func(t *testing.T) {
-		errs := validateSubnets(testCase.subnets, createValidVnet(),
-			field.NewPath("spec").Child("networkSpec").Child("subnets"))
-		g.Expect(errs).To(HaveLen(1))
-		g.Expect(errs[0].Type).To(Equal(field.ErrorTypeRequired))
-		g.Expect(errs[0].Field).To(Equal("spec.networkSpec.subnets"))
-		g.Expect(errs[0].Detail).To(ContainSubstring("required role control-plane not included"))
-	})
+	tests := []struct {
+		name          string
+		subnets       Subnets
+		expectedField string
+		expectedType  field.ErrorType
+	}{
+		{
+			name: "node subnets sharing a NAT gateway",
+			subnets: append(createValidSubnets(),
+				nodeSubnet("node-subnet-1", natGateway("shared-natgw", "pip-shared-natgw")),
+				nodeSubnet("node-subnet-2", natGateway("shared-natgw", "pip-shared-natgw")),
+			),
+		},
+		{
+			name: "node subnets sharing a NAT gateway with different public IPs",
+			subnets: append(createValidSubnets(),
+				nodeSubnet("node-subnet-1", natGateway("shared-natgw", "pip-shared-natgw")),
+				nodeSubnet("node-subnet-2", natGateway("shared-natgw", "pip-other")),
+			),
+			expectedField: "subnets[3].natGateway.ip.name",
+			expectedType:  field.ErrorTypeInvalid,
+		},
+		{
+			name: "control plane subnet sharing a NAT gateway with a node subnet",
+			subnets: Subnets{
+				{
+					SubnetClassSpec: SubnetClassSpec{
+						Role: SubnetControlPlane,
+						Name: "control-plane-subnet",
+					},
+					NatGateway: natGateway("shared-natgw", "pip-shared-natgw"),
+				},
+				nodeSubnet("node-subnet", natGateway("shared-natgw", "pip-shared-natgw")),
+			},
+			expectedField: "subnets[1].natGateway.name",
+			expectedType:  field.ErrorTypeForbidden,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateSubnets(tc.subnets, createValidVnet(), field.NewPath("subnets"))
+			if tc.expectedField == "" {
+				g.Expect(errs).To(BeEmpty())
+				return
+			}
+			g.Expect(errs).To(HaveLen(1))
+			g.Expect(errs[0].Type).To(Equal(tc.expectedType))
+			g.Expect(errs[0].Field).To(Equal(tc.expectedField))
+		})
+	}
 }
 
-func TestSubnetNamesNotUnique(t *testing.T) {
-	g := NewWithT(t)
-
-	type test struct {
-		name    string
-		subnets Subnets
+func TestValidateGatewaySubnet(t *testing.T) {
+	gatewaySubnet := func(name string, cidr string) SubnetSpec {
+		return SubnetSpec{
+			SubnetClassSpec: SubnetClassSpec{
+				Role:       SubnetGateway,
+				Name:       name,
+				CIDRBlocks: []string{cidr},
+			},
+		}
+	}
+	nodeSubnet := func(cidr string) SubnetSpec {
+		return SubnetSpec{
+			SubnetClassSpec: SubnetClassSpec{
+				Role:       SubnetNode,
+				Name:       "node-subnet",
+				CIDRBlocks: []string{cidr},
+			},
+			SecurityGroup: SecurityGroup{Name: "node-nsg"},
+		}
 	}
 
-	testCase := test{
-		name:    "subnets - names not unique",
-		subnets: createValidSubnets(),
+	tests := []struct {
+		name        string
+		subnets     Subnets
+		wantErr     bool
+		expectedErr field.Error
+	}{
+		{
+			name:    "no gateway subnet",
+			subnets: Subnets{nodeSubnet("10.1.0.0/16")},
+			wantErr: false,
+		},
+		{
+			name: "node subnet adjacent to the gateway subnet",
+			subnets: Subnets{
+				gatewaySubnet(GatewaySubnetName, "10.0.255.224/27"),
+				nodeSubnet("10.1.0.0/16"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "gateway subnet with the wrong name",
+			subnets: Subnets{
+				gatewaySubnet("my-gateway", "10.0.255.224/27"),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "subnets[0].name",
+				BadValue: "my-gateway",
+				Detail:   "gateway subnet must be named GatewaySubnet",
+			},
+		},
+		{
+			name: "gateway subnet too small",
+			subnets: Subnets{
+				gatewaySubnet(GatewaySubnetName, "10.0.255.240/28"),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "subnets[0].cidrBlocks",
+				BadValue: "10.0.255.240/28",
+				Detail:   "gateway subnet prefix length must be /27 or shorter",
+			},
+		},
+		{
+			name: "node subnet overlaps the gateway subnet",
+			subnets: Subnets{
+				gatewaySubnet(GatewaySubnetName, "10.0.255.224/27"),
+				nodeSubnet("10.0.0.0/16"),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "subnets[1].cidrBlocks",
+				BadValue: "10.0.0.0/16",
+				Detail:   "subnet CIDR overlaps with the gateway subnet CIDR 10.0.255.224/27",
+			},
+		},
+		{
+			name: "gateway subnet with a network security group",
+			subnets: Subnets{
+				{
+					SubnetClassSpec: SubnetClassSpec{
+						Role:       SubnetGateway,
+						Name:       GatewaySubnetName,
+						CIDRBlocks: []string{"10.0.255.224/27"},
+					},
+					SecurityGroup: SecurityGroup{Name: "gateway-nsg"},
+				},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueForbidden",
+				Field:  "subnets[0].securityGroup",
+				Detail: "network security groups are not supported on the gateway subnet",
+			},
+		},
+		{
+			name: "multiple gateway subnets",
+			subnets: Subnets{
+				gatewaySubnet(GatewaySubnetName, "10.0.255.224/27"),
+				gatewaySubnet(GatewaySubnetName, "10.0.254.224/27"),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueForbidden",
+				Field:  "subnets[1].role",
+				Detail: "only one subnet can have the gateway role",
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := validateGatewaySubnet(testCase.subnets, field.NewPath("subnets"))
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
 	}
+}
 
-	testCase.subnets[0].Name = "subnet-name"
-	testCase.subnets[1].Name = "subnet-name"
+func TestValidateRouteServerSubnet(t *testing.T) {
+	routeServerSubnet := func(name string, cidr string) SubnetSpec {
+		return SubnetSpec{
+			SubnetClassSpec: SubnetClassSpec{
+				Role:       SubnetRouteServer,
+				Name:       name,
+				CIDRBlocks: []string{cidr},
+			},
+		}
+	}
+	nodeSubnet := func(cidr string) SubnetSpec {
+		return SubnetSpec{
+			SubnetClassSpec: SubnetClassSpec{
+				Role:       SubnetNode,
+				Name:       "node-subnet",
+				CIDRBlocks: []string{cidr},
+			},
+			SecurityGroup: SecurityGroup{Name: "node-nsg"},
+		}
+	}
 
-	t.Run(testCase.name, func(t *testing.T) {
-		errs := validateSubnets(testCase.subnets, createValidVnet(),
-			field.NewPath("spec").Child("networkSpec").Child("subnets"))
-		g.Expect(errs).To(HaveLen(1))
-		g.Expect(errs[0].Type).To(Equal(field.ErrorTypeDuplicate))
-		g.Expect(errs[0].Field).To(Equal("spec.networkSpec.subnets"))
-	})
+	tests := []struct {
+		name        string
+		subnets     Subnets
+		wantErr     bool
+		expectedErr field.Error
+	}{
+		{
+			name:    "no route server subnet",
+			subnets: Subnets{nodeSubnet("10.1.0.0/16")},
+			wantErr: false,
+		},
+		{
+			name: "valid route server subnet",
+			subnets: Subnets{
+				routeServerSubnet(RouteServerSubnetName, "10.0.255.224/27"),
+				nodeSubnet("10.1.0.0/16"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "route server subnet with the wrong name",
+			subnets: Subnets{
+				routeServerSubnet("my-route-server", "10.0.255.224/27"),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "subnets[0].name",
+				BadValue: "my-route-server",
+				Detail:   "route server subnet must be named RouteServerSubnet",
+			},
+		},
+		{
+			name: "route server subnet too small",
+			subnets: Subnets{
+				routeServerSubnet(RouteServerSubnetName, "10.0.255.240/28"),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "subnets[0].cidrBlocks",
+				BadValue: "10.0.255.240/28",
+				Detail:   "route server subnet prefix length must be /27 or shorter",
+			},
+		},
+		{
+			name: "node subnet overlaps the route server subnet",
+			subnets: Subnets{
+				routeServerSubnet(RouteServerSubnetName, "10.0.255.224/27"),
+				nodeSubnet("10.0.0.0/16"),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "subnets[1].cidrBlocks",
+				BadValue: "10.0.0.0/16",
+				Detail:   "subnet CIDR overlaps with the route server subnet CIDR 10.0.255.224/27",
+			},
+		},
+		{
+			name: "route server subnet with a network security group",
+			subnets: Subnets{
+				{
+					SubnetClassSpec: SubnetClassSpec{
+						Role:       SubnetRouteServer,
+						Name:       RouteServerSubnetName,
+						CIDRBlocks: []string{"10.0.255.224/27"},
+					},
+					SecurityGroup: SecurityGroup{Name: "route-server-nsg"},
+				},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueForbidden",
+				Field:  "subnets[0].securityGroup",
+				Detail: "network security groups are not supported on the route server subnet",
+			},
+		},
+		{
+			name: "multiple route server subnets",
+			subnets: Subnets{
+				routeServerSubnet(RouteServerSubnetName, "10.0.255.224/27"),
+				routeServerSubnet(RouteServerSubnetName, "10.0.254.224/27"),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueForbidden",
+				Field:  "subnets[1].role",
+				Detail: "only one subnet can have the route-server role",
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := validateRouteServerSubnet(testCase.subnets, field.NewPath("subnets"))
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
 }
 
-func TestSubnetNameValid(t *testing.T) {
+func TestValidatePodSubnet(t *testing.T) {
 	g := NewWithT(t)
 
-	type test struct {
-		name       string
-		subnetName string
+	podSubnet := func(cidr string) SubnetSpec {
+		return SubnetSpec{
+			SubnetClassSpec: SubnetClassSpec{
+				Role:       SubnetPod,
+				Name:       "pod-subnet",
+				CIDRBlocks: []string{cidr},
+			},
+		}
+	}
+	nodeSubnet := func(cidr string) SubnetSpec {
+		return SubnetSpec{
+			SubnetClassSpec: SubnetClassSpec{
+				Role:       SubnetNode,
+				Name:       "node-subnet",
+				CIDRBlocks: []string{cidr},
+			},
+			SecurityGroup: SecurityGroup{Name: "node-nsg"},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		subnets     Subnets
+		wantErr     bool
+		expectedErr field.Error
+	}{
+		{
+			name:    "no pod subnet",
+			subnets: Subnets{nodeSubnet("10.1.0.0/16")},
+			wantErr: false,
+		},
+		{
+			name: "pod subnet next to the node subnet",
+			subnets: Subnets{
+				nodeSubnet("10.1.0.0/24"),
+				podSubnet("10.2.0.0/16"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "pod subnet too small",
+			subnets: Subnets{
+				podSubnet("10.2.0.0/25"),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "subnets[0].cidrBlocks",
+				BadValue: "10.2.0.0/25",
+				Detail:   "pod subnet prefix length must be /24 or shorter",
+			},
+		},
+		{
+			name: "node subnet overlaps the pod subnet",
+			subnets: Subnets{
+				podSubnet("10.2.0.0/16"),
+				nodeSubnet("10.2.1.0/24"),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "subnets[1].cidrBlocks",
+				BadValue: "10.2.1.0/24",
+				Detail:   "subnet CIDR overlaps with the pod subnet CIDR 10.2.0.0/16",
+			},
+		},
+		{
+			name: "multiple pod subnets",
+			subnets: Subnets{
+				podSubnet("10.2.0.0/16"),
+				podSubnet("10.3.0.0/16"),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueForbidden",
+				Field:  "subnets[1].role",
+				Detail: "only one subnet can have the pod role",
+			},
+		},
 	}
-
-	testCase := test{
-		name:       "subnet name - valid",
-		subnetName: "control-plane-subnet",
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validatePodSubnet(testCase.subnets, field.NewPath("subnets"))
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
 	}
-
-	t.Run(testCase.name, func(t *testing.T) {
-		err := validateSubnetName(testCase.subnetName,
-			field.NewPath("spec").Child("networkSpec").Child("subnets").Index(0).Child("name"))
-		g.Expect(err).To(BeNil())
-	})
 }
 
-func TestSubnetNameInvalid(t *testing.T) {
+func TestValidateSecurityRule(t *testing.T) {
 	g := NewWithT(t)
 
-	type test struct {
-		name       string
-		subnetName string
+	tests := []struct {
+		name      string
+		validRule SecurityRule
+		wantErr   bool
+	}{
+		{
+			name: "security rule - valid priority",
+			validRule: SecurityRule{
+				Name:        "allow_apiserver",
+				Description: "Allow K8s API Server",
+				Priority:    101,
+			},
+			wantErr: false,
+		},
+		{
+			name: "security rule - no priority",
+			validRule: SecurityRule{
+				Name:        "allow_apiserver",
+				Description: "Allow K8s API Server",
+			},
+			wantErr: false,
+		},
+		{
+			name: "security rule - invalid low priority",
+			validRule: SecurityRule{
+				Name:        "allow_apiserver",
+				Description: "Allow K8s API Server",
+				Priority:    99,
+			},
+			wantErr: true,
+		},
+		{
+			name: "security rule - invalid high priority",
+			validRule: SecurityRule{
+				Name:        "allow_apiserver",
+				Description: "Allow K8s API Server",
+				Priority:    5000,
+			},
+			wantErr: true,
+		},
+		{
+			name: "security rule - valid sources and destinations",
+			validRule: SecurityRule{
+				Name:         "allow_apiserver",
+				Description:  "Allow K8s API Server",
+				Priority:     101,
+				Sources:      []string{"10.0.0.0/16", "10.1.0.0/16"},
+				Destinations: []string{"10.2.0.0/16", "10.3.0.0/16"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "security rule - both source and sources",
+			validRule: SecurityRule{
+				Name:        "allow_apiserver",
+				Description: "Allow K8s API Server",
+				Priority:    101,
+				Source:      ptr.To("*"),
+				Sources:     []string{"10.0.0.0/16"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "security rule - both destination and destinations",
+			validRule: SecurityRule{
+				Name:         "allow_apiserver",
+				Description:  "Allow K8s API Server",
+				Priority:     101,
+				Destination:  ptr.To("*"),
+				Destinations: []string{"10.0.0.0/16"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "security rule - valid application security groups",
+			validRule: SecurityRule{
+				Name:                                 "allow_apiserver",
+				Description:                          "Allow K8s API Server",
+				Priority:                             101,
+				SourceApplicationSecurityGroups:      []string{"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/applicationSecurityGroups/web"},
+				DestinationApplicationSecurityGroups: []string{"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/applicationSecurityGroups/api"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "security rule - source application security groups with source",
+			validRule: SecurityRule{
+				Name:                            "allow_apiserver",
+				Description:                     "Allow K8s API Server",
+				Priority:                        101,
+				Source:                          ptr.To("*"),
+				SourceApplicationSecurityGroups: []string{"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/applicationSecurityGroups/web"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "security rule - destination application security groups with destinations",
+			validRule: SecurityRule{
+				Name:                                 "allow_apiserver",
+				Description:                          "Allow K8s API Server",
+				Priority:                             101,
+				Destinations:                         []string{"10.0.0.0/16"},
+				DestinationApplicationSecurityGroups: []string{"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/applicationSecurityGroups/api"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "security rule - invalid application security group ID",
+			validRule: SecurityRule{
+				Name:                            "allow_apiserver",
+				Description:                     "Allow K8s API Server",
+				Priority:                        101,
+				SourceApplicationSecurityGroups: []string{"not-an-id"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "security rule - application security group ID of the wrong resource type",
+			validRule: SecurityRule{
+				Name:                                 "allow_apiserver",
+				Description:                          "Allow K8s API Server",
+				Priority:                             101,
+				DestinationApplicationSecurityGroups: []string{"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkSecurityGroups/nsg"},
+			},
+			wantErr: true,
+		},
 	}
-
-	testCase := test{
-		name:       "subnet name - invalid",
-		subnetName: "inv@lid-subnet-name",
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateSecurityRule(
+				testCase.validRule,
+				field.NewPath("spec").Child("networkSpec").Child("subnets").Index(0).Child("securityGroup").Child("securityRules").Index(0),
+			)
+			if testCase.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
 	}
-
-	t.Run(testCase.name, func(t *testing.T) {
-		err := validateSubnetName(testCase.subnetName,
-			field.NewPath("spec").Child("networkSpec").Child("subnets").Index(0).Child("name"))
-		g.Expect(err).NotTo(BeNil())
-		g.Expect(err.Type).To(Equal(field.ErrorTypeInvalid))
-		g.Expect(err.Field).To(Equal("spec.networkSpec.subnets[0].name"))
-		g.Expect(err.BadValue).To(BeEquivalentTo(testCase.subnetName))
-	})
 }
 
-func TestValidateSubnetCIDR(t *testing.T) {
+func TestValidateSecurityRulePriorities(t *testing.T) {
 	g := NewWithT(t)
 
 	tests := []struct {
-		name             string
-		vnetCidrBlocks   []string
-		subnetCidrBlocks []string
-		wantErr          bool
-		expectedErr      field.Error
+		name          string
+		securityGroup SecurityGroupClass
+		wantErr       bool
+		expectedErr   field.Error
 	}{
 		{
-			name:             "valid subnet cidr",
-			vnetCidrBlocks:   []string{"10.0.0.0/8"},
-			subnetCidrBlocks: []string{"10.1.0.0/16", "10.0.0.0/16"},
-			wantErr:          false,
+			name: "rules with a priority and no band",
+			securityGroup: SecurityGroupClass{
+				SecurityRules: SecurityRules{{Name: "allow_ssh", Priority: 100}},
+			},
+			wantErr: false,
 		},
 		{
-			name:             "invalid subnet cidr not in the right format",
-			vnetCidrBlocks:   []string{"10.0.0.0/8"},
-			subnetCidrBlocks: []string{"10.1.0.0/16", "10.0.0.0/16", "foo/bar"},
-			wantErr:          true,
+			name: "rule without a priority and no band",
+			securityGroup: SecurityGroupClass{
+				SecurityRules: SecurityRules{{Name: "allow_ssh"}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueRequired",
+				Field:  "securityGroup.securityRules[0].priority",
+				Detail: "a priority is required unless the security group has a rulePriorityBand",
+			},
+		},
+		{
+			name: "rules without a priority and a band",
+			securityGroup: SecurityGroupClass{
+				RulePriorityBand: &SecurityRulePriorityBand{Min: 2000, Max: 2001},
+				SecurityRules:    SecurityRules{{Name: "allow_ssh"}, {Name: "allow_http"}, {Name: "allow_https", Priority: 100}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "band with min greater than max",
+			securityGroup: SecurityGroupClass{
+				RulePriorityBand: &SecurityRulePriorityBand{Min: 2000, Max: 1000},
+			},
+			wantErr: true,
 			expectedErr: field.Error{
 				Type:     "FieldValueInvalid",
-				Field:    "subnets.cidrBlocks",
-				BadValue: "foo/bar",
-				Detail:   "invalid CIDR format",
+				Field:    "securityGroup.rulePriorityBand",
+				BadValue: SecurityRulePriorityBand{Min: 2000, Max: 1000},
+				Detail:   "the rule priority band should be a range of priorities between 100 and 4096",
 			},
 		},
 		{
-			name:             "subnet cidr not in vnet range",
-			vnetCidrBlocks:   []string{"10.0.0.0/8"},
-			subnetCidrBlocks: []string{"10.1.0.0/16", "10.0.0.0/16", "11.1.0.0/16"},
-			wantErr:          true,
+			name: "band out of the priority range",
+			securityGroup: SecurityGroupClass{
+				RulePriorityBand: &SecurityRulePriorityBand{Min: 50, Max: 1000},
+			},
+			wantErr: true,
 			expectedErr: field.Error{
 				Type:     "FieldValueInvalid",
-				Field:    "subnets.cidrBlocks",
-				BadValue: "11.1.0.0/16",
-				Detail:   "subnet CIDR not in vnet address space: [10.0.0.0/8]",
+				Field:    "securityGroup.rulePriorityBand",
+				BadValue: SecurityRulePriorityBand{Min: 50, Max: 1000},
+				Detail:   "the rule priority band should be a range of priorities between 100 and 4096",
 			},
 		},
 		{
-			name:             "subnet cidr in at least one vnet's range in case of multiple vnet cidr blocks",
-			vnetCidrBlocks:   []string{"10.0.0.0/8", "11.0.0.0/8"},
-			subnetCidrBlocks: []string{"10.1.0.0/16", "10.0.0.0/16", "11.1.0.0/16"},
-			wantErr:          false,
+			name: "rule with a priority of the band",
+			securityGroup: SecurityGroupClass{
+				RulePriorityBand: &SecurityRulePriorityBand{Min: 2000, Max: 2099},
+				SecurityRules:    SecurityRules{{Name: "allow_ssh"}, {Name: "allow_http", Priority: 2050}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "securityGroup.securityRules[1].priority",
+				BadValue: int32(2050),
+				Detail:   "priorities from 2000 to 2099 are reserved for the rules without a priority",
+			},
+		},
+		{
+			name: "more rules without a priority than the band has priorities",
+			securityGroup: SecurityGroupClass{
+				RulePriorityBand: &SecurityRulePriorityBand{Min: 2000, Max: 2001},
+				SecurityRules:    SecurityRules{{Name: "allow_ssh"}, {Name: "allow_http"}, {Name: "allow_https"}},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "securityGroup.rulePriorityBand",
+				BadValue: SecurityRulePriorityBand{Min: 2000, Max: 2001},
+				Detail:   "the band has 2 priorities but 3 rules have no priority",
+			},
 		},
 	}
 	for _, testCase := range tests {
 		t.Run(testCase.name, func(t *testing.T) {
-			err := validateSubnetCIDR(testCase.subnetCidrBlocks, testCase.vnetCidrBlocks, field.NewPath("subnets.cidrBlocks"))
+			err := validateSecurityRulePriorities(testCase.securityGroup, field.NewPath("securityGroup"))
 			if testCase.wantErr {
-				// Searches for expected error in list of thrown errors
 				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
 			} else {
 				g.Expect(err).To(BeEmpty())
@@ -652,54 +2500,103 @@ func TestValidateSubnetCIDR(t *testing.T) {
 	}
 }
 
-func TestValidateSecurityRule(t *testing.T) {
-	g := NewWithT(t)
+func TestValidateBastionSpec(t *testing.T) {
+	testcases := []struct {
+		name    string
+		bastion *AzureBastion
+		wantErr bool
+	}{
+		{
+			name:    "no azure bastion",
+			bastion: nil,
+			wantErr: false,
+		},
+		{
+			name:    "basic sku",
+			bastion: &AzureBastion{Sku: BasicBastionHostSku},
+			wantErr: false,
+		},
+		{
+			name:    "basic sku with tunneling",
+			bastion: &AzureBastion{Sku: BasicBastionHostSku, EnableTunneling: true},
+			wantErr: true,
+		},
+		{
+			name:    "basic sku with scale units",
+			bastion: &AzureBastion{Sku: BasicBastionHostSku, ScaleUnits: ptr.To[int32](2)},
+			wantErr: true,
+		},
+		{
+			name:    "standard sku with tunneling and scale units",
+			bastion: &AzureBastion{Sku: StandardBastionHostSku, EnableTunneling: true, ScaleUnits: ptr.To[int32](50)},
+			wantErr: false,
+		},
+		{
+			name:    "standard sku with too few scale units",
+			bastion: &AzureBastion{Sku: StandardBastionHostSku, ScaleUnits: ptr.To[int32](1)},
+			wantErr: true,
+		},
+		{
+			name:    "standard sku with too many scale units",
+			bastion: &AzureBastion{Sku: StandardBastionHostSku, ScaleUnits: ptr.To[int32](51)},
+			wantErr: true,
+		},
+		{
+			name:    "public IP with zones",
+			bastion: &AzureBastion{Sku: BasicBastionHostSku, PublicIP: PublicIPSpec{Name: "bastion-ip", Zones: []string{"1"}}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := validateBastionSpec(BastionSpec{AzureBastion: tc.bastion}, field.NewPath("bastionSpec"))
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
 
-	tests := []struct {
-		name      string
-		validRule SecurityRule
-		wantErr   bool
+func TestValidateDataCollectionRuleID(t *testing.T) {
+	testcases := []struct {
+		name    string
+		id      string
+		wantErr bool
 	}{
 		{
-			name: "security rule - valid priority",
-			validRule: SecurityRule{
-				Name:        "allow_apiserver",
-				Description: "Allow K8s API Server",
-				Priority:    101,
-			},
+			name:    "no data collection rule",
+			id:      "",
 			wantErr: false,
 		},
 		{
-			name: "security rule - invalid low priority",
-			validRule: SecurityRule{
-				Name:        "allow_apiserver",
-				Description: "Allow K8s API Server",
-				Priority:    99,
-			},
+			name:    "valid data collection rule ID",
+			id:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Insights/dataCollectionRules/my-dcr",
+			wantErr: false,
+		},
+		{
+			name:    "invalid resource ID",
+			id:      "my-dcr",
 			wantErr: true,
 		},
 		{
-			name: "security rule - invalid high priority",
-			validRule: SecurityRule{
-				Name:        "allow_apiserver",
-				Description: "Allow K8s API Server",
-				Priority:    5000,
-			},
+			name:    "resource ID of another resource type",
+			id:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Insights/dataCollectionEndpoints/my-dce",
 			wantErr: true,
 		},
 	}
-	for _, testCase := range tests {
-		testCase := testCase
-		t.Run(testCase.name, func(t *testing.T) {
-			t.Parallel()
-			err := validateSecurityRule(
-				testCase.validRule,
-				field.NewPath("spec").Child("networkSpec").Child("subnets").Index(0).Child("securityGroup").Child("securityRules").Index(0),
-			)
-			if testCase.wantErr {
-				g.Expect(err).To(HaveOccurred())
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := validateDataCollectionRuleID(tc.id, field.NewPath("dataCollectionRuleID"))
+			if tc.wantErr {
+				g.Expect(err).NotTo(BeNil())
 			} else {
-				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(err).To(BeNil())
 			}
 		})
 	}
@@ -904,6 +2801,68 @@ func TestValidateAPIServerLB(t *testing.T) {
 			cpCIDRS: []string{"10.0.0.0/24", "10.1.0.0/24"},
 			wantErr: false,
 		},
+		{
+			name: "internal LB without a private IP",
+			lb: LoadBalancerSpec{
+				FrontendIPs: []FrontendIP{
+					{
+						Name: "ip-1",
+					},
+				},
+				LoadBalancerClassSpec: LoadBalancerClassSpec{
+					Type: Internal,
+					SKU:  SKUStandard,
+				},
+				Name: "my-private-lb",
+			},
+			cpCIDRS: []string{"10.0.0.0/24"},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueRequired",
+				Field:    "apiServerLB.frontendIPConfigs[0].privateIP",
+				BadValue: "",
+				Detail:   "Internal Load Balancers need a static private IP in the control plane subnet",
+			},
+		},
+		{
+			name: "inbound NAT rules with a valid idle timeout and TCP reset",
+			lb: func() LoadBalancerSpec {
+				lb := createValidAPIServerLB()
+				lb.InboundNATRules = &InboundNATRulesSpec{IdleTimeoutInMinutes: ptr.To[int32](30), EnableTCPReset: ptr.To(true)}
+				return lb
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "inbound NAT rules with a too short idle timeout",
+			lb: func() LoadBalancerSpec {
+				lb := createValidAPIServerLB()
+				lb.InboundNATRules = &InboundNATRulesSpec{IdleTimeoutInMinutes: ptr.To[int32](3)}
+				return lb
+			}(),
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "apiServerLB.inboundNATRules.idleTimeoutInMinutes",
+				BadValue: 3,
+				Detail:   "inbound NAT rules idle timeout should be between 4 and 30 minutes",
+			},
+		},
+		{
+			name: "inbound NAT rules with a too long idle timeout",
+			lb: func() LoadBalancerSpec {
+				lb := createValidAPIServerLB()
+				lb.InboundNATRules = &InboundNATRulesSpec{IdleTimeoutInMinutes: ptr.To[int32](31)}
+				return lb
+			}(),
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "apiServerLB.inboundNATRules.idleTimeoutInMinutes",
+				BadValue: 31,
+				Detail:   "inbound NAT rules idle timeout should be between 4 and 30 minutes",
+			},
+		},
 	}
 
 	for _, test := range testcases {
@@ -1001,6 +2960,90 @@ func TestPrivateDNSZoneName(t *testing.T) {
 	}
 }
 
+func TestValidateHealthProbe(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name        string
+		probe       *HealthProbeSpec
+		wantErr     bool
+		expectedErr field.Error
+	}{
+		{
+			name:    "no health probe",
+			probe:   nil,
+			wantErr: false,
+		},
+		{
+			name:    "valid TCP probe",
+			probe:   &HealthProbeSpec{Protocol: ProbeProtocolTCP, IntervalInSeconds: ptr.To[int32](5), NumberOfProbes: ptr.To[int32](2)},
+			wantErr: false,
+		},
+		{
+			name:    "valid HTTP probe",
+			probe:   &HealthProbeSpec{Protocol: ProbeProtocolHTTP, RequestPath: "/healthz"},
+			wantErr: false,
+		},
+		{
+			name:    "valid HTTPS probe",
+			probe:   &HealthProbeSpec{Protocol: ProbeProtocolHTTPS, RequestPath: "/readyz"},
+			wantErr: false,
+		},
+		{
+			name:    "TCP probe with a request path",
+			probe:   &HealthProbeSpec{Protocol: ProbeProtocolTCP, RequestPath: "/readyz"},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueForbidden",
+				Field:  "healthProbe.requestPath",
+				Detail: "request path is not supported for the Tcp protocol",
+			},
+		},
+		{
+			name:    "HTTP probe without a request path",
+			probe:   &HealthProbeSpec{Protocol: ProbeProtocolHTTP},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueRequired",
+				Field:  "healthProbe.requestPath",
+				Detail: "request path is required for the Http protocol",
+			},
+		},
+		{
+			name:    "HTTPS probe with a relative request path",
+			probe:   &HealthProbeSpec{Protocol: ProbeProtocolHTTPS, RequestPath: "readyz"},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "healthProbe.requestPath",
+				BadValue: "readyz",
+				Detail:   "request path must start with /",
+			},
+		},
+		{
+			name:    "unsupported protocol",
+			probe:   &HealthProbeSpec{Protocol: "Udp"},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueNotSupported",
+				Field:    "healthProbe.protocol",
+				BadValue: ProbeProtocol("Udp"),
+				Detail:   `supported values: "Tcp", "Http", "Https"`,
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateHealthProbe(testCase.probe, field.NewPath("healthProbe"))
+			if testCase.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(testCase.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
 func TestValidateNodeOutboundLB(t *testing.T) {
 	g := NewWithT(t)
 
@@ -1143,6 +3186,19 @@ func TestValidateNodeOutboundLB(t *testing.T) {
 				Detail:   "Max front end ips allowed is 16",
 			},
 		},
+		{
+			name: "inbound NAT rules on the node outbound lb",
+			lb: &LoadBalancerSpec{
+				FrontendIPsCount: ptr.To[int32](1),
+				InboundNATRules:  &InboundNATRulesSpec{EnableTCPReset: ptr.To(true)},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueForbidden",
+				Field:  "nodeOutboundLB.inboundNATRules",
+				Detail: "inbound NAT rules are only supported for the API server load balancer",
+			},
+		},
 	}
 
 	for _, test := range testcases {
@@ -1224,6 +3280,23 @@ func TestValidateControlPlaneNodeOutboundLB(t *testing.T) {
 				Detail:   "Max front end ips allowed is 16",
 			},
 		},
+		{
+			name: "additional backend pools are forbidden",
+			lb: &LoadBalancerSpec{
+				AdditionalBackendPools: []BackendPool{{Name: "service-pool"}},
+			},
+			apiServerLB: LoadBalancerSpec{
+				LoadBalancerClassSpec: LoadBalancerClassSpec{
+					Type: Internal,
+				},
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:   "FieldValueForbidden",
+				Field:  "controlPlaneOutboundLB.additionalBackendPools",
+				Detail: "additional backend pools are only supported for the node outbound load balancer",
+			},
+		},
 	}
 
 	for _, test := range testcases {
@@ -1240,6 +3313,76 @@ func TestValidateControlPlaneNodeOutboundLB(t *testing.T) {
 	}
 }
 
+func TestValidateAdditionalBackendPools(t *testing.T) {
+	g := NewWithT(t)
+
+	testcases := []struct {
+		name            string
+		pool            BackendPool
+		additionalPools []BackendPool
+		wantErr         bool
+		expectedErr     field.Error
+	}{
+		{
+			name:    "no additional backend pools",
+			pool:    BackendPool{Name: "cluster-outboundBackendPool"},
+			wantErr: false,
+		},
+		{
+			name:            "unique additional backend pools",
+			pool:            BackendPool{Name: "cluster-outboundBackendPool"},
+			additionalPools: []BackendPool{{Name: "service-pool-1"}, {Name: "service-pool-2"}},
+			wantErr:         false,
+		},
+		{
+			name:            "additional backend pool without a name",
+			pool:            BackendPool{Name: "cluster-outboundBackendPool"},
+			additionalPools: []BackendPool{{Name: ""}},
+			wantErr:         true,
+			expectedErr: field.Error{
+				Type:   "FieldValueRequired",
+				Field:  "additionalBackendPools[0].name",
+				Detail: "additional backend pools must have a name",
+			},
+		},
+		{
+			name:            "additional backend pool with the name of the backend pool",
+			pool:            BackendPool{Name: "cluster-outboundBackendPool"},
+			additionalPools: []BackendPool{{Name: "cluster-outboundBackendPool"}},
+			wantErr:         true,
+			expectedErr: field.Error{
+				Type:     "FieldValueDuplicate",
+				Field:    "additionalBackendPools[0].name",
+				BadValue: "cluster-outboundBackendPool",
+			},
+		},
+		{
+			name:            "additional backend pools with the same name",
+			pool:            BackendPool{Name: "cluster-outboundBackendPool"},
+			additionalPools: []BackendPool{{Name: "service-pool"}, {Name: "service-pool"}},
+			wantErr:         true,
+			expectedErr: field.Error{
+				Type:     "FieldValueDuplicate",
+				Field:    "additionalBackendPools[1].name",
+				BadValue: "service-pool",
+			},
+		},
+	}
+
+	for _, test := range testcases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateAdditionalBackendPools(test.pool, test.additionalPools, field.NewPath("additionalBackendPools"))
+			if test.wantErr {
+				g.Expect(err).To(ContainElement(MatchError(test.expectedErr.Error())))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
 func TestValidateCloudProviderConfigOverrides(t *testing.T) {
 	g := NewWithT(t)
 
@@ -1347,6 +3490,19 @@ func createValidCluster() *AzureCluster {
 	}
 }
 
+func createValidClusterWithBastion(sku BastionHostSkuName, enableTunneling bool, scaleUnits *int32) *AzureCluster {
+	cluster := createValidCluster()
+	cluster.Spec.BastionSpec = BastionSpec{
+		AzureBastion: &AzureBastion{
+			Name:            "my-bastion",
+			Sku:             sku,
+			EnableTunneling: enableTunneling,
+			ScaleUnits:      scaleUnits,
+		},
+	}
+	return cluster
+}
+
 func createValidNetworkSpec() NetworkSpec {
 	return NetworkSpec{
 		Vnet: VnetSpec{
@@ -1579,3 +3735,162 @@ func TestClusterWithExtendedLocationInvalid(t *testing.T) {
 		g.Expect(err).NotTo(BeNil())
 	})
 }
+
+func TestValidateTokenAudience(t *testing.T) {
+	tests := []struct {
+		name        string
+		audience    string
+		environment string
+		wantErr     bool
+	}{
+		{
+			name:        "no token audience",
+			environment: "AzurePublicCloud",
+		},
+		{
+			name:        "token audience of a custom cloud",
+			audience:    "https://management.adfs.azurestack.local/4de154de-f8a8-4017-af41-df619da68155",
+			environment: AzureStackCloud,
+		},
+		{
+			name:        "token audience of a public cloud",
+			audience:    "https://management.azure.com/",
+			environment: "AzurePublicCloud",
+			wantErr:     true,
+		},
+		{
+			name:     "token audience of the default cloud",
+			audience: "https://management.azure.com/",
+			wantErr:  true,
+		},
+		{
+			name:        "token audience which is not an HTTPS URL",
+			audience:    "http://management.local.azurestack.external/",
+			environment: AzureStackCloud,
+			wantErr:     true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := validateTokenAudience(tc.audience, tc.environment, field.NewPath("spec", "tokenAudience"))
+			if tc.wantErr {
+				g.Expect(err).NotTo(BeNil())
+			} else {
+				g.Expect(err).To(BeNil())
+			}
+		})
+	}
+}
+
+func TestValidateSecondaryLocation(t *testing.T) {
+	tests := []struct {
+		name              string
+		secondaryLocation string
+		wantErr           bool
+	}{
+		{
+			name: "no secondary location",
+		},
+		{
+			name:              "secondary location different from the location",
+			secondaryLocation: "eastus",
+		},
+		{
+			name:              "secondary location same as the location",
+			secondaryLocation: "westus",
+			wantErr:           true,
+		},
+		{
+			name:              "secondary location same as the location with a different spelling",
+			secondaryLocation: "West US",
+			wantErr:           true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := validateSecondaryLocation(tc.secondaryLocation, "westus", field.NewPath("spec", "secondaryLocation"))
+			if tc.wantErr {
+				g.Expect(err).NotTo(BeNil())
+			} else {
+				g.Expect(err).To(BeNil())
+			}
+		})
+	}
+}
+
+func TestValidateExtendedLocationNetwork(t *testing.T) {
+	extendedLocation := &ExtendedLocationSpec{Name: "microsoftlosangeles1", Type: "EdgeZone"}
+	publicLB := func(zones ...string) LoadBalancerSpec {
+		return LoadBalancerSpec{
+			FrontendIPs: []FrontendIP{
+				{Name: "ip-1", PublicIP: &PublicIPSpec{Name: "pip-1", Zones: zones}},
+			},
+		}
+	}
+	tests := []struct {
+		name             string
+		extendedLocation *ExtendedLocationSpec
+		networkSpec      NetworkSpec
+		bastionSpec      BastionSpec
+		expectedFields   []string
+	}{
+		{
+			name:             "cluster in an extended location",
+			extendedLocation: extendedLocation,
+			networkSpec:      NetworkSpec{APIServerLB: publicLB()},
+		},
+		{
+			name:        "zonal public IPs and Azure Bastion outside of an extended location",
+			networkSpec: NetworkSpec{APIServerLB: publicLB("1")},
+			bastionSpec: BastionSpec{AzureBastion: &AzureBastion{}},
+		},
+		{
+			name:             "Azure Bastion in an extended location",
+			extendedLocation: extendedLocation,
+			bastionSpec:      BastionSpec{AzureBastion: &AzureBastion{}},
+			expectedFields:   []string{"spec.bastionSpec.azureBastion"},
+		},
+		{
+			name:             "zonal public IPs in an extended location",
+			extendedLocation: extendedLocation,
+			networkSpec: NetworkSpec{
+				APIServerLB:            publicLB("1"),
+				NodeOutboundLB:         ptr.To(publicLB()),
+				ControlPlaneOutboundLB: ptr.To(publicLB("1", "2")),
+			},
+			expectedFields: []string{
+				"spec.networkSpec.apiServerLB.frontendIPs[0].publicIP.zones",
+				"spec.networkSpec.controlPlaneOutboundLB.frontendIPs[0].publicIP.zones",
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var fields []string
+			for _, err := range validateExtendedLocationNetwork(tc.extendedLocation, tc.networkSpec, tc.bastionSpec, field.NewPath("spec")) {
+				g.Expect(err.Type).To(Equal(field.ErrorTypeForbidden))
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
+
+func createValidTrafficManager() *TrafficManagerSpec {
+	return &TrafficManagerSpec{
+		Name:            "traf-test-cluster-apiserver",
+		RelativeDNSName: "test-cluster",
+		RoutingMethod:   TrafficManagerRoutingMethodPriority,
+		TTL:             DefaultTrafficManagerTTL,
+		MonitorPath:     DefaultTrafficManagerMonitorPath,
+		Endpoints: []TrafficManagerEndpoint{
+			{Name: "westeurope", Target: "my-cluster-westeurope.westeurope.cloudapp.azure.com"},
+		},
+	}
+}
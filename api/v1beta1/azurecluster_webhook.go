@@ -17,6 +17,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -28,10 +30,23 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
-// SetupWebhookWithManager sets up and registers the webhook with the manager.
-func (c *AzureCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+// AvailabilityZonesGetter gets the availability zones of the location of an AzureCluster.
+// +kubebuilder:object:generate=false
+type AvailabilityZonesGetter interface {
+	// GetAvailabilityZones returns the availability zones of the location of the cluster. An empty list means the
+	// location doesn't support availability zones.
+	GetAvailabilityZones(ctx context.Context, cluster *AzureCluster) ([]string, error)
+}
+
+// SetupAzureClusterWebhookWithManager sets up and registers the webhook with the manager.
+// The availability zones of the public IPs are validated against the availability zones of the location if an
+// AvailabilityZonesGetter is provided.
+func SetupAzureClusterWebhookWithManager(mgr ctrl.Manager, zonesGetter AvailabilityZonesGetter) error {
+	cw := &azureClusterWebhook{zonesGetter: zonesGetter}
 	return ctrl.NewWebhookManagedBy(mgr).
-		For(c).
+		For(&AzureCluster{}).
+		WithDefaulter(cw).
+		WithValidator(cw).
 		Complete()
 }
 
@@ -77,6 +92,13 @@ func (c *AzureCluster) ValidateUpdate(oldRaw runtime.Object) (admission.Warnings
 		allErrs = append(allErrs, err)
 	}
 
+	if err := webhookutils.ValidateImmutable(
+		field.NewPath("Spec", "ExtendedLocation"),
+		old.Spec.ExtendedLocation,
+		c.Spec.ExtendedLocation); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
 	if old.Spec.ControlPlaneEndpoint.Host != "" && c.Spec.ControlPlaneEndpoint.Host != old.Spec.ControlPlaneEndpoint.Host {
 		allErrs = append(allErrs,
 			field.Invalid(field.NewPath("spec", "ControlPlaneEndpoint", "Host"),
@@ -108,6 +130,21 @@ func (c *AzureCluster) ValidateUpdate(oldRaw runtime.Object) (admission.Warnings
 		}
 	}
 
+	if old.Spec.ResourceGroupDeletionProtection && !c.Spec.ResourceGroupDeletionProtection &&
+		c.GetAnnotations()[RemoveResourceGroupLockAnnotation] != "true" {
+		allErrs = append(allErrs,
+			field.Forbidden(field.NewPath("spec", "resourceGroupDeletionProtection"),
+				fmt.Sprintf("can only be disabled when the %s annotation is set to \"true\"", RemoveResourceGroupLockAnnotation)),
+		)
+	}
+
+	if err := webhookutils.ValidateImmutable(
+		field.NewPath("Spec", "TokenAudience"),
+		old.Spec.TokenAudience,
+		c.Spec.TokenAudience); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
 	if err := webhookutils.ValidateImmutable(
 		field.NewPath("Spec", "NetworkSpec", "PrivateDNSZoneName"),
 		old.Spec.NetworkSpec.PrivateDNSZoneName,
@@ -115,13 +152,7 @@ func (c *AzureCluster) ValidateUpdate(oldRaw runtime.Object) (admission.Warnings
 		allErrs = append(allErrs, err)
 	}
 
-	// Allow enabling azure bastion but avoid disabling it.
-	if old.Spec.BastionSpec.AzureBastion != nil && !reflect.DeepEqual(old.Spec.BastionSpec.AzureBastion, c.Spec.BastionSpec.AzureBastion) {
-		allErrs = append(allErrs,
-			field.Invalid(field.NewPath("spec", "BastionSpec", "AzureBastion"),
-				c.Spec.BastionSpec.AzureBastion, "azure bastion cannot be removed from a cluster"),
-		)
-	}
+	allErrs = append(allErrs, validateBastionUpdate(old.Spec.BastionSpec.AzureBastion, c.Spec.BastionSpec.AzureBastion)...)
 
 	if err := webhookutils.ValidateImmutable(
 		field.NewPath("Spec", "NetworkSpec", "ControlPlaneOutboundLB"),
@@ -130,6 +161,15 @@ func (c *AzureCluster) ValidateUpdate(oldRaw runtime.Object) (admission.Warnings
 		allErrs = append(allErrs, err)
 	}
 
+	if err := webhookutils.ValidateImmutable(
+		field.NewPath("Spec", "NetworkSpec", "OutboundPublicIPPrefix"),
+		old.Spec.NetworkSpec.OutboundPublicIPPrefix,
+		c.Spec.NetworkSpec.OutboundPublicIPPrefix); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	allErrs = append(allErrs, validateTrafficManagerUpdate(old.Spec.NetworkSpec.TrafficManager, c.Spec.NetworkSpec.TrafficManager)...)
+
 	allErrs = append(allErrs, c.validateSubnetUpdate(old)...)
 
 	if len(allErrs) == 0 {
@@ -181,13 +221,150 @@ func (c *AzureCluster) validateSubnetUpdate(old *AzureCluster) field.ErrorList {
 						c.Spec.NetworkSpec.Subnets[i].SecurityGroup.Name, "field is immutable"),
 				)
 			}
+			// The priorities assigned to the security rules without a priority are taken from the band, so changing it
+			// would reassign them.
+			if oldSubnet.SecurityGroup.RulePriorityBand != nil && !reflect.DeepEqual(subnet.SecurityGroup.RulePriorityBand, oldSubnet.SecurityGroup.RulePriorityBand) {
+				allErrs = append(allErrs,
+					field.Invalid(field.NewPath("spec", "networkSpec", "subnets").Index(oldSubnetIndex[subnet.Name]).Child("SecurityGroup").Child("RulePriorityBand"),
+						c.Spec.NetworkSpec.Subnets[i].SecurityGroup.RulePriorityBand, "field is immutable"),
+				)
+			}
 		}
 	}
 
 	return allErrs
 }
 
+// validateBastionUpdate allows enabling azure bastion but avoids disabling it. Once enabled, only the SKU,
+// tunneling and scale units can be changed, and the SKU cannot be downgraded from Standard to Basic.
+func validateBastionUpdate(old, bastion *AzureBastion) field.ErrorList {
+	var allErrs field.ErrorList
+	if old == nil {
+		return allErrs
+	}
+	fldPath := field.NewPath("spec", "BastionSpec", "AzureBastion")
+	if bastion == nil {
+		return append(allErrs, field.Invalid(fldPath, bastion, "azure bastion cannot be removed from a cluster"))
+	}
+
+	oldImmutable, newImmutable := *old, *bastion
+	oldImmutable.Sku, newImmutable.Sku = "", ""
+	oldImmutable.EnableTunneling, newImmutable.EnableTunneling = false, false
+	oldImmutable.ScaleUnits, newImmutable.ScaleUnits = nil, nil
+	if !reflect.DeepEqual(oldImmutable, newImmutable) {
+		allErrs = append(allErrs, field.Invalid(fldPath, bastion,
+			"only the sku, enableTunneling and scaleUnits fields of azure bastion can be updated"))
+	}
+
+	if old.Sku == StandardBastionHostSku && bastion.Sku == BasicBastionHostSku {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("Sku"), bastion.Sku,
+			"azure bastion sku cannot be downgraded from Standard to Basic"))
+	}
+	return allErrs
+}
+
+// validateTrafficManagerUpdate validates that the Traffic Manager profile, whose FQDN is the control plane endpoint of
+// the cluster, is neither added nor removed, and that its name and relative DNS name don't change. Its endpoints can
+// change to add or remove regions.
+func validateTrafficManagerUpdate(old, trafficManager *TrafficManagerSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("Spec", "NetworkSpec", "TrafficManager")
+	if old == nil || trafficManager == nil {
+		if old != trafficManager {
+			allErrs = append(allErrs, field.Invalid(fldPath, trafficManager, "a Traffic Manager profile can't be added or removed once the cluster is created"))
+		}
+		return allErrs
+	}
+	if err := webhookutils.ValidateImmutable(fldPath.Child("Name"), old.Name, trafficManager.Name); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := webhookutils.ValidateImmutable(fldPath.Child("RelativeDNSName"), old.RelativeDNSName, trafficManager.RelativeDNSName); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	return allErrs
+}
+
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
 func (c *AzureCluster) ValidateDelete() (admission.Warnings, error) {
 	return nil, nil
 }
+
+// azureClusterWebhook implements a validating and defaulting webhook for AzureClusters, which also validates the
+// cluster against its Azure location.
+type azureClusterWebhook struct {
+	zonesGetter AvailabilityZonesGetter
+}
+
+var _ webhook.CustomDefaulter = &azureClusterWebhook{}
+var _ webhook.CustomValidator = &azureClusterWebhook{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type.
+func (cw *azureClusterWebhook) Default(_ context.Context, obj runtime.Object) error {
+	c, ok := obj.(*AzureCluster)
+	if !ok {
+		return apierrors.NewBadRequest("expected an AzureCluster resource")
+	}
+	c.Default()
+	return nil
+}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (cw *azureClusterWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	c, ok := obj.(*AzureCluster)
+	if !ok {
+		return nil, apierrors.NewBadRequest("expected an AzureCluster resource")
+	}
+	warnings, err := c.ValidateCreate()
+	if err != nil {
+		return warnings, err
+	}
+	return cw.validateLocation(ctx, c, warnings)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (cw *azureClusterWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	c, ok := newObj.(*AzureCluster)
+	if !ok {
+		return nil, apierrors.NewBadRequest("expected an AzureCluster resource")
+	}
+	warnings, err := c.ValidateUpdate(oldObj)
+	if err != nil {
+		return warnings, err
+	}
+	return cw.validateLocation(ctx, c, warnings)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type.
+func (cw *azureClusterWebhook) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	c, ok := obj.(*AzureCluster)
+	if !ok {
+		return nil, apierrors.NewBadRequest("expected an AzureCluster resource")
+	}
+	return c.ValidateDelete()
+}
+
+// validateLocation validates the availability zones of the public IPs against the availability zones of the
+// location of the cluster. The zones are only looked up when some public IP has zones.
+func (cw *azureClusterWebhook) validateLocation(ctx context.Context, c *AzureCluster, warnings admission.Warnings) (admission.Warnings, error) {
+	if cw.zonesGetter == nil || !hasPublicIPZones(c.Spec.NetworkSpec) {
+		return warnings, nil
+	}
+	availableZones, err := cw.zonesGetter.GetAvailabilityZones(ctx, c)
+	if err != nil {
+		return append(warnings, fmt.Sprintf("the public IP zones were not validated against the availability zones of location %s: %v", c.Spec.Location, err)), nil
+	}
+	if allErrs := ValidatePublicIPZonesAvailability(c.Spec.NetworkSpec, c.Spec.Location, availableZones); len(allErrs) > 0 {
+		return warnings, apierrors.NewInvalid(GroupVersion.WithKind("AzureCluster").GroupKind(), c.Name, allErrs)
+	}
+	return warnings, nil
+}
+
+// hasPublicIPZones returns true if some public IP of a load balancer frontend has availability zones.
+func hasPublicIPZones(networkSpec NetworkSpec) bool {
+	for _, lbPublicIP := range loadBalancerPublicIPs(networkSpec, field.NewPath("spec", "networkSpec")) {
+		if len(lbPublicIP.publicIP.Zones) > 0 {
+			return true
+		}
+	}
+	return false
+}
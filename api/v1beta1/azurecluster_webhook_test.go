@@ -17,9 +17,12 @@ limitations under the License.
 package v1beta1
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
@@ -119,6 +122,63 @@ func TestAzureCluster_ValidateCreate(t *testing.T) {
 	}
 }
 
+func TestAzureCluster_ValidateCreateFloatingIP(t *testing.T) {
+	tests := []struct {
+		name         string
+		cluster      *AzureCluster
+		wantErr      bool
+		wantWarnings bool
+	}{
+		{
+			name: "floating IP on the API server load balancer",
+			cluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.NetworkSpec.APIServerLB.EnableFloatingIP = ptr.To(true)
+				return cluster
+			}(),
+			wantErr:      false,
+			wantWarnings: false,
+		},
+		{
+			name: "floating IP on the inbound NAT rules warns about the frontend ports",
+			cluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.NetworkSpec.APIServerLB.InboundNATRules = &InboundNATRulesSpec{EnableFloatingIP: ptr.To(true)}
+				return cluster
+			}(),
+			wantErr:      false,
+			wantWarnings: true,
+		},
+		{
+			name: "floating IP on the node outbound load balancer",
+			cluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.NetworkSpec.NodeOutboundLB = &LoadBalancerSpec{EnableFloatingIP: ptr.To(true)}
+				return cluster
+			}(),
+			wantErr:      true,
+			wantWarnings: false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			warnings, err := tc.cluster.ValidateCreate()
+			if tc.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring("floating IP is only supported for the API server load balancer"))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			if tc.wantWarnings {
+				g.Expect(warnings).To(ConsistOf(ContainSubstring("inboundNATRules.enableFloatingIP")))
+			} else {
+				g.Expect(warnings).To(BeEmpty())
+			}
+		})
+	}
+}
+
 func TestAzureCluster_ValidateUpdate(t *testing.T) {
 	g := NewWithT(t)
 
@@ -272,6 +332,24 @@ func TestAzureCluster_ValidateUpdate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "azurecluster extendedLocation is immutable",
+			oldCluster: &AzureCluster{
+				Spec: AzureClusterSpec{
+					AzureClusterClassSpec: AzureClusterClassSpec{
+						ExtendedLocation: &ExtendedLocationSpec{Name: "microsoftvancouver1", Type: "EdgeZone"},
+					},
+				},
+			},
+			cluster: &AzureCluster{
+				Spec: AzureClusterSpec{
+					AzureClusterClassSpec: AzureClusterClassSpec{
+						ExtendedLocation: &ExtendedLocationSpec{Name: "microsoftlosangeles1", Type: "EdgeZone"},
+					},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "azurecluster azureEnvironment is immutable",
 			oldCluster: &AzureCluster{
@@ -318,6 +396,125 @@ func TestAzureCluster_ValidateUpdate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "outbound public IP prefix is immutable",
+			oldCluster: &AzureCluster{
+				Spec: AzureClusterSpec{
+					NetworkSpec: NetworkSpec{
+						OutboundPublicIPPrefix: &PublicIPPrefixSpec{Name: "my-prefix", PrefixLength: 30},
+					},
+				},
+			},
+			cluster: &AzureCluster{
+				Spec: AzureClusterSpec{
+					NetworkSpec: NetworkSpec{
+						OutboundPublicIPPrefix: &PublicIPPrefixSpec{Name: "my-prefix", PrefixLength: 29},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:       "outbound public IP prefix can't be added to an existing cluster",
+			oldCluster: createValidCluster(),
+			cluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.NetworkSpec.OutboundPublicIPPrefix = &PublicIPPrefixSpec{Name: "my-prefix", PrefixLength: 30}
+				return cluster
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "token audience is immutable",
+			oldCluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.AzureEnvironment = AzureStackCloud
+				cluster.Spec.TokenAudience = "https://management.local.azurestack.external/"
+				return cluster
+			}(),
+			cluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.AzureEnvironment = AzureStackCloud
+				cluster.Spec.TokenAudience = "https://management.adfs.azurestack.local/4de154de-f8a8-4017-af41-df619da68155"
+				return cluster
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "resource group deletion protection can't be disabled without the lock removal annotation",
+			oldCluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.ResourceGroupDeletionProtection = true
+				return cluster
+			}(),
+			cluster: createValidCluster(),
+			wantErr: true,
+		},
+		{
+			name: "resource group deletion protection can be disabled with the lock removal annotation",
+			oldCluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.ResourceGroupDeletionProtection = true
+				return cluster
+			}(),
+			cluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Annotations = map[string]string{RemoveResourceGroupLockAnnotation: "true"}
+				return cluster
+			}(),
+			wantErr: false,
+		},
+		{
+			name:       "resource group deletion protection can be enabled",
+			oldCluster: createValidCluster(),
+			cluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.ResourceGroupDeletionProtection = true
+				return cluster
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "traffic manager relative DNS name is immutable",
+			oldCluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.NetworkSpec.TrafficManager = createValidTrafficManager()
+				return cluster
+			}(),
+			cluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.NetworkSpec.TrafficManager = createValidTrafficManager()
+				cluster.Spec.NetworkSpec.TrafficManager.RelativeDNSName = "my-other-cluster"
+				return cluster
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "traffic manager endpoints are mutable",
+			oldCluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.NetworkSpec.TrafficManager = createValidTrafficManager()
+				return cluster
+			}(),
+			cluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.NetworkSpec.TrafficManager = createValidTrafficManager()
+				cluster.Spec.NetworkSpec.TrafficManager.Endpoints = append(cluster.Spec.NetworkSpec.TrafficManager.Endpoints,
+					TrafficManagerEndpoint{Name: "eastus", Target: "my-cluster-eastus.eastus.cloudapp.azure.com"})
+				return cluster
+			}(),
+			wantErr: false,
+		},
+		{
+			name:       "traffic manager can't be added to an existing cluster",
+			oldCluster: createValidCluster(),
+			cluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.NetworkSpec.TrafficManager = createValidTrafficManager()
+				return cluster
+			}(),
+			wantErr: true,
+		},
 		{
 			name: "natGateway name is immutable",
 			oldCluster: func() *AzureCluster {
@@ -342,6 +539,70 @@ func TestAzureCluster_ValidateUpdate(t *testing.T) {
 			}(),
 			wantErr: false,
 		},
+		{
+			name:       "security rule priority band can be set",
+			oldCluster: createValidCluster(),
+			cluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.NetworkSpec.Subnets[0].SecurityGroup.RulePriorityBand = &SecurityRulePriorityBand{Min: 2000, Max: 2099}
+				return cluster
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "security rule priority band is immutable once set",
+			oldCluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.NetworkSpec.Subnets[0].SecurityGroup.RulePriorityBand = &SecurityRulePriorityBand{Min: 2000, Max: 2099}
+				return cluster
+			}(),
+			cluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.NetworkSpec.Subnets[0].SecurityGroup.RulePriorityBand = &SecurityRulePriorityBand{Min: 2000, Max: 2199}
+				return cluster
+			}(),
+			wantErr: true,
+		},
+		{
+			name:       "azure bastion can be enabled",
+			oldCluster: createValidCluster(),
+			cluster:    createValidClusterWithBastion(StandardBastionHostSku, true, ptr.To[int32](2)),
+			wantErr:    false,
+		},
+		{
+			name:       "azure bastion cannot be removed",
+			oldCluster: createValidClusterWithBastion(BasicBastionHostSku, false, nil),
+			cluster:    createValidCluster(),
+			wantErr:    true,
+		},
+		{
+			name:       "azure bastion can be upgraded to Standard with tunneling and scale units",
+			oldCluster: createValidClusterWithBastion(BasicBastionHostSku, false, nil),
+			cluster:    createValidClusterWithBastion(StandardBastionHostSku, true, ptr.To[int32](10)),
+			wantErr:    false,
+		},
+		{
+			name:       "azure bastion scale units can be changed",
+			oldCluster: createValidClusterWithBastion(StandardBastionHostSku, false, ptr.To[int32](2)),
+			cluster:    createValidClusterWithBastion(StandardBastionHostSku, false, ptr.To[int32](4)),
+			wantErr:    false,
+		},
+		{
+			name:       "azure bastion cannot be downgraded to Basic",
+			oldCluster: createValidClusterWithBastion(StandardBastionHostSku, false, nil),
+			cluster:    createValidClusterWithBastion(BasicBastionHostSku, false, nil),
+			wantErr:    true,
+		},
+		{
+			name:       "azure bastion name is immutable",
+			oldCluster: createValidClusterWithBastion(BasicBastionHostSku, false, nil),
+			cluster: func() *AzureCluster {
+				cluster := createValidClusterWithBastion(BasicBastionHostSku, false, nil)
+				cluster.Spec.BastionSpec.AzureBastion.Name = "another-bastion"
+				return cluster
+			}(),
+			wantErr: true,
+		},
 	}
 	for _, tc := range tests {
 		tc := tc
@@ -356,3 +617,89 @@ func TestAzureCluster_ValidateUpdate(t *testing.T) {
 		})
 	}
 }
+
+type fakeAvailabilityZonesGetter struct {
+	zones []string
+	err   error
+	calls *int
+}
+
+func (f fakeAvailabilityZonesGetter) GetAvailabilityZones(_ context.Context, _ *AzureCluster) ([]string, error) {
+	if f.calls != nil {
+		*f.calls++
+	}
+	return f.zones, f.err
+}
+
+func TestAzureCluster_ValidateCreatePublicIPZones(t *testing.T) {
+	tests := []struct {
+		name             string
+		zones            []string
+		getter           AvailabilityZonesGetter
+		wantErr          string
+		expectedWarnings int
+		expectedCalls    int
+	}{
+		{
+			name:          "zonal public IP in an available zone",
+			zones:         []string{"1"},
+			getter:        fakeAvailabilityZonesGetter{zones: []string{"1", "2", "3"}},
+			expectedCalls: 1,
+		},
+		{
+			name:          "zone-redundant public IP doesn't look up the availability zones",
+			getter:        fakeAvailabilityZonesGetter{},
+			expectedCalls: 0,
+		},
+		{
+			name:          "zonal public IP in an unavailable zone",
+			zones:         []string{"4"},
+			getter:        fakeAvailabilityZonesGetter{zones: []string{"1", "2", "3"}},
+			wantErr:       `Unsupported value: "4"`,
+			expectedCalls: 1,
+		},
+		{
+			name:          "zonal public IP in a location without availability zones",
+			zones:         []string{"1"},
+			getter:        fakeAvailabilityZonesGetter{},
+			wantErr:       "location westcentralus does not support availability zones",
+			expectedCalls: 1,
+		},
+		{
+			name:             "failure to get the availability zones is a warning",
+			zones:            []string{"1"},
+			getter:           fakeAvailabilityZonesGetter{err: errors.New("failed to get the resource SKUs")},
+			expectedWarnings: 1,
+			expectedCalls:    1,
+		},
+		{
+			name:  "no availability zones getter",
+			zones: []string{"4"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			cluster := createValidCluster()
+			cluster.Spec.Location = "westcentralus"
+			cluster.Spec.NetworkSpec.APIServerLB.FrontendIPs[0].PublicIP.Zones = tc.zones
+
+			var calls int
+			if getter, ok := tc.getter.(fakeAvailabilityZonesGetter); ok {
+				getter.calls = &calls
+				tc.getter = getter
+			}
+			cw := &azureClusterWebhook{zonesGetter: tc.getter}
+			warnings, err := cw.ValidateCreate(context.Background(), cluster)
+			if tc.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tc.wantErr))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(warnings).To(HaveLen(tc.expectedWarnings))
+			g.Expect(calls).To(Equal(tc.expectedCalls))
+		})
+	}
+}
@@ -62,6 +62,16 @@ func (c *AzureClusterTemplate) validateClusterTemplateSpec() field.ErrorList {
 
 	allErrs = append(allErrs, c.validatePrivateDNSZoneName()...)
 
+	if err := validateTokenAudience(c.Spec.Template.Spec.TokenAudience, c.Spec.Template.Spec.AzureEnvironment,
+		field.NewPath("spec").Child("template").Child("spec").Child("tokenAudience")); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	if err := validateSecondaryLocation(c.Spec.Template.Spec.SecondaryLocation, c.Spec.Template.Spec.Location,
+		field.NewPath("spec").Child("template").Child("spec").Child("secondaryLocation")); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
 	return allErrs
 }
 
@@ -112,6 +122,7 @@ func validateSubnetTemplates(subnets SubnetTemplatesSpec, vnet VnetTemplateSpec,
 				allErrs = append(allErrs, err)
 			}
 		}
+		allErrs = append(allErrs, validateSecurityRulePriorities(subnet.SecurityGroup, fld.Index(i).Child("securityGroup"))...)
 		allErrs = append(allErrs, validateSubnetCIDR(subnet.CIDRBlocks, vnet.CIDRBlocks, fld.Index(i).Child("cidrBlocks"))...)
 	}
 	for k, v := range requiredSubnetRoles {
@@ -136,6 +136,11 @@ func validateMarketplaceImage(image *Image, fldPath *field.Path) field.ErrorList
 	if image.Marketplace.Version == "" {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("Version"), "", "Version cannot be empty when specifying an AzureMarketplaceImage"))
 	}
+	if plan := image.Marketplace.Plan; plan != nil {
+		if plan.Publisher == "" || plan.Offer == "" || plan.SKU == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("Plan"), plan, "Publisher, Offer and SKU of the Plan cannot be empty when specifying an AzureMarketplaceImage plan"))
+		}
+	}
 	return allErrs
 }
 
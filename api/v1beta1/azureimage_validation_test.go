@@ -61,6 +61,15 @@ func TestImageTooManyDetails(t *testing.T) {
 	g.Expect(ValidateImage(image, field.NewPath("image"))).To(HaveLen(1))
 }
 
+func TestImageCommunityGalleryAndOtherDetails(t *testing.T) {
+	g := NewWithT(t)
+
+	image := createTestComputeImage(nil, nil)
+	image.ID = ptr.To("ID1234")
+
+	g.Expect(ValidateImage(image, field.NewPath("image"))).To(HaveLen(1))
+}
+
 func TestComputeImageGalleryValid(t *testing.T) {
 	g := NewWithT(t)
 
@@ -156,6 +165,14 @@ func TestMarketPlaceImageValid(t *testing.T) {
 			expectedErrors: 1,
 			image:          createTestMarketPlaceImage("PUB1234", "OFFER1234", "SKU1234", ""),
 		},
+		"AzureMarketplaceImage - with plan": {
+			expectedErrors: 0,
+			image:          createTestMarketPlaceImageWithPlan(&ImagePlan{Publisher: "PUB1234", Offer: "PRODUCT1234", SKU: "PLAN1234"}),
+		},
+		"AzureMarketplaceImage - plan missing sku": {
+			expectedErrors: 1,
+			image:          createTestMarketPlaceImageWithPlan(&ImagePlan{Publisher: "PUB1234", Offer: "PRODUCT1234"}),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -222,6 +239,12 @@ func createTestMarketPlaceImage(publisher, offer, sku, version string) *Image {
 	}
 }
 
+func createTestMarketPlaceImageWithPlan(plan *ImagePlan) *Image {
+	image := createTestMarketPlaceImage("PUB1234", "OFFER1234", "SKU1234", "1.0.0")
+	image.Marketplace.Plan = plan
+	return image
+}
+
 func createTestImageByID(imageID string) *Image {
 	return &Image{
 		ID: &imageID,
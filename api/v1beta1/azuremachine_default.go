@@ -35,9 +35,13 @@ import (
 // ContributorRoleID is the ID of the built-in "Contributor" role.
 const ContributorRoleID = "b24988ac-6180-42a0-ab88-20f7382dd24c"
 
+// DefaultLocalNVMeStorageMountPath is the default path the striped local NVMe disks of a VM are mounted at.
+const DefaultLocalNVMeStorageMountPath = "/mnt/nvme"
+
 // SetDefaultSSHPublicKey sets the default SSHPublicKey for an AzureMachine.
+// No key is generated when the key is read from Key Vault.
 func (s *AzureMachineSpec) SetDefaultSSHPublicKey() error {
-	if sshKeyData := s.SSHPublicKey; sshKeyData == "" {
+	if sshKeyData := s.SSHPublicKey; sshKeyData == "" && s.SSHPublicKeySecretRef == nil {
 		_, publicRsaKey, err := utilSSH.GenerateSSHKey()
 		if err != nil {
 			return err
@@ -77,8 +81,8 @@ func (s *AzureMachineSpec) SetDataDisksDefaults() {
 			}
 		}
 		if disk.CachingType == "" {
-			if s.DataDisks[i].ManagedDisk != nil &&
-				s.DataDisks[i].ManagedDisk.StorageAccountType == string(compute.StorageAccountTypesUltraSSDLRS) {
+			if s.DataDisks[i].IsShared() || (s.DataDisks[i].ManagedDisk != nil &&
+				s.DataDisks[i].ManagedDisk.StorageAccountType == string(compute.StorageAccountTypesUltraSSDLRS)) {
 				s.DataDisks[i].CachingType = string(compute.CachingTypesNone)
 			} else {
 				s.DataDisks[i].CachingType = string(compute.CachingTypesReadWrite)
@@ -174,6 +178,26 @@ func (s *AzureMachineSpec) SetNetworkInterfacesDefaults() {
 	}
 }
 
+// SetLocalNVMeStorageDefaults sets the defaults for the striped local NVMe storage.
+func (s *AzureMachineSpec) SetLocalNVMeStorageDefaults() {
+	if s.LocalNVMeStorage != nil && s.LocalNVMeStorage.MountPath == "" {
+		s.LocalNVMeStorage.MountPath = DefaultLocalNVMeStorageMountPath
+	}
+}
+
+// SetDataDiskArrayDefaults sets the defaults for the array of data disks.
+func (s *AzureMachineSpec) SetDataDiskArrayDefaults() {
+	if s.DataDiskArray == nil {
+		return
+	}
+	if s.DataDiskArray.Level == "" {
+		s.DataDiskArray.Level = DataDiskRAID0
+	}
+	if s.DataDiskArray.Filesystem == "" {
+		s.DataDiskArray.Filesystem = DataDiskFilesystemExt4
+	}
+}
+
 // GetOwnerAzureClusterNameAndNamespace returns the owner azure cluster's name and namespace for the given cluster name and namespace.
 func GetOwnerAzureClusterNameAndNamespace(cli client.Client, clusterName string, namespace string, maxAttempts int) (azureClusterName string, azureClusterNamespace string, err error) {
 	ctx := context.Background()
@@ -250,6 +274,8 @@ func (m *AzureMachine) SetDefaults(client client.Client) error {
 	m.Spec.SetSpotEvictionPolicyDefaults()
 	m.Spec.SetDiagnosticsDefaults()
 	m.Spec.SetNetworkInterfacesDefaults()
+	m.Spec.SetLocalNVMeStorageDefaults()
+	m.Spec.SetDataDiskArrayDefaults()
 
 	return kerrors.NewAggregate(errs)
 }
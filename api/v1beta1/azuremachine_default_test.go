@@ -51,6 +51,12 @@ func TestAzureMachineSpec_SetDefaultSSHPublicKey(t *testing.T) {
 	err = publicKeyNotExistTest.machine.Spec.SetDefaultSSHPublicKey()
 	g.Expect(err).To(BeNil())
 	g.Expect(publicKeyNotExistTest.machine.Spec.SSHPublicKey).To(Not(BeEmpty()))
+
+	publicKeyFromKeyVaultTest := test{machine: createMachineWithSSHPublicKey("")}
+	publicKeyFromKeyVaultTest.machine.Spec.SSHPublicKeySecretRef = &KeyVaultSecretReference{VaultName: "my-vault", SecretName: "ssh-key"}
+	err = publicKeyFromKeyVaultTest.machine.Spec.SetDefaultSSHPublicKey()
+	g.Expect(err).To(BeNil())
+	g.Expect(publicKeyFromKeyVaultTest.machine.Spec.SSHPublicKey).To(BeEmpty())
 }
 
 func TestAzureMachineSpec_SetIdentityDefaults(t *testing.T) {
@@ -258,6 +264,15 @@ func TestAzureMachineSpec_SetDataDisksDefaults(t *testing.T) {
 					},
 					Lun: ptr.To[int32](3),
 				},
+				{
+					NameSuffix: "testdisk4",
+					DiskSizeGB: 30,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: "Premium_LRS",
+					},
+					Lun:       ptr.To[int32](4),
+					MaxShares: ptr.To[int32](2),
+				},
 			},
 			output: []DataDisk{
 				{
@@ -281,6 +296,16 @@ func TestAzureMachineSpec_SetDataDisksDefaults(t *testing.T) {
 					},
 					CachingType: "None",
 				},
+				{
+					NameSuffix: "testdisk4",
+					DiskSizeGB: 30,
+					Lun:        ptr.To[int32](4),
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: "Premium_LRS",
+					},
+					CachingType: "None",
+					MaxShares:   ptr.To[int32](2),
+				},
 			},
 		},
 	}
@@ -402,6 +427,72 @@ func TestAzureMachineSpec_SetNetworkInterfacesDefaults(t *testing.T) {
 	}
 }
 
+func TestAzureMachineSpec_SetLocalNVMeStorageDefaults(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name    string
+		machine *AzureMachine
+		want    *AzureMachine
+	}{
+		{
+			name:    "no local NVMe storage",
+			machine: &AzureMachine{Spec: AzureMachineSpec{}},
+			want:    &AzureMachine{Spec: AzureMachineSpec{}},
+		},
+		{
+			name:    "local NVMe storage without a mount path",
+			machine: &AzureMachine{Spec: AzureMachineSpec{LocalNVMeStorage: &LocalNVMeStorage{}}},
+			want:    &AzureMachine{Spec: AzureMachineSpec{LocalNVMeStorage: &LocalNVMeStorage{MountPath: "/mnt/nvme"}}},
+		},
+		{
+			name:    "local NVMe storage with a mount path",
+			machine: &AzureMachine{Spec: AzureMachineSpec{LocalNVMeStorage: &LocalNVMeStorage{MountPath: "/var/lib/scratch"}}},
+			want:    &AzureMachine{Spec: AzureMachineSpec{LocalNVMeStorage: &LocalNVMeStorage{MountPath: "/var/lib/scratch"}}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.machine.Spec.SetLocalNVMeStorageDefaults()
+			g.Expect(tc.machine).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestAzureMachineSpec_SetDataDiskArrayDefaults(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name    string
+		machine *AzureMachine
+		want    *AzureMachine
+	}{
+		{
+			name:    "no data disk array",
+			machine: &AzureMachine{Spec: AzureMachineSpec{}},
+			want:    &AzureMachine{Spec: AzureMachineSpec{}},
+		},
+		{
+			name:    "data disk array without a level and a filesystem",
+			machine: &AzureMachine{Spec: AzureMachineSpec{DataDiskArray: &DataDiskArray{MountPath: "/mnt/data"}}},
+			want:    &AzureMachine{Spec: AzureMachineSpec{DataDiskArray: &DataDiskArray{Level: DataDiskRAID0, Filesystem: DataDiskFilesystemExt4, MountPath: "/mnt/data"}}},
+		},
+		{
+			name:    "data disk array with a level and a filesystem",
+			machine: &AzureMachine{Spec: AzureMachineSpec{DataDiskArray: &DataDiskArray{Level: DataDiskRAID1, Filesystem: DataDiskFilesystemXFS, MountPath: "/mnt/data"}}},
+			want:    &AzureMachine{Spec: AzureMachineSpec{DataDiskArray: &DataDiskArray{Level: DataDiskRAID1, Filesystem: DataDiskFilesystemXFS, MountPath: "/mnt/data"}}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.machine.Spec.SetDataDiskArrayDefaults()
+			g.Expect(tc.machine).To(Equal(tc.want))
+		})
+	}
+}
+
 func TestAzureMachineSpec_GetOwnerCluster(t *testing.T) {
 	tests := []struct {
 		name            string
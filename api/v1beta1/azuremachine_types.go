@@ -28,6 +28,10 @@ const (
 	// MachineFinalizer allows ReconcileAzureMachine to clean up Azure resources associated with AzureMachine before
 	// removing it from the apiserver.
 	MachineFinalizer = "azuremachine.infrastructure.cluster.x-k8s.io"
+
+	// DefaultStartStopScheduleTagName is the default name of the VM tag holding the schedule an Azure Automation runbook
+	// stops and starts the VM on.
+	DefaultStartStopScheduleTagName = "AutoShutdownSchedule"
 )
 
 // AzureMachineSpec defines the desired state of AzureMachine.
@@ -43,6 +47,12 @@ type AzureMachineSpec struct {
 	// +optional
 	FailureDomain *string `json:"failureDomain,omitempty"`
 
+	// ExtendedLocation is the edge zone the VM and its network interfaces and public IP are placed in, overriding the
+	// extended location of the AzureCluster. The VM size must be available in the edge zone. Requires the EdgeZone
+	// feature flag. Immutable.
+	// +optional
+	ExtendedLocation *ExtendedLocationSpec `json:"extendedLocation,omitempty"`
+
 	// Image is used to provide details of an image to use during VM creation.
 	// If image details are omitted the image will default the Azure Marketplace "capi" offer,
 	// which is based on Ubuntu.
@@ -50,6 +60,12 @@ type AzureMachineSpec struct {
 	// +optional
 	Image *Image `json:"image,omitempty"`
 
+	// AcceptImagePlanTerms accepts the marketplace terms of the purchase plan of the image in the subscription before
+	// the VM is created, if they are not accepted yet. Setting it accepts the legal terms of the image publisher on
+	// behalf of the subscription owner. Otherwise the terms must be accepted beforehand, e.g. with the Azure CLI.
+	// +optional
+	AcceptImagePlanTerms bool `json:"acceptImagePlanTerms,omitempty"`
+
 	// Identity is the type of identity used for the virtual machine.
 	// The type 'SystemAssigned' is an implicitly created identity.
 	// The generated identity will be assigned a Subscription contributor role.
@@ -86,6 +102,12 @@ type AzureMachineSpec struct {
 	// +optional
 	SSHPublicKey string `json:"sshPublicKey"`
 
+	// SSHPublicKeySecretRef references a Key Vault secret holding the SSH public key to add to a Virtual Machine,
+	// either in the authorized_keys format or base64-encoded. The secret is read with the cluster identity when the
+	// Virtual Machine is created. Mutually exclusive with SSHPublicKey. Linux only.
+	// +optional
+	SSHPublicKeySecretRef *KeyVaultSecretReference `json:"sshPublicKeySecretRef,omitempty"`
+
 	// AdditionalTags is an optional set of tags to add to an instance, in addition to the ones added by default by the
 	// Azure provider. If both the AzureCluster and the AzureMachine specify the same tag name with different values, the
 	// AzureMachine's value takes precedence.
@@ -142,6 +164,226 @@ type AzureMachineSpec struct {
 	// The primary interface will be the first networkInterface specified (index 0) in the list.
 	// +optional
 	NetworkInterfaces []NetworkInterface `json:"networkInterfaces,omitempty"`
+
+	// LocalNVMeStorage stripes the local NVMe disks of the VM into a RAID 0 array mounted on the VM, with a cloud-init
+	// boothook added to the bootstrap data. The VM size must have local NVMe disks. Linux only.
+	// +optional
+	LocalNVMeStorage *LocalNVMeStorage `json:"localNVMeStorage,omitempty"`
+
+	// DataDiskArray assembles data disks of the VM into a software RAID array formatted and mounted on the VM, with a
+	// cloud-init boothook added to the bootstrap data. It can't be changed once set. Linux only.
+	// +optional
+	DataDiskArray *DataDiskArray `json:"dataDiskArray,omitempty"`
+
+	// BootstrapCompletionCheck configures how the CAPZ bootstrapping VM extension verifies that the bootstrap of the VM
+	// completed, which the BootstrapSucceeded condition reports. It defaults to waiting for the sentinel file written by
+	// the bootstrap provider for up to 300 seconds. The bootstrapping extension is only available in the Azure public
+	// cloud. Linux only.
+	// +optional
+	BootstrapCompletionCheck *BootstrapCompletionCheck `json:"bootstrapCompletionCheck,omitempty"`
+
+	// AvailabilitySet references an existing availability set the VM joins instead of the availability set managed for
+	// its control plane, machine deployment or machine set. The availability set is neither created nor deleted, and it
+	// must be in the resource group and location of the VM. Mutually exclusive with FailureDomain.
+	// +optional
+	AvailabilitySet *AvailabilitySetReference `json:"availabilitySet,omitempty"`
+
+	// PlatformFaultDomainCount is the number of fault domains of the availability set created for the control plane,
+	// machine deployment or machine set of the machine. It defaults to the maximum fault domain count of the region,
+	// which it must not exceed, and only applies when the availability set is created. Mutually exclusive with
+	// AvailabilitySet.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PlatformFaultDomainCount *int32 `json:"platformFaultDomainCount,omitempty"`
+
+	// VirtualMachineScaleSet references an existing virtual machine scale set in Flexible orchestration mode the VM is
+	// placed into, instead of an availability set. The scale set is neither created nor deleted. Mutually exclusive with
+	// AvailabilitySet and PlatformFaultDomainCount.
+	// +optional
+	VirtualMachineScaleSet *VirtualMachineScaleSetReference `json:"virtualMachineScaleSet,omitempty"`
+
+	// RunCommands are scripts run on the VM with the Azure Run Command API once it is provisioned and bootstrapped, in
+	// order, e.g. post-provisioning scripts. Each run command runs once, its output and errors are reported as events
+	// and by a RunCommand/<name>Succeeded condition. A failed run command marks the machine as failed. They can't be
+	// changed once set.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	RunCommands []RunCommand `json:"runCommands,omitempty"`
+
+	// StartStopSchedule registers the VM with an Azure Automation runbook that stops and starts VMs on a schedule, by
+	// tagging the VM with the schedule the runbook reads. The tag is updated in place and removed once unset.
+	// +optional
+	StartStopSchedule *StartStopSchedule `json:"startStopSchedule,omitempty"`
+
+	// NodeLabelTags are the names of the VM tags propagated as labels to the Kubernetes node of the machine, e.g. for
+	// scheduling. Each label has the name and the value of its tag, and the tags with a value that isn't a valid label
+	// value are skipped. The labels aren't removed from the node when the tags are removed from the VM.
+	// +listType=set
+	// +optional
+	NodeLabelTags []string `json:"nodeLabelTags,omitempty"`
+}
+
+// StartStopSchedule defines the schedule an Azure Automation runbook stops and starts a VM on.
+type StartStopSchedule struct {
+	// Schedule is the comma-separated list of periods during which the VM is stopped, each either a time range such as
+	// "10PM -> 6AM", a day of the week such as "Saturday" or a date such as "December 25". The VM is started outside
+	// of these periods, in the time zone of the Automation account.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// TagName is the name of the VM tag the runbook reads the schedule from. Defaults to AutoShutdownSchedule.
+	// +kubebuilder:validation:MaxLength=512
+	// +optional
+	TagName string `json:"tagName,omitempty"`
+}
+
+// RunCommand defines a script run on a VM with the Azure Run Command API.
+type RunCommand struct {
+	// Name is the name of the run command resource of the VM.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=80
+	Name string `json:"name"`
+
+	// Source is the script run by the run command.
+	Source RunCommandSource `json:"source"`
+
+	// Parameters are the parameters passed to the script.
+	// +optional
+	Parameters []RunCommandParameter `json:"parameters,omitempty"`
+
+	// TimeoutInSeconds is the timeout of the execution of the script. It defaults to the timeout of the Run Command API,
+	// 90 minutes.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutInSeconds *int32 `json:"timeoutInSeconds,omitempty"`
+}
+
+// RunCommandSource defines the script of a run command, either inline or downloaded from a URI.
+type RunCommandSource struct {
+	// Script is the content of the script. Mutually exclusive with ScriptURI.
+	// +optional
+	Script string `json:"script,omitempty"`
+
+	// ScriptURI is the HTTPS URI the script is downloaded from, e.g. a storage blob with a SAS token. Mutually exclusive
+	// with Script.
+	// +optional
+	ScriptURI string `json:"scriptURI,omitempty"`
+}
+
+// RunCommandParameter defines a parameter passed to the script of a run command.
+type RunCommandParameter struct {
+	// Name is the name of the parameter.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Value is the value of the parameter.
+	Value string `json:"value"`
+}
+
+// VirtualMachineScaleSetReference references an existing virtual machine scale set in Flexible orchestration mode.
+type VirtualMachineScaleSetReference struct {
+	// ID is the resource ID of the virtual machine scale set.
+	ID string `json:"id"`
+
+	// PlatformFaultDomain is the fault domain of the scale set the VM is placed in. It must be lower than the fault domain
+	// count of the scale set, and can't be set if the scale set has a single fault domain, in which case Azure spreads its
+	// VMs across fault domains on a best effort basis.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	PlatformFaultDomain *int32 `json:"platformFaultDomain,omitempty"`
+}
+
+// AvailabilitySetReference references an existing availability set by name or by resource ID.
+type AvailabilitySetReference struct {
+	// Name is the name of an availability set in the resource group of the cluster. Mutually exclusive with ID.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// ID is the resource ID of the availability set. Mutually exclusive with Name.
+	// +optional
+	ID string `json:"id,omitempty"`
+}
+
+// LocalNVMeStorage defines how the local NVMe disks of a VM are striped and mounted.
+type LocalNVMeStorage struct {
+	// MountPath is the absolute path the striped local NVMe disks are mounted at. Defaults to /mnt/nvme.
+	// +kubebuilder:default=/mnt/nvme
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// DataDiskRAIDLevel is the RAID level of an array of data disks.
+type DataDiskRAIDLevel string
+
+const (
+	// DataDiskRAID0 stripes the data disks, for the capacity and throughput of all the disks without redundancy.
+	DataDiskRAID0 DataDiskRAIDLevel = "RAID0"
+	// DataDiskRAID1 mirrors the data disks, for the capacity of a single disk.
+	DataDiskRAID1 DataDiskRAIDLevel = "RAID1"
+	// DataDiskRAID10 stripes mirrored pairs of data disks, for half of the capacity of the disks.
+	DataDiskRAID10 DataDiskRAIDLevel = "RAID10"
+)
+
+// DataDiskFilesystem is the filesystem an array of data disks is formatted with.
+type DataDiskFilesystem string
+
+const (
+	// DataDiskFilesystemExt4 formats the array with ext4.
+	DataDiskFilesystemExt4 DataDiskFilesystem = "ext4"
+	// DataDiskFilesystemXFS formats the array with XFS.
+	DataDiskFilesystemXFS DataDiskFilesystem = "xfs"
+)
+
+// DataDiskArray defines how data disks of a VM are assembled into a software RAID array and mounted.
+type DataDiskArray struct {
+	// DataDisks are the name suffixes of the data disks of the machine assembled into the array. The array is only
+	// created on blank disks, and assembled again from the same disks on the next boots.
+	// +kubebuilder:validation:MinItems=2
+	// +listType=set
+	DataDisks []string `json:"dataDisks"`
+
+	// Level is the RAID level of the array. Defaults to RAID0.
+	// +kubebuilder:validation:Enum=RAID0;RAID1;RAID10
+	// +kubebuilder:default=RAID0
+	// +optional
+	Level DataDiskRAIDLevel `json:"level,omitempty"`
+
+	// Filesystem is the filesystem the array is formatted with. Defaults to ext4.
+	// +kubebuilder:validation:Enum=ext4;xfs
+	// +kubebuilder:default=ext4
+	// +optional
+	Filesystem DataDiskFilesystem `json:"filesystem,omitempty"`
+
+	// MountPath is the absolute path the array is mounted at.
+	// +kubebuilder:validation:MinLength=1
+	MountPath string `json:"mountPath"`
+}
+
+// BootstrapCompletionCheckType is the type of check verifying that the bootstrap of a VM completed.
+type BootstrapCompletionCheckType string
+
+const (
+	// BootstrapCompletionCheckSentinel waits for the sentinel file written by the bootstrap provider once the bootstrap
+	// data executed successfully.
+	BootstrapCompletionCheckSentinel BootstrapCompletionCheckType = "Sentinel"
+	// BootstrapCompletionCheckCloudInit waits for cloud-init to complete all its stages and fails if cloud-init reported
+	// errors, e.g. in a module running after the bootstrap commands, before waiting for the sentinel file.
+	BootstrapCompletionCheckCloudInit BootstrapCompletionCheckType = "CloudInit"
+)
+
+// BootstrapCompletionCheck configures how the completion of the bootstrap of a VM is verified.
+type BootstrapCompletionCheck struct {
+	// Type is the type of check, Sentinel or CloudInit.
+	// +kubebuilder:validation:Enum=Sentinel;CloudInit
+	Type BootstrapCompletionCheckType `json:"type"`
+
+	// TimeoutSeconds is how long the check waits for the bootstrap to complete before the BootstrapSucceeded condition
+	// reports a failure. Defaults to 300.
+	// +kubebuilder:validation:Minimum=60
+	// +kubebuilder:validation:Maximum=5400
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
 }
 
 // SpotVMOptions defines the options relevant to running the Machine on Spot VMs.
@@ -171,6 +413,20 @@ type SystemAssignedIdentityRole struct {
 	// If not specified, the scope will be the subscription.
 	// +optional
 	Scope string `json:"scope,omitempty"`
+
+	// AdditionalRoles are other roles to assign to the system assigned identity, each one at its own scope, e.g. a
+	// role granting access to the secrets of a key vault. The names of their role assignments are derived from Name.
+	// +optional
+	AdditionalRoles []SystemAssignedIdentityAdditionalRole `json:"additionalRoles,omitempty"`
+}
+
+// SystemAssignedIdentityAdditionalRole defines an additional role and scope to assign to the system assigned identity.
+type SystemAssignedIdentityAdditionalRole struct {
+	// DefinitionID is the ID of the role definition to assign. It can be an Azure built-in role or a custom role.
+	DefinitionID string `json:"definitionID"`
+
+	// Scope is the ID of the subscription, resource group or resource that the role assignment applies to.
+	Scope string `json:"scope"`
 }
 
 // AzureMachineStatus defines the observed state of AzureMachine.
@@ -187,6 +443,22 @@ type AzureMachineStatus struct {
 	// +optional
 	VMState *ProvisioningState `json:"vmState,omitempty"`
 
+	// FaultDomain is the platform fault domain the Azure virtual machine is placed in within its availability set.
+	// +optional
+	FaultDomain *int32 `json:"faultDomain,omitempty"`
+
+	// BootDiagnosticsConsoleURI is the URI of the console screenshot of the Azure virtual machine, taken by boot diagnostics.
+	// It is only set when boot diagnostics are enabled and Azure reports it, which is usually only the case for a
+	// user-managed storage account.
+	// +optional
+	BootDiagnosticsConsoleURI string `json:"bootDiagnosticsConsoleURI,omitempty"`
+
+	// SerialConsoleLogURI is the URI of the serial console log of the Azure virtual machine, captured by boot diagnostics.
+	// It is only set when boot diagnostics are enabled and Azure reports it, which is usually only the case for a
+	// user-managed storage account.
+	// +optional
+	SerialConsoleLogURI string `json:"serialConsoleLogURI,omitempty"`
+
 	// ErrorReason will be set in the event that there is a terminal problem
 	// reconciling the Machine and will contain a succinct value suitable
 	// for machine interpretation.
@@ -237,6 +509,13 @@ type AzureMachineStatus struct {
 
 // AdditionalCapabilities enables or disables a capability on the virtual machine.
 type AdditionalCapabilities struct {
+	// HibernationEnabled enables or disables the hibernation capability of the virtual machine, which allows
+	// hibernating it to save costs while keeping its memory state. The VM size must support hibernation and the OS disk
+	// must be large enough to store the memory of the VM. Hibernation can't be enabled along with an ephemeral OS disk
+	// or Spot VM options.
+	// +optional
+	HibernationEnabled *bool `json:"hibernationEnabled,omitempty"`
+
 	// UltraSSDEnabled enables or disables Azure UltraSSD capability for the virtual machine.
 	// Defaults to true if Ultra SSD data disks are specified,
 	// otherwise it doesn't set the capability on the VM.
@@ -19,14 +19,52 @@ package v1beta1
 import (
 	"encoding/base64"
 	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/ssh"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api-provider-azure/feature"
 	azureutil "sigs.k8s.io/cluster-api-provider-azure/util/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/slice"
+	webhookutils "sigs.k8s.io/cluster-api-provider-azure/util/webhook"
 )
 
+var (
+	keyVaultNameRegex          = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]{1,22}[a-zA-Z0-9]$`)
+	keyVaultSecretNameRegex    = regexp.MustCompile(`^[a-zA-Z0-9-]{1,127}$`)
+	keyVaultSecretVersionRegex = regexp.MustCompile(`^[a-fA-F0-9]{32}$`)
+	// mountPathRegex restricts the mount paths of the local NVMe storage and of the data disk array to characters that
+	// are safe in the cloud-init boothooks mounting them.
+	mountPathRegex           = regexp.MustCompile(`^(/[a-zA-Z0-9._-]+)+$`)
+	availabilitySetNameRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._-]{0,78}[a-zA-Z0-9_])?$`)
+)
+
+// premiumV2StorageAccountType is the storage account type of Premium SSD v2 disks, which is not part of the compute API
+// version used by CAPZ.
+const premiumV2StorageAccountType = "PremiumV2_LRS"
+
+const (
+	// invalidTagNameCharacters are the characters Azure doesn't allow in tag names.
+	invalidTagNameCharacters = `<>%&\?/`
+	// maxTagValueLength is the maximum length of the value of an Azure tag.
+	maxTagValueLength = 256
+)
+
+// premiumStorageAccountTypes are the storage account types which require a VM size supporting premium storage.
+var premiumStorageAccountTypes = map[string]bool{
+	string(compute.StorageAccountTypesPremiumLRS): true,
+	string(compute.StorageAccountTypesPremiumZRS): true,
+	premiumV2StorageAccountType:                   true,
+}
+
 // ValidateAzureMachineSpec checks an AzureMachineSpec and returns any validation errors.
 func ValidateAzureMachineSpec(spec AzureMachineSpec) field.ErrorList {
 	var allErrs field.ErrorList
@@ -43,7 +81,11 @@ func ValidateAzureMachineSpec(spec AzureMachineSpec) field.ErrorList {
 		allErrs = append(allErrs, errs...)
 	}
 
-	if errs := ValidateSSHKey(spec.SSHPublicKey, field.NewPath("sshPublicKey")); len(errs) > 0 {
+	if spec.SSHPublicKeySecretRef != nil {
+		if errs := ValidateSSHKeySecretRef(spec.SSHPublicKey, spec.SSHPublicKeySecretRef, field.NewPath("sshPublicKeySecretRef")); len(errs) > 0 {
+			allErrs = append(allErrs, errs...)
+		}
+	} else if errs := ValidateSSHKey(spec.SSHPublicKey, field.NewPath("sshPublicKey")); len(errs) > 0 {
 		allErrs = append(allErrs, errs...)
 	}
 
@@ -67,6 +109,298 @@ func ValidateAzureMachineSpec(spec AzureMachineSpec) field.ErrorList {
 		allErrs = append(allErrs, errs...)
 	}
 
+	if errs := ValidateLocalNVMeStorage(spec.LocalNVMeStorage, spec.OSDisk.OSType, field.NewPath("localNVMeStorage")); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	if errs := ValidateDataDiskArray(spec, field.NewPath("dataDiskArray")); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	if errs := ValidateBootstrapCompletionCheck(spec.BootstrapCompletionCheck, spec.OSDisk.OSType, field.NewPath("bootstrapCompletionCheck")); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	if errs := ValidateHibernation(spec, field.NewPath("additionalCapabilities", "hibernationEnabled")); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	if errs := ValidateAvailabilitySet(spec.AvailabilitySet, spec.FailureDomain, field.NewPath("availabilitySet")); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	if spec.PlatformFaultDomainCount != nil && spec.AvailabilitySet != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("platformFaultDomainCount"), "the fault domain count of an existing availability set can't be set"))
+	}
+
+	if errs := ValidateVirtualMachineScaleSet(spec, field.NewPath("virtualMachineScaleSet")); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	if errs := ValidateRunCommands(spec.RunCommands, field.NewPath("runCommands")); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	if errs := ValidateStartStopSchedule(spec.StartStopSchedule, field.NewPath("startStopSchedule")); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	if errs := ValidateNodeLabelTags(spec.NodeLabelTags, field.NewPath("nodeLabelTags")); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	if !feature.Gates.Enabled(feature.EdgeZone) && spec.ExtendedLocation != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("extendedLocation"), "can be set only if the EdgeZone feature flag is enabled"))
+	}
+
+	if spec.ExtendedLocation != nil && spec.FailureDomain != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("failureDomain"), "can't be set along with extendedLocation, as edge zones don't have availability zones"))
+	}
+
+	return allErrs
+}
+
+// ValidateVirtualMachineScaleSet validates that a reference to a virtual machine scale set has a valid scale set
+// resource ID, and that it isn't combined with an availability set.
+func ValidateVirtualMachineScaleSet(spec AzureMachineSpec, fieldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	ref := spec.VirtualMachineScaleSet
+	if ref == nil {
+		return allErrs
+	}
+	if spec.AvailabilitySet != nil || spec.PlatformFaultDomainCount != nil {
+		allErrs = append(allErrs, field.Forbidden(fieldPath, "a VM can't be in both a virtual machine scale set and an availability set"))
+	}
+	if resourceID, err := azureutil.ParseResourceID(ref.ID); err != nil {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("id"), ref.ID, "must be a valid Azure resource ID"))
+	} else if !strings.EqualFold(resourceID.ResourceType.String(), "Microsoft.Compute/virtualMachineScaleSets") {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("id"), ref.ID, "must be a virtual machine scale set resource ID"))
+	}
+	return allErrs
+}
+
+// ValidateStartStopSchedule validates that the schedule of an Azure Automation runbook is a comma-separated list of time
+// ranges, days of the week and dates, and that it fits in a VM tag.
+func ValidateStartStopSchedule(schedule *StartStopSchedule, fieldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if schedule == nil {
+		return allErrs
+	}
+
+	if strings.ContainsAny(schedule.TagName, invalidTagNameCharacters) {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("tagName"), schedule.TagName,
+			fmt.Sprintf("tag names can't contain any of the characters %s", invalidTagNameCharacters)))
+	}
+
+	if len(schedule.Schedule) > maxTagValueLength {
+		allErrs = append(allErrs, field.TooLong(fieldPath.Child("schedule"), schedule.Schedule, maxTagValueLength))
+		return allErrs
+	}
+	for _, period := range strings.Split(schedule.Schedule, ",") {
+		if !isStartStopSchedulePeriod(strings.TrimSpace(period)) {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("schedule"), schedule.Schedule,
+				fmt.Sprintf("%q must be a time range such as \"10PM -> 6AM\", a day of the week such as \"Saturday\" or a date such as \"December 25\"", strings.TrimSpace(period))))
+		}
+	}
+	return allErrs
+}
+
+// ValidateNodeLabelTags validates that the names of the VM tags propagated as node labels are both valid tag names and
+// valid label names.
+func ValidateNodeLabelTags(tagNames []string, fieldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, tagName := range tagNames {
+		if strings.ContainsAny(tagName, invalidTagNameCharacters) {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Index(i), tagName,
+				fmt.Sprintf("tag names can't contain any of the characters %s", invalidTagNameCharacters)))
+			continue
+		}
+		for _, msg := range validation.IsQualifiedName(tagName) {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Index(i), tagName, msg))
+		}
+	}
+	return allErrs
+}
+
+// isStartStopSchedulePeriod returns true if a period of the schedule of an Azure Automation runbook is a time range, a
+// day of the week or a date.
+func isStartStopSchedulePeriod(period string) bool {
+	if start, end, ok := strings.Cut(period, "->"); ok {
+		return isStartStopScheduleTime(start) && isStartStopScheduleTime(end)
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(period, day.String()) {
+			return true
+		}
+	}
+	for _, layout := range []string{"January 2", "Jan 2"} {
+		if _, err := time.Parse(layout, period); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isStartStopScheduleTime returns true if a time of the schedule of an Azure Automation runbook is a time of the day
+// such as "10PM", "6:30AM" or "22:00".
+func isStartStopScheduleTime(t string) bool {
+	t = strings.ToUpper(strings.ReplaceAll(t, " ", ""))
+	for _, layout := range []string{"3PM", "3:04PM", "15:04"} {
+		if _, err := time.Parse(layout, t); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRunCommands validates that each run command has either an inline script or an HTTPS script URI, and that
+// the names of its parameters are unique.
+func ValidateRunCommands(runCommands []RunCommand, fieldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, runCommand := range runCommands {
+		sourcePath := fieldPath.Index(i).Child("source")
+		switch source := runCommand.Source; {
+		case source.Script == "" && source.ScriptURI == "":
+			allErrs = append(allErrs, field.Required(sourcePath, "either script or scriptURI must be set"))
+		case source.Script != "" && source.ScriptURI != "":
+			allErrs = append(allErrs, field.Forbidden(sourcePath, "script and scriptURI are mutually exclusive"))
+		case source.ScriptURI != "":
+			if u, err := url.Parse(source.ScriptURI); err != nil || u.Scheme != "https" || u.Host == "" {
+				allErrs = append(allErrs, field.Invalid(sourcePath.Child("scriptURI"), source.ScriptURI, "must be a valid HTTPS URI"))
+			}
+		}
+		names := make(map[string]bool, len(runCommand.Parameters))
+		for j, parameter := range runCommand.Parameters {
+			if names[parameter.Name] {
+				allErrs = append(allErrs, field.Duplicate(fieldPath.Index(i).Child("parameters").Index(j).Child("name"), parameter.Name))
+			}
+			names[parameter.Name] = true
+		}
+	}
+	return allErrs
+}
+
+// ValidateLocalNVMeStorage validates that the striped local NVMe storage is only requested for Linux VMs, and that its
+// mount path is a clean absolute path.
+func ValidateLocalNVMeStorage(storage *LocalNVMeStorage, osType string, fieldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if storage == nil {
+		return allErrs
+	}
+	if osType == WindowsOS {
+		allErrs = append(allErrs, field.Forbidden(fieldPath, "striping the local NVMe disks is only supported on Linux VMs"))
+	}
+	if storage.MountPath != "" && !mountPathRegex.MatchString(storage.MountPath) {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("mountPath"), storage.MountPath,
+			"must be an absolute path made of letters, digits, '.', '_', '-' and '/' without a trailing '/'"))
+	}
+	return allErrs
+}
+
+// minDataDiskArrayDisks is the minimum number of data disks of an array per RAID level.
+var minDataDiskArrayDisks = map[DataDiskRAIDLevel]int{
+	DataDiskRAID0:  2,
+	DataDiskRAID1:  2,
+	DataDiskRAID10: 4,
+}
+
+// ValidateDataDiskArray validates that the data disk array is only requested for Linux VMs, that it assembles enough
+// distinct data disks of the machine for its RAID level, and that its mount path is a clean absolute path not used by
+// the local NVMe storage.
+func ValidateDataDiskArray(spec AzureMachineSpec, fieldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	array := spec.DataDiskArray
+	if array == nil {
+		return allErrs
+	}
+	if spec.OSDisk.OSType == WindowsOS {
+		allErrs = append(allErrs, field.Forbidden(fieldPath, "data disk arrays are only supported on Linux VMs"))
+	}
+
+	dataDisks := make(map[string]struct{}, len(spec.DataDisks))
+	for _, disk := range spec.DataDisks {
+		dataDisks[disk.NameSuffix] = struct{}{}
+	}
+	arrayDisks := make(map[string]struct{}, len(array.DataDisks))
+	for i, name := range array.DataDisks {
+		if _, ok := dataDisks[name]; !ok {
+			allErrs = append(allErrs, field.NotFound(fieldPath.Child("dataDisks").Index(i), name))
+		}
+		if _, ok := arrayDisks[name]; ok {
+			allErrs = append(allErrs, field.Duplicate(fieldPath.Child("dataDisks").Index(i), name))
+		}
+		arrayDisks[name] = struct{}{}
+	}
+
+	level := array.Level
+	if level == "" {
+		level = DataDiskRAID0
+	}
+	if minDisks, ok := minDataDiskArrayDisks[level]; !ok {
+		allErrs = append(allErrs, field.NotSupported(fieldPath.Child("level"), array.Level,
+			[]string{string(DataDiskRAID0), string(DataDiskRAID1), string(DataDiskRAID10)}))
+	} else if len(arrayDisks) < minDisks {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("dataDisks"), array.DataDisks,
+			fmt.Sprintf("a %s array needs at least %d data disks", level, minDisks)))
+	} else if level == DataDiskRAID10 && len(arrayDisks)%2 != 0 {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("dataDisks"), array.DataDisks,
+			fmt.Sprintf("a %s array needs an even number of data disks", level)))
+	}
+
+	if array.Filesystem != "" && array.Filesystem != DataDiskFilesystemExt4 && array.Filesystem != DataDiskFilesystemXFS {
+		allErrs = append(allErrs, field.NotSupported(fieldPath.Child("filesystem"), array.Filesystem,
+			[]string{string(DataDiskFilesystemExt4), string(DataDiskFilesystemXFS)}))
+	}
+
+	if !mountPathRegex.MatchString(array.MountPath) {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("mountPath"), array.MountPath,
+			"must be an absolute path made of letters, digits, '.', '_', '-' and '/' without a trailing '/'"))
+	} else if spec.LocalNVMeStorage != nil && spec.LocalNVMeStorage.MountPath == array.MountPath {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("mountPath"), array.MountPath,
+			"the local NVMe storage is mounted at the same path"))
+	}
+	return allErrs
+}
+
+// ValidateBootstrapCompletionCheck validates that the completion check of the bootstrap is only configured for Linux VMs.
+func ValidateBootstrapCompletionCheck(check *BootstrapCompletionCheck, osType string, fieldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if check == nil {
+		return allErrs
+	}
+	if osType == WindowsOS {
+		allErrs = append(allErrs, field.Forbidden(fieldPath, "the bootstrap completion check can only be configured on Linux VMs"))
+	}
+	return allErrs
+}
+
+// ValidateAvailabilitySet validates that a reference to an existing availability set has either a valid name or a valid
+// availability set resource ID, and that it isn't combined with an availability zone.
+func ValidateAvailabilitySet(ref *AvailabilitySetReference, failureDomain *string, fieldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if ref == nil {
+		return allErrs
+	}
+	if failureDomain != nil {
+		allErrs = append(allErrs, field.Forbidden(fieldPath, "a VM can't be in both an availability set and an availability zone"))
+	}
+	switch {
+	case ref.Name == "" && ref.ID == "":
+		allErrs = append(allErrs, field.Required(fieldPath, "either name or id must be set"))
+	case ref.Name != "" && ref.ID != "":
+		allErrs = append(allErrs, field.Forbidden(fieldPath, "name and id are mutually exclusive"))
+	case ref.Name != "":
+		if !availabilitySetNameRegex.MatchString(ref.Name) {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("name"), ref.Name,
+				"must be 1-80 characters of letters, digits, '.', '_' and '-', start with a letter or a digit and end with a letter, a digit or '_'"))
+		}
+	default:
+		if resourceID, err := azureutil.ParseResourceID(ref.ID); err != nil {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("id"), ref.ID, "must be a valid Azure resource ID"))
+		} else if !strings.EqualFold(resourceID.ResourceType.String(), "Microsoft.Compute/availabilitySets") {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("id"), ref.ID, "must be an availability set resource ID"))
+		}
+	}
 	return allErrs
 }
 
@@ -86,7 +420,30 @@ func ValidateNetwork(subnetName string, acceleratedNetworking *bool, networkInte
 		}
 	}
 
-	return field.ErrorList{}
+	allErrs := field.ErrorList{}
+	for i, nic := range networkInterfaces {
+		for j, config := range nic.SecondaryIPConfigs {
+			if ip := net.ParseIP(config.PrivateIP); ip == nil || ip.To4() == nil {
+				allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("secondaryIPConfigs").Index(j).Child("privateIP"), config.PrivateIP,
+					"must be a valid IPv4 address"))
+			}
+		}
+		if nic.VirtualNetworkTap != nil {
+			allErrs = append(allErrs, validateVirtualNetworkTapID(nic.VirtualNetworkTap.ID, fldPath.Index(i).Child("virtualNetworkTap", "id"))...)
+		}
+	}
+	return allErrs
+}
+
+// validateVirtualNetworkTapID validates that a virtual network tap reference is a valid Azure resource ID.
+func validateVirtualNetworkTapID(id string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if resourceID, err := azureutil.ParseResourceID(id); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath, id, "must be a valid Azure resource ID"))
+	} else if !strings.EqualFold(resourceID.ResourceType.String(), "Microsoft.Network/virtualNetworkTaps") {
+		allErrs = append(allErrs, field.Invalid(fldPath, id, "must be a virtual network tap resource ID"))
+	}
+	return allErrs
 }
 
 // ValidateSSHKey validates an SSHKey.
@@ -107,6 +464,29 @@ func ValidateSSHKey(sshKey string, fldPath *field.Path) field.ErrorList {
 	return allErrs
 }
 
+// ValidateSSHKeySecretRef validates the reference to a Key Vault secret holding the SSH public key.
+func ValidateSSHKeySecretRef(sshKey string, ref *KeyVaultSecretReference, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if sshKey != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "sshPublicKeySecretRef and sshPublicKey are mutually exclusive"))
+	}
+	if !keyVaultNameRegex.MatchString(ref.VaultName) || strings.Contains(ref.VaultName, "--") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("vaultName"), ref.VaultName,
+			"must be 3 to 24 alphanumeric characters or hyphens, start with a letter, end with a letter or digit and not contain consecutive hyphens"))
+	}
+	if !keyVaultSecretNameRegex.MatchString(ref.SecretName) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("secretName"), ref.SecretName,
+			"must be 1 to 127 alphanumeric characters or hyphens"))
+	}
+	if ref.SecretVersion != "" && !keyVaultSecretVersionRegex.MatchString(ref.SecretVersion) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("secretVersion"), ref.SecretVersion,
+			"must be a 32 character hexadecimal string"))
+	}
+
+	return allErrs
+}
+
 // ValidateSystemAssignedIdentity validates the system-assigned identities list.
 func ValidateSystemAssignedIdentity(identityType VMIdentity, oldIdentity, newIdentity string, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
@@ -162,6 +542,30 @@ func ValidateSystemAssignedIdentityRole(identityType VMIdentity, roleAssignmentN
 	if identityType != VMIdentitySystemAssigned && role != nil {
 		allErrs = append(allErrs, field.Forbidden(field.NewPath("Spec", "Role"), "systemAssignedIdentityRole can only be set when identity is set to SystemAssigned"))
 	}
+	if role != nil {
+		allErrs = append(allErrs, ValidateSystemAssignedIdentityAdditionalRoles(role.AdditionalRoles, fldPath.Child("additionalRoles"))...)
+	}
+	return allErrs
+}
+
+// ValidateSystemAssignedIdentityAdditionalRoles validates that the additional roles of a system-assigned identity are
+// role definition IDs assigned at the ID of a subscription, resource group or resource, each one once.
+func ValidateSystemAssignedIdentityAdditionalRoles(roles []SystemAssignedIdentityAdditionalRole, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := make(map[SystemAssignedIdentityAdditionalRole]bool, len(roles))
+	for i, role := range roles {
+		if id, err := azureutil.ParseResourceID(role.DefinitionID); err != nil || !strings.EqualFold(id.ResourceType.String(), "Microsoft.Authorization/roleDefinitions") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("definitionID"), role.DefinitionID, "must be the ID of a Microsoft.Authorization/roleDefinitions resource"))
+		}
+		if _, err := azureutil.ParseResourceID(role.Scope); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("scope"), role.Scope, "must be the ID of a subscription, resource group or resource"))
+		}
+		key := SystemAssignedIdentityAdditionalRole{DefinitionID: strings.ToLower(role.DefinitionID), Scope: strings.ToLower(strings.TrimSuffix(role.Scope, "/"))}
+		if seen[key] {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i), role))
+		}
+		seen[key] = true
+	}
 	return allErrs
 }
 
@@ -171,8 +575,8 @@ func ValidateDataDisks(dataDisks []DataDisk, fieldPath *field.Path) field.ErrorL
 	lunSet := make(map[int32]struct{})
 	nameSet := make(map[string]struct{})
 	for _, disk := range dataDisks {
-		// validate that the disk size is between 4 and 32767.
-		if disk.DiskSizeGB < 4 || disk.DiskSizeGB > 32767 {
+		// validate that the disk size is between 4 and 32767, unless an existing disk is attached.
+		if disk.AttachExistingDisk == nil && (disk.DiskSizeGB < 4 || disk.DiskSizeGB > 32767) {
 			allErrs = append(allErrs, field.Invalid(fieldPath.Child("DiskSizeGB"), "", "the disk size should be a value between 4 and 32767"))
 		}
 
@@ -206,6 +610,212 @@ func ValidateDataDisks(dataDisks []DataDisk, fieldPath *field.Path) field.ErrorL
 
 		// validate cachingType
 		allErrs = append(allErrs, validateCachingType(disk.CachingType, fieldPath, disk.ManagedDisk)...)
+
+		// validate that provisioned performance is only set on UltraSSD disks
+		allErrs = append(allErrs, validateDiskPerformance(disk, fieldPath)...)
+
+		// validate that bursting and the performance tier schedule are only set on premium disks
+		allErrs = append(allErrs, validateDiskPerformanceTier(disk, fieldPath)...)
+
+		// validate that shared disks use a storage account type and caching type supporting them
+		allErrs = append(allErrs, validateSharedDisk(disk, fieldPath)...)
+
+		// validate the reference to an existing disk to attach
+		allErrs = append(allErrs, validateAttachExistingDisk(disk, fieldPath)...)
+
+		// validate that the logical sector size is only set on Premium SSD v2 disks created by CAPZ
+		allErrs = append(allErrs, validateLogicalSectorSize(disk, fieldPath)...)
+	}
+	return allErrs
+}
+
+// validateLogicalSectorSize validates that the logical sector size of a data disk is only set for a PremiumV2_LRS disk
+// created by CAPZ.
+func validateLogicalSectorSize(disk DataDisk, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if !disk.HasLogicalSectorSize() {
+		return allErrs
+	}
+	sectorSizePath := fieldPath.Child("managedDisk", "logicalSectorSize")
+	sectorSize := *disk.ManagedDisk.LogicalSectorSize
+	if sectorSize != 512 && sectorSize != 4096 {
+		allErrs = append(allErrs, field.NotSupported(sectorSizePath, sectorSize, []string{"512", "4096"}))
+	}
+	if disk.ManagedDisk.StorageAccountType != premiumV2StorageAccountType {
+		allErrs = append(allErrs, field.Invalid(sectorSizePath, sectorSize,
+			fmt.Sprintf("can only be set when managedDisk.storageAccountType is '%s'", premiumV2StorageAccountType)))
+	}
+	if disk.AttachExistingDisk != nil {
+		allErrs = append(allErrs, field.Forbidden(sectorSizePath, "the logical sector size of an existing disk can't be set"))
+	}
+	return allErrs
+}
+
+// validateAttachExistingDisk validates that an existing disk to attach is referenced by a managed disk resource ID,
+// and that the settings only applying to the disks created by CAPZ are not set.
+func validateAttachExistingDisk(disk DataDisk, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if disk.AttachExistingDisk == nil {
+		return allErrs
+	}
+	idPath := fieldPath.Child("attachExistingDisk", "id")
+	if resourceID, err := azureutil.ParseResourceID(disk.AttachExistingDisk.ID); err != nil {
+		allErrs = append(allErrs, field.Invalid(idPath, disk.AttachExistingDisk.ID, "must be a valid Azure resource ID"))
+	} else if !strings.EqualFold(resourceID.ResourceType.String(), "Microsoft.Compute/disks") {
+		allErrs = append(allErrs, field.Invalid(idPath, disk.AttachExistingDisk.ID, "must be a managed disk resource ID"))
+	}
+	if disk.IsShared() {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("maxShares"), "cannot be greater than 1 when attaching an existing disk"))
+	}
+	if disk.DiskIOPSReadWrite != nil || disk.DiskMBpsReadWrite != nil {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("attachExistingDisk"), "the provisioned IOPS and throughput of an existing disk can't be set"))
+	}
+	return allErrs
+}
+
+// validateSharedDisk validates that a data disk shared by multiple VMs has no host caching and uses a premium or
+// UltraSSD storage account type.
+func validateSharedDisk(disk DataDisk, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if !disk.IsShared() {
+		return allErrs
+	}
+	if disk.CachingType != string(compute.CachingTypesNone) {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("cachingType"), disk.CachingType, fmt.Sprintf("must be '%s' when maxShares is greater than 1", compute.CachingTypesNone)))
+	}
+	supported := []string{
+		string(compute.StorageAccountTypesPremiumLRS),
+		string(compute.StorageAccountTypesPremiumZRS),
+		string(compute.StorageAccountTypesUltraSSDLRS),
+	}
+	if disk.ManagedDisk == nil {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("managedDisk", "storageAccountType"), fmt.Sprintf("must be one of %v when maxShares is greater than 1", supported)))
+		return allErrs
+	}
+	for _, storageAccountType := range supported {
+		if disk.ManagedDisk.StorageAccountType == storageAccountType {
+			return allErrs
+		}
+	}
+	allErrs = append(allErrs, field.NotSupported(fieldPath.Child("managedDisk", "storageAccountType"), disk.ManagedDisk.StorageAccountType, supported))
+	return allErrs
+}
+
+// validateDiskPerformance validates that the provisioned IOPS and throughput of a data disk are only set for an
+// UltraSSD disk.
+func validateDiskPerformance(disk DataDisk, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if disk.DiskIOPSReadWrite == nil && disk.DiskMBpsReadWrite == nil {
+		return allErrs
+	}
+	if disk.ManagedDisk != nil && disk.ManagedDisk.StorageAccountType == string(compute.StorageAccountTypesUltraSSDLRS) {
+		return allErrs
+	}
+	msg := fmt.Sprintf("can only be set when managedDisk.storageAccountType is '%s'", compute.StorageAccountTypesUltraSSDLRS)
+	if disk.DiskIOPSReadWrite != nil {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("diskIOPSReadWrite"), *disk.DiskIOPSReadWrite, msg))
+	}
+	if disk.DiskMBpsReadWrite != nil {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("diskMBpsReadWrite"), *disk.DiskMBpsReadWrite, msg))
+	}
+	return allErrs
+}
+
+// premiumDiskTiers are the performance tiers of premium SSD disks, ordered by performance, with the largest disk size
+// in GiB of which each one is the baseline tier.
+var premiumDiskTiers = []struct {
+	name       string
+	diskSizeGB int32
+}{
+	{"P1", 4}, {"P2", 8}, {"P3", 16}, {"P4", 32}, {"P6", 64}, {"P10", 128}, {"P15", 256}, {"P20", 512},
+	{"P30", 1024}, {"P40", 2048}, {"P50", 4096}, {"P60", 8192}, {"P70", 16384}, {"P80", 32767},
+}
+
+// premiumDiskTierIndex returns the index of a performance tier in premiumDiskTiers, or -1 if it doesn't exist.
+func premiumDiskTierIndex(tier string) int {
+	for i, t := range premiumDiskTiers {
+		if t.name == tier {
+			return i
+		}
+	}
+	return -1
+}
+
+// minBusinessHoursForDiskTierDowngrade is the shortest time between the upgrade and the downgrade of a disk
+// performance tier, as Azure allows downgrading the tier of a disk only once every 12 hours.
+const minBusinessHoursForDiskTierDowngrade = 12 * time.Hour
+
+// validateDiskPerformanceTier validates that bursting and the performance tier schedule of a data disk are only set for
+// a premium SSD disk, and that the schedule can be applied to the disk.
+func validateDiskPerformanceTier(disk DataDisk, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if !ptr.Deref(disk.BurstingEnabled, false) && disk.PerformanceTierSchedule == nil {
+		return allErrs
+	}
+	supported := []string{
+		string(compute.StorageAccountTypesPremiumLRS),
+		string(compute.StorageAccountTypesPremiumZRS),
+	}
+	var storageAccountType string
+	if disk.ManagedDisk != nil {
+		storageAccountType = disk.ManagedDisk.StorageAccountType
+	}
+	if storageAccountType != supported[0] && storageAccountType != supported[1] {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("managedDisk", "storageAccountType"), storageAccountType,
+			fmt.Sprintf("must be one of %v when burstingEnabled or performanceTierSchedule is set", supported)))
+	}
+	if disk.AttachExistingDisk != nil {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("attachExistingDisk"), "bursting and the performance tier schedule of an existing disk can't be set"))
+	}
+	if ptr.Deref(disk.BurstingEnabled, false) && disk.DiskSizeGB <= 512 {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("burstingEnabled"), true, "can only be set for disks larger than 512 GiB"))
+	}
+
+	schedule := disk.PerformanceTierSchedule
+	if schedule == nil {
+		return allErrs
+	}
+	schedulePath := fieldPath.Child("performanceTierSchedule")
+	tierNames := make([]string, 0, len(premiumDiskTiers))
+	for _, t := range premiumDiskTiers {
+		tierNames = append(tierNames, t.name)
+	}
+	tier, offHoursTier := premiumDiskTierIndex(schedule.Tier), premiumDiskTierIndex(schedule.OffHoursTier)
+	if tier < 0 {
+		allErrs = append(allErrs, field.NotSupported(schedulePath.Child("tier"), schedule.Tier, tierNames))
+	}
+	if offHoursTier < 0 {
+		allErrs = append(allErrs, field.NotSupported(schedulePath.Child("offHoursTier"), schedule.OffHoursTier, tierNames))
+	}
+	if tier >= 0 && offHoursTier >= 0 {
+		if offHoursTier > tier {
+			allErrs = append(allErrs, field.Invalid(schedulePath.Child("offHoursTier"), schedule.OffHoursTier,
+				fmt.Sprintf("must not be higher than the business hours tier %s", schedule.Tier)))
+		}
+		if baseline := premiumDiskTiers[offHoursTier]; disk.AttachExistingDisk == nil && baseline.diskSizeGB < disk.DiskSizeGB {
+			allErrs = append(allErrs, field.Invalid(schedulePath.Child("offHoursTier"), schedule.OffHoursTier,
+				fmt.Sprintf("must not be lower than the baseline tier of a %d GiB disk", disk.DiskSizeGB)))
+		}
+	}
+
+	start, errStart := time.Parse(DiskPerformanceTierScheduleTimeFormat, schedule.OffHoursStart)
+	if errStart != nil {
+		allErrs = append(allErrs, field.Invalid(schedulePath.Child("offHoursStart"), schedule.OffHoursStart, "must be a time of day in the HH:MM format"))
+	}
+	end, errEnd := time.Parse(DiskPerformanceTierScheduleTimeFormat, schedule.OffHoursEnd)
+	if errEnd != nil {
+		allErrs = append(allErrs, field.Invalid(schedulePath.Child("offHoursEnd"), schedule.OffHoursEnd, "must be a time of day in the HH:MM format"))
+	}
+	if errStart == nil && errEnd == nil {
+		// Business hours go from the end of off-hours to their start, possibly spanning midnight.
+		businessHours := start.Sub(end)
+		if businessHours < 0 {
+			businessHours += 24 * time.Hour
+		}
+		if businessHours < minBusinessHoursForDiskTierDowngrade {
+			allErrs = append(allErrs, field.Invalid(schedulePath.Child("offHoursStart"), schedule.OffHoursStart,
+				fmt.Sprintf("business hours from %s must last at least %s, as the tier of a disk can only be downgraded once every 12 hours", schedule.OffHoursEnd, minBusinessHoursForDiskTierDowngrade)))
+		}
 	}
 	return allErrs
 }
@@ -250,6 +860,16 @@ func validateManagedDisk(m *ManagedDiskParameters, fieldPath *field.Path, isOSDi
 	if m != nil {
 		allErrs = append(allErrs, validateStorageAccountType(m.StorageAccountType, fieldPath.Child("StorageAccountType"), isOSDisk)...)
 
+		if isOSDisk && m.LogicalSectorSize != nil {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("logicalSectorSize"), "the logical sector size can only be set for data disks"))
+		}
+
+		if m.DiskEncryptionSet != nil {
+			if err := validateDiskEncryptionSetID(m.DiskEncryptionSet.ID, fieldPath.Child("diskEncryptionSet", "id")); err != nil {
+				allErrs = append(allErrs, err)
+			}
+		}
+
 		// DiskEncryptionSet can only be set when SecurityEncryptionType is set to DiskWithVMGuestState
 		// https://learn.microsoft.com/en-us/rest/api/compute/virtual-machines/create-or-update?tabs=HTTP#securityencryptiontypes
 		if isOSDisk && m.SecurityProfile != nil && m.SecurityProfile.DiskEncryptionSet != nil {
@@ -260,12 +880,27 @@ func validateManagedDisk(m *ManagedDiskParameters, fieldPath *field.Path, isOSDi
 					"diskEncryptionSet is only supported when securityEncryptionType is set to DiskWithVMGuestState",
 				))
 			}
+			if err := validateDiskEncryptionSetID(m.SecurityProfile.DiskEncryptionSet.ID, fieldPath.Child("securityProfile", "diskEncryptionSet", "id")); err != nil {
+				allErrs = append(allErrs, err)
+			}
 		}
 	}
 
 	return allErrs
 }
 
+// validateDiskEncryptionSetID validates that a disk encryption set reference is a valid Azure resource ID.
+func validateDiskEncryptionSetID(id string, fldPath *field.Path) *field.Error {
+	resourceID, err := azureutil.ParseResourceID(id)
+	if err != nil {
+		return field.Invalid(fldPath, id, "must be a valid Azure resource ID")
+	}
+	if !strings.EqualFold(resourceID.ResourceType.String(), "Microsoft.Compute/diskEncryptionSets") {
+		return field.Invalid(fldPath, id, "must be a disk encryption set resource ID")
+	}
+	return nil
+}
+
 // ValidateDataDisksUpdate validates updates to Data disks.
 func ValidateDataDisksUpdate(oldDataDisks, newDataDisks []DataDisk, fieldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
@@ -301,6 +936,14 @@ func ValidateDataDisksUpdate(oldDataDisks, newDataDisks []DataDisk, fieldPath *f
 			if newDisk.CachingType != oldDisk.CachingType {
 				allErrs = append(allErrs, field.Invalid(fieldPath.Index(i).Child("cachingType"), newDataDisks, fieldErrMsg))
 			}
+
+			if !ptr.Equal(newDisk.MaxShares, oldDisk.MaxShares) {
+				allErrs = append(allErrs, field.Invalid(fieldPath.Index(i).Child("maxShares"), newDataDisks, fieldErrMsg))
+			}
+
+			if !ptr.Equal(newDisk.AttachExistingDisk, oldDisk.AttachExistingDisk) {
+				allErrs = append(allErrs, field.Invalid(fieldPath.Index(i).Child("attachExistingDisk"), newDataDisks, fieldErrMsg))
+			}
 		} else {
 			allErrs = append(allErrs, field.Invalid(fieldPath.Index(i).Child("nameSuffix"), newDataDisks, diskErrMsg))
 		}
@@ -309,6 +952,37 @@ func ValidateDataDisksUpdate(oldDataDisks, newDataDisks []DataDisk, fieldPath *f
 	return allErrs
 }
 
+// ValidateDataDisksAddition validates that the data disks of an existing machine are only added to, as the data disks
+// already attached to the VM can't be removed or changed, and their LUN identifies them within the VM.
+func ValidateDataDisksAddition(oldDataDisks, newDataDisks []DataDisk, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	newDisks := make(map[string]int, len(newDataDisks))
+	for i, disk := range newDataDisks {
+		newDisks[disk.NameSuffix] = i
+	}
+
+	for _, oldDisk := range oldDataDisks {
+		i, ok := newDisks[oldDisk.NameSuffix]
+		if !ok {
+			allErrs = append(allErrs, field.Invalid(fieldPath, newDataDisks,
+				fmt.Sprintf("removing data disk %s after machine creation is not allowed", oldDisk.NameSuffix)))
+			continue
+		}
+		newDisk := newDataDisks[i]
+		if !ptr.Equal(newDisk.Lun, oldDisk.Lun) {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Index(i).Child("lun"), newDisk.Lun,
+				"changing the logical unit number of an attached data disk is not allowed"))
+			continue
+		}
+		if err := webhookutils.ValidateImmutable(fieldPath.Index(i), oldDisk, newDisk); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	return allErrs
+}
+
 func validateManagedDisksUpdate(oldDiskParams, newDiskParams *ManagedDiskParameters, fieldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	fieldErrMsg := "changing managed disk options after machine creation is not allowed"
@@ -324,6 +998,9 @@ func validateManagedDisksUpdate(oldDiskParams, newDiskParams *ManagedDiskParamet
 		} else if (newDiskParams.DiskEncryptionSet != nil && oldDiskParams.DiskEncryptionSet == nil) || (newDiskParams.DiskEncryptionSet == nil && oldDiskParams.DiskEncryptionSet != nil) {
 			allErrs = append(allErrs, field.Invalid(fieldPath.Child("diskEncryptionSet"), newDiskParams, fieldErrMsg))
 		}
+		if !ptr.Equal(newDiskParams.LogicalSectorSize, oldDiskParams.LogicalSectorSize) {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("logicalSectorSize"), newDiskParams, fieldErrMsg))
+		}
 	} else if (newDiskParams != nil && oldDiskParams == nil) || (newDiskParams == nil && oldDiskParams != nil) {
 		allErrs = append(allErrs, field.Invalid(fieldPath, newDiskParams, fieldErrMsg))
 	}
@@ -343,6 +1020,13 @@ func validateStorageAccountType(storageAccountType string, fieldPath *field.Path
 		return allErrs
 	}
 
+	if storageAccountType == premiumV2StorageAccountType {
+		if isOSDisk {
+			allErrs = append(allErrs, field.Invalid(fieldPath, storageAccountType, "PremiumV2_LRS can only be used with data disks, it cannot be used with OS Disks"))
+		}
+		return allErrs
+	}
+
 	for _, possibleStorageAccountType := range compute.PossibleDiskStorageAccountTypesValues() {
 		if string(possibleStorageAccountType) == storageAccountType {
 			return allErrs
@@ -356,9 +1040,9 @@ func validateCachingType(cachingType string, fieldPath *field.Path, managedDisk
 	allErrs := field.ErrorList{}
 	cachingTypeChildPath := fieldPath.Child("CachingType")
 
-	if managedDisk != nil && managedDisk.StorageAccountType == string(compute.StorageAccountTypesUltraSSDLRS) {
+	if managedDisk != nil && (managedDisk.StorageAccountType == string(compute.StorageAccountTypesUltraSSDLRS) || managedDisk.StorageAccountType == premiumV2StorageAccountType) {
 		if cachingType != string(compute.CachingTypesNone) {
-			allErrs = append(allErrs, field.Invalid(cachingTypeChildPath, cachingType, fmt.Sprintf("cachingType '%s' is not supported when storageAccountType is '%s'. Allowed values are: '%s'", cachingType, compute.StorageAccountTypesUltraSSDLRS, compute.CachingTypesNone)))
+			allErrs = append(allErrs, field.Invalid(cachingTypeChildPath, cachingType, fmt.Sprintf("cachingType '%s' is not supported when storageAccountType is '%s'. Allowed values are: '%s'", cachingType, managedDisk.StorageAccountType, compute.CachingTypesNone)))
 		}
 	}
 
@@ -385,6 +1069,9 @@ func ValidateDiagnostics(diagnostics *Diagnostics, fieldPath *field.Path) field.
 			} else if diagnostics.Boot.UserManaged.StorageAccountURI == "" {
 				allErrs = append(allErrs, field.Required(fieldPath.Child("StorageAccountURI"),
 					fmt.Sprintf("StorageAccountURI cannot be empty when storageAccountType is '%s'", UserManagedDiagnosticsStorage)))
+			} else if u, err := url.ParseRequestURI(diagnostics.Boot.UserManaged.StorageAccountURI); err != nil || u.Host == "" {
+				allErrs = append(allErrs, field.Invalid(fieldPath.Child("StorageAccountURI"), diagnostics.Boot.UserManaged.StorageAccountURI,
+					"StorageAccountURI must be an absolute URI, such as the blob endpoint of the storage account"))
 			}
 		case ManagedDiagnosticsStorage:
 			if diagnostics.Boot.UserManaged != nil &&
@@ -398,7 +1085,7 @@ func ValidateDiagnostics(diagnostics *Diagnostics, fieldPath *field.Path) field.
 				diagnostics.Boot.UserManaged.StorageAccountURI != "" {
 				allErrs = append(allErrs, field.Invalid(fieldPath.Child("StorageAccountURI"), diagnostics.Boot.UserManaged.StorageAccountURI,
 					fmt.Sprintf("StorageAccountURI cannot be set when storageAccountType is '%s'",
-						ManagedDiagnosticsStorage)))
+						DisabledDiagnosticsStorage)))
 			}
 		}
 	}
@@ -419,6 +1106,12 @@ func ValidateConfidentialCompute(managedDisk *ManagedDiskParameters, profile *Se
 		securityEncryptionType = managedDisk.SecurityProfile.SecurityEncryptionType
 	}
 
+	// Confidential VMs require the OS disk to be encrypted with the VM guest state
+	if profile != nil && profile.SecurityType == SecurityTypesConfidentialVM && securityEncryptionType == "" {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("SecurityType"), profile.SecurityType,
+			fmt.Sprintf("securityEncryptionType should be set on the OS disk when SecurityType is set to '%s'", SecurityTypesConfidentialVM)))
+	}
+
 	if profile != nil && securityEncryptionType != "" {
 		// SecurityEncryptionType can only be set for Confindential VMs
 		if profile.SecurityType != SecurityTypesConfidentialVM {
@@ -454,3 +1147,234 @@ func ValidateConfidentialCompute(managedDisk *ManagedDiskParameters, profile *Se
 
 	return allErrs
 }
+
+// ValidateStorageAccountTypeCapabilities validates the storage account types of the OS and data disks against the
+// capabilities of the VM size. UltraSSD data disks enable the UltraSSD capability on the VM, so it can't be explicitly
+// disabled. The capabilities of the VM size are only checked when they are known.
+func ValidateStorageAccountTypeCapabilities(spec AzureMachineSpec, capabilities *VMSizeCapabilities) field.ErrorList {
+	var allErrs field.ErrorList
+
+	validateStorageAccountType := func(managedDisk *ManagedDiskParameters, fieldPath *field.Path) {
+		if managedDisk == nil {
+			return
+		}
+		storageAccountType := managedDisk.StorageAccountType
+		if premiumStorageAccountTypes[storageAccountType] && capabilities != nil && !capabilities.PremiumIO {
+			allErrs = append(allErrs, field.Invalid(fieldPath, storageAccountType,
+				fmt.Sprintf("VM size %s does not support premium storage, use a VM size with the PremiumIO capability or the %s or %s storage account type",
+					spec.VMSize, compute.StorageAccountTypesStandardLRS, compute.StorageAccountTypesStandardSSDLRS)))
+		}
+		if storageAccountType != string(compute.StorageAccountTypesUltraSSDLRS) {
+			return
+		}
+		if spec.AdditionalCapabilities != nil && !ptr.Deref(spec.AdditionalCapabilities.UltraSSDEnabled, true) {
+			allErrs = append(allErrs, field.Invalid(fieldPath, storageAccountType,
+				fmt.Sprintf("%s requires additionalCapabilities.ultraSSDEnabled not to be false", storageAccountType)))
+		}
+		if capabilities != nil && !capabilities.UltraSSDAvailable {
+			allErrs = append(allErrs, field.Invalid(fieldPath, storageAccountType,
+				fmt.Sprintf("VM size %s does not support %s in the location and zone of the machine, use a VM size with the UltraSSDAvailable capability or a different storage account type",
+					spec.VMSize, storageAccountType)))
+		}
+	}
+
+	validateStorageAccountType(spec.OSDisk.ManagedDisk, field.NewPath("osDisk", "managedDisk", "storageAccountType"))
+	for i, disk := range spec.DataDisks {
+		validateStorageAccountType(disk.ManagedDisk, field.NewPath("dataDisks").Index(i).Child("managedDisk", "storageAccountType"))
+	}
+
+	return allErrs
+}
+
+// normalizeLocation returns the name of an Azure location in lower case without spaces, e.g. "West US" becomes "westus".
+func normalizeLocation(location string) string {
+	return strings.ToLower(strings.ReplaceAll(location, " ", ""))
+}
+
+// ValidateTrustedLaunchCapability validates the VM size of a machine requesting trusted launch is a Generation 2 VM
+// size supporting it. Nothing is validated if the capabilities of the VM size are not known yet.
+func ValidateTrustedLaunchCapability(spec AzureMachineSpec, capabilities *VMSizeCapabilities) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.SecurityProfile == nil || spec.SecurityProfile.SecurityType != SecurityTypesTrustedLaunch || capabilities == nil {
+		return allErrs
+	}
+
+	if !capabilities.TrustedLaunch {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("securityProfile", "securityType"), spec.SecurityProfile.SecurityType,
+			fmt.Sprintf("VM size %s does not support trusted launch, use a Generation 2 VM size that supports it", spec.VMSize)))
+	}
+	return allErrs
+}
+
+// ValidateConfidentialVMCapability validates the VM size of a confidential VM supports confidential computing. Nothing
+// is validated if the capabilities of the VM size are not known yet.
+func ValidateConfidentialVMCapability(spec AzureMachineSpec, capabilities *VMSizeCapabilities) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.SecurityProfile == nil || spec.SecurityProfile.SecurityType != SecurityTypesConfidentialVM || capabilities == nil {
+		return allErrs
+	}
+
+	if !capabilities.ConfidentialVM {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("securityProfile", "securityType"), spec.SecurityProfile.SecurityType,
+			fmt.Sprintf("VM size %s does not support confidential VMs, use a confidential VM size such as a DCasv5 or ECasv5 series VM size", spec.VMSize)))
+	}
+	return allErrs
+}
+
+// ValidateLocalNVMeStorageCapability validates that the VM size has local NVMe disks when striping them is requested.
+// The capabilities are not validated if they are nil.
+func ValidateLocalNVMeStorageCapability(spec AzureMachineSpec, capabilities *VMSizeCapabilities) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.LocalNVMeStorage == nil || capabilities == nil || capabilities.LocalNVMeDisks {
+		return allErrs
+	}
+	allErrs = append(allErrs, field.Invalid(field.NewPath("vmSize"), spec.VMSize,
+		"the VM size has no local NVMe disks to stripe, use a VM size with local NVMe disks, e.g. of the Lsv3 series, or remove localNVMeStorage"))
+	return allErrs
+}
+
+// ValidateHibernation validates that hibernation is not enabled along with an ephemeral OS disk or Spot VM options,
+// which Azure doesn't support.
+func ValidateHibernation(spec AzureMachineSpec, fieldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.AdditionalCapabilities == nil || !ptr.Deref(spec.AdditionalCapabilities.HibernationEnabled, false) {
+		return allErrs
+	}
+	if spec.OSDisk.DiffDiskSettings != nil {
+		allErrs = append(allErrs, field.Forbidden(fieldPath, "hibernation can't be enabled on a VM with an ephemeral OS disk"))
+	}
+	if spec.SpotVMOptions != nil {
+		allErrs = append(allErrs, field.Forbidden(fieldPath, "hibernation can't be enabled on a Spot VM"))
+	}
+	return allErrs
+}
+
+// ValidateHibernationCapability validates that the VM size supports hibernation when it is enabled, and that the OS
+// disk is large enough to store the memory of the VM when it is hibernated. The capabilities are not validated if they
+// are nil.
+func ValidateHibernationCapability(spec AzureMachineSpec, capabilities *VMSizeCapabilities) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.AdditionalCapabilities == nil || !ptr.Deref(spec.AdditionalCapabilities.HibernationEnabled, false) || capabilities == nil {
+		return allErrs
+	}
+	if !capabilities.Hibernation {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("vmSize"), spec.VMSize,
+			"the VM size does not support hibernation, use a VM size that supports it or disable additionalCapabilities.hibernationEnabled"))
+	}
+	if diskSizeGB := ptr.Deref(spec.OSDisk.DiskSizeGB, 0); diskSizeGB != 0 && float64(diskSizeGB) < capabilities.MemoryGB {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("osDisk", "diskSizeGB"), diskSizeGB,
+			fmt.Sprintf("must be at least %v, the memory of VM size %s in GB, to store the memory of the VM when it is hibernated", capabilities.MemoryGB, spec.VMSize)))
+	}
+	return allErrs
+}
+
+// ValidatePlatformFaultDomainCountCapability validates that the fault domain count of the availability set of the machine
+// doesn't exceed the maximum fault domain count of the region. The capabilities are not validated if they are nil or
+// the maximum is unknown.
+func ValidatePlatformFaultDomainCountCapability(spec AzureMachineSpec, capabilities *VMSizeCapabilities) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.PlatformFaultDomainCount == nil || capabilities == nil || capabilities.MaximumPlatformFaultDomainCount == 0 {
+		return allErrs
+	}
+	if *spec.PlatformFaultDomainCount > capabilities.MaximumPlatformFaultDomainCount {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("platformFaultDomainCount"), *spec.PlatformFaultDomainCount,
+			fmt.Sprintf("must not exceed %d, the maximum fault domain count of an availability set in location %s", capabilities.MaximumPlatformFaultDomainCount, capabilities.Location)))
+	}
+	return allErrs
+}
+
+// ValidateEncryptionAtHostCapability validates that the VM size supports encryption at host when it is enabled. The
+// rejection of a VM size suggests VM sizes that support it. The capabilities are not validated if they are nil.
+func ValidateEncryptionAtHostCapability(spec AzureMachineSpec, capabilities *VMSizeCapabilities) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.SecurityProfile == nil || !ptr.Deref(spec.SecurityProfile.EncryptionAtHost, false) || capabilities == nil {
+		return allErrs
+	}
+
+	fieldPath := field.NewPath("securityProfile", "encryptionAtHost")
+	if capabilities.EncryptionAtHost {
+		return allErrs
+	}
+	if len(capabilities.EncryptionAtHostVMSizes) == 0 {
+		allErrs = append(allErrs, field.Invalid(fieldPath, true,
+			fmt.Sprintf("VM size %s does not support encryption at host and no VM size in the location of the machine supports it", spec.VMSize)))
+		return allErrs
+	}
+	allErrs = append(allErrs, field.Invalid(fieldPath, true,
+		fmt.Sprintf("VM size %s does not support encryption at host, use a VM size that supports it, e.g. %s",
+			spec.VMSize, strings.Join(capabilities.EncryptionAtHostVMSizes, ", "))))
+	return allErrs
+}
+
+// ValidateSecondaryIPConfigsCapability validates that the private IP addresses of the secondary IP configurations of
+// the network interfaces are within their subnets. The IP configurations of a network interface are not validated if
+// the capabilities are nil or the address ranges of its subnet are unknown, e.g. because its subnet name is defaulted
+// by the controller.
+func ValidateSecondaryIPConfigsCapability(spec AzureMachineSpec, capabilities *VMSizeCapabilities) field.ErrorList {
+	var allErrs field.ErrorList
+	if capabilities == nil {
+		return allErrs
+	}
+	for i, nic := range spec.NetworkInterfaces {
+		cidrBlocks, ok := capabilities.SubnetCIDRBlocks[nic.SubnetName]
+		if !ok || nic.SubnetName == "" {
+			continue
+		}
+		for j, config := range nic.SecondaryIPConfigs {
+			if !ipInCIDRBlocks(config.PrivateIP, cidrBlocks) {
+				allErrs = append(allErrs, field.Invalid(field.NewPath("networkInterfaces").Index(i).Child("secondaryIPConfigs").Index(j).Child("privateIP"), config.PrivateIP,
+					fmt.Sprintf("must be within subnet %s (%s)", nic.SubnetName, strings.Join(cidrBlocks, ", "))))
+			}
+		}
+	}
+	return allErrs
+}
+
+// ipInCIDRBlocks returns true if the IP address is within one of the CIDR blocks.
+func ipInCIDRBlocks(ipAddress string, cidrBlocks []string) bool {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range cidrBlocks {
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateSecondaryLocationCapability validates that the VM size of a machine is available in the secondary location
+// of its cluster and, for a machine with a failure domain, that it is available in the same zone there, so that the
+// machine can be failed over. Nothing is validated if the capabilities are nil or the cluster has no secondary
+// location.
+func ValidateSecondaryLocationCapability(spec AzureMachineSpec, capabilities *VMSizeCapabilities) field.ErrorList {
+	var allErrs field.ErrorList
+	if capabilities == nil || capabilities.SecondaryLocation == "" {
+		return allErrs
+	}
+	if !capabilities.SecondaryLocationVMSizeAvailable {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("vmSize"), spec.VMSize,
+			fmt.Sprintf("VM size is not available in secondary location %s of the cluster, use a VM size available in both locations", capabilities.SecondaryLocation)))
+		return allErrs
+	}
+	if zone := ptr.Deref(spec.FailureDomain, ""); zone != "" && !slice.Contains(capabilities.SecondaryLocationZones, zone) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("failureDomain"), zone,
+			fmt.Sprintf("VM size %s is not available in zone %s of secondary location %s of the cluster", spec.VMSize, zone, capabilities.SecondaryLocation)))
+	}
+	return allErrs
+}
+
+// ValidateExtendedLocationCapability validates that the VM size of a machine is available in the extended location of
+// the machine, e.g. an edge zone.
+func ValidateExtendedLocationCapability(spec AzureMachineSpec, capabilities *VMSizeCapabilities) field.ErrorList {
+	var allErrs field.ErrorList
+	if capabilities == nil || spec.ExtendedLocation == nil {
+		return allErrs
+	}
+	if !capabilities.ExtendedLocationVMSizeAvailable {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("extendedLocation", "name"), spec.ExtendedLocation.Name,
+			fmt.Sprintf("VM size %s is not available in edge zone %s of location %s", spec.VMSize, spec.ExtendedLocation.Name, capabilities.Location)))
+	}
+	return allErrs
+}
@@ -21,14 +21,18 @@ import (
 	"crypto/rsa"
 	"encoding/base64"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
 	"github.com/google/uuid"
 	. "github.com/onsi/gomega"
 	"golang.org/x/crypto/ssh"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	utilfeature "k8s.io/component-base/featuregate/testing"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api-provider-azure/feature"
 )
 
 func TestAzureMachine_ValidateSSHKey(t *testing.T) {
@@ -68,6 +72,75 @@ func TestAzureMachine_ValidateSSHKey(t *testing.T) {
 	}
 }
 
+func TestAzureMachine_ValidateSSHKeySecretRef(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name    string
+		sshKey  string
+		ref     KeyVaultSecretReference
+		wantErr bool
+	}{
+		{
+			name:    "valid secret reference",
+			ref:     KeyVaultSecretReference{VaultName: "my-vault", SecretName: "ssh-key"},
+			wantErr: false,
+		},
+		{
+			name:    "valid secret reference with version",
+			ref:     KeyVaultSecretReference{VaultName: "my-vault", SecretName: "ssh-key", SecretVersion: "0123456789abcdef0123456789abcdef"},
+			wantErr: false,
+		},
+		{
+			name:    "secret reference with an SSH public key",
+			sshKey:  generateSSHPublicKey(true),
+			ref:     KeyVaultSecretReference{VaultName: "my-vault", SecretName: "ssh-key"},
+			wantErr: true,
+		},
+		{
+			name:    "vault name too short",
+			ref:     KeyVaultSecretReference{VaultName: "kv", SecretName: "ssh-key"},
+			wantErr: true,
+		},
+		{
+			name:    "vault name starting with a digit",
+			ref:     KeyVaultSecretReference{VaultName: "1-vault", SecretName: "ssh-key"},
+			wantErr: true,
+		},
+		{
+			name:    "vault name with consecutive hyphens",
+			ref:     KeyVaultSecretReference{VaultName: "my--vault", SecretName: "ssh-key"},
+			wantErr: true,
+		},
+		{
+			name:    "empty secret name",
+			ref:     KeyVaultSecretReference{VaultName: "my-vault"},
+			wantErr: true,
+		},
+		{
+			name:    "secret name with invalid characters",
+			ref:     KeyVaultSecretReference{VaultName: "my-vault", SecretName: "ssh_key"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid secret version",
+			ref:     KeyVaultSecretReference{VaultName: "my-vault", SecretName: "ssh-key", SecretVersion: "latest"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateSSHKeySecretRef(tc.sshKey, &tc.ref, field.NewPath("sshPublicKeySecretRef"))
+			if tc.wantErr {
+				g.Expect(err).NotTo(BeEmpty())
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
 func generateSSHPublicKey(b64Enconded bool) string {
 	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
 	publicRsaKey, _ := ssh.NewPublicKey(&privateKey.PublicKey)
@@ -112,6 +185,36 @@ func TestAzureMachine_ValidateOSDisk(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:    "valid os disk encrypted with a customer-managed key",
+			wantErr: false,
+			osDisk: OSDisk{
+				DiskSizeGB:  ptr.To[int32](30),
+				CachingType: "None",
+				OSType:      "blah",
+				ManagedDisk: &ManagedDiskParameters{
+					StorageAccountType: "Standard_LRS",
+					DiskEncryptionSet: &DiskEncryptionSetParameters{
+						ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/diskEncryptionSets/my-des",
+					},
+				},
+			},
+		},
+		{
+			name:    "os disk encryption set is not an Azure resource ID",
+			wantErr: true,
+			osDisk: OSDisk{
+				DiskSizeGB:  ptr.To[int32](30),
+				CachingType: "None",
+				OSType:      "blah",
+				ManagedDisk: &ManagedDiskParameters{
+					StorageAccountType: "Standard_LRS",
+					DiskEncryptionSet: &DiskEncryptionSetParameters{
+						ID: "my-des",
+					},
+				},
+			},
+		},
 		{
 			name:    "byoc encryption with ephemeral os disk spec",
 			wantErr: true,
@@ -196,6 +299,21 @@ func generateNegativeTestCases() []osDiskTestInput {
 				Option: string(compute.DiffDiskOptionsLocal),
 			},
 		},
+		{
+			DiskSizeGB: ptr.To[int32](30),
+			OSType:     "blah",
+			ManagedDisk: &ManagedDiskParameters{
+				StorageAccountType: "PremiumV2_LRS",
+			},
+		},
+		{
+			DiskSizeGB: ptr.To[int32](30),
+			OSType:     "blah",
+			ManagedDisk: &ManagedDiskParameters{
+				StorageAccountType: "Premium_LRS",
+				LogicalSectorSize:  ptr.To[int32](512),
+			},
+		},
 	}
 
 	for i, input := range invalidDiskSpecs {
@@ -244,6 +362,42 @@ func TestAzureMachine_ValidateDataDisks(t *testing.T) {
 			disks:   []DataDisk{},
 			wantErr: false,
 		},
+		{
+			name: "valid disk encrypted with a customer-managed key",
+			disks: []DataDisk{
+				{
+					NameSuffix:  "my_disk",
+					DiskSizeGB:  64,
+					Lun:         ptr.To[int32](0),
+					CachingType: "ReadWrite",
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: "Premium_LRS",
+						DiskEncryptionSet: &DiskEncryptionSetParameters{
+							ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/diskEncryptionSets/my-des",
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid disk encryption set ID",
+			disks: []DataDisk{
+				{
+					NameSuffix:  "my_disk",
+					DiskSizeGB:  64,
+					Lun:         ptr.To[int32](0),
+					CachingType: "ReadWrite",
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: "Premium_LRS",
+						DiskEncryptionSet: &DiskEncryptionSetParameters{
+							ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "valid disks",
 			disks: []DataDisk{
@@ -386,7 +540,7 @@ func TestAzureMachine_ValidateDataDisks(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "valid combination of managed disk storage account type UltraSSD_LRS and cachingType None",
+			name: "valid provisioned IOPS and throughput on an UltraSSD_LRS disk",
 			disks: []DataDisk{
 				{
 					NameSuffix: "my_disk_1",
@@ -394,106 +548,569 @@ func TestAzureMachine_ValidateDataDisks(t *testing.T) {
 					ManagedDisk: &ManagedDiskParameters{
 						StorageAccountType: string(compute.StorageAccountTypesUltraSSDLRS),
 					},
+					Lun:               ptr.To[int32](0),
+					CachingType:       string(compute.CachingTypesNone),
+					DiskIOPSReadWrite: ptr.To[int64](5000),
+					DiskMBpsReadWrite: ptr.To[int64](200),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid provisioned IOPS on a Premium_LRS disk",
+			disks: []DataDisk{
+				{
+					NameSuffix: "my_disk_1",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: string(compute.StorageAccountTypesPremiumLRS),
+					},
+					Lun:               ptr.To[int32](0),
+					CachingType:       string(compute.CachingTypesNone),
+					DiskIOPSReadWrite: ptr.To[int64](5000),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid provisioned throughput without managed disk parameters",
+			disks: []DataDisk{
+				{
+					NameSuffix:        "my_disk_1",
+					DiskSizeGB:        64,
+					Lun:               ptr.To[int32](0),
+					CachingType:       string(compute.CachingTypesNone),
+					DiskMBpsReadWrite: ptr.To[int64](200),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid shared Premium_LRS disk with cachingType None",
+			disks: []DataDisk{
+				{
+					NameSuffix: "my_shared_disk",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: string(compute.StorageAccountTypesPremiumLRS),
+					},
 					Lun:         ptr.To[int32](0),
 					CachingType: string(compute.CachingTypesNone),
+					MaxShares:   ptr.To[int32](2),
 				},
 			},
 			wantErr: false,
 		},
 		{
-			name: "invalid combination of managed disk storage account type UltraSSD_LRS and cachingType ReadWrite",
+			name: "valid shared UltraSSD_LRS disk with cachingType None",
 			disks: []DataDisk{
 				{
-					NameSuffix: "my_disk_1",
+					NameSuffix: "my_shared_disk",
 					DiskSizeGB: 64,
 					ManagedDisk: &ManagedDiskParameters{
 						StorageAccountType: string(compute.StorageAccountTypesUltraSSDLRS),
 					},
 					Lun:         ptr.To[int32](0),
-					CachingType: string(compute.CachingTypesReadWrite),
+					CachingType: string(compute.CachingTypesNone),
+					MaxShares:   ptr.To[int32](2),
 				},
 			},
-			wantErr: true,
+			wantErr: false,
 		},
 		{
-			name: "invalid combination of managed disk storage account type UltraSSD_LRS and cachingType ReadOnly",
+			name: "invalid shared disk with cachingType ReadOnly",
 			disks: []DataDisk{
 				{
-					NameSuffix: "my_disk_1",
+					NameSuffix: "my_shared_disk",
 					DiskSizeGB: 64,
 					ManagedDisk: &ManagedDiskParameters{
-						StorageAccountType: string(compute.StorageAccountTypesUltraSSDLRS),
+						StorageAccountType: string(compute.StorageAccountTypesPremiumLRS),
 					},
 					Lun:         ptr.To[int32](0),
 					CachingType: string(compute.CachingTypesReadOnly),
+					MaxShares:   ptr.To[int32](2),
 				},
 			},
 			wantErr: true,
 		},
-	}
-
-	for _, test := range testcases {
-		t.Run(test.name, func(t *testing.T) {
-			err := ValidateDataDisks(test.disks, field.NewPath("dataDisks"))
-			if test.wantErr {
-				g.Expect(err).NotTo(BeEmpty())
-			} else {
-				g.Expect(err).To(BeEmpty())
-			}
-		})
-	}
-}
-
-func TestAzureMachine_ValidateSystemAssignedIdentity(t *testing.T) {
-	g := NewWithT(t)
-
-	tests := []struct {
-		name               string
-		roleAssignmentName string
-		old                string
-		Identity           VMIdentity
-		wantErr            bool
-	}{
 		{
-			name:               "valid UUID",
-			roleAssignmentName: uuid.New().String(),
-			Identity:           VMIdentitySystemAssigned,
-			wantErr:            false,
+			name: "invalid shared Standard_LRS disk",
+			disks: []DataDisk{
+				{
+					NameSuffix: "my_shared_disk",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: string(compute.StorageAccountTypesStandardLRS),
+					},
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesNone),
+					MaxShares:   ptr.To[int32](2),
+				},
+			},
+			wantErr: true,
 		},
 		{
-			name:               "wrong Identity type",
-			roleAssignmentName: uuid.New().String(),
-			Identity:           VMIdentityNone,
-			wantErr:            true,
+			name: "invalid shared disk without managed disk parameters",
+			disks: []DataDisk{
+				{
+					NameSuffix:  "my_shared_disk",
+					DiskSizeGB:  64,
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesNone),
+					MaxShares:   ptr.To[int32](2),
+				},
+			},
+			wantErr: true,
 		},
 		{
-			name:               "not a valid UUID",
-			roleAssignmentName: "notaguid",
-			Identity:           VMIdentitySystemAssigned,
-			wantErr:            true,
+			name: "valid PremiumV2_LRS disk with a logical sector size",
+			disks: []DataDisk{
+				{
+					NameSuffix: "my_disk",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: "PremiumV2_LRS",
+						LogicalSectorSize:  ptr.To[int32](512),
+					},
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesNone),
+				},
+			},
+			wantErr: false,
 		},
 		{
-			name:               "empty",
-			roleAssignmentName: "",
-			Identity:           VMIdentitySystemAssigned,
-			wantErr:            true,
+			name: "invalid PremiumV2_LRS disk with cachingType ReadWrite",
+			disks: []DataDisk{
+				{
+					NameSuffix: "my_disk",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: "PremiumV2_LRS",
+					},
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesReadWrite),
+				},
+			},
+			wantErr: true,
 		},
 		{
-			name:               "changed",
-			roleAssignmentName: uuid.New().String(),
-			old:                uuid.New().String(),
-			Identity:           VMIdentitySystemAssigned,
-			wantErr:            true,
+			name: "invalid logical sector size on a Premium_LRS disk",
+			disks: []DataDisk{
+				{
+					NameSuffix: "my_disk",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: string(compute.StorageAccountTypesPremiumLRS),
+						LogicalSectorSize:  ptr.To[int32](512),
+					},
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesNone),
+				},
+			},
+			wantErr: true,
 		},
-	}
-
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			err := ValidateSystemAssignedIdentity(tc.Identity, tc.old, tc.roleAssignmentName, field.NewPath("sshPublicKey"))
-			if tc.wantErr {
-				g.Expect(err).NotTo(BeEmpty())
-			} else {
-				g.Expect(err).To(BeEmpty())
+		{
+			name: "unsupported logical sector size",
+			disks: []DataDisk{
+				{
+					NameSuffix: "my_disk",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: "PremiumV2_LRS",
+						LogicalSectorSize:  ptr.To[int32](1024),
+					},
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesNone),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid existing disk without a disk size",
+			disks: []DataDisk{
+				{
+					NameSuffix:  "my_existing_disk",
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesReadWrite),
+					AttachExistingDisk: &AttachExistingDisk{
+						ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/my-disk",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid existing disk ID",
+			disks: []DataDisk{
+				{
+					NameSuffix:  "my_existing_disk",
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesReadWrite),
+					AttachExistingDisk: &AttachExistingDisk{
+						ID: "my-disk",
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid existing disk ID of another resource type",
+			disks: []DataDisk{
+				{
+					NameSuffix:  "my_existing_disk",
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesReadWrite),
+					AttachExistingDisk: &AttachExistingDisk{
+						ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/snapshots/my-snapshot",
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid existing disk with provisioned IOPS",
+			disks: []DataDisk{
+				{
+					NameSuffix:  "my_existing_disk",
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesNone),
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: string(compute.StorageAccountTypesUltraSSDLRS),
+					},
+					DiskIOPSReadWrite: ptr.To[int64](5000),
+					AttachExistingDisk: &AttachExistingDisk{
+						ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/my-disk",
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid combination of managed disk storage account type UltraSSD_LRS and cachingType None",
+			disks: []DataDisk{
+				{
+					NameSuffix: "my_disk_1",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: string(compute.StorageAccountTypesUltraSSDLRS),
+					},
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesNone),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid combination of managed disk storage account type UltraSSD_LRS and cachingType ReadWrite",
+			disks: []DataDisk{
+				{
+					NameSuffix: "my_disk_1",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: string(compute.StorageAccountTypesUltraSSDLRS),
+					},
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesReadWrite),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid combination of managed disk storage account type UltraSSD_LRS and cachingType ReadOnly",
+			disks: []DataDisk{
+				{
+					NameSuffix: "my_disk_1",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: string(compute.StorageAccountTypesUltraSSDLRS),
+					},
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesReadOnly),
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateDataDisks(test.disks, field.NewPath("dataDisks"))
+			if test.wantErr {
+				g.Expect(err).NotTo(BeEmpty())
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateDiskPerformanceTier(t *testing.T) {
+	premiumDisk := func(sizeGB int32, schedule *DiskPerformanceTierSchedule) DataDisk {
+		return DataDisk{
+			NameSuffix:              "my_disk",
+			DiskSizeGB:              sizeGB,
+			ManagedDisk:             &ManagedDiskParameters{StorageAccountType: string(compute.StorageAccountTypesPremiumLRS)},
+			PerformanceTierSchedule: schedule,
+		}
+	}
+	schedule := func(tier, offHoursTier, start, end string) *DiskPerformanceTierSchedule {
+		return &DiskPerformanceTierSchedule{Tier: tier, OffHoursTier: offHoursTier, OffHoursStart: start, OffHoursEnd: end}
+	}
+
+	testcases := []struct {
+		name    string
+		disk    DataDisk
+		wantErr string
+	}{
+		{
+			name: "no bursting nor schedule",
+			disk: DataDisk{NameSuffix: "my_disk", DiskSizeGB: 64},
+		},
+		{
+			name: "valid schedule with off-hours spanning midnight",
+			disk: premiumDisk(128, schedule("P30", "P10", "20:00", "07:00")),
+		},
+		{
+			name: "valid bursting on a large premium disk",
+			disk: DataDisk{
+				NameSuffix:      "my_disk",
+				DiskSizeGB:      1024,
+				ManagedDisk:     &ManagedDiskParameters{StorageAccountType: string(compute.StorageAccountTypesPremiumZRS)},
+				BurstingEnabled: ptr.To(true),
+			},
+		},
+		{
+			name: "bursting on a small disk",
+			disk: DataDisk{
+				NameSuffix:      "my_disk",
+				DiskSizeGB:      512,
+				ManagedDisk:     &ManagedDiskParameters{StorageAccountType: string(compute.StorageAccountTypesPremiumLRS)},
+				BurstingEnabled: ptr.To(true),
+			},
+			wantErr: "can only be set for disks larger than 512 GiB",
+		},
+		{
+			name: "schedule on a standard disk",
+			disk: DataDisk{
+				NameSuffix:              "my_disk",
+				DiskSizeGB:              128,
+				ManagedDisk:             &ManagedDiskParameters{StorageAccountType: string(compute.StorageAccountTypesStandardSSDLRS)},
+				PerformanceTierSchedule: schedule("P30", "P10", "20:00", "07:00"),
+			},
+			wantErr: "must be one of [Premium_LRS Premium_ZRS] when burstingEnabled or performanceTierSchedule is set",
+		},
+		{
+			name:    "unknown tier",
+			disk:    premiumDisk(128, schedule("P31", "P10", "20:00", "07:00")),
+			wantErr: `Unsupported value: "P31"`,
+		},
+		{
+			name:    "off-hours tier higher than the business hours tier",
+			disk:    premiumDisk(128, schedule("P10", "P30", "20:00", "07:00")),
+			wantErr: "must not be higher than the business hours tier P10",
+		},
+		{
+			name:    "off-hours tier lower than the baseline tier of the disk size",
+			disk:    premiumDisk(1024, schedule("P40", "P20", "20:00", "07:00")),
+			wantErr: "must not be lower than the baseline tier of a 1024 GiB disk",
+		},
+		{
+			name:    "invalid off-hours start",
+			disk:    premiumDisk(128, schedule("P30", "P10", "8pm", "07:00")),
+			wantErr: "must be a time of day in the HH:MM format",
+		},
+		{
+			name:    "business hours shorter than 12 hours",
+			disk:    premiumDisk(128, schedule("P30", "P10", "18:00", "08:00")),
+			wantErr: "business hours from 08:00 must last at least 12h0m0s",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := validateDiskPerformanceTier(tc.disk, field.NewPath("dataDisks").Index(0))
+			if tc.wantErr != "" {
+				g.Expect(errs.ToAggregate()).To(MatchError(ContainSubstring(tc.wantErr)))
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestDiskPerformanceTierSchedule_TierAt(t *testing.T) {
+	testcases := []struct {
+		name     string
+		schedule DiskPerformanceTierSchedule
+		time     time.Time
+		want     string
+	}{
+		{
+			name:     "business hours",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "20:00", OffHoursEnd: "07:00"},
+			time:     time.Date(2023, time.June, 14, 12, 0, 0, 0, time.UTC),
+			want:     "P30",
+		},
+		{
+			name:     "off-hours before midnight",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "20:00", OffHoursEnd: "07:00"},
+			time:     time.Date(2023, time.June, 14, 20, 0, 0, 0, time.UTC),
+			want:     "P10",
+		},
+		{
+			name:     "off-hours after midnight",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "20:00", OffHoursEnd: "07:00"},
+			time:     time.Date(2023, time.June, 14, 6, 59, 0, 0, time.UTC),
+			want:     "P10",
+		},
+		{
+			name:     "end of off-hours",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "20:00", OffHoursEnd: "07:00"},
+			time:     time.Date(2023, time.June, 14, 7, 0, 0, 0, time.UTC),
+			want:     "P30",
+		},
+		{
+			name:     "off-hours not spanning midnight",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "00:00", OffHoursEnd: "06:00"},
+			time:     time.Date(2023, time.June, 14, 3, 0, 0, 0, time.UTC),
+			want:     "P10",
+		},
+		{
+			name:     "time in another location is converted to UTC",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "20:00", OffHoursEnd: "07:00"},
+			time:     time.Date(2023, time.June, 14, 18, 0, 0, 0, time.FixedZone("UTC-3", -3*60*60)),
+			want:     "P10",
+		},
+		{
+			name:     "weekend business hours",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "20:00", OffHoursEnd: "07:00", Weekends: true},
+			time:     time.Date(2023, time.June, 17, 12, 0, 0, 0, time.UTC),
+			want:     "P10",
+		},
+		{
+			name:     "weekday business hours with weekends off",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "20:00", OffHoursEnd: "07:00", Weekends: true},
+			time:     time.Date(2023, time.June, 16, 12, 0, 0, 0, time.UTC),
+			want:     "P30",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+			g.Expect(tc.schedule.TierAt(tc.time)).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestDiskPerformanceTierSchedule_NextChangeAfter(t *testing.T) {
+	testcases := []struct {
+		name     string
+		schedule DiskPerformanceTierSchedule
+		time     time.Time
+		want     time.Time
+	}{
+		{
+			name:     "business hours",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "20:00", OffHoursEnd: "07:00"},
+			time:     time.Date(2023, time.June, 14, 12, 0, 0, 0, time.UTC),
+			want:     time.Date(2023, time.June, 14, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "off-hours before midnight",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "20:00", OffHoursEnd: "07:00"},
+			time:     time.Date(2023, time.June, 14, 20, 0, 0, 0, time.UTC),
+			want:     time.Date(2023, time.June, 15, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "off-hours after midnight",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "20:00", OffHoursEnd: "07:00"},
+			time:     time.Date(2023, time.June, 14, 6, 59, 0, 0, time.UTC),
+			want:     time.Date(2023, time.June, 14, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "time in another location is converted to UTC",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "20:00", OffHoursEnd: "07:00"},
+			time:     time.Date(2023, time.June, 14, 16, 30, 0, 0, time.FixedZone("UTC-3", -3*60*60)),
+			want:     time.Date(2023, time.June, 14, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "midnight before the start of off-hours with weekends off",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "20:00", OffHoursEnd: "07:00", Weekends: true},
+			time:     time.Date(2023, time.June, 16, 21, 0, 0, 0, time.UTC),
+			want:     time.Date(2023, time.June, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "invalid times of day",
+			schedule: DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "8pm", OffHoursEnd: "7am"},
+			time:     time.Date(2023, time.June, 14, 12, 0, 0, 0, time.UTC),
+			want:     time.Time{},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+			g.Expect(tc.schedule.NextChangeAfter(tc.time)).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestAzureMachine_ValidateSystemAssignedIdentity(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name               string
+		roleAssignmentName string
+		old                string
+		Identity           VMIdentity
+		wantErr            bool
+	}{
+		{
+			name:               "valid UUID",
+			roleAssignmentName: uuid.New().String(),
+			Identity:           VMIdentitySystemAssigned,
+			wantErr:            false,
+		},
+		{
+			name:               "wrong Identity type",
+			roleAssignmentName: uuid.New().String(),
+			Identity:           VMIdentityNone,
+			wantErr:            true,
+		},
+		{
+			name:               "not a valid UUID",
+			roleAssignmentName: "notaguid",
+			Identity:           VMIdentitySystemAssigned,
+			wantErr:            true,
+		},
+		{
+			name:               "empty",
+			roleAssignmentName: "",
+			Identity:           VMIdentitySystemAssigned,
+			wantErr:            true,
+		},
+		{
+			name:               "changed",
+			roleAssignmentName: uuid.New().String(),
+			old:                uuid.New().String(),
+			Identity:           VMIdentitySystemAssigned,
+			wantErr:            true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateSystemAssignedIdentity(tc.Identity, tc.old, tc.roleAssignmentName, field.NewPath("sshPublicKey"))
+			if tc.wantErr {
+				g.Expect(err).NotTo(BeEmpty())
+			} else {
+				g.Expect(err).To(BeEmpty())
 			}
 		})
 	}
@@ -831,10 +1448,66 @@ func TestAzureMachine_ValidateDataDisksUpdate(t *testing.T) {
 			},
 			wantErr: true,
 		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
+		{
+			name: "invalid maxShares update",
+			disks: []DataDisk{
+				{
+					NameSuffix: "my_disk_1",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: "Premium_LRS",
+					},
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesNone),
+					MaxShares:   ptr.To[int32](3),
+				},
+			},
+			oldDisks: []DataDisk{
+				{
+					NameSuffix: "my_disk_1",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: "Premium_LRS",
+					},
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesNone),
+					MaxShares:   ptr.To[int32](2),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid logicalSectorSize update",
+			disks: []DataDisk{
+				{
+					NameSuffix: "my_disk_1",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: "PremiumV2_LRS",
+						LogicalSectorSize:  ptr.To[int32](4096),
+					},
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesNone),
+				},
+			},
+			oldDisks: []DataDisk{
+				{
+					NameSuffix: "my_disk_1",
+					DiskSizeGB: 64,
+					ManagedDisk: &ManagedDiskParameters{
+						StorageAccountType: "PremiumV2_LRS",
+						LogicalSectorSize:  ptr.To[int32](512),
+					},
+					Lun:         ptr.To[int32](0),
+					CachingType: string(compute.CachingTypesNone),
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
 			err := ValidateDataDisksUpdate(test.oldDisks, test.disks, field.NewPath("dataDisks"))
 			if test.wantErr {
 				g.Expect(err).NotTo(BeEmpty())
@@ -913,6 +1586,61 @@ func TestAzureMachine_ValidateNetwork(t *testing.T) {
 			}},
 			wantErr: true,
 		},
+		{
+			name:                  "valid config with a secondary IP configuration",
+			subnetName:            "",
+			acceleratedNetworking: nil,
+			networkInterfaces: []NetworkInterface{{
+				SubnetName:         "subnet1",
+				PrivateIPConfigs:   1,
+				SecondaryIPConfigs: []SecondaryIPConfig{{Name: "floating-vip", PrivateIP: "10.0.0.100"}},
+			}},
+			wantErr: false,
+		},
+		{
+			name:                  "invalid config setting a secondary IP configuration with an IPv6 address",
+			subnetName:            "",
+			acceleratedNetworking: nil,
+			networkInterfaces: []NetworkInterface{{
+				SubnetName:         "subnet1",
+				PrivateIPConfigs:   1,
+				SecondaryIPConfigs: []SecondaryIPConfig{{Name: "floating-vip", PrivateIP: "2001:1234:5678:9abd::100"}},
+			}},
+			wantErr: true,
+		},
+		{
+			name:                  "valid config with a virtual network tap",
+			subnetName:            "",
+			acceleratedNetworking: nil,
+			networkInterfaces: []NetworkInterface{{
+				SubnetName:        "subnet1",
+				PrivateIPConfigs:  1,
+				VirtualNetworkTap: &VirtualNetworkTapReference{ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/my-tap"},
+			}},
+			wantErr: false,
+		},
+		{
+			name:                  "invalid config with a virtual network tap that isn't a resource ID",
+			subnetName:            "",
+			acceleratedNetworking: nil,
+			networkInterfaces: []NetworkInterface{{
+				SubnetName:        "subnet1",
+				PrivateIPConfigs:  1,
+				VirtualNetworkTap: &VirtualNetworkTapReference{ID: "my-tap"},
+			}},
+			wantErr: true,
+		},
+		{
+			name:                  "invalid config with a virtual network tap referencing another resource type",
+			subnetName:            "",
+			acceleratedNetworking: nil,
+			networkInterfaces: []NetworkInterface{{
+				SubnetName:        "subnet1",
+				PrivateIPConfigs:  1,
+				VirtualNetworkTap: &VirtualNetworkTapReference{ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet"},
+			}},
+			wantErr: true,
+		},
 		{
 			name:                  "invalid config setting privateIPConfigs to less than 1",
 			subnetName:            "",
@@ -1092,6 +1820,19 @@ func TestAzureMachine_ValidateConfidentialCompute(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid configuration with SecurityType set to ConfidentialVM and no encryption",
+			managedDisk: &ManagedDiskParameters{
+				StorageAccountType: "Premium_LRS",
+			},
+			securityProfile: &SecurityProfile{
+				SecurityType: SecurityTypesConfidentialVM,
+				UefiSettings: &UefiSettings{
+					VTpmEnabled: ptr.To(true),
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid configuration with VMGuestStateOnly encryption and SecurityType not set to ConfidentialVM",
 			managedDisk: &ManagedDiskParameters{
@@ -1120,3 +1861,1203 @@ func TestAzureMachine_ValidateConfidentialCompute(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateStorageAccountTypeCapabilities(t *testing.T) {
+	premiumVMSize := &VMSizeCapabilities{PremiumIO: true, UltraSSDAvailable: true}
+	standardVMSize := &VMSizeCapabilities{PremiumIO: false, UltraSSDAvailable: false}
+	ultraDataDisk := DataDisk{
+		NameSuffix: "ultra",
+		DiskSizeGB: 128,
+		ManagedDisk: &ManagedDiskParameters{
+			StorageAccountType: string(compute.StorageAccountTypesUltraSSDLRS),
+		},
+		CachingType: string(compute.CachingTypesNone),
+	}
+
+	tests := []struct {
+		name           string
+		spec           AzureMachineSpec
+		capabilities   *VMSizeCapabilities
+		expectedFields []string
+	}{
+		{
+			name: "premium OS disk with a VM size supporting premium storage",
+			spec: AzureMachineSpec{
+				VMSize: "Standard_D2s_v3",
+				OSDisk: generateValidOSDisk(),
+			},
+			capabilities: premiumVMSize,
+		},
+		{
+			name: "premium OS disk with a VM size not supporting premium storage",
+			spec: AzureMachineSpec{
+				VMSize: "Standard_D2_v3",
+				OSDisk: generateValidOSDisk(),
+			},
+			capabilities:   standardVMSize,
+			expectedFields: []string{"osDisk.managedDisk.storageAccountType"},
+		},
+		{
+			name: "premium data disk with a VM size not supporting premium storage",
+			spec: AzureMachineSpec{
+				VMSize: "Standard_D2_v3",
+				OSDisk: OSDisk{ManagedDisk: &ManagedDiskParameters{StorageAccountType: string(compute.StorageAccountTypesStandardSSDLRS)}},
+				DataDisks: []DataDisk{
+					{NameSuffix: "standard", ManagedDisk: &ManagedDiskParameters{StorageAccountType: string(compute.StorageAccountTypesStandardLRS)}},
+					{NameSuffix: "premium", ManagedDisk: &ManagedDiskParameters{StorageAccountType: string(compute.StorageAccountTypesPremiumZRS)}},
+				},
+			},
+			capabilities:   standardVMSize,
+			expectedFields: []string{"dataDisks[1].managedDisk.storageAccountType"},
+		},
+		{
+			name: "premium storage is not validated when the VM size capabilities are unknown",
+			spec: AzureMachineSpec{
+				VMSize: "Standard_D2_v3",
+				OSDisk: generateValidOSDisk(),
+			},
+			capabilities: nil,
+		},
+		{
+			name: "UltraSSD data disk with the UltraSSD capability enabled",
+			spec: AzureMachineSpec{
+				VMSize:                 "Standard_D2s_v3",
+				OSDisk:                 generateValidOSDisk(),
+				DataDisks:              []DataDisk{ultraDataDisk},
+				AdditionalCapabilities: &AdditionalCapabilities{UltraSSDEnabled: ptr.To(true)},
+			},
+			capabilities: premiumVMSize,
+		},
+		{
+			name: "UltraSSD data disk without additional capabilities",
+			spec: AzureMachineSpec{
+				VMSize:    "Standard_D2s_v3",
+				OSDisk:    generateValidOSDisk(),
+				DataDisks: []DataDisk{ultraDataDisk},
+			},
+			capabilities: premiumVMSize,
+		},
+		{
+			name: "UltraSSD data disk with the UltraSSD capability disabled",
+			spec: AzureMachineSpec{
+				VMSize:                 "Standard_D2s_v3",
+				OSDisk:                 generateValidOSDisk(),
+				DataDisks:              []DataDisk{ultraDataDisk},
+				AdditionalCapabilities: &AdditionalCapabilities{UltraSSDEnabled: ptr.To(false)},
+			},
+			capabilities:   nil,
+			expectedFields: []string{"dataDisks[0].managedDisk.storageAccountType"},
+		},
+		{
+			name: "UltraSSD data disk with a VM size not supporting UltraSSD",
+			spec: AzureMachineSpec{
+				VMSize:                 "Standard_D2s_v3",
+				OSDisk:                 generateValidOSDisk(),
+				DataDisks:              []DataDisk{ultraDataDisk},
+				AdditionalCapabilities: &AdditionalCapabilities{UltraSSDEnabled: ptr.To(true)},
+			},
+			capabilities:   &VMSizeCapabilities{PremiumIO: true, UltraSSDAvailable: false},
+			expectedFields: []string{"dataDisks[0].managedDisk.storageAccountType"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := ValidateStorageAccountTypeCapabilities(tc.spec, tc.capabilities)
+			fields := []string{}
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+				g.Expect(err.Detail).To(Or(ContainSubstring(tc.spec.VMSize), ContainSubstring("ultraSSDEnabled")))
+			}
+			g.Expect(fields).To(ConsistOf(tc.expectedFields))
+		})
+	}
+}
+
+func TestValidateEncryptionAtHostCapability(t *testing.T) {
+	tests := []struct {
+		name            string
+		securityProfile *SecurityProfile
+		capabilities    *VMSizeCapabilities
+		expectedDetail  string
+	}{
+		{
+			name:            "encryption at host not requested",
+			securityProfile: &SecurityProfile{EncryptionAtHost: ptr.To(false)},
+			capabilities:    &VMSizeCapabilities{EncryptionAtHost: false},
+		},
+		{
+			name:            "encryption at host with a VM size supporting it",
+			securityProfile: &SecurityProfile{EncryptionAtHost: ptr.To(true)},
+			capabilities:    &VMSizeCapabilities{EncryptionAtHost: true},
+		},
+		{
+			name:            "encryption at host with VM size capabilities not known yet",
+			securityProfile: &SecurityProfile{EncryptionAtHost: ptr.To(true)},
+		},
+		{
+			name:            "encryption at host with a VM size not supporting it suggests VM sizes",
+			securityProfile: &SecurityProfile{EncryptionAtHost: ptr.To(true)},
+			capabilities: &VMSizeCapabilities{
+				EncryptionAtHost:        false,
+				EncryptionAtHostVMSizes: []string{"Standard_D4_v3", "Standard_D2s_v3"},
+			},
+			expectedDetail: "VM size Standard_D2_v3 does not support encryption at host, use a VM size that supports it, e.g. Standard_D4_v3, Standard_D2s_v3",
+		},
+		{
+			name:            "encryption at host without any VM size supporting it",
+			securityProfile: &SecurityProfile{EncryptionAtHost: ptr.To(true)},
+			capabilities:    &VMSizeCapabilities{EncryptionAtHost: false},
+			expectedDetail:  "VM size Standard_D2_v3 does not support encryption at host and no VM size in the location of the machine supports it",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := AzureMachineSpec{
+				VMSize:          "Standard_D2_v3",
+				SecurityProfile: tc.securityProfile,
+			}
+			errs := ValidateEncryptionAtHostCapability(spec, tc.capabilities)
+			if tc.expectedDetail == "" {
+				g.Expect(errs).To(BeEmpty())
+				return
+			}
+			g.Expect(errs).To(HaveLen(1))
+			g.Expect(errs[0].Field).To(Equal("securityProfile.encryptionAtHost"))
+			g.Expect(errs[0].Detail).To(Equal(tc.expectedDetail))
+		})
+	}
+}
+
+func TestValidateBootstrapCompletionCheck(t *testing.T) {
+	tests := []struct {
+		name           string
+		check          *BootstrapCompletionCheck
+		osType         string
+		expectedFields []string
+	}{
+		{
+			name:   "bootstrap completion check not configured",
+			osType: WindowsOS,
+		},
+		{
+			name:   "cloud-init completion check on a Linux VM",
+			check:  &BootstrapCompletionCheck{Type: BootstrapCompletionCheckCloudInit, TimeoutSeconds: ptr.To[int32](600)},
+			osType: LinuxOS,
+		},
+		{
+			name:           "bootstrap completion check on a Windows VM",
+			check:          &BootstrapCompletionCheck{Type: BootstrapCompletionCheckSentinel},
+			osType:         WindowsOS,
+			expectedFields: []string{"bootstrapCompletionCheck"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := ValidateBootstrapCompletionCheck(tc.check, tc.osType, field.NewPath("bootstrapCompletionCheck"))
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
+
+func TestValidateLocalNVMeStorage(t *testing.T) {
+	tests := []struct {
+		name           string
+		storage        *LocalNVMeStorage
+		osType         string
+		expectedFields []string
+	}{
+		{
+			name:   "local NVMe storage not requested",
+			osType: WindowsOS,
+		},
+		{
+			name:    "local NVMe storage on a Linux VM",
+			storage: &LocalNVMeStorage{MountPath: "/var/lib/containerd"},
+			osType:  LinuxOS,
+		},
+		{
+			name:           "local NVMe storage on a Windows VM",
+			storage:        &LocalNVMeStorage{MountPath: "/mnt/nvme"},
+			osType:         WindowsOS,
+			expectedFields: []string{"localNVMeStorage"},
+		},
+		{
+			name:           "relative mount path",
+			storage:        &LocalNVMeStorage{MountPath: "mnt/nvme"},
+			osType:         LinuxOS,
+			expectedFields: []string{"localNVMeStorage.mountPath"},
+		},
+		{
+			name:           "mount path with a trailing slash",
+			storage:        &LocalNVMeStorage{MountPath: "/mnt/nvme/"},
+			osType:         LinuxOS,
+			expectedFields: []string{"localNVMeStorage.mountPath"},
+		},
+		{
+			name:           "mount path with shell characters",
+			storage:        &LocalNVMeStorage{MountPath: "/mnt/nvme';reboot'"},
+			osType:         LinuxOS,
+			expectedFields: []string{"localNVMeStorage.mountPath"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := ValidateLocalNVMeStorage(tc.storage, tc.osType, field.NewPath("localNVMeStorage"))
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
+
+func TestValidateDataDiskArray(t *testing.T) {
+	dataDisks := []DataDisk{
+		{NameSuffix: "data-1", Lun: ptr.To[int32](0)},
+		{NameSuffix: "data-2", Lun: ptr.To[int32](1)},
+		{NameSuffix: "data-3", Lun: ptr.To[int32](2)},
+		{NameSuffix: "data-4", Lun: ptr.To[int32](3)},
+	}
+	tests := []struct {
+		name           string
+		array          *DataDiskArray
+		osType         string
+		nvmeMountPath  string
+		expectedFields []string
+	}{
+		{
+			name:   "data disk array not requested",
+			osType: WindowsOS,
+		},
+		{
+			name:   "RAID0 array of two data disks",
+			array:  &DataDiskArray{DataDisks: []string{"data-1", "data-2"}, MountPath: "/var/lib/data"},
+			osType: LinuxOS,
+		},
+		{
+			name:   "RAID10 array of four data disks formatted with xfs",
+			array:  &DataDiskArray{DataDisks: []string{"data-1", "data-2", "data-3", "data-4"}, Level: DataDiskRAID10, Filesystem: DataDiskFilesystemXFS, MountPath: "/mnt/data"},
+			osType: LinuxOS,
+		},
+		{
+			name:           "data disk array on a Windows VM",
+			array:          &DataDiskArray{DataDisks: []string{"data-1", "data-2"}, MountPath: "/mnt/data"},
+			osType:         WindowsOS,
+			expectedFields: []string{"dataDiskArray"},
+		},
+		{
+			name:           "array with a disk which isn't a data disk of the machine",
+			array:          &DataDiskArray{DataDisks: []string{"data-1", "data-5"}, MountPath: "/mnt/data"},
+			osType:         LinuxOS,
+			expectedFields: []string{"dataDiskArray.dataDisks[1]"},
+		},
+		{
+			name:           "array with a duplicate disk",
+			array:          &DataDiskArray{DataDisks: []string{"data-1", "data-1"}, Level: DataDiskRAID1, MountPath: "/mnt/data"},
+			osType:         LinuxOS,
+			expectedFields: []string{"dataDiskArray.dataDisks[1]", "dataDiskArray.dataDisks"},
+		},
+		{
+			name:           "RAID10 array with too few disks",
+			array:          &DataDiskArray{DataDisks: []string{"data-1", "data-2"}, Level: DataDiskRAID10, MountPath: "/mnt/data"},
+			osType:         LinuxOS,
+			expectedFields: []string{"dataDiskArray.dataDisks"},
+		},
+		{
+			name: "RAID10 array with an odd number of disks",
+			array: &DataDiskArray{
+				DataDisks: []string{"data-1", "data-2", "data-3", "data-4", "data-5"},
+				Level:     DataDiskRAID10,
+				MountPath: "/mnt/data",
+			},
+			osType:         LinuxOS,
+			expectedFields: []string{"dataDiskArray.dataDisks[4]", "dataDiskArray.dataDisks"},
+		},
+		{
+			name:           "unsupported level and filesystem",
+			array:          &DataDiskArray{DataDisks: []string{"data-1", "data-2"}, Level: "RAID5", Filesystem: "btrfs", MountPath: "/mnt/data"},
+			osType:         LinuxOS,
+			expectedFields: []string{"dataDiskArray.level", "dataDiskArray.filesystem"},
+		},
+		{
+			name:           "mount path with shell characters",
+			array:          &DataDiskArray{DataDisks: []string{"data-1", "data-2"}, MountPath: "/mnt/data';reboot'"},
+			osType:         LinuxOS,
+			expectedFields: []string{"dataDiskArray.mountPath"},
+		},
+		{
+			name:           "mount path of the local NVMe storage",
+			array:          &DataDiskArray{DataDisks: []string{"data-1", "data-2"}, MountPath: "/mnt/nvme"},
+			osType:         LinuxOS,
+			nvmeMountPath:  "/mnt/nvme",
+			expectedFields: []string{"dataDiskArray.mountPath"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := AzureMachineSpec{
+				OSDisk:        OSDisk{OSType: tc.osType},
+				DataDisks:     dataDisks,
+				DataDiskArray: tc.array,
+			}
+			if tc.nvmeMountPath != "" {
+				spec.LocalNVMeStorage = &LocalNVMeStorage{MountPath: tc.nvmeMountPath}
+			}
+			errs := ValidateDataDiskArray(spec, field.NewPath("dataDiskArray"))
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
+
+func TestValidateAvailabilitySet(t *testing.T) {
+	tests := []struct {
+		name           string
+		ref            *AvailabilitySetReference
+		failureDomain  *string
+		expectedFields []string
+	}{
+		{
+			name:          "no availability set with a failure domain",
+			failureDomain: ptr.To("1"),
+		},
+		{
+			name: "availability set by name",
+			ref:  &AvailabilitySetReference{Name: "my-as_1"},
+		},
+		{
+			name: "availability set by ID",
+			ref:  &AvailabilitySetReference{ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/availabilitySets/my-as"},
+		},
+		{
+			name:           "availability set with a failure domain",
+			ref:            &AvailabilitySetReference{Name: "my-as"},
+			failureDomain:  ptr.To("1"),
+			expectedFields: []string{"availabilitySet"},
+		},
+		{
+			name:           "neither name nor ID",
+			ref:            &AvailabilitySetReference{},
+			expectedFields: []string{"availabilitySet"},
+		},
+		{
+			name: "both name and ID",
+			ref: &AvailabilitySetReference{
+				Name: "my-as",
+				ID:   "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/availabilitySets/my-as",
+			},
+			expectedFields: []string{"availabilitySet"},
+		},
+		{
+			name:           "invalid name",
+			ref:            &AvailabilitySetReference{Name: "my-as-"},
+			expectedFields: []string{"availabilitySet.name"},
+		},
+		{
+			name:           "invalid ID",
+			ref:            &AvailabilitySetReference{ID: "my-as"},
+			expectedFields: []string{"availabilitySet.id"},
+		},
+		{
+			name:           "ID of another resource type",
+			ref:            &AvailabilitySetReference{ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/my-disk"},
+			expectedFields: []string{"availabilitySet.id"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := ValidateAvailabilitySet(tc.ref, tc.failureDomain, field.NewPath("availabilitySet"))
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
+
+func TestValidateVirtualMachineScaleSet(t *testing.T) {
+	scaleSetID := "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachineScaleSets/my-vmss"
+	tests := []struct {
+		name           string
+		spec           AzureMachineSpec
+		expectedFields []string
+	}{
+		{
+			name: "no scale set",
+		},
+		{
+			name: "scale set by ID",
+			spec: AzureMachineSpec{VirtualMachineScaleSet: &VirtualMachineScaleSetReference{ID: scaleSetID}},
+		},
+		{
+			name: "scale set with an availability set",
+			spec: AzureMachineSpec{
+				VirtualMachineScaleSet: &VirtualMachineScaleSetReference{ID: scaleSetID},
+				AvailabilitySet:        &AvailabilitySetReference{Name: "my-as"},
+			},
+			expectedFields: []string{"virtualMachineScaleSet"},
+		},
+		{
+			name: "scale set with a fault domain count",
+			spec: AzureMachineSpec{
+				VirtualMachineScaleSet:   &VirtualMachineScaleSetReference{ID: scaleSetID},
+				PlatformFaultDomainCount: ptr.To[int32](2),
+			},
+			expectedFields: []string{"virtualMachineScaleSet"},
+		},
+		{
+			name:           "invalid ID",
+			spec:           AzureMachineSpec{VirtualMachineScaleSet: &VirtualMachineScaleSetReference{ID: "my-vmss"}},
+			expectedFields: []string{"virtualMachineScaleSet.id"},
+		},
+		{
+			name: "ID of another resource type",
+			spec: AzureMachineSpec{VirtualMachineScaleSet: &VirtualMachineScaleSetReference{
+				ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/availabilitySets/my-as",
+			}},
+			expectedFields: []string{"virtualMachineScaleSet.id"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := ValidateVirtualMachineScaleSet(tc.spec, field.NewPath("virtualMachineScaleSet"))
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
+
+func TestValidateSystemAssignedIdentityAdditionalRoles(t *testing.T) {
+	readerRoleID := "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/acdd72a7-3385-48ef-bd42-f606fba81ae7"
+	tests := []struct {
+		name           string
+		roles          []SystemAssignedIdentityAdditionalRole
+		expectedFields []string
+	}{
+		{
+			name: "no additional roles",
+		},
+		{
+			name: "roles at the scope of a subscription, a resource group and a resource",
+			roles: []SystemAssignedIdentityAdditionalRole{
+				{DefinitionID: readerRoleID, Scope: "/subscriptions/123/"},
+				{DefinitionID: readerRoleID, Scope: "/subscriptions/123/resourceGroups/my-rg"},
+				{DefinitionID: "/providers/Microsoft.Authorization/roleDefinitions/4633458b-17de-408a-b874-0445c86b69e6", Scope: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-kv"},
+			},
+		},
+		{
+			name: "invalid scope",
+			roles: []SystemAssignedIdentityAdditionalRole{
+				{DefinitionID: readerRoleID, Scope: "my-rg"},
+			},
+			expectedFields: []string{"additionalRoles[0].scope"},
+		},
+		{
+			name: "definition ID of another resource type",
+			roles: []SystemAssignedIdentityAdditionalRole{
+				{DefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleAssignments/123", Scope: "/subscriptions/123"},
+			},
+			expectedFields: []string{"additionalRoles[0].definitionID"},
+		},
+		{
+			name: "duplicate role",
+			roles: []SystemAssignedIdentityAdditionalRole{
+				{DefinitionID: readerRoleID, Scope: "/subscriptions/123/resourceGroups/my-rg"},
+				{DefinitionID: readerRoleID, Scope: "/subscriptions/123/resourcegroups/MY-RG/"},
+			},
+			expectedFields: []string{"additionalRoles[1]"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := ValidateSystemAssignedIdentityAdditionalRoles(tc.roles, field.NewPath("additionalRoles"))
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
+
+func TestValidateStartStopSchedule(t *testing.T) {
+	tests := []struct {
+		name           string
+		schedule       *StartStopSchedule
+		expectedFields []string
+	}{
+		{
+			name: "no schedule",
+		},
+		{
+			name:     "time ranges, days of the week and dates",
+			schedule: &StartStopSchedule{Schedule: "10PM -> 6AM, 12:30PM->1:30pm, 22:00 -> 06:00, Saturday, sunday, December 25, Jan 1"},
+		},
+		{
+			name:     "custom tag name",
+			schedule: &StartStopSchedule{Schedule: "Saturday", TagName: "StopSchedule"},
+		},
+		{
+			name:           "tag name with invalid characters",
+			schedule:       &StartStopSchedule{Schedule: "Saturday", TagName: "stop/schedule"},
+			expectedFields: []string{"startStopSchedule.tagName"},
+		},
+		{
+			name:           "invalid time range",
+			schedule:       &StartStopSchedule{Schedule: "10PM -> 25:00"},
+			expectedFields: []string{"startStopSchedule.schedule"},
+		},
+		{
+			name:           "invalid day and date",
+			schedule:       &StartStopSchedule{Schedule: "Weekend, February 30"},
+			expectedFields: []string{"startStopSchedule.schedule", "startStopSchedule.schedule"},
+		},
+		{
+			name:           "schedule too long for a tag",
+			schedule:       &StartStopSchedule{Schedule: strings.Repeat("Saturday,", 30)},
+			expectedFields: []string{"startStopSchedule.schedule"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := ValidateStartStopSchedule(tc.schedule, field.NewPath("startStopSchedule"))
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
+
+func TestValidateNodeLabelTags(t *testing.T) {
+	tests := []struct {
+		name           string
+		tagNames       []string
+		expectedFields []string
+	}{
+		{
+			name: "no tags",
+		},
+		{
+			name:     "valid tag names",
+			tagNames: []string{"workload", "cost-center", "team.name"},
+		},
+		{
+			name:           "tag name with characters invalid in tag names",
+			tagNames:       []string{"workload", "example.com/workload"},
+			expectedFields: []string{"nodeLabelTags[1]"},
+		},
+		{
+			name:           "tag name invalid as a label name",
+			tagNames:       []string{"cost center", strings.Repeat("a", 64)},
+			expectedFields: []string{"nodeLabelTags[0]", "nodeLabelTags[1]"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := ValidateNodeLabelTags(tc.tagNames, field.NewPath("nodeLabelTags"))
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
+
+func TestValidateRunCommands(t *testing.T) {
+	tests := []struct {
+		name           string
+		runCommands    []RunCommand
+		expectedFields []string
+	}{
+		{
+			name: "no run commands",
+		},
+		{
+			name: "inline script with parameters",
+			runCommands: []RunCommand{{
+				Name:       "post-provision",
+				Source:     RunCommandSource{Script: "echo $GREETING"},
+				Parameters: []RunCommandParameter{{Name: "GREETING", Value: "hello"}},
+			}},
+		},
+		{
+			name: "HTTPS script URI",
+			runCommands: []RunCommand{{
+				Name:   "post-provision",
+				Source: RunCommandSource{ScriptURI: "https://mystorage.blob.core.windows.net/scripts/post-provision.sh?sv=2021-08-06&sig=abc"},
+			}},
+		},
+		{
+			name:           "no script",
+			runCommands:    []RunCommand{{Name: "post-provision"}},
+			expectedFields: []string{"runCommands[0].source"},
+		},
+		{
+			name: "script and script URI",
+			runCommands: []RunCommand{{
+				Name:   "post-provision",
+				Source: RunCommandSource{Script: "echo hello", ScriptURI: "https://mystorage.blob.core.windows.net/scripts/post-provision.sh"},
+			}},
+			expectedFields: []string{"runCommands[0].source"},
+		},
+		{
+			name: "HTTP script URI",
+			runCommands: []RunCommand{
+				{Name: "first", Source: RunCommandSource{Script: "echo hello"}},
+				{Name: "second", Source: RunCommandSource{ScriptURI: "http://mystorage.blob.core.windows.net/scripts/post-provision.sh"}},
+			},
+			expectedFields: []string{"runCommands[1].source.scriptURI"},
+		},
+		{
+			name: "script URI without host",
+			runCommands: []RunCommand{{
+				Name:   "post-provision",
+				Source: RunCommandSource{ScriptURI: "post-provision.sh"},
+			}},
+			expectedFields: []string{"runCommands[0].source.scriptURI"},
+		},
+		{
+			name: "duplicate parameters",
+			runCommands: []RunCommand{{
+				Name:       "post-provision",
+				Source:     RunCommandSource{Script: "echo $GREETING"},
+				Parameters: []RunCommandParameter{{Name: "GREETING", Value: "hello"}, {Name: "GREETING", Value: "hi"}},
+			}},
+			expectedFields: []string{"runCommands[0].parameters[1].name"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs := ValidateRunCommands(tc.runCommands, field.NewPath("runCommands"))
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
+
+func TestValidateSecondaryIPConfigsCapability(t *testing.T) {
+	tests := []struct {
+		name         string
+		subnetName   string
+		privateIP    string
+		capabilities *VMSizeCapabilities
+		wantErr      bool
+	}{
+		{
+			name:         "IP address within the subnet",
+			subnetName:   "node-subnet",
+			privateIP:    "10.1.0.100",
+			capabilities: &VMSizeCapabilities{SubnetCIDRBlocks: map[string][]string{"node-subnet": {"10.0.0.0/16", "10.1.0.0/16"}}},
+		},
+		{
+			name:         "IP address outside the subnet",
+			subnetName:   "node-subnet",
+			privateIP:    "10.2.0.100",
+			capabilities: &VMSizeCapabilities{SubnetCIDRBlocks: map[string][]string{"node-subnet": {"10.0.0.0/16", "10.1.0.0/16"}}},
+			wantErr:      true,
+		},
+		{
+			name:         "unknown subnet",
+			subnetName:   "other-subnet",
+			privateIP:    "10.2.0.100",
+			capabilities: &VMSizeCapabilities{SubnetCIDRBlocks: map[string][]string{"node-subnet": {"10.0.0.0/16"}}},
+		},
+		{
+			name:      "unknown capabilities",
+			privateIP: "10.2.0.100",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := AzureMachineSpec{NetworkInterfaces: []NetworkInterface{{
+				SubnetName:         tc.subnetName,
+				PrivateIPConfigs:   1,
+				SecondaryIPConfigs: []SecondaryIPConfig{{Name: "floating-vip", PrivateIP: tc.privateIP}},
+			}}}
+			errs := ValidateSecondaryIPConfigsCapability(spec, tc.capabilities)
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateSecondaryLocationCapability(t *testing.T) {
+	tests := []struct {
+		name          string
+		failureDomain *string
+		capabilities  *VMSizeCapabilities
+		wantErr       bool
+	}{
+		{
+			name:         "no secondary location",
+			capabilities: &VMSizeCapabilities{},
+		},
+		{
+			name:         "VM size available in the secondary location",
+			capabilities: &VMSizeCapabilities{SecondaryLocation: "eastus", SecondaryLocationVMSizeAvailable: true},
+		},
+		{
+			name:         "VM size not available in the secondary location",
+			capabilities: &VMSizeCapabilities{SecondaryLocation: "eastus"},
+			wantErr:      true,
+		},
+		{
+			name:          "zone available in the secondary location",
+			failureDomain: ptr.To("2"),
+			capabilities:  &VMSizeCapabilities{SecondaryLocation: "eastus", SecondaryLocationVMSizeAvailable: true, SecondaryLocationZones: []string{"1", "2"}},
+		},
+		{
+			name:          "zone not available in the secondary location",
+			failureDomain: ptr.To("3"),
+			capabilities:  &VMSizeCapabilities{SecondaryLocation: "eastus", SecondaryLocationVMSizeAvailable: true, SecondaryLocationZones: []string{"1", "2"}},
+			wantErr:       true,
+		},
+		{
+			name:          "unknown capabilities",
+			failureDomain: ptr.To("3"),
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := AzureMachineSpec{VMSize: "Standard_D2s_v3", FailureDomain: tc.failureDomain}
+			errs := ValidateSecondaryLocationCapability(spec, tc.capabilities)
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateExtendedLocationCapability(t *testing.T) {
+	tests := []struct {
+		name             string
+		extendedLocation *ExtendedLocationSpec
+		capabilities     *VMSizeCapabilities
+		wantErr          bool
+	}{
+		{
+			name:         "no extended location",
+			capabilities: &VMSizeCapabilities{Location: "westus"},
+		},
+		{
+			name:             "VM size available in the edge zone",
+			extendedLocation: &ExtendedLocationSpec{Name: "microsoftlosangeles1", Type: "EdgeZone"},
+			capabilities:     &VMSizeCapabilities{Location: "westus", ExtendedLocationVMSizeAvailable: true},
+		},
+		{
+			name:             "VM size not available in the edge zone",
+			extendedLocation: &ExtendedLocationSpec{Name: "microsoftlosangeles1", Type: "EdgeZone"},
+			capabilities:     &VMSizeCapabilities{Location: "westus"},
+			wantErr:          true,
+		},
+		{
+			name:             "unknown capabilities",
+			extendedLocation: &ExtendedLocationSpec{Name: "microsoftlosangeles1", Type: "EdgeZone"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := AzureMachineSpec{VMSize: "Standard_D2s_v3", ExtendedLocation: tc.extendedLocation}
+			errs := ValidateExtendedLocationCapability(spec, tc.capabilities)
+			if tc.wantErr {
+				g.Expect(errs).To(ConsistOf(HaveField("Field", "extendedLocation.name")))
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateAzureMachineSpecExtendedLocation(t *testing.T) {
+	extendedLocation := &ExtendedLocationSpec{Name: "microsoftlosangeles1", Type: "EdgeZone"}
+	tests := []struct {
+		name          string
+		spec          AzureMachineSpec
+		edgeZone      bool
+		expectedField string
+	}{
+		{
+			name:     "extended location with the EdgeZone feature flag enabled",
+			spec:     AzureMachineSpec{ExtendedLocation: extendedLocation},
+			edgeZone: true,
+		},
+		{
+			name:          "extended location with the EdgeZone feature flag disabled",
+			spec:          AzureMachineSpec{ExtendedLocation: extendedLocation},
+			expectedField: "extendedLocation",
+		},
+		{
+			name:          "extended location with a failure domain",
+			spec:          AzureMachineSpec{ExtendedLocation: extendedLocation, FailureDomain: ptr.To("1")},
+			edgeZone:      true,
+			expectedField: "failureDomain",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			defer utilfeature.SetFeatureGateDuringTest(t, feature.Gates, feature.EdgeZone, tc.edgeZone)()
+			tc.spec.SSHPublicKey = generateSSHPublicKey(true)
+			var fields []string
+			for _, err := range ValidateAzureMachineSpec(tc.spec) {
+				fields = append(fields, err.Field)
+			}
+			if tc.expectedField == "" {
+				g.Expect(fields).NotTo(ContainElements("extendedLocation", "failureDomain"))
+			} else {
+				g.Expect(fields).To(ContainElement(tc.expectedField))
+			}
+		})
+	}
+}
+
+func TestValidatePlatformFaultDomainCountCapability(t *testing.T) {
+	tests := []struct {
+		name             string
+		faultDomainCount *int32
+		capabilities     *VMSizeCapabilities
+		wantErr          bool
+	}{
+		{
+			name:             "fault domain count not set",
+			capabilities:     &VMSizeCapabilities{Location: "westus", MaximumPlatformFaultDomainCount: 2},
+			faultDomainCount: nil,
+		},
+		{
+			name:             "fault domain count within the maximum of the location",
+			faultDomainCount: ptr.To[int32](2),
+			capabilities:     &VMSizeCapabilities{Location: "westus", MaximumPlatformFaultDomainCount: 2},
+		},
+		{
+			name:             "fault domain count exceeding the maximum of the location",
+			faultDomainCount: ptr.To[int32](3),
+			capabilities:     &VMSizeCapabilities{Location: "westus", MaximumPlatformFaultDomainCount: 2},
+			wantErr:          true,
+		},
+		{
+			name:             "unknown maximum fault domain count",
+			faultDomainCount: ptr.To[int32](3),
+			capabilities:     &VMSizeCapabilities{Location: "westus"},
+		},
+		{
+			name:             "unknown capabilities",
+			faultDomainCount: ptr.To[int32](3),
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := AzureMachineSpec{PlatformFaultDomainCount: tc.faultDomainCount}
+			errs := ValidatePlatformFaultDomainCountCapability(spec, tc.capabilities)
+			if tc.wantErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateLocalNVMeStorageCapability(t *testing.T) {
+	tests := []struct {
+		name         string
+		storage      *LocalNVMeStorage
+		capabilities *VMSizeCapabilities
+		wantErr      bool
+	}{
+		{
+			name:         "local NVMe storage not requested",
+			capabilities: &VMSizeCapabilities{LocalNVMeDisks: false},
+		},
+		{
+			name:         "local NVMe storage with a VM size with local NVMe disks",
+			storage:      &LocalNVMeStorage{MountPath: "/mnt/nvme"},
+			capabilities: &VMSizeCapabilities{LocalNVMeDisks: true},
+		},
+		{
+			name:    "local NVMe storage with VM size capabilities not known yet",
+			storage: &LocalNVMeStorage{MountPath: "/mnt/nvme"},
+		},
+		{
+			name:         "local NVMe storage with a VM size without local NVMe disks",
+			storage:      &LocalNVMeStorage{MountPath: "/mnt/nvme"},
+			capabilities: &VMSizeCapabilities{LocalNVMeDisks: false},
+			wantErr:      true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := AzureMachineSpec{
+				VMSize:           "Standard_D2_v3",
+				LocalNVMeStorage: tc.storage,
+			}
+			errs := ValidateLocalNVMeStorageCapability(spec, tc.capabilities)
+			if !tc.wantErr {
+				g.Expect(errs).To(BeEmpty())
+				return
+			}
+			g.Expect(errs).To(HaveLen(1))
+			g.Expect(errs[0].Field).To(Equal("vmSize"))
+		})
+	}
+}
+
+func TestValidateHibernation(t *testing.T) {
+	hibernation := &AdditionalCapabilities{HibernationEnabled: ptr.To(true)}
+	tests := []struct {
+		name                   string
+		additionalCapabilities *AdditionalCapabilities
+		diffDiskSettings       *DiffDiskSettings
+		spotVMOptions          *SpotVMOptions
+		expectedErrors         int
+	}{
+		{
+			name:             "hibernation not enabled on a Spot VM with an ephemeral OS disk",
+			diffDiskSettings: &DiffDiskSettings{Option: "Local"},
+			spotVMOptions:    &SpotVMOptions{},
+		},
+		{
+			name:                   "hibernation disabled on a Spot VM",
+			additionalCapabilities: &AdditionalCapabilities{HibernationEnabled: ptr.To(false)},
+			spotVMOptions:          &SpotVMOptions{},
+		},
+		{
+			name:                   "hibernation enabled",
+			additionalCapabilities: hibernation,
+		},
+		{
+			name:                   "hibernation enabled with an ephemeral OS disk",
+			additionalCapabilities: hibernation,
+			diffDiskSettings:       &DiffDiskSettings{Option: "Local"},
+			expectedErrors:         1,
+		},
+		{
+			name:                   "hibernation enabled on a Spot VM",
+			additionalCapabilities: hibernation,
+			spotVMOptions:          &SpotVMOptions{},
+			expectedErrors:         1,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := AzureMachineSpec{
+				AdditionalCapabilities: tc.additionalCapabilities,
+				OSDisk:                 OSDisk{DiffDiskSettings: tc.diffDiskSettings},
+				SpotVMOptions:          tc.spotVMOptions,
+			}
+			errs := ValidateHibernation(spec, field.NewPath("additionalCapabilities", "hibernationEnabled"))
+			g.Expect(errs).To(HaveLen(tc.expectedErrors))
+			for _, err := range errs {
+				g.Expect(err.Field).To(Equal("additionalCapabilities.hibernationEnabled"))
+			}
+		})
+	}
+}
+
+func TestValidateHibernationCapability(t *testing.T) {
+	hibernation := &AdditionalCapabilities{HibernationEnabled: ptr.To(true)}
+	tests := []struct {
+		name                   string
+		additionalCapabilities *AdditionalCapabilities
+		diskSizeGB             *int32
+		capabilities           *VMSizeCapabilities
+		expectedFields         []string
+	}{
+		{
+			name:         "hibernation not enabled",
+			capabilities: &VMSizeCapabilities{Hibernation: false},
+		},
+		{
+			name:                   "hibernation enabled with a VM size supporting it",
+			additionalCapabilities: hibernation,
+			diskSizeGB:             ptr.To[int32](30),
+			capabilities:           &VMSizeCapabilities{Hibernation: true, MemoryGB: 16},
+		},
+		{
+			name:                   "hibernation enabled with VM size capabilities not known yet",
+			additionalCapabilities: hibernation,
+			diskSizeGB:             ptr.To[int32](8),
+		},
+		{
+			name:                   "hibernation enabled with a VM size not supporting it",
+			additionalCapabilities: hibernation,
+			capabilities:           &VMSizeCapabilities{Hibernation: false, MemoryGB: 16},
+			expectedFields:         []string{"vmSize"},
+		},
+		{
+			name:                   "hibernation enabled with an OS disk smaller than the memory",
+			additionalCapabilities: hibernation,
+			diskSizeGB:             ptr.To[int32](8),
+			capabilities:           &VMSizeCapabilities{Hibernation: true, MemoryGB: 16},
+			expectedFields:         []string{"osDisk.diskSizeGB"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := AzureMachineSpec{
+				VMSize:                 "Standard_D4s_v5",
+				AdditionalCapabilities: tc.additionalCapabilities,
+				OSDisk:                 OSDisk{DiskSizeGB: tc.diskSizeGB},
+			}
+			errs := ValidateHibernationCapability(spec, tc.capabilities)
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
+
+func TestValidateTrustedLaunchCapability(t *testing.T) {
+	trustedLaunch := &SecurityProfile{SecurityType: SecurityTypesTrustedLaunch}
+	tests := []struct {
+		name            string
+		securityProfile *SecurityProfile
+		capabilities    *VMSizeCapabilities
+		expectedFields  []string
+	}{
+		{
+			name:            "trusted launch not requested",
+			securityProfile: &SecurityProfile{SecurityType: SecurityTypesConfidentialVM},
+			capabilities:    &VMSizeCapabilities{TrustedLaunch: false},
+		},
+		{
+			name:            "trusted launch with a Generation 2 VM size",
+			securityProfile: trustedLaunch,
+			capabilities:    &VMSizeCapabilities{TrustedLaunch: true},
+		},
+		{
+			name:            "trusted launch with VM size capabilities not known yet",
+			securityProfile: trustedLaunch,
+		},
+		{
+			name:            "trusted launch with a VM size not supporting it",
+			securityProfile: trustedLaunch,
+			capabilities:    &VMSizeCapabilities{TrustedLaunch: false},
+			expectedFields:  []string{"securityProfile.securityType"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := AzureMachineSpec{
+				VMSize:          "Standard_D2_v3",
+				SecurityProfile: tc.securityProfile,
+			}
+			errs := ValidateTrustedLaunchCapability(spec, tc.capabilities)
+			fields := make([]string, 0, len(errs))
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(ConsistOf(tc.expectedFields))
+		})
+	}
+}
+
+func TestValidateConfidentialVMCapability(t *testing.T) {
+	confidentialVM := &SecurityProfile{SecurityType: SecurityTypesConfidentialVM}
+	tests := []struct {
+		name            string
+		securityProfile *SecurityProfile
+		capabilities    *VMSizeCapabilities
+		expectedFields  []string
+	}{
+		{
+			name:            "confidential VM not requested",
+			securityProfile: &SecurityProfile{SecurityType: SecurityTypesTrustedLaunch},
+			capabilities:    &VMSizeCapabilities{ConfidentialVM: false},
+		},
+		{
+			name:            "confidential VM with a confidential VM size",
+			securityProfile: confidentialVM,
+			capabilities:    &VMSizeCapabilities{ConfidentialVM: true},
+		},
+		{
+			name:            "confidential VM with VM size capabilities not known yet",
+			securityProfile: confidentialVM,
+		},
+		{
+			name:            "confidential VM with a VM size not supporting it",
+			securityProfile: confidentialVM,
+			capabilities:    &VMSizeCapabilities{ConfidentialVM: false},
+			expectedFields:  []string{"securityProfile.securityType"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			spec := AzureMachineSpec{
+				VMSize:          "Standard_D2s_v5",
+				SecurityProfile: tc.securityProfile,
+			}
+			errs := ValidateConfidentialVMCapability(spec, tc.capabilities)
+			fields := make([]string, 0, len(errs))
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(ConsistOf(tc.expectedFields))
+		})
+	}
+}
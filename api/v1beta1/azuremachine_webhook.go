@@ -18,20 +18,78 @@ package v1beta1
 
 import (
 	"context"
+	"fmt"
 	"reflect"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
 	webhookutils "sigs.k8s.io/cluster-api-provider-azure/util/webhook"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+// vmSizeCapabilitiesTimeout bounds the lookup of the capabilities of the VM size of a machine, well below the 10s
+// timeout of the API server for the webhook, so that a slow lookup is reported as a warning rather than failing the
+// admission of the machine.
+const vmSizeCapabilitiesTimeout = 5 * time.Second
+
+// VMSizeCapabilities are the capabilities of a VM size validated by the AzureMachine webhook.
+// +kubebuilder:object:generate=false
+type VMSizeCapabilities struct {
+	// PremiumIO is true if the VM size supports premium storage.
+	PremiumIO bool
+	// UltraSSDAvailable is true if the VM size supports UltraSSD disks in the location and zone of the machine.
+	UltraSSDAvailable bool
+	// EncryptionAtHost is true if the VM size supports encryption at host.
+	EncryptionAtHost bool
+	// EncryptionAtHostVMSizes are VM sizes available in the location of the machine that support encryption at host.
+	// They are suggested when the VM size of a machine requesting encryption at host doesn't support it.
+	EncryptionAtHostVMSizes []string
+	// LocalNVMeDisks is true if the VM size has local NVMe disks.
+	LocalNVMeDisks bool
+	// TrustedLaunch is true if the VM size is a Generation 2 VM size supporting trusted launch.
+	TrustedLaunch bool
+	// ConfidentialVM is true if the VM size supports confidential VMs.
+	ConfidentialVM bool
+	// Location is the location of the machine.
+	Location string
+	// MaximumPlatformFaultDomainCount is the maximum fault domain count of an availability set in the location of the
+	// machine. It is zero if it is unknown.
+	MaximumPlatformFaultDomainCount int32
+	// SubnetCIDRBlocks are the address ranges of the subnets of the cluster of the machine, by subnet name.
+	SubnetCIDRBlocks map[string][]string
+	// SecondaryLocation is the secondary location of the cluster of the machine, which the machine must be able to fail
+	// over to. It is empty if the cluster has no secondary location.
+	SecondaryLocation string
+	// SecondaryLocationVMSizeAvailable is true if the VM size is available in the secondary location.
+	SecondaryLocationVMSizeAvailable bool
+	// SecondaryLocationZones are the availability zones in which the VM size is available in the secondary location.
+	SecondaryLocationZones []string
+	// ExtendedLocationVMSizeAvailable is true if the VM size is available in the extended location of the machine, e.g.
+	// an edge zone. It is only looked up for a machine with an extended location.
+	ExtendedLocationVMSizeAvailable bool
+	// Hibernation is true if the VM size supports hibernation.
+	Hibernation bool
+	// MemoryGB is the memory of the VM size in GB. It is zero if it is unknown.
+	MemoryGB float64
+}
+
+// VMSizeCapabilitiesGetter gets the capabilities of the VM size of an AzureMachine.
+// +kubebuilder:object:generate=false
+type VMSizeCapabilitiesGetter interface {
+	// GetVMSizeCapabilities returns the capabilities of the VM size of the machine, or nil if they can't be known yet,
+	// e.g. before the AzureCluster of the machine is created.
+	GetVMSizeCapabilities(ctx context.Context, machine *AzureMachine) (*VMSizeCapabilities, error)
+}
+
 // SetupAzureMachineWebhookWithManager sets up and registers the webhook with the manager.
-func SetupAzureMachineWebhookWithManager(mgr ctrl.Manager) error {
-	mw := &azureMachineWebhook{Client: mgr.GetClient()}
+// New machines are validated against the capabilities of their VM size if a VMSizeCapabilitiesGetter is provided.
+func SetupAzureMachineWebhookWithManager(mgr ctrl.Manager, capabilitiesGetter VMSizeCapabilitiesGetter) error {
+	mw := &azureMachineWebhook{Client: mgr.GetClient(), capabilitiesGetter: capabilitiesGetter}
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(&AzureMachine{}).
 		WithDefaulter(mw).
@@ -44,7 +102,8 @@ func SetupAzureMachineWebhookWithManager(mgr ctrl.Manager) error {
 
 // azureMachineWebhook implements a validating and defaulting webhook for AzureMachines.
 type azureMachineWebhook struct {
-	Client client.Client
+	Client             client.Client
+	capabilitiesGetter VMSizeCapabilitiesGetter
 }
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
@@ -66,11 +125,33 @@ func (mw *azureMachineWebhook) ValidateCreate(ctx context.Context, obj runtime.O
 		allErrs = append(allErrs, errs...)
 	}
 
+	var warnings admission.Warnings
+	var capabilities *VMSizeCapabilities
+	if mw.capabilitiesGetter != nil {
+		capabilitiesCtx, cancel := context.WithTimeout(ctx, vmSizeCapabilitiesTimeout)
+		defer cancel()
+		var err error
+		capabilities, err = mw.capabilitiesGetter.GetVMSizeCapabilities(capabilitiesCtx, m)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("the machine was not validated against the capabilities of VM size %s: %v", spec.VMSize, err))
+		}
+	}
+	allErrs = append(allErrs, ValidateStorageAccountTypeCapabilities(spec, capabilities)...)
+	allErrs = append(allErrs, ValidateEncryptionAtHostCapability(spec, capabilities)...)
+	allErrs = append(allErrs, ValidateTrustedLaunchCapability(spec, capabilities)...)
+	allErrs = append(allErrs, ValidateConfidentialVMCapability(spec, capabilities)...)
+	allErrs = append(allErrs, ValidateLocalNVMeStorageCapability(spec, capabilities)...)
+	allErrs = append(allErrs, ValidatePlatformFaultDomainCountCapability(spec, capabilities)...)
+	allErrs = append(allErrs, ValidateSecondaryIPConfigsCapability(spec, capabilities)...)
+	allErrs = append(allErrs, ValidateSecondaryLocationCapability(spec, capabilities)...)
+	allErrs = append(allErrs, ValidateExtendedLocationCapability(spec, capabilities)...)
+	allErrs = append(allErrs, ValidateHibernationCapability(spec, capabilities)...)
+
 	if len(allErrs) == 0 {
-		return nil, nil
+		return warnings, nil
 	}
 
-	return nil, apierrors.NewInvalid(GroupVersion.WithKind("AzureMachine").GroupKind(), m.Name, allErrs)
+	return warnings, apierrors.NewInvalid(GroupVersion.WithKind("AzureMachine").GroupKind(), m.Name, allErrs)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
@@ -85,6 +166,13 @@ func (mw *azureMachineWebhook) ValidateUpdate(ctx context.Context, oldObj, newOb
 		return nil, apierrors.NewBadRequest("expected an AzureMachine resource")
 	}
 
+	// Azure can't change the VM size of every VM in place, and CAPZ doesn't resize existing VMs, so a machine with a
+	// different VM size must be created instead.
+	if old.Spec.VMSize != "" && m.Spec.VMSize != old.Spec.VMSize {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("Spec", "VMSize"), m.Spec.VMSize,
+			fmt.Sprintf("field is immutable, changing the VM size from %s requires recreating the machine: use a new AzureMachineTemplate with the new VM size and roll out the MachineDeployment or KubeadmControlPlane instead", old.Spec.VMSize)))
+	}
+
 	if err := webhookutils.ValidateImmutable(
 		field.NewPath("Spec", "Image"),
 		old.Spec.Image,
@@ -120,18 +208,35 @@ func (mw *azureMachineWebhook) ValidateUpdate(ctx context.Context, oldObj, newOb
 		allErrs = append(allErrs, err)
 	}
 
+	// The OS disk can grow in place but no other field of the OS disk can change.
+	oldOSDisk := old.Spec.OSDisk.DeepCopy()
+	oldOSDisk.DiskSizeGB = m.Spec.OSDisk.DiskSizeGB
 	if err := webhookutils.ValidateImmutable(
 		field.NewPath("Spec", "OSDisk"),
-		old.Spec.OSDisk,
+		*oldOSDisk,
 		m.Spec.OSDisk); err != nil {
 		allErrs = append(allErrs, err)
 	}
 
-	if err := webhookutils.ValidateImmutable(
-		field.NewPath("Spec", "DataDisks"),
-		old.Spec.DataDisks,
-		m.Spec.DataDisks); err != nil {
-		allErrs = append(allErrs, err)
+	if oldSize, newSize := ptr.Deref(old.Spec.OSDisk.DiskSizeGB, 0), ptr.Deref(m.Spec.OSDisk.DiskSizeGB, 0); newSize != oldSize {
+		switch {
+		case oldSize != 0 && newSize < oldSize:
+			allErrs = append(allErrs,
+				field.Invalid(field.NewPath("Spec", "OSDisk", "DiskSizeGB"), m.Spec.OSDisk.DiskSizeGB,
+					fmt.Sprintf("the OS disk can't shrink below %d GB: Azure managed disks can only grow", oldSize)),
+			)
+		case m.Spec.OSDisk.DiffDiskSettings != nil:
+			allErrs = append(allErrs,
+				field.Invalid(field.NewPath("Spec", "OSDisk", "DiskSizeGB"), m.Spec.OSDisk.DiskSizeGB,
+					"the size of an ephemeral OS disk can't change"),
+			)
+		}
+	}
+
+	// Data disks can be attached to an existing machine but the data disks already attached can't change.
+	if !reflect.DeepEqual(m.Spec.DataDisks, old.Spec.DataDisks) {
+		allErrs = append(allErrs, ValidateDataDisksAddition(old.Spec.DataDisks, m.Spec.DataDisks, field.NewPath("Spec", "DataDisks"))...)
+		allErrs = append(allErrs, ValidateDataDisks(m.Spec.DataDisks, field.NewPath("Spec", "DataDisks"))...)
 	}
 
 	if err := webhookutils.ValidateImmutable(
@@ -141,6 +246,13 @@ func (mw *azureMachineWebhook) ValidateUpdate(ctx context.Context, oldObj, newOb
 		allErrs = append(allErrs, err)
 	}
 
+	if err := webhookutils.ValidateImmutable(
+		field.NewPath("Spec", "SSHPublicKeySecretRef"),
+		old.Spec.SSHPublicKeySecretRef,
+		m.Spec.SSHPublicKeySecretRef); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
 	if err := webhookutils.ValidateImmutable(
 		field.NewPath("Spec", "AllocatePublicIP"),
 		old.Spec.AllocatePublicIP,
@@ -179,7 +291,66 @@ func (mw *azureMachineWebhook) ValidateUpdate(ctx context.Context, oldObj, newOb
 		allErrs = append(allErrs, err)
 	}
 
-	if old.Spec.Diagnostics != nil {
+	if err := webhookutils.ValidateImmutable(
+		field.NewPath("Spec", "LocalNVMeStorage"),
+		old.Spec.LocalNVMeStorage,
+		m.Spec.LocalNVMeStorage); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	if err := webhookutils.ValidateImmutable(
+		field.NewPath("Spec", "DataDiskArray"),
+		old.Spec.DataDiskArray,
+		m.Spec.DataDiskArray); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	if err := webhookutils.ValidateImmutable(
+		field.NewPath("Spec", "BootstrapCompletionCheck"),
+		old.Spec.BootstrapCompletionCheck,
+		m.Spec.BootstrapCompletionCheck); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	if err := webhookutils.ValidateImmutable(
+		field.NewPath("Spec", "AvailabilitySet"),
+		old.Spec.AvailabilitySet,
+		m.Spec.AvailabilitySet); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	if err := webhookutils.ValidateImmutable(
+		field.NewPath("Spec", "PlatformFaultDomainCount"),
+		old.Spec.PlatformFaultDomainCount,
+		m.Spec.PlatformFaultDomainCount); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	if err := webhookutils.ValidateImmutable(
+		field.NewPath("Spec", "VirtualMachineScaleSet"),
+		old.Spec.VirtualMachineScaleSet,
+		m.Spec.VirtualMachineScaleSet); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	if err := webhookutils.ValidateImmutable(
+		field.NewPath("Spec", "ExtendedLocation"),
+		old.Spec.ExtendedLocation,
+		m.Spec.ExtendedLocation); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	if err := webhookutils.ValidateImmutable(
+		field.NewPath("Spec", "RunCommands"),
+		old.Spec.RunCommands,
+		m.Spec.RunCommands); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	// The storage account URI of user-managed boot diagnostics can be changed to rotate the storage account.
+	if isUserManagedBootDiagnostics(old.Spec.Diagnostics) && isUserManagedBootDiagnostics(m.Spec.Diagnostics) {
+		allErrs = append(allErrs, ValidateDiagnostics(m.Spec.Diagnostics, field.NewPath("Spec", "Diagnostics"))...)
+	} else if old.Spec.Diagnostics != nil {
 		if err := webhookutils.ValidateImmutable(
 			field.NewPath("Spec", "Diagnostics"),
 			old.Spec.Diagnostics,
@@ -188,17 +359,37 @@ func (mw *azureMachineWebhook) ValidateUpdate(ctx context.Context, oldObj, newOb
 		}
 	}
 
+	// The start/stop schedule is a tag of the VM updated in place.
+	if !reflect.DeepEqual(m.Spec.StartStopSchedule, old.Spec.StartStopSchedule) {
+		allErrs = append(allErrs, ValidateStartStopSchedule(m.Spec.StartStopSchedule, field.NewPath("Spec", "StartStopSchedule"))...)
+	}
+
+	// The node labels are patched on the node on each reconciliation.
+	if !reflect.DeepEqual(m.Spec.NodeLabelTags, old.Spec.NodeLabelTags) {
+		allErrs = append(allErrs, ValidateNodeLabelTags(m.Spec.NodeLabelTags, field.NewPath("Spec", "NodeLabelTags"))...)
+	}
+
 	if !reflect.DeepEqual(m.Spec.NetworkInterfaces, old.Spec.NetworkInterfaces) {
+		// Compare a copy of the old spec so that the old object isn't changed.
+		oldSpec := old.Spec.DeepCopy()
+
 		// The defaulting webhook may have migrated values from the old SubnetName field to the new NetworkInterfaces format.
-		old.Spec.SetNetworkInterfacesDefaults()
+		oldSpec.SetNetworkInterfacesDefaults()
 
 		// The reconciler will populate the SubnetName on the first interface if the user left it blank.
-		if old.Spec.NetworkInterfaces[0].SubnetName == "" && m.Spec.NetworkInterfaces[0].SubnetName != "" {
-			old.Spec.NetworkInterfaces[0].SubnetName = m.Spec.NetworkInterfaces[0].SubnetName
+		if oldSpec.NetworkInterfaces[0].SubnetName == "" && m.Spec.NetworkInterfaces[0].SubnetName != "" {
+			oldSpec.NetworkInterfaces[0].SubnetName = m.Spec.NetworkInterfaces[0].SubnetName
+		}
+
+		// The virtual network taps of the interfaces are reconciled in place.
+		if len(oldSpec.NetworkInterfaces) == len(m.Spec.NetworkInterfaces) {
+			for i := range oldSpec.NetworkInterfaces {
+				oldSpec.NetworkInterfaces[i].VirtualNetworkTap = m.Spec.NetworkInterfaces[i].VirtualNetworkTap
+			}
 		}
 
 		// Enforce immutability for all other changes to NetworkInterfaces.
-		if !reflect.DeepEqual(m.Spec.NetworkInterfaces, old.Spec.NetworkInterfaces) {
+		if !reflect.DeepEqual(m.Spec.NetworkInterfaces, oldSpec.NetworkInterfaces) {
 			allErrs = append(allErrs,
 				field.Invalid(field.NewPath("spec", "networkInterfaces"),
 					m.Spec.NetworkInterfaces, "field is immutable"),
@@ -212,6 +403,11 @@ func (mw *azureMachineWebhook) ValidateUpdate(ctx context.Context, oldObj, newOb
 	return nil, apierrors.NewInvalid(GroupVersion.WithKind("AzureMachine").GroupKind(), m.Name, allErrs)
 }
 
+// isUserManagedBootDiagnostics returns true if the boot diagnostics are stored in a user-managed storage account.
+func isUserManagedBootDiagnostics(diagnostics *Diagnostics) bool {
+	return diagnostics != nil && diagnostics.Boot != nil && diagnostics.Boot.StorageAccountType == UserManagedDiagnosticsStorage
+}
+
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
 func (mw *azureMachineWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
 	return nil, nil
@@ -19,6 +19,7 @@ package v1beta1
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-12-01/compute"
 	. "github.com/onsi/gomega"
@@ -74,6 +75,16 @@ func TestAzureMachine_ValidateCreate(t *testing.T) {
 			machine: createMachineWithImageByID(""),
 			wantErr: true,
 		},
+		{
+			name:    "azuremachine with fault domain count",
+			machine: createMachineWithAvailabilitySet(nil, ptr.To[int32](2)),
+			wantErr: false,
+		},
+		{
+			name:    "azuremachine with fault domain count of an existing availability set",
+			machine: createMachineWithAvailabilitySet(&AvailabilitySetReference{Name: "existing-as"}, ptr.To[int32](2)),
+			wantErr: true,
+		},
 		{
 			name:    "azuremachine with valid SSHPublicKey",
 			machine: createMachineWithSSHPublicKey(validSSHPublicKey),
@@ -117,11 +128,21 @@ func TestAzureMachine_ValidateCreate(t *testing.T) {
 			machine: createMachineWithDiagnostics(ManagedDiagnosticsStorage, nil),
 			wantErr: false,
 		},
+		{
+			name:    "azuremachine with managed diagnostics profile and a storage account URI",
+			machine: createMachineWithDiagnostics(ManagedDiagnosticsStorage, &UserManagedBootDiagnostics{StorageAccountURI: "https://fakeurl"}),
+			wantErr: true,
+		},
 		{
 			name:    "azuremachine with disabled diagnostics profile",
-			machine: createMachineWithDiagnostics(ManagedDiagnosticsStorage, nil),
+			machine: createMachineWithDiagnostics(DisabledDiagnosticsStorage, nil),
 			wantErr: false,
 		},
+		{
+			name:    "azuremachine with disabled diagnostics profile and a storage account URI",
+			machine: createMachineWithDiagnostics(DisabledDiagnosticsStorage, &UserManagedBootDiagnostics{StorageAccountURI: "https://fakeurl"}),
+			wantErr: true,
+		},
 		{
 			name:    "azuremachine with user managed diagnostics profile and defined user managed storage account",
 			machine: createMachineWithDiagnostics(UserManagedDiagnosticsStorage, &UserManagedBootDiagnostics{StorageAccountURI: "https://fakeurl"}),
@@ -137,6 +158,16 @@ func TestAzureMachine_ValidateCreate(t *testing.T) {
 			machine: createMachineWithDiagnostics(UserManagedDiagnosticsStorage, nil),
 			wantErr: true,
 		},
+		{
+			name:    "azuremachine with user managed diagnostics profile, but empty storage account URI",
+			machine: createMachineWithDiagnostics(UserManagedDiagnosticsStorage, &UserManagedBootDiagnostics{}),
+			wantErr: true,
+		},
+		{
+			name:    "azuremachine with user managed diagnostics profile, but invalid storage account URI",
+			machine: createMachineWithDiagnostics(UserManagedDiagnosticsStorage, &UserManagedBootDiagnostics{StorageAccountURI: "fakeurl"}),
+			wantErr: true,
+		},
 		{
 			name:    "azuremachine with invalid network configuration",
 			machine: createMachineWithNetworkConfig("subnet", nil, []NetworkInterface{{SubnetName: "subnet1"}}),
@@ -231,15 +262,327 @@ func TestAzureMachine_ValidateCreate(t *testing.T) {
 	}
 }
 
+type fakeVMSizeCapabilitiesGetter struct {
+	capabilities *VMSizeCapabilities
+	err          error
+}
+
+func (f fakeVMSizeCapabilitiesGetter) GetVMSizeCapabilities(_ context.Context, _ *AzureMachine) (*VMSizeCapabilities, error) {
+	return f.capabilities, f.err
+}
+
+// vmSizeCapabilitiesGetterFunc is a VMSizeCapabilitiesGetter function.
+type vmSizeCapabilitiesGetterFunc func(ctx context.Context, machine *AzureMachine) (*VMSizeCapabilities, error)
+
+func (f vmSizeCapabilitiesGetterFunc) GetVMSizeCapabilities(ctx context.Context, machine *AzureMachine) (*VMSizeCapabilities, error) {
+	return f(ctx, machine)
+}
+
+func TestAzureMachine_ValidateCreateVMSizeCapabilitiesTimeout(t *testing.T) {
+	g := NewWithT(t)
+	machine := &AzureMachine{
+		Spec: AzureMachineSpec{
+			VMSize:       "Standard_A2_v2",
+			SSHPublicKey: validSSHPublicKey,
+			OSDisk:       generateValidOSDisk(),
+		},
+	}
+	var deadline time.Time
+	var hasDeadline bool
+	getter := vmSizeCapabilitiesGetterFunc(func(ctx context.Context, _ *AzureMachine) (*VMSizeCapabilities, error) {
+		deadline, hasDeadline = ctx.Deadline()
+		return nil, context.DeadlineExceeded
+	})
+	mw := &azureMachineWebhook{capabilitiesGetter: getter}
+	warnings, err := mw.ValidateCreate(context.Background(), machine)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(warnings).To(HaveLen(1))
+	g.Expect(hasDeadline).To(BeTrue())
+	g.Expect(time.Until(deadline)).To(BeNumerically("<=", vmSizeCapabilitiesTimeout))
+}
+
+func TestAzureMachine_ValidateCreateStorageAccountTypeCapabilities(t *testing.T) {
+	tests := []struct {
+		name             string
+		getter           VMSizeCapabilitiesGetter
+		wantErr          string
+		expectedWarnings int
+	}{
+		{
+			name:   "premium storage with a VM size supporting premium storage",
+			getter: fakeVMSizeCapabilitiesGetter{capabilities: &VMSizeCapabilities{PremiumIO: true}},
+		},
+		{
+			name:    "premium storage with a VM size not supporting premium storage",
+			getter:  fakeVMSizeCapabilitiesGetter{capabilities: &VMSizeCapabilities{PremiumIO: false}},
+			wantErr: "VM size Standard_A2_v2 does not support premium storage",
+		},
+		{
+			name:   "VM size capabilities not known yet",
+			getter: fakeVMSizeCapabilitiesGetter{},
+		},
+		{
+			name:             "failure to get the VM size capabilities is a warning",
+			getter:           fakeVMSizeCapabilitiesGetter{err: errors.New("failed to get VM size Standard_A2_v2")},
+			expectedWarnings: 1,
+		},
+		{
+			name: "no VM size capabilities getter",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			machine := &AzureMachine{
+				Spec: AzureMachineSpec{
+					VMSize:       "Standard_A2_v2",
+					SSHPublicKey: validSSHPublicKey,
+					OSDisk:       generateValidOSDisk(),
+				},
+			}
+			mw := &azureMachineWebhook{capabilitiesGetter: tc.getter}
+			warnings, err := mw.ValidateCreate(context.Background(), machine)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(warnings).To(HaveLen(tc.expectedWarnings))
+		})
+	}
+}
+
+func TestAzureMachine_ValidateCreateEncryptionAtHostCapability(t *testing.T) {
+	g := NewWithT(t)
+	machine := &AzureMachine{
+		Spec: AzureMachineSpec{
+			VMSize:          "Standard_A2_v2",
+			SSHPublicKey:    validSSHPublicKey,
+			OSDisk:          generateValidOSDisk(),
+			SecurityProfile: &SecurityProfile{EncryptionAtHost: ptr.To(true)},
+		},
+	}
+	mw := &azureMachineWebhook{capabilitiesGetter: fakeVMSizeCapabilitiesGetter{capabilities: &VMSizeCapabilities{
+		PremiumIO:               true,
+		EncryptionAtHost:        false,
+		EncryptionAtHostVMSizes: []string{"Standard_D2s_v3", "Standard_E2s_v3"},
+	}}}
+	_, err := mw.ValidateCreate(context.Background(), machine)
+	g.Expect(err).To(MatchError(ContainSubstring("VM size Standard_A2_v2 does not support encryption at host, use a VM size that supports it, e.g. Standard_D2s_v3, Standard_E2s_v3")))
+}
+
+func TestAzureMachine_ValidateCreateTrustedLaunchCapability(t *testing.T) {
+	tests := []struct {
+		name         string
+		capabilities *VMSizeCapabilities
+		wantErr      string
+	}{
+		{
+			name:         "Generation 2 VM size",
+			capabilities: &VMSizeCapabilities{PremiumIO: true, TrustedLaunch: true},
+		},
+		{
+			name:         "VM size with trusted launch disabled",
+			capabilities: &VMSizeCapabilities{PremiumIO: true, TrustedLaunch: false},
+			wantErr:      "VM size Standard_D2s_v3 does not support trusted launch",
+		},
+		{
+			name: "capabilities not known yet",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			machine := &AzureMachine{
+				Spec: AzureMachineSpec{
+					VMSize:       "Standard_D2s_v3",
+					SSHPublicKey: validSSHPublicKey,
+					OSDisk:       generateValidOSDisk(),
+					SecurityProfile: &SecurityProfile{
+						SecurityType: SecurityTypesTrustedLaunch,
+						UefiSettings: &UefiSettings{SecureBootEnabled: ptr.To(true), VTpmEnabled: ptr.To(true)},
+					},
+				},
+			}
+			mw := &azureMachineWebhook{capabilitiesGetter: fakeVMSizeCapabilitiesGetter{capabilities: tc.capabilities}}
+			_, err := mw.ValidateCreate(context.Background(), machine)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestAzureMachine_ValidateCreateEncryptionAtHostWithDiskEncryptionSet(t *testing.T) {
+	diskEncryptionSetID := "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/diskEncryptionSets/my-des"
+	tests := []struct {
+		name         string
+		capabilities *VMSizeCapabilities
+		wantErr      string
+	}{
+		{
+			name:         "disk encryption set with a VM size supporting encryption at host",
+			capabilities: &VMSizeCapabilities{PremiumIO: true, EncryptionAtHost: true, Location: "westus"},
+		},
+		{
+			name: "disk encryption set with a VM size not supporting encryption at host",
+			capabilities: &VMSizeCapabilities{PremiumIO: true, EncryptionAtHost: false, Location: "westus",
+				EncryptionAtHostVMSizes: []string{"Standard_D4s_v3"}},
+			wantErr: "VM size Standard_D2s_v3 does not support encryption at host, use a VM size that supports it, e.g. Standard_D4s_v3",
+		},
+		{
+			name: "capabilities not known yet",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			osDisk := generateValidOSDisk()
+			osDisk.ManagedDisk.DiskEncryptionSet = &DiskEncryptionSetParameters{ID: diskEncryptionSetID}
+			machine := &AzureMachine{
+				Spec: AzureMachineSpec{
+					VMSize:          "Standard_D2s_v3",
+					SSHPublicKey:    validSSHPublicKey,
+					OSDisk:          osDisk,
+					SecurityProfile: &SecurityProfile{EncryptionAtHost: ptr.To(true)},
+					DataDisks: []DataDisk{
+						{
+							NameSuffix:  "data",
+							DiskSizeGB:  128,
+							Lun:         ptr.To[int32](0),
+							CachingType: "ReadWrite",
+							ManagedDisk: &ManagedDiskParameters{
+								StorageAccountType: "Premium_LRS",
+								DiskEncryptionSet:  &DiskEncryptionSetParameters{ID: diskEncryptionSetID},
+							},
+						},
+					},
+				},
+			}
+			mw := &azureMachineWebhook{capabilitiesGetter: fakeVMSizeCapabilitiesGetter{capabilities: tc.capabilities}}
+			_, err := mw.ValidateCreate(context.Background(), machine)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestAzureMachine_ValidateCreateSecondaryLocation(t *testing.T) {
+	tests := []struct {
+		name         string
+		capabilities *VMSizeCapabilities
+		wantErr      string
+	}{
+		{
+			name:         "VM size and zone available in the primary and secondary locations",
+			capabilities: &VMSizeCapabilities{PremiumIO: true, Location: "westus", SecondaryLocation: "eastus", SecondaryLocationVMSizeAvailable: true, SecondaryLocationZones: []string{"1", "2", "3"}},
+		},
+		{
+			name:         "VM size available in the primary location but not in the secondary location",
+			capabilities: &VMSizeCapabilities{PremiumIO: true, Location: "westus", SecondaryLocation: "eastus"},
+			wantErr:      "VM size is not available in secondary location eastus of the cluster",
+		},
+		{
+			name:         "zone not available in the secondary location",
+			capabilities: &VMSizeCapabilities{PremiumIO: true, Location: "westus", SecondaryLocation: "eastus", SecondaryLocationVMSizeAvailable: true, SecondaryLocationZones: []string{"2", "3"}},
+			wantErr:      "VM size Standard_D2s_v3 is not available in zone 1 of secondary location eastus of the cluster",
+		},
+		{
+			name:         "cluster without secondary location",
+			capabilities: &VMSizeCapabilities{PremiumIO: true, Location: "westus"},
+		},
+		{
+			name: "capabilities not known yet",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			machine := &AzureMachine{
+				Spec: AzureMachineSpec{
+					VMSize:        "Standard_D2s_v3",
+					FailureDomain: ptr.To("1"),
+					SSHPublicKey:  validSSHPublicKey,
+					OSDisk:        generateValidOSDisk(),
+				},
+			}
+			mw := &azureMachineWebhook{capabilitiesGetter: fakeVMSizeCapabilitiesGetter{capabilities: tc.capabilities}}
+			_, err := mw.ValidateCreate(context.Background(), machine)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
 func TestAzureMachine_ValidateUpdate(t *testing.T) {
 	g := NewWithT(t)
 
 	tests := []struct {
-		name       string
-		oldMachine *AzureMachine
-		newMachine *AzureMachine
-		wantErr    bool
+		name              string
+		oldMachine        *AzureMachine
+		newMachine        *AzureMachine
+		wantErr           bool
+		wantErrSubstrings []string
 	}{
+		{
+			name: "validTest: azuremachine.spec.VMSize is unchanged",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					VMSize: "Standard_D2s_v3",
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					VMSize: "Standard_D2s_v3",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalidTest: azuremachine.spec.VMSize is immutable",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					VMSize: "Standard_D2s_v3",
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					VMSize: "Standard_D4s_v3",
+				},
+			},
+			wantErr: true,
+			wantErrSubstrings: []string{
+				"Spec.VMSize",
+				"changing the VM size from Standard_D2s_v3 requires recreating the machine",
+				"roll out the MachineDeployment or KubeadmControlPlane",
+			},
+		},
+		{
+			name: "invalidTest: azuremachine.spec.extendedLocation is immutable",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					ExtendedLocation: &ExtendedLocationSpec{Name: "microsoftvancouver1", Type: "EdgeZone"},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					ExtendedLocation: &ExtendedLocationSpec{Name: "microsoftlosangeles1", Type: "EdgeZone"},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalidTest: azuremachine.spec.image is immutable",
 			oldMachine: &AzureMachine{
@@ -448,6 +791,68 @@ func TestAzureMachine_ValidateUpdate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "validTest: azuremachine.spec.OSDisk.DiskSizeGB can grow",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					OSDisk: OSDisk{
+						OSType:     "osType-1",
+						DiskSizeGB: ptr.To[int32](30),
+					},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					OSDisk: OSDisk{
+						OSType:     "osType-1",
+						DiskSizeGB: ptr.To[int32](128),
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalidTest: azuremachine.spec.OSDisk.DiskSizeGB can't shrink",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					OSDisk: OSDisk{
+						OSType:     "osType-1",
+						DiskSizeGB: ptr.To[int32](128),
+					},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					OSDisk: OSDisk{
+						OSType:     "osType-1",
+						DiskSizeGB: ptr.To[int32](30),
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalidTest: azuremachine.spec.OSDisk.DiskSizeGB of an ephemeral OS disk can't grow",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					OSDisk: OSDisk{
+						OSType:           "osType-1",
+						DiskSizeGB:       ptr.To[int32](30),
+						DiffDiskSettings: &DiffDiskSettings{Option: "Local"},
+					},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					OSDisk: OSDisk{
+						OSType:           "osType-1",
+						DiskSizeGB:       ptr.To[int32](128),
+						DiffDiskSettings: &DiffDiskSettings{Option: "Local"},
+					},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalidTest: azuremachine.spec.DataDisks is immutable",
 			oldMachine: &AzureMachine{
@@ -492,6 +897,81 @@ func TestAzureMachine_ValidateUpdate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "validTest: azuremachine.spec.DataDisks can be added",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					DataDisks: []DataDisk{
+						{NameSuffix: "etcddisk", DiskSizeGB: 128, Lun: ptr.To[int32](0), CachingType: "ReadWrite"},
+					},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					DataDisks: []DataDisk{
+						{NameSuffix: "etcddisk", DiskSizeGB: 128, Lun: ptr.To[int32](0), CachingType: "ReadWrite"},
+						{NameSuffix: "datadisk", DiskSizeGB: 256, Lun: ptr.To[int32](1), CachingType: "ReadWrite"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalidTest: added azuremachine.spec.DataDisks can't reuse the LUN of an attached data disk",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					DataDisks: []DataDisk{
+						{NameSuffix: "etcddisk", DiskSizeGB: 128, Lun: ptr.To[int32](0), CachingType: "ReadWrite"},
+					},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					DataDisks: []DataDisk{
+						{NameSuffix: "etcddisk", DiskSizeGB: 128, Lun: ptr.To[int32](0), CachingType: "ReadWrite"},
+						{NameSuffix: "datadisk", DiskSizeGB: 256, Lun: ptr.To[int32](0), CachingType: "ReadWrite"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalidTest: the LUN of azuremachine.spec.DataDisks is immutable",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					DataDisks: []DataDisk{
+						{NameSuffix: "etcddisk", DiskSizeGB: 128, Lun: ptr.To[int32](0), CachingType: "ReadWrite"},
+					},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					DataDisks: []DataDisk{
+						{NameSuffix: "etcddisk", DiskSizeGB: 128, Lun: ptr.To[int32](1), CachingType: "ReadWrite"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalidTest: azuremachine.spec.DataDisks can't be removed",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					DataDisks: []DataDisk{
+						{NameSuffix: "etcddisk", DiskSizeGB: 128, Lun: ptr.To[int32](0), CachingType: "ReadWrite"},
+						{NameSuffix: "datadisk", DiskSizeGB: 256, Lun: ptr.To[int32](1), CachingType: "ReadWrite"},
+					},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					DataDisks: []DataDisk{
+						{NameSuffix: "etcddisk", DiskSizeGB: 128, Lun: ptr.To[int32](0), CachingType: "ReadWrite"},
+					},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalidTest: azuremachine.spec.SSHPublicKey is immutable",
 			oldMachine: &AzureMachine{
@@ -696,6 +1176,67 @@ func TestAzureMachine_ValidateUpdate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "invalidTest: azuremachine.spec.AvailabilitySet is immutable",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					AvailabilitySet: &AvailabilitySetReference{Name: "as-1"},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					AvailabilitySet: &AvailabilitySetReference{Name: "as-2"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "validTest: azuremachine.spec.AvailabilitySet is immutable",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					AvailabilitySet: &AvailabilitySetReference{Name: "as-1"},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					AvailabilitySet: &AvailabilitySetReference{Name: "as-1"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalidTest: azuremachine.spec.PlatformFaultDomainCount is immutable",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					PlatformFaultDomainCount: ptr.To[int32](2),
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					PlatformFaultDomainCount: ptr.To[int32](3),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalidTest: azuremachine.spec.VirtualMachineScaleSet is immutable",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					VirtualMachineScaleSet: &VirtualMachineScaleSetReference{
+						ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachineScaleSets/my-vmss",
+					},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					VirtualMachineScaleSet: &VirtualMachineScaleSetReference{
+						ID:                  "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachineScaleSets/my-vmss",
+						PlatformFaultDomain: ptr.To[int32](1),
+					},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalidTest: azuremachine.spec.Diagnostics is immutable",
 			oldMachine: &AzureMachine{
@@ -766,6 +1307,63 @@ func TestAzureMachine_ValidateUpdate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "validTest: azuremachine.spec.Diagnostics user-managed storage account URI can be rotated",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					Diagnostics: &Diagnostics{Boot: &BootDiagnostics{
+						StorageAccountType: UserManagedDiagnosticsStorage,
+						UserManaged:        &UserManagedBootDiagnostics{StorageAccountURI: "https://oldstorage.blob.core.windows.net/"},
+					}},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					Diagnostics: &Diagnostics{Boot: &BootDiagnostics{
+						StorageAccountType: UserManagedDiagnosticsStorage,
+						UserManaged:        &UserManagedBootDiagnostics{StorageAccountURI: "https://newstorage.blob.core.windows.net/"},
+					}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalidTest: azuremachine.spec.Diagnostics user-managed storage account URI must be valid",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					Diagnostics: &Diagnostics{Boot: &BootDiagnostics{
+						StorageAccountType: UserManagedDiagnosticsStorage,
+						UserManaged:        &UserManagedBootDiagnostics{StorageAccountURI: "https://oldstorage.blob.core.windows.net/"},
+					}},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					Diagnostics: &Diagnostics{Boot: &BootDiagnostics{
+						StorageAccountType: UserManagedDiagnosticsStorage,
+						UserManaged:        &UserManagedBootDiagnostics{StorageAccountURI: "newstorage"},
+					}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalidTest: azuremachine.spec.Diagnostics user-managed storage account cannot be removed",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					Diagnostics: &Diagnostics{Boot: &BootDiagnostics{
+						StorageAccountType: UserManagedDiagnosticsStorage,
+						UserManaged:        &UserManagedBootDiagnostics{StorageAccountURI: "https://oldstorage.blob.core.windows.net/"},
+					}},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					Diagnostics: &Diagnostics{Boot: &BootDiagnostics{StorageAccountType: UserManagedDiagnosticsStorage}},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "validTest: azuremachine.spec.networkInterfaces is immutable",
 			oldMachine: &AzureMachine{
@@ -794,16 +1392,54 @@ func TestAzureMachine_ValidateUpdate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "validTest: azuremachine.spec.networkInterfaces virtualNetworkTap is mutable",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					NetworkInterfaces: []NetworkInterface{{SubnetName: "subnet", PrivateIPConfigs: 1}},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					NetworkInterfaces: []NetworkInterface{{
+						SubnetName:        "subnet",
+						PrivateIPConfigs:  1,
+						VirtualNetworkTap: &VirtualNetworkTapReference{ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/my-tap"},
+					}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalidtest: azuremachine.spec.runCommands is immutable",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					RunCommands: []RunCommand{{Name: "post-provision", Source: RunCommandSource{Script: "echo hello"}}},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					RunCommands: []RunCommand{{Name: "post-provision", Source: RunCommandSource{Script: "echo hi"}}},
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			mw := &azureMachineWebhook{}
+			oldMachine := tc.oldMachine.DeepCopy()
 			_, err := mw.ValidateUpdate(context.Background(), tc.oldMachine, tc.newMachine)
 			if tc.wantErr {
 				g.Expect(err).To(HaveOccurred())
+				for _, substring := range tc.wantErrSubstrings {
+					g.Expect(err).To(MatchError(ContainSubstring(substring)))
+				}
 			} else {
 				g.Expect(err).NotTo(HaveOccurred())
 			}
+			// The validation doesn't change the old machine.
+			g.Expect(tc.oldMachine).To(Equal(oldMachine))
 		})
 	}
 }
@@ -938,6 +1574,17 @@ func createMachineWithImageByID(imageID string) *AzureMachine {
 	}
 }
 
+func createMachineWithAvailabilitySet(availabilitySet *AvailabilitySetReference, faultDomainCount *int32) *AzureMachine {
+	return &AzureMachine{
+		Spec: AzureMachineSpec{
+			SSHPublicKey:             validSSHPublicKey,
+			OSDisk:                   validOSDisk,
+			AvailabilitySet:          availabilitySet,
+			PlatformFaultDomainCount: faultDomainCount,
+		},
+	}
+}
+
 func createMachineWithOsDiskCacheType(cacheType string) *AzureMachine {
 	machine := &AzureMachine{
 		Spec: AzureMachineSpec{
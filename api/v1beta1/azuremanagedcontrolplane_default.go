@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"golang.org/x/crypto/ssh"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/utils/ptr"
 	utilSSH "sigs.k8s.io/cluster-api-provider-azure/util/ssh"
 )
@@ -32,6 +33,9 @@ const (
 	defaultAKSNodeSubnetCIDR = "10.240.0.0/16"
 )
 
+// KeyVaultSecretsUserRoleID is the ID of the built-in "Key Vault Secrets User" role.
+const KeyVaultSecretsUserRoleID = "4633458b-17de-408a-b874-0445c86b69e6"
+
 // setDefaultSSHPublicKey sets the default SSHPublicKey for an AzureManagedControlPlane.
 func (m *AzureManagedControlPlane) setDefaultSSHPublicKey() error {
 	if sshKey := m.Spec.SSHPublicKey; sshKey != nil && *sshKey == "" {
@@ -53,6 +57,45 @@ func (m *AzureManagedControlPlane) setDefaultNodeResourceGroupName() {
 	}
 }
 
+// setDefaultNodeResourceGroupRoleAssignment sets the default name and role definition of the role assignment to the
+// user-assigned identity on the node resource group.
+func (m *AzureManagedControlPlane) setDefaultNodeResourceGroupRoleAssignment() {
+	if m.Spec.Identity == nil || m.Spec.Identity.NodeResourceGroupRoleAssignment == nil {
+		return
+	}
+	roleAssignment := m.Spec.Identity.NodeResourceGroupRoleAssignment
+	if roleAssignment.Name == "" {
+		roleAssignment.Name = string(uuid.NewUUID())
+	}
+	if roleAssignment.DefinitionID == "" {
+		roleAssignment.DefinitionID = builtInRoleDefinitionID(m.Spec.SubscriptionID, ContributorRoleID)
+	}
+}
+
+// builtInRoleDefinitionID returns the ID of a built-in role definition in a subscription. Built-in roles exist in every
+// subscription, so their tenant-level ID is used when the subscription of the cluster isn't set.
+func builtInRoleDefinitionID(subscriptionID, roleID string) string {
+	if subscriptionID == "" {
+		return fmt.Sprintf("/providers/Microsoft.Authorization/roleDefinitions/%s", roleID)
+	}
+	return fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s", subscriptionID, roleID)
+}
+
+// setDefaultKeyVaultRoleAssignment sets the default name and role definition of the role assignment to the
+// user-assigned identity of the Key Vault secrets provider on the Key Vault.
+func (m *AzureManagedControlPlane) setDefaultKeyVaultRoleAssignment() {
+	if m.Spec.KeyVaultSecretsProvider == nil || m.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment == nil {
+		return
+	}
+	roleAssignment := m.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment
+	if roleAssignment.Name == "" {
+		roleAssignment.Name = string(uuid.NewUUID())
+	}
+	if roleAssignment.DefinitionID == "" {
+		roleAssignment.DefinitionID = builtInRoleDefinitionID(m.Spec.SubscriptionID, KeyVaultSecretsUserRoleID)
+	}
+}
+
 // setDefaultVirtualNetwork sets the default VirtualNetwork for an AzureManagedControlPlane.
 func (m *AzureManagedControlPlane) setDefaultVirtualNetwork() {
 	if m.Spec.VirtualNetwork.Name == "" {
@@ -55,6 +55,83 @@ func hardcodedAzureManagedControlPlaneWithSSHKey(sshPublicKey string) *AzureMana
 	}
 }
 
+func TestSetDefaultNodeResourceGroupRoleAssignment(t *testing.T) {
+	g := NewWithT(t)
+
+	amcp := &AzureManagedControlPlane{
+		Spec: AzureManagedControlPlaneSpec{
+			SubscriptionID: "123",
+			Identity: &Identity{
+				Type:                            ManagedControlPlaneIdentityTypeUserAssigned,
+				UserAssignedIdentityResourceID:  "/resource/id",
+				NodeResourceGroupRoleAssignment: &NodeResourceGroupRoleAssignment{},
+			},
+		},
+	}
+	amcp.setDefaultNodeResourceGroupRoleAssignment()
+	g.Expect(amcp.Spec.Identity.NodeResourceGroupRoleAssignment.Name).NotTo(BeEmpty())
+	g.Expect(amcp.Spec.Identity.NodeResourceGroupRoleAssignment.DefinitionID).To(Equal("/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + ContributorRoleID))
+
+	existing := &NodeResourceGroupRoleAssignment{
+		Name:         "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+		DefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/acdd72a7-3385-48ef-bd42-f606fba81ae7",
+	}
+	amcp.Spec.Identity.NodeResourceGroupRoleAssignment = existing.DeepCopy()
+	amcp.setDefaultNodeResourceGroupRoleAssignment()
+	g.Expect(amcp.Spec.Identity.NodeResourceGroupRoleAssignment).To(Equal(existing))
+
+	amcp.Spec.Identity.NodeResourceGroupRoleAssignment = nil
+	amcp.setDefaultNodeResourceGroupRoleAssignment()
+	g.Expect(amcp.Spec.Identity.NodeResourceGroupRoleAssignment).To(BeNil())
+
+	// Without a subscription, the tenant-level ID of the built-in role is used.
+	amcp.Spec.SubscriptionID = ""
+	amcp.Spec.Identity.NodeResourceGroupRoleAssignment = &NodeResourceGroupRoleAssignment{}
+	amcp.setDefaultNodeResourceGroupRoleAssignment()
+	g.Expect(amcp.Spec.Identity.NodeResourceGroupRoleAssignment.DefinitionID).To(Equal("/providers/Microsoft.Authorization/roleDefinitions/" + ContributorRoleID))
+}
+
+func TestSetDefaultKeyVaultRoleAssignment(t *testing.T) {
+	g := NewWithT(t)
+
+	amcp := &AzureManagedControlPlane{
+		Spec: AzureManagedControlPlaneSpec{
+			SubscriptionID: "123",
+			KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+				Enabled:                        true,
+				UserAssignedIdentityResourceID: "/resource/id",
+				KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+					KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+				},
+			},
+		},
+	}
+	amcp.setDefaultKeyVaultRoleAssignment()
+	g.Expect(amcp.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment.Name).NotTo(BeEmpty())
+	g.Expect(amcp.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment.DefinitionID).To(Equal("/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID))
+
+	existing := &KeyVaultRoleAssignment{
+		Name:               "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+		KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+		DefinitionID:       "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/00482a5a-887f-4fb3-b363-3b7fe8e74483",
+	}
+	amcp.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment = existing.DeepCopy()
+	amcp.setDefaultKeyVaultRoleAssignment()
+	g.Expect(amcp.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment).To(Equal(existing))
+
+	amcp.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment = nil
+	amcp.setDefaultKeyVaultRoleAssignment()
+	g.Expect(amcp.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment).To(BeNil())
+
+	// Without a subscription, the tenant-level ID of the built-in role is used.
+	amcp.Spec.SubscriptionID = ""
+	amcp.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment = &KeyVaultRoleAssignment{
+		KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+	}
+	amcp.setDefaultKeyVaultRoleAssignment()
+	g.Expect(amcp.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment.DefinitionID).To(Equal("/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID))
+}
+
 func TestSetDefaultAutoScalerProfile(t *testing.T) {
 	g := NewWithT(t)
 
@@ -27,6 +27,13 @@ const (
 	// removing it from the apiserver.
 	ManagedClusterFinalizer = "azuremanagedcontrolplane.infrastructure.cluster.x-k8s.io"
 
+	// VirtualNodesAddonName is the name of the AKS add-on used for virtual nodes (ACI connector).
+	VirtualNodesAddonName = "aciConnectorLinux"
+
+	// KeyVaultSecretsProviderAddonName is the name of the AKS add-on used for the Azure Key Vault provider for the
+	// Secrets Store CSI driver.
+	KeyVaultSecretsProviderAddonName = "azureKeyvaultSecretsProvider"
+
 	// PrivateDNSZoneModeSystem represents mode System for azuremanagedcontrolplane.
 	PrivateDNSZoneModeSystem string = "System"
 
@@ -149,10 +156,26 @@ type AzureManagedControlPlaneSpec struct {
 	// +optional
 	AADProfile *AADProfile `json:"aadProfile,omitempty"`
 
+	// DisableLocalAccounts disables the static local account credentials of the cluster, so that only AAD
+	// credentials can be used. Requires a managed AADProfile.
+	// +optional
+	DisableLocalAccounts *bool `json:"disableLocalAccounts,omitempty"`
+
 	// AddonProfiles are the profiles of managed cluster add-on.
 	// +optional
 	AddonProfiles []AddonProfile `json:"addonProfiles,omitempty"`
 
+	// VirtualNodes is the configuration of the virtual nodes (ACI connector) add-on, which allows
+	// pods to be scheduled on Azure Container Instances.
+	// Requires the "azure" network plugin.
+	// +optional
+	VirtualNodes *VirtualNodes `json:"virtualNodes,omitempty"`
+
+	// KeyVaultSecretsProvider is the configuration of the Azure Key Vault provider for the Secrets Store CSI driver
+	// add-on, which allows pods to mount secrets stored in Azure Key Vault.
+	// +optional
+	KeyVaultSecretsProvider *KeyVaultSecretsProvider `json:"keyVaultSecretsProvider,omitempty"`
+
 	// SKU is the SKU of the AKS to be provisioned.
 	// +optional
 	SKU *AKSSku `json:"sku,omitempty"`
@@ -215,6 +238,70 @@ type AddonProfile struct {
 	Enabled bool `json:"enabled"`
 }
 
+// VirtualNodes - Profile of the virtual nodes (ACI connector) add-on.
+// See also [AKS doc].
+//
+// [AKS doc]: https://learn.microsoft.com/azure/aks/virtual-nodes
+type VirtualNodes struct {
+	// Enabled - Whether virtual nodes are enabled.
+	Enabled bool `json:"enabled"`
+
+	// SubnetName - The name of a dedicated subnet in the cluster virtual network used by Azure Container Instances.
+	// It must not be the subnet used by the cluster node pools. Required when virtual nodes are enabled.
+	// +optional
+	SubnetName string `json:"subnetName,omitempty"`
+
+	// CIDRBlock - The CIDR block of the dedicated subnet. When set, the subnet is created in the cluster virtual network.
+	// Leave empty to use an existing subnet.
+	// +optional
+	CIDRBlock string `json:"cidrBlock,omitempty"`
+}
+
+// KeyVaultSecretsProvider - Profile of the Azure Key Vault provider for the Secrets Store CSI driver add-on.
+// See also [AKS doc].
+//
+// [AKS doc]: https://learn.microsoft.com/azure/aks/csi-secrets-store-driver
+type KeyVaultSecretsProvider struct {
+	// Enabled - Whether the Key Vault secrets provider is enabled.
+	Enabled bool `json:"enabled"`
+
+	// EnableSecretRotation - Whether the mounted secrets are periodically synced with Key Vault.
+	// +optional
+	EnableSecretRotation bool `json:"enableSecretRotation,omitempty"`
+
+	// UserAssignedIdentityResourceID - ARM resource ID of the user-assigned identity KeyVaultRoleAssignment is assigned
+	// to, for instance an identity used to access Key Vault through workload identity. It is the principal of the role
+	// assignment only: AKS doesn't accept an identity for the add-on, which always has the identity AKS creates for it.
+	// If not specified, the role is assigned to the identity of the add-on.
+	// Immutable once KeyVaultRoleAssignment is set.
+	// +optional
+	UserAssignedIdentityResourceID string `json:"userAssignedIdentityResourceID,omitempty"`
+
+	// KeyVaultRoleAssignment - Role to assign to UserAssignedIdentityResourceID, or to the identity AKS creates for the
+	// add-on when it is not specified, scoped to a Key Vault.
+	// The role assignment is deleted along with the AKS cluster. Immutable once set.
+	// +optional
+	KeyVaultRoleAssignment *KeyVaultRoleAssignment `json:"keyVaultRoleAssignment,omitempty"`
+}
+
+// KeyVaultRoleAssignment is a role assignment to the identity accessing Key Vault through the Key Vault secrets
+// provider, scoped to a Key Vault.
+type KeyVaultRoleAssignment struct {
+	// Name is the name of the role assignment. It can be any valid UUID.
+	// If not specified, a random UUID will be generated.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// KeyVaultResourceID is the ARM resource ID of the Key Vault the role is scoped to.
+	KeyVaultResourceID string `json:"keyVaultResourceID"`
+
+	// DefinitionID is the ID of the role definition to assign. It can be an Azure built-in role or a custom role.
+	// Refer to built-in roles: https://learn.microsoft.com/en-us/azure/role-based-access-control/built-in-roles
+	// If not specified, the built-in Key Vault Secrets User role is assigned.
+	// +optional
+	DefinitionID string `json:"definitionID,omitempty"`
+}
+
 // AzureManagedControlPlaneSkuTier - Tier of a managed cluster SKU.
 // +kubebuilder:validation:Enum=Free;Paid
 type AzureManagedControlPlaneSkuTier string
@@ -267,6 +354,11 @@ type APIServerAccessProfile struct {
 	// AuthorizedIPRanges - Authorized IP Ranges to kubernetes API server.
 	// +optional
 	AuthorizedIPRanges []string `json:"authorizedIPRanges,omitempty"`
+	// AuthorizedIPRangesConfigMapRef references a ConfigMap in the namespace of the AzureManagedControlPlane whose data
+	// provides authorized IP ranges in addition to AuthorizedIPRanges. Each value of the ConfigMap data is a list of CIDRs
+	// separated by commas or whitespace. The ConfigMap is read every time the managed cluster is reconciled.
+	// +optional
+	AuthorizedIPRangesConfigMapRef *corev1.LocalObjectReference `json:"authorizedIPRangesConfigMapRef,omitempty"`
 	// EnablePrivateCluster - Whether to create the cluster as a private cluster or not.
 	// +optional
 	EnablePrivateCluster *bool `json:"enablePrivateCluster,omitempty"`
@@ -321,6 +413,16 @@ type AzureManagedControlPlaneStatus struct {
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 
+	// Version is the Kubernetes version of the AKS control plane, as last reported by AKS once a create or upgrade
+	// operation has completed. Agent pools are not upgraded past this version.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// KeyVaultSecretsProviderIdentity is the ARM resource ID of the user-assigned identity AKS created for the Key
+	// Vault secrets provider add-on, as last reported by AKS.
+	// +optional
+	KeyVaultSecretsProviderIdentity string `json:"keyVaultSecretsProviderIdentity,omitempty"`
+
 	// LongRunningOperationStates saves the states for Azure long-running operations so they can be continued on the
 	// next reconciliation loop.
 	// +optional
@@ -459,6 +561,28 @@ type Identity struct {
 	// UserAssignedIdentityResourceID - Identity ARM resource ID when using user-assigned identity.
 	// +optional
 	UserAssignedIdentityResourceID string `json:"userAssignedIdentityResourceID,omitempty"`
+
+	// NodeResourceGroupRoleAssignment - Role to assign to the user-assigned identity, scoped to the node resource group.
+	// AKS node operations require the control plane identity to have rights on the node resource group.
+	// The role assignment is deleted along with the AKS cluster. Can only be set when Type is UserAssigned.
+	// Immutable once set.
+	// +optional
+	NodeResourceGroupRoleAssignment *NodeResourceGroupRoleAssignment `json:"nodeResourceGroupRoleAssignment,omitempty"`
+}
+
+// NodeResourceGroupRoleAssignment is a role assignment to the user-assigned identity of an AKS control plane,
+// scoped to its node resource group.
+type NodeResourceGroupRoleAssignment struct {
+	// Name is the name of the role assignment. It can be any valid UUID.
+	// If not specified, a random UUID will be generated.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// DefinitionID is the ID of the role definition to assign. It can be an Azure built-in role or a custom role.
+	// Refer to built-in roles: https://learn.microsoft.com/en-us/azure/role-based-access-control/built-in-roles
+	// If not specified, the built-in Contributor role is assigned.
+	// +optional
+	DefinitionID string `json:"definitionID,omitempty"`
 }
 
 // +kubebuilder:object:root=true
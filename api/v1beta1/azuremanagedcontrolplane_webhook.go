@@ -27,12 +27,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/cluster-api-provider-azure/feature"
+	azureutil "sigs.k8s.io/cluster-api-provider-azure/util/azure"
 	webhookutils "sigs.k8s.io/cluster-api-provider-azure/util/webhook"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	capifeature "sigs.k8s.io/cluster-api/feature"
@@ -97,6 +99,8 @@ func (mw *azureManagedControlPlaneWebhook) Default(ctx context.Context, obj runt
 	}
 
 	m.setDefaultNodeResourceGroupName()
+	m.setDefaultNodeResourceGroupRoleAssignment()
+	m.setDefaultKeyVaultRoleAssignment()
 	m.setDefaultVirtualNetwork()
 	m.setDefaultSubnet()
 	m.setDefaultSku()
@@ -242,6 +246,40 @@ func (mw *azureManagedControlPlaneWebhook) ValidateUpdate(ctx context.Context, o
 		allErrs = append(allErrs, err)
 	}
 
+	if old.Spec.Identity != nil && old.Spec.Identity.NodeResourceGroupRoleAssignment != nil {
+		var roleAssignment *NodeResourceGroupRoleAssignment
+		if m.Spec.Identity != nil {
+			roleAssignment = m.Spec.Identity.NodeResourceGroupRoleAssignment
+		}
+		if err := webhookutils.ValidateImmutable(
+			field.NewPath("Spec", "Identity", "NodeResourceGroupRoleAssignment"),
+			old.Spec.Identity.NodeResourceGroupRoleAssignment,
+			roleAssignment); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	if old.Spec.KeyVaultSecretsProvider != nil && old.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment != nil {
+		var identityID string
+		var roleAssignment *KeyVaultRoleAssignment
+		if m.Spec.KeyVaultSecretsProvider != nil {
+			identityID = m.Spec.KeyVaultSecretsProvider.UserAssignedIdentityResourceID
+			roleAssignment = m.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment
+		}
+		if err := webhookutils.ValidateImmutable(
+			field.NewPath("Spec", "KeyVaultSecretsProvider", "UserAssignedIdentityResourceID"),
+			old.Spec.KeyVaultSecretsProvider.UserAssignedIdentityResourceID,
+			identityID); err != nil {
+			allErrs = append(allErrs, err)
+		}
+		if err := webhookutils.ValidateImmutable(
+			field.NewPath("Spec", "KeyVaultSecretsProvider", "KeyVaultRoleAssignment"),
+			old.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment,
+			roleAssignment); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+
 	if errs := m.validateVirtualNetworkUpdate(old); len(errs) > 0 {
 		allErrs = append(allErrs, errs...)
 	}
@@ -273,6 +311,9 @@ func (m *AzureManagedControlPlane) Validate(cli client.Client) error {
 		m.validateManagedClusterNetwork,
 		m.validateAutoScalerProfile,
 		m.validateIdentity,
+		m.validateVirtualNodes,
+		m.validateKeyVaultSecretsProvider,
+		m.validateDisableLocalAccounts,
 	}
 
 	var errs []error
@@ -363,6 +404,9 @@ func (m *AzureManagedControlPlane) validateAPIServerAccessProfile(_ client.Clien
 				allErrs = append(allErrs, field.Invalid(field.NewPath("Spec", "APIServerAccessProfile", "AuthorizedIPRanges"), ipRange, "invalid CIDR format"))
 			}
 		}
+		if ref := m.Spec.APIServerAccessProfile.AuthorizedIPRangesConfigMapRef; ref != nil && ref.Name == "" {
+			allErrs = append(allErrs, field.Required(field.NewPath("Spec", "APIServerAccessProfile", "AuthorizedIPRangesConfigMapRef", "Name"), "the name of the ConfigMap with the authorized IP ranges must be set"))
+		}
 		if len(allErrs) > 0 {
 			return kerrors.NewAggregate(allErrs.ToAggregate().Errors())
 		}
@@ -697,6 +741,109 @@ func (m *AzureManagedControlPlane) validateIdentity(_ client.Client) error {
 			if m.Spec.Identity.UserAssignedIdentityResourceID != "" {
 				allErrs = append(allErrs, field.Invalid(field.NewPath("Spec", "Identity", "UserAssignedIdentityResourceID"), m.Spec.Identity.UserAssignedIdentityResourceID, "should be empty if Identity.Type is SystemAssigned"))
 			}
+			if m.Spec.Identity.NodeResourceGroupRoleAssignment != nil {
+				allErrs = append(allErrs, field.Forbidden(field.NewPath("Spec", "Identity", "NodeResourceGroupRoleAssignment"), "can only be set if Identity.Type is UserAssigned"))
+			}
+		}
+		if roleAssignment := m.Spec.Identity.NodeResourceGroupRoleAssignment; roleAssignment != nil {
+			if _, err := uuid.Parse(roleAssignment.Name); err != nil {
+				allErrs = append(allErrs, field.Invalid(field.NewPath("Spec", "Identity", "NodeResourceGroupRoleAssignment", "Name"), roleAssignment.Name, "must be a valid UUID"))
+			}
+			if roleAssignment.DefinitionID == "" {
+				allErrs = append(allErrs, field.Required(field.NewPath("Spec", "Identity", "NodeResourceGroupRoleAssignment", "DefinitionID"), "the definitionID field cannot be empty"))
+			}
+		}
+	}
+
+	if len(allErrs) > 0 {
+		return kerrors.NewAggregate(allErrs.ToAggregate().Errors())
+	}
+
+	return nil
+}
+
+// validateVirtualNodes validates the virtual nodes (ACI connector) add-on configuration.
+func (m *AzureManagedControlPlane) validateVirtualNodes(_ client.Client) error {
+	if m.Spec.VirtualNodes == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("Spec", "VirtualNodes")
+
+	for _, profile := range m.Spec.AddonProfiles {
+		if profile.Name == VirtualNodesAddonName {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("Spec", "AddonProfiles"), fmt.Sprintf("the %s add-on must be configured through Spec.VirtualNodes", VirtualNodesAddonName)))
+		}
+	}
+
+	if m.Spec.VirtualNodes.Enabled {
+		if m.Spec.VirtualNodes.SubnetName == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("SubnetName"), "a dedicated subnet is required when virtual nodes are enabled"))
+		}
+		if ptr.Deref(m.Spec.NetworkPlugin, "") != "azure" {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("Spec", "NetworkPlugin"), ptr.Deref(m.Spec.NetworkPlugin, ""), "virtual nodes require the azure network plugin"))
+		}
+	}
+
+	if m.Spec.VirtualNodes.SubnetName != "" && m.Spec.VirtualNodes.SubnetName == m.Spec.VirtualNetwork.Subnet.Name {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("SubnetName"), m.Spec.VirtualNodes.SubnetName, "must be a dedicated subnet, not the node subnet"))
+	}
+
+	if m.Spec.VirtualNodes.CIDRBlock != "" {
+		if m.Spec.VirtualNodes.SubnetName == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("SubnetName"), "must be specified when CIDRBlock is set"))
+		}
+		allErrs = append(allErrs, validateSubnetCIDR([]string{m.Spec.VirtualNodes.CIDRBlock}, []string{m.Spec.VirtualNetwork.CIDRBlock}, fldPath.Child("CIDRBlock"))...)
+	}
+
+	if len(allErrs) > 0 {
+		return kerrors.NewAggregate(allErrs.ToAggregate().Errors())
+	}
+
+	return nil
+}
+
+// validateKeyVaultSecretsProvider validates the Azure Key Vault provider for the Secrets Store CSI driver add-on
+// configuration.
+func (m *AzureManagedControlPlane) validateKeyVaultSecretsProvider(_ client.Client) error {
+	if m.Spec.KeyVaultSecretsProvider == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("Spec", "KeyVaultSecretsProvider")
+
+	for _, profile := range m.Spec.AddonProfiles {
+		if profile.Name == KeyVaultSecretsProviderAddonName {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("Spec", "AddonProfiles"), fmt.Sprintf("the %s add-on must be configured through Spec.KeyVaultSecretsProvider", KeyVaultSecretsProviderAddonName)))
+		}
+	}
+
+	if id := m.Spec.KeyVaultSecretsProvider.UserAssignedIdentityResourceID; id != "" {
+		if resourceID, err := azureutil.ParseResourceID(id); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("UserAssignedIdentityResourceID"), id, "must be a valid Azure resource ID"))
+		} else if !strings.EqualFold(resourceID.ResourceType.String(), "Microsoft.ManagedIdentity/userAssignedIdentities") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("UserAssignedIdentityResourceID"), id, "must be a user-assigned identity resource ID"))
+		}
+	}
+
+	if roleAssignment := m.Spec.KeyVaultSecretsProvider.KeyVaultRoleAssignment; roleAssignment != nil {
+		roleAssignmentPath := fldPath.Child("KeyVaultRoleAssignment")
+		// Without a user-assigned identity, the role is assigned to the identity AKS creates for the add-on once enabled.
+		if m.Spec.KeyVaultSecretsProvider.UserAssignedIdentityResourceID == "" && !m.Spec.KeyVaultSecretsProvider.Enabled {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("Enabled"), m.Spec.KeyVaultSecretsProvider.Enabled, "must be true to assign KeyVaultRoleAssignment to the identity of the add-on when UserAssignedIdentityResourceID is not specified"))
+		}
+		if _, err := uuid.Parse(roleAssignment.Name); err != nil {
+			allErrs = append(allErrs, field.Invalid(roleAssignmentPath.Child("Name"), roleAssignment.Name, "must be a valid UUID"))
+		}
+		if roleAssignment.DefinitionID == "" {
+			allErrs = append(allErrs, field.Required(roleAssignmentPath.Child("DefinitionID"), "the definitionID field cannot be empty"))
+		}
+		if resourceID, err := azureutil.ParseResourceID(roleAssignment.KeyVaultResourceID); err != nil {
+			allErrs = append(allErrs, field.Invalid(roleAssignmentPath.Child("KeyVaultResourceID"), roleAssignment.KeyVaultResourceID, "must be a valid Azure resource ID"))
+		} else if !strings.EqualFold(resourceID.ResourceType.String(), "Microsoft.KeyVault/vaults") {
+			allErrs = append(allErrs, field.Invalid(roleAssignmentPath.Child("KeyVaultResourceID"), roleAssignment.KeyVaultResourceID, "must be a Key Vault resource ID"))
 		}
 	}
 
@@ -706,3 +853,15 @@ func (m *AzureManagedControlPlane) validateIdentity(_ client.Client) error {
 
 	return nil
 }
+
+// validateDisableLocalAccounts validates that local accounts are only disabled on clusters with managed AAD, as there
+// would be no way left to authenticate to the cluster otherwise.
+func (m *AzureManagedControlPlane) validateDisableLocalAccounts(_ client.Client) error {
+	if !ptr.Deref(m.Spec.DisableLocalAccounts, false) {
+		return nil
+	}
+	if m.Spec.AADProfile == nil || !m.Spec.AADProfile.Managed {
+		return field.Invalid(field.NewPath("Spec", "DisableLocalAccounts"), *m.Spec.DisableLocalAccounts, "local accounts can only be disabled when AADProfile.Managed is true")
+	}
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilfeature "k8s.io/component-base/featuregate/testing"
 	"k8s.io/utils/ptr"
@@ -280,6 +281,33 @@ func TestValidatingWebhook(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "AuthorizedIPRangesConfigMapRef without a name",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.21.2",
+					APIServerAccessProfile: &APIServerAccessProfile{
+						AuthorizedIPRangesConfigMapRef: &corev1.LocalObjectReference{},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "valid AuthorizedIPRangesConfigMapRef",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.21.2",
+					APIServerAccessProfile: &APIServerAccessProfile{
+						AuthorizedIPRanges:             []string{"1.2.3.4/32"},
+						AuthorizedIPRangesConfigMapRef: &corev1.LocalObjectReference{Name: "authorized-ip-ranges"},
+					},
+				},
+			},
+			expectErr: false,
+		},
 		{
 			name: "Testing valid AutoScalerProfile",
 			amcp: AzureManagedControlPlane{
@@ -649,6 +677,76 @@ func TestValidatingWebhook(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "Testing valid Identity: UserAssigned with a node resource group role assignment",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					Identity: &Identity{
+						Type:                           ManagedControlPlaneIdentityTypeUserAssigned,
+						UserAssignedIdentityResourceID: "/resource/id",
+						NodeResourceGroupRoleAssignment: &NodeResourceGroupRoleAssignment{
+							Name:         "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							DefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + ContributorRoleID,
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Testing invalid Identity: SystemAssigned with a node resource group role assignment",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					Identity: &Identity{
+						Type: ManagedControlPlaneIdentityTypeSystemAssigned,
+						NodeResourceGroupRoleAssignment: &NodeResourceGroupRoleAssignment{
+							Name:         "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							DefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + ContributorRoleID,
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing invalid Identity: node resource group role assignment with an invalid name",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					Identity: &Identity{
+						Type:                           ManagedControlPlaneIdentityTypeUserAssigned,
+						UserAssignedIdentityResourceID: "/resource/id",
+						NodeResourceGroupRoleAssignment: &NodeResourceGroupRoleAssignment{
+							Name:         "not-a-uuid",
+							DefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + ContributorRoleID,
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing invalid Identity: node resource group role assignment without a role definition",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					Identity: &Identity{
+						Type:                           ManagedControlPlaneIdentityTypeUserAssigned,
+						UserAssignedIdentityResourceID: "/resource/id",
+						NodeResourceGroupRoleAssignment: &NodeResourceGroupRoleAssignment{
+							Name: "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
 		{
 			name: "Testing invalid Identity: UserAssigned with missing properties",
 			amcp: AzureManagedControlPlane{
@@ -662,6 +760,352 @@ func TestValidatingWebhook(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "Testing valid VirtualNodes",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version:       "v1.24.1",
+					NetworkPlugin: ptr.To("azure"),
+					VirtualNetwork: ManagedControlPlaneVirtualNetwork{
+						CIDRBlock: "10.0.0.0/8",
+						Subnet: ManagedControlPlaneSubnet{
+							Name:      "node-subnet",
+							CIDRBlock: "10.240.0.0/16",
+						},
+					},
+					VirtualNodes: &VirtualNodes{
+						Enabled:    true,
+						SubnetName: "aci-subnet",
+						CIDRBlock:  "10.241.0.0/16",
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Testing valid disabled VirtualNodes without a subnet",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					VirtualNodes: &VirtualNodes{
+						Enabled: false,
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Testing invalid VirtualNodes: enabled without a subnet",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version:       "v1.24.1",
+					NetworkPlugin: ptr.To("azure"),
+					VirtualNodes: &VirtualNodes{
+						Enabled: true,
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing invalid VirtualNodes: subnet is the node subnet",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version:       "v1.24.1",
+					NetworkPlugin: ptr.To("azure"),
+					VirtualNetwork: ManagedControlPlaneVirtualNetwork{
+						Subnet: ManagedControlPlaneSubnet{
+							Name: "node-subnet",
+						},
+					},
+					VirtualNodes: &VirtualNodes{
+						Enabled:    true,
+						SubnetName: "node-subnet",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing invalid VirtualNodes: subnet CIDR outside of the virtual network",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version:       "v1.24.1",
+					NetworkPlugin: ptr.To("azure"),
+					VirtualNetwork: ManagedControlPlaneVirtualNetwork{
+						CIDRBlock: "10.0.0.0/8",
+					},
+					VirtualNodes: &VirtualNodes{
+						Enabled:    true,
+						SubnetName: "aci-subnet",
+						CIDRBlock:  "192.168.0.0/16",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing invalid VirtualNodes: kubenet network plugin",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version:       "v1.24.1",
+					NetworkPlugin: ptr.To("kubenet"),
+					VirtualNodes: &VirtualNodes{
+						Enabled:    true,
+						SubnetName: "aci-subnet",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing invalid VirtualNodes: add-on also set in AddonProfiles",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version:       "v1.24.1",
+					NetworkPlugin: ptr.To("azure"),
+					AddonProfiles: []AddonProfile{
+						{
+							Name:    VirtualNodesAddonName,
+							Enabled: true,
+						},
+					},
+					VirtualNodes: &VirtualNodes{
+						Enabled:    true,
+						SubnetName: "aci-subnet",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing valid KeyVaultSecretsProvider with a Key Vault role assignment",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						EnableSecretRotation:           true,
+						UserAssignedIdentityResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity",
+						KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+							Name:               "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+							DefinitionID:       "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID,
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Testing valid KeyVaultSecretsProvider without a user-assigned identity",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled: true,
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Testing invalid KeyVaultSecretsProvider: identity is not a user-assigned identity",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						UserAssignedIdentityResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing invalid KeyVaultSecretsProvider: identity is not a resource ID",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						UserAssignedIdentityResourceID: "my-identity",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing valid KeyVaultSecretsProvider: Key Vault role assignment to the identity of the add-on",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled: true,
+						KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+							Name:               "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+							DefinitionID:       "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID,
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Testing invalid KeyVaultSecretsProvider: Key Vault role assignment to the identity of a disabled add-on",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled: false,
+						KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+							Name:               "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+							DefinitionID:       "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID,
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing invalid KeyVaultSecretsProvider: Key Vault role assignment with an invalid name",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						UserAssignedIdentityResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity",
+						KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+							Name:               "not-a-uuid",
+							KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+							DefinitionID:       "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID,
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing invalid KeyVaultSecretsProvider: Key Vault role assignment without a role definition",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						UserAssignedIdentityResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity",
+						KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+							Name:               "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing invalid KeyVaultSecretsProvider: Key Vault role assignment scoped to a resource which is not a Key Vault",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						UserAssignedIdentityResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity",
+						KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+							Name:               "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg",
+							DefinitionID:       "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID,
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing invalid KeyVaultSecretsProvider: add-on also set in AddonProfiles",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					AddonProfiles: []AddonProfile{
+						{
+							Name:    KeyVaultSecretsProviderAddonName,
+							Enabled: true,
+						},
+					},
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled: true,
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing valid DisableLocalAccounts with managed AAD",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.24.1",
+					AADProfile: &AADProfile{
+						Managed:             true,
+						AdminGroupObjectIDs: []string{"616077a8-5db7-4c98-b856-b34619afg75h"},
+					},
+					DisableLocalAccounts: ptr.To(true),
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Testing invalid DisableLocalAccounts without AAD",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version:              "v1.24.1",
+					DisableLocalAccounts: ptr.To(true),
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing invalid DisableLocalAccounts with unmanaged AAD",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version:              "v1.24.1",
+					AADProfile:           &AADProfile{Managed: false},
+					DisableLocalAccounts: ptr.To(true),
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Testing valid local accounts enabled without AAD",
+			amcp: AzureManagedControlPlane{
+				ObjectMeta: getAMCPMetaData(),
+				Spec: AzureManagedControlPlaneSpec{
+					Version:              "v1.24.1",
+					DisableLocalAccounts: ptr.To(false),
+				},
+			},
+			expectErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -890,6 +1334,215 @@ func TestAzureManagedControlPlane_ValidateUpdate(t *testing.T) {
 			amcp:    createAzureManagedControlPlane("192.168.0.10", "1.999.9", generateSSHPublicKey(true)),
 			wantErr: true,
 		},
+		{
+			name: "AzureManagedControlPlane local accounts can be disabled in place with managed AAD",
+			oldAMCP: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version:    "v1.18.0",
+					AADProfile: &AADProfile{Managed: true, AdminGroupObjectIDs: []string{"616077a8-5db7-4c98-b856-b34619afg75h"}},
+				},
+			},
+			amcp: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version:              "v1.18.0",
+					AADProfile:           &AADProfile{Managed: true, AdminGroupObjectIDs: []string{"616077a8-5db7-4c98-b856-b34619afg75h"}},
+					DisableLocalAccounts: ptr.To(true),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "AzureManagedControlPlane node resource group role assignment can be added",
+			oldAMCP: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					Identity: &Identity{
+						Type:                           ManagedControlPlaneIdentityTypeUserAssigned,
+						UserAssignedIdentityResourceID: "/resource/id",
+					},
+				},
+			},
+			amcp: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					Identity: &Identity{
+						Type:                           ManagedControlPlaneIdentityTypeUserAssigned,
+						UserAssignedIdentityResourceID: "/resource/id",
+						NodeResourceGroupRoleAssignment: &NodeResourceGroupRoleAssignment{
+							Name:         "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							DefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + ContributorRoleID,
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "AzureManagedControlPlane node resource group role assignment is immutable",
+			oldAMCP: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					Identity: &Identity{
+						Type:                           ManagedControlPlaneIdentityTypeUserAssigned,
+						UserAssignedIdentityResourceID: "/resource/id",
+						NodeResourceGroupRoleAssignment: &NodeResourceGroupRoleAssignment{
+							Name:         "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							DefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + ContributorRoleID,
+						},
+					},
+				},
+			},
+			amcp: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					Identity: &Identity{
+						Type:                           ManagedControlPlaneIdentityTypeUserAssigned,
+						UserAssignedIdentityResourceID: "/resource/id",
+						NodeResourceGroupRoleAssignment: &NodeResourceGroupRoleAssignment{
+							Name:         "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							DefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/acdd72a7-3385-48ef-bd42-f606fba81ae7",
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "AzureManagedControlPlane Key Vault role assignment can be added",
+			oldAMCP: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						UserAssignedIdentityResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity",
+					},
+				},
+			},
+			amcp: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						UserAssignedIdentityResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity",
+						KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+							Name:               "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+							DefinitionID:       "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID,
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "AzureManagedControlPlane Key Vault secret rotation can be enabled with a Key Vault role assignment",
+			oldAMCP: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						UserAssignedIdentityResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity",
+						KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+							Name:               "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+							DefinitionID:       "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID,
+						},
+					},
+				},
+			},
+			amcp: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						EnableSecretRotation:           true,
+						UserAssignedIdentityResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity",
+						KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+							Name:               "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+							DefinitionID:       "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID,
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "AzureManagedControlPlane Key Vault role assignment is immutable",
+			oldAMCP: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						UserAssignedIdentityResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity",
+						KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+							Name:               "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+							DefinitionID:       "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID,
+						},
+					},
+				},
+			},
+			amcp: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						UserAssignedIdentityResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity",
+						KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+							Name:               "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/other-vault",
+							DefinitionID:       "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID,
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "AzureManagedControlPlane Key Vault secrets provider identity is immutable with a Key Vault role assignment",
+			oldAMCP: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						UserAssignedIdentityResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity",
+						KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+							Name:               "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+							DefinitionID:       "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID,
+						},
+					},
+				},
+			},
+			amcp: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+						Enabled:                        true,
+						UserAssignedIdentityResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/other-identity",
+						KeyVaultRoleAssignment: &KeyVaultRoleAssignment{
+							Name:               "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+							KeyVaultResourceID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+							DefinitionID:       "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/" + KeyVaultSecretsUserRoleID,
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "AzureManagedControlPlane SubscriptionID is immutable",
 			oldAMCP: &AzureManagedControlPlane{
@@ -1265,6 +1918,28 @@ func TestAzureManagedControlPlane_ValidateUpdate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "AzureManagedControlPlane AuthorizedIPRangesConfigMapRef is mutable",
+			oldAMCP: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					APIServerAccessProfile: &APIServerAccessProfile{
+						AuthorizedIPRangesConfigMapRef: &corev1.LocalObjectReference{Name: "authorized-ip-ranges"},
+					},
+				},
+			},
+			amcp: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: ptr.To("192.168.0.10"),
+					Version:      "v1.18.0",
+					APIServerAccessProfile: &APIServerAccessProfile{
+						AuthorizedIPRangesConfigMapRef: &corev1.LocalObjectReference{Name: "other-authorized-ip-ranges"},
+					},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "AzureManagedControlPlane.VirtualNetwork Name is mutable",
 			oldAMCP: &AzureManagedControlPlane{
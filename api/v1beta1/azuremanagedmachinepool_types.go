@@ -35,6 +35,9 @@ const (
 
 	// DefaultOSType represents the default operating system for azmachinepool.
 	DefaultOSType string = LinuxOS
+
+	// spotScaleSetPriority is the ScaleSetPriority of the node pools of Spot VMs.
+	spotScaleSetPriority = "Spot"
 )
 
 // NodePoolMode enumerates the values for agent pool mode.
@@ -561,6 +564,35 @@ type AzureManagedMachinePoolSpec struct {
 	// Immutable.
 	// +optional
 	EnableFIPS *bool `json:"enableFIPS,omitempty"`
+
+	// NodeImageVersion pins the node image version of the node pool, e.g. AKSUbuntu-2204gen2containerd-202308.01.0.
+	// When the version of the node pool differs, the node image is upgraded once the pinned version is the latest
+	// version available to the node pool, as AKS only supports upgrading to the latest node image version. Until then,
+	// the AgentPoolNodeImageUpToDate condition reports the pinned version as unavailable.
+	// When unset, the node image version is not managed.
+	// +optional
+	NodeImageVersion *string `json:"nodeImageVersion,omitempty"`
+
+	// DrainTimeoutInMinutes is how long AKS waits for the pods of a node to be evicted, respecting their pod disruption
+	// budgets, when it cordons and drains the node to scale the node pool down or to upgrade it. The operation fails
+	// when a pod can't be evicted within the timeout. Must be between 1 and 1440. AKS defaults it to 30 minutes.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1440
+	// +optional
+	DrainTimeoutInMinutes *int32 `json:"drainTimeoutInMinutes,omitempty"`
+
+	// SpotEvictionWebhook is notified of the scheduled evictions of the Spot nodes of the node pool, so that their
+	// workloads can be drained before the nodes are evicted. Only valid when ScaleSetPriority is Spot.
+	// +optional
+	SpotEvictionWebhook *SpotEvictionWebhook `json:"spotEvictionWebhook,omitempty"`
+}
+
+// SpotEvictionWebhook is an endpoint notified of the scheduled evictions of Spot nodes.
+type SpotEvictionWebhook struct {
+	// URL is the HTTPS endpoint a JSON notification is posted to for each scheduled eviction of a node, as reported by
+	// the VMEventScheduled condition of the node.
+	// +kubebuilder:validation:Pattern=`^https://`
+	URL string `json:"url"`
 }
 
 // ManagedMachinePoolScaling specifies scaling options.
@@ -19,6 +19,7 @@ package v1beta1
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -43,6 +44,16 @@ import (
 
 var validNodePublicPrefixID = regexp.MustCompile(`(?i)^/?subscriptions/[0-9a-f]{8}-([0-9a-f]{4}-){3}[0-9a-f]{12}/resourcegroups/[^/]+/providers/microsoft\.network/publicipprefixes/[^/]+$`)
 
+// validNodeImageVersion matches AKS node image versions, e.g. AKSUbuntu-2204gen2containerd-202308.01.0 or
+// AKSWindows-2019-containerd-17763.4737.230809.
+var validNodeImageVersion = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(-[A-Za-z0-9]+)*-[0-9]+(\.[0-9]+)+$`)
+
+const (
+	// minDrainTimeoutInMinutes and maxDrainTimeoutInMinutes bound the drain timeout of AKS node pools.
+	minDrainTimeoutInMinutes = 1
+	maxDrainTimeoutInMinutes = 1440
+)
+
 // SetupAzureManagedMachinePoolWebhookWithManager sets up and registers the webhook with the manager.
 func SetupAzureManagedMachinePoolWebhookWithManager(mgr ctrl.Manager) error {
 	mw := &azureManagedMachinePoolWebhook{Client: mgr.GetClient()}
@@ -108,6 +119,9 @@ func (mw *azureManagedMachinePoolWebhook) ValidateCreate(ctx context.Context, ob
 		m.validateKubeletConfig,
 		m.validateLinuxOSConfig,
 		m.validateSubnetName,
+		m.validateNodeImageVersion,
+		m.validateDrainTimeout,
+		m.validateSpotEvictionWebhook,
 	}
 
 	var errs []error
@@ -147,6 +161,28 @@ func (mw *azureManagedMachinePoolWebhook) ValidateUpdate(ctx context.Context, ol
 				err.Error()))
 	}
 
+	if err := m.validateNodeImageVersion(); err != nil {
+		allErrs = append(allErrs,
+			field.Invalid(
+				field.NewPath("Spec", "NodeImageVersion"),
+				m.Spec.NodeImageVersion,
+				err.Error()))
+	}
+
+	if err := m.validateDrainTimeout(); err != nil {
+		allErrs = append(allErrs,
+			field.Invalid(
+				field.NewPath("Spec", "DrainTimeoutInMinutes"),
+				m.Spec.DrainTimeoutInMinutes,
+				err.Error()))
+	}
+
+	// The error isn't wrapped with the value of the field, as the webhook URL may contain credentials.
+	var spotEvictionWebhookErr *field.Error
+	if errors.As(m.validateSpotEvictionWebhook(), &spotEvictionWebhookErr) {
+		allErrs = append(allErrs, spotEvictionWebhookErr)
+	}
+
 	if err := webhookutils.ValidateImmutable(
 		field.NewPath("Spec", "OSType"),
 		old.Spec.OSType,
@@ -210,6 +246,20 @@ func (mw *azureManagedMachinePoolWebhook) ValidateUpdate(ctx context.Context, ol
 		}
 	}
 
+	if m.Spec.Mode == string(NodePoolModeSystem) && old.Spec.Mode != string(NodePoolModeSystem) {
+		// validate that AKS accepts the node pool as a system node pool
+		if ptr.Deref(m.Spec.OSType, LinuxOS) != LinuxOS {
+			allErrs = append(allErrs, field.Forbidden(
+				field.NewPath("Spec", "Mode"),
+				"Cannot change node pool mode to System, System node pools must have OSType 'Linux'"))
+		}
+		if ptr.Deref(m.Spec.ScaleSetPriority, "") == spotScaleSetPriority {
+			allErrs = append(allErrs, field.Forbidden(
+				field.NewPath("Spec", "Mode"),
+				"Cannot change node pool mode to System, Spot node pools can't be System node pools"))
+		}
+	}
+
 	if err := webhookutils.ValidateImmutable(
 		field.NewPath("Spec", "MaxPods"),
 		old.Spec.MaxPods,
@@ -397,6 +447,50 @@ func (m *AzureManagedMachinePool) validateNodePublicIPPrefixID() error {
 	return nil
 }
 
+func (m *AzureManagedMachinePool) validateNodeImageVersion() error {
+	if m.Spec.NodeImageVersion != nil && !validNodeImageVersion.MatchString(*m.Spec.NodeImageVersion) {
+		return field.Invalid(
+			field.NewPath("Spec", "NodeImageVersion"),
+			m.Spec.NodeImageVersion,
+			fmt.Sprintf("node image version must match %q, e.g. AKSUbuntu-2204gen2containerd-202308.01.0", validNodeImageVersion.String()))
+	}
+	return nil
+}
+
+func (m *AzureManagedMachinePool) validateDrainTimeout() error {
+	if m.Spec.DrainTimeoutInMinutes != nil && (*m.Spec.DrainTimeoutInMinutes < minDrainTimeoutInMinutes || *m.Spec.DrainTimeoutInMinutes > maxDrainTimeoutInMinutes) {
+		return field.Invalid(
+			field.NewPath("Spec", "DrainTimeoutInMinutes"),
+			m.Spec.DrainTimeoutInMinutes,
+			fmt.Sprintf("drain timeout must be between %d and %d minutes", minDrainTimeoutInMinutes, maxDrainTimeoutInMinutes))
+	}
+	return nil
+}
+
+func (m *AzureManagedMachinePool) validateSpotEvictionWebhook() error {
+	if m.Spec.SpotEvictionWebhook == nil {
+		return nil
+	}
+	if ptr.Deref(m.Spec.ScaleSetPriority, "") != spotScaleSetPriority {
+		return field.Forbidden(
+			field.NewPath("Spec", "SpotEvictionWebhook"),
+			"can only be set when ScaleSetPriority is Spot")
+	}
+	webhookURL, err := url.Parse(m.Spec.SpotEvictionWebhook.URL)
+	if err != nil || webhookURL.Scheme != "https" || webhookURL.Host == "" {
+		return field.Invalid(
+			field.NewPath("Spec", "SpotEvictionWebhook", "URL"),
+			m.Spec.SpotEvictionWebhook.URL,
+			"must be an absolute HTTPS URL")
+	}
+	if webhookURL.User != nil {
+		return field.Forbidden(
+			field.NewPath("Spec", "SpotEvictionWebhook", "URL"),
+			"must not contain credentials")
+	}
+	return nil
+}
+
 func (m *AzureManagedMachinePool) validateEnableNodePublicIP() error {
 	if (m.Spec.EnableNodePublicIP == nil || !*m.Spec.EnableNodePublicIP) &&
 		m.Spec.NodePublicIPPrefixID != nil {
@@ -42,16 +42,51 @@ const (
 	VMProvisionFailedReason = "VMProvisionFailed"
 	// UserAssignedIdentityMissingReason used for failures when a user-assigned identity is missing.
 	UserAssignedIdentityMissingReason = "UserAssignedIdentityMissing"
+	// VMDriftCorrectedReason is used for events emitted when fields of a VM that drifted from the spec are reapplied.
+	VMDriftCorrectedReason = "VMDriftCorrected"
+	// VMOSDiskResizingReason is used for events emitted when a VM is deallocated to grow its OS disk.
+	VMOSDiskResizingReason = "VMOSDiskResizing"
+	// VMOSDiskResizedReason is used for events emitted when a VM is started again after growing its OS disk.
+	VMOSDiskResizedReason = "VMOSDiskResized"
+	// VMDataDisksAttachedReason is used for events emitted when data disks added to an existing machine are attached to its VM.
+	VMDataDisksAttachedReason = "VMDataDisksAttached"
+	// ImageNotReplicatedReason used when the gallery image version of a VM is not replicated to the region of the VM.
+	ImageNotReplicatedReason = "ImageNotReplicated"
+	// ImagePlanNotAcceptedReason used when the marketplace agreement of the image plan of a VM is not accepted in its subscription.
+	ImagePlanNotAcceptedReason = "ImagePlanNotAccepted"
+	// ImagePlanTermsAcceptedReason is used for events emitted when the marketplace terms of the image plan of a VM are accepted.
+	ImagePlanTermsAcceptedReason = "ImagePlanTermsAccepted"
+	// PreflightCheckFailedReason used when an Azure resource a VM to be created depends on, e.g. an existing disk to
+	// attach, is missing or can't be used with the VM.
+	PreflightCheckFailedReason = "PreflightCheckFailed"
 	// WaitingForClusterInfrastructureReason used when machine is waiting for cluster infrastructure to be ready before proceeding.
 	WaitingForClusterInfrastructureReason = "WaitingForClusterInfrastructure"
 	// WaitingForBootstrapDataReason used when machine is waiting for bootstrap data to be ready before proceeding.
 	WaitingForBootstrapDataReason = "WaitingForBootstrapData"
 	// BootstrapSucceededCondition reports the result of the execution of the bootstrap data on the machine.
 	BootstrapSucceededCondition clusterv1.ConditionType = "BootstrapSucceeded"
+	// BootstrapDataTooLargeReason used when the bootstrap data exceeds the maximum size of the VM custom data.
+	BootstrapDataTooLargeReason = "BootstrapDataTooLarge"
 	// BootstrapInProgressReason is used to indicate the bootstrap data has not finished executing.
 	BootstrapInProgressReason = "BootstrapInProgress"
 	// BootstrapFailedReason is used to indicate the bootstrap process ran into an error.
 	BootstrapFailedReason = "BootstrapFailed"
+	// ExtensionConditionPrefix prefixes the condition type reporting the status of a VM extension,
+	// e.g. Extension/CAPZ.Linux.BootstrappingReady.
+	ExtensionConditionPrefix = "Extension/"
+	// ExtensionProvisioningReason is used to indicate a VM extension is still being provisioned.
+	ExtensionProvisioningReason = "ExtensionProvisioning"
+	// ExtensionFailedReason is used to indicate a VM extension failed to provision.
+	ExtensionFailedReason = "ExtensionFailed"
+	// RunCommandConditionPrefix prefixes the condition type reporting the result of a run command,
+	// e.g. RunCommand/post-provisionSucceeded.
+	RunCommandConditionPrefix = "RunCommand/"
+	// RunCommandRunningReason is used to indicate the script of a run command is still running.
+	RunCommandRunningReason = "RunCommandRunning"
+	// RunCommandFailedReason is used to indicate the script of a run command failed.
+	RunCommandFailedReason = "RunCommandFailed"
+	// RunCommandSucceededReason is used for events reporting the output of a run command that succeeded.
+	RunCommandSucceededReason = "RunCommandSucceeded"
 )
 
 // AzureMachinePool Conditions and Reasons.
@@ -86,6 +121,27 @@ const (
 	ManagedClusterRunningCondition clusterv1.ConditionType = "ManagedClusterRunning"
 	// AgentPoolsReadyCondition means the AKS agent pools exist and are ready to be used.
 	AgentPoolsReadyCondition clusterv1.ConditionType = "AgentPoolsReady"
+	// ControlPlaneUpgradingReason is used when the Kubernetes version of the AKS control plane is being upgraded.
+	ControlPlaneUpgradingReason = "ControlPlaneUpgrading"
+	// WaitingForControlPlaneUpgradeReason is used when the Kubernetes version upgrade of an agent pool is waiting for
+	// the AKS control plane to be upgraded first.
+	WaitingForControlPlaneUpgradeReason = "WaitingForControlPlaneUpgrade"
+	// AgentPoolNodesDrainedCondition means the nodes removed by the last scale-down of an AKS agent pool were cordoned
+	// and drained without violating the pod disruption budgets of their pods.
+	AgentPoolNodesDrainedCondition clusterv1.ConditionType = "AgentPoolNodesDrained"
+	// DrainingNodesReason is used while AKS cordons and drains the nodes removed by the scale-down of an agent pool.
+	DrainingNodesReason = "DrainingNodes"
+	// PodDisruptionBudgetViolationReason is used when AKS failed to drain a node removed by the scale-down of an agent
+	// pool within the drain timeout, as evicting its pods would violate their pod disruption budgets.
+	PodDisruptionBudgetViolationReason = "PodDisruptionBudgetViolation"
+	// AgentPoolNodeImageUpToDateCondition means the node image of an AKS agent pool is the node image version it is
+	// pinned to.
+	AgentPoolNodeImageUpToDateCondition clusterv1.ConditionType = "AgentPoolNodeImageUpToDate"
+	// UpgradingNodeImageReason is used while the node image of an agent pool is upgraded to the pinned version.
+	UpgradingNodeImageReason = "UpgradingNodeImage"
+	// NodeImageVersionUnavailableReason is used when the node image version an agent pool is pinned to isn't the latest
+	// version available to the agent pool, so AKS can't upgrade the agent pool to it.
+	NodeImageVersionUnavailableReason = "NodeImageVersionUnavailable"
 	// AzureResourceAvailableCondition means the AKS cluster is healthy according to Azure's Resource Health API.
 	AzureResourceAvailableCondition clusterv1.ConditionType = "AzureResourceAvailable"
 )
@@ -104,6 +160,13 @@ const (
 	RouteTablesReadyCondition clusterv1.ConditionType = "RouteTablesReady"
 	// PublicIPsReadyCondition means the public IPs exist and are ready to be used.
 	PublicIPsReadyCondition clusterv1.ConditionType = "PublicIPsReady"
+	// PublicIPPrefixesReadyCondition means the public IP prefixes exist and are ready to be used.
+	PublicIPPrefixesReadyCondition clusterv1.ConditionType = "PublicIPPrefixesReady"
+	// TrafficManagerProfilesReadyCondition means the Traffic Manager profiles exist and are ready to be used.
+	TrafficManagerProfilesReadyCondition clusterv1.ConditionType = "TrafficManagerProfilesReady"
+	// ResourceGroupLockReadyCondition means the management lock protecting the resource group of the cluster from
+	// deletion exists.
+	ResourceGroupLockReadyCondition clusterv1.ConditionType = "ResourceGroupLockReady"
 	// NATGatewaysReadyCondition means the NAT gateways exist and are ready to be used.
 	NATGatewaysReadyCondition clusterv1.ConditionType = "NATGatewaysReady"
 	// SubnetsReadyCondition means the subnets exist and are ready to be used.
@@ -118,6 +181,8 @@ const (
 	PrivateDNSRecordReadyCondition clusterv1.ConditionType = "PrivateDNSRecordReady"
 	// BastionHostReadyCondition means the bastion host exists and is ready to be used.
 	BastionHostReadyCondition clusterv1.ConditionType = "BastionHostReady"
+	// DataCollectionRuleAssociationReadyCondition means the data collection rule association exists and is ready to be used.
+	DataCollectionRuleAssociationReadyCondition clusterv1.ConditionType = "DataCollectionRuleAssociationReady"
 	// InboundNATRulesReadyCondition means the inbound NAT rules exist and are ready to be used.
 	InboundNATRulesReadyCondition clusterv1.ConditionType = "InboundNATRulesReady"
 	// AvailabilitySetReadyCondition means the availability set exists and is ready to be used.
@@ -130,6 +195,14 @@ const (
 	NetworkInterfaceReadyCondition clusterv1.ConditionType = "NetworkInterfacesReady"
 	// PrivateEndpointsReadyCondition means the private endpoints exist and are ready to be used.
 	PrivateEndpointsReadyCondition clusterv1.ConditionType = "PrivateEndpointsReady"
+	// APIServerLoadBalancerHealthyCondition means at least one backend of the API server load balancer passes its health probe.
+	APIServerLoadBalancerHealthyCondition clusterv1.ConditionType = "APIServerLoadBalancerHealthy"
+	// EgressValidatedCondition means the nodes of a private cluster have an egress path to their required destinations.
+	EgressValidatedCondition clusterv1.ConditionType = "EgressValidated"
+	// AzureResourcesUnlockedCondition means no Azure resource was refused to be created, updated or deleted because of a
+	// management lock on the resource, its resource group or its subscription. Changes to a locked resource are only
+	// retried periodically until it can be changed again.
+	AzureResourcesUnlockedCondition clusterv1.ConditionType = "AzureResourcesUnlocked"
 
 	// CreatingReason means the resource is being created.
 	CreatingReason = "Creating"
@@ -143,6 +216,12 @@ const (
 	DeletionFailedReason = "DeletionFailed"
 	// UpdatingReason means the resource is being updated.
 	UpdatingReason = "Updating"
+	// NoHealthyBackendsReason means none of the backends of a load balancer pass its health probe.
+	NoHealthyBackendsReason = "NoHealthyBackends"
+	// EgressBlockedReason means the nodes of a private cluster have no egress path to some required destination.
+	EgressBlockedReason = "EgressBlocked"
+	// ScopeLockedReason means an Azure resource can't be changed because of a management lock.
+	ScopeLockedReason = "ScopeLocked"
 )
 
 const (
@@ -153,6 +232,25 @@ const (
 	CustomHeaderPrefix = "infrastructure.cluster.x-k8s.io/custom-header-"
 )
 
+const (
+	// DryRunAnnotation enables dry-run mode when set to "true" on an AzureCluster or an AzureMachine. In dry-run mode,
+	// the changes CAPZ would make to the Azure resources of the object are reported as events but are not applied.
+	DryRunAnnotation = "capz.io/dry-run"
+	// DryRunChangeReason is used for events reporting a change that would be made to an Azure resource outside of dry-run mode.
+	DryRunChangeReason = "DryRunChange"
+)
+
+const (
+	// RemoveResourceGroupLockAnnotation allows the removal of the management lock protecting the resource group of an
+	// AzureCluster from deletion when set to "true". It is required to delete an AzureCluster with
+	// ResourceGroupDeletionProtection and to disable the protection.
+	RemoveResourceGroupLockAnnotation = "capz.io/remove-resource-group-lock"
+	// DoNotDeleteAnnotation prevents the deletion of the resource group of an AzureCluster or an
+	// AzureManagedControlPlane when set to "true", even if the resource group was created by CAPZ. The resources
+	// created by CAPZ are deleted one by one instead.
+	DoNotDeleteAnnotation = "capz.io/do-not-delete"
+)
+
 const (
 	// LinuxOS is Linux OS value for OSDisk.OSType.
 	LinuxOS = "Linux"
@@ -166,3 +264,17 @@ const (
 	// value for the label is the CAPI Cluster Name.
 	OwnedByClusterLabelKey = NameAzureProviderPrefix + string(ResourceLifecycleOwned)
 )
+
+const (
+	// OutboundDenyMinRulePriority is the lowest priority of the node subnet security rules generated to deny
+	// outbound traffic except to allowlisted destinations. Priorities from this one up to 4096 are reserved.
+	OutboundDenyMinRulePriority = 4000
+	// OutboundDenyRulePriority is the priority of the node subnet security rule denying all other outbound traffic.
+	OutboundDenyRulePriority = 4096
+	// MaxOutboundAllowedDestinations is the maximum number of allowlisted outbound destinations, as each one gets a
+	// security rule priority between the rule allowing the virtual network and the rule denying all outbound traffic.
+	MaxOutboundAllowedDestinations = OutboundDenyRulePriority - OutboundDenyMinRulePriority - 1
+)
+
+// DiskPerformanceTierScheduleTimeFormat is the format of the times of day of a disk performance tier schedule.
+const DiskPerformanceTierScheduleTimeFormat = "15:04"
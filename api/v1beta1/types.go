@@ -17,9 +17,14 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/utils/net"
+	"k8s.io/utils/ptr"
 )
 
 const (
@@ -29,6 +34,12 @@ const (
 	Node string = "node"
 	// Bastion subnet label.
 	Bastion string = "bastion"
+	// Gateway subnet label.
+	Gateway string = "gateway"
+	// Pod subnet label.
+	Pod string = "pod"
+	// RouteServer subnet label.
+	RouteServer string = "route-server"
 )
 
 // SecurityEncryptionType represents the Encryption Type when the virtual machine is a
@@ -63,6 +74,8 @@ const (
 	PutFuture string = "PUT"
 	// DeleteFuture is a future that was derived from a DELETE request.
 	DeleteFuture string = "DELETE"
+	// PostFuture is a future that was derived from a POST request, e.g. to deallocate or start a VM.
+	PostFuture string = "POST"
 )
 
 // Future contains the data needed for an Azure long-running operation to continue across reconcile loops.
@@ -109,9 +122,124 @@ type NetworkSpec struct {
 	// +optional
 	ControlPlaneOutboundLB *LoadBalancerSpec `json:"controlPlaneOutboundLB,omitempty"`
 
+	// OutboundPublicIPPrefix is the configuration for a public IP prefix the public IPs of the node outbound load
+	// balancer and of the node NAT gateways are allocated from, to get predictable egress IPs.
+	// It can't be changed once the cluster is created.
+	// +optional
+	OutboundPublicIPPrefix *PublicIPPrefixSpec `json:"outboundPublicIPPrefix,omitempty"`
+
+	// OutboundDeny configures private clusters to deny all outbound traffic of the nodes except to allowlisted
+	// destinations, instead of relying on implicit outbound connectivity.
+	// +optional
+	OutboundDeny *OutboundDenySpec `json:"outboundDeny,omitempty"`
+
+	// EgressValidation enables a pre-flight validation that the nodes of a private cluster have an egress path to the
+	// destinations they need to bootstrap, before the Azure resources of the cluster are reconciled.
+	// +optional
+	EgressValidation *EgressValidationSpec `json:"egressValidation,omitempty"`
+
+	// TrafficManager is the configuration for a Traffic Manager profile fronting the API server endpoints of several
+	// regions. When set, the FQDN of the profile is the control plane endpoint of the cluster.
+	// +optional
+	TrafficManager *TrafficManagerSpec `json:"trafficManager,omitempty"`
+
 	NetworkClassSpec `json:",inline"`
 }
 
+// OutboundDenySpec defines the destinations the nodes of a private cluster are allowed to reach when all other
+// outbound traffic is denied.
+type OutboundDenySpec struct {
+	// AllowedDestinations is a list of CIDRs, IP addresses or service tags, such as 'AzureCloud' or
+	// 'MicrosoftContainerRegistry', the nodes are allowed to reach. Traffic within the virtual network is always allowed.
+	// +optional
+	AllowedDestinations []string `json:"allowedDestinations,omitempty"`
+}
+
+// EgressValidationSpec defines the destinations the egress pre-flight validation checks the nodes of a private cluster
+// can reach.
+type EgressValidationSpec struct {
+	// RequiredDestinations is a list of CIDRs, IP addresses or service tags the nodes need to reach. It defaults to the
+	// 'MicrosoftContainerRegistry' and 'AzureActiveDirectory' service tags.
+	// +optional
+	RequiredDestinations []string `json:"requiredDestinations,omitempty"`
+}
+
+// TrafficManagerRoutingMethod is the method a Traffic Manager profile uses to route DNS queries to its endpoints.
+// +kubebuilder:validation:Enum=Priority;Weighted;Performance
+type TrafficManagerRoutingMethod string
+
+const (
+	// TrafficManagerRoutingMethodPriority routes DNS queries to the healthy endpoint with the lowest priority value.
+	TrafficManagerRoutingMethodPriority TrafficManagerRoutingMethod = "Priority"
+	// TrafficManagerRoutingMethodWeighted distributes DNS queries across the healthy endpoints based on their weight.
+	TrafficManagerRoutingMethodWeighted TrafficManagerRoutingMethod = "Weighted"
+	// TrafficManagerRoutingMethodPerformance routes DNS queries to the healthy endpoint with the lowest network latency
+	// to the client.
+	TrafficManagerRoutingMethodPerformance TrafficManagerRoutingMethod = "Performance"
+)
+
+// TrafficManagerSpec defines a Traffic Manager profile fronting the API server endpoints of several regions.
+type TrafficManagerSpec struct {
+	// Name is the name of the Traffic Manager profile. Defaults to traf-<cluster-name>-apiserver.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// RelativeDNSName is the relative DNS name of the Traffic Manager profile, which must be globally unique. The
+	// control plane endpoint of the cluster is <relativeDNSName>.trafficmanager.net in the Azure public cloud.
+	RelativeDNSName string `json:"relativeDNSName"`
+
+	// RoutingMethod is the method used to route DNS queries to the endpoints. Defaults to Priority.
+	// +kubebuilder:default=Priority
+	// +optional
+	RoutingMethod TrafficManagerRoutingMethod `json:"routingMethod,omitempty"`
+
+	// TTL is the DNS time-to-live of the profile in seconds, i.e. how long clients cache the endpoint it resolves to.
+	// Defaults to 30.
+	// +optional
+	TTL int64 `json:"ttl,omitempty"`
+
+	// MonitorPath is the path of the HTTPS probe Traffic Manager sends to the API server port of the endpoints to check
+	// their health. Defaults to /readyz.
+	// +optional
+	MonitorPath string `json:"monitorPath,omitempty"`
+
+	// Endpoints are the regional API server endpoints fronted by the profile.
+	// +kubebuilder:validation:MinItems=1
+	Endpoints []TrafficManagerEndpoint `json:"endpoints"`
+}
+
+// TrafficManagerEndpoint defines a regional API server endpoint fronted by a Traffic Manager profile.
+type TrafficManagerEndpoint struct {
+	// Name is the name of the endpoint.
+	Name string `json:"name"`
+
+	// Target is the FQDN or public IP address of the regional API server endpoint, e.g. the DNS name of the public IP
+	// of the API server load balancer of the cluster in that region.
+	Target string `json:"target"`
+
+	// Location is the Azure region of the endpoint. It is required with the Performance routing method.
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// Priority is the priority of the endpoint with the Priority routing method, from 1 to 1000. Lower values are
+	// preferred. Defaults to the order of the endpoints.
+	// +optional
+	Priority *int64 `json:"priority,omitempty"`
+
+	// Weight is the weight of the endpoint with the Weighted routing method, from 1 to 1000. Defaults to 1.
+	// +optional
+	Weight *int64 `json:"weight,omitempty"`
+}
+
+// TrafficManagerEndpointPriority returns the priority of the endpoint at index i, which defaults to its position in the
+// list of endpoints.
+func TrafficManagerEndpointPriority(endpoints []TrafficManagerEndpoint, i int) int64 {
+	if endpoints[i].Priority != nil {
+		return *endpoints[i].Priority
+	}
+	return int64(i + 1)
+}
+
 // VnetSpec configures an Azure virtual network.
 type VnetSpec struct {
 	// ResourceGroup is the name of the resource group of the existing virtual network
@@ -131,6 +259,13 @@ type VnetSpec struct {
 	// +optional
 	Peerings VnetPeerings `json:"peerings,omitempty"`
 
+	// FlowTimeoutInMinutes is the timeout of the flows of the virtual network connections, used to track long-lived
+	// connections. It must be between 4 and 30 minutes. Flow timeout is disabled when not set.
+	// +kubebuilder:validation:Minimum=4
+	// +kubebuilder:validation:Maximum=30
+	// +optional
+	FlowTimeoutInMinutes *int32 `json:"flowTimeoutInMinutes,omitempty"`
+
 	VnetClassSpec `json:",inline"`
 }
 
@@ -148,6 +283,14 @@ type VnetPeeringClassSpec struct {
 	// RemoteVnetName defines name of the remote virtual network.
 	RemoteVnetName string `json:"remoteVnetName"`
 
+	// RemoteVnetID is the resource ID of the remote virtual network, which can be in another subscription than the
+	// cluster. ResourceGroup and RemoteVnetName default to the ones of the resource ID.
+	// The peering from the remote virtual network to the cluster's virtual network is only created when the remote
+	// virtual network is in the subscription of the cluster and the cluster identity is authorized to create it,
+	// otherwise only the peering from the cluster's virtual network is created.
+	// +optional
+	RemoteVnetID string `json:"remoteVnetID,omitempty"`
+
 	// ForwardPeeringProperties specifies VnetPeeringProperties for peering from the cluster's virtual network to the
 	// remote virtual network.
 	// +optional
@@ -225,6 +368,43 @@ type RouteTable struct {
 	// +optional
 	ID   string `json:"id,omitempty"`
 	Name string `json:"name"`
+	// Routes is a list of user-defined routes in the route table.
+	// Subnets sharing a route table must specify the same routes.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Routes []Route `json:"routes,omitempty"`
+}
+
+// RouteNextHopType defines the type of Azure hop a packet matching a route is sent to.
+type RouteNextHopType string
+
+const (
+	// RouteNextHopTypeVirtualNetworkGateway sends the traffic to the virtual network gateway.
+	RouteNextHopTypeVirtualNetworkGateway = RouteNextHopType("VirtualNetworkGateway")
+	// RouteNextHopTypeVnetLocal routes the traffic within the virtual network.
+	RouteNextHopTypeVnetLocal = RouteNextHopType("VnetLocal")
+	// RouteNextHopTypeInternet sends the traffic to the Internet.
+	RouteNextHopTypeInternet = RouteNextHopType("Internet")
+	// RouteNextHopTypeVirtualAppliance sends the traffic to a virtual appliance, such as a firewall.
+	RouteNextHopTypeVirtualAppliance = RouteNextHopType("VirtualAppliance")
+	// RouteNextHopTypeNone drops the traffic.
+	RouteNextHopTypeNone = RouteNextHopType("None")
+)
+
+// Route defines an Azure user-defined route.
+type Route struct {
+	// Name is the name of the route, unique within the route table.
+	Name string `json:"name"`
+	// AddressPrefix is the destination CIDR or service tag to which the route applies.
+	AddressPrefix string `json:"addressPrefix"`
+	// NextHopType is the type of Azure hop the packet should be sent to.
+	// +kubebuilder:validation:Enum=VirtualNetworkGateway;VnetLocal;Internet;VirtualAppliance;None
+	NextHopType RouteNextHopType `json:"nextHopType"`
+	// NextHopIPAddress is the IP address packets should be forwarded to.
+	// Required when NextHopType is VirtualAppliance, and not allowed otherwise.
+	// +optional
+	NextHopIPAddress string `json:"nextHopIPAddress,omitempty"`
 }
 
 // NatGateway defines an Azure NAT gateway.
@@ -270,6 +450,17 @@ const (
 	SecurityRuleDirectionOutbound = SecurityRuleDirection("Outbound")
 )
 
+// SecurityRuleAction defines the action type for a security group rule.
+type SecurityRuleAction string
+
+const (
+	// SecurityRuleActionAllow allows the traffic matching a security rule.
+	SecurityRuleActionAllow = SecurityRuleAction("Allow")
+
+	// SecurityRuleActionDeny denies the traffic matching a security rule.
+	SecurityRuleActionDeny = SecurityRuleAction("Deny")
+)
+
 // SecurityRule defines an Azure security rule for security groups.
 type SecurityRule struct {
 	// Name is a unique name within the network security group.
@@ -282,7 +473,12 @@ type SecurityRule struct {
 	// Direction indicates whether the rule applies to inbound, or outbound traffic. "Inbound" or "Outbound".
 	// +kubebuilder:validation:Enum=Inbound;Outbound
 	Direction SecurityRuleDirection `json:"direction"`
+	// Action specifies whether the traffic matching the rule is allowed or denied. "Allow" or "Deny". Defaults to "Allow".
+	// +kubebuilder:validation:Enum=Allow;Deny
+	// +optional
+	Action SecurityRuleAction `json:"action,omitempty"`
 	// Priority is a number between 100 and 4096. Each rule should have a unique value for priority. Rules are processed in priority order, with lower numbers processed before higher numbers. Once traffic matches a rule, processing stops.
+	// It can be left unset if the security group has a rulePriorityBand, in which case a priority of the band is assigned to the rule.
 	// +optional
 	Priority int32 `json:"priority,omitempty"`
 	// SourcePorts specifies source port or range. Integer or range between 0 and 65535. Asterix '*' can also be used to match all ports.
@@ -297,6 +493,36 @@ type SecurityRule struct {
 	// Destination is the destination address prefix. CIDR or destination IP range. Asterix '*' can also be used to match all source IPs. Default tags such as 'VirtualNetwork', 'AzureLoadBalancer' and 'Internet' can also be used.
 	// +optional
 	Destination *string `json:"destination,omitempty"`
+	// Sources specifies a list of CIDRs or source IP ranges. Default tags such as 'VirtualNetwork', 'AzureLoadBalancer' and 'Internet' can also be used.
+	// Mutually exclusive with Source.
+	// +optional
+	Sources []string `json:"sources,omitempty"`
+	// Destinations specifies a list of CIDRs or destination IP ranges. Default tags such as 'VirtualNetwork', 'AzureLoadBalancer' and 'Internet' can also be used.
+	// Mutually exclusive with Destination.
+	// +optional
+	Destinations []string `json:"destinations,omitempty"`
+	// SourceApplicationSecurityGroups specifies a list of Azure resource IDs of existing application security groups to use as the source.
+	// The application security groups are only referenced and are not created or deleted by CAPZ.
+	// Mutually exclusive with Source and Sources.
+	// +optional
+	SourceApplicationSecurityGroups []string `json:"sourceApplicationSecurityGroups,omitempty"`
+	// DestinationApplicationSecurityGroups specifies a list of Azure resource IDs of existing application security groups to use as the destination.
+	// The application security groups are only referenced and are not created or deleted by CAPZ.
+	// Mutually exclusive with Destination and Destinations.
+	// +optional
+	DestinationApplicationSecurityGroups []string `json:"destinationApplicationSecurityGroups,omitempty"`
+}
+
+// SecurityRulePriorityBand defines a range of security rule priorities.
+type SecurityRulePriorityBand struct {
+	// Min is the lowest priority of the band.
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=4096
+	Min int32 `json:"min"`
+	// Max is the highest priority of the band.
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=4096
+	Max int32 `json:"max"`
 }
 
 // SecurityRules is a slice of Azure security rules for security groups.
@@ -320,10 +546,79 @@ type LoadBalancerSpec struct {
 	// BackendPool describes the backend pool of the load balancer.
 	// +optional
 	BackendPool BackendPool `json:"backendPool,omitempty"`
+	// AdditionalBackendPools describes backend pools of the load balancer the node network interfaces are associated
+	// with in addition to BackendPool, e.g. to share the nodes with a service load balancer. Only supported for the
+	// node outbound load balancer.
+	// +optional
+	AdditionalBackendPools []BackendPool `json:"additionalBackendPools,omitempty"`
+	// InboundNATRules configures the inbound NAT rules giving SSH access to the control plane machines through the
+	// load balancer. Only supported for the API server load balancer.
+	// +optional
+	InboundNATRules *InboundNATRulesSpec `json:"inboundNATRules,omitempty"`
+	// EnableFloatingIP enables floating IP, also known as direct server return, on the load balancing rule of the API
+	// server load balancer. The control plane machines then receive the traffic with the frontend IP of the load
+	// balancer as destination. Only supported for the API server load balancer.
+	// +optional
+	EnableFloatingIP *bool `json:"enableFloatingIP,omitempty"`
+	// HealthProbe configures the health probe of the load balancing rule of the API server load balancer. It defaults
+	// to an HTTPS probe of the /readyz path of the API server every 15 seconds, which marks a control plane machine as
+	// unhealthy after 4 failed probes. Only supported for the API server load balancer.
+	// +optional
+	HealthProbe *HealthProbeSpec `json:"healthProbe,omitempty"`
 
 	LoadBalancerClassSpec `json:",inline"`
 }
 
+// ProbeProtocol is the protocol of a load balancer health probe.
+type ProbeProtocol string
+
+const (
+	// ProbeProtocolTCP probes a backend by establishing a TCP connection to its port.
+	ProbeProtocolTCP = ProbeProtocol("Tcp")
+	// ProbeProtocolHTTP probes a backend with an HTTP request, which must return a 200 status.
+	ProbeProtocolHTTP = ProbeProtocol("Http")
+	// ProbeProtocolHTTPS probes a backend with an HTTPS request, which must return a 200 status.
+	ProbeProtocolHTTPS = ProbeProtocol("Https")
+)
+
+// HealthProbeSpec defines the health probe of a load balancer.
+type HealthProbeSpec struct {
+	// Protocol is the protocol of the probe: Tcp, Http or Https.
+	// +kubebuilder:validation:Enum=Tcp;Http;Https
+	Protocol ProbeProtocol `json:"protocol"`
+	// RequestPath is the path requested by an HTTP or HTTPS probe, e.g. /readyz. It is required for the Http and Https
+	// protocols and not supported for the Tcp protocol.
+	// +optional
+	RequestPath string `json:"requestPath,omitempty"`
+	// IntervalInSeconds is the interval between two probes, at least 5 seconds. It defaults to 15 seconds.
+	// +kubebuilder:validation:Minimum=5
+	// +optional
+	IntervalInSeconds *int32 `json:"intervalInSeconds,omitempty"`
+	// NumberOfProbes is the number of consecutive failed probes after which a backend is marked unhealthy. It defaults
+	// to 4.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumberOfProbes *int32 `json:"numberOfProbes,omitempty"`
+}
+
+// InboundNATRulesSpec defines the configuration of the inbound NAT rules of a load balancer.
+type InboundNATRulesSpec struct {
+	// IdleTimeoutInMinutes is the timeout of idle TCP connections through the inbound NAT rules, between 4 and 30
+	// minutes. It defaults to 4 minutes.
+	// +kubebuilder:validation:Minimum=4
+	// +kubebuilder:validation:Maximum=30
+	// +optional
+	IdleTimeoutInMinutes *int32 `json:"idleTimeoutInMinutes,omitempty"`
+	// EnableTCPReset sends a TCP reset to both ends of the connections closed by the idle timeout.
+	// +optional
+	EnableTCPReset *bool `json:"enableTCPReset,omitempty"`
+	// EnableFloatingIP enables floating IP, also known as direct server return, on the inbound NAT rules. Floating IP
+	// requires the frontend port of a rule to match its backend port 22, which only holds for the rule of the first
+	// control plane machine.
+	// +optional
+	EnableFloatingIP *bool `json:"enableFloatingIP,omitempty"`
+}
+
 // SKU defines an Azure load balancer SKU.
 type SKU string
 
@@ -355,10 +650,31 @@ type FrontendIP struct {
 // PublicIPSpec defines the inputs to create an Azure public IP address.
 type PublicIPSpec struct {
 	Name string `json:"name"`
+	// DNSName is the fully qualified domain name of the public IP, e.g. <label>.<location>.cloudapp.azure.com in the
+	// Azure public cloud. Its first label is set as the DNS label of the public IP: it must be 3 to 63 characters long,
+	// start with a lowercase letter, end with a lowercase letter or a number and only contain lowercase letters, numbers
+	// and hyphens. The DNS label must be unique in the location.
 	// +optional
 	DNSName string `json:"dnsName,omitempty"`
 	// +optional
 	IPTags []IPTag `json:"ipTags,omitempty"`
+	// Zones are the availability zones of the public IP. If empty, the public IP is zone-redundant across the
+	// availability zones of the location, when the location supports availability zones. Only supported for the
+	// public IPs of load balancer frontends.
+	// +optional
+	Zones []string `json:"zones,omitempty"`
+}
+
+// PublicIPPrefixSpec defines the inputs to create an Azure public IP prefix.
+type PublicIPPrefixSpec struct {
+	// Name is the name of the public IP prefix. It defaults to ippre-<cluster name>-outbound.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// PrefixLength is the length of the public IP prefix, which sets the number of public IPs it contains:
+	// 16 public IPs for a /28 prefix down to 2 public IPs for a /31 prefix.
+	// +kubebuilder:validation:Minimum=28
+	// +kubebuilder:validation:Maximum=31
+	PrefixLength int32 `json:"prefixLength"`
 }
 
 // IPTag contains the IpTag associated with the object.
@@ -475,6 +791,10 @@ type AzureMarketplaceImage struct {
 	// +kubebuilder:default=false
 	// +optional
 	ThirdPartyImage bool `json:"thirdPartyImage"`
+	// Plan is the purchase plan of a third party image whose plan differs from its publisher, offer and SKU.
+	// Setting it generates a Plan for the image regardless of ThirdPartyImage.
+	// +optional
+	Plan *ImagePlan `json:"plan,omitempty"`
 }
 
 // AzureSharedGalleryImage defines an image in a Shared Image Gallery to use for VM creation.
@@ -549,6 +869,19 @@ type UserAssignedIdentity struct {
 	ProviderID string `json:"providerID"`
 }
 
+// KeyVaultSecretReference is a reference to a secret stored in an Azure Key Vault.
+type KeyVaultSecretReference struct {
+	// VaultName is the name of the Key Vault holding the secret.
+	VaultName string `json:"vaultName"`
+
+	// SecretName is the name of the secret.
+	SecretName string `json:"secretName"`
+
+	// SecretVersion is the version of the secret. Defaults to the current version of the secret.
+	// +optional
+	SecretVersion string `json:"secretVersion,omitempty"`
+}
+
 const (
 	// AzureIdentityBindingSelector is the label used to match with the AzureIdentityBinding
 	// For the controller to match an identity binding, it needs a [label] with the key `aadpodidbinding`
@@ -617,6 +950,124 @@ type DataDisk struct {
 	// +optional
 	// +kubebuilder:validation:Enum=None;ReadOnly;ReadWrite
 	CachingType string `json:"cachingType,omitempty"`
+	// DiskIOPSReadWrite is the number of IOPS provisioned for the data disk. It can only be set for UltraSSD_LRS data disks.
+	// If not set, Azure provisions a default number of IOPS based on the disk size.
+	// +kubebuilder:validation:Minimum=100
+	// +optional
+	DiskIOPSReadWrite *int64 `json:"diskIOPSReadWrite,omitempty"`
+	// DiskMBpsReadWrite is the throughput in MB per second provisioned for the data disk. It can only be set for
+	// UltraSSD_LRS data disks. If not set, Azure provisions a default throughput based on the disk size.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	DiskMBpsReadWrite *int64 `json:"diskMBpsReadWrite,omitempty"`
+	// MaxShares is the maximum number of VMs that can attach the data disk at the same time. A data disk with maxShares
+	// greater than 1 is shared: it is created once for the cluster, named <clusterName>_<nameSuffix>, and attached by ID
+	// to every machine of the cluster with a shared data disk of the same name suffix. Shared data disks require the
+	// cachingType None and the Premium_LRS, Premium_ZRS or UltraSSD_LRS storage account type.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxShares *int32 `json:"maxShares,omitempty"`
+	// AttachExistingDisk attaches an existing managed disk to the VM instead of creating a new one. The disk must be
+	// in the location of the machine. The size of an existing disk is not changed, so diskSizeGB is ignored.
+	// Existing disks are not deleted with the machine.
+	// +optional
+	AttachExistingDisk *AttachExistingDisk `json:"attachExistingDisk,omitempty"`
+	// BurstingEnabled enables on-demand bursting beyond the provisioned performance target of the data disk. It can
+	// only be set for Premium_LRS and Premium_ZRS data disks larger than 512 GiB.
+	// +optional
+	BurstingEnabled *bool `json:"burstingEnabled,omitempty"`
+	// PerformanceTierSchedule downgrades the performance tier of the data disk off-hours to save cost. It can only be
+	// set for Premium_LRS and Premium_ZRS data disks.
+	// +optional
+	PerformanceTierSchedule *DiskPerformanceTierSchedule `json:"performanceTierSchedule,omitempty"`
+}
+
+// DiskPerformanceTierSchedule defines the performance tier of a data disk during business hours and off-hours.
+// The machine is reconciled at the start and end of off-hours to apply tier changes.
+type DiskPerformanceTierSchedule struct {
+	// Tier is the performance tier of the disk during business hours, such as P30.
+	Tier string `json:"tier"`
+	// OffHoursTier is the performance tier of the disk off-hours, such as P10. It can't be lower than the baseline
+	// performance tier of the disk size.
+	OffHoursTier string `json:"offHoursTier"`
+	// OffHoursStart is the time of day, in the HH:MM format in UTC, at which the disk is downgraded to the off-hours
+	// tier.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	OffHoursStart string `json:"offHoursStart"`
+	// OffHoursEnd is the time of day, in the HH:MM format in UTC, at which the disk is upgraded back to the business
+	// hours tier. As Azure allows downgrading the tier of a disk only once every 12 hours, business hours must last
+	// at least 12 hours.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	OffHoursEnd string `json:"offHoursEnd"`
+	// Weekends keeps the disk in the off-hours tier all day on Saturdays and Sundays.
+	// +optional
+	Weekends bool `json:"weekends,omitempty"`
+}
+
+// AttachExistingDisk references an existing managed disk to attach to a VM.
+type AttachExistingDisk struct {
+	// ID is the resource ID of the managed disk.
+	ID string `json:"id"`
+}
+
+// IsShared returns true if the data disk can be attached to multiple VMs at the same time.
+func (d DataDisk) IsShared() bool {
+	return d.MaxShares != nil && *d.MaxShares > 1
+}
+
+// HasLogicalSectorSize returns true if the data disk is created with a logical sector size.
+func (d DataDisk) HasLogicalSectorSize() bool {
+	return d.ManagedDisk != nil && d.ManagedDisk.LogicalSectorSize != nil
+}
+
+// TierAt returns the performance tier the disk should have at the given time.
+func (s *DiskPerformanceTierSchedule) TierAt(t time.Time) string {
+	t = t.UTC()
+	if s.Weekends && (t.Weekday() == time.Saturday || t.Weekday() == time.Sunday) {
+		return s.OffHoursTier
+	}
+	start, errStart := time.Parse(DiskPerformanceTierScheduleTimeFormat, s.OffHoursStart)
+	end, errEnd := time.Parse(DiskPerformanceTierScheduleTimeFormat, s.OffHoursEnd)
+	if errStart != nil || errEnd != nil {
+		return s.Tier
+	}
+	now := t.Hour()*60 + t.Minute()
+	startMinutes, endMinutes := start.Hour()*60+start.Minute(), end.Hour()*60+end.Minute()
+	offHours := now >= startMinutes && now < endMinutes
+	if startMinutes > endMinutes {
+		// Off-hours span midnight, such as from 19:00 to 07:00.
+		offHours = now >= startMinutes || now < endMinutes
+	}
+	if offHours {
+		return s.OffHoursTier
+	}
+	return s.Tier
+}
+
+// NextChangeAfter returns the next time after the given time at which the disk may change tier, i.e. the next start
+// or end of off-hours, or the next midnight when the disk stays in the off-hours tier on weekends. It returns the zero
+// time if the schedule has no valid times of day and doesn't cover weekends.
+func (s *DiskPerformanceTierSchedule) NextChangeAfter(t time.Time) time.Time {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	var next time.Time
+	if s.Weekends {
+		next = midnight.AddDate(0, 0, 1)
+	}
+	for _, timeOfDay := range []string{s.OffHoursStart, s.OffHoursEnd} {
+		parsed, err := time.Parse(DiskPerformanceTierScheduleTimeFormat, timeOfDay)
+		if err != nil {
+			continue
+		}
+		boundary := midnight.Add(time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute)
+		if !boundary.After(t) {
+			boundary = boundary.AddDate(0, 0, 1)
+		}
+		if next.IsZero() || boundary.Before(next) {
+			next = boundary
+		}
+	}
+	return next
 }
 
 // VMExtension specifies the parameters for a custom VM extension.
@@ -645,6 +1096,11 @@ type ManagedDiskParameters struct {
 	// SecurityProfile specifies the security profile for the managed disk.
 	// +optional
 	SecurityProfile *VMDiskSecurityProfile `json:"securityProfile,omitempty"`
+	// LogicalSectorSize is the logical sector size in bytes of the managed disk, 512 or 4096. It can only be set for
+	// PremiumV2_LRS data disks, which are then created before the VM and attached to it. Azure uses 4096 when not set.
+	// +kubebuilder:validation:Enum=512;4096
+	// +optional
+	LogicalSectorSize *int32 `json:"logicalSectorSize,omitempty"`
 }
 
 // VMDiskSecurityProfile specifies the security profile settings for the managed disk.
@@ -694,6 +1150,30 @@ const (
 
 	// SubnetBastion defines a Bastion subnet role.
 	SubnetBastion = SubnetRole(Bastion)
+
+	// SubnetGateway defines a virtual network gateway (e.g. ExpressRoute) subnet role.
+	SubnetGateway = SubnetRole(Gateway)
+
+	// SubnetPod defines a subnet role for the pod IPs allocated by a self-managed Azure CNI.
+	SubnetPod = SubnetRole(Pod)
+
+	// SubnetRouteServer defines an Azure Route Server subnet role.
+	SubnetRouteServer = SubnetRole(RouteServer)
+)
+
+const (
+	// GatewaySubnetName is the name Azure requires for the subnet hosting virtual network gateways.
+	GatewaySubnetName = "GatewaySubnet"
+	// GatewaySubnetMaxPrefixLength is the longest prefix length allowed for the gateway subnet.
+	// Smaller subnets can't host an ExpressRoute gateway.
+	GatewaySubnetMaxPrefixLength = 27
+	// RouteServerSubnetName is the name Azure requires for the subnet hosting a Route Server.
+	RouteServerSubnetName = "RouteServerSubnet"
+	// RouteServerSubnetMaxPrefixLength is the longest prefix length allowed for the route server subnet.
+	RouteServerSubnetMaxPrefixLength = 27
+	// PodSubnetMaxPrefixLength is the longest prefix length allowed for the pod subnet.
+	// Azure CNI pre-allocates an IP per pod on every node, which quickly exhausts smaller subnets.
+	PodSubnetMaxPrefixLength = 24
 )
 
 // SubnetSpec configures an Azure subnet.
@@ -783,6 +1263,38 @@ type NetworkInterface struct {
 	// +kubebuilder:validation:nullable
 	// +optional
 	AcceleratedNetworking *bool `json:"acceleratedNetworking,omitempty"`
+
+	// SecondaryIPConfigs are secondary IP configurations with a static private IP address added to the interface, e.g.
+	// the floating virtual IP address of a high-availability pair of machines. They are added when the interface is
+	// created, after the IP configurations specified by PrivateIPConfigs, and are never primary.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	SecondaryIPConfigs []SecondaryIPConfig `json:"secondaryIPConfigs,omitempty"`
+
+	// VirtualNetworkTap references an existing virtual network tap the traffic of the primary IP configuration of the
+	// interface is mirrored to, e.g. for packet inspection by a security appliance. Unlike the other fields of the
+	// interface, it can be changed after the interface is created.
+	// +optional
+	VirtualNetworkTap *VirtualNetworkTapReference `json:"virtualNetworkTap,omitempty"`
+}
+
+// VirtualNetworkTapReference references an existing virtual network tap.
+type VirtualNetworkTapReference struct {
+	// ID is the resource ID of the virtual network tap.
+	ID string `json:"id"`
+}
+
+// SecondaryIPConfig defines a secondary IP configuration of a network interface with a static private IP address.
+type SecondaryIPConfig struct {
+	// Name is the name of the IP configuration, e.g. the name a failover agent moving a floating IP address between the
+	// machines of a high-availability pair knows it by.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=80
+	Name string `json:"name"`
+
+	// PrivateIP is the static private IPv4 address of the IP configuration. It must be within the subnet of the interface.
+	PrivateIP string `json:"privateIP"`
 }
 
 // GetControlPlaneSubnet returns the cluster control plane subnet.
@@ -813,6 +1325,78 @@ func (n *NetworkSpec) UpdateNodeSubnet(subnet SubnetSpec) {
 	}
 }
 
+// OutboundPublicIPCount returns the number of public IPs of the node outbound load balancer and of the node NAT
+// gateways, which are allocated from the outbound public IP prefix when it is set.
+func (n *NetworkSpec) OutboundPublicIPCount() int {
+	count := 0
+	if n.NodeOutboundLB != nil {
+		for _, ip := range n.NodeOutboundLB.FrontendIPs {
+			if ip.PublicIP != nil {
+				count++
+			}
+		}
+	}
+	// A NAT gateway, and so its public IP, can be shared by several node subnets.
+	natGatewayIPs := make(map[string]struct{})
+	for _, subnet := range n.Subnets {
+		if subnet.Role == SubnetNode && subnet.IsNatGatewayEnabled() {
+			natGatewayIPs[subnet.NatGateway.NatGatewayIP.Name] = struct{}{}
+		}
+	}
+	return count + len(natGatewayIPs)
+}
+
+// OutboundDenySecurityRules returns the security rules of the node subnets denying all outbound traffic except to
+// the virtual network and to the allowlisted destinations, or nil if outbound deny isn't configured.
+func (n *NetworkSpec) OutboundDenySecurityRules() SecurityRules {
+	if n.OutboundDeny == nil {
+		return nil
+	}
+	rules := SecurityRules{
+		{
+			Name:             "allow_outbound_vnet",
+			Description:      "Allow outbound traffic within the virtual network",
+			Protocol:         SecurityGroupProtocolAll,
+			Direction:        SecurityRuleDirectionOutbound,
+			Action:           SecurityRuleActionAllow,
+			Priority:         OutboundDenyMinRulePriority,
+			Source:           ptr.To("*"),
+			SourcePorts:      ptr.To("*"),
+			Destination:      ptr.To("VirtualNetwork"),
+			DestinationPorts: ptr.To("*"),
+		},
+	}
+	// Service tags can't be combined in a single rule, so each allowlisted destination gets its own rule, named
+	// after the destination so that changing it replaces the rule.
+	nameReplacer := strings.NewReplacer("/", "_", ":", "-")
+	for i, destination := range n.OutboundDeny.AllowedDestinations {
+		rules = append(rules, SecurityRule{
+			Name:             "allow_outbound_" + nameReplacer.Replace(destination),
+			Description:      fmt.Sprintf("Allow outbound traffic to %s", destination),
+			Protocol:         SecurityGroupProtocolAll,
+			Direction:        SecurityRuleDirectionOutbound,
+			Action:           SecurityRuleActionAllow,
+			Priority:         int32(OutboundDenyMinRulePriority + 1 + i),
+			Source:           ptr.To("*"),
+			SourcePorts:      ptr.To("*"),
+			Destination:      ptr.To(destination),
+			DestinationPorts: ptr.To("*"),
+		})
+	}
+	return append(rules, SecurityRule{
+		Name:             "deny_outbound",
+		Description:      "Deny all other outbound traffic",
+		Protocol:         SecurityGroupProtocolAll,
+		Direction:        SecurityRuleDirectionOutbound,
+		Action:           SecurityRuleActionDeny,
+		Priority:         OutboundDenyRulePriority,
+		Source:           ptr.To("*"),
+		SourcePorts:      ptr.To("*"),
+		Destination:      ptr.To("*"),
+		DestinationPorts: ptr.To("*"),
+	})
+}
+
 // IsNatGatewayEnabled returns whether or not a NAT gateway is enabled on the subnet.
 func (s SubnetSpec) IsNatGatewayEnabled() bool {
 	return s.NatGateway.Name != ""
@@ -828,6 +1412,19 @@ func (s SubnetSpec) IsIPv6Enabled() bool {
 	return false
 }
 
+// IsDualStack returns whether or not the subnet has both an IPv4 and an IPv6 CIDR block.
+func (s SubnetSpec) IsDualStack() bool {
+	var hasIPv4, hasIPv6 bool
+	for _, cidr := range s.CIDRBlocks {
+		if net.IsIPv6CIDRString(cidr) {
+			hasIPv6 = true
+		} else if net.IsIPv4CIDRString(cidr) {
+			hasIPv4 = true
+		}
+	}
+	return hasIPv4 && hasIPv6
+}
+
 // SecurityProfile specifies the Security profile settings for a
 // virtual machine or virtual machine scale set.
 type SecurityProfile struct {
@@ -964,6 +1561,13 @@ const (
 	StandardBastionHostSku BastionHostSkuName = "Standard"
 )
 
+const (
+	// MinBastionHostScaleUnits is the minimum number of scale units of a Standard Azure Bastion Host.
+	MinBastionHostScaleUnits int32 = 2
+	// MaxBastionHostScaleUnits is the maximum number of scale units of a Standard Azure Bastion Host.
+	MaxBastionHostScaleUnits int32 = 50
+)
+
 // BastionSpec specifies how the Bastion feature should be set up for the cluster.
 type BastionSpec struct {
 	// +optional
@@ -987,6 +1591,11 @@ type AzureBastion struct {
 	// +kubebuilder:default=false
 	// +optional
 	EnableTunneling bool `json:"enableTunneling,omitempty"`
+	// ScaleUnits is the number of scale units of the Azure Bastion Host. Only supported with the Standard SKU.
+	// +kubebuilder:validation:Minimum=2
+	// +kubebuilder:validation:Maximum=50
+	// +optional
+	ScaleUnits *int32 `json:"scaleUnits,omitempty"`
 }
 
 // BackendPool describes the backend pool of the load balancer.
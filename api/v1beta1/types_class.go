@@ -31,6 +31,12 @@ type AzureClusterClassSpec struct {
 	// +optional
 	ExtendedLocation *ExtendedLocationSpec `json:"extendedLocation,omitempty"`
 
+	// SecondaryLocation is the Azure region the cluster would fail over to for disaster recovery. CAPZ doesn't create
+	// any resource in it, but the VM sizes and availability zones of the machines of the cluster are validated to be
+	// available in it so that the cluster can be failed over.
+	// +optional
+	SecondaryLocation string `json:"secondaryLocation,omitempty"`
+
 	// AdditionalTags is an optional set of tags to add to Azure resources managed by the Azure provider, in addition to the
 	// ones added by default.
 	// +optional
@@ -46,9 +52,17 @@ type AzureClusterClassSpec struct {
 	// - GermanCloud: "AzureGermanCloud"
 	// - PublicCloud: "AzurePublicCloud"
 	// - USGovernmentCloud: "AzureUSGovernmentCloud"
+	// - StackCloud: "AzureStackCloud", a custom cloud whose environment is read from the file set in the
+	// AZURE_ENVIRONMENT_FILEPATH environment variable
 	// +optional
 	AzureEnvironment string `json:"azureEnvironment,omitempty"`
 
+	// TokenAudience is the audience of the Azure Resource Manager tokens requested by the cluster identity and by the
+	// identities of the VMs. It can only be set for the AzureStackCloud environment and defaults to the token audience
+	// of its environment file, or to its resource manager endpoint.
+	// +optional
+	TokenAudience string `json:"tokenAudience,omitempty"`
+
 	// CloudProviderConfigOverrides is an optional set of configuration values that can be overridden in azure cloud provider config.
 	// This is only a subset of options that are available in azure cloud provider config.
 	// Some values for the cloud provider config are inferred from other parts of cluster api provider azure spec, and may not be available for overrides.
@@ -91,8 +105,12 @@ type SubnetClassSpec struct {
 	// Name defines a name for the subnet resource.
 	Name string `json:"name"`
 
-	// Role defines the subnet role (eg. Node, ControlPlane)
-	// +kubebuilder:validation:Enum=node;control-plane;bastion
+	// Role defines the subnet role (eg. Node, ControlPlane, Gateway, Pod, RouteServer).
+	// A subnet with the gateway role hosts virtual network gateways such as an ExpressRoute gateway, and must be named "GatewaySubnet".
+	// A subnet with the pod role hosts the pod IPs allocated by a self-managed Azure CNI.
+	// A subnet with the route-server role hosts an Azure Route Server peering over BGP with network virtual appliances,
+	// and must be named "RouteServerSubnet".
+	// +kubebuilder:validation:Enum=node;control-plane;bastion;gateway;pod;route-server
 	Role SubnetRole `json:"role"`
 
 	// CIDRBlocks defines the subnet's address space, specified as one or more address prefixes in CIDR notation.
@@ -125,6 +143,11 @@ type SecurityGroupClass struct {
 	SecurityRules SecurityRules `json:"securityRules,omitempty"`
 	// +optional
 	Tags Tags `json:"tags,omitempty"`
+	// RulePriorityBand reserves a range of priorities assigned to the security rules without a priority. The priority
+	// assigned to a rule is kept across reconciliations, and the rules with a priority can't use the priorities of the
+	// band. It can't be changed once set.
+	// +optional
+	RulePriorityBand *SecurityRulePriorityBand `json:"rulePriorityBand,omitempty"`
 }
 
 // FrontendIPClass defines the FrontendIP properties that may be shared across several Azure clusters.
@@ -72,6 +72,11 @@ func (in *APIServerAccessProfile) DeepCopyInto(out *APIServerAccessProfile) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AuthorizedIPRangesConfigMapRef != nil {
+		in, out := &in.AuthorizedIPRangesConfigMapRef, &out.AuthorizedIPRangesConfigMapRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
 	if in.EnablePrivateCluster != nil {
 		in, out := &in.EnablePrivateCluster, &out.EnablePrivateCluster
 		*out = new(bool)
@@ -102,6 +107,11 @@ func (in *APIServerAccessProfile) DeepCopy() *APIServerAccessProfile {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AdditionalCapabilities) DeepCopyInto(out *AdditionalCapabilities) {
 	*out = *in
+	if in.HibernationEnabled != nil {
+		in, out := &in.HibernationEnabled, &out.HibernationEnabled
+		*out = new(bool)
+		**out = **in
+	}
 	if in.UltraSSDEnabled != nil {
 		in, out := &in.UltraSSDEnabled, &out.UltraSSDEnabled
 		*out = new(bool)
@@ -181,6 +191,21 @@ func (in *AllowedNamespaces) DeepCopy() *AllowedNamespaces {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AttachExistingDisk) DeepCopyInto(out *AttachExistingDisk) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AttachExistingDisk.
+func (in *AttachExistingDisk) DeepCopy() *AttachExistingDisk {
+	if in == nil {
+		return nil
+	}
+	out := new(AttachExistingDisk)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AutoScalerProfile) DeepCopyInto(out *AutoScalerProfile) {
 	*out = *in
@@ -281,11 +306,31 @@ func (in *AutoScalerProfile) DeepCopy() *AutoScalerProfile {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AvailabilitySetReference) DeepCopyInto(out *AvailabilitySetReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AvailabilitySetReference.
+func (in *AvailabilitySetReference) DeepCopy() *AvailabilitySetReference {
+	if in == nil {
+		return nil
+	}
+	out := new(AvailabilitySetReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AzureBastion) DeepCopyInto(out *AzureBastion) {
 	*out = *in
 	in.Subnet.DeepCopyInto(&out.Subnet)
 	in.PublicIP.DeepCopyInto(&out.PublicIP)
+	if in.ScaleUnits != nil {
+		in, out := &in.ScaleUnits, &out.ScaleUnits
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureBastion.
@@ -775,6 +820,11 @@ func (in *AzureMachineSpec) DeepCopyInto(out *AzureMachineSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ExtendedLocation != nil {
+		in, out := &in.ExtendedLocation, &out.ExtendedLocation
+		*out = new(ExtendedLocationSpec)
+		**out = **in
+	}
 	if in.Image != nil {
 		in, out := &in.Image, &out.Image
 		*out = new(Image)
@@ -788,7 +838,7 @@ func (in *AzureMachineSpec) DeepCopyInto(out *AzureMachineSpec) {
 	if in.SystemAssignedIdentityRole != nil {
 		in, out := &in.SystemAssignedIdentityRole, &out.SystemAssignedIdentityRole
 		*out = new(SystemAssignedIdentityRole)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	in.OSDisk.DeepCopyInto(&out.OSDisk)
 	if in.DataDisks != nil {
@@ -798,6 +848,11 @@ func (in *AzureMachineSpec) DeepCopyInto(out *AzureMachineSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SSHPublicKeySecretRef != nil {
+		in, out := &in.SSHPublicKeySecretRef, &out.SSHPublicKeySecretRef
+		*out = new(KeyVaultSecretReference)
+		**out = **in
+	}
 	if in.AdditionalTags != nil {
 		in, out := &in.AdditionalTags, &out.AdditionalTags
 		*out = make(Tags, len(*in))
@@ -849,6 +904,53 @@ func (in *AzureMachineSpec) DeepCopyInto(out *AzureMachineSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LocalNVMeStorage != nil {
+		in, out := &in.LocalNVMeStorage, &out.LocalNVMeStorage
+		*out = new(LocalNVMeStorage)
+		**out = **in
+	}
+	if in.DataDiskArray != nil {
+		in, out := &in.DataDiskArray, &out.DataDiskArray
+		*out = new(DataDiskArray)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BootstrapCompletionCheck != nil {
+		in, out := &in.BootstrapCompletionCheck, &out.BootstrapCompletionCheck
+		*out = new(BootstrapCompletionCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AvailabilitySet != nil {
+		in, out := &in.AvailabilitySet, &out.AvailabilitySet
+		*out = new(AvailabilitySetReference)
+		**out = **in
+	}
+	if in.PlatformFaultDomainCount != nil {
+		in, out := &in.PlatformFaultDomainCount, &out.PlatformFaultDomainCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.VirtualMachineScaleSet != nil {
+		in, out := &in.VirtualMachineScaleSet, &out.VirtualMachineScaleSet
+		*out = new(VirtualMachineScaleSetReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RunCommands != nil {
+		in, out := &in.RunCommands, &out.RunCommands
+		*out = make([]RunCommand, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StartStopSchedule != nil {
+		in, out := &in.StartStopSchedule, &out.StartStopSchedule
+		*out = new(StartStopSchedule)
+		**out = **in
+	}
+	if in.NodeLabelTags != nil {
+		in, out := &in.NodeLabelTags, &out.NodeLabelTags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureMachineSpec.
@@ -874,6 +976,11 @@ func (in *AzureMachineStatus) DeepCopyInto(out *AzureMachineStatus) {
 		*out = new(ProvisioningState)
 		**out = **in
 	}
+	if in.FaultDomain != nil {
+		in, out := &in.FaultDomain, &out.FaultDomain
+		*out = new(int32)
+		**out = **in
+	}
 	if in.FailureReason != nil {
 		in, out := &in.FailureReason, &out.FailureReason
 		*out = new(errors.MachineStatusError)
@@ -1200,6 +1307,11 @@ func (in *AzureManagedControlPlaneSpec) DeepCopyInto(out *AzureManagedControlPla
 		*out = new(AADProfile)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DisableLocalAccounts != nil {
+		in, out := &in.DisableLocalAccounts, &out.DisableLocalAccounts
+		*out = new(bool)
+		**out = **in
+	}
 	if in.AddonProfiles != nil {
 		in, out := &in.AddonProfiles, &out.AddonProfiles
 		*out = make([]AddonProfile, len(*in))
@@ -1207,6 +1319,16 @@ func (in *AzureManagedControlPlaneSpec) DeepCopyInto(out *AzureManagedControlPla
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.VirtualNodes != nil {
+		in, out := &in.VirtualNodes, &out.VirtualNodes
+		*out = new(VirtualNodes)
+		**out = **in
+	}
+	if in.KeyVaultSecretsProvider != nil {
+		in, out := &in.KeyVaultSecretsProvider, &out.KeyVaultSecretsProvider
+		*out = new(KeyVaultSecretsProvider)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.SKU != nil {
 		in, out := &in.SKU, &out.SKU
 		*out = new(AKSSku)
@@ -1230,7 +1352,7 @@ func (in *AzureManagedControlPlaneSpec) DeepCopyInto(out *AzureManagedControlPla
 	if in.Identity != nil {
 		in, out := &in.Identity, &out.Identity
 		*out = new(Identity)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -1447,6 +1569,21 @@ func (in *AzureManagedMachinePoolSpec) DeepCopyInto(out *AzureManagedMachinePool
 		*out = new(bool)
 		**out = **in
 	}
+	if in.NodeImageVersion != nil {
+		in, out := &in.NodeImageVersion, &out.NodeImageVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.DrainTimeoutInMinutes != nil {
+		in, out := &in.DrainTimeoutInMinutes, &out.DrainTimeoutInMinutes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SpotEvictionWebhook != nil {
+		in, out := &in.SpotEvictionWebhook, &out.SpotEvictionWebhook
+		*out = new(SpotEvictionWebhook)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureManagedMachinePoolSpec.
@@ -1500,6 +1637,11 @@ func (in *AzureManagedMachinePoolStatus) DeepCopy() *AzureManagedMachinePoolStat
 func (in *AzureMarketplaceImage) DeepCopyInto(out *AzureMarketplaceImage) {
 	*out = *in
 	out.ImagePlan = in.ImagePlan
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(ImagePlan)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureMarketplaceImage.
@@ -1642,6 +1784,26 @@ func (in *BootDiagnostics) DeepCopy() *BootDiagnostics {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapCompletionCheck) DeepCopyInto(out *BootstrapCompletionCheck) {
+	*out = *in
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapCompletionCheck.
+func (in *BootstrapCompletionCheck) DeepCopy() *BootstrapCompletionCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapCompletionCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BuildParams) DeepCopyInto(out *BuildParams) {
 	*out = *in
@@ -1710,6 +1872,36 @@ func (in *DataDisk) DeepCopyInto(out *DataDisk) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.DiskIOPSReadWrite != nil {
+		in, out := &in.DiskIOPSReadWrite, &out.DiskIOPSReadWrite
+		*out = new(int64)
+		**out = **in
+	}
+	if in.DiskMBpsReadWrite != nil {
+		in, out := &in.DiskMBpsReadWrite, &out.DiskMBpsReadWrite
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxShares != nil {
+		in, out := &in.MaxShares, &out.MaxShares
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AttachExistingDisk != nil {
+		in, out := &in.AttachExistingDisk, &out.AttachExistingDisk
+		*out = new(AttachExistingDisk)
+		**out = **in
+	}
+	if in.BurstingEnabled != nil {
+		in, out := &in.BurstingEnabled, &out.BurstingEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PerformanceTierSchedule != nil {
+		in, out := &in.PerformanceTierSchedule, &out.PerformanceTierSchedule
+		*out = new(DiskPerformanceTierSchedule)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataDisk.
@@ -1722,6 +1914,26 @@ func (in *DataDisk) DeepCopy() *DataDisk {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataDiskArray) DeepCopyInto(out *DataDiskArray) {
+	*out = *in
+	if in.DataDisks != nil {
+		in, out := &in.DataDisks, &out.DataDisks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataDiskArray.
+func (in *DataDiskArray) DeepCopy() *DataDiskArray {
+	if in == nil {
+		return nil
+	}
+	out := new(DataDiskArray)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Diagnostics) DeepCopyInto(out *Diagnostics) {
 	*out = *in
@@ -1757,6 +1969,21 @@ func (in *DiffDiskSettings) DeepCopy() *DiffDiskSettings {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskPerformanceTierSchedule) DeepCopyInto(out *DiskPerformanceTierSchedule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskPerformanceTierSchedule.
+func (in *DiskPerformanceTierSchedule) DeepCopy() *DiskPerformanceTierSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskPerformanceTierSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DiskEncryptionSetParameters) DeepCopyInto(out *DiskEncryptionSetParameters) {
 	*out = *in
@@ -1772,6 +1999,26 @@ func (in *DiskEncryptionSetParameters) DeepCopy() *DiskEncryptionSetParameters {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EgressValidationSpec) DeepCopyInto(out *EgressValidationSpec) {
+	*out = *in
+	if in.RequiredDestinations != nil {
+		in, out := &in.RequiredDestinations, &out.RequiredDestinations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressValidationSpec.
+func (in *EgressValidationSpec) DeepCopy() *EgressValidationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EgressValidationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtendedLocationSpec) DeepCopyInto(out *ExtendedLocationSpec) {
 	*out = *in
@@ -1857,6 +2104,31 @@ func (in Futures) DeepCopy() Futures {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthProbeSpec) DeepCopyInto(out *HealthProbeSpec) {
+	*out = *in
+	if in.IntervalInSeconds != nil {
+		in, out := &in.IntervalInSeconds, &out.IntervalInSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NumberOfProbes != nil {
+		in, out := &in.NumberOfProbes, &out.NumberOfProbes
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthProbeSpec.
+func (in *HealthProbeSpec) DeepCopy() *HealthProbeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthProbeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IPTag) DeepCopyInto(out *IPTag) {
 	*out = *in
@@ -1875,6 +2147,11 @@ func (in *IPTag) DeepCopy() *IPTag {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Identity) DeepCopyInto(out *Identity) {
 	*out = *in
+	if in.NodeResourceGroupRoleAssignment != nil {
+		in, out := &in.NodeResourceGroupRoleAssignment, &out.NodeResourceGroupRoleAssignment
+		*out = new(NodeResourceGroupRoleAssignment)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity.
@@ -1903,7 +2180,7 @@ func (in *Image) DeepCopyInto(out *Image) {
 	if in.Marketplace != nil {
 		in, out := &in.Marketplace, &out.Marketplace
 		*out = new(AzureMarketplaceImage)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.ComputeGallery != nil {
 		in, out := &in.ComputeGallery, &out.ComputeGallery
@@ -1937,6 +2214,86 @@ func (in *ImagePlan) DeepCopy() *ImagePlan {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InboundNATRulesSpec) DeepCopyInto(out *InboundNATRulesSpec) {
+	*out = *in
+	if in.IdleTimeoutInMinutes != nil {
+		in, out := &in.IdleTimeoutInMinutes, &out.IdleTimeoutInMinutes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.EnableTCPReset != nil {
+		in, out := &in.EnableTCPReset, &out.EnableTCPReset
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnableFloatingIP != nil {
+		in, out := &in.EnableFloatingIP, &out.EnableFloatingIP
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InboundNATRulesSpec.
+func (in *InboundNATRulesSpec) DeepCopy() *InboundNATRulesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InboundNATRulesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyVaultRoleAssignment) DeepCopyInto(out *KeyVaultRoleAssignment) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyVaultRoleAssignment.
+func (in *KeyVaultRoleAssignment) DeepCopy() *KeyVaultRoleAssignment {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyVaultRoleAssignment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyVaultSecretReference) DeepCopyInto(out *KeyVaultSecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyVaultSecretReference.
+func (in *KeyVaultSecretReference) DeepCopy() *KeyVaultSecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyVaultSecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyVaultSecretsProvider) DeepCopyInto(out *KeyVaultSecretsProvider) {
+	*out = *in
+	if in.KeyVaultRoleAssignment != nil {
+		in, out := &in.KeyVaultRoleAssignment, &out.KeyVaultRoleAssignment
+		*out = new(KeyVaultRoleAssignment)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyVaultSecretsProvider.
+func (in *KeyVaultSecretsProvider) DeepCopy() *KeyVaultSecretsProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyVaultSecretsProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeletConfig) DeepCopyInto(out *KubeletConfig) {
 	*out = *in
@@ -2118,6 +2475,26 @@ func (in *LoadBalancerSpec) DeepCopyInto(out *LoadBalancerSpec) {
 		**out = **in
 	}
 	out.BackendPool = in.BackendPool
+	if in.AdditionalBackendPools != nil {
+		in, out := &in.AdditionalBackendPools, &out.AdditionalBackendPools
+		*out = make([]BackendPool, len(*in))
+		copy(*out, *in)
+	}
+	if in.InboundNATRules != nil {
+		in, out := &in.InboundNATRules, &out.InboundNATRules
+		*out = new(InboundNATRulesSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EnableFloatingIP != nil {
+		in, out := &in.EnableFloatingIP, &out.EnableFloatingIP
+		*out = new(bool)
+		**out = **in
+	}
+	if in.HealthProbe != nil {
+		in, out := &in.HealthProbe, &out.HealthProbe
+		*out = new(HealthProbeSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	in.LoadBalancerClassSpec.DeepCopyInto(&out.LoadBalancerClassSpec)
 }
 
@@ -2131,6 +2508,21 @@ func (in *LoadBalancerSpec) DeepCopy() *LoadBalancerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalNVMeStorage) DeepCopyInto(out *LocalNVMeStorage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalNVMeStorage.
+func (in *LocalNVMeStorage) DeepCopy() *LocalNVMeStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalNVMeStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManagedControlPlaneSubnet) DeepCopyInto(out *ManagedControlPlaneSubnet) {
 	*out = *in
@@ -2189,6 +2581,11 @@ func (in *ManagedDiskParameters) DeepCopyInto(out *ManagedDiskParameters) {
 		*out = new(VMDiskSecurityProfile)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LogicalSectorSize != nil {
+		in, out := &in.LogicalSectorSize, &out.LogicalSectorSize
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDiskParameters.
@@ -2281,6 +2678,16 @@ func (in *NetworkInterface) DeepCopyInto(out *NetworkInterface) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.SecondaryIPConfigs != nil {
+		in, out := &in.SecondaryIPConfigs, &out.SecondaryIPConfigs
+		*out = make([]SecondaryIPConfig, len(*in))
+		copy(*out, *in)
+	}
+	if in.VirtualNetworkTap != nil {
+		in, out := &in.VirtualNetworkTap, &out.VirtualNetworkTap
+		*out = new(VirtualNetworkTapReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkInterface.
@@ -2315,6 +2722,26 @@ func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
 		*out = new(LoadBalancerSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.OutboundPublicIPPrefix != nil {
+		in, out := &in.OutboundPublicIPPrefix, &out.OutboundPublicIPPrefix
+		*out = new(PublicIPPrefixSpec)
+		**out = **in
+	}
+	if in.OutboundDeny != nil {
+		in, out := &in.OutboundDeny, &out.OutboundDeny
+		*out = new(OutboundDenySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EgressValidation != nil {
+		in, out := &in.EgressValidation, &out.EgressValidation
+		*out = new(EgressValidationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TrafficManager != nil {
+		in, out := &in.TrafficManager, &out.TrafficManager
+		*out = new(TrafficManagerSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	out.NetworkClassSpec = in.NetworkClassSpec
 }
 
@@ -2363,6 +2790,21 @@ func (in *NetworkTemplateSpec) DeepCopy() *NetworkTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeResourceGroupRoleAssignment) DeepCopyInto(out *NodeResourceGroupRoleAssignment) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeResourceGroupRoleAssignment.
+func (in *NodeResourceGroupRoleAssignment) DeepCopy() *NodeResourceGroupRoleAssignment {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeResourceGroupRoleAssignment)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OSDisk) DeepCopyInto(out *OSDisk) {
 	*out = *in
@@ -2393,6 +2835,26 @@ func (in *OSDisk) DeepCopy() *OSDisk {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutboundDenySpec) DeepCopyInto(out *OutboundDenySpec) {
+	*out = *in
+	if in.AllowedDestinations != nil {
+		in, out := &in.AllowedDestinations, &out.AllowedDestinations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutboundDenySpec.
+func (in *OutboundDenySpec) DeepCopy() *OutboundDenySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OutboundDenySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PrivateEndpointSpec) DeepCopyInto(out *PrivateEndpointSpec) {
 	*out = *in
@@ -2466,6 +2928,21 @@ func (in *PrivateLinkServiceConnection) DeepCopy() *PrivateLinkServiceConnection
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PublicIPPrefixSpec) DeepCopyInto(out *PublicIPPrefixSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublicIPPrefixSpec.
+func (in *PublicIPPrefixSpec) DeepCopy() *PublicIPPrefixSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PublicIPPrefixSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PublicIPSpec) DeepCopyInto(out *PublicIPSpec) {
 	*out = *in
@@ -2474,6 +2951,11 @@ func (in *PublicIPSpec) DeepCopyInto(out *PublicIPSpec) {
 		*out = make([]IPTag, len(*in))
 		copy(*out, *in)
 	}
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublicIPSpec.
@@ -2527,9 +3009,29 @@ func (in *RateLimitSpec) DeepCopy() *RateLimitSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Route) DeepCopyInto(out *Route) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Route.
+func (in *Route) DeepCopy() *Route {
+	if in == nil {
+		return nil
+	}
+	out := new(Route)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RouteTable) DeepCopyInto(out *RouteTable) {
 	*out = *in
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]Route, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteTable.
@@ -2542,6 +3044,77 @@ func (in *RouteTable) DeepCopy() *RouteTable {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunCommand) DeepCopyInto(out *RunCommand) {
+	*out = *in
+	out.Source = in.Source
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]RunCommandParameter, len(*in))
+		copy(*out, *in)
+	}
+	if in.TimeoutInSeconds != nil {
+		in, out := &in.TimeoutInSeconds, &out.TimeoutInSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunCommand.
+func (in *RunCommand) DeepCopy() *RunCommand {
+	if in == nil {
+		return nil
+	}
+	out := new(RunCommand)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunCommandParameter) DeepCopyInto(out *RunCommandParameter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunCommandParameter.
+func (in *RunCommandParameter) DeepCopy() *RunCommandParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(RunCommandParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunCommandSource) DeepCopyInto(out *RunCommandSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunCommandSource.
+func (in *RunCommandSource) DeepCopy() *RunCommandSource {
+	if in == nil {
+		return nil
+	}
+	out := new(RunCommandSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecondaryIPConfig) DeepCopyInto(out *SecondaryIPConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecondaryIPConfig.
+func (in *SecondaryIPConfig) DeepCopy() *SecondaryIPConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SecondaryIPConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecurityGroup) DeepCopyInto(out *SecurityGroup) {
 	*out = *in
@@ -2575,6 +3148,11 @@ func (in *SecurityGroupClass) DeepCopyInto(out *SecurityGroupClass) {
 			(*out)[key] = val
 		}
 	}
+	if in.RulePriorityBand != nil {
+		in, out := &in.RulePriorityBand, &out.RulePriorityBand
+		*out = new(SecurityRulePriorityBand)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityGroupClass.
@@ -2635,6 +3213,26 @@ func (in *SecurityRule) DeepCopyInto(out *SecurityRule) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Destinations != nil {
+		in, out := &in.Destinations, &out.Destinations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SourceApplicationSecurityGroups != nil {
+		in, out := &in.SourceApplicationSecurityGroups, &out.SourceApplicationSecurityGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DestinationApplicationSecurityGroups != nil {
+		in, out := &in.DestinationApplicationSecurityGroups, &out.DestinationApplicationSecurityGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityRule.
@@ -2647,6 +3245,21 @@ func (in *SecurityRule) DeepCopy() *SecurityRule {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecurityRulePriorityBand) DeepCopyInto(out *SecurityRulePriorityBand) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityRulePriorityBand.
+func (in *SecurityRulePriorityBand) DeepCopy() *SecurityRulePriorityBand {
+	if in == nil {
+		return nil
+	}
+	out := new(SecurityRulePriorityBand)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in SecurityRules) DeepCopyInto(out *SecurityRules) {
 	{
@@ -2709,6 +3322,21 @@ func (in ServiceEndpoints) DeepCopy() ServiceEndpoints {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpotEvictionWebhook) DeepCopyInto(out *SpotEvictionWebhook) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpotEvictionWebhook.
+func (in *SpotEvictionWebhook) DeepCopy() *SpotEvictionWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(SpotEvictionWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SpotVMOptions) DeepCopyInto(out *SpotVMOptions) {
 	*out = *in
@@ -2734,6 +3362,21 @@ func (in *SpotVMOptions) DeepCopy() *SpotVMOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StartStopSchedule) DeepCopyInto(out *StartStopSchedule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StartStopSchedule.
+func (in *StartStopSchedule) DeepCopy() *StartStopSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(StartStopSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SubnetClassSpec) DeepCopyInto(out *SubnetClassSpec) {
 	*out = *in
@@ -2772,7 +3415,7 @@ func (in *SubnetClassSpec) DeepCopy() *SubnetClassSpec {
 func (in *SubnetSpec) DeepCopyInto(out *SubnetSpec) {
 	*out = *in
 	in.SecurityGroup.DeepCopyInto(&out.SecurityGroup)
-	out.RouteTable = in.RouteTable
+	in.RouteTable.DeepCopyInto(&out.RouteTable)
 	in.NatGateway.DeepCopyInto(&out.NatGateway)
 	in.SubnetClassSpec.DeepCopyInto(&out.SubnetClassSpec)
 }
@@ -3002,9 +3645,29 @@ func (in *SysctlConfig) DeepCopy() *SysctlConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SystemAssignedIdentityAdditionalRole) DeepCopyInto(out *SystemAssignedIdentityAdditionalRole) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SystemAssignedIdentityAdditionalRole.
+func (in *SystemAssignedIdentityAdditionalRole) DeepCopy() *SystemAssignedIdentityAdditionalRole {
+	if in == nil {
+		return nil
+	}
+	out := new(SystemAssignedIdentityAdditionalRole)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SystemAssignedIdentityRole) DeepCopyInto(out *SystemAssignedIdentityRole) {
 	*out = *in
+	if in.AdditionalRoles != nil {
+		in, out := &in.AdditionalRoles, &out.AdditionalRoles
+		*out = make([]SystemAssignedIdentityAdditionalRole, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SystemAssignedIdentityRole.
@@ -3072,6 +3735,53 @@ func (in Taints) DeepCopy() Taints {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficManagerEndpoint) DeepCopyInto(out *TrafficManagerEndpoint) {
+	*out = *in
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficManagerEndpoint.
+func (in *TrafficManagerEndpoint) DeepCopy() *TrafficManagerEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficManagerEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficManagerSpec) DeepCopyInto(out *TrafficManagerSpec) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]TrafficManagerEndpoint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficManagerSpec.
+func (in *TrafficManagerSpec) DeepCopy() *TrafficManagerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficManagerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UefiSettings) DeepCopyInto(out *UefiSettings) {
 	*out = *in
@@ -3176,6 +3886,56 @@ func (in *VMExtension) DeepCopy() *VMExtension {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualMachineScaleSetReference) DeepCopyInto(out *VirtualMachineScaleSetReference) {
+	*out = *in
+	if in.PlatformFaultDomain != nil {
+		in, out := &in.PlatformFaultDomain, &out.PlatformFaultDomain
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualMachineScaleSetReference.
+func (in *VirtualMachineScaleSetReference) DeepCopy() *VirtualMachineScaleSetReference {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualMachineScaleSetReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualNetworkTapReference) DeepCopyInto(out *VirtualNetworkTapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualNetworkTapReference.
+func (in *VirtualNetworkTapReference) DeepCopy() *VirtualNetworkTapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualNetworkTapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualNodes) DeepCopyInto(out *VirtualNodes) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualNodes.
+func (in *VirtualNodes) DeepCopy() *VirtualNodes {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualNodes)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VnetClassSpec) DeepCopyInto(out *VnetClassSpec) {
 	*out = *in
@@ -3323,6 +4083,11 @@ func (in *VnetSpec) DeepCopyInto(out *VnetSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.FlowTimeoutInMinutes != nil {
+		in, out := &in.FlowTimeoutInMinutes, &out.FlowTimeoutInMinutes
+		*out = new(int32)
+		**out = **in
+	}
 	in.VnetClassSpec.DeepCopyInto(&out.VnetClassSpec)
 }
 
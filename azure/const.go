@@ -23,6 +23,30 @@ const (
 	// for annotation formatting rules.
 	VMTagsLastAppliedAnnotation = "sigs.k8s.io/cluster-api-provider-azure-last-applied-tags-vm"
 
+	// NICTagsLastAppliedAnnotation is the key for the machine object annotation
+	// which tracks the AdditionalTags applied to the machine's network interfaces.
+	// See https://kubernetes.io/docs/concepts/overview/working-with-objects/annotations/
+	// for annotation formatting rules.
+	NICTagsLastAppliedAnnotation = "sigs.k8s.io/cluster-api-provider-azure-last-applied-tags-nic"
+
+	// DiskTagsLastAppliedAnnotation is the key for the machine object annotation
+	// which tracks the AdditionalTags applied to the machine's OS and data disks.
+	// See https://kubernetes.io/docs/concepts/overview/working-with-objects/annotations/
+	// for annotation formatting rules.
+	DiskTagsLastAppliedAnnotation = "sigs.k8s.io/cluster-api-provider-azure-last-applied-tags-disk"
+
+	// PublicIPTagsLastAppliedAnnotation is the key for the machine object annotation
+	// which tracks the AdditionalTags applied to the machine's public IP.
+	// See https://kubernetes.io/docs/concepts/overview/working-with-objects/annotations/
+	// for annotation formatting rules.
+	PublicIPTagsLastAppliedAnnotation = "sigs.k8s.io/cluster-api-provider-azure-last-applied-tags-publicip"
+
+	// ClusterResourcesTagsLastAppliedAnnotation is the key for the Azure Cluster object annotation
+	// which tracks the AdditionalTags applied to the network resources managed for the cluster.
+	// See https://kubernetes.io/docs/concepts/overview/working-with-objects/annotations/
+	// for annotation formatting rules.
+	ClusterResourcesTagsLastAppliedAnnotation = "sigs.k8s.io/cluster-api-provider-azure-last-applied-tags-cluster-resources"
+
 	// RGTagsLastAppliedAnnotation is the key for the Azure Cluster object annotation
 	// which tracks the AdditionalTags for Resource Group which is part in the Azure Cluster.
 	// See https://kubernetes.io/docs/concepts/overview/working-with-objects/annotations/
@@ -41,9 +65,35 @@ const (
 	// for annotation formatting rules.
 	SecurityRuleLastAppliedAnnotation = "sigs.k8s.io/cluster-api-provider-azure-last-applied-security-rules"
 
+	// RouteLastAppliedAnnotation is the key for the Azure Cluster
+	// object annotation which tracks the user-defined routes for route tables.
+	// See https://kubernetes.io/docs/concepts/overview/working-with-objects/annotations/
+	// for annotation formatting rules.
+	RouteLastAppliedAnnotation = "sigs.k8s.io/cluster-api-provider-azure-last-applied-routes"
+
+	// VirtualNetworkTapLastAppliedAnnotation is the key for the Azure Machine
+	// object annotation which tracks the virtual network taps of its network interfaces.
+	// See https://kubernetes.io/docs/concepts/overview/working-with-objects/annotations/
+	// for annotation formatting rules.
+	VirtualNetworkTapLastAppliedAnnotation = "sigs.k8s.io/cluster-api-provider-azure-last-applied-virtual-network-taps"
+
 	// CustomDataHashAnnotation is the key for the machine object annotation
 	// which tracks the hash of the custom data.
 	// See https://kubernetes.io/docs/concepts/overview/working-with-objects/annotations/
 	// for annotation formatting rules.
 	CustomDataHashAnnotation = "sigs.k8s.io/cluster-api-provider-azure-vmss-custom-data-hash"
+
+	// OSDiskResizeAnnotation is the key for the machine object annotation
+	// which tracks the state of the in-place resize of the OS disk of the machine's VM,
+	// while the VM is deallocated to grow its OS disk and started again.
+	// See https://kubernetes.io/docs/concepts/overview/working-with-objects/annotations/
+	// for annotation formatting rules.
+	OSDiskResizeAnnotation = "sigs.k8s.io/cluster-api-provider-azure-os-disk-resize"
+
+	// LockedResourcesAnnotation is the key for the Azure Cluster and Azure Machine
+	// object annotation which tracks when each Azure resource was last found to be
+	// locked by a management lock.
+	// See https://kubernetes.io/docs/concepts/overview/working-with-objects/annotations/
+	// for annotation formatting rules.
+	LockedResourcesAnnotation = "sigs.k8s.io/cluster-api-provider-azure-locked-resources"
 )
@@ -106,7 +106,14 @@ func ImageToPlan(image *infrav1.Image) *compute.Plan {
 		}
 	}
 
-	// Plan is needed for third party Marketplace images.
+	// Plan is needed for third party Marketplace images, whose purchase plan may differ from the image reference.
+	if image.Marketplace != nil && image.Marketplace.Plan != nil {
+		return &compute.Plan{
+			Publisher: ptr.To(image.Marketplace.Plan.Publisher),
+			Name:      ptr.To(image.Marketplace.Plan.SKU),
+			Product:   ptr.To(image.Marketplace.Plan.Offer),
+		}
+	}
 	if image.Marketplace != nil && image.Marketplace.ThirdPartyImage {
 		return &compute.Plan{
 			Publisher: ptr.To(image.Marketplace.Publisher),
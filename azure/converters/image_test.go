@@ -125,6 +125,31 @@ func Test_ImageToPlan(t *testing.T) {
 				}))
 			},
 		},
+		{
+			name: "Should return the purchase plan of a Marketplace image with plan details",
+			image: &infrav1.Image{
+				Marketplace: &infrav1.AzureMarketplaceImage{
+					ImagePlan: infrav1.ImagePlan{
+						Publisher: "my-publisher",
+						Offer:     "my-offer",
+						SKU:       "my-sku-gen2",
+					},
+					Version: "v0.5.0",
+					Plan: &infrav1.ImagePlan{
+						Publisher: "my-publisher",
+						Offer:     "my-product",
+						SKU:       "my-plan",
+					},
+				},
+			},
+			expect: func(g *GomegaWithT, result *compute.Plan) {
+				g.Expect(result).To(Equal(&compute.Plan{
+					Name:      ptr.To("my-plan"),
+					Publisher: ptr.To("my-publisher"),
+					Product:   ptr.To("my-product"),
+				}))
+			},
+		},
 		{
 			name: "Should return nil for an image ID",
 			image: &infrav1.Image{
@@ -37,6 +37,23 @@ func SecurityRuleToSDK(rule infrav1.SecurityRule) network.SecurityRule {
 		},
 	}
 
+	if len(rule.Sources) > 0 {
+		secRule.SourceAddressPrefix = nil
+		secRule.SourceAddressPrefixes = ptr.To(append([]string{}, rule.Sources...))
+	}
+	if len(rule.Destinations) > 0 {
+		secRule.DestinationAddressPrefix = nil
+		secRule.DestinationAddressPrefixes = ptr.To(append([]string{}, rule.Destinations...))
+	}
+	if len(rule.SourceApplicationSecurityGroups) > 0 {
+		secRule.SourceAddressPrefix = nil
+		secRule.SourceApplicationSecurityGroups = applicationSecurityGroupsToSDK(rule.SourceApplicationSecurityGroups)
+	}
+	if len(rule.DestinationApplicationSecurityGroups) > 0 {
+		secRule.DestinationAddressPrefix = nil
+		secRule.DestinationApplicationSecurityGroups = applicationSecurityGroupsToSDK(rule.DestinationApplicationSecurityGroups)
+	}
+
 	switch rule.Protocol {
 	case infrav1.SecurityGroupProtocolAll:
 		secRule.Protocol = network.SecurityRuleProtocolAsterisk
@@ -48,6 +65,10 @@ func SecurityRuleToSDK(rule infrav1.SecurityRule) network.SecurityRule {
 		secRule.Protocol = network.SecurityRuleProtocolIcmp
 	}
 
+	if rule.Action == infrav1.SecurityRuleActionDeny {
+		secRule.Access = network.SecurityRuleAccessDeny
+	}
+
 	switch rule.Direction {
 	case infrav1.SecurityRuleDirectionOutbound:
 		secRule.Direction = network.SecurityRuleDirectionOutbound
@@ -57,3 +78,12 @@ func SecurityRuleToSDK(rule infrav1.SecurityRule) network.SecurityRule {
 
 	return secRule
 }
+
+// applicationSecurityGroupsToSDK converts a list of application security group resource IDs to Azure application security group references.
+func applicationSecurityGroupsToSDK(ids []string) *[]network.ApplicationSecurityGroup {
+	asgs := make([]network.ApplicationSecurityGroup, 0, len(ids))
+	for _, id := range ids {
+		asgs = append(asgs, network.ApplicationSecurityGroup{ID: ptr.To(id)})
+	}
+	return &asgs
+}
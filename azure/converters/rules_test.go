@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package converters
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+func TestSecurityRuleToSDK(t *testing.T) {
+	tests := []struct {
+		name string
+		rule infrav1.SecurityRule
+		want network.SecurityRule
+	}{
+		{
+			name: "rule with single source and destination",
+			rule: infrav1.SecurityRule{
+				Name:             "allow_ssh",
+				Description:      "Allow SSH",
+				Priority:         2200,
+				Protocol:         infrav1.SecurityGroupProtocolTCP,
+				Direction:        infrav1.SecurityRuleDirectionInbound,
+				Source:           ptr.To("*"),
+				SourcePorts:      ptr.To("*"),
+				Destination:      ptr.To("*"),
+				DestinationPorts: ptr.To("22"),
+			},
+			want: network.SecurityRule{
+				Name: ptr.To("allow_ssh"),
+				SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+					Description:              ptr.To("Allow SSH"),
+					SourceAddressPrefix:      ptr.To("*"),
+					SourcePortRange:          ptr.To("*"),
+					DestinationAddressPrefix: ptr.To("*"),
+					DestinationPortRange:     ptr.To("22"),
+					Access:                   network.SecurityRuleAccessAllow,
+					Priority:                 ptr.To[int32](2200),
+					Protocol:                 network.SecurityRuleProtocolTCP,
+					Direction:                network.SecurityRuleDirectionInbound,
+				},
+			},
+		},
+		{
+			name: "rule with multiple sources and destinations",
+			rule: infrav1.SecurityRule{
+				Name:             "allow_apiserver",
+				Description:      "Allow API Server",
+				Priority:         2201,
+				Protocol:         infrav1.SecurityGroupProtocolAll,
+				Direction:        infrav1.SecurityRuleDirectionOutbound,
+				Sources:          []string{"10.0.0.0/16", "VirtualNetwork"},
+				SourcePorts:      ptr.To("*"),
+				Destinations:     []string{"10.1.0.0/16", "10.2.0.0/16"},
+				DestinationPorts: ptr.To("6443"),
+			},
+			want: network.SecurityRule{
+				Name: ptr.To("allow_apiserver"),
+				SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+					Description:                ptr.To("Allow API Server"),
+					SourceAddressPrefixes:      &[]string{"10.0.0.0/16", "VirtualNetwork"},
+					SourcePortRange:            ptr.To("*"),
+					DestinationAddressPrefixes: &[]string{"10.1.0.0/16", "10.2.0.0/16"},
+					DestinationPortRange:       ptr.To("6443"),
+					Access:                     network.SecurityRuleAccessAllow,
+					Priority:                   ptr.To[int32](2201),
+					Protocol:                   network.SecurityRuleProtocolAsterisk,
+					Direction:                  network.SecurityRuleDirectionOutbound,
+				},
+			},
+		},
+		{
+			name: "rule with application security groups",
+			rule: infrav1.SecurityRule{
+				Name:                                 "allow_web_to_api",
+				Description:                          "Allow web tier to reach API tier",
+				Priority:                             2202,
+				Protocol:                             infrav1.SecurityGroupProtocolTCP,
+				Direction:                            infrav1.SecurityRuleDirectionInbound,
+				SourceApplicationSecurityGroups:      []string{"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/applicationSecurityGroups/web"},
+				SourcePorts:                          ptr.To("*"),
+				DestinationApplicationSecurityGroups: []string{"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/applicationSecurityGroups/api"},
+				DestinationPorts:                     ptr.To("443"),
+			},
+			want: network.SecurityRule{
+				Name: ptr.To("allow_web_to_api"),
+				SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+					Description: ptr.To("Allow web tier to reach API tier"),
+					SourceApplicationSecurityGroups: &[]network.ApplicationSecurityGroup{
+						{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/applicationSecurityGroups/web")},
+					},
+					SourcePortRange: ptr.To("*"),
+					DestinationApplicationSecurityGroups: &[]network.ApplicationSecurityGroup{
+						{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/applicationSecurityGroups/api")},
+					},
+					DestinationPortRange: ptr.To("443"),
+					Access:               network.SecurityRuleAccessAllow,
+					Priority:             ptr.To[int32](2202),
+					Protocol:             network.SecurityRuleProtocolTCP,
+					Direction:            network.SecurityRuleDirectionInbound,
+				},
+			},
+		},
+		{
+			name: "deny rule",
+			rule: infrav1.SecurityRule{
+				Name:             "deny_outbound",
+				Description:      "Deny all other outbound traffic",
+				Priority:         4096,
+				Protocol:         infrav1.SecurityGroupProtocolAll,
+				Direction:        infrav1.SecurityRuleDirectionOutbound,
+				Action:           infrav1.SecurityRuleActionDeny,
+				Source:           ptr.To("*"),
+				SourcePorts:      ptr.To("*"),
+				Destination:      ptr.To("*"),
+				DestinationPorts: ptr.To("*"),
+			},
+			want: network.SecurityRule{
+				Name: ptr.To("deny_outbound"),
+				SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+					Description:              ptr.To("Deny all other outbound traffic"),
+					SourceAddressPrefix:      ptr.To("*"),
+					SourcePortRange:          ptr.To("*"),
+					DestinationAddressPrefix: ptr.To("*"),
+					DestinationPortRange:     ptr.To("*"),
+					Access:                   network.SecurityRuleAccessDeny,
+					Priority:                 ptr.To[int32](4096),
+					Protocol:                 network.SecurityRuleProtocolAsterisk,
+					Direction:                network.SecurityRuleDirectionOutbound,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+			g.Expect(SecurityRuleToSDK(tt.rule)).To(Equal(tt.want))
+		})
+	}
+}
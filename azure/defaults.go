@@ -24,6 +24,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/go-autorest/autorest"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 	"sigs.k8s.io/cluster-api-provider-azure/version"
 )
@@ -96,6 +97,9 @@ const (
 	// bootstrapSentinelFile is the file written by bootstrap provider on machines to indicate successful bootstrapping,
 	// as defined by the Cluster API Bootstrap Provider contract (https://cluster-api.sigs.k8s.io/developer/providers/bootstrap.html).
 	bootstrapSentinelFile = "/run/cluster-api/bootstrap-success.complete"
+	// cloudInitRecoverableErrorExitCode is the exit code of cloud-init status when cloud-init completed with recoverable
+	// errors, e.g. deprecation warnings, which don't make the bootstrap fail.
+	cloudInitRecoverableErrorExitCode = 2
 )
 
 const (
@@ -108,7 +112,7 @@ const (
 
 var (
 	// LinuxBootstrapExtensionCommand is the command the VM bootstrap extension will execute to verify Linux nodes bootstrap completes successfully.
-	LinuxBootstrapExtensionCommand = fmt.Sprintf("for i in $(seq 1 %d); do test -f %s && break; if [ $i -eq %d ]; then exit 1; else sleep %d; fi; done", bootstrapExtensionRetries, bootstrapSentinelFile, bootstrapExtensionRetries, bootstrapExtensionSleep)
+	LinuxBootstrapExtensionCommand = linuxSentinelBootstrapExtensionCommand(bootstrapExtensionRetries)
 	// WindowsBootstrapExtensionCommand is the command the VM bootstrap extension will execute to verify Windows nodes bootstrap completes successfully.
 	WindowsBootstrapExtensionCommand = fmt.Sprintf("powershell.exe -Command \"for ($i = 0; $i -lt %d; $i++) {if (Test-Path '%s') {exit 0} else {Start-Sleep -Seconds %d}} exit -2\"",
 		bootstrapExtensionRetries, bootstrapSentinelFile, bootstrapExtensionSleep)
@@ -187,6 +191,11 @@ func GenerateDataDiskName(machineName, nameSuffix string) string {
 	return fmt.Sprintf("%s_%s", machineName, nameSuffix)
 }
 
+// GenerateSharedDataDiskName generates the name of a data disk shared by the machines of a cluster.
+func GenerateSharedDataDiskName(clusterName, nameSuffix string) string {
+	return fmt.Sprintf("%s_%s", clusterName, nameSuffix)
+}
+
 // GenerateVnetPeeringName generates the name for a peering between two vnets.
 func GenerateVnetPeeringName(sourceVnetName string, remoteVnetName string) string {
 	return fmt.Sprintf("%s-To-%s", sourceVnetName, remoteVnetName)
@@ -200,6 +209,16 @@ func GenerateAvailabilitySetName(clusterName, nodeGroup string) string {
 	return fmt.Sprintf("%s_%s-as", clusterName, nodeGroup)
 }
 
+// GenerateDataCollectionRuleAssociationName generates the name of the association of a cluster with a data collection rule.
+func GenerateDataCollectionRuleAssociationName(clusterName string) string {
+	return fmt.Sprintf("%s-dcra", clusterName)
+}
+
+// GenerateResourceGroupLockName generates the name of the management lock protecting the resource group of a cluster from deletion.
+func GenerateResourceGroupLockName(clusterName string) string {
+	return fmt.Sprintf("%s-rg-lock", clusterName)
+}
+
 // WithIndex appends the index as suffix to a generated name.
 func WithIndex(name string, n int) string {
 	return fmt.Sprintf("%s-%d", name, n)
@@ -240,6 +259,11 @@ func SecurityGroupID(subscriptionID, resourceGroup, nsgName string) string {
 	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/networkSecurityGroups/%s", subscriptionID, resourceGroup, nsgName)
 }
 
+// PublicIPPrefixID returns the azure resource ID for a given public IP prefix.
+func PublicIPPrefixID(subscriptionID, resourceGroup, prefixName string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/publicIPPrefixes/%s", subscriptionID, resourceGroup, prefixName)
+}
+
 // NatGatewayID returns the azure resource ID for a given NAT gateway.
 func NatGatewayID(subscriptionID, resourceGroup, natgatewayName string) string {
 	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/natGateways/%s", subscriptionID, resourceGroup, natgatewayName)
@@ -250,6 +274,21 @@ func NetworkInterfaceID(subscriptionID, resourceGroup, nicName string) string {
 	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/networkInterfaces/%s", subscriptionID, resourceGroup, nicName)
 }
 
+// DiskID returns the azure resource ID for a given managed disk.
+func DiskID(subscriptionID, resourceGroup, diskName string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/disks/%s", subscriptionID, resourceGroup, diskName)
+}
+
+// LoadBalancerID returns the azure resource ID for a given load balancer.
+func LoadBalancerID(subscriptionID, resourceGroup, loadBalancerName string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/loadBalancers/%s", subscriptionID, resourceGroup, loadBalancerName)
+}
+
+// BastionHostID returns the azure resource ID for a given bastion host.
+func BastionHostID(subscriptionID, resourceGroup, bastionHostName string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/bastionHosts/%s", subscriptionID, resourceGroup, bastionHostName)
+}
+
 // FrontendIPConfigID returns the azure resource ID for a given frontend IP config.
 func FrontendIPConfigID(subscriptionID, resourceGroup, loadBalancerName, configName string) string {
 	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/loadBalancers/%s/frontendIPConfigurations/%s", subscriptionID, resourceGroup, loadBalancerName, configName)
@@ -290,15 +329,42 @@ func ManagedClusterID(subscriptionID, resourceGroup, managedClusterName string)
 	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s", subscriptionID, resourceGroup, managedClusterName)
 }
 
+// linuxSentinelBootstrapExtensionCommand returns the command checking for the existence of the bootstrapSentinelFile on
+// Linux nodes, with the given number of retries and a sleep between retries.
+func linuxSentinelBootstrapExtensionCommand(retries int) string {
+	return fmt.Sprintf("for i in $(seq 1 %d); do test -f %s && break; if [ $i -eq %d ]; then exit 1; else sleep %d; fi; done", retries, bootstrapSentinelFile, retries, bootstrapExtensionSleep)
+}
+
+// LinuxBootstrapExtensionCommandFor returns the command the VM bootstrap extension executes to verify Linux nodes
+// bootstrap completes successfully with the given completion check. The CloudInit check waits for cloud-init to complete
+// all its stages within the timeout and fails if cloud-init reported errors, before checking for the bootstrapSentinelFile
+// written during the final stage.
+func LinuxBootstrapExtensionCommandFor(check *infrav1.BootstrapCompletionCheck) string {
+	if check == nil {
+		return LinuxBootstrapExtensionCommand
+	}
+	timeoutSeconds := int32(bootstrapExtensionRetries * bootstrapExtensionSleep)
+	if check.TimeoutSeconds != nil {
+		timeoutSeconds = *check.TimeoutSeconds
+	}
+	if check.Type == infrav1.BootstrapCompletionCheckCloudInit {
+		return fmt.Sprintf("timeout %d cloud-init status --wait >/dev/null; rc=$?; if [ $rc -ne 0 ] && [ $rc -ne %d ]; then exit 1; fi; test -f %s",
+			timeoutSeconds, cloudInitRecoverableErrorExitCode, bootstrapSentinelFile)
+	}
+	return linuxSentinelBootstrapExtensionCommand(int(timeoutSeconds) / bootstrapExtensionSleep)
+}
+
 // GetBootstrappingVMExtension returns the CAPZ Bootstrapping VM extension.
 // The CAPZ Bootstrapping extension is a simple clone of https://github.com/Azure/custom-script-extension-linux for Linux or
 // https://learn.microsoft.com/azure/virtual-machines/extensions/custom-script-windows for Windows.
 // This extension allows running arbitrary scripts on the VM.
 // Its role is to detect and report Kubernetes bootstrap failure or success.
-func GetBootstrappingVMExtension(osType string, cloud string, vmName string, cpuArchitectureType string) *ExtensionSpec {
+// The completion check only applies to Linux nodes, and defaults to checking for the bootstrapSentinelFile when nil.
+func GetBootstrappingVMExtension(osType string, cloud string, vmName string, cpuArchitectureType string, check *infrav1.BootstrapCompletionCheck) *ExtensionSpec {
 	// currently, the bootstrap extension is only available in AzurePublicCloud.
 	if osType == LinuxOS && cloud == PublicCloudName {
-		// The command checks for the existence of the bootstrapSentinelFile on the machine, with retries and sleep between retries.
+		// The command checks for the existence of the bootstrapSentinelFile on the machine, with retries and sleep between
+		// retries, after waiting for cloud-init to complete when the CloudInit completion check is configured.
 		// We set the version to 1.1.1 for arm64 machines and 1.0 for x64. This is due to a known issue with newer versions of
 		// Go on Ubuntu 20.04. The issue is being tracked here: https://github.com/golang/go/issues/58550
 		// TODO: Remove this once the issue is fixed, or when Ubuntu 20.04 is no longer supported.
@@ -312,7 +378,7 @@ func GetBootstrappingVMExtension(osType string, cloud string, vmName string, cpu
 			Publisher: "Microsoft.Azure.ContainerUpstream",
 			Version:   extensionVersion,
 			ProtectedSettings: map[string]string{
-				"commandToExecute": LinuxBootstrapExtensionCommand,
+				"commandToExecute": LinuxBootstrapExtensionCommandFor(check),
 			},
 		}
 	} else if osType == WindowsOS && cloud == PublicCloudName {
@@ -356,6 +422,7 @@ func ARMClientOptions(azureEnvironment string) (*arm.ClientOptions, error) {
 	opts.PerCallPolicies = []policy.Policy{
 		correlationIDPolicy{},
 		userAgentPolicy{},
+		rateLimitPolicy{limiters: apiRateLimiters},
 	}
 	opts.Retry.MaxRetries = -1 // Less than zero means one try and no retries.
 
@@ -391,6 +458,9 @@ func SetAutoRestClientDefaults(c *autorest.Client, auth autorest.Authorizer) {
 	// The wrapped Sender should set the x-ms-correlation-request-id on the given
 	// request, then pass the new request to the underlying Sender.
 	c.Sender = autorest.DecorateSender(c.Sender, msCorrelationIDSendDecorator)
+	// Requests wait for the client-side rate limiter of their subscription, shared by all clients, if rate limiting is
+	// enabled.
+	c.Sender = autorest.DecorateSender(c.Sender, apiRateLimiters.SendDecorator)
 	// The default number of retries is 3. This means the client will attempt to retry operation results like resource
 	// conflicts (HTTP 409). For a reconciling controller, this is undesirable behavior since if the controller runs
 	// into an error reconciling, the controller would be better off to end with an error and try again later.
@@ -29,6 +29,8 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/go-autorest/autorest"
 	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
@@ -80,7 +82,7 @@ func TestARMClientOptions(t *testing.T) {
 			g.Expect(err).NotTo(HaveOccurred())
 			g.Expect(opts.Cloud).To(Equal(tc.expectedCloud))
 			g.Expect(opts.Retry.MaxRetries).To(BeNumerically("==", -1))
-			g.Expect(opts.PerCallPolicies).To(HaveLen(2))
+			g.Expect(opts.PerCallPolicies).To(HaveLen(3))
 		})
 	}
 }
@@ -98,12 +100,13 @@ func TestPerCallPolicies(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// Call the factory function and ensure it has both PerCallPolicies.
+	// Call the factory function and ensure it has all the PerCallPolicies.
 	opts, err := ARMClientOptions("")
 	g.Expect(err).NotTo(HaveOccurred())
-	g.Expect(opts.PerCallPolicies).To(HaveLen(2))
+	g.Expect(opts.PerCallPolicies).To(HaveLen(3))
 	g.Expect(opts.PerCallPolicies).To(ContainElement(BeAssignableToTypeOf(correlationIDPolicy{})))
 	g.Expect(opts.PerCallPolicies).To(ContainElement(BeAssignableToTypeOf(userAgentPolicy{})))
+	g.Expect(opts.PerCallPolicies).To(ContainElement(BeAssignableToTypeOf(rateLimitPolicy{})))
 
 	// Create a request with a correlation ID.
 	ctx := context.WithValue(context.Background(), tele.CorrIDKeyVal, tele.CorrID(corrID))
@@ -273,7 +276,7 @@ func TestGetBootstrappingVMExtension(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			g := NewWithT(t)
-			actualExtension := GetBootstrappingVMExtension(tc.osType, tc.cloud, tc.vmName, tc.cpuArchitecture)
+			actualExtension := GetBootstrappingVMExtension(tc.osType, tc.cloud, tc.vmName, tc.cpuArchitecture, nil)
 			if tc.expectNil {
 				g.Expect(actualExtension).To(BeNil())
 			} else {
@@ -282,3 +285,44 @@ func TestGetBootstrappingVMExtension(t *testing.T) {
 		})
 	}
 }
+
+func TestLinuxBootstrapExtensionCommandFor(t *testing.T) {
+	testCases := []struct {
+		name     string
+		check    *infrav1.BootstrapCompletionCheck
+		expected string
+	}{
+		{
+			name:     "no completion check waits for the sentinel file",
+			check:    nil,
+			expected: LinuxBootstrapExtensionCommand,
+		},
+		{
+			name:     "sentinel completion check with the default timeout",
+			check:    &infrav1.BootstrapCompletionCheck{Type: infrav1.BootstrapCompletionCheckSentinel},
+			expected: LinuxBootstrapExtensionCommand,
+		},
+		{
+			name:     "sentinel completion check with a timeout",
+			check:    &infrav1.BootstrapCompletionCheck{Type: infrav1.BootstrapCompletionCheckSentinel, TimeoutSeconds: ptr.To[int32](600)},
+			expected: "for i in $(seq 1 120); do test -f /run/cluster-api/bootstrap-success.complete && break; if [ $i -eq 120 ]; then exit 1; else sleep 5; fi; done",
+		},
+		{
+			name:     "cloud-init completion check with the default timeout",
+			check:    &infrav1.BootstrapCompletionCheck{Type: infrav1.BootstrapCompletionCheckCloudInit},
+			expected: "timeout 300 cloud-init status --wait >/dev/null; rc=$?; if [ $rc -ne 0 ] && [ $rc -ne 2 ]; then exit 1; fi; test -f /run/cluster-api/bootstrap-success.complete",
+		},
+		{
+			name:     "cloud-init completion check with a timeout",
+			check:    &infrav1.BootstrapCompletionCheck{Type: infrav1.BootstrapCompletionCheckCloudInit, TimeoutSeconds: ptr.To[int32](900)},
+			expected: "timeout 900 cloud-init status --wait >/dev/null; rc=$?; if [ $rc -ne 0 ] && [ $rc -ne 2 ]; then exit 1; fi; test -f /run/cluster-api/bootstrap-success.complete",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(LinuxBootstrapExtensionCommandFor(tc.check)).To(Equal(tc.expected))
+		})
+	}
+}
@@ -21,13 +21,28 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 )
 
+const (
+	// scopeLockedErrorCode is the code of the error returned when changing a resource protected by a management lock.
+	scopeLockedErrorCode = "ScopeLocked"
+	// linkedAuthorizationFailedErrorCode is the code of the error returned when changing a resource requires changing a
+	// linked resource which can't be changed, e.g. because it is protected by a management lock.
+	linkedAuthorizationFailedErrorCode = "LinkedAuthorizationFailed"
+	// diskAttachedErrorCode is the code of the error returned when deleting a disk which is attached to a VM.
+	diskAttachedErrorCode = "DiskAttached"
+	// operationNotAllowedErrorCode is the code of the error returned when an operation isn't allowed in the current
+	// state of a resource, e.g. deleting a disk which is attached to a VM.
+	operationNotAllowedErrorCode = "OperationNotAllowed"
+)
+
 // ResourceNotFound parses an error to check if its status code is Not Found (404).
 func ResourceNotFound(err error) bool {
 	return hasStatusCode(err, http.StatusNotFound)
@@ -38,6 +53,40 @@ func ResourceConflict(err error) bool {
 	return hasStatusCode(err, http.StatusConflict)
 }
 
+// ResourceForbidden parses an error to check if its status code is Forbidden (403).
+func ResourceForbidden(err error) bool {
+	return hasStatusCode(err, http.StatusForbidden)
+}
+
+// ResourceLocked parses an error to check if Azure refused to change a resource because of a management lock on the
+// resource, its resource group or its subscription, or on a linked resource, e.g. the subnet of a network interface.
+// Azure also refuses to change a resource when the identity lacks permissions on a linked resource with the same
+// LinkedAuthorizationFailed error code, so it's only a lock when the error mentions one.
+func ResourceLocked(err error) bool {
+	if hasErrorCode(err, scopeLockedErrorCode) {
+		return true
+	}
+	return hasErrorCode(err, linkedAuthorizationFailedErrorCode) && strings.Contains(strings.ToLower(err.Error()), "lock")
+}
+
+// DiskAttached parses an error to check if Azure refused to delete a disk because it is still attached to a VM.
+func DiskAttached(err error) bool {
+	if hasErrorCode(err, diskAttachedErrorCode) {
+		return true
+	}
+	return hasErrorCode(err, operationNotAllowedErrorCode) && strings.Contains(strings.ToLower(err.Error()), "attached")
+}
+
+// hasErrorCode returns true if an error is a RequestError or ResponseError with a matching Azure error code.
+func hasErrorCode(err error, code string) bool {
+	var requestErr *azureautorest.RequestError // azure-sdk-for-go v1
+	if errors.As(err, &requestErr) && requestErr.ServiceError != nil {
+		return requestErr.ServiceError.Code == code
+	}
+	var rerr *azcore.ResponseError // azure-sdk-for-go v2
+	return errors.As(err, &rerr) && rerr.ErrorCode == code
+}
+
 // hasStatusCode returns true if an error is a DetailedError or ResponseError with a matching status code.
 func hasStatusCode(err error, statusCode int) bool {
 	derr := autorest.DetailedError{} // azure-sdk-for-go v1
@@ -8,6 +8,7 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
 	"github.com/pkg/errors"
 )
 
@@ -140,3 +141,132 @@ func TestResourceConflict(t *testing.T) {
 		})
 	}
 }
+
+func TestDiskAttached(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		success bool
+	}{
+		{
+			name: "Disk attached request error",
+			err: autorest.DetailedError{
+				StatusCode: http.StatusConflict,
+				Original:   &azureautorest.RequestError{ServiceError: &azureautorest.ServiceError{Code: "DiskAttached"}},
+			},
+			success: true,
+		},
+		{
+			name: "Operation not allowed request error for an attached disk",
+			err: autorest.DetailedError{
+				StatusCode: http.StatusConflict,
+				Original: &azureautorest.RequestError{ServiceError: &azureautorest.ServiceError{
+					Code:    "OperationNotAllowed",
+					Message: "Disk my-vm_OSDisk is attached to VM /subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm.",
+				}},
+			},
+			success: true,
+		},
+		{
+			name: "Operation not allowed request error for another reason",
+			err: autorest.DetailedError{
+				StatusCode: http.StatusConflict,
+				Original: &azureautorest.RequestError{ServiceError: &azureautorest.ServiceError{
+					Code:    "OperationNotAllowed",
+					Message: "Operation is not allowed while the disk is being resized.",
+				}},
+			},
+			success: false,
+		},
+		{
+			name:    "Disk attached response error",
+			err:     &azcore.ResponseError{StatusCode: http.StatusConflict, ErrorCode: "DiskAttached"},
+			success: true,
+		},
+		{
+			name:    "Conflict detailed error mentioning an attachment",
+			err:     autorest.DetailedError{StatusCode: http.StatusConflict, Message: "the network interface is attached to a VM"},
+			success: false,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := DiskAttached(tc.err); got != tc.success {
+				t.Errorf("DiskAttached() = %v, want %v", got, tc.success)
+			}
+		})
+	}
+}
+
+func TestResourceLocked(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		success bool
+	}{
+		{
+			name: "Scope locked request error",
+			err: autorest.DetailedError{
+				StatusCode: http.StatusConflict,
+				Original:   &azureautorest.RequestError{ServiceError: &azureautorest.ServiceError{Code: "ScopeLocked"}},
+			},
+			success: true,
+		},
+		{
+			name: "Linked authorization failed request error because of a lock",
+			err: autorest.DetailedError{
+				StatusCode: http.StatusForbidden,
+				Original: &azureautorest.RequestError{ServiceError: &azureautorest.ServiceError{
+					Code:    "LinkedAuthorizationFailed",
+					Message: "The client has permission to perform action 'Microsoft.Network/virtualNetworks/subnets/join/action' on scope 'my-nic', however the linked scope 'my-subnet' is locked.",
+				}},
+			},
+			success: true,
+		},
+		{
+			name: "Linked authorization failed request error because of missing permissions",
+			err: autorest.DetailedError{
+				StatusCode: http.StatusForbidden,
+				Original: &azureautorest.RequestError{ServiceError: &azureautorest.ServiceError{
+					Code:    "LinkedAuthorizationFailed",
+					Message: "The client has permission to perform action 'Microsoft.Network/virtualNetworks/subnets/join/action' on scope 'my-nic', however it does not have permission to perform action 'Microsoft.Network/virtualNetworks/subnets/join/action' on the linked scope 'my-subnet'.",
+				}},
+			},
+			success: false,
+		},
+		{
+			name: "Conflict request error",
+			err: autorest.DetailedError{
+				StatusCode: http.StatusConflict,
+				Original:   &azureautorest.RequestError{ServiceError: &azureautorest.ServiceError{Code: "Conflict"}},
+			},
+			success: false,
+		},
+		{
+			name:    "Scope locked response error",
+			err:     &azcore.ResponseError{StatusCode: http.StatusConflict, ErrorCode: "ScopeLocked"},
+			success: true,
+		},
+		{
+			name:    "Conflict detailed error",
+			err:     autorest.DetailedError{StatusCode: http.StatusConflict},
+			success: false,
+		},
+		{
+			name:    "Scope locked generic error",
+			err:     errors.New("ScopeLocked"),
+			success: false,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ResourceLocked(tc.err); got != tc.success {
+				t.Errorf("ResourceLocked() = %v, want %v", got, tc.success)
+			}
+		})
+	}
+}
@@ -18,9 +18,11 @@ package azure
 
 import (
 	"context"
+	"time"
 
 	"github.com/Azure/azure-service-operator/v2/pkg/genruntime"
 	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -36,6 +38,26 @@ type Pauser interface {
 	Pause(context.Context) error
 }
 
+// SelectivePauser may be implemented by a Pauser that doesn't require pausing in every case. A SelectivePauser is only
+// paused when ShouldPause returns true.
+type SelectivePauser interface {
+	Pauser
+	ShouldPause(context.Context) bool
+}
+
+// ShouldPause returns the Pauser of a service and true if the service needs to be paused, i.e. it is a Pauser and, if
+// it is a SelectivePauser, its ShouldPause returns true.
+func ShouldPause(ctx context.Context, service Reconciler) (Pauser, bool) {
+	pauser, ok := service.(Pauser)
+	if !ok {
+		return nil, false
+	}
+	if selectivePauser, ok := pauser.(SelectivePauser); ok && !selectivePauser.ShouldPause(ctx) {
+		return nil, false
+	}
+	return pauser, true
+}
+
 // ServiceReconciler is an Azure service reconciler which can reconcile an Azure service.
 type ServiceReconciler interface {
 	Name() string
@@ -55,6 +77,17 @@ type Authorizer interface {
 	HashKey() string
 }
 
+// KeyVaultAuthorizer is an interface which can get the authorizer and DNS suffix for the Azure Key Vault data plane.
+type KeyVaultAuthorizer interface {
+	KeyVaultAuthorizer(ctx context.Context) (autorest.Authorizer, error)
+	KeyVaultDNSSuffix() string
+}
+
+// CloudEnvironmentDescriber is an interface which can get the Azure cloud environment of a cluster.
+type CloudEnvironmentDescriber interface {
+	AzureEnvironment() azureautorest.Environment
+}
+
 // NetworkDescriber is an interface which can get common Azure Cluster Networking information.
 type NetworkDescriber interface {
 	Vnet() *infrav1.VnetSpec
@@ -73,6 +106,7 @@ type NetworkDescriber interface {
 	GetPrivateDNSZoneName() string
 	OutboundLBName(string) string
 	OutboundPoolName(string) string
+	OutboundAdditionalPoolNames(string) []string
 }
 
 // ClusterDescriber is an interface which can get common Azure Cluster information.
@@ -100,10 +134,30 @@ type AsyncStatusUpdater interface {
 	UpdatePatchStatus(clusterv1.ConditionType, string, error)
 }
 
-// ClusterScoper combines the ClusterDescriber and NetworkDescriber interfaces.
+// DryRunner is an interface for scopes that can reconcile in dry-run mode. In dry-run mode, the changes to Azure
+// resources are computed and reported as events but no mutating call is made to Azure. The async service enforces it
+// for the resources it reconciles, so services making mutating calls to Azure directly must check IsDryRun before each
+// of them and record a DryRunChange event instead.
+type DryRunner interface {
+	IsDryRun() bool
+	RecordEvent(eventType, reason, messageFmt string, args ...interface{})
+}
+
+// ResourceLockReporter is an interface for scopes that report the Azure resources which can't be changed because of a
+// management lock, so that the changes are not retried before the lock may have been removed.
+type ResourceLockReporter interface {
+	SetResourceLocked(serviceName, resourceName, rgName string)
+	ResourceLockedSince(serviceName, resourceName, rgName string) (time.Time, bool)
+	ClearResourceLocked(serviceName, resourceName, rgName string)
+}
+
+// ClusterScoper combines the ClusterDescriber, NetworkDescriber, KeyVaultAuthorizer and CloudEnvironmentDescriber
+// interfaces.
 type ClusterScoper interface {
 	ClusterDescriber
 	NetworkDescriber
+	KeyVaultAuthorizer
+	CloudEnvironmentDescriber
 }
 
 // ManagedClusterScoper defines the interface for ManagedClusterScope.
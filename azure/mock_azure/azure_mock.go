@@ -23,9 +23,11 @@ package mock_azure
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	genruntime "github.com/Azure/azure-service-operator/v2/pkg/genruntime"
 	autorest "github.com/Azure/go-autorest/autorest"
+	azure "github.com/Azure/go-autorest/autorest/azure"
 	gomock "go.uber.org/mock/gomock"
 	v1beta1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	v1beta10 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -119,6 +121,57 @@ func (mr *MockPauserMockRecorder) Pause(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pause", reflect.TypeOf((*MockPauser)(nil).Pause), arg0)
 }
 
+// MockSelectivePauser is a mock of SelectivePauser interface.
+type MockSelectivePauser struct {
+	ctrl     *gomock.Controller
+	recorder *MockSelectivePauserMockRecorder
+}
+
+// MockSelectivePauserMockRecorder is the mock recorder for MockSelectivePauser.
+type MockSelectivePauserMockRecorder struct {
+	mock *MockSelectivePauser
+}
+
+// NewMockSelectivePauser creates a new mock instance.
+func NewMockSelectivePauser(ctrl *gomock.Controller) *MockSelectivePauser {
+	mock := &MockSelectivePauser{ctrl: ctrl}
+	mock.recorder = &MockSelectivePauserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSelectivePauser) EXPECT() *MockSelectivePauserMockRecorder {
+	return m.recorder
+}
+
+// Pause mocks base method.
+func (m *MockSelectivePauser) Pause(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pause", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Pause indicates an expected call of Pause.
+func (mr *MockSelectivePauserMockRecorder) Pause(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pause", reflect.TypeOf((*MockSelectivePauser)(nil).Pause), arg0)
+}
+
+// ShouldPause mocks base method.
+func (m *MockSelectivePauser) ShouldPause(arg0 context.Context) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShouldPause", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// ShouldPause indicates an expected call of ShouldPause.
+func (mr *MockSelectivePauserMockRecorder) ShouldPause(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShouldPause", reflect.TypeOf((*MockSelectivePauser)(nil).ShouldPause), arg0)
+}
+
 // MockServiceReconciler is a mock of ServiceReconciler interface.
 type MockServiceReconciler struct {
 	ctrl     *gomock.Controller
@@ -334,6 +387,95 @@ func (mr *MockAuthorizerMockRecorder) TenantID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantID", reflect.TypeOf((*MockAuthorizer)(nil).TenantID))
 }
 
+// MockKeyVaultAuthorizer is a mock of KeyVaultAuthorizer interface.
+type MockKeyVaultAuthorizer struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyVaultAuthorizerMockRecorder
+}
+
+// MockKeyVaultAuthorizerMockRecorder is the mock recorder for MockKeyVaultAuthorizer.
+type MockKeyVaultAuthorizerMockRecorder struct {
+	mock *MockKeyVaultAuthorizer
+}
+
+// NewMockKeyVaultAuthorizer creates a new mock instance.
+func NewMockKeyVaultAuthorizer(ctrl *gomock.Controller) *MockKeyVaultAuthorizer {
+	mock := &MockKeyVaultAuthorizer{ctrl: ctrl}
+	mock.recorder = &MockKeyVaultAuthorizerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeyVaultAuthorizer) EXPECT() *MockKeyVaultAuthorizerMockRecorder {
+	return m.recorder
+}
+
+// KeyVaultAuthorizer mocks base method.
+func (m *MockKeyVaultAuthorizer) KeyVaultAuthorizer(ctx context.Context) (autorest.Authorizer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyVaultAuthorizer", ctx)
+	ret0, _ := ret[0].(autorest.Authorizer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KeyVaultAuthorizer indicates an expected call of KeyVaultAuthorizer.
+func (mr *MockKeyVaultAuthorizerMockRecorder) KeyVaultAuthorizer(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyVaultAuthorizer", reflect.TypeOf((*MockKeyVaultAuthorizer)(nil).KeyVaultAuthorizer), ctx)
+}
+
+// KeyVaultDNSSuffix mocks base method.
+func (m *MockKeyVaultAuthorizer) KeyVaultDNSSuffix() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyVaultDNSSuffix")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// KeyVaultDNSSuffix indicates an expected call of KeyVaultDNSSuffix.
+func (mr *MockKeyVaultAuthorizerMockRecorder) KeyVaultDNSSuffix() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyVaultDNSSuffix", reflect.TypeOf((*MockKeyVaultAuthorizer)(nil).KeyVaultDNSSuffix))
+}
+
+// MockCloudEnvironmentDescriber is a mock of CloudEnvironmentDescriber interface.
+type MockCloudEnvironmentDescriber struct {
+	ctrl     *gomock.Controller
+	recorder *MockCloudEnvironmentDescriberMockRecorder
+}
+
+// MockCloudEnvironmentDescriberMockRecorder is the mock recorder for MockCloudEnvironmentDescriber.
+type MockCloudEnvironmentDescriberMockRecorder struct {
+	mock *MockCloudEnvironmentDescriber
+}
+
+// NewMockCloudEnvironmentDescriber creates a new mock instance.
+func NewMockCloudEnvironmentDescriber(ctrl *gomock.Controller) *MockCloudEnvironmentDescriber {
+	mock := &MockCloudEnvironmentDescriber{ctrl: ctrl}
+	mock.recorder = &MockCloudEnvironmentDescriberMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCloudEnvironmentDescriber) EXPECT() *MockCloudEnvironmentDescriberMockRecorder {
+	return m.recorder
+}
+
+// AzureEnvironment mocks base method.
+func (m *MockCloudEnvironmentDescriber) AzureEnvironment() azure.Environment {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureEnvironment")
+	ret0, _ := ret[0].(azure.Environment)
+	return ret0
+}
+
+// AzureEnvironment indicates an expected call of AzureEnvironment.
+func (mr *MockCloudEnvironmentDescriberMockRecorder) AzureEnvironment() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureEnvironment", reflect.TypeOf((*MockCloudEnvironmentDescriber)(nil).AzureEnvironment))
+}
+
 // MockNetworkDescriber is a mock of NetworkDescriber interface.
 type MockNetworkDescriber struct {
 	ctrl     *gomock.Controller
@@ -497,6 +639,20 @@ func (mr *MockNetworkDescriberMockRecorder) NodeSubnets() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeSubnets", reflect.TypeOf((*MockNetworkDescriber)(nil).NodeSubnets))
 }
 
+// OutboundAdditionalPoolNames mocks base method.
+func (m *MockNetworkDescriber) OutboundAdditionalPoolNames(arg0 string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OutboundAdditionalPoolNames", arg0)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// OutboundAdditionalPoolNames indicates an expected call of OutboundAdditionalPoolNames.
+func (mr *MockNetworkDescriberMockRecorder) OutboundAdditionalPoolNames(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OutboundAdditionalPoolNames", reflect.TypeOf((*MockNetworkDescriber)(nil).OutboundAdditionalPoolNames), arg0)
+}
+
 // OutboundLBName mocks base method.
 func (m *MockNetworkDescriber) OutboundLBName(arg0 string) string {
 	m.ctrl.T.Helper()
@@ -951,6 +1107,122 @@ func (mr *MockAsyncStatusUpdaterMockRecorder) UpdatePutStatus(arg0, arg1, arg2 i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePutStatus", reflect.TypeOf((*MockAsyncStatusUpdater)(nil).UpdatePutStatus), arg0, arg1, arg2)
 }
 
+// MockDryRunner is a mock of DryRunner interface.
+type MockDryRunner struct {
+	ctrl     *gomock.Controller
+	recorder *MockDryRunnerMockRecorder
+}
+
+// MockDryRunnerMockRecorder is the mock recorder for MockDryRunner.
+type MockDryRunnerMockRecorder struct {
+	mock *MockDryRunner
+}
+
+// NewMockDryRunner creates a new mock instance.
+func NewMockDryRunner(ctrl *gomock.Controller) *MockDryRunner {
+	mock := &MockDryRunner{ctrl: ctrl}
+	mock.recorder = &MockDryRunnerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDryRunner) EXPECT() *MockDryRunnerMockRecorder {
+	return m.recorder
+}
+
+// IsDryRun mocks base method.
+func (m *MockDryRunner) IsDryRun() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsDryRun")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsDryRun indicates an expected call of IsDryRun.
+func (mr *MockDryRunnerMockRecorder) IsDryRun() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDryRun", reflect.TypeOf((*MockDryRunner)(nil).IsDryRun))
+}
+
+// RecordEvent mocks base method.
+func (m *MockDryRunner) RecordEvent(eventType, reason, messageFmt string, args ...interface{}) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{eventType, reason, messageFmt}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "RecordEvent", varargs...)
+}
+
+// RecordEvent indicates an expected call of RecordEvent.
+func (mr *MockDryRunnerMockRecorder) RecordEvent(eventType, reason, messageFmt interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{eventType, reason, messageFmt}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordEvent", reflect.TypeOf((*MockDryRunner)(nil).RecordEvent), varargs...)
+}
+
+// MockResourceLockReporter is a mock of ResourceLockReporter interface.
+type MockResourceLockReporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockResourceLockReporterMockRecorder
+}
+
+// MockResourceLockReporterMockRecorder is the mock recorder for MockResourceLockReporter.
+type MockResourceLockReporterMockRecorder struct {
+	mock *MockResourceLockReporter
+}
+
+// NewMockResourceLockReporter creates a new mock instance.
+func NewMockResourceLockReporter(ctrl *gomock.Controller) *MockResourceLockReporter {
+	mock := &MockResourceLockReporter{ctrl: ctrl}
+	mock.recorder = &MockResourceLockReporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockResourceLockReporter) EXPECT() *MockResourceLockReporterMockRecorder {
+	return m.recorder
+}
+
+// ClearResourceLocked mocks base method.
+func (m *MockResourceLockReporter) ClearResourceLocked(serviceName, resourceName, rgName string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ClearResourceLocked", serviceName, resourceName, rgName)
+}
+
+// ClearResourceLocked indicates an expected call of ClearResourceLocked.
+func (mr *MockResourceLockReporterMockRecorder) ClearResourceLocked(serviceName, resourceName, rgName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearResourceLocked", reflect.TypeOf((*MockResourceLockReporter)(nil).ClearResourceLocked), serviceName, resourceName, rgName)
+}
+
+// ResourceLockedSince mocks base method.
+func (m *MockResourceLockReporter) ResourceLockedSince(serviceName, resourceName, rgName string) (time.Time, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResourceLockedSince", serviceName, resourceName, rgName)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// ResourceLockedSince indicates an expected call of ResourceLockedSince.
+func (mr *MockResourceLockReporterMockRecorder) ResourceLockedSince(serviceName, resourceName, rgName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResourceLockedSince", reflect.TypeOf((*MockResourceLockReporter)(nil).ResourceLockedSince), serviceName, resourceName, rgName)
+}
+
+// SetResourceLocked mocks base method.
+func (m *MockResourceLockReporter) SetResourceLocked(serviceName, resourceName, rgName string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetResourceLocked", serviceName, resourceName, rgName)
+}
+
+// SetResourceLocked indicates an expected call of SetResourceLocked.
+func (mr *MockResourceLockReporterMockRecorder) SetResourceLocked(serviceName, resourceName, rgName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetResourceLocked", reflect.TypeOf((*MockResourceLockReporter)(nil).SetResourceLocked), serviceName, resourceName, rgName)
+}
+
 // MockClusterScoper is a mock of ClusterScoper interface.
 type MockClusterScoper struct {
 	ctrl     *gomock.Controller
@@ -1058,6 +1330,20 @@ func (mr *MockClusterScoperMockRecorder) AvailabilitySetEnabled() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockClusterScoper)(nil).AvailabilitySetEnabled))
 }
 
+// AzureEnvironment mocks base method.
+func (m *MockClusterScoper) AzureEnvironment() azure.Environment {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureEnvironment")
+	ret0, _ := ret[0].(azure.Environment)
+	return ret0
+}
+
+// AzureEnvironment indicates an expected call of AzureEnvironment.
+func (mr *MockClusterScoperMockRecorder) AzureEnvironment() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureEnvironment", reflect.TypeOf((*MockClusterScoper)(nil).AzureEnvironment))
+}
+
 // BaseURI mocks base method.
 func (m *MockClusterScoper) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -1296,6 +1582,35 @@ func (mr *MockClusterScoperMockRecorder) IsVnetManaged() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsVnetManaged", reflect.TypeOf((*MockClusterScoper)(nil).IsVnetManaged))
 }
 
+// KeyVaultAuthorizer mocks base method.
+func (m *MockClusterScoper) KeyVaultAuthorizer(ctx context.Context) (autorest.Authorizer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyVaultAuthorizer", ctx)
+	ret0, _ := ret[0].(autorest.Authorizer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KeyVaultAuthorizer indicates an expected call of KeyVaultAuthorizer.
+func (mr *MockClusterScoperMockRecorder) KeyVaultAuthorizer(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyVaultAuthorizer", reflect.TypeOf((*MockClusterScoper)(nil).KeyVaultAuthorizer), ctx)
+}
+
+// KeyVaultDNSSuffix mocks base method.
+func (m *MockClusterScoper) KeyVaultDNSSuffix() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyVaultDNSSuffix")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// KeyVaultDNSSuffix indicates an expected call of KeyVaultDNSSuffix.
+func (mr *MockClusterScoperMockRecorder) KeyVaultDNSSuffix() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyVaultDNSSuffix", reflect.TypeOf((*MockClusterScoper)(nil).KeyVaultDNSSuffix))
+}
+
 // Location mocks base method.
 func (m *MockClusterScoper) Location() string {
 	m.ctrl.T.Helper()
@@ -1324,6 +1639,20 @@ func (mr *MockClusterScoperMockRecorder) NodeSubnets() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeSubnets", reflect.TypeOf((*MockClusterScoper)(nil).NodeSubnets))
 }
 
+// OutboundAdditionalPoolNames mocks base method.
+func (m *MockClusterScoper) OutboundAdditionalPoolNames(arg0 string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OutboundAdditionalPoolNames", arg0)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// OutboundAdditionalPoolNames indicates an expected call of OutboundAdditionalPoolNames.
+func (mr *MockClusterScoperMockRecorder) OutboundAdditionalPoolNames(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OutboundAdditionalPoolNames", reflect.TypeOf((*MockClusterScoper)(nil).OutboundAdditionalPoolNames), arg0)
+}
+
 // OutboundLBName mocks base method.
 func (m *MockClusterScoper) OutboundLBName(arg0 string) string {
 	m.ctrl.T.Helper()
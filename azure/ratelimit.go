@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// APIRateLimiters rate limits the requests sent to Azure with a token bucket per subscription, so that all the clients
+// of a subscription share a budget of requests whichever service they belong to. Requests that don't target a
+// subscription share a bucket of their own. The zero value doesn't rate limit requests.
+type APIRateLimiters struct {
+	mu       sync.Mutex
+	qps      float32
+	burst    int
+	limiters map[string]flowcontrol.RateLimiter
+}
+
+// apiRateLimiters rate limits the requests of all the Azure clients of the controller manager.
+var apiRateLimiters = &APIRateLimiters{}
+
+// SetAPIRateLimits sets the number of requests per second and the burst of requests each subscription is allowed to
+// send to Azure. A QPS of zero or less disables rate limiting. It applies to clients created before it is called.
+func SetAPIRateLimits(qps float32, burst int) {
+	apiRateLimiters.SetLimits(qps, burst)
+}
+
+// SetLimits sets the number of requests per second and the burst of requests of each subscription, and resets the
+// budgets of the subscriptions. A QPS of zero or less disables rate limiting.
+func (l *APIRateLimiters) SetLimits(qps float32, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.qps = qps
+	l.burst = burst
+	l.limiters = nil
+}
+
+// ForSubscription returns the rate limiter shared by the requests to a subscription, or nil if requests aren't rate
+// limited.
+func (l *APIRateLimiters) ForSubscription(subscriptionID string) flowcontrol.RateLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.qps <= 0 {
+		return nil
+	}
+	key := strings.ToLower(subscriptionID)
+	limiter, ok := l.limiters[key]
+	if !ok {
+		if l.limiters == nil {
+			l.limiters = make(map[string]flowcontrol.RateLimiter)
+		}
+		limiter = flowcontrol.NewTokenBucketRateLimiter(l.qps, l.burst)
+		l.limiters[key] = limiter
+	}
+	return limiter
+}
+
+// wait blocks until a request is allowed to be sent to Azure by the rate limiter of its subscription, or its context
+// is done.
+func (l *APIRateLimiters) wait(r *http.Request) error {
+	subscriptionID := subscriptionIDFromPath(r.URL.Path)
+	limiter := l.ForSubscription(subscriptionID)
+	if limiter == nil {
+		return nil
+	}
+	if err := limiter.Wait(r.Context()); err != nil {
+		return errors.Wrapf(err, "client-side rate limit of subscription %q exceeded", subscriptionID)
+	}
+	return nil
+}
+
+// SendDecorator rate limits the requests sent by an autorest client.
+func (l *APIRateLimiters) SendDecorator(snd autorest.Sender) autorest.Sender {
+	return autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+		if err := l.wait(r); err != nil {
+			return nil, err
+		}
+		return snd.Do(r)
+	})
+}
+
+// rateLimitPolicy rate limits the requests sent by an azure-sdk-for-go v2 client.
+// It implements the policy.Policy interface.
+type rateLimitPolicy struct {
+	limiters *APIRateLimiters
+}
+
+// Do waits for the rate limiter of the subscription of a request before sending it.
+func (p rateLimitPolicy) Do(req *policy.Request) (*http.Response, error) {
+	if err := p.limiters.wait(req.Raw()); err != nil {
+		return nil, err
+	}
+	return req.Next()
+}
+
+// subscriptionIDFromPath returns the subscription ID of an Azure Resource Manager request path, e.g. 123 for
+// /subscriptions/123/resourceGroups/my-rg, or an empty string if the request doesn't target a subscription.
+func subscriptionIDFromPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) < 2 || !strings.EqualFold(segments[0], "subscriptions") {
+		return ""
+	}
+	return segments[1]
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+)
+
+// newCountingServer returns a server answering requests with an empty JSON object, and the number of requests it
+// received.
+func newCountingServer() (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	}))
+	return server, &requests
+}
+
+func TestAPIRateLimitersGateConcurrentCalls(t *testing.T) {
+	g := NewWithT(t)
+	server, requests := newCountingServer()
+	defer server.Close()
+
+	// The budget doesn't refill during the test, so only the burst of requests is sent.
+	limiters := &APIRateLimiters{}
+	limiters.SetLimits(0.001, 2)
+	sender := autorest.DecorateSender(server.Client(), limiters.SendDecorator)
+
+	var wg sync.WaitGroup
+	var sent, limited int32
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/subscriptions/123/resourceGroups/my-rg", http.NoBody)
+			g.Expect(err).NotTo(HaveOccurred())
+			resp, err := sender.Do(req)
+			if err != nil {
+				g.Expect(err.Error()).To(ContainSubstring(`client-side rate limit of subscription "123" exceeded`))
+				atomic.AddInt32(&limited, 1)
+				return
+			}
+			defer resp.Body.Close()
+			atomic.AddInt32(&sent, 1)
+		}()
+	}
+	wg.Wait()
+
+	g.Expect(atomic.LoadInt32(&sent)).To(BeNumerically("==", 2))
+	g.Expect(atomic.LoadInt32(&limited)).To(BeNumerically("==", 3))
+	g.Expect(atomic.LoadInt32(requests)).To(BeNumerically("==", 2))
+}
+
+func TestAPIRateLimitersShareBudgetAcrossServices(t *testing.T) {
+	g := NewWithT(t)
+	server, requests := newCountingServer()
+	defer server.Close()
+
+	SetAPIRateLimits(0.001, 2)
+	t.Cleanup(func() { SetAPIRateLimits(0, 0) })
+
+	// The clients of two services of the same subscription share its budget.
+	disksClient := compute.NewDisksClientWithBaseURI(server.URL, "123")
+	SetAutoRestClientDefaults(&disksClient.Client, autorest.NullAuthorizer{})
+	vnetsClient := network.NewVirtualNetworksClientWithBaseURI(server.URL, "123")
+	SetAutoRestClientDefaults(&vnetsClient.Client, autorest.NullAuthorizer{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := disksClient.Get(ctx, "my-rg", "my-disk")
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = disksClient.Get(ctx, "my-rg", "my-disk")
+	g.Expect(err).NotTo(HaveOccurred())
+	// autorest retries the request until its context is done, and returns the error of the context.
+	limitedCtx, limitedCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer limitedCancel()
+	_, err = vnetsClient.Get(limitedCtx, "my-rg", "my-vnet", "")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(atomic.LoadInt32(requests)).To(BeNumerically("==", 2))
+
+	// Another subscription has a budget of its own.
+	otherVnetsClient := network.NewVirtualNetworksClientWithBaseURI(server.URL, "456")
+	SetAutoRestClientDefaults(&otherVnetsClient.Client, autorest.NullAuthorizer{})
+	_, err = otherVnetsClient.Get(ctx, "my-rg", "my-vnet", "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(atomic.LoadInt32(requests)).To(BeNumerically("==", 3))
+}
+
+func TestAPIRateLimitersDisabled(t *testing.T) {
+	g := NewWithT(t)
+	limiters := &APIRateLimiters{}
+	g.Expect(limiters.ForSubscription("123")).To(BeNil())
+
+	limiters.SetLimits(10, 5)
+	g.Expect(limiters.ForSubscription("123")).NotTo(BeNil())
+	g.Expect(limiters.ForSubscription("123")).To(BeIdenticalTo(limiters.ForSubscription("123")))
+
+	limiters.SetLimits(0, 5)
+	g.Expect(limiters.ForSubscription("123")).To(BeNil())
+}
+
+func TestSubscriptionIDFromPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/my-disk", expected: "123"},
+		{path: "/Subscriptions/123", expected: "123"},
+		{path: "/subscriptions", expected: ""},
+		{path: "/providers/Microsoft.Authorization/roleDefinitions", expected: ""},
+		{path: "", expected: ""},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.path, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(subscriptionIDFromPath(tc.path)).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ServicePosition declares where a service injected into a ServiceRegistry is placed, relative to the names of the
+// services already registered. When neither After nor Before is set, the service is placed last.
+type ServicePosition struct {
+	// After is the name of the service the injected service is reconciled after.
+	After string
+	// Before is the name of the service the injected service is reconciled before.
+	Before string
+}
+
+// ServiceRegistry is an ordered list of ServiceReconcilers. Services are reconciled in the order of the registry and
+// deleted in the reverse order.
+type ServiceRegistry struct {
+	services []ServiceReconciler
+}
+
+// NewServiceRegistry creates a new ServiceRegistry holding the given services, in order.
+func NewServiceRegistry(services ...ServiceReconciler) *ServiceRegistry {
+	return &ServiceRegistry{services: services}
+}
+
+// Inject adds a service to the registry at the given position.
+// It returns an error if a service with the same name is already registered, if a service named in the position is
+// not registered, or if the position can't be satisfied.
+func (r *ServiceRegistry) Inject(service ServiceReconciler, position ServicePosition) error {
+	if r.indexOf(service.Name()) >= 0 {
+		return errors.Errorf("service %s is already registered", service.Name())
+	}
+
+	index := len(r.services)
+	if position.After != "" {
+		after := r.indexOf(position.After)
+		if after < 0 {
+			return errors.Errorf("cannot inject service %s after service %s: service %s is not registered", service.Name(), position.After, position.After)
+		}
+		index = after + 1
+	}
+	if position.Before != "" {
+		before := r.indexOf(position.Before)
+		if before < 0 {
+			return errors.Errorf("cannot inject service %s before service %s: service %s is not registered", service.Name(), position.Before, position.Before)
+		}
+		if position.After != "" && before < index {
+			return errors.Errorf("cannot inject service %s after service %s and before service %s: service %s is reconciled before service %s",
+				service.Name(), position.After, position.Before, position.Before, position.After)
+		}
+		index = before
+	}
+
+	r.services = append(r.services, nil)
+	copy(r.services[index+1:], r.services[index:])
+	r.services[index] = service
+	return nil
+}
+
+// Services returns the registered services, in reconcile order.
+func (r *ServiceRegistry) Services() []ServiceReconciler {
+	return r.services
+}
+
+func (r *ServiceRegistry) indexOf(name string) int {
+	for i, service := range r.services {
+		if service.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type fakeService struct {
+	name string
+}
+
+func (f fakeService) Name() string                                { return f.name }
+func (f fakeService) IsManaged(ctx context.Context) (bool, error) { return true, nil }
+func (f fakeService) Reconcile(ctx context.Context) error         { return nil }
+func (f fakeService) Delete(ctx context.Context) error            { return nil }
+
+func serviceNames(services []ServiceReconciler) []string {
+	names := make([]string, 0, len(services))
+	for _, service := range services {
+		names = append(names, service.Name())
+	}
+	return names
+}
+
+func TestServiceRegistryInject(t *testing.T) {
+	tests := []struct {
+		name          string
+		position      ServicePosition
+		expectedOrder []string
+		expectedError string
+	}{
+		{
+			name:          "service without position is placed last",
+			position:      ServicePosition{},
+			expectedOrder: []string{"publicips", "interfaces", "disks", "virtualmachine", "custom"},
+		},
+		{
+			name:          "service is placed after the named service",
+			position:      ServicePosition{After: "interfaces"},
+			expectedOrder: []string{"publicips", "interfaces", "custom", "disks", "virtualmachine"},
+		},
+		{
+			name:          "service is placed before the named service",
+			position:      ServicePosition{Before: "interfaces"},
+			expectedOrder: []string{"publicips", "custom", "interfaces", "disks", "virtualmachine"},
+		},
+		{
+			name:          "service is placed after and before the named services",
+			position:      ServicePosition{After: "interfaces", Before: "virtualmachine"},
+			expectedOrder: []string{"publicips", "interfaces", "disks", "custom", "virtualmachine"},
+		},
+		{
+			name:          "service is placed after the last service",
+			position:      ServicePosition{After: "virtualmachine"},
+			expectedOrder: []string{"publicips", "interfaces", "disks", "virtualmachine", "custom"},
+		},
+		{
+			name:          "unknown service after",
+			position:      ServicePosition{After: "unknown"},
+			expectedError: "cannot inject service custom after service unknown: service unknown is not registered",
+		},
+		{
+			name:          "unknown service before",
+			position:      ServicePosition{Before: "unknown"},
+			expectedError: "cannot inject service custom before service unknown: service unknown is not registered",
+		},
+		{
+			name:          "conflicting position",
+			position:      ServicePosition{After: "virtualmachine", Before: "interfaces"},
+			expectedError: "cannot inject service custom after service virtualmachine and before service interfaces: service interfaces is reconciled before service virtualmachine",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			registry := NewServiceRegistry(
+				fakeService{"publicips"},
+				fakeService{"interfaces"},
+				fakeService{"disks"},
+				fakeService{"virtualmachine"},
+			)
+			err := registry.Inject(fakeService{"custom"}, tc.position)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+				g.Expect(serviceNames(registry.Services())).To(Equal([]string{"publicips", "interfaces", "disks", "virtualmachine"}))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(serviceNames(registry.Services())).To(Equal(tc.expectedOrder))
+			}
+		})
+	}
+}
+
+func TestServiceRegistryInjectDuplicate(t *testing.T) {
+	g := NewWithT(t)
+
+	registry := NewServiceRegistry(fakeService{"interfaces"})
+	g.Expect(registry.Inject(fakeService{"interfaces"}, ServicePosition{})).To(MatchError("service interfaces is already registered"))
+	g.Expect(serviceNames(registry.Services())).To(Equal([]string{"interfaces"}))
+}
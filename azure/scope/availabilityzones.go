@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AvailabilityZonesGetter gets the availability zones of the location of an AzureCluster from the resource SKUs
+// available in the location.
+type AvailabilityZonesGetter struct {
+	Client client.Client
+}
+
+// GetAvailabilityZones returns the availability zones of the location of an AzureCluster.
+func (g *AvailabilityZonesGetter) GetAvailabilityZones(ctx context.Context, azureCluster *infrav1.AzureCluster) ([]string, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "scope.AvailabilityZonesGetter.GetAvailabilityZones")
+	defer done()
+
+	var azureClients AzureClients
+	if azureCluster.Spec.IdentityRef == nil {
+		if err := azureClients.setCredentials(azureCluster.Spec.SubscriptionID, azureCluster.Spec.AzureEnvironment, azureCluster.Spec.TokenAudience); err != nil {
+			return nil, errors.Wrap(err, "failed to configure azure settings and credentials from environment")
+		}
+	} else {
+		credentialsProvider, err := NewAzureClusterCredentialsProvider(ctx, g.Client, azureCluster)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to init credentials provider")
+		}
+		if err := azureClients.setCredentialsWithProvider(ctx, azureCluster.Spec.SubscriptionID, azureCluster.Spec.AzureEnvironment, azureCluster.Spec.TokenAudience, credentialsProvider); err != nil {
+			return nil, errors.Wrap(err, "failed to configure azure settings and credentials for Identity")
+		}
+	}
+
+	skuCache, err := resourceskus.GetCache(azureClientsAuthorizer{&azureClients}, azureCluster.Spec.Location)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the resource SKUs")
+	}
+	zones, err := skuCache.GetZones(ctx, azureCluster.Spec.Location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get availability zones for location %s", azureCluster.Spec.Location)
+	}
+	return zones, nil
+}
+
+// azureClientsAuthorizer implements azure.Authorizer for AzureClients outside of a scope.
+type azureClientsAuthorizer struct {
+	*AzureClients
+}
+
+// BaseURI returns the Azure ResourceManagerEndpoint.
+func (a azureClientsAuthorizer) BaseURI() string {
+	return a.ResourceManagerEndpoint
+}
+
+// Authorizer returns the Azure client Authorizer.
+func (a azureClientsAuthorizer) Authorizer() autorest.Authorizer {
+	return a.AzureClients.Authorizer
+}
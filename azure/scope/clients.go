@@ -37,6 +37,9 @@ type AzureClients struct {
 	Authorizer                 autorest.Authorizer
 	ResourceManagerEndpoint    string
 	ResourceManagerVMDNSSuffix string
+
+	// credentialsProvider provides the credentials of the cluster identity, if any.
+	credentialsProvider CredentialsProvider
 }
 
 // CloudEnvironment returns the Azure environment the controller runs in.
@@ -73,8 +76,29 @@ func (c *AzureClients) HashKey() string {
 	return base64.URLEncoding.EncodeToString(hasher.Sum(nil))
 }
 
-func (c *AzureClients) setCredentials(subscriptionID, environmentName string) error {
-	settings, err := c.getSettingsFromEnvironment(environmentName)
+// KeyVaultAuthorizer returns an authorizer for the Azure Key Vault data plane, using the same credentials as Authorizer.
+func (c *AzureClients) KeyVaultAuthorizer(ctx context.Context) (autorest.Authorizer, error) {
+	audience := c.Environment.ResourceIdentifiers.KeyVault
+	if c.credentialsProvider != nil {
+		return c.credentialsProvider.GetAuthorizer(ctx, c.ResourceManagerEndpoint, c.Environment.ActiveDirectoryEndpoint, audience)
+	}
+	settings := c.EnvironmentSettings
+	settings.Environment.TokenAudience = audience
+	return azureutil.GetAuthorizer(settings)
+}
+
+// AzureEnvironment returns the endpoints and the token audience of the Azure cloud environment the cluster runs in.
+func (c *AzureClients) AzureEnvironment() azureautorest.Environment {
+	return c.Environment
+}
+
+// KeyVaultDNSSuffix returns the DNS suffix of the Azure Key Vault endpoints in the cloud environment.
+func (c *AzureClients) KeyVaultDNSSuffix() string {
+	return c.Environment.KeyVaultDNSSuffix
+}
+
+func (c *AzureClients) setCredentials(subscriptionID, environmentName, tokenAudience string) error {
+	settings, err := c.getSettingsFromEnvironment(environmentName, tokenAudience)
 	if err != nil {
 		return err
 	}
@@ -103,12 +127,12 @@ func (c *AzureClients) setCredentials(subscriptionID, environmentName string) er
 	return nil
 }
 
-func (c *AzureClients) setCredentialsWithProvider(ctx context.Context, subscriptionID, environmentName string, credentialsProvider CredentialsProvider) error {
+func (c *AzureClients) setCredentialsWithProvider(ctx context.Context, subscriptionID, environmentName, tokenAudience string, credentialsProvider CredentialsProvider) error {
 	if credentialsProvider == nil {
 		return fmt.Errorf("credentials provider cannot have an empty value")
 	}
 
-	settings, err := c.getSettingsFromEnvironment(environmentName)
+	settings, err := c.getSettingsFromEnvironment(environmentName, tokenAudience)
 	if err != nil {
 		return err
 	}
@@ -133,11 +157,12 @@ func (c *AzureClients) setCredentialsWithProvider(ctx context.Context, subscript
 	}
 	c.Values[auth.ClientSecret] = strings.TrimSuffix(clientSecret, "\n")
 
+	c.credentialsProvider = credentialsProvider
 	c.Authorizer, err = credentialsProvider.GetAuthorizer(ctx, c.ResourceManagerEndpoint, c.Environment.ActiveDirectoryEndpoint, c.Environment.TokenAudience)
 	return err
 }
 
-func (c *AzureClients) getSettingsFromEnvironment(environmentName string) (s auth.EnvironmentSettings, err error) {
+func (c *AzureClients) getSettingsFromEnvironment(environmentName, tokenAudience string) (s auth.EnvironmentSettings, err error) {
 	s = auth.EnvironmentSettings{
 		Values: map[string]string{},
 	}
@@ -157,12 +182,27 @@ func (c *AzureClients) getSettingsFromEnvironment(environmentName string) (s aut
 	} else {
 		s.Environment, err = azureautorest.EnvironmentFromName(v)
 	}
+	s.Environment.TokenAudience = environmentTokenAudience(s.Environment, tokenAudience)
 	if s.Values[auth.Resource] == "" {
 		s.Values[auth.Resource] = s.Environment.ResourceManagerEndpoint
 	}
 	return
 }
 
+// environmentTokenAudience returns the audience of the Azure Resource Manager tokens in a cloud environment. The token
+// audience set in the cluster spec takes precedence. The environment files of custom clouds may have no token audience,
+// in which case the resource manager endpoint is used, as it is the default audience of Azure Resource Manager tokens.
+func environmentTokenAudience(environment azureautorest.Environment, tokenAudience string) string {
+	switch {
+	case tokenAudience != "":
+		return tokenAudience
+	case environment.TokenAudience != "":
+		return environment.TokenAudience
+	default:
+		return environment.ResourceManagerEndpoint
+	}
+}
+
 // setValue adds the specified environment variable value to the Values map if it exists.
 func setValue(settings auth.EnvironmentSettings, key string) {
 	if v := os.Getenv(key); v != "" {
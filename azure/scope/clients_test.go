@@ -17,6 +17,9 @@ limitations under the License.
 package scope
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/Azure/go-autorest/autorest"
@@ -70,7 +73,7 @@ func TestGettingEnvironment(t *testing.T) {
 			c := AzureClients{
 				Authorizer: autorest.NullAuthorizer{},
 			}
-			err := c.setCredentials("1234", test.azureEnv)
+			err := c.setCredentials("1234", test.azureEnv, "")
 			if test.expectedError {
 				g.Expect(err).To(HaveOccurred())
 				g.Expect(err.Error()).To(ContainSubstring(test.expectedErrorMessage))
@@ -82,3 +85,60 @@ func TestGettingEnvironment(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvironmentTokenAudience(t *testing.T) {
+	stackCloudEnvironment := `{
+		"name": "AzureStackCloud",
+		"resourceManagerEndpoint": "https://management.local.azurestack.external/",
+		"activeDirectoryEndpoint": "https://login.microsoftonline.com/"%s
+	}`
+	var tests = map[string]struct {
+		azureEnv              string
+		environmentFile       string
+		tokenAudience         string
+		expectedTokenAudience string
+	}{
+		"AzurePublicCloud": {
+			azureEnv:              "AzurePublicCloud",
+			expectedTokenAudience: "https://management.azure.com/",
+		},
+		"AzureUSGovernmentCloud": {
+			azureEnv:              "AzureUSGovernmentCloud",
+			expectedTokenAudience: "https://management.usgovcloudapi.net/",
+		},
+		"AzureChinaCloud": {
+			azureEnv:              "AzureChinaCloud",
+			expectedTokenAudience: "https://management.chinacloudapi.cn/",
+		},
+		"AzureStackCloud with a token audience in the environment file": {
+			azureEnv:              "AzureStackCloud",
+			environmentFile:       `, "tokenAudience": "https://management.adfs.azurestack.local/4de154de-f8a8-4017-af41-df619da68155"`,
+			expectedTokenAudience: "https://management.adfs.azurestack.local/4de154de-f8a8-4017-af41-df619da68155",
+		},
+		"AzureStackCloud without a token audience in the environment file": {
+			azureEnv:              "AzureStackCloud",
+			expectedTokenAudience: "https://management.local.azurestack.external/",
+		},
+		"AzureStackCloud with a token audience in the cluster spec": {
+			azureEnv:              "AzureStackCloud",
+			environmentFile:       `, "tokenAudience": "https://management.adfs.azurestack.local/4de154de-f8a8-4017-af41-df619da68155"`,
+			tokenAudience:         "https://management.local.azurestack.external/",
+			expectedTokenAudience: "https://management.local.azurestack.external/",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+			if test.azureEnv == "AzureStackCloud" {
+				path := filepath.Join(t.TempDir(), "azurestackcloud.json")
+				g.Expect(os.WriteFile(path, []byte(fmt.Sprintf(stackCloudEnvironment, test.environmentFile)), 0600)).To(Succeed())
+				t.Setenv("AZURE_ENVIRONMENT_FILEPATH", path)
+			}
+			c := AzureClients{
+				Authorizer: autorest.NullAuthorizer{},
+			}
+			g.Expect(c.setCredentials("1234", test.azureEnv, test.tokenAudience)).To(Succeed())
+			g.Expect(c.AzureEnvironment().TokenAudience).To(Equal(test.expectedTokenAudience))
+		})
+	}
+}
@@ -24,10 +24,13 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/net"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
@@ -39,12 +42,16 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/natgateways"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/privatedns"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/privateendpoints"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicipprefixes"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicips"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourcelocks"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/routetables"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/securitygroups"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/subnets"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/trafficmanagerprofiles"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualnetworks"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/vnetpeerings"
+	azureutil "sigs.k8s.io/cluster-api-provider-azure/util/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/util/futures"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -60,6 +67,7 @@ type ClusterScopeParams struct {
 	Cluster      *clusterv1.Cluster
 	AzureCluster *infrav1.AzureCluster
 	Cache        *ClusterCache
+	Recorder     record.EventRecorder
 }
 
 // NewClusterScope creates a new Scope from the supplied parameters.
@@ -76,7 +84,7 @@ func NewClusterScope(ctx context.Context, params ClusterScopeParams) (*ClusterSc
 	}
 
 	if params.AzureCluster.Spec.IdentityRef == nil {
-		err := params.AzureClients.setCredentials(params.AzureCluster.Spec.SubscriptionID, params.AzureCluster.Spec.AzureEnvironment)
+		err := params.AzureClients.setCredentials(params.AzureCluster.Spec.SubscriptionID, params.AzureCluster.Spec.AzureEnvironment, params.AzureCluster.Spec.TokenAudience)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to configure azure settings and credentials from environment")
 		}
@@ -85,7 +93,7 @@ func NewClusterScope(ctx context.Context, params ClusterScopeParams) (*ClusterSc
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to init credentials provider")
 		}
-		err = params.AzureClients.setCredentialsWithProvider(ctx, params.AzureCluster.Spec.SubscriptionID, params.AzureCluster.Spec.AzureEnvironment, credentialsProvider)
+		err = params.AzureClients.setCredentialsWithProvider(ctx, params.AzureCluster.Spec.SubscriptionID, params.AzureCluster.Spec.AzureEnvironment, params.AzureCluster.Spec.TokenAudience, credentialsProvider)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to configure azure settings and credentials for Identity")
 		}
@@ -107,6 +115,7 @@ func NewClusterScope(ctx context.Context, params ClusterScopeParams) (*ClusterSc
 		AzureCluster: params.AzureCluster,
 		patchHelper:  helper,
 		cache:        params.Cache,
+		recorder:     params.Recorder,
 	}, nil
 }
 
@@ -115,6 +124,7 @@ type ClusterScope struct {
 	Client      client.Client
 	patchHelper *patch.Helper
 	cache       *ClusterCache
+	recorder    record.EventRecorder
 
 	AzureClients
 	Cluster      *clusterv1.Cluster
@@ -141,6 +151,91 @@ func (s *ClusterScope) GetClient() client.Client {
 	return s.Client
 }
 
+// PublicIPPrefixSpecs returns the public IP prefix specs.
+func (s *ClusterScope) PublicIPPrefixSpecs() []azure.ResourceSpecGetter {
+	prefix := s.AzureCluster.Spec.NetworkSpec.OutboundPublicIPPrefix
+	if prefix == nil {
+		return nil
+	}
+	return []azure.ResourceSpecGetter{
+		&publicipprefixes.PublicIPPrefixSpec{
+			Name:             prefix.Name,
+			ResourceGroup:    s.ResourceGroup(),
+			ClusterName:      s.ClusterName(),
+			Location:         s.Location(),
+			ExtendedLocation: s.ExtendedLocation(),
+			PrefixLength:     prefix.PrefixLength,
+			FailureDomains:   s.FailureDomains(),
+			AdditionalTags:   s.AdditionalTags(),
+		},
+	}
+}
+
+// TrafficManagerProfileSpecs returns the Traffic Manager profile specs.
+func (s *ClusterScope) TrafficManagerProfileSpecs() []azure.ResourceSpecGetter {
+	trafficManager := s.AzureCluster.Spec.NetworkSpec.TrafficManager
+	if trafficManager == nil {
+		return nil
+	}
+	return []azure.ResourceSpecGetter{
+		&trafficmanagerprofiles.TrafficManagerProfileSpec{
+			Name:            trafficManager.Name,
+			ResourceGroup:   s.ResourceGroup(),
+			ClusterName:     s.ClusterName(),
+			RelativeDNSName: trafficManager.RelativeDNSName,
+			RoutingMethod:   trafficManager.RoutingMethod,
+			TTL:             trafficManager.TTL,
+			MonitorPort:     s.APIServerPort(),
+			MonitorPath:     trafficManager.MonitorPath,
+			Endpoints:       trafficManager.Endpoints,
+			AdditionalTags:  s.AdditionalTags(),
+		},
+	}
+}
+
+// ResourceLockSpecs returns the spec of the management lock protecting the resource group from deletion. It is only
+// returned when the protection is enabled, or when the removal of a lock created before was allowed.
+func (s *ClusterScope) ResourceLockSpecs() []azure.ResourceSpecGetter {
+	if !s.ResourceGroupDeletionProtection() && !s.ResourceGroupLockRemovalAllowed() {
+		return nil
+	}
+	return []azure.ResourceSpecGetter{
+		&resourcelocks.ResourceLockSpec{
+			Name:          azure.GenerateResourceGroupLockName(s.ClusterName()),
+			ResourceGroup: s.ResourceGroup(),
+			Notes:         fmt.Sprintf("Protects the resource group of cluster %s from deletion. Managed by CAPZ.", s.ClusterName()),
+		},
+	}
+}
+
+// ResourceGroupDeletionProtection returns true if the resource group must be protected from deletion by a management lock.
+func (s *ClusterScope) ResourceGroupDeletionProtection() bool {
+	return s.AzureCluster.Spec.ResourceGroupDeletionProtection
+}
+
+// ResourceGroupLockRemovalAllowed returns true if the management lock protecting the resource group from deletion may be removed.
+func (s *ClusterScope) ResourceGroupLockRemovalAllowed() bool {
+	return s.AzureCluster.GetAnnotations()[infrav1.RemoveResourceGroupLockAnnotation] == "true"
+}
+
+// TrafficManagerFQDN returns the FQDN of the Traffic Manager profile fronting the API server, if any.
+func (s *ClusterScope) TrafficManagerFQDN() string {
+	trafficManager := s.AzureCluster.Spec.NetworkSpec.TrafficManager
+	if trafficManager == nil {
+		return ""
+	}
+	return strings.ToLower(fmt.Sprintf("%s.%s", trafficManager.RelativeDNSName, s.AzureClients.Environment.TrafficManagerDNSSuffix))
+}
+
+// outboundPublicIPPrefixID returns the ID of the public IP prefix the node outbound public IPs are allocated from, if any.
+func (s *ClusterScope) outboundPublicIPPrefixID() string {
+	prefix := s.AzureCluster.Spec.NetworkSpec.OutboundPublicIPPrefix
+	if prefix == nil {
+		return ""
+	}
+	return azure.PublicIPPrefixID(s.SubscriptionID(), s.ResourceGroup(), prefix.Name)
+}
+
 // PublicIPSpecs returns the public IP specs.
 func (s *ClusterScope) PublicIPSpecs() []azure.ResourceSpecGetter {
 	var publicIPSpecs []azure.ResourceSpecGetter
@@ -160,6 +255,7 @@ func (s *ClusterScope) PublicIPSpecs() []azure.ResourceSpecGetter {
 					Location:         s.Location(),
 					ExtendedLocation: s.ExtendedLocation(),
 					FailureDomains:   s.FailureDomains(),
+					Zones:            ip.PublicIP.Zones,
 					AdditionalTags:   s.AdditionalTags(),
 				})
 			}
@@ -175,6 +271,7 @@ func (s *ClusterScope) PublicIPSpecs() []azure.ResourceSpecGetter {
 				Location:         s.Location(),
 				ExtendedLocation: s.ExtendedLocation(),
 				FailureDomains:   s.FailureDomains(),
+				Zones:            s.APIServerPublicIP().Zones,
 				AdditionalTags:   s.AdditionalTags(),
 				IPTags:           s.APIServerPublicIP().IPTags,
 			},
@@ -194,29 +291,37 @@ func (s *ClusterScope) PublicIPSpecs() []azure.ResourceSpecGetter {
 				Location:         s.Location(),
 				ExtendedLocation: s.ExtendedLocation(),
 				FailureDomains:   s.FailureDomains(),
+				Zones:            ip.PublicIP.Zones,
 				AdditionalTags:   s.AdditionalTags(),
+				PublicIPPrefixID: s.outboundPublicIPPrefixID(),
 			})
 		}
 	}
 
-	// Public IP specs for node NAT gateways
+	// Public IP specs for node NAT gateways, once per NAT gateway as it can be shared by several node subnets.
 	var nodeNatGatewayIPSpecs []azure.ResourceSpecGetter
+	natGatewayIPSet := make(map[string]struct{})
 	for _, subnet := range s.NodeSubnets() {
 		if subnet.IsNatGatewayEnabled() {
+			if _, ok := natGatewayIPSet[subnet.NatGateway.NatGatewayIP.Name]; ok {
+				continue
+			}
+			natGatewayIPSet[subnet.NatGateway.NatGatewayIP.Name] = struct{}{}
 			nodeNatGatewayIPSpecs = append(nodeNatGatewayIPSpecs, &publicips.PublicIPSpec{
-				Name:           subnet.NatGateway.NatGatewayIP.Name,
-				ResourceGroup:  s.ResourceGroup(),
-				DNSName:        subnet.NatGateway.NatGatewayIP.DNSName,
-				IsIPv6:         false, // Public IP is IPv4 by default
-				ClusterName:    s.ClusterName(),
-				Location:       s.Location(),
-				FailureDomains: s.FailureDomains(),
-				AdditionalTags: s.AdditionalTags(),
-				IPTags:         subnet.NatGateway.NatGatewayIP.IPTags,
+				Name:             subnet.NatGateway.NatGatewayIP.Name,
+				ResourceGroup:    s.ResourceGroup(),
+				DNSName:          subnet.NatGateway.NatGatewayIP.DNSName,
+				IsIPv6:           false, // Public IP is IPv4 by default
+				ClusterName:      s.ClusterName(),
+				Location:         s.Location(),
+				FailureDomains:   s.FailureDomains(),
+				AdditionalTags:   s.AdditionalTags(),
+				IPTags:           subnet.NatGateway.NatGatewayIP.IPTags,
+				PublicIPPrefixID: s.outboundPublicIPPrefixID(),
 			})
 		}
-		publicIPSpecs = append(publicIPSpecs, nodeNatGatewayIPSpecs...)
 	}
+	publicIPSpecs = append(publicIPSpecs, nodeNatGatewayIPSpecs...)
 
 	if azureBastion := s.AzureBastion(); azureBastion != nil {
 		// public IP for Azure Bastion.
@@ -259,27 +364,32 @@ func (s *ClusterScope) LBSpecs() []azure.ResourceSpecGetter {
 			BackendPoolName:      s.APIServerLB().BackendPool.Name,
 			IdleTimeoutInMinutes: s.APIServerLB().IdleTimeoutInMinutes,
 			AdditionalTags:       s.AdditionalTags(),
+			EnableFloatingIP:     ptr.Deref(s.APIServerLB().EnableFloatingIP, false),
+			HealthProbe:          s.APIServerLB().HealthProbe,
+			FailureDomains:       s.FailureDomains(),
 		},
 	}
 
 	// Node outbound LB
 	if s.NodeOutboundLB() != nil {
 		specs = append(specs, &loadbalancers.LBSpec{
-			Name:                 s.NodeOutboundLB().Name,
-			ResourceGroup:        s.ResourceGroup(),
-			SubscriptionID:       s.SubscriptionID(),
-			ClusterName:          s.ClusterName(),
-			Location:             s.Location(),
-			ExtendedLocation:     s.ExtendedLocation(),
-			VNetName:             s.Vnet().Name,
-			VNetResourceGroup:    s.Vnet().ResourceGroup,
-			FrontendIPConfigs:    s.NodeOutboundLB().FrontendIPs,
-			Type:                 s.NodeOutboundLB().Type,
-			SKU:                  s.NodeOutboundLB().SKU,
-			BackendPoolName:      s.NodeOutboundLB().BackendPool.Name,
-			IdleTimeoutInMinutes: s.NodeOutboundLB().IdleTimeoutInMinutes,
-			Role:                 infrav1.NodeOutboundRole,
-			AdditionalTags:       s.AdditionalTags(),
+			Name:                       s.NodeOutboundLB().Name,
+			ResourceGroup:              s.ResourceGroup(),
+			SubscriptionID:             s.SubscriptionID(),
+			ClusterName:                s.ClusterName(),
+			Location:                   s.Location(),
+			ExtendedLocation:           s.ExtendedLocation(),
+			VNetName:                   s.Vnet().Name,
+			VNetResourceGroup:          s.Vnet().ResourceGroup,
+			FrontendIPConfigs:          s.NodeOutboundLB().FrontendIPs,
+			Type:                       s.NodeOutboundLB().Type,
+			SKU:                        s.NodeOutboundLB().SKU,
+			BackendPoolName:            s.NodeOutboundLB().BackendPool.Name,
+			AdditionalBackendPoolNames: s.OutboundAdditionalPoolNames(infrav1.Node),
+			IdleTimeoutInMinutes:       s.NodeOutboundLB().IdleTimeoutInMinutes,
+			Role:                       infrav1.NodeOutboundRole,
+			AdditionalTags:             s.AdditionalTags(),
+			DisableOutboundNAT:         s.isNodeOutboundNATDisabled(),
 		})
 	}
 
@@ -307,17 +417,77 @@ func (s *ClusterScope) LBSpecs() []azure.ResourceSpecGetter {
 	return specs
 }
 
+// ValidateEgress is the egress pre-flight validation of private clusters. It checks that every node subnet has an egress
+// path, i.e. a NAT gateway, the node outbound load balancer or a default route to a firewall or a virtual network
+// gateway, and that outbound deny, if configured, allows the required destinations. Only the egress configured in the
+// AzureCluster is considered.
+func (s *ClusterScope) ValidateEgress() error {
+	egressValidation := s.AzureCluster.Spec.NetworkSpec.EgressValidation
+	if egressValidation == nil {
+		return nil
+	}
+
+	var problems []string
+	hasNodeOutboundLB := s.NodeOutboundLB() != nil && !s.isNodeOutboundNATDisabled()
+	for _, subnet := range s.NodeSubnets() {
+		if subnet.IsNatGatewayEnabled() || hasNodeOutboundLB || hasDefaultRoute(subnet.RouteTable) {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("node subnet %s has no NAT gateway, node outbound load balancer or default route", subnet.Name))
+	}
+
+	if outboundDeny := s.AzureCluster.Spec.NetworkSpec.OutboundDeny; outboundDeny != nil {
+		allowed := make(map[string]struct{}, len(outboundDeny.AllowedDestinations))
+		for _, destination := range outboundDeny.AllowedDestinations {
+			allowed[strings.ToLower(destination)] = struct{}{}
+		}
+		for _, destination := range egressValidation.RequiredDestinations {
+			if _, ok := allowed[strings.ToLower(destination)]; !ok {
+				problems = append(problems, fmt.Sprintf("required destination %s is not an allowed destination of outbound deny", destination))
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.Errorf("the nodes have no egress path to their required destinations: %s", strings.Join(problems, "; "))
+}
+
+// hasDefaultRoute returns true if the route table sends the traffic to any destination to a firewall or a virtual
+// network gateway.
+func hasDefaultRoute(routeTable infrav1.RouteTable) bool {
+	for _, route := range routeTable.Routes {
+		if route.AddressPrefix != "0.0.0.0/0" {
+			continue
+		}
+		if route.NextHopType == infrav1.RouteNextHopTypeVirtualAppliance || route.NextHopType == infrav1.RouteNextHopTypeVirtualNetworkGateway {
+			return true
+		}
+	}
+	return false
+}
+
+// isNodeOutboundNATDisabled returns true if outbound deny is configured without any allowlisted destination, in which
+// case the nodes don't need the outbound rule of the node outbound load balancer.
+func (s *ClusterScope) isNodeOutboundNATDisabled() bool {
+	outboundDeny := s.AzureCluster.Spec.NetworkSpec.OutboundDeny
+	return outboundDeny != nil && len(outboundDeny.AllowedDestinations) == 0
+}
+
 // RouteTableSpecs returns the subnet route tables.
 func (s *ClusterScope) RouteTableSpecs() []azure.ResourceSpecGetter {
 	var specs []azure.ResourceSpecGetter
 	for _, subnet := range s.AzureCluster.Spec.NetworkSpec.Subnets {
 		if subnet.RouteTable.Name != "" {
 			specs = append(specs, &routetables.RouteTableSpec{
-				Name:           subnet.RouteTable.Name,
-				Location:       s.Location(),
-				ResourceGroup:  s.ResourceGroup(),
-				ClusterName:    s.ClusterName(),
-				AdditionalTags: s.AdditionalTags(),
+				Name:              subnet.RouteTable.Name,
+				Location:          s.Location(),
+				ResourceGroup:     s.ResourceGroup(),
+				ClusterName:       s.ClusterName(),
+				AdditionalTags:    s.AdditionalTags(),
+				Routes:            subnet.RouteTable.Routes,
+				LastAppliedRoutes: s.getLastAppliedRoutes(subnet.RouteTable.Name),
 			})
 		}
 	}
@@ -355,17 +525,28 @@ func (s *ClusterScope) NatGatewaySpecs() []azure.ResourceSpecGetter {
 
 // NSGSpecs returns the security group specs.
 func (s *ClusterScope) NSGSpecs() []azure.ResourceSpecGetter {
-	nsgspecs := make([]azure.ResourceSpecGetter, len(s.AzureCluster.Spec.NetworkSpec.Subnets))
-	for i, subnet := range s.AzureCluster.Spec.NetworkSpec.Subnets {
-		nsgspecs[i] = &securitygroups.NSGSpec{
+	nsgspecs := make([]azure.ResourceSpecGetter, 0, len(s.AzureCluster.Spec.NetworkSpec.Subnets))
+	for _, subnet := range s.AzureCluster.Spec.NetworkSpec.Subnets {
+		// Subnets such as the gateway subnet don't support network security groups.
+		if subnet.SecurityGroup.Name == "" {
+			continue
+		}
+		securityRules := subnet.SecurityGroup.SecurityRules
+		if subnet.Role == infrav1.SubnetNode {
+			if outboundDenyRules := s.AzureCluster.Spec.NetworkSpec.OutboundDenySecurityRules(); outboundDenyRules != nil {
+				securityRules = append(append(infrav1.SecurityRules{}, securityRules...), outboundDenyRules...)
+			}
+		}
+		nsgspecs = append(nsgspecs, &securitygroups.NSGSpec{
 			Name:                     subnet.SecurityGroup.Name,
-			SecurityRules:            subnet.SecurityGroup.SecurityRules,
+			SecurityRules:            securityRules,
 			ResourceGroup:            s.ResourceGroup(),
 			Location:                 s.Location(),
 			ClusterName:              s.ClusterName(),
 			AdditionalTags:           s.AdditionalTags(),
 			LastAppliedSecurityRules: s.getLastAppliedSecurityRules(subnet.SecurityGroup.Name),
-		}
+			RulePriorityBand:         subnet.SecurityGroup.RulePriorityBand,
+		})
 	}
 
 	return nsgspecs
@@ -418,6 +599,11 @@ func (s *ClusterScope) SubnetSpecs() []azure.ResourceSpecGetter {
 	return subnetSpecs
 }
 
+// ResourceGroupDeletionGuarded returns true if the resource group must not be deleted with the cluster.
+func (s *ClusterScope) ResourceGroupDeletionGuarded() bool {
+	return s.AzureCluster.GetAnnotations()[infrav1.DoNotDeleteAnnotation] == "true"
+}
+
 // GroupSpec returns the resource group spec.
 func (s *ClusterScope) GroupSpec() azure.ResourceSpecGetter {
 	return &groups.GroupSpec{
@@ -442,20 +628,28 @@ func (s *ClusterScope) ASOGroupSpec() azure.ASOResourceSpecGetter {
 
 // VnetPeeringSpecs returns the virtual network peering specs.
 func (s *ClusterScope) VnetPeeringSpecs() []azure.ResourceSpecGetter {
-	peeringSpecs := make([]azure.ResourceSpecGetter, 2*len(s.Vnet().Peerings))
-	for i, peering := range s.Vnet().Peerings {
+	peeringSpecs := make([]azure.ResourceSpecGetter, 0, 2*len(s.Vnet().Peerings))
+	for _, peering := range s.Vnet().Peerings {
+		remoteSubscriptionID := s.remoteVnetSubscriptionID(peering)
 		forwardPeering := &vnetpeerings.VnetPeeringSpec{
 			PeeringName:               azure.GenerateVnetPeeringName(s.Vnet().Name, peering.RemoteVnetName),
 			SourceVnetName:            s.Vnet().Name,
 			SourceResourceGroup:       s.Vnet().ResourceGroup,
 			RemoteVnetName:            peering.RemoteVnetName,
 			RemoteResourceGroup:       peering.ResourceGroup,
-			SubscriptionID:            s.SubscriptionID(),
+			SubscriptionID:            remoteSubscriptionID,
 			AllowForwardedTraffic:     peering.ForwardPeeringProperties.AllowForwardedTraffic,
 			AllowGatewayTransit:       peering.ForwardPeeringProperties.AllowGatewayTransit,
 			AllowVirtualNetworkAccess: peering.ForwardPeeringProperties.AllowVirtualNetworkAccess,
 			UseRemoteGateways:         peering.ForwardPeeringProperties.UseRemoteGateways,
 		}
+		peeringSpecs = append(peeringSpecs, forwardPeering)
+
+		// The peerings client is bound to the subscription of the cluster, so the reverse peering of a remote virtual
+		// network in another subscription has to be created out of band.
+		if !strings.EqualFold(remoteSubscriptionID, s.SubscriptionID()) {
+			continue
+		}
 		reversePeering := &vnetpeerings.VnetPeeringSpec{
 			PeeringName:               azure.GenerateVnetPeeringName(peering.RemoteVnetName, s.Vnet().Name),
 			SourceVnetName:            peering.RemoteVnetName,
@@ -467,24 +661,34 @@ func (s *ClusterScope) VnetPeeringSpecs() []azure.ResourceSpecGetter {
 			AllowGatewayTransit:       peering.ReversePeeringProperties.AllowGatewayTransit,
 			AllowVirtualNetworkAccess: peering.ReversePeeringProperties.AllowVirtualNetworkAccess,
 			UseRemoteGateways:         peering.ReversePeeringProperties.UseRemoteGateways,
+			Reverse:                   true,
 		}
-		peeringSpecs[i*2] = forwardPeering
-		peeringSpecs[i*2+1] = reversePeering
+		peeringSpecs = append(peeringSpecs, reversePeering)
 	}
 
 	return peeringSpecs
 }
 
+// remoteVnetSubscriptionID returns the subscription ID of the remote virtual network of a peering, which is the one
+// of the cluster unless the peering references the remote virtual network by resource ID.
+func (s *ClusterScope) remoteVnetSubscriptionID(peering infrav1.VnetPeeringSpec) string {
+	if resourceID, err := azureutil.ParseResourceID(peering.RemoteVnetID); err == nil {
+		return resourceID.SubscriptionID
+	}
+	return s.SubscriptionID()
+}
+
 // VNetSpec returns the virtual network spec.
 func (s *ClusterScope) VNetSpec() azure.ResourceSpecGetter {
 	return &virtualnetworks.VNetSpec{
-		ResourceGroup:    s.Vnet().ResourceGroup,
-		Name:             s.Vnet().Name,
-		CIDRs:            s.Vnet().CIDRBlocks,
-		ExtendedLocation: s.ExtendedLocation(),
-		Location:         s.Location(),
-		ClusterName:      s.ClusterName(),
-		AdditionalTags:   s.AdditionalTags(),
+		ResourceGroup:        s.Vnet().ResourceGroup,
+		Name:                 s.Vnet().Name,
+		CIDRs:                s.Vnet().CIDRBlocks,
+		ExtendedLocation:     s.ExtendedLocation(),
+		Location:             s.Location(),
+		FlowTimeoutInMinutes: s.Vnet().FlowTimeoutInMinutes,
+		ClusterName:          s.ClusterName(),
+		AdditionalTags:       s.AdditionalTags(),
 	}
 }
 
@@ -513,7 +717,7 @@ func (s *ClusterScope) PrivateDNSSpec() (zoneSpec azure.ResourceSpecGetter, link
 			links[i+1] = privatedns.LinkSpec{
 				Name:              azure.GenerateVNetLinkName(peering.RemoteVnetName),
 				ZoneName:          s.GetPrivateDNSZoneName(),
-				SubscriptionID:    s.SubscriptionID(),
+				SubscriptionID:    s.remoteVnetSubscriptionID(peering),
 				VNetResourceGroup: peering.ResourceGroup,
 				VNetName:          peering.RemoteVnetName,
 				ResourceGroup:     s.ResourceGroup(),
@@ -563,12 +767,19 @@ func (s *ClusterScope) AzureBastionSpec() azure.ResourceSpecGetter {
 			PublicIPID:      publicIPID,
 			Sku:             s.AzureBastion().Sku,
 			EnableTunneling: s.AzureBastion().EnableTunneling,
+			ScaleUnits:      s.AzureBastion().ScaleUnits,
+			AdditionalTags:  s.AdditionalTags(),
 		}
 	}
 
 	return nil
 }
 
+// DataCollectionRuleID returns the ID of the data collection rule the VMs of the cluster are associated with.
+func (s *ClusterScope) DataCollectionRuleID() string {
+	return s.AzureCluster.Spec.DataCollectionRuleID
+}
+
 // Vnet returns the cluster Vnet.
 func (s *ClusterScope) Vnet() *infrav1.VnetSpec {
 	return &s.AzureCluster.Spec.NetworkSpec.Vnet
@@ -745,6 +956,19 @@ func (s *ClusterScope) OutboundPoolName(role string) string {
 	return lb.BackendPool.Name
 }
 
+// OutboundAdditionalPoolNames returns the names of the additional backend pools of the outbound LB.
+func (s *ClusterScope) OutboundAdditionalPoolNames(role string) []string {
+	lb := s.outboundLB(role)
+	if lb == nil {
+		return nil
+	}
+	var names []string
+	for _, pool := range lb.AdditionalBackendPools {
+		names = append(names, pool.Name)
+	}
+	return names
+}
+
 // ResourceGroup returns the cluster resource group.
 func (s *ClusterScope) ResourceGroup() string {
 	return s.AzureCluster.Spec.ResourceGroup
@@ -852,6 +1076,9 @@ func (s *ClusterScope) PatchObject(ctx context.Context) error {
 			infrav1.PrivateDNSLinkReadyCondition,
 			infrav1.PrivateDNSRecordReadyCondition,
 			infrav1.PrivateEndpointsReadyCondition,
+			infrav1.DataCollectionRuleAssociationReadyCondition,
+			infrav1.APIServerLoadBalancerHealthyCondition,
+			infrav1.AzureResourcesUnlockedCondition,
 		}})
 }
 
@@ -879,6 +1106,9 @@ func (s *ClusterScope) APIServerPort() int32 {
 
 // APIServerHost returns the hostname used to reach the API server.
 func (s *ClusterScope) APIServerHost() string {
+	if fqdn := s.TrafficManagerFQDN(); fqdn != "" {
+		return fqdn
+	}
 	if s.IsAPIServerPrivate() {
 		return azure.GeneratePrivateFQDN(s.GetPrivateDNSZoneName())
 	}
@@ -992,6 +1222,9 @@ func (s *ClusterScope) DeleteLongRunningOperationState(name, service, futureType
 // UpdateDeleteStatus updates a condition on the AzureCluster status after a DELETE operation.
 func (s *ClusterScope) UpdateDeleteStatus(condition clusterv1.ConditionType, service string, err error) {
 	switch {
+	case err == nil && s.IsDryRun():
+		// Nothing was changed in Azure in dry-run mode, so the condition is left as it is.
+		return
 	case err == nil:
 		conditions.MarkFalse(s.AzureCluster, condition, infrav1.DeletedReason, clusterv1.ConditionSeverityInfo, "%s successfully deleted", service)
 	case azure.IsOperationNotDoneError(err):
@@ -1001,9 +1234,29 @@ func (s *ClusterScope) UpdateDeleteStatus(condition clusterv1.ConditionType, ser
 	}
 }
 
+// SetAPIServerLBHealth sets the APIServerLoadBalancerHealthy condition on the AzureCluster from the number of healthy
+// backends of the API server load balancer. The condition is false when no backend is healthy.
+func (s *ClusterScope) SetAPIServerLBHealth(healthyBackends, totalBackends int32) {
+	switch {
+	case healthyBackends > 0:
+		conditions.Set(s.AzureCluster, &clusterv1.Condition{
+			Type:    infrav1.APIServerLoadBalancerHealthyCondition,
+			Status:  corev1.ConditionTrue,
+			Message: fmt.Sprintf("%d of %d backends are healthy", healthyBackends, totalBackends),
+		})
+	case totalBackends == 0:
+		conditions.MarkFalse(s.AzureCluster, infrav1.APIServerLoadBalancerHealthyCondition, infrav1.NoHealthyBackendsReason, clusterv1.ConditionSeverityInfo, "the API server load balancer has no backends")
+	default:
+		conditions.MarkFalse(s.AzureCluster, infrav1.APIServerLoadBalancerHealthyCondition, infrav1.NoHealthyBackendsReason, clusterv1.ConditionSeverityWarning, "0 of %d backends are healthy", totalBackends)
+	}
+}
+
 // UpdatePutStatus updates a condition on the AzureCluster status after a PUT operation.
 func (s *ClusterScope) UpdatePutStatus(condition clusterv1.ConditionType, service string, err error) {
 	switch {
+	case err == nil && s.IsDryRun():
+		// Nothing was changed in Azure in dry-run mode, so the condition is left as it is.
+		return
 	case err == nil:
 		conditions.MarkTrue(s.AzureCluster, condition)
 	case azure.IsOperationNotDoneError(err):
@@ -1016,6 +1269,9 @@ func (s *ClusterScope) UpdatePutStatus(condition clusterv1.ConditionType, servic
 // UpdatePatchStatus updates a condition on the AzureCluster status after a PATCH operation.
 func (s *ClusterScope) UpdatePatchStatus(condition clusterv1.ConditionType, service string, err error) {
 	switch {
+	case err == nil && s.IsDryRun():
+		// Nothing was changed in Azure in dry-run mode, so the condition is left as it is.
+		return
 	case err == nil:
 		conditions.MarkTrue(s.AzureCluster, condition)
 	case azure.IsOperationNotDoneError(err):
@@ -1060,15 +1316,83 @@ func (s *ClusterScope) SetAnnotation(key, value string) {
 	s.AzureCluster.Annotations[key] = value
 }
 
+// IsDryRun returns true if the AzureCluster has the dry-run annotation set to "true".
+func (s *ClusterScope) IsDryRun() bool {
+	return s.AzureCluster.GetAnnotations()[infrav1.DryRunAnnotation] == "true"
+}
+
+// RecordEvent records an event on the AzureCluster if an event recorder is configured.
+func (s *ClusterScope) RecordEvent(eventType, reason, messageFmt string, args ...interface{}) {
+	if s.recorder == nil {
+		return
+	}
+	s.recorder.Eventf(s.AzureCluster, eventType, reason, messageFmt, args...)
+}
+
+// SetResourceLocked records a resource of the AzureCluster as locked by a management lock and sets the AzureResourcesUnlocked
+// condition to false.
+func (s *ClusterScope) SetResourceLocked(serviceName, resourceName, rgName string) {
+	setResourceLocked(s.AzureCluster, serviceName, resourceName, rgName)
+}
+
+// ResourceLockedSince returns when a resource was last found to be locked by a management lock, if it still is.
+func (s *ClusterScope) ResourceLockedSince(serviceName, resourceName, rgName string) (time.Time, bool) {
+	return resourceLockedSince(s.AzureCluster, serviceName, resourceName, rgName)
+}
+
+// ClearResourceLocked records a resource of the AzureCluster that was changed as no longer locked and sets the
+// AzureResourcesUnlocked condition to true once no resource is locked.
+func (s *ClusterScope) ClearResourceLocked(serviceName, resourceName, rgName string) {
+	clearResourceLocked(s.AzureCluster, serviceName, resourceName, rgName)
+}
+
 // TagsSpecs returns the tag specs for the AzureCluster.
 func (s *ClusterScope) TagsSpecs() []azure.TagsSpec {
-	return []azure.TagsSpec{
+	specs := []azure.TagsSpec{
 		{
 			Scope:      azure.ResourceGroupID(s.SubscriptionID(), s.ResourceGroup()),
 			Tags:       s.AdditionalTags(),
 			Annotation: azure.RGTagsLastAppliedAnnotation,
 		},
 	}
+
+	// Cluster-level tags cascade to every network resource managed for the cluster, so that changing
+	// spec.additionalTags is propagated to existing resources on the next reconcile.
+	for _, scope := range s.managedNetworkResourceIDs() {
+		specs = append(specs, azure.TagsSpec{
+			Scope:      scope,
+			Tags:       s.AdditionalTags(),
+			Annotation: azure.ClusterResourcesTagsLastAppliedAnnotation,
+		})
+	}
+
+	return specs
+}
+
+// managedNetworkResourceIDs returns the IDs of the network resources created for the cluster.
+func (s *ClusterScope) managedNetworkResourceIDs() []string {
+	var ids []string
+	appendIDs := func(specs []azure.ResourceSpecGetter, resourceID func(subscriptionID, resourceGroup, name string) string) {
+		for _, spec := range specs {
+			if spec.ResourceName() != "" {
+				ids = append(ids, resourceID(s.SubscriptionID(), spec.ResourceGroupName(), spec.ResourceName()))
+			}
+		}
+	}
+
+	// Security groups, route tables and NAT gateways are only created in a vnet managed by CAPZ.
+	if s.IsVnetManaged() {
+		ids = append(ids, azure.VNetID(s.SubscriptionID(), s.Vnet().ResourceGroup, s.Vnet().Name))
+		appendIDs(s.NSGSpecs(), azure.SecurityGroupID)
+		appendIDs(s.RouteTableSpecs(), azure.RouteTableID)
+		appendIDs(s.NatGatewaySpecs(), azure.NatGatewayID)
+	}
+	appendIDs(s.PublicIPSpecs(), azure.PublicIPID)
+	appendIDs(s.LBSpecs(), azure.LoadBalancerID)
+	if s.IsAzureBastionEnabled() {
+		ids = append(ids, azure.BastionHostID(s.SubscriptionID(), s.ResourceGroup(), s.AzureBastion().Name))
+	}
+	return ids
 }
 
 // PrivateEndpointSpecs returns the private endpoint specs.
@@ -1139,3 +1463,18 @@ func (s *ClusterScope) getLastAppliedSecurityRules(nsgName string) map[string]in
 	}
 	return lastAppliedSecurityRules
 }
+
+func (s *ClusterScope) getLastAppliedRoutes(routeTableName string) map[string]interface{} {
+	// Retrieve the last applied routes for all route tables.
+	lastAppliedRoutesAll, err := s.AnnotationJSON(azure.RouteLastAppliedAnnotation)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+
+	// Retrieve the last applied routes for this route table.
+	lastAppliedRoutes, ok := lastAppliedRoutesAll[routeTableName].(map[string]interface{})
+	if !ok {
+		lastAppliedRoutes = map[string]interface{}{}
+	}
+	return lastAppliedRoutes
+}
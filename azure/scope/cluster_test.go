@@ -22,11 +22,14 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/ptr"
@@ -35,12 +38,17 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/bastionhosts"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/loadbalancers"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/natgateways"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicipprefixes"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicips"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/routetables"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/securitygroups"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/subnets"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/trafficmanagerprofiles"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualnetworks"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/vnetpeerings"
+	"sigs.k8s.io/cluster-api-provider-azure/util/slice"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -575,6 +583,72 @@ func TestPublicIPSpecs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Azure cluster with zonal public type apiserver LB",
+			azureCluster: &infrav1.AzureCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "my-cluster",
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "cluster.x-k8s.io/v1beta1",
+							Kind:       "Cluster",
+							Name:       "my-cluster",
+						},
+					},
+				},
+				Status: infrav1.AzureClusterStatus{
+					FailureDomains: map[string]clusterv1.FailureDomainSpec{
+						"failure-domain-id-1": {},
+						"failure-domain-id-2": {},
+						"failure-domain-id-3": {},
+					},
+				},
+				Spec: infrav1.AzureClusterSpec{
+					ResourceGroup: "my-rg",
+					AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+						SubscriptionID: "123",
+						Location:       "centralIndia",
+						AdditionalTags: infrav1.Tags{
+							"Name": "my-publicip-ipv6",
+							"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": "owned",
+						},
+					},
+					NetworkSpec: infrav1.NetworkSpec{
+						ControlPlaneOutboundLB: &infrav1.LoadBalancerSpec{
+							LoadBalancerClassSpec: infrav1.LoadBalancerClassSpec{},
+						},
+						APIServerLB: infrav1.LoadBalancerSpec{
+							LoadBalancerClassSpec: infrav1.LoadBalancerClassSpec{},
+							FrontendIPs: []infrav1.FrontendIP{
+								{
+									PublicIP: &infrav1.PublicIPSpec{
+										Name:    "40.60.89.22",
+										DNSName: "fake-dns",
+										Zones:   []string{"failure-domain-id-2"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedPublicIPSpec: []azure.ResourceSpecGetter{
+				&publicips.PublicIPSpec{
+					Name:           "40.60.89.22",
+					ResourceGroup:  "my-rg",
+					DNSName:        "fake-dns",
+					IsIPv6:         false,
+					ClusterName:    "my-cluster",
+					Location:       "centralIndia",
+					FailureDomains: []string{"failure-domain-id-1", "failure-domain-id-2", "failure-domain-id-3"},
+					Zones:          []string{"failure-domain-id-2"},
+					AdditionalTags: infrav1.Tags{
+						"Name": "my-publicip-ipv6",
+						"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": "owned",
+					},
+				},
+			},
+		},
 		{
 			name: "Azure cluster with public type apiserver LB and public node outbound lb",
 			azureCluster: &infrav1.AzureCluster{
@@ -743,6 +817,143 @@ func TestPublicIPSpecs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Azure cluster with node subnets sharing a NAT gateway",
+			azureCluster: &infrav1.AzureCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "my-cluster",
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "cluster.x-k8s.io/v1beta1",
+							Kind:       "Cluster",
+							Name:       "my-cluster",
+						},
+					},
+				},
+				Spec: infrav1.AzureClusterSpec{
+					ResourceGroup: "my-rg",
+					AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+						SubscriptionID: "123",
+						Location:       "centralIndia",
+					},
+					NetworkSpec: infrav1.NetworkSpec{
+						Subnets: infrav1.Subnets{
+							infrav1.SubnetSpec{
+								SubnetClassSpec: infrav1.SubnetClassSpec{
+									Role: infrav1.SubnetNode,
+									Name: "node-subnet-1",
+								},
+								NatGateway: infrav1.NatGateway{
+									NatGatewayIP:        infrav1.PublicIPSpec{Name: "pip-shared-natgw"},
+									NatGatewayClassSpec: infrav1.NatGatewayClassSpec{Name: "shared-natgw"},
+								},
+							},
+							infrav1.SubnetSpec{
+								SubnetClassSpec: infrav1.SubnetClassSpec{
+									Role: infrav1.SubnetNode,
+									Name: "node-subnet-2",
+								},
+								NatGateway: infrav1.NatGateway{
+									NatGatewayIP:        infrav1.PublicIPSpec{Name: "pip-shared-natgw"},
+									NatGatewayClassSpec: infrav1.NatGatewayClassSpec{Name: "shared-natgw"},
+								},
+							},
+						},
+						APIServerLB: infrav1.LoadBalancerSpec{
+							LoadBalancerClassSpec: infrav1.LoadBalancerClassSpec{
+								Type: infrav1.Internal,
+							},
+						},
+					},
+				},
+			},
+			expectedPublicIPSpec: []azure.ResourceSpecGetter{
+				&publicips.PublicIPSpec{
+					Name:           "pip-shared-natgw",
+					ResourceGroup:  "my-rg",
+					IsIPv6:         false,
+					ClusterName:    "my-cluster",
+					Location:       "centralIndia",
+					FailureDomains: []string{},
+					AdditionalTags: infrav1.Tags{},
+				},
+			},
+		},
+		{
+			name: "Azure cluster with outbound public IPs allocated from a public IP prefix",
+			azureCluster: &infrav1.AzureCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "my-cluster",
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "cluster.x-k8s.io/v1beta1",
+							Kind:       "Cluster",
+							Name:       "my-cluster",
+						},
+					},
+				},
+				Spec: infrav1.AzureClusterSpec{
+					ResourceGroup: "my-rg",
+					AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+						SubscriptionID: "123",
+						Location:       "centralIndia",
+					},
+					NetworkSpec: infrav1.NetworkSpec{
+						Subnets: infrav1.Subnets{
+							infrav1.SubnetSpec{
+								SubnetClassSpec: infrav1.SubnetClassSpec{
+									Role: infrav1.SubnetNode,
+									Name: "node-subnet",
+								},
+								NatGateway: infrav1.NatGateway{
+									NatGatewayIP:        infrav1.PublicIPSpec{Name: "pip-natgw"},
+									NatGatewayClassSpec: infrav1.NatGatewayClassSpec{Name: "natgw"},
+								},
+							},
+						},
+						NodeOutboundLB: &infrav1.LoadBalancerSpec{
+							FrontendIPs: []infrav1.FrontendIP{
+								{
+									Name: "my-frontend-ip",
+									PublicIP: &infrav1.PublicIPSpec{
+										Name: "pip-my-cluster-node-outbound",
+									},
+								},
+							},
+						},
+						APIServerLB: infrav1.LoadBalancerSpec{
+							LoadBalancerClassSpec: infrav1.LoadBalancerClassSpec{
+								Type: infrav1.Internal,
+							},
+						},
+						OutboundPublicIPPrefix: &infrav1.PublicIPPrefixSpec{
+							Name:         "my-prefix",
+							PrefixLength: 30,
+						},
+					},
+				},
+			},
+			expectedPublicIPSpec: []azure.ResourceSpecGetter{
+				&publicips.PublicIPSpec{
+					Name:             "pip-my-cluster-node-outbound",
+					ResourceGroup:    "my-rg",
+					ClusterName:      "my-cluster",
+					Location:         "centralIndia",
+					FailureDomains:   []string{},
+					AdditionalTags:   infrav1.Tags{},
+					PublicIPPrefixID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPPrefixes/my-prefix",
+				},
+				&publicips.PublicIPSpec{
+					Name:             "pip-natgw",
+					ResourceGroup:    "my-rg",
+					ClusterName:      "my-cluster",
+					Location:         "centralIndia",
+					FailureDomains:   []string{},
+					AdditionalTags:   infrav1.Tags{},
+					PublicIPPrefixID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPPrefixes/my-prefix",
+				},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -808,6 +1019,11 @@ func TestRouteTableSpecs(t *testing.T) {
 					},
 				},
 				AzureCluster: &infrav1.AzureCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{
+							azure.RouteLastAppliedAnnotation: `{"fake-route-table-1":{"old-route":"192.168.0.0/16"}}`,
+						},
+					},
 					Spec: infrav1.AzureClusterSpec{
 						ResourceGroup: "my-rg",
 						AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
@@ -819,6 +1035,14 @@ func TestRouteTableSpecs(t *testing.T) {
 									RouteTable: infrav1.RouteTable{
 										ID:   "fake-route-table-id-1",
 										Name: "fake-route-table-1",
+										Routes: []infrav1.Route{
+											{
+												Name:             "to-firewall",
+												AddressPrefix:    "0.0.0.0/0",
+												NextHopType:      infrav1.RouteNextHopTypeVirtualAppliance,
+												NextHopIPAddress: "10.0.100.4",
+											},
+										},
 									},
 								},
 								{
@@ -840,13 +1064,25 @@ func TestRouteTableSpecs(t *testing.T) {
 					Location:       "centralIndia",
 					ClusterName:    "my-cluster",
 					AdditionalTags: make(infrav1.Tags),
+					Routes: []infrav1.Route{
+						{
+							Name:             "to-firewall",
+							AddressPrefix:    "0.0.0.0/0",
+							NextHopType:      infrav1.RouteNextHopTypeVirtualAppliance,
+							NextHopIPAddress: "10.0.100.4",
+						},
+					},
+					LastAppliedRoutes: map[string]interface{}{
+						"old-route": "192.168.0.0/16",
+					},
 				},
 				&routetables.RouteTableSpec{
-					Name:           "fake-route-table-2",
-					ResourceGroup:  "my-rg",
-					Location:       "centralIndia",
-					ClusterName:    "my-cluster",
-					AdditionalTags: make(infrav1.Tags),
+					Name:              "fake-route-table-2",
+					ResourceGroup:     "my-rg",
+					Location:          "centralIndia",
+					ClusterName:       "my-cluster",
+					AdditionalTags:    make(infrav1.Tags),
+					LastAppliedRoutes: map[string]interface{}{},
 				},
 			},
 		},
@@ -1128,10 +1364,108 @@ func TestNSGSpecs(t *testing.T) {
 					},
 				},
 			},
-			want: []azure.ResourceSpecGetter{},
+			want: []azure.ResourceSpecGetter{},
+		},
+		{
+			name: "returns specified security groups if present",
+			clusterScope: ClusterScope{
+				Cluster: &clusterv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "my-cluster",
+					},
+				},
+				AzureCluster: &infrav1.AzureCluster{
+					Spec: infrav1.AzureClusterSpec{
+						ResourceGroup: "my-rg",
+						AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+							Location: "centralIndia",
+						},
+						NetworkSpec: infrav1.NetworkSpec{
+							Subnets: infrav1.Subnets{
+								{
+									SecurityGroup: infrav1.SecurityGroup{
+										Name: "fake-security-group-1",
+										SecurityGroupClass: infrav1.SecurityGroupClass{
+											SecurityRules: infrav1.SecurityRules{
+												{
+													Name: "fake-rule-1",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				cache: &ClusterCache{},
+			},
+			want: []azure.ResourceSpecGetter{
+				&securitygroups.NSGSpec{
+					Name: "fake-security-group-1",
+					SecurityRules: infrav1.SecurityRules{
+						{
+							Name: "fake-rule-1",
+						},
+					},
+					ResourceGroup:            "my-rg",
+					Location:                 "centralIndia",
+					ClusterName:              "my-cluster",
+					AdditionalTags:           make(infrav1.Tags),
+					LastAppliedSecurityRules: map[string]interface{}{},
+				},
+			},
+		},
+		{
+			name: "skips the route server subnet",
+			clusterScope: ClusterScope{
+				Cluster: &clusterv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "my-cluster",
+					},
+				},
+				AzureCluster: &infrav1.AzureCluster{
+					Spec: infrav1.AzureClusterSpec{
+						ResourceGroup: "my-rg",
+						AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+							Location: "centralIndia",
+						},
+						NetworkSpec: infrav1.NetworkSpec{
+							Subnets: infrav1.Subnets{
+								{
+									SubnetClassSpec: infrav1.SubnetClassSpec{
+										Role: infrav1.SubnetNode,
+										Name: "node-subnet",
+									},
+									SecurityGroup: infrav1.SecurityGroup{
+										Name: "node-nsg",
+									},
+								},
+								{
+									SubnetClassSpec: infrav1.SubnetClassSpec{
+										Role: infrav1.SubnetRouteServer,
+										Name: infrav1.RouteServerSubnetName,
+									},
+								},
+							},
+						},
+					},
+				},
+				cache: &ClusterCache{},
+			},
+			want: []azure.ResourceSpecGetter{
+				&securitygroups.NSGSpec{
+					Name:                     "node-nsg",
+					ResourceGroup:            "my-rg",
+					Location:                 "centralIndia",
+					ClusterName:              "my-cluster",
+					AdditionalTags:           make(infrav1.Tags),
+					LastAppliedSecurityRules: map[string]interface{}{},
+				},
+			},
 		},
 		{
-			name: "returns specified security groups if present",
+			name: "appends outbound deny rules to node subnet security groups",
 			clusterScope: ClusterScope{
 				Cluster: &clusterv1.Cluster{
 					ObjectMeta: metav1.ObjectMeta{
@@ -1145,10 +1479,26 @@ func TestNSGSpecs(t *testing.T) {
 							Location: "centralIndia",
 						},
 						NetworkSpec: infrav1.NetworkSpec{
+							OutboundDeny: &infrav1.OutboundDenySpec{
+								AllowedDestinations: []string{"MicrosoftContainerRegistry", "10.1.0.0/16"},
+							},
 							Subnets: infrav1.Subnets{
 								{
+									SubnetClassSpec: infrav1.SubnetClassSpec{
+										Role: infrav1.SubnetControlPlane,
+										Name: "control-plane-subnet",
+									},
 									SecurityGroup: infrav1.SecurityGroup{
-										Name: "fake-security-group-1",
+										Name: "control-plane-nsg",
+									},
+								},
+								{
+									SubnetClassSpec: infrav1.SubnetClassSpec{
+										Role: infrav1.SubnetNode,
+										Name: "node-subnet",
+									},
+									SecurityGroup: infrav1.SecurityGroup{
+										Name: "node-nsg",
 										SecurityGroupClass: infrav1.SecurityGroupClass{
 											SecurityRules: infrav1.SecurityRules{
 												{
@@ -1166,11 +1516,67 @@ func TestNSGSpecs(t *testing.T) {
 			},
 			want: []azure.ResourceSpecGetter{
 				&securitygroups.NSGSpec{
-					Name: "fake-security-group-1",
+					Name:                     "control-plane-nsg",
+					ResourceGroup:            "my-rg",
+					Location:                 "centralIndia",
+					ClusterName:              "my-cluster",
+					AdditionalTags:           make(infrav1.Tags),
+					LastAppliedSecurityRules: map[string]interface{}{},
+				},
+				&securitygroups.NSGSpec{
+					Name: "node-nsg",
 					SecurityRules: infrav1.SecurityRules{
 						{
 							Name: "fake-rule-1",
 						},
+						{
+							Name:             "allow_outbound_vnet",
+							Description:      "Allow outbound traffic within the virtual network",
+							Protocol:         infrav1.SecurityGroupProtocolAll,
+							Direction:        infrav1.SecurityRuleDirectionOutbound,
+							Action:           infrav1.SecurityRuleActionAllow,
+							Priority:         4000,
+							Source:           ptr.To("*"),
+							SourcePorts:      ptr.To("*"),
+							Destination:      ptr.To("VirtualNetwork"),
+							DestinationPorts: ptr.To("*"),
+						},
+						{
+							Name:             "allow_outbound_MicrosoftContainerRegistry",
+							Description:      "Allow outbound traffic to MicrosoftContainerRegistry",
+							Protocol:         infrav1.SecurityGroupProtocolAll,
+							Direction:        infrav1.SecurityRuleDirectionOutbound,
+							Action:           infrav1.SecurityRuleActionAllow,
+							Priority:         4001,
+							Source:           ptr.To("*"),
+							SourcePorts:      ptr.To("*"),
+							Destination:      ptr.To("MicrosoftContainerRegistry"),
+							DestinationPorts: ptr.To("*"),
+						},
+						{
+							Name:             "allow_outbound_10.1.0.0_16",
+							Description:      "Allow outbound traffic to 10.1.0.0/16",
+							Protocol:         infrav1.SecurityGroupProtocolAll,
+							Direction:        infrav1.SecurityRuleDirectionOutbound,
+							Action:           infrav1.SecurityRuleActionAllow,
+							Priority:         4002,
+							Source:           ptr.To("*"),
+							SourcePorts:      ptr.To("*"),
+							Destination:      ptr.To("10.1.0.0/16"),
+							DestinationPorts: ptr.To("*"),
+						},
+						{
+							Name:             "deny_outbound",
+							Description:      "Deny all other outbound traffic",
+							Protocol:         infrav1.SecurityGroupProtocolAll,
+							Direction:        infrav1.SecurityRuleDirectionOutbound,
+							Action:           infrav1.SecurityRuleActionDeny,
+							Priority:         4096,
+							Source:           ptr.To("*"),
+							SourcePorts:      ptr.To("*"),
+							Destination:      ptr.To("*"),
+							DestinationPorts: ptr.To("*"),
+						},
 					},
 					ResourceGroup:            "my-rg",
 					Location:                 "centralIndia",
@@ -1289,6 +1695,135 @@ func TestSubnetSpecs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "returns pod subnet spec",
+			clusterScope: ClusterScope{
+				Cluster: &clusterv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "my-cluster",
+					},
+				},
+				AzureClients: AzureClients{
+					EnvironmentSettings: auth.EnvironmentSettings{
+						Values: map[string]string{
+							auth.SubscriptionID: "123",
+						},
+					},
+				},
+				AzureCluster: &infrav1.AzureCluster{
+					Spec: infrav1.AzureClusterSpec{
+						ResourceGroup: "my-rg",
+						AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+							Location: "centralIndia",
+						},
+						NetworkSpec: infrav1.NetworkSpec{
+							Vnet: infrav1.VnetSpec{
+								ID:            "fake-vnet-id-1",
+								Name:          "fake-vnet-1",
+								ResourceGroup: "my-rg-vnet",
+							},
+							Subnets: infrav1.Subnets{
+								{
+									SubnetClassSpec: infrav1.SubnetClassSpec{
+										Role:       infrav1.SubnetNode,
+										CIDRBlocks: []string{"10.1.0.0/24"},
+										Name:       "fake-subnet-1",
+									},
+									SecurityGroup: infrav1.SecurityGroup{
+										Name: "fake-security-group-1",
+									},
+								},
+								{
+									SubnetClassSpec: infrav1.SubnetClassSpec{
+										Role:       infrav1.SubnetPod,
+										CIDRBlocks: []string{"10.2.0.0/16"},
+										Name:       "fake-pod-subnet",
+									},
+								},
+							},
+						},
+					},
+				},
+				cache: &ClusterCache{},
+			},
+			want: []azure.ResourceSpecGetter{
+				&subnets.SubnetSpec{
+					Name:              "fake-subnet-1",
+					ResourceGroup:     "my-rg",
+					SubscriptionID:    "123",
+					CIDRs:             []string{"10.1.0.0/24"},
+					VNetName:          "fake-vnet-1",
+					VNetResourceGroup: "my-rg-vnet",
+					IsVNetManaged:     false,
+					SecurityGroupName: "fake-security-group-1",
+					Role:              infrav1.SubnetNode,
+				},
+				&subnets.SubnetSpec{
+					Name:              "fake-pod-subnet",
+					ResourceGroup:     "my-rg",
+					SubscriptionID:    "123",
+					CIDRs:             []string{"10.2.0.0/16"},
+					VNetName:          "fake-vnet-1",
+					VNetResourceGroup: "my-rg-vnet",
+					IsVNetManaged:     false,
+					Role:              infrav1.SubnetPod,
+				},
+			},
+		},
+		{
+			name: "returns route server subnet spec without security group",
+			clusterScope: ClusterScope{
+				Cluster: &clusterv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "my-cluster",
+					},
+				},
+				AzureClients: AzureClients{
+					EnvironmentSettings: auth.EnvironmentSettings{
+						Values: map[string]string{
+							auth.SubscriptionID: "123",
+						},
+					},
+				},
+				AzureCluster: &infrav1.AzureCluster{
+					Spec: infrav1.AzureClusterSpec{
+						ResourceGroup: "my-rg",
+						AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+							Location: "centralIndia",
+						},
+						NetworkSpec: infrav1.NetworkSpec{
+							Vnet: infrav1.VnetSpec{
+								ID:            "fake-vnet-id-1",
+								Name:          "fake-vnet-1",
+								ResourceGroup: "my-rg-vnet",
+							},
+							Subnets: infrav1.Subnets{
+								{
+									SubnetClassSpec: infrav1.SubnetClassSpec{
+										Role:       infrav1.SubnetRouteServer,
+										CIDRBlocks: []string{"10.0.255.224/27"},
+										Name:       infrav1.RouteServerSubnetName,
+									},
+								},
+							},
+						},
+					},
+				},
+				cache: &ClusterCache{},
+			},
+			want: []azure.ResourceSpecGetter{
+				&subnets.SubnetSpec{
+					Name:              "RouteServerSubnet",
+					ResourceGroup:     "my-rg",
+					SubscriptionID:    "123",
+					CIDRs:             []string{"10.0.255.224/27"},
+					VNetName:          "fake-vnet-1",
+					VNetResourceGroup: "my-rg-vnet",
+					IsVNetManaged:     false,
+					Role:              infrav1.SubnetRouteServer,
+				},
+			},
+		},
 
 		{
 			name: "returns specified subnet spec and bastion spec if enabled",
@@ -1601,6 +2136,9 @@ func TestAzureBastionSpec(t *testing.T) {
 						ResourceGroup: "my-rg",
 						AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
 							Location: "centralIndia",
+							AdditionalTags: infrav1.Tags{
+								"costcenter": "cluster",
+							},
 						},
 						NetworkSpec: infrav1.NetworkSpec{
 							Vnet: infrav1.VnetSpec{
@@ -1650,6 +2188,9 @@ func TestAzureBastionSpec(t *testing.T) {
 					"virtualNetworks/%s/subnets/%s", "123", "my-rg", "fake-vnet-1", "fake-bastion-subnet-1"),
 				PublicIPID: fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/"+
 					"publicIPAddresses/%s", "123", "my-rg", "fake-public-ip-1"),
+				AdditionalTags: infrav1.Tags{
+					"costcenter": "cluster",
+				},
 			},
 		},
 	}
@@ -2431,18 +2972,226 @@ func TestGenerateFQDN(t *testing.T) {
 				AzureClients: AzureClients{
 					Authorizer: autorest.NullAuthorizer{},
 				},
-				Cluster:      cluster,
-				AzureCluster: azureCluster,
-				Client:       fakeClient,
-			})
-			g.Expect(err).NotTo(HaveOccurred())
-			got := clusterScope.GenerateFQDN(tc.ipName)
-			g.Expect(got).Should(ContainSubstring(tc.clusterName))
-			g.Expect(got).Should(ContainSubstring(tc.location))
+				Cluster:      cluster,
+				AzureCluster: azureCluster,
+				Client:       fakeClient,
+			})
+			g.Expect(err).NotTo(HaveOccurred())
+			got := clusterScope.GenerateFQDN(tc.ipName)
+			g.Expect(got).Should(ContainSubstring(tc.clusterName))
+			g.Expect(got).Should(ContainSubstring(tc.location))
+		})
+	}
+}
+
+func TestTagsSpecs(t *testing.T) {
+	newClusterScope := func(vnetID string) ClusterScope {
+		return ClusterScope{
+			Cluster: &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "my-cluster",
+				},
+			},
+			AzureClients: AzureClients{
+				EnvironmentSettings: auth.EnvironmentSettings{
+					Values: map[string]string{
+						auth.SubscriptionID: "123",
+					},
+				},
+			},
+			AzureCluster: &infrav1.AzureCluster{
+				Spec: infrav1.AzureClusterSpec{
+					ResourceGroup: "my-rg",
+					AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+						SubscriptionID: "123",
+						AdditionalTags: infrav1.Tags{
+							"costcenter": "cluster",
+						},
+					},
+					NetworkSpec: infrav1.NetworkSpec{
+						Vnet: infrav1.VnetSpec{
+							ID:            vnetID,
+							Name:          "my-vnet",
+							ResourceGroup: "my-rg",
+						},
+						Subnets: infrav1.Subnets{
+							{
+								SubnetClassSpec: infrav1.SubnetClassSpec{
+									Role: infrav1.SubnetNode,
+									Name: "node-subnet",
+								},
+								SecurityGroup: infrav1.SecurityGroup{
+									Name: "node-nsg",
+								},
+								RouteTable: infrav1.RouteTable{
+									Name: "node-rt",
+								},
+								NatGateway: infrav1.NatGateway{
+									NatGatewayClassSpec: infrav1.NatGatewayClassSpec{
+										Name: "node-natgw",
+									},
+									NatGatewayIP: infrav1.PublicIPSpec{
+										Name: "pip-node-natgw",
+									},
+								},
+							},
+						},
+						APIServerLB: infrav1.LoadBalancerSpec{
+							Name: "my-lb",
+							FrontendIPs: []infrav1.FrontendIP{
+								{
+									Name: "my-lb-frontEnd",
+									PublicIP: &infrav1.PublicIPSpec{
+										Name: "pip-my-lb",
+									},
+								},
+							},
+							LoadBalancerClassSpec: infrav1.LoadBalancerClassSpec{
+								Type: infrav1.Public,
+							},
+						},
+					},
+				},
+			},
+			cache: &ClusterCache{},
+		}
+	}
+	clusterTags := infrav1.Tags{
+		"costcenter": "cluster",
+	}
+	rgSpec := azure.TagsSpec{
+		Scope:      "/subscriptions/123/resourceGroups/my-rg",
+		Tags:       clusterTags,
+		Annotation: azure.RGTagsLastAppliedAnnotation,
+	}
+	resourceSpec := func(scope string) azure.TagsSpec {
+		return azure.TagsSpec{
+			Scope:      scope,
+			Tags:       clusterTags,
+			Annotation: azure.ClusterResourcesTagsLastAppliedAnnotation,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		clusterScope ClusterScope
+		want         []azure.TagsSpec
+	}{
+		{
+			name:         "cluster tags cascade to the resource group and the managed network resources",
+			clusterScope: newClusterScope(""),
+			want: []azure.TagsSpec{
+				rgSpec,
+				resourceSpec("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet"),
+				resourceSpec("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkSecurityGroups/node-nsg"),
+				resourceSpec("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/routeTables/node-rt"),
+				resourceSpec("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/natGateways/node-natgw"),
+				resourceSpec("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/pip-my-lb"),
+				resourceSpec("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/pip-node-natgw"),
+				resourceSpec("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/loadBalancers/my-lb"),
+			},
+		},
+		{
+			name:         "resources of an unmanaged vnet are not tagged",
+			clusterScope: newClusterScope("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet"),
+			want: []azure.TagsSpec{
+				rgSpec,
+				resourceSpec("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/pip-my-lb"),
+				resourceSpec("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/pip-node-natgw"),
+				resourceSpec("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/loadBalancers/my-lb"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			g.Expect(tt.clusterScope.TagsSpecs()).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestIsDryRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        bool
+	}{
+		{
+			name: "no annotations",
+			want: false,
+		},
+		{
+			name:        "dry-run annotation set to true",
+			annotations: map[string]string{infrav1.DryRunAnnotation: "true"},
+			want:        true,
+		},
+		{
+			name:        "dry-run annotation set to false",
+			annotations: map[string]string{infrav1.DryRunAnnotation: "false"},
+			want:        false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			clusterScope := ClusterScope{
+				AzureCluster: &infrav1.AzureCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: tt.annotations,
+					},
+				},
+			}
+			g.Expect(clusterScope.IsDryRun()).To(Equal(tt.want))
 		})
 	}
 }
 
+func TestClusterScope_ResourceLocked(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := ClusterScope{AzureCluster: &infrav1.AzureCluster{}}
+	var _ azure.ResourceLockReporter = &clusterScope
+
+	_, locked := clusterScope.ResourceLockedSince("virtualnetworks", "my-vnet", "my-rg")
+	g.Expect(locked).To(BeFalse())
+
+	clusterScope.SetResourceLocked("virtualnetworks", "my-vnet", "my-rg")
+	vnetLockedSince, locked := clusterScope.ResourceLockedSince("virtualnetworks", "my-vnet", "my-rg")
+	g.Expect(locked).To(BeTrue())
+	g.Expect(vnetLockedSince).To(BeTemporally("~", time.Now(), time.Minute))
+	_, locked = clusterScope.ResourceLockedSince("subnets", "my-subnet", "my-rg")
+	g.Expect(locked).To(BeFalse())
+
+	// Another locked resource does not reset the backoff of the first one.
+	clusterScope.SetResourceLocked("subnets", "my-subnet", "my-rg")
+	since, locked := clusterScope.ResourceLockedSince("virtualnetworks", "my-vnet", "my-rg")
+	g.Expect(locked).To(BeTrue())
+	g.Expect(since).To(BeTemporally("==", vnetLockedSince))
+	_, locked = clusterScope.ResourceLockedSince("subnets", "my-subnet", "my-rg")
+	g.Expect(locked).To(BeTrue())
+	condition := conditions.Get(clusterScope.AzureCluster, infrav1.AzureResourcesUnlockedCondition)
+	g.Expect(condition).NotTo(BeNil())
+	g.Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+	g.Expect(condition.Reason).To(Equal(infrav1.ScopeLockedReason))
+	g.Expect(condition.Message).To(Equal("resources locked by a management lock: my-rg/my-subnet (service: subnets), my-rg/my-vnet (service: virtualnetworks)"))
+
+	// Changing one resource keeps the other one locked.
+	clusterScope.ClearResourceLocked("subnets", "my-subnet", "my-rg")
+	_, locked = clusterScope.ResourceLockedSince("subnets", "my-subnet", "my-rg")
+	g.Expect(locked).To(BeFalse())
+	_, locked = clusterScope.ResourceLockedSince("virtualnetworks", "my-vnet", "my-rg")
+	g.Expect(locked).To(BeTrue())
+	g.Expect(conditions.IsFalse(clusterScope.AzureCluster, infrav1.AzureResourcesUnlockedCondition)).To(BeTrue())
+
+	clusterScope.ClearResourceLocked("virtualnetworks", "my-vnet", "my-rg")
+	g.Expect(conditions.IsTrue(clusterScope.AzureCluster, infrav1.AzureResourcesUnlockedCondition)).To(BeTrue())
+	g.Expect(clusterScope.AzureCluster.GetAnnotations()).NotTo(HaveKey(azure.LockedResourcesAnnotation))
+}
+
 func TestAdditionalTags(t *testing.T) {
 	tests := []struct {
 		name                       string
@@ -2821,6 +3570,7 @@ func TestClusterScope_LBSpecs(t *testing.T) {
 					AdditionalTags: infrav1.Tags{
 						"foo": "bar",
 					},
+					FailureDomains: []string{},
 				},
 				&loadbalancers.LBSpec{
 					Name:              "node-outbound-lb",
@@ -2918,6 +3668,13 @@ func TestClusterScope_LBSpecs(t *testing.T) {
 						},
 					},
 				},
+				Status: infrav1.AzureClusterStatus{
+					FailureDomains: clusterv1.FailureDomains{
+						"1": clusterv1.FailureDomainSpec{ControlPlane: true},
+						"2": clusterv1.FailureDomainSpec{ControlPlane: true},
+						"3": clusterv1.FailureDomainSpec{ControlPlane: true},
+					},
+				},
 			},
 			want: []azure.ResourceSpecGetter{
 				&loadbalancers.LBSpec{
@@ -2936,6 +3693,7 @@ func TestClusterScope_LBSpecs(t *testing.T) {
 					BackendPoolName:      "api-server-lb-backend-pool",
 					IdleTimeoutInMinutes: ptr.To[int32](30),
 					AdditionalTags:       infrav1.Tags{},
+					FailureDomains:       []string{"1", "2", "3"},
 				},
 			},
 		},
@@ -3131,6 +3889,88 @@ func TestExtendedLocationType(t *testing.T) {
 	}
 }
 
+func TestClusterScope_NetworkSpecsInExtendedLocation(t *testing.T) {
+	g := NewWithT(t)
+	scheme := runtime.NewScheme()
+	_ = infrav1.AddToScheme(scheme)
+	_ = clusterv1.AddToScheme(scheme)
+
+	extendedLocation := &infrav1.ExtendedLocationSpec{Name: "microsoftlosangeles1", Type: "EdgeZone"}
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-cluster",
+			Namespace: "default",
+		},
+	}
+	azureCluster := &infrav1.AzureCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-cluster",
+			Namespace: "default",
+		},
+		Spec: infrav1.AzureClusterSpec{
+			ResourceGroup: "my-rg",
+			AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+				SubscriptionID:   "123",
+				Location:         "westus",
+				ExtendedLocation: extendedLocation,
+			},
+			NetworkSpec: infrav1.NetworkSpec{
+				NodeOutboundLB: &infrav1.LoadBalancerSpec{},
+				OutboundPublicIPPrefix: &infrav1.PublicIPPrefixSpec{
+					PrefixLength: 31,
+				},
+			},
+		},
+	}
+	azureCluster.Default()
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(cluster, azureCluster).Build()
+	clusterScope, err := NewClusterScope(context.TODO(), ClusterScopeParams{
+		AzureClients: AzureClients{
+			Authorizer: autorest.NullAuthorizer{},
+		},
+		Cluster:      cluster,
+		AzureCluster: azureCluster,
+		Client:       fakeClient,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	vnetSpec, ok := clusterScope.VNetSpec().(*virtualnetworks.VNetSpec)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(vnetSpec.ExtendedLocation).To(Equal(extendedLocation))
+
+	lbSpecs := clusterScope.LBSpecs()
+	g.Expect(lbSpecs).To(HaveLen(2))
+	for _, spec := range lbSpecs {
+		lbSpec, ok := spec.(*loadbalancers.LBSpec)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(lbSpec.ExtendedLocation).To(Equal(extendedLocation), "load balancer %s", lbSpec.Name)
+	}
+
+	publicIPNames := []string{
+		azureCluster.Spec.NetworkSpec.APIServerLB.FrontendIPs[0].PublicIP.Name,
+		azureCluster.Spec.NetworkSpec.NodeOutboundLB.FrontendIPs[0].PublicIP.Name,
+	}
+	var publicIPSpecs []*publicips.PublicIPSpec
+	for _, spec := range clusterScope.PublicIPSpecs() {
+		publicIPSpec, ok := spec.(*publicips.PublicIPSpec)
+		g.Expect(ok).To(BeTrue())
+		if slice.Contains(publicIPNames, publicIPSpec.Name) {
+			publicIPSpecs = append(publicIPSpecs, publicIPSpec)
+		}
+	}
+	g.Expect(publicIPSpecs).To(HaveLen(2))
+	for _, publicIPSpec := range publicIPSpecs {
+		g.Expect(publicIPSpec.ExtendedLocation).To(Equal(extendedLocation), "public IP %s", publicIPSpec.Name)
+	}
+
+	prefixSpecs := clusterScope.PublicIPPrefixSpecs()
+	g.Expect(prefixSpecs).To(HaveLen(1))
+	prefixSpec, ok := prefixSpecs[0].(*publicipprefixes.PublicIPPrefixSpec)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(prefixSpec.ExtendedLocation).To(Equal(extendedLocation))
+}
+
 func TestVNetPeerings(t *testing.T) {
 	fakeSubscriptionID := "123"
 
@@ -3180,6 +4020,7 @@ func TestVNetPeerings(t *testing.T) {
 					RemoteResourceGroup: "rg1",
 					RemoteVnetName:      "vnet1",
 					SubscriptionID:      fakeSubscriptionID,
+					Reverse:             true,
 				},
 			},
 		},
@@ -3230,6 +4071,7 @@ func TestVNetPeerings(t *testing.T) {
 					AllowForwardedTraffic: ptr.To(true),
 					AllowGatewayTransit:   ptr.To(true),
 					UseRemoteGateways:     ptr.To(false),
+					Reverse:               true,
 				},
 			},
 		},
@@ -3286,6 +4128,7 @@ func TestVNetPeerings(t *testing.T) {
 					AllowForwardedTraffic: ptr.To(true),
 					AllowGatewayTransit:   ptr.To(true),
 					UseRemoteGateways:     ptr.To(false),
+					Reverse:               true,
 				},
 				&vnetpeerings.VnetPeeringSpec{
 					PeeringName:         "vnet1-To-vnet3",
@@ -3302,6 +4145,70 @@ func TestVNetPeerings(t *testing.T) {
 					RemoteResourceGroup: "rg1",
 					RemoteVnetName:      "vnet1",
 					SubscriptionID:      fakeSubscriptionID,
+					Reverse:             true,
+				},
+			},
+		},
+		{
+			name:           "VNet peering with a remote VNet ID in the cluster subscription",
+			subscriptionID: fakeSubscriptionID,
+			azureClusterVNetSpec: infrav1.VnetSpec{
+				ResourceGroup: "rg1",
+				Name:          "vnet1",
+				Peerings: infrav1.VnetPeerings{
+					{
+						VnetPeeringClassSpec: infrav1.VnetPeeringClassSpec{
+							ResourceGroup:  "rg2",
+							RemoteVnetName: "vnet2",
+							RemoteVnetID:   "/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet2",
+						},
+					},
+				},
+			},
+			want: []azure.ResourceSpecGetter{
+				&vnetpeerings.VnetPeeringSpec{
+					PeeringName:         "vnet1-To-vnet2",
+					SourceResourceGroup: "rg1",
+					SourceVnetName:      "vnet1",
+					RemoteResourceGroup: "rg2",
+					RemoteVnetName:      "vnet2",
+					SubscriptionID:      fakeSubscriptionID,
+				},
+				&vnetpeerings.VnetPeeringSpec{
+					PeeringName:         "vnet2-To-vnet1",
+					SourceResourceGroup: "rg2",
+					SourceVnetName:      "vnet2",
+					RemoteResourceGroup: "rg1",
+					RemoteVnetName:      "vnet1",
+					SubscriptionID:      fakeSubscriptionID,
+					Reverse:             true,
+				},
+			},
+		},
+		{
+			name:           "VNet peering with a remote VNet ID in another subscription",
+			subscriptionID: fakeSubscriptionID,
+			azureClusterVNetSpec: infrav1.VnetSpec{
+				ResourceGroup: "rg1",
+				Name:          "vnet1",
+				Peerings: infrav1.VnetPeerings{
+					{
+						VnetPeeringClassSpec: infrav1.VnetPeeringClassSpec{
+							ResourceGroup:  "hub-rg",
+							RemoteVnetName: "hub-vnet",
+							RemoteVnetID:   "/subscriptions/456/resourceGroups/hub-rg/providers/Microsoft.Network/virtualNetworks/hub-vnet",
+						},
+					},
+				},
+			},
+			want: []azure.ResourceSpecGetter{
+				&vnetpeerings.VnetPeeringSpec{
+					PeeringName:         "vnet1-To-hub-vnet",
+					SourceResourceGroup: "rg1",
+					SourceVnetName:      "vnet1",
+					RemoteResourceGroup: "hub-rg",
+					RemoteVnetName:      "hub-vnet",
+					SubscriptionID:      "456",
 				},
 			},
 		},
@@ -3362,3 +4269,319 @@ func TestVNetPeerings(t *testing.T) {
 		})
 	}
 }
+
+func TestClusterScope_SetAPIServerLBHealth(t *testing.T) {
+	tests := []struct {
+		name            string
+		healthyBackends int32
+		totalBackends   int32
+		expectedStatus  corev1.ConditionStatus
+		expectedReason  string
+		expectedSev     clusterv1.ConditionSeverity
+		expectedMessage string
+	}{
+		{
+			name:            "all backends are healthy",
+			healthyBackends: 3,
+			totalBackends:   3,
+			expectedStatus:  corev1.ConditionTrue,
+			expectedMessage: "3 of 3 backends are healthy",
+		},
+		{
+			name:            "some backends are healthy",
+			healthyBackends: 1,
+			totalBackends:   3,
+			expectedStatus:  corev1.ConditionTrue,
+			expectedMessage: "1 of 3 backends are healthy",
+		},
+		{
+			name:            "no backends are healthy",
+			healthyBackends: 0,
+			totalBackends:   3,
+			expectedStatus:  corev1.ConditionFalse,
+			expectedReason:  infrav1.NoHealthyBackendsReason,
+			expectedSev:     clusterv1.ConditionSeverityWarning,
+			expectedMessage: "0 of 3 backends are healthy",
+		},
+		{
+			name:            "no backends",
+			healthyBackends: 0,
+			totalBackends:   0,
+			expectedStatus:  corev1.ConditionFalse,
+			expectedReason:  infrav1.NoHealthyBackendsReason,
+			expectedSev:     clusterv1.ConditionSeverityInfo,
+			expectedMessage: "the API server load balancer has no backends",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			clusterScope := &ClusterScope{AzureCluster: &infrav1.AzureCluster{}}
+			clusterScope.SetAPIServerLBHealth(tc.healthyBackends, tc.totalBackends)
+			condition := conditions.Get(clusterScope.AzureCluster, infrav1.APIServerLoadBalancerHealthyCondition)
+			g.Expect(condition).NotTo(BeNil())
+			g.Expect(condition.Status).To(Equal(tc.expectedStatus))
+			g.Expect(condition.Reason).To(Equal(tc.expectedReason))
+			g.Expect(condition.Severity).To(Equal(tc.expectedSev))
+			g.Expect(condition.Message).To(Equal(tc.expectedMessage))
+		})
+	}
+}
+
+func TestClusterScope_PublicIPPrefixSpecs(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   *infrav1.PublicIPPrefixSpec
+		expected []azure.ResourceSpecGetter
+	}{
+		{
+			name:     "no public IP prefix",
+			prefix:   nil,
+			expected: nil,
+		},
+		{
+			name:   "outbound public IP prefix",
+			prefix: &infrav1.PublicIPPrefixSpec{Name: "my-prefix", PrefixLength: 29},
+			expected: []azure.ResourceSpecGetter{
+				&publicipprefixes.PublicIPPrefixSpec{
+					Name:           "my-prefix",
+					ResourceGroup:  "my-rg",
+					ClusterName:    "my-cluster",
+					Location:       "centralIndia",
+					PrefixLength:   29,
+					FailureDomains: []string{"1"},
+					AdditionalTags: infrav1.Tags{"foo": "bar"},
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			clusterScope := &ClusterScope{
+				Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}},
+				AzureCluster: &infrav1.AzureCluster{
+					Spec: infrav1.AzureClusterSpec{
+						ResourceGroup: "my-rg",
+						AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+							Location:       "centralIndia",
+							AdditionalTags: infrav1.Tags{"foo": "bar"},
+						},
+						NetworkSpec: infrav1.NetworkSpec{
+							OutboundPublicIPPrefix: tc.prefix,
+						},
+					},
+					Status: infrav1.AzureClusterStatus{
+						FailureDomains: clusterv1.FailureDomains{"1": {}},
+					},
+				},
+			}
+			g.Expect(clusterScope.PublicIPPrefixSpecs()).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestClusterScope_ValidateEgress(t *testing.T) {
+	nodeSubnet := func(natGatewayName string, routes ...infrav1.Route) infrav1.SubnetSpec {
+		return infrav1.SubnetSpec{
+			SubnetClassSpec: infrav1.SubnetClassSpec{Role: infrav1.SubnetNode, Name: "node-subnet"},
+			NatGateway:      infrav1.NatGateway{NatGatewayClassSpec: infrav1.NatGatewayClassSpec{Name: natGatewayName}},
+			RouteTable:      infrav1.RouteTable{Name: "node-routetable", Routes: routes},
+		}
+	}
+	egressValidation := &infrav1.EgressValidationSpec{RequiredDestinations: []string{"MicrosoftContainerRegistry", "AzureActiveDirectory"}}
+
+	tests := []struct {
+		name             string
+		subnet           infrav1.SubnetSpec
+		nodeOutboundLB   *infrav1.LoadBalancerSpec
+		outboundDeny     *infrav1.OutboundDenySpec
+		egressValidation *infrav1.EgressValidationSpec
+		expectedError    string
+	}{
+		{
+			name:             "egress validation disabled",
+			subnet:           nodeSubnet(""),
+			egressValidation: nil,
+		},
+		{
+			name:             "node subnet with a NAT gateway",
+			subnet:           nodeSubnet("node-natgw"),
+			egressValidation: egressValidation,
+		},
+		{
+			name:             "node subnet using the node outbound load balancer",
+			subnet:           nodeSubnet(""),
+			nodeOutboundLB:   &infrav1.LoadBalancerSpec{Name: "outbound-lb"},
+			egressValidation: egressValidation,
+		},
+		{
+			name: "node subnet with a default route to a firewall",
+			subnet: nodeSubnet("", infrav1.Route{
+				Name:             "default",
+				AddressPrefix:    "0.0.0.0/0",
+				NextHopType:      infrav1.RouteNextHopTypeVirtualAppliance,
+				NextHopIPAddress: "10.100.0.4",
+			}),
+			egressValidation: egressValidation,
+		},
+		{
+			name: "node subnet with a route to a firewall for some destinations only",
+			subnet: nodeSubnet("", infrav1.Route{
+				Name:             "onprem",
+				AddressPrefix:    "192.168.0.0/16",
+				NextHopType:      infrav1.RouteNextHopTypeVirtualAppliance,
+				NextHopIPAddress: "10.100.0.4",
+			}),
+			egressValidation: egressValidation,
+			expectedError:    "node subnet node-subnet has no NAT gateway, node outbound load balancer or default route",
+		},
+		{
+			name:             "node subnet without egress path",
+			subnet:           nodeSubnet(""),
+			egressValidation: egressValidation,
+			expectedError:    "node subnet node-subnet has no NAT gateway, node outbound load balancer or default route",
+		},
+		{
+			name:             "outbound deny allowing the required destinations",
+			subnet:           nodeSubnet("node-natgw"),
+			outboundDeny:     &infrav1.OutboundDenySpec{AllowedDestinations: []string{"microsoftcontainerregistry", "AzureActiveDirectory", "10.100.0.0/16"}},
+			egressValidation: egressValidation,
+		},
+		{
+			name:             "outbound deny missing a required destination",
+			subnet:           nodeSubnet("node-natgw"),
+			outboundDeny:     &infrav1.OutboundDenySpec{AllowedDestinations: []string{"MicrosoftContainerRegistry"}},
+			egressValidation: egressValidation,
+			expectedError:    "required destination AzureActiveDirectory is not an allowed destination of outbound deny",
+		},
+		{
+			name:             "outbound deny without allowed destinations disables the outbound rule of the node outbound load balancer",
+			subnet:           nodeSubnet(""),
+			nodeOutboundLB:   &infrav1.LoadBalancerSpec{Name: "outbound-lb"},
+			outboundDeny:     &infrav1.OutboundDenySpec{},
+			egressValidation: egressValidation,
+			expectedError:    "node subnet node-subnet has no NAT gateway, node outbound load balancer or default route",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			clusterScope := &ClusterScope{
+				Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}},
+				AzureCluster: &infrav1.AzureCluster{
+					Spec: infrav1.AzureClusterSpec{
+						NetworkSpec: infrav1.NetworkSpec{
+							APIServerLB: infrav1.LoadBalancerSpec{
+								LoadBalancerClassSpec: infrav1.LoadBalancerClassSpec{Type: infrav1.Internal},
+							},
+							NodeOutboundLB:   tc.nodeOutboundLB,
+							Subnets:          infrav1.Subnets{tc.subnet},
+							OutboundDeny:     tc.outboundDeny,
+							EgressValidation: tc.egressValidation,
+						},
+					},
+				},
+			}
+			err := clusterScope.ValidateEgress()
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestClusterScope_TrafficManagerProfileSpecs(t *testing.T) {
+	endpoints := []infrav1.TrafficManagerEndpoint{
+		{Name: "westeurope", Target: "my-cluster-westeurope.westeurope.cloudapp.azure.com"},
+		{Name: "eastus", Target: "my-cluster-eastus.eastus.cloudapp.azure.com"},
+	}
+	tests := []struct {
+		name           string
+		trafficManager *infrav1.TrafficManagerSpec
+		expected       []azure.ResourceSpecGetter
+		expectedHost   string
+	}{
+		{
+			name:           "no Traffic Manager profile",
+			trafficManager: nil,
+			expected:       nil,
+			expectedHost:   "my-cluster-apiserver.westeurope.cloudapp.azure.com",
+		},
+		{
+			name: "Traffic Manager profile fronting the API server",
+			trafficManager: &infrav1.TrafficManagerSpec{
+				Name:            "traf-my-cluster-apiserver",
+				RelativeDNSName: "My-Cluster",
+				RoutingMethod:   infrav1.TrafficManagerRoutingMethodPriority,
+				TTL:             30,
+				MonitorPath:     "/readyz",
+				Endpoints:       endpoints,
+			},
+			expected: []azure.ResourceSpecGetter{
+				&trafficmanagerprofiles.TrafficManagerProfileSpec{
+					Name:            "traf-my-cluster-apiserver",
+					ResourceGroup:   "my-rg",
+					ClusterName:     "my-cluster",
+					RelativeDNSName: "My-Cluster",
+					RoutingMethod:   infrav1.TrafficManagerRoutingMethodPriority,
+					TTL:             30,
+					MonitorPort:     443,
+					MonitorPath:     "/readyz",
+					Endpoints:       endpoints,
+					AdditionalTags:  infrav1.Tags{"foo": "bar"},
+				},
+			},
+			expectedHost: "my-cluster.trafficmanager.net",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			clusterScope := &ClusterScope{
+				AzureClients: AzureClients{
+					EnvironmentSettings: auth.EnvironmentSettings{Environment: azureautorest.PublicCloud},
+				},
+				Cluster: &clusterv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"},
+					Spec: clusterv1.ClusterSpec{
+						ClusterNetwork: &clusterv1.ClusterNetwork{APIServerPort: ptr.To[int32](443)},
+					},
+				},
+				AzureCluster: &infrav1.AzureCluster{
+					Spec: infrav1.AzureClusterSpec{
+						ResourceGroup: "my-rg",
+						AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+							Location:       "westeurope",
+							AdditionalTags: infrav1.Tags{"foo": "bar"},
+						},
+						NetworkSpec: infrav1.NetworkSpec{
+							APIServerLB: infrav1.LoadBalancerSpec{
+								FrontendIPs: []infrav1.FrontendIP{
+									{
+										PublicIP: &infrav1.PublicIPSpec{
+											DNSName: "my-cluster-apiserver.westeurope.cloudapp.azure.com",
+										},
+									},
+								},
+								LoadBalancerClassSpec: infrav1.LoadBalancerClassSpec{
+									Type: infrav1.Public,
+								},
+							},
+							TrafficManager: tc.trafficManager,
+						},
+					},
+				},
+			}
+			g.Expect(clusterScope.TrafficManagerProfileSpecs()).To(Equal(tc.expected))
+			g.Expect(clusterScope.APIServerHost()).To(Equal(tc.expectedHost))
+		})
+	}
+}
@@ -21,21 +21,27 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/availabilitysets"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/datacollectionruleassociations"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/disks"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/inboundnatrules"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/marketplaceagreements"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/networkinterfaces"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicips"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/roleassignments"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/runcommands"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualmachineimages"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualmachines"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/vmextensions"
@@ -57,6 +63,11 @@ type MachineScopeParams struct {
 	Machine      *clusterv1.Machine
 	AzureMachine *infrav1.AzureMachine
 	Cache        *MachineCache
+	Recorder     record.EventRecorder
+	// CompressBootstrapData enables the gzip compression of the bootstrap data of Linux VMs.
+	CompressBootstrapData bool
+	// ReplicateGalleryImages enables the replication of gallery image versions to the region of the VMs.
+	ReplicateGalleryImages bool
 }
 
 // NewMachineScope creates a new MachineScope from the supplied parameters.
@@ -84,6 +95,10 @@ func NewMachineScope(params MachineScopeParams) (*MachineScope, error) {
 		patchHelper:   helper,
 		ClusterScoper: params.ClusterScope,
 		cache:         params.Cache,
+		recorder:      params.Recorder,
+
+		compressBootstrapData:  params.CompressBootstrapData,
+		replicateGalleryImages: params.ReplicateGalleryImages,
 	}, nil
 }
 
@@ -96,6 +111,15 @@ type MachineScope struct {
 	Machine      *clusterv1.Machine
 	AzureMachine *infrav1.AzureMachine
 	cache        *MachineCache
+	recorder     record.EventRecorder
+
+	compressBootstrapData  bool
+	replicateGalleryImages bool
+
+	// nodeLabels holds the labels of the node of the machine read from the tags of its VM.
+	nodeLabels map[string]string
+	// vmPrincipalID holds the principal ID of the system-assigned identity of the VM read by the VM service.
+	vmPrincipalID string
 }
 
 // MachineCache stores common machine information so we don't have to hit the API multiple times within the same reconcile loop.
@@ -151,10 +175,12 @@ func (m *MachineScope) VMSpec() azure.ResourceSpecGetter {
 		Location:               m.Location(),
 		ExtendedLocation:       m.ExtendedLocation(),
 		ResourceGroup:          m.ResourceGroup(),
+		SubscriptionID:         m.SubscriptionID(),
 		ClusterName:            m.ClusterName(),
 		Role:                   m.Role(),
 		NICIDs:                 m.NICIDs(),
 		SSHKeyData:             m.AzureMachine.Spec.SSHPublicKey,
+		SSHKeySecretRef:        m.AzureMachine.Spec.SSHPublicKeySecretRef,
 		Size:                   m.AzureMachine.Spec.VMSize,
 		OSDisk:                 m.AzureMachine.Spec.OSDisk,
 		DataDisks:              m.AzureMachine.Spec.DataDisks,
@@ -168,24 +194,96 @@ func (m *MachineScope) VMSpec() azure.ResourceSpecGetter {
 		AdditionalTags:         m.AdditionalTags(),
 		AdditionalCapabilities: m.AzureMachine.Spec.AdditionalCapabilities,
 		ProviderID:             m.ProviderID(),
+		OSDiskResizeState:      m.AzureMachine.GetAnnotations()[azure.OSDiskResizeAnnotation],
+		NodeLabelTags:          m.AzureMachine.Spec.NodeLabelTags,
+	}
+	if ref := m.AzureMachine.Spec.VirtualMachineScaleSet; ref != nil {
+		spec.ScaleSetID = ref.ID
+		spec.PlatformFaultDomain = ref.PlatformFaultDomain
 	}
 	if m.cache != nil {
 		spec.SKU = m.cache.VMSKU
 		spec.Image = m.cache.VMImage
 		spec.BootstrapData = m.cache.BootstrapData
 	}
+	// cloud-init supports gzip compressed user data.
+	spec.CompressBootstrapData = m.compressBootstrapData && m.AzureMachine.Spec.OSDisk.OSType != azure.WindowsOS
+	if storage := m.AzureMachine.Spec.LocalNVMeStorage; storage != nil {
+		spec.LocalNVMeStorage = storage.DeepCopy()
+		if spec.LocalNVMeStorage.MountPath == "" {
+			spec.LocalNVMeStorage.MountPath = infrav1.DefaultLocalNVMeStorageMountPath
+		}
+	}
+	spec.DataDiskArray = m.AzureMachine.Spec.DataDiskArray
+	spec.ReplicateGalleryImage = m.replicateGalleryImages
 	return spec
 }
 
-// TagsSpecs returns the tags for the AzureMachine.
+// TagsSpecs returns the tags for the AzureMachine and the child resources it owns (network interfaces, disks and public IP),
+// so that cost allocation tags are applied consistently across all of them.
 func (m *MachineScope) TagsSpecs() []azure.TagsSpec {
-	return []azure.TagsSpec{
+	machineTags := m.AdditionalTags()
+	specs := []azure.TagsSpec{
 		{
 			Scope:      azure.VMID(m.SubscriptionID(), m.ResourceGroup(), m.Name()),
-			Tags:       m.AdditionalTags(),
+			Tags:       m.vmTags(machineTags),
 			Annotation: azure.VMTagsLastAppliedAnnotation,
 		},
 	}
+
+	for _, nicID := range m.NICIDs() {
+		specs = append(specs, azure.TagsSpec{
+			Scope:      nicID,
+			Tags:       machineTags,
+			Annotation: azure.NICTagsLastAppliedAnnotation,
+		})
+	}
+
+	// Ephemeral OS disks are not managed disk resources and cannot be tagged.
+	if m.AzureMachine.Spec.OSDisk.DiffDiskSettings == nil {
+		specs = append(specs, azure.TagsSpec{
+			Scope:      azure.DiskID(m.SubscriptionID(), m.ResourceGroup(), azure.GenerateOSDiskName(m.Name())),
+			Tags:       machineTags,
+			Annotation: azure.DiskTagsLastAppliedAnnotation,
+		})
+	}
+	for _, dd := range m.AzureMachine.Spec.DataDisks {
+		// Shared data disks belong to the cluster and existing data disks aren't managed by CAPZ.
+		if dd.IsShared() || dd.AttachExistingDisk != nil {
+			continue
+		}
+		specs = append(specs, azure.TagsSpec{
+			Scope:      azure.DiskID(m.SubscriptionID(), m.ResourceGroup(), azure.GenerateDataDiskName(m.Name(), dd.NameSuffix)),
+			Tags:       machineTags,
+			Annotation: azure.DiskTagsLastAppliedAnnotation,
+		})
+	}
+	if m.AzureMachine.Spec.AllocatePublicIP {
+		specs = append(specs, azure.TagsSpec{
+			Scope:      azure.PublicIPID(m.SubscriptionID(), m.ResourceGroup(), azure.GenerateNodePublicIPName(m.Name())),
+			Tags:       machineTags,
+			Annotation: azure.PublicIPTagsLastAppliedAnnotation,
+		})
+	}
+
+	return specs
+}
+
+// vmTags returns the tags of the VM, i.e. the additional tags of the machine and the tag holding the schedule an Azure
+// Automation runbook stops and starts the VM on.
+func (m *MachineScope) vmTags(machineTags infrav1.Tags) infrav1.Tags {
+	schedule := m.AzureMachine.Spec.StartStopSchedule
+	if schedule == nil {
+		return machineTags
+	}
+	tagName := schedule.TagName
+	if tagName == "" {
+		tagName = infrav1.DefaultStartStopScheduleTagName
+	}
+	vmTags := infrav1.Tags{}
+	vmTags.Merge(machineTags)
+	vmTags[tagName] = schedule.Schedule
+	return vmTags
 }
 
 // PublicIPSpecs returns the public IP specs.
@@ -200,13 +298,22 @@ func (m *MachineScope) PublicIPSpecs() []azure.ResourceSpecGetter {
 			IsIPv6:           false, // Set to default value
 			Location:         m.Location(),
 			ExtendedLocation: m.ExtendedLocation(),
-			FailureDomains:   m.FailureDomains(),
-			AdditionalTags:   m.ClusterScoper.AdditionalTags(),
+			FailureDomains:   m.publicIPFailureDomains(),
+			AdditionalTags:   m.AdditionalTags(),
 		})
 	}
 	return specs
 }
 
+// publicIPFailureDomains returns the failure domains of the cluster the public IP of the machine is zone-redundant
+// across, or none if the machine is in its own edge zone, as edge zones don't have availability zones.
+func (m *MachineScope) publicIPFailureDomains() []string {
+	if m.hasOwnExtendedLocation() {
+		return nil
+	}
+	return m.FailureDomains()
+}
+
 // InboundNatSpecs returns the inbound NAT specs.
 func (m *MachineScope) InboundNatSpecs() []azure.ResourceSpecGetter {
 	// The existing inbound NAT rules are needed in order to find an available SSH port for each new inbound NAT rule.
@@ -222,12 +329,44 @@ func (m *MachineScope) InboundNatSpecs() []azure.ResourceSpecGetter {
 			id := azure.FrontendIPConfigID(m.SubscriptionID(), m.ResourceGroup(), m.APIServerLBName(), ipConfig)
 			spec.FrontendIPConfigurationID = ptr.To(id)
 		}
+		if natRules := m.APIServerLB().InboundNATRules; natRules != nil {
+			spec.IdleTimeoutInMinutes = natRules.IdleTimeoutInMinutes
+			spec.EnableTCPReset = natRules.EnableTCPReset
+			spec.EnableFloatingIP = natRules.EnableFloatingIP
+		}
 
 		return []azure.ResourceSpecGetter{spec}
 	}
 	return []azure.ResourceSpecGetter{}
 }
 
+// DataCollectionRuleAssociationSpec returns the spec of the association of the VM with the data collection rule of the
+// cluster. Once the data collection rule is removed from the cluster, the spec has no data collection rule until the
+// association CAPZ created is deleted.
+func (m *MachineScope) DataCollectionRuleAssociationSpec() azure.ResourceSpecGetter {
+	var dataCollectionRuleID string
+	if describer, ok := m.ClusterScoper.(dataCollectionRuleDescriber); ok {
+		dataCollectionRuleID = describer.DataCollectionRuleID()
+	}
+	if dataCollectionRuleID == "" && !conditions.Has(m.AzureMachine, infrav1.DataCollectionRuleAssociationReadyCondition) {
+		return nil
+	}
+
+	return &datacollectionruleassociations.DataCollectionRuleAssociationSpec{
+		Name:                 azure.GenerateDataCollectionRuleAssociationName(m.ClusterName()),
+		ResourceGroup:        m.ResourceGroup(),
+		ResourceURI:          azure.VMID(m.SubscriptionID(), m.ResourceGroup(), m.Name()),
+		DataCollectionRuleID: dataCollectionRuleID,
+		ClusterName:          m.ClusterName(),
+	}
+}
+
+// dataCollectionRuleDescriber is implemented by the scopes of clusters whose VMs can be associated with a data
+// collection rule.
+type dataCollectionRuleDescriber interface {
+	DataCollectionRuleID() string
+}
+
 // NICSpecs returns the network interface specs.
 func (m *MachineScope) NICSpecs() []azure.ResourceSpecGetter {
 	nicSpecs := []azure.ResourceSpecGetter{}
@@ -256,13 +395,19 @@ func (m *MachineScope) BuildNICSpec(nicName string, infrav1NetworkInterface infr
 		VNetName:              m.Vnet().Name,
 		VNetResourceGroup:     m.Vnet().ResourceGroup,
 		AcceleratedNetworking: infrav1NetworkInterface.AcceleratedNetworking,
-		IPv6Enabled:           m.IsIPv6Enabled(),
+		IPv6Enabled:           m.IsIPv6Enabled() || m.isDualStackSubnet(infrav1NetworkInterface.SubnetName),
 		EnableIPForwarding:    m.AzureMachine.Spec.EnableIPForwarding,
 		SubnetName:            infrav1NetworkInterface.SubnetName,
 		AdditionalTags:        m.AdditionalTags(),
 		ClusterName:           m.ClusterName(),
 		IPConfigs:             []networkinterfaces.IPConfig{},
+		SecondaryIPConfigs:    infrav1NetworkInterface.SecondaryIPConfigs,
+	}
+
+	if infrav1NetworkInterface.VirtualNetworkTap != nil {
+		spec.VirtualNetworkTapID = infrav1NetworkInterface.VirtualNetworkTap.ID
 	}
+	spec.LastAppliedVirtualNetworkTapID = m.getLastAppliedVirtualNetworkTapID(nicName)
 
 	if m.cache != nil {
 		spec.SKU = &m.cache.VMSKU
@@ -274,6 +419,7 @@ func (m *MachineScope) BuildNICSpec(nicName string, infrav1NetworkInterface infr
 
 	if primaryNetworkInterface {
 		spec.DNSServers = m.AzureMachine.Spec.DNSServers
+		spec.PodSubnetName = m.PodSubnetName()
 
 		if m.Role() == infrav1.ControlPlane {
 			spec.PublicLBName = m.OutboundLBName(m.Role())
@@ -294,6 +440,7 @@ func (m *MachineScope) BuildNICSpec(nicName string, infrav1NetworkInterface infr
 		if m.Role() == infrav1.Node && !m.Subnet().IsNatGatewayEnabled() && !m.AzureMachine.Spec.AllocatePublicIP {
 			spec.PublicLBName = m.OutboundLBName(m.Role())
 			spec.PublicLBAddressPoolName = m.OutboundPoolName(m.Role())
+			spec.AdditionalPublicLBAddressPoolNames = m.OutboundAdditionalPoolNames(m.Role())
 		}
 	}
 
@@ -313,21 +460,119 @@ func (m *MachineScope) NICIDs() []string {
 
 // DiskSpecs returns the disk specs.
 func (m *MachineScope) DiskSpecs() []azure.ResourceSpecGetter {
-	diskSpecs := make([]azure.ResourceSpecGetter, 1+len(m.AzureMachine.Spec.DataDisks))
+	diskSpecs := make([]azure.ResourceSpecGetter, 1, 1+len(m.AzureMachine.Spec.DataDisks))
 	diskSpecs[0] = &disks.DiskSpec{
 		Name:          azure.GenerateOSDiskName(m.Name()),
 		ResourceGroup: m.ResourceGroup(),
 	}
 
-	for i, dd := range m.AzureMachine.Spec.DataDisks {
-		diskSpecs[i+1] = &disks.DiskSpec{
-			Name:          azure.GenerateDataDiskName(m.Name(), dd.NameSuffix),
-			ResourceGroup: m.ResourceGroup(),
+	for _, dd := range m.AzureMachine.Spec.DataDisks {
+		switch {
+		case dd.AttachExistingDisk != nil:
+			// Existing data disks are not managed by CAPZ, so they are neither updated nor deleted.
+			continue
+		case dd.IsShared():
+			diskSpecs = append(diskSpecs, m.sharedDataDiskSpec(dd))
+		case dd.HasLogicalSectorSize():
+			diskSpecs = append(diskSpecs, m.logicalSectorSizeDataDiskSpec(dd))
+		default:
+			diskSpecs = append(diskSpecs, &disks.DiskSpec{
+				Name:              azure.GenerateDataDiskName(m.Name(), dd.NameSuffix),
+				ResourceGroup:     m.ResourceGroup(),
+				DiskIOPSReadWrite: dd.DiskIOPSReadWrite,
+				DiskMBpsReadWrite: dd.DiskMBpsReadWrite,
+				Tier:              dataDiskTier(dd, time.Now()),
+				BurstingEnabled:   dd.BurstingEnabled,
+			})
 		}
 	}
 	return diskSpecs
 }
 
+// sharedDataDiskSpec returns the spec of a data disk shared by the machines of the cluster. The disk is zonal, in
+// the zone of the machine, unless its storage account type is zone-redundant.
+func (m *MachineScope) sharedDataDiskSpec(dd infrav1.DataDisk) *disks.DiskSpec {
+	spec := &disks.DiskSpec{
+		Name:              azure.GenerateSharedDataDiskName(m.ClusterName(), dd.NameSuffix),
+		ResourceGroup:     m.ResourceGroup(),
+		Location:          m.Location(),
+		ExtendedLocation:  m.ExtendedLocation(),
+		ClusterName:       m.ClusterName(),
+		DiskSizeGB:        dd.DiskSizeGB,
+		MaxShares:         dd.MaxShares,
+		DiskIOPSReadWrite: dd.DiskIOPSReadWrite,
+		DiskMBpsReadWrite: dd.DiskMBpsReadWrite,
+		Tier:              dataDiskTier(dd, time.Now()),
+		BurstingEnabled:   dd.BurstingEnabled,
+		AdditionalTags:    m.ClusterScoper.AdditionalTags(),
+	}
+	if dd.ManagedDisk != nil {
+		spec.StorageAccountType = dd.ManagedDisk.StorageAccountType
+		if dd.ManagedDisk.DiskEncryptionSet != nil {
+			spec.DiskEncryptionSetID = dd.ManagedDisk.DiskEncryptionSet.ID
+		}
+	}
+	if !strings.HasSuffix(spec.StorageAccountType, "_ZRS") {
+		spec.Zone = m.AvailabilityZone()
+	}
+	return spec
+}
+
+// logicalSectorSizeDataDiskSpec returns the spec of a data disk with a logical sector size, which is created in the
+// zone of the machine before the VM as the logical sector size can't be set on the data disks created with the VM.
+func (m *MachineScope) logicalSectorSizeDataDiskSpec(dd infrav1.DataDisk) *disks.DiskSpec {
+	spec := &disks.DiskSpec{
+		Name:               azure.GenerateDataDiskName(m.Name(), dd.NameSuffix),
+		ResourceGroup:      m.ResourceGroup(),
+		Location:           m.Location(),
+		ExtendedLocation:   m.ExtendedLocation(),
+		Zone:               m.AvailabilityZone(),
+		ClusterName:        m.ClusterName(),
+		DiskSizeGB:         dd.DiskSizeGB,
+		StorageAccountType: dd.ManagedDisk.StorageAccountType,
+		LogicalSectorSize:  dd.ManagedDisk.LogicalSectorSize,
+		AdditionalTags:     m.AdditionalTags(),
+	}
+	if dd.ManagedDisk.DiskEncryptionSet != nil {
+		spec.DiskEncryptionSetID = dd.ManagedDisk.DiskEncryptionSet.ID
+	}
+	return spec
+}
+
+// dataDiskTier returns the performance tier a data disk should have at the given time, or an empty string if the data
+// disk has no performance tier schedule.
+func dataDiskTier(dd infrav1.DataDisk, t time.Time) string {
+	if dd.PerformanceTierSchedule == nil {
+		return ""
+	}
+	return dd.PerformanceTierSchedule.TierAt(t)
+}
+
+// DataDiskTierRequeueAfter returns how long to wait before reconciling the machine again so that the next change of
+// performance tier of its data disks is applied on time, or zero if none of its data disks has a schedule.
+func (m *MachineScope) DataDiskTierRequeueAfter() time.Duration {
+	return dataDiskTierRequeueAfter(m.AzureMachine.Spec.DataDisks, time.Now())
+}
+
+// dataDiskTierRequeueAfter returns the time from the given time to the next change of performance tier of the given
+// data disks, or zero if none of them has a schedule.
+func dataDiskTierRequeueAfter(dataDisks []infrav1.DataDisk, t time.Time) time.Duration {
+	var requeueAfter time.Duration
+	for _, dd := range dataDisks {
+		if dd.AttachExistingDisk != nil || dd.PerformanceTierSchedule == nil {
+			continue
+		}
+		next := dd.PerformanceTierSchedule.NextChangeAfter(t)
+		if next.IsZero() {
+			continue
+		}
+		if after := next.Sub(t); requeueAfter == 0 || after < requeueAfter {
+			requeueAfter = after
+		}
+	}
+	return requeueAfter
+}
+
 // RoleAssignmentSpecs returns the role assignment specs.
 func (m *MachineScope) RoleAssignmentSpecs(principalID *string) []azure.ResourceSpecGetter {
 	roles := make([]azure.ResourceSpecGetter, 1)
@@ -341,6 +586,19 @@ func (m *MachineScope) RoleAssignmentSpecs(principalID *string) []azure.Resource
 			RoleDefinitionID: m.SystemAssignedIdentityDefinitionID(),
 			PrincipalID:      principalID,
 		}
+		if role := m.AzureMachine.Spec.SystemAssignedIdentityRole; role != nil {
+			for _, additionalRole := range role.AdditionalRoles {
+				roles = append(roles, &roleassignments.RoleAssignmentSpec{
+					Name:             roleassignments.AdditionalRoleAssignmentName(role.Name, additionalRole.Scope, additionalRole.DefinitionID),
+					MachineName:      m.Name(),
+					ResourceGroup:    m.ResourceGroup(),
+					ResourceType:     azure.VirtualMachine,
+					Scope:            additionalRole.Scope,
+					RoleDefinitionID: additionalRole.DefinitionID,
+					PrincipalID:      principalID,
+				})
+			}
+		}
 		return roles
 	}
 	return []azure.ResourceSpecGetter{}
@@ -376,7 +634,7 @@ func (m *MachineScope) VMExtensionSpecs() []azure.ResourceSpecGetter {
 	}
 
 	cpuArchitectureType, _ := m.cache.VMSKU.GetCapability(resourceskus.CPUArchitectureType)
-	bootstrapExtensionSpec := azure.GetBootstrappingVMExtension(m.AzureMachine.Spec.OSDisk.OSType, m.CloudEnvironment(), m.Name(), cpuArchitectureType)
+	bootstrapExtensionSpec := azure.GetBootstrappingVMExtension(m.AzureMachine.Spec.OSDisk.OSType, m.CloudEnvironment(), m.Name(), cpuArchitectureType, m.AzureMachine.Spec.BootstrapCompletionCheck)
 
 	if bootstrapExtensionSpec != nil {
 		extensionSpecs = append(extensionSpecs, &vmextensions.VMExtensionSpec{
@@ -389,6 +647,33 @@ func (m *MachineScope) VMExtensionSpecs() []azure.ResourceSpecGetter {
 	return extensionSpecs
 }
 
+// RunCommandSpecs returns the VM run command specs.
+func (m *MachineScope) RunCommandSpecs() []azure.ResourceSpecGetter {
+	var runCommandSpecs = []azure.ResourceSpecGetter{}
+	for _, runCommand := range m.AzureMachine.Spec.RunCommands {
+		runCommandSpecs = append(runCommandSpecs, &runcommands.RunCommandSpec{
+			Name:             runCommand.Name,
+			VMName:           m.Name(),
+			ResourceGroup:    m.ResourceGroup(),
+			Location:         m.Location(),
+			Script:           runCommand.Source.Script,
+			ScriptURI:        runCommand.Source.ScriptURI,
+			ScriptParameters: runCommand.Parameters,
+			TimeoutInSeconds: runCommand.TimeoutInSeconds,
+		})
+	}
+	return runCommandSpecs
+}
+
+// MarketplaceAgreementSpec returns the marketplace agreement spec of the purchase plan of the image of the VM, or nil if
+// the VM already exists or its image has no plan.
+func (m *MachineScope) MarketplaceAgreementSpec() *azure.MarketplaceAgreementSpec {
+	if m.ProviderID() != "" || m.cache == nil {
+		return nil
+	}
+	return marketplaceagreements.NewAgreementSpec(m.cache.VMImage, m.AzureMachine.Spec.AcceptImagePlanTerms)
+}
+
 // Subnet returns the machine's subnet.
 func (m *MachineScope) Subnet() infrav1.SubnetSpec {
 	for _, subnet := range m.Subnets() {
@@ -400,12 +685,26 @@ func (m *MachineScope) Subnet() infrav1.SubnetSpec {
 	return infrav1.SubnetSpec{}
 }
 
+// isDualStackSubnet returns true if the named cluster subnet has both an IPv4 and an IPv6 CIDR block.
+func (m *MachineScope) isDualStackSubnet(name string) bool {
+	for _, subnet := range m.Subnets() {
+		if subnet.Name == name {
+			return subnet.IsDualStack()
+		}
+	}
+	return false
+}
+
 // AvailabilityZone returns the AzureMachine Availability Zone.
 // Priority for selecting the AZ is
-//  1. Machine.Spec.FailureDomain
-//  2. AzureMachine.Spec.FailureDomain (This is to support deprecated AZ)
-//  3. No AZ
+//  1. No AZ if the AzureMachine has its own edge zone, as edge zones don't have availability zones
+//  2. Machine.Spec.FailureDomain
+//  3. AzureMachine.Spec.FailureDomain (This is to support deprecated AZ)
+//  4. No AZ
 func (m *MachineScope) AvailabilityZone() string {
+	if m.hasOwnExtendedLocation() {
+		return ""
+	}
 	if m.Machine.Spec.FailureDomain != nil {
 		return *m.Machine.Spec.FailureDomain
 	}
@@ -463,18 +762,39 @@ func (m *MachineScope) ProviderID() string {
 
 // AvailabilitySetSpec returns the availability set spec for this machine if available.
 func (m *MachineScope) AvailabilitySetSpec() azure.ResourceSpecGetter {
+	if id := m.existingAvailabilitySetID(); id != "" {
+		resourceID, err := azureutil.ParseResourceID(id)
+		if err != nil {
+			// the ID is validated by the webhook, the VM creation fails on an invalid ID anyway.
+			return nil
+		}
+		return &availabilitysets.AvailabilitySetSpec{
+			Name:          resourceID.Name,
+			ResourceGroup: resourceID.ResourceGroupName,
+			ClusterName:   m.ClusterName(),
+			Location:      m.Location(),
+			Existing:      true,
+		}
+	}
+
+	// VMs placed into a virtual machine scale set can't be in an availability set.
+	if m.AzureMachine.Spec.VirtualMachineScaleSet != nil {
+		return nil
+	}
+
 	availabilitySetName, ok := m.AvailabilitySet()
 	if !ok {
 		return nil
 	}
 
 	spec := &availabilitysets.AvailabilitySetSpec{
-		Name:           availabilitySetName,
-		ResourceGroup:  m.ResourceGroup(),
-		ClusterName:    m.ClusterName(),
-		Location:       m.Location(),
-		SKU:            nil,
-		AdditionalTags: m.AdditionalTags(),
+		Name:                     availabilitySetName,
+		ResourceGroup:            m.ResourceGroup(),
+		ClusterName:              m.ClusterName(),
+		Location:                 m.Location(),
+		SKU:                      nil,
+		AdditionalTags:           m.AdditionalTags(),
+		PlatformFaultDomainCount: m.AzureMachine.Spec.PlatformFaultDomainCount,
 	}
 
 	if m.cache != nil {
@@ -484,6 +804,36 @@ func (m *MachineScope) AvailabilitySetSpec() azure.ResourceSpecGetter {
 	return spec
 }
 
+// ExtendedLocation returns the extended location of the machine, or the extended location of the cluster if the
+// machine doesn't have one.
+func (m *MachineScope) ExtendedLocation() *infrav1.ExtendedLocationSpec {
+	if m.hasOwnExtendedLocation() {
+		return m.AzureMachine.Spec.ExtendedLocation
+	}
+	return m.ClusterScoper.ExtendedLocation()
+}
+
+// hasOwnExtendedLocation returns true if the AzureMachine overrides the extended location of the cluster.
+func (m *MachineScope) hasOwnExtendedLocation() bool {
+	return m.AzureMachine != nil && m.AzureMachine.Spec.ExtendedLocation != nil
+}
+
+// ExtendedLocationName returns the name of the extended location of the machine.
+func (m *MachineScope) ExtendedLocationName() string {
+	if m.ExtendedLocation() == nil {
+		return ""
+	}
+	return m.ExtendedLocation().Name
+}
+
+// ExtendedLocationType returns the type of the extended location of the machine.
+func (m *MachineScope) ExtendedLocationType() string {
+	if m.ExtendedLocation() == nil {
+		return ""
+	}
+	return m.ExtendedLocation().Type
+}
+
 // AvailabilitySet returns the availability set for this machine if available.
 func (m *MachineScope) AvailabilitySet() (string, bool) {
 	// AvailabilitySet service is not supported on EdgeZone currently.
@@ -510,6 +860,12 @@ func (m *MachineScope) AvailabilitySet() (string, bool) {
 
 // AvailabilitySetID returns the availability set for this machine, or "" if there is no availability set.
 func (m *MachineScope) AvailabilitySetID() string {
+	if id := m.existingAvailabilitySetID(); id != "" {
+		return id
+	}
+	if m.AzureMachine.Spec.VirtualMachineScaleSet != nil {
+		return ""
+	}
 	var asID string
 	if asName, ok := m.AvailabilitySet(); ok {
 		asID = azure.AvailabilitySetID(m.SubscriptionID(), m.ResourceGroup(), asName)
@@ -517,6 +873,19 @@ func (m *MachineScope) AvailabilitySetID() string {
 	return asID
 }
 
+// existingAvailabilitySetID returns the resource ID of the existing availability set this machine joins, or "" if the
+// availability set of the machine is managed.
+func (m *MachineScope) existingAvailabilitySetID() string {
+	ref := m.AzureMachine.Spec.AvailabilitySet
+	if ref == nil {
+		return ""
+	}
+	if ref.ID != "" {
+		return ref.ID
+	}
+	return azure.AvailabilitySetID(m.SubscriptionID(), m.ResourceGroup(), ref.Name)
+}
+
 // SystemAssignedIdentityName returns the role assignment name for the system assigned identity.
 func (m *MachineScope) SystemAssignedIdentityName() string {
 	if m.AzureMachine.Spec.SystemAssignedIdentityRole != nil {
@@ -559,6 +928,23 @@ func (m *MachineScope) SetVMState(v infrav1.ProvisioningState) {
 	m.AzureMachine.Status.VMState = &v
 }
 
+// SetBootDiagnosticsURIs sets the URIs of the boot diagnostics console screenshot and serial console log of the
+// AzureMachine VM.
+func (m *MachineScope) SetBootDiagnosticsURIs(consoleURI, serialConsoleLogURI string) {
+	m.AzureMachine.Status.BootDiagnosticsConsoleURI = consoleURI
+	m.AzureMachine.Status.SerialConsoleLogURI = serialConsoleLogURI
+}
+
+// FaultDomain returns the availability set fault domain of the AzureMachine VM, if known.
+func (m *MachineScope) FaultDomain() *int32 {
+	return m.AzureMachine.Status.FaultDomain
+}
+
+// SetFaultDomain sets the availability set fault domain of the AzureMachine VM.
+func (m *MachineScope) SetFaultDomain(faultDomain int32) {
+	m.AzureMachine.Status.FaultDomain = &faultDomain
+}
+
 // SetReady sets the AzureMachine Ready Status to true.
 func (m *MachineScope) SetReady() {
 	m.AzureMachine.Status.Ready = true
@@ -579,9 +965,24 @@ func (m *MachineScope) SetFailureReason(v capierrors.MachineStatusError) {
 	m.AzureMachine.Status.FailureReason = &v
 }
 
+// GetCondition returns the specified AzureMachine condition, or nil if it isn't set.
+func (m *MachineScope) GetCondition(conditionType clusterv1.ConditionType) *clusterv1.Condition {
+	return conditions.Get(m.AzureMachine, conditionType)
+}
+
+// SetConditionTrue sets the specified AzureMachine condition to true.
+func (m *MachineScope) SetConditionTrue(conditionType clusterv1.ConditionType) {
+	conditions.MarkTrue(m.AzureMachine, conditionType)
+}
+
 // SetConditionFalse sets the specified AzureMachine condition to false.
 func (m *MachineScope) SetConditionFalse(conditionType clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, message string) {
-	conditions.MarkFalse(m.AzureMachine, conditionType, reason, severity, message)
+	conditions.MarkFalse(m.AzureMachine, conditionType, reason, severity, "%s", message)
+}
+
+// DeleteCondition deletes a condition of the AzureMachine.
+func (m *MachineScope) DeleteCondition(conditionType clusterv1.ConditionType) {
+	conditions.Delete(m.AzureMachine, conditionType)
 }
 
 // SetAnnotation sets a key value annotation on the AzureMachine.
@@ -592,6 +993,52 @@ func (m *MachineScope) SetAnnotation(key, value string) {
 	m.AzureMachine.Annotations[key] = value
 }
 
+// RemoveAnnotation removes an annotation from the AzureMachine.
+func (m *MachineScope) RemoveAnnotation(key string) {
+	delete(m.AzureMachine.Annotations, key)
+}
+
+// IsDryRun returns true if the AzureMachine has the dry-run annotation set to "true".
+func (m *MachineScope) IsDryRun() bool {
+	return m.AzureMachine.GetAnnotations()[infrav1.DryRunAnnotation] == "true"
+}
+
+// RecordEvent records an event on the AzureMachine if an event recorder is configured.
+func (m *MachineScope) RecordEvent(eventType, reason, messageFmt string, args ...interface{}) {
+	if m.recorder == nil {
+		return
+	}
+	m.recorder.Eventf(m.AzureMachine, eventType, reason, messageFmt, args...)
+}
+
+// SetResourceLocked records a resource of the AzureMachine as locked by a management lock and sets the AzureResourcesUnlocked
+// condition to false.
+func (m *MachineScope) SetResourceLocked(serviceName, resourceName, rgName string) {
+	setResourceLocked(m.AzureMachine, serviceName, resourceName, rgName)
+}
+
+// ResourceLockedSince returns when a resource was last found to be locked by a management lock, if it still is.
+func (m *MachineScope) ResourceLockedSince(serviceName, resourceName, rgName string) (time.Time, bool) {
+	return resourceLockedSince(m.AzureMachine, serviceName, resourceName, rgName)
+}
+
+// ClearResourceLocked records a resource of the AzureMachine that was changed as no longer locked and sets the
+// AzureResourcesUnlocked condition to true once no resource is locked.
+func (m *MachineScope) ClearResourceLocked(serviceName, resourceName, rgName string) {
+	clearResourceLocked(m.AzureMachine, serviceName, resourceName, rgName)
+}
+
+// getLastAppliedVirtualNetworkTapID returns the resource ID of the virtual network tap last attached to a network
+// interface of the machine.
+func (m *MachineScope) getLastAppliedVirtualNetworkTapID(nicName string) string {
+	lastAppliedTaps, err := m.AnnotationJSON(azure.VirtualNetworkTapLastAppliedAnnotation)
+	if err != nil {
+		return ""
+	}
+	tapID, _ := lastAppliedTaps[nicName].(string)
+	return tapID
+}
+
 // AnnotationJSON returns a map[string]interface from a JSON annotation.
 func (m *MachineScope) AnnotationJSON(annotation string) (map[string]interface{}, error) {
 	out := map[string]interface{}{}
@@ -624,6 +1071,27 @@ func (m *MachineScope) SetAddresses(addrs []corev1.NodeAddress) {
 	m.AzureMachine.Status.Addresses = addrs
 }
 
+// SetNodeLabels sets the labels of the node of the machine read from the tags of its VM.
+func (m *MachineScope) SetNodeLabels(labels map[string]string) {
+	m.nodeLabels = labels
+}
+
+// NodeLabels returns the labels of the node of the machine read from the tags of its VM.
+func (m *MachineScope) NodeLabels() map[string]string {
+	return m.nodeLabels
+}
+
+// SetVMPrincipalID sets the principal ID of the system-assigned identity of the VM.
+func (m *MachineScope) SetVMPrincipalID(principalID string) {
+	m.vmPrincipalID = principalID
+}
+
+// VMPrincipalID returns the principal ID of the system-assigned identity of the VM if the VM service read it during
+// this reconciliation.
+func (m *MachineScope) VMPrincipalID() string {
+	return m.vmPrincipalID
+}
+
 // PatchObject persists the machine spec and status.
 func (m *MachineScope) PatchObject(ctx context.Context) error {
 	conditions.SetSummary(m.AzureMachine)
@@ -636,6 +1104,7 @@ func (m *MachineScope) PatchObject(ctx context.Context) error {
 			infrav1.VMRunningCondition,
 			infrav1.AvailabilitySetReadyCondition,
 			infrav1.NetworkInterfaceReadyCondition,
+			infrav1.AzureResourcesUnlockedCondition,
 		}})
 }
 
@@ -647,15 +1116,11 @@ func (m *MachineScope) Close(ctx context.Context) error {
 // AdditionalTags merges AdditionalTags from the scope's AzureCluster and AzureMachine. If the same key is present in both,
 // the value from AzureMachine takes precedence.
 func (m *MachineScope) AdditionalTags() infrav1.Tags {
-	tags := make(infrav1.Tags)
-	// Start with the cluster-wide tags...
-	tags.Merge(m.ClusterScoper.AdditionalTags())
-	// ... and merge in the Machine's
-	tags.Merge(m.AzureMachine.Spec.AdditionalTags)
+	additionalTags := tags.MergeAdditional(m.ClusterScoper.AdditionalTags(), m.AzureMachine.Spec.AdditionalTags)
 	// Set the cloud provider tag
-	tags[infrav1.ClusterAzureCloudProviderTagKey(m.ClusterName())] = string(infrav1.ResourceLifecycleOwned)
+	additionalTags[infrav1.ClusterAzureCloudProviderTagKey(m.ClusterName())] = string(infrav1.ResourceLifecycleOwned)
 
-	return tags
+	return additionalTags
 }
 
 // GetBootstrapData returns the bootstrap data from the secret in the Machine's bootstrap.dataSecretName.
@@ -702,6 +1167,17 @@ func (m *MachineScope) GetVMImage(ctx context.Context) (*infrav1.Image, error) {
 	return svc.GetDefaultUbuntuImage(ctx, m.Location(), ptr.Deref(m.Machine.Spec.Version, ""))
 }
 
+// PodSubnetName returns the name of the cluster subnet with the pod role, if any.
+// A self-managed Azure CNI assigns the secondary IP configurations of the primary NIC, allocated from this subnet, to pods.
+func (m *MachineScope) PodSubnetName() string {
+	for _, subnet := range m.Subnets() {
+		if subnet.Role == infrav1.SubnetPod {
+			return subnet.Name
+		}
+	}
+	return ""
+}
+
 // SetSubnetName defaults the AzureMachine subnet name to the name of one the subnets with the machine role when there is only one of them.
 // Note: this logic exists only for purposes of ensuring backwards compatibility for old clusters created without the `subnetName` field being
 // set, and should be removed in the future when this field is no longer optional.
@@ -745,6 +1221,9 @@ func (m *MachineScope) DeleteLongRunningOperationState(name, service, futureType
 // UpdateDeleteStatus updates a condition on the AzureMachine status after a DELETE operation.
 func (m *MachineScope) UpdateDeleteStatus(condition clusterv1.ConditionType, service string, err error) {
 	switch {
+	case err == nil && m.IsDryRun():
+		// Nothing was changed in Azure in dry-run mode, so the condition is left as it is.
+		return
 	case err == nil:
 		conditions.MarkFalse(m.AzureMachine, condition, infrav1.DeletedReason, clusterv1.ConditionSeverityInfo, "%s successfully deleted", service)
 	case azure.IsOperationNotDoneError(err):
@@ -757,6 +1236,9 @@ func (m *MachineScope) UpdateDeleteStatus(condition clusterv1.ConditionType, ser
 // UpdatePutStatus updates a condition on the AzureMachine status after a PUT operation.
 func (m *MachineScope) UpdatePutStatus(condition clusterv1.ConditionType, service string, err error) {
 	switch {
+	case err == nil && m.IsDryRun():
+		// Nothing was changed in Azure in dry-run mode, so the condition is left as it is.
+		return
 	case err == nil:
 		conditions.MarkTrue(m.AzureMachine, condition)
 	case azure.IsOperationNotDoneError(err):
@@ -769,6 +1251,9 @@ func (m *MachineScope) UpdatePutStatus(condition clusterv1.ConditionType, servic
 // UpdatePatchStatus updates a condition on the AzureMachine status after a PATCH operation.
 func (m *MachineScope) UpdatePatchStatus(condition clusterv1.ConditionType, service string, err error) {
 	switch {
+	case err == nil && m.IsDryRun():
+		// Nothing was changed in Azure in dry-run mode, so the condition is left as it is.
+		return
 	case err == nil:
 		conditions.MarkTrue(m.AzureMachine, condition)
 	case azure.IsOperationNotDoneError(err):
@@ -18,29 +18,37 @@ package scope
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	azureautorest "github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/mock_azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/availabilitysets"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/datacollectionruleassociations"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/disks"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/inboundnatrules"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/networkinterfaces"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicips"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/roleassignments"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/runcommands"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualmachineimages"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualmachineimages/mock_virtualmachineimages"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualmachines"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/vmextensions"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 )
 
 func TestMachineScope_Name(t *testing.T) {
@@ -269,6 +277,9 @@ func TestMachineScope_PublicIPSpecs(t *testing.T) {
 					},
 					Spec: infrav1.AzureMachineSpec{
 						AllocatePublicIP: true,
+						AdditionalTags: infrav1.Tags{
+							"costcenter": "machine",
+						},
 					},
 				},
 				ClusterScoper: &ClusterScope{
@@ -322,6 +333,8 @@ func TestMachineScope_PublicIPSpecs(t *testing.T) {
 					AdditionalTags: infrav1.Tags{
 						"Name": "my-publicip-ipv6",
 						"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": "owned",
+						"costcenter":                       "machine",
+						"kubernetes.io_cluster_my-cluster": "owned",
 					},
 				},
 			},
@@ -336,6 +349,87 @@ func TestMachineScope_PublicIPSpecs(t *testing.T) {
 	}
 }
 
+func TestMachineScope_DataCollectionRuleAssociationSpec(t *testing.T) {
+	dcrID := "/subscriptions/123/resourceGroups/monitoring-rg/providers/Microsoft.Insights/dataCollectionRules/my-dcr"
+	newMachineScope := func(dataCollectionRuleID string, associated bool) MachineScope {
+		azureMachine := &infrav1.AzureMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "machine-name",
+			},
+		}
+		if associated {
+			conditions.MarkTrue(azureMachine, infrav1.DataCollectionRuleAssociationReadyCondition)
+		}
+		return MachineScope{
+			Machine:      &clusterv1.Machine{},
+			AzureMachine: azureMachine,
+			ClusterScoper: &ClusterScope{
+				Cluster: &clusterv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "my-cluster",
+					},
+				},
+				AzureClients: AzureClients{
+					EnvironmentSettings: auth.EnvironmentSettings{
+						Values: map[string]string{
+							auth.SubscriptionID: "123",
+						},
+					},
+				},
+				AzureCluster: &infrav1.AzureCluster{
+					Spec: infrav1.AzureClusterSpec{
+						ResourceGroup:        "my-rg",
+						DataCollectionRuleID: dataCollectionRuleID,
+					},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name         string
+		machineScope MachineScope
+		want         azure.ResourceSpecGetter
+	}{
+		{
+			name:         "returns nil if no data collection rule is specified",
+			machineScope: newMachineScope("", false),
+			want:         nil,
+		},
+		{
+			name:         "returns the association of the VM with the data collection rule of the cluster",
+			machineScope: newMachineScope(dcrID, false),
+			want: &datacollectionruleassociations.DataCollectionRuleAssociationSpec{
+				Name:                 "my-cluster-dcra",
+				ResourceGroup:        "my-rg",
+				ResourceURI:          "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/machine-name",
+				DataCollectionRuleID: dcrID,
+				ClusterName:          "my-cluster",
+			},
+		},
+		{
+			name:         "returns the association to delete once the data collection rule is removed from the cluster",
+			machineScope: newMachineScope("", true),
+			want: &datacollectionruleassociations.DataCollectionRuleAssociationSpec{
+				Name:          "my-cluster-dcra",
+				ResourceGroup: "my-rg",
+				ResourceURI:   "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/machine-name",
+				ClusterName:   "my-cluster",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			if tt.want == nil {
+				g.Expect(tt.machineScope.DataCollectionRuleAssociationSpec()).To(BeNil())
+			} else {
+				g.Expect(tt.machineScope.DataCollectionRuleAssociationSpec()).To(Equal(tt.want))
+			}
+		})
+	}
+}
+
 func TestMachineScope_InboundNatSpecs(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -406,6 +500,66 @@ func TestMachineScope_InboundNatSpecs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "returns InboundNatSpec with the idle timeout, TCP reset and floating IP of the API server LB",
+			machineScope: MachineScope{
+				Machine: &clusterv1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							clusterv1.MachineControlPlaneLabel: "",
+						},
+					},
+				},
+				AzureMachine: &infrav1.AzureMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "machine-name",
+					},
+				},
+				ClusterScoper: &ClusterScope{
+					AzureClients: AzureClients{
+						EnvironmentSettings: auth.EnvironmentSettings{
+							Values: map[string]string{
+								auth.SubscriptionID: "123",
+							},
+						},
+					},
+					AzureCluster: &infrav1.AzureCluster{
+						Spec: infrav1.AzureClusterSpec{
+							ResourceGroup: "my-rg",
+							AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+								SubscriptionID: "123",
+							},
+							NetworkSpec: infrav1.NetworkSpec{
+								APIServerLB: infrav1.LoadBalancerSpec{
+									Name: "foo-loadbalancer",
+									FrontendIPs: []infrav1.FrontendIP{
+										{
+											Name: "foo-frontend-ip",
+										},
+									},
+									InboundNATRules: &infrav1.InboundNATRulesSpec{
+										IdleTimeoutInMinutes: ptr.To[int32](30),
+										EnableTCPReset:       ptr.To(true),
+										EnableFloatingIP:     ptr.To(true),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: []azure.ResourceSpecGetter{
+				&inboundnatrules.InboundNatSpec{
+					Name:                      "machine-name",
+					LoadBalancerName:          "foo-loadbalancer",
+					ResourceGroup:             "my-rg",
+					FrontendIPConfigurationID: ptr.To(azure.FrontendIPConfigID("123", "my-rg", "foo-loadbalancer", "foo-frontend-ip")),
+					IdleTimeoutInMinutes:      ptr.To[int32](30),
+					EnableTCPReset:            ptr.To(true),
+					EnableFloatingIP:          ptr.To(true),
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -526,6 +680,71 @@ func TestMachineScope_RoleAssignmentSpecs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "returns a RoleAssignmentSpec for each additional role",
+			machineScope: MachineScope{
+				Machine: &clusterv1.Machine{},
+				AzureMachine: &infrav1.AzureMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "machine-name",
+					},
+					Spec: infrav1.AzureMachineSpec{
+						Identity: infrav1.VMIdentitySystemAssigned,
+						SystemAssignedIdentityRole: &infrav1.SystemAssignedIdentityRole{
+							Name:         "azure-role-assignment-name",
+							Scope:        "/subscriptions/123/",
+							DefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/contributor",
+							AdditionalRoles: []infrav1.SystemAssignedIdentityAdditionalRole{
+								{
+									Scope:        "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-kv",
+									DefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/secrets-user",
+								},
+								{
+									Scope:        "/subscriptions/456",
+									DefinitionID: "/subscriptions/456/providers/Microsoft.Authorization/roleDefinitions/reader",
+								},
+							},
+						},
+					},
+				},
+				ClusterScoper: &ClusterScope{
+					AzureCluster: &infrav1.AzureCluster{
+						Spec: infrav1.AzureClusterSpec{
+							ResourceGroup: "my-rg",
+						},
+					},
+				},
+			},
+			want: []azure.ResourceSpecGetter{
+				&roleassignments.RoleAssignmentSpec{
+					ResourceType:     azure.VirtualMachine,
+					MachineName:      "machine-name",
+					Name:             "azure-role-assignment-name",
+					ResourceGroup:    "my-rg",
+					Scope:            "/subscriptions/123/",
+					RoleDefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/contributor",
+					PrincipalID:      ptr.To("fakePrincipalID"),
+				},
+				&roleassignments.RoleAssignmentSpec{
+					ResourceType:     azure.VirtualMachine,
+					MachineName:      "machine-name",
+					Name:             roleassignments.AdditionalRoleAssignmentName("azure-role-assignment-name", "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-kv", "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/secrets-user"),
+					ResourceGroup:    "my-rg",
+					Scope:            "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-kv",
+					RoleDefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/secrets-user",
+					PrincipalID:      ptr.To("fakePrincipalID"),
+				},
+				&roleassignments.RoleAssignmentSpec{
+					ResourceType:     azure.VirtualMachine,
+					MachineName:      "machine-name",
+					Name:             roleassignments.AdditionalRoleAssignmentName("azure-role-assignment-name", "/subscriptions/456", "/subscriptions/456/providers/Microsoft.Authorization/roleDefinitions/reader"),
+					ResourceGroup:    "my-rg",
+					Scope:            "/subscriptions/456",
+					RoleDefinitionID: "/subscriptions/456/providers/Microsoft.Authorization/roleDefinitions/reader",
+					PrincipalID:      ptr.To("fakePrincipalID"),
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -872,6 +1091,61 @@ func TestMachineScope_VMExtensionSpecs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "If OS type is Linux and a cloud-init completion check is configured, it returns ExtensionSpec waiting for cloud-init",
+			machineScope: MachineScope{
+				Machine: &clusterv1.Machine{},
+				AzureMachine: &infrav1.AzureMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "machine-name",
+					},
+					Spec: infrav1.AzureMachineSpec{
+						OSDisk: infrav1.OSDisk{
+							OSType: "Linux",
+						},
+						BootstrapCompletionCheck: &infrav1.BootstrapCompletionCheck{
+							Type:           infrav1.BootstrapCompletionCheckCloudInit,
+							TimeoutSeconds: ptr.To[int32](600),
+						},
+					},
+				},
+				ClusterScoper: &ClusterScope{
+					AzureClients: AzureClients{
+						EnvironmentSettings: auth.EnvironmentSettings{
+							Environment: azureautorest.Environment{
+								Name: azureautorest.PublicCloud.Name,
+							},
+						},
+					},
+					AzureCluster: &infrav1.AzureCluster{
+						Spec: infrav1.AzureClusterSpec{
+							ResourceGroup: "my-rg",
+							AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+								Location: "westus",
+							},
+						},
+					},
+				},
+				cache: &MachineCache{
+					VMSKU: resourceskus.SKU{},
+				},
+			},
+			want: []azure.ResourceSpecGetter{
+				&vmextensions.VMExtensionSpec{
+					ExtensionSpec: azure.ExtensionSpec{
+						Name:      "CAPZ.Linux.Bootstrapping",
+						VMName:    "machine-name",
+						Publisher: "Microsoft.Azure.ContainerUpstream",
+						Version:   "1.0",
+						ProtectedSettings: map[string]string{
+							"commandToExecute": "timeout 600 cloud-init status --wait >/dev/null; rc=$?; if [ $rc -ne 0 ] && [ $rc -ne 2 ]; then exit 1; fi; test -f /run/cluster-api/bootstrap-success.complete",
+						},
+					},
+					ResourceGroup: "my-rg",
+					Location:      "westus",
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -882,6 +1156,98 @@ func TestMachineScope_VMExtensionSpecs(t *testing.T) {
 	}
 }
 
+func TestMachineScope_RunCommandSpecs(t *testing.T) {
+	g := NewWithT(t)
+	machineScope := MachineScope{
+		ClusterScoper: &ClusterScope{
+			AzureCluster: &infrav1.AzureCluster{
+				Spec: infrav1.AzureClusterSpec{
+					ResourceGroup: "my-rg",
+					AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+						Location: "westus",
+					},
+				},
+			},
+		},
+		AzureMachine: &infrav1.AzureMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "machine-name",
+			},
+			Spec: infrav1.AzureMachineSpec{
+				RunCommands: []infrav1.RunCommand{
+					{
+						Name:             "post-provision",
+						Source:           infrav1.RunCommandSource{Script: "echo $GREETING"},
+						Parameters:       []infrav1.RunCommandParameter{{Name: "GREETING", Value: "hello"}},
+						TimeoutInSeconds: ptr.To[int32](600),
+					},
+					{
+						Name:   "harden",
+						Source: infrav1.RunCommandSource{ScriptURI: "https://mystorage.blob.core.windows.net/scripts/harden.sh"},
+					},
+				},
+			},
+		},
+	}
+	g.Expect(machineScope.RunCommandSpecs()).To(Equal([]azure.ResourceSpecGetter{
+		&runcommands.RunCommandSpec{
+			Name:             "post-provision",
+			VMName:           "machine-name",
+			ResourceGroup:    "my-rg",
+			Location:         "westus",
+			Script:           "echo $GREETING",
+			ScriptParameters: []infrav1.RunCommandParameter{{Name: "GREETING", Value: "hello"}},
+			TimeoutInSeconds: ptr.To[int32](600),
+		},
+		&runcommands.RunCommandSpec{
+			Name:          "harden",
+			VMName:        "machine-name",
+			ResourceGroup: "my-rg",
+			Location:      "westus",
+			ScriptURI:     "https://mystorage.blob.core.windows.net/scripts/harden.sh",
+		},
+	}))
+}
+
+func TestMachineScope_UpdatePutStatusBootstrapSucceeded(t *testing.T) {
+	g := NewWithT(t)
+
+	machineScope := MachineScope{
+		AzureMachine: &infrav1.AzureMachine{},
+	}
+
+	// The bootstrapping extension is still running while cloud-init hasn't completed.
+	machineScope.UpdatePutStatus(infrav1.BootstrapSucceededCondition, "vmextensions", azure.NewOperationNotDoneError(&infrav1.Future{}))
+	condition := conditions.Get(machineScope.AzureMachine, infrav1.BootstrapSucceededCondition)
+	g.Expect(condition).NotTo(BeNil())
+	g.Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+	g.Expect(condition.Reason).To(Equal(infrav1.CreatingReason))
+
+	// The bootstrapping extension succeeds once cloud-init completed.
+	machineScope.UpdatePutStatus(infrav1.BootstrapSucceededCondition, "vmextensions", nil)
+	g.Expect(conditions.IsTrue(machineScope.AzureMachine, infrav1.BootstrapSucceededCondition)).To(BeTrue())
+}
+
+func TestMachineScope_UpdatePutStatusDryRun(t *testing.T) {
+	g := NewWithT(t)
+
+	machineScope := MachineScope{
+		AzureMachine: &infrav1.AzureMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{infrav1.DryRunAnnotation: "true"},
+			},
+		},
+	}
+
+	// Nothing was created in dry-run mode, so a successful service doesn't mark its condition true.
+	machineScope.UpdatePutStatus(infrav1.VMRunningCondition, "virtualmachine", nil)
+	g.Expect(conditions.Has(machineScope.AzureMachine, infrav1.VMRunningCondition)).To(BeFalse())
+
+	// Failures are still reported.
+	machineScope.UpdatePutStatus(infrav1.VMRunningCondition, "virtualmachine", fmt.Errorf("some error"))
+	g.Expect(conditions.IsFalse(machineScope.AzureMachine, infrav1.VMRunningCondition)).To(BeTrue())
+}
+
 func TestMachineScope_Subnet(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -1039,41 +1405,21 @@ func TestMachineScope_AvailabilityZone(t *testing.T) {
 			},
 			want: "dummy-failure-domain-from-azuremachine-spec",
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.machineScope.AvailabilityZone(); got != tt.want {
-				t.Errorf("AvailabilityZone() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func TestMachineScope_Namespace(t *testing.T) {
-	tests := []struct {
-		name         string
-		machineScope MachineScope
-		want         string
-	}{
 		{
-			name: "returns azure machine namespace",
+			name: "returns empty if the azuremachine has its own edge zone",
 			machineScope: MachineScope{
-				AzureMachine: &infrav1.AzureMachine{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      "machine-name",
-						Namespace: "foo",
+				Machine: &clusterv1.Machine{
+					Spec: clusterv1.MachineSpec{
+						FailureDomain: ptr.To("dummy-failure-domain-from-machine-spec"),
 					},
 				},
-			},
-			want: "foo",
-		},
-		{
-			name: "returns azure machine namespace as empty if namespace is no specified",
-			machineScope: MachineScope{
 				AzureMachine: &infrav1.AzureMachine{
 					ObjectMeta: metav1.ObjectMeta{
 						Name: "machine-name",
 					},
+					Spec: infrav1.AzureMachineSpec{
+						ExtendedLocation: &infrav1.ExtendedLocationSpec{Name: "microsoftlosangeles1", Type: "EdgeZone"},
+					},
 				},
 			},
 			want: "",
@@ -1081,21 +1427,167 @@ func TestMachineScope_Namespace(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.machineScope.Namespace(); got != tt.want {
-				t.Errorf("Namespace() = %v, want %v", got, tt.want)
+			if got := tt.machineScope.AvailabilityZone(); got != tt.want {
+				t.Errorf("AvailabilityZone() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func TestMachineScope_IsControlPlane(t *testing.T) {
+func TestMachineScope_ExtendedLocation(t *testing.T) {
+	clusterEdgeZone := &infrav1.ExtendedLocationSpec{Name: "microsoftvancouver1", Type: "EdgeZone"}
+	machineEdgeZone := &infrav1.ExtendedLocationSpec{Name: "microsoftlosangeles1", Type: "EdgeZone"}
 	tests := []struct {
-		name         string
-		machineScope MachineScope
-		want         bool
+		name                     string
+		clusterExtendedLocation  *infrav1.ExtendedLocationSpec
+		machineExtendedLocation  *infrav1.ExtendedLocationSpec
+		expectedExtendedLocation *infrav1.ExtendedLocationSpec
+		expectedFailureDomains   []string
 	}{
 		{
-			name: "returns false when machine is not control plane",
+			name:                   "no extended location",
+			expectedFailureDomains: []string{"1"},
+		},
+		{
+			name:                     "extended location of the cluster",
+			clusterExtendedLocation:  clusterEdgeZone,
+			expectedExtendedLocation: clusterEdgeZone,
+			expectedFailureDomains:   []string{"1"},
+		},
+		{
+			name:                     "extended location of the machine overrides the one of the cluster",
+			clusterExtendedLocation:  clusterEdgeZone,
+			machineExtendedLocation:  machineEdgeZone,
+			expectedExtendedLocation: machineEdgeZone,
+		},
+		{
+			name:                     "extended location of the machine without one for the cluster",
+			machineExtendedLocation:  machineEdgeZone,
+			expectedExtendedLocation: machineEdgeZone,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			machineScope := MachineScope{
+				Machine: &clusterv1.Machine{
+					Spec: clusterv1.MachineSpec{
+						FailureDomain: ptr.To("1"),
+					},
+				},
+				AzureMachine: &infrav1.AzureMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "machine-name",
+					},
+					Spec: infrav1.AzureMachineSpec{
+						AllocatePublicIP: true,
+						ExtendedLocation: tt.machineExtendedLocation,
+						DataDisks: []infrav1.DataDisk{
+							{
+								NameSuffix: "data",
+								DiskSizeGB: 128,
+								ManagedDisk: &infrav1.ManagedDiskParameters{
+									StorageAccountType: "UltraSSD_LRS",
+									LogicalSectorSize:  ptr.To[int32](4096),
+								},
+							},
+						},
+					},
+				},
+				ClusterScoper: &ClusterScope{
+					Cluster: &clusterv1.Cluster{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "my-cluster",
+						},
+					},
+					AzureCluster: &infrav1.AzureCluster{
+						Spec: infrav1.AzureClusterSpec{
+							ResourceGroup: "my-rg",
+							AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+								Location:         "westus",
+								ExtendedLocation: tt.clusterExtendedLocation,
+							},
+						},
+						Status: infrav1.AzureClusterStatus{
+							FailureDomains: map[string]clusterv1.FailureDomainSpec{
+								"1": {},
+							},
+						},
+					},
+				},
+			}
+
+			vmSpec, ok := machineScope.VMSpec().(*virtualmachines.VMSpec)
+			g.Expect(ok).To(BeTrue())
+			g.Expect(vmSpec.ExtendedLocation).To(Equal(tt.expectedExtendedLocation))
+
+			nicSpec := machineScope.BuildNICSpec("machine-name-nic", infrav1.NetworkInterface{}, true)
+			g.Expect(nicSpec.ExtendedLocation).To(Equal(tt.expectedExtendedLocation))
+
+			publicIPSpecs := machineScope.PublicIPSpecs()
+			g.Expect(publicIPSpecs).To(HaveLen(1))
+			publicIPSpec, ok := publicIPSpecs[0].(*publicips.PublicIPSpec)
+			g.Expect(ok).To(BeTrue())
+			g.Expect(publicIPSpec.ExtendedLocation).To(Equal(tt.expectedExtendedLocation))
+			g.Expect(publicIPSpec.FailureDomains).To(Equal(tt.expectedFailureDomains))
+
+			diskSpecs := machineScope.DiskSpecs()
+			g.Expect(diskSpecs).To(HaveLen(2))
+			diskSpec, ok := diskSpecs[1].(*disks.DiskSpec)
+			g.Expect(ok).To(BeTrue())
+			g.Expect(diskSpec.ExtendedLocation).To(Equal(tt.expectedExtendedLocation))
+		})
+	}
+}
+
+func TestMachineScope_Namespace(t *testing.T) {
+	tests := []struct {
+		name         string
+		machineScope MachineScope
+		want         string
+	}{
+		{
+			name: "returns azure machine namespace",
+			machineScope: MachineScope{
+				AzureMachine: &infrav1.AzureMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "machine-name",
+						Namespace: "foo",
+					},
+				},
+			},
+			want: "foo",
+		},
+		{
+			name: "returns azure machine namespace as empty if namespace is no specified",
+			machineScope: MachineScope{
+				AzureMachine: &infrav1.AzureMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "machine-name",
+					},
+				},
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.machineScope.Namespace(); got != tt.want {
+				t.Errorf("Namespace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMachineScope_IsControlPlane(t *testing.T) {
+	tests := []struct {
+		name         string
+		machineScope MachineScope
+		want         bool
+	}{
+		{
+			name: "returns false when machine is not control plane",
 			machineScope: MachineScope{
 				Machine: &clusterv1.Machine{},
 				AzureMachine: &infrav1.AzureMachine{
@@ -1347,6 +1839,138 @@ func TestMachineScope_AvailabilitySet(t *testing.T) {
 	}
 }
 
+func TestMachineScope_ExistingAvailabilitySet(t *testing.T) {
+	tests := []struct {
+		name                    string
+		availabilitySet         *infrav1.AvailabilitySetReference
+		wantAvailabilitySetID   string
+		wantAvailabilitySetSpec *availabilitysets.AvailabilitySetSpec
+	}{
+		{
+			name:                  "existing availability set referenced by name is in the resource group of the cluster",
+			availabilitySet:       &infrav1.AvailabilitySetReference{Name: "existing-as"},
+			wantAvailabilitySetID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/availabilitySets/existing-as",
+			wantAvailabilitySetSpec: &availabilitysets.AvailabilitySetSpec{
+				Name:          "existing-as",
+				ResourceGroup: "my-rg",
+				ClusterName:   "cluster",
+				Location:      "westus",
+				Existing:      true,
+			},
+		},
+		{
+			name:                  "existing availability set referenced by ID",
+			availabilitySet:       &infrav1.AvailabilitySetReference{ID: "/subscriptions/123/resourceGroups/other-rg/providers/Microsoft.Compute/availabilitySets/existing-as"},
+			wantAvailabilitySetID: "/subscriptions/123/resourceGroups/other-rg/providers/Microsoft.Compute/availabilitySets/existing-as",
+			wantAvailabilitySetSpec: &availabilitysets.AvailabilitySetSpec{
+				Name:          "existing-as",
+				ResourceGroup: "other-rg",
+				ClusterName:   "cluster",
+				Location:      "westus",
+				Existing:      true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			machineScope := MachineScope{
+				ClusterScoper: &ClusterScope{
+					AzureClients: AzureClients{
+						EnvironmentSettings: auth.EnvironmentSettings{
+							Values: map[string]string{
+								auth.SubscriptionID: "123",
+							},
+						},
+					},
+					Cluster: &clusterv1.Cluster{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "cluster",
+						},
+					},
+					AzureCluster: &infrav1.AzureCluster{
+						Spec: infrav1.AzureClusterSpec{
+							ResourceGroup: "my-rg",
+							AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+								Location: "westus",
+							},
+						},
+					},
+				},
+				Machine: &clusterv1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							clusterv1.MachineControlPlaneLabel: "",
+						},
+					},
+				},
+				AzureMachine: &infrav1.AzureMachine{
+					Spec: infrav1.AzureMachineSpec{
+						AvailabilitySet: tt.availabilitySet,
+					},
+				},
+			}
+			g.Expect(machineScope.AvailabilitySetID()).To(Equal(tt.wantAvailabilitySetID))
+			g.Expect(machineScope.AvailabilitySetSpec()).To(Equal(tt.wantAvailabilitySetSpec))
+		})
+	}
+}
+
+func TestMachineScope_VirtualMachineScaleSet(t *testing.T) {
+	g := NewWithT(t)
+	scaleSetID := "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachineScaleSets/my-vmss"
+	machineScope := MachineScope{
+		ClusterScoper: &ClusterScope{
+			AzureClients: AzureClients{
+				EnvironmentSettings: auth.EnvironmentSettings{
+					Values: map[string]string{
+						auth.SubscriptionID: "123",
+					},
+				},
+			},
+			Cluster: &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cluster",
+				},
+			},
+			AzureCluster: &infrav1.AzureCluster{
+				Spec: infrav1.AzureClusterSpec{
+					ResourceGroup: "my-rg",
+					AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+						Location: "westus",
+					},
+				},
+			},
+		},
+		Machine: &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					clusterv1.MachineControlPlaneLabel: "",
+				},
+			},
+		},
+		AzureMachine: &infrav1.AzureMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "machine-name",
+			},
+			Spec: infrav1.AzureMachineSpec{
+				VirtualMachineScaleSet: &infrav1.VirtualMachineScaleSetReference{
+					ID:                  scaleSetID,
+					PlatformFaultDomain: ptr.To[int32](2),
+				},
+			},
+		},
+	}
+	g.Expect(machineScope.AvailabilitySetID()).To(BeEmpty())
+	g.Expect(machineScope.AvailabilitySetSpec()).To(BeNil())
+	vmSpec, ok := machineScope.VMSpec().(*virtualmachines.VMSpec)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(vmSpec.AvailabilitySetID).To(BeEmpty())
+	g.Expect(vmSpec.ScaleSetID).To(Equal(scaleSetID))
+	g.Expect(vmSpec.PlatformFaultDomain).To(Equal(ptr.To[int32](2)))
+}
+
 func TestMachineScope_VMState(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -1392,6 +2016,68 @@ func TestMachineScope_VMState(t *testing.T) {
 	}
 }
 
+func TestMachineScope_MarketplaceAgreementSpec(t *testing.T) {
+	thirdPartyImage := &infrav1.Image{
+		Marketplace: &infrav1.AzureMarketplaceImage{
+			ImagePlan:       infrav1.ImagePlan{Publisher: "my-publisher", Offer: "my-offer", SKU: "my-sku"},
+			Version:         "1.0.0",
+			ThirdPartyImage: true,
+		},
+	}
+	tests := []struct {
+		name         string
+		machineScope MachineScope
+		want         *azure.MarketplaceAgreementSpec
+	}{
+		{
+			name: "returns the agreement of the image plan",
+			machineScope: MachineScope{
+				AzureMachine: &infrav1.AzureMachine{},
+				cache:        &MachineCache{VMImage: thirdPartyImage},
+			},
+			want: &azure.MarketplaceAgreementSpec{Publisher: "my-publisher", Offer: "my-offer", Plan: "my-sku"},
+		},
+		{
+			name: "accepts the terms of the agreement when opted in",
+			machineScope: MachineScope{
+				AzureMachine: &infrav1.AzureMachine{
+					Spec: infrav1.AzureMachineSpec{AcceptImagePlanTerms: true},
+				},
+				cache: &MachineCache{VMImage: thirdPartyImage},
+			},
+			want: &azure.MarketplaceAgreementSpec{Publisher: "my-publisher", Offer: "my-offer", Plan: "my-sku", AcceptTerms: true},
+		},
+		{
+			name: "returns nil for an image without a plan",
+			machineScope: MachineScope{
+				AzureMachine: &infrav1.AzureMachine{
+					Spec: infrav1.AzureMachineSpec{AcceptImagePlanTerms: true},
+				},
+				cache: &MachineCache{VMImage: &infrav1.Image{ID: ptr.To("my-image")}},
+			},
+		},
+		{
+			name: "returns nil for an existing VM",
+			machineScope: MachineScope{
+				AzureMachine: &infrav1.AzureMachine{
+					Spec: infrav1.AzureMachineSpec{
+						ProviderID:           ptr.To("azure:///subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm"),
+						AcceptImagePlanTerms: true,
+					},
+				},
+				cache: &MachineCache{VMImage: thirdPartyImage},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(tt.machineScope.MarketplaceAgreementSpec()).To(Equal(tt.want))
+		})
+	}
+}
+
 func TestMachineScope_GetVMImage(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -1795,7 +2481,7 @@ func TestMachineScope_NICSpecs(t *testing.T) {
 			},
 		},
 		{
-			name: "Node Machine with no NAT gateway and no public IP address and SKU is in machine cache",
+			name: "Node Machine in a dual-stack subnet",
 			machineScope: MachineScope{
 				ClusterScoper: &ClusterScope{
 					AzureClients: AzureClients{
@@ -1836,8 +2522,9 @@ func TestMachineScope_NICSpecs(t *testing.T) {
 								Subnets: []infrav1.SubnetSpec{
 									{
 										SubnetClassSpec: infrav1.SubnetClassSpec{
-											Role: infrav1.SubnetNode,
-											Name: "subnet1",
+											Role:       infrav1.SubnetNode,
+											Name:       "subnet1",
+											CIDRBlocks: []string{"10.1.0.0/16", "2001:1234:5678:9abc::/64"},
 										},
 									},
 								},
@@ -1871,11 +2558,6 @@ func TestMachineScope_NICSpecs(t *testing.T) {
 						},
 					},
 				},
-				cache: &MachineCache{
-					VMSKU: resourceskus.SKU{
-						Name: ptr.To("Standard_D2v2"),
-					},
-				},
 			},
 			want: []azure.ResourceSpecGetter{
 				&networkinterfaces.NICSpec{
@@ -1896,12 +2578,10 @@ func TestMachineScope_NICSpecs(t *testing.T) {
 					PublicIPName:              "",
 					AcceleratedNetworking:     nil,
 					DNSServers:                nil,
-					IPv6Enabled:               false,
+					IPv6Enabled:               true,
 					EnableIPForwarding:        false,
-					SKU: &resourceskus.SKU{
-						Name: ptr.To("Standard_D2v2"),
-					},
-					ClusterName: "cluster",
+					SKU:                       nil,
+					ClusterName:               "cluster",
 					AdditionalTags: infrav1.Tags{
 						"kubernetes.io_cluster_cluster": "owned",
 					},
@@ -1909,7 +2589,7 @@ func TestMachineScope_NICSpecs(t *testing.T) {
 			},
 		},
 		{
-			name: "Node Machine with NAT gateway",
+			name: "Node Machine with a pod subnet",
 			machineScope: MachineScope{
 				ClusterScoper: &ClusterScope{
 					AzureClients: AzureClients{
@@ -1953,15 +2633,19 @@ func TestMachineScope_NICSpecs(t *testing.T) {
 											Role: infrav1.SubnetNode,
 											Name: "subnet1",
 										},
-										NatGateway: infrav1.NatGateway{
-											NatGatewayClassSpec: infrav1.NatGatewayClassSpec{
-												Name: "natgw",
-											},
+									},
+									{
+										SubnetClassSpec: infrav1.SubnetClassSpec{
+											Role: infrav1.SubnetPod,
+											Name: "pod-subnet",
 										},
 									},
 								},
 								NodeOutboundLB: &infrav1.LoadBalancerSpec{
 									Name: "outbound-lb",
+									BackendPool: infrav1.BackendPool{
+										Name: "outbound-lb-outboundBackendPool",
+									},
 								},
 							},
 						},
@@ -1975,7 +2659,7 @@ func TestMachineScope_NICSpecs(t *testing.T) {
 						ProviderID: ptr.To("azure:///subscriptions/1234-5678/resourceGroups/my-cluster/providers/Microsoft.Compute/virtualMachines/machine-name"),
 						NetworkInterfaces: []infrav1.NetworkInterface{{
 							SubnetName:       "subnet1",
-							PrivateIPConfigs: 1,
+							PrivateIPConfigs: 3,
 						}},
 					},
 				},
@@ -1996,11 +2680,12 @@ func TestMachineScope_NICSpecs(t *testing.T) {
 					SubscriptionID:            "123",
 					MachineName:               "machine-name",
 					SubnetName:                "subnet1",
-					IPConfigs:                 []networkinterfaces.IPConfig{{}},
+					IPConfigs:                 []networkinterfaces.IPConfig{{}, {}, {}},
+					PodSubnetName:             "pod-subnet",
 					VNetName:                  "vnet1",
 					VNetResourceGroup:         "rg1",
-					PublicLBName:              "",
-					PublicLBAddressPoolName:   "",
+					PublicLBName:              "outbound-lb",
+					PublicLBAddressPoolName:   "outbound-lb-outboundBackendPool",
 					PublicLBNATRuleName:       "",
 					InternalLBName:            "",
 					InternalLBAddressPoolName: "",
@@ -2018,7 +2703,7 @@ func TestMachineScope_NICSpecs(t *testing.T) {
 			},
 		},
 		{
-			name: "Node Machine with public IP address",
+			name: "Node Machine with no NAT gateway and no public IP address and SKU is in machine cache",
 			machineScope: MachineScope{
 				ClusterScoper: &ClusterScope{
 					AzureClients: AzureClients{
@@ -2064,6 +2749,125 @@ func TestMachineScope_NICSpecs(t *testing.T) {
 										},
 									},
 								},
+								NodeOutboundLB: &infrav1.LoadBalancerSpec{
+									Name: "outbound-lb",
+									BackendPool: infrav1.BackendPool{
+										Name: "outbound-lb-outboundBackendPool",
+									},
+								},
+							},
+						},
+					},
+				},
+				AzureMachine: &infrav1.AzureMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "machine",
+					},
+					Spec: infrav1.AzureMachineSpec{
+						ProviderID: ptr.To("azure:///subscriptions/1234-5678/resourceGroups/my-cluster/providers/Microsoft.Compute/virtualMachines/machine-name"),
+						NetworkInterfaces: []infrav1.NetworkInterface{{
+							SubnetName:       "subnet1",
+							PrivateIPConfigs: 1,
+						}},
+					},
+				},
+				Machine: &clusterv1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "machine",
+						Labels: map[string]string{
+							// clusterv1.MachineControlPlaneLabel: "true",
+						},
+					},
+				},
+				cache: &MachineCache{
+					VMSKU: resourceskus.SKU{
+						Name: ptr.To("Standard_D2v2"),
+					},
+				},
+			},
+			want: []azure.ResourceSpecGetter{
+				&networkinterfaces.NICSpec{
+					Name:                      "machine-name-nic",
+					ResourceGroup:             "my-rg",
+					Location:                  "westus",
+					SubscriptionID:            "123",
+					MachineName:               "machine-name",
+					SubnetName:                "subnet1",
+					IPConfigs:                 []networkinterfaces.IPConfig{{}},
+					VNetName:                  "vnet1",
+					VNetResourceGroup:         "rg1",
+					PublicLBName:              "outbound-lb",
+					PublicLBAddressPoolName:   "outbound-lb-outboundBackendPool",
+					PublicLBNATRuleName:       "",
+					InternalLBName:            "",
+					InternalLBAddressPoolName: "",
+					PublicIPName:              "",
+					AcceleratedNetworking:     nil,
+					DNSServers:                nil,
+					IPv6Enabled:               false,
+					EnableIPForwarding:        false,
+					SKU: &resourceskus.SKU{
+						Name: ptr.To("Standard_D2v2"),
+					},
+					ClusterName: "cluster",
+					AdditionalTags: infrav1.Tags{
+						"kubernetes.io_cluster_cluster": "owned",
+					},
+				},
+			},
+		},
+		{
+			name: "Node Machine with NAT gateway",
+			machineScope: MachineScope{
+				ClusterScoper: &ClusterScope{
+					AzureClients: AzureClients{
+						EnvironmentSettings: auth.EnvironmentSettings{
+							Values: map[string]string{
+								auth.SubscriptionID: "123",
+							},
+						},
+					},
+					Cluster: &clusterv1.Cluster{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "cluster",
+							Namespace: "default",
+						},
+					},
+					AzureCluster: &infrav1.AzureCluster{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "cluster",
+							Namespace: "default",
+							OwnerReferences: []metav1.OwnerReference{
+								{
+									APIVersion: "cluster.x-k8s.io/v1beta1",
+									Kind:       "Cluster",
+									Name:       "cluster",
+								},
+							},
+						},
+						Spec: infrav1.AzureClusterSpec{
+							ResourceGroup: "my-rg",
+							AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+								Location: "westus",
+							},
+							NetworkSpec: infrav1.NetworkSpec{
+								Vnet: infrav1.VnetSpec{
+									Name:          "vnet1",
+									ResourceGroup: "rg1",
+								},
+								Subnets: []infrav1.SubnetSpec{
+									{
+										SubnetClassSpec: infrav1.SubnetClassSpec{
+											Role: infrav1.SubnetNode,
+											Name: "subnet1",
+										},
+										NatGateway: infrav1.NatGateway{
+											NatGatewayClassSpec: infrav1.NatGatewayClassSpec{
+												Name: "natgw",
+											},
+										},
+									},
+								},
 								NodeOutboundLB: &infrav1.LoadBalancerSpec{
 									Name: "outbound-lb",
 								},
@@ -2081,7 +2885,111 @@ func TestMachineScope_NICSpecs(t *testing.T) {
 							SubnetName:       "subnet1",
 							PrivateIPConfigs: 1,
 						}},
-						AllocatePublicIP: true,
+					},
+				},
+				Machine: &clusterv1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "machine",
+						Labels: map[string]string{
+							// clusterv1.MachineControlPlaneLabel: "true",
+						},
+					},
+				},
+			},
+			want: []azure.ResourceSpecGetter{
+				&networkinterfaces.NICSpec{
+					Name:                      "machine-name-nic",
+					ResourceGroup:             "my-rg",
+					Location:                  "westus",
+					SubscriptionID:            "123",
+					MachineName:               "machine-name",
+					SubnetName:                "subnet1",
+					IPConfigs:                 []networkinterfaces.IPConfig{{}},
+					VNetName:                  "vnet1",
+					VNetResourceGroup:         "rg1",
+					PublicLBName:              "",
+					PublicLBAddressPoolName:   "",
+					PublicLBNATRuleName:       "",
+					InternalLBName:            "",
+					InternalLBAddressPoolName: "",
+					PublicIPName:              "",
+					AcceleratedNetworking:     nil,
+					DNSServers:                nil,
+					IPv6Enabled:               false,
+					EnableIPForwarding:        false,
+					SKU:                       nil,
+					ClusterName:               "cluster",
+					AdditionalTags: infrav1.Tags{
+						"kubernetes.io_cluster_cluster": "owned",
+					},
+				},
+			},
+		},
+		{
+			name: "Node Machine with public IP address",
+			machineScope: MachineScope{
+				ClusterScoper: &ClusterScope{
+					AzureClients: AzureClients{
+						EnvironmentSettings: auth.EnvironmentSettings{
+							Values: map[string]string{
+								auth.SubscriptionID: "123",
+							},
+						},
+					},
+					Cluster: &clusterv1.Cluster{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "cluster",
+							Namespace: "default",
+						},
+					},
+					AzureCluster: &infrav1.AzureCluster{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "cluster",
+							Namespace: "default",
+							OwnerReferences: []metav1.OwnerReference{
+								{
+									APIVersion: "cluster.x-k8s.io/v1beta1",
+									Kind:       "Cluster",
+									Name:       "cluster",
+								},
+							},
+						},
+						Spec: infrav1.AzureClusterSpec{
+							ResourceGroup: "my-rg",
+							AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+								Location: "westus",
+							},
+							NetworkSpec: infrav1.NetworkSpec{
+								Vnet: infrav1.VnetSpec{
+									Name:          "vnet1",
+									ResourceGroup: "rg1",
+								},
+								Subnets: []infrav1.SubnetSpec{
+									{
+										SubnetClassSpec: infrav1.SubnetClassSpec{
+											Role: infrav1.SubnetNode,
+											Name: "subnet1",
+										},
+									},
+								},
+								NodeOutboundLB: &infrav1.LoadBalancerSpec{
+									Name: "outbound-lb",
+								},
+							},
+						},
+					},
+				},
+				AzureMachine: &infrav1.AzureMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "machine",
+					},
+					Spec: infrav1.AzureMachineSpec{
+						ProviderID: ptr.To("azure:///subscriptions/1234-5678/resourceGroups/my-cluster/providers/Microsoft.Compute/virtualMachines/machine-name"),
+						NetworkInterfaces: []infrav1.NetworkInterface{{
+							SubnetName:       "subnet1",
+							PrivateIPConfigs: 1,
+						}},
+						AllocatePublicIP: true,
 					},
 				},
 				Machine: &clusterv1.Machine{
@@ -2987,7 +3895,9 @@ func TestDiskSpecs(t *testing.T) {
 								NameSuffix: "etcddisk",
 							},
 							{
-								NameSuffix: "otherdisk",
+								NameSuffix:        "otherdisk",
+								DiskIOPSReadWrite: ptr.To[int64](5000),
+								DiskMBpsReadWrite: ptr.To[int64](200),
 							},
 						},
 					},
@@ -3008,7 +3918,236 @@ func TestDiskSpecs(t *testing.T) {
 					ResourceGroup: "my-rg",
 				},
 				&disks.DiskSpec{
-					Name:          "my-azure-machine_otherdisk",
+					Name:              "my-azure-machine_otherdisk",
+					ResourceGroup:     "my-rg",
+					DiskIOPSReadWrite: ptr.To[int64](5000),
+					DiskMBpsReadWrite: ptr.To[int64](200),
+				},
+			},
+		},
+		{
+			name: "os and shared data disks",
+			machineScope: MachineScope{
+				ClusterScoper: &ClusterScope{
+					Cluster: &clusterv1.Cluster{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "cluster",
+						},
+					},
+					AzureCluster: &infrav1.AzureCluster{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "cluster",
+						},
+						Spec: infrav1.AzureClusterSpec{
+							ResourceGroup: "my-rg",
+							AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+								Location: "westus",
+								AdditionalTags: infrav1.Tags{
+									"costcenter": "cluster",
+								},
+							},
+						},
+					},
+				},
+				AzureMachine: &infrav1.AzureMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "my-azure-machine",
+					},
+					Spec: infrav1.AzureMachineSpec{
+						OSDisk: infrav1.OSDisk{
+							DiskSizeGB: ptr.To[int32](30),
+							OSType:     "Linux",
+						},
+						DataDisks: []infrav1.DataDisk{
+							{
+								NameSuffix: "shared",
+								DiskSizeGB: 256,
+								ManagedDisk: &infrav1.ManagedDiskParameters{
+									StorageAccountType: "Premium_LRS",
+								},
+								MaxShares: ptr.To[int32](3),
+							},
+							{
+								NameSuffix: "zrsshared",
+								DiskSizeGB: 256,
+								ManagedDisk: &infrav1.ManagedDiskParameters{
+									StorageAccountType: "Premium_ZRS",
+									DiskEncryptionSet: &infrav1.DiskEncryptionSetParameters{
+										ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/diskEncryptionSets/my-des",
+									},
+								},
+								MaxShares: ptr.To[int32](3),
+							},
+						},
+					},
+				},
+				Machine: &clusterv1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "machine",
+					},
+					Spec: clusterv1.MachineSpec{
+						FailureDomain: ptr.To("1"),
+					},
+				},
+			},
+			want: []azure.ResourceSpecGetter{
+				&disks.DiskSpec{
+					Name:          "my-azure-machine_OSDisk",
+					ResourceGroup: "my-rg",
+				},
+				&disks.DiskSpec{
+					Name:               "cluster_shared",
+					ResourceGroup:      "my-rg",
+					Location:           "westus",
+					Zone:               "1",
+					ClusterName:        "cluster",
+					DiskSizeGB:         256,
+					StorageAccountType: "Premium_LRS",
+					MaxShares:          ptr.To[int32](3),
+					AdditionalTags:     infrav1.Tags{"costcenter": "cluster"},
+				},
+				&disks.DiskSpec{
+					Name:                "cluster_zrsshared",
+					ResourceGroup:       "my-rg",
+					Location:            "westus",
+					ClusterName:         "cluster",
+					DiskSizeGB:          256,
+					StorageAccountType:  "Premium_ZRS",
+					MaxShares:           ptr.To[int32](3),
+					AdditionalTags:      infrav1.Tags{"costcenter": "cluster"},
+					DiskEncryptionSetID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/diskEncryptionSets/my-des",
+				},
+			},
+		},
+		{
+			name: "os and logical sector size data disks",
+			machineScope: MachineScope{
+				ClusterScoper: &ClusterScope{
+					Cluster: &clusterv1.Cluster{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "cluster",
+						},
+					},
+					AzureCluster: &infrav1.AzureCluster{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "cluster",
+						},
+						Spec: infrav1.AzureClusterSpec{
+							ResourceGroup: "my-rg",
+							AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+								Location: "westus",
+								AdditionalTags: infrav1.Tags{
+									"costcenter": "cluster",
+								},
+							},
+						},
+					},
+				},
+				AzureMachine: &infrav1.AzureMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "my-azure-machine",
+					},
+					Spec: infrav1.AzureMachineSpec{
+						OSDisk: infrav1.OSDisk{
+							DiskSizeGB: ptr.To[int32](30),
+							OSType:     "Linux",
+						},
+						DataDisks: []infrav1.DataDisk{
+							{
+								NameSuffix: "sectors",
+								DiskSizeGB: 128,
+								ManagedDisk: &infrav1.ManagedDiskParameters{
+									StorageAccountType: "PremiumV2_LRS",
+									LogicalSectorSize:  ptr.To[int32](512),
+								},
+								CachingType: "None",
+							},
+						},
+						AdditionalTags: infrav1.Tags{
+							"team": "storage",
+						},
+					},
+				},
+				Machine: &clusterv1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "machine",
+					},
+					Spec: clusterv1.MachineSpec{
+						FailureDomain: ptr.To("2"),
+					},
+				},
+			},
+			want: []azure.ResourceSpecGetter{
+				&disks.DiskSpec{
+					Name:          "my-azure-machine_OSDisk",
+					ResourceGroup: "my-rg",
+				},
+				&disks.DiskSpec{
+					Name:               "my-azure-machine_sectors",
+					ResourceGroup:      "my-rg",
+					Location:           "westus",
+					Zone:               "2",
+					ClusterName:        "cluster",
+					DiskSizeGB:         128,
+					StorageAccountType: "PremiumV2_LRS",
+					LogicalSectorSize:  ptr.To[int32](512),
+					AdditionalTags:     infrav1.Tags{"costcenter": "cluster", "team": "storage", "kubernetes.io_cluster_cluster": "owned"},
+				},
+			},
+		},
+		{
+			name: "existing data disks are not managed",
+			machineScope: MachineScope{
+				ClusterScoper: &ClusterScope{
+					Cluster: &clusterv1.Cluster{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "cluster",
+						},
+					},
+					AzureCluster: &infrav1.AzureCluster{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "cluster",
+						},
+						Spec: infrav1.AzureClusterSpec{
+							ResourceGroup: "my-rg",
+						},
+					},
+				},
+				AzureMachine: &infrav1.AzureMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "my-azure-machine",
+					},
+					Spec: infrav1.AzureMachineSpec{
+						OSDisk: infrav1.OSDisk{
+							DiskSizeGB: ptr.To[int32](30),
+							OSType:     "Linux",
+						},
+						DataDisks: []infrav1.DataDisk{
+							{
+								NameSuffix: "existing",
+								AttachExistingDisk: &infrav1.AttachExistingDisk{
+									ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/my-existing-disk",
+								},
+							},
+							{
+								NameSuffix: "etcddisk",
+							},
+						},
+					},
+				},
+				Machine: &clusterv1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "machine",
+					},
+				},
+			},
+			want: []azure.ResourceSpecGetter{
+				&disks.DiskSpec{
+					Name:          "my-azure-machine_OSDisk",
+					ResourceGroup: "my-rg",
+				},
+				&disks.DiskSpec{
+					Name:          "my-azure-machine_etcddisk",
 					ResourceGroup: "my-rg",
 				},
 			},
@@ -3026,3 +4165,279 @@ func TestDiskSpecs(t *testing.T) {
 		})
 	}
 }
+
+func TestMachineScope_TagsSpecs(t *testing.T) {
+	newMachineScope := func(osDisk infrav1.OSDisk) MachineScope {
+		return MachineScope{
+			ClusterScoper: &ClusterScope{
+				AzureClients: AzureClients{
+					EnvironmentSettings: auth.EnvironmentSettings{
+						Values: map[string]string{
+							auth.SubscriptionID: "123",
+						},
+					},
+				},
+				Cluster: &clusterv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cluster",
+					},
+				},
+				AzureCluster: &infrav1.AzureCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cluster",
+					},
+					Spec: infrav1.AzureClusterSpec{
+						ResourceGroup: "my-rg",
+						AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+							AdditionalTags: infrav1.Tags{
+								"costcenter": "cluster",
+							},
+						},
+						NetworkSpec: infrav1.NetworkSpec{
+							Vnet: infrav1.VnetSpec{
+								Name: "vnet1",
+							},
+							Subnets: []infrav1.SubnetSpec{
+								{
+									SubnetClassSpec: infrav1.SubnetClassSpec{
+										Role: infrav1.SubnetNode,
+										Name: "subnet1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			AzureMachine: &infrav1.AzureMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "machine",
+				},
+				Spec: infrav1.AzureMachineSpec{
+					OSDisk: osDisk,
+					DataDisks: []infrav1.DataDisk{
+						{
+							NameSuffix: "etcddisk",
+						},
+					},
+					AdditionalTags: infrav1.Tags{
+						"costcenter": "machine",
+					},
+					NetworkInterfaces: []infrav1.NetworkInterface{{
+						SubnetName: "subnet1",
+					}},
+				},
+			},
+			Machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "machine",
+				},
+			},
+		}
+	}
+	wantTags := infrav1.Tags{
+		"costcenter":                    "machine",
+		"kubernetes.io_cluster_cluster": "owned",
+	}
+
+	tests := []struct {
+		name         string
+		machineScope MachineScope
+		want         []azure.TagsSpec
+	}{
+		{
+			name:         "tags are propagated to the VM, its network interfaces and its disks",
+			machineScope: newMachineScope(infrav1.OSDisk{OSType: "Linux"}),
+			want: []azure.TagsSpec{
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/machine",
+					Tags:       wantTags,
+					Annotation: azure.VMTagsLastAppliedAnnotation,
+				},
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkInterfaces/machine-nic",
+					Tags:       wantTags,
+					Annotation: azure.NICTagsLastAppliedAnnotation,
+				},
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/machine_OSDisk",
+					Tags:       wantTags,
+					Annotation: azure.DiskTagsLastAppliedAnnotation,
+				},
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/machine_etcddisk",
+					Tags:       wantTags,
+					Annotation: azure.DiskTagsLastAppliedAnnotation,
+				},
+			},
+		},
+		{
+			name: "ephemeral OS disk is not tagged",
+			machineScope: newMachineScope(infrav1.OSDisk{
+				OSType: "Linux",
+				DiffDiskSettings: &infrav1.DiffDiskSettings{
+					Option: "Local",
+				},
+			}),
+			want: []azure.TagsSpec{
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/machine",
+					Tags:       wantTags,
+					Annotation: azure.VMTagsLastAppliedAnnotation,
+				},
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkInterfaces/machine-nic",
+					Tags:       wantTags,
+					Annotation: azure.NICTagsLastAppliedAnnotation,
+				},
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/machine_etcddisk",
+					Tags:       wantTags,
+					Annotation: azure.DiskTagsLastAppliedAnnotation,
+				},
+			},
+		},
+		{
+			name: "public IP is tagged",
+			machineScope: func() MachineScope {
+				m := newMachineScope(infrav1.OSDisk{OSType: "Linux", DiffDiskSettings: &infrav1.DiffDiskSettings{Option: "Local"}})
+				m.AzureMachine.Spec.AllocatePublicIP = true
+				return m
+			}(),
+			want: []azure.TagsSpec{
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/machine",
+					Tags:       wantTags,
+					Annotation: azure.VMTagsLastAppliedAnnotation,
+				},
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkInterfaces/machine-nic",
+					Tags:       wantTags,
+					Annotation: azure.NICTagsLastAppliedAnnotation,
+				},
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/machine_etcddisk",
+					Tags:       wantTags,
+					Annotation: azure.DiskTagsLastAppliedAnnotation,
+				},
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/pip-machine",
+					Tags:       wantTags,
+					Annotation: azure.PublicIPTagsLastAppliedAnnotation,
+				},
+			},
+		},
+		{
+			name: "start/stop schedule tags only the VM",
+			machineScope: func() MachineScope {
+				m := newMachineScope(infrav1.OSDisk{OSType: "Linux", DiffDiskSettings: &infrav1.DiffDiskSettings{Option: "Local"}})
+				m.AzureMachine.Spec.StartStopSchedule = &infrav1.StartStopSchedule{Schedule: "10PM -> 6AM, Saturday, Sunday"}
+				return m
+			}(),
+			want: []azure.TagsSpec{
+				{
+					Scope: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/machine",
+					Tags: infrav1.Tags{
+						"costcenter":                    "machine",
+						"kubernetes.io_cluster_cluster": "owned",
+						"AutoShutdownSchedule":          "10PM -> 6AM, Saturday, Sunday",
+					},
+					Annotation: azure.VMTagsLastAppliedAnnotation,
+				},
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkInterfaces/machine-nic",
+					Tags:       wantTags,
+					Annotation: azure.NICTagsLastAppliedAnnotation,
+				},
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/machine_etcddisk",
+					Tags:       wantTags,
+					Annotation: azure.DiskTagsLastAppliedAnnotation,
+				},
+			},
+		},
+		{
+			name: "start/stop schedule with a custom tag name",
+			machineScope: func() MachineScope {
+				m := newMachineScope(infrav1.OSDisk{OSType: "Linux", DiffDiskSettings: &infrav1.DiffDiskSettings{Option: "Local"}})
+				m.AzureMachine.Spec.DataDisks = nil
+				m.AzureMachine.Spec.StartStopSchedule = &infrav1.StartStopSchedule{Schedule: "December 25", TagName: "StopSchedule"}
+				return m
+			}(),
+			want: []azure.TagsSpec{
+				{
+					Scope: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/machine",
+					Tags: infrav1.Tags{
+						"costcenter":                    "machine",
+						"kubernetes.io_cluster_cluster": "owned",
+						"StopSchedule":                  "December 25",
+					},
+					Annotation: azure.VMTagsLastAppliedAnnotation,
+				},
+				{
+					Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkInterfaces/machine-nic",
+					Tags:       wantTags,
+					Annotation: azure.NICTagsLastAppliedAnnotation,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			g.Expect(tt.machineScope.TagsSpecs()).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestDataDiskTierRequeueAfter(t *testing.T) {
+	now := time.Date(2023, time.June, 14, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		dataDisks []infrav1.DataDisk
+		want      time.Duration
+	}{
+		{
+			name:      "no performance tier schedule",
+			dataDisks: []infrav1.DataDisk{{NameSuffix: "disk1", DiskSizeGB: 128}},
+			want:      0,
+		},
+		{
+			name: "earliest change of performance tier",
+			dataDisks: []infrav1.DataDisk{
+				{
+					NameSuffix:              "disk1",
+					DiskSizeGB:              128,
+					PerformanceTierSchedule: &infrav1.DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "20:00", OffHoursEnd: "07:00"},
+				},
+				{
+					NameSuffix:              "disk2",
+					DiskSizeGB:              128,
+					PerformanceTierSchedule: &infrav1.DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "18:00", OffHoursEnd: "06:00"},
+				},
+			},
+			want: 6 * time.Hour,
+		},
+		{
+			name: "existing data disk is ignored",
+			dataDisks: []infrav1.DataDisk{
+				{
+					NameSuffix:              "disk1",
+					AttachExistingDisk:      &infrav1.AttachExistingDisk{ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/my-disk"},
+					PerformanceTierSchedule: &infrav1.DiskPerformanceTierSchedule{Tier: "P30", OffHoursTier: "P10", OffHoursStart: "18:00", OffHoursEnd: "06:00"},
+				},
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+			g.Expect(dataDiskTierRequeueAfter(tt.dataDisks, now)).To(Equal(tt.want))
+		})
+	}
+}
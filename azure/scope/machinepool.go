@@ -35,6 +35,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/roleassignments"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/scalesets"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualmachineimages"
 	infrav1exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1beta1"
 	azureutil "sigs.k8s.io/cluster-api-provider-azure/util/azure"
@@ -522,15 +523,11 @@ func (m *MachinePoolScope) SetFailureReason(v capierrors.MachineStatusError) {
 // AdditionalTags merges AdditionalTags from the scope's AzureCluster and AzureMachinePool. If the same key is present in both,
 // the value from AzureMachinePool takes precedence.
 func (m *MachinePoolScope) AdditionalTags() infrav1.Tags {
-	tags := make(infrav1.Tags)
-	// Start with the cluster-wide tags...
-	tags.Merge(m.ClusterScoper.AdditionalTags())
-	// ... and merge in the Machine Pool's
-	tags.Merge(m.AzureMachinePool.Spec.AdditionalTags)
+	additionalTags := tags.MergeAdditional(m.ClusterScoper.AdditionalTags(), m.AzureMachinePool.Spec.AdditionalTags)
 	// Set the cloud provider tag
-	tags[infrav1.ClusterAzureCloudProviderTagKey(m.ClusterName())] = string(infrav1.ResourceLifecycleOwned)
+	additionalTags[infrav1.ClusterAzureCloudProviderTagKey(m.ClusterName())] = string(infrav1.ResourceLifecycleOwned)
 
-	return tags
+	return additionalTags
 }
 
 // SetAnnotation sets a key value annotation on the AzureMachinePool.
@@ -696,6 +693,19 @@ func (m *MachinePoolScope) RoleAssignmentSpecs(principalID *string) []azure.Reso
 			RoleDefinitionID: m.SystemAssignedIdentityDefinitionID(),
 			PrincipalID:      principalID,
 		}
+		if role := m.AzureMachinePool.Spec.SystemAssignedIdentityRole; role != nil {
+			for _, additionalRole := range role.AdditionalRoles {
+				roles = append(roles, &roleassignments.RoleAssignmentSpec{
+					Name:             roleassignments.AdditionalRoleAssignmentName(role.Name, additionalRole.Scope, additionalRole.DefinitionID),
+					MachineName:      m.Name(),
+					ResourceGroup:    m.ResourceGroup(),
+					ResourceType:     azure.VirtualMachineScaleSet,
+					Scope:            additionalRole.Scope,
+					RoleDefinitionID: additionalRole.DefinitionID,
+					PrincipalID:      principalID,
+				})
+			}
+		}
 		return roles
 	}
 	return []azure.ResourceSpecGetter{}
@@ -731,7 +741,7 @@ func (m *MachinePoolScope) VMSSExtensionSpecs() []azure.ResourceSpecGetter {
 	}
 
 	cpuArchitectureType, _ := m.cache.VMSKU.GetCapability(resourceskus.CPUArchitectureType)
-	bootstrapExtensionSpec := azure.GetBootstrappingVMExtension(m.AzureMachinePool.Spec.Template.OSDisk.OSType, m.CloudEnvironment(), m.Name(), cpuArchitectureType)
+	bootstrapExtensionSpec := azure.GetBootstrappingVMExtension(m.AzureMachinePool.Spec.Template.OSDisk.OSType, m.CloudEnvironment(), m.Name(), cpuArchitectureType, nil)
 
 	if bootstrapExtensionSpec != nil {
 		extensionSpecs = append(extensionSpecs, &scalesets.VMSSExtensionSpec{
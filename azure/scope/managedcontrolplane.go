@@ -27,6 +27,7 @@ import (
 	"golang.org/x/mod/semver"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
@@ -34,6 +35,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/groups"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/managedclusters"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/privateendpoints"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/roleassignments"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/subnets"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualnetworks"
 	"sigs.k8s.io/cluster-api-provider-azure/util/futures"
@@ -74,7 +76,7 @@ func NewManagedControlPlaneScope(ctx context.Context, params ManagedControlPlane
 	}
 
 	if params.ControlPlane.Spec.IdentityRef == nil {
-		if err := params.AzureClients.setCredentials(params.ControlPlane.Spec.SubscriptionID, params.ControlPlane.Spec.AzureEnvironment); err != nil {
+		if err := params.AzureClients.setCredentials(params.ControlPlane.Spec.SubscriptionID, params.ControlPlane.Spec.AzureEnvironment, ""); err != nil {
 			return nil, errors.Wrap(err, "failed to create Azure session")
 		}
 	} else {
@@ -83,7 +85,7 @@ func NewManagedControlPlaneScope(ctx context.Context, params ManagedControlPlane
 			return nil, errors.Wrap(err, "failed to init credentials provider")
 		}
 
-		if err := params.AzureClients.setCredentialsWithProvider(ctx, params.ControlPlane.Spec.SubscriptionID, params.ControlPlane.Spec.AzureEnvironment, credentialsProvider); err != nil {
+		if err := params.AzureClients.setCredentialsWithProvider(ctx, params.ControlPlane.Spec.SubscriptionID, params.ControlPlane.Spec.AzureEnvironment, "", credentialsProvider); err != nil {
 			return nil, errors.Wrap(err, "failed to configure azure settings and credentials for Identity")
 		}
 	}
@@ -244,6 +246,11 @@ func (s *ManagedControlPlaneScope) Vnet() *infrav1.VnetSpec {
 	}
 }
 
+// ResourceGroupDeletionGuarded returns true if the resource group must not be deleted with the cluster.
+func (s *ManagedControlPlaneScope) ResourceGroupDeletionGuarded() bool {
+	return s.ControlPlane.GetAnnotations()[infrav1.DoNotDeleteAnnotation] == "true"
+}
+
 // GroupSpec returns the resource group spec.
 func (s *ManagedControlPlaneScope) GroupSpec() azure.ResourceSpecGetter {
 	return &groups.GroupSpec{
@@ -295,7 +302,7 @@ func (s *ManagedControlPlaneScope) NodeNatGateway() infrav1.NatGateway {
 
 // SubnetSpecs returns the subnets specs.
 func (s *ManagedControlPlaneScope) SubnetSpecs() []azure.ResourceSpecGetter {
-	return []azure.ResourceSpecGetter{
+	subnetSpecs := []azure.ResourceSpecGetter{
 		&subnets.SubnetSpec{
 			Name:              s.NodeSubnet().Name,
 			ResourceGroup:     s.ResourceGroup(),
@@ -308,6 +315,22 @@ func (s *ManagedControlPlaneScope) SubnetSpecs() []azure.ResourceSpecGetter {
 			ServiceEndpoints:  s.NodeSubnet().ServiceEndpoints,
 		},
 	}
+
+	// The dedicated virtual nodes subnet is only created when a CIDR block is provided.
+	if virtualNodes := s.ControlPlane.Spec.VirtualNodes; virtualNodes != nil && virtualNodes.CIDRBlock != "" {
+		subnetSpecs = append(subnetSpecs, &subnets.SubnetSpec{
+			Name:              virtualNodes.SubnetName,
+			ResourceGroup:     s.ResourceGroup(),
+			SubscriptionID:    s.SubscriptionID(),
+			CIDRs:             []string{virtualNodes.CIDRBlock},
+			VNetName:          s.Vnet().Name,
+			VNetResourceGroup: s.Vnet().ResourceGroup,
+			IsVNetManaged:     s.IsVnetManaged(),
+			Role:              infrav1.SubnetNode,
+		})
+	}
+
+	return subnetSpecs
 }
 
 // Subnets returns the subnets specs.
@@ -433,6 +456,12 @@ func (s *ManagedControlPlaneScope) OutboundPoolName(_ string) string {
 	return "aksOutboundBackendPool" // hard-coded in aks
 }
 
+// OutboundAdditionalPoolNames returns the names of the additional backend pools of the outbound LB.
+// Note: for managed clusters, the outbound LB lifecycle is not managed.
+func (s *ManagedControlPlaneScope) OutboundAdditionalPoolNames(_ string) []string {
+	return nil
+}
+
 // GetPrivateDNSZoneName returns the Private DNS Zone from the spec or generate it from cluster name.
 // Currently always empty as managed control planes do not currently implement private clusters.
 func (s *ManagedControlPlaneScope) GetPrivateDNSZoneName() string {
@@ -507,6 +536,7 @@ func (s *ManagedControlPlaneScope) ManagedClusterSpec() azure.ResourceSpecGetter
 			AdminGroupObjectIDs: s.ControlPlane.Spec.AADProfile.AdminGroupObjectIDs,
 		}
 	}
+	managedClusterSpec.DisableLocalAccounts = s.ControlPlane.Spec.DisableLocalAccounts
 
 	if s.ControlPlane.Spec.AddonProfiles != nil {
 		for _, profile := range s.ControlPlane.Spec.AddonProfiles {
@@ -518,6 +548,20 @@ func (s *ManagedControlPlaneScope) ManagedClusterSpec() azure.ResourceSpecGetter
 		}
 	}
 
+	if s.ControlPlane.Spec.VirtualNodes != nil {
+		managedClusterSpec.VirtualNodes = &managedclusters.VirtualNodes{
+			Enabled:    s.ControlPlane.Spec.VirtualNodes.Enabled,
+			SubnetName: s.ControlPlane.Spec.VirtualNodes.SubnetName,
+		}
+	}
+
+	if s.ControlPlane.Spec.KeyVaultSecretsProvider != nil {
+		managedClusterSpec.KeyVaultSecretsProvider = &managedclusters.KeyVaultSecretsProvider{
+			Enabled:              s.ControlPlane.Spec.KeyVaultSecretsProvider.Enabled,
+			EnableSecretRotation: s.ControlPlane.Spec.KeyVaultSecretsProvider.EnableSecretRotation,
+		}
+	}
+
 	if s.ControlPlane.Spec.SKU != nil {
 		managedClusterSpec.SKU = &managedclusters.SKU{
 			Tier: string(s.ControlPlane.Spec.SKU.Tier),
@@ -541,6 +585,12 @@ func (s *ManagedControlPlaneScope) ManagedClusterSpec() azure.ResourceSpecGetter
 			PrivateDNSZone:                 s.ControlPlane.Spec.APIServerAccessProfile.PrivateDNSZone,
 			EnablePrivateClusterPublicFQDN: s.ControlPlane.Spec.APIServerAccessProfile.EnablePrivateClusterPublicFQDN,
 		}
+		if ref := s.ControlPlane.Spec.APIServerAccessProfile.AuthorizedIPRangesConfigMapRef; ref != nil {
+			managedClusterSpec.APIServerAccessProfile.AuthorizedIPRangesConfigMap = &types.NamespacedName{
+				Namespace: s.ControlPlane.Namespace,
+				Name:      ref.Name,
+			}
+		}
 	}
 
 	if s.ControlPlane.Spec.AutoScalerProfile != nil {
@@ -633,6 +683,27 @@ func (s *ManagedControlPlaneScope) SetKubeletIdentity(id string) {
 	s.ControlPlane.Spec.KubeletUserAssignedIdentity = id
 }
 
+// SetKeyVaultSecretsProviderIdentity sets the ID of the user-assigned identity AKS created for the Key Vault secrets
+// provider add-on.
+func (s *ManagedControlPlaneScope) SetKeyVaultSecretsProviderIdentity(id string) {
+	s.ControlPlane.Status.KeyVaultSecretsProviderIdentity = id
+}
+
+// ControlPlaneVersion returns the Kubernetes version of the AKS control plane as last reported by AKS.
+func (s *ManagedControlPlaneScope) ControlPlaneVersion() string {
+	return s.ControlPlane.Status.Version
+}
+
+// SetControlPlaneVersion sets the Kubernetes version of the AKS control plane as reported by AKS.
+func (s *ManagedControlPlaneScope) SetControlPlaneVersion(version string) {
+	s.ControlPlane.Status.Version = version
+}
+
+// SetConditionFalse sets the specified AzureManagedControlPlane condition to false.
+func (s *ManagedControlPlaneScope) SetConditionFalse(conditionType clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, message string) {
+	conditions.MarkFalse(s.ControlPlane, conditionType, reason, severity, "%s", message)
+}
+
 // SetLongRunningOperationState will set the future on the AzureManagedControlPlane status to allow the resource to continue
 // in the next reconciliation.
 func (s *ManagedControlPlaneScope) SetLongRunningOperationState(future *infrav1.Future) {
@@ -756,6 +827,68 @@ func (s *ManagedControlPlaneScope) AvailabilityStatusFilter(cond *clusterv1.Cond
 	return cond
 }
 
+// Name returns the name of the managed control plane.
+func (s *ManagedControlPlaneScope) Name() string {
+	return s.ControlPlane.Name
+}
+
+// HasSystemAssignedIdentity returns true if the managed cluster has a system assigned identity.
+func (s *ManagedControlPlaneScope) HasSystemAssignedIdentity() bool {
+	identity := s.ControlPlane.Spec.Identity
+	return identity == nil || identity.Type == infrav1.ManagedControlPlaneIdentityTypeSystemAssigned
+}
+
+// RoleAssignmentResourceType returns the role assignment resource type.
+func (s *ManagedControlPlaneScope) RoleAssignmentResourceType() string {
+	return azure.ManagedCluster
+}
+
+// RoleAssignmentIdentityID returns the ID of the user-assigned identity to assign a role on the node resource
+// group to, or an empty string if no role assignment is configured.
+func (s *ManagedControlPlaneScope) RoleAssignmentIdentityID() string {
+	identity := s.ControlPlane.Spec.Identity
+	if identity == nil || identity.Type != infrav1.ManagedControlPlaneIdentityTypeUserAssigned || identity.NodeResourceGroupRoleAssignment == nil {
+		return ""
+	}
+	return identity.UserAssignedIdentityResourceID
+}
+
+// RoleAssignmentSpecs returns the role assignment specs. The principal ID is the one of the control plane identity,
+// the role assignment to the identity of the Key Vault secrets provider is resolved from the identity ID. Without a
+// user-assigned identity set for it, the Key Vault role is assigned to the identity AKS created for the add-on once
+// AKS reports it.
+func (s *ManagedControlPlaneScope) RoleAssignmentSpecs(principalID *string) []azure.ResourceSpecGetter {
+	specs := []azure.ResourceSpecGetter{}
+	if s.RoleAssignmentIdentityID() != "" {
+		roleAssignment := s.ControlPlane.Spec.Identity.NodeResourceGroupRoleAssignment
+		specs = append(specs, &roleassignments.RoleAssignmentSpec{
+			Name:             roleAssignment.Name,
+			ResourceGroup:    s.NodeResourceGroup(),
+			ResourceType:     azure.ManagedCluster,
+			Scope:            azure.ResourceGroupID(s.SubscriptionID(), s.NodeResourceGroup()),
+			RoleDefinitionID: roleAssignment.DefinitionID,
+			PrincipalID:      principalID,
+		})
+	}
+	if provider := s.ControlPlane.Spec.KeyVaultSecretsProvider; provider != nil && provider.KeyVaultRoleAssignment != nil {
+		identityID := provider.UserAssignedIdentityResourceID
+		if identityID == "" {
+			identityID = s.ControlPlane.Status.KeyVaultSecretsProviderIdentity
+		}
+		if identityID != "" {
+			specs = append(specs, &roleassignments.RoleAssignmentSpec{
+				Name:             provider.KeyVaultRoleAssignment.Name,
+				ResourceGroup:    s.ResourceGroup(),
+				ResourceType:     azure.ManagedCluster,
+				Scope:            provider.KeyVaultRoleAssignment.KeyVaultResourceID,
+				RoleDefinitionID: provider.KeyVaultRoleAssignment.DefinitionID,
+				IdentityID:       identityID,
+			})
+		}
+	}
+	return specs
+}
+
 // PrivateEndpointSpecs returns the private endpoint specs.
 func (s *ManagedControlPlaneScope) PrivateEndpointSpecs() []azure.ResourceSpecGetter {
 	privateEndpointSpecs := make([]azure.ResourceSpecGetter, len(s.ControlPlane.Spec.VirtualNetwork.Subnet.PrivateEndpoints))
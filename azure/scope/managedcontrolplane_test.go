@@ -22,13 +22,16 @@ import (
 
 	"github.com/Azure/go-autorest/autorest"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/agentpools"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/managedclusters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/roleassignments"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -332,6 +335,83 @@ func TestManagedControlPlaneScope_AddonProfiles(t *testing.T) {
 	}
 }
 
+func TestManagedControlPlaneScope_APIServerAccessProfile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = expv1.AddToScheme(scheme)
+	_ = infrav1.AddToScheme(scheme)
+
+	cases := []struct {
+		Name     string
+		Profile  *infrav1.APIServerAccessProfile
+		Expected *managedclusters.APIServerAccessProfile
+	}{
+		{
+			Name:     "Without an API server access profile",
+			Profile:  nil,
+			Expected: nil,
+		},
+		{
+			Name: "With authorized IP ranges",
+			Profile: &infrav1.APIServerAccessProfile{
+				AuthorizedIPRanges: []string{"1.2.3.4/32"},
+			},
+			Expected: &managedclusters.APIServerAccessProfile{
+				AuthorizedIPRanges: []string{"1.2.3.4/32"},
+			},
+		},
+		{
+			Name: "With authorized IP ranges from a ConfigMap",
+			Profile: &infrav1.APIServerAccessProfile{
+				AuthorizedIPRanges:             []string{"1.2.3.4/32"},
+				AuthorizedIPRangesConfigMapRef: &corev1.LocalObjectReference{Name: "authorized-ip-ranges"},
+			},
+			Expected: &managedclusters.APIServerAccessProfile{
+				AuthorizedIPRanges:          []string{"1.2.3.4/32"},
+				AuthorizedIPRangesConfigMap: &types.NamespacedName{Namespace: "default", Name: "authorized-ip-ranges"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			g := NewWithT(t)
+			input := ManagedControlPlaneScopeParams{
+				AzureClients: AzureClients{
+					Authorizer: autorest.NullAuthorizer{},
+				},
+				Cluster: &clusterv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "cluster1",
+						Namespace: "default",
+					},
+				},
+				ControlPlane: &infrav1.AzureManagedControlPlane{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "cluster1",
+						Namespace: "default",
+					},
+					Spec: infrav1.AzureManagedControlPlaneSpec{
+						SubscriptionID:         "00000000-0000-0000-0000-000000000000",
+						APIServerAccessProfile: c.Profile,
+					},
+				},
+				ManagedMachinePools: []ManagedMachinePool{
+					{
+						MachinePool:      getMachinePool("pool0"),
+						InfraMachinePool: getAzureMachinePool("pool0", infrav1.NodePoolModeSystem),
+					},
+				},
+			}
+			input.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(input.ControlPlane).Build()
+			s, err := NewManagedControlPlaneScope(context.TODO(), input)
+			g.Expect(err).To(Succeed())
+			managedCluster := s.ManagedClusterSpec()
+			g.Expect(managedCluster.(*managedclusters.ManagedClusterSpec).APIServerAccessProfile).To(Equal(c.Expected))
+		})
+	}
+}
+
 func TestManagedControlPlaneScope_OSType(t *testing.T) {
 	scheme := runtime.NewScheme()
 	_ = expv1.AddToScheme(scheme)
@@ -603,3 +683,200 @@ func TestManagedControlPlaneScope_IsVnetManagedCache(t *testing.T) {
 		})
 	}
 }
+
+func TestManagedControlPlaneScope_RoleAssignmentSpecs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = expv1.AddToScheme(scheme)
+	_ = infrav1.AddToScheme(scheme)
+	identityID := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity"
+	definitionID := "/subscriptions/00000000-0000-0000-0000-000000000000/providers/Microsoft.Authorization/roleDefinitions/" + infrav1.ContributorRoleID
+	keyVaultID := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault"
+	keyVaultDefinitionID := "/subscriptions/00000000-0000-0000-0000-000000000000/providers/Microsoft.Authorization/roleDefinitions/" + infrav1.KeyVaultSecretsUserRoleID
+	addonIdentityID := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/MC_my-rg_cluster1_eastus/providers/Microsoft.ManagedIdentity/userAssignedIdentities/azurekeyvaultsecretsprovider-cluster1"
+	cases := []struct {
+		Name                    string
+		Identity                *infrav1.Identity
+		KeyVaultSecretsProvider *infrav1.KeyVaultSecretsProvider
+		AddonIdentityID         string
+		ExpectedIdentityID      string
+		Expected                []azure.ResourceSpecGetter
+	}{
+		{
+			Name:     "system-assigned identity",
+			Identity: &infrav1.Identity{Type: infrav1.ManagedControlPlaneIdentityTypeSystemAssigned},
+			Expected: []azure.ResourceSpecGetter{},
+		},
+		{
+			Name: "user-assigned identity without a node resource group role assignment",
+			Identity: &infrav1.Identity{
+				Type:                           infrav1.ManagedControlPlaneIdentityTypeUserAssigned,
+				UserAssignedIdentityResourceID: identityID,
+			},
+			Expected: []azure.ResourceSpecGetter{},
+		},
+		{
+			Name: "user-assigned identity with a node resource group role assignment",
+			Identity: &infrav1.Identity{
+				Type:                           infrav1.ManagedControlPlaneIdentityTypeUserAssigned,
+				UserAssignedIdentityResourceID: identityID,
+				NodeResourceGroupRoleAssignment: &infrav1.NodeResourceGroupRoleAssignment{
+					Name:         "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+					DefinitionID: definitionID,
+				},
+			},
+			ExpectedIdentityID: identityID,
+			Expected: []azure.ResourceSpecGetter{
+				&roleassignments.RoleAssignmentSpec{
+					Name:             "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+					ResourceGroup:    "MC_my-rg_cluster1_eastus",
+					ResourceType:     azure.ManagedCluster,
+					Scope:            "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/MC_my-rg_cluster1_eastus",
+					RoleDefinitionID: definitionID,
+					PrincipalID:      ptr.To("principal-id"),
+				},
+			},
+		},
+		{
+			Name:     "Key Vault secrets provider without a Key Vault role assignment",
+			Identity: &infrav1.Identity{Type: infrav1.ManagedControlPlaneIdentityTypeSystemAssigned},
+			KeyVaultSecretsProvider: &infrav1.KeyVaultSecretsProvider{
+				Enabled:                        true,
+				UserAssignedIdentityResourceID: identityID,
+			},
+			Expected: []azure.ResourceSpecGetter{},
+		},
+		{
+			Name:     "Key Vault secrets provider with a Key Vault role assignment",
+			Identity: &infrav1.Identity{Type: infrav1.ManagedControlPlaneIdentityTypeSystemAssigned},
+			KeyVaultSecretsProvider: &infrav1.KeyVaultSecretsProvider{
+				Enabled:                        true,
+				UserAssignedIdentityResourceID: identityID,
+				KeyVaultRoleAssignment: &infrav1.KeyVaultRoleAssignment{
+					Name:               "6f0c9a5e-3d4b-4c2a-9e8f-1b2c3d4e5f60",
+					KeyVaultResourceID: keyVaultID,
+					DefinitionID:       keyVaultDefinitionID,
+				},
+			},
+			Expected: []azure.ResourceSpecGetter{
+				&roleassignments.RoleAssignmentSpec{
+					Name:             "6f0c9a5e-3d4b-4c2a-9e8f-1b2c3d4e5f60",
+					ResourceGroup:    "my-rg",
+					ResourceType:     azure.ManagedCluster,
+					Scope:            keyVaultID,
+					RoleDefinitionID: keyVaultDefinitionID,
+					IdentityID:       identityID,
+				},
+			},
+		},
+		{
+			Name:     "Key Vault role assignment to the identity of the add-on",
+			Identity: &infrav1.Identity{Type: infrav1.ManagedControlPlaneIdentityTypeSystemAssigned},
+			KeyVaultSecretsProvider: &infrav1.KeyVaultSecretsProvider{
+				Enabled: true,
+				KeyVaultRoleAssignment: &infrav1.KeyVaultRoleAssignment{
+					Name:               "6f0c9a5e-3d4b-4c2a-9e8f-1b2c3d4e5f60",
+					KeyVaultResourceID: keyVaultID,
+					DefinitionID:       keyVaultDefinitionID,
+				},
+			},
+			AddonIdentityID: addonIdentityID,
+			Expected: []azure.ResourceSpecGetter{
+				&roleassignments.RoleAssignmentSpec{
+					Name:             "6f0c9a5e-3d4b-4c2a-9e8f-1b2c3d4e5f60",
+					ResourceGroup:    "my-rg",
+					ResourceType:     azure.ManagedCluster,
+					Scope:            keyVaultID,
+					RoleDefinitionID: keyVaultDefinitionID,
+					IdentityID:       addonIdentityID,
+				},
+			},
+		},
+		{
+			Name:     "Key Vault role assignment to the identity of the add-on not reported yet",
+			Identity: &infrav1.Identity{Type: infrav1.ManagedControlPlaneIdentityTypeSystemAssigned},
+			KeyVaultSecretsProvider: &infrav1.KeyVaultSecretsProvider{
+				Enabled: true,
+				KeyVaultRoleAssignment: &infrav1.KeyVaultRoleAssignment{
+					Name:               "6f0c9a5e-3d4b-4c2a-9e8f-1b2c3d4e5f60",
+					KeyVaultResourceID: keyVaultID,
+					DefinitionID:       keyVaultDefinitionID,
+				},
+			},
+			Expected: []azure.ResourceSpecGetter{},
+		},
+		{
+			Name: "node resource group and Key Vault role assignments",
+			Identity: &infrav1.Identity{
+				Type:                           infrav1.ManagedControlPlaneIdentityTypeUserAssigned,
+				UserAssignedIdentityResourceID: identityID,
+				NodeResourceGroupRoleAssignment: &infrav1.NodeResourceGroupRoleAssignment{
+					Name:         "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+					DefinitionID: definitionID,
+				},
+			},
+			KeyVaultSecretsProvider: &infrav1.KeyVaultSecretsProvider{
+				Enabled:                        true,
+				UserAssignedIdentityResourceID: identityID,
+				KeyVaultRoleAssignment: &infrav1.KeyVaultRoleAssignment{
+					Name:               "6f0c9a5e-3d4b-4c2a-9e8f-1b2c3d4e5f60",
+					KeyVaultResourceID: keyVaultID,
+					DefinitionID:       keyVaultDefinitionID,
+				},
+			},
+			ExpectedIdentityID: identityID,
+			Expected: []azure.ResourceSpecGetter{
+				&roleassignments.RoleAssignmentSpec{
+					Name:             "2a8ebc43-5b46-4d8c-a2cf-8e4e6b0e4a5c",
+					ResourceGroup:    "MC_my-rg_cluster1_eastus",
+					ResourceType:     azure.ManagedCluster,
+					Scope:            "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/MC_my-rg_cluster1_eastus",
+					RoleDefinitionID: definitionID,
+					PrincipalID:      ptr.To("principal-id"),
+				},
+				&roleassignments.RoleAssignmentSpec{
+					Name:             "6f0c9a5e-3d4b-4c2a-9e8f-1b2c3d4e5f60",
+					ResourceGroup:    "my-rg",
+					ResourceType:     azure.ManagedCluster,
+					Scope:            keyVaultID,
+					RoleDefinitionID: keyVaultDefinitionID,
+					IdentityID:       identityID,
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			g := NewWithT(t)
+			input := ManagedControlPlaneScopeParams{
+				Cluster: &clusterv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "cluster1",
+						Namespace: "default",
+					},
+				},
+				ControlPlane: &infrav1.AzureManagedControlPlane{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "cluster1",
+						Namespace: "default",
+					},
+					Spec: infrav1.AzureManagedControlPlaneSpec{
+						SubscriptionID:          "00000000-0000-0000-0000-000000000000",
+						ResourceGroupName:       "my-rg",
+						NodeResourceGroupName:   "MC_my-rg_cluster1_eastus",
+						Identity:                c.Identity,
+						KeyVaultSecretsProvider: c.KeyVaultSecretsProvider,
+					},
+					Status: infrav1.AzureManagedControlPlaneStatus{
+						KeyVaultSecretsProviderIdentity: c.AddonIdentityID,
+					},
+				},
+			}
+			input.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(input.ControlPlane).Build()
+			s, err := NewManagedControlPlaneScope(context.TODO(), input)
+			g.Expect(err).To(Succeed())
+			g.Expect(s.RoleAssignmentIdentityID()).To(Equal(c.ExpectedIdentityID))
+			g.Expect(s.RoleAssignmentSpecs(ptr.To("principal-id"))).To(Equal(c.Expected))
+		})
+	}
+}
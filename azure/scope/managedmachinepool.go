@@ -26,6 +26,7 @@ import (
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/agentpools"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 	"sigs.k8s.io/cluster-api-provider-azure/util/futures"
 	"sigs.k8s.io/cluster-api-provider-azure/util/maps"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
@@ -166,35 +167,49 @@ func buildAgentPoolSpec(managedControlPlane *infrav1.AzureManagedControlPlane,
 		replicas = *machinePool.Spec.Replicas
 	}
 
+	var controlPlaneVersion *string
+	if managedControlPlane.Status.Version != "" {
+		v := strings.TrimPrefix(managedControlPlane.Status.Version, "v")
+		controlPlaneVersion = &v
+	}
+
 	agentPoolSpec := &agentpools.AgentPoolSpec{
-		Name:          ptr.Deref(managedMachinePool.Spec.Name, ""),
-		ResourceGroup: managedControlPlane.Spec.ResourceGroupName,
-		Cluster:       managedControlPlane.Name,
-		SKU:           managedMachinePool.Spec.SKU,
-		Replicas:      replicas,
-		Version:       normalizedVersion,
-		OSType:        managedMachinePool.Spec.OSType,
+		Name:                ptr.Deref(managedMachinePool.Spec.Name, ""),
+		ResourceGroup:       managedControlPlane.Spec.ResourceGroupName,
+		Cluster:             managedControlPlane.Name,
+		SKU:                 managedMachinePool.Spec.SKU,
+		Replicas:            replicas,
+		Version:             normalizedVersion,
+		ControlPlaneVersion: controlPlaneVersion,
+		OSType:              managedMachinePool.Spec.OSType,
 		VnetSubnetID: azure.SubnetID(
 			managedControlPlane.Spec.SubscriptionID,
 			managedControlPlane.Spec.VirtualNetwork.ResourceGroup,
 			managedControlPlane.Spec.VirtualNetwork.Name,
 			ptr.Deref(getAgentPoolSubnet(managedControlPlane, managedMachinePool), ""),
 		),
-		Mode:                 managedMachinePool.Spec.Mode,
-		MaxPods:              managedMachinePool.Spec.MaxPods,
-		AvailabilityZones:    managedMachinePool.Spec.AvailabilityZones,
-		OsDiskType:           managedMachinePool.Spec.OsDiskType,
-		EnableUltraSSD:       managedMachinePool.Spec.EnableUltraSSD,
-		Headers:              maps.FilterByKeyPrefix(agentPoolAnnotations, infrav1.CustomHeaderPrefix),
-		EnableNodePublicIP:   managedMachinePool.Spec.EnableNodePublicIP,
-		NodePublicIPPrefixID: managedMachinePool.Spec.NodePublicIPPrefixID,
-		ScaleSetPriority:     managedMachinePool.Spec.ScaleSetPriority,
-		ScaleDownMode:        managedMachinePool.Spec.ScaleDownMode,
-		SpotMaxPrice:         managedMachinePool.Spec.SpotMaxPrice,
-		AdditionalTags:       managedMachinePool.Spec.AdditionalTags,
-		KubeletDiskType:      managedMachinePool.Spec.KubeletDiskType,
-		LinuxOSConfig:        managedMachinePool.Spec.LinuxOSConfig,
-		EnableFIPS:           managedMachinePool.Spec.EnableFIPS,
+		Mode:                  managedMachinePool.Spec.Mode,
+		MaxPods:               managedMachinePool.Spec.MaxPods,
+		AvailabilityZones:     managedMachinePool.Spec.AvailabilityZones,
+		OsDiskType:            managedMachinePool.Spec.OsDiskType,
+		EnableUltraSSD:        managedMachinePool.Spec.EnableUltraSSD,
+		Headers:               maps.FilterByKeyPrefix(agentPoolAnnotations, infrav1.CustomHeaderPrefix),
+		EnableNodePublicIP:    managedMachinePool.Spec.EnableNodePublicIP,
+		NodePublicIPPrefixID:  managedMachinePool.Spec.NodePublicIPPrefixID,
+		ScaleSetPriority:      managedMachinePool.Spec.ScaleSetPriority,
+		ScaleDownMode:         managedMachinePool.Spec.ScaleDownMode,
+		SpotMaxPrice:          managedMachinePool.Spec.SpotMaxPrice,
+		AdditionalTags:        managedMachinePool.Spec.AdditionalTags,
+		KubeletDiskType:       managedMachinePool.Spec.KubeletDiskType,
+		LinuxOSConfig:         managedMachinePool.Spec.LinuxOSConfig,
+		EnableFIPS:            managedMachinePool.Spec.EnableFIPS,
+		NodeImageVersion:      managedMachinePool.Spec.NodeImageVersion,
+		DrainTimeoutInMinutes: managedMachinePool.Spec.DrainTimeoutInMinutes,
+	}
+
+	// Tags of the control plane cascade to its agent pools, tags set on the pool take precedence.
+	if len(managedControlPlane.Spec.AdditionalTags) > 0 {
+		agentPoolSpec.AdditionalTags = tags.MergeAdditional(managedControlPlane.Spec.AdditionalTags, managedMachinePool.Spec.AdditionalTags)
 	}
 
 	if managedMachinePool.Spec.OSDiskSizeGB != nil {
@@ -258,6 +273,21 @@ func (s *ManagedMachinePoolScope) SetAgentPoolReady(ready bool) {
 	s.InfraMachinePool.Status.Ready = ready
 }
 
+// SetConditionFalse sets the specified AzureManagedMachinePool condition to false.
+func (s *ManagedMachinePoolScope) SetConditionFalse(conditionType clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, message string) {
+	conditions.MarkFalse(s.InfraMachinePool, conditionType, reason, severity, "%s", message)
+}
+
+// SetConditionTrue sets a condition to true on the AzureManagedMachinePool.
+func (s *ManagedMachinePoolScope) SetConditionTrue(conditionType clusterv1.ConditionType) {
+	conditions.MarkTrue(s.InfraMachinePool, conditionType)
+}
+
+// DeleteCondition deletes a condition from the AzureManagedMachinePool.
+func (s *ManagedMachinePoolScope) DeleteCondition(conditionType clusterv1.ConditionType) {
+	conditions.Delete(s.InfraMachinePool, conditionType)
+}
+
 // SetLongRunningOperationState will set the future on the AzureManagedMachinePool status to allow the resource to continue
 // in the next reconciliation.
 func (s *ManagedMachinePoolScope) SetLongRunningOperationState(future *infrav1.Future) {
@@ -313,6 +313,49 @@ func TestManagedMachinePoolScope_AdditionalTags(t *testing.T) {
 				Headers:      map[string]string{},
 			},
 		},
+		{
+			Name: "With additional tags on the control plane and the pool",
+			Input: ManagedMachinePoolScopeParams{
+				Cluster: &clusterv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "cluster1",
+						Namespace: "default",
+					},
+				},
+				ControlPlane: &infrav1.AzureManagedControlPlane{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "cluster1",
+						Namespace: "default",
+					},
+					Spec: infrav1.AzureManagedControlPlaneSpec{
+						SubscriptionID: "00000000-0000-0000-0000-000000000000",
+						AdditionalTags: map[string]string{
+							"environment": "staging",
+							"costcenter":  "platform",
+						},
+					},
+				},
+				ManagedMachinePool: ManagedMachinePool{
+					MachinePool: getMachinePool("pool1"),
+					InfraMachinePool: getAzureMachinePoolWithAdditionalTags("pool1", map[string]string{
+						"environment": "production",
+					}),
+				},
+			},
+			Expected: &agentpools.AgentPoolSpec{
+				Name:     "pool1",
+				SKU:      "Standard_D2s_v3",
+				Mode:     "System",
+				Cluster:  "cluster1",
+				Replicas: 1,
+				AdditionalTags: map[string]string{
+					"environment": "production",
+					"costcenter":  "platform",
+				},
+				VnetSubnetID: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups//providers/Microsoft.Network/virtualNetworks//subnets/",
+				Headers:      map[string]string{},
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+// lockedResourceKey returns the key of a resource in the LockedResourcesAnnotation of an object.
+func lockedResourceKey(serviceName, resourceName, rgName string) string {
+	return fmt.Sprintf("%s/%s (service: %s)", rgName, resourceName, serviceName)
+}
+
+// lockedResources returns when each resource locked by a management lock was last found to be locked according to
+// the LockedResourcesAnnotation of an object.
+func lockedResources(from conditions.Getter) map[string]time.Time {
+	locked := map[string]time.Time{}
+	jsonAnnotation := from.GetAnnotations()[azure.LockedResourcesAnnotation]
+	if jsonAnnotation == "" {
+		return locked
+	}
+	if err := json.Unmarshal([]byte(jsonAnnotation), &locked); err != nil {
+		// The locked resources are changed again right away if the annotation can't be parsed.
+		return map[string]time.Time{}
+	}
+	return locked
+}
+
+// setLockedResources persists the resources locked by a management lock in the LockedResourcesAnnotation of an object
+// and sets its AzureResourcesUnlocked condition accordingly.
+func setLockedResources(to conditions.Setter, locked map[string]time.Time) {
+	annotations := to.GetAnnotations()
+	if len(locked) == 0 {
+		delete(annotations, azure.LockedResourcesAnnotation)
+		to.SetAnnotations(annotations)
+		conditions.MarkTrue(to, infrav1.AzureResourcesUnlockedCondition)
+		return
+	}
+
+	// Times marshal without error, so the annotation is always updated.
+	if b, err := json.Marshal(locked); err == nil {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[azure.LockedResourcesAnnotation] = string(b)
+		to.SetAnnotations(annotations)
+	}
+	keys := make([]string, 0, len(locked))
+	for key := range locked {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	conditions.MarkFalse(to, infrav1.AzureResourcesUnlockedCondition, infrav1.ScopeLockedReason, clusterv1.ConditionSeverityWarning,
+		"resources locked by a management lock: %s", strings.Join(keys, ", "))
+}
+
+// setResourceLocked records that a resource was found to be locked by a management lock now.
+func setResourceLocked(to conditions.Setter, serviceName, resourceName, rgName string) {
+	locked := lockedResources(to)
+	locked[lockedResourceKey(serviceName, resourceName, rgName)] = time.Now().UTC()
+	setLockedResources(to, locked)
+}
+
+// resourceLockedSince returns when a resource was last found to be locked by a management lock, and whether it is
+// still recorded as locked.
+func resourceLockedSince(from conditions.Getter, serviceName, resourceName, rgName string) (time.Time, bool) {
+	since, ok := lockedResources(from)[lockedResourceKey(serviceName, resourceName, rgName)]
+	return since, ok
+}
+
+// clearResourceLocked records that a resource could be changed, so it is no longer locked.
+func clearResourceLocked(to conditions.Setter, serviceName, resourceName, rgName string) {
+	locked := lockedResources(to)
+	delete(locked, lockedResourceKey(serviceName, resourceName, rgName))
+	setLockedResources(to, locked)
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/availabilitysets"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// VMSizeCapabilitiesGetter gets the capabilities of the VM size of an AzureMachine from the resource SKUs available
+// in the location of its AzureCluster.
+type VMSizeCapabilitiesGetter struct {
+	Client client.Client
+}
+
+// GetVMSizeCapabilities returns the capabilities of the VM size of an AzureMachine. It returns nil if the AzureCluster
+// of the machine does not exist yet.
+func (g *VMSizeCapabilitiesGetter) GetVMSizeCapabilities(ctx context.Context, machine *infrav1.AzureMachine) (*infrav1.VMSizeCapabilities, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "scope.VMSizeCapabilitiesGetter.GetVMSizeCapabilities")
+	defer done()
+
+	clusterName, ok := machine.Labels[clusterv1.ClusterNameLabel]
+	if !ok {
+		return nil, nil
+	}
+	cluster := &clusterv1.Cluster{}
+	if err := g.Client.Get(ctx, client.ObjectKey{Namespace: machine.Namespace, Name: clusterName}, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get Cluster %s/%s", machine.Namespace, clusterName)
+	}
+	ref := cluster.Spec.InfrastructureRef
+	if ref == nil || ref.Kind != "AzureCluster" {
+		return nil, nil
+	}
+	azureCluster := &infrav1.AzureCluster{}
+	if err := g.Client.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: ref.Name}, azureCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get AzureCluster %s/%s", cluster.Namespace, ref.Name)
+	}
+
+	clusterScope, err := NewClusterScope(ctx, ClusterScopeParams{
+		Client:       g.Client,
+		Cluster:      cluster,
+		AzureCluster: azureCluster,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cluster scope")
+	}
+	skuCache, err := resourceskus.GetCache(clusterScope, clusterScope.Location())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the resource SKUs")
+	}
+	capabilities, err := vmSizeCapabilities(ctx, skuCache, machine, clusterScope.Location())
+	if err != nil {
+		return nil, err
+	}
+	if secondaryLocation := azureCluster.Spec.SecondaryLocation; secondaryLocation != "" {
+		secondarySKUCache, err := resourceskus.GetCache(clusterScope, secondaryLocation)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the resource SKUs of the secondary location")
+		}
+		if err := setSecondaryLocationCapabilities(ctx, capabilities, secondarySKUCache, machine.Spec.VMSize, secondaryLocation); err != nil {
+			return nil, err
+		}
+	}
+	capabilities.SubnetCIDRBlocks = subnetCIDRBlocks(clusterScope.Subnets())
+	return capabilities, nil
+}
+
+// subnetCIDRBlocks returns the address ranges of the subnets, by subnet name.
+func subnetCIDRBlocks(subnets infrav1.Subnets) map[string][]string {
+	cidrBlocks := make(map[string][]string, len(subnets))
+	for _, subnet := range subnets {
+		cidrBlocks[subnet.Name] = subnet.CIDRBlocks
+	}
+	return cidrBlocks
+}
+
+// maxSuggestedVMSizes is the maximum number of VM sizes suggested when the VM size of a machine lacks a capability.
+const maxSuggestedVMSizes = 10
+
+// vmSizeCapabilities returns the capabilities of the VM size of an AzureMachine from a resource SKU cache. UltraSSD
+// availability depends on the zone, so it is only checked for a machine with a failure domain. The availability in an
+// edge zone is only checked for a machine with an extended location. VM sizes supporting encryption at host are only
+// listed when the machine requests it and its VM size doesn't support it.
+func vmSizeCapabilities(ctx context.Context, skuCache *resourceskus.Cache, machine *infrav1.AzureMachine, location string) (*infrav1.VMSizeCapabilities, error) {
+	sku, err := skuCache.Get(ctx, machine.Spec.VMSize, resourceskus.VirtualMachines)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get VM size %s in location %s", machine.Spec.VMSize, location)
+	}
+
+	capabilities := &infrav1.VMSizeCapabilities{
+		Location:          location,
+		PremiumIO:         sku.HasCapability(resourceskus.PremiumIO),
+		UltraSSDAvailable: true,
+		EncryptionAtHost:  sku.HasCapability(resourceskus.EncryptionAtHost),
+		TrustedLaunch:     supportsTrustedLaunch(sku),
+		LocalNVMeDisks:    sku.HasLocalNVMeDisks(),
+		Hibernation:       sku.HasCapability(resourceskus.HibernationSupported),
+	}
+	if memoryGB, ok := sku.GetCapability(resourceskus.MemoryGB); ok {
+		capabilities.MemoryGB, _ = strconv.ParseFloat(memoryGB, 64)
+	}
+	// The confidential computing capability is the confidential computing technology of the VM size, e.g. SNP.
+	_, capabilities.ConfidentialVM = sku.GetCapability(resourceskus.ConfidentialComputingType)
+	if extendedLocation := machine.Spec.ExtendedLocation; extendedLocation != nil {
+		capabilities.ExtendedLocationVMSizeAvailable = sku.HasExtendedLocation(location, extendedLocation.Name)
+	}
+	if zone := ptr.Deref(machine.Spec.FailureDomain, ""); zone != "" {
+		capabilities.UltraSSDAvailable = sku.HasLocationCapability(resourceskus.UltraSSDAvailable, location, zone)
+	}
+	if securityProfile := machine.Spec.SecurityProfile; securityProfile != nil && ptr.Deref(securityProfile.EncryptionAtHost, false) && !capabilities.EncryptionAtHost {
+		capabilities.EncryptionAtHostVMSizes, err = vmSizesWithCapability(ctx, skuCache, resourceskus.EncryptionAtHost, ptr.Deref(sku.Family, ""))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list VM sizes supporting encryption at host in location %s", location)
+		}
+	}
+	if machine.Spec.PlatformFaultDomainCount != nil {
+		capabilities.MaximumPlatformFaultDomainCount = maximumPlatformFaultDomainCount(ctx, skuCache)
+	}
+	return capabilities, nil
+}
+
+// setSecondaryLocationCapabilities sets whether a VM size is available in the secondary location of a cluster, from
+// the resource SKU cache of the secondary location, and the availability zones it is available in there. A VM size
+// restricted in the whole location is not available.
+func setSecondaryLocationCapabilities(ctx context.Context, capabilities *infrav1.VMSizeCapabilities, skuCache *resourceskus.Cache, vmSize, location string) error {
+	capabilities.SecondaryLocation = location
+	err := skuCache.Map(ctx, func(sku resourceskus.SKU) {
+		if ptr.Deref(sku.ResourceType, "") != string(resourceskus.VirtualMachines) || !strings.EqualFold(ptr.Deref(sku.Name, ""), vmSize) {
+			return
+		}
+		capabilities.SecondaryLocationVMSizeAvailable = true
+		if sku.Restrictions == nil {
+			return
+		}
+		for _, restriction := range *sku.Restrictions {
+			if restriction.Type == compute.ResourceSkuRestrictionsTypeLocation {
+				capabilities.SecondaryLocationVMSizeAvailable = false
+			}
+		}
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get VM size %s in secondary location %s", vmSize, location)
+	}
+	if !capabilities.SecondaryLocationVMSizeAvailable {
+		return nil
+	}
+	capabilities.SecondaryLocationZones, err = skuCache.GetZonesWithVMSize(ctx, vmSize, location)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the availability zones of VM size %s in secondary location %s", vmSize, location)
+	}
+	return nil
+}
+
+// maximumPlatformFaultDomainCount returns the maximum fault domain count of an availability set in the location of a
+// resource SKU cache, or zero if it is unknown.
+func maximumPlatformFaultDomainCount(ctx context.Context, skuCache *resourceskus.Cache) int32 {
+	sku, err := skuCache.Get(ctx, string(compute.AvailabilitySetSkuTypesAligned), resourceskus.AvailabilitySets)
+	if err != nil {
+		return 0
+	}
+	count, err := availabilitysets.MaximumPlatformFaultDomainCount(sku)
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// supportsTrustedLaunch returns true if a VM size is a Generation 2 VM size that supports trusted launch.
+func supportsTrustedLaunch(sku resourceskus.SKU) bool {
+	if sku.HasCapability(resourceskus.TrustedLaunchDisabled) {
+		return false
+	}
+	generations, _ := sku.GetCapability(resourceskus.HyperVGenerations)
+	for _, generation := range strings.Split(generations, ",") {
+		if strings.EqualFold(strings.TrimSpace(generation), string(compute.HyperVGenerationV2)) {
+			return true
+		}
+	}
+	return false
+}
+
+// vmSizesWithCapability returns up to maxSuggestedVMSizes VM sizes with a capability, sorted by name. VM sizes of the
+// given family are listed first as they are the closest alternatives.
+func vmSizesWithCapability(ctx context.Context, skuCache *resourceskus.Cache, capability, family string) ([]string, error) {
+	var sameFamily, otherFamilies []string
+	err := skuCache.Map(ctx, func(sku resourceskus.SKU) {
+		if ptr.Deref(sku.ResourceType, "") != string(resourceskus.VirtualMachines) || sku.Name == nil || !sku.HasCapability(capability) {
+			return
+		}
+		if family != "" && ptr.Deref(sku.Family, "") == family {
+			sameFamily = append(sameFamily, *sku.Name)
+		} else {
+			otherFamilies = append(otherFamilies, *sku.Name)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(sameFamily)
+	sort.Strings(otherFamilies)
+	sizes := make([]string, 0, len(sameFamily)+len(otherFamilies))
+	sizes = append(sizes, sameFamily...)
+	sizes = append(sizes, otherFamilies...)
+	if len(sizes) > maxSuggestedVMSizes {
+		sizes = sizes[:maxSuggestedVMSizes]
+	}
+	return sizes, nil
+}
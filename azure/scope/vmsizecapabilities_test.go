@@ -0,0 +1,359 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+)
+
+func TestVMSizeCapabilities(t *testing.T) {
+	skuCache := resourceskus.NewStaticCache([]compute.ResourceSku{
+		{
+			Name:         ptr.To("Standard_D2s_v3"),
+			ResourceType: ptr.To(string(resourceskus.VirtualMachines)),
+			Family:       ptr.To("standardDSv3Family"),
+			Locations:    &[]string{"test-location"},
+			LocationInfo: &[]compute.ResourceSkuLocationInfo{
+				{
+					Location: ptr.To("test-location"),
+					Zones:    &[]string{"1", "2"},
+					ZoneDetails: &[]compute.ResourceSkuZoneDetails{
+						{
+							Name: &[]string{"1"},
+							Capabilities: &[]compute.ResourceSkuCapabilities{
+								{Name: ptr.To(resourceskus.UltraSSDAvailable), Value: ptr.To("True")},
+							},
+						},
+					},
+				},
+			},
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: ptr.To(resourceskus.PremiumIO), Value: ptr.To("True")},
+				{Name: ptr.To(resourceskus.EncryptionAtHost), Value: ptr.To("True")},
+			},
+		},
+		{
+			Name:         ptr.To("Standard_D2_v3"),
+			ResourceType: ptr.To(string(resourceskus.VirtualMachines)),
+			Family:       ptr.To("standardDv3Family"),
+			Locations:    &[]string{"test-location"},
+			LocationInfo: &[]compute.ResourceSkuLocationInfo{
+				{
+					Location:          ptr.To("test-location"),
+					Zones:             &[]string{"1", "2"},
+					ExtendedLocations: &[]string{"test-edge-zone"},
+				},
+			},
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: ptr.To(resourceskus.PremiumIO), Value: ptr.To("False")},
+			},
+		},
+		{
+			Name:         ptr.To("Standard_E2s_v3"),
+			ResourceType: ptr.To(string(resourceskus.VirtualMachines)),
+			Family:       ptr.To("standardESv3Family"),
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: ptr.To(resourceskus.EncryptionAtHost), Value: ptr.To("True")},
+			},
+		},
+		{
+			Name:         ptr.To("Standard_D4_v3"),
+			ResourceType: ptr.To(string(resourceskus.VirtualMachines)),
+			Family:       ptr.To("standardDv3Family"),
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: ptr.To(resourceskus.EncryptionAtHost), Value: ptr.To("True")},
+			},
+		},
+		{
+			Name:         ptr.To("Standard_D2s_v5"),
+			ResourceType: ptr.To(string(resourceskus.VirtualMachines)),
+			Family:       ptr.To("standardDSv5Family"),
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: ptr.To(resourceskus.HyperVGenerations), Value: ptr.To("V1,V2")},
+			},
+		},
+		{
+			Name:         ptr.To("Standard_NC6s_v3"),
+			ResourceType: ptr.To(string(resourceskus.VirtualMachines)),
+			Family:       ptr.To("standardNCSv3Family"),
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: ptr.To(resourceskus.HyperVGenerations), Value: ptr.To("V1,V2")},
+				{Name: ptr.To(resourceskus.TrustedLaunchDisabled), Value: ptr.To("True")},
+			},
+		},
+		{
+			Name:         ptr.To("Standard_DC2as_v5"),
+			ResourceType: ptr.To(string(resourceskus.VirtualMachines)),
+			Family:       ptr.To("standardDCASv5Family"),
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: ptr.To(resourceskus.HyperVGenerations), Value: ptr.To("V2")},
+				{Name: ptr.To(resourceskus.ConfidentialComputingType), Value: ptr.To("SNP")},
+			},
+		},
+		{
+			Name:         ptr.To("Standard_A2_v2"),
+			ResourceType: ptr.To(string(resourceskus.VirtualMachines)),
+			Family:       ptr.To("standardAv2Family"),
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: ptr.To(resourceskus.HyperVGenerations), Value: ptr.To("V1")},
+			},
+		},
+		{
+			Name:         ptr.To("Standard_L8s_v3"),
+			ResourceType: ptr.To(string(resourceskus.VirtualMachines)),
+			Family:       ptr.To("standardLSv3Family"),
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: ptr.To(resourceskus.NvmeDiskSizeInMiB), Value: ptr.To("1831420")},
+			},
+		},
+		{
+			Name:         ptr.To("Standard_D4s_v5"),
+			ResourceType: ptr.To(string(resourceskus.VirtualMachines)),
+			Family:       ptr.To("standardDSv5Family"),
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: ptr.To(resourceskus.MemoryGB), Value: ptr.To("16")},
+				{Name: ptr.To(resourceskus.HibernationSupported), Value: ptr.To("True")},
+			},
+		},
+		{
+			Name:         ptr.To(string(compute.AvailabilitySetSkuTypesAligned)),
+			ResourceType: ptr.To(string(resourceskus.AvailabilitySets)),
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: ptr.To(resourceskus.MaximumPlatformFaultDomainCount), Value: ptr.To("2")},
+			},
+		},
+		{
+			Name:         ptr.To("premium-disk"),
+			ResourceType: ptr.To(string(resourceskus.Disks)),
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: ptr.To(resourceskus.EncryptionAtHost), Value: ptr.To("True")},
+			},
+		},
+	}, "test-location")
+
+	tests := []struct {
+		name             string
+		vmSize           string
+		failureDomain    *string
+		encryptionAtHost *bool
+		faultDomainCount *int32
+		extendedLocation *infrav1.ExtendedLocationSpec
+		expected         *infrav1.VMSizeCapabilities
+		expectedError    string
+	}{
+		{
+			name:     "VM size with premium storage support",
+			vmSize:   "Standard_D2s_v3",
+			expected: &infrav1.VMSizeCapabilities{Location: "test-location", PremiumIO: true, UltraSSDAvailable: true, EncryptionAtHost: true},
+		},
+		{
+			name:     "VM size without premium storage support",
+			vmSize:   "Standard_D2_v3",
+			expected: &infrav1.VMSizeCapabilities{Location: "test-location", PremiumIO: false, UltraSSDAvailable: true},
+		},
+		{
+			name:          "VM size with UltraSSD support in the zone",
+			vmSize:        "Standard_D2s_v3",
+			failureDomain: ptr.To("1"),
+			expected:      &infrav1.VMSizeCapabilities{Location: "test-location", PremiumIO: true, UltraSSDAvailable: true, EncryptionAtHost: true},
+		},
+		{
+			name:          "VM size without UltraSSD support in the zone",
+			vmSize:        "Standard_D2s_v3",
+			failureDomain: ptr.To("2"),
+			expected:      &infrav1.VMSizeCapabilities{Location: "test-location", PremiumIO: true, UltraSSDAvailable: false, EncryptionAtHost: true},
+		},
+		{
+			name:             "VM size supporting encryption at host",
+			vmSize:           "Standard_D2s_v3",
+			encryptionAtHost: ptr.To(true),
+			expected:         &infrav1.VMSizeCapabilities{Location: "test-location", PremiumIO: true, UltraSSDAvailable: true, EncryptionAtHost: true},
+		},
+		{
+			name:             "VM size not supporting encryption at host suggests VM sizes of the same family first",
+			vmSize:           "Standard_D2_v3",
+			encryptionAtHost: ptr.To(true),
+			expected: &infrav1.VMSizeCapabilities{
+				Location:                "test-location",
+				PremiumIO:               false,
+				UltraSSDAvailable:       true,
+				EncryptionAtHost:        false,
+				EncryptionAtHostVMSizes: []string{"Standard_D4_v3", "Standard_D2s_v3", "Standard_E2s_v3"},
+			},
+		},
+		{
+			name:     "Generation 2 VM size supporting trusted launch",
+			vmSize:   "Standard_D2s_v5",
+			expected: &infrav1.VMSizeCapabilities{Location: "test-location", UltraSSDAvailable: true, TrustedLaunch: true},
+		},
+		{
+			name:     "Generation 2 VM size with trusted launch disabled",
+			vmSize:   "Standard_NC6s_v3",
+			expected: &infrav1.VMSizeCapabilities{Location: "test-location", UltraSSDAvailable: true, TrustedLaunch: false},
+		},
+		{
+			name:     "VM size supporting confidential VMs",
+			vmSize:   "Standard_DC2as_v5",
+			expected: &infrav1.VMSizeCapabilities{Location: "test-location", UltraSSDAvailable: true, TrustedLaunch: true, ConfidentialVM: true},
+		},
+		{
+			name:     "Generation 1 VM size",
+			vmSize:   "Standard_A2_v2",
+			expected: &infrav1.VMSizeCapabilities{Location: "test-location", UltraSSDAvailable: true, TrustedLaunch: false},
+		},
+		{
+			name:     "VM size with local NVMe disks",
+			vmSize:   "Standard_L8s_v3",
+			expected: &infrav1.VMSizeCapabilities{Location: "test-location", UltraSSDAvailable: true, LocalNVMeDisks: true},
+		},
+		{
+			name:     "VM size supporting hibernation",
+			vmSize:   "Standard_D4s_v5",
+			expected: &infrav1.VMSizeCapabilities{Location: "test-location", UltraSSDAvailable: true, Hibernation: true, MemoryGB: 16},
+		},
+		{
+			name:             "maximum fault domain count of the location when the fault domain count is set",
+			vmSize:           "Standard_D2_v3",
+			faultDomainCount: ptr.To[int32](3),
+			expected:         &infrav1.VMSizeCapabilities{Location: "test-location", UltraSSDAvailable: true, MaximumPlatformFaultDomainCount: 2},
+		},
+		{
+			name:             "VM size available in the edge zone",
+			vmSize:           "Standard_D2_v3",
+			extendedLocation: &infrav1.ExtendedLocationSpec{Name: "Test-Edge-Zone", Type: "EdgeZone"},
+			expected:         &infrav1.VMSizeCapabilities{Location: "test-location", UltraSSDAvailable: true, ExtendedLocationVMSizeAvailable: true},
+		},
+		{
+			name:             "VM size not available in the edge zone",
+			vmSize:           "Standard_D2s_v3",
+			extendedLocation: &infrav1.ExtendedLocationSpec{Name: "test-edge-zone", Type: "EdgeZone"},
+			expected:         &infrav1.VMSizeCapabilities{Location: "test-location", PremiumIO: true, UltraSSDAvailable: true, EncryptionAtHost: true},
+		},
+		{
+			name:          "unknown VM size",
+			vmSize:        "Standard_Unknown",
+			expectedError: "failed to get VM size Standard_Unknown in location test-location",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			machine := &infrav1.AzureMachine{
+				Spec: infrav1.AzureMachineSpec{
+					VMSize:                   tc.vmSize,
+					FailureDomain:            tc.failureDomain,
+					PlatformFaultDomainCount: tc.faultDomainCount,
+					ExtendedLocation:         tc.extendedLocation,
+				},
+			}
+			if tc.encryptionAtHost != nil {
+				machine.Spec.SecurityProfile = &infrav1.SecurityProfile{EncryptionAtHost: tc.encryptionAtHost}
+			}
+			capabilities, err := vmSizeCapabilities(context.TODO(), skuCache, machine, "test-location")
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedError)))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(capabilities).To(Equal(tc.expected))
+		})
+	}
+}
+
+// fakeImageGetter returns the features of images by their name.
+func TestSetSecondaryLocationCapabilities(t *testing.T) {
+	skuCache := resourceskus.NewStaticCache([]compute.ResourceSku{
+		{
+			Name:         ptr.To("Standard_D2s_v3"),
+			ResourceType: ptr.To(string(resourceskus.VirtualMachines)),
+			LocationInfo: &[]compute.ResourceSkuLocationInfo{
+				{
+					Location: ptr.To("secondary-location"),
+					Zones:    &[]string{"1", "2", "3"},
+				},
+			},
+			Restrictions: &[]compute.ResourceSkuRestrictions{
+				{
+					Type:            compute.ResourceSkuRestrictionsTypeZone,
+					RestrictionInfo: &compute.ResourceSkuRestrictionInfo{Zones: &[]string{"3"}},
+				},
+			},
+		},
+		{
+			Name:         ptr.To("Standard_D4s_v3"),
+			ResourceType: ptr.To(string(resourceskus.VirtualMachines)),
+			LocationInfo: &[]compute.ResourceSkuLocationInfo{
+				{
+					Location: ptr.To("secondary-location"),
+					Zones:    &[]string{"1"},
+				},
+			},
+			Restrictions: &[]compute.ResourceSkuRestrictions{
+				{
+					Type:            compute.ResourceSkuRestrictionsTypeLocation,
+					RestrictionInfo: &compute.ResourceSkuRestrictionInfo{Zones: &[]string{}},
+				},
+			},
+		},
+		{
+			Name:         ptr.To("Standard_D8s_v3"),
+			ResourceType: ptr.To(string(resourceskus.Disks)),
+		},
+	}, "secondary-location")
+
+	tests := []struct {
+		name              string
+		vmSize            string
+		expectedAvailable bool
+		expectedZones     []string
+	}{
+		{
+			name:              "VM size available in the secondary location",
+			vmSize:            "Standard_D2s_v3",
+			expectedAvailable: true,
+			expectedZones:     []string{"1", "2"},
+		},
+		{
+			name:   "VM size restricted in the secondary location",
+			vmSize: "Standard_D4s_v3",
+		},
+		{
+			name:   "VM size missing from the secondary location",
+			vmSize: "Standard_D8s_v3",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			capabilities := &infrav1.VMSizeCapabilities{}
+			err := setSecondaryLocationCapabilities(context.TODO(), capabilities, skuCache, tc.vmSize, "secondary-location")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(capabilities.SecondaryLocation).To(Equal("secondary-location"))
+			g.Expect(capabilities.SecondaryLocationVMSizeAvailable).To(Equal(tc.expectedAvailable))
+			g.Expect(capabilities.SecondaryLocationZones).To(Equal(tc.expectedZones))
+		})
+	}
+}
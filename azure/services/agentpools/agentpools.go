@@ -18,6 +18,8 @@ package agentpools
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2022-03-01/containerservice"
 	"github.com/pkg/errors"
@@ -25,11 +27,17 @@ import (
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
-const serviceName = "agentpools"
+const (
+	serviceName = "agentpools"
+
+	// upgradingNodeImageVersionState is the provisioning state of an agent pool while its node image is upgraded.
+	upgradingNodeImageVersionState = "UpgradingNodeImageVersion"
+)
 
 // AgentPoolScope defines the scope interface for an agent pool.
 type AgentPoolScope interface {
@@ -47,20 +55,31 @@ type AgentPoolScope interface {
 	SetCAPIMachinePoolAnnotation(key, value string)
 	RemoveCAPIMachinePoolAnnotation(key string)
 	SetSubnetName()
+	SetConditionFalse(conditionType clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, message string)
+	SetConditionTrue(conditionType clusterv1.ConditionType)
+	DeleteCondition(conditionType clusterv1.ConditionType)
+}
+
+// nodeImageUpgrader upgrades the node image of an agent pool.
+type nodeImageUpgrader interface {
+	LatestNodeImageVersion(ctx context.Context, spec azure.ResourceSpecGetter) (string, error)
+	UpgradeNodeImageVersion(ctx context.Context, spec azure.ResourceSpecGetter) error
 }
 
 // Service provides operations on Azure resources.
 type Service struct {
 	scope AgentPoolScope
 	async.Reconciler
+	nodeImageUpgrader nodeImageUpgrader
 }
 
 // New creates a new service.
 func New(scope AgentPoolScope) *Service {
 	client := newClient(scope)
 	return &Service{
-		scope:      scope,
-		Reconciler: async.New(scope, client, client),
+		scope:             scope,
+		Reconciler:        async.New(scope, client, client),
+		nodeImageUpgrader: client,
 	}
 }
 
@@ -77,6 +96,7 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	var resultingErr error
 	if agentPoolSpec := s.scope.AgentPoolSpec(); agentPoolSpec != nil {
 		result, err := s.CreateOrUpdateResource(ctx, agentPoolSpec, serviceName)
+		s.reconcileNodesDrainedCondition(agentPoolSpec, err)
 		if err != nil {
 			resultingErr = err
 		} else {
@@ -91,6 +111,13 @@ func (s *Service) Reconcile(ctx context.Context) error {
 			} else { // Otherwise, remove the annotation.
 				s.scope.RemoveCAPIMachinePoolAnnotation(clusterv1.ReplicasManagedByAnnotation)
 			}
+			if spec, ok := agentPoolSpec.(*AgentPoolSpec); ok && spec.WaitingForControlPlaneUpgrade() {
+				// The control plane is upgraded before the agent pools, so report the pending pool upgrade instead of a failure.
+				err := errors.Errorf("waiting for the control plane to be upgraded to %s before upgrading agent pool %s", *spec.Version, spec.Name)
+				s.scope.SetConditionFalse(infrav1.AgentPoolsReadyCondition, infrav1.WaitingForControlPlaneUpgradeReason, clusterv1.ConditionSeverityInfo, err.Error())
+				return azure.WithTransientError(err, reconciler.DefaultReconcilerRequeue)
+			}
+			resultingErr = s.reconcileNodeImageVersion(ctx, agentPoolSpec, agentPool)
 		}
 	} else {
 		return nil
@@ -100,6 +127,83 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	return resultingErr
 }
 
+// reconcileNodesDrainedCondition reports whether AKS could cordon and drain the nodes removed by a scale-down of the
+// agent pool without violating the pod disruption budgets of their pods. A failed drain fails the agent pool operation,
+// which is retried by the next reconciliation.
+func (s *Service) reconcileNodesDrainedCondition(spec azure.ResourceSpecGetter, err error) {
+	agentPoolSpec, ok := spec.(*AgentPoolSpec)
+	switch {
+	case isPodDisruptionBudgetViolation(err):
+		s.scope.SetConditionFalse(infrav1.AgentPoolNodesDrainedCondition, infrav1.PodDisruptionBudgetViolationReason, clusterv1.ConditionSeverityWarning, err.Error())
+	case ok && agentPoolSpec.ScalingDown() && azure.IsOperationNotDoneError(err):
+		s.scope.SetConditionFalse(infrav1.AgentPoolNodesDrainedCondition, infrav1.DrainingNodesReason, clusterv1.ConditionSeverityInfo,
+			fmt.Sprintf("draining the nodes removed by the scale-down of agent pool %s to %d nodes", agentPoolSpec.Name, agentPoolSpec.Replicas))
+	case err == nil:
+		s.scope.SetConditionTrue(infrav1.AgentPoolNodesDrainedCondition)
+	}
+}
+
+// isPodDisruptionBudgetViolation returns true if an agent pool operation failed because AKS couldn't evict the pods of
+// a node it drained within the drain timeout without violating their pod disruption budgets.
+func isPodDisruptionBudgetViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "poddrainfailure") || strings.Contains(message, "disruption budget")
+}
+
+// reconcileNodeImageVersion upgrades the node image of the agent pool when it is pinned to a version other than the
+// current one. AKS can only upgrade an agent pool to the latest node image version, so the upgrade is started once the
+// pinned version is the latest version available to the agent pool. Until then, the pinned version is reported as
+// unavailable by the AgentPoolNodeImageUpToDate condition without failing the reconciliation.
+func (s *Service) reconcileNodeImageVersion(ctx context.Context, spec azure.ResourceSpecGetter, agentPool containerservice.AgentPool) error {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "agentpools.Service.reconcileNodeImageVersion")
+	defer done()
+
+	agentPoolSpec, ok := spec.(*AgentPoolSpec)
+	if !ok || agentPoolSpec.NodeImageVersion == nil {
+		s.scope.DeleteCondition(infrav1.AgentPoolNodeImageUpToDateCondition)
+		return nil
+	}
+	pinnedVersion := *agentPoolSpec.NodeImageVersion
+
+	var currentVersion, provisioningState string
+	if agentPool.ManagedClusterAgentPoolProfileProperties != nil {
+		currentVersion = ptr.Deref(agentPool.NodeImageVersion, "")
+		provisioningState = ptr.Deref(agentPool.ProvisioningState, "")
+	}
+	if currentVersion == pinnedVersion {
+		s.scope.SetConditionTrue(infrav1.AgentPoolNodeImageUpToDateCondition)
+		return nil
+	}
+
+	if provisioningState == upgradingNodeImageVersionState {
+		s.scope.SetConditionFalse(infrav1.AgentPoolNodeImageUpToDateCondition, infrav1.UpgradingNodeImageReason, clusterv1.ConditionSeverityInfo,
+			fmt.Sprintf("upgrading node image of agent pool %s from %s to %s", agentPoolSpec.Name, currentVersion, pinnedVersion))
+		return azure.WithTransientError(errors.Errorf("node image of agent pool %s is being upgraded from %s", agentPoolSpec.Name, currentVersion), reconciler.DefaultReconcilerRequeue)
+	}
+
+	latestVersion, err := s.nodeImageUpgrader.LatestNodeImageVersion(ctx, spec)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the latest node image version of agent pool %s", agentPoolSpec.Name)
+	}
+	if latestVersion != pinnedVersion {
+		s.scope.SetConditionFalse(infrav1.AgentPoolNodeImageUpToDateCondition, infrav1.NodeImageVersionUnavailableReason, clusterv1.ConditionSeverityWarning,
+			fmt.Sprintf("pinned node image version %s is not available for agent pool %s: current version is %s, latest available version is %s",
+				pinnedVersion, agentPoolSpec.Name, currentVersion, latestVersion))
+		return nil
+	}
+
+	log.V(2).Info("upgrading node image version", "agentPool", agentPoolSpec.Name, "from", currentVersion, "to", pinnedVersion)
+	if err := s.nodeImageUpgrader.UpgradeNodeImageVersion(ctx, spec); err != nil {
+		return errors.Wrapf(err, "failed to upgrade node image version of agent pool %s", agentPoolSpec.Name)
+	}
+	s.scope.SetConditionFalse(infrav1.AgentPoolNodeImageUpToDateCondition, infrav1.UpgradingNodeImageReason, clusterv1.ConditionSeverityInfo,
+		fmt.Sprintf("upgrading node image of agent pool %s from %s to %s", agentPoolSpec.Name, currentVersion, pinnedVersion))
+	return azure.WithTransientError(errors.Errorf("upgrading node image of agent pool %s from %s to %s", agentPoolSpec.Name, currentVersion, pinnedVersion), reconciler.DefaultReconcilerRequeue)
+}
+
 // Delete deletes the virtual network with the provided name.
 func (s *Service) Delete(ctx context.Context) error {
 	ctx, _, done := tele.StartSpanWithLogger(ctx, "agentpools.Service.Delete")
@@ -23,9 +23,11 @@ import (
 
 	"github.com/Azure/go-autorest/autorest"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 	"go.uber.org/mock/gomock"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/agentpools/mock_agentpools"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async/mock_async"
 	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
@@ -47,8 +49,10 @@ func TestReconcileAgentPools(t *testing.T) {
 				fakeAgentPoolSpec := fakeAgentPool()
 				s.AgentPoolSpec().Return(&fakeAgentPoolSpec)
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeAgentPoolSpec, serviceName).Return(sdkFakeAgentPool(sdkWithAutoscaling(true), sdkWithCount(1)), nil)
+				s.SetConditionTrue(infrav1.AgentPoolNodesDrainedCondition)
 				s.SetCAPIMachinePoolAnnotation(clusterv1.ReplicasManagedByAnnotation, "true")
 				s.SetCAPIMachinePoolReplicas(ptr.To[int32](1))
+				s.DeleteCondition(infrav1.AgentPoolNodeImageUpToDateCondition)
 				s.UpdatePutStatus(infrav1.AgentPoolsReadyCondition, serviceName, nil)
 			},
 		},
@@ -59,8 +63,35 @@ func TestReconcileAgentPools(t *testing.T) {
 				fakeAgentPoolSpec := fakeAgentPool()
 				s.AgentPoolSpec().Return(&fakeAgentPoolSpec)
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeAgentPoolSpec, serviceName).Return(sdkFakeAgentPool(sdkWithAutoscaling(false), sdkWithCount(1)), nil)
+				s.SetConditionTrue(infrav1.AgentPoolNodesDrainedCondition)
 				s.RemoveCAPIMachinePoolAnnotation(clusterv1.ReplicasManagedByAnnotation)
-
+				s.DeleteCondition(infrav1.AgentPoolNodeImageUpToDateCondition)
+				s.UpdatePutStatus(infrav1.AgentPoolsReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "agent pool upgrade waits for the control plane upgrade",
+			expectedError: "waiting for the control plane to be upgraded to 1.26.3 before upgrading agent pool fake-agent-pool-name. Object will be requeued after 15s",
+			expect: func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				fakeAgentPoolSpec := fakeAgentPool(withVersion("1.26.3"), withControlPlaneVersion("1.25.5"))
+				s.AgentPoolSpec().Return(&fakeAgentPoolSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeAgentPoolSpec, serviceName).Return(sdkFakeAgentPool(sdkWithAutoscaling(false), sdkWithOrchestratorVersion("1.25.5")), nil)
+				s.SetConditionTrue(infrav1.AgentPoolNodesDrainedCondition)
+				s.RemoveCAPIMachinePoolAnnotation(clusterv1.ReplicasManagedByAnnotation)
+				s.SetConditionFalse(infrav1.AgentPoolsReadyCondition, infrav1.WaitingForControlPlaneUpgradeReason, clusterv1.ConditionSeverityInfo,
+					"waiting for the control plane to be upgraded to 1.26.3 before upgrading agent pool fake-agent-pool-name")
+			},
+		},
+		{
+			name:          "agent pool is upgraded after the control plane",
+			expectedError: "",
+			expect: func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				fakeAgentPoolSpec := fakeAgentPool(withVersion("1.26.3"), withControlPlaneVersion("1.26.3"))
+				s.AgentPoolSpec().Return(&fakeAgentPoolSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeAgentPoolSpec, serviceName).Return(sdkFakeAgentPool(sdkWithAutoscaling(false), sdkWithOrchestratorVersion("1.26.3")), nil)
+				s.SetConditionTrue(infrav1.AgentPoolNodesDrainedCondition)
+				s.RemoveCAPIMachinePoolAnnotation(clusterv1.ReplicasManagedByAnnotation)
+				s.DeleteCondition(infrav1.AgentPoolNodeImageUpToDateCondition)
 				s.UpdatePutStatus(infrav1.AgentPoolsReadyCondition, serviceName, nil)
 			},
 		},
@@ -111,6 +142,213 @@ func TestReconcileAgentPools(t *testing.T) {
 	}
 }
 
+func TestReconcileAgentPoolsNodesDrainedCondition(t *testing.T) {
+	pdbError := errors.New("Code=\"PodDrainFailure\" Message=\"Drain node aks-pool1-12345678-vmss000001 failed when evicting pod my-pod failed with Too Many Requests error. This is often caused by a restrictive Pod Disruption Budget (PDB) policy.\"")
+	operationNotDoneError := azure.NewOperationNotDoneError(&infrav1.Future{Type: infrav1.PutFuture, ServiceName: serviceName, Name: "fake-agent-pool-name"})
+
+	testcases := []struct {
+		name          string
+		scalingDown   bool
+		expectedError string
+		expect        func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, spec *AgentPoolSpec)
+	}{
+		{
+			name:          "nodes are being drained by a scale-down",
+			scalingDown:   true,
+			expectedError: operationNotDoneError.Error(),
+			expect: func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, spec *AgentPoolSpec) {
+				s.AgentPoolSpec().Return(spec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), spec, serviceName).Return(nil, operationNotDoneError)
+				s.SetConditionFalse(infrav1.AgentPoolNodesDrainedCondition, infrav1.DrainingNodesReason, clusterv1.ConditionSeverityInfo,
+					"draining the nodes removed by the scale-down of agent pool fake-agent-pool-name to 1 nodes")
+				s.UpdatePutStatus(infrav1.AgentPoolsReadyCondition, serviceName, operationNotDoneError)
+			},
+		},
+		{
+			name:          "operation in progress is not a scale-down",
+			expectedError: operationNotDoneError.Error(),
+			expect: func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, spec *AgentPoolSpec) {
+				s.AgentPoolSpec().Return(spec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), spec, serviceName).Return(nil, operationNotDoneError)
+				s.UpdatePutStatus(infrav1.AgentPoolsReadyCondition, serviceName, operationNotDoneError)
+			},
+		},
+		{
+			name:          "scale-down is blocked by a pod disruption budget",
+			scalingDown:   true,
+			expectedError: pdbError.Error(),
+			expect: func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, spec *AgentPoolSpec) {
+				s.AgentPoolSpec().Return(spec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), spec, serviceName).Return(nil, pdbError)
+				s.SetConditionFalse(infrav1.AgentPoolNodesDrainedCondition, infrav1.PodDisruptionBudgetViolationReason, clusterv1.ConditionSeverityWarning, pdbError.Error())
+				s.UpdatePutStatus(infrav1.AgentPoolsReadyCondition, serviceName, pdbError)
+			},
+		},
+		{
+			name:          "other errors don't change the condition",
+			scalingDown:   true,
+			expectedError: internalError.Error(),
+			expect: func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, spec *AgentPoolSpec) {
+				s.AgentPoolSpec().Return(spec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), spec, serviceName).Return(nil, internalError)
+				s.UpdatePutStatus(infrav1.AgentPoolsReadyCondition, serviceName, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_agentpools.NewMockAgentPoolScope(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+			fakeAgentPoolSpec := fakeAgentPool(withAutoscaling(false), withDrainTimeoutInMinutes(30))
+			fakeAgentPoolSpec.scalingDown = tc.scalingDown
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT(), &fakeAgentPoolSpec)
+
+			s := &Service{
+				scope:      scopeMock,
+				Reconciler: asyncMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			g.Expect(err).To(MatchError(tc.expectedError))
+		})
+	}
+}
+
+func TestReconcileAgentPoolsNodeImageVersion(t *testing.T) {
+	const (
+		currentVersion = "AKSUbuntu-2204gen2containerd-202308.01.0"
+		pinnedVersion  = "AKSUbuntu-2204gen2containerd-202309.06.0"
+	)
+
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, u *mock_agentpools.MocknodeImageUpgraderMockRecorder)
+	}{
+		{
+			name:          "agent pool already runs the pinned node image version",
+			expectedError: "",
+			expect: func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, u *mock_agentpools.MocknodeImageUpgraderMockRecorder) {
+				fakeAgentPoolSpec := fakeAgentPool(withNodeImageVersion(pinnedVersion))
+				s.AgentPoolSpec().Return(&fakeAgentPoolSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeAgentPoolSpec, serviceName).Return(sdkFakeAgentPool(sdkWithAutoscaling(false), sdkWithNodeImageVersion(pinnedVersion)), nil)
+				s.SetConditionTrue(infrav1.AgentPoolNodesDrainedCondition)
+				s.RemoveCAPIMachinePoolAnnotation(clusterv1.ReplicasManagedByAnnotation)
+				s.SetConditionTrue(infrav1.AgentPoolNodeImageUpToDateCondition)
+				s.UpdatePutStatus(infrav1.AgentPoolsReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "node image version is not pinned",
+			expectedError: "",
+			expect: func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, u *mock_agentpools.MocknodeImageUpgraderMockRecorder) {
+				fakeAgentPoolSpec := fakeAgentPool()
+				s.AgentPoolSpec().Return(&fakeAgentPoolSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeAgentPoolSpec, serviceName).Return(sdkFakeAgentPool(sdkWithAutoscaling(false), sdkWithNodeImageVersion(currentVersion)), nil)
+				s.SetConditionTrue(infrav1.AgentPoolNodesDrainedCondition)
+				s.RemoveCAPIMachinePoolAnnotation(clusterv1.ReplicasManagedByAnnotation)
+				s.DeleteCondition(infrav1.AgentPoolNodeImageUpToDateCondition)
+				s.UpdatePutStatus(infrav1.AgentPoolsReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "node image is upgraded when the pinned version is the latest available",
+			expectedError: "upgrading node image of agent pool fake-agent-pool-name from " + currentVersion + " to " + pinnedVersion + ". Object will be requeued after 15s",
+			expect: func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, u *mock_agentpools.MocknodeImageUpgraderMockRecorder) {
+				fakeAgentPoolSpec := fakeAgentPool(withNodeImageVersion(pinnedVersion))
+				s.AgentPoolSpec().Return(&fakeAgentPoolSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeAgentPoolSpec, serviceName).Return(sdkFakeAgentPool(sdkWithAutoscaling(false), sdkWithNodeImageVersion(currentVersion)), nil)
+				s.SetConditionTrue(infrav1.AgentPoolNodesDrainedCondition)
+				s.RemoveCAPIMachinePoolAnnotation(clusterv1.ReplicasManagedByAnnotation)
+				u.LatestNodeImageVersion(gomockinternal.AContext(), &fakeAgentPoolSpec).Return(pinnedVersion, nil)
+				u.UpgradeNodeImageVersion(gomockinternal.AContext(), &fakeAgentPoolSpec).Return(nil)
+				s.SetConditionFalse(infrav1.AgentPoolNodeImageUpToDateCondition, infrav1.UpgradingNodeImageReason, clusterv1.ConditionSeverityInfo,
+					"upgrading node image of agent pool fake-agent-pool-name from "+currentVersion+" to "+pinnedVersion)
+				s.UpdatePutStatus(infrav1.AgentPoolsReadyCondition, serviceName, gomock.Any())
+			},
+		},
+		{
+			name:          "node image upgrade is in progress",
+			expectedError: "node image of agent pool fake-agent-pool-name is being upgraded from " + currentVersion + ". Object will be requeued after 15s",
+			expect: func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, u *mock_agentpools.MocknodeImageUpgraderMockRecorder) {
+				fakeAgentPoolSpec := fakeAgentPool(withNodeImageVersion(pinnedVersion))
+				s.AgentPoolSpec().Return(&fakeAgentPoolSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeAgentPoolSpec, serviceName).Return(sdkFakeAgentPool(sdkWithAutoscaling(false), sdkWithNodeImageVersion(currentVersion), sdkWithProvisioningState("UpgradingNodeImageVersion")), nil)
+				s.SetConditionTrue(infrav1.AgentPoolNodesDrainedCondition)
+				s.RemoveCAPIMachinePoolAnnotation(clusterv1.ReplicasManagedByAnnotation)
+				s.SetConditionFalse(infrav1.AgentPoolNodeImageUpToDateCondition, infrav1.UpgradingNodeImageReason, clusterv1.ConditionSeverityInfo,
+					"upgrading node image of agent pool fake-agent-pool-name from "+currentVersion+" to "+pinnedVersion)
+				s.UpdatePutStatus(infrav1.AgentPoolsReadyCondition, serviceName, gomock.Any())
+			},
+		},
+		{
+			name:          "pinned node image version is reported when it is not the latest available",
+			expectedError: "",
+			expect: func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, u *mock_agentpools.MocknodeImageUpgraderMockRecorder) {
+				fakeAgentPoolSpec := fakeAgentPool(withNodeImageVersion(pinnedVersion))
+				s.AgentPoolSpec().Return(&fakeAgentPoolSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeAgentPoolSpec, serviceName).Return(sdkFakeAgentPool(sdkWithAutoscaling(false), sdkWithNodeImageVersion(currentVersion)), nil)
+				s.SetConditionTrue(infrav1.AgentPoolNodesDrainedCondition)
+				s.RemoveCAPIMachinePoolAnnotation(clusterv1.ReplicasManagedByAnnotation)
+				u.LatestNodeImageVersion(gomockinternal.AContext(), &fakeAgentPoolSpec).Return("AKSUbuntu-2204gen2containerd-202310.04.0", nil)
+				s.SetConditionFalse(infrav1.AgentPoolNodeImageUpToDateCondition, infrav1.NodeImageVersionUnavailableReason, clusterv1.ConditionSeverityWarning,
+					"pinned node image version "+pinnedVersion+" is not available for agent pool fake-agent-pool-name: current version is "+currentVersion+", latest available version is AKSUbuntu-2204gen2containerd-202310.04.0")
+				s.UpdatePutStatus(infrav1.AgentPoolsReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "fail to upgrade the node image",
+			expectedError: "failed to upgrade node image version of agent pool fake-agent-pool-name: " + internalError.Error(),
+			expect: func(s *mock_agentpools.MockAgentPoolScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, u *mock_agentpools.MocknodeImageUpgraderMockRecorder) {
+				fakeAgentPoolSpec := fakeAgentPool(withNodeImageVersion(pinnedVersion))
+				s.AgentPoolSpec().Return(&fakeAgentPoolSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeAgentPoolSpec, serviceName).Return(sdkFakeAgentPool(sdkWithAutoscaling(false), sdkWithNodeImageVersion(currentVersion)), nil)
+				s.SetConditionTrue(infrav1.AgentPoolNodesDrainedCondition)
+				s.RemoveCAPIMachinePoolAnnotation(clusterv1.ReplicasManagedByAnnotation)
+				u.LatestNodeImageVersion(gomockinternal.AContext(), &fakeAgentPoolSpec).Return(pinnedVersion, nil)
+				u.UpgradeNodeImageVersion(gomockinternal.AContext(), &fakeAgentPoolSpec).Return(internalError)
+				s.UpdatePutStatus(infrav1.AgentPoolsReadyCondition, serviceName, gomock.Any())
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_agentpools.NewMockAgentPoolScope(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+			upgraderMock := mock_agentpools.NewMocknodeImageUpgrader(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT(), upgraderMock.EXPECT())
+
+			s := &Service{
+				scope:             scopeMock,
+				Reconciler:        asyncMock,
+				nodeImageUpgrader: upgraderMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
 func TestDeleteAgentPools(t *testing.T) {
 	testcases := []struct {
 		name          string
@@ -17,13 +17,17 @@ limitations under the License.
 package agentpools
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"net/http"
 
 	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2022-03-01/containerservice"
 	"github.com/Azure/go-autorest/autorest"
 	azureautorest "github.com/Azure/go-autorest/autorest/azure"
 	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
@@ -81,6 +85,12 @@ func (ac *azureClient) CreateOrUpdateAsync(ctx context.Context, spec azure.Resou
 		preparer.Header.Add(key, element)
 	}
 
+	if agentPoolSpec, ok := spec.(*AgentPoolSpec); ok && agentPoolSpec.DrainTimeoutInMinutes != nil {
+		if err := withDrainTimeout(preparer, *agentPoolSpec.DrainTimeoutInMinutes); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to prepare operation")
+		}
+	}
+
 	createFuture, err := ac.agentpools.CreateOrUpdateSender(preparer)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to begin operation")
@@ -100,6 +110,45 @@ func (ac *azureClient) CreateOrUpdateAsync(ctx context.Context, spec azure.Resou
 	return result, nil, err
 }
 
+// drainTimeoutAPIVersion is the AKS API version agent pools are updated with when they have a drain timeout, as the
+// drain timeout is not part of the AKS API version of the SDK.
+const drainTimeoutAPIVersion = "2023-10-01"
+
+// withDrainTimeout adds the drain timeout of the agent pool to the upgrade settings in the body of an agent pool
+// request, and sends the request with an AKS API version supporting it.
+func withDrainTimeout(req *http.Request, drainTimeoutInMinutes int32) error {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read agent pool")
+	}
+	agentPool := map[string]interface{}{}
+	if err := json.Unmarshal(body, &agentPool); err != nil {
+		return errors.Wrap(err, "failed to unmarshal agent pool")
+	}
+	properties, ok := agentPool["properties"].(map[string]interface{})
+	if !ok {
+		properties = map[string]interface{}{}
+		agentPool["properties"] = properties
+	}
+	upgradeSettings, ok := properties["upgradeSettings"].(map[string]interface{})
+	if !ok {
+		upgradeSettings = map[string]interface{}{}
+		properties["upgradeSettings"] = upgradeSettings
+	}
+	upgradeSettings["drainTimeoutInMinutes"] = drainTimeoutInMinutes
+	body, err = json.Marshal(agentPool)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal agent pool")
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	query := req.URL.Query()
+	query.Set("api-version", drainTimeoutAPIVersion)
+	req.URL.RawQuery = query.Encode()
+	return nil
+}
+
 // DeleteAsync deletes an agent pool asynchronously. DeleteAsync sends a DELETE
 // request to Azure and if accepted without error, the func will return a Future which can be used to track the ongoing
 // progress of the operation.
@@ -126,6 +175,31 @@ func (ac *azureClient) DeleteAsync(ctx context.Context, spec azure.ResourceSpecG
 	return nil, err
 }
 
+// LatestNodeImageVersion returns the latest node image version available to an agent pool.
+func (ac *azureClient) LatestNodeImageVersion(ctx context.Context, spec azure.ResourceSpecGetter) (string, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "agentpools.azureClient.LatestNodeImageVersion")
+	defer done()
+
+	profile, err := ac.agentpools.GetUpgradeProfile(ctx, spec.ResourceGroupName(), spec.OwnerResourceName(), spec.ResourceName())
+	if err != nil {
+		return "", err
+	}
+	if profile.AgentPoolUpgradeProfileProperties == nil {
+		return "", nil
+	}
+	return ptr.Deref(profile.LatestNodeImageVersion, ""), nil
+}
+
+// UpgradeNodeImageVersion starts upgrading the node image of an agent pool to the latest version available.
+// It does not wait for the upgrade to complete, as the progress is reflected in the provisioning state of the agent pool.
+func (ac *azureClient) UpgradeNodeImageVersion(ctx context.Context, spec azure.ResourceSpecGetter) error {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "agentpools.azureClient.UpgradeNodeImageVersion")
+	defer done()
+
+	_, err := ac.agentpools.UpgradeNodeImageVersion(ctx, spec.ResourceGroupName(), spec.OwnerResourceName(), spec.ResourceName())
+	return err
+}
+
 // IsDone returns true if the long-running operation has completed.
 func (ac *azureClient) IsDone(ctx context.Context, future azureautorest.FutureAPI) (isDone bool, err error) {
 	ctx, _, done := tele.StartSpanWithLogger(ctx, "agentpools.azureClient.IsDone")
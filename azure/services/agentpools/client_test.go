@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agentpools
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWithDrainTimeout(t *testing.T) {
+	testcases := []struct {
+		name                    string
+		body                    string
+		expectedUpgradeSettings map[string]interface{}
+	}{
+		{
+			name:                    "upgrade settings are added",
+			body:                    `{"properties":{"count":3}}`,
+			expectedUpgradeSettings: map[string]interface{}{"drainTimeoutInMinutes": float64(30)},
+		},
+		{
+			name:                    "existing upgrade settings are kept",
+			body:                    `{"properties":{"count":3,"upgradeSettings":{"maxSurge":"33%"}}}`,
+			expectedUpgradeSettings: map[string]interface{}{"maxSurge": "33%", "drainTimeoutInMinutes": float64(30)},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			req, err := http.NewRequest(http.MethodPut, "https://management.azure.com/agentPools/pool0?api-version=2022-03-01", bytes.NewBufferString(tc.body))
+			g.Expect(err).NotTo(HaveOccurred())
+
+			g.Expect(withDrainTimeout(req, 30)).To(Succeed())
+			g.Expect(req.URL.Query().Get("api-version")).To(Equal(drainTimeoutAPIVersion))
+
+			body, err := io.ReadAll(req.Body)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(req.ContentLength).To(Equal(int64(len(body))))
+			agentPool := map[string]map[string]interface{}{}
+			g.Expect(json.Unmarshal(body, &agentPool)).To(Succeed())
+			g.Expect(agentPool["properties"]).To(HaveKeyWithValue("count", float64(3)))
+			g.Expect(agentPool["properties"]).To(HaveKeyWithValue("upgradeSettings", tc.expectedUpgradeSettings))
+		})
+	}
+}
@@ -21,6 +21,7 @@ limitations under the License.
 package mock_agentpools
 
 import (
+	context "context"
 	reflect "reflect"
 
 	autorest "github.com/Azure/go-autorest/autorest"
@@ -207,6 +208,18 @@ func (mr *MockAgentPoolScopeMockRecorder) ClusterName() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClusterName", reflect.TypeOf((*MockAgentPoolScope)(nil).ClusterName))
 }
 
+// DeleteCondition mocks base method.
+func (m *MockAgentPoolScope) DeleteCondition(conditionType v1beta10.ConditionType) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteCondition", conditionType)
+}
+
+// DeleteCondition indicates an expected call of DeleteCondition.
+func (mr *MockAgentPoolScopeMockRecorder) DeleteCondition(conditionType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCondition", reflect.TypeOf((*MockAgentPoolScope)(nil).DeleteCondition), conditionType)
+}
+
 // DeleteLongRunningOperationState mocks base method.
 func (m *MockAgentPoolScope) DeleteLongRunningOperationState(arg0, arg1, arg2 string) {
 	m.ctrl.T.Helper()
@@ -431,6 +444,30 @@ func (mr *MockAgentPoolScopeMockRecorder) SetCAPIMachinePoolReplicas(replicas in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCAPIMachinePoolReplicas", reflect.TypeOf((*MockAgentPoolScope)(nil).SetCAPIMachinePoolReplicas), replicas)
 }
 
+// SetConditionFalse mocks base method.
+func (m *MockAgentPoolScope) SetConditionFalse(conditionType v1beta10.ConditionType, reason string, severity v1beta10.ConditionSeverity, message string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetConditionFalse", conditionType, reason, severity, message)
+}
+
+// SetConditionFalse indicates an expected call of SetConditionFalse.
+func (mr *MockAgentPoolScopeMockRecorder) SetConditionFalse(conditionType, reason, severity, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConditionFalse", reflect.TypeOf((*MockAgentPoolScope)(nil).SetConditionFalse), conditionType, reason, severity, message)
+}
+
+// SetConditionTrue mocks base method.
+func (m *MockAgentPoolScope) SetConditionTrue(conditionType v1beta10.ConditionType) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetConditionTrue", conditionType)
+}
+
+// SetConditionTrue indicates an expected call of SetConditionTrue.
+func (mr *MockAgentPoolScopeMockRecorder) SetConditionTrue(conditionType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConditionTrue", reflect.TypeOf((*MockAgentPoolScope)(nil).SetConditionTrue), conditionType)
+}
+
 // SetLongRunningOperationState mocks base method.
 func (m *MockAgentPoolScope) SetLongRunningOperationState(arg0 *v1beta1.Future) {
 	m.ctrl.T.Helper()
@@ -518,3 +555,55 @@ func (mr *MockAgentPoolScopeMockRecorder) UpdatePutStatus(arg0, arg1, arg2 inter
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePutStatus", reflect.TypeOf((*MockAgentPoolScope)(nil).UpdatePutStatus), arg0, arg1, arg2)
 }
+
+// MocknodeImageUpgrader is a mock of nodeImageUpgrader interface.
+type MocknodeImageUpgrader struct {
+	ctrl     *gomock.Controller
+	recorder *MocknodeImageUpgraderMockRecorder
+}
+
+// MocknodeImageUpgraderMockRecorder is the mock recorder for MocknodeImageUpgrader.
+type MocknodeImageUpgraderMockRecorder struct {
+	mock *MocknodeImageUpgrader
+}
+
+// NewMocknodeImageUpgrader creates a new mock instance.
+func NewMocknodeImageUpgrader(ctrl *gomock.Controller) *MocknodeImageUpgrader {
+	mock := &MocknodeImageUpgrader{ctrl: ctrl}
+	mock.recorder = &MocknodeImageUpgraderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocknodeImageUpgrader) EXPECT() *MocknodeImageUpgraderMockRecorder {
+	return m.recorder
+}
+
+// LatestNodeImageVersion mocks base method.
+func (m *MocknodeImageUpgrader) LatestNodeImageVersion(ctx context.Context, spec azure.ResourceSpecGetter) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LatestNodeImageVersion", ctx, spec)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LatestNodeImageVersion indicates an expected call of LatestNodeImageVersion.
+func (mr *MocknodeImageUpgraderMockRecorder) LatestNodeImageVersion(ctx, spec interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestNodeImageVersion", reflect.TypeOf((*MocknodeImageUpgrader)(nil).LatestNodeImageVersion), ctx, spec)
+}
+
+// UpgradeNodeImageVersion mocks base method.
+func (m *MocknodeImageUpgrader) UpgradeNodeImageVersion(ctx context.Context, spec azure.ResourceSpecGetter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpgradeNodeImageVersion", ctx, spec)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpgradeNodeImageVersion indicates an expected call of UpgradeNodeImageVersion.
+func (mr *MocknodeImageUpgraderMockRecorder) UpgradeNodeImageVersion(ctx, spec interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpgradeNodeImageVersion", reflect.TypeOf((*MocknodeImageUpgrader)(nil).UpgradeNodeImageVersion), ctx, spec)
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2022-03-01/containerservice"
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
+	"golang.org/x/mod/semver"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
@@ -73,6 +74,10 @@ type AgentPoolSpec struct {
 	// Version defines the desired Kubernetes version.
 	Version *string
 
+	// ControlPlaneVersion is the current Kubernetes version of the AKS control plane. When the desired Version is newer,
+	// the agent pool upgrade is held back until the control plane upgrade has completed.
+	ControlPlaneVersion *string
+
 	// SKU defines the Azure VM size for the agent pool VMs.
 	SKU string
 
@@ -150,6 +155,30 @@ type AgentPoolSpec struct {
 
 	// EnableFIPS indicates whether FIPS is enabled on the node pool
 	EnableFIPS *bool
+
+	// NodeImageVersion is the node image version the node pool is pinned to.
+	NodeImageVersion *string
+
+	// DrainTimeoutInMinutes is how long AKS waits for the pods of a node to be evicted when it drains the node.
+	DrainTimeoutInMinutes *int32
+
+	// scalingDown is true when the last call to Parameters scales the existing agent pool down.
+	scalingDown bool
+}
+
+// ScalingDown returns true when the last call to Parameters scales the existing agent pool down, in which case AKS
+// cordons and drains the nodes it removes.
+func (s *AgentPoolSpec) ScalingDown() bool {
+	return s.scalingDown
+}
+
+// WaitingForControlPlaneUpgrade returns true when the desired Kubernetes version of the agent pool is newer than the
+// current version of the AKS control plane.
+func (s *AgentPoolSpec) WaitingForControlPlaneUpgrade() bool {
+	if s.Version == nil || s.ControlPlaneVersion == nil {
+		return false
+	}
+	return semver.Compare("v"+*s.Version, "v"+*s.ControlPlaneVersion) > 0
 }
 
 // ResourceName returns the name of the agent pool.
@@ -176,8 +205,16 @@ func (s *AgentPoolSpec) CustomHeaders() map[string]string {
 func (s *AgentPoolSpec) Parameters(ctx context.Context, existing interface{}) (params interface{}, err error) {
 	_, log, done := tele.StartSpanWithLogger(ctx, "agentpools.Service.Parameters")
 	defer done()
+	s.scalingDown = false
 
 	nodeLabels := s.NodeLabels
+	orchestratorVersion := s.Version
+	if s.WaitingForControlPlaneUpgrade() {
+		// Agent pools can't run a newer Kubernetes version than the control plane, so keep the pool at the control
+		// plane version until the control plane upgrade has completed.
+		orchestratorVersion = s.ControlPlaneVersion
+		log.V(4).Info("waiting for the control plane to be upgraded before upgrading the agent pool", "controlPlaneVersion", *s.ControlPlaneVersion, "version", *s.Version)
+	}
 	if existing != nil {
 		existingPool, ok := existing.(containerservice.AgentPool)
 		if !ok {
@@ -191,6 +228,10 @@ func (s *AgentPoolSpec) Parameters(ctx context.Context, existing interface{}) (p
 			return nil, azure.WithTransientError(errors.New(msg), 20*time.Second)
 		}
 
+		if s.WaitingForControlPlaneUpgrade() && existingPool.OrchestratorVersion != nil {
+			orchestratorVersion = existingPool.OrchestratorVersion
+		}
+
 		// Normalize individual agent pools to diff in case we need to update
 		existingProfile := containerservice.AgentPool{
 			ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
@@ -212,7 +253,7 @@ func (s *AgentPoolSpec) Parameters(ctx context.Context, existing interface{}) (p
 		normalizedProfile := containerservice.AgentPool{
 			ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
 				Count:               &s.Replicas,
-				OrchestratorVersion: s.Version,
+				OrchestratorVersion: orchestratorVersion,
 				Mode:                containerservice.AgentPoolMode(s.Mode),
 				EnableAutoScaling:   ptr.To(s.EnableAutoScaling),
 				MinCount:            s.MinCount,
@@ -270,6 +311,7 @@ func (s *AgentPoolSpec) Parameters(ctx context.Context, existing interface{}) (p
 			return nil, nil
 		}
 		log.V(4).Info("found a diff between the desired spec and the existing agentpool", "difference", diff)
+		s.scalingDown = !s.EnableAutoScaling && existingPool.Count != nil && s.Replicas < *existingPool.Count
 	}
 
 	var availabilityZones *[]string
@@ -370,7 +412,7 @@ func (s *AgentPoolSpec) Parameters(ctx context.Context, existing interface{}) (p
 			Mode:                 containerservice.AgentPoolMode(s.Mode),
 			NodeLabels:           nodeLabels,
 			NodeTaints:           nodeTaints,
-			OrchestratorVersion:  s.Version,
+			OrchestratorVersion:  orchestratorVersion,
 			OsDiskSizeGB:         &s.OSDiskSizeGB,
 			OsDiskType:           containerservice.OSDiskType(ptr.Deref(s.OsDiskType, "")),
 			OsType:               containerservice.OSType(ptr.Deref(s.OSType, "")),
@@ -84,6 +84,30 @@ func withSpotMaxPrice(spotMaxPrice string) func(*AgentPoolSpec) {
 	}
 }
 
+func withNodeImageVersion(version string) func(*AgentPoolSpec) {
+	return func(pool *AgentPoolSpec) {
+		pool.NodeImageVersion = ptr.To(version)
+	}
+}
+
+func withDrainTimeoutInMinutes(drainTimeoutInMinutes int32) func(*AgentPoolSpec) {
+	return func(pool *AgentPoolSpec) {
+		pool.DrainTimeoutInMinutes = ptr.To(drainTimeoutInMinutes)
+	}
+}
+
+func withVersion(version string) func(*AgentPoolSpec) {
+	return func(pool *AgentPoolSpec) {
+		pool.Version = ptr.To(version)
+	}
+}
+
+func withControlPlaneVersion(version string) func(*AgentPoolSpec) {
+	return func(pool *AgentPoolSpec) {
+		pool.ControlPlaneVersion = ptr.To(version)
+	}
+}
+
 func sdkFakeAgentPool(changes ...func(*containerservice.AgentPool)) containerservice.AgentPool {
 	pool := containerservice.AgentPool{
 		ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
@@ -140,12 +164,24 @@ func sdkWithCount(count int32) func(*containerservice.AgentPool) {
 	}
 }
 
+func sdkWithOrchestratorVersion(version string) func(*containerservice.AgentPool) {
+	return func(pool *containerservice.AgentPool) {
+		pool.ManagedClusterAgentPoolProfileProperties.OrchestratorVersion = ptr.To(version)
+	}
+}
+
 func sdkWithProvisioningState(state string) func(*containerservice.AgentPool) {
 	return func(pool *containerservice.AgentPool) {
 		pool.ManagedClusterAgentPoolProfileProperties.ProvisioningState = ptr.To(state)
 	}
 }
 
+func sdkWithNodeImageVersion(version string) func(*containerservice.AgentPool) {
+	return func(pool *containerservice.AgentPool) {
+		pool.ManagedClusterAgentPoolProfileProperties.NodeImageVersion = ptr.To(version)
+	}
+}
+
 func TestParameters(t *testing.T) {
 	testcases := []struct {
 		name          string
@@ -298,6 +334,24 @@ func TestParameters(t *testing.T) {
 			expected:      sdkFakeAgentPool(),
 			expectedError: nil,
 		},
+		{
+			name: "parameters with an existing user agent pool repurposed as a system agent pool",
+			spec: fakeAgentPool(func(pool *AgentPoolSpec) { pool.Mode = string(infrav1.NodePoolModeSystem) }),
+			existing: sdkFakeAgentPool(
+				func(pool *containerservice.AgentPool) { pool.Mode = containerservice.AgentPoolModeUser },
+				sdkWithProvisioningState("Succeeded"),
+			),
+			expected: sdkFakeAgentPool(func(pool *containerservice.AgentPool) { pool.Mode = containerservice.AgentPoolModeSystem }),
+		},
+		{
+			name: "parameters with an existing system agent pool repurposed as a user agent pool",
+			spec: fakeAgentPool(func(pool *AgentPoolSpec) { pool.Mode = string(infrav1.NodePoolModeUser) }),
+			existing: sdkFakeAgentPool(
+				func(pool *containerservice.AgentPool) { pool.Mode = containerservice.AgentPoolModeSystem },
+				sdkWithProvisioningState("Succeeded"),
+			),
+			expected: sdkFakeAgentPool(func(pool *containerservice.AgentPool) { pool.Mode = containerservice.AgentPoolModeUser }),
+		},
 		{
 			name: "parameters with an existing agent pool and update needed on node labels",
 			spec: fakeAgentPool(),
@@ -373,6 +427,56 @@ func TestParameters(t *testing.T) {
 			expected:      nil,
 			expectedError: nil,
 		},
+		{
+			name: "new agent pool is created at the control plane version while the control plane is upgraded",
+			spec: fakeAgentPool(
+				withVersion("1.26.3"),
+				withControlPlaneVersion("1.25.5"),
+			),
+			existing:      nil,
+			expected:      sdkFakeAgentPool(sdkWithOrchestratorVersion("1.25.5")),
+			expectedError: nil,
+		},
+		{
+			name: "existing agent pool is not upgraded before the control plane",
+			spec: fakeAgentPool(
+				withVersion("1.26.3"),
+				withControlPlaneVersion("1.25.5"),
+			),
+			existing: sdkFakeAgentPool(
+				sdkWithOrchestratorVersion("1.25.5"),
+				sdkWithProvisioningState("Succeeded"),
+			),
+			expected:      nil,
+			expectedError: nil,
+		},
+		{
+			name: "existing agent pool keeps its version on other updates while the control plane is upgraded",
+			spec: fakeAgentPool(
+				withVersion("1.26.3"),
+				withControlPlaneVersion("1.25.5"),
+			),
+			existing: sdkFakeAgentPool(
+				sdkWithOrchestratorVersion("1.25.5"),
+				sdkWithAutoscaling(false),
+				sdkWithProvisioningState("Succeeded"),
+			),
+			expected:      sdkFakeAgentPool(sdkWithOrchestratorVersion("1.25.5")),
+			expectedError: nil,
+		},
+		{
+			name: "existing agent pool is upgraded once the control plane is upgraded",
+			spec: fakeAgentPool(
+				withVersion("1.26.3"),
+				withControlPlaneVersion("1.26.3"),
+			),
+			existing: sdkFakeAgentPool(
+				sdkWithOrchestratorVersion("1.25.5"),
+				sdkWithProvisioningState("Succeeded"),
+			),
+			expected:      sdkFakeAgentPool(sdkWithOrchestratorVersion("1.26.3")),
+			expectedError: nil,
+		},
 	}
 	for _, tc := range testcases {
 		tc := tc
@@ -394,6 +498,51 @@ func TestParameters(t *testing.T) {
 	}
 }
 
+func TestScalingDown(t *testing.T) {
+	testcases := []struct {
+		name     string
+		spec     AgentPoolSpec
+		existing interface{}
+		expected bool
+	}{
+		{
+			name:     "new agent pool",
+			spec:     fakeAgentPool(withAutoscaling(false)),
+			existing: nil,
+			expected: false,
+		},
+		{
+			name:     "agent pool is scaled down",
+			spec:     fakeAgentPool(withAutoscaling(false), withDrainTimeoutInMinutes(30)),
+			existing: sdkFakeAgentPool(sdkWithAutoscaling(false), sdkWithCount(5), sdkWithProvisioningState("Succeeded")),
+			expected: true,
+		},
+		{
+			name:     "agent pool is scaled up",
+			spec:     fakeAgentPool(withAutoscaling(false), withReplicas(5)),
+			existing: sdkFakeAgentPool(sdkWithAutoscaling(false), sdkWithCount(1), sdkWithProvisioningState("Succeeded")),
+			expected: false,
+		},
+		{
+			name:     "agent pool count is handed over to the autoscaler",
+			spec:     fakeAgentPool(),
+			existing: sdkFakeAgentPool(sdkWithAutoscaling(false), sdkWithCount(5), sdkWithProvisioningState("Succeeded")),
+			expected: false,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			_, err := tc.spec.Parameters(context.TODO(), tc.existing)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(tc.spec.ScalingDown()).To(Equal(tc.expected))
+		})
+	}
+}
+
 func TestMergeSystemNodeLabels(t *testing.T) {
 	testcases := []struct {
 		name       string
@@ -25,6 +25,7 @@ import (
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/aso"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 )
 
 // GroupSpec defines the specification for a Resource Group.
@@ -59,12 +60,12 @@ func (s *GroupSpec) Parameters(ctx context.Context, object genruntime.MetaObject
 		},
 		Spec: asoresourcesv1.ResourceGroup_Spec{
 			Location: ptr.To(s.Location),
-			Tags: infrav1.Build(infrav1.BuildParams{
+			Tags: tags.Merge(tags.MergeParams{
 				ClusterName: s.ClusterName,
 				Lifecycle:   infrav1.ResourceLifecycleOwned,
 				Name:        ptr.To(s.Name),
 				Role:        ptr.To(infrav1.CommonRole),
-				Additional:  s.AdditionalTags,
+				ClusterTags: s.AdditionalTags,
 			}),
 		},
 	}, nil
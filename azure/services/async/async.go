@@ -112,26 +112,27 @@ func (s *Service) CreateOrUpdateResource(ctx context.Context, spec azure.Resourc
 		return processOngoingOperation(ctx, s.Scope, s.Creator, resourceName, serviceName, futureType)
 	}
 
-	// Get the resource if it already exists, and use it to construct the desired resource parameters.
-	var existingResource interface{}
-	if existing, err := s.Creator.Get(ctx, spec); err != nil && !azure.ResourceNotFound(err) {
-		errWrapped := errors.Wrapf(err, "failed to get existing resource %s/%s (service: %s)", rgName, resourceName, serviceName)
-		return nil, azure.WithTransientError(errWrapped, getRetryAfterFromError(err))
-	} else if err == nil {
-		existingResource = existing
-		log.V(2).Info("successfully got existing resource", "service", serviceName, "resource", resourceName, "resourceGroup", rgName)
-	}
-
-	// Construct parameters using the resource spec and information from the existing resource, if there is one.
-	parameters, err := spec.Parameters(ctx, existingResource)
+	// Compute the desired parameters from the resource spec and the existing resource, if there is one.
+	existingResource, parameters, err := s.getParameters(ctx, spec, serviceName, resourceName, rgName)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get desired parameters for resource %s/%s (service: %s)", rgName, resourceName, serviceName)
-	} else if parameters == nil {
+		return nil, err
+	}
+	logParametersDiff(ctx, serviceName, resourceName, rgName, existingResource, parameters)
+	if parameters == nil {
 		// Nothing to do, don't create or update the resource and return the existing resource.
 		log.V(2).Info("resource up to date", "service", serviceName, "resource", resourceName, "resourceGroup", rgName)
 		return existingResource, nil
 	}
 
+	if dryRunner, ok := s.Scope.(azure.DryRunner); ok && dryRunner.IsDryRun() {
+		// Report the change instead of applying it and return the resource as it is.
+		return existingResource, recordDryRunUpdate(ctx, dryRunner, serviceName, resourceName, rgName, existingResource, parameters)
+	}
+
+	if err := checkResourceLocked(s.Scope, serviceName, resourceName, rgName); err != nil {
+		return nil, err
+	}
+
 	// Create or update the resource with the desired parameters.
 	logMessageVerbPrefix := "creat"
 	if existingResource != nil {
@@ -147,8 +148,13 @@ func (s *Service) CreateOrUpdateResource(ctx context.Context, spec azure.Resourc
 			return nil, errWrapped
 		}
 		s.Scope.SetLongRunningOperationState(future)
+		clearResourceLocked(s.Scope, serviceName, resourceName, rgName)
 		return nil, azure.WithTransientError(azure.NewOperationNotDoneError(future), getRequeueAfterFromFuture(sdkFuture))
 	} else if err != nil {
+		if azure.ResourceLocked(err) {
+			setResourceLocked(ctx, s.Scope, serviceName, resourceName, rgName, err)
+			return nil, azure.WithTransientError(errWrapped, reconciler.DefaultResourceLockedRequeue)
+		}
 		// If it is an intermittent failure with context deadline exceeded or canceled as the reconciler could not complete
 		// in the max amount of time, mark it as a transient error and return.
 		if azure.IsContextDeadlineExceededOrCanceledError(ctx.Err()) {
@@ -157,10 +163,33 @@ func (s *Service) CreateOrUpdateResource(ctx context.Context, spec azure.Resourc
 		return nil, errWrapped
 	}
 
+	clearResourceLocked(s.Scope, serviceName, resourceName, rgName)
 	log.V(2).Info(fmt.Sprintf("successfully %sed resource", logMessageVerbPrefix), "service", serviceName, "resource", resourceName, "resourceGroup", rgName)
 	return result, nil
 }
 
+// getParameters gets the resource if it already exists and uses it to construct the desired resource parameters.
+// It does not make any mutating call to Azure. A nil parameters value means the resource is up to date.
+func (s *Service) getParameters(ctx context.Context, spec azure.ResourceSpecGetter, serviceName, resourceName, rgName string) (existingResource interface{}, parameters interface{}, err error) {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "async.Service.getParameters")
+	defer done()
+
+	if existing, err := s.Creator.Get(ctx, spec); err != nil && !azure.ResourceNotFound(err) {
+		errWrapped := errors.Wrapf(err, "failed to get existing resource %s/%s (service: %s)", rgName, resourceName, serviceName)
+		return nil, nil, azure.WithTransientError(errWrapped, getRetryAfterFromError(err))
+	} else if err == nil {
+		existingResource = existing
+		log.V(2).Info("successfully got existing resource", "service", serviceName, "resource", resourceName, "resourceGroup", rgName)
+	}
+
+	// Construct parameters using the resource spec and information from the existing resource, if there is one.
+	parameters, err = spec.Parameters(ctx, existingResource)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get desired parameters for resource %s/%s (service: %s)", rgName, resourceName, serviceName)
+	}
+	return existingResource, parameters, nil
+}
+
 // DeleteResource implements the logic for deleting a resource Asynchronously.
 func (s *Service) DeleteResource(ctx context.Context, spec azure.ResourceSpecGetter, serviceName string) (err error) {
 	ctx, log, done := tele.StartSpanWithLogger(ctx, "async.Service.DeleteResource")
@@ -177,6 +206,17 @@ func (s *Service) DeleteResource(ctx context.Context, spec azure.ResourceSpecGet
 		return err
 	}
 
+	if dryRunner, ok := s.Scope.(azure.DryRunner); ok && dryRunner.IsDryRun() {
+		// Report the deletion instead of applying it. An error is returned so that the owner of the resource is not
+		// considered deleted while dry-run mode is enabled.
+		recordDryRunDelete(ctx, dryRunner, serviceName, resourceName, rgName)
+		return azure.WithTransientError(errors.Errorf("skipped deleting resource %s/%s (service: %s) in dry-run mode", rgName, resourceName, serviceName), reconciler.DefaultReconcilerRequeue)
+	}
+
+	if err := checkResourceLocked(s.Scope, serviceName, resourceName, rgName); err != nil {
+		return err
+	}
+
 	// No long running operation is active, so delete the resource.
 	log.V(2).Info("deleting resource", "service", serviceName, "resource", resourceName, "resourceGroup", rgName)
 	sdkFuture, err := s.Deleter.DeleteAsync(ctx, spec)
@@ -187,12 +227,18 @@ func (s *Service) DeleteResource(ctx context.Context, spec azure.ResourceSpecGet
 			return errors.Wrapf(err, "failed to delete resource %s/%s (service: %s)", rgName, resourceName, serviceName)
 		}
 		s.Scope.SetLongRunningOperationState(future)
+		clearResourceLocked(s.Scope, serviceName, resourceName, rgName)
 		return azure.WithTransientError(azure.NewOperationNotDoneError(future), getRequeueAfterFromFuture(sdkFuture))
 	} else if err != nil {
 		if azure.ResourceNotFound(err) {
 			// already deleted
+			clearResourceLocked(s.Scope, serviceName, resourceName, rgName)
 			return nil
 		}
+		if azure.ResourceLocked(err) {
+			setResourceLocked(ctx, s.Scope, serviceName, resourceName, rgName, err)
+			return azure.WithTransientError(errors.Wrapf(err, "failed to delete resource %s/%s (service: %s)", rgName, resourceName, serviceName), reconciler.DefaultResourceLockedRequeue)
+		}
 		// If it is an intermittent failure with context deadline exceeded or canceled as the reconciler could not complete
 		// in the max amount of time, mark it as a transient error and return.
 		if azure.IsContextDeadlineExceededOrCanceledError(ctx.Err()) {
@@ -201,10 +247,47 @@ func (s *Service) DeleteResource(ctx context.Context, spec azure.ResourceSpecGet
 		return errors.Wrapf(err, "failed to delete resource %s/%s (service: %s)", rgName, resourceName, serviceName)
 	}
 
+	clearResourceLocked(s.Scope, serviceName, resourceName, rgName)
 	log.V(2).Info("successfully deleted resource", "service", serviceName, "resource", resourceName, "resourceGroup", rgName)
 	return nil
 }
 
+// checkResourceLocked returns a transient error if the scope reported the resource as locked by a management lock less
+// than DefaultResourceLockedRequeue ago, so that it is not changed again before the lock may have been removed.
+func checkResourceLocked(scope FutureScope, serviceName, resourceName, rgName string) error {
+	reporter, ok := scope.(azure.ResourceLockReporter)
+	if !ok {
+		return nil
+	}
+	since, locked := reporter.ResourceLockedSince(serviceName, resourceName, rgName)
+	if !locked {
+		return nil
+	}
+	retryAt := since.Add(reconciler.DefaultResourceLockedRequeue)
+	if wait := time.Until(retryAt); wait > 0 {
+		return azure.WithTransientError(errors.Errorf("resource %s/%s (service: %s) is locked, not changing it before %s", rgName, resourceName, serviceName, retryAt.Format(time.RFC3339)), wait)
+	}
+	return nil
+}
+
+// setResourceLocked reports the resource as locked by a management lock if the scope supports it.
+func setResourceLocked(ctx context.Context, scope FutureScope, serviceName, resourceName, rgName string, err error) {
+	_, log, done := tele.StartSpanWithLogger(ctx, "async.setResourceLocked")
+	defer done()
+
+	log.V(2).Info("resource is locked by a management lock", "service", serviceName, "resource", resourceName, "resourceGroup", rgName, "error", err.Error())
+	if reporter, ok := scope.(azure.ResourceLockReporter); ok {
+		reporter.SetResourceLocked(serviceName, resourceName, rgName)
+	}
+}
+
+// clearResourceLocked reports the resource as no longer locked if the scope supports it.
+func clearResourceLocked(scope FutureScope, serviceName, resourceName, rgName string) {
+	if reporter, ok := scope.(azure.ResourceLockReporter); ok {
+		reporter.ClearResourceLocked(serviceName, resourceName, rgName)
+	}
+}
+
 // getRequeueAfterFromFuture returns the max between the `RETRY-AFTER` header and the default requeue time.
 // This ensures we respect the retry-after header if it is set and avoid retrying too often during an API throttling event.
 func getRequeueAfterFromFuture(sdkFuture azureautorest.FutureAPI) time.Duration {
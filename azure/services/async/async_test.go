@@ -26,13 +26,18 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources"
 	"github.com/Azure/go-autorest/autorest"
 	azureautorest "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/go-logr/logr/funcr"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/mock_azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async/mock_async"
 	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
 	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 var (
@@ -62,6 +67,10 @@ var (
 	fakeInternalError      = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
 	fakeNotFoundError      = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusNotFound}, "Not Found")
 	errCtxExceeded         = errors.New("ctx exceeded")
+	fakeScopeLockedError   = autorest.DetailedError{
+		StatusCode: http.StatusConflict,
+		Original:   &azureautorest.RequestError{ServiceError: &azureautorest.ServiceError{Code: "ScopeLocked"}},
+	}
 )
 
 // TestProcessOngoingOperation tests the processOngoingOperation function.
@@ -437,6 +446,237 @@ func TestDeleteResource(t *testing.T) {
 	}
 }
 
+// dryRunFutureScope is a FutureScope that can reconcile in dry-run mode.
+type dryRunFutureScope struct {
+	*mock_async.MockFutureScope
+	*mock_azure.MockDryRunner
+}
+
+// TestCreateOrUpdateResourceDryRun tests that the CreateOrUpdateResource function does not create or update resources in dry-run mode.
+func TestCreateOrUpdateResourceDryRun(t *testing.T) {
+	testcases := []struct {
+		name           string
+		existing       interface{}
+		getErr         error
+		parameters     interface{}
+		expectedResult interface{}
+		expectedAction string
+		expectedDiff   []string
+	}{
+		{
+			name:           "resource that does not exist is not created",
+			getErr:         fakeNotFoundError,
+			parameters:     resources.GenericResource{Location: ptr.To("westus")},
+			expectedAction: "created",
+			expectedDiff:   []string{"location"},
+		},
+		{
+			name:     "existing resource is not updated",
+			existing: resources.GenericResource{Location: ptr.To("westus")},
+			parameters: resources.GenericResource{
+				Location: ptr.To("westus"),
+				Tags:     map[string]*string{"env": ptr.To("prod")},
+			},
+			expectedResult: resources.GenericResource{Location: ptr.To("westus")},
+			expectedAction: "updated",
+			expectedDiff:   []string{"tags"},
+		},
+		{
+			name:           "resource up to date does not report a change",
+			existing:       resources.GenericResource{Location: ptr.To("westus")},
+			expectedResult: resources.GenericResource{Location: ptr.To("westus")},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := dryRunFutureScope{
+				MockFutureScope: mock_async.NewMockFutureScope(mockCtrl),
+				MockDryRunner:   mock_azure.NewMockDryRunner(mockCtrl),
+			}
+			creatorMock := mock_async.NewMockCreator(mockCtrl)
+			specMock := mock_azure.NewMockResourceSpecGetter(mockCtrl)
+
+			specMock.EXPECT().ResourceName().Return("test-resource")
+			specMock.EXPECT().ResourceGroupName().Return("test-group")
+			scopeMock.MockFutureScope.EXPECT().GetLongRunningOperationState("test-resource", "test-service", infrav1.PutFuture).Return(nil)
+			creatorMock.EXPECT().Get(gomockinternal.AContext(), specMock).Return(tc.existing, tc.getErr)
+			specMock.EXPECT().Parameters(gomockinternal.AContext(), tc.existing).Return(tc.parameters, nil)
+			// CreateOrUpdateAsync must not be called.
+			var diff string
+			if tc.parameters != nil {
+				scopeMock.MockDryRunner.EXPECT().IsDryRun().Return(true)
+				scopeMock.MockDryRunner.EXPECT().RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason, gomock.Any(),
+					"test-group", "test-resource", "test-service", tc.expectedAction, gomock.Any()).
+					Do(func(_, _, _ string, args ...interface{}) {
+						diff = args[4].(string)
+					})
+			}
+
+			s := New(scopeMock, creatorMock, nil)
+			result, err := s.CreateOrUpdateResource(context.TODO(), specMock, "test-service")
+			g.Expect(err).NotTo(HaveOccurred())
+			if tc.expectedResult == nil {
+				g.Expect(result).To(BeNil())
+			} else {
+				g.Expect(result).To(Equal(tc.expectedResult))
+			}
+			for _, expected := range tc.expectedDiff {
+				g.Expect(diff).To(ContainSubstring(expected))
+			}
+		})
+	}
+}
+
+// TestDeleteResourceDryRun tests that the DeleteResource function does not delete resources in dry-run mode.
+func TestDeleteResourceDryRun(t *testing.T) {
+	g := NewWithT(t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scopeMock := dryRunFutureScope{
+		MockFutureScope: mock_async.NewMockFutureScope(mockCtrl),
+		MockDryRunner:   mock_azure.NewMockDryRunner(mockCtrl),
+	}
+	deleterMock := mock_async.NewMockDeleter(mockCtrl)
+	specMock := mock_azure.NewMockResourceSpecGetter(mockCtrl)
+
+	specMock.EXPECT().ResourceName().Return("test-resource")
+	specMock.EXPECT().ResourceGroupName().Return("test-group")
+	scopeMock.MockFutureScope.EXPECT().GetLongRunningOperationState("test-resource", "test-service", infrav1.DeleteFuture).Return(nil)
+	scopeMock.MockDryRunner.EXPECT().IsDryRun().Return(true)
+	scopeMock.MockDryRunner.EXPECT().RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason, gomock.Any(), "test-group", "test-resource", "test-service")
+	// DeleteAsync must not be called.
+
+	s := New(scopeMock, nil, deleterMock)
+	err := s.DeleteResource(context.TODO(), specMock, "test-service")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("skipped deleting resource test-group/test-resource (service: test-service) in dry-run mode"))
+	var reconcileError azure.ReconcileError
+	g.Expect(errors.As(err, &reconcileError)).To(BeTrue())
+	g.Expect(reconcileError.IsTransient()).To(BeTrue())
+}
+
+// lockReporterFutureScope is a FutureScope that reports the resources locked by a management lock.
+type lockReporterFutureScope struct {
+	*mock_async.MockFutureScope
+	*mock_azure.MockResourceLockReporter
+}
+
+// TestCreateOrUpdateResourceLocked tests that the CreateOrUpdateResource function stops updating a resource locked by a
+// management lock until the lock may have been removed.
+func TestCreateOrUpdateResourceLocked(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expect        func(s *mock_azure.MockResourceLockReporterMockRecorder, c *mock_async.MockCreatorMockRecorder)
+		expectedError string
+		expectedAfter time.Duration
+	}{
+		{
+			name: "lock error reports the resource as locked",
+			expect: func(s *mock_azure.MockResourceLockReporterMockRecorder, c *mock_async.MockCreatorMockRecorder) {
+				s.ResourceLockedSince("test-service", "test-resource", "test-group").Return(time.Time{}, false)
+				c.CreateOrUpdateAsync(gomockinternal.AContext(), gomock.AssignableToTypeOf(&mock_azure.MockResourceSpecGetter{}), fakeResourceParameters).Return(nil, nil, fakeScopeLockedError)
+				s.SetResourceLocked("test-service", "test-resource", "test-group")
+			},
+			expectedError: "failed to update resource test-group/test-resource (service: test-service)",
+			expectedAfter: reconciler.DefaultResourceLockedRequeue,
+		},
+		{
+			name: "recently locked resource is not updated",
+			expect: func(s *mock_azure.MockResourceLockReporterMockRecorder, c *mock_async.MockCreatorMockRecorder) {
+				s.ResourceLockedSince("test-service", "test-resource", "test-group").Return(time.Now().Add(-time.Minute), true)
+				// CreateOrUpdateAsync must not be called.
+			},
+			expectedError: "resource test-group/test-resource (service: test-service) is locked, not changing it before",
+		},
+		{
+			name: "resource locked a while ago is updated again and no longer reported as locked",
+			expect: func(s *mock_azure.MockResourceLockReporterMockRecorder, c *mock_async.MockCreatorMockRecorder) {
+				s.ResourceLockedSince("test-service", "test-resource", "test-group").Return(time.Now().Add(-reconciler.DefaultResourceLockedRequeue), true)
+				c.CreateOrUpdateAsync(gomockinternal.AContext(), gomock.AssignableToTypeOf(&mock_azure.MockResourceSpecGetter{}), fakeResourceParameters).Return(fakeResourceParameters, nil, nil)
+				s.ClearResourceLocked("test-service", "test-resource", "test-group")
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := lockReporterFutureScope{
+				MockFutureScope:          mock_async.NewMockFutureScope(mockCtrl),
+				MockResourceLockReporter: mock_azure.NewMockResourceLockReporter(mockCtrl),
+			}
+			creatorMock := mock_async.NewMockCreator(mockCtrl)
+			specMock := mock_azure.NewMockResourceSpecGetter(mockCtrl)
+
+			specMock.EXPECT().ResourceName().Return("test-resource")
+			specMock.EXPECT().ResourceGroupName().Return("test-group")
+			scopeMock.MockFutureScope.EXPECT().GetLongRunningOperationState("test-resource", "test-service", infrav1.PutFuture).Return(nil)
+			creatorMock.EXPECT().Get(gomockinternal.AContext(), specMock).Return(fakeExistingResource, nil)
+			specMock.EXPECT().Parameters(gomockinternal.AContext(), fakeExistingResource).Return(fakeResourceParameters, nil)
+			tc.expect(scopeMock.MockResourceLockReporter.EXPECT(), creatorMock.EXPECT())
+
+			s := New(scopeMock, creatorMock, nil)
+			_, err := s.CreateOrUpdateResource(context.TODO(), specMock, "test-service")
+			if tc.expectedError == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(err.Error()).To(ContainSubstring(tc.expectedError))
+			var reconcileError azure.ReconcileError
+			g.Expect(errors.As(err, &reconcileError)).To(BeTrue())
+			g.Expect(reconcileError.IsTransient()).To(BeTrue())
+			g.Expect(reconcileError.RequeueAfter()).To(BeNumerically(">", 0))
+			if tc.expectedAfter != 0 {
+				g.Expect(reconcileError.RequeueAfter()).To(Equal(tc.expectedAfter))
+			}
+		})
+	}
+}
+
+// TestDeleteResourceLocked tests that the DeleteResource function reports a resource locked by a management lock.
+func TestDeleteResourceLocked(t *testing.T) {
+	g := NewWithT(t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scopeMock := lockReporterFutureScope{
+		MockFutureScope:          mock_async.NewMockFutureScope(mockCtrl),
+		MockResourceLockReporter: mock_azure.NewMockResourceLockReporter(mockCtrl),
+	}
+	deleterMock := mock_async.NewMockDeleter(mockCtrl)
+	specMock := mock_azure.NewMockResourceSpecGetter(mockCtrl)
+
+	specMock.EXPECT().ResourceName().Return("test-resource")
+	specMock.EXPECT().ResourceGroupName().Return("test-group")
+	scopeMock.MockFutureScope.EXPECT().GetLongRunningOperationState("test-resource", "test-service", infrav1.DeleteFuture).Return(nil)
+	scopeMock.MockResourceLockReporter.EXPECT().ResourceLockedSince("test-service", "test-resource", "test-group").Return(time.Time{}, false)
+	deleterMock.EXPECT().DeleteAsync(gomockinternal.AContext(), specMock).Return(nil, fakeScopeLockedError)
+	scopeMock.MockResourceLockReporter.EXPECT().SetResourceLocked("test-service", "test-resource", "test-group")
+
+	s := New(scopeMock, nil, deleterMock)
+	err := s.DeleteResource(context.TODO(), specMock, "test-service")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("failed to delete resource test-group/test-resource (service: test-service)"))
+	var reconcileError azure.ReconcileError
+	g.Expect(errors.As(err, &reconcileError)).To(BeTrue())
+	g.Expect(reconcileError.IsTransient()).To(BeTrue())
+	g.Expect(reconcileError.RequeueAfter()).To(Equal(reconciler.DefaultResourceLockedRequeue))
+}
+
 func TestGetRetryAfterFromError(t *testing.T) {
 	cases := []struct {
 		name                   string
@@ -519,3 +759,68 @@ func TestGetRetryAfterFromError(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateOrUpdateResourceLogsParametersDiff(t *testing.T) {
+	existing := &resources.GenericResource{
+		Location: ptr.To("westus"),
+		Tags:     map[string]*string{"owner": ptr.To("capz")},
+	}
+	testcases := []struct {
+		name            string
+		parameters      interface{}
+		expectedMessage string
+		expectedFields  string
+	}{
+		{
+			name:            "up-to-date resource",
+			parameters:      nil,
+			expectedMessage: "no changes between the existing resource and the desired parameters",
+		},
+		{
+			name: "changed resource",
+			parameters: &resources.GenericResource{
+				Location: ptr.To("westus"),
+				Tags:     map[string]*string{"owner": ptr.To("capi"), "team": ptr.To("infra")},
+			},
+			expectedMessage: "changes between the existing resource and the desired parameters",
+			expectedFields:  `"changedFields"=["tags.owner","tags.team"]`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_async.NewMockFutureScope(mockCtrl)
+			creatorMock := mock_async.NewMockCreator(mockCtrl)
+			specMock := mock_azure.NewMockResourceSpecGetter(mockCtrl)
+
+			specMock.EXPECT().ResourceName().Return("test-resource")
+			specMock.EXPECT().ResourceGroupName().Return("test-group")
+			scopeMock.EXPECT().GetLongRunningOperationState("test-resource", "test-service", infrav1.PutFuture).Return(nil)
+			creatorMock.EXPECT().Get(gomockinternal.AContext(), gomock.AssignableToTypeOf(&mock_azure.MockResourceSpecGetter{})).Return(existing, nil)
+			specMock.EXPECT().Parameters(gomockinternal.AContext(), existing).Return(tc.parameters, nil)
+			if tc.parameters != nil {
+				creatorMock.EXPECT().CreateOrUpdateAsync(gomockinternal.AContext(), gomock.AssignableToTypeOf(&mock_azure.MockResourceSpecGetter{}), tc.parameters).Return(existing, nil, nil)
+			}
+
+			var logs []string
+			logger := funcr.New(func(prefix, args string) {
+				logs = append(logs, args)
+			}, funcr.Options{Verbosity: 4})
+			ctx := log.IntoContext(context.Background(), logger)
+
+			s := New(scopeMock, creatorMock, nil)
+			_, err := s.CreateOrUpdateResource(ctx, specMock, "test-service")
+			g.Expect(err).NotTo(HaveOccurred())
+
+			g.Expect(logs).To(ContainElement(And(
+				ContainSubstring(`"msg"="%s"`, tc.expectedMessage),
+				ContainSubstring(`"level"=4`),
+				ContainSubstring(tc.expectedFields),
+			)))
+		})
+	}
+}
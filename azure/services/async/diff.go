@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package async
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// logParametersDiff logs the fields of the desired parameters of an existing resource which differ from the resource at
+// debug level, to help understand why a resource is updated. Only the paths of the fields are logged, as their values
+// may be secrets, e.g. the custom data of a VM.
+func logParametersDiff(ctx context.Context, serviceName, resourceName, rgName string, existing, parameters interface{}) {
+	_, log, done := tele.StartSpanWithLogger(ctx, "async.logParametersDiff")
+	defer done()
+
+	if existing == nil || !log.V(4).Enabled() {
+		return
+	}
+	if parameters == nil {
+		log.V(4).Info("no changes between the existing resource and the desired parameters", "service", serviceName, "resource", resourceName, "resourceGroup", rgName)
+		return
+	}
+	fields, err := changedFields(existing, parameters)
+	if err != nil {
+		log.V(4).Info("failed to compute the changes to the existing resource", "service", serviceName, "resource", resourceName, "resourceGroup", rgName, "error", err.Error())
+		return
+	}
+	log.V(4).Info("changes between the existing resource and the desired parameters", "service", serviceName, "resource", resourceName, "resourceGroup", rgName, "changedFields", fields)
+}
+
+// changedFields returns the sorted paths of the fields set in the desired parameters which differ from the existing
+// resource, e.g. properties.hardwareProfile.vmSize. Both are compared through their JSON representation, which is what
+// is sent to Azure, so that it works with any SDK parameters struct. Fields only set in the existing resource, e.g.
+// read-only fields, are ignored.
+func changedFields(existing, parameters interface{}) ([]string, error) {
+	existingMap, err := toJSONMap(existing)
+	if err != nil {
+		return nil, err
+	}
+	parametersMap, err := toJSONMap(parameters)
+	if err != nil {
+		return nil, err
+	}
+	if existingMap == nil {
+		// Every field of a resource that doesn't exist yet is changed.
+		existingMap = map[string]interface{}{}
+	}
+	fields := []string{}
+	collectChangedFields("", existingMap, parametersMap, &fields)
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// collectChangedFields appends the paths of the values of desired which differ from existing to fields. Objects are
+// compared field by field and arrays of the same length element by element.
+func collectChangedFields(path string, existing, desired interface{}, fields *[]string) {
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		existingValue, ok := existing.(map[string]interface{})
+		if !ok {
+			*fields = append(*fields, path)
+			return
+		}
+		for key, value := range desiredValue {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			collectChangedFields(fieldPath, existingValue[key], value, fields)
+		}
+	case []interface{}:
+		existingValue, ok := existing.([]interface{})
+		if !ok || len(existingValue) != len(desiredValue) {
+			*fields = append(*fields, path)
+			return
+		}
+		for i := range desiredValue {
+			collectChangedFields(fmt.Sprintf("%s[%d]", path, i), existingValue[i], desiredValue[i], fields)
+		}
+	default:
+		if !reflect.DeepEqual(existing, desired) {
+			*fields = append(*fields, path)
+		}
+	}
+}
+
+// toJSONMap returns the JSON representation of a resource as a map, or nil for a nil resource.
+func toJSONMap(resource interface{}) (map[string]interface{}, error) {
+	if resource == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal %T", resource)
+	}
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal %T", resource)
+	}
+	return out, nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package async
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+)
+
+func TestChangedFields(t *testing.T) {
+	existing := network.SecurityGroup{
+		ID:       ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkSecurityGroups/my-nsg"),
+		Location: ptr.To("westus"),
+		SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+			ProvisioningState: network.ProvisioningStateSucceeded,
+			SecurityRules: &[]network.SecurityRule{
+				{
+					Name: ptr.To("allow_ssh"),
+					SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+						Priority: ptr.To[int32](100),
+						Access:   network.SecurityRuleAccessAllow,
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		parameters interface{}
+		expected   []string
+	}{
+		{
+			name: "no changes, ignoring the read-only fields of the existing resource",
+			parameters: network.SecurityGroup{
+				Location: ptr.To("westus"),
+				SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+					SecurityRules: &[]network.SecurityRule{
+						{
+							Name: ptr.To("allow_ssh"),
+							SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+								Priority: ptr.To[int32](100),
+								Access:   network.SecurityRuleAccessAllow,
+							},
+						},
+					},
+				},
+			},
+			expected: []string{},
+		},
+		{
+			name: "changed field of an array element",
+			parameters: network.SecurityGroup{
+				Location: ptr.To("westus"),
+				SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+					SecurityRules: &[]network.SecurityRule{
+						{
+							Name: ptr.To("allow_ssh"),
+							SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+								Priority: ptr.To[int32](200),
+								Access:   network.SecurityRuleAccessDeny,
+							},
+						},
+					},
+				},
+			},
+			expected: []string{
+				"properties.securityRules[0].properties.access",
+				"properties.securityRules[0].properties.priority",
+			},
+		},
+		{
+			name: "added array element and new field",
+			parameters: network.SecurityGroup{
+				Location: ptr.To("westus"),
+				Tags:     map[string]*string{"owner": ptr.To("capz")},
+				SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+					SecurityRules: &[]network.SecurityRule{
+						{Name: ptr.To("allow_ssh")},
+						{Name: ptr.To("allow_https")},
+					},
+				},
+			},
+			expected: []string{
+				"properties.securityRules",
+				"tags",
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			fields, err := changedFields(existing, tc.parameters)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(fields).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package async
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// maxDryRunEventDiffLength is the maximum length of the changed fields included in a dry-run event, they are all
+// logged.
+const maxDryRunEventDiffLength = 800
+
+// recordDryRunUpdate reports the creation or update of a resource that would be made outside of dry-run mode.
+func recordDryRunUpdate(ctx context.Context, dryRunner azure.DryRunner, serviceName, resourceName, rgName string, existing, parameters interface{}) error {
+	_, log, done := tele.StartSpanWithLogger(ctx, "async.recordDryRunUpdate")
+	defer done()
+
+	fields, err := changedFields(existing, parameters)
+	if err != nil {
+		return errors.Wrapf(err, "failed to compute the changes to resource %s/%s (service: %s)", rgName, resourceName, serviceName)
+	}
+
+	action := "created"
+	if existing != nil {
+		action = "updated"
+	}
+	log.Info("dry run: resource would be "+action, "service", serviceName, "resource", resourceName, "resourceGroup", rgName, "changedFields", fields)
+	diff := strings.Join(fields, ", ")
+	if len(diff) > maxDryRunEventDiffLength {
+		diff = diff[:maxDryRunEventDiffLength] + "...(truncated)"
+	}
+	dryRunner.RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason, "Dry run: resource %s/%s (service: %s) would be %s, changed fields: %s", rgName, resourceName, serviceName, action, diff)
+	return nil
+}
+
+// recordDryRunDelete reports the deletion of a resource that would be made outside of dry-run mode.
+func recordDryRunDelete(ctx context.Context, dryRunner azure.DryRunner, serviceName, resourceName, rgName string) {
+	_, log, done := tele.StartSpanWithLogger(ctx, "async.recordDryRunDelete")
+	defer done()
+
+	log.Info("dry run: resource would be deleted", "service", serviceName, "resource", resourceName, "resourceGroup", rgName)
+	dryRunner.RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason, "Dry run: resource %s/%s (service: %s) would be deleted", rgName, resourceName, serviceName)
+}
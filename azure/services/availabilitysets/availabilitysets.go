@@ -25,6 +25,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+	azureutil "sigs.k8s.io/cluster-api-provider-azure/util/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
@@ -36,6 +37,14 @@ type AvailabilitySetScope interface {
 	azure.ClusterDescriber
 	azure.AsyncStatusUpdater
 	AvailabilitySetSpec() azure.ResourceSpecGetter
+	ProviderID() string
+	FaultDomain() *int32
+	SetFaultDomain(int32)
+}
+
+// faultDomainGetter gets the platform fault domain of a VM.
+type faultDomainGetter interface {
+	VMFaultDomain(ctx context.Context, resourceGroupName, vmName string) (*int32, error)
 }
 
 // Service provides operations on Azure resources.
@@ -43,17 +52,19 @@ type Service struct {
 	Scope AvailabilitySetScope
 	async.Getter
 	async.Reconciler
-	resourceSKUCache *resourceskus.Cache
+	resourceSKUCache  *resourceskus.Cache
+	faultDomainGetter faultDomainGetter
 }
 
 // New creates a new availability sets service.
 func New(scope AvailabilitySetScope, skuCache *resourceskus.Cache) *Service {
 	client := NewClient(scope)
 	return &Service{
-		Scope:            scope,
-		Getter:           client,
-		resourceSKUCache: skuCache,
-		Reconciler:       async.New(scope, client, client),
+		Scope:             scope,
+		Getter:            client,
+		resourceSKUCache:  skuCache,
+		Reconciler:        async.New(scope, client, client),
+		faultDomainGetter: client,
 	}
 }
 
@@ -72,7 +83,17 @@ func (s *Service) Reconcile(ctx context.Context) error {
 
 	var err error
 	if setSpec := s.Scope.AvailabilitySetSpec(); setSpec != nil {
-		_, err = s.CreateOrUpdateResource(ctx, setSpec, serviceName)
+		if isExisting(setSpec) {
+			// the VM joins an existing availability set which is not created, only checked for existence.
+			if _, err = s.Get(ctx, setSpec); err != nil {
+				err = errors.Wrapf(err, "failed to get existing availability set %s in resource group %s", setSpec.ResourceName(), setSpec.ResourceGroupName())
+			}
+		} else {
+			_, err = s.CreateOrUpdateResource(ctx, setSpec, serviceName)
+		}
+		if err == nil {
+			s.reconcileFaultDomain(ctx)
+		}
 	} else {
 		log.V(2).Info("skip creation when no availability set spec is found")
 		return nil
@@ -82,6 +103,36 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	return err
 }
 
+// reconcileFaultDomain records the fault domain of the availability set the VM is placed in, once the VM exists. Failing
+// to get the fault domain does not prevent the VM from being reconciled.
+func (s *Service) reconcileFaultDomain(ctx context.Context) {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "availabilitysets.Service.reconcileFaultDomain")
+	defer done()
+
+	if s.Scope.FaultDomain() != nil {
+		return
+	}
+	providerID := s.Scope.ProviderID()
+	if providerID == "" {
+		// the VM is not created yet.
+		return
+	}
+
+	resourceID, err := azureutil.ParseResourceID(providerID)
+	if err != nil {
+		log.V(2).Info("failed to parse the provider ID of the VM", "providerID", providerID, "error", err.Error())
+		return
+	}
+	faultDomain, err := s.faultDomainGetter.VMFaultDomain(ctx, resourceID.ResourceGroupName, resourceID.Name)
+	if err != nil {
+		log.V(2).Info("failed to get the fault domain of the VM", "vm", resourceID.Name, "error", err.Error())
+		return
+	}
+	if faultDomain != nil {
+		s.Scope.SetFaultDomain(*faultDomain)
+	}
+}
+
 // Delete deletes availability sets.
 func (s *Service) Delete(ctx context.Context) error {
 	ctx, log, done := tele.StartSpanWithLogger(ctx, "availabilitysets.Service.Delete")
@@ -96,6 +147,10 @@ func (s *Service) Delete(ctx context.Context) error {
 		log.V(2).Info("skip deletion when no availability set spec is found")
 		return nil
 	}
+	if isExisting(setSpec) {
+		log.V(2).Info("skip deletion of existing availability set", "availability set", setSpec.ResourceName())
+		return nil
+	}
 
 	existingSet, err := s.Get(ctx, setSpec)
 	if err != nil {
@@ -120,7 +175,16 @@ func (s *Service) Delete(ctx context.Context) error {
 	return resultingErr
 }
 
-// IsManaged returns always returns true as CAPZ does not support BYO availability set.
+// IsManaged returns false if the VM joins an existing availability set, which is neither created nor deleted by CAPZ.
 func (s *Service) IsManaged(ctx context.Context) (bool, error) {
+	if setSpec := s.Scope.AvailabilitySetSpec(); setSpec != nil {
+		return !isExisting(setSpec), nil
+	}
 	return true, nil
 }
+
+// isExisting returns true if the spec is for an existing availability set.
+func isExisting(setSpec azure.ResourceSpecGetter) bool {
+	spec, ok := setSpec.(*AvailabilitySetSpec)
+	return ok && spec.Existing
+}
@@ -61,9 +61,17 @@ var (
 		SKU:            nil,
 		AdditionalTags: map[string]string{},
 	}
+	fakeExistingSetSpec = AvailabilitySetSpec{
+		Name:          "existing-as",
+		ResourceGroup: "existing-rg",
+		ClusterName:   "test-cluster",
+		Location:      "test-location",
+		Existing:      true,
+	}
 	internalError  = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
 	parameterError = errors.Errorf("some error with parameters")
 	notFoundError  = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusNotFound}, "Not Found")
+	fakeProviderID = "azure:///subscriptions/123/resourceGroups/test-rg/providers/Microsoft.Compute/virtualMachines/test-vm"
 	fakeSetWithVMs = compute.AvailabilitySet{
 		AvailabilitySetProperties: &compute.AvailabilitySetProperties{
 			VirtualMachines: &[]compute.SubResource{
@@ -77,28 +85,65 @@ func TestReconcileAvailabilitySets(t *testing.T) {
 	testcases := []struct {
 		name          string
 		expectedError string
-		expect        func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+		expect        func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, f *mock_availabilitysets.MockfaultDomainGetterMockRecorder)
 	}{
 		{
 			name:          "create or update availability set",
 			expectedError: "",
-			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, f *mock_availabilitysets.MockfaultDomainGetterMockRecorder) {
+				s.AvailabilitySetSpec().Return(&fakeSetSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeSetSpec, serviceName).Return(nil, nil)
+				s.FaultDomain().Return(nil)
+				s.ProviderID().Return("")
+				s.UpdatePutStatus(infrav1.AvailabilitySetReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "record the fault domain of the VM once it exists",
+			expectedError: "",
+			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, f *mock_availabilitysets.MockfaultDomainGetterMockRecorder) {
+				s.AvailabilitySetSpec().Return(&fakeSetSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeSetSpec, serviceName).Return(nil, nil)
+				s.FaultDomain().Return(nil)
+				s.ProviderID().Return(fakeProviderID)
+				f.VMFaultDomain(gomockinternal.AContext(), "test-rg", "test-vm").Return(ptr.To[int32](1), nil)
+				s.SetFaultDomain(int32(1))
+				s.UpdatePutStatus(infrav1.AvailabilitySetReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "skip getting the fault domain once the fault domain of the VM is recorded",
+			expectedError: "",
+			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, f *mock_availabilitysets.MockfaultDomainGetterMockRecorder) {
+				s.AvailabilitySetSpec().Return(&fakeSetSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeSetSpec, serviceName).Return(nil, nil)
+				s.FaultDomain().Return(ptr.To[int32](1))
+				s.UpdatePutStatus(infrav1.AvailabilitySetReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "failing to get the fault domain does not fail the reconciliation",
+			expectedError: "",
+			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, f *mock_availabilitysets.MockfaultDomainGetterMockRecorder) {
 				s.AvailabilitySetSpec().Return(&fakeSetSpec)
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeSetSpec, serviceName).Return(nil, nil)
+				s.FaultDomain().Return(nil)
+				s.ProviderID().Return(fakeProviderID)
+				f.VMFaultDomain(gomockinternal.AContext(), "test-rg", "test-vm").Return(nil, internalError)
 				s.UpdatePutStatus(infrav1.AvailabilitySetReadyCondition, serviceName, nil)
 			},
 		},
 		{
 			name:          "noop if no availability set spec returns nil",
 			expectedError: "",
-			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, f *mock_availabilitysets.MockfaultDomainGetterMockRecorder) {
 				s.AvailabilitySetSpec().Return(nil)
 			},
 		},
 		{
 			name:          "missing required value in availability set spec",
 			expectedError: "some error with parameters",
-			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, f *mock_availabilitysets.MockfaultDomainGetterMockRecorder) {
 				s.AvailabilitySetSpec().Return(&fakeSetSpecMissing)
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeSetSpecMissing, serviceName).Return(nil, parameterError)
 				s.UpdatePutStatus(infrav1.AvailabilitySetReadyCondition, serviceName, parameterError)
@@ -107,7 +152,7 @@ func TestReconcileAvailabilitySets(t *testing.T) {
 		{
 			name:          "error in creating availability set",
 			expectedError: "#: Internal Server Error: StatusCode=500",
-			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, f *mock_availabilitysets.MockfaultDomainGetterMockRecorder) {
 				s.AvailabilitySetSpec().Return(&fakeSetSpec)
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeSetSpec, serviceName).Return(nil, internalError)
 				s.UpdatePutStatus(infrav1.AvailabilitySetReadyCondition, serviceName, internalError)
@@ -124,12 +169,77 @@ func TestReconcileAvailabilitySets(t *testing.T) {
 			defer mockCtrl.Finish()
 			scopeMock := mock_availabilitysets.NewMockAvailabilitySetScope(mockCtrl)
 			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+			faultDomainGetterMock := mock_availabilitysets.NewMockfaultDomainGetter(mockCtrl)
 
-			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT())
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT(), faultDomainGetterMock.EXPECT())
 
 			s := &Service{
-				Scope:      scopeMock,
-				Reconciler: asyncMock,
+				Scope:             scopeMock,
+				Reconciler:        asyncMock,
+				faultDomainGetter: faultDomainGetterMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestReconcileExistingAvailabilitySet(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, m *mock_async.MockGetterMockRecorder, f *mock_availabilitysets.MockfaultDomainGetterMockRecorder)
+	}{
+		{
+			name:          "existing availability set is joined without being created",
+			expectedError: "",
+			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, m *mock_async.MockGetterMockRecorder, f *mock_availabilitysets.MockfaultDomainGetterMockRecorder) {
+				s.AvailabilitySetSpec().Return(&fakeExistingSetSpec)
+				m.Get(gomockinternal.AContext(), &fakeExistingSetSpec).Return(compute.AvailabilitySet{}, nil)
+				s.FaultDomain().Return(nil)
+				s.ProviderID().Return(fakeProviderID)
+				f.VMFaultDomain(gomockinternal.AContext(), "test-rg", "test-vm").Return(ptr.To[int32](2), nil)
+				s.SetFaultDomain(int32(2))
+				s.UpdatePutStatus(infrav1.AvailabilitySetReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "existing availability set not found",
+			expectedError: "failed to get existing availability set existing-as in resource group existing-rg: #: Not Found: StatusCode=404",
+			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, m *mock_async.MockGetterMockRecorder, f *mock_availabilitysets.MockfaultDomainGetterMockRecorder) {
+				s.AvailabilitySetSpec().Return(&fakeExistingSetSpec)
+				m.Get(gomockinternal.AContext(), &fakeExistingSetSpec).Return(nil, notFoundError)
+				s.UpdatePutStatus(infrav1.AvailabilitySetReadyCondition, serviceName, gomockinternal.ErrStrEq("failed to get existing availability set existing-as in resource group existing-rg: #: Not Found: StatusCode=404"))
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_availabilitysets.NewMockAvailabilitySetScope(mockCtrl)
+			getterMock := mock_async.NewMockGetter(mockCtrl)
+			// the reconciler mock expects no call: an existing availability set is never created.
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+			faultDomainGetterMock := mock_availabilitysets.NewMockfaultDomainGetter(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), getterMock.EXPECT(), faultDomainGetterMock.EXPECT())
+
+			s := &Service{
+				Scope:             scopeMock,
+				Getter:            getterMock,
+				Reconciler:        asyncMock,
+				faultDomainGetter: faultDomainGetterMock,
 			}
 
 			err := s.Reconcile(context.TODO())
@@ -168,6 +278,13 @@ func TestDeleteAvailabilitySets(t *testing.T) {
 				s.AvailabilitySetSpec().Return(nil)
 			},
 		},
+		{
+			name:          "noop if the availability set is an existing one",
+			expectedError: "",
+			expect: func(s *mock_availabilitysets.MockAvailabilitySetScopeMockRecorder, m *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.AvailabilitySetSpec().Return(&fakeExistingSetSpec)
+			},
+		},
 		{
 			name:          "delete proceeds with missing required value in availability set spec",
 			expectedError: "",
@@ -267,3 +384,37 @@ func TestDeleteAvailabilitySets(t *testing.T) {
 		})
 	}
 }
+
+func TestIsManaged(t *testing.T) {
+	testcases := []struct {
+		name    string
+		spec    *AvailabilitySetSpec
+		managed bool
+	}{
+		{
+			name:    "managed availability set",
+			spec:    &fakeSetSpec,
+			managed: true,
+		},
+		{
+			name:    "existing availability set",
+			spec:    &fakeExistingSetSpec,
+			managed: false,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_availabilitysets.NewMockAvailabilitySetScope(mockCtrl)
+			scopeMock.EXPECT().AvailabilitySetSpec().Return(tc.spec)
+
+			s := &Service{Scope: scopeMock}
+			managed, err := s.IsManaged(context.TODO())
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(managed).To(Equal(tc.managed))
+		})
+	}
+}
@@ -30,15 +30,24 @@ import (
 // AzureClient contains the Azure go-sdk Client.
 type AzureClient struct {
 	availabilitySets compute.AvailabilitySetsClient
+	virtualMachines  compute.VirtualMachinesClient
 }
 
 // NewClient creates a new Resource SKUs Client from subscription ID.
 func NewClient(auth azure.Authorizer) *AzureClient {
 	return &AzureClient{
 		availabilitySets: newAvailabilitySetsClient(auth.SubscriptionID(), auth.BaseURI(), auth.Authorizer()),
+		virtualMachines:  newVirtualMachinesClient(auth.SubscriptionID(), auth.BaseURI(), auth.Authorizer()),
 	}
 }
 
+// newVirtualMachinesClient creates a new VM client from subscription ID.
+func newVirtualMachinesClient(subscriptionID string, baseURI string, authorizer autorest.Authorizer) compute.VirtualMachinesClient {
+	vmClient := compute.NewVirtualMachinesClientWithBaseURI(baseURI, subscriptionID)
+	azure.SetAutoRestClientDefaults(&vmClient.Client, authorizer)
+	return vmClient
+}
+
 // newAvailabilitySetsClient creates a new AvailabilitySets Client from subscription ID.
 func newAvailabilitySetsClient(subscriptionID string, baseURI string, authorizer autorest.Authorizer) compute.AvailabilitySetsClient {
 	asClient := compute.NewAvailabilitySetsClientWithBaseURI(baseURI, subscriptionID)
@@ -54,6 +63,18 @@ func (ac *AzureClient) Get(ctx context.Context, spec azure.ResourceSpecGetter) (
 	return ac.availabilitySets.Get(ctx, spec.ResourceGroupName(), spec.ResourceName())
 }
 
+// VMFaultDomain returns the platform fault domain of a VM from its instance view.
+func (ac *AzureClient) VMFaultDomain(ctx context.Context, resourceGroupName, vmName string) (*int32, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "availabilitysets.AzureClient.VMFaultDomain")
+	defer done()
+
+	instanceView, err := ac.virtualMachines.InstanceView(ctx, resourceGroupName, vmName)
+	if err != nil {
+		return nil, err
+	}
+	return instanceView.PlatformFaultDomain, nil
+}
+
 // CreateOrUpdateAsync creates or updates a availability set asynchronously.
 // It sends a PUT request to Azure and if accepted without error, the func will return a Future which can be used to track the ongoing
 // progress of the operation.
@@ -21,6 +21,7 @@ limitations under the License.
 package mock_availabilitysets
 
 import (
+	context "context"
 	reflect "reflect"
 
 	autorest "github.com/Azure/go-autorest/autorest"
@@ -261,6 +262,20 @@ func (mr *MockAvailabilitySetScopeMockRecorder) FailureDomains() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailureDomains", reflect.TypeOf((*MockAvailabilitySetScope)(nil).FailureDomains))
 }
 
+// FaultDomain mocks base method.
+func (m *MockAvailabilitySetScope) FaultDomain() *int32 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FaultDomain")
+	ret0, _ := ret[0].(*int32)
+	return ret0
+}
+
+// FaultDomain indicates an expected call of FaultDomain.
+func (mr *MockAvailabilitySetScopeMockRecorder) FaultDomain() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FaultDomain", reflect.TypeOf((*MockAvailabilitySetScope)(nil).FaultDomain))
+}
+
 // GetLongRunningOperationState mocks base method.
 func (m *MockAvailabilitySetScope) GetLongRunningOperationState(arg0, arg1, arg2 string) *v1beta1.Future {
 	m.ctrl.T.Helper()
@@ -303,6 +318,20 @@ func (mr *MockAvailabilitySetScopeMockRecorder) Location() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Location", reflect.TypeOf((*MockAvailabilitySetScope)(nil).Location))
 }
 
+// ProviderID mocks base method.
+func (m *MockAvailabilitySetScope) ProviderID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProviderID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ProviderID indicates an expected call of ProviderID.
+func (mr *MockAvailabilitySetScopeMockRecorder) ProviderID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProviderID", reflect.TypeOf((*MockAvailabilitySetScope)(nil).ProviderID))
+}
+
 // ResourceGroup mocks base method.
 func (m *MockAvailabilitySetScope) ResourceGroup() string {
 	m.ctrl.T.Helper()
@@ -317,6 +346,18 @@ func (mr *MockAvailabilitySetScopeMockRecorder) ResourceGroup() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResourceGroup", reflect.TypeOf((*MockAvailabilitySetScope)(nil).ResourceGroup))
 }
 
+// SetFaultDomain mocks base method.
+func (m *MockAvailabilitySetScope) SetFaultDomain(arg0 int32) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetFaultDomain", arg0)
+}
+
+// SetFaultDomain indicates an expected call of SetFaultDomain.
+func (mr *MockAvailabilitySetScopeMockRecorder) SetFaultDomain(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFaultDomain", reflect.TypeOf((*MockAvailabilitySetScope)(nil).SetFaultDomain), arg0)
+}
+
 // SetLongRunningOperationState mocks base method.
 func (m *MockAvailabilitySetScope) SetLongRunningOperationState(arg0 *v1beta1.Future) {
 	m.ctrl.T.Helper()
@@ -392,3 +433,41 @@ func (mr *MockAvailabilitySetScopeMockRecorder) UpdatePutStatus(arg0, arg1, arg2
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePutStatus", reflect.TypeOf((*MockAvailabilitySetScope)(nil).UpdatePutStatus), arg0, arg1, arg2)
 }
+
+// MockfaultDomainGetter is a mock of faultDomainGetter interface.
+type MockfaultDomainGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockfaultDomainGetterMockRecorder
+}
+
+// MockfaultDomainGetterMockRecorder is the mock recorder for MockfaultDomainGetter.
+type MockfaultDomainGetterMockRecorder struct {
+	mock *MockfaultDomainGetter
+}
+
+// NewMockfaultDomainGetter creates a new mock instance.
+func NewMockfaultDomainGetter(ctrl *gomock.Controller) *MockfaultDomainGetter {
+	mock := &MockfaultDomainGetter{ctrl: ctrl}
+	mock.recorder = &MockfaultDomainGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockfaultDomainGetter) EXPECT() *MockfaultDomainGetterMockRecorder {
+	return m.recorder
+}
+
+// VMFaultDomain mocks base method.
+func (m *MockfaultDomainGetter) VMFaultDomain(ctx context.Context, resourceGroupName, vmName string) (*int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VMFaultDomain", ctx, resourceGroupName, vmName)
+	ret0, _ := ret[0].(*int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VMFaultDomain indicates an expected call of VMFaultDomain.
+func (mr *MockfaultDomainGetterMockRecorder) VMFaultDomain(ctx, resourceGroupName, vmName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VMFaultDomain", reflect.TypeOf((*MockfaultDomainGetter)(nil).VMFaultDomain), ctx, resourceGroupName, vmName)
+}
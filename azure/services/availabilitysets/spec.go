@@ -26,6 +26,8 @@ import (
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
 // AvailabilitySetSpec defines the specification for an availability set.
@@ -36,6 +38,11 @@ type AvailabilitySetSpec struct {
 	Location       string
 	SKU            *resourceskus.SKU
 	AdditionalTags infrav1.Tags
+	// PlatformFaultDomainCount is the requested fault domain count, clamped to the maximum fault domain count of the
+	// region. The maximum is used if it is nil.
+	PlatformFaultDomainCount *int32
+	// Existing is true if the availability set is an existing one the VM joins, which is neither created nor deleted.
+	Existing bool
 }
 
 // ResourceName returns the name of the availability set.
@@ -55,6 +62,9 @@ func (s *AvailabilitySetSpec) OwnerResourceName() string {
 
 // Parameters returns the parameters for the availability set.
 func (s *AvailabilitySetSpec) Parameters(ctx context.Context, existing interface{}) (params interface{}, err error) {
+	_, log, done := tele.StartSpanWithLogger(ctx, "availabilitysets.Service.Parameters")
+	defer done()
+
 	if existing != nil {
 		if _, ok := existing.(compute.AvailabilitySet); !ok {
 			return nil, errors.Errorf("%T is not a compute.AvailabilitySet", existing)
@@ -67,33 +77,49 @@ func (s *AvailabilitySetSpec) Parameters(ctx context.Context, existing interface
 		return nil, errors.New("unable to get required availability set SKU from machine cache")
 	}
 
-	var faultDomainCount *int32
-	faultDomainCountStr, ok := s.SKU.GetCapability(resourceskus.MaximumPlatformFaultDomainCount)
-	if !ok {
-		return nil, errors.Errorf("unable to get required availability set SKU capability %s", resourceskus.MaximumPlatformFaultDomainCount)
-	}
-	count, err := strconv.ParseInt(faultDomainCountStr, 10, 32)
+	faultDomainCount, err := MaximumPlatformFaultDomainCount(*s.SKU)
 	if err != nil {
-		return nil, errors.Wrapf(err, "unable to parse availability set fault domain count")
+		return nil, err
+	}
+	if s.PlatformFaultDomainCount != nil {
+		if *s.PlatformFaultDomainCount > faultDomainCount {
+			log.V(2).Info("clamping the fault domain count of the availability set to the maximum of the region",
+				"availability set", s.Name, "requested", *s.PlatformFaultDomainCount, "maximum", faultDomainCount)
+		} else {
+			faultDomainCount = *s.PlatformFaultDomainCount
+		}
 	}
-	faultDomainCount = ptr.To[int32](int32(count))
 
 	asParams := compute.AvailabilitySet{
 		Sku: &compute.Sku{
 			Name: ptr.To(string(compute.AvailabilitySetSkuTypesAligned)),
 		},
 		AvailabilitySetProperties: &compute.AvailabilitySetProperties{
-			PlatformFaultDomainCount: faultDomainCount,
+			PlatformFaultDomainCount: ptr.To(faultDomainCount),
 		},
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
-			ClusterName: s.ClusterName,
-			Lifecycle:   infrav1.ResourceLifecycleOwned,
-			Name:        ptr.To(s.Name),
-			Role:        ptr.To(infrav1.CommonRole),
-			Additional:  s.AdditionalTags,
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
+			ClusterName:  s.ClusterName,
+			Lifecycle:    infrav1.ResourceLifecycleOwned,
+			Name:         ptr.To(s.Name),
+			Role:         ptr.To(infrav1.CommonRole),
+			ResourceTags: s.AdditionalTags,
 		})),
 		Location: ptr.To(s.Location),
 	}
 
 	return asParams, nil
 }
+
+// MaximumPlatformFaultDomainCount returns the maximum fault domain count of an availability set in a region, from the
+// availability set SKU of the region.
+func MaximumPlatformFaultDomainCount(sku resourceskus.SKU) (int32, error) {
+	faultDomainCountStr, ok := sku.GetCapability(resourceskus.MaximumPlatformFaultDomainCount)
+	if !ok {
+		return 0, errors.Errorf("unable to get required availability set SKU capability %s", resourceskus.MaximumPlatformFaultDomainCount)
+	}
+	count, err := strconv.ParseInt(faultDomainCountStr, 10, 32)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unable to parse availability set fault domain count")
+	}
+	return int32(count), nil
+}
@@ -73,6 +73,40 @@ func TestParameters(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "fault domain count within the maximum of the region is passed through",
+			spec: &AvailabilitySetSpec{
+				Name:                     "test-as",
+				ResourceGroup:            "test-rg",
+				ClusterName:              "test-cluster",
+				Location:                 "test-location",
+				SKU:                      &fakeSku,
+				PlatformFaultDomainCount: ptr.To[int32](2),
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.AvailabilitySet{}))
+				g.Expect(result.(compute.AvailabilitySet).PlatformFaultDomainCount).To(Equal(ptr.To[int32](2)))
+			},
+			expectedError: "",
+		},
+		{
+			name: "fault domain count exceeding the maximum of the region is clamped",
+			spec: &AvailabilitySetSpec{
+				Name:                     "test-as",
+				ResourceGroup:            "test-rg",
+				ClusterName:              "test-cluster",
+				Location:                 "test-location",
+				SKU:                      &fakeSku,
+				PlatformFaultDomainCount: ptr.To[int32](5),
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.AvailabilitySet{}))
+				g.Expect(result.(compute.AvailabilitySet).PlatformFaultDomainCount).To(Equal(ptr.To[int32](int32(fakeFaultDomainCount))))
+			},
+			expectedError: "",
+		},
 	}
 	for _, tc := range testcases {
 		tc := tc
@@ -21,12 +21,14 @@ limitations under the License.
 package mock_bastionhosts
 
 import (
+	context "context"
 	reflect "reflect"
 
 	autorest "github.com/Azure/go-autorest/autorest"
+	azure "github.com/Azure/go-autorest/autorest/azure"
 	gomock "go.uber.org/mock/gomock"
 	v1beta1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
-	azure "sigs.k8s.io/cluster-api-provider-azure/azure"
+	azure0 "sigs.k8s.io/cluster-api-provider-azure/azure"
 	v1beta10 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
@@ -138,10 +140,10 @@ func (mr *MockBastionScopeMockRecorder) AvailabilitySetEnabled() *gomock.Call {
 }
 
 // AzureBastionSpec mocks base method.
-func (m *MockBastionScope) AzureBastionSpec() azure.ResourceSpecGetter {
+func (m *MockBastionScope) AzureBastionSpec() azure0.ResourceSpecGetter {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "AzureBastionSpec")
-	ret0, _ := ret[0].(azure.ResourceSpecGetter)
+	ret0, _ := ret[0].(azure0.ResourceSpecGetter)
 	return ret0
 }
 
@@ -151,6 +153,20 @@ func (mr *MockBastionScopeMockRecorder) AzureBastionSpec() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureBastionSpec", reflect.TypeOf((*MockBastionScope)(nil).AzureBastionSpec))
 }
 
+// AzureEnvironment mocks base method.
+func (m *MockBastionScope) AzureEnvironment() azure.Environment {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureEnvironment")
+	ret0, _ := ret[0].(azure.Environment)
+	return ret0
+}
+
+// AzureEnvironment indicates an expected call of AzureEnvironment.
+func (mr *MockBastionScopeMockRecorder) AzureEnvironment() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureEnvironment", reflect.TypeOf((*MockBastionScope)(nil).AzureEnvironment))
+}
+
 // BaseURI mocks base method.
 func (m *MockBastionScope) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -415,6 +431,35 @@ func (mr *MockBastionScopeMockRecorder) IsVnetManaged() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsVnetManaged", reflect.TypeOf((*MockBastionScope)(nil).IsVnetManaged))
 }
 
+// KeyVaultAuthorizer mocks base method.
+func (m *MockBastionScope) KeyVaultAuthorizer(ctx context.Context) (autorest.Authorizer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyVaultAuthorizer", ctx)
+	ret0, _ := ret[0].(autorest.Authorizer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KeyVaultAuthorizer indicates an expected call of KeyVaultAuthorizer.
+func (mr *MockBastionScopeMockRecorder) KeyVaultAuthorizer(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyVaultAuthorizer", reflect.TypeOf((*MockBastionScope)(nil).KeyVaultAuthorizer), ctx)
+}
+
+// KeyVaultDNSSuffix mocks base method.
+func (m *MockBastionScope) KeyVaultDNSSuffix() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyVaultDNSSuffix")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// KeyVaultDNSSuffix indicates an expected call of KeyVaultDNSSuffix.
+func (mr *MockBastionScopeMockRecorder) KeyVaultDNSSuffix() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyVaultDNSSuffix", reflect.TypeOf((*MockBastionScope)(nil).KeyVaultDNSSuffix))
+}
+
 // Location mocks base method.
 func (m *MockBastionScope) Location() string {
 	m.ctrl.T.Helper()
@@ -443,6 +488,20 @@ func (mr *MockBastionScopeMockRecorder) NodeSubnets() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeSubnets", reflect.TypeOf((*MockBastionScope)(nil).NodeSubnets))
 }
 
+// OutboundAdditionalPoolNames mocks base method.
+func (m *MockBastionScope) OutboundAdditionalPoolNames(arg0 string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OutboundAdditionalPoolNames", arg0)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// OutboundAdditionalPoolNames indicates an expected call of OutboundAdditionalPoolNames.
+func (mr *MockBastionScopeMockRecorder) OutboundAdditionalPoolNames(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OutboundAdditionalPoolNames", reflect.TypeOf((*MockBastionScope)(nil).OutboundAdditionalPoolNames), arg0)
+}
+
 // OutboundLBName mocks base method.
 func (m *MockBastionScope) OutboundLBName(arg0 string) string {
 	m.ctrl.T.Helper()
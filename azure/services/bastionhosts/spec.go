@@ -26,6 +26,7 @@ import (
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 )
 
 // AzureBastionSpec defines the specification for azure bastion feature.
@@ -38,6 +39,8 @@ type AzureBastionSpec struct {
 	PublicIPID      string
 	Sku             infrav1.BastionHostSkuName
 	EnableTunneling bool
+	ScaleUnits      *int32
+	AdditionalTags  infrav1.Tags
 }
 
 // AzureBastionSpecInput defines the required inputs to construct an azure bastion spec.
@@ -65,11 +68,26 @@ func (s *AzureBastionSpec) OwnerResourceName() string {
 // Parameters returns the parameters for the bastion host.
 func (s *AzureBastionSpec) Parameters(ctx context.Context, existing interface{}) (parameters interface{}, err error) {
 	if existing != nil {
-		if _, ok := existing.(network.BastionHost); !ok {
+		existingBastionHost, ok := existing.(network.BastionHost)
+		if !ok {
 			return nil, errors.Errorf("%T is not a network.BastionHost", existing)
 		}
-		// bastion host already exists
-		return nil, nil
+
+		if !s.needsUpdate(existingBastionHost) {
+			// bastion host is up to date, nothing to do
+			return nil, nil
+		}
+
+		// Only update the SKU, tunneling and scale units, keeping the remaining properties of the existing bastion host.
+		existingBastionHost.Sku = &network.Sku{
+			Name: network.BastionHostSkuName(s.Sku),
+		}
+		if existingBastionHost.BastionHostPropertiesFormat == nil {
+			existingBastionHost.BastionHostPropertiesFormat = &network.BastionHostPropertiesFormat{}
+		}
+		existingBastionHost.EnableTunneling = ptr.To(s.EnableTunneling)
+		existingBastionHost.ScaleUnits = s.ScaleUnits
+		return existingBastionHost, nil
 	}
 
 	bastionHostIPConfigName := fmt.Sprintf("%s-%s", s.Name, "bastionIP")
@@ -77,17 +95,19 @@ func (s *AzureBastionSpec) Parameters(ctx context.Context, existing interface{})
 	return network.BastionHost{
 		Name:     ptr.To(s.Name),
 		Location: ptr.To(s.Location),
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
 			ClusterName: s.ClusterName,
 			Lifecycle:   infrav1.ResourceLifecycleOwned,
 			Name:        ptr.To(s.Name),
 			Role:        ptr.To("Bastion"),
+			ClusterTags: s.AdditionalTags,
 		})),
 		Sku: &network.Sku{
 			Name: network.BastionHostSkuName(s.Sku),
 		},
 		BastionHostPropertiesFormat: &network.BastionHostPropertiesFormat{
 			EnableTunneling: ptr.To(s.EnableTunneling),
+			ScaleUnits:      s.ScaleUnits,
 			DNSName:         ptr.To(fmt.Sprintf("%s-bastion", strings.ToLower(s.Name))),
 			IPConfigurations: &[]network.BastionHostIPConfiguration{
 				{
@@ -106,3 +126,22 @@ func (s *AzureBastionSpec) Parameters(ctx context.Context, existing interface{})
 		},
 	}, nil
 }
+
+// needsUpdate returns true if the SKU, tunneling or scale units of the existing bastion host differ from the spec.
+func (s *AzureBastionSpec) needsUpdate(existing network.BastionHost) bool {
+	if existing.Sku == nil || existing.Sku.Name != network.BastionHostSkuName(s.Sku) {
+		return true
+	}
+	props := existing.BastionHostPropertiesFormat
+	if props == nil {
+		return true
+	}
+	if ptr.Deref(props.EnableTunneling, false) != s.EnableTunneling {
+		return true
+	}
+	// Azure reports the default scale units when none were requested, so only compare them when set in the spec.
+	if s.ScaleUnits != nil && ptr.Deref(props.ScaleUnits, 0) != *s.ScaleUnits {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bastionhosts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+func existingBastionHost(sku network.BastionHostSkuName, enableTunneling bool, scaleUnits *int32) network.BastionHost {
+	return network.BastionHost{
+		Name:     ptr.To("my-bastion"),
+		Location: ptr.To("westus"),
+		Etag:     ptr.To("fake-etag"),
+		Sku: &network.Sku{
+			Name: sku,
+		},
+		BastionHostPropertiesFormat: &network.BastionHostPropertiesFormat{
+			EnableTunneling: ptr.To(enableTunneling),
+			ScaleUnits:      scaleUnits,
+			DNSName:         ptr.To("my-bastion-bastion"),
+			IPConfigurations: &[]network.BastionHostIPConfiguration{
+				{
+					Name: ptr.To("my-bastion-bastionIP"),
+				},
+			},
+		},
+	}
+}
+
+func TestParameters(t *testing.T) {
+	testcases := []struct {
+		name          string
+		spec          AzureBastionSpec
+		existing      interface{}
+		expect        func(g *WithT, result interface{})
+		expectedError string
+	}{
+		{
+			name: "basic bastion host does not exist",
+			spec: AzureBastionSpec{
+				Name:     "my-bastion",
+				Location: "westus",
+				Sku:      infrav1.BasicBastionHostSku,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.BastionHost{}))
+				bastion := result.(network.BastionHost)
+				g.Expect(bastion.Sku.Name).To(Equal(network.BastionHostSkuNameBasic))
+				g.Expect(bastion.EnableTunneling).To(Equal(ptr.To(false)))
+				g.Expect(bastion.ScaleUnits).To(BeNil())
+				g.Expect(bastion.DNSName).To(Equal(ptr.To("my-bastion-bastion")))
+				g.Expect(*bastion.IPConfigurations).To(HaveLen(1))
+			},
+		},
+		{
+			name: "standard bastion host with tunneling and scale units does not exist",
+			spec: AzureBastionSpec{
+				Name:            "my-bastion",
+				Location:        "westus",
+				Sku:             infrav1.StandardBastionHostSku,
+				EnableTunneling: true,
+				ScaleUnits:      ptr.To[int32](4),
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.BastionHost{}))
+				bastion := result.(network.BastionHost)
+				g.Expect(bastion.Sku.Name).To(Equal(network.BastionHostSkuNameStandard))
+				g.Expect(bastion.EnableTunneling).To(Equal(ptr.To(true)))
+				g.Expect(bastion.ScaleUnits).To(Equal(ptr.To[int32](4)))
+			},
+		},
+		{
+			name: "basic bastion host is up to date",
+			spec: AzureBastionSpec{
+				Name: "my-bastion",
+				Sku:  infrav1.BasicBastionHostSku,
+			},
+			existing: existingBastionHost(network.BastionHostSkuNameBasic, false, ptr.To[int32](2)),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name: "standard bastion host with scale units is up to date",
+			spec: AzureBastionSpec{
+				Name:            "my-bastion",
+				Sku:             infrav1.StandardBastionHostSku,
+				EnableTunneling: true,
+				ScaleUnits:      ptr.To[int32](4),
+			},
+			existing: existingBastionHost(network.BastionHostSkuNameStandard, true, ptr.To[int32](4)),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name: "basic bastion host is upgraded to standard with tunneling",
+			spec: AzureBastionSpec{
+				Name:            "my-bastion",
+				Sku:             infrav1.StandardBastionHostSku,
+				EnableTunneling: true,
+			},
+			existing: existingBastionHost(network.BastionHostSkuNameBasic, false, ptr.To[int32](2)),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.BastionHost{}))
+				bastion := result.(network.BastionHost)
+				g.Expect(bastion.Sku.Name).To(Equal(network.BastionHostSkuNameStandard))
+				g.Expect(bastion.EnableTunneling).To(Equal(ptr.To(true)))
+				g.Expect(bastion.ScaleUnits).To(BeNil())
+				g.Expect(bastion.Etag).To(Equal(ptr.To("fake-etag")))
+				g.Expect(*bastion.IPConfigurations).To(HaveLen(1))
+			},
+		},
+		{
+			name: "standard bastion host scale units are updated",
+			spec: AzureBastionSpec{
+				Name:       "my-bastion",
+				Sku:        infrav1.StandardBastionHostSku,
+				ScaleUnits: ptr.To[int32](10),
+			},
+			existing: existingBastionHost(network.BastionHostSkuNameStandard, false, ptr.To[int32](2)),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.BastionHost{}))
+				bastion := result.(network.BastionHost)
+				g.Expect(bastion.Sku.Name).To(Equal(network.BastionHostSkuNameStandard))
+				g.Expect(bastion.EnableTunneling).To(Equal(ptr.To(false)))
+				g.Expect(bastion.ScaleUnits).To(Equal(ptr.To[int32](10)))
+			},
+		},
+		{
+			name: "standard bastion host tunneling is disabled",
+			spec: AzureBastionSpec{
+				Name: "my-bastion",
+				Sku:  infrav1.StandardBastionHostSku,
+			},
+			existing: existingBastionHost(network.BastionHostSkuNameStandard, true, ptr.To[int32](2)),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.BastionHost{}))
+				bastion := result.(network.BastionHost)
+				g.Expect(bastion.EnableTunneling).To(Equal(ptr.To(false)))
+			},
+		},
+		{
+			name:          "existing is not a bastion host",
+			spec:          AzureBastionSpec{Name: "my-bastion"},
+			existing:      struct{}{},
+			expectedError: "struct {} is not a network.BastionHost",
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			result, err := tc.spec.Parameters(context.TODO(), tc.existing)
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			tc.expect(g, result)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datacollectionruleassociations
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2021-09-01-preview/insights"
+	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// apiVersion is the GA version of the data collection rule associations API the requests are sent with, as the SDK
+// only has data collection rule associations clients in the preview packages of the insights API.
+const apiVersion = "2022-06-01"
+
+// azureClient contains the Azure go-sdk Client.
+type azureClient struct {
+	associations insights.DataCollectionRuleAssociationsClient
+}
+
+// newClient creates a new data collection rule associations client from subscription ID.
+func newClient(auth azure.Authorizer) *azureClient {
+	c := newDataCollectionRuleAssociationsClient(auth.SubscriptionID(), auth.BaseURI(), auth.Authorizer())
+	return &azureClient{c}
+}
+
+// newDataCollectionRuleAssociationsClient creates a data collection rule associations client from subscription ID.
+func newDataCollectionRuleAssociationsClient(subscriptionID string, baseURI string, authorizer autorest.Authorizer) insights.DataCollectionRuleAssociationsClient {
+	associationsClient := insights.NewDataCollectionRuleAssociationsClientWithBaseURI(baseURI, subscriptionID)
+	azure.SetAutoRestClientDefaults(&associationsClient.Client, authorizer)
+	associationsClient.RequestInspector = withAPIVersion(apiVersion)
+	return associationsClient
+}
+
+// withAPIVersion returns a PrepareDecorator sending requests with the given API version.
+func withAPIVersion(version string) autorest.PrepareDecorator {
+	return func(p autorest.Preparer) autorest.Preparer {
+		return autorest.PreparerFunc(func(r *http.Request) (*http.Request, error) {
+			r, err := p.Prepare(r)
+			if err != nil {
+				return r, err
+			}
+			query := r.URL.Query()
+			query.Set("api-version", version)
+			r.URL.RawQuery = query.Encode()
+			return r, nil
+		})
+	}
+}
+
+// Get gets the specified data collection rule association of a resource.
+func (ac *azureClient) Get(ctx context.Context, spec azure.ResourceSpecGetter) (result interface{}, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "datacollectionruleassociations.azureClient.Get")
+	defer done()
+
+	return ac.associations.Get(ctx, spec.OwnerResourceName(), spec.ResourceName())
+}
+
+// CreateOrUpdateAsync creates or updates a data collection rule association.
+// Creating a data collection rule association is not a long running operation, so we don't ever return a future.
+func (ac *azureClient) CreateOrUpdateAsync(ctx context.Context, spec azure.ResourceSpecGetter, parameters interface{}) (result interface{}, future azureautorest.FutureAPI, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "datacollectionruleassociations.azureClient.CreateOrUpdateAsync")
+	defer done()
+
+	association, ok := parameters.(insights.DataCollectionRuleAssociationProxyOnlyResource)
+	if !ok {
+		return nil, nil, errors.Errorf("%T is not an insights.DataCollectionRuleAssociationProxyOnlyResource", parameters)
+	}
+
+	result, err = ac.associations.Create(ctx, spec.OwnerResourceName(), spec.ResourceName(), &association)
+	return result, nil, err
+}
+
+// DeleteAsync deletes a data collection rule association.
+// Deleting a data collection rule association is not a long running operation, so we don't ever return a future.
+func (ac *azureClient) DeleteAsync(ctx context.Context, spec azure.ResourceSpecGetter) (future azureautorest.FutureAPI, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "datacollectionruleassociations.azureClient.DeleteAsync")
+	defer done()
+
+	_, err = ac.associations.Delete(ctx, spec.OwnerResourceName(), spec.ResourceName())
+	return nil, err
+}
+
+// IsDone returns true if the long-running operation has completed.
+func (ac *azureClient) IsDone(ctx context.Context, future azureautorest.FutureAPI) (isDone bool, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "datacollectionruleassociations.azureClient.IsDone")
+	defer done()
+
+	return future.DoneWithContext(ctx, ac.associations)
+}
+
+// Result is a no-op for data collection rule associations as neither create nor delete return a future.
+func (ac *azureClient) Result(ctx context.Context, future azureautorest.FutureAPI, futureType string) (result interface{}, err error) {
+	return nil, nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datacollectionruleassociations
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+)
+
+func TestClientUsesGAAPIVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	var apiVersions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiVersions = append(apiVersions, r.URL.Query().Get("api-version"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := &azureClient{newDataCollectionRuleAssociationsClient("123", server.URL, autorest.NullAuthorizer{})}
+	_, err := client.Get(context.Background(), &fakeAssociationSpec)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = client.DeleteAsync(context.Background(), &fakeAssociationSpec)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(apiVersions).To(Equal([]string{apiVersion, apiVersion}))
+}
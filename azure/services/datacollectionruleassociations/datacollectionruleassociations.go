@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datacollectionruleassociations
+
+import (
+	"context"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const serviceName = "datacollectionruleassociations"
+
+// DataCollectionRuleAssociationScope defines the scope interface for a data collection rule association service.
+type DataCollectionRuleAssociationScope interface {
+	azure.Authorizer
+	azure.AsyncStatusUpdater
+	DataCollectionRuleAssociationSpec() azure.ResourceSpecGetter
+	DeleteCondition(clusterv1.ConditionType)
+}
+
+// Service provides operations on Azure resources.
+type Service struct {
+	Scope DataCollectionRuleAssociationScope
+	async.Reconciler
+}
+
+// New creates a new service.
+func New(scope DataCollectionRuleAssociationScope) *Service {
+	client := newClient(scope)
+	return &Service{
+		Scope:      scope,
+		Reconciler: async.New(scope, client, client),
+	}
+}
+
+// Name returns the service name.
+func (s *Service) Name() string {
+	return serviceName
+}
+
+// Reconcile idempotently creates or updates the association of the VM with a data collection rule, or deletes it once
+// the data collection rule is removed from the cluster.
+func (s *Service) Reconcile(ctx context.Context) error {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "datacollectionruleassociations.Service.Reconcile")
+	defer done()
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureServiceReconcileTimeout)
+	defer cancel()
+
+	spec := s.Scope.DataCollectionRuleAssociationSpec()
+	if spec == nil {
+		return nil
+	}
+
+	if associationSpec, ok := spec.(*DataCollectionRuleAssociationSpec); ok && associationSpec.DataCollectionRuleID == "" {
+		if err := s.DeleteResource(ctx, spec, serviceName); err != nil {
+			s.Scope.UpdateDeleteStatus(infrav1.DataCollectionRuleAssociationReadyCondition, serviceName, err)
+			return err
+		}
+		// The condition records that the association exists, so it is removed once the association is deleted.
+		if dryRunner, ok := s.Scope.(azure.DryRunner); !ok || !dryRunner.IsDryRun() {
+			s.Scope.DeleteCondition(infrav1.DataCollectionRuleAssociationReadyCondition)
+		}
+		return nil
+	}
+
+	_, err := s.CreateOrUpdateResource(ctx, spec, serviceName)
+	s.Scope.UpdatePutStatus(infrav1.DataCollectionRuleAssociationReadyCondition, serviceName, err)
+	return err
+}
+
+// Delete deletes the association of the VM with a data collection rule.
+func (s *Service) Delete(ctx context.Context) error {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "datacollectionruleassociations.Service.Delete")
+	defer done()
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureServiceReconcileTimeout)
+	defer cancel()
+
+	spec := s.Scope.DataCollectionRuleAssociationSpec()
+	if spec == nil {
+		return nil
+	}
+
+	err := s.DeleteResource(ctx, spec, serviceName)
+	s.Scope.UpdateDeleteStatus(infrav1.DataCollectionRuleAssociationReadyCondition, serviceName, err)
+	return err
+}
+
+// IsManaged returns always returns true as the association is always created by CAPZ.
+func (s *Service) IsManaged(ctx context.Context) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datacollectionruleassociations
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async/mock_async"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/datacollectionruleassociations/mock_datacollectionruleassociations"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+var (
+	fakeAssociationSpec = DataCollectionRuleAssociationSpec{
+		Name:                 "my-cluster-dcra",
+		ResourceGroup:        "my-rg",
+		ResourceURI:          "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm",
+		DataCollectionRuleID: "/subscriptions/123/resourceGroups/monitoring-rg/providers/Microsoft.Insights/dataCollectionRules/my-dcr",
+		ClusterName:          "my-cluster",
+	}
+	fakeRemovedAssociationSpec = DataCollectionRuleAssociationSpec{
+		Name:          "my-cluster-dcra",
+		ResourceGroup: "my-rg",
+		ResourceURI:   "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm",
+		ClusterName:   "my-cluster",
+	}
+	internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+)
+
+func TestReconcileDataCollectionRuleAssociation(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_datacollectionruleassociations.MockDataCollectionRuleAssociationScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+	}{
+		{
+			name:          "no data collection rule association spec",
+			expectedError: "",
+			expect: func(s *mock_datacollectionruleassociations.MockDataCollectionRuleAssociationScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DataCollectionRuleAssociationSpec().Return(nil)
+			},
+		},
+		{
+			name:          "data collection rule association successfully created",
+			expectedError: "",
+			expect: func(s *mock_datacollectionruleassociations.MockDataCollectionRuleAssociationScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DataCollectionRuleAssociationSpec().Return(&fakeAssociationSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeAssociationSpec, serviceName).Return(nil, nil)
+				s.UpdatePutStatus(infrav1.DataCollectionRuleAssociationReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "fail to create data collection rule association",
+			expectedError: internalError.Error(),
+			expect: func(s *mock_datacollectionruleassociations.MockDataCollectionRuleAssociationScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DataCollectionRuleAssociationSpec().Return(&fakeAssociationSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeAssociationSpec, serviceName).Return(nil, internalError)
+				s.UpdatePutStatus(infrav1.DataCollectionRuleAssociationReadyCondition, serviceName, internalError)
+			},
+		},
+		{
+			name:          "data collection rule association deleted once the data collection rule is removed",
+			expectedError: "",
+			expect: func(s *mock_datacollectionruleassociations.MockDataCollectionRuleAssociationScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DataCollectionRuleAssociationSpec().Return(&fakeRemovedAssociationSpec)
+				r.DeleteResource(gomockinternal.AContext(), &fakeRemovedAssociationSpec, serviceName).Return(nil)
+				s.DeleteCondition(infrav1.DataCollectionRuleAssociationReadyCondition)
+			},
+		},
+		{
+			name:          "fail to delete data collection rule association once the data collection rule is removed",
+			expectedError: internalError.Error(),
+			expect: func(s *mock_datacollectionruleassociations.MockDataCollectionRuleAssociationScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DataCollectionRuleAssociationSpec().Return(&fakeRemovedAssociationSpec)
+				r.DeleteResource(gomockinternal.AContext(), &fakeRemovedAssociationSpec, serviceName).Return(internalError)
+				s.UpdateDeleteStatus(infrav1.DataCollectionRuleAssociationReadyCondition, serviceName, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_datacollectionruleassociations.NewMockDataCollectionRuleAssociationScope(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT())
+
+			s := &Service{
+				Scope:      scopeMock,
+				Reconciler: asyncMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestDeleteDataCollectionRuleAssociation(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_datacollectionruleassociations.MockDataCollectionRuleAssociationScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+	}{
+		{
+			name:          "no data collection rule association spec",
+			expectedError: "",
+			expect: func(s *mock_datacollectionruleassociations.MockDataCollectionRuleAssociationScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DataCollectionRuleAssociationSpec().Return(nil)
+			},
+		},
+		{
+			name:          "data collection rule association successfully deleted",
+			expectedError: "",
+			expect: func(s *mock_datacollectionruleassociations.MockDataCollectionRuleAssociationScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DataCollectionRuleAssociationSpec().Return(&fakeAssociationSpec)
+				r.DeleteResource(gomockinternal.AContext(), &fakeAssociationSpec, serviceName).Return(nil)
+				s.UpdateDeleteStatus(infrav1.DataCollectionRuleAssociationReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "fail to delete data collection rule association",
+			expectedError: internalError.Error(),
+			expect: func(s *mock_datacollectionruleassociations.MockDataCollectionRuleAssociationScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DataCollectionRuleAssociationSpec().Return(&fakeAssociationSpec)
+				r.DeleteResource(gomockinternal.AContext(), &fakeAssociationSpec, serviceName).Return(internalError)
+				s.UpdateDeleteStatus(infrav1.DataCollectionRuleAssociationReadyCondition, serviceName, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_datacollectionruleassociations.NewMockDataCollectionRuleAssociationScope(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT())
+
+			s := &Service{
+				Scope:      scopeMock,
+				Reconciler: asyncMock,
+			}
+
+			err := s.Delete(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datacollectionruleassociations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2021-09-01-preview/insights"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+)
+
+// DataCollectionRuleAssociationSpec defines the specification for the association of a resource with a data collection rule.
+type DataCollectionRuleAssociationSpec struct {
+	Name                 string
+	ResourceGroup        string
+	ResourceURI          string
+	DataCollectionRuleID string
+	ClusterName          string
+}
+
+// ResourceName returns the name of the data collection rule association.
+func (s *DataCollectionRuleAssociationSpec) ResourceName() string {
+	return s.Name
+}
+
+// ResourceGroupName returns the name of the resource group.
+func (s *DataCollectionRuleAssociationSpec) ResourceGroupName() string {
+	return s.ResourceGroup
+}
+
+// OwnerResourceName returns the ID of the resource the data collection rule is associated with.
+func (s *DataCollectionRuleAssociationSpec) OwnerResourceName() string {
+	return s.ResourceURI
+}
+
+// Parameters returns the parameters for the data collection rule association.
+func (s *DataCollectionRuleAssociationSpec) Parameters(ctx context.Context, existing interface{}) (params interface{}, err error) {
+	if existing != nil {
+		existingAssociation, ok := existing.(insights.DataCollectionRuleAssociationProxyOnlyResource)
+		if !ok {
+			return nil, errors.Errorf("%T is not an insights.DataCollectionRuleAssociationProxyOnlyResource", existing)
+		}
+
+		// Resource IDs are case-insensitive.
+		if existingAssociation.DataCollectionRuleAssociationProxyOnlyResourceProperties != nil &&
+			strings.EqualFold(ptr.Deref(existingAssociation.DataCollectionRuleID, ""), s.DataCollectionRuleID) {
+			// association is already linked to the data collection rule, nothing to update.
+			return nil, nil
+		}
+	}
+
+	return insights.DataCollectionRuleAssociationProxyOnlyResource{
+		DataCollectionRuleAssociationProxyOnlyResourceProperties: &insights.DataCollectionRuleAssociationProxyOnlyResourceProperties{
+			Description:          ptr.To(fmt.Sprintf("Data collection rule association for cluster %s", s.ClusterName)),
+			DataCollectionRuleID: ptr.To(s.DataCollectionRuleID),
+		},
+	}, nil
+}
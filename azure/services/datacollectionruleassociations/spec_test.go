@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datacollectionruleassociations
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2021-09-01-preview/insights"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+)
+
+func TestParameters(t *testing.T) {
+	testcases := []struct {
+		name          string
+		existing      interface{}
+		expect        func(g *WithT, result interface{})
+		expectedError string
+	}{
+		{
+			name:     "association does not exist",
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(Equal(insights.DataCollectionRuleAssociationProxyOnlyResource{
+					DataCollectionRuleAssociationProxyOnlyResourceProperties: &insights.DataCollectionRuleAssociationProxyOnlyResourceProperties{
+						Description:          ptr.To("Data collection rule association for cluster my-cluster"),
+						DataCollectionRuleID: ptr.To(fakeAssociationSpec.DataCollectionRuleID),
+					},
+				}))
+			},
+		},
+		{
+			name: "association already links the data collection rule",
+			existing: insights.DataCollectionRuleAssociationProxyOnlyResource{
+				Name: ptr.To("my-cluster-dcra"),
+				DataCollectionRuleAssociationProxyOnlyResourceProperties: &insights.DataCollectionRuleAssociationProxyOnlyResourceProperties{
+					DataCollectionRuleID: ptr.To(strings.ToLower(fakeAssociationSpec.DataCollectionRuleID)),
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name: "association links another data collection rule",
+			existing: insights.DataCollectionRuleAssociationProxyOnlyResource{
+				Name: ptr.To("my-cluster-dcra"),
+				DataCollectionRuleAssociationProxyOnlyResourceProperties: &insights.DataCollectionRuleAssociationProxyOnlyResourceProperties{
+					DataCollectionRuleID: ptr.To("/subscriptions/123/resourceGroups/monitoring-rg/providers/Microsoft.Insights/dataCollectionRules/old-dcr"),
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(insights.DataCollectionRuleAssociationProxyOnlyResource{}))
+				association := result.(insights.DataCollectionRuleAssociationProxyOnlyResource)
+				g.Expect(association.DataCollectionRuleID).To(Equal(ptr.To(fakeAssociationSpec.DataCollectionRuleID)))
+			},
+		},
+		{
+			name:          "existing is not a data collection rule association",
+			existing:      struct{}{},
+			expectedError: "struct {} is not an insights.DataCollectionRuleAssociationProxyOnlyResource",
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			spec := fakeAssociationSpec
+			result, err := spec.Parameters(context.TODO(), tc.existing)
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			tc.expect(g, result)
+		})
+	}
+}
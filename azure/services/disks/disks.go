@@ -26,7 +26,8 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
-const serviceName = "disks"
+// ServiceName is the name of this service.
+const ServiceName = "disks"
 
 // DiskScope defines the scope interface for a disk service.
 type DiskScope interface {
@@ -46,25 +47,45 @@ func New(scope DiskScope) *Service {
 	client := newClient(scope)
 	return &Service{
 		Scope:      scope,
-		Reconciler: async.New(scope, nil, client),
+		Reconciler: async.New(scope, client, client),
 	}
 }
 
 // Name returns the service name.
 func (s *Service) Name() string {
-	return serviceName
+	return ServiceName
 }
 
-// Reconcile on disk is currently no-op. OS disks should only be deleted and will create with the VM automatically.
+// Reconcile creates the shared data disks and the data disks with a logical sector size of a VM, and configures the
+// provisioned IOPS and throughput of its disks.
+// Other disks are created with the VM automatically, so disks without provisioned performance are left as they are.
 func (s *Service) Reconcile(ctx context.Context) error {
-	_, _, done := tele.StartSpanWithLogger(ctx, "disks.Service.Reconcile")
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "disks.Service.Reconcile")
 	defer done()
 
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureServiceReconcileTimeout)
+	defer cancel()
+
+	// We go through the list of DiskSpecs to update each one, independently of the result of the previous one.
+	// If multiple errors occur, we return the most pressing one.
+	//  Order of precedence (highest -> lowest) is: error that is not an operationNotDoneError (i.e. error updating) -> operationNotDoneError (i.e. updating in progress) -> no error (i.e. updated)
 	// DisksReadyCondition is set in the VM service.
-	return nil
+	var result error
+	for _, diskSpec := range s.Scope.DiskSpecs() {
+		if spec, ok := diskSpec.(*DiskSpec); !ok || !(spec.IsCreatedBeforeVM() || spec.HasProvisionedPerformance()) {
+			continue
+		}
+		if _, err := s.CreateOrUpdateResource(ctx, diskSpec, ServiceName); err != nil {
+			if !azure.IsOperationNotDoneError(err) || result == nil {
+				result = err
+			}
+		}
+	}
+	return result
 }
 
-// Delete deletes the disk associated with a VM.
+// Delete deletes the disks associated with a VM. Shared data disks may still be attached to other VMs, so they are
+// left in place and deleted with the resource group of the cluster.
 func (s *Service) Delete(ctx context.Context) error {
 	ctx, _, done := tele.StartSpanWithLogger(ctx, "disks.Service.Delete")
 	defer done()
@@ -82,13 +103,16 @@ func (s *Service) Delete(ctx context.Context) error {
 	//  Order of precedence (highest -> lowest) is: error that is not an operationNotDoneError (i.e. error creating) -> operationNotDoneError (i.e. creating in progress) -> no error (i.e. created)
 	var result error
 	for _, diskSpec := range specs {
-		if err := s.DeleteResource(ctx, diskSpec, serviceName); err != nil {
+		if spec, ok := diskSpec.(*DiskSpec); ok && spec.IsShared() {
+			continue
+		}
+		if err := s.DeleteResource(ctx, diskSpec, ServiceName); err != nil {
 			if !azure.IsOperationNotDoneError(err) || result == nil {
 				result = err
 			}
 		}
 	}
-	s.Scope.UpdateDeleteStatus(infrav1.DisksReadyCondition, serviceName, result)
+	s.Scope.UpdateDeleteStatus(infrav1.DisksReadyCondition, ServiceName, result)
 	return result
 }
 
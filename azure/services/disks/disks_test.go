@@ -24,6 +24,7 @@ import (
 	"github.com/Azure/go-autorest/autorest"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async/mock_async"
@@ -47,9 +48,113 @@ var (
 		&diskSpec2,
 	}
 
+	ultraDiskSpec = DiskSpec{
+		Name:              "my-ultra-disk",
+		ResourceGroup:     "my-group",
+		DiskIOPSReadWrite: ptr.To[int64](5000),
+		DiskMBpsReadWrite: ptr.To[int64](200),
+	}
+
+	sharedDiskSpec = DiskSpec{
+		Name:               "my-cluster_shared",
+		ResourceGroup:      "my-group",
+		Location:           "test-location",
+		Zone:               "1",
+		ClusterName:        "my-cluster",
+		DiskSizeGB:         256,
+		StorageAccountType: "Premium_LRS",
+		MaxShares:          ptr.To[int32](3),
+	}
+
+	logicalSectorSizeDiskSpec = DiskSpec{
+		Name:               "my-vm_my-disk",
+		ResourceGroup:      "my-group",
+		Location:           "test-location",
+		ClusterName:        "my-cluster",
+		DiskSizeGB:         128,
+		StorageAccountType: "PremiumV2_LRS",
+		LogicalSectorSize:  ptr.To[int32](512),
+	}
+
 	internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
 )
 
+func TestReconcileDisk(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_disks.MockDiskScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+	}{
+		{
+			name:          "noop if no disk has provisioned performance",
+			expectedError: "",
+			expect: func(s *mock_disks.MockDiskScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DiskSpecs().Return(fakeDiskSpecs)
+			},
+		},
+		{
+			name:          "configure the provisioned performance of a disk",
+			expectedError: "",
+			expect: func(s *mock_disks.MockDiskScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DiskSpecs().Return([]azure.ResourceSpecGetter{&diskSpec1, &ultraDiskSpec})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &ultraDiskSpec, ServiceName).Return(nil, nil)
+			},
+		},
+		{
+			name:          "create a shared disk",
+			expectedError: "",
+			expect: func(s *mock_disks.MockDiskScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DiskSpecs().Return([]azure.ResourceSpecGetter{&diskSpec1, &sharedDiskSpec})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &sharedDiskSpec, ServiceName).Return(nil, nil)
+			},
+		},
+		{
+			name:          "create a disk with a logical sector size",
+			expectedError: "",
+			expect: func(s *mock_disks.MockDiskScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DiskSpecs().Return([]azure.ResourceSpecGetter{&diskSpec1, &logicalSectorSizeDiskSpec})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &logicalSectorSizeDiskSpec, ServiceName).Return(nil, nil)
+			},
+		},
+		{
+			name:          "error while trying to configure the provisioned performance of a disk",
+			expectedError: "#: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_disks.MockDiskScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DiskSpecs().Return([]azure.ResourceSpecGetter{&diskSpec1, &ultraDiskSpec})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &ultraDiskSpec, ServiceName).Return(nil, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_disks.NewMockDiskScope(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT())
+
+			s := &Service{
+				Scope:      scopeMock,
+				Reconciler: asyncMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
 func TestDeleteDisk(t *testing.T) {
 	testcases := []struct {
 		name          string
@@ -69,9 +174,20 @@ func TestDeleteDisk(t *testing.T) {
 			expect: func(s *mock_disks.MockDiskScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.DiskSpecs().Return(fakeDiskSpecs)
 				gomock.InOrder(
-					r.DeleteResource(gomockinternal.AContext(), &diskSpec1, serviceName).Return(nil),
-					r.DeleteResource(gomockinternal.AContext(), &diskSpec2, serviceName).Return(nil),
-					s.UpdateDeleteStatus(infrav1.DisksReadyCondition, serviceName, nil),
+					r.DeleteResource(gomockinternal.AContext(), &diskSpec1, ServiceName).Return(nil),
+					r.DeleteResource(gomockinternal.AContext(), &diskSpec2, ServiceName).Return(nil),
+					s.UpdateDeleteStatus(infrav1.DisksReadyCondition, ServiceName, nil),
+				)
+			},
+		},
+		{
+			name:          "shared disks are not deleted with the machine",
+			expectedError: "",
+			expect: func(s *mock_disks.MockDiskScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.DiskSpecs().Return([]azure.ResourceSpecGetter{&diskSpec1, &sharedDiskSpec})
+				gomock.InOrder(
+					r.DeleteResource(gomockinternal.AContext(), &diskSpec1, ServiceName).Return(nil),
+					s.UpdateDeleteStatus(infrav1.DisksReadyCondition, ServiceName, nil),
 				)
 			},
 		},
@@ -81,9 +197,9 @@ func TestDeleteDisk(t *testing.T) {
 			expect: func(s *mock_disks.MockDiskScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.DiskSpecs().Return(fakeDiskSpecs)
 				gomock.InOrder(
-					r.DeleteResource(gomockinternal.AContext(), &diskSpec1, serviceName).Return(nil),
-					r.DeleteResource(gomockinternal.AContext(), &diskSpec2, serviceName).Return(nil),
-					s.UpdateDeleteStatus(infrav1.DisksReadyCondition, serviceName, nil),
+					r.DeleteResource(gomockinternal.AContext(), &diskSpec1, ServiceName).Return(nil),
+					r.DeleteResource(gomockinternal.AContext(), &diskSpec2, ServiceName).Return(nil),
+					s.UpdateDeleteStatus(infrav1.DisksReadyCondition, ServiceName, nil),
 				)
 			},
 		},
@@ -93,9 +209,9 @@ func TestDeleteDisk(t *testing.T) {
 			expect: func(s *mock_disks.MockDiskScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.DiskSpecs().Return(fakeDiskSpecs)
 				gomock.InOrder(
-					r.DeleteResource(gomockinternal.AContext(), &diskSpec1, serviceName).Return(internalError),
-					r.DeleteResource(gomockinternal.AContext(), &diskSpec2, serviceName).Return(nil),
-					s.UpdateDeleteStatus(infrav1.DisksReadyCondition, serviceName, internalError),
+					r.DeleteResource(gomockinternal.AContext(), &diskSpec1, ServiceName).Return(internalError),
+					r.DeleteResource(gomockinternal.AContext(), &diskSpec2, ServiceName).Return(nil),
+					s.UpdateDeleteStatus(infrav1.DisksReadyCondition, ServiceName, internalError),
 				)
 			},
 		},
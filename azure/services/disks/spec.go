@@ -16,12 +16,43 @@ limitations under the License.
 
 package disks
 
-import "context"
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+)
 
 // DiskSpec defines the specification for a disk.
 type DiskSpec struct {
 	Name          string
 	ResourceGroup string
+	Location      string
+	// ExtendedLocation is the edge zone of a disk created before the VM, the edge zone of the VM.
+	ExtendedLocation *infrav1.ExtendedLocationSpec
+	// Zone is the availability zone of a shared disk. It is empty for a zone-redundant shared disk.
+	Zone               string
+	ClusterName        string
+	DiskSizeGB         int32
+	StorageAccountType string
+	// MaxShares is the maximum number of VMs that can attach the disk at the same time.
+	MaxShares      *int32
+	AdditionalTags infrav1.Tags
+	// DiskIOPSReadWrite is the number of IOPS to provision for an UltraSSD disk.
+	DiskIOPSReadWrite *int64
+	// DiskMBpsReadWrite is the throughput in MB per second to provision for an UltraSSD disk.
+	DiskMBpsReadWrite *int64
+	// Tier is the performance tier the premium SSD disk should currently have, following its performance tier schedule.
+	Tier string
+	// BurstingEnabled enables on-demand bursting of a premium SSD disk.
+	BurstingEnabled *bool
+	// DiskEncryptionSetID is the ID of the disk encryption set used to encrypt the disk with a customer-managed key.
+	DiskEncryptionSetID string
+	// LogicalSectorSize is the logical sector size in bytes of a Premium SSD v2 disk.
+	LogicalSectorSize *int32
 }
 
 // ResourceName returns the name of the disk.
@@ -39,7 +70,105 @@ func (s *DiskSpec) OwnerResourceName() string {
 	return ""
 }
 
-// Parameters is a no-op for disks.
+// HasProvisionedPerformance returns true if IOPS, throughput, a performance tier or bursting are provisioned for the
+// disk.
+func (s *DiskSpec) HasProvisionedPerformance() bool {
+	return s.DiskIOPSReadWrite != nil || s.DiskMBpsReadWrite != nil || s.Tier != "" || s.BurstingEnabled != nil
+}
+
+// IsShared returns true if the disk can be attached to multiple VMs at the same time.
+func (s *DiskSpec) IsShared() bool {
+	return s.MaxShares != nil && *s.MaxShares > 1
+}
+
+// IsCreatedBeforeVM returns true if the disk is created by the disks service and attached to the VM, as shared disks
+// and disks with a logical sector size can't be created with the VM.
+func (s *DiskSpec) IsCreatedBeforeVM() bool {
+	return s.IsShared() || s.LogicalSectorSize != nil
+}
+
+// Parameters returns the parameters to create a shared disk or a disk with a logical sector size, or to update the
+// provisioned IOPS, throughput, performance tier and bursting of an existing disk. Other disks are created with the VM,
+// so nothing is done until they exist.
 func (s *DiskSpec) Parameters(ctx context.Context, existing interface{}) (params interface{}, err error) {
-	return nil, nil
+	if existing == nil {
+		if !s.IsCreatedBeforeVM() {
+			return nil, nil
+		}
+		var zones *[]string
+		if s.Zone != "" {
+			zones = &[]string{s.Zone}
+		}
+		var tier *string
+		if s.Tier != "" {
+			tier = ptr.To(s.Tier)
+		}
+		var encryption *compute.Encryption
+		if s.DiskEncryptionSetID != "" {
+			encryption = &compute.Encryption{
+				DiskEncryptionSetID: ptr.To(s.DiskEncryptionSetID),
+				Type:                compute.EncryptionTypeEncryptionAtRestWithCustomerKey,
+			}
+		}
+		return compute.Disk{
+			Location:         ptr.To(s.Location),
+			ExtendedLocation: converters.ExtendedLocationToComputeSDK(s.ExtendedLocation),
+			Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+				ClusterName: s.ClusterName,
+				Lifecycle:   infrav1.ResourceLifecycleOwned,
+				Name:        ptr.To(s.Name),
+				Additional:  s.AdditionalTags,
+			})),
+			Sku: &compute.DiskSku{
+				Name: compute.DiskStorageAccountTypes(s.StorageAccountType),
+			},
+			Zones: zones,
+			DiskProperties: &compute.DiskProperties{
+				CreationData: &compute.CreationData{
+					CreateOption:      compute.DiskCreateOptionEmpty,
+					LogicalSectorSize: s.LogicalSectorSize,
+				},
+				DiskSizeGB:        ptr.To(s.DiskSizeGB),
+				MaxShares:         s.MaxShares,
+				DiskIOPSReadWrite: s.DiskIOPSReadWrite,
+				DiskMBpsReadWrite: s.DiskMBpsReadWrite,
+				Tier:              tier,
+				BurstingEnabled:   s.BurstingEnabled,
+				Encryption:        encryption,
+			},
+		}, nil
+	}
+	if !s.HasProvisionedPerformance() {
+		return nil, nil
+	}
+
+	disk, ok := existing.(compute.Disk)
+	if !ok {
+		return nil, errors.Errorf("%T is not a compute.Disk", existing)
+	}
+	if disk.DiskProperties == nil {
+		disk.DiskProperties = &compute.DiskProperties{}
+	}
+
+	changed := false
+	if s.DiskIOPSReadWrite != nil && ptr.Deref(disk.DiskIOPSReadWrite, 0) != *s.DiskIOPSReadWrite {
+		disk.DiskIOPSReadWrite = s.DiskIOPSReadWrite
+		changed = true
+	}
+	if s.DiskMBpsReadWrite != nil && ptr.Deref(disk.DiskMBpsReadWrite, 0) != *s.DiskMBpsReadWrite {
+		disk.DiskMBpsReadWrite = s.DiskMBpsReadWrite
+		changed = true
+	}
+	if s.Tier != "" && ptr.Deref(disk.Tier, "") != s.Tier {
+		disk.Tier = ptr.To(s.Tier)
+		changed = true
+	}
+	if s.BurstingEnabled != nil && ptr.Deref(disk.BurstingEnabled, false) != *s.BurstingEnabled {
+		disk.BurstingEnabled = s.BurstingEnabled
+		changed = true
+	}
+	if !changed {
+		return nil, nil
+	}
+	return disk, nil
 }
@@ -0,0 +1,312 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+func TestParameters(t *testing.T) {
+	existingUltraDisk := compute.Disk{
+		ID:       ptr.To("my-ultra-disk-id"),
+		Location: ptr.To("test-location"),
+		Sku:      &compute.DiskSku{Name: compute.DiskStorageAccountTypesUltraSSDLRS},
+		DiskProperties: &compute.DiskProperties{
+			DiskSizeGB:        ptr.To[int32](128),
+			DiskIOPSReadWrite: ptr.To[int64](500),
+			DiskMBpsReadWrite: ptr.To[int64](8),
+		},
+	}
+
+	// The disk properties are updated in place, so each test case gets its own existing premium disk.
+	newExistingPremiumDisk := func() compute.Disk {
+		return compute.Disk{
+			ID:       ptr.To("my-premium-disk-id"),
+			Location: ptr.To("test-location"),
+			Sku:      &compute.DiskSku{Name: compute.DiskStorageAccountTypesPremiumLRS},
+			DiskProperties: &compute.DiskProperties{
+				DiskSizeGB: ptr.To[int32](1024),
+				Tier:       ptr.To("P30"),
+			},
+		}
+	}
+
+	testcases := []struct {
+		name          string
+		spec          *DiskSpec
+		existing      interface{}
+		expect        func(g *WithT, result interface{})
+		expectedError string
+	}{
+		{
+			name:     "disk doesn't exist yet",
+			spec:     &ultraDiskSpec,
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name:     "shared disk doesn't exist yet",
+			spec:     &sharedDiskSpec,
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(Equal(compute.Disk{
+					Location: ptr.To("test-location"),
+					Tags: map[string]*string{
+						"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+						"Name": ptr.To("my-cluster_shared"),
+					},
+					Sku:   &compute.DiskSku{Name: compute.DiskStorageAccountTypesPremiumLRS},
+					Zones: &[]string{"1"},
+					DiskProperties: &compute.DiskProperties{
+						CreationData: &compute.CreationData{CreateOption: compute.DiskCreateOptionEmpty},
+						DiskSizeGB:   ptr.To[int32](256),
+						MaxShares:    ptr.To[int32](3),
+					},
+				}))
+			},
+		},
+		{
+			name: "zone-redundant shared disk doesn't exist yet",
+			spec: &DiskSpec{
+				Name:               "my-cluster_shared",
+				ResourceGroup:      "my-group",
+				Location:           "test-location",
+				ClusterName:        "my-cluster",
+				DiskSizeGB:         256,
+				StorageAccountType: "Premium_ZRS",
+				MaxShares:          ptr.To[int32](3),
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.Disk{}))
+				g.Expect(result.(compute.Disk).Zones).To(BeNil())
+				g.Expect(result.(compute.Disk).Sku.Name).To(Equal(compute.DiskStorageAccountTypesPremiumZRS))
+			},
+		},
+		{
+			name: "disk with a logical sector size doesn't exist yet",
+			spec: &DiskSpec{
+				Name:               "my-vm_my-disk",
+				ResourceGroup:      "my-group",
+				Location:           "test-location",
+				Zone:               "2",
+				ClusterName:        "my-cluster",
+				DiskSizeGB:         128,
+				StorageAccountType: "PremiumV2_LRS",
+				LogicalSectorSize:  ptr.To[int32](512),
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(Equal(compute.Disk{
+					Location: ptr.To("test-location"),
+					Tags: map[string]*string{
+						"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+						"Name": ptr.To("my-vm_my-disk"),
+					},
+					Sku:   &compute.DiskSku{Name: compute.DiskStorageAccountTypes("PremiumV2_LRS")},
+					Zones: &[]string{"2"},
+					DiskProperties: &compute.DiskProperties{
+						CreationData: &compute.CreationData{
+							CreateOption:      compute.DiskCreateOptionEmpty,
+							LogicalSectorSize: ptr.To[int32](512),
+						},
+						DiskSizeGB: ptr.To[int32](128),
+					},
+				}))
+			},
+		},
+		{
+			name: "disk in an edge zone doesn't exist yet",
+			spec: &DiskSpec{
+				Name:               "my-vm_my-disk",
+				ResourceGroup:      "my-group",
+				Location:           "test-location",
+				ExtendedLocation:   &infrav1.ExtendedLocationSpec{Name: "microsoftlosangeles1", Type: "EdgeZone"},
+				ClusterName:        "my-cluster",
+				DiskSizeGB:         128,
+				StorageAccountType: "Premium_LRS",
+				LogicalSectorSize:  ptr.To[int32](4096),
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.Disk{}))
+				g.Expect(result.(compute.Disk).ExtendedLocation).To(Equal(&compute.ExtendedLocation{
+					Name: ptr.To("microsoftlosangeles1"),
+					Type: compute.ExtendedLocationTypesEdgeZone,
+				}))
+				g.Expect(result.(compute.Disk).Zones).To(BeNil())
+			},
+		},
+		{
+			name: "shared disk encrypted with a customer-managed key doesn't exist yet",
+			spec: &DiskSpec{
+				Name:                "my-cluster_shared",
+				ResourceGroup:       "my-group",
+				Location:            "test-location",
+				ClusterName:         "my-cluster",
+				DiskSizeGB:          256,
+				StorageAccountType:  "Premium_LRS",
+				MaxShares:           ptr.To[int32](3),
+				DiskEncryptionSetID: "/subscriptions/123/resourceGroups/my-group/providers/Microsoft.Compute/diskEncryptionSets/my-des",
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.Disk{}))
+				g.Expect(result.(compute.Disk).Encryption).To(Equal(&compute.Encryption{
+					DiskEncryptionSetID: ptr.To("/subscriptions/123/resourceGroups/my-group/providers/Microsoft.Compute/diskEncryptionSets/my-des"),
+					Type:                compute.EncryptionTypeEncryptionAtRestWithCustomerKey,
+				}))
+			},
+		},
+		{
+			name: "shared disk already exists",
+			spec: &sharedDiskSpec,
+			existing: compute.Disk{
+				ID:             ptr.To("my-shared-disk-id"),
+				DiskProperties: &compute.DiskProperties{MaxShares: ptr.To[int32](3)},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name:     "disk without provisioned performance",
+			spec:     &diskSpec1,
+			existing: existingUltraDisk,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name:     "disk with different provisioned IOPS and throughput",
+			spec:     &ultraDiskSpec,
+			existing: existingUltraDisk,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(Equal(compute.Disk{
+					ID:       ptr.To("my-ultra-disk-id"),
+					Location: ptr.To("test-location"),
+					Sku:      &compute.DiskSku{Name: compute.DiskStorageAccountTypesUltraSSDLRS},
+					DiskProperties: &compute.DiskProperties{
+						DiskSizeGB:        ptr.To[int32](128),
+						DiskIOPSReadWrite: ptr.To[int64](5000),
+						DiskMBpsReadWrite: ptr.To[int64](200),
+					},
+				}))
+			},
+		},
+		{
+			name: "disk with different provisioned IOPS only",
+			spec: &DiskSpec{
+				Name:              "my-ultra-disk",
+				ResourceGroup:     "my-group",
+				DiskIOPSReadWrite: ptr.To[int64](1000),
+			},
+			existing: existingUltraDisk,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.Disk{}))
+				g.Expect(result.(compute.Disk).DiskIOPSReadWrite).To(Equal(ptr.To[int64](1000)))
+				g.Expect(result.(compute.Disk).DiskMBpsReadWrite).To(Equal(ptr.To[int64](8)))
+			},
+		},
+		{
+			name: "disk with the expected provisioned IOPS and throughput",
+			spec: &DiskSpec{
+				Name:              "my-ultra-disk",
+				ResourceGroup:     "my-group",
+				DiskIOPSReadWrite: ptr.To[int64](500),
+				DiskMBpsReadWrite: ptr.To[int64](8),
+			},
+			existing: existingUltraDisk,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name: "premium disk downgraded to its off-hours tier",
+			spec: &DiskSpec{
+				Name:          "my-premium-disk",
+				ResourceGroup: "my-group",
+				Tier:          "P10",
+			},
+			existing: newExistingPremiumDisk(),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.Disk{}))
+				g.Expect(result.(compute.Disk).Tier).To(Equal(ptr.To("P10")))
+				g.Expect(result.(compute.Disk).BurstingEnabled).To(BeNil())
+			},
+		},
+		{
+			name: "premium disk with bursting enabled",
+			spec: &DiskSpec{
+				Name:            "my-premium-disk",
+				ResourceGroup:   "my-group",
+				Tier:            "P30",
+				BurstingEnabled: ptr.To(true),
+			},
+			existing: newExistingPremiumDisk(),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.Disk{}))
+				g.Expect(result.(compute.Disk).Tier).To(Equal(ptr.To("P30")))
+				g.Expect(result.(compute.Disk).BurstingEnabled).To(Equal(ptr.To(true)))
+			},
+		},
+		{
+			name: "premium disk with the expected tier",
+			spec: &DiskSpec{
+				Name:          "my-premium-disk",
+				ResourceGroup: "my-group",
+				Tier:          "P30",
+			},
+			existing: newExistingPremiumDisk(),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name:          "existing is not a disk",
+			spec:          &ultraDiskSpec,
+			existing:      "not a disk",
+			expectedError: "string is not a compute.Disk",
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			result, err := tc.spec.Parameters(context.TODO(), tc.existing)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			tc.expect(g, result)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package galleryimageversions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// ImageVersion identifies a version of an image in an Azure Compute Gallery.
+type ImageVersion struct {
+	SubscriptionID string
+	ResourceGroup  string
+	Gallery        string
+	Image          string
+	Version        string
+}
+
+// String returns the gallery, image and version names of the image version.
+func (v ImageVersion) String() string {
+	return fmt.Sprintf("%s/%s/%s", v.Gallery, v.Image, v.Version)
+}
+
+// Client wraps go-sdk.
+type Client interface {
+	Get(ctx context.Context, ref ImageVersion) (compute.GalleryImageVersion, error)
+	UpdateTargetRegions(ctx context.Context, ref ImageVersion, version compute.GalleryImageVersion, targetRegions []compute.TargetRegion) error
+}
+
+// AzureClient contains the Azure go-sdk Client.
+type AzureClient struct {
+	auth azure.Authorizer
+}
+
+// NewClient creates a new gallery image versions client from auth info.
+// The gallery may live in another subscription than the cluster, so the SDK client is created for each request.
+func NewClient(auth azure.Authorizer) *AzureClient {
+	return &AzureClient{auth}
+}
+
+// newGalleryImageVersionsClient creates a new gallery image versions client from subscription ID, base URI, and authorizer.
+func (ac *AzureClient) newGalleryImageVersionsClient(subscriptionID string) compute.GalleryImageVersionsClient {
+	galleryImageVersionsClient := compute.NewGalleryImageVersionsClientWithBaseURI(ac.auth.BaseURI(), subscriptionID)
+	azure.SetAutoRestClientDefaults(&galleryImageVersionsClient.Client, ac.auth.Authorizer())
+	return galleryImageVersionsClient
+}
+
+// Get returns a gallery image version along with its replication status.
+func (ac *AzureClient) Get(ctx context.Context, ref ImageVersion) (compute.GalleryImageVersion, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "galleryimageversions.AzureClient.Get")
+	defer done()
+
+	return ac.newGalleryImageVersionsClient(ref.SubscriptionID).Get(ctx, ref.ResourceGroup, ref.Gallery, ref.Image, ref.Version, compute.ReplicationStatusTypesReplicationStatus)
+}
+
+// UpdateTargetRegions replaces the target regions a gallery image version is replicated to. It does not wait for the
+// replication to complete, which can take a long time; the replication status is reported by Get.
+func (ac *AzureClient) UpdateTargetRegions(ctx context.Context, ref ImageVersion, version compute.GalleryImageVersion, targetRegions []compute.TargetRegion) error {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "galleryimageversions.AzureClient.UpdateTargetRegions")
+	defer done()
+
+	properties := compute.GalleryImageVersionProperties{
+		PublishingProfile: &compute.GalleryImageVersionPublishingProfile{},
+	}
+	if version.GalleryImageVersionProperties != nil {
+		// The storage profile is required and the other publishing settings are kept as is.
+		properties.StorageProfile = version.StorageProfile
+		if version.PublishingProfile != nil {
+			*properties.PublishingProfile = *version.PublishingProfile
+		}
+	}
+	properties.PublishingProfile.TargetRegions = &targetRegions
+
+	_, err := ac.newGalleryImageVersionsClient(ref.SubscriptionID).Update(ctx, ref.ResourceGroup, ref.Gallery, ref.Image, ref.Version, compute.GalleryImageVersionUpdate{
+		GalleryImageVersionProperties: &properties,
+	})
+	return err
+}
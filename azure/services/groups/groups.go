@@ -45,6 +45,7 @@ type GroupScope interface {
 	azure.AsyncStatusUpdater
 	GroupSpec() azure.ResourceSpecGetter
 	ClusterName() string
+	ResourceGroupDeletionGuarded() bool
 }
 
 // New creates a new service.
@@ -115,9 +116,10 @@ func (s *Service) Delete(ctx context.Context) error {
 }
 
 // IsManaged returns true if the resource group has an owned tag with the cluster name as value,
-// meaning that the resource group's lifecycle is managed.
+// meaning that the resource group's lifecycle is managed. A resource group guarded by the do-not-delete annotation is
+// never managed, so that only the resources created by CAPZ in it are deleted.
 func (s *Service) IsManaged(ctx context.Context) (bool, error) {
-	ctx, _, done := tele.StartSpanWithLogger(ctx, "groups.Service.IsManaged")
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "groups.Service.IsManaged")
 	defer done()
 
 	groupSpec := s.Scope.GroupSpec()
@@ -131,5 +133,12 @@ func (s *Service) IsManaged(ctx context.Context) (bool, error) {
 	}
 
 	tags := converters.MapToTags(group.Tags)
-	return tags.HasOwned(s.Scope.ClusterName()), nil
+	if !tags.HasOwned(s.Scope.ClusterName()) {
+		return false, nil
+	}
+	if s.Scope.ResourceGroupDeletionGuarded() {
+		log.V(4).Info("resource group is guarded from deletion", "annotation", infrav1.DoNotDeleteAnnotation)
+		return false, nil
+	}
+	return true, nil
 }
@@ -139,6 +139,7 @@ func TestDeleteGroups(t *testing.T) {
 				s.GroupSpec().AnyTimes().Return(&fakeGroupSpec)
 				m.Get(gomockinternal.AContext(), &fakeGroupSpec).Return(sampleManagedGroup, nil)
 				s.ClusterName().Return("test-cluster")
+				s.ResourceGroupDeletionGuarded().Return(false)
 				r.DeleteResource(gomockinternal.AContext(), &fakeGroupSpec, ServiceName).Return(nil)
 				s.UpdateDeleteStatus(infrav1.ResourceGroupReadyCondition, ServiceName, nil)
 			},
@@ -152,6 +153,16 @@ func TestDeleteGroups(t *testing.T) {
 				s.ClusterName().Return("test-cluster")
 			},
 		},
+		{
+			name:          "managed resource group guarded by the do-not-delete annotation is not deleted",
+			expectedError: "",
+			expect: func(s *mock_groups.MockGroupScopeMockRecorder, m *mock_groups.MockclientMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.GroupSpec().AnyTimes().Return(&fakeGroupSpec)
+				m.Get(gomockinternal.AContext(), &fakeGroupSpec).Return(sampleManagedGroup, nil)
+				s.ClusterName().Return("test-cluster")
+				s.ResourceGroupDeletionGuarded().Return(true)
+			},
+		},
 		{
 			name:          "fail to check if resource group is managed",
 			expectedError: "could not get resource group management state",
@@ -177,6 +188,7 @@ func TestDeleteGroups(t *testing.T) {
 				s.GroupSpec().AnyTimes().Return(&fakeGroupSpec)
 				m.Get(gomockinternal.AContext(), &fakeGroupSpec).Return(sampleManagedGroup, nil)
 				s.ClusterName().Return("test-cluster")
+				s.ResourceGroupDeletionGuarded().Return(false)
 				r.DeleteResource(gomockinternal.AContext(), &fakeGroupSpec, ServiceName).Return(internalError)
 				s.UpdateDeleteStatus(infrav1.ResourceGroupReadyCondition, ServiceName, gomockinternal.ErrStrEq("#: Internal Server Error: StatusCode=500"))
 			},
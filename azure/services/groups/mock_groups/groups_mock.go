@@ -191,6 +191,20 @@ func (mr *MockGroupScopeMockRecorder) HashKey() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HashKey", reflect.TypeOf((*MockGroupScope)(nil).HashKey))
 }
 
+// ResourceGroupDeletionGuarded mocks base method.
+func (m *MockGroupScope) ResourceGroupDeletionGuarded() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResourceGroupDeletionGuarded")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// ResourceGroupDeletionGuarded indicates an expected call of ResourceGroupDeletionGuarded.
+func (mr *MockGroupScopeMockRecorder) ResourceGroupDeletionGuarded() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResourceGroupDeletionGuarded", reflect.TypeOf((*MockGroupScope)(nil).ResourceGroupDeletionGuarded))
+}
+
 // SetLongRunningOperationState mocks base method.
 func (m *MockGroupScope) SetLongRunningOperationState(arg0 *v1beta1.Future) {
 	m.ctrl.T.Helper()
@@ -23,6 +23,7 @@ import (
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 )
 
 // GroupSpec defines the specification for a Resource Group.
@@ -59,12 +60,12 @@ func (s *GroupSpec) Parameters(ctx context.Context, existing interface{}) (param
 	return resources.Group{
 		Location: ptr.To(s.Location),
 		// User defined additional tags are created with the resource group and updated using tags service.
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
 			ClusterName: s.ClusterName,
 			Lifecycle:   infrav1.ResourceLifecycleOwned,
 			Name:        ptr.To(s.Name),
 			Role:        ptr.To(infrav1.CommonRole),
-			Additional:  s.AdditionalTags,
+			ClusterTags: s.AdditionalTags,
 		})),
 	}, nil
 }
@@ -21,6 +21,7 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/services/msi/mgmt/2018-11-30/msi"
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	azureutil "sigs.k8s.io/cluster-api-provider-azure/util/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
@@ -30,6 +31,7 @@ import (
 type Client interface {
 	Get(ctx context.Context, resourceGroupName, name string) (msi.Identity, error)
 	GetClientID(ctx context.Context, providerID string) (string, error)
+	GetPrincipalID(ctx context.Context, providerID string) (string, error)
 }
 
 // AzureClient contains the Azure go-sdk Client.
@@ -73,3 +75,22 @@ func (ac *AzureClient) GetClientID(ctx context.Context, providerID string) (stri
 	}
 	return ident.ClientID.String(), nil
 }
+
+// GetPrincipalID returns the principal ID of a managed service identity, given its full URL identifier.
+func (ac *AzureClient) GetPrincipalID(ctx context.Context, providerID string) (string, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "identities.GetPrincipalID")
+	defer done()
+
+	parsed, err := azureutil.ParseResourceID(providerID)
+	if err != nil {
+		return "", err
+	}
+	ident, err := ac.Get(ctx, parsed.ResourceGroupName, parsed.Name)
+	if err != nil {
+		return "", err
+	}
+	if ident.PrincipalID == nil {
+		return "", errors.Errorf("identity %s has no principal ID", providerID)
+	}
+	return ident.PrincipalID.String(), nil
+}
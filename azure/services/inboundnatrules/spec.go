@@ -24,6 +24,9 @@ import (
 	"k8s.io/utils/ptr"
 )
 
+// defaultIdleTimeoutInMinutes is the idle timeout of an inbound NAT rule when none is specified.
+const defaultIdleTimeoutInMinutes = 4
+
 // InboundNatSpec defines the specification for an inbound NAT rule.
 type InboundNatSpec struct {
 	Name                      string
@@ -31,6 +34,9 @@ type InboundNatSpec struct {
 	ResourceGroup             string
 	FrontendIPConfigurationID *string
 	SSHFrontendPort           *int32
+	IdleTimeoutInMinutes      *int32
+	EnableTCPReset            *bool
+	EnableFloatingIP          *bool
 }
 
 // ResourceName returns the name of the inbound NAT rule.
@@ -50,12 +56,41 @@ func (s *InboundNatSpec) OwnerResourceName() string {
 
 // Parameters returns the parameters for the inbound NAT rule.
 func (s *InboundNatSpec) Parameters(ctx context.Context, existing interface{}) (parameters interface{}, err error) {
+	idleTimeoutInMinutes := ptr.Deref(s.IdleTimeoutInMinutes, defaultIdleTimeoutInMinutes)
+
 	if existing != nil {
-		if _, ok := existing.(network.InboundNatRule); !ok {
+		existingRule, ok := existing.(network.InboundNatRule)
+		if !ok {
 			return nil, errors.Errorf("%T is not a network.InboundNatRule", existing)
 		}
+		if existingRule.InboundNatRulePropertiesFormat == nil {
+			return nil, nil
+		}
+
+		// Only the idle timeout, TCP reset and floating IP of an existing rule are updated, so it keeps its SSH frontend
+		// port.
+		update := false
+		props := *existingRule.InboundNatRulePropertiesFormat
+		if ptr.Deref(props.IdleTimeoutInMinutes, defaultIdleTimeoutInMinutes) != idleTimeoutInMinutes {
+			update = true
+			props.IdleTimeoutInMinutes = ptr.To(idleTimeoutInMinutes)
+		}
+		if s.EnableTCPReset != nil && ptr.Deref(props.EnableTCPReset, false) != *s.EnableTCPReset {
+			update = true
+			props.EnableTCPReset = s.EnableTCPReset
+		}
+		if s.EnableFloatingIP != nil && ptr.Deref(props.EnableFloatingIP, false) != *s.EnableFloatingIP {
+			update = true
+			props.EnableFloatingIP = s.EnableFloatingIP
+		}
+		if !update {
+			return nil, nil
+		}
 
-		return nil, nil
+		return network.InboundNatRule{
+			Name:                           existingRule.Name,
+			InboundNatRulePropertiesFormat: &props,
+		}, nil
 	}
 
 	if s.FrontendIPConfigurationID == nil {
@@ -66,8 +101,9 @@ func (s *InboundNatSpec) Parameters(ctx context.Context, existing interface{}) (
 		Name: ptr.To(s.ResourceName()),
 		InboundNatRulePropertiesFormat: &network.InboundNatRulePropertiesFormat{
 			BackendPort:          ptr.To[int32](22),
-			EnableFloatingIP:     ptr.To(false),
-			IdleTimeoutInMinutes: ptr.To[int32](4),
+			EnableFloatingIP:     ptr.To(ptr.Deref(s.EnableFloatingIP, false)),
+			EnableTCPReset:       s.EnableTCPReset,
+			IdleTimeoutInMinutes: ptr.To(idleTimeoutInMinutes),
 			FrontendIPConfiguration: &network.SubResource{
 				ID: s.FrontendIPConfigurationID,
 			},
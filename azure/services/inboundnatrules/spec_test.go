@@ -17,11 +17,201 @@ limitations under the License.
 package inboundnatrules
 
 import (
+	"context"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
 	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
 )
 
+var fakeFrontendIPConfigID = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/loadBalancers/my-lb/frontendIPConfigurations/my-frontend"
+
+func newExistingNatRule(idleTimeoutInMinutes int32, enableTCPReset bool) network.InboundNatRule {
+	return network.InboundNatRule{
+		Name: ptr.To("my-machine"),
+		InboundNatRulePropertiesFormat: &network.InboundNatRulePropertiesFormat{
+			BackendPort:          ptr.To[int32](22),
+			EnableFloatingIP:     ptr.To(false),
+			EnableTCPReset:       ptr.To(enableTCPReset),
+			IdleTimeoutInMinutes: ptr.To(idleTimeoutInMinutes),
+			FrontendIPConfiguration: &network.SubResource{
+				ID: ptr.To(fakeFrontendIPConfigID),
+			},
+			Protocol:     network.TransportProtocolTCP,
+			FrontendPort: ptr.To[int32](2201),
+		},
+	}
+}
+
+func TestParameters(t *testing.T) {
+	testcases := []struct {
+		name          string
+		spec          InboundNatSpec
+		existing      interface{}
+		expected      interface{}
+		expectedError string
+	}{
+		{
+			name: "new rule with default idle timeout and TCP reset",
+			spec: InboundNatSpec{
+				Name:                      "my-machine",
+				FrontendIPConfigurationID: ptr.To(fakeFrontendIPConfigID),
+				SSHFrontendPort:           ptr.To[int32](22),
+			},
+			expected: network.InboundNatRule{
+				Name: ptr.To("my-machine"),
+				InboundNatRulePropertiesFormat: &network.InboundNatRulePropertiesFormat{
+					BackendPort:          ptr.To[int32](22),
+					EnableFloatingIP:     ptr.To(false),
+					IdleTimeoutInMinutes: ptr.To[int32](4),
+					FrontendIPConfiguration: &network.SubResource{
+						ID: ptr.To(fakeFrontendIPConfigID),
+					},
+					Protocol:     network.TransportProtocolTCP,
+					FrontendPort: ptr.To[int32](22),
+				},
+			},
+		},
+		{
+			name: "new rule with explicit idle timeout and TCP reset",
+			spec: InboundNatSpec{
+				Name:                      "my-machine",
+				FrontendIPConfigurationID: ptr.To(fakeFrontendIPConfigID),
+				SSHFrontendPort:           ptr.To[int32](22),
+				IdleTimeoutInMinutes:      ptr.To[int32](30),
+				EnableTCPReset:            ptr.To(true),
+			},
+			expected: network.InboundNatRule{
+				Name: ptr.To("my-machine"),
+				InboundNatRulePropertiesFormat: &network.InboundNatRulePropertiesFormat{
+					BackendPort:          ptr.To[int32](22),
+					EnableFloatingIP:     ptr.To(false),
+					EnableTCPReset:       ptr.To(true),
+					IdleTimeoutInMinutes: ptr.To[int32](30),
+					FrontendIPConfiguration: &network.SubResource{
+						ID: ptr.To(fakeFrontendIPConfigID),
+					},
+					Protocol:     network.TransportProtocolTCP,
+					FrontendPort: ptr.To[int32](22),
+				},
+			},
+		},
+		{
+			name: "new rule with floating IP",
+			spec: InboundNatSpec{
+				Name:                      "my-machine",
+				FrontendIPConfigurationID: ptr.To(fakeFrontendIPConfigID),
+				SSHFrontendPort:           ptr.To[int32](22),
+				EnableFloatingIP:          ptr.To(true),
+			},
+			expected: network.InboundNatRule{
+				Name: ptr.To("my-machine"),
+				InboundNatRulePropertiesFormat: &network.InboundNatRulePropertiesFormat{
+					BackendPort:          ptr.To[int32](22),
+					EnableFloatingIP:     ptr.To(true),
+					IdleTimeoutInMinutes: ptr.To[int32](4),
+					FrontendIPConfiguration: &network.SubResource{
+						ID: ptr.To(fakeFrontendIPConfigID),
+					},
+					Protocol:     network.TransportProtocolTCP,
+					FrontendPort: ptr.To[int32](22),
+				},
+			},
+		},
+		{
+			name: "new rule without frontend IP configuration",
+			spec: InboundNatSpec{
+				Name: "my-machine",
+			},
+			expectedError: "FrontendIPConfigurationID is not set",
+		},
+		{
+			name: "existing rule with default idle timeout",
+			spec: InboundNatSpec{
+				Name:                      "my-machine",
+				FrontendIPConfigurationID: ptr.To(fakeFrontendIPConfigID),
+				SSHFrontendPort:           ptr.To[int32](2202),
+			},
+			existing: newExistingNatRule(4, false),
+			expected: nil,
+		},
+		{
+			name: "existing rule with up to date idle timeout and TCP reset",
+			spec: InboundNatSpec{
+				Name:                      "my-machine",
+				FrontendIPConfigurationID: ptr.To(fakeFrontendIPConfigID),
+				SSHFrontendPort:           ptr.To[int32](2202),
+				IdleTimeoutInMinutes:      ptr.To[int32](15),
+				EnableTCPReset:            ptr.To(true),
+			},
+			existing: newExistingNatRule(15, true),
+			expected: nil,
+		},
+		{
+			name: "existing rule with an outdated idle timeout keeps its frontend port",
+			spec: InboundNatSpec{
+				Name:                      "my-machine",
+				FrontendIPConfigurationID: ptr.To(fakeFrontendIPConfigID),
+				SSHFrontendPort:           ptr.To[int32](2202),
+				IdleTimeoutInMinutes:      ptr.To[int32](30),
+			},
+			existing: newExistingNatRule(4, false),
+			expected: newExistingNatRule(30, false),
+		},
+		{
+			name: "existing rule enabling TCP reset",
+			spec: InboundNatSpec{
+				Name:                      "my-machine",
+				FrontendIPConfigurationID: ptr.To(fakeFrontendIPConfigID),
+				EnableTCPReset:            ptr.To(true),
+			},
+			existing: newExistingNatRule(4, false),
+			expected: newExistingNatRule(4, true),
+		},
+		{
+			name: "existing rule enabling floating IP",
+			spec: InboundNatSpec{
+				Name:                      "my-machine",
+				FrontendIPConfigurationID: ptr.To(fakeFrontendIPConfigID),
+				EnableFloatingIP:          ptr.To(true),
+			},
+			existing: newExistingNatRule(4, false),
+			expected: func() network.InboundNatRule {
+				rule := newExistingNatRule(4, false)
+				rule.EnableFloatingIP = ptr.To(true)
+				return rule
+			}(),
+		},
+		{
+			name:          "existing resource of the wrong type",
+			spec:          InboundNatSpec{Name: "my-machine"},
+			existing:      struct{}{},
+			expectedError: "struct {} is not a network.InboundNatRule",
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			result, err := tc.spec.Parameters(context.TODO(), tc.existing)
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+				if tc.expected == nil {
+					g.Expect(result).To(BeNil())
+				} else {
+					g.Expect(result).To(Equal(tc.expected))
+				}
+			}
+		})
+	}
+}
+
 func TestGetAvailablePort(t *testing.T) {
 	testcases := []struct {
 		name               string
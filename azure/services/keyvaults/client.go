@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyvaults
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/v7.1/keyvault"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// Client wraps go-sdk.
+type Client interface {
+	GetSecret(ctx context.Context, ref infrav1.KeyVaultSecretReference) (string, error)
+}
+
+// AzureClient contains the Azure go-sdk Client.
+type AzureClient struct {
+	auth azure.KeyVaultAuthorizer
+}
+
+// NewClient creates a new Key Vault secrets client from auth info.
+// The Key Vault authorizer is only requested when a secret is read.
+func NewClient(auth azure.KeyVaultAuthorizer) *AzureClient {
+	return &AzureClient{auth}
+}
+
+// GetSecret returns the value of a Key Vault secret.
+func (ac *AzureClient) GetSecret(ctx context.Context, ref infrav1.KeyVaultSecretReference) (string, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "keyvaults.AzureClient.GetSecret")
+	defer done()
+
+	authorizer, err := ac.auth.KeyVaultAuthorizer(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get Key Vault authorizer")
+	}
+	secretsClient := keyvault.New()
+	azure.SetAutoRestClientDefaults(&secretsClient.Client, authorizer)
+
+	vaultBaseURL := fmt.Sprintf("https://%s.%s", ref.VaultName, ac.auth.KeyVaultDNSSuffix())
+	secret, err := secretsClient.GetSecret(ctx, vaultBaseURL, ref.SecretName, ref.SecretVersion)
+	if err != nil {
+		return "", err
+	}
+	return ptr.Deref(secret.Value, ""), nil
+}
@@ -19,6 +19,7 @@ package loadbalancers
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
 	"github.com/Azure/go-autorest/autorest"
@@ -30,6 +31,18 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
+// backendHealthAPIVersion is the network API version used to get the health of a load balancing rule. The vendored
+// network SDK predates this API, so the request is built by hand.
+const backendHealthAPIVersion = "2023-04-01"
+
+// backendHealth is the health of the backends of a load balancing rule, as reported by its health probe.
+type backendHealth struct {
+	// Up is the number of backends passing the health probe.
+	Up int32 `json:"up"`
+	// Down is the number of backends failing the health probe.
+	Down int32 `json:"down"`
+}
+
 // azureClient contains the Azure go-sdk Client.
 type azureClient struct {
 	loadbalancers network.LoadBalancersClient
@@ -56,6 +69,61 @@ func (ac *azureClient) Get(ctx context.Context, spec azure.ResourceSpecGetter) (
 	return ac.loadbalancers.Get(ctx, spec.ResourceGroupName(), spec.ResourceName(), "")
 }
 
+// BackendHealth gets the number of healthy and unhealthy backends of a load balancing rule.
+func (ac *azureClient) BackendHealth(ctx context.Context, resourceGroupName, lbName, ruleName string) (healthy, unhealthy int32, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "loadbalancers.azureClient.BackendHealth")
+	defer done()
+
+	pathParameters := map[string]interface{}{
+		"groupName":             autorest.Encode("path", resourceGroupName),
+		"loadBalancerName":      autorest.Encode("path", lbName),
+		"loadBalancingRuleName": autorest.Encode("path", ruleName),
+		"subscriptionId":        autorest.Encode("path", ac.loadbalancers.SubscriptionID),
+	}
+	queryParameters := map[string]interface{}{
+		"api-version": backendHealthAPIVersion,
+	}
+	req, err := autorest.Prepare((&http.Request{}).WithContext(ctx),
+		autorest.AsPost(),
+		autorest.WithBaseURL(ac.loadbalancers.BaseURI),
+		autorest.WithPathParameters("/subscriptions/{subscriptionId}/resourceGroups/{groupName}/providers/Microsoft.Network/loadBalancers/{loadBalancerName}/loadBalancingRules/{loadBalancingRuleName}/health", pathParameters),
+		autorest.WithQueryParameters(queryParameters))
+	if err != nil {
+		return 0, 0, autorest.NewErrorWithError(err, "network.LoadBalancersClient", "BackendHealth", nil, "Failure preparing request")
+	}
+
+	resp, err := ac.loadbalancers.Send(req, azureautorest.DoRetryWithRegistration(ac.loadbalancers.Client))
+	if err != nil {
+		return 0, 0, autorest.NewErrorWithError(err, "network.LoadBalancersClient", "BackendHealth", resp, "Failure sending request")
+	}
+
+	// The health of a load balancing rule is returned by a long-running operation.
+	future, err := azureautorest.NewFutureFromResponse(resp)
+	if err != nil {
+		return 0, 0, autorest.NewErrorWithError(err, "network.LoadBalancersClient", "BackendHealth", resp, "Failure responding to request")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureCallTimeout)
+	defer cancel()
+
+	if err := future.WaitForCompletionRef(ctx, ac.loadbalancers.Client); err != nil {
+		return 0, 0, err
+	}
+	resp, err = future.GetResult(ac.loadbalancers)
+	if err != nil {
+		return 0, 0, autorest.NewErrorWithError(err, "network.LoadBalancersClient", "BackendHealth", resp, "Failure getting the result")
+	}
+	var health backendHealth
+	err = autorest.Respond(resp,
+		azureautorest.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(&health),
+		autorest.ByClosing())
+	if err != nil {
+		return 0, 0, autorest.NewErrorWithError(err, "network.LoadBalancersClient", "BackendHealth", resp, "Failure responding to request")
+	}
+	return 0, 0, nil
+}
+
 // CreateOrUpdateAsync creates or updates a load balancer asynchronously.
 // It sends a PUT request to Azure and if accepted without error, the func will return a Future which can be used to track the ongoing
 // progress of the operation.
@@ -30,6 +30,10 @@ const (
 	serviceName           = "loadbalancers"
 	httpsProbe            = "HTTPSProbe"
 	httpsProbeRequestPath = "/readyz"
+	// defaultProbeIntervalInSeconds is the default interval between two health probes of the API server port.
+	defaultProbeIntervalInSeconds = 15
+	// defaultNumberOfProbes is the default number of failed health probes before the API server port is marked down.
+	defaultNumberOfProbes = 4
 	lbRuleHTTPS           = "LBRuleHTTPS"
 	outboundNAT           = "OutboundNATAllProtocols"
 )
@@ -39,11 +43,18 @@ type LBScope interface {
 	azure.ClusterScoper
 	azure.AsyncStatusUpdater
 	LBSpecs() []azure.ResourceSpecGetter
+	SetAPIServerLBHealth(healthyBackends, totalBackends int32)
+}
+
+// BackendHealthGetter gets the number of healthy and unhealthy backends of a load balancing rule.
+type BackendHealthGetter interface {
+	BackendHealth(ctx context.Context, resourceGroupName, lbName, ruleName string) (healthy, unhealthy int32, err error)
 }
 
 // Service provides operations on Azure resources.
 type Service struct {
-	Scope LBScope
+	Scope        LBScope
+	healthGetter BackendHealthGetter
 	async.Reconciler
 }
 
@@ -51,8 +62,9 @@ type Service struct {
 func New(scope LBScope) *Service {
 	client := newClient(scope)
 	return &Service{
-		Scope:      scope,
-		Reconciler: async.New(scope, client, client),
+		Scope:        scope,
+		healthGetter: client,
+		Reconciler:   async.New(scope, client, client),
 	}
 }
 
@@ -83,6 +95,8 @@ func (s *Service) Reconcile(ctx context.Context) error {
 			if !azure.IsOperationNotDoneError(err) || result == nil {
 				result = err
 			}
+		} else if lbSpec.ResourceName() == s.Scope.APIServerLBName() {
+			s.updateAPIServerLBHealth(ctx, lbSpec)
 		}
 	}
 
@@ -90,6 +104,20 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	return result
 }
 
+// updateAPIServerLBHealth sets the APIServerLoadBalancerHealthy condition from the health of the backends of the API
+// server load balancing rule. The health is only reported, so failing to get it doesn't fail the reconciliation.
+func (s *Service) updateAPIServerLBHealth(ctx context.Context, lbSpec azure.ResourceSpecGetter) {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "loadbalancers.Service.updateAPIServerLBHealth")
+	defer done()
+
+	healthy, unhealthy, err := s.healthGetter.BackendHealth(ctx, lbSpec.ResourceGroupName(), lbSpec.ResourceName(), lbRuleHTTPS)
+	if err != nil {
+		log.V(2).Info("failed to get the health of the API server load balancer backends", "loadBalancer", lbSpec.ResourceName(), "error", err.Error())
+		return
+	}
+	s.Scope.SetAPIServerLBHealth(healthy, healthy+unhealthy)
+}
+
 // Delete deletes the public load balancer with the provided name.
 func (s *Service) Delete(ctx context.Context) error {
 	ctx, _, done := tele.StartSpanWithLogger(ctx, "loadbalancers.Service.Delete")
@@ -108,19 +108,19 @@ func TestReconcileLoadBalancer(t *testing.T) {
 	testcases := []struct {
 		name          string
 		expectedError string
-		expect        func(s *mock_loadbalancers.MockLBScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+		expect        func(s *mock_loadbalancers.MockLBScopeMockRecorder, h *mock_loadbalancers.MockBackendHealthGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder)
 	}{
 		{
 			name:          "noop if no LBSpecs are found",
 			expectedError: "",
-			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, h *mock_loadbalancers.MockBackendHealthGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.LBSpecs().Return([]azure.ResourceSpecGetter{})
 			},
 		},
 		{
 			name:          "fail to create a public LB",
 			expectedError: "#: Internal Server Error: StatusCode=500",
-			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, h *mock_loadbalancers.MockBackendHealthGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.LBSpecs().Return([]azure.ResourceSpecGetter{&fakePublicAPILBSpec})
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakePublicAPILBSpec, serviceName).Return(nil, internalError)
 				s.UpdatePutStatus(infrav1.LoadBalancersReadyCondition, serviceName, internalError)
@@ -129,41 +129,86 @@ func TestReconcileLoadBalancer(t *testing.T) {
 		{
 			name:          "create public apiserver LB",
 			expectedError: "",
-			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, h *mock_loadbalancers.MockBackendHealthGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.LBSpecs().Return([]azure.ResourceSpecGetter{&fakePublicAPILBSpec})
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakePublicAPILBSpec, serviceName).Return(nil, nil)
+				s.APIServerLBName().Return("my-publiclb")
+				h.BackendHealth(gomockinternal.AContext(), "my-rg", "my-publiclb", lbRuleHTTPS).Return(int32(2), int32(1), nil)
+				s.SetAPIServerLBHealth(int32(2), int32(3))
 				s.UpdatePutStatus(infrav1.LoadBalancersReadyCondition, serviceName, nil)
 			},
 		},
 		{
 			name:          "create internal apiserver LB",
 			expectedError: "",
-			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, h *mock_loadbalancers.MockBackendHealthGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.LBSpecs().Return([]azure.ResourceSpecGetter{&fakeInternalAPILBSpec})
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeInternalAPILBSpec, serviceName).Return(nil, nil)
+				s.APIServerLBName().Return("my-private-lb")
+				h.BackendHealth(gomockinternal.AContext(), "my-rg", "my-private-lb", lbRuleHTTPS).Return(int32(3), int32(0), nil)
+				s.SetAPIServerLBHealth(int32(3), int32(3))
 				s.UpdatePutStatus(infrav1.LoadBalancersReadyCondition, serviceName, nil)
 			},
 		},
 		{
 			name:          "create node outbound LB",
 			expectedError: "",
-			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, h *mock_loadbalancers.MockBackendHealthGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.LBSpecs().Return([]azure.ResourceSpecGetter{&fakeNodeOutboundLBSpec})
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeNodeOutboundLBSpec, serviceName).Return(nil, nil)
+				s.APIServerLBName().Return("my-publiclb")
 				s.UpdatePutStatus(infrav1.LoadBalancersReadyCondition, serviceName, nil)
 			},
 		},
 		{
 			name:          "create multiple LBs",
 			expectedError: "",
-			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, h *mock_loadbalancers.MockBackendHealthGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.LBSpecs().Return([]azure.ResourceSpecGetter{&fakePublicAPILBSpec, &fakeInternalAPILBSpec, &fakeNodeOutboundLBSpec})
+				s.APIServerLBName().Return("my-publiclb").Times(3)
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakePublicAPILBSpec, serviceName).Return(nil, nil)
+				h.BackendHealth(gomockinternal.AContext(), "my-rg", "my-publiclb", lbRuleHTTPS).Return(int32(1), int32(2), nil)
+				s.SetAPIServerLBHealth(int32(1), int32(3))
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeInternalAPILBSpec, serviceName).Return(nil, nil)
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeNodeOutboundLBSpec, serviceName).Return(nil, nil)
 				s.UpdatePutStatus(infrav1.LoadBalancersReadyCondition, serviceName, nil)
 			},
 		},
+		{
+			name:          "no healthy backends behind the apiserver LB",
+			expectedError: "",
+			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, h *mock_loadbalancers.MockBackendHealthGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.LBSpecs().Return([]azure.ResourceSpecGetter{&fakePublicAPILBSpec})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakePublicAPILBSpec, serviceName).Return(nil, nil)
+				s.APIServerLBName().Return("my-publiclb")
+				h.BackendHealth(gomockinternal.AContext(), "my-rg", "my-publiclb", lbRuleHTTPS).Return(int32(0), int32(3), nil)
+				s.SetAPIServerLBHealth(int32(0), int32(3))
+				s.UpdatePutStatus(infrav1.LoadBalancersReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "apiserver LB without backends",
+			expectedError: "",
+			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, h *mock_loadbalancers.MockBackendHealthGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.LBSpecs().Return([]azure.ResourceSpecGetter{&fakePublicAPILBSpec})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakePublicAPILBSpec, serviceName).Return(nil, nil)
+				s.APIServerLBName().Return("my-publiclb")
+				h.BackendHealth(gomockinternal.AContext(), "my-rg", "my-publiclb", lbRuleHTTPS).Return(int32(0), int32(0), nil)
+				s.SetAPIServerLBHealth(int32(0), int32(0))
+				s.UpdatePutStatus(infrav1.LoadBalancersReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "failing to get the apiserver LB health doesn't fail the reconciliation",
+			expectedError: "",
+			expect: func(s *mock_loadbalancers.MockLBScopeMockRecorder, h *mock_loadbalancers.MockBackendHealthGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.LBSpecs().Return([]azure.ResourceSpecGetter{&fakePublicAPILBSpec})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakePublicAPILBSpec, serviceName).Return(nil, nil)
+				s.APIServerLBName().Return("my-publiclb")
+				h.BackendHealth(gomockinternal.AContext(), "my-rg", "my-publiclb", lbRuleHTTPS).Return(int32(0), int32(0), internalError)
+				s.UpdatePutStatus(infrav1.LoadBalancersReadyCondition, serviceName, nil)
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -176,13 +221,15 @@ func TestReconcileLoadBalancer(t *testing.T) {
 			defer mockCtrl.Finish()
 
 			scopeMock := mock_loadbalancers.NewMockLBScope(mockCtrl)
+			healthMock := mock_loadbalancers.NewMockBackendHealthGetter(mockCtrl)
 			asyncMock := mock_async.NewMockReconciler(mockCtrl)
 
-			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT())
+			tc.expect(scopeMock.EXPECT(), healthMock.EXPECT(), asyncMock.EXPECT())
 
 			s := &Service{
-				Scope:      scopeMock,
-				Reconciler: asyncMock,
+				Scope:        scopeMock,
+				healthGetter: healthMock,
+				Reconciler:   asyncMock,
 			}
 			err := s.Reconcile(context.TODO())
 			if tc.expectedError != "" {
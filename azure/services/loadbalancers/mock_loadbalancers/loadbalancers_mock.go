@@ -21,12 +21,14 @@ limitations under the License.
 package mock_loadbalancers
 
 import (
+	context "context"
 	reflect "reflect"
 
 	autorest "github.com/Azure/go-autorest/autorest"
+	azure "github.com/Azure/go-autorest/autorest/azure"
 	gomock "go.uber.org/mock/gomock"
 	v1beta1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
-	azure "sigs.k8s.io/cluster-api-provider-azure/azure"
+	azure0 "sigs.k8s.io/cluster-api-provider-azure/azure"
 	v1beta10 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
@@ -137,6 +139,20 @@ func (mr *MockLBScopeMockRecorder) AvailabilitySetEnabled() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockLBScope)(nil).AvailabilitySetEnabled))
 }
 
+// AzureEnvironment mocks base method.
+func (m *MockLBScope) AzureEnvironment() azure.Environment {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureEnvironment")
+	ret0, _ := ret[0].(azure.Environment)
+	return ret0
+}
+
+// AzureEnvironment indicates an expected call of AzureEnvironment.
+func (mr *MockLBScopeMockRecorder) AzureEnvironment() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureEnvironment", reflect.TypeOf((*MockLBScope)(nil).AzureEnvironment))
+}
+
 // BaseURI mocks base method.
 func (m *MockLBScope) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -401,11 +417,40 @@ func (mr *MockLBScopeMockRecorder) IsVnetManaged() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsVnetManaged", reflect.TypeOf((*MockLBScope)(nil).IsVnetManaged))
 }
 
+// KeyVaultAuthorizer mocks base method.
+func (m *MockLBScope) KeyVaultAuthorizer(ctx context.Context) (autorest.Authorizer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyVaultAuthorizer", ctx)
+	ret0, _ := ret[0].(autorest.Authorizer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KeyVaultAuthorizer indicates an expected call of KeyVaultAuthorizer.
+func (mr *MockLBScopeMockRecorder) KeyVaultAuthorizer(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyVaultAuthorizer", reflect.TypeOf((*MockLBScope)(nil).KeyVaultAuthorizer), ctx)
+}
+
+// KeyVaultDNSSuffix mocks base method.
+func (m *MockLBScope) KeyVaultDNSSuffix() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyVaultDNSSuffix")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// KeyVaultDNSSuffix indicates an expected call of KeyVaultDNSSuffix.
+func (mr *MockLBScopeMockRecorder) KeyVaultDNSSuffix() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyVaultDNSSuffix", reflect.TypeOf((*MockLBScope)(nil).KeyVaultDNSSuffix))
+}
+
 // LBSpecs mocks base method.
-func (m *MockLBScope) LBSpecs() []azure.ResourceSpecGetter {
+func (m *MockLBScope) LBSpecs() []azure0.ResourceSpecGetter {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "LBSpecs")
-	ret0, _ := ret[0].([]azure.ResourceSpecGetter)
+	ret0, _ := ret[0].([]azure0.ResourceSpecGetter)
 	return ret0
 }
 
@@ -443,6 +488,20 @@ func (mr *MockLBScopeMockRecorder) NodeSubnets() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeSubnets", reflect.TypeOf((*MockLBScope)(nil).NodeSubnets))
 }
 
+// OutboundAdditionalPoolNames mocks base method.
+func (m *MockLBScope) OutboundAdditionalPoolNames(arg0 string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OutboundAdditionalPoolNames", arg0)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// OutboundAdditionalPoolNames indicates an expected call of OutboundAdditionalPoolNames.
+func (mr *MockLBScopeMockRecorder) OutboundAdditionalPoolNames(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OutboundAdditionalPoolNames", reflect.TypeOf((*MockLBScope)(nil).OutboundAdditionalPoolNames), arg0)
+}
+
 // OutboundLBName mocks base method.
 func (m *MockLBScope) OutboundLBName(arg0 string) string {
 	m.ctrl.T.Helper()
@@ -485,6 +544,18 @@ func (mr *MockLBScopeMockRecorder) ResourceGroup() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResourceGroup", reflect.TypeOf((*MockLBScope)(nil).ResourceGroup))
 }
 
+// SetAPIServerLBHealth mocks base method.
+func (m *MockLBScope) SetAPIServerLBHealth(healthyBackends, totalBackends int32) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetAPIServerLBHealth", healthyBackends, totalBackends)
+}
+
+// SetAPIServerLBHealth indicates an expected call of SetAPIServerLBHealth.
+func (mr *MockLBScopeMockRecorder) SetAPIServerLBHealth(healthyBackends, totalBackends interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAPIServerLBHealth", reflect.TypeOf((*MockLBScope)(nil).SetAPIServerLBHealth), healthyBackends, totalBackends)
+}
+
 // SetLongRunningOperationState mocks base method.
 func (m *MockLBScope) SetLongRunningOperationState(arg0 *v1beta1.Future) {
 	m.ctrl.T.Helper()
@@ -614,3 +685,42 @@ func (mr *MockLBScopeMockRecorder) Vnet() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vnet", reflect.TypeOf((*MockLBScope)(nil).Vnet))
 }
+
+// MockBackendHealthGetter is a mock of BackendHealthGetter interface.
+type MockBackendHealthGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockBackendHealthGetterMockRecorder
+}
+
+// MockBackendHealthGetterMockRecorder is the mock recorder for MockBackendHealthGetter.
+type MockBackendHealthGetterMockRecorder struct {
+	mock *MockBackendHealthGetter
+}
+
+// NewMockBackendHealthGetter creates a new mock instance.
+func NewMockBackendHealthGetter(ctrl *gomock.Controller) *MockBackendHealthGetter {
+	mock := &MockBackendHealthGetter{ctrl: ctrl}
+	mock.recorder = &MockBackendHealthGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBackendHealthGetter) EXPECT() *MockBackendHealthGetterMockRecorder {
+	return m.recorder
+}
+
+// BackendHealth mocks base method.
+func (m *MockBackendHealthGetter) BackendHealth(ctx context.Context, resourceGroupName, lbName, ruleName string) (int32, int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BackendHealth", ctx, resourceGroupName, lbName, ruleName)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(int32)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// BackendHealth indicates an expected call of BackendHealth.
+func (mr *MockBackendHealthGetterMockRecorder) BackendHealth(ctx, resourceGroupName, lbName, ruleName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BackendHealth", reflect.TypeOf((*MockBackendHealthGetter)(nil).BackendHealth), ctx, resourceGroupName, lbName, ruleName)
+}
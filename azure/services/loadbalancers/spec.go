@@ -18,6 +18,7 @@ package loadbalancers
 
 import (
 	"context"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
 	"github.com/pkg/errors"
@@ -25,27 +26,41 @@ import (
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 )
 
 // LBSpec defines the specification for a Load Balancer.
 type LBSpec struct {
-	Name                 string
-	ResourceGroup        string
-	SubscriptionID       string
-	ClusterName          string
-	Location             string
-	ExtendedLocation     *infrav1.ExtendedLocationSpec
-	Role                 string
-	Type                 infrav1.LBType
-	SKU                  infrav1.SKU
-	VNetName             string
-	VNetResourceGroup    string
-	SubnetName           string
-	BackendPoolName      string
-	FrontendIPConfigs    []infrav1.FrontendIP
-	APIServerPort        int32
-	IdleTimeoutInMinutes *int32
-	AdditionalTags       map[string]string
+	Name              string
+	ResourceGroup     string
+	SubscriptionID    string
+	ClusterName       string
+	Location          string
+	ExtendedLocation  *infrav1.ExtendedLocationSpec
+	Role              string
+	Type              infrav1.LBType
+	SKU               infrav1.SKU
+	VNetName          string
+	VNetResourceGroup string
+	SubnetName        string
+	BackendPoolName   string
+	// AdditionalBackendPoolNames are the names of backend pools created in addition to BackendPoolName.
+	AdditionalBackendPoolNames []string
+	FrontendIPConfigs          []infrav1.FrontendIP
+	APIServerPort              int32
+	IdleTimeoutInMinutes       *int32
+	AdditionalTags             map[string]string
+	// DisableOutboundNAT removes the outbound rule of the load balancer, so that its backends have no outbound
+	// connectivity through it.
+	DisableOutboundNAT bool
+	// EnableFloatingIP enables floating IP, also known as direct server return, on the load balancing rule of the API
+	// server load balancer.
+	EnableFloatingIP bool
+	// HealthProbe configures the health probe of the API server load balancer. The default probe is used if it is nil.
+	HealthProbe *infrav1.HealthProbeSpec
+	// FailureDomains are the availability zones the frontend of an internal Standard load balancer is zone-redundant
+	// across, so that its static private IP stays reachable when a zone fails.
+	FailureDomains []string
 }
 
 // ResourceName returns the name of the load balancer.
@@ -100,6 +115,9 @@ func (s *LBSpec) Parameters(ctx context.Context, existing interface{}) (paramete
 			if !lbRuleExists(loadBalancingRules, rule) {
 				update = true
 				loadBalancingRules = append(loadBalancingRules, rule)
+			} else if rules, updated := updateLBRuleFloatingIP(loadBalancingRules, rule); updated {
+				update = true
+				loadBalancingRules = rules
 			}
 		}
 
@@ -112,6 +130,10 @@ func (s *LBSpec) Parameters(ctx context.Context, existing interface{}) (paramete
 		}
 
 		outboundRules = *existingLB.OutboundRules
+		if s.DisableOutboundNAT && outboundRuleExists(outboundRules, network.OutboundRule{Name: ptr.To(outboundNAT)}) {
+			update = true
+			outboundRules = removeOutboundRule(outboundRules, outboundNAT)
+		}
 		for _, rule := range getOutboundRules(*s, wantedFrontendIDs) {
 			if !outboundRuleExists(outboundRules, rule) {
 				update = true
@@ -124,6 +146,13 @@ func (s *LBSpec) Parameters(ctx context.Context, existing interface{}) (paramete
 			if !probeExists(probes, probe) {
 				update = true
 				probes = append(probes, probe)
+			} else if s.HealthProbe != nil {
+				// Existing probes are only updated when the health probe is configured, to keep the probes modified
+				// outside of CAPZ otherwise.
+				if updatedProbes, updated := updateProbe(probes, probe); updated {
+					update = true
+					probes = updatedProbes
+				}
 			}
 		}
 
@@ -144,11 +173,11 @@ func (s *LBSpec) Parameters(ctx context.Context, existing interface{}) (paramete
 		Sku:              &network.LoadBalancerSku{Name: converters.SKUtoSDK(s.SKU)},
 		Location:         ptr.To(s.Location),
 		ExtendedLocation: converters.ExtendedLocationToNetworkSDK(s.ExtendedLocation),
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
 			ClusterName: s.ClusterName,
 			Lifecycle:   infrav1.ResourceLifecycleOwned,
 			Role:        ptr.To(s.Role),
-			Additional:  s.AdditionalTags,
+			ClusterTags: s.AdditionalTags,
 		})),
 		LoadBalancerPropertiesFormat: &network.LoadBalancerPropertiesFormat{
 			FrontendIPConfigurations: &frontendIPConfigs,
@@ -167,6 +196,7 @@ func getFrontendIPConfigs(lbSpec LBSpec) ([]network.FrontendIPConfiguration, []n
 	frontendIDs := make([]network.SubResource, 0)
 	for _, ipConfig := range lbSpec.FrontendIPConfigs {
 		var properties network.FrontendIPConfigurationPropertiesFormat
+		var zones *[]string
 		if lbSpec.Type == infrav1.Internal {
 			properties = network.FrontendIPConfigurationPropertiesFormat{
 				PrivateIPAllocationMethod: network.IPAllocationMethodStatic,
@@ -175,6 +205,7 @@ func getFrontendIPConfigs(lbSpec LBSpec) ([]network.FrontendIPConfiguration, []n
 				},
 				PrivateIPAddress: ptr.To(ipConfig.PrivateIPAddress),
 			}
+			zones = getInternalFrontendZones(lbSpec)
 		} else {
 			properties = network.FrontendIPConfigurationPropertiesFormat{
 				PublicIPAddress: &network.PublicIPAddress{
@@ -185,6 +216,7 @@ func getFrontendIPConfigs(lbSpec LBSpec) ([]network.FrontendIPConfiguration, []n
 		frontendIPConfigurations = append(frontendIPConfigurations, network.FrontendIPConfiguration{
 			FrontendIPConfigurationPropertiesFormat: &properties,
 			Name:                                    ptr.To(ipConfig.Name),
+			Zones:                                   zones,
 		})
 		frontendIDs = append(frontendIDs, network.SubResource{
 			ID: ptr.To(azure.FrontendIPConfigID(lbSpec.SubscriptionID, lbSpec.ResourceGroup, lbSpec.Name, ipConfig.Name)),
@@ -193,8 +225,20 @@ func getFrontendIPConfigs(lbSpec LBSpec) ([]network.FrontendIPConfiguration, []n
 	return frontendIPConfigurations, frontendIDs
 }
 
+// getInternalFrontendZones returns the zones of the frontend of an internal load balancer. The frontend is
+// zone-redundant across the failure domains of the cluster, which only Standard load balancers outside of an edge zone
+// support. The frontend of a public load balancer gets its zones from its public IP instead.
+func getInternalFrontendZones(lbSpec LBSpec) *[]string {
+	if lbSpec.SKU != infrav1.SKUStandard || lbSpec.ExtendedLocation != nil || len(lbSpec.FailureDomains) == 0 {
+		return nil
+	}
+	zones := make([]string, len(lbSpec.FailureDomains))
+	copy(zones, lbSpec.FailureDomains)
+	return &zones
+}
+
 func getOutboundRules(lbSpec LBSpec, frontendIDs []network.SubResource) []network.OutboundRule {
-	if lbSpec.Type == infrav1.Internal {
+	if lbSpec.Type == infrav1.Internal || lbSpec.DisableOutboundNAT {
 		return []network.OutboundRule{}
 	}
 	return []network.OutboundRule{
@@ -229,7 +273,7 @@ func getLoadBalancingRules(lbSpec LBSpec, frontendIDs []network.SubResource) []n
 					FrontendPort:            ptr.To[int32](lbSpec.APIServerPort),
 					BackendPort:             ptr.To[int32](lbSpec.APIServerPort),
 					IdleTimeoutInMinutes:    lbSpec.IdleTimeoutInMinutes,
-					EnableFloatingIP:        ptr.To(false),
+					EnableFloatingIP:        ptr.To(lbSpec.EnableFloatingIP),
 					LoadDistribution:        network.LoadDistributionDefault,
 					FrontendIPConfiguration: &frontendIPConfig,
 					BackendAddressPool: &network.SubResource{
@@ -246,31 +290,88 @@ func getLoadBalancingRules(lbSpec LBSpec, frontendIDs []network.SubResource) []n
 }
 
 func getBackendAddressPools(lbSpec LBSpec) []network.BackendAddressPool {
-	return []network.BackendAddressPool{
+	pools := []network.BackendAddressPool{
 		{
 			Name: ptr.To(lbSpec.BackendPoolName),
 		},
 	}
+	for _, name := range lbSpec.AdditionalBackendPoolNames {
+		pools = append(pools, network.BackendAddressPool{
+			Name: ptr.To(name),
+		})
+	}
+	return pools
 }
 
 func getProbes(lbSpec LBSpec) []network.Probe {
 	if lbSpec.Role == infrav1.APIServerRole {
+		// The probe keeps its name whatever its protocol, as the load balancing rule references it by name.
 		return []network.Probe{
 			{
-				Name: ptr.To(httpsProbe),
-				ProbePropertiesFormat: &network.ProbePropertiesFormat{
-					Protocol:          network.ProbeProtocolHTTPS,
-					Port:              ptr.To[int32](lbSpec.APIServerPort),
-					RequestPath:       ptr.To(httpsProbeRequestPath),
-					IntervalInSeconds: ptr.To[int32](15),
-					NumberOfProbes:    ptr.To[int32](4),
-				},
+				Name:                  ptr.To(httpsProbe),
+				ProbePropertiesFormat: getProbeProperties(lbSpec),
 			},
 		}
 	}
 	return []network.Probe{}
 }
 
+// getProbeProperties returns the properties of the health probe of the API server port. It defaults to an HTTPS probe
+// of the readiness endpoint of the API server.
+func getProbeProperties(lbSpec LBSpec) *network.ProbePropertiesFormat {
+	properties := &network.ProbePropertiesFormat{
+		Protocol:          network.ProbeProtocolHTTPS,
+		Port:              ptr.To[int32](lbSpec.APIServerPort),
+		RequestPath:       ptr.To(httpsProbeRequestPath),
+		IntervalInSeconds: ptr.To[int32](defaultProbeIntervalInSeconds),
+		NumberOfProbes:    ptr.To[int32](defaultNumberOfProbes),
+	}
+	probe := lbSpec.HealthProbe
+	if probe == nil {
+		return properties
+	}
+	properties.Protocol = network.ProbeProtocol(probe.Protocol)
+	properties.RequestPath = nil
+	if probe.Protocol != infrav1.ProbeProtocolTCP {
+		properties.RequestPath = ptr.To(probe.RequestPath)
+	}
+	if probe.IntervalInSeconds != nil {
+		properties.IntervalInSeconds = probe.IntervalInSeconds
+	}
+	if probe.NumberOfProbes != nil {
+		properties.NumberOfProbes = probe.NumberOfProbes
+	}
+	return properties
+}
+
+// updateProbe returns the probes with the properties of an existing probe replaced by the properties of the wanted
+// probe of the same name, and whether they changed.
+func updateProbe(probes []network.Probe, probe network.Probe) ([]network.Probe, bool) {
+	for i, p := range probes {
+		if ptr.Deref(p.Name, "") != ptr.Deref(probe.Name, "") {
+			continue
+		}
+		if p.ProbePropertiesFormat != nil && probePropertiesEqual(*p.ProbePropertiesFormat, *probe.ProbePropertiesFormat) {
+			return probes, false
+		}
+		updated := make([]network.Probe, len(probes))
+		copy(updated, probes)
+		updated[i].ProbePropertiesFormat = probe.ProbePropertiesFormat
+		return updated, true
+	}
+	return probes, false
+}
+
+// probePropertiesEqual returns true if two probes have the same protocol, port, request path, interval and number of
+// probes.
+func probePropertiesEqual(a, b network.ProbePropertiesFormat) bool {
+	return strings.EqualFold(string(a.Protocol), string(b.Protocol)) &&
+		ptr.Deref(a.Port, 0) == ptr.Deref(b.Port, 0) &&
+		ptr.Deref(a.RequestPath, "") == ptr.Deref(b.RequestPath, "") &&
+		ptr.Deref(a.IntervalInSeconds, 0) == ptr.Deref(b.IntervalInSeconds, 0) &&
+		ptr.Deref(a.NumberOfProbes, 0) == ptr.Deref(b.NumberOfProbes, 0)
+}
+
 func probeExists(probes []network.Probe, probe network.Probe) bool {
 	for _, p := range probes {
 		if ptr.Deref(p.Name, "") == ptr.Deref(probe.Name, "") {
@@ -289,6 +390,16 @@ func outboundRuleExists(rules []network.OutboundRule, rule network.OutboundRule)
 	return false
 }
 
+func removeOutboundRule(rules []network.OutboundRule, name string) []network.OutboundRule {
+	kept := make([]network.OutboundRule, 0, len(rules))
+	for _, r := range rules {
+		if ptr.Deref(r.Name, "") != name {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
 func poolExists(pools []network.BackendAddressPool, pool network.BackendAddressPool) bool {
 	for _, p := range pools {
 		if ptr.Deref(p.Name, "") == ptr.Deref(pool.Name, "") {
@@ -307,6 +418,27 @@ func lbRuleExists(rules []network.LoadBalancingRule, rule network.LoadBalancingR
 	return false
 }
 
+// updateLBRuleFloatingIP returns a copy of the rules where the existing rule with the name of rule has the floating IP
+// setting of rule, and whether that setting changed.
+func updateLBRuleFloatingIP(rules []network.LoadBalancingRule, rule network.LoadBalancingRule) ([]network.LoadBalancingRule, bool) {
+	wanted := ptr.Deref(rule.EnableFloatingIP, false)
+	for i, r := range rules {
+		if ptr.Deref(r.Name, "") != ptr.Deref(rule.Name, "") || r.LoadBalancingRulePropertiesFormat == nil {
+			continue
+		}
+		if ptr.Deref(r.EnableFloatingIP, false) == wanted {
+			return rules, false
+		}
+		updated := make([]network.LoadBalancingRule, len(rules))
+		copy(updated, rules)
+		props := *r.LoadBalancingRulePropertiesFormat
+		props.EnableFloatingIP = ptr.To(wanted)
+		updated[i].LoadBalancingRulePropertiesFormat = &props
+		return updated, true
+	}
+	return rules, false
+}
+
 func ipExists(configs []network.FrontendIPConfiguration, config network.FrontendIPConfiguration) bool {
 	for _, ip := range configs {
 		if ptr.Deref(ip.Name, "") == ptr.Deref(config.Name, "") {
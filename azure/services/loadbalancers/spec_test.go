@@ -87,6 +87,60 @@ func TestParameters(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name:     "new zone-redundant internal API load balancer with a static private IP",
+			spec:     newInternalAPILBSpecWithFailureDomains("1", "2", "3"),
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.LoadBalancer{}))
+				frontends := *result.(network.LoadBalancer).FrontendIPConfigurations
+				g.Expect(frontends).To(HaveLen(1))
+				g.Expect(frontends[0].Zones).To(Equal(&[]string{"1", "2", "3"}))
+				g.Expect(frontends[0].PrivateIPAllocationMethod).To(Equal(network.IPAllocationMethodStatic))
+				g.Expect(frontends[0].PrivateIPAddress).To(Equal(ptr.To("10.0.0.10")))
+			},
+			expectedError: "",
+		},
+		{
+			name:     "new internal API load balancer in a location without availability zones has no frontend zones",
+			spec:     &fakeInternalAPILBSpec,
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.LoadBalancer{}))
+				frontends := *result.(network.LoadBalancer).FrontendIPConfigurations
+				g.Expect(frontends).To(HaveLen(1))
+				g.Expect(frontends[0].Zones).To(BeNil())
+				g.Expect(frontends[0].PrivateIPAllocationMethod).To(Equal(network.IPAllocationMethodStatic))
+			},
+			expectedError: "",
+		},
+		{
+			name: "new internal API load balancer in an edge zone has no frontend zones",
+			spec: func() *LBSpec {
+				spec := newInternalAPILBSpecWithFailureDomains("1", "2", "3")
+				spec.ExtendedLocation = &infrav1.ExtendedLocationSpec{Name: "losangeles", Type: "EdgeZone"}
+				return spec
+			}(),
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.LoadBalancer{}))
+				g.Expect((*result.(network.LoadBalancer).FrontendIPConfigurations)[0].Zones).To(BeNil())
+				g.Expect(result.(network.LoadBalancer).ExtendedLocation).To(Equal(&network.ExtendedLocation{
+					Name: ptr.To("losangeles"),
+					Type: network.ExtendedLocationTypesEdgeZone,
+				}))
+			},
+			expectedError: "",
+		},
+		{
+			name:     "zone-redundant internal API load balancer exists with all expected values",
+			spec:     newInternalAPILBSpecWithFailureDomains("1", "2", "3"),
+			existing: newDefaultInternalAPIServerLB(),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+			expectedError: "",
+		},
 		{
 			name:     "node outbound load balancer exists with all expected values",
 			spec:     &fakeNodeOutboundLBSpec,
@@ -96,6 +150,94 @@ func TestParameters(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name:     "node outbound load balancer with outbound NAT disabled removes the existing outbound rule",
+			spec:     newNodeOutboundLBSpecWithOutboundNATDisabled(),
+			existing: newDefaultNodeOutboundLB(),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.LoadBalancer{}))
+				g.Expect(*result.(network.LoadBalancer).OutboundRules).To(BeEmpty())
+			},
+			expectedError: "",
+		},
+		{
+			name:     "new node outbound load balancer with outbound NAT disabled",
+			spec:     newNodeOutboundLBSpecWithOutboundNATDisabled(),
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.LoadBalancer{}))
+				lb := result.(network.LoadBalancer)
+				g.Expect(*lb.OutboundRules).To(BeEmpty())
+				g.Expect(*lb.BackendAddressPools).To(HaveLen(1))
+			},
+			expectedError: "",
+		},
+		{
+			name:     "node outbound load balancer exists with missing additional backend pools",
+			spec:     newNodeOutboundLBSpecWithAdditionalBackendPools(),
+			existing: newDefaultNodeOutboundLB(),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.LoadBalancer{}))
+				g.Expect(*result.(network.LoadBalancer).BackendAddressPools).To(Equal([]network.BackendAddressPool{
+					{Name: ptr.To("my-cluster-outboundBackendPool")},
+					{Name: ptr.To("service-pool-1")},
+					{Name: ptr.To("service-pool-2")},
+				}))
+			},
+			expectedError: "",
+		},
+		{
+			name:     "new public API load balancer with floating IP enabled",
+			spec:     newPublicAPILBSpecWithFloatingIP(),
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.LoadBalancer{}))
+				rules := *result.(network.LoadBalancer).LoadBalancingRules
+				g.Expect(rules).To(HaveLen(1))
+				g.Expect(rules[0].EnableFloatingIP).To(Equal(ptr.To(true)))
+			},
+			expectedError: "",
+		},
+		{
+			name:     "public API load balancer exists with floating IP disabled on its rule",
+			spec:     newPublicAPILBSpecWithFloatingIP(),
+			existing: newSamplePublicAPIServerLB(false, false, true, false, false),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.LoadBalancer{}))
+				rules := *result.(network.LoadBalancer).LoadBalancingRules
+				g.Expect(rules).To(HaveLen(1))
+				g.Expect(rules[0].EnableFloatingIP).To(Equal(ptr.To(true)))
+				g.Expect(rules[0].LoadDistribution).To(Equal(network.LoadDistributionSourceIP))
+			},
+			expectedError: "",
+		},
+		{
+			name:     "public API load balancer exists with a probe differing from the configured health probe",
+			spec:     newPublicAPILBSpecWithHealthProbe(&infrav1.HealthProbeSpec{Protocol: infrav1.ProbeProtocolTCP}),
+			existing: newSamplePublicAPIServerLB(false, false, false, true, false),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.LoadBalancer{}))
+				probes := *result.(network.LoadBalancer).Probes
+				g.Expect(probes).To(HaveLen(1))
+				g.Expect(probes[0].Name).To(Equal(ptr.To(httpsProbe)))
+				g.Expect(probes[0].Protocol).To(Equal(network.ProbeProtocolTCP))
+				g.Expect(probes[0].RequestPath).To(BeNil())
+				g.Expect(probes[0].NumberOfProbes).To(Equal(ptr.To[int32](4)))
+			},
+			expectedError: "",
+		},
+		{
+			name: "public API load balancer exists with a probe matching the configured health probe",
+			spec: newPublicAPILBSpecWithHealthProbe(&infrav1.HealthProbeSpec{
+				Protocol:    infrav1.ProbeProtocolHTTPS,
+				RequestPath: httpsProbeRequestPath,
+			}),
+			existing: newSamplePublicAPIServerLB(false, false, false, false, false),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+			expectedError: "",
+		},
 		{
 			name:     "load balancer exists with missing frontend IP configs",
 			spec:     &fakePublicAPILBSpec,
@@ -165,6 +307,107 @@ func TestParameters(t *testing.T) {
 	}
 }
 
+func newNodeOutboundLBSpecWithOutboundNATDisabled() *LBSpec {
+	spec := fakeNodeOutboundLBSpec
+	spec.DisableOutboundNAT = true
+	return &spec
+}
+
+func newInternalAPILBSpecWithFailureDomains(failureDomains ...string) *LBSpec {
+	spec := fakeInternalAPILBSpec
+	spec.FailureDomains = failureDomains
+	return &spec
+}
+
+func newPublicAPILBSpecWithFloatingIP() *LBSpec {
+	spec := fakePublicAPILBSpec
+	spec.EnableFloatingIP = true
+	return &spec
+}
+
+func newPublicAPILBSpecWithHealthProbe(probe *infrav1.HealthProbeSpec) *LBSpec {
+	spec := fakePublicAPILBSpec
+	spec.HealthProbe = probe
+	return &spec
+}
+
+func TestGetProbeProperties(t *testing.T) {
+	testcases := []struct {
+		name        string
+		healthProbe *infrav1.HealthProbeSpec
+		expected    *network.ProbePropertiesFormat
+	}{
+		{
+			name:        "defaults to an HTTPS probe of the readiness endpoint",
+			healthProbe: nil,
+			expected: &network.ProbePropertiesFormat{
+				Protocol:          network.ProbeProtocolHTTPS,
+				Port:              ptr.To[int32](6443),
+				RequestPath:       ptr.To("/readyz"),
+				IntervalInSeconds: ptr.To[int32](15),
+				NumberOfProbes:    ptr.To[int32](4),
+			},
+		},
+		{
+			name:        "TCP probe has no request path",
+			healthProbe: &infrav1.HealthProbeSpec{Protocol: infrav1.ProbeProtocolTCP},
+			expected: &network.ProbePropertiesFormat{
+				Protocol:          network.ProbeProtocolTCP,
+				Port:              ptr.To[int32](6443),
+				IntervalInSeconds: ptr.To[int32](15),
+				NumberOfProbes:    ptr.To[int32](4),
+			},
+		},
+		{
+			name: "HTTP probe with a custom request path, interval and number of probes",
+			healthProbe: &infrav1.HealthProbeSpec{
+				Protocol:          infrav1.ProbeProtocolHTTP,
+				RequestPath:       "/healthz",
+				IntervalInSeconds: ptr.To[int32](5),
+				NumberOfProbes:    ptr.To[int32](2),
+			},
+			expected: &network.ProbePropertiesFormat{
+				Protocol:          network.ProbeProtocolHTTP,
+				Port:              ptr.To[int32](6443),
+				RequestPath:       ptr.To("/healthz"),
+				IntervalInSeconds: ptr.To[int32](5),
+				NumberOfProbes:    ptr.To[int32](2),
+			},
+		},
+		{
+			name: "HTTPS probe with a custom request path keeps the default interval and number of probes",
+			healthProbe: &infrav1.HealthProbeSpec{
+				Protocol:    infrav1.ProbeProtocolHTTPS,
+				RequestPath: "/livez",
+			},
+			expected: &network.ProbePropertiesFormat{
+				Protocol:          network.ProbeProtocolHTTPS,
+				Port:              ptr.To[int32](6443),
+				RequestPath:       ptr.To("/livez"),
+				IntervalInSeconds: ptr.To[int32](15),
+				NumberOfProbes:    ptr.To[int32](4),
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			spec := fakePublicAPILBSpec
+			spec.HealthProbe = tc.healthProbe
+			g.Expect(getProbeProperties(spec)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func newNodeOutboundLBSpecWithAdditionalBackendPools() *LBSpec {
+	spec := fakeNodeOutboundLBSpec
+	spec.AdditionalBackendPoolNames = []string{"service-pool-1", "service-pool-2"}
+	return &spec
+}
+
 func newDefaultNodeOutboundLB() network.LoadBalancer {
 	return network.LoadBalancer{
 		Tags: map[string]*string{
@@ -211,7 +454,7 @@ func newDefaultNodeOutboundLB() network.LoadBalancer {
 func newSamplePublicAPIServerLB(verifyFrontendIP bool, verifyBackendAddressPools bool, verifyLBRules bool, verifyProbes bool, verifyOutboundRules bool) network.LoadBalancer {
 	var subnet *network.Subnet
 	var backendAddressPoolProps *network.BackendAddressPoolPropertiesFormat
-	enableFloatingIP := ptr.To(false)
+	loadDistribution := network.LoadDistributionDefault
 	numProbes := ptr.To[int32](4)
 	idleTimeout := ptr.To[int32](4)
 
@@ -226,7 +469,7 @@ func newSamplePublicAPIServerLB(verifyFrontendIP bool, verifyBackendAddressPools
 		}
 	}
 	if verifyLBRules {
-		enableFloatingIP = ptr.To(true)
+		loadDistribution = network.LoadDistributionSourceIP
 	}
 	if verifyProbes {
 		numProbes = ptr.To[int32](999)
@@ -267,8 +510,8 @@ func newSamplePublicAPIServerLB(verifyFrontendIP bool, verifyBackendAddressPools
 						FrontendPort:         ptr.To[int32](6443),
 						BackendPort:          ptr.To[int32](6443),
 						IdleTimeoutInMinutes: ptr.To[int32](4),
-						EnableFloatingIP:     enableFloatingIP, // Add to verify that LoadBalancingRules aren't overwritten on update
-						LoadDistribution:     network.LoadDistributionDefault,
+						EnableFloatingIP:     ptr.To(false),
+						LoadDistribution:     loadDistribution, // Add to verify that LoadBalancingRules aren't overwritten on update
 						FrontendIPConfiguration: &network.SubResource{
 							ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/loadBalancers/my-publiclb/frontendIPConfigurations/my-publiclb-frontEnd"),
 						},
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedclusters
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// resolveAuthorizedIPRanges adds the authorized IP ranges of the ConfigMap referenced by the managed cluster spec to the
+// authorized IP ranges of the spec.
+func (s *Service) resolveAuthorizedIPRanges(ctx context.Context, spec azure.ResourceSpecGetter) error {
+	managedClusterSpec, ok := spec.(*ManagedClusterSpec)
+	if !ok || managedClusterSpec.APIServerAccessProfile == nil || managedClusterSpec.APIServerAccessProfile.AuthorizedIPRangesConfigMap == nil {
+		return nil
+	}
+	profile := managedClusterSpec.APIServerAccessProfile
+	ranges, err := authorizedIPRangesFromConfigMap(ctx, s.Scope.GetClient(), *profile.AuthorizedIPRangesConfigMap)
+	if err != nil {
+		return err
+	}
+	profile.AuthorizedIPRanges = mergeAuthorizedIPRanges(profile.AuthorizedIPRanges, ranges)
+	return nil
+}
+
+// authorizedIPRangesFromConfigMap returns the authorized IP ranges listed in the data of a ConfigMap. Each value of the
+// data is a list of CIDRs separated by commas or whitespace, and the values are read in the order of their keys.
+func authorizedIPRangesFromConfigMap(ctx context.Context, c client.Client, key types.NamespacedName) ([]string, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "managedclusters.authorizedIPRangesFromConfigMap")
+	defer done()
+
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, key, configMap); err != nil {
+		return nil, errors.Wrapf(err, "failed to get ConfigMap %s with the authorized IP ranges", key)
+	}
+
+	dataKeys := make([]string, 0, len(configMap.Data))
+	for dataKey := range configMap.Data {
+		dataKeys = append(dataKeys, dataKey)
+	}
+	sort.Strings(dataKeys)
+
+	var ranges, invalid []string
+	for _, dataKey := range dataKeys {
+		for _, ipRange := range strings.FieldsFunc(configMap.Data[dataKey], func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		}) {
+			if _, _, err := net.ParseCIDR(ipRange); err != nil {
+				invalid = append(invalid, fmt.Sprintf("%q (key %q)", ipRange, dataKey))
+				continue
+			}
+			ranges = append(ranges, ipRange)
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, errors.Errorf("invalid CIDRs in ConfigMap %s with the authorized IP ranges: %s", key, strings.Join(invalid, ", "))
+	}
+	return ranges, nil
+}
+
+// mergeAuthorizedIPRanges returns the authorized IP ranges of the spec followed by the additional ranges that are not
+// already listed.
+func mergeAuthorizedIPRanges(ranges []string, additional []string) []string {
+	merged := make([]string, 0, len(ranges)+len(additional))
+	seen := make(map[string]bool, len(ranges)+len(additional))
+	for _, list := range [][]string{ranges, additional} {
+		for _, ipRange := range list {
+			if seen[ipRange] {
+				continue
+			}
+			seen[ipRange] = true
+			merged = append(merged, ipRange)
+		}
+	}
+	return merged
+}
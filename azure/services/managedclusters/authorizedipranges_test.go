@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedclusters
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/managedclusters/mock_managedclusters"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResolveAuthorizedIPRanges(t *testing.T) {
+	configMapKey := types.NamespacedName{Namespace: "default", Name: "authorized-ip-ranges"}
+
+	testcases := []struct {
+		name          string
+		profile       *APIServerAccessProfile
+		configMap     *corev1.ConfigMap
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "no ConfigMap reference",
+			profile:  &APIServerAccessProfile{AuthorizedIPRanges: []string{"1.2.3.4/32"}},
+			expected: []string{"1.2.3.4/32"},
+		},
+		{
+			name: "ranges from the ConfigMap",
+			profile: &APIServerAccessProfile{
+				AuthorizedIPRangesConfigMap: &configMapKey,
+			},
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "authorized-ip-ranges"},
+				Data: map[string]string{
+					"vpn":    "10.1.0.0/16\n10.2.0.0/16\n",
+					"office": "20.30.40.0/24, 20.30.41.0/24",
+				},
+			},
+			expected: []string{"20.30.40.0/24", "20.30.41.0/24", "10.1.0.0/16", "10.2.0.0/16"},
+		},
+		{
+			name: "ranges from the ConfigMap are added to the ranges of the spec",
+			profile: &APIServerAccessProfile{
+				AuthorizedIPRanges:          []string{"1.2.3.4/32", "10.1.0.0/16"},
+				AuthorizedIPRangesConfigMap: &configMapKey,
+			},
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "authorized-ip-ranges"},
+				Data: map[string]string{
+					"vpn": "10.1.0.0/16 10.2.0.0/16",
+				},
+			},
+			expected: []string{"1.2.3.4/32", "10.1.0.0/16", "10.2.0.0/16"},
+		},
+		{
+			name: "empty ConfigMap",
+			profile: &APIServerAccessProfile{
+				AuthorizedIPRanges:          []string{"1.2.3.4/32"},
+				AuthorizedIPRangesConfigMap: &configMapKey,
+			},
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "authorized-ip-ranges"},
+			},
+			expected: []string{"1.2.3.4/32"},
+		},
+		{
+			name: "ConfigMap not found",
+			profile: &APIServerAccessProfile{
+				AuthorizedIPRangesConfigMap: &configMapKey,
+			},
+			expectedError: "failed to get ConfigMap default/authorized-ip-ranges with the authorized IP ranges: configmaps \"authorized-ip-ranges\" not found",
+		},
+		{
+			name: "invalid CIDRs in the ConfigMap",
+			profile: &APIServerAccessProfile{
+				AuthorizedIPRanges:          []string{"1.2.3.4/32"},
+				AuthorizedIPRangesConfigMap: &configMapKey,
+			},
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "authorized-ip-ranges"},
+				Data: map[string]string{
+					"office": "20.30.40.0/24,1.2.3.400/32",
+					"vpn":    "10.1.0.0",
+				},
+			},
+			expected:      []string{"1.2.3.4/32"},
+			expectedError: "invalid CIDRs in ConfigMap default/authorized-ip-ranges with the authorized IP ranges: \"1.2.3.400/32\" (key \"office\"), \"10.1.0.0\" (key \"vpn\")",
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_managedclusters.NewMockManagedClusterScope(mockCtrl)
+
+			clientBuilder := fakeclient.NewClientBuilder()
+			if tc.configMap != nil {
+				clientBuilder.WithObjects(tc.configMap)
+			}
+			scopeMock.EXPECT().GetClient().Return(clientBuilder.Build()).AnyTimes()
+
+			s := &Service{Scope: scopeMock}
+			spec := &ManagedClusterSpec{Name: "my-managedcluster", ResourceGroup: "my-rg", APIServerAccessProfile: tc.profile}
+			err := s.resolveAuthorizedIPRanges(context.TODO(), spec)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(spec.APIServerAccessProfile.AuthorizedIPRanges).To(Equal(tc.expected))
+		})
+	}
+}
@@ -18,9 +18,11 @@ package managedclusters
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2022-03-01/containerservice"
 	"github.com/pkg/errors"
+	"golang.org/x/mod/semver"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
@@ -29,22 +31,34 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const serviceName = "managedcluster"
 
 const kubeletIdentityKey = "kubeletidentity"
 
+// virtualNodesSubnetNameConfigKey is the virtual nodes add-on config key for the dedicated subnet name.
+const virtualNodesSubnetNameConfigKey = "SubnetName"
+
+// keyVaultSecretRotationConfigKey is the Key Vault secrets provider add-on config key enabling the rotation of secrets.
+const keyVaultSecretRotationConfigKey = "enableSecretRotation"
+
 // ManagedClusterScope defines the scope interface for a managed cluster.
 type ManagedClusterScope interface {
 	azure.Authorizer
 	azure.AsyncStatusUpdater
+	GetClient() client.Client
 	ManagedClusterSpec() azure.ResourceSpecGetter
 	SetControlPlaneEndpoint(clusterv1.APIEndpoint)
 	SetKubeletIdentity(string)
+	SetKeyVaultSecretsProviderIdentity(string)
 	MakeEmptyKubeConfigSecret() corev1.Secret
 	GetKubeConfigData() []byte
 	SetKubeConfigData([]byte)
+	ControlPlaneVersion() string
+	SetControlPlaneVersion(string)
+	SetConditionFalse(conditionType clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, message string)
 }
 
 // Service provides operations on azure resources.
@@ -82,6 +96,11 @@ func (s *Service) Reconcile(ctx context.Context) error {
 		return nil
 	}
 
+	if err := s.resolveAuthorizedIPRanges(ctx, managedClusterSpec); err != nil {
+		s.Scope.UpdatePutStatus(infrav1.ManagedClusterRunningCondition, serviceName, err)
+		return err
+	}
+
 	result, resultErr := s.CreateOrUpdateResource(ctx, managedClusterSpec, serviceName)
 	if resultErr == nil {
 		managedCluster, ok := result.(containerservice.ManagedCluster)
@@ -108,11 +127,42 @@ func (s *Service) Reconcile(ctx context.Context) error {
 		if id := managedCluster.ManagedClusterProperties.IdentityProfile[kubeletIdentityKey]; id != nil && id.ResourceID != nil {
 			s.Scope.SetKubeletIdentity(*id.ResourceID)
 		}
+
+		// AKS creates the identity of the Key Vault secrets provider add-on, which Key Vault roles are assigned to
+		// unless another identity is set.
+		if profile := managedCluster.ManagedClusterProperties.AddonProfiles[infrav1.KeyVaultSecretsProviderAddonName]; profile != nil && profile.Identity != nil && profile.Identity.ResourceID != nil {
+			s.Scope.SetKeyVaultSecretsProviderIdentity(*profile.Identity.ResourceID)
+		}
+
+		// Record the version the control plane is running once AKS is done with it so agent pools are only upgraded
+		// after the control plane.
+		if ptr.Deref(managedCluster.ManagedClusterProperties.ProvisioningState, "") == string(infrav1.Succeeded) && managedCluster.ManagedClusterProperties.KubernetesVersion != nil {
+			s.Scope.SetControlPlaneVersion("v" + *managedCluster.ManagedClusterProperties.KubernetesVersion)
+		}
 	}
 	s.Scope.UpdatePutStatus(infrav1.ManagedClusterRunningCondition, serviceName, resultErr)
+	if azure.IsOperationNotDoneError(resultErr) {
+		s.reportControlPlaneUpgrade(managedClusterSpec)
+	}
 	return resultErr
 }
 
+// reportControlPlaneUpgrade reports an in-progress operation on the managed cluster as a control plane upgrade when the
+// desired Kubernetes version is newer than the last version reported by AKS.
+func (s *Service) reportControlPlaneUpgrade(spec azure.ResourceSpecGetter) {
+	managedClusterSpec, ok := spec.(*ManagedClusterSpec)
+	if !ok {
+		return
+	}
+	currentVersion := s.Scope.ControlPlaneVersion()
+	desiredVersion := "v" + managedClusterSpec.Version
+	if currentVersion == "" || semver.Compare(currentVersion, desiredVersion) >= 0 {
+		return
+	}
+	s.Scope.SetConditionFalse(infrav1.ManagedClusterRunningCondition, infrav1.ControlPlaneUpgradingReason, clusterv1.ConditionSeverityInfo,
+		fmt.Sprintf("upgrading control plane from %s to %s", currentVersion, desiredVersion))
+}
+
 // Delete deletes the managed cluster.
 func (s *Service) Delete(ctx context.Context) error {
 	ctx, _, done := tele.StartSpanWithLogger(ctx, "managedclusters.Service.Delete")
@@ -19,20 +19,29 @@ package managedclusters
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2022-03-01/containerservice"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async/mock_async"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/managedclusters/mock_managedclusters"
 	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
-var fakeManagedClusterSpec = &ManagedClusterSpec{Name: "my-managedcluster", ResourceGroup: "my-rg"}
+var (
+	fakeManagedClusterSpec = &ManagedClusterSpec{Name: "my-managedcluster", ResourceGroup: "my-rg"}
+	notDoneError           = azure.NewOperationNotDoneError(&infrav1.Future{Type: "PUT", ResourceGroup: "my-rg", Name: "my-managedcluster"})
+)
 
 func TestReconcile(t *testing.T) {
 	testcases := []struct {
@@ -56,6 +65,52 @@ func TestReconcile(t *testing.T) {
 				s.UpdatePutStatus(infrav1.ManagedClusterRunningCondition, serviceName, errors.New("some unexpected error occurred"))
 			},
 		},
+		{
+			name:          "ConfigMap with the authorized IP ranges not found",
+			expectedError: "failed to get ConfigMap default/authorized-ip-ranges with the authorized IP ranges: configmaps \"authorized-ip-ranges\" not found",
+			expect: func(m *mock_managedclusters.MockCredentialGetterMockRecorder, s *mock_managedclusters.MockManagedClusterScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				spec := &ManagedClusterSpec{
+					Name:          "my-managedcluster",
+					ResourceGroup: "my-rg",
+					APIServerAccessProfile: &APIServerAccessProfile{
+						AuthorizedIPRangesConfigMap: &types.NamespacedName{Namespace: "default", Name: "authorized-ip-ranges"},
+					},
+				}
+				s.ManagedClusterSpec().Return(spec)
+				s.GetClient().Return(fakeclient.NewClientBuilder().Build())
+				s.UpdatePutStatus(infrav1.ManagedClusterRunningCondition, serviceName, gomockinternal.ErrStrEq("failed to get ConfigMap default/authorized-ip-ranges with the authorized IP ranges: configmaps \"authorized-ip-ranges\" not found"))
+			},
+		},
+		{
+			name:          "create managed cluster with the authorized IP ranges of a ConfigMap",
+			expectedError: notDoneError.Error(),
+			expect: func(m *mock_managedclusters.MockCredentialGetterMockRecorder, s *mock_managedclusters.MockManagedClusterScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				spec := &ManagedClusterSpec{
+					Name:          "my-managedcluster",
+					ResourceGroup: "my-rg",
+					APIServerAccessProfile: &APIServerAccessProfile{
+						AuthorizedIPRanges:          []string{"1.2.3.4/32"},
+						AuthorizedIPRangesConfigMap: &types.NamespacedName{Namespace: "default", Name: "authorized-ip-ranges"},
+					},
+				}
+				s.ManagedClusterSpec().Return(spec)
+				s.GetClient().Return(fakeclient.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "authorized-ip-ranges"},
+					Data:       map[string]string{"office": "20.30.40.0/24"},
+				}).Build())
+				r.CreateOrUpdateResource(gomockinternal.AContext(), gomockinternal.CustomMatcher(
+					func(x interface{}, _ map[string]interface{}) bool {
+						resolved, ok := x.(*ManagedClusterSpec)
+						return ok && reflect.DeepEqual(resolved.APIServerAccessProfile.AuthorizedIPRanges, []string{"1.2.3.4/32", "20.30.40.0/24"})
+					},
+					func(_ map[string]interface{}) string {
+						return "a managed cluster spec with the authorized IP ranges [1.2.3.4/32 20.30.40.0/24]"
+					},
+				), serviceName).Return(nil, notDoneError)
+				s.UpdatePutStatus(infrav1.ManagedClusterRunningCondition, serviceName, notDoneError)
+				s.ControlPlaneVersion().Return("")
+			},
+		},
 		{
 			name:          "create managed cluster succeeds",
 			expectedError: "",
@@ -65,11 +120,18 @@ func TestReconcile(t *testing.T) {
 					ManagedClusterProperties: &containerservice.ManagedClusterProperties{
 						Fqdn:              ptr.To("my-managedcluster-fqdn"),
 						ProvisioningState: ptr.To("Succeeded"),
+						KubernetesVersion: ptr.To("1.26.3"),
 						IdentityProfile: map[string]*containerservice.UserAssignedIdentity{
 							kubeletIdentityKey: {
 								ResourceID: ptr.To("kubelet-id"),
 							},
 						},
+						AddonProfiles: map[string]*containerservice.ManagedClusterAddonProfile{
+							infrav1.KeyVaultSecretsProviderAddonName: {
+								Enabled:  ptr.To(true),
+								Identity: &containerservice.ManagedClusterAddonProfileIdentity{ResourceID: ptr.To("key-vault-secrets-provider-id")},
+							},
+						},
 					},
 				}, nil)
 				s.SetControlPlaneEndpoint(clusterv1.APIEndpoint{
@@ -79,9 +141,45 @@ func TestReconcile(t *testing.T) {
 				m.GetCredentials(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return([]byte("credentials"), nil)
 				s.SetKubeConfigData([]byte("credentials"))
 				s.SetKubeletIdentity("kubelet-id")
+				s.SetKeyVaultSecretsProviderIdentity("key-vault-secrets-provider-id")
+				s.SetControlPlaneVersion("v1.26.3")
 				s.UpdatePutStatus(infrav1.ManagedClusterRunningCondition, serviceName, nil)
 			},
 		},
+		{
+			name:          "create managed cluster in progress",
+			expectedError: notDoneError.Error(),
+			expect: func(m *mock_managedclusters.MockCredentialGetterMockRecorder, s *mock_managedclusters.MockManagedClusterScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				spec := &ManagedClusterSpec{Name: "my-managedcluster", ResourceGroup: "my-rg", Version: "1.26.3"}
+				s.ManagedClusterSpec().Return(spec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), spec, serviceName).Return(nil, notDoneError)
+				s.UpdatePutStatus(infrav1.ManagedClusterRunningCondition, serviceName, notDoneError)
+				s.ControlPlaneVersion().Return("")
+			},
+		},
+		{
+			name:          "control plane upgrade in progress",
+			expectedError: notDoneError.Error(),
+			expect: func(m *mock_managedclusters.MockCredentialGetterMockRecorder, s *mock_managedclusters.MockManagedClusterScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				spec := &ManagedClusterSpec{Name: "my-managedcluster", ResourceGroup: "my-rg", Version: "1.26.3"}
+				s.ManagedClusterSpec().Return(spec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), spec, serviceName).Return(nil, notDoneError)
+				s.UpdatePutStatus(infrav1.ManagedClusterRunningCondition, serviceName, notDoneError)
+				s.ControlPlaneVersion().Return("v1.25.5")
+				s.SetConditionFalse(infrav1.ManagedClusterRunningCondition, infrav1.ControlPlaneUpgradingReason, clusterv1.ConditionSeverityInfo, "upgrading control plane from v1.25.5 to v1.26.3")
+			},
+		},
+		{
+			name:          "managed cluster update other than an upgrade in progress",
+			expectedError: notDoneError.Error(),
+			expect: func(m *mock_managedclusters.MockCredentialGetterMockRecorder, s *mock_managedclusters.MockManagedClusterScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				spec := &ManagedClusterSpec{Name: "my-managedcluster", ResourceGroup: "my-rg", Version: "1.26.3"}
+				s.ManagedClusterSpec().Return(spec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), spec, serviceName).Return(nil, notDoneError)
+				s.UpdatePutStatus(infrav1.ManagedClusterRunningCondition, serviceName, notDoneError)
+				s.ControlPlaneVersion().Return("v1.26.3")
+			},
+		},
 		{
 			name:          "fail to get managed cluster credentials",
 			expectedError: "failed to get credentials for managed cluster: internal server error",
@@ -29,6 +29,7 @@ import (
 	v1beta1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	azure "sigs.k8s.io/cluster-api-provider-azure/azure"
 	v1beta10 "sigs.k8s.io/cluster-api/api/v1beta1"
+	client "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // MockManagedClusterScope is a mock of ManagedClusterScope interface.
@@ -124,6 +125,20 @@ func (mr *MockManagedClusterScopeMockRecorder) CloudEnvironment() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudEnvironment", reflect.TypeOf((*MockManagedClusterScope)(nil).CloudEnvironment))
 }
 
+// ControlPlaneVersion mocks base method.
+func (m *MockManagedClusterScope) ControlPlaneVersion() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ControlPlaneVersion")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ControlPlaneVersion indicates an expected call of ControlPlaneVersion.
+func (mr *MockManagedClusterScopeMockRecorder) ControlPlaneVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ControlPlaneVersion", reflect.TypeOf((*MockManagedClusterScope)(nil).ControlPlaneVersion))
+}
+
 // DeleteLongRunningOperationState mocks base method.
 func (m *MockManagedClusterScope) DeleteLongRunningOperationState(arg0, arg1, arg2 string) {
 	m.ctrl.T.Helper()
@@ -136,6 +151,20 @@ func (mr *MockManagedClusterScopeMockRecorder) DeleteLongRunningOperationState(a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLongRunningOperationState", reflect.TypeOf((*MockManagedClusterScope)(nil).DeleteLongRunningOperationState), arg0, arg1, arg2)
 }
 
+// GetClient mocks base method.
+func (m *MockManagedClusterScope) GetClient() client.Client {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClient")
+	ret0, _ := ret[0].(client.Client)
+	return ret0
+}
+
+// GetClient indicates an expected call of GetClient.
+func (mr *MockManagedClusterScopeMockRecorder) GetClient() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClient", reflect.TypeOf((*MockManagedClusterScope)(nil).GetClient))
+}
+
 // GetKubeConfigData mocks base method.
 func (m *MockManagedClusterScope) GetKubeConfigData() []byte {
 	m.ctrl.T.Helper()
@@ -206,6 +235,18 @@ func (mr *MockManagedClusterScopeMockRecorder) ManagedClusterSpec() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ManagedClusterSpec", reflect.TypeOf((*MockManagedClusterScope)(nil).ManagedClusterSpec))
 }
 
+// SetConditionFalse mocks base method.
+func (m *MockManagedClusterScope) SetConditionFalse(conditionType v1beta10.ConditionType, reason string, severity v1beta10.ConditionSeverity, message string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetConditionFalse", conditionType, reason, severity, message)
+}
+
+// SetConditionFalse indicates an expected call of SetConditionFalse.
+func (mr *MockManagedClusterScopeMockRecorder) SetConditionFalse(conditionType, reason, severity, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConditionFalse", reflect.TypeOf((*MockManagedClusterScope)(nil).SetConditionFalse), conditionType, reason, severity, message)
+}
+
 // SetControlPlaneEndpoint mocks base method.
 func (m *MockManagedClusterScope) SetControlPlaneEndpoint(arg0 v1beta10.APIEndpoint) {
 	m.ctrl.T.Helper()
@@ -218,6 +259,30 @@ func (mr *MockManagedClusterScopeMockRecorder) SetControlPlaneEndpoint(arg0 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetControlPlaneEndpoint", reflect.TypeOf((*MockManagedClusterScope)(nil).SetControlPlaneEndpoint), arg0)
 }
 
+// SetControlPlaneVersion mocks base method.
+func (m *MockManagedClusterScope) SetControlPlaneVersion(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetControlPlaneVersion", arg0)
+}
+
+// SetControlPlaneVersion indicates an expected call of SetControlPlaneVersion.
+func (mr *MockManagedClusterScopeMockRecorder) SetControlPlaneVersion(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetControlPlaneVersion", reflect.TypeOf((*MockManagedClusterScope)(nil).SetControlPlaneVersion), arg0)
+}
+
+// SetKeyVaultSecretsProviderIdentity mocks base method.
+func (m *MockManagedClusterScope) SetKeyVaultSecretsProviderIdentity(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetKeyVaultSecretsProviderIdentity", arg0)
+}
+
+// SetKeyVaultSecretsProviderIdentity indicates an expected call of SetKeyVaultSecretsProviderIdentity.
+func (mr *MockManagedClusterScopeMockRecorder) SetKeyVaultSecretsProviderIdentity(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetKeyVaultSecretsProviderIdentity", reflect.TypeOf((*MockManagedClusterScope)(nil).SetKeyVaultSecretsProviderIdentity), arg0)
+}
+
 // SetKubeConfigData mocks base method.
 func (m *MockManagedClusterScope) SetKubeConfigData(arg0 []byte) {
 	m.ctrl.T.Helper()
@@ -22,15 +22,19 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2022-03-01/containerservice"
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
@@ -90,9 +94,18 @@ type ManagedClusterSpec struct {
 	// AddonProfiles are the profiles of managed cluster add-on.
 	AddonProfiles []AddonProfile
 
+	// VirtualNodes is the configuration of the virtual nodes (ACI connector) add-on.
+	VirtualNodes *VirtualNodes
+
+	// KeyVaultSecretsProvider is the configuration of the Azure Key Vault provider for the Secrets Store CSI driver add-on.
+	KeyVaultSecretsProvider *KeyVaultSecretsProvider
+
 	// AADProfile is Azure Active Directory configuration to integrate with AKS, for aad authentication.
 	AADProfile *AADProfile
 
+	// DisableLocalAccounts disables the static local account credentials of the cluster.
+	DisableLocalAccounts *bool
+
 	// SKU is the SKU of the AKS to be provisioned.
 	SKU *SKU
 
@@ -134,6 +147,24 @@ type AddonProfile struct {
 	Enabled bool
 }
 
+// VirtualNodes is the configuration of the virtual nodes (ACI connector) add-on.
+type VirtualNodes struct {
+	// Enabled defines whether virtual nodes are enabled.
+	Enabled bool
+
+	// SubnetName is the name of the dedicated subnet used by Azure Container Instances.
+	SubnetName string
+}
+
+// KeyVaultSecretsProvider is the configuration of the Azure Key Vault provider for the Secrets Store CSI driver add-on.
+type KeyVaultSecretsProvider struct {
+	// Enabled defines whether the Key Vault secrets provider is enabled.
+	Enabled bool
+
+	// EnableSecretRotation defines whether the mounted secrets are periodically synced with Key Vault.
+	EnableSecretRotation bool
+}
+
 // SKU is an AKS SKU.
 type SKU struct {
 	// Tier is the tier of a managed cluster SKU.
@@ -167,6 +198,9 @@ type LoadBalancerProfile struct {
 type APIServerAccessProfile struct {
 	// AuthorizedIPRanges are the authorized IP Ranges to kubernetes API server.
 	AuthorizedIPRanges []string
+	// AuthorizedIPRangesConfigMap is the ConfigMap whose data provides additional authorized IP ranges. It is resolved
+	// into AuthorizedIPRanges when the managed cluster is reconciled.
+	AuthorizedIPRangesConfigMap *types.NamespacedName
 	// EnablePrivateCluster defines hether to create the cluster as a private cluster or not.
 	EnablePrivateCluster *bool
 	// PrivateDNSZone is the private dns zone for private clusters.
@@ -286,12 +320,12 @@ func (s *ManagedClusterSpec) Parameters(ctx context.Context, existing interface{
 			Type: containerservice.ResourceIdentityTypeSystemAssigned,
 		},
 		Location: &s.Location,
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
 			Lifecycle:   infrav1.ResourceLifecycleOwned,
 			ClusterName: s.ClusterName,
 			Name:        ptr.To(s.Name),
 			Role:        ptr.To(infrav1.CommonRole),
-			Additional:  s.Tags,
+			ClusterTags: s.Tags,
 		})),
 		ManagedClusterProperties: &containerservice.ManagedClusterProperties{
 			NodeResourceGroup: &s.NodeResourceGroup,
@@ -355,6 +389,8 @@ func (s *ManagedClusterSpec) Parameters(ctx context.Context, existing interface{
 		}
 	}
 
+	managedCluster.DisableLocalAccounts = s.DisableLocalAccounts
+
 	for i := range s.AddonProfiles {
 		if managedCluster.AddonProfiles == nil {
 			managedCluster.AddonProfiles = map[string]*containerservice.ManagedClusterAddonProfile{}
@@ -369,6 +405,33 @@ func (s *ManagedClusterSpec) Parameters(ctx context.Context, existing interface{
 		managedCluster.AddonProfiles[item.Name] = addonProfile
 	}
 
+	if s.VirtualNodes != nil {
+		if managedCluster.AddonProfiles == nil {
+			managedCluster.AddonProfiles = map[string]*containerservice.ManagedClusterAddonProfile{}
+		}
+		addonProfile := &containerservice.ManagedClusterAddonProfile{
+			Enabled: ptr.To(s.VirtualNodes.Enabled),
+		}
+		if s.VirtualNodes.SubnetName != "" {
+			addonProfile.Config = map[string]*string{
+				virtualNodesSubnetNameConfigKey: ptr.To(s.VirtualNodes.SubnetName),
+			}
+		}
+		managedCluster.AddonProfiles[infrav1.VirtualNodesAddonName] = addonProfile
+	}
+
+	if s.KeyVaultSecretsProvider != nil {
+		if managedCluster.AddonProfiles == nil {
+			managedCluster.AddonProfiles = map[string]*containerservice.ManagedClusterAddonProfile{}
+		}
+		managedCluster.AddonProfiles[infrav1.KeyVaultSecretsProviderAddonName] = &containerservice.ManagedClusterAddonProfile{
+			Enabled: ptr.To(s.KeyVaultSecretsProvider.Enabled),
+			Config: map[string]*string{
+				keyVaultSecretRotationConfigKey: ptr.To(strconv.FormatBool(s.KeyVaultSecretsProvider.EnableSecretRotation)),
+			},
+		}
+	}
+
 	if s.SKU != nil {
 		tierName := containerservice.ManagedClusterSKUTier(s.SKU.Tier)
 		managedCluster.Sku = &containerservice.ManagedClusterSKU{
@@ -545,6 +608,12 @@ func computeDiffOfNormalizedClusters(managedCluster containerservice.ManagedClus
 		}
 	}
 
+	// Only compare the local accounts disablement when it is set, as AKS always returns it.
+	if managedCluster.DisableLocalAccounts != nil {
+		propertiesNormalized.DisableLocalAccounts = managedCluster.DisableLocalAccounts
+		existingMCPropertiesNormalized.DisableLocalAccounts = ptr.To(ptr.Deref(existingMC.DisableLocalAccounts, false))
+	}
+
 	if managedCluster.NetworkProfile != nil {
 		propertiesNormalized.NetworkProfile.LoadBalancerProfile = managedCluster.NetworkProfile.LoadBalancerProfile
 	}
@@ -625,6 +694,25 @@ func computeDiffOfNormalizedClusters(managedCluster containerservice.ManagedClus
 		}
 	}
 
+	// Only compare the virtual nodes and Key Vault secrets provider add-ons when they are managed by CAPZ, so other
+	// add-ons configured out of band do not trigger an update.
+	addonNormalizers := map[string]func(*containerservice.ManagedClusterAddonProfile) *containerservice.ManagedClusterAddonProfile{
+		infrav1.VirtualNodesAddonName:            normalizeVirtualNodesAddonProfile,
+		infrav1.KeyVaultSecretsProviderAddonName: normalizeKeyVaultSecretsProviderAddonProfile,
+	}
+	for name, normalize := range addonNormalizers {
+		profile, ok := managedCluster.AddonProfiles[name]
+		if !ok {
+			continue
+		}
+		if propertiesNormalized.AddonProfiles == nil {
+			propertiesNormalized.AddonProfiles = map[string]*containerservice.ManagedClusterAddonProfile{}
+			existingMCPropertiesNormalized.AddonProfiles = map[string]*containerservice.ManagedClusterAddonProfile{}
+		}
+		propertiesNormalized.AddonProfiles[name] = normalize(profile)
+		existingMCPropertiesNormalized.AddonProfiles[name] = normalize(existingMC.AddonProfiles[name])
+	}
+
 	// Once the AKS autoscaler has been updated it will always return values so we need to
 	// respect those values even though the settings are now not being explicitly set by CAPZ.
 	if existingMC.AutoScalerProfile != nil && managedCluster.AutoScalerProfile == nil {
@@ -691,3 +779,40 @@ func isAuthIPRangesNilOrEmpty(managedCluster containerservice.ManagedCluster) bo
 	}
 	return false
 }
+
+// normalizeVirtualNodesAddonProfile returns the fields of a virtual nodes add-on profile that CAPZ manages.
+func normalizeVirtualNodesAddonProfile(profile *containerservice.ManagedClusterAddonProfile) *containerservice.ManagedClusterAddonProfile {
+	normalized := &containerservice.ManagedClusterAddonProfile{
+		Enabled: ptr.To(false),
+	}
+	if profile == nil {
+		return normalized
+	}
+	normalized.Enabled = ptr.To(ptr.Deref(profile.Enabled, false))
+	// The subnet is only relevant while the add-on is enabled.
+	if subnetName, ok := profile.Config[virtualNodesSubnetNameConfigKey]; ok && *normalized.Enabled && ptr.Deref(subnetName, "") != "" {
+		normalized.Config = map[string]*string{
+			virtualNodesSubnetNameConfigKey: subnetName,
+		}
+	}
+	return normalized
+}
+
+// normalizeKeyVaultSecretsProviderAddonProfile returns the fields of a Key Vault secrets provider add-on profile that
+// CAPZ manages.
+func normalizeKeyVaultSecretsProviderAddonProfile(profile *containerservice.ManagedClusterAddonProfile) *containerservice.ManagedClusterAddonProfile {
+	normalized := &containerservice.ManagedClusterAddonProfile{
+		Enabled: ptr.To(false),
+	}
+	if profile == nil {
+		return normalized
+	}
+	normalized.Enabled = ptr.To(ptr.Deref(profile.Enabled, false))
+	// The secret rotation is only relevant while the add-on is enabled, and AKS reports it disabled when unset.
+	if *normalized.Enabled {
+		normalized.Config = map[string]*string{
+			keyVaultSecretRotationConfigKey: ptr.To(strconv.FormatBool(strings.EqualFold(ptr.Deref(profile.Config[keyVaultSecretRotationConfigKey], ""), "true"))),
+		}
+	}
+	return normalized
+}
@@ -310,6 +310,303 @@ func TestParameters(t *testing.T) {
 				g.Expect(result).To(BeNil())
 			},
 		},
+		{
+			name:     "enable virtual nodes on an existing managed cluster",
+			existing: getExistingCluster(),
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:         "v1.22.0",
+				LoadBalancerSKU: "Standard",
+				VirtualNodes: &VirtualNodes{
+					Enabled:    true,
+					SubnetName: "aci-subnet",
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(containerservice.ManagedCluster{}))
+				g.Expect(result.(containerservice.ManagedCluster).AddonProfiles).To(HaveKeyWithValue(infrav1.VirtualNodesAddonName, &containerservice.ManagedClusterAddonProfile{
+					Enabled: ptr.To(true),
+					Config: map[string]*string{
+						"SubnetName": ptr.To("aci-subnet"),
+					},
+				}))
+			},
+		},
+		{
+			name:     "no update needed when virtual nodes are already enabled",
+			existing: getExistingClusterWithVirtualNodes(true),
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:         "v1.22.0",
+				LoadBalancerSKU: "Standard",
+				VirtualNodes: &VirtualNodes{
+					Enabled:    true,
+					SubnetName: "aci-subnet",
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name:     "disable virtual nodes on an existing managed cluster",
+			existing: getExistingClusterWithVirtualNodes(true),
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:         "v1.22.0",
+				LoadBalancerSKU: "Standard",
+				VirtualNodes: &VirtualNodes{
+					Enabled:    false,
+					SubnetName: "aci-subnet",
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(containerservice.ManagedCluster{}))
+				g.Expect(result.(containerservice.ManagedCluster).AddonProfiles[infrav1.VirtualNodesAddonName].Enabled).To(Equal(ptr.To(false)))
+			},
+		},
+		{
+			name:     "no update needed when virtual nodes are already disabled",
+			existing: getExistingClusterWithVirtualNodes(false),
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:         "v1.22.0",
+				LoadBalancerSKU: "Standard",
+				VirtualNodes: &VirtualNodes{
+					Enabled:    false,
+					SubnetName: "aci-subnet",
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name:     "enable the Key Vault secrets provider on an existing managed cluster",
+			existing: getExistingCluster(),
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:         "v1.22.0",
+				LoadBalancerSKU: "Standard",
+				KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+					Enabled:              true,
+					EnableSecretRotation: true,
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(containerservice.ManagedCluster{}))
+				g.Expect(result.(containerservice.ManagedCluster).AddonProfiles).To(HaveKeyWithValue(infrav1.KeyVaultSecretsProviderAddonName, &containerservice.ManagedClusterAddonProfile{
+					Enabled: ptr.To(true),
+					Config: map[string]*string{
+						"enableSecretRotation": ptr.To("true"),
+					},
+				}))
+			},
+		},
+		{
+			name:     "no update needed when the Key Vault secrets provider is already enabled",
+			existing: getExistingClusterWithKeyVaultSecretsProvider(true, "false"),
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:         "v1.22.0",
+				LoadBalancerSKU: "Standard",
+				KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+					Enabled: true,
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name:     "enable the secret rotation of the Key Vault secrets provider",
+			existing: getExistingClusterWithKeyVaultSecretsProvider(true, "false"),
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:         "v1.22.0",
+				LoadBalancerSKU: "Standard",
+				KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+					Enabled:              true,
+					EnableSecretRotation: true,
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(containerservice.ManagedCluster{}))
+				g.Expect(result.(containerservice.ManagedCluster).AddonProfiles[infrav1.KeyVaultSecretsProviderAddonName].Config).To(HaveKeyWithValue("enableSecretRotation", ptr.To("true")))
+			},
+		},
+		{
+			name:     "disable the Key Vault secrets provider on an existing managed cluster",
+			existing: getExistingClusterWithKeyVaultSecretsProvider(true, "true"),
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:         "v1.22.0",
+				LoadBalancerSKU: "Standard",
+				KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+					Enabled: false,
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(containerservice.ManagedCluster{}))
+				g.Expect(result.(containerservice.ManagedCluster).AddonProfiles[infrav1.KeyVaultSecretsProviderAddonName].Enabled).To(Equal(ptr.To(false)))
+			},
+		},
+		{
+			name:     "no update needed when the Key Vault secrets provider is already disabled",
+			existing: getExistingClusterWithKeyVaultSecretsProvider(false, ""),
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:         "v1.22.0",
+				LoadBalancerSKU: "Standard",
+				KeyVaultSecretsProvider: &KeyVaultSecretsProvider{
+					Enabled: false,
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name:     "disable local accounts on a new managed cluster",
+			existing: nil,
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:              "v1.22.0",
+				LoadBalancerSKU:      "Standard",
+				DisableLocalAccounts: ptr.To(true),
+				GetAllAgentPools: func() ([]azure.ResourceSpecGetter, error) {
+					return []azure.ResourceSpecGetter{}, nil
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(containerservice.ManagedCluster{}))
+				g.Expect(result.(containerservice.ManagedCluster).DisableLocalAccounts).To(Equal(ptr.To(true)))
+			},
+		},
+		{
+			name:     "disable local accounts on an existing managed cluster",
+			existing: getExistingCluster(),
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:              "v1.22.0",
+				LoadBalancerSKU:      "Standard",
+				DisableLocalAccounts: ptr.To(true),
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(containerservice.ManagedCluster{}))
+				g.Expect(result.(containerservice.ManagedCluster).DisableLocalAccounts).To(Equal(ptr.To(true)))
+			},
+		},
+		{
+			name:     "no update needed when local accounts are already disabled",
+			existing: getExistingClusterWithDisableLocalAccounts(true),
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:              "v1.22.0",
+				LoadBalancerSKU:      "Standard",
+				DisableLocalAccounts: ptr.To(true),
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name:     "re-enable local accounts on an existing managed cluster",
+			existing: getExistingClusterWithDisableLocalAccounts(true),
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:              "v1.22.0",
+				LoadBalancerSKU:      "Standard",
+				DisableLocalAccounts: ptr.To(false),
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(containerservice.ManagedCluster{}))
+				g.Expect(result.(containerservice.ManagedCluster).DisableLocalAccounts).To(Equal(ptr.To(false)))
+			},
+		},
+		{
+			name:     "no update needed when local accounts disablement is not set",
+			existing: getExistingClusterWithDisableLocalAccounts(true),
+			spec: &ManagedClusterSpec{
+				Name:          "test-managedcluster",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				Tags: map[string]string{
+					"test-tag": "test-value",
+				},
+				Version:              "v1.22.0",
+				LoadBalancerSKU:      "Standard",
+				DisableLocalAccounts: nil,
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
 	}
 	for _, tc := range testcases {
 		tc := tc
@@ -387,6 +684,43 @@ func getExistingClusterWithAPIServerAccessProfile() containerservice.ManagedClus
 	return mc
 }
 
+func getExistingClusterWithVirtualNodes(enabled bool) containerservice.ManagedCluster {
+	mc := getExistingCluster()
+	mc.AddonProfiles = map[string]*containerservice.ManagedClusterAddonProfile{
+		infrav1.VirtualNodesAddonName: {
+			Enabled: ptr.To(enabled),
+		},
+	}
+	if enabled {
+		mc.AddonProfiles[infrav1.VirtualNodesAddonName].Config = map[string]*string{
+			"SubnetName": ptr.To("aci-subnet"),
+		}
+	}
+	return mc
+}
+
+func getExistingClusterWithKeyVaultSecretsProvider(enabled bool, secretRotation string) containerservice.ManagedCluster {
+	mc := getExistingCluster()
+	mc.AddonProfiles = map[string]*containerservice.ManagedClusterAddonProfile{
+		infrav1.KeyVaultSecretsProviderAddonName: {
+			Enabled: ptr.To(enabled),
+		},
+	}
+	if secretRotation != "" {
+		mc.AddonProfiles[infrav1.KeyVaultSecretsProviderAddonName].Config = map[string]*string{
+			"enableSecretRotation": ptr.To(secretRotation),
+			"rotationPollInterval": ptr.To("2m"),
+		}
+	}
+	return mc
+}
+
+func getExistingClusterWithDisableLocalAccounts(disabled bool) containerservice.ManagedCluster {
+	mc := getExistingCluster()
+	mc.DisableLocalAccounts = ptr.To(disabled)
+	return mc
+}
+
 func getExistingCluster() containerservice.ManagedCluster {
 	mc := getSampleManagedCluster()
 	mc.ProvisioningState = ptr.To("Succeeded")
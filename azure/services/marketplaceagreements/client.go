@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marketplaceagreements
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/marketplaceordering/mgmt/2015-06-01/marketplaceordering"
+	"github.com/Azure/go-autorest/autorest"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// Client wraps go-sdk.
+type Client interface {
+	Get(ctx context.Context, publisher, offer, plan string) (marketplaceordering.AgreementTerms, error)
+	Sign(ctx context.Context, publisher, offer, plan string) (marketplaceordering.AgreementTerms, error)
+}
+
+// AzureClient contains the Azure go-sdk Client.
+type AzureClient struct {
+	agreements marketplaceordering.MarketplaceAgreementsClient
+}
+
+// NewClient creates a new marketplace agreements client from auth info.
+func NewClient(auth azure.Authorizer) *AzureClient {
+	c := newMarketplaceAgreementsClient(auth.SubscriptionID(), auth.BaseURI(), auth.Authorizer())
+	return &AzureClient{c}
+}
+
+// newMarketplaceAgreementsClient creates a new marketplace agreements client from subscription ID, base URI, and authorizer.
+func newMarketplaceAgreementsClient(subscriptionID string, baseURI string, authorizer autorest.Authorizer) marketplaceordering.MarketplaceAgreementsClient {
+	agreementsClient := marketplaceordering.NewMarketplaceAgreementsClientWithBaseURI(baseURI, subscriptionID)
+	azure.SetAutoRestClientDefaults(&agreementsClient.Client, authorizer)
+	return agreementsClient
+}
+
+// Get returns the terms of the marketplace agreement of a virtual machine image plan in the subscription, which tell
+// whether they are accepted.
+func (ac *AzureClient) Get(ctx context.Context, publisher, offer, plan string) (marketplaceordering.AgreementTerms, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "marketplaceagreements.AzureClient.Get")
+	defer done()
+
+	return ac.agreements.Get(ctx, publisher, offer, plan)
+}
+
+// Sign accepts the terms of the marketplace agreement of a virtual machine image plan in the subscription.
+func (ac *AzureClient) Sign(ctx context.Context, publisher, offer, plan string) (marketplaceordering.AgreementTerms, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "marketplaceagreements.AzureClient.Sign")
+	defer done()
+
+	return ac.agreements.Sign(ctx, publisher, offer, plan)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marketplaceagreements
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// ServiceName is the name of this service.
+const ServiceName = "marketplaceagreements"
+
+// AgreementScope defines the scope interface for a marketplace agreements service.
+type AgreementScope interface {
+	azure.Authorizer
+	MarketplaceAgreementSpec() *azure.MarketplaceAgreementSpec
+	RecordEvent(eventType, reason, messageFmt string, args ...interface{})
+}
+
+// Service provides operations on Azure resources.
+type Service struct {
+	Scope  AgreementScope
+	client Client
+}
+
+// New creates a new marketplace agreements service.
+func New(scope AgreementScope) *Service {
+	return &Service{
+		Scope:  scope,
+		client: NewClient(scope),
+	}
+}
+
+// Name returns the service name.
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// Reconcile accepts the marketplace terms of the purchase plan of the image of a VM to be created when they are not
+// accepted yet, if the machine opted in. Accepting the terms has legal implications, so without the opt-in they are
+// only checked by the virtual machines service.
+func (s *Service) Reconcile(ctx context.Context) error {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "marketplaceagreements.Service.Reconcile")
+	defer done()
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureServiceReconcileTimeout)
+	defer cancel()
+
+	spec := s.Scope.MarketplaceAgreementSpec()
+	if spec == nil || !spec.AcceptTerms {
+		return nil
+	}
+
+	terms, err := s.client.Get(ctx, spec.Publisher, spec.Offer, spec.Plan)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the marketplace agreement of image plan %s", spec.PlanID())
+	}
+	if terms.AgreementProperties != nil && ptr.Deref(terms.Accepted, false) {
+		return nil
+	}
+
+	if dryRunner, ok := s.Scope.(azure.DryRunner); ok && dryRunner.IsDryRun() {
+		// Accepting the terms binds the whole subscription, so it is only reported.
+		log.Info("dry run: marketplace terms of the image plan would be accepted", "plan", spec.PlanID())
+		dryRunner.RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason,
+			"Dry run: marketplace terms of image plan %s would be accepted in subscription %s", spec.PlanID(), s.Scope.SubscriptionID())
+		return nil
+	}
+
+	log.V(2).Info("accepting the marketplace terms of the image plan", "plan", spec.PlanID())
+	if _, err := s.client.Sign(ctx, spec.Publisher, spec.Offer, spec.Plan); err != nil {
+		return errors.Wrapf(err, "failed to accept the marketplace terms of image plan %s", spec.PlanID())
+	}
+	s.Scope.RecordEvent(corev1.EventTypeNormal, infrav1.ImagePlanTermsAcceptedReason,
+		"Accepted the marketplace terms of image plan %s in subscription %s", spec.PlanID(), s.Scope.SubscriptionID())
+	return nil
+}
+
+// Delete is a no-op. The marketplace terms are accepted for the whole subscription, so they are left accepted for the
+// other VMs using the image plan.
+func (s *Service) Delete(_ context.Context) error {
+	return nil
+}
+
+// IsManaged always returns true as the marketplace terms are only accepted when the machine opted in.
+func (s *Service) IsManaged(_ context.Context) (bool, error) {
+	return true, nil
+}
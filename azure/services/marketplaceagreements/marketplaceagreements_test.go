@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marketplaceagreements
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/marketplaceordering/mgmt/2015-06-01/marketplaceordering"
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/marketplaceagreements/mock_marketplaceagreements"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+var (
+	agreementSpec         = azure.MarketplaceAgreementSpec{Publisher: "my-publisher", Offer: "my-offer", Plan: "my-plan"}
+	acceptedAgreementSpec = azure.MarketplaceAgreementSpec{Publisher: "my-publisher", Offer: "my-offer", Plan: "my-plan", AcceptTerms: true}
+
+	internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+)
+
+// fakeDryRunScope is an agreement scope reconciling in dry-run mode.
+type fakeDryRunScope struct {
+	*mock_marketplaceagreements.MockAgreementScope
+}
+
+func (fakeDryRunScope) IsDryRun() bool {
+	return true
+}
+
+func agreementTerms(accepted bool) marketplaceordering.AgreementTerms {
+	return marketplaceordering.AgreementTerms{AgreementProperties: &marketplaceordering.AgreementProperties{Accepted: ptr.To(accepted)}}
+}
+
+func TestReconcileMarketplaceAgreements(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_marketplaceagreements.MockAgreementScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder)
+	}{
+		{
+			name: "image without a plan",
+			expect: func(s *mock_marketplaceagreements.MockAgreementScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder) {
+				s.MarketplaceAgreementSpec().Return(nil)
+			},
+		},
+		{
+			name: "terms are not accepted without the opt-in",
+			expect: func(s *mock_marketplaceagreements.MockAgreementScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder) {
+				s.MarketplaceAgreementSpec().Return(&agreementSpec)
+			},
+		},
+		{
+			name: "terms already accepted",
+			expect: func(s *mock_marketplaceagreements.MockAgreementScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder) {
+				s.MarketplaceAgreementSpec().Return(&acceptedAgreementSpec)
+				m.Get(gomockinternal.AContext(), "my-publisher", "my-offer", "my-plan").Return(agreementTerms(true), nil)
+			},
+		},
+		{
+			name: "terms are accepted with the opt-in",
+			expect: func(s *mock_marketplaceagreements.MockAgreementScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder) {
+				s.MarketplaceAgreementSpec().Return(&acceptedAgreementSpec)
+				m.Get(gomockinternal.AContext(), "my-publisher", "my-offer", "my-plan").Return(agreementTerms(false), nil)
+				m.Sign(gomockinternal.AContext(), "my-publisher", "my-offer", "my-plan").Return(agreementTerms(true), nil)
+				s.SubscriptionID().Return("123")
+				s.RecordEvent(corev1.EventTypeNormal, infrav1.ImagePlanTermsAcceptedReason, gomock.Any(), "my-publisher/my-offer/my-plan", "123")
+			},
+		},
+		{
+			name:          "fail to get the agreement",
+			expectedError: "failed to get the marketplace agreement of image plan my-publisher/my-offer/my-plan: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_marketplaceagreements.MockAgreementScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder) {
+				s.MarketplaceAgreementSpec().Return(&acceptedAgreementSpec)
+				m.Get(gomockinternal.AContext(), "my-publisher", "my-offer", "my-plan").Return(marketplaceordering.AgreementTerms{}, internalError)
+			},
+		},
+		{
+			name:          "fail to accept the terms",
+			expectedError: "failed to accept the marketplace terms of image plan my-publisher/my-offer/my-plan: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_marketplaceagreements.MockAgreementScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder) {
+				s.MarketplaceAgreementSpec().Return(&acceptedAgreementSpec)
+				m.Get(gomockinternal.AContext(), "my-publisher", "my-offer", "my-plan").Return(agreementTerms(false), nil)
+				m.Sign(gomockinternal.AContext(), "my-publisher", "my-offer", "my-plan").Return(marketplaceordering.AgreementTerms{}, internalError)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_marketplaceagreements.NewMockAgreementScope(mockCtrl)
+			clientMock := mock_marketplaceagreements.NewMockClient(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), clientMock.EXPECT())
+
+			s := &Service{
+				Scope:  scopeMock,
+				client: clientMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestReconcileMarketplaceAgreementsDryRun(t *testing.T) {
+	g := NewWithT(t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scopeMock := mock_marketplaceagreements.NewMockAgreementScope(mockCtrl)
+	clientMock := mock_marketplaceagreements.NewMockClient(mockCtrl)
+
+	scopeMock.EXPECT().MarketplaceAgreementSpec().Return(&acceptedAgreementSpec)
+	clientMock.EXPECT().Get(gomockinternal.AContext(), "my-publisher", "my-offer", "my-plan").Return(agreementTerms(false), nil)
+	scopeMock.EXPECT().SubscriptionID().Return("123")
+	scopeMock.EXPECT().RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason, gomock.Any(), "my-publisher/my-offer/my-plan", "123")
+
+	s := &Service{
+		Scope:  fakeDryRunScope{scopeMock},
+		client: clientMock,
+	}
+	g.Expect(s.Reconcile(context.TODO())).To(Succeed())
+}
+
+func TestNewAgreementSpec(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(NewAgreementSpec(nil, true)).To(BeNil())
+	g.Expect(NewAgreementSpec(&infrav1.Image{ID: ptr.To("my-image")}, true)).To(BeNil())
+	g.Expect(NewAgreementSpec(&infrav1.Image{
+		Marketplace: &infrav1.AzureMarketplaceImage{
+			ImagePlan: infrav1.ImagePlan{Publisher: "my-publisher", Offer: "my-offer", SKU: "my-sku"},
+			Version:   "1.0.0",
+			Plan:      &infrav1.ImagePlan{Publisher: "my-publisher", Offer: "my-offer", SKU: "my-plan"},
+		},
+	}, true)).To(Equal(&acceptedAgreementSpec))
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marketplaceagreements
+
+import (
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+)
+
+// NewAgreementSpec returns the marketplace agreement spec of the purchase plan of an image, or nil if the image has
+// no plan.
+func NewAgreementSpec(image *infrav1.Image, acceptTerms bool) *azure.MarketplaceAgreementSpec {
+	if image == nil {
+		return nil
+	}
+	plan := converters.ImageToPlan(image)
+	if plan == nil {
+		return nil
+	}
+	return &azure.MarketplaceAgreementSpec{
+		Publisher:   ptr.Deref(plan.Publisher, ""),
+		Offer:       ptr.Deref(plan.Product, ""),
+		Plan:        ptr.Deref(plan.Name, ""),
+		AcceptTerms: acceptTerms,
+	}
+}
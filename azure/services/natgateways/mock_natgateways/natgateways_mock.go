@@ -21,12 +21,14 @@ limitations under the License.
 package mock_natgateways
 
 import (
+	context "context"
 	reflect "reflect"
 
 	autorest "github.com/Azure/go-autorest/autorest"
+	azure "github.com/Azure/go-autorest/autorest/azure"
 	gomock "go.uber.org/mock/gomock"
 	v1beta1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
-	azure "sigs.k8s.io/cluster-api-provider-azure/azure"
+	azure0 "sigs.k8s.io/cluster-api-provider-azure/azure"
 	v1beta10 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
@@ -137,6 +139,20 @@ func (mr *MockNatGatewayScopeMockRecorder) AvailabilitySetEnabled() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockNatGatewayScope)(nil).AvailabilitySetEnabled))
 }
 
+// AzureEnvironment mocks base method.
+func (m *MockNatGatewayScope) AzureEnvironment() azure.Environment {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureEnvironment")
+	ret0, _ := ret[0].(azure.Environment)
+	return ret0
+}
+
+// AzureEnvironment indicates an expected call of AzureEnvironment.
+func (mr *MockNatGatewayScopeMockRecorder) AzureEnvironment() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureEnvironment", reflect.TypeOf((*MockNatGatewayScope)(nil).AzureEnvironment))
+}
+
 // BaseURI mocks base method.
 func (m *MockNatGatewayScope) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -401,6 +417,35 @@ func (mr *MockNatGatewayScopeMockRecorder) IsVnetManaged() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsVnetManaged", reflect.TypeOf((*MockNatGatewayScope)(nil).IsVnetManaged))
 }
 
+// KeyVaultAuthorizer mocks base method.
+func (m *MockNatGatewayScope) KeyVaultAuthorizer(ctx context.Context) (autorest.Authorizer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyVaultAuthorizer", ctx)
+	ret0, _ := ret[0].(autorest.Authorizer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KeyVaultAuthorizer indicates an expected call of KeyVaultAuthorizer.
+func (mr *MockNatGatewayScopeMockRecorder) KeyVaultAuthorizer(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyVaultAuthorizer", reflect.TypeOf((*MockNatGatewayScope)(nil).KeyVaultAuthorizer), ctx)
+}
+
+// KeyVaultDNSSuffix mocks base method.
+func (m *MockNatGatewayScope) KeyVaultDNSSuffix() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyVaultDNSSuffix")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// KeyVaultDNSSuffix indicates an expected call of KeyVaultDNSSuffix.
+func (mr *MockNatGatewayScopeMockRecorder) KeyVaultDNSSuffix() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyVaultDNSSuffix", reflect.TypeOf((*MockNatGatewayScope)(nil).KeyVaultDNSSuffix))
+}
+
 // Location mocks base method.
 func (m *MockNatGatewayScope) Location() string {
 	m.ctrl.T.Helper()
@@ -416,10 +461,10 @@ func (mr *MockNatGatewayScopeMockRecorder) Location() *gomock.Call {
 }
 
 // NatGatewaySpecs mocks base method.
-func (m *MockNatGatewayScope) NatGatewaySpecs() []azure.ResourceSpecGetter {
+func (m *MockNatGatewayScope) NatGatewaySpecs() []azure0.ResourceSpecGetter {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "NatGatewaySpecs")
-	ret0, _ := ret[0].([]azure.ResourceSpecGetter)
+	ret0, _ := ret[0].([]azure0.ResourceSpecGetter)
 	return ret0
 }
 
@@ -443,6 +488,20 @@ func (mr *MockNatGatewayScopeMockRecorder) NodeSubnets() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeSubnets", reflect.TypeOf((*MockNatGatewayScope)(nil).NodeSubnets))
 }
 
+// OutboundAdditionalPoolNames mocks base method.
+func (m *MockNatGatewayScope) OutboundAdditionalPoolNames(arg0 string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OutboundAdditionalPoolNames", arg0)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// OutboundAdditionalPoolNames indicates an expected call of OutboundAdditionalPoolNames.
+func (mr *MockNatGatewayScopeMockRecorder) OutboundAdditionalPoolNames(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OutboundAdditionalPoolNames", reflect.TypeOf((*MockNatGatewayScope)(nil).OutboundAdditionalPoolNames), arg0)
+}
+
 // OutboundLBName mocks base method.
 func (m *MockNatGatewayScope) OutboundLBName(arg0 string) string {
 	m.ctrl.T.Helper()
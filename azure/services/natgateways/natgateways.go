@@ -21,6 +21,7 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
 	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async"
@@ -42,6 +43,7 @@ type NatGatewayScope interface {
 type Service struct {
 	Scope NatGatewayScope
 	async.Reconciler
+	async.Getter
 }
 
 // New creates a new service.
@@ -49,6 +51,7 @@ func New(scope NatGatewayScope) *Service {
 	client := newClient(scope)
 	return &Service{
 		Scope:      scope,
+		Getter:     client,
 		Reconciler: async.New(scope, client, client),
 	}
 }
@@ -107,7 +110,8 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	return resultingErr
 }
 
-// Delete deletes the NAT gateway with the provided name.
+// Delete deletes the NAT gateways with the provided names. A NAT gateway can be shared by several subnets, so it is
+// only deleted once no subnet references it anymore.
 func (s *Service) Delete(ctx context.Context) error {
 	ctx, log, done := tele.StartSpanWithLogger(ctx, "natgateways.Service.Delete")
 	defer done()
@@ -132,6 +136,13 @@ func (s *Service) Delete(ctx context.Context) error {
 	//  Order of precedence (highest -> lowest) is: error that is not an operationNotDoneError (ie. error creating) -> operationNotDoneError (ie. creating in progress) -> no error (ie. created)
 	var resultingErr error
 	for _, natGatewaySpec := range specs {
+		if subnets, err := s.associatedSubnets(ctx, natGatewaySpec); err != nil {
+			resultingErr = err
+			continue
+		} else if len(subnets) > 0 {
+			log.Info("Skipping NAT gateway deletion as it is still associated with subnets", "natGateway", natGatewaySpec.ResourceName(), "subnets", subnets)
+			continue
+		}
 		if err := s.DeleteResource(ctx, natGatewaySpec, serviceName); err != nil {
 			if !azure.IsOperationNotDoneError(err) || resultingErr == nil {
 				resultingErr = err
@@ -142,6 +153,28 @@ func (s *Service) Delete(ctx context.Context) error {
 	return resultingErr
 }
 
+// associatedSubnets returns the IDs of the subnets associated with a NAT gateway. It returns no subnets if the NAT
+// gateway doesn't exist.
+func (s *Service) associatedSubnets(ctx context.Context, spec azure.ResourceSpecGetter) ([]string, error) {
+	existing, err := s.Get(ctx, spec)
+	if azure.ResourceNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to get NAT gateway %s", spec.ResourceName())
+	}
+	natGateway, ok := existing.(network.NatGateway)
+	if !ok {
+		return nil, errors.Errorf("%T is not a network.NatGateway", existing)
+	}
+	var subnets []string
+	if natGateway.NatGatewayPropertiesFormat != nil && natGateway.Subnets != nil {
+		for _, subnet := range *natGateway.Subnets {
+			subnets = append(subnets, ptr.Deref(subnet.ID, ""))
+		}
+	}
+	return subnets, nil
+}
+
 // IsManaged returns true if the NAT gateways' lifecycles are managed.
 func (s *Service) IsManaged(ctx context.Context) (bool, error) {
 	_, _, done := tele.StartSpanWithLogger(ctx, "natgateways.Service.IsManaged")
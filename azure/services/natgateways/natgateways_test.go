@@ -48,6 +48,14 @@ var (
 		ClusterName:    "my-cluster",
 		NatGatewayIP:   infrav1.PublicIPSpec{Name: "pip-node-subnet"},
 	}
+	natGatewaySpec2 = NatGatewaySpec{
+		Name:           "my-node-natgateway-2",
+		ResourceGroup:  "my-rg",
+		SubscriptionID: "my-sub",
+		Location:       "westus",
+		ClusterName:    "my-cluster",
+		NatGatewayIP:   infrav1.PublicIPSpec{Name: "pip-node-subnet-2"},
+	}
 	natGateway1 = network.NatGateway{
 		ID: ptr.To("/subscriptions/my-sub/resourceGroups/my-rg/providers/Microsoft.Network/natGateways/my-node-natgateway-1"),
 	}
@@ -62,6 +70,7 @@ var (
 		"sigs.k8s.io_cluster-api-provider-azure_role":                 "common",
 	}
 	internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+	notFoundError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusNotFound}, "Not Found")
 )
 
 func TestReconcileNatGateways(t *testing.T) {
@@ -157,13 +166,13 @@ func TestDeleteNatGateway(t *testing.T) {
 		name          string
 		tags          infrav1.Tags
 		expectedError string
-		expect        func(s *mock_natgateways.MockNatGatewayScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+		expect        func(s *mock_natgateways.MockNatGatewayScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, g *mock_async.MockGetterMockRecorder)
 	}{
 		{
 			name:          "noop if no NAT gateways specs are found",
 			tags:          ownedVNetTags,
 			expectedError: "",
-			expect: func(s *mock_natgateways.MockNatGatewayScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_natgateways.MockNatGatewayScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, g *mock_async.MockGetterMockRecorder) {
 				s.IsVnetManaged().Return(true)
 				s.NatGatewaySpecs().Return([]azure.ResourceSpecGetter{})
 			},
@@ -172,7 +181,7 @@ func TestDeleteNatGateway(t *testing.T) {
 			name:          "NAT gateways in custom vnet mode",
 			tags:          customVNetTags,
 			expectedError: "",
-			expect: func(s *mock_natgateways.MockNatGatewayScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_natgateways.MockNatGatewayScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, g *mock_async.MockGetterMockRecorder) {
 				s.IsVnetManaged().Return(false)
 			},
 		},
@@ -180,20 +189,69 @@ func TestDeleteNatGateway(t *testing.T) {
 			name:          "NAT gateway deleted successfully",
 			tags:          ownedVNetTags,
 			expectedError: "",
-			expect: func(s *mock_natgateways.MockNatGatewayScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_natgateways.MockNatGatewayScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, g *mock_async.MockGetterMockRecorder) {
 				s.IsVnetManaged().Return(true)
 				s.NatGatewaySpecs().Return([]azure.ResourceSpecGetter{&natGatewaySpec1})
+				g.Get(gomockinternal.AContext(), &natGatewaySpec1).Return(natGateway1, nil)
 				r.DeleteResource(gomockinternal.AContext(), &natGatewaySpec1, serviceName).Return(nil)
 				s.UpdateDeleteStatus(infrav1.NATGatewaysReadyCondition, serviceName, nil)
 			},
 		},
+		{
+			name:          "NAT gateway already deleted",
+			tags:          ownedVNetTags,
+			expectedError: "",
+			expect: func(s *mock_natgateways.MockNatGatewayScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, g *mock_async.MockGetterMockRecorder) {
+				s.IsVnetManaged().Return(true)
+				s.NatGatewaySpecs().Return([]azure.ResourceSpecGetter{&natGatewaySpec1})
+				g.Get(gomockinternal.AContext(), &natGatewaySpec1).Return(nil, notFoundError)
+				r.DeleteResource(gomockinternal.AContext(), &natGatewaySpec1, serviceName).Return(nil)
+				s.UpdateDeleteStatus(infrav1.NATGatewaysReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "shared NAT gateway still associated with a subnet is not deleted",
+			tags:          ownedVNetTags,
+			expectedError: "",
+			expect: func(s *mock_natgateways.MockNatGatewayScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, g *mock_async.MockGetterMockRecorder) {
+				s.IsVnetManaged().Return(true)
+				s.NatGatewaySpecs().Return([]azure.ResourceSpecGetter{&natGatewaySpec1, &natGatewaySpec2})
+				g.Get(gomockinternal.AContext(), &natGatewaySpec1).Return(network.NatGateway{
+					ID: natGateway1.ID,
+					NatGatewayPropertiesFormat: &network.NatGatewayPropertiesFormat{
+						Subnets: &[]network.SubResource{
+							{ID: ptr.To("/subscriptions/my-sub/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/other-subnet")},
+						},
+					},
+				}, nil)
+				g.Get(gomockinternal.AContext(), &natGatewaySpec2).Return(network.NatGateway{
+					NatGatewayPropertiesFormat: &network.NatGatewayPropertiesFormat{
+						Subnets: &[]network.SubResource{},
+					},
+				}, nil)
+				r.DeleteResource(gomockinternal.AContext(), &natGatewaySpec2, serviceName).Return(nil)
+				s.UpdateDeleteStatus(infrav1.NATGatewaysReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "fails to get the NAT gateway",
+			tags:          ownedVNetTags,
+			expectedError: "failed to get NAT gateway my-node-natgateway-1: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_natgateways.MockNatGatewayScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, g *mock_async.MockGetterMockRecorder) {
+				s.IsVnetManaged().Return(true)
+				s.NatGatewaySpecs().Return([]azure.ResourceSpecGetter{&natGatewaySpec1})
+				g.Get(gomockinternal.AContext(), &natGatewaySpec1).Return(nil, internalError)
+				s.UpdateDeleteStatus(infrav1.NATGatewaysReadyCondition, serviceName, gomockinternal.ErrStrEq("failed to get NAT gateway my-node-natgateway-1: #: Internal Server Error: StatusCode=500"))
+			},
+		},
 		{
 			name:          "NAT gateway deletion fails",
 			tags:          ownedVNetTags,
 			expectedError: "#: Internal Server Error: StatusCode=500",
-			expect: func(s *mock_natgateways.MockNatGatewayScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_natgateways.MockNatGatewayScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, g *mock_async.MockGetterMockRecorder) {
 				s.IsVnetManaged().Return(true)
 				s.NatGatewaySpecs().Return([]azure.ResourceSpecGetter{&natGatewaySpec1})
+				g.Get(gomockinternal.AContext(), &natGatewaySpec1).Return(natGateway1, nil)
 				r.DeleteResource(gomockinternal.AContext(), &natGatewaySpec1, serviceName).Return(internalError)
 				s.UpdateDeleteStatus(infrav1.NATGatewaysReadyCondition, serviceName, internalError)
 			},
@@ -209,12 +267,14 @@ func TestDeleteNatGateway(t *testing.T) {
 			defer mockCtrl.Finish()
 			scopeMock := mock_natgateways.NewMockNatGatewayScope(mockCtrl)
 			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+			getterMock := mock_async.NewMockGetter(mockCtrl)
 
-			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT())
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT(), getterMock.EXPECT())
 
 			s := &Service{
 				Scope:      scopeMock,
 				Reconciler: asyncMock,
+				Getter:     getterMock,
 			}
 
 			err := s.Delete(context.TODO())
@@ -25,6 +25,7 @@ import (
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 	azureutil "sigs.k8s.io/cluster-api-provider-azure/util/azure"
 )
 
@@ -79,11 +80,11 @@ func (s *NatGatewaySpec) Parameters(ctx context.Context, existing interface{}) (
 				},
 			},
 		},
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
 			ClusterName: s.ClusterName,
 			Lifecycle:   infrav1.ResourceLifecycleOwned,
 			Name:        ptr.To(s.Name),
-			Additional:  s.AdditionalTags,
+			ClusterTags: s.AdditionalTags,
 		})),
 	}
 
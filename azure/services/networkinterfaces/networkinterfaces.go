@@ -34,6 +34,7 @@ type NICScope interface {
 	azure.ClusterDescriber
 	azure.AsyncStatusUpdater
 	NICSpecs() []azure.ResourceSpecGetter
+	UpdateAnnotationJSON(string, map[string]interface{}) error
 }
 
 // Service provides operations on Azure resources.
@@ -75,12 +76,35 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	// If multiple errors occur, we return the most pressing one.
 	//  Order of precedence (highest -> lowest) is: error that is not an operationNotDoneError (i.e. error creating) -> operationNotDoneError (i.e. creating in progress) -> no error (i.e. created)
 	var result error
+	tapsChanged := false
+	newAnnotation := make(map[string]interface{})
 	for _, nicSpec := range specs {
-		if _, err := s.CreateOrUpdateResource(ctx, nicSpec, serviceName); err != nil {
+		_, err := s.CreateOrUpdateResource(ctx, nicSpec, serviceName)
+		if err != nil {
 			if !azure.IsOperationNotDoneError(err) || result == nil {
 				result = err
 			}
 		}
+
+		spec, ok := nicSpec.(*NICSpec)
+		if !ok || (spec.VirtualNetworkTapID == "" && spec.LastAppliedVirtualNetworkTapID == "") {
+			continue
+		}
+		tapsChanged = true
+		// The tap is only applied when the network interface was created or updated outside of dry-run mode.
+		tapID := spec.LastAppliedVirtualNetworkTapID
+		if dryRunner, ok := s.Scope.(azure.DryRunner); err == nil && (!ok || !dryRunner.IsDryRun()) {
+			tapID = spec.VirtualNetworkTapID
+		}
+		if tapID != "" {
+			newAnnotation[spec.Name] = tapID
+		}
+	}
+
+	if tapsChanged {
+		if err := s.Scope.UpdateAnnotationJSON(azure.VirtualNetworkTapLastAppliedAnnotation, newAnnotation); err != nil {
+			return err
+		}
 	}
 
 	s.Scope.UpdatePutStatus(infrav1.NetworkInterfaceReadyCondition, serviceName, result)
@@ -73,6 +73,20 @@ var (
 	internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
 )
 
+var (
+	fakeTapNICSpec = NICSpec{
+		Name:                "nic-1",
+		ResourceGroup:       "my-rg",
+		VirtualNetworkTapID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/my-tap",
+	}
+	fakeLastAppliedTapNICSpec = NICSpec{
+		Name:                           "nic-2",
+		ResourceGroup:                  "my-rg",
+		VirtualNetworkTapID:            "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/my-tap",
+		LastAppliedVirtualNetworkTapID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/other-tap",
+	}
+)
+
 func TestReconcileNetworkInterface(t *testing.T) {
 	testcases := []struct {
 		name          string
@@ -124,6 +138,21 @@ func TestReconcileNetworkInterface(t *testing.T) {
 				s.UpdatePutStatus(infrav1.NetworkInterfaceReadyCondition, serviceName, internalError)
 			},
 		},
+		{
+			name:          "record the virtual network taps attached to the network interfaces",
+			expectedError: internalError.Error(),
+			expect: func(s *mock_networkinterfaces.MockNICScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.NICSpecs().Return([]azure.ResourceSpecGetter{&fakeTapNICSpec, &fakeLastAppliedTapNICSpec})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeTapNICSpec, serviceName).Return(nil, nil)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeLastAppliedTapNICSpec, serviceName).Return(nil, internalError)
+				// The tap of the network interface that failed to update is still the one last applied.
+				s.UpdateAnnotationJSON(azure.VirtualNetworkTapLastAppliedAnnotation, map[string]interface{}{
+					fakeTapNICSpec.Name:            fakeTapNICSpec.VirtualNetworkTapID,
+					fakeLastAppliedTapNICSpec.Name: fakeLastAppliedTapNICSpec.LastAppliedVirtualNetworkTapID,
+				})
+				s.UpdatePutStatus(infrav1.NetworkInterfaceReadyCondition, serviceName, internalError)
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -18,7 +18,10 @@ package networkinterfaces
 
 import (
 	"context"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
 	"github.com/pkg/errors"
@@ -27,6 +30,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 )
 
 // NICSpec defines the specification for a Network Interface.
@@ -55,6 +59,21 @@ type NICSpec struct {
 	AdditionalTags            infrav1.Tags
 	ClusterName               string
 	IPConfigs                 []IPConfig
+	// PodSubnetName is the name of the subnet the secondary IP configurations are allocated from, which a
+	// self-managed Azure CNI assigns to pods. Secondary IP configurations use the NIC subnet when empty.
+	PodSubnetName string
+	// SecondaryIPConfigs are the secondary IP configurations with a static private IP address in the NIC subnet, e.g.
+	// of a floating IP address. They are added after the IP configurations of IPConfigs.
+	SecondaryIPConfigs []infrav1.SecondaryIPConfig
+	// AdditionalPublicLBAddressPoolNames are the names of backend pools of the public load balancer the NIC is
+	// associated with in addition to PublicLBAddressPoolName.
+	AdditionalPublicLBAddressPoolNames []string
+	// VirtualNetworkTapID is the resource ID of the virtual network tap the traffic of the primary IP configuration is
+	// mirrored to. The tap of an existing network interface is updated in place.
+	VirtualNetworkTapID string
+	// LastAppliedVirtualNetworkTapID is the resource ID of the virtual network tap CAPZ last attached to the primary IP
+	// configuration. It is detached from an existing network interface when VirtualNetworkTapID changes.
+	LastAppliedVirtualNetworkTapID string
 }
 
 // IPConfig defines the specification for an IP address configuration.
@@ -81,11 +100,12 @@ func (s *NICSpec) OwnerResourceName() string {
 // Parameters returns the parameters for the network interface.
 func (s *NICSpec) Parameters(ctx context.Context, existing interface{}) (parameters interface{}, err error) {
 	if existing != nil {
-		if _, ok := existing.(network.Interface); !ok {
+		existingNIC, ok := existing.(network.Interface)
+		if !ok {
 			return nil, errors.Errorf("%T is not a network.Interface", existing)
 		}
-		// network interface already exists
-		return nil, nil
+		// network interface already exists, only its virtual network tap is reconciled
+		return s.updateVirtualNetworkTap(existingNIC), nil
 	}
 
 	primaryIPConfig := &network.InterfaceIPConfigurationPropertiesFormat{
@@ -97,6 +117,11 @@ func (s *NICSpec) Parameters(ctx context.Context, existing interface{}) (paramet
 	}
 	primaryIPConfig.Subnet = subnet
 
+	// On dual-stack NICs the primary IP configuration must be IPv4, the IPv6 one is added as secondary below.
+	if s.IPv6Enabled {
+		primaryIPConfig.PrivateIPAddressVersion = network.IPVersionIPv4
+	}
+
 	primaryIPConfig.PrivateIPAllocationMethod = network.IPAllocationMethodDynamic
 	if s.StaticIPAddress != "" {
 		primaryIPConfig.PrivateIPAllocationMethod = network.IPAllocationMethodStatic
@@ -111,6 +136,12 @@ func (s *NICSpec) Parameters(ctx context.Context, existing interface{}) (paramet
 					ID: ptr.To(azure.AddressPoolID(s.SubscriptionID, s.ResourceGroup, s.PublicLBName, s.PublicLBAddressPoolName)),
 				})
 		}
+		for _, poolName := range s.AdditionalPublicLBAddressPoolNames {
+			backendAddressPools = append(backendAddressPools,
+				network.BackendAddressPool{
+					ID: ptr.To(azure.AddressPoolID(s.SubscriptionID, s.ResourceGroup, s.PublicLBName, poolName)),
+				})
+		}
 		if s.PublicLBNATRuleName != "" {
 			primaryIPConfig.LoadBalancerInboundNatRules = &[]network.InboundNatRule{
 				{
@@ -127,20 +158,25 @@ func (s *NICSpec) Parameters(ctx context.Context, existing interface{}) (paramet
 	}
 	primaryIPConfig.LoadBalancerBackendAddressPools = &backendAddressPools
 
+	if s.VirtualNetworkTapID != "" {
+		primaryIPConfig.VirtualNetworkTaps = s.virtualNetworkTaps()
+	}
+
 	if s.PublicIPName != "" {
 		primaryIPConfig.PublicIPAddress = &network.PublicIPAddress{
 			ID: ptr.To(azure.PublicIPID(s.SubscriptionID, s.ResourceGroup, s.PublicIPName)),
 		}
 	}
 
-	if s.AcceleratedNetworking == nil {
-		// set accelerated networking to the capability of the VMSize
+	// An explicit AcceleratedNetworking is always honored, otherwise accelerated networking is enabled if the VMSize
+	// supports it.
+	accelNet := s.AcceleratedNetworking
+	if accelNet == nil {
 		if s.SKU == nil {
 			return nil, errors.New("unable to get required network interface SKU from machine cache")
 		}
 
-		accelNet := s.SKU.HasCapability(resourceskus.AcceleratedNetworking)
-		s.AcceleratedNetworking = &accelNet
+		accelNet = ptr.To(s.SKU.HasCapability(resourceskus.AcceleratedNetworking))
 	}
 
 	dnsSettings := network.InterfaceDNSSettings{}
@@ -155,11 +191,18 @@ func (s *NICSpec) Parameters(ctx context.Context, existing interface{}) (paramet
 		},
 	}
 
+	secondarySubnet := subnet
+	if s.PodSubnetName != "" {
+		secondarySubnet = &network.Subnet{
+			ID: ptr.To(azure.SubnetID(s.SubscriptionID, s.VNetResourceGroup, s.VNetName, s.PodSubnetName)),
+		}
+	}
+
 	// Build additional IPConfigs if more than 1 is specified
 	for i := 1; i < len(s.IPConfigs); i++ {
 		c := s.IPConfigs[i]
 		newIPConfigPropertiesFormat := &network.InterfaceIPConfigurationPropertiesFormat{}
-		newIPConfigPropertiesFormat.Subnet = subnet
+		newIPConfigPropertiesFormat.Subnet = secondarySubnet
 		config := network.InterfaceIPConfiguration{
 			Name:                                     ptr.To(s.Name + "-" + strconv.Itoa(i)),
 			InterfaceIPConfigurationPropertiesFormat: newIPConfigPropertiesFormat,
@@ -188,11 +231,22 @@ func (s *NICSpec) Parameters(ctx context.Context, existing interface{}) (paramet
 		config.InterfaceIPConfigurationPropertiesFormat.Primary = ptr.To(false)
 		ipConfigurations = append(ipConfigurations, config)
 	}
+	for _, c := range s.SecondaryIPConfigs {
+		ipConfigurations = append(ipConfigurations, network.InterfaceIPConfiguration{
+			Name: ptr.To(c.Name),
+			InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+				Primary:                   ptr.To(false),
+				Subnet:                    &network.Subnet{ID: subnet.ID},
+				PrivateIPAllocationMethod: network.IPAllocationMethodStatic,
+				PrivateIPAddress:          ptr.To(c.PrivateIP),
+			},
+		})
+	}
 	if s.IPv6Enabled {
 		ipv6Config := network.InterfaceIPConfiguration{
 			Name: ptr.To("ipConfigv6"),
 			InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
-				PrivateIPAddressVersion: "IPv6",
+				PrivateIPAddressVersion: network.IPVersionIPv6,
 				Primary:                 ptr.To(false),
 				Subnet:                  &network.Subnet{ID: subnet.ID},
 			},
@@ -205,16 +259,94 @@ func (s *NICSpec) Parameters(ctx context.Context, existing interface{}) (paramet
 		Location:         ptr.To(s.Location),
 		ExtendedLocation: converters.ExtendedLocationToNetworkSDK(s.ExtendedLocation),
 		InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
-			EnableAcceleratedNetworking: s.AcceleratedNetworking,
+			EnableAcceleratedNetworking: accelNet,
 			IPConfigurations:            &ipConfigurations,
 			DNSSettings:                 &dnsSettings,
 			EnableIPForwarding:          ptr.To(s.EnableIPForwarding),
 		},
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
-			ClusterName: s.ClusterName,
-			Lifecycle:   infrav1.ResourceLifecycleOwned,
-			Name:        ptr.To(s.Name),
-			Additional:  s.AdditionalTags,
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
+			ClusterName:  s.ClusterName,
+			Lifecycle:    infrav1.ResourceLifecycleOwned,
+			Name:         ptr.To(s.Name),
+			ResourceTags: s.AdditionalTags,
 		})),
 	}, nil
 }
+
+// virtualNetworkTaps returns the virtual network taps of the primary IP configuration.
+func (s *NICSpec) virtualNetworkTaps() *[]network.VirtualNetworkTap {
+	if s.VirtualNetworkTapID == "" {
+		return nil
+	}
+	return &[]network.VirtualNetworkTap{{ID: ptr.To(s.VirtualNetworkTapID)}}
+}
+
+// updateVirtualNetworkTap returns the existing network interface with the virtual network tap CAPZ last attached to
+// its primary IP configuration replaced by the tap of the spec, or nil if there is nothing to change. The taps attached
+// outside of CAPZ are kept, and the taps of a network interface whose tap was never set in the spec aren't changed.
+func (s *NICSpec) updateVirtualNetworkTap(existing network.Interface) interface{} {
+	if s.VirtualNetworkTapID == "" && s.LastAppliedVirtualNetworkTapID == "" {
+		return nil
+	}
+	if existing.InterfacePropertiesFormat == nil || existing.IPConfigurations == nil {
+		return nil
+	}
+	ipConfigs := make([]network.InterfaceIPConfiguration, len(*existing.IPConfigurations))
+	copy(ipConfigs, *existing.IPConfigurations)
+	for i, ipConfig := range ipConfigs {
+		if ipConfig.InterfaceIPConfigurationPropertiesFormat == nil || !ptr.Deref(ipConfig.Primary, false) {
+			continue
+		}
+		taps := s.managedVirtualNetworkTaps(ipConfig.VirtualNetworkTaps)
+		if virtualNetworkTapsEqual(ipConfig.VirtualNetworkTaps, taps) {
+			return nil
+		}
+		properties := *ipConfig.InterfaceIPConfigurationPropertiesFormat
+		properties.VirtualNetworkTaps = taps
+		ipConfigs[i].InterfaceIPConfigurationPropertiesFormat = &properties
+
+		nicProperties := *existing.InterfacePropertiesFormat
+		nicProperties.IPConfigurations = &ipConfigs
+		existing.InterfacePropertiesFormat = &nicProperties
+		return existing
+	}
+	return nil
+}
+
+// managedVirtualNetworkTaps returns the virtual network taps of an existing IP configuration with the tap CAPZ last
+// attached replaced by the tap of the spec.
+func (s *NICSpec) managedVirtualNetworkTaps(existing *[]network.VirtualNetworkTap) *[]network.VirtualNetworkTap {
+	taps := []network.VirtualNetworkTap{}
+	if existing != nil {
+		for _, tap := range *existing {
+			id := ptr.Deref(tap.ID, "")
+			if strings.EqualFold(id, s.LastAppliedVirtualNetworkTapID) || strings.EqualFold(id, s.VirtualNetworkTapID) {
+				continue
+			}
+			taps = append(taps, tap)
+		}
+	}
+	if s.VirtualNetworkTapID != "" {
+		taps = append(taps, network.VirtualNetworkTap{ID: ptr.To(s.VirtualNetworkTapID)})
+	}
+	return &taps
+}
+
+// virtualNetworkTapsEqual returns true if two lists of virtual network taps reference the same taps, comparing their
+// resource IDs case-insensitively.
+func virtualNetworkTapsEqual(a, b *[]network.VirtualNetworkTap) bool {
+	var aIDs, bIDs []string
+	if a != nil {
+		for _, tap := range *a {
+			aIDs = append(aIDs, strings.ToLower(ptr.Deref(tap.ID, "")))
+		}
+	}
+	if b != nil {
+		for _, tap := range *b {
+			bIDs = append(bIDs, strings.ToLower(ptr.Deref(tap.ID, "")))
+		}
+	}
+	sort.Strings(aIDs)
+	sort.Strings(bIDs)
+	return reflect.DeepEqual(aIDs, bIDs)
+}
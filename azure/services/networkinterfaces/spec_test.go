@@ -25,6 +25,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/format"
 	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
 )
 
@@ -97,6 +98,23 @@ var (
 		ClusterName:             "my-cluster",
 	}
 
+	fakeMultipleBackendPoolsNICSpec = NICSpec{
+		Name:                               "my-net-interface",
+		ResourceGroup:                      "my-rg",
+		Location:                           "fake-location",
+		SubscriptionID:                     "123",
+		MachineName:                        "azure-test1",
+		SubnetName:                         "my-subnet",
+		VNetName:                           "my-vnet",
+		VNetResourceGroup:                  "my-rg",
+		PublicLBName:                       "my-public-lb",
+		PublicLBAddressPoolName:            "cluster-name-outboundBackendPool",
+		AdditionalPublicLBAddressPoolNames: []string{"service-pool-1", "service-pool-2"},
+		AcceleratedNetworking:              nil,
+		SKU:                                &fakeSku,
+		ClusterName:                        "my-cluster",
+	}
+
 	fakeControlPlaneNICSpec = NICSpec{
 		Name:                      "my-net-interface",
 		ResourceGroup:             "my-rg",
@@ -142,9 +160,28 @@ var (
 		VNetResourceGroup:     "my-rg",
 		PublicLBName:          "my-public-lb",
 		AcceleratedNetworking: ptr.To(false),
+		SKU:                   &fakeSku,
 		ClusterName:           "my-cluster",
 	}
 
+	fakeUnsupportedAcceleratedNetworkingNICSpec = NICSpec{
+		Name:                  "my-net-interface",
+		ResourceGroup:         "my-rg",
+		Location:              "fake-location",
+		SubscriptionID:        "123",
+		MachineName:           "azure-test1",
+		SubnetName:            "my-subnet",
+		VNetName:              "my-vnet",
+		VNetResourceGroup:     "my-rg",
+		PublicLBName:          "my-public-lb",
+		AcceleratedNetworking: nil,
+		SKU: &resourceskus.SKU{
+			Name: ptr.To("Standard_A1_v2"),
+			Kind: ptr.To(string(resourceskus.VirtualMachines)),
+		},
+		ClusterName: "my-cluster",
+	}
+
 	fakeIpv6NICSpec = NICSpec{
 		Name:                  "my-net-interface",
 		ResourceGroup:         "my-rg",
@@ -232,6 +269,41 @@ var (
 		IPConfigs:             []IPConfig{{}, {}},
 		ClusterName:           "my-cluster",
 	}
+	fakePodSubnetNICSpec = NICSpec{
+		Name:                  "my-net-interface",
+		ResourceGroup:         "my-rg",
+		Location:              "fake-location",
+		SubscriptionID:        "123",
+		MachineName:           "azure-test1",
+		SubnetName:            "my-subnet",
+		VNetName:              "my-vnet",
+		IPv6Enabled:           false,
+		VNetResourceGroup:     "my-rg",
+		AcceleratedNetworking: nil,
+		SKU:                   &fakeSku,
+		EnableIPForwarding:    true,
+		IPConfigs:             []IPConfig{{}, {}},
+		PodSubnetName:         "my-pod-subnet",
+		ClusterName:           "my-cluster",
+	}
+	fakeFloatingIPNICSpec = NICSpec{
+		Name:                  "my-net-interface",
+		ResourceGroup:         "my-rg",
+		Location:              "fake-location",
+		SubscriptionID:        "123",
+		MachineName:           "azure-test1",
+		SubnetName:            "my-subnet",
+		VNetName:              "my-vnet",
+		IPv6Enabled:           false,
+		VNetResourceGroup:     "my-rg",
+		AcceleratedNetworking: nil,
+		SKU:                   &fakeSku,
+		EnableIPForwarding:    true,
+		IPConfigs:             []IPConfig{{}, {}},
+		PodSubnetName:         "my-pod-subnet",
+		SecondaryIPConfigs:    []infrav1.SecondaryIPConfig{{Name: "floating-vip", PrivateIP: "10.0.0.100"}},
+		ClusterName:           "my-cluster",
+	}
 	fakeTwoIPconfigWithPublicNICSpec = NICSpec{
 		Name:                  "my-net-interface",
 		ResourceGroup:         "my-rg",
@@ -249,6 +321,21 @@ var (
 		IPConfigs:             []IPConfig{{}, {}},
 		ClusterName:           "my-cluster",
 	}
+	fakeVirtualNetworkTapNICSpec = NICSpec{
+		Name:                  "my-net-interface",
+		ResourceGroup:         "my-rg",
+		Location:              "fake-location",
+		SubscriptionID:        "123",
+		MachineName:           "azure-test1",
+		SubnetName:            "my-subnet",
+		VNetName:              "my-vnet",
+		VNetResourceGroup:     "my-rg",
+		AcceleratedNetworking: nil,
+		SKU:                   &fakeSku,
+		IPConfigs:             []IPConfig{{}, {}},
+		VirtualNetworkTapID:   "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/my-tap",
+		ClusterName:           "my-cluster",
+	}
 )
 
 func TestParameters(t *testing.T) {
@@ -335,6 +422,43 @@ func TestParameters(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name:     "get parameters for network interface with multiple backend pools",
+			spec:     &fakeMultipleBackendPoolsNICSpec,
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.Interface{}))
+				g.Expect(result.(network.Interface)).To(Equal(network.Interface{
+					Tags: map[string]*string{
+						"Name": ptr.To("my-net-interface"),
+						"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+					},
+					Location: ptr.To("fake-location"),
+					InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+						EnableAcceleratedNetworking: ptr.To(true),
+						EnableIPForwarding:          ptr.To(false),
+						DNSSettings:                 &network.InterfaceDNSSettings{},
+						Primary:                     nil,
+						IPConfigurations: &[]network.InterfaceIPConfiguration{
+							{
+								Name: ptr.To("pipConfig"),
+								InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+									Primary: ptr.To(true),
+									LoadBalancerBackendAddressPools: &[]network.BackendAddressPool{
+										{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/loadBalancers/my-public-lb/backendAddressPools/cluster-name-outboundBackendPool")},
+										{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/loadBalancers/my-public-lb/backendAddressPools/service-pool-1")},
+										{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/loadBalancers/my-public-lb/backendAddressPools/service-pool-2")},
+									},
+									PrivateIPAllocationMethod: network.IPAllocationMethodDynamic,
+									Subnet:                    &network.Subnet{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet")},
+								},
+							},
+						},
+					},
+				}))
+			},
+			expectedError: "",
+		},
 		{
 			name:     "get parameters for control plane network interface",
 			spec:     &fakeControlPlaneNICSpec,
@@ -405,7 +529,7 @@ func TestParameters(t *testing.T) {
 			expectedError: "",
 		},
 		{
-			name:     "get parameters for network interface without accelerated networking",
+			name:     "get parameters for network interface with accelerated networking explicitly disabled",
 			spec:     &fakeNonAcceleratedNetworkingNICSpec,
 			existing: nil,
 			expect: func(g *WithT, result interface{}) {
@@ -438,7 +562,40 @@ func TestParameters(t *testing.T) {
 			expectedError: "",
 		},
 		{
-			name:     "get parameters for network interface ipv6",
+			name:     "get parameters for network interface with a VM size without accelerated networking",
+			spec:     &fakeUnsupportedAcceleratedNetworkingNICSpec,
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.Interface{}))
+				g.Expect(result.(network.Interface)).To(Equal(network.Interface{
+					Tags: map[string]*string{
+						"Name": ptr.To("my-net-interface"),
+						"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+					},
+					Location: ptr.To("fake-location"),
+					InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+						Primary:                     nil,
+						EnableAcceleratedNetworking: ptr.To(false),
+						EnableIPForwarding:          ptr.To(false),
+						DNSSettings:                 &network.InterfaceDNSSettings{},
+						IPConfigurations: &[]network.InterfaceIPConfiguration{
+							{
+								Name: ptr.To("pipConfig"),
+								InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+									Primary:                         ptr.To(true),
+									Subnet:                          &network.Subnet{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet")},
+									PrivateIPAllocationMethod:       network.IPAllocationMethodDynamic,
+									LoadBalancerBackendAddressPools: &[]network.BackendAddressPool{},
+								},
+							},
+						},
+					},
+				}))
+			},
+			expectedError: "",
+		},
+		{
+			name:     "get parameters for dual-stack network interface with primary IPv4 and secondary IPv6 configs",
 			spec:     &fakeIpv6NICSpec,
 			existing: nil,
 			expect: func(g *WithT, result interface{}) {
@@ -461,6 +618,7 @@ func TestParameters(t *testing.T) {
 									Primary:                         ptr.To(true),
 									Subnet:                          &network.Subnet{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet")},
 									PrivateIPAllocationMethod:       network.IPAllocationMethodDynamic,
+									PrivateIPAddressVersion:         network.IPVersionIPv4,
 									LoadBalancerBackendAddressPools: &[]network.BackendAddressPool{},
 								},
 							},
@@ -469,7 +627,7 @@ func TestParameters(t *testing.T) {
 								InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
 									Subnet:                  &network.Subnet{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet")},
 									Primary:                 ptr.To(false),
-									PrivateIPAddressVersion: "IPv6",
+									PrivateIPAddressVersion: network.IPVersionIPv6,
 								},
 							},
 						},
@@ -586,6 +744,99 @@ func TestParameters(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name:     "get parameters for network interface with secondary ipconfigs in the pod subnet",
+			spec:     &fakePodSubnetNICSpec,
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.Interface{}))
+				g.Expect(result.(network.Interface)).To(Equal(network.Interface{
+					Tags: map[string]*string{
+						"Name": ptr.To("my-net-interface"),
+						"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+					},
+					Location: ptr.To("fake-location"),
+					InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+						Primary:                     nil,
+						EnableAcceleratedNetworking: ptr.To(true),
+						EnableIPForwarding:          ptr.To(true),
+						DNSSettings:                 &network.InterfaceDNSSettings{},
+						IPConfigurations: &[]network.InterfaceIPConfiguration{
+							{
+								Name: ptr.To("pipConfig"),
+								InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+									Primary:                         ptr.To(true),
+									Subnet:                          &network.Subnet{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet")},
+									PrivateIPAllocationMethod:       network.IPAllocationMethodDynamic,
+									LoadBalancerBackendAddressPools: &[]network.BackendAddressPool{},
+								},
+							},
+							{
+								Name: ptr.To("my-net-interface-1"),
+								InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+									Primary:                         ptr.To(false),
+									Subnet:                          &network.Subnet{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-pod-subnet")},
+									PrivateIPAllocationMethod:       network.IPAllocationMethodDynamic,
+									LoadBalancerBackendAddressPools: nil,
+								},
+							},
+						},
+					},
+				}))
+			},
+			expectedError: "",
+		},
+		{
+			name:     "get parameters for network interface with a floating ip in a secondary ipconfig",
+			spec:     &fakeFloatingIPNICSpec,
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.Interface{}))
+				g.Expect(result.(network.Interface)).To(Equal(network.Interface{
+					Tags: map[string]*string{
+						"Name": ptr.To("my-net-interface"),
+						"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+					},
+					Location: ptr.To("fake-location"),
+					InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+						Primary:                     nil,
+						EnableAcceleratedNetworking: ptr.To(true),
+						EnableIPForwarding:          ptr.To(true),
+						DNSSettings:                 &network.InterfaceDNSSettings{},
+						IPConfigurations: &[]network.InterfaceIPConfiguration{
+							{
+								Name: ptr.To("pipConfig"),
+								InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+									Primary:                         ptr.To(true),
+									Subnet:                          &network.Subnet{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet")},
+									PrivateIPAllocationMethod:       network.IPAllocationMethodDynamic,
+									LoadBalancerBackendAddressPools: &[]network.BackendAddressPool{},
+								},
+							},
+							{
+								Name: ptr.To("my-net-interface-1"),
+								InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+									Primary:                         ptr.To(false),
+									Subnet:                          &network.Subnet{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-pod-subnet")},
+									PrivateIPAllocationMethod:       network.IPAllocationMethodDynamic,
+									LoadBalancerBackendAddressPools: nil,
+								},
+							},
+							{
+								Name: ptr.To("floating-vip"),
+								InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+									Primary:                   ptr.To(false),
+									Subnet:                    &network.Subnet{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet")},
+									PrivateIPAllocationMethod: network.IPAllocationMethodStatic,
+									PrivateIPAddress:          ptr.To("10.0.0.100"),
+								},
+							},
+						},
+					},
+				}))
+			},
+			expectedError: "",
+		},
 		{
 			name:     "get parameters for network interface with two ipconfigs and a public ip",
 			spec:     &fakeTwoIPconfigWithPublicNICSpec,
@@ -668,6 +919,135 @@ func TestParameters(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name:     "get parameters for network interface with a virtual network tap",
+			spec:     &fakeVirtualNetworkTapNICSpec,
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.Interface{}))
+				g.Expect(result.(network.Interface)).To(Equal(network.Interface{
+					Tags: map[string]*string{
+						"Name": ptr.To("my-net-interface"),
+						"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+					},
+					Location: ptr.To("fake-location"),
+					InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+						EnableAcceleratedNetworking: ptr.To(true),
+						EnableIPForwarding:          ptr.To(false),
+						DNSSettings:                 &network.InterfaceDNSSettings{},
+						IPConfigurations: &[]network.InterfaceIPConfiguration{
+							{
+								Name: ptr.To("pipConfig"),
+								InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+									Primary:                         ptr.To(true),
+									Subnet:                          &network.Subnet{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet")},
+									PrivateIPAllocationMethod:       network.IPAllocationMethodDynamic,
+									LoadBalancerBackendAddressPools: &[]network.BackendAddressPool{},
+									VirtualNetworkTaps: &[]network.VirtualNetworkTap{
+										{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/my-tap")},
+									},
+								},
+							},
+							{
+								Name: ptr.To("my-net-interface-1"),
+								InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+									Primary:                   ptr.To(false),
+									Subnet:                    &network.Subnet{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet")},
+									PrivateIPAllocationMethod: network.IPAllocationMethodDynamic,
+								},
+							},
+						},
+					},
+				}))
+			},
+			expectedError: "",
+		},
+		{
+			name:     "attach a virtual network tap to the primary ipconfig of an existing network interface",
+			spec:     &fakeVirtualNetworkTapNICSpec,
+			existing: fakeExistingNIC(nil),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.Interface{}))
+				ipConfigs := *result.(network.Interface).IPConfigurations
+				g.Expect(ipConfigs).To(HaveLen(2))
+				g.Expect(ipConfigs[0].VirtualNetworkTaps).To(Equal(&[]network.VirtualNetworkTap{
+					{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/my-tap")},
+				}))
+				g.Expect(ipConfigs[1].VirtualNetworkTaps).To(BeNil())
+				g.Expect(result.(network.Interface).Etag).To(Equal(ptr.To("fake-etag")))
+			},
+			expectedError: "",
+		},
+		{
+			name: "replace the virtual network tap last attached to an existing network interface",
+			spec: func() *NICSpec {
+				spec := fakeVirtualNetworkTapNICSpec
+				spec.LastAppliedVirtualNetworkTapID = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/other-tap"
+				return &spec
+			}(),
+			existing: fakeExistingNIC(ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/other-tap")),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.Interface{}))
+				g.Expect((*result.(network.Interface).IPConfigurations)[0].VirtualNetworkTaps).To(Equal(&[]network.VirtualNetworkTap{
+					{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/my-tap")},
+				}))
+			},
+			expectedError: "",
+		},
+		{
+			name:     "keep the virtual network tap attached outside of CAPZ to an existing network interface",
+			spec:     &fakeVirtualNetworkTapNICSpec,
+			existing: fakeExistingNIC(ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/other-tap")),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.Interface{}))
+				g.Expect((*result.(network.Interface).IPConfigurations)[0].VirtualNetworkTaps).To(Equal(&[]network.VirtualNetworkTap{
+					{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/other-tap")},
+					{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/my-tap")},
+				}))
+			},
+			expectedError: "",
+		},
+		{
+			name: "detach the virtual network tap last attached to an existing network interface",
+			spec: func() *NICSpec {
+				spec := fakeDynamicPrivateIPNICSpec
+				spec.LastAppliedVirtualNetworkTapID = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/my-tap"
+				return &spec
+			}(),
+			existing: fakeExistingNIC(ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/my-tap")),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.Interface{}))
+				g.Expect((*result.(network.Interface).IPConfigurations)[0].VirtualNetworkTaps).To(Equal(&[]network.VirtualNetworkTap{}))
+			},
+			expectedError: "",
+		},
+		{
+			name:     "don't detach a virtual network tap attached outside of CAPZ to an existing network interface",
+			spec:     &fakeDynamicPrivateIPNICSpec,
+			existing: fakeExistingNIC(ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/my-tap")),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+			expectedError: "",
+		},
+		{
+			name:     "existing network interface already has the virtual network tap",
+			spec:     &fakeVirtualNetworkTapNICSpec,
+			existing: fakeExistingNIC(ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/MY-TAP")),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+			expectedError: "",
+		},
+		{
+			name:     "existing network interface without a virtual network tap",
+			spec:     &fakeDynamicPrivateIPNICSpec,
+			existing: fakeExistingNIC(nil),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+			expectedError: "",
+		},
 	}
 	format.MaxLength = 10000
 	for _, tc := range testcases {
@@ -687,3 +1067,33 @@ func TestParameters(t *testing.T) {
 		})
 	}
 }
+
+// fakeExistingNIC returns an existing network interface with two IP configurations, the primary one mirrored to the
+// virtual network tap with the given ID, if any.
+func fakeExistingNIC(tapID *string) network.Interface {
+	var taps *[]network.VirtualNetworkTap
+	if tapID != nil {
+		taps = &[]network.VirtualNetworkTap{{ID: tapID}}
+	}
+	return network.Interface{
+		Etag:     ptr.To("fake-etag"),
+		Location: ptr.To("fake-location"),
+		InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+			IPConfigurations: &[]network.InterfaceIPConfiguration{
+				{
+					Name: ptr.To("pipConfig"),
+					InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+						Primary:            ptr.To(true),
+						VirtualNetworkTaps: taps,
+					},
+				},
+				{
+					Name: ptr.To("my-net-interface-1"),
+					InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+						Primary: ptr.To(false),
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orphanedresources
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/resourcegraph/mgmt/2021-03-01/resourcegraph"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-10-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// client wraps go-sdk.
+type client interface {
+	Resources(context.Context, resourcegraph.QueryRequest) (resourcegraph.QueryResponse, error)
+	DeleteByID(context.Context, string, string) error
+}
+
+// AzureClient contains the Azure go-sdk clients.
+type AzureClient struct {
+	resourcegraph resourcegraph.BaseClient
+	resources     resources.Client
+}
+
+var _ client = (*AzureClient)(nil)
+
+// NewClient creates a new Azure Resource Graph and resources client from subscription ID.
+func NewClient(auth azure.Authorizer) *AzureClient {
+	return &AzureClient{
+		resourcegraph: newResourceGraphClient(auth.BaseURI(), auth.Authorizer()),
+		resources:     newResourcesClient(auth.SubscriptionID(), auth.BaseURI(), auth.Authorizer()),
+	}
+}
+
+// newResourceGraphClient creates a new Azure Resource Graph client.
+func newResourceGraphClient(baseURI string, authorizer autorest.Authorizer) resourcegraph.BaseClient {
+	graphClient := resourcegraph.NewWithBaseURI(baseURI)
+	azure.SetAutoRestClientDefaults(&graphClient.Client, authorizer)
+	return graphClient
+}
+
+// newResourcesClient creates a new resources client from subscription ID.
+func newResourcesClient(subscriptionID string, baseURI string, authorizer autorest.Authorizer) resources.Client {
+	resourcesClient := resources.NewClientWithBaseURI(baseURI, subscriptionID)
+	azure.SetAutoRestClientDefaults(&resourcesClient.Client, authorizer)
+	return resourcesClient
+}
+
+// Resources queries the resources matching an Azure Resource Graph query.
+func (ac *AzureClient) Resources(ctx context.Context, query resourcegraph.QueryRequest) (resourcegraph.QueryResponse, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "orphanedresources.AzureClient.Resources")
+	defer done()
+
+	return ac.resourcegraph.Resources(ctx, query)
+}
+
+// DeleteByID starts the deletion of a resource by ID with the given API version of its resource provider. It doesn't
+// wait for the deletion to complete.
+func (ac *AzureClient) DeleteByID(ctx context.Context, resourceID string, apiVersion string) error {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "orphanedresources.AzureClient.DeleteByID")
+	defer done()
+
+	_, err := ac.resources.DeleteByID(ctx, resourceID, apiVersion)
+	return err
+}
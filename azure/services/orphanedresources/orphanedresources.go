@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orphanedresources
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/resourcegraph/mgmt/2021-03-01/resourcegraph"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// ServiceName is the name of this service.
+const ServiceName = "orphanedresources"
+
+// apiVersions are the API versions used to delete the orphaned resources by type. They match the API versions of the
+// SDKs CAPZ creates these resources with. Only the resource types listed here are cleaned up.
+var apiVersions = map[string]string{
+	"microsoft.network/networkinterfaces": "2021-08-01",
+	"microsoft.network/publicipaddresses": "2021-08-01",
+	"microsoft.compute/disks":             "2021-08-01",
+}
+
+// OrphanedResourcesScope defines the scope interface for an orphaned resources service.
+type OrphanedResourcesScope interface {
+	azure.Authorizer
+	ClusterName() string
+	ResourceGroup() string
+}
+
+// Service deletes the resources owned by a cluster that its normal teardown missed.
+type Service struct {
+	Scope OrphanedResourcesScope
+	client
+}
+
+// New creates a new service.
+func New(scope OrphanedResourcesScope) *Service {
+	return &Service{
+		Scope:  scope,
+		client: NewClient(scope),
+	}
+}
+
+// Name returns the service name.
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// Reconcile is a no-op, orphaned resources are only looked for on deletion.
+func (s *Service) Reconcile(ctx context.Context) error {
+	return nil
+}
+
+// Delete queries Azure Resource Graph for the network interfaces, disks and public IP addresses of the resource group
+// owned by the cluster, e.g. left behind by a failed deletion, and starts their deletion. It returns a transient error
+// while orphaned resources are found so that the deletion is requeued until they are gone.
+func (s *Service) Delete(ctx context.Context) error {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "orphanedresources.Service.Delete")
+	defer done()
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureServiceReconcileTimeout)
+	defer cancel()
+
+	ids, err := s.orphanedResourceIDs(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to query orphaned resources")
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if dryRunner, ok := s.Scope.(azure.DryRunner); ok && dryRunner.IsDryRun() {
+		dryRunner.RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason, "Dry run: %d orphaned resources would be deleted", len(ids))
+		return nil
+	}
+
+	var errs []error
+	for id, resourceType := range ids {
+		log.V(2).Info("deleting orphaned resource", "id", id)
+		if err := s.client.DeleteByID(ctx, id, apiVersions[resourceType]); err != nil && !azure.ResourceNotFound(err) {
+			errs = append(errs, errors.Wrapf(err, "failed to delete orphaned resource %s", id))
+		}
+	}
+	if err := kerrors.NewAggregate(errs); err != nil {
+		return err
+	}
+	return azure.WithTransientError(errors.Errorf("deleting %d orphaned resources", len(ids)), reconciler.DefaultReconcilerRequeue)
+}
+
+// orphanedResourceIDs returns the types of the orphaned resources of the cluster by resource ID.
+func (s *Service) orphanedResourceIDs(ctx context.Context) (map[string]string, error) {
+	resp, err := s.client.Resources(ctx, resourcegraph.QueryRequest{
+		Subscriptions: &[]string{s.Scope.SubscriptionID()},
+		Query:         ptr.To(s.query()),
+		Options: &resourcegraph.QueryRequestOptions{
+			ResultFormat: resourcegraph.ResultFormatObjectArray,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, ok := resp.Data.([]interface{})
+	if !ok && resp.Data != nil {
+		return nil, errors.Errorf("%T is not an object array", resp.Data)
+	}
+	ids := make(map[string]string, len(rows))
+	for _, row := range rows {
+		obj, ok := row.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("%T is not an object", row)
+		}
+		id, _ := obj["id"].(string)
+		resourceType, _ := obj["type"].(string)
+		resourceType = strings.ToLower(resourceType)
+		if _, ok := apiVersions[resourceType]; id == "" || !ok {
+			continue
+		}
+		ids[id] = resourceType
+	}
+	return ids, nil
+}
+
+// query returns the Azure Resource Graph query of the resources owned by the cluster in its resource group.
+func (s *Service) query() string {
+	types := make([]string, 0, len(apiVersions))
+	for resourceType := range apiVersions {
+		types = append(types, fmt.Sprintf("'%s'", resourceType))
+	}
+	sort.Strings(types)
+	return fmt.Sprintf("Resources | where resourceGroup =~ '%s' | where tags['%s'] =~ '%s' | where type in~ (%s) | project id, type",
+		s.Scope.ResourceGroup(), infrav1.ClusterTagKey(s.Scope.ClusterName()), infrav1.ResourceLifecycleOwned, strings.Join(types, ", "))
+}
+
+// IsManaged always returns true as only the resources owned by the cluster are deleted.
+func (s *Service) IsManaged(ctx context.Context) (bool, error) {
+	return true, nil
+}
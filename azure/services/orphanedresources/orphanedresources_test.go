@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orphanedresources
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/resourcegraph/mgmt/2021-03-01/resourcegraph"
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/orphanedresources/mock_orphanedresources"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+const (
+	fakeNICID  = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkInterfaces/my-vm-nic"
+	fakeDiskID = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/my-vm_OSDisk"
+	fakePIPID  = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/pip-my-vm"
+)
+
+var notFoundError = autorest.DetailedError{StatusCode: http.StatusNotFound}
+
+func TestDeleteOrphanedResources(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expect        func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder)
+		expectedError string
+	}{
+		{
+			name:          "no orphaned resources",
+			expectedError: "",
+			expect: func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder) {
+				expectScope(s)
+				m.Resources(gomockinternal.AContext(), expectedQueryRequest()).Return(resourcegraph.QueryResponse{Data: []interface{}{}}, nil)
+			},
+		},
+		{
+			name:          "orphaned network interface, disk and public IP are deleted",
+			expectedError: "deleting 3 orphaned resources. Object will be requeued after 15s",
+			expect: func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder) {
+				expectScope(s)
+				m.Resources(gomockinternal.AContext(), expectedQueryRequest()).Return(resourcegraph.QueryResponse{Data: []interface{}{
+					map[string]interface{}{"id": fakeNICID, "type": "microsoft.network/networkinterfaces"},
+					map[string]interface{}{"id": fakeDiskID, "type": "microsoft.compute/disks"},
+					map[string]interface{}{"id": fakePIPID, "type": "Microsoft.Network/publicIPAddresses"},
+				}}, nil)
+				m.DeleteByID(gomockinternal.AContext(), fakeNICID, "2021-08-01").Return(nil)
+				m.DeleteByID(gomockinternal.AContext(), fakeDiskID, "2021-08-01").Return(nil)
+				m.DeleteByID(gomockinternal.AContext(), fakePIPID, "2021-08-01").Return(nil)
+			},
+		},
+		{
+			name:          "orphaned resources of other types are ignored",
+			expectedError: "",
+			expect: func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder) {
+				expectScope(s)
+				m.Resources(gomockinternal.AContext(), expectedQueryRequest()).Return(resourcegraph.QueryResponse{Data: []interface{}{
+					map[string]interface{}{"id": "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet", "type": "microsoft.network/virtualnetworks"},
+				}}, nil)
+			},
+		},
+		{
+			name:          "orphaned resource already deleted",
+			expectedError: "deleting 1 orphaned resources. Object will be requeued after 15s",
+			expect: func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder) {
+				expectScope(s)
+				m.Resources(gomockinternal.AContext(), expectedQueryRequest()).Return(resourcegraph.QueryResponse{Data: []interface{}{
+					map[string]interface{}{"id": fakeNICID, "type": "microsoft.network/networkinterfaces"},
+				}}, nil)
+				m.DeleteByID(gomockinternal.AContext(), fakeNICID, "2021-08-01").Return(notFoundError)
+			},
+		},
+		{
+			name:          "failure to delete an orphaned resource",
+			expectedError: "failed to delete orphaned resource " + fakeDiskID + ": #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder) {
+				expectScope(s)
+				m.Resources(gomockinternal.AContext(), expectedQueryRequest()).Return(resourcegraph.QueryResponse{Data: []interface{}{
+					map[string]interface{}{"id": fakeDiskID, "type": "microsoft.compute/disks"},
+				}}, nil)
+				m.DeleteByID(gomockinternal.AContext(), fakeDiskID, "2021-08-01").Return(autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error"))
+			},
+		},
+		{
+			name:          "failure to query orphaned resources",
+			expectedError: "failed to query orphaned resources: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder) {
+				expectScope(s)
+				m.Resources(gomockinternal.AContext(), expectedQueryRequest()).Return(resourcegraph.QueryResponse{},
+					autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error"))
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_orphanedresources.NewMockOrphanedResourcesScope(mockCtrl)
+			clientMock := mock_orphanedresources.NewMockclient(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), clientMock.EXPECT())
+
+			s := &Service{
+				Scope:  scopeMock,
+				client: clientMock,
+			}
+
+			err := s.Delete(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestDeleteOrphanedResourcesRequeues(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scopeMock := mock_orphanedresources.NewMockOrphanedResourcesScope(mockCtrl)
+	clientMock := mock_orphanedresources.NewMockclient(mockCtrl)
+
+	expectScope(scopeMock.EXPECT())
+	clientMock.EXPECT().Resources(gomockinternal.AContext(), expectedQueryRequest()).Return(resourcegraph.QueryResponse{Data: []interface{}{
+		map[string]interface{}{"id": fakeNICID, "type": "microsoft.network/networkinterfaces"},
+	}}, nil)
+	clientMock.EXPECT().DeleteByID(gomockinternal.AContext(), fakeNICID, "2021-08-01").Return(nil)
+
+	s := &Service{
+		Scope:  scopeMock,
+		client: clientMock,
+	}
+	err := s.Delete(context.TODO())
+	var reconcileErr azure.ReconcileError
+	g.Expect(err).To(BeAssignableToTypeOf(reconcileErr))
+	g.Expect(err.(azure.ReconcileError).IsTransient()).To(BeTrue())
+}
+
+// fakeDryRunScope is an orphaned resources scope reconciling in dry-run mode.
+type fakeDryRunScope struct {
+	*mock_orphanedresources.MockOrphanedResourcesScope
+	events []string
+}
+
+func (s *fakeDryRunScope) IsDryRun() bool {
+	return true
+}
+
+func (s *fakeDryRunScope) RecordEvent(eventType, reason, messageFmt string, args ...interface{}) {
+	s.events = append(s.events, reason)
+}
+
+func TestDeleteOrphanedResourcesDryRun(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scopeMock := mock_orphanedresources.NewMockOrphanedResourcesScope(mockCtrl)
+	clientMock := mock_orphanedresources.NewMockclient(mockCtrl)
+
+	expectScope(scopeMock.EXPECT())
+	clientMock.EXPECT().Resources(gomockinternal.AContext(), expectedQueryRequest()).Return(resourcegraph.QueryResponse{Data: []interface{}{
+		map[string]interface{}{"id": fakeNICID, "type": "microsoft.network/networkinterfaces"},
+	}}, nil)
+
+	dryRunScope := &fakeDryRunScope{MockOrphanedResourcesScope: scopeMock}
+	s := &Service{
+		Scope:  dryRunScope,
+		client: clientMock,
+	}
+	g.Expect(s.Delete(context.TODO())).To(Succeed())
+	g.Expect(dryRunScope.events).To(Equal([]string{infrav1.DryRunChangeReason}))
+}
+
+func expectScope(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder) {
+	s.SubscriptionID().AnyTimes().Return("123")
+	s.ResourceGroup().AnyTimes().Return("my-rg")
+	s.ClusterName().AnyTimes().Return("my-cluster")
+}
+
+func expectedQueryRequest() resourcegraph.QueryRequest {
+	return resourcegraph.QueryRequest{
+		Subscriptions: &[]string{"123"},
+		Query: ptr.To("Resources | where resourceGroup =~ 'my-rg' | where tags['sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster'] =~ 'owned' " +
+			"| where type in~ ('microsoft.compute/disks', 'microsoft.network/networkinterfaces', 'microsoft.network/publicipaddresses') | project id, type"),
+		Options: &resourcegraph.QueryRequestOptions{
+			ResultFormat: resourcegraph.ResultFormatObjectArray,
+		},
+	}
+}
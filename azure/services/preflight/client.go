@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2021-07-01/features"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+const (
+	// ComputeResourceProvider is the namespace of the Azure compute resource provider.
+	ComputeResourceProvider = "Microsoft.Compute"
+	// EncryptionAtHostFeature is the subscription feature of the compute resource provider enabling encryption at host.
+	EncryptionAtHostFeature = "EncryptionAtHost"
+	// FeatureRegistered is the registration state of a subscription feature that is registered.
+	FeatureRegistered = "Registered"
+)
+
+// Client gets the Azure resources a VM depends on, so that they are checked before the VM is created.
+type Client interface {
+	GetDisk(ctx context.Context, id string) (compute.Disk, error)
+	GetDiskEncryptionSet(ctx context.Context, id string) (compute.DiskEncryptionSet, error)
+	GetVirtualMachineScaleSet(ctx context.Context, id string) (compute.VirtualMachineScaleSet, error)
+	GetEncryptionAtHostFeatureState(ctx context.Context) (string, error)
+	GetImageFeatures(ctx context.Context, image *infrav1.Image, location string) (ImageFeatures, error)
+}
+
+// AzureClient gets the Azure resources a VM depends on.
+type AzureClient struct {
+	auth azure.Authorizer
+}
+
+var _ Client = &AzureClient{}
+
+// NewClient creates a new preflight client from auth info.
+// The resources may live in another subscription than the cluster, so the SDK clients are created for each request.
+func NewClient(auth azure.Authorizer) *AzureClient {
+	return &AzureClient{auth}
+}
+
+// GetDisk returns a managed disk by resource ID.
+func (ac *AzureClient) GetDisk(ctx context.Context, id string) (compute.Disk, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "preflight.AzureClient.GetDisk")
+	defer done()
+
+	resourceID, err := arm.ParseResourceID(id)
+	if err != nil {
+		return compute.Disk{}, errors.Wrapf(err, "failed to parse disk ID %s", id)
+	}
+	disksClient := compute.NewDisksClientWithBaseURI(ac.auth.BaseURI(), resourceID.SubscriptionID)
+	azure.SetAutoRestClientDefaults(&disksClient.Client, ac.auth.Authorizer())
+	return disksClient.Get(ctx, resourceID.ResourceGroupName, resourceID.Name)
+}
+
+// GetDiskEncryptionSet returns a disk encryption set by resource ID.
+func (ac *AzureClient) GetDiskEncryptionSet(ctx context.Context, id string) (compute.DiskEncryptionSet, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "preflight.AzureClient.GetDiskEncryptionSet")
+	defer done()
+
+	resourceID, err := arm.ParseResourceID(id)
+	if err != nil {
+		return compute.DiskEncryptionSet{}, errors.Wrapf(err, "failed to parse disk encryption set ID %s", id)
+	}
+	diskEncryptionSetsClient := compute.NewDiskEncryptionSetsClientWithBaseURI(ac.auth.BaseURI(), resourceID.SubscriptionID)
+	azure.SetAutoRestClientDefaults(&diskEncryptionSetsClient.Client, ac.auth.Authorizer())
+	return diskEncryptionSetsClient.Get(ctx, resourceID.ResourceGroupName, resourceID.Name)
+}
+
+// GetVirtualMachineScaleSet returns a virtual machine scale set by resource ID.
+func (ac *AzureClient) GetVirtualMachineScaleSet(ctx context.Context, id string) (compute.VirtualMachineScaleSet, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "preflight.AzureClient.GetVirtualMachineScaleSet")
+	defer done()
+
+	resourceID, err := arm.ParseResourceID(id)
+	if err != nil {
+		return compute.VirtualMachineScaleSet{}, errors.Wrapf(err, "failed to parse virtual machine scale set ID %s", id)
+	}
+	scaleSetsClient := compute.NewVirtualMachineScaleSetsClientWithBaseURI(ac.auth.BaseURI(), resourceID.SubscriptionID)
+	azure.SetAutoRestClientDefaults(&scaleSetsClient.Client, ac.auth.Authorizer())
+	return scaleSetsClient.Get(ctx, resourceID.ResourceGroupName, resourceID.Name, "")
+}
+
+// GetEncryptionAtHostFeatureState returns the registration state of the EncryptionAtHost feature of the compute resource
+// provider in the subscription of the cluster, e.g. Registered.
+func (ac *AzureClient) GetEncryptionAtHostFeatureState(ctx context.Context) (string, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "preflight.AzureClient.GetEncryptionAtHostFeatureState")
+	defer done()
+
+	featuresClient := features.NewClientWithBaseURI(ac.auth.BaseURI(), ac.auth.SubscriptionID())
+	azure.SetAutoRestClientDefaults(&featuresClient.Client, ac.auth.Authorizer())
+	feature, err := featuresClient.Get(ctx, ComputeResourceProvider, EncryptionAtHostFeature)
+	if err != nil {
+		return "", err
+	}
+	if feature.Properties == nil {
+		return "", nil
+	}
+	return ptr.Deref(feature.Properties.State, ""), nil
+}
+
+// GetImageFeatures returns the features of the image of a VM.
+func (ac *AzureClient) GetImageFeatures(ctx context.Context, image *infrav1.Image, location string) (ImageFeatures, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "preflight.AzureClient.GetImageFeatures")
+	defer done()
+
+	return getImageFeatures(ctx, image, location, &azureImageGetter{
+		subscriptionID: ac.auth.SubscriptionID(),
+		baseURI:        ac.auth.BaseURI(),
+		authorizer:     ac.auth.Authorizer(),
+	})
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	azureutil "sigs.k8s.io/cluster-api-provider-azure/util/azure"
+)
+
+// ImageFeatures are the features of an image that determine the security types of the VMs created from it.
+type ImageFeatures struct {
+	// HyperVGeneration is the hypervisor generation of the image, V1 or V2.
+	HyperVGeneration string
+	// SecurityType is the value of the SecurityType feature of the image, e.g. ConfidentialVmSupported.
+	SecurityType string
+}
+
+// imageSecurityTypeFeature is the name of the image feature listing the security types an image supports.
+const imageSecurityTypeFeature = "SecurityType"
+
+// imageGetter gets the features of the images a VM can be created from.
+type imageGetter interface {
+	MarketplaceImageFeatures(ctx context.Context, location, publisher, offer, sku, version string) (ImageFeatures, error)
+	GalleryImageFeatures(ctx context.Context, subscriptionID, resourceGroup, gallery, image string) (ImageFeatures, error)
+	CommunityGalleryImageFeatures(ctx context.Context, location, gallery, image string) (ImageFeatures, error)
+	ManagedImageFeatures(ctx context.Context, subscriptionID, resourceGroup, image string) (ImageFeatures, error)
+}
+
+// getImageFeatures returns the features of an image. The default images are Generation 1 images without security
+// type. The features of an image ID which is neither a gallery image version nor a managed image are unknown.
+func getImageFeatures(ctx context.Context, image *infrav1.Image, location string, getter imageGetter) (ImageFeatures, error) {
+	if image == nil {
+		return ImageFeatures{HyperVGeneration: string(compute.HyperVGenerationV1)}, nil
+	}
+
+	switch {
+	case image.Marketplace != nil:
+		return getter.MarketplaceImageFeatures(ctx, location, image.Marketplace.Publisher, image.Marketplace.Offer, image.Marketplace.SKU, image.Marketplace.Version)
+	case image.SharedGallery != nil:
+		return getter.GalleryImageFeatures(ctx, image.SharedGallery.SubscriptionID, image.SharedGallery.ResourceGroup, image.SharedGallery.Gallery, image.SharedGallery.Name)
+	case image.ComputeGallery != nil && image.ComputeGallery.SubscriptionID != nil && image.ComputeGallery.ResourceGroup != nil:
+		return getter.GalleryImageFeatures(ctx, *image.ComputeGallery.SubscriptionID, *image.ComputeGallery.ResourceGroup, image.ComputeGallery.Gallery, image.ComputeGallery.Name)
+	case image.ComputeGallery != nil:
+		return getter.CommunityGalleryImageFeatures(ctx, location, image.ComputeGallery.Gallery, image.ComputeGallery.Name)
+	case image.ID != nil:
+		resourceID, err := azureutil.ParseResourceID(*image.ID)
+		if err != nil {
+			return ImageFeatures{}, errors.Wrapf(err, "failed to parse image ID %s", *image.ID)
+		}
+		switch {
+		case strings.EqualFold(resourceID.ResourceType.String(), "Microsoft.Compute/galleries/images/versions"):
+			return getter.GalleryImageFeatures(ctx, resourceID.SubscriptionID, resourceID.ResourceGroupName, resourceID.Parent.Parent.Name, resourceID.Parent.Name)
+		case strings.EqualFold(resourceID.ResourceType.String(), "Microsoft.Compute/images"):
+			return getter.ManagedImageFeatures(ctx, resourceID.SubscriptionID, resourceID.ResourceGroupName, resourceID.Name)
+		}
+	}
+	return ImageFeatures{}, nil
+}
+
+// galleryImageSecurityType returns the value of the SecurityType feature of a gallery image.
+func galleryImageSecurityType(features *[]compute.GalleryImageFeature) string {
+	if features == nil {
+		return ""
+	}
+	for _, feature := range *features {
+		if strings.EqualFold(ptr.Deref(feature.Name, ""), imageSecurityTypeFeature) {
+			return ptr.Deref(feature.Value, "")
+		}
+	}
+	return ""
+}
+
+// azureImageGetter gets the features of images from Azure.
+type azureImageGetter struct {
+	subscriptionID string
+	baseURI        string
+	authorizer     autorest.Authorizer
+}
+
+// MarketplaceImageFeatures returns the features of a marketplace image. The latest version of the image is looked up
+// if the version is latest.
+func (g *azureImageGetter) MarketplaceImageFeatures(ctx context.Context, location, publisher, offer, sku, version string) (ImageFeatures, error) {
+	imagesClient := compute.NewVirtualMachineImagesClientWithBaseURI(g.baseURI, g.subscriptionID)
+	azure.SetAutoRestClientDefaults(&imagesClient.Client, g.authorizer)
+	if strings.EqualFold(version, azure.LatestVersion) {
+		images, err := imagesClient.List(ctx, location, publisher, offer, sku, "", ptr.To[int32](1), "name desc")
+		if err != nil {
+			return ImageFeatures{}, err
+		}
+		if images.Value == nil || len(*images.Value) == 0 {
+			return ImageFeatures{}, errors.Errorf("no version of image %s/%s/%s found", publisher, offer, sku)
+		}
+		version = ptr.Deref((*images.Value)[0].Name, "")
+	}
+	image, err := imagesClient.Get(ctx, location, publisher, offer, sku, version)
+	if err != nil {
+		return ImageFeatures{}, err
+	}
+	if image.VirtualMachineImageProperties == nil {
+		return ImageFeatures{}, nil
+	}
+	features := ImageFeatures{HyperVGeneration: string(image.HyperVGeneration)}
+	if image.Features != nil {
+		for _, feature := range *image.Features {
+			if strings.EqualFold(ptr.Deref(feature.Name, ""), imageSecurityTypeFeature) {
+				features.SecurityType = ptr.Deref(feature.Value, "")
+			}
+		}
+	}
+	return features, nil
+}
+
+// GalleryImageFeatures returns the features of an image of an Azure Compute Gallery.
+func (g *azureImageGetter) GalleryImageFeatures(ctx context.Context, subscriptionID, resourceGroup, gallery, image string) (ImageFeatures, error) {
+	imagesClient := compute.NewGalleryImagesClientWithBaseURI(g.baseURI, subscriptionID)
+	azure.SetAutoRestClientDefaults(&imagesClient.Client, g.authorizer)
+	galleryImage, err := imagesClient.Get(ctx, resourceGroup, gallery, image)
+	if err != nil {
+		return ImageFeatures{}, err
+	}
+	if galleryImage.GalleryImageProperties == nil {
+		return ImageFeatures{}, nil
+	}
+	return ImageFeatures{
+		HyperVGeneration: string(galleryImage.HyperVGeneration),
+		SecurityType:     galleryImageSecurityType(galleryImage.Features),
+	}, nil
+}
+
+// CommunityGalleryImageFeatures returns the features of an image of a community gallery.
+func (g *azureImageGetter) CommunityGalleryImageFeatures(ctx context.Context, location, gallery, image string) (ImageFeatures, error) {
+	imagesClient := compute.NewCommunityGalleryImagesClientWithBaseURI(g.baseURI, g.subscriptionID)
+	azure.SetAutoRestClientDefaults(&imagesClient.Client, g.authorizer)
+	galleryImage, err := imagesClient.Get(ctx, location, gallery, image)
+	if err != nil {
+		return ImageFeatures{}, err
+	}
+	if galleryImage.CommunityGalleryImageProperties == nil {
+		return ImageFeatures{}, nil
+	}
+	return ImageFeatures{
+		HyperVGeneration: string(galleryImage.HyperVGeneration),
+		SecurityType:     galleryImageSecurityType(galleryImage.Features),
+	}, nil
+}
+
+// ManagedImageFeatures returns the features of a managed image. Managed images have no security type.
+func (g *azureImageGetter) ManagedImageFeatures(ctx context.Context, subscriptionID, resourceGroup, image string) (ImageFeatures, error) {
+	imagesClient := compute.NewImagesClientWithBaseURI(g.baseURI, subscriptionID)
+	azure.SetAutoRestClientDefaults(&imagesClient.Client, g.authorizer)
+	managedImage, err := imagesClient.Get(ctx, resourceGroup, image, "")
+	if err != nil {
+		return ImageFeatures{}, err
+	}
+	if managedImage.ImageProperties == nil {
+		return ImageFeatures{}, nil
+	}
+	return ImageFeatures{HyperVGeneration: string(managedImage.HyperVGeneration)}, nil
+}
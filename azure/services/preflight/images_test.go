@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+type fakeImageGetter struct {
+	features map[string]ImageFeatures
+}
+
+func (g fakeImageGetter) get(name string) (ImageFeatures, error) {
+	features, ok := g.features[name]
+	if !ok {
+		return ImageFeatures{}, errors.Errorf("image %s not found", name)
+	}
+	return features, nil
+}
+
+func (g fakeImageGetter) MarketplaceImageFeatures(_ context.Context, _, _, _, sku, _ string) (ImageFeatures, error) {
+	return g.get(sku)
+}
+
+func (g fakeImageGetter) GalleryImageFeatures(_ context.Context, _, _, _, image string) (ImageFeatures, error) {
+	return g.get(image)
+}
+
+func (g fakeImageGetter) CommunityGalleryImageFeatures(_ context.Context, _, _, image string) (ImageFeatures, error) {
+	return g.get("community/" + image)
+}
+
+func (g fakeImageGetter) ManagedImageFeatures(_ context.Context, _, _, image string) (ImageFeatures, error) {
+	return g.get(image)
+}
+
+func TestGetImageFeatures(t *testing.T) {
+	getter := fakeImageGetter{features: map[string]ImageFeatures{
+		"ubuntu-2204-gen2":        {HyperVGeneration: "V2"},
+		"ubuntu-2204-cvm":         {HyperVGeneration: "V2", SecurityType: "ConfidentialVmSupported"},
+		"gallery-image":           {HyperVGeneration: "V2", SecurityType: "TrustedLaunchAndConfidentialVmSupported"},
+		"community/gallery-image": {HyperVGeneration: "V1"},
+		"managed-image":           {HyperVGeneration: "V1"},
+	}}
+
+	tests := []struct {
+		name          string
+		image         *infrav1.Image
+		expected      ImageFeatures
+		expectedError string
+	}{
+		{
+			name:     "default image",
+			expected: ImageFeatures{HyperVGeneration: "V1"},
+		},
+		{
+			name: "marketplace image",
+			image: &infrav1.Image{Marketplace: &infrav1.AzureMarketplaceImage{
+				ImagePlan: infrav1.ImagePlan{Publisher: "cncf-upstream", Offer: "capi", SKU: "ubuntu-2204-gen2"},
+				Version:   "latest",
+			}},
+			expected: ImageFeatures{HyperVGeneration: "V2"},
+		},
+		{
+			name: "confidential VM marketplace image",
+			image: &infrav1.Image{Marketplace: &infrav1.AzureMarketplaceImage{
+				ImagePlan: infrav1.ImagePlan{Publisher: "Canonical", Offer: "0001-com-ubuntu-confidential-vm-jammy", SKU: "ubuntu-2204-cvm"},
+				Version:   "latest",
+			}},
+			expected: ImageFeatures{HyperVGeneration: "V2", SecurityType: "ConfidentialVmSupported"},
+		},
+		{
+			name: "compute gallery image",
+			image: &infrav1.Image{ComputeGallery: &infrav1.AzureComputeGalleryImage{
+				Gallery:        "my-gallery",
+				Name:           "gallery-image",
+				Version:        "1.0.0",
+				SubscriptionID: ptr.To("123"),
+				ResourceGroup:  ptr.To("my-rg"),
+			}},
+			expected: ImageFeatures{HyperVGeneration: "V2", SecurityType: "TrustedLaunchAndConfidentialVmSupported"},
+		},
+		{
+			name: "community gallery image",
+			image: &infrav1.Image{ComputeGallery: &infrav1.AzureComputeGalleryImage{
+				Gallery: "community-gallery",
+				Name:    "gallery-image",
+				Version: "1.0.0",
+			}},
+			expected: ImageFeatures{HyperVGeneration: "V1"},
+		},
+		{
+			name:     "gallery image version ID",
+			image:    &infrav1.Image{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/galleries/my-gallery/images/gallery-image/versions/1.0.0")},
+			expected: ImageFeatures{HyperVGeneration: "V2", SecurityType: "TrustedLaunchAndConfidentialVmSupported"},
+		},
+		{
+			name:     "managed image ID",
+			image:    &infrav1.Image{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/images/managed-image")},
+			expected: ImageFeatures{HyperVGeneration: "V1"},
+		},
+		{
+			name:     "ID of another resource type",
+			image:    &infrav1.Image{ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/snapshots/my-snapshot")},
+			expected: ImageFeatures{},
+		},
+		{
+			name:          "invalid image ID",
+			image:         &infrav1.Image{ID: ptr.To("fake-image-id")},
+			expectedError: "failed to parse image ID fake-image-id",
+		},
+		{
+			name: "image not found",
+			image: &infrav1.Image{SharedGallery: &infrav1.AzureSharedGalleryImage{
+				SubscriptionID: "123",
+				ResourceGroup:  "my-rg",
+				Gallery:        "my-gallery",
+				Name:           "unknown-image",
+				Version:        "1.0.0",
+			}},
+			expectedError: "image unknown-image not found",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			features, err := getImageFeatures(context.TODO(), tc.image, "test-location", getter)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedError)))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(features).To(Equal(tc.expected))
+		})
+	}
+}
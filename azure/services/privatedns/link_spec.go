@@ -25,6 +25,7 @@ import (
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 )
 
 // LinkSpec defines the specification for a virtual network link in a private DNS zone.
@@ -72,10 +73,10 @@ func (s LinkSpec) Parameters(ctx context.Context, existing interface{}) (params
 			RegistrationEnabled: ptr.To(false),
 		},
 		Location: ptr.To(azure.Global),
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
 			ClusterName: s.ClusterName,
 			Lifecycle:   infrav1.ResourceLifecycleOwned,
-			Additional:  s.AdditionalTags,
+			ClusterTags: s.AdditionalTags,
 		})),
 	}, nil
 }
@@ -25,6 +25,7 @@ import (
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 )
 
 // ZoneSpec defines the specification for private dns zone.
@@ -62,10 +63,10 @@ func (s ZoneSpec) Parameters(ctx context.Context, existing interface{}) (params
 
 	return privatedns.PrivateZone{
 		Location: ptr.To(azure.Global),
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
 			ClusterName: s.ClusterName,
 			Lifecycle:   infrav1.ResourceLifecycleOwned,
-			Additional:  s.AdditionalTags,
+			ClusterTags: s.AdditionalTags,
 		})),
 	}, nil
 }
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package publicipprefixes
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
+	"github.com/pkg/errors"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+const serviceName = "publicipprefixes"
+
+// publicIPsRemovalRequeue is how long to wait for the public IPs allocated from a prefix to be deleted before
+// deleting the prefix again.
+const publicIPsRemovalRequeue = 15 * time.Second
+
+// PublicIPPrefixScope defines the scope interface for a public IP prefix service.
+type PublicIPPrefixScope interface {
+	azure.Authorizer
+	azure.AsyncStatusUpdater
+	PublicIPPrefixSpecs() []azure.ResourceSpecGetter
+}
+
+// Service provides operations on Azure resources.
+type Service struct {
+	Scope PublicIPPrefixScope
+	async.Reconciler
+	async.Getter
+}
+
+// New creates a new service.
+func New(scope PublicIPPrefixScope) *Service {
+	client := newClient(scope)
+	return &Service{
+		Scope:      scope,
+		Getter:     client,
+		Reconciler: async.New(scope, client, client),
+	}
+}
+
+// Name returns the service name.
+func (s *Service) Name() string {
+	return serviceName
+}
+
+// Reconcile idempotently creates or updates a public IP prefix.
+func (s *Service) Reconcile(ctx context.Context) error {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "publicipprefixes.Service.Reconcile")
+	defer done()
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureServiceReconcileTimeout)
+	defer cancel()
+
+	specs := s.Scope.PublicIPPrefixSpecs()
+	if len(specs) == 0 {
+		return nil
+	}
+
+	// We go through the list of PublicIPPrefixSpecs to reconcile each one, independently of the result of the previous one.
+	// If multiple errors occur, we return the most pressing one.
+	//  Order of precedence (highest -> lowest) is: error that is not an operationNotDoneError (i.e. error creating) -> operationNotDoneError (i.e. creating in progress) -> no error (i.e. created)
+	var result error
+	for _, prefixSpec := range specs {
+		if _, err := s.CreateOrUpdateResource(ctx, prefixSpec, serviceName); err != nil {
+			if !azure.IsOperationNotDoneError(err) || result == nil {
+				result = err
+			}
+		}
+	}
+
+	s.Scope.UpdatePutStatus(infrav1.PublicIPPrefixesReadyCondition, serviceName, result)
+	return result
+}
+
+// Delete deletes the public IP prefixes with the provided scope. A prefix can't be deleted while public IPs are
+// allocated from it, so its deletion waits for the public IPs to be deleted first.
+func (s *Service) Delete(ctx context.Context) error {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "publicipprefixes.Service.Delete")
+	defer done()
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureServiceReconcileTimeout)
+	defer cancel()
+
+	specs := s.Scope.PublicIPPrefixSpecs()
+	if len(specs) == 0 {
+		return nil
+	}
+
+	// We go through the list of PublicIPPrefixSpecs to delete each one, independently of the result of the previous one.
+	// If multiple errors occur, we return the most pressing one.
+	//  Order of precedence (highest -> lowest) is: error that is not an operationNotDoneError (i.e. error deleting) -> operationNotDoneError (i.e. deleting in progress) -> no error (i.e. deleted)
+	var result error
+	for _, prefixSpec := range specs {
+		if err := s.deletePrefix(ctx, prefixSpec); err != nil {
+			if !azure.IsOperationNotDoneError(err) || result == nil {
+				result = err
+			}
+		}
+	}
+
+	s.Scope.UpdateDeleteStatus(infrav1.PublicIPPrefixesReadyCondition, serviceName, result)
+	return result
+}
+
+// deletePrefix deletes a public IP prefix once no public IP is allocated from it anymore.
+func (s *Service) deletePrefix(ctx context.Context, spec azure.ResourceSpecGetter) error {
+	existing, err := s.Get(ctx, spec)
+	if azure.ResourceNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to get public IP prefix %s", spec.ResourceName())
+	}
+	prefix, ok := existing.(network.PublicIPPrefix)
+	if !ok {
+		return errors.Errorf("%T is not a network.PublicIPPrefix", existing)
+	}
+	if prefix.PublicIPPrefixPropertiesFormat != nil && prefix.PublicIPAddresses != nil && len(*prefix.PublicIPAddresses) > 0 {
+		return azure.WithTransientError(errors.Errorf("public IP prefix %s still has %d public IPs allocated", spec.ResourceName(), len(*prefix.PublicIPAddresses)), publicIPsRemovalRequeue)
+	}
+	return s.DeleteResource(ctx, spec, serviceName)
+}
+
+// IsManaged returns always returns true as CAPZ does not support BYO public IP prefixes.
+func (s *Service) IsManaged(ctx context.Context) (bool, error) {
+	return true, nil
+}
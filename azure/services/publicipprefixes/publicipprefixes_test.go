@@ -0,0 +1,215 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package publicipprefixes
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async/mock_async"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicipprefixes/mock_publicipprefixes"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+var (
+	fakePublicIPPrefixSpec = PublicIPPrefixSpec{
+		Name:           "my-prefix",
+		ResourceGroup:  "my-rg",
+		ClusterName:    "my-cluster",
+		Location:       "centralIndia",
+		PrefixLength:   30,
+		FailureDomains: []string{"1", "2", "3"},
+		AdditionalTags: infrav1.Tags{
+			"foo": "bar",
+		},
+	}
+
+	internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+	notFoundError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusNotFound}, "Not Found")
+)
+
+func TestReconcilePublicIPPrefix(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_publicipprefixes.MockPublicIPPrefixScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+	}{
+		{
+			name:          "noop if no public IP prefix specs are found",
+			expectedError: "",
+			expect: func(s *mock_publicipprefixes.MockPublicIPPrefixScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.PublicIPPrefixSpecs().Return([]azure.ResourceSpecGetter{})
+			},
+		},
+		{
+			name:          "successfully create a public IP prefix",
+			expectedError: "",
+			expect: func(s *mock_publicipprefixes.MockPublicIPPrefixScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.PublicIPPrefixSpecs().Return([]azure.ResourceSpecGetter{&fakePublicIPPrefixSpec})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakePublicIPPrefixSpec, serviceName).Return(nil, nil)
+				s.UpdatePutStatus(infrav1.PublicIPPrefixesReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "fail to create a public IP prefix",
+			expectedError: "#: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_publicipprefixes.MockPublicIPPrefixScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.PublicIPPrefixSpecs().Return([]azure.ResourceSpecGetter{&fakePublicIPPrefixSpec})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakePublicIPPrefixSpec, serviceName).Return(nil, internalError)
+				s.UpdatePutStatus(infrav1.PublicIPPrefixesReadyCondition, serviceName, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			scopeMock := mock_publicipprefixes.NewMockPublicIPPrefixScope(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT())
+
+			s := &Service{
+				Scope:      scopeMock,
+				Reconciler: asyncMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestDeletePublicIPPrefix(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_publicipprefixes.MockPublicIPPrefixScopeMockRecorder, g *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+	}{
+		{
+			name:          "noop if no public IP prefix specs are found",
+			expectedError: "",
+			expect: func(s *mock_publicipprefixes.MockPublicIPPrefixScopeMockRecorder, g *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.PublicIPPrefixSpecs().Return([]azure.ResourceSpecGetter{})
+			},
+		},
+		{
+			name:          "successfully delete a public IP prefix without public IPs",
+			expectedError: "",
+			expect: func(s *mock_publicipprefixes.MockPublicIPPrefixScopeMockRecorder, g *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.PublicIPPrefixSpecs().Return([]azure.ResourceSpecGetter{&fakePublicIPPrefixSpec})
+				g.Get(gomockinternal.AContext(), &fakePublicIPPrefixSpec).Return(network.PublicIPPrefix{
+					Name:                           ptr.To("my-prefix"),
+					PublicIPPrefixPropertiesFormat: &network.PublicIPPrefixPropertiesFormat{},
+				}, nil)
+				r.DeleteResource(gomockinternal.AContext(), &fakePublicIPPrefixSpec, serviceName).Return(nil)
+				s.UpdateDeleteStatus(infrav1.PublicIPPrefixesReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "noop if the public IP prefix is already deleted",
+			expectedError: "",
+			expect: func(s *mock_publicipprefixes.MockPublicIPPrefixScopeMockRecorder, g *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.PublicIPPrefixSpecs().Return([]azure.ResourceSpecGetter{&fakePublicIPPrefixSpec})
+				g.Get(gomockinternal.AContext(), &fakePublicIPPrefixSpec).Return(nil, notFoundError)
+				s.UpdateDeleteStatus(infrav1.PublicIPPrefixesReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "wait for the public IPs of the prefix to be deleted",
+			expectedError: "public IP prefix my-prefix still has 2 public IPs allocated",
+			expect: func(s *mock_publicipprefixes.MockPublicIPPrefixScopeMockRecorder, g *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.PublicIPPrefixSpecs().Return([]azure.ResourceSpecGetter{&fakePublicIPPrefixSpec})
+				g.Get(gomockinternal.AContext(), &fakePublicIPPrefixSpec).Return(network.PublicIPPrefix{
+					Name: ptr.To("my-prefix"),
+					PublicIPPrefixPropertiesFormat: &network.PublicIPPrefixPropertiesFormat{
+						PublicIPAddresses: &[]network.ReferencedPublicIPAddress{
+							{ID: ptr.To("pip-1")},
+							{ID: ptr.To("pip-2")},
+						},
+					},
+				}, nil)
+				s.UpdateDeleteStatus(infrav1.PublicIPPrefixesReadyCondition, serviceName, gomock.Any())
+			},
+		},
+		{
+			name:          "fail to get the public IP prefix",
+			expectedError: "failed to get public IP prefix my-prefix: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_publicipprefixes.MockPublicIPPrefixScopeMockRecorder, g *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.PublicIPPrefixSpecs().Return([]azure.ResourceSpecGetter{&fakePublicIPPrefixSpec})
+				g.Get(gomockinternal.AContext(), &fakePublicIPPrefixSpec).Return(nil, internalError)
+				s.UpdateDeleteStatus(infrav1.PublicIPPrefixesReadyCondition, serviceName, gomock.Any())
+			},
+		},
+		{
+			name:          "fail to delete the public IP prefix",
+			expectedError: "#: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_publicipprefixes.MockPublicIPPrefixScopeMockRecorder, g *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.PublicIPPrefixSpecs().Return([]azure.ResourceSpecGetter{&fakePublicIPPrefixSpec})
+				g.Get(gomockinternal.AContext(), &fakePublicIPPrefixSpec).Return(network.PublicIPPrefix{Name: ptr.To("my-prefix")}, nil)
+				r.DeleteResource(gomockinternal.AContext(), &fakePublicIPPrefixSpec, serviceName).Return(internalError)
+				s.UpdateDeleteStatus(infrav1.PublicIPPrefixesReadyCondition, serviceName, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			scopeMock := mock_publicipprefixes.NewMockPublicIPPrefixScope(mockCtrl)
+			getterMock := mock_async.NewMockGetter(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), getterMock.EXPECT(), asyncMock.EXPECT())
+
+			s := &Service{
+				Scope:      scopeMock,
+				Getter:     getterMock,
+				Reconciler: asyncMock,
+			}
+
+			err := s.Delete(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedError)))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package publicipprefixes
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
+)
+
+// PublicIPPrefixSpec defines the specification for a public IP prefix.
+type PublicIPPrefixSpec struct {
+	Name             string
+	ResourceGroup    string
+	ClusterName      string
+	Location         string
+	ExtendedLocation *infrav1.ExtendedLocationSpec
+	PrefixLength     int32
+	FailureDomains   []string
+	AdditionalTags   infrav1.Tags
+}
+
+// ResourceName returns the name of the public IP prefix.
+func (s *PublicIPPrefixSpec) ResourceName() string {
+	return s.Name
+}
+
+// ResourceGroupName returns the name of the resource group.
+func (s *PublicIPPrefixSpec) ResourceGroupName() string {
+	return s.ResourceGroup
+}
+
+// OwnerResourceName is a no-op for public IP prefixes.
+func (s *PublicIPPrefixSpec) OwnerResourceName() string {
+	return ""
+}
+
+// Parameters returns the parameters for the public IP prefix.
+func (s *PublicIPPrefixSpec) Parameters(ctx context.Context, existing interface{}) (params interface{}, err error) {
+	if existing != nil {
+		if _, ok := existing.(network.PublicIPPrefix); !ok {
+			return nil, errors.Errorf("%T is not a network.PublicIPPrefix", existing)
+		}
+		// public IP prefix already exists, its length can't be changed.
+		return nil, nil
+	}
+
+	return network.PublicIPPrefix{
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
+			ClusterName: s.ClusterName,
+			Lifecycle:   infrav1.ResourceLifecycleOwned,
+			Name:        ptr.To(s.Name),
+			ClusterTags: s.AdditionalTags,
+		})),
+		// The public IPs allocated from a prefix must have the same SKU, zones and extended location as the prefix.
+		Sku:              &network.PublicIPPrefixSku{Name: network.PublicIPPrefixSkuNameStandard},
+		Name:             ptr.To(s.Name),
+		Location:         ptr.To(s.Location),
+		ExtendedLocation: converters.ExtendedLocationToNetworkSDK(s.ExtendedLocation),
+		PublicIPPrefixPropertiesFormat: &network.PublicIPPrefixPropertiesFormat{
+			PublicIPAddressVersion: network.IPVersionIPv4,
+			PrefixLength:           ptr.To(s.PrefixLength),
+		},
+		Zones: &s.FailureDomains,
+	}, nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package publicipprefixes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+func TestParameters(t *testing.T) {
+	testCases := []struct {
+		name          string
+		existing      interface{}
+		spec          PublicIPPrefixSpec
+		expected      interface{}
+		expectedError string
+	}{
+		{
+			name:     "noop if public IP prefix exists",
+			existing: network.PublicIPPrefix{Name: ptr.To("my-prefix")},
+			spec:     fakePublicIPPrefixSpec,
+			expected: nil,
+		},
+		{
+			name:     "public IP prefix",
+			existing: nil,
+			spec:     fakePublicIPPrefixSpec,
+			expected: network.PublicIPPrefix{
+				Name:     ptr.To("my-prefix"),
+				Sku:      &network.PublicIPPrefixSku{Name: network.PublicIPPrefixSkuNameStandard},
+				Location: ptr.To("centralIndia"),
+				Tags: map[string]*string{
+					"Name": ptr.To("my-prefix"),
+					"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+					"foo": ptr.To("bar"),
+				},
+				PublicIPPrefixPropertiesFormat: &network.PublicIPPrefixPropertiesFormat{
+					PublicIPAddressVersion: network.IPVersionIPv4,
+					PrefixLength:           ptr.To[int32](30),
+				},
+				Zones: &[]string{"1", "2", "3"},
+			},
+		},
+		{
+			name:     "public IP prefix in an edge zone",
+			existing: nil,
+			spec: PublicIPPrefixSpec{
+				Name:             "my-prefix",
+				ResourceGroup:    "my-rg",
+				ClusterName:      "my-cluster",
+				Location:         "westus",
+				ExtendedLocation: &infrav1.ExtendedLocationSpec{Name: "microsoftlosangeles1", Type: "EdgeZone"},
+				PrefixLength:     30,
+			},
+			expected: network.PublicIPPrefix{
+				Name:     ptr.To("my-prefix"),
+				Sku:      &network.PublicIPPrefixSku{Name: network.PublicIPPrefixSkuNameStandard},
+				Location: ptr.To("westus"),
+				ExtendedLocation: &network.ExtendedLocation{
+					Name: ptr.To("microsoftlosangeles1"),
+					Type: network.ExtendedLocationTypesEdgeZone,
+				},
+				Tags: map[string]*string{
+					"Name": ptr.To("my-prefix"),
+					"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+				},
+				PublicIPPrefixPropertiesFormat: &network.PublicIPPrefixPropertiesFormat{
+					PublicIPAddressVersion: network.IPVersionIPv4,
+					PrefixLength:           ptr.To[int32](30),
+				},
+				Zones: ptr.To([]string(nil)),
+			},
+		},
+		{
+			name:          "existing is not a public IP prefix",
+			existing:      "not a prefix",
+			spec:          fakePublicIPPrefixSpec,
+			expected:      nil,
+			expectedError: "string is not a network.PublicIPPrefix",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			result, err := tc.spec.Parameters(context.TODO(), tc.existing)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			if tc.expected == nil {
+				g.Expect(result).To(BeNil())
+			} else {
+				g.Expect(result).To(Equal(tc.expected))
+			}
+		})
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 )
 
 // PublicIPSpec defines the specification for a Public IP.
@@ -37,8 +38,10 @@ type PublicIPSpec struct {
 	Location         string
 	ExtendedLocation *infrav1.ExtendedLocationSpec
 	FailureDomains   []string
+	Zones            []string
 	AdditionalTags   infrav1.Tags
 	IPTags           []infrav1.IPTag
+	PublicIPPrefixID string
 }
 
 // ResourceName returns the name of the public IP.
@@ -80,12 +83,23 @@ func (s *PublicIPSpec) Parameters(ctx context.Context, existing interface{}) (pa
 		}
 	}
 
+	// the public IP is zone-redundant across the failure domains of the cluster unless zones are specified
+	zones := s.Zones
+	if len(zones) == 0 {
+		zones = s.FailureDomains
+	}
+
+	var publicIPPrefix *network.SubResource
+	if s.PublicIPPrefixID != "" {
+		publicIPPrefix = &network.SubResource{ID: ptr.To(s.PublicIPPrefixID)}
+	}
+
 	return network.PublicIPAddress{
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
 			ClusterName: s.ClusterName,
 			Lifecycle:   infrav1.ResourceLifecycleOwned,
 			Name:        ptr.To(s.Name),
-			Additional:  s.AdditionalTags,
+			ClusterTags: s.AdditionalTags,
 		})),
 		Sku:              &network.PublicIPAddressSku{Name: network.PublicIPAddressSkuNameStandard},
 		Name:             ptr.To(s.Name),
@@ -96,7 +110,8 @@ func (s *PublicIPSpec) Parameters(ctx context.Context, existing interface{}) (pa
 			PublicIPAllocationMethod: network.IPAllocationMethodStatic,
 			DNSSettings:              dnsSettings,
 			IPTags:                   converters.IPTagsToSDK(s.IPTags),
+			PublicIPPrefix:           publicIPPrefix,
 		},
-		Zones: &s.FailureDomains,
+		Zones: &zones,
 	}, nil
 }
@@ -86,6 +86,36 @@ var (
 		Zones: &[]string{"failure-domain-id-1", "failure-domain-id-2", "failure-domain-id-3"},
 	}
 
+	fakePublicIPSpecWithPrefix = PublicIPSpec{
+		Name:        "my-publicip-3",
+		Location:    "centralIndia",
+		ClusterName: "my-cluster",
+		AdditionalTags: infrav1.Tags{
+			"foo": "bar",
+		},
+		FailureDomains:   []string{"failure-domain-id-1", "failure-domain-id-2", "failure-domain-id-3"},
+		PublicIPPrefixID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPPrefixes/my-prefix",
+	}
+
+	fakePublicIPWithPrefix = network.PublicIPAddress{
+		Name:     ptr.To("my-publicip-3"),
+		Sku:      &network.PublicIPAddressSku{Name: network.PublicIPAddressSkuNameStandard},
+		Location: ptr.To("centralIndia"),
+		Tags: map[string]*string{
+			"Name": ptr.To("my-publicip-3"),
+			"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+			"foo": ptr.To("bar"),
+		},
+		PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{
+			PublicIPAddressVersion:   network.IPVersionIPv4,
+			PublicIPAllocationMethod: network.IPAllocationMethodStatic,
+			PublicIPPrefix: &network.SubResource{
+				ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPPrefixes/my-prefix"),
+			},
+		},
+		Zones: &[]string{"failure-domain-id-1", "failure-domain-id-2", "failure-domain-id-3"},
+	}
+
 	fakePublicIPIpv6 = network.PublicIPAddress{
 		Name:     ptr.To("my-publicip-ipv6"),
 		Sku:      &network.PublicIPAddressSku{Name: network.PublicIPAddressSkuNameStandard},
@@ -136,6 +166,92 @@ func TestParameters(t *testing.T) {
 			expected:      fakePublicIPWithoutDNS,
 			expectedError: "",
 		},
+		{
+			name:          "public ipv4 address allocated from a public IP prefix",
+			existing:      nil,
+			spec:          fakePublicIPSpecWithPrefix,
+			expected:      fakePublicIPWithPrefix,
+			expectedError: "",
+		},
+		{
+			name:     "zonal public ipv4 address",
+			existing: nil,
+			spec: PublicIPSpec{
+				Name:           "my-publicip-zonal",
+				Location:       "centralIndia",
+				ClusterName:    "my-cluster",
+				FailureDomains: []string{"1", "2", "3"},
+				Zones:          []string{"2"},
+			},
+			expected: network.PublicIPAddress{
+				Name:     ptr.To("my-publicip-zonal"),
+				Sku:      &network.PublicIPAddressSku{Name: network.PublicIPAddressSkuNameStandard},
+				Location: ptr.To("centralIndia"),
+				Tags: map[string]*string{
+					"Name": ptr.To("my-publicip-zonal"),
+					"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+				},
+				PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{
+					PublicIPAddressVersion:   network.IPVersionIPv4,
+					PublicIPAllocationMethod: network.IPAllocationMethodStatic,
+				},
+				Zones: &[]string{"2"},
+			},
+			expectedError: "",
+		},
+		{
+			name:     "non-zonal public ipv4 address in a location without availability zones",
+			existing: nil,
+			spec: PublicIPSpec{
+				Name:        "my-publicip-nonzonal",
+				Location:    "westcentralus",
+				ClusterName: "my-cluster",
+			},
+			expected: network.PublicIPAddress{
+				Name:     ptr.To("my-publicip-nonzonal"),
+				Sku:      &network.PublicIPAddressSku{Name: network.PublicIPAddressSkuNameStandard},
+				Location: ptr.To("westcentralus"),
+				Tags: map[string]*string{
+					"Name": ptr.To("my-publicip-nonzonal"),
+					"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+				},
+				PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{
+					PublicIPAddressVersion:   network.IPVersionIPv4,
+					PublicIPAllocationMethod: network.IPAllocationMethodStatic,
+				},
+				Zones: ptr.To([]string(nil)),
+			},
+			expectedError: "",
+		},
+		{
+			name:     "public ipv4 address in an edge zone",
+			existing: nil,
+			spec: PublicIPSpec{
+				Name:             "my-publicip-edgezone",
+				Location:         "westus",
+				ExtendedLocation: &infrav1.ExtendedLocationSpec{Name: "microsoftlosangeles1", Type: "EdgeZone"},
+				ClusterName:      "my-cluster",
+			},
+			expected: network.PublicIPAddress{
+				Name:     ptr.To("my-publicip-edgezone"),
+				Sku:      &network.PublicIPAddressSku{Name: network.PublicIPAddressSkuNameStandard},
+				Location: ptr.To("westus"),
+				ExtendedLocation: &network.ExtendedLocation{
+					Name: ptr.To("microsoftlosangeles1"),
+					Type: network.ExtendedLocationTypesEdgeZone,
+				},
+				Tags: map[string]*string{
+					"Name": ptr.To("my-publicip-edgezone"),
+					"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+				},
+				PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{
+					PublicIPAddressVersion:   network.IPVersionIPv4,
+					PublicIPAllocationMethod: network.IPAllocationMethodStatic,
+				},
+				Zones: ptr.To([]string(nil)),
+			},
+			expectedError: "",
+		},
 		{
 			name:          "public ipv6 address with dns",
 			existing:      nil,
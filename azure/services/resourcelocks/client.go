@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcelocks
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2016-09-01/locks"
+	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// azureClient contains the Azure go-sdk Client.
+type azureClient struct {
+	locks locks.ManagementLocksClient
+}
+
+// newClient creates a new management lock client from subscription ID.
+func newClient(auth azure.Authorizer) *azureClient {
+	c := newManagementLocksClient(auth.SubscriptionID(), auth.BaseURI(), auth.Authorizer())
+	return &azureClient{c}
+}
+
+// newManagementLocksClient creates a new management lock client from subscription ID.
+func newManagementLocksClient(subscriptionID string, baseURI string, authorizer autorest.Authorizer) locks.ManagementLocksClient {
+	locksClient := locks.NewManagementLocksClientWithBaseURI(baseURI, subscriptionID)
+	azure.SetAutoRestClientDefaults(&locksClient.Client, authorizer)
+	return locksClient
+}
+
+// Get gets the specified management lock of a resource group.
+func (ac *azureClient) Get(ctx context.Context, spec azure.ResourceSpecGetter) (result interface{}, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "resourcelocks.azureClient.Get")
+	defer done()
+
+	return ac.locks.GetAtResourceGroupLevel(ctx, spec.ResourceGroupName(), spec.ResourceName())
+}
+
+// CreateOrUpdateAsync creates or updates a management lock of a resource group.
+// Creating a management lock is not a long running operation, so we don't ever return a future.
+func (ac *azureClient) CreateOrUpdateAsync(ctx context.Context, spec azure.ResourceSpecGetter, parameters interface{}) (result interface{}, future azureautorest.FutureAPI, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "resourcelocks.azureClient.CreateOrUpdate")
+	defer done()
+
+	lock, ok := parameters.(locks.ManagementLockObject)
+	if !ok {
+		return nil, nil, errors.Errorf("%T is not a locks.ManagementLockObject", parameters)
+	}
+
+	result, err = ac.locks.CreateOrUpdateAtResourceGroupLevel(ctx, spec.ResourceGroupName(), spec.ResourceName(), lock)
+	return result, nil, err
+}
+
+// DeleteAsync deletes the specified management lock of a resource group.
+// Deleting a management lock is not a long running operation, so we don't ever return a future.
+func (ac *azureClient) DeleteAsync(ctx context.Context, spec azure.ResourceSpecGetter) (future azureautorest.FutureAPI, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "resourcelocks.azureClient.Delete")
+	defer done()
+
+	_, err = ac.locks.DeleteAtResourceGroupLevel(ctx, spec.ResourceGroupName(), spec.ResourceName())
+	return nil, err
+}
+
+// IsDone returns true if the long-running operation has completed.
+func (ac *azureClient) IsDone(ctx context.Context, future azureautorest.FutureAPI) (isDone bool, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "resourcelocks.azureClient.IsDone")
+	defer done()
+
+	return future.DoneWithContext(ctx, ac.locks)
+}
+
+// Result fetches the result of a long-running operation future.
+func (ac *azureClient) Result(ctx context.Context, future azureautorest.FutureAPI, futureType string) (result interface{}, err error) {
+	// Result is a no-op for management locks as their operations don't return a future.
+	return nil, nil
+}
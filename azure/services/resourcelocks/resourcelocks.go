@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcelocks
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// ServiceName is the name of this service.
+const ServiceName = "resourcelocks"
+
+// ResourceLockScope defines the scope interface for a resource lock service.
+type ResourceLockScope interface {
+	azure.Authorizer
+	azure.AsyncStatusUpdater
+	ResourceLockSpecs() []azure.ResourceSpecGetter
+	ResourceGroupDeletionProtection() bool
+	ResourceGroupLockRemovalAllowed() bool
+}
+
+// Service provides operations on Azure resources.
+type Service struct {
+	Scope ResourceLockScope
+	async.Reconciler
+}
+
+// New creates a new service.
+func New(scope ResourceLockScope) *Service {
+	client := newClient(scope)
+	return &Service{
+		Scope:      scope,
+		Reconciler: async.New(scope, client, client),
+	}
+}
+
+// Name returns the service name.
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// Reconcile idempotently creates the management lock protecting the resource group from deletion when the protection
+// is enabled, and removes it when the protection was disabled or its removal was allowed.
+func (s *Service) Reconcile(ctx context.Context) error {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "resourcelocks.Service.Reconcile")
+	defer done()
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureServiceReconcileTimeout)
+	defer cancel()
+
+	specs := s.Scope.ResourceLockSpecs()
+	if len(specs) == 0 {
+		return nil
+	}
+
+	// The lock is removed as soon as its removal is allowed so that the machines of the cluster, which are deleted
+	// before the AzureCluster, can be deleted.
+	protected := s.Scope.ResourceGroupDeletionProtection() && !s.Scope.ResourceGroupLockRemovalAllowed()
+	var result error
+	for _, lockSpec := range specs {
+		var err error
+		if protected {
+			_, err = s.CreateOrUpdateResource(ctx, lockSpec, ServiceName)
+		} else {
+			err = s.DeleteResource(ctx, lockSpec, ServiceName)
+		}
+		if err != nil {
+			if !azure.IsOperationNotDoneError(err) || result == nil {
+				result = err
+			}
+		}
+	}
+
+	if protected {
+		s.Scope.UpdatePutStatus(infrav1.ResourceGroupLockReadyCondition, ServiceName, result)
+	} else {
+		s.Scope.UpdateDeleteStatus(infrav1.ResourceGroupLockReadyCondition, ServiceName, result)
+	}
+	return result
+}
+
+// Delete removes the management lock protecting the resource group from deletion. It returns an error without removing
+// the lock unless its removal was explicitly allowed with the capz.io/remove-resource-group-lock annotation.
+func (s *Service) Delete(ctx context.Context) error {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "resourcelocks.Service.Delete")
+	defer done()
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureServiceReconcileTimeout)
+	defer cancel()
+
+	specs := s.Scope.ResourceLockSpecs()
+	if len(specs) == 0 {
+		return nil
+	}
+
+	if !s.Scope.ResourceGroupLockRemovalAllowed() {
+		err := errors.Errorf("the resource group is protected from deletion, set the %s annotation to \"true\" to remove its lock and delete the cluster", infrav1.RemoveResourceGroupLockAnnotation)
+		s.Scope.UpdateDeleteStatus(infrav1.ResourceGroupLockReadyCondition, ServiceName, err)
+		return err
+	}
+
+	var result error
+	for _, lockSpec := range specs {
+		if err := s.DeleteResource(ctx, lockSpec, ServiceName); err != nil {
+			if !azure.IsOperationNotDoneError(err) || result == nil {
+				result = err
+			}
+		}
+	}
+
+	s.Scope.UpdateDeleteStatus(infrav1.ResourceGroupLockReadyCondition, ServiceName, result)
+	return result
+}
+
+// IsManaged returns always returns true as the resource lock is only created by CAPZ.
+func (s *Service) IsManaged(ctx context.Context) (bool, error) {
+	return true, nil
+}
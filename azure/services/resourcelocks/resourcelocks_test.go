@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcelocks
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async/mock_async"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourcelocks/mock_resourcelocks"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+var (
+	fakeResourceLockSpec = ResourceLockSpec{
+		Name:          "my-cluster-rg-lock",
+		ResourceGroup: "my-rg",
+		Notes:         "Protects the resource group of cluster my-cluster from deletion. Managed by CAPZ.",
+	}
+
+	internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+)
+
+func TestReconcileResourceLock(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_resourcelocks.MockResourceLockScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+	}{
+		{
+			name:          "noop if no resource lock specs are found",
+			expectedError: "",
+			expect: func(s *mock_resourcelocks.MockResourceLockScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.ResourceLockSpecs().Return([]azure.ResourceSpecGetter{})
+			},
+		},
+		{
+			name:          "successfully create the resource group lock",
+			expectedError: "",
+			expect: func(s *mock_resourcelocks.MockResourceLockScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.ResourceLockSpecs().Return([]azure.ResourceSpecGetter{&fakeResourceLockSpec})
+				s.ResourceGroupDeletionProtection().Return(true)
+				s.ResourceGroupLockRemovalAllowed().Return(false)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeResourceLockSpec, ServiceName).Return(nil, nil)
+				s.UpdatePutStatus(infrav1.ResourceGroupLockReadyCondition, ServiceName, nil)
+			},
+		},
+		{
+			name:          "fail to create the resource group lock",
+			expectedError: "#: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_resourcelocks.MockResourceLockScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.ResourceLockSpecs().Return([]azure.ResourceSpecGetter{&fakeResourceLockSpec})
+				s.ResourceGroupDeletionProtection().Return(true)
+				s.ResourceGroupLockRemovalAllowed().Return(false)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeResourceLockSpec, ServiceName).Return(nil, internalError)
+				s.UpdatePutStatus(infrav1.ResourceGroupLockReadyCondition, ServiceName, internalError)
+			},
+		},
+		{
+			name:          "remove the resource group lock when its removal is allowed",
+			expectedError: "",
+			expect: func(s *mock_resourcelocks.MockResourceLockScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.ResourceLockSpecs().Return([]azure.ResourceSpecGetter{&fakeResourceLockSpec})
+				s.ResourceGroupDeletionProtection().Return(true)
+				s.ResourceGroupLockRemovalAllowed().Return(true)
+				r.DeleteResource(gomockinternal.AContext(), &fakeResourceLockSpec, ServiceName).Return(nil)
+				s.UpdateDeleteStatus(infrav1.ResourceGroupLockReadyCondition, ServiceName, nil)
+			},
+		},
+		{
+			name:          "remove the resource group lock when the protection is disabled",
+			expectedError: "",
+			expect: func(s *mock_resourcelocks.MockResourceLockScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.ResourceLockSpecs().Return([]azure.ResourceSpecGetter{&fakeResourceLockSpec})
+				s.ResourceGroupDeletionProtection().Return(false)
+				r.DeleteResource(gomockinternal.AContext(), &fakeResourceLockSpec, ServiceName).Return(nil)
+				s.UpdateDeleteStatus(infrav1.ResourceGroupLockReadyCondition, ServiceName, nil)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			scopeMock := mock_resourcelocks.NewMockResourceLockScope(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT())
+
+			s := &Service{
+				Scope:      scopeMock,
+				Reconciler: asyncMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestDeleteResourceLock(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_resourcelocks.MockResourceLockScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+	}{
+		{
+			name:          "noop if no resource lock specs are found",
+			expectedError: "",
+			expect: func(s *mock_resourcelocks.MockResourceLockScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.ResourceLockSpecs().Return([]azure.ResourceSpecGetter{})
+			},
+		},
+		{
+			name:          "refuse to remove the resource group lock without the annotation",
+			expectedError: "the resource group is protected from deletion, set the capz.io/remove-resource-group-lock annotation to \"true\" to remove its lock and delete the cluster",
+			expect: func(s *mock_resourcelocks.MockResourceLockScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.ResourceLockSpecs().Return([]azure.ResourceSpecGetter{&fakeResourceLockSpec})
+				s.ResourceGroupLockRemovalAllowed().Return(false)
+				s.UpdateDeleteStatus(infrav1.ResourceGroupLockReadyCondition, ServiceName, gomock.Any())
+			},
+		},
+		{
+			name:          "successfully remove the resource group lock",
+			expectedError: "",
+			expect: func(s *mock_resourcelocks.MockResourceLockScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.ResourceLockSpecs().Return([]azure.ResourceSpecGetter{&fakeResourceLockSpec})
+				s.ResourceGroupLockRemovalAllowed().Return(true)
+				r.DeleteResource(gomockinternal.AContext(), &fakeResourceLockSpec, ServiceName).Return(nil)
+				s.UpdateDeleteStatus(infrav1.ResourceGroupLockReadyCondition, ServiceName, nil)
+			},
+		},
+		{
+			name:          "fail to remove the resource group lock",
+			expectedError: "#: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_resourcelocks.MockResourceLockScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.ResourceLockSpecs().Return([]azure.ResourceSpecGetter{&fakeResourceLockSpec})
+				s.ResourceGroupLockRemovalAllowed().Return(true)
+				r.DeleteResource(gomockinternal.AContext(), &fakeResourceLockSpec, ServiceName).Return(internalError)
+				s.UpdateDeleteStatus(infrav1.ResourceGroupLockReadyCondition, ServiceName, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			scopeMock := mock_resourcelocks.NewMockResourceLockScope(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT())
+
+			s := &Service{
+				Scope:      scopeMock,
+				Reconciler: asyncMock,
+			}
+
+			err := s.Delete(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
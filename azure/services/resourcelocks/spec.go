@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcelocks
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2016-09-01/locks"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+)
+
+// ResourceLockSpec defines the specification for a CanNotDelete management lock protecting a resource group.
+type ResourceLockSpec struct {
+	Name          string
+	ResourceGroup string
+	Notes         string
+}
+
+// ResourceName returns the name of the management lock.
+func (s *ResourceLockSpec) ResourceName() string {
+	return s.Name
+}
+
+// ResourceGroupName returns the name of the resource group.
+func (s *ResourceLockSpec) ResourceGroupName() string {
+	return s.ResourceGroup
+}
+
+// OwnerResourceName is a no-op for management locks.
+func (s *ResourceLockSpec) OwnerResourceName() string {
+	return ""
+}
+
+// Parameters returns the parameters for the management lock.
+func (s *ResourceLockSpec) Parameters(ctx context.Context, existing interface{}) (params interface{}, err error) {
+	if existing != nil {
+		existingLock, ok := existing.(locks.ManagementLockObject)
+		if !ok {
+			return nil, errors.Errorf("%T is not a locks.ManagementLockObject", existing)
+		}
+		// Management lock already exists, only update it if its level or notes changed.
+		if existingLock.ManagementLockProperties != nil &&
+			existingLock.Level == locks.CanNotDelete &&
+			ptr.Deref(existingLock.Notes, "") == s.Notes {
+			return nil, nil
+		}
+	}
+
+	return locks.ManagementLockObject{
+		Name: ptr.To(s.Name),
+		ManagementLockProperties: &locks.ManagementLockProperties{
+			Level: locks.CanNotDelete,
+			Notes: ptr.To(s.Notes),
+		},
+	}, nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcelocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2016-09-01/locks"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+)
+
+func TestParameters(t *testing.T) {
+	expectedLock := locks.ManagementLockObject{
+		Name: ptr.To("my-cluster-rg-lock"),
+		ManagementLockProperties: &locks.ManagementLockProperties{
+			Level: locks.CanNotDelete,
+			Notes: ptr.To("Protects the resource group of cluster my-cluster from deletion. Managed by CAPZ."),
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		existing      interface{}
+		expected      interface{}
+		expectedError string
+	}{
+		{
+			name:     "CanNotDelete lock",
+			existing: nil,
+			expected: expectedLock,
+		},
+		{
+			name:     "noop if the lock is up to date",
+			existing: expectedLock,
+			expected: nil,
+		},
+		{
+			name: "update the lock if its level changed",
+			existing: locks.ManagementLockObject{
+				Name: ptr.To("my-cluster-rg-lock"),
+				ManagementLockProperties: &locks.ManagementLockProperties{
+					Level: locks.ReadOnly,
+					Notes: ptr.To("Protects the resource group of cluster my-cluster from deletion. Managed by CAPZ."),
+				},
+			},
+			expected: expectedLock,
+		},
+		{
+			name:          "existing is not a management lock",
+			existing:      "not a lock",
+			expected:      nil,
+			expectedError: "string is not a locks.ManagementLockObject",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			result, err := fakeResourceLockSpec.Parameters(context.TODO(), tc.existing)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			if tc.expected == nil {
+				g.Expect(result).To(BeNil())
+			} else {
+				g.Expect(result).To(Equal(tc.expected))
+			}
+		})
+	}
+}
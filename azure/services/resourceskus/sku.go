@@ -66,14 +66,22 @@ const (
 	EncryptionAtHost = "EncryptionAtHostSupported"
 	// MaximumPlatformFaultDomainCount identifies the maximum fault domain count for an availability set in a region.
 	MaximumPlatformFaultDomainCount = "MaximumPlatformFaultDomainCount"
+	// PremiumIO identifies the capability for the support of premium storage.
+	PremiumIO = "PremiumIO"
 	// UltraSSDAvailable identifies the capability for the support of UltraSSD data disks.
 	UltraSSDAvailable = "UltraSSDAvailable"
+	// HyperVGenerations identifies the hypervisor generations supported by a VM size, e.g. "V1,V2".
+	HyperVGenerations = "HyperVGenerations"
 	// TrustedLaunchDisabled identifies the absence of the trusted launch capability.
 	TrustedLaunchDisabled = "TrustedLaunchDisabled"
 	// ConfidentialComputingType identifies the capability for confidentical computing.
 	ConfidentialComputingType = "ConfidentialComputingType"
 	// CPUArchitectureType identifies the capability for cpu architecture.
 	CPUArchitectureType = "CpuArchitectureType"
+	// NvmeDiskSizeInMiB identifies the capability for the total size of the local NVMe disks of a VM size.
+	NvmeDiskSizeInMiB = "NvmeDiskSizeInMiB"
+	// HibernationSupported identifies the capability for the support of hibernation.
+	HibernationSupported = "HibernationSupported"
 )
 
 // HasCapability return true for a capability which can be either
@@ -93,6 +101,12 @@ func (s SKU) HasCapability(name string) bool {
 	return false
 }
 
+// HasLocalNVMeDisks returns true if the VM size has local NVMe disks.
+func (s SKU) HasLocalNVMeDisks() bool {
+	ok, err := s.HasCapabilityWithCapacity(NvmeDiskSizeInMiB, 1)
+	return err == nil && ok
+}
+
 // HasCapabilityWithCapacity returns true when the provided resource
 // exposes a numeric capability and the maximum value exposed by that
 // capability exceeds the value requested by the user. Examples include
@@ -171,3 +185,24 @@ func (s SKU) HasLocationCapability(capabilityName, location, zone string) bool {
 	}
 	return false
 }
+
+// HasExtendedLocation returns true if the provided resource is available in an extended location, e.g. an edge zone,
+// of the location.
+func (s SKU) HasExtendedLocation(location, extendedLocation string) bool {
+	if s.LocationInfo == nil {
+		return false
+	}
+
+	for _, info := range *s.LocationInfo {
+		if info.Location == nil || !strings.EqualFold(*info.Location, location) || info.ExtendedLocations == nil {
+			continue
+		}
+
+		for _, name := range *info.ExtendedLocations {
+			if strings.EqualFold(name, extendedLocation) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -308,3 +308,40 @@ func (mr *MockRoleAssignmentScopeMockRecorder) UpdatePutStatus(arg0, arg1, arg2
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePutStatus", reflect.TypeOf((*MockRoleAssignmentScope)(nil).UpdatePutStatus), arg0, arg1, arg2)
 }
+
+// MockUserAssignedIdentityScope is a mock of UserAssignedIdentityScope interface.
+type MockUserAssignedIdentityScope struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserAssignedIdentityScopeMockRecorder
+}
+
+// MockUserAssignedIdentityScopeMockRecorder is the mock recorder for MockUserAssignedIdentityScope.
+type MockUserAssignedIdentityScopeMockRecorder struct {
+	mock *MockUserAssignedIdentityScope
+}
+
+// NewMockUserAssignedIdentityScope creates a new mock instance.
+func NewMockUserAssignedIdentityScope(ctrl *gomock.Controller) *MockUserAssignedIdentityScope {
+	mock := &MockUserAssignedIdentityScope{ctrl: ctrl}
+	mock.recorder = &MockUserAssignedIdentityScopeMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserAssignedIdentityScope) EXPECT() *MockUserAssignedIdentityScopeMockRecorder {
+	return m.recorder
+}
+
+// RoleAssignmentIdentityID mocks base method.
+func (m *MockUserAssignedIdentityScope) RoleAssignmentIdentityID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RoleAssignmentIdentityID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// RoleAssignmentIdentityID indicates an expected call of RoleAssignmentIdentityID.
+func (mr *MockUserAssignedIdentityScopeMockRecorder) RoleAssignmentIdentityID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RoleAssignmentIdentityID", reflect.TypeOf((*MockUserAssignedIdentityScope)(nil).RoleAssignmentIdentityID))
+}
@@ -18,18 +18,41 @@ package roleassignments
 
 import (
 	"context"
+	"strings"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/authorization/mgmt/authorization"
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/identities"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/scalesets"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualmachines"
 	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
-const serviceName = "roleassignments"
+const (
+	serviceName = "roleassignments"
+
+	// principalNotFoundErrorCode is the code of the error returned when creating a role assignment to a principal that
+	// isn't found in Azure AD, e.g. a newly created identity that hasn't propagated yet.
+	principalNotFoundErrorCode = "PrincipalNotFound"
+)
+
+// defaultPrincipalNotFoundBackoff is how long creating a role assignment is retried for while its principal isn't
+// found. It waits 1s, 2s and 4s between attempts, which fits in the service reconcile timeout. The role assignment is
+// requeued if its principal still isn't found afterwards.
+var defaultPrincipalNotFoundBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    4,
+}
 
 // RoleAssignmentScope defines the scope interface for a role assignment service.
 type RoleAssignmentScope interface {
@@ -42,12 +65,27 @@ type RoleAssignmentScope interface {
 	ResourceGroup() string
 }
 
+// VMPrincipalIDScope defines the scope interface for role assignments to the system-assigned identity of a VM whose
+// principal ID may already be known, as done for machines.
+type VMPrincipalIDScope interface {
+	VMPrincipalID() string
+}
+
+// UserAssignedIdentityScope defines the scope interface for role assignments to a user-assigned identity,
+// as done for managed clusters.
+type UserAssignedIdentityScope interface {
+	RoleAssignmentIdentityID() string
+}
+
 // Service provides operations on Azure resources.
 type Service struct {
 	Scope                 RoleAssignmentScope
 	virtualMachinesGetter async.Getter
 	async.Reconciler
 	virtualMachineScaleSetClient scalesets.Client
+	identitiesGetter             identities.Client
+	roleAssignmentsGetter        async.Getter
+	principalNotFoundBackoff     wait.Backoff
 }
 
 // New creates a new service.
@@ -57,6 +95,9 @@ func New(scope RoleAssignmentScope) *Service {
 		Scope:                        scope,
 		virtualMachinesGetter:        virtualmachines.NewClient(scope),
 		virtualMachineScaleSetClient: scalesets.NewClient(scope),
+		identitiesGetter:             identities.NewClient(scope),
+		roleAssignmentsGetter:        client,
+		principalNotFoundBackoff:     defaultPrincipalNotFoundBackoff,
 		Reconciler:                   async.New(scope, client, client),
 	}
 }
@@ -74,15 +115,19 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	defer cancel()
 	log.V(2).Info("reconciling role assignment")
 
+	resourceType := s.Scope.RoleAssignmentResourceType()
+
 	// Return early if the identity is not system assigned as there will be no
-	// role assignment spec in this case.
-	if !s.Scope.HasSystemAssignedIdentity() {
+	// role assignment spec in this case. Managed clusters assign roles to their
+	// user-assigned identities instead.
+	if resourceType != azure.ManagedCluster && !s.Scope.HasSystemAssignedIdentity() {
 		log.V(2).Info("no role assignment spec to reconcile")
 		return nil
 	}
 
 	var principalID *string
-	resourceType := s.Scope.RoleAssignmentResourceType()
+	principalIDs := map[string]string{}
+	identityType := "system assigned"
 	switch resourceType {
 	case azure.VirtualMachine:
 		ID, err := s.getVMPrincipalID(ctx)
@@ -96,29 +141,106 @@ func (s *Service) Reconcile(ctx context.Context) error {
 			return errors.Wrap(err, "failed to assign role to system assigned identity")
 		}
 		principalID = ID
+	case azure.ManagedCluster:
+		// Managed clusters may assign roles to other user-assigned identities than the control plane's, e.g. the
+		// identity of the Key Vault secrets provider, whose principal ID is resolved per role assignment.
+		if identityID := s.userAssignedIdentityID(); identityID != "" {
+			ID, err := s.identitiesGetter.GetPrincipalID(ctx, identityID)
+			if err != nil {
+				return errors.Wrap(err, "failed to assign role to user assigned identity")
+			}
+			principalID = &ID
+			principalIDs[identityID] = ID
+		}
+		identityType = "user assigned"
 	default:
-		return errors.Errorf("unexpected resource type %q. Expected one of [%s, %s]", resourceType,
-			azure.VirtualMachine, azure.VirtualMachineScaleSet)
+		return errors.Errorf("unexpected resource type %q. Expected one of [%s, %s, %s]", resourceType,
+			azure.VirtualMachine, azure.VirtualMachineScaleSet, azure.ManagedCluster)
 	}
 
-	for _, roleAssignmentSpec := range s.Scope.RoleAssignmentSpecs(principalID) {
+	roleAssignmentSpecs := s.Scope.RoleAssignmentSpecs(principalID)
+	if len(roleAssignmentSpecs) == 0 {
+		log.V(2).Info("no role assignment spec to reconcile")
+		return nil
+	}
+	for _, roleAssignmentSpec := range roleAssignmentSpecs {
+		if err := s.resolvePrincipalID(ctx, roleAssignmentSpec, principalIDs); err != nil {
+			return errors.Wrap(err, "failed to assign role to user assigned identity")
+		}
 		log.V(2).Info("Creating role assignment")
 		if roleAssignmentSpec.ResourceName() == "" {
 			log.V(2).Info("RoleAssignmentName is empty. This is not expected and will cause this System Assigned Identity to have no permissions.")
 		}
-		_, err := s.CreateOrUpdateResource(ctx, roleAssignmentSpec, serviceName)
-		if err != nil {
-			return errors.Wrapf(err, "cannot assign role to %s system assigned identity", resourceType)
+		if err := s.createRoleAssignment(ctx, roleAssignmentSpec); err != nil {
+			return errors.Wrapf(err, "cannot assign role to %s %s identity", resourceType, identityType)
 		}
 	}
 
 	return nil
 }
 
+// resolvePrincipalID sets the principal ID of a role assignment to a user-assigned identity from the ID of the
+// identity, caching the principal IDs already resolved.
+func (s *Service) resolvePrincipalID(ctx context.Context, spec azure.ResourceSpecGetter, principalIDs map[string]string) error {
+	roleAssignmentSpec, ok := spec.(*RoleAssignmentSpec)
+	if !ok || roleAssignmentSpec.PrincipalID != nil || roleAssignmentSpec.IdentityID == "" {
+		return nil
+	}
+	principalID, ok := principalIDs[roleAssignmentSpec.IdentityID]
+	if !ok {
+		var err error
+		principalID, err = s.identitiesGetter.GetPrincipalID(ctx, roleAssignmentSpec.IdentityID)
+		if err != nil {
+			return err
+		}
+		principalIDs[roleAssignmentSpec.IdentityID] = principalID
+	}
+	roleAssignmentSpec.PrincipalID = ptr.To(principalID)
+	return nil
+}
+
+// createRoleAssignment creates a role assignment, retrying with backoff while its principal isn't found as a newly
+// created identity takes time to propagate in Azure AD. If the principal still isn't found once the backoff is
+// exhausted, a transient error is returned so that the role assignment is created again later.
+func (s *Service) createRoleAssignment(ctx context.Context, spec azure.ResourceSpecGetter) error {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "roleassignments.Service.createRoleAssignment")
+	defer done()
+
+	backoff := s.principalNotFoundBackoff
+	for {
+		_, err := s.CreateOrUpdateResource(ctx, spec, serviceName)
+		if !isPrincipalNotFound(err) {
+			return err
+		}
+		if backoff.Steps <= 1 {
+			return azure.WithTransientError(errors.Wrap(err, "principal not found, the identity may not have propagated yet"), reconciler.DefaultReconcilerRequeue)
+		}
+		delay := backoff.Step()
+		log.V(2).Info("principal of the role assignment not found, waiting for the identity to propagate", "name", spec.ResourceName(), "retryAfter", delay)
+		select {
+		case <-ctx.Done():
+			return azure.WithTransientError(errors.Wrap(err, "principal not found, the identity may not have propagated yet"), reconciler.DefaultReconcilerRequeue)
+		case <-time.After(delay):
+		}
+	}
+}
+
+// isPrincipalNotFound returns true if an error is the Azure service error returned when the principal of a role
+// assignment isn't found.
+func isPrincipalNotFound(err error) bool {
+	var requestErr *azureautorest.RequestError
+	return errors.As(err, &requestErr) && requestErr.ServiceError != nil && requestErr.ServiceError.Code == principalNotFoundErrorCode
+}
+
 // getVMPrincipalID returns the VM principal ID.
 func (s *Service) getVMPrincipalID(ctx context.Context) (*string, error) {
 	ctx, log, done := tele.StartSpanWithLogger(ctx, "roleassignments.Service.getVMPrincipalID")
 	defer done()
+	if principalIDScope, ok := s.Scope.(VMPrincipalIDScope); ok {
+		if principalID := principalIDScope.VMPrincipalID(); principalID != "" {
+			return ptr.To(principalID), nil
+		}
+	}
 	log.V(2).Info("fetching principal ID for VM")
 	spec := &virtualmachines.VMSpec{
 		Name:          s.Scope.Name(),
@@ -148,10 +270,80 @@ func (s *Service) getVMSSPrincipalID(ctx context.Context) (*string, error) {
 	return resultVMSS.Identity.PrincipalID, nil
 }
 
-// Delete is a no-op as the role assignments get deleted as part of VM deletion.
+// userAssignedIdentityID returns the ID of the user-assigned identity to assign roles to, if any.
+func (s *Service) userAssignedIdentityID() string {
+	identityScope, ok := s.Scope.(UserAssignedIdentityScope)
+	if !ok {
+		return ""
+	}
+	return identityScope.RoleAssignmentIdentityID()
+}
+
+// Delete deletes the role assignments to the user-assigned identities of a managed cluster, and the role assignments of the
+// system-assigned identity of a VM or VMSS that CAPZ created, i.e. that are assigned to the identity. Role assignments
+// with the same name assigned to another principal are left untouched.
 func (s *Service) Delete(ctx context.Context) error {
-	_, _, done := tele.StartSpanWithLogger(ctx, "roleassignments.Service.Delete")
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "roleassignments.Service.Delete")
 	defer done()
+
+	resourceType := s.Scope.RoleAssignmentResourceType()
+	if resourceType != azure.ManagedCluster && !s.Scope.HasSystemAssignedIdentity() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureServiceReconcileTimeout)
+	defer cancel()
+
+	if resourceType == azure.ManagedCluster {
+		for _, roleAssignmentSpec := range s.Scope.RoleAssignmentSpecs(nil) {
+			log.V(2).Info("Deleting role assignment", "name", roleAssignmentSpec.ResourceName())
+			if err := s.DeleteResource(ctx, roleAssignmentSpec, serviceName); err != nil {
+				return errors.Wrap(err, "failed to delete role assignment")
+			}
+		}
+		return nil
+	}
+
+	var principalID *string
+	var err error
+	switch resourceType {
+	case azure.VirtualMachine:
+		principalID, err = s.getVMPrincipalID(ctx)
+	case azure.VirtualMachineScaleSet:
+		principalID, err = s.getVMSSPrincipalID(ctx)
+	default:
+		return errors.Errorf("unexpected resource type %q. Expected one of [%s, %s, %s]", resourceType,
+			azure.VirtualMachine, azure.VirtualMachineScaleSet, azure.ManagedCluster)
+	}
+	if azure.ResourceNotFound(err) || (err == nil && principalID == nil) {
+		// Without the principal of the identity, there is no telling which role assignments CAPZ created.
+		log.V(2).Info("no system assigned identity to delete the role assignments of")
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "failed to delete role assignments of system assigned identity")
+	}
+
+	for _, roleAssignmentSpec := range s.Scope.RoleAssignmentSpecs(principalID) {
+		existing, err := s.roleAssignmentsGetter.Get(ctx, roleAssignmentSpec)
+		if azure.ResourceNotFound(err) {
+			continue
+		} else if err != nil {
+			return errors.Wrapf(err, "failed to get role assignment %s", roleAssignmentSpec.ResourceName())
+		}
+		roleAssignment, ok := existing.(authorization.RoleAssignment)
+		if !ok {
+			return errors.Errorf("%T is not an authorization.RoleAssignment", existing)
+		}
+		if roleAssignment.Properties == nil || !strings.EqualFold(ptr.Deref(roleAssignment.Properties.PrincipalID, ""), *principalID) {
+			log.V(2).Info("Skipping role assignment not created for the system assigned identity", "name", roleAssignmentSpec.ResourceName())
+			continue
+		}
+		log.V(2).Info("Deleting role assignment", "name", roleAssignmentSpec.ResourceName())
+		if err := s.DeleteResource(ctx, roleAssignmentSpec, serviceName); err != nil {
+			return errors.Wrap(err, "failed to delete role assignment")
+		}
+	}
+
 	return nil
 }
 
@@ -20,15 +20,22 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/authorization/mgmt/authorization"
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
 	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/google/uuid"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async/mock_async"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/identities/mock_identities"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/roleassignments/mock_roleassignments"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/scalesets/mock_scalesets"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualmachines"
@@ -119,6 +126,49 @@ func TestReconcileRoleAssignmentsVM(t *testing.T) {
 					autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error"))
 			},
 		},
+		{
+			name:          "retry creating a role assignment while its principal is not found",
+			expectedError: "",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder,
+				m *mock_async.MockGetterMockRecorder,
+				r *mock_async.MockReconcilerMockRecorder) {
+				s.SubscriptionID().AnyTimes().Return("12345")
+				s.ResourceGroup().Return("my-rg")
+				s.Name().Return(fakeRoleAssignment1.MachineName)
+				s.RoleAssignmentResourceType().Return("VirtualMachine")
+				s.HasSystemAssignedIdentity().Return(true)
+				s.RoleAssignmentSpecs(&fakePrincipalID).Return(fakeRoleAssignmentSpecs[0:1])
+				m.Get(gomockinternal.AContext(), &fakeVMSpec).Return(compute.VirtualMachine{
+					Identity: &compute.VirtualMachineIdentity{
+						PrincipalID: &fakePrincipalID,
+					},
+				}, nil)
+				gomock.InOrder(
+					r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeRoleAssignment1, serviceName).Return(nil, newPrincipalNotFoundError()).Times(2),
+					r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeRoleAssignment1, serviceName).Return(&fakeRoleAssignment1, nil),
+				)
+			},
+		},
+		{
+			name:          "requeue a role assignment whose principal is still not found",
+			expectedError: "cannot assign role to VirtualMachine system assigned identity: principal not found, the identity may not have propagated yet: #: Principal fake-p-id does not exist in the directory: StatusCode=400 -- Original Error: autorest/azure: Service returned an error. Status=<nil> Code=\"PrincipalNotFound\" Message=\"Principal fake-p-id does not exist in the directory\". Object will be requeued after 15s",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder,
+				m *mock_async.MockGetterMockRecorder,
+				r *mock_async.MockReconcilerMockRecorder) {
+				s.SubscriptionID().AnyTimes().Return("12345")
+				s.ResourceGroup().Return("my-rg")
+				s.Name().Return(fakeRoleAssignment1.MachineName)
+				s.RoleAssignmentResourceType().Return("VirtualMachine")
+				s.HasSystemAssignedIdentity().Return(true)
+				s.RoleAssignmentSpecs(&fakePrincipalID).Return(fakeRoleAssignmentSpecs[0:1])
+				m.Get(gomockinternal.AContext(), &fakeVMSpec).Return(compute.VirtualMachine{
+					Identity: &compute.VirtualMachineIdentity{
+						PrincipalID: &fakePrincipalID,
+					},
+				}, nil)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeRoleAssignment1, serviceName).Return(nil, newPrincipalNotFoundError()).Times(3)
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -135,9 +185,10 @@ func TestReconcileRoleAssignmentsVM(t *testing.T) {
 			tc.expect(scopeMock.EXPECT(), vmGetterMock.EXPECT(), asyncMock.EXPECT())
 
 			s := &Service{
-				Scope:                 scopeMock,
-				virtualMachinesGetter: vmGetterMock,
-				Reconciler:            asyncMock,
+				Scope:                    scopeMock,
+				virtualMachinesGetter:    vmGetterMock,
+				Reconciler:               asyncMock,
+				principalNotFoundBackoff: wait.Backoff{Duration: time.Millisecond, Steps: 3},
 			}
 
 			err := s.Reconcile(context.TODO())
@@ -151,6 +202,56 @@ func TestReconcileRoleAssignmentsVM(t *testing.T) {
 	}
 }
 
+// fakeMachineScope is a role assignment scope whose VM principal ID was already read by the VM service.
+type fakeMachineScope struct {
+	*mock_roleassignments.MockRoleAssignmentScope
+	principalID string
+}
+
+func (s *fakeMachineScope) VMPrincipalID() string {
+	return s.principalID
+}
+
+func TestReconcileRoleAssignmentsVMWithKnownPrincipalID(t *testing.T) {
+	g := NewWithT(t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scopeMock := mock_roleassignments.NewMockRoleAssignmentScope(mockCtrl)
+	vmGetterMock := mock_async.NewMockGetter(mockCtrl)
+	asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+	// The VM isn't fetched again as its principal ID is already known.
+	scopeMock.EXPECT().HasSystemAssignedIdentity().Return(true)
+	scopeMock.EXPECT().RoleAssignmentResourceType().Return(azure.VirtualMachine)
+	scopeMock.EXPECT().RoleAssignmentSpecs(&fakePrincipalID).Return(fakeRoleAssignmentSpecs[:1])
+	asyncMock.EXPECT().CreateOrUpdateResource(gomockinternal.AContext(), &fakeRoleAssignment1, serviceName).Return(&fakeRoleAssignment1, nil)
+
+	s := &Service{
+		Scope:                    &fakeMachineScope{MockRoleAssignmentScope: scopeMock, principalID: fakePrincipalID},
+		virtualMachinesGetter:    vmGetterMock,
+		Reconciler:               asyncMock,
+		principalNotFoundBackoff: wait.Backoff{Duration: time.Millisecond, Steps: 3},
+	}
+
+	g.Expect(s.Reconcile(context.TODO())).To(Succeed())
+}
+
+// newPrincipalNotFoundError returns the error returned when creating a role assignment to an identity that hasn't
+// propagated in Azure AD yet.
+func newPrincipalNotFoundError() error {
+	return autorest.DetailedError{
+		Original: &azureautorest.RequestError{
+			ServiceError: &azureautorest.ServiceError{
+				Code:    principalNotFoundErrorCode,
+				Message: "Principal fake-p-id does not exist in the directory",
+			},
+		},
+		StatusCode: http.StatusBadRequest,
+		Message:    "Principal fake-p-id does not exist in the directory",
+	}
+}
+
 func TestReconcileRoleAssignmentsVMSS(t *testing.T) {
 	testcases := []struct {
 		name   string
@@ -242,3 +343,367 @@ func TestReconcileRoleAssignmentsVMSS(t *testing.T) {
 		})
 	}
 }
+
+// fakeManagedClusterScope is a role assignment scope assigning roles to a user-assigned identity.
+type fakeManagedClusterScope struct {
+	*mock_roleassignments.MockRoleAssignmentScope
+	*mock_roleassignments.MockUserAssignedIdentityScope
+}
+
+var (
+	fakeIdentityID                  = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity"
+	fakeNodeResourceGroupAssignment = RoleAssignmentSpec{
+		Name:             "00000000-0000-0000-0000-000000000000",
+		ResourceGroup:    "my-node-rg",
+		ResourceType:     azure.ManagedCluster,
+		PrincipalID:      &fakePrincipalID,
+		RoleDefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/b24988ac-6180-42a0-ab88-20f7382dd24c",
+		Scope:            "/subscriptions/123/resourceGroups/my-node-rg",
+	}
+	fakeKeyVaultIdentityID = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-keyvault-identity"
+	fakeKeyVaultAssignment = RoleAssignmentSpec{
+		Name:             "11111111-1111-1111-1111-111111111111",
+		ResourceGroup:    "my-rg",
+		ResourceType:     azure.ManagedCluster,
+		RoleDefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/4633458b-17de-408a-b874-0445c86b69e6",
+		Scope:            "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-vault",
+		IdentityID:       fakeKeyVaultIdentityID,
+	}
+)
+
+func TestReconcileRoleAssignmentsManagedCluster(t *testing.T) {
+	testcases := []struct {
+		name   string
+		expect func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder,
+			i *mock_identities.MockClientMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+		expectedError string
+	}{
+		{
+			name: "create a role assignment on the node resource group",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder,
+				i *mock_identities.MockClientMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.RoleAssignmentResourceType().Return(azure.ManagedCluster)
+				u.RoleAssignmentIdentityID().Return(fakeIdentityID)
+				i.GetPrincipalID(gomockinternal.AContext(), fakeIdentityID).Return(fakePrincipalID, nil)
+				s.RoleAssignmentSpecs(&fakePrincipalID).Return([]azure.ResourceSpecGetter{&fakeNodeResourceGroupAssignment})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeNodeResourceGroupAssignment, serviceName).Return(&fakeNodeResourceGroupAssignment, nil)
+			},
+		},
+		{
+			name: "no role assignment to the user-assigned identity",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder,
+				i *mock_identities.MockClientMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.RoleAssignmentResourceType().Return(azure.ManagedCluster)
+				u.RoleAssignmentIdentityID().Return("")
+				s.RoleAssignmentSpecs(nil).Return([]azure.ResourceSpecGetter{})
+			},
+		},
+		{
+			name: "create a role assignment on a Key Vault to the identity of the Key Vault secrets provider",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder,
+				i *mock_identities.MockClientMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				keyVaultAssignment := fakeKeyVaultAssignment
+				s.RoleAssignmentResourceType().Return(azure.ManagedCluster)
+				u.RoleAssignmentIdentityID().Return("")
+				s.RoleAssignmentSpecs(nil).Return([]azure.ResourceSpecGetter{&keyVaultAssignment})
+				i.GetPrincipalID(gomockinternal.AContext(), fakeKeyVaultIdentityID).Return(fakePrincipalID, nil)
+				expected := fakeKeyVaultAssignment
+				expected.PrincipalID = &fakePrincipalID
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &expected, serviceName).Return(&expected, nil)
+			},
+		},
+		{
+			name: "create role assignments on the node resource group and on a Key Vault to the same identity",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder,
+				i *mock_identities.MockClientMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				keyVaultAssignment := fakeKeyVaultAssignment
+				keyVaultAssignment.IdentityID = fakeIdentityID
+				s.RoleAssignmentResourceType().Return(azure.ManagedCluster)
+				u.RoleAssignmentIdentityID().Return(fakeIdentityID)
+				i.GetPrincipalID(gomockinternal.AContext(), fakeIdentityID).Return(fakePrincipalID, nil)
+				s.RoleAssignmentSpecs(&fakePrincipalID).Return([]azure.ResourceSpecGetter{&fakeNodeResourceGroupAssignment, &keyVaultAssignment})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeNodeResourceGroupAssignment, serviceName).Return(&fakeNodeResourceGroupAssignment, nil)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &keyVaultAssignment, serviceName).Return(&keyVaultAssignment, nil)
+			},
+		},
+		{
+			name:          "error getting the principal ID of the identity of the Key Vault secrets provider",
+			expectedError: "failed to assign role to user assigned identity: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder,
+				i *mock_identities.MockClientMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				keyVaultAssignment := fakeKeyVaultAssignment
+				s.RoleAssignmentResourceType().Return(azure.ManagedCluster)
+				u.RoleAssignmentIdentityID().Return("")
+				s.RoleAssignmentSpecs(nil).Return([]azure.ResourceSpecGetter{&keyVaultAssignment})
+				i.GetPrincipalID(gomockinternal.AContext(), fakeKeyVaultIdentityID).Return("",
+					autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error"))
+			},
+		},
+		{
+			name:          "error getting the principal ID of the user-assigned identity",
+			expectedError: "failed to assign role to user assigned identity: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder,
+				i *mock_identities.MockClientMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.RoleAssignmentResourceType().Return(azure.ManagedCluster)
+				u.RoleAssignmentIdentityID().Return(fakeIdentityID)
+				i.GetPrincipalID(gomockinternal.AContext(), fakeIdentityID).Return("",
+					autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error"))
+			},
+		},
+		{
+			name:          "return error when creating a role assignment",
+			expectedError: fmt.Sprintf("cannot assign role to %s user assigned identity: #: Internal Server Error: StatusCode=500", azure.ManagedCluster),
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder,
+				i *mock_identities.MockClientMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.RoleAssignmentResourceType().Return(azure.ManagedCluster)
+				u.RoleAssignmentIdentityID().Return(fakeIdentityID)
+				i.GetPrincipalID(gomockinternal.AContext(), fakeIdentityID).Return(fakePrincipalID, nil)
+				s.RoleAssignmentSpecs(&fakePrincipalID).Return([]azure.ResourceSpecGetter{&fakeNodeResourceGroupAssignment})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeNodeResourceGroupAssignment, serviceName).Return(nil,
+					autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error"))
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_roleassignments.NewMockRoleAssignmentScope(mockCtrl)
+			identityScopeMock := mock_roleassignments.NewMockUserAssignedIdentityScope(mockCtrl)
+			identitiesMock := mock_identities.NewMockClient(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), identityScopeMock.EXPECT(), identitiesMock.EXPECT(), asyncMock.EXPECT())
+
+			s := &Service{
+				Scope:            fakeManagedClusterScope{scopeMock, identityScopeMock},
+				Reconciler:       asyncMock,
+				identitiesGetter: identitiesMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestDeleteRoleAssignments(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expect        func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+		expectedError string
+	}{
+		{
+			name: "VM without system assigned identity",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.RoleAssignmentResourceType().Return(azure.VirtualMachine)
+				s.HasSystemAssignedIdentity().Return(false)
+			},
+		},
+		{
+			name: "delete the role assignment on the node resource group",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.RoleAssignmentResourceType().Return(azure.ManagedCluster)
+				s.RoleAssignmentSpecs(nil).Return([]azure.ResourceSpecGetter{&fakeNodeResourceGroupAssignment})
+				r.DeleteResource(gomockinternal.AContext(), &fakeNodeResourceGroupAssignment, serviceName).Return(nil)
+			},
+		},
+		{
+			name: "no role assignment to the user-assigned identities",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.RoleAssignmentResourceType().Return(azure.ManagedCluster)
+				s.RoleAssignmentSpecs(nil).Return([]azure.ResourceSpecGetter{})
+			},
+		},
+		{
+			name: "delete the role assignment on a Key Vault",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.RoleAssignmentResourceType().Return(azure.ManagedCluster)
+				s.RoleAssignmentSpecs(nil).Return([]azure.ResourceSpecGetter{&fakeKeyVaultAssignment})
+				r.DeleteResource(gomockinternal.AContext(), &fakeKeyVaultAssignment, serviceName).Return(nil)
+			},
+		},
+		{
+			name:          "error deleting the role assignment on the node resource group",
+			expectedError: "failed to delete role assignment: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, u *mock_roleassignments.MockUserAssignedIdentityScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.RoleAssignmentResourceType().Return(azure.ManagedCluster)
+				s.RoleAssignmentSpecs(nil).Return([]azure.ResourceSpecGetter{&fakeNodeResourceGroupAssignment})
+				r.DeleteResource(gomockinternal.AContext(), &fakeNodeResourceGroupAssignment, serviceName).Return(
+					autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error"))
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_roleassignments.NewMockRoleAssignmentScope(mockCtrl)
+			identityScopeMock := mock_roleassignments.NewMockUserAssignedIdentityScope(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), identityScopeMock.EXPECT(), asyncMock.EXPECT())
+
+			s := &Service{
+				Scope:      fakeManagedClusterScope{scopeMock, identityScopeMock},
+				Reconciler: asyncMock,
+			}
+
+			err := s.Delete(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestDeleteRoleAssignmentsVM(t *testing.T) {
+	vmWithIdentity := compute.VirtualMachine{
+		Identity: &compute.VirtualMachineIdentity{
+			PrincipalID: &fakePrincipalID,
+		},
+	}
+	mainRoleAssignment := RoleAssignmentSpec{
+		Name:             "main-role-assignment",
+		MachineName:      "test-vm",
+		ResourceGroup:    "my-rg",
+		ResourceType:     azure.VirtualMachine,
+		PrincipalID:      &fakePrincipalID,
+		Scope:            "/subscriptions/123/",
+		RoleDefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/contributor",
+	}
+	additionalRoleAssignment := RoleAssignmentSpec{
+		Name:             AdditionalRoleAssignmentName("main-role-assignment", "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-kv", "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/secrets-user"),
+		MachineName:      "test-vm",
+		ResourceGroup:    "my-rg",
+		ResourceType:     azure.VirtualMachine,
+		PrincipalID:      &fakePrincipalID,
+		Scope:            "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.KeyVault/vaults/my-kv",
+		RoleDefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/secrets-user",
+	}
+	roleAssignmentTo := func(principalID string) authorization.RoleAssignment {
+		return authorization.RoleAssignment{
+			Properties: &authorization.RoleAssignmentPropertiesWithScope{PrincipalID: ptr.To(principalID)},
+		}
+	}
+	notFoundError := autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusNotFound}, "Not Found")
+	internalError := autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+
+	testcases := []struct {
+		name          string
+		expect        func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, m *mock_async.MockGetterMockRecorder, ra *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+		expectedError string
+	}{
+		{
+			name: "delete the role assignments of the system assigned identity",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, m *mock_async.MockGetterMockRecorder, ra *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.RoleAssignmentResourceType().Return(azure.VirtualMachine)
+				s.HasSystemAssignedIdentity().Return(true)
+				s.ResourceGroup().Return("my-rg")
+				s.Name().Return("test-vm")
+				m.Get(gomockinternal.AContext(), &fakeVMSpec).Return(vmWithIdentity, nil)
+				s.RoleAssignmentSpecs(&fakePrincipalID).Return([]azure.ResourceSpecGetter{&mainRoleAssignment, &additionalRoleAssignment})
+				ra.Get(gomockinternal.AContext(), &mainRoleAssignment).Return(roleAssignmentTo(fakePrincipalID), nil)
+				r.DeleteResource(gomockinternal.AContext(), &mainRoleAssignment, serviceName).Return(nil)
+				ra.Get(gomockinternal.AContext(), &additionalRoleAssignment).Return(roleAssignmentTo(fakePrincipalID), nil)
+				r.DeleteResource(gomockinternal.AContext(), &additionalRoleAssignment, serviceName).Return(nil)
+			},
+		},
+		{
+			name: "skip the role assignments that don't exist or aren't assigned to the identity",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, m *mock_async.MockGetterMockRecorder, ra *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.RoleAssignmentResourceType().Return(azure.VirtualMachine)
+				s.HasSystemAssignedIdentity().Return(true)
+				s.ResourceGroup().Return("my-rg")
+				s.Name().Return("test-vm")
+				m.Get(gomockinternal.AContext(), &fakeVMSpec).Return(vmWithIdentity, nil)
+				s.RoleAssignmentSpecs(&fakePrincipalID).Return([]azure.ResourceSpecGetter{&mainRoleAssignment, &additionalRoleAssignment})
+				ra.Get(gomockinternal.AContext(), &mainRoleAssignment).Return(nil, notFoundError)
+				ra.Get(gomockinternal.AContext(), &additionalRoleAssignment).Return(roleAssignmentTo("other-principal-id"), nil)
+			},
+		},
+		{
+			name: "VM already deleted",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, m *mock_async.MockGetterMockRecorder, ra *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.RoleAssignmentResourceType().Return(azure.VirtualMachine)
+				s.HasSystemAssignedIdentity().Return(true)
+				s.ResourceGroup().Return("my-rg")
+				s.Name().Return("test-vm")
+				m.Get(gomockinternal.AContext(), &fakeVMSpec).Return(nil, notFoundError)
+			},
+		},
+		{
+			name:          "error getting a role assignment",
+			expectedError: "failed to get role assignment main-role-assignment: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_roleassignments.MockRoleAssignmentScopeMockRecorder, m *mock_async.MockGetterMockRecorder, ra *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.RoleAssignmentResourceType().Return(azure.VirtualMachine)
+				s.HasSystemAssignedIdentity().Return(true)
+				s.ResourceGroup().Return("my-rg")
+				s.Name().Return("test-vm")
+				m.Get(gomockinternal.AContext(), &fakeVMSpec).Return(vmWithIdentity, nil)
+				s.RoleAssignmentSpecs(&fakePrincipalID).Return([]azure.ResourceSpecGetter{&mainRoleAssignment, &additionalRoleAssignment})
+				ra.Get(gomockinternal.AContext(), &mainRoleAssignment).Return(nil, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_roleassignments.NewMockRoleAssignmentScope(mockCtrl)
+			vmGetterMock := mock_async.NewMockGetter(mockCtrl)
+			roleAssignmentsGetterMock := mock_async.NewMockGetter(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), vmGetterMock.EXPECT(), roleAssignmentsGetterMock.EXPECT(), asyncMock.EXPECT())
+
+			s := &Service{
+				Scope:                 scopeMock,
+				virtualMachinesGetter: vmGetterMock,
+				roleAssignmentsGetter: roleAssignmentsGetterMock,
+				Reconciler:            asyncMock,
+			}
+
+			err := s.Delete(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestAdditionalRoleAssignmentName(t *testing.T) {
+	g := NewWithT(t)
+
+	scope := "/subscriptions/123/resourceGroups/my-rg"
+	roleDefinitionID := "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/reader"
+	name := AdditionalRoleAssignmentName("main-role-assignment", scope, roleDefinitionID)
+	_, err := uuid.Parse(name)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(AdditionalRoleAssignmentName("main-role-assignment", strings.ToUpper(scope)+"/", roleDefinitionID)).To(Equal(name))
+	g.Expect(AdditionalRoleAssignmentName("other-role-assignment", scope, roleDefinitionID)).NotTo(Equal(name))
+	g.Expect(AdditionalRoleAssignmentName("main-role-assignment", "/subscriptions/123", roleDefinitionID)).NotTo(Equal(name))
+}
@@ -18,8 +18,10 @@ package roleassignments
 
 import (
 	"context"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/authorization/mgmt/authorization"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"k8s.io/utils/ptr"
 )
@@ -33,6 +35,9 @@ type RoleAssignmentSpec struct {
 	PrincipalID      *string
 	RoleDefinitionID string
 	Scope            string
+	// IdentityID is the ID of the user-assigned identity to assign the role to when PrincipalID is nil.
+	// The principal ID of the identity is resolved by the service.
+	IdentityID string
 }
 
 // ResourceName returns the name of the role assignment.
@@ -67,3 +72,12 @@ func (s *RoleAssignmentSpec) Parameters(ctx context.Context, existing interface{
 		},
 	}, nil
 }
+
+// AdditionalRoleAssignmentName returns the name of the role assignment of an additional role of a system-assigned
+// identity. It is a UUID derived from the name of the role assignment of the identity's main role, which is unique to
+// the identity, and from the scope and role definition of the additional role, so that it doesn't change between
+// reconciliations.
+func AdditionalRoleAssignmentName(roleAssignmentName, scope, roleDefinitionID string) string {
+	name := strings.ToLower(strings.Join([]string{roleAssignmentName, strings.TrimSuffix(scope, "/"), roleDefinitionID}, "|"))
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String()
+}
@@ -46,6 +46,23 @@ var (
 		Location:      "fake-location",
 		ClusterName:   "test-cluster",
 	}
+	fakeRTWithRoutes = RouteTableSpec{
+		Name:          "test-rt-3",
+		ResourceGroup: "test-rg",
+		Location:      "fake-location",
+		ClusterName:   "test-cluster",
+		Routes: []infrav1.Route{
+			{
+				Name:             "to-firewall",
+				AddressPrefix:    "0.0.0.0/0",
+				NextHopType:      infrav1.RouteNextHopTypeVirtualAppliance,
+				NextHopIPAddress: "10.0.100.4",
+			},
+		},
+		LastAppliedRoutes: map[string]interface{}{
+			"old-route": "192.168.0.0/16",
+		},
+	}
 	errFake      = errors.New("this is an error")
 	notDoneError = azure.NewOperationNotDoneError(&infrav1.Future{})
 )
@@ -73,9 +90,41 @@ func TestReconcileRouteTables(t *testing.T) {
 				s.RouteTableSpecs().Return([]azure.ResourceSpecGetter{&fakeRT, &fakeRT2})
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeRT, serviceName).Return(nil, nil)
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeRT2, serviceName).Return(nil, nil)
+				s.UpdateAnnotationJSON(azure.RouteLastAppliedAnnotation, map[string]interface{}{})
 				s.UpdatePutStatus(infrav1.RouteTablesReadyCondition, serviceName, nil)
 			},
 		},
+		{
+			name:          "create route table with routes succeeds and tracks the applied routes",
+			expectedError: "",
+			expect: func(s *mock_routetables.MockRouteTableScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.IsVnetManaged().Return(true)
+				s.RouteTableSpecs().Return([]azure.ResourceSpecGetter{&fakeRTWithRoutes})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeRTWithRoutes, serviceName).Return(nil, nil)
+				s.UpdateAnnotationJSON(azure.RouteLastAppliedAnnotation, map[string]interface{}{
+					"test-rt-3": map[string]interface{}{
+						"to-firewall": "0.0.0.0/0",
+					},
+				})
+				s.UpdatePutStatus(infrav1.RouteTablesReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "route table update fails and keeps tracking the previously applied routes",
+			expectedError: errFake.Error(),
+			expect: func(s *mock_routetables.MockRouteTableScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.IsVnetManaged().Return(true)
+				s.RouteTableSpecs().Return([]azure.ResourceSpecGetter{&fakeRTWithRoutes})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeRTWithRoutes, serviceName).Return(nil, errFake)
+				s.UpdateAnnotationJSON(azure.RouteLastAppliedAnnotation, map[string]interface{}{
+					"test-rt-3": map[string]interface{}{
+						"to-firewall": "0.0.0.0/0",
+						"old-route":   "192.168.0.0/16",
+					},
+				})
+				s.UpdatePutStatus(infrav1.RouteTablesReadyCondition, serviceName, errFake)
+			},
+		},
 		{
 			name:          "first route table create fails",
 			expectedError: errFake.Error(),
@@ -84,6 +133,7 @@ func TestReconcileRouteTables(t *testing.T) {
 				s.RouteTableSpecs().Return([]azure.ResourceSpecGetter{&fakeRT, &fakeRT2})
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeRT, serviceName).Return(nil, errFake)
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeRT2, serviceName).Return(nil, nil)
+				s.UpdateAnnotationJSON(azure.RouteLastAppliedAnnotation, map[string]interface{}{})
 				s.UpdatePutStatus(infrav1.RouteTablesReadyCondition, serviceName, errFake)
 			},
 		},
@@ -95,6 +145,7 @@ func TestReconcileRouteTables(t *testing.T) {
 				s.RouteTableSpecs().Return([]azure.ResourceSpecGetter{&fakeRT, &fakeRT2})
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeRT, serviceName).Return(nil, errFake)
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeRT2, serviceName).Return(nil, notDoneError)
+				s.UpdateAnnotationJSON(azure.RouteLastAppliedAnnotation, map[string]interface{}{})
 				s.UpdatePutStatus(infrav1.RouteTablesReadyCondition, serviceName, errFake)
 			},
 		},
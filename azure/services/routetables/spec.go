@@ -18,21 +18,25 @@ package routetables
 
 import (
 	"context"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
 	"github.com/pkg/errors"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 )
 
 // RouteTableSpec defines the specification for a route table.
 type RouteTableSpec struct {
-	Name           string
-	ResourceGroup  string
-	Location       string
-	ClusterName    string
-	AdditionalTags infrav1.Tags
+	Name              string
+	ResourceGroup     string
+	Location          string
+	ClusterName       string
+	AdditionalTags    infrav1.Tags
+	Routes            []infrav1.Route
+	LastAppliedRoutes map[string]interface{}
 }
 
 // ResourceName returns the name of the route table.
@@ -52,22 +56,85 @@ func (s *RouteTableSpec) OwnerResourceName() string {
 
 // Parameters returns the parameters for the route table.
 func (s *RouteTableSpec) Parameters(ctx context.Context, existing interface{}) (params interface{}, err error) {
+	routes := make([]network.Route, 0, len(s.Routes))
+	var etag *string
+
 	if existing != nil {
-		if _, ok := existing.(network.RouteTable); !ok {
+		existingRT, ok := existing.(network.RouteTable)
+		if !ok {
 			return nil, errors.Errorf("%T is not a network.RouteTable", existing)
 		}
 		// route table already exists
-		// currently don't support specifying your own routes via spec.
-		return nil, nil
+		// We append the existing route table etag to the header to ensure we only apply the updates if the route table has not been modified.
+		etag = existingRT.Etag
+
+		var existingRoutes []network.Route
+		if existingRT.RouteTablePropertiesFormat != nil && existingRT.Routes != nil {
+			existingRoutes = *existingRT.Routes
+		}
+
+		update := false
+		desired := make(map[string]struct{}, len(s.Routes))
+		for _, route := range s.Routes {
+			sdkRoute := converters.RouteToSDK(route)
+			if !routeExists(existingRoutes, sdkRoute) {
+				update = true
+			}
+			routes = append(routes, sdkRoute)
+			desired[strings.ToLower(route.Name)] = struct{}{}
+		}
+
+		for _, oldRoute := range existingRoutes {
+			name := ptr.Deref(oldRoute.Name, "")
+			if _, ok := desired[strings.ToLower(name)]; ok {
+				continue
+			}
+			// If the route was applied by CAPZ and is no longer specified, then it has been deleted.
+			if _, tracked := s.LastAppliedRoutes[name]; tracked {
+				update = true
+				continue
+			}
+			// Keep routes which aren't managed by CAPZ, e.g. routes added by the cloud provider.
+			routes = append(routes, oldRoute)
+		}
+
+		if !update {
+			// Skip update for the route table as the expected routes are present
+			return nil, nil
+		}
+	} else {
+		for _, route := range s.Routes {
+			routes = append(routes, converters.RouteToSDK(route))
+		}
 	}
+
 	return network.RouteTable{
-		Location:                   ptr.To(s.Location),
-		RouteTablePropertiesFormat: &network.RouteTablePropertiesFormat{},
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+		Location: ptr.To(s.Location),
+		RouteTablePropertiesFormat: &network.RouteTablePropertiesFormat{
+			Routes: &routes,
+		},
+		Etag: etag,
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
 			ClusterName: s.ClusterName,
 			Lifecycle:   infrav1.ResourceLifecycleOwned,
 			Name:        ptr.To(s.Name),
-			Additional:  s.AdditionalTags,
+			ClusterTags: s.AdditionalTags,
 		})),
 	}, nil
 }
+
+// routeExists returns true if the route is present in the list of routes with the same properties.
+func routeExists(routes []network.Route, route network.Route) bool {
+	for _, existingRoute := range routes {
+		if !strings.EqualFold(ptr.Deref(existingRoute.Name, ""), ptr.Deref(route.Name, "")) {
+			continue
+		}
+		if existingRoute.RoutePropertiesFormat == nil {
+			return false
+		}
+		return strings.EqualFold(ptr.Deref(existingRoute.AddressPrefix, ""), ptr.Deref(route.AddressPrefix, "")) &&
+			existingRoute.NextHopType == route.NextHopType &&
+			ptr.Deref(existingRoute.NextHopIPAddress, "") == ptr.Deref(route.NextHopIPAddress, "")
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routetables
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+var (
+	firewallRoute = infrav1.Route{
+		Name:             "to-firewall",
+		AddressPrefix:    "0.0.0.0/0",
+		NextHopType:      infrav1.RouteNextHopTypeVirtualAppliance,
+		NextHopIPAddress: "10.0.100.4",
+	}
+	onPremRoute = infrav1.Route{
+		Name:          "to-on-prem",
+		AddressPrefix: "192.168.0.0/16",
+		NextHopType:   infrav1.RouteNextHopTypeVirtualNetworkGateway,
+	}
+	sdkFirewallRoute = network.Route{
+		Name: ptr.To("to-firewall"),
+		RoutePropertiesFormat: &network.RoutePropertiesFormat{
+			AddressPrefix:    ptr.To("0.0.0.0/0"),
+			NextHopType:      network.RouteNextHopTypeVirtualAppliance,
+			NextHopIPAddress: ptr.To("10.0.100.4"),
+		},
+	}
+	sdkOnPremRoute = network.Route{
+		Name: ptr.To("to-on-prem"),
+		RoutePropertiesFormat: &network.RoutePropertiesFormat{
+			AddressPrefix: ptr.To("192.168.0.0/16"),
+			NextHopType:   network.RouteNextHopTypeVirtualNetworkGateway,
+		},
+	}
+	// sdkNodeRoute is a route added outside of CAPZ, e.g. by the cloud provider.
+	sdkNodeRoute = network.Route{
+		Name: ptr.To("node-0"),
+		RoutePropertiesFormat: &network.RoutePropertiesFormat{
+			AddressPrefix:    ptr.To("10.244.0.0/24"),
+			NextHopType:      network.RouteNextHopTypeVirtualAppliance,
+			NextHopIPAddress: ptr.To("10.1.0.4"),
+		},
+	}
+)
+
+func TestParameters(t *testing.T) {
+	testcases := []struct {
+		name          string
+		spec          *RouteTableSpec
+		existing      interface{}
+		expect        func(g *WithT, result interface{})
+		expectedError string
+	}{
+		{
+			name: "new route table without routes",
+			spec: &RouteTableSpec{
+				Name:          "test-rt",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				ClusterName:   "my-cluster",
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(Equal(network.RouteTable{
+					Location: ptr.To("test-location"),
+					RouteTablePropertiesFormat: &network.RouteTablePropertiesFormat{
+						Routes: &[]network.Route{},
+					},
+					Tags: map[string]*string{
+						"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+						"Name": ptr.To("test-rt"),
+					},
+				}))
+			},
+		},
+		{
+			name: "new route table with routes",
+			spec: &RouteTableSpec{
+				Name:          "test-rt",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				ClusterName:   "my-cluster",
+				Routes:        []infrav1.Route{firewallRoute, onPremRoute},
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.RouteTable{}))
+				g.Expect(*result.(network.RouteTable).Routes).To(Equal([]network.Route{sdkFirewallRoute, sdkOnPremRoute}))
+			},
+		},
+		{
+			name: "existing route table with the expected routes doesn't need an update",
+			spec: &RouteTableSpec{
+				Name:          "test-rt",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				ClusterName:   "my-cluster",
+				Routes:        []infrav1.Route{firewallRoute},
+				LastAppliedRoutes: map[string]interface{}{
+					"to-firewall": "0.0.0.0/0",
+				},
+			},
+			existing: network.RouteTable{
+				Name: ptr.To("test-rt"),
+				RouteTablePropertiesFormat: &network.RouteTablePropertiesFormat{
+					Routes: &[]network.Route{sdkNodeRoute, sdkFirewallRoute},
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name: "existing route table without routes doesn't need an update",
+			spec: &RouteTableSpec{
+				Name:          "test-rt",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				ClusterName:   "my-cluster",
+			},
+			existing: network.RouteTable{
+				Name:                       ptr.To("test-rt"),
+				RouteTablePropertiesFormat: &network.RouteTablePropertiesFormat{},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name: "existing route table gets a new route and keeps untracked routes",
+			spec: &RouteTableSpec{
+				Name:          "test-rt",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				ClusterName:   "my-cluster",
+				Routes:        []infrav1.Route{firewallRoute, onPremRoute},
+				LastAppliedRoutes: map[string]interface{}{
+					"to-firewall": "0.0.0.0/0",
+				},
+			},
+			existing: network.RouteTable{
+				Name: ptr.To("test-rt"),
+				Etag: ptr.To("fake-etag"),
+				RouteTablePropertiesFormat: &network.RouteTablePropertiesFormat{
+					Routes: &[]network.Route{sdkNodeRoute, sdkFirewallRoute},
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.RouteTable{}))
+				g.Expect(result.(network.RouteTable).Etag).To(Equal(ptr.To("fake-etag")))
+				g.Expect(*result.(network.RouteTable).Routes).To(Equal([]network.Route{sdkFirewallRoute, sdkOnPremRoute, sdkNodeRoute}))
+			},
+		},
+		{
+			name: "existing route is updated when its next hop changes",
+			spec: &RouteTableSpec{
+				Name:          "test-rt",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				ClusterName:   "my-cluster",
+				Routes:        []infrav1.Route{firewallRoute},
+				LastAppliedRoutes: map[string]interface{}{
+					"to-firewall": "0.0.0.0/0",
+				},
+			},
+			existing: network.RouteTable{
+				Name: ptr.To("test-rt"),
+				RouteTablePropertiesFormat: &network.RouteTablePropertiesFormat{
+					Routes: &[]network.Route{
+						{
+							Name: ptr.To("to-firewall"),
+							RoutePropertiesFormat: &network.RoutePropertiesFormat{
+								AddressPrefix:    ptr.To("0.0.0.0/0"),
+								NextHopType:      network.RouteNextHopTypeVirtualAppliance,
+								NextHopIPAddress: ptr.To("10.0.100.5"),
+							},
+						},
+					},
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.RouteTable{}))
+				g.Expect(*result.(network.RouteTable).Routes).To(Equal([]network.Route{sdkFirewallRoute}))
+			},
+		},
+		{
+			name: "tracked route removed from the spec is deleted",
+			spec: &RouteTableSpec{
+				Name:          "test-rt",
+				ResourceGroup: "test-rg",
+				Location:      "test-location",
+				ClusterName:   "my-cluster",
+				Routes:        []infrav1.Route{firewallRoute},
+				LastAppliedRoutes: map[string]interface{}{
+					"to-firewall": "0.0.0.0/0",
+					"to-on-prem":  "192.168.0.0/16",
+				},
+			},
+			existing: network.RouteTable{
+				Name: ptr.To("test-rt"),
+				RouteTablePropertiesFormat: &network.RouteTablePropertiesFormat{
+					Routes: &[]network.Route{sdkFirewallRoute, sdkOnPremRoute, sdkNodeRoute},
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.RouteTable{}))
+				g.Expect(*result.(network.RouteTable).Routes).To(Equal([]network.Route{sdkFirewallRoute, sdkNodeRoute}))
+			},
+		},
+		{
+			name: "existing is not a route table",
+			spec: &RouteTableSpec{
+				Name: "test-rt",
+			},
+			existing: network.SecurityGroup{},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+			expectedError: "network.SecurityGroup is not a network.RouteTable",
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			result, err := tc.spec.Parameters(context.TODO(), tc.existing)
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			tc.expect(g, result)
+		})
+	}
+}
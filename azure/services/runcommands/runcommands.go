@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runcommands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	serviceName = "runcommands"
+
+	// maxOutputLength is the maximum length of the output and error streams of a script included in events and
+	// conditions. Longer streams are truncated to their end, where the result of a script usually is.
+	maxOutputLength = 512
+)
+
+// RunCommandScope defines the scope interface for a VM run command service.
+type RunCommandScope interface {
+	azure.Authorizer
+	azure.AsyncStatusUpdater
+	RunCommandSpecs() []azure.ResourceSpecGetter
+	GetCondition(clusterv1.ConditionType) *clusterv1.Condition
+	SetConditionTrue(clusterv1.ConditionType)
+	SetConditionFalse(clusterv1.ConditionType, string, clusterv1.ConditionSeverity, string)
+	RecordEvent(eventType, reason, messageFmt string, args ...interface{})
+}
+
+// instanceViewGetter gets a VM run command along with its instance view.
+type instanceViewGetter interface {
+	GetWithInstanceView(ctx context.Context, spec azure.ResourceSpecGetter) (compute.VirtualMachineRunCommand, error)
+}
+
+// Service provides operations on Azure resources.
+type Service struct {
+	Scope RunCommandScope
+	async.Reconciler
+	instanceViewGetter instanceViewGetter
+}
+
+// New creates a new VM run command service.
+func New(scope RunCommandScope) *Service {
+	client := newClient(scope)
+	return &Service{
+		Scope:              scope,
+		Reconciler:         async.New(scope, client, client),
+		instanceViewGetter: client,
+	}
+}
+
+// Name returns the service name.
+func (s *Service) Name() string {
+	return serviceName
+}
+
+// Reconcile runs the run commands of the VM in order, each one once the previous one succeeded. A run command only
+// runs once: its result is reported by its condition and by an event, and isn't checked again afterwards. A failed
+// run command is a terminal error, so the machine is marked as failed instead of being requeued.
+func (s *Service) Reconcile(ctx context.Context) error {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "runcommands.Service.Reconcile")
+	defer done()
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureServiceReconcileTimeout)
+	defer cancel()
+
+	for _, runCommandSpec := range s.Scope.RunCommandSpecs() {
+		name := runCommandSpec.ResourceName()
+		if condition := s.Scope.GetCondition(RunCommandSucceededCondition(name)); condition != nil {
+			if condition.Status == corev1.ConditionTrue {
+				continue
+			}
+			if condition.Reason == infrav1.RunCommandFailedReason {
+				return azure.WithTerminalError(errors.New(condition.Message))
+			}
+		}
+
+		_, err := s.CreateOrUpdateResource(ctx, runCommandSpec, serviceName)
+		if azure.IsOperationNotDoneError(err) {
+			s.Scope.SetConditionFalse(RunCommandSucceededCondition(name), infrav1.RunCommandRunningReason, clusterv1.ConditionSeverityInfo,
+				fmt.Sprintf("run command %s is running", name))
+			return errors.Wrapf(err, "run command %s is still running", name)
+		}
+		if err := s.reportResult(ctx, runCommandSpec, err); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reportResult sets the RunCommand/<name>Succeeded condition and records an event reporting the result of a run
+// command found in its instance view, along with the output of its script when it succeeded or the error stream of
+// its script when it failed. It returns an error unless the run command succeeded, which is terminal when the run
+// command failed.
+func (s *Service) reportResult(ctx context.Context, spec azure.ResourceSpecGetter, createErr error) error {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "runcommands.Service.reportResult")
+	defer done()
+
+	name := spec.ResourceName()
+	runCommand, err := s.instanceViewGetter.GetWithInstanceView(ctx, spec)
+	if err != nil {
+		if createErr != nil {
+			// The run command may not exist, e.g. the request was throttled, so it is created again next time.
+			log.V(2).Info("failed to get the instance view of the run command", "runCommand", name, "error", err.Error())
+			return createErr
+		}
+		return errors.Wrapf(err, "failed to get the instance view of run command %s", name)
+	}
+
+	var instanceView compute.VirtualMachineRunCommandInstanceView
+	if runCommand.VirtualMachineRunCommandProperties != nil && runCommand.InstanceView != nil {
+		instanceView = *runCommand.InstanceView
+	}
+
+	condition := RunCommandSucceededCondition(name)
+	switch instanceView.ExecutionState {
+	case compute.ExecutionStateSucceeded:
+		s.Scope.SetConditionTrue(condition)
+		s.Scope.RecordEvent(corev1.EventTypeNormal, infrav1.RunCommandSucceededReason, "run command %s succeeded: %s",
+			name, truncate(ptr.Deref(instanceView.Output, "")))
+		return nil
+	case compute.ExecutionStateFailed, compute.ExecutionStateTimedOut, compute.ExecutionStateCanceled:
+		message := fmt.Sprintf("run command %s execution state is %s", name, instanceView.ExecutionState)
+		if instanceView.ExitCode != nil {
+			message += fmt.Sprintf(" with exit code %d", *instanceView.ExitCode)
+		}
+		if details := executionDetails(instanceView); details != "" {
+			message += ": " + details
+		}
+		s.Scope.SetConditionFalse(condition, infrav1.RunCommandFailedReason, clusterv1.ConditionSeverityError, message)
+		s.Scope.RecordEvent(corev1.EventTypeWarning, infrav1.RunCommandFailedReason, "%s", message)
+		return azure.WithTerminalError(errors.New(message))
+	}
+
+	if createErr != nil {
+		return createErr
+	}
+	// The operation completed before the script, check it again later.
+	s.Scope.SetConditionFalse(condition, infrav1.RunCommandRunningReason, clusterv1.ConditionSeverityInfo,
+		fmt.Sprintf("run command %s is running", name))
+	return azure.WithTransientError(errors.Errorf("run command %s execution state is %s", name, instanceView.ExecutionState), reconciler.DefaultReconcilerRequeue)
+}
+
+// RunCommandSucceededCondition returns the type of the condition reporting the result of a VM run command.
+func RunCommandSucceededCondition(runCommandName string) clusterv1.ConditionType {
+	return clusterv1.ConditionType(infrav1.RunCommandConditionPrefix + runCommandName + "Succeeded")
+}
+
+// executionDetails returns the error stream of the script of a failed run command, or the execution message when the
+// script didn't write to it, e.g. it couldn't be downloaded.
+func executionDetails(instanceView compute.VirtualMachineRunCommandInstanceView) string {
+	if details := strings.TrimSpace(ptr.Deref(instanceView.Error, "")); details != "" {
+		return truncate(details)
+	}
+	return truncate(strings.TrimSpace(ptr.Deref(instanceView.ExecutionMessage, "")))
+}
+
+// truncate truncates a script stream to its last maxOutputLength characters.
+func truncate(stream string) string {
+	if len(stream) > maxOutputLength {
+		return "..." + stream[len(stream)-maxOutputLength:]
+	}
+	return stream
+}
+
+// Delete is a no-op. VM run commands are deleted as part of VM deletion.
+func (s *Service) Delete(_ context.Context) error {
+	return nil
+}
+
+// IsManaged always returns true as CAPZ does not support BYO VM run commands.
+func (s *Service) IsManaged(ctx context.Context) (bool, error) {
+	return true, nil
+}
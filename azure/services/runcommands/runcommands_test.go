@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runcommands
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async/mock_async"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/runcommands/mock_runcommands"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+var (
+	runCommandSpec1 = RunCommandSpec{
+		Name:          "my-run-command-1",
+		VMName:        "my-vm",
+		ResourceGroup: "my-rg",
+		Location:      "test-location",
+		Script:        "echo hello",
+	}
+
+	runCommandSpec2 = RunCommandSpec{
+		Name:          "my-run-command-2",
+		VMName:        "my-vm",
+		ResourceGroup: "my-rg",
+		Location:      "test-location",
+		ScriptURI:     "https://mystorage.blob.core.windows.net/scripts/post-provision.sh",
+	}
+
+	internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+	notDoneError  = azure.NewOperationNotDoneError(&infrav1.Future{})
+
+	succeededRunCommand = compute.VirtualMachineRunCommand{
+		VirtualMachineRunCommandProperties: &compute.VirtualMachineRunCommandProperties{
+			InstanceView: &compute.VirtualMachineRunCommandInstanceView{
+				ExecutionState: compute.ExecutionStateSucceeded,
+				ExitCode:       ptr.To[int32](0),
+				Output:         ptr.To("hello"),
+			},
+		},
+	}
+
+	failedRunCommand = compute.VirtualMachineRunCommand{
+		VirtualMachineRunCommandProperties: &compute.VirtualMachineRunCommandProperties{
+			InstanceView: &compute.VirtualMachineRunCommandInstanceView{
+				ExecutionState: compute.ExecutionStateFailed,
+				ExitCode:       ptr.To[int32](2),
+				Output:         ptr.To("post-provisioning node"),
+				Error:          ptr.To("post-provision.sh: line 3: kubectl: command not found\n"),
+			},
+		},
+	}
+
+	runningRunCommand = compute.VirtualMachineRunCommand{
+		VirtualMachineRunCommandProperties: &compute.VirtualMachineRunCommandProperties{
+			InstanceView: &compute.VirtualMachineRunCommandInstanceView{
+				ExecutionState: compute.ExecutionStateRunning,
+			},
+		},
+	}
+
+	runCommandFailedMessage = "run command my-run-command-1 execution state is Failed with exit code 2: post-provision.sh: line 3: kubectl: command not found"
+)
+
+func TestReconcileRunCommands(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_runcommands.MockRunCommandScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_runcommands.MockinstanceViewGetterMockRecorder)
+	}{
+		{
+			name:          "no run commands",
+			expectedError: "",
+			expect: func(s *mock_runcommands.MockRunCommandScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_runcommands.MockinstanceViewGetterMockRecorder) {
+				s.RunCommandSpecs().Return([]azure.ResourceSpecGetter{})
+			},
+		},
+		{
+			name:          "run command succeeded",
+			expectedError: "",
+			expect: func(s *mock_runcommands.MockRunCommandScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_runcommands.MockinstanceViewGetterMockRecorder) {
+				s.RunCommandSpecs().Return([]azure.ResourceSpecGetter{&runCommandSpec1})
+				s.GetCondition(RunCommandSucceededCondition(runCommandSpec1.Name)).Return(nil)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &runCommandSpec1, serviceName).Return(nil, nil)
+				v.GetWithInstanceView(gomockinternal.AContext(), &runCommandSpec1).Return(succeededRunCommand, nil)
+				s.SetConditionTrue(RunCommandSucceededCondition(runCommandSpec1.Name))
+				s.RecordEvent(corev1.EventTypeNormal, infrav1.RunCommandSucceededReason, "run command %s succeeded: %s", "my-run-command-1", "hello")
+			},
+		},
+		{
+			name:          "run command already succeeded",
+			expectedError: "",
+			expect: func(s *mock_runcommands.MockRunCommandScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_runcommands.MockinstanceViewGetterMockRecorder) {
+				s.RunCommandSpecs().Return([]azure.ResourceSpecGetter{&runCommandSpec1})
+				s.GetCondition(RunCommandSucceededCondition(runCommandSpec1.Name)).Return(&clusterv1.Condition{Status: corev1.ConditionTrue})
+			},
+		},
+		{
+			name:          "run command failed",
+			expectedError: azure.WithTerminalError(errors.New(runCommandFailedMessage)).Error(),
+			expect: func(s *mock_runcommands.MockRunCommandScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_runcommands.MockinstanceViewGetterMockRecorder) {
+				s.RunCommandSpecs().Return([]azure.ResourceSpecGetter{&runCommandSpec1, &runCommandSpec2})
+				s.GetCondition(RunCommandSucceededCondition(runCommandSpec1.Name)).Return(nil)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &runCommandSpec1, serviceName).Return(nil, internalError)
+				v.GetWithInstanceView(gomockinternal.AContext(), &runCommandSpec1).Return(failedRunCommand, nil)
+				s.SetConditionFalse(RunCommandSucceededCondition(runCommandSpec1.Name), infrav1.RunCommandFailedReason, clusterv1.ConditionSeverityError, runCommandFailedMessage)
+				s.RecordEvent(corev1.EventTypeWarning, infrav1.RunCommandFailedReason, "%s", runCommandFailedMessage)
+			},
+		},
+		{
+			name:          "run command already failed",
+			expectedError: azure.WithTerminalError(errors.New(runCommandFailedMessage)).Error(),
+			expect: func(s *mock_runcommands.MockRunCommandScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_runcommands.MockinstanceViewGetterMockRecorder) {
+				s.RunCommandSpecs().Return([]azure.ResourceSpecGetter{&runCommandSpec1, &runCommandSpec2})
+				s.GetCondition(RunCommandSucceededCondition(runCommandSpec1.Name)).Return(&clusterv1.Condition{
+					Status:  corev1.ConditionFalse,
+					Reason:  infrav1.RunCommandFailedReason,
+					Message: runCommandFailedMessage,
+				})
+			},
+		},
+		{
+			name:          "run command is still running",
+			expectedError: errors.Wrap(notDoneError, "run command my-run-command-1 is still running").Error(),
+			expect: func(s *mock_runcommands.MockRunCommandScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_runcommands.MockinstanceViewGetterMockRecorder) {
+				s.RunCommandSpecs().Return([]azure.ResourceSpecGetter{&runCommandSpec1, &runCommandSpec2})
+				s.GetCondition(RunCommandSucceededCondition(runCommandSpec1.Name)).Return(nil)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &runCommandSpec1, serviceName).Return(nil, notDoneError)
+				s.SetConditionFalse(RunCommandSucceededCondition(runCommandSpec1.Name), infrav1.RunCommandRunningReason, clusterv1.ConditionSeverityInfo, "run command my-run-command-1 is running")
+			},
+		},
+		{
+			name:          "operation completed before the script",
+			expectedError: "run command my-run-command-1 execution state is Running. Object will be requeued after 15s",
+			expect: func(s *mock_runcommands.MockRunCommandScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_runcommands.MockinstanceViewGetterMockRecorder) {
+				s.RunCommandSpecs().Return([]azure.ResourceSpecGetter{&runCommandSpec1})
+				s.GetCondition(RunCommandSucceededCondition(runCommandSpec1.Name)).Return(nil)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &runCommandSpec1, serviceName).Return(nil, nil)
+				v.GetWithInstanceView(gomockinternal.AContext(), &runCommandSpec1).Return(runningRunCommand, nil)
+				s.SetConditionFalse(RunCommandSucceededCondition(runCommandSpec1.Name), infrav1.RunCommandRunningReason, clusterv1.ConditionSeverityInfo, "run command my-run-command-1 is running")
+			},
+		},
+		{
+			name:          "error creating the run command",
+			expectedError: internalError.Error(),
+			expect: func(s *mock_runcommands.MockRunCommandScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_runcommands.MockinstanceViewGetterMockRecorder) {
+				s.RunCommandSpecs().Return([]azure.ResourceSpecGetter{&runCommandSpec1})
+				s.GetCondition(RunCommandSucceededCondition(runCommandSpec1.Name)).Return(nil)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &runCommandSpec1, serviceName).Return(nil, internalError)
+				v.GetWithInstanceView(gomockinternal.AContext(), &runCommandSpec1).Return(compute.VirtualMachineRunCommand{}, internalError)
+			},
+		},
+		{
+			name:          "run commands run in order",
+			expectedError: "",
+			expect: func(s *mock_runcommands.MockRunCommandScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_runcommands.MockinstanceViewGetterMockRecorder) {
+				s.RunCommandSpecs().Return([]azure.ResourceSpecGetter{&runCommandSpec1, &runCommandSpec2})
+				gomock.InOrder(
+					s.GetCondition(RunCommandSucceededCondition(runCommandSpec1.Name)).Return(&clusterv1.Condition{Status: corev1.ConditionTrue}),
+					s.GetCondition(RunCommandSucceededCondition(runCommandSpec2.Name)).Return(nil),
+					r.CreateOrUpdateResource(gomockinternal.AContext(), &runCommandSpec2, serviceName).Return(nil, nil),
+					v.GetWithInstanceView(gomockinternal.AContext(), &runCommandSpec2).Return(succeededRunCommand, nil),
+					s.SetConditionTrue(RunCommandSucceededCondition(runCommandSpec2.Name)),
+					s.RecordEvent(corev1.EventTypeNormal, infrav1.RunCommandSucceededReason, "run command %s succeeded: %s", "my-run-command-2", "hello"),
+				)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_runcommands.NewMockRunCommandScope(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+			instanceViewMock := mock_runcommands.NewMockinstanceViewGetter(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT(), instanceViewMock.EXPECT())
+
+			s := &Service{
+				Scope:              scopeMock,
+				Reconciler:         asyncMock,
+				instanceViewGetter: instanceViewMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestExecutionDetails(t *testing.T) {
+	g := NewWithT(t)
+
+	stderr := strings.Repeat("a", 2*maxOutputLength) + "the actual error"
+	details := executionDetails(compute.VirtualMachineRunCommandInstanceView{Error: ptr.To(stderr)})
+	g.Expect(details).To(HaveSuffix("the actual error"))
+	g.Expect(details).To(HaveLen(maxOutputLength + len("...")))
+
+	details = executionDetails(compute.VirtualMachineRunCommandInstanceView{
+		Error:            ptr.To("  \n"),
+		ExecutionMessage: ptr.To("Failed to download the script"),
+	})
+	g.Expect(details).To(Equal("Failed to download the script"))
+	g.Expect(executionDetails(compute.VirtualMachineRunCommandInstanceView{})).To(BeEmpty())
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runcommands
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+// RunCommandSpec defines the specification for a VM run command.
+type RunCommandSpec struct {
+	Name             string
+	VMName           string
+	ResourceGroup    string
+	Location         string
+	Script           string
+	ScriptURI        string
+	ScriptParameters []infrav1.RunCommandParameter
+	TimeoutInSeconds *int32
+}
+
+// ResourceName returns the name of the VM run command.
+func (s *RunCommandSpec) ResourceName() string {
+	return s.Name
+}
+
+// ResourceGroupName returns the name of the resource group.
+func (s *RunCommandSpec) ResourceGroupName() string {
+	return s.ResourceGroup
+}
+
+// OwnerResourceName returns the name of the VM that owns this VM run command.
+func (s *RunCommandSpec) OwnerResourceName() string {
+	return s.VMName
+}
+
+// Parameters returns the parameters for the VM run command.
+func (s *RunCommandSpec) Parameters(ctx context.Context, existing interface{}) (interface{}, error) {
+	if existing != nil {
+		if _, ok := existing.(compute.VirtualMachineRunCommand); !ok {
+			return nil, errors.Errorf("%T is not a compute.VirtualMachineRunCommand", existing)
+		}
+
+		// The run command already ran, it must not run again.
+		return nil, nil
+	}
+
+	source := &compute.VirtualMachineRunCommandScriptSource{}
+	if s.Script != "" {
+		source.Script = ptr.To(s.Script)
+	}
+	if s.ScriptURI != "" {
+		source.ScriptURI = ptr.To(s.ScriptURI)
+	}
+
+	var parameters *[]compute.RunCommandInputParameter
+	if len(s.ScriptParameters) > 0 {
+		inputParameters := make([]compute.RunCommandInputParameter, 0, len(s.ScriptParameters))
+		for _, parameter := range s.ScriptParameters {
+			inputParameters = append(inputParameters, compute.RunCommandInputParameter{
+				Name:  ptr.To(parameter.Name),
+				Value: ptr.To(parameter.Value),
+			})
+		}
+		parameters = &inputParameters
+	}
+
+	return compute.VirtualMachineRunCommand{
+		VirtualMachineRunCommandProperties: &compute.VirtualMachineRunCommandProperties{
+			Source:     source,
+			Parameters: parameters,
+			// The operation completes once the script completes, so that its result is in the instance view.
+			AsyncExecution:   ptr.To(false),
+			TimeoutInSeconds: s.TimeoutInSeconds,
+		},
+		Location: ptr.To(s.Location),
+	}, nil
+}
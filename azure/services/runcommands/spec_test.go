@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runcommands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+var (
+	fakeRunCommandSpec = RunCommandSpec{
+		Name:          "my-run-command",
+		VMName:        "my-vm",
+		ResourceGroup: "my-rg",
+		Location:      "my-location",
+		Script:        "echo $GREETING",
+		ScriptParameters: []infrav1.RunCommandParameter{
+			{Name: "GREETING", Value: "hello"},
+		},
+		TimeoutInSeconds: ptr.To[int32](600),
+	}
+
+	fakeRunCommandParams = compute.VirtualMachineRunCommand{
+		VirtualMachineRunCommandProperties: &compute.VirtualMachineRunCommandProperties{
+			Source: &compute.VirtualMachineRunCommandScriptSource{
+				Script: ptr.To("echo $GREETING"),
+			},
+			Parameters: &[]compute.RunCommandInputParameter{
+				{Name: ptr.To("GREETING"), Value: ptr.To("hello")},
+			},
+			AsyncExecution:   ptr.To(false),
+			TimeoutInSeconds: ptr.To[int32](600),
+		},
+		Location: ptr.To("my-location"),
+	}
+
+	fakeScriptURIRunCommandSpec = RunCommandSpec{
+		Name:          "my-run-command",
+		VMName:        "my-vm",
+		ResourceGroup: "my-rg",
+		Location:      "my-location",
+		ScriptURI:     "https://mystorage.blob.core.windows.net/scripts/post-provision.sh",
+	}
+)
+
+func TestParameters(t *testing.T) {
+	testcases := []struct {
+		name          string
+		spec          *RunCommandSpec
+		existing      interface{}
+		expect        func(g *WithT, result interface{})
+		expectedError string
+	}{
+		{
+			name:     "get parameters for run command with an inline script",
+			spec:     &fakeRunCommandSpec,
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(Equal(fakeRunCommandParams))
+			},
+			expectedError: "",
+		},
+		{
+			name:     "get parameters for run command with a script URI",
+			spec:     &fakeScriptURIRunCommandSpec,
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(Equal(compute.VirtualMachineRunCommand{
+					VirtualMachineRunCommandProperties: &compute.VirtualMachineRunCommandProperties{
+						Source: &compute.VirtualMachineRunCommandScriptSource{
+							ScriptURI: ptr.To("https://mystorage.blob.core.windows.net/scripts/post-provision.sh"),
+						},
+						AsyncExecution: ptr.To(false),
+					},
+					Location: ptr.To("my-location"),
+				}))
+			},
+			expectedError: "",
+		},
+		{
+			name:     "run command that already exists",
+			spec:     &fakeRunCommandSpec,
+			existing: fakeRunCommandParams,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+			expectedError: "",
+		},
+		{
+			name:     "existing is not a run command",
+			spec:     &fakeRunCommandSpec,
+			existing: struct{}{},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+			expectedError: "struct {} is not a compute.VirtualMachineRunCommand",
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			result, err := tc.spec.Parameters(context.TODO(), tc.existing)
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			tc.expect(g, result)
+		})
+	}
+}
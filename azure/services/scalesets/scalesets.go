@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 	azureutil "sigs.k8s.io/cluster-api-provider-azure/util/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/util/generators"
 	"sigs.k8s.io/cluster-api-provider-azure/util/slice"
@@ -568,15 +569,15 @@ func (s *Service) buildVMSSFromSpec(ctx context.Context, vmssSpec azure.ScaleSet
 		}
 	}
 
-	tags := infrav1.Build(infrav1.BuildParams{
-		ClusterName: s.Scope.ClusterName(),
-		Lifecycle:   infrav1.ResourceLifecycleOwned,
-		Name:        ptr.To(vmssSpec.Name),
-		Role:        ptr.To(infrav1.Node),
-		Additional:  s.Scope.AdditionalTags(),
+	vmssTags := tags.Merge(tags.MergeParams{
+		ClusterName:  s.Scope.ClusterName(),
+		Lifecycle:    infrav1.ResourceLifecycleOwned,
+		Name:         ptr.To(vmssSpec.Name),
+		Role:         ptr.To(infrav1.Node),
+		ResourceTags: s.Scope.AdditionalTags(),
 	})
 
-	vmss.Tags = converters.TagsToMap(tags)
+	vmss.Tags = converters.TagsToMap(vmssTags)
 	return vmss, nil
 }
 
@@ -741,10 +742,12 @@ func (s *Service) generateStorageProfile(ctx context.Context, vmssSpec azure.Sca
 	dataDisks := make([]compute.VirtualMachineScaleSetDataDisk, len(vmssSpec.DataDisks))
 	for i, disk := range vmssSpec.DataDisks {
 		dataDisks[i] = compute.VirtualMachineScaleSetDataDisk{
-			CreateOption: compute.DiskCreateOptionTypesEmpty,
-			DiskSizeGB:   ptr.To[int32](disk.DiskSizeGB),
-			Lun:          disk.Lun,
-			Name:         ptr.To(azure.GenerateDataDiskName(vmssSpec.Name, disk.NameSuffix)),
+			CreateOption:      compute.DiskCreateOptionTypesEmpty,
+			DiskSizeGB:        ptr.To[int32](disk.DiskSizeGB),
+			Lun:               disk.Lun,
+			Name:              ptr.To(azure.GenerateDataDiskName(vmssSpec.Name, disk.NameSuffix)),
+			DiskIOPSReadWrite: disk.DiskIOPSReadWrite,
+			DiskMBpsReadWrite: disk.DiskMBpsReadWrite,
 		}
 
 		if disk.ManagedDisk != nil {
@@ -840,6 +843,14 @@ func (s *Service) generateImagePlan(ctx context.Context) *compute.Plan {
 		}
 	}
 
+	if image.Marketplace != nil && image.Marketplace.Plan != nil {
+		return &compute.Plan{
+			Publisher: ptr.To(image.Marketplace.Plan.Publisher),
+			Name:      ptr.To(image.Marketplace.Plan.SKU),
+			Product:   ptr.To(image.Marketplace.Plan.Offer),
+		}
+	}
+
 	if image.Marketplace == nil || !image.Marketplace.ThirdPartyImage {
 		return nil
 	}
@@ -24,7 +24,9 @@ import (
 	"github.com/pkg/errors"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 )
 
 // NSGSpec defines the specification for a security group.
@@ -36,6 +38,7 @@ type NSGSpec struct {
 	ResourceGroup            string
 	AdditionalTags           infrav1.Tags
 	LastAppliedSecurityRules map[string]interface{}
+	RulePriorityBand         *infrav1.SecurityRulePriorityBand
 }
 
 // ResourceName returns the name of the security group.
@@ -70,7 +73,11 @@ func (s *NSGSpec) Parameters(ctx context.Context, existing interface{}) (interfa
 		// Check if the expected rules are present
 		update := false
 
-		for _, rule := range s.SecurityRules {
+		rules, err := s.assignRulePriorities(*existingNSG.SecurityRules)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range rules {
 			sdkRule := converters.SecurityRuleToSDK(rule)
 			if !ruleExists(*existingNSG.SecurityRules, sdkRule) {
 				update = true
@@ -98,7 +105,11 @@ func (s *NSGSpec) Parameters(ctx context.Context, existing interface{}) (interfa
 		}
 	} else {
 		// new security group
-		for _, rule := range s.SecurityRules {
+		rules, err := s.assignRulePriorities(nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range rules {
 			securityRules = append(securityRules, converters.SecurityRuleToSDK(rule))
 		}
 	}
@@ -109,15 +120,65 @@ func (s *NSGSpec) Parameters(ctx context.Context, existing interface{}) (interfa
 			SecurityRules: &securityRules,
 		},
 		Etag: etag,
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
 			ClusterName: s.ClusterName,
 			Lifecycle:   infrav1.ResourceLifecycleOwned,
 			Name:        ptr.To(s.Name),
-			Additional:  s.AdditionalTags,
+			ClusterTags: s.AdditionalTags,
 		})),
 	}, nil
 }
 
+// assignRulePriorities returns the security rules with a priority of the rule priority band assigned to the rules
+// without one. A rule keeps the priority it already has in the existing security group so that the priorities don't
+// move across reconciliations, otherwise it gets the lowest priority of the band not used by any other rule.
+func (s *NSGSpec) assignRulePriorities(existingRules []network.SecurityRule) (infrav1.SecurityRules, error) {
+	if s.RulePriorityBand == nil {
+		return s.SecurityRules, nil
+	}
+	band := *s.RulePriorityBand
+
+	used := make(map[int32]bool, len(existingRules)+len(s.SecurityRules))
+	existingPriorities := make(map[string]int32, len(existingRules))
+	for _, rule := range existingRules {
+		priority := ptr.Deref(rule.Priority, 0)
+		used[priority] = true
+		existingPriorities[strings.ToLower(ptr.Deref(rule.Name, ""))] = priority
+	}
+	for _, rule := range s.SecurityRules {
+		if rule.Priority != 0 {
+			used[rule.Priority] = true
+		}
+	}
+
+	rules := make(infrav1.SecurityRules, len(s.SecurityRules))
+	var unassigned []int
+	for i, rule := range s.SecurityRules {
+		rules[i] = rule
+		if rule.Priority != 0 {
+			continue
+		}
+		if priority, ok := existingPriorities[strings.ToLower(rule.Name)]; ok && priority >= band.Min && priority <= band.Max {
+			rules[i].Priority = priority
+			continue
+		}
+		unassigned = append(unassigned, i)
+	}
+
+	next := band.Min
+	for _, i := range unassigned {
+		for next <= band.Max && used[next] {
+			next++
+		}
+		if next > band.Max {
+			return nil, azure.WithTerminalError(errors.Errorf("no priority is left in the band %d-%d of security group %s for security rule %s", band.Min, band.Max, s.Name, rules[i].Name))
+		}
+		rules[i].Priority = next
+		used[next] = true
+	}
+	return rules, nil
+}
+
 // TODO: review this logic and make sure it is what we want. It seems incorrect to skip rules that don't have a certain protocol, etc.
 func ruleExists(rules []network.SecurityRule, rule network.SecurityRule) bool {
 	for _, existingRule := range rules {
@@ -247,6 +247,80 @@ func TestParameters(t *testing.T) {
 				}))
 			},
 		},
+		{
+			name: "NSG does not exist and rules without a priority get one from the band",
+			spec: &NSGSpec{
+				Name:             "test-nsg",
+				Location:         "test-location",
+				SecurityRules:    infrav1.SecurityRules{withPriority(sshRule, 0), customRule, withPriority(otherRule, 0)},
+				RulePriorityBand: &infrav1.SecurityRulePriorityBand{Min: 1000, Max: 1009},
+				ResourceGroup:    "test-group",
+				ClusterName:      "my-cluster",
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.SecurityGroup{}))
+				g.Expect(*result.(network.SecurityGroup).SecurityRules).To(Equal([]network.SecurityRule{
+					converters.SecurityRuleToSDK(withPriority(sshRule, 1000)),
+					converters.SecurityRuleToSDK(customRule),
+					converters.SecurityRuleToSDK(withPriority(otherRule, 1001)),
+				}))
+			},
+		},
+		{
+			name: "NSG already exists and rules without a priority keep the priority assigned before",
+			spec: &NSGSpec{
+				Name:             "test-nsg",
+				Location:         "test-location",
+				SecurityRules:    infrav1.SecurityRules{withPriority(otherRule, 0), withPriority(sshRule, 0)},
+				RulePriorityBand: &infrav1.SecurityRulePriorityBand{Min: 1000, Max: 1009},
+				ResourceGroup:    "test-group",
+				ClusterName:      "my-cluster",
+			},
+			existing: network.SecurityGroup{
+				Name:     ptr.To("test-nsg"),
+				Location: ptr.To("test-location"),
+				Etag:     ptr.To("fake-etag"),
+				SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+					SecurityRules: &[]network.SecurityRule{
+						converters.SecurityRuleToSDK(withPriority(customRule, 1000)),
+						converters.SecurityRuleToSDK(withPriority(sshRule, 1001)),
+					},
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.SecurityGroup{}))
+				g.Expect(*result.(network.SecurityGroup).SecurityRules).To(Equal([]network.SecurityRule{
+					converters.SecurityRuleToSDK(withPriority(otherRule, 1002)),
+					converters.SecurityRuleToSDK(withPriority(customRule, 1000)),
+					converters.SecurityRuleToSDK(withPriority(sshRule, 1001)),
+				}))
+			},
+		},
+		{
+			name: "NSG already exists and no priority is left in the band",
+			spec: &NSGSpec{
+				Name:             "test-nsg",
+				Location:         "test-location",
+				SecurityRules:    infrav1.SecurityRules{withPriority(otherRule, 0)},
+				RulePriorityBand: &infrav1.SecurityRulePriorityBand{Min: 1000, Max: 1000},
+				ResourceGroup:    "test-group",
+				ClusterName:      "my-cluster",
+			},
+			existing: network.SecurityGroup{
+				Name:     ptr.To("test-nsg"),
+				Location: ptr.To("test-location"),
+				SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+					SecurityRules: &[]network.SecurityRule{
+						converters.SecurityRuleToSDK(withPriority(customRule, 1000)),
+					},
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+			expectedError: "reconcile error that cannot be recovered occurred: no priority is left in the band 1000-1000 of security group test-nsg for security rule other_rule. Object will not be requeued",
+		},
 	}
 
 	for _, tc := range testcases {
@@ -267,6 +341,11 @@ func TestParameters(t *testing.T) {
 	}
 }
 
+func withPriority(rule infrav1.SecurityRule, priority int32) infrav1.SecurityRule {
+	rule.Priority = priority
+	return rule
+}
+
 func TestRuleExists(t *testing.T) {
 	testcases := []struct {
 		name     string
@@ -128,6 +128,11 @@ func (s *SubnetSpec) shouldUpdate(existingSubnet network.Subnet) bool {
 		return true
 	}
 
+	// Update the subnet if a route table was added, so it gets associated with the subnet.
+	if s.RouteTableName != "" && existingSubnet.SubnetPropertiesFormat.RouteTable == nil {
+		return true
+	}
+
 	// Update the subnet if the service endpoints changed.
 	if existingSubnet.ServiceEndpoints != nil || len(s.ServiceEndpoints) > 0 {
 		var existingServiceEndpoints []network.ServiceEndpointPropertiesFormat
@@ -245,6 +245,46 @@ func TestSubnetSpec_shouldUpdate(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "subnet should be updated when a route table gets added",
+			fields: fields{
+				Name:           "my-subnet",
+				ResourceGroup:  "my-rg",
+				SubscriptionID: "123",
+				IsVNetManaged:  true,
+				RouteTableName: "my-route-table",
+			},
+			args: args{
+				existingSubnet: network.Subnet{
+					Name: ptr.To("my-subnet"),
+					SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+						RouteTable: nil,
+					},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "subnet should not be updated when the route table is already associated",
+			fields: fields{
+				Name:           "my-subnet",
+				ResourceGroup:  "my-rg",
+				SubscriptionID: "123",
+				IsVNetManaged:  true,
+				RouteTableName: "my-route-table",
+			},
+			args: args{
+				existingSubnet: network.Subnet{
+					Name: ptr.To("my-subnet"),
+					SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+						RouteTable: &network.RouteTable{
+							ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/routeTables/my-route-table"),
+						},
+					},
+				},
+			},
+			want: false,
+		},
 		{
 			name: "subnet should be updated if service endpoints changed",
 			fields: fields{
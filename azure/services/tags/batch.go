@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tags
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+)
+
+// DefaultUpdateBatchSize is the default maximum number of tag update requests sent to ARM in a single reconcile.
+// The tags of the remaining resources are updated in the following reconciles, so that retagging many resources
+// doesn't exhaust the ARM write limits of the subscription in a burst.
+const DefaultUpdateBatchSize = 50
+
+// errBatchFull is returned when a resource needs a tag update but the update budget of the reconcile is spent.
+var errBatchFull = errors.New("tag update batch is full")
+
+// updateBatch tracks the tag update requests sent to ARM during a reconcile.
+type updateBatch struct {
+	size int
+	sent int
+}
+
+// reserve reserves the budget for n tag update requests. It returns errBatchFull if the batch can't fit them. The
+// requests of the first resource always fit so that every reconcile makes progress.
+func (b *updateBatch) reserve(n int) error {
+	if b.sent > 0 && b.sent+n > b.size {
+		return errBatchFull
+	}
+	b.sent += n
+	return nil
+}
+
+// coalesceTagsSpecs merges the tags specs of the same resource recorded in the same annotation, so that the tags of a
+// resource are read and updated once per reconcile. Tags of later specs take precedence, and the order of the first
+// spec of each resource is kept.
+func coalesceTagsSpecs(tagsSpecs []azure.TagsSpec) []azure.TagsSpec {
+	type key struct {
+		scope      string
+		annotation string
+	}
+	coalesced := make([]azure.TagsSpec, 0, len(tagsSpecs))
+	index := make(map[key]int, len(tagsSpecs))
+	for _, tagsSpec := range tagsSpecs {
+		k := key{scope: tagsSpec.Scope, annotation: tagsSpec.Annotation}
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(coalesced)
+			coalesced = append(coalesced, tagsSpec)
+			continue
+		}
+		merged := make(infrav1.Tags, len(coalesced[i].Tags)+len(tagsSpec.Tags))
+		for name, value := range coalesced[i].Tags {
+			merged[name] = value
+		}
+		for name, value := range tagsSpec.Tags {
+			merged[name] = value
+		}
+		coalesced[i].Tags = merged
+	}
+	return coalesced
+}
+
+// throttlingRetryAfter returns how long to wait before sending requests to ARM again after a throttling (HTTP 429)
+// response, and false if the error is not a throttling response. It honors the Retry-After header of the response,
+// and falls back to reconciler.DefaultHTTP429RetryAfter.
+func throttlingRetryAfter(err error) (time.Duration, bool) {
+	var detailedError autorest.DetailedError
+	if !errors.As(err, &detailedError) || detailedError.Response == nil || detailedError.Response.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	retryAfter := detailedError.Response.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := time.Parse(time.RFC1123, retryAfter); err == nil && time.Until(t) > 0 {
+		return time.Until(t), true
+	}
+	return reconciler.DefaultHTTP429RetryAfter, true
+}
+
+// throttledOr returns a transient error requeued after the Retry-After delay if err is an ARM throttling response,
+// and err otherwise.
+func throttledOr(err error) error {
+	if retryAfter, ok := throttlingRetryAfter(err); ok {
+		return azure.WithTransientError(err, retryAfter)
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tags
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+)
+
+func TestUpdateBatchReserve(t *testing.T) {
+	g := NewWithT(t)
+
+	batch := &updateBatch{size: 2}
+	// The first resource always fits, even if it needs more requests than the batch size.
+	g.Expect(batch.reserve(3)).To(Succeed())
+	g.Expect(batch.reserve(1)).To(MatchError(errBatchFull))
+	g.Expect(batch.sent).To(Equal(3))
+
+	batch = &updateBatch{size: 3}
+	g.Expect(batch.reserve(2)).To(Succeed())
+	g.Expect(batch.reserve(2)).To(MatchError(errBatchFull))
+	g.Expect(batch.reserve(1)).To(Succeed())
+	g.Expect(batch.sent).To(Equal(3))
+}
+
+func TestCoalesceTagsSpecs(t *testing.T) {
+	g := NewWithT(t)
+
+	tagsSpecs := []azure.TagsSpec{
+		{Scope: "/vm", Tags: map[string]string{"a": "1", "b": "1"}, Annotation: "vm-annotation"},
+		{Scope: "/disk", Tags: map[string]string{"a": "1"}, Annotation: "disk-annotation"},
+		{Scope: "/vm", Tags: map[string]string{"b": "2", "c": "2"}, Annotation: "vm-annotation"},
+		{Scope: "/vm", Tags: map[string]string{"d": "3"}, Annotation: "other-annotation"},
+	}
+	g.Expect(coalesceTagsSpecs(tagsSpecs)).To(Equal([]azure.TagsSpec{
+		{Scope: "/vm", Tags: map[string]string{"a": "1", "b": "2", "c": "2"}, Annotation: "vm-annotation"},
+		{Scope: "/disk", Tags: map[string]string{"a": "1"}, Annotation: "disk-annotation"},
+		{Scope: "/vm", Tags: map[string]string{"d": "3"}, Annotation: "other-annotation"},
+	}))
+	// The tags of the input specs are left untouched.
+	g.Expect(tagsSpecs[0].Tags).To(Equal(infrav1.Tags{"a": "1", "b": "1"}))
+}
+
+func TestThrottlingRetryAfter(t *testing.T) {
+	tests := []struct {
+		name               string
+		err                error
+		expectedThrottled  bool
+		expectedRetryAfter time.Duration
+	}{
+		{
+			name: "throttled with Retry-After in seconds",
+			err: autorest.NewErrorWithResponse("", "", &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{"Retry-After": []string{"17"}},
+			}, "Too Many Requests"),
+			expectedThrottled:  true,
+			expectedRetryAfter: 17 * time.Second,
+		},
+		{
+			name:               "throttled without Retry-After",
+			err:                errors.Wrap(autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusTooManyRequests}, "Too Many Requests"), "cannot update tags"),
+			expectedThrottled:  true,
+			expectedRetryAfter: reconciler.DefaultHTTP429RetryAfter,
+		},
+		{
+			name:              "not throttled",
+			err:               autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error"),
+			expectedThrottled: false,
+		},
+		{
+			name:              "not an HTTP error",
+			err:               errors.New("boom"),
+			expectedThrottled: false,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			retryAfter, throttled := throttlingRetryAfter(tc.err)
+			g.Expect(throttled).To(Equal(tc.expectedThrottled))
+			g.Expect(retryAfter).To(Equal(tc.expectedRetryAfter))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tags
+
+import (
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+// MergeParams are the inputs to Merge.
+type MergeParams struct {
+	// ClusterName is the name of the cluster owning the resource.
+	ClusterName string
+	// Lifecycle is the lifecycle of the resource, i.e. owned or shared.
+	Lifecycle infrav1.ResourceLifecycle
+	// Name is the value of the Name tag. It is not set when nil.
+	Name *string
+	// Role is the value of the role tag. It is not set when nil.
+	Role *string
+	// ClusterTags are the additional tags of the AzureCluster or AzureManagedControlPlane.
+	ClusterTags infrav1.Tags
+	// ResourceTags are the additional tags of the object the resource is created for, e.g. the AzureMachine.
+	ResourceTags infrav1.Tags
+}
+
+// Merge returns the tags of a resource managed by CAPZ. Tags are merged with the following precedence, from lowest
+// to highest:
+//
+//  1. cluster-level additional tags, e.g. AzureCluster spec.additionalTags;
+//  2. resource-level additional tags, e.g. AzureMachine spec.additionalTags;
+//  3. tags owned by CAPZ: the cluster ownership tag, the role tag and the Name tag.
+//
+// Tags owned by CAPZ always win so that user-provided tags can never break the tracking of resource ownership.
+func Merge(params MergeParams) infrav1.Tags {
+	return infrav1.Build(infrav1.BuildParams{
+		ClusterName: params.ClusterName,
+		Lifecycle:   params.Lifecycle,
+		Name:        params.Name,
+		Role:        params.Role,
+		Additional:  MergeAdditional(params.ClusterTags, params.ResourceTags),
+	})
+}
+
+// MergeAdditional merges cluster-level and resource-level additional tags.
+// Resource-level tags take precedence over cluster-level tags with the same key.
+func MergeAdditional(clusterTags, resourceTags infrav1.Tags) infrav1.Tags {
+	tags := make(infrav1.Tags, len(clusterTags)+len(resourceTags))
+	tags.Merge(clusterTags)
+	tags.Merge(resourceTags)
+	return tags
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tags
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+func TestMerge(t *testing.T) {
+	testcases := []struct {
+		name   string
+		params MergeParams
+		want   infrav1.Tags
+	}{
+		{
+			name: "only CAPZ-owned tags",
+			params: MergeParams{
+				ClusterName: "my-cluster",
+				Lifecycle:   infrav1.ResourceLifecycleOwned,
+				Name:        ptr.To("my-vm"),
+				Role:        ptr.To(infrav1.Node),
+			},
+			want: infrav1.Tags{
+				"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": "owned",
+				"sigs.k8s.io_cluster-api-provider-azure_role":               "node",
+				"Name": "my-vm",
+			},
+		},
+		{
+			name: "cluster tags are applied to the resource",
+			params: MergeParams{
+				ClusterName: "my-cluster",
+				Lifecycle:   infrav1.ResourceLifecycleOwned,
+				ClusterTags: infrav1.Tags{"costcenter": "cluster", "env": "prod"},
+			},
+			want: infrav1.Tags{
+				"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": "owned",
+				"costcenter": "cluster",
+				"env":        "prod",
+			},
+		},
+		{
+			name: "resource tags take precedence over cluster tags",
+			params: MergeParams{
+				ClusterName:  "my-cluster",
+				Lifecycle:    infrav1.ResourceLifecycleOwned,
+				ClusterTags:  infrav1.Tags{"costcenter": "cluster", "env": "prod"},
+				ResourceTags: infrav1.Tags{"costcenter": "machine", "team": "infra"},
+			},
+			want: infrav1.Tags{
+				"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": "owned",
+				"costcenter": "machine",
+				"env":        "prod",
+				"team":       "infra",
+			},
+		},
+		{
+			name: "CAPZ-owned tags take precedence over cluster and resource tags",
+			params: MergeParams{
+				ClusterName: "my-cluster",
+				Lifecycle:   infrav1.ResourceLifecycleOwned,
+				Name:        ptr.To("my-vm"),
+				Role:        ptr.To(infrav1.ControlPlane),
+				ClusterTags: infrav1.Tags{
+					"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": "shared",
+					"Name": "cluster-name",
+				},
+				ResourceTags: infrav1.Tags{
+					"sigs.k8s.io_cluster-api-provider-azure_role": "node",
+					"Name": "resource-name",
+				},
+			},
+			want: infrav1.Tags{
+				"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": "owned",
+				"sigs.k8s.io_cluster-api-provider-azure_role":               "control-plane",
+				"Name": "my-vm",
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			g.Expect(Merge(tc.params)).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestMergeAdditional(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterTags := infrav1.Tags{"costcenter": "cluster", "env": "prod"}
+	resourceTags := infrav1.Tags{"costcenter": "machine"}
+
+	g.Expect(MergeAdditional(clusterTags, resourceTags)).To(Equal(infrav1.Tags{"costcenter": "machine", "env": "prod"}))
+	// The inputs are not modified.
+	g.Expect(clusterTags).To(Equal(infrav1.Tags{"costcenter": "cluster", "env": "prod"}))
+	g.Expect(MergeAdditional(nil, nil)).To(BeEmpty())
+}
@@ -21,9 +21,12 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-10-01/resources"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
@@ -42,6 +45,9 @@ type TagScope interface {
 type Service struct {
 	Scope TagScope
 	client
+	// UpdateBatchSize is the maximum number of tag update requests sent to ARM in a single reconcile.
+	// DefaultUpdateBatchSize is used when it is not set.
+	UpdateBatchSize int
 }
 
 // New creates a new service.
@@ -63,6 +69,8 @@ func (s *Service) Name() string {
 // interpreted as managed.
 var alwaysManagedAnnotations = map[string]struct{}{
 	azure.ManagedClusterTagsLastAppliedAnnotation: {},
+	// Disks created implicitly with a VM do not carry the "owned" tag until CAPZ tags them.
+	azure.DiskTagsLastAppliedAnnotation: {},
 }
 
 // Reconcile ensures tags are correct.
@@ -70,59 +78,126 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	ctx, log, done := tele.StartSpanWithLogger(ctx, "tags.Service.Reconcile")
 	defer done()
 
-	for _, tagsSpec := range s.Scope.TagsSpecs() {
-		existingTags, err := s.client.GetAtScope(ctx, tagsSpec.Scope)
+	tagsSpecs := coalesceTagsSpecs(s.Scope.TagsSpecs())
+	batch := &updateBatch{size: s.UpdateBatchSize}
+	if batch.size <= 0 {
+		batch.size = DefaultUpdateBatchSize
+	}
+
+	// Several specs may share the same annotation, e.g. all of a machine's disks. The last applied tags are
+	// read once per annotation so every resource is compared against the same previous state, and the
+	// annotation is only written back once the last spec sharing it has been reconciled.
+	remaining := make(map[string]int)
+	for _, tagsSpec := range tagsSpecs {
+		remaining[tagsSpec.Annotation]++
+	}
+	lastApplied := make(map[string]map[string]interface{})
+	pending := make(map[string]map[string]interface{})
+
+	for _, tagsSpec := range tagsSpecs {
+		remaining[tagsSpec.Annotation]--
+
+		newAnnotation, err := s.reconcileTagsSpec(ctx, tagsSpec, lastApplied, batch)
+		if errors.Is(err, errBatchFull) {
+			// The annotations of the resources left to update are not written, so they are compared against the
+			// same previous state in the next reconcile. Tags already deleted from a resource are not deleted again.
+			log.V(2).Info("Deferring tag updates to the next reconcile", "sent", batch.sent)
+			return azure.WithTransientError(errors.Errorf("sent %d tag updates, the tags of the remaining resources will be updated in the next reconcile", batch.sent), reconciler.DefaultReconcilerRequeue)
+		}
 		if err != nil {
-			return errors.Wrap(err, "failed to get existing tags")
+			return err
 		}
-		tags := make(map[string]*string)
-		if existingTags.Properties != nil && existingTags.Properties.Tags != nil {
-			tags = existingTags.Properties.Tags
+		if newAnnotation != nil {
+			pending[tagsSpec.Annotation] = newAnnotation
 		}
 
-		if _, alwaysManaged := alwaysManagedAnnotations[tagsSpec.Annotation]; !alwaysManaged && !s.isResourceManaged(tags) {
-			log.V(4).Info("Skipping tags reconcile for not managed resource")
-			continue
+		// We also need to update the annotation even if nothing changed to
+		// ensure it's set immediately following resource creation.
+		if annotation, ok := pending[tagsSpec.Annotation]; ok && remaining[tagsSpec.Annotation] == 0 {
+			if err := s.Scope.UpdateAnnotationJSON(tagsSpec.Annotation, annotation); err != nil {
+				return err
+			}
 		}
+	}
+	return nil
+}
+
+// reconcileTagsSpec updates the tags of a single resource and returns the annotation to record for it.
+// A nil annotation is returned when the resource is not managed by CAPZ. errBatchFull is returned when the tags of
+// the resource need to be updated but the batch is full, and a transient error when ARM throttles the requests.
+func (s *Service) reconcileTagsSpec(ctx context.Context, tagsSpec azure.TagsSpec, lastApplied map[string]map[string]interface{}, batch *updateBatch) (map[string]interface{}, error) {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "tags.Service.reconcileTagsSpec")
+	defer done()
+
+	existingTags, err := s.client.GetAtScope(ctx, tagsSpec.Scope)
+	if err != nil {
+		return nil, throttledOr(errors.Wrap(err, "failed to get existing tags"))
+	}
+	tags := make(map[string]*string)
+	if existingTags.Properties != nil && existingTags.Properties.Tags != nil {
+		tags = existingTags.Properties.Tags
+	}
+
+	if _, alwaysManaged := alwaysManagedAnnotations[tagsSpec.Annotation]; !alwaysManaged && !s.isResourceManaged(tags) {
+		log.V(4).Info("Skipping tags reconcile for not managed resource")
+		return nil, nil
+	}
 
-		lastAppliedTags, err := s.Scope.AnnotationJSON(tagsSpec.Annotation)
+	lastAppliedTags, ok := lastApplied[tagsSpec.Annotation]
+	if !ok {
+		lastAppliedTags, err = s.Scope.AnnotationJSON(tagsSpec.Annotation)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		changed, createdOrUpdated, deleted, newAnnotation := TagsChanged(lastAppliedTags, tagsSpec.Tags, tags)
+		lastApplied[tagsSpec.Annotation] = lastAppliedTags
+	}
+	changed, createdOrUpdated, deleted, newAnnotation := TagsChanged(lastAppliedTags, tagsSpec.Tags, tags)
+	if dryRunner, ok := s.Scope.(azure.DryRunner); ok && dryRunner.IsDryRun() {
+		// Report the changes instead of applying them, the annotation is left untouched.
 		if changed {
-			log.V(2).Info("Updating tags")
-			if len(createdOrUpdated) > 0 {
-				createdOrUpdatedTags := make(map[string]*string)
-				for k, v := range createdOrUpdated {
-					createdOrUpdatedTags[k] = ptr.To(v)
-				}
-
-				if _, err := s.client.UpdateAtScope(ctx, tagsSpec.Scope, resources.TagsPatchResource{Operation: "Merge", Properties: &resources.Tags{Tags: createdOrUpdatedTags}}); err != nil {
-					return errors.Wrap(err, "cannot update tags")
-				}
-			}
+			log.Info("dry run: tags would be updated", "createdOrUpdated", createdOrUpdated, "deleted", deleted)
+			dryRunner.RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason, "Dry run: tags of %s would be updated: set %v, deleted %v", tagsSpec.Scope, createdOrUpdated, deleted)
+		}
+		return nil, nil
+	}
+	if changed {
+		requests := 0
+		if len(createdOrUpdated) > 0 {
+			requests++
+		}
+		if len(deleted) > 0 {
+			requests++
+		}
+		if err := batch.reserve(requests); err != nil {
+			return nil, err
+		}
 
-			if len(deleted) > 0 {
-				deletedTags := make(map[string]*string)
-				for k, v := range deleted {
-					deletedTags[k] = ptr.To(v)
-				}
+		log.V(2).Info("Updating tags")
+		if len(createdOrUpdated) > 0 {
+			createdOrUpdatedTags := make(map[string]*string)
+			for k, v := range createdOrUpdated {
+				createdOrUpdatedTags[k] = ptr.To(v)
+			}
 
-				if _, err := s.client.UpdateAtScope(ctx, tagsSpec.Scope, resources.TagsPatchResource{Operation: "Delete", Properties: &resources.Tags{Tags: deletedTags}}); err != nil {
-					return errors.Wrap(err, "cannot update tags")
-				}
+			if _, err := s.client.UpdateAtScope(ctx, tagsSpec.Scope, resources.TagsPatchResource{Operation: "Merge", Properties: &resources.Tags{Tags: createdOrUpdatedTags}}); err != nil {
+				return nil, throttledOr(errors.Wrap(err, "cannot update tags"))
 			}
-			log.V(2).Info("successfully updated tags")
 		}
 
-		// We also need to update the annotation even if nothing changed to
-		// ensure it's set immediately following resource creation.
-		if err := s.Scope.UpdateAnnotationJSON(tagsSpec.Annotation, newAnnotation); err != nil {
-			return err
+		if len(deleted) > 0 {
+			deletedTags := make(map[string]*string)
+			for k, v := range deleted {
+				deletedTags[k] = ptr.To(v)
+			}
+
+			if _, err := s.client.UpdateAtScope(ctx, tagsSpec.Scope, resources.TagsPatchResource{Operation: "Delete", Properties: &resources.Tags{Tags: deletedTags}}); err != nil {
+				return nil, throttledOr(errors.Wrap(err, "cannot update tags"))
+			}
 		}
+		log.V(2).Info("successfully updated tags")
 	}
-	return nil
+
+	return newAnnotation, nil
 }
 
 func (s *Service) isResourceManaged(tags map[string]*string) bool {
@@ -153,12 +228,15 @@ func TagsChanged(lastAppliedTags map[string]interface{}, desiredTags map[string]
 
 	// Loop over lastAppliedTags, checking if entries are in desiredTags.
 	// If an entry is present in lastAppliedTags but not in desiredTags, it has been deleted
-	// since last time. We flag this in the deleted map.
+	// since last time. We flag this in the deleted map, unless it is already absent from
+	// currentTags, e.g. because it was deleted by a previous reconcile which couldn't
+	// update the annotation yet.
 	for t, v := range lastAppliedTags {
 		_, ok := desiredTags[t]
+		_, exists := currentTags[t]
 
 		// Entry isn't in desiredTags, it has been deleted.
-		if !ok {
+		if !ok && exists {
 			// Cast v to a string here. This should be fine, tags are always
 			// strings.
 			deleted[t] = v.(string)
@@ -25,17 +25,21 @@ import (
 	"github.com/Azure/go-autorest/autorest"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/mock_azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags/mock_tags"
 	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
 )
 
 func TestReconcileTags(t *testing.T) {
 	testcases := []struct {
-		name          string
-		expect        func(s *mock_tags.MockTagScopeMockRecorder, m *mock_tags.MockclientMockRecorder)
-		expectedError string
+		name            string
+		updateBatchSize int
+		expect          func(s *mock_tags.MockTagScopeMockRecorder, m *mock_tags.MockclientMockRecorder)
+		expectedError   string
 	}{
 		{
 			name:          "create tags for managed resources",
@@ -181,6 +185,80 @@ func TestReconcileTags(t *testing.T) {
 				)
 			},
 		},
+		{
+			name:          "propagate and prune tags across child resources sharing an annotation",
+			expectedError: "",
+			expect: func(s *mock_tags.MockTagScopeMockRecorder, m *mock_tags.MockclientMockRecorder) {
+				s.ClusterName().AnyTimes().Return("test-cluster")
+				gomock.InOrder(
+					s.TagsSpecs().Return([]azure.TagsSpec{
+						{
+							Scope: "/sub/123/disks/os",
+							Tags: map[string]string{
+								"costcenter": "new",
+							},
+							Annotation: azure.DiskTagsLastAppliedAnnotation,
+						},
+						{
+							Scope: "/sub/123/disks/data",
+							Tags: map[string]string{
+								"costcenter": "new",
+							},
+							Annotation: azure.DiskTagsLastAppliedAnnotation,
+						},
+					}),
+					// Disks are always managed, even without the "owned" tag.
+					m.GetAtScope(gomockinternal.AContext(), "/sub/123/disks/os").Return(resources.TagsResource{Properties: &resources.Tags{
+						Tags: map[string]*string{
+							"costcenter": ptr.To("old"),
+							"team":       ptr.To("a"),
+						},
+					}}, nil),
+					s.AnnotationJSON(azure.DiskTagsLastAppliedAnnotation).Return(map[string]interface{}{"costcenter": "old", "team": "a"}, nil),
+					m.UpdateAtScope(gomockinternal.AContext(), "/sub/123/disks/os", resources.TagsPatchResource{
+						Operation: "Merge",
+						Properties: &resources.Tags{
+							Tags: map[string]*string{
+								"costcenter": ptr.To("new"),
+							},
+						},
+					}),
+					m.UpdateAtScope(gomockinternal.AContext(), "/sub/123/disks/os", resources.TagsPatchResource{
+						Operation: "Delete",
+						Properties: &resources.Tags{
+							Tags: map[string]*string{
+								"team": ptr.To("a"),
+							},
+						},
+					}),
+					// The last applied annotation is only read once and is not updated until the last disk is done,
+					// so the removed tag is pruned from the second disk too.
+					m.GetAtScope(gomockinternal.AContext(), "/sub/123/disks/data").Return(resources.TagsResource{Properties: &resources.Tags{
+						Tags: map[string]*string{
+							"costcenter": ptr.To("old"),
+							"team":       ptr.To("a"),
+						},
+					}}, nil),
+					m.UpdateAtScope(gomockinternal.AContext(), "/sub/123/disks/data", resources.TagsPatchResource{
+						Operation: "Merge",
+						Properties: &resources.Tags{
+							Tags: map[string]*string{
+								"costcenter": ptr.To("new"),
+							},
+						},
+					}),
+					m.UpdateAtScope(gomockinternal.AContext(), "/sub/123/disks/data", resources.TagsPatchResource{
+						Operation: "Delete",
+						Properties: &resources.Tags{
+							Tags: map[string]*string{
+								"team": ptr.To("a"),
+							},
+						},
+					}),
+					s.UpdateAnnotationJSON(azure.DiskTagsLastAppliedAnnotation, map[string]interface{}{"costcenter": "new"}),
+				)
+			},
+		},
 		{
 			name:          "error getting existing tags",
 			expectedError: "failed to get existing tags: #: Internal Server Error: StatusCode=500",
@@ -229,6 +307,137 @@ func TestReconcileTags(t *testing.T) {
 				}).Return(resources.TagsResource{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error"))
 			},
 		},
+		{
+			name:            "defer tag updates once the batch is full",
+			updateBatchSize: 1,
+			expectedError:   "sent 1 tag updates, the tags of the remaining resources will be updated in the next reconcile. Object will be requeued after 15s",
+			expect: func(s *mock_tags.MockTagScopeMockRecorder, m *mock_tags.MockclientMockRecorder) {
+				s.ClusterName().AnyTimes().Return("test-cluster")
+				gomock.InOrder(
+					s.TagsSpecs().Return([]azure.TagsSpec{
+						{
+							Scope:      "/sub/123/fake/scope",
+							Tags:       map[string]string{"foo": "bar"},
+							Annotation: "my-annotation",
+						},
+						{
+							Scope:      "/sub/123/unchanged/scope",
+							Tags:       map[string]string{"foo": "bar"},
+							Annotation: "my-annotation-2",
+						},
+						{
+							Scope:      "/sub/123/other/scope",
+							Tags:       map[string]string{"foo": "bar"},
+							Annotation: "my-annotation-3",
+						},
+					}),
+					m.GetAtScope(gomockinternal.AContext(), "/sub/123/fake/scope").Return(resources.TagsResource{Properties: &resources.Tags{
+						Tags: map[string]*string{
+							"sigs.k8s.io_cluster-api-provider-azure_cluster_test-cluster": ptr.To("owned"),
+						},
+					}}, nil),
+					s.AnnotationJSON("my-annotation"),
+					m.UpdateAtScope(gomockinternal.AContext(), "/sub/123/fake/scope", resources.TagsPatchResource{
+						Operation: "Merge",
+						Properties: &resources.Tags{
+							Tags: map[string]*string{"foo": ptr.To("bar")},
+						},
+					}),
+					s.UpdateAnnotationJSON("my-annotation", map[string]interface{}{"foo": "bar"}),
+					// Resources whose tags are up to date don't use the batch.
+					m.GetAtScope(gomockinternal.AContext(), "/sub/123/unchanged/scope").Return(resources.TagsResource{Properties: &resources.Tags{
+						Tags: map[string]*string{
+							"sigs.k8s.io_cluster-api-provider-azure_cluster_test-cluster": ptr.To("owned"),
+							"foo": ptr.To("bar"),
+						},
+					}}, nil),
+					s.AnnotationJSON("my-annotation-2").Return(map[string]interface{}{"foo": "bar"}, nil),
+					s.UpdateAnnotationJSON("my-annotation-2", map[string]interface{}{"foo": "bar"}),
+					m.GetAtScope(gomockinternal.AContext(), "/sub/123/other/scope").Return(resources.TagsResource{Properties: &resources.Tags{
+						Tags: map[string]*string{
+							"sigs.k8s.io_cluster-api-provider-azure_cluster_test-cluster": ptr.To("owned"),
+						},
+					}}, nil),
+					s.AnnotationJSON("my-annotation-3"),
+				)
+			},
+		},
+		{
+			name:          "coalesce the tags specs of the same resource",
+			expectedError: "",
+			expect: func(s *mock_tags.MockTagScopeMockRecorder, m *mock_tags.MockclientMockRecorder) {
+				s.ClusterName().AnyTimes().Return("test-cluster")
+				gomock.InOrder(
+					s.TagsSpecs().Return([]azure.TagsSpec{
+						{
+							Scope:      "/sub/123/fake/scope",
+							Tags:       map[string]string{"foo": "bar", "thing": "stuff"},
+							Annotation: "my-annotation",
+						},
+						{
+							Scope:      "/sub/123/fake/scope",
+							Tags:       map[string]string{"foo": "baz"},
+							Annotation: "my-annotation",
+						},
+					}),
+					m.GetAtScope(gomockinternal.AContext(), "/sub/123/fake/scope").Return(resources.TagsResource{Properties: &resources.Tags{
+						Tags: map[string]*string{
+							"sigs.k8s.io_cluster-api-provider-azure_cluster_test-cluster": ptr.To("owned"),
+						},
+					}}, nil),
+					s.AnnotationJSON("my-annotation"),
+					m.UpdateAtScope(gomockinternal.AContext(), "/sub/123/fake/scope", resources.TagsPatchResource{
+						Operation: "Merge",
+						Properties: &resources.Tags{
+							Tags: map[string]*string{
+								"foo":   ptr.To("baz"),
+								"thing": ptr.To("stuff"),
+							},
+						},
+					}),
+					s.UpdateAnnotationJSON("my-annotation", map[string]interface{}{"foo": "baz", "thing": "stuff"}),
+				)
+			},
+		},
+		{
+			name:          "requeue after the Retry-After delay when getting tags is throttled",
+			expectedError: "failed to get existing tags: #: Too Many Requests: StatusCode=429. Object will be requeued after 30s",
+			expect: func(s *mock_tags.MockTagScopeMockRecorder, m *mock_tags.MockclientMockRecorder) {
+				s.ClusterName().AnyTimes().Return("test-cluster")
+				s.TagsSpecs().Return([]azure.TagsSpec{
+					{
+						Scope:      "/sub/123/fake/scope",
+						Tags:       map[string]string{"foo": "bar"},
+						Annotation: "my-annotation",
+					},
+				})
+				m.GetAtScope(gomockinternal.AContext(), "/sub/123/fake/scope").Return(resources.TagsResource{}, autorest.NewErrorWithResponse("", "", &http.Response{
+					StatusCode: http.StatusTooManyRequests,
+					Header:     http.Header{"Retry-After": []string{"30"}},
+				}, "Too Many Requests"))
+			},
+		},
+		{
+			name:          "back off when updating tags is throttled",
+			expectedError: "cannot update tags: #: Too Many Requests: StatusCode=429. Object will be requeued after 1m0s",
+			expect: func(s *mock_tags.MockTagScopeMockRecorder, m *mock_tags.MockclientMockRecorder) {
+				s.ClusterName().AnyTimes().Return("test-cluster")
+				s.TagsSpecs().Return([]azure.TagsSpec{
+					{
+						Scope:      "/sub/123/fake/scope",
+						Tags:       map[string]string{"foo": "bar"},
+						Annotation: "my-annotation",
+					},
+				})
+				m.GetAtScope(gomockinternal.AContext(), "/sub/123/fake/scope").Return(resources.TagsResource{Properties: &resources.Tags{
+					Tags: map[string]*string{
+						"sigs.k8s.io_cluster-api-provider-azure_cluster_test-cluster": ptr.To("owned"),
+					},
+				}}, nil)
+				s.AnnotationJSON("my-annotation")
+				m.UpdateAtScope(gomockinternal.AContext(), "/sub/123/fake/scope", gomock.Any()).Return(resources.TagsResource{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusTooManyRequests}, "Too Many Requests"))
+			},
+		},
 		{
 			name:          "tags unchanged",
 			expectedError: "",
@@ -268,8 +477,9 @@ func TestReconcileTags(t *testing.T) {
 			tc.expect(scopeMock.EXPECT(), clientMock.EXPECT())
 
 			s := &Service{
-				Scope:  scopeMock,
-				client: clientMock,
+				Scope:           scopeMock,
+				client:          clientMock,
+				UpdateBatchSize: tc.updateBatchSize,
 			}
 
 			err := s.Reconcile(context.TODO())
@@ -283,6 +493,47 @@ func TestReconcileTags(t *testing.T) {
 	}
 }
 
+func TestReconcileTagsDryRun(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scopeMock := struct {
+		*mock_tags.MockTagScope
+		*mock_azure.MockDryRunner
+	}{
+		MockTagScope:  mock_tags.NewMockTagScope(mockCtrl),
+		MockDryRunner: mock_azure.NewMockDryRunner(mockCtrl),
+	}
+	clientMock := mock_tags.NewMockclient(mockCtrl)
+
+	scopeMock.MockTagScope.EXPECT().ClusterName().AnyTimes().Return("test-cluster")
+	scopeMock.MockTagScope.EXPECT().TagsSpecs().Return([]azure.TagsSpec{
+		{
+			Scope: "/sub/123/fake/scope",
+			Tags: map[string]string{
+				"foo": "bar",
+			},
+			Annotation: "my-annotation",
+		},
+	})
+	clientMock.EXPECT().GetAtScope(gomockinternal.AContext(), "/sub/123/fake/scope").Return(resources.TagsResource{Properties: &resources.Tags{
+		Tags: map[string]*string{
+			"sigs.k8s.io_cluster-api-provider-azure_cluster_test-cluster": ptr.To("owned"),
+		},
+	}}, nil)
+	scopeMock.MockTagScope.EXPECT().AnnotationJSON("my-annotation")
+	scopeMock.MockDryRunner.EXPECT().IsDryRun().Return(true)
+	scopeMock.MockDryRunner.EXPECT().RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason, gomock.Any(),
+		"/sub/123/fake/scope", map[string]string{"foo": "bar"}, map[string]string{})
+	// Neither UpdateAtScope nor UpdateAnnotationJSON must be called.
+
+	s := &Service{
+		Scope:  scopeMock,
+		client: clientMock,
+	}
+	g.Expect(s.Reconcile(context.TODO())).To(Succeed())
+}
+
 func TestTagsChanged(t *testing.T) {
 	g := NewWithT(t)
 
@@ -343,6 +594,16 @@ func TestTagsChanged(t *testing.T) {
 				"foo": "hello",
 			},
 			expectedNewAnnotations: map[string]interface{}{},
+		}, "tag already deleted": {
+			lastAppliedTags: map[string]interface{}{
+				"foo": "hello",
+			},
+			desiredTags:              map[string]string{},
+			currentTags:              map[string]*string{},
+			expectedResult:           false,
+			expectedCreatedOrUpdated: map[string]string{},
+			expectedDeleted:          map[string]string{},
+			expectedNewAnnotations:   map[string]interface{}{},
 		}, "tag created": {
 			lastAppliedTags: map[string]interface{}{
 				"foo": "hello",
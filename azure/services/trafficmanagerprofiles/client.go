@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trafficmanagerprofiles
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/trafficmanager/mgmt/2018-08-01/trafficmanager"
+	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// azureClient contains the Azure go-sdk Client.
+type azureClient struct {
+	profiles trafficmanager.ProfilesClient
+}
+
+// newClient creates a new Traffic Manager profile client from subscription ID.
+func newClient(auth azure.Authorizer) *azureClient {
+	c := newProfilesClient(auth.SubscriptionID(), auth.BaseURI(), auth.Authorizer())
+	return &azureClient{c}
+}
+
+// newProfilesClient creates a new Traffic Manager profile client from subscription ID.
+func newProfilesClient(subscriptionID string, baseURI string, authorizer autorest.Authorizer) trafficmanager.ProfilesClient {
+	profilesClient := trafficmanager.NewProfilesClientWithBaseURI(baseURI, subscriptionID)
+	azure.SetAutoRestClientDefaults(&profilesClient.Client, authorizer)
+	return profilesClient
+}
+
+// Get gets the specified Traffic Manager profile in a specified resource group.
+func (ac *azureClient) Get(ctx context.Context, spec azure.ResourceSpecGetter) (result interface{}, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "trafficmanagerprofiles.azureClient.Get")
+	defer done()
+
+	return ac.profiles.Get(ctx, spec.ResourceGroupName(), spec.ResourceName())
+}
+
+// CreateOrUpdateAsync creates or updates a Traffic Manager profile.
+// Creating a Traffic Manager profile is not a long running operation, so we don't ever return a future.
+func (ac *azureClient) CreateOrUpdateAsync(ctx context.Context, spec azure.ResourceSpecGetter, parameters interface{}) (result interface{}, future azureautorest.FutureAPI, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "trafficmanagerprofiles.azureClient.CreateOrUpdate")
+	defer done()
+
+	profile, ok := parameters.(trafficmanager.Profile)
+	if !ok {
+		return nil, nil, errors.Errorf("%T is not a trafficmanager.Profile", parameters)
+	}
+
+	result, err = ac.profiles.CreateOrUpdate(ctx, spec.ResourceGroupName(), spec.ResourceName(), profile)
+	return result, nil, err
+}
+
+// DeleteAsync deletes the specified Traffic Manager profile.
+// Deleting a Traffic Manager profile is not a long running operation, so we don't ever return a future.
+func (ac *azureClient) DeleteAsync(ctx context.Context, spec azure.ResourceSpecGetter) (future azureautorest.FutureAPI, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "trafficmanagerprofiles.azureClient.Delete")
+	defer done()
+
+	_, err = ac.profiles.Delete(ctx, spec.ResourceGroupName(), spec.ResourceName())
+	return nil, err
+}
+
+// IsDone returns true if the long-running operation has completed.
+func (ac *azureClient) IsDone(ctx context.Context, future azureautorest.FutureAPI) (isDone bool, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "trafficmanagerprofiles.azureClient.IsDone")
+	defer done()
+
+	return future.DoneWithContext(ctx, ac.profiles)
+}
+
+// Result fetches the result of a long-running operation future.
+func (ac *azureClient) Result(ctx context.Context, future azureautorest.FutureAPI, futureType string) (result interface{}, err error) {
+	// Result is a no-op for Traffic Manager profiles as their operations don't return a future.
+	return nil, nil
+}
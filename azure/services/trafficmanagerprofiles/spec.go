@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trafficmanagerprofiles
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/trafficmanager/mgmt/2018-08-01/trafficmanager"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
+)
+
+// externalEndpointType is the type of the Traffic Manager endpoints targeting an FQDN or an IP address.
+const externalEndpointType = "Microsoft.Network/trafficManagerProfiles/externalEndpoints"
+
+// TrafficManagerProfileSpec defines the specification for a Traffic Manager profile fronting the API server endpoints
+// of several regions.
+type TrafficManagerProfileSpec struct {
+	Name            string
+	ResourceGroup   string
+	ClusterName     string
+	RelativeDNSName string
+	RoutingMethod   infrav1.TrafficManagerRoutingMethod
+	TTL             int64
+	MonitorPort     int32
+	MonitorPath     string
+	Endpoints       []infrav1.TrafficManagerEndpoint
+	AdditionalTags  infrav1.Tags
+}
+
+// ResourceName returns the name of the Traffic Manager profile.
+func (s *TrafficManagerProfileSpec) ResourceName() string {
+	return s.Name
+}
+
+// ResourceGroupName returns the name of the resource group.
+func (s *TrafficManagerProfileSpec) ResourceGroupName() string {
+	return s.ResourceGroup
+}
+
+// OwnerResourceName is a no-op for Traffic Manager profiles.
+func (s *TrafficManagerProfileSpec) OwnerResourceName() string {
+	return ""
+}
+
+// Parameters returns the parameters for the Traffic Manager profile.
+func (s *TrafficManagerProfileSpec) Parameters(ctx context.Context, existing interface{}) (params interface{}, err error) {
+	if existing != nil {
+		existingProfile, ok := existing.(trafficmanager.Profile)
+		if !ok {
+			return nil, errors.Errorf("%T is not a trafficmanager.Profile", existing)
+		}
+		// Traffic Manager profile already exists, only update it if its configuration or endpoints changed.
+		if s.isUpToDate(existingProfile) {
+			return nil, nil
+		}
+	}
+
+	return trafficmanager.Profile{
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
+			ClusterName: s.ClusterName,
+			Lifecycle:   infrav1.ResourceLifecycleOwned,
+			Name:        ptr.To(s.Name),
+			Role:        ptr.To(infrav1.APIServerRole),
+			ClusterTags: s.AdditionalTags,
+		})),
+		Name: ptr.To(s.Name),
+		// Traffic Manager profiles are global resources.
+		Location: ptr.To("global"),
+		ProfileProperties: &trafficmanager.ProfileProperties{
+			ProfileStatus:        trafficmanager.ProfileStatusEnabled,
+			TrafficRoutingMethod: trafficmanager.TrafficRoutingMethod(s.RoutingMethod),
+			DNSConfig: &trafficmanager.DNSConfig{
+				RelativeName: ptr.To(s.RelativeDNSName),
+				TTL:          ptr.To(s.TTL),
+			},
+			// The API servers serve their health endpoints to anonymous clients over HTTPS, and Traffic Manager doesn't
+			// validate the certificates of the endpoints it probes.
+			MonitorConfig: &trafficmanager.MonitorConfig{
+				Protocol: trafficmanager.MonitorProtocolHTTPS,
+				Port:     ptr.To(int64(s.MonitorPort)),
+				Path:     ptr.To(s.MonitorPath),
+			},
+			Endpoints: ptr.To(s.generateEndpoints()),
+		},
+	}, nil
+}
+
+// generateEndpoints returns the external endpoints of the profile targeting the regional API server endpoints.
+func (s *TrafficManagerProfileSpec) generateEndpoints() []trafficmanager.Endpoint {
+	endpoints := make([]trafficmanager.Endpoint, 0, len(s.Endpoints))
+	for i, endpoint := range s.Endpoints {
+		properties := &trafficmanager.EndpointProperties{
+			Target:         ptr.To(endpoint.Target),
+			EndpointStatus: trafficmanager.EndpointStatusEnabled,
+			Weight:         endpoint.Weight,
+		}
+		if endpoint.Location != "" {
+			properties.EndpointLocation = ptr.To(endpoint.Location)
+		}
+		if s.RoutingMethod == infrav1.TrafficManagerRoutingMethodPriority {
+			properties.Priority = ptr.To(infrav1.TrafficManagerEndpointPriority(s.Endpoints, i))
+		} else {
+			properties.Priority = endpoint.Priority
+		}
+		endpoints = append(endpoints, trafficmanager.Endpoint{
+			Name:               ptr.To(endpoint.Name),
+			Type:               ptr.To(externalEndpointType),
+			EndpointProperties: properties,
+		})
+	}
+	return endpoints
+}
+
+// trafficManagerEndpoint is the part of a Traffic Manager endpoint configured by CAPZ.
+type trafficManagerEndpoint struct {
+	name     string
+	target   string
+	location string
+	priority int64
+	weight   int64
+}
+
+// isUpToDate returns true if the configuration and the endpoints of the existing profile match the spec. The optional
+// endpoint fields that are not set in the spec are assigned by Azure, so they are not compared.
+func (s *TrafficManagerProfileSpec) isUpToDate(existing trafficmanager.Profile) bool {
+	props := existing.ProfileProperties
+	if props == nil || props.DNSConfig == nil || props.MonitorConfig == nil || props.Endpoints == nil {
+		return false
+	}
+	if string(props.TrafficRoutingMethod) != string(s.RoutingMethod) ||
+		ptr.Deref(props.DNSConfig.TTL, 0) != s.TTL ||
+		props.MonitorConfig.Protocol != trafficmanager.MonitorProtocolHTTPS ||
+		ptr.Deref(props.MonitorConfig.Port, 0) != int64(s.MonitorPort) ||
+		ptr.Deref(props.MonitorConfig.Path, "") != s.MonitorPath {
+		return false
+	}
+
+	desired := s.generateEndpoints()
+	if len(*props.Endpoints) != len(desired) {
+		return false
+	}
+	existingEndpoints := make(map[string]trafficManagerEndpoint, len(*props.Endpoints))
+	for _, endpoint := range *props.Endpoints {
+		existingEndpoints[ptr.Deref(endpoint.Name, "")] = toTrafficManagerEndpoint(endpoint)
+	}
+	for _, endpoint := range desired {
+		want := toTrafficManagerEndpoint(endpoint)
+		got, ok := existingEndpoints[want.name]
+		if !ok {
+			return false
+		}
+		if want.location == "" {
+			got.location = ""
+		}
+		if want.priority == 0 {
+			got.priority = 0
+		}
+		if want.weight == 0 {
+			got.weight = 0
+		}
+		if got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// toTrafficManagerEndpoint returns the part of a Traffic Manager endpoint configured by CAPZ.
+func toTrafficManagerEndpoint(endpoint trafficmanager.Endpoint) trafficManagerEndpoint {
+	e := trafficManagerEndpoint{name: ptr.Deref(endpoint.Name, "")}
+	if endpoint.EndpointProperties != nil {
+		e.target = ptr.Deref(endpoint.Target, "")
+		// Azure returns the display name of the endpoint location, e.g. "West Europe" for westeurope.
+		e.location = strings.ToLower(strings.ReplaceAll(ptr.Deref(endpoint.EndpointLocation, ""), " ", ""))
+		e.priority = ptr.Deref(endpoint.Priority, 0)
+		e.weight = ptr.Deref(endpoint.Weight, 0)
+	}
+	return e
+}
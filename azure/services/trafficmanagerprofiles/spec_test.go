@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trafficmanagerprofiles
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/trafficmanager/mgmt/2018-08-01/trafficmanager"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+func TestParameters(t *testing.T) {
+	expectedProfile := trafficmanager.Profile{
+		Name:     ptr.To("traf-my-cluster-apiserver"),
+		Location: ptr.To("global"),
+		Tags: map[string]*string{
+			"Name": ptr.To("traf-my-cluster-apiserver"),
+			"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+			"sigs.k8s.io_cluster-api-provider-azure_role":               ptr.To("apiserver"),
+			"foo": ptr.To("bar"),
+		},
+		ProfileProperties: &trafficmanager.ProfileProperties{
+			ProfileStatus:        trafficmanager.ProfileStatusEnabled,
+			TrafficRoutingMethod: trafficmanager.TrafficRoutingMethodPriority,
+			DNSConfig: &trafficmanager.DNSConfig{
+				RelativeName: ptr.To("my-cluster"),
+				TTL:          ptr.To[int64](30),
+			},
+			MonitorConfig: &trafficmanager.MonitorConfig{
+				Protocol: trafficmanager.MonitorProtocolHTTPS,
+				Port:     ptr.To[int64](6443),
+				Path:     ptr.To("/readyz"),
+			},
+			Endpoints: &[]trafficmanager.Endpoint{
+				{
+					Name: ptr.To("westeurope"),
+					Type: ptr.To("Microsoft.Network/trafficManagerProfiles/externalEndpoints"),
+					EndpointProperties: &trafficmanager.EndpointProperties{
+						Target:           ptr.To("my-cluster-westeurope.westeurope.cloudapp.azure.com"),
+						EndpointStatus:   trafficmanager.EndpointStatusEnabled,
+						EndpointLocation: ptr.To("westeurope"),
+						Priority:         ptr.To[int64](1),
+					},
+				},
+				{
+					Name: ptr.To("northeurope"),
+					Type: ptr.To("Microsoft.Network/trafficManagerProfiles/externalEndpoints"),
+					EndpointProperties: &trafficmanager.EndpointProperties{
+						Target:         ptr.To("20.30.40.50"),
+						EndpointStatus: trafficmanager.EndpointStatusEnabled,
+						Priority:       ptr.To[int64](10),
+					},
+				},
+			},
+		},
+	}
+
+	// existingProfile returns the profile as returned by Azure, with the endpoint fields assigned by Azure.
+	existingProfile := func(mutate func(*trafficmanager.Profile)) trafficmanager.Profile {
+		profile := trafficmanager.Profile{
+			Name: ptr.To("traf-my-cluster-apiserver"),
+			ProfileProperties: &trafficmanager.ProfileProperties{
+				ProfileStatus:        trafficmanager.ProfileStatusEnabled,
+				TrafficRoutingMethod: trafficmanager.TrafficRoutingMethodPriority,
+				DNSConfig: &trafficmanager.DNSConfig{
+					RelativeName: ptr.To("my-cluster"),
+					Fqdn:         ptr.To("my-cluster.trafficmanager.net"),
+					TTL:          ptr.To[int64](30),
+				},
+				MonitorConfig: &trafficmanager.MonitorConfig{
+					Protocol:          trafficmanager.MonitorProtocolHTTPS,
+					Port:              ptr.To[int64](6443),
+					Path:              ptr.To("/readyz"),
+					IntervalInSeconds: ptr.To[int64](30),
+				},
+				Endpoints: &[]trafficmanager.Endpoint{
+					{
+						Name: ptr.To("northeurope"),
+						EndpointProperties: &trafficmanager.EndpointProperties{
+							Target:   ptr.To("20.30.40.50"),
+							Priority: ptr.To[int64](10),
+							Weight:   ptr.To[int64](1),
+						},
+					},
+					{
+						Name: ptr.To("westeurope"),
+						EndpointProperties: &trafficmanager.EndpointProperties{
+							Target:           ptr.To("my-cluster-westeurope.westeurope.cloudapp.azure.com"),
+							EndpointLocation: ptr.To("West Europe"),
+							Priority:         ptr.To[int64](1),
+							Weight:           ptr.To[int64](1),
+						},
+					},
+				},
+			},
+		}
+		if mutate != nil {
+			mutate(&profile)
+		}
+		return profile
+	}
+
+	testCases := []struct {
+		name          string
+		existing      interface{}
+		spec          TrafficManagerProfileSpec
+		expected      interface{}
+		expectedError string
+	}{
+		{
+			name:     "Traffic Manager profile with external endpoints",
+			existing: nil,
+			spec:     fakeTrafficManagerProfileSpec,
+			expected: expectedProfile,
+		},
+		{
+			name:     "noop if the Traffic Manager profile is up to date",
+			existing: existingProfile(nil),
+			spec:     fakeTrafficManagerProfileSpec,
+			expected: nil,
+		},
+		{
+			name: "update the Traffic Manager profile if an endpoint target changed",
+			existing: existingProfile(func(p *trafficmanager.Profile) {
+				(*p.Endpoints)[0].Target = ptr.To("20.30.40.60")
+			}),
+			spec:     fakeTrafficManagerProfileSpec,
+			expected: expectedProfile,
+		},
+		{
+			name: "update the Traffic Manager profile if an endpoint is missing",
+			existing: existingProfile(func(p *trafficmanager.Profile) {
+				*p.Endpoints = (*p.Endpoints)[:1]
+			}),
+			spec:     fakeTrafficManagerProfileSpec,
+			expected: expectedProfile,
+		},
+		{
+			name: "update the Traffic Manager profile if the TTL changed",
+			existing: existingProfile(func(p *trafficmanager.Profile) {
+				p.DNSConfig.TTL = ptr.To[int64](300)
+			}),
+			spec:     fakeTrafficManagerProfileSpec,
+			expected: expectedProfile,
+		},
+		{
+			name:     "weighted endpoints don't get a default priority",
+			existing: nil,
+			spec: TrafficManagerProfileSpec{
+				Name:            "traf-my-cluster-apiserver",
+				ResourceGroup:   "my-rg",
+				ClusterName:     "my-cluster",
+				RelativeDNSName: "my-cluster",
+				RoutingMethod:   infrav1.TrafficManagerRoutingMethodWeighted,
+				TTL:             30,
+				MonitorPort:     6443,
+				MonitorPath:     "/readyz",
+				Endpoints: []infrav1.TrafficManagerEndpoint{
+					{Name: "westeurope", Target: "20.30.40.50", Weight: ptr.To[int64](100)},
+				},
+			},
+			expected: trafficmanager.Profile{
+				Name:     ptr.To("traf-my-cluster-apiserver"),
+				Location: ptr.To("global"),
+				Tags: map[string]*string{
+					"Name": ptr.To("traf-my-cluster-apiserver"),
+					"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+					"sigs.k8s.io_cluster-api-provider-azure_role":               ptr.To("apiserver"),
+				},
+				ProfileProperties: &trafficmanager.ProfileProperties{
+					ProfileStatus:        trafficmanager.ProfileStatusEnabled,
+					TrafficRoutingMethod: trafficmanager.TrafficRoutingMethodWeighted,
+					DNSConfig: &trafficmanager.DNSConfig{
+						RelativeName: ptr.To("my-cluster"),
+						TTL:          ptr.To[int64](30),
+					},
+					MonitorConfig: &trafficmanager.MonitorConfig{
+						Protocol: trafficmanager.MonitorProtocolHTTPS,
+						Port:     ptr.To[int64](6443),
+						Path:     ptr.To("/readyz"),
+					},
+					Endpoints: &[]trafficmanager.Endpoint{
+						{
+							Name: ptr.To("westeurope"),
+							Type: ptr.To("Microsoft.Network/trafficManagerProfiles/externalEndpoints"),
+							EndpointProperties: &trafficmanager.EndpointProperties{
+								Target:         ptr.To("20.30.40.50"),
+								EndpointStatus: trafficmanager.EndpointStatusEnabled,
+								Weight:         ptr.To[int64](100),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:          "existing is not a Traffic Manager profile",
+			existing:      "not a profile",
+			spec:          fakeTrafficManagerProfileSpec,
+			expected:      nil,
+			expectedError: "string is not a trafficmanager.Profile",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			result, err := tc.spec.Parameters(context.TODO(), tc.existing)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			if tc.expected == nil {
+				g.Expect(result).To(BeNil())
+			} else {
+				g.Expect(result).To(Equal(tc.expected))
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trafficmanagerprofiles
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async/mock_async"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/trafficmanagerprofiles/mock_trafficmanagerprofiles"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+var (
+	fakeTrafficManagerProfileSpec = TrafficManagerProfileSpec{
+		Name:            "traf-my-cluster-apiserver",
+		ResourceGroup:   "my-rg",
+		ClusterName:     "my-cluster",
+		RelativeDNSName: "my-cluster",
+		RoutingMethod:   infrav1.TrafficManagerRoutingMethodPriority,
+		TTL:             30,
+		MonitorPort:     6443,
+		MonitorPath:     "/readyz",
+		Endpoints: []infrav1.TrafficManagerEndpoint{
+			{Name: "westeurope", Target: "my-cluster-westeurope.westeurope.cloudapp.azure.com", Location: "westeurope"},
+			{Name: "northeurope", Target: "20.30.40.50", Priority: ptr.To[int64](10)},
+		},
+		AdditionalTags: infrav1.Tags{
+			"foo": "bar",
+		},
+	}
+
+	internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+)
+
+func TestReconcileTrafficManagerProfile(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_trafficmanagerprofiles.MockTrafficManagerProfileScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+	}{
+		{
+			name:          "noop if no Traffic Manager profile specs are found",
+			expectedError: "",
+			expect: func(s *mock_trafficmanagerprofiles.MockTrafficManagerProfileScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.TrafficManagerProfileSpecs().Return([]azure.ResourceSpecGetter{})
+			},
+		},
+		{
+			name:          "successfully create a Traffic Manager profile",
+			expectedError: "",
+			expect: func(s *mock_trafficmanagerprofiles.MockTrafficManagerProfileScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.TrafficManagerProfileSpecs().Return([]azure.ResourceSpecGetter{&fakeTrafficManagerProfileSpec})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeTrafficManagerProfileSpec, serviceName).Return(nil, nil)
+				s.UpdatePutStatus(infrav1.TrafficManagerProfilesReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "fail to create a Traffic Manager profile",
+			expectedError: "#: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_trafficmanagerprofiles.MockTrafficManagerProfileScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.TrafficManagerProfileSpecs().Return([]azure.ResourceSpecGetter{&fakeTrafficManagerProfileSpec})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeTrafficManagerProfileSpec, serviceName).Return(nil, internalError)
+				s.UpdatePutStatus(infrav1.TrafficManagerProfilesReadyCondition, serviceName, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			scopeMock := mock_trafficmanagerprofiles.NewMockTrafficManagerProfileScope(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT())
+
+			s := &Service{
+				Scope:      scopeMock,
+				Reconciler: asyncMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestDeleteTrafficManagerProfile(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_trafficmanagerprofiles.MockTrafficManagerProfileScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+	}{
+		{
+			name:          "noop if no Traffic Manager profile specs are found",
+			expectedError: "",
+			expect: func(s *mock_trafficmanagerprofiles.MockTrafficManagerProfileScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.TrafficManagerProfileSpecs().Return([]azure.ResourceSpecGetter{})
+			},
+		},
+		{
+			name:          "successfully delete a Traffic Manager profile",
+			expectedError: "",
+			expect: func(s *mock_trafficmanagerprofiles.MockTrafficManagerProfileScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.TrafficManagerProfileSpecs().Return([]azure.ResourceSpecGetter{&fakeTrafficManagerProfileSpec})
+				r.DeleteResource(gomockinternal.AContext(), &fakeTrafficManagerProfileSpec, serviceName).Return(nil)
+				s.UpdateDeleteStatus(infrav1.TrafficManagerProfilesReadyCondition, serviceName, nil)
+			},
+		},
+		{
+			name:          "fail to delete a Traffic Manager profile",
+			expectedError: "#: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_trafficmanagerprofiles.MockTrafficManagerProfileScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.TrafficManagerProfileSpecs().Return([]azure.ResourceSpecGetter{&fakeTrafficManagerProfileSpec})
+				r.DeleteResource(gomockinternal.AContext(), &fakeTrafficManagerProfileSpec, serviceName).Return(internalError)
+				s.UpdateDeleteStatus(infrav1.TrafficManagerProfilesReadyCondition, serviceName, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			scopeMock := mock_trafficmanagerprofiles.NewMockTrafficManagerProfileScope(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT())
+
+			s := &Service{
+				Scope:      scopeMock,
+				Reconciler: asyncMock,
+			}
+
+			err := s.Delete(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
@@ -45,8 +45,11 @@ type (
 	Client interface {
 		Get(context.Context, azure.ResourceSpecGetter) (interface{}, error)
 		GetByID(context.Context, string) (compute.VirtualMachine, error)
+		InstanceView(context.Context, azure.ResourceSpecGetter) (compute.VirtualMachineInstanceView, error)
 		CreateOrUpdateAsync(ctx context.Context, spec azure.ResourceSpecGetter, parameters interface{}) (result interface{}, future azureautorest.FutureAPI, err error)
 		DeleteAsync(ctx context.Context, spec azure.ResourceSpecGetter) (future azureautorest.FutureAPI, err error)
+		DeallocateAsync(ctx context.Context, spec azure.ResourceSpecGetter) (future azureautorest.FutureAPI, err error)
+		StartAsync(ctx context.Context, spec azure.ResourceSpecGetter) (future azureautorest.FutureAPI, err error)
 		IsDone(ctx context.Context, future azureautorest.FutureAPI) (isDone bool, err error)
 		Result(ctx context.Context, future azureautorest.FutureAPI, futureType string) (result interface{}, err error)
 		GetResultIfDone(ctx context.Context, future *infrav1.Future) (compute.VirtualMachine, error)
@@ -77,12 +80,13 @@ func newVirtualMachinesClient(subscriptionID string, baseURI string, authorizer
 	return vmClient
 }
 
-// Get retrieves information about the model view or the instance view of a virtual machine.
+// Get retrieves information about the model view and the instance view of a virtual machine.
+// The instance view tells whether the virtual machine is deallocated, which is required to grow its OS disk.
 func (ac *AzureClient) Get(ctx context.Context, spec azure.ResourceSpecGetter) (result interface{}, err error) {
 	ctx, _, done := tele.StartSpanWithLogger(ctx, "virtualmachines.AzureClient.Get")
 	defer done()
 
-	return ac.virtualmachines.Get(ctx, spec.ResourceGroupName(), spec.ResourceName(), "")
+	return ac.virtualmachines.Get(ctx, spec.ResourceGroupName(), spec.ResourceName(), compute.InstanceViewTypesInstanceView)
 }
 
 // GetByID retrieves information about the model or instance view of a virtual machine.
@@ -100,13 +104,24 @@ func (ac *AzureClient) GetByID(ctx context.Context, resourceID string) (compute.
 	return ac.virtualmachines.Get(ctx, parsed.ResourceGroupName, parsed.Name, "")
 }
 
+// InstanceView retrieves the run-time state of a virtual machine.
+func (ac *AzureClient) InstanceView(ctx context.Context, spec azure.ResourceSpecGetter) (compute.VirtualMachineInstanceView, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "virtualmachines.AzureClient.InstanceView")
+	defer done()
+
+	return ac.virtualmachines.InstanceView(ctx, spec.ResourceGroupName(), spec.ResourceName())
+}
+
 // CreateOrUpdateAsync creates or updates a virtual machine asynchronously.
-// It sends a PUT request to Azure and if accepted without error, the func will return a Future which can be used to track the ongoing
-// progress of the operation.
+// It sends a PUT request to Azure, or a PATCH request for a minimal update of an existing virtual machine, and if accepted
+// without error, the func will return a Future which can be used to track the ongoing progress of the operation.
 func (ac *AzureClient) CreateOrUpdateAsync(ctx context.Context, spec azure.ResourceSpecGetter, parameters interface{}) (result interface{}, future azureautorest.FutureAPI, err error) {
 	ctx, _, done := tele.StartSpanWithLogger(ctx, "virtualmachines.AzureClient.CreateOrUpdate")
 	defer done()
 
+	if update, ok := parameters.(vmUpdate); ok {
+		return ac.updateAsync(ctx, spec, update.VirtualMachineUpdate)
+	}
 	vm, ok := parameters.(compute.VirtualMachine)
 	if !ok {
 		return nil, nil, errors.Errorf("%T is not a compute.VirtualMachine", parameters)
@@ -131,6 +146,30 @@ func (ac *AzureClient) CreateOrUpdateAsync(ctx context.Context, spec azure.Resou
 	return result, nil, err
 }
 
+// updateAsync updates the fields of an existing virtual machine set in the update with a PATCH request.
+func (ac *AzureClient) updateAsync(ctx context.Context, spec azure.ResourceSpecGetter, update compute.VirtualMachineUpdate) (result interface{}, future azureautorest.FutureAPI, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "virtualmachines.AzureClient.updateAsync")
+	defer done()
+
+	updateFuture, err := ac.virtualmachines.Update(ctx, spec.ResourceGroupName(), spec.ResourceName(), update)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureCallTimeout)
+	defer cancel()
+
+	err = updateFuture.WaitForCompletionRef(ctx, ac.virtualmachines.Client)
+	if err != nil {
+		// if an error occurs, return the future.
+		// this means the long-running operation didn't finish in the specified timeout.
+		return nil, &updateFuture, err
+	}
+	result, err = updateFuture.Result(ac.virtualmachines)
+	// if the operation completed, return a nil future
+	return result, nil, err
+}
+
 // DeleteAsync deletes a virtual machine asynchronously. DeleteAsync sends a DELETE
 // request to Azure and if accepted without error, the func will return a Future which can be used to track the ongoing
 // progress of the operation.
@@ -158,6 +197,58 @@ func (ac *AzureClient) DeleteAsync(ctx context.Context, spec azure.ResourceSpecG
 	return nil, err
 }
 
+// DeallocateAsync deallocates a virtual machine asynchronously. DeallocateAsync sends a POST
+// request to Azure and if accepted without error, the func will return a Future which can be used to track the ongoing
+// progress of the operation.
+func (ac *AzureClient) DeallocateAsync(ctx context.Context, spec azure.ResourceSpecGetter) (future azureautorest.FutureAPI, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "virtualmachines.AzureClient.Deallocate")
+	defer done()
+
+	deallocateFuture, err := ac.virtualmachines.Deallocate(ctx, spec.ResourceGroupName(), spec.ResourceName(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureCallTimeout)
+	defer cancel()
+
+	err = deallocateFuture.WaitForCompletionRef(ctx, ac.virtualmachines.Client)
+	if err != nil {
+		// if an error occurs, return the future.
+		// this means the long-running operation didn't finish in the specified timeout.
+		return &deallocateFuture, err
+	}
+	_, err = deallocateFuture.Result(ac.virtualmachines)
+	// if the operation completed, return a nil future.
+	return nil, err
+}
+
+// StartAsync starts a virtual machine asynchronously. StartAsync sends a POST
+// request to Azure and if accepted without error, the func will return a Future which can be used to track the ongoing
+// progress of the operation.
+func (ac *AzureClient) StartAsync(ctx context.Context, spec azure.ResourceSpecGetter) (future azureautorest.FutureAPI, err error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "virtualmachines.AzureClient.Start")
+	defer done()
+
+	startFuture, err := ac.virtualmachines.Start(ctx, spec.ResourceGroupName(), spec.ResourceName())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureCallTimeout)
+	defer cancel()
+
+	err = startFuture.WaitForCompletionRef(ctx, ac.virtualmachines.Client)
+	if err != nil {
+		// if an error occurs, return the future.
+		// this means the long-running operation didn't finish in the specified timeout.
+		return &startFuture, err
+	}
+	_, err = startFuture.Result(ac.virtualmachines)
+	// if the operation completed, return a nil future.
+	return nil, err
+}
+
 // IsDone returns true if the long-running operation has completed.
 func (ac *AzureClient) IsDone(ctx context.Context, future azureautorest.FutureAPI) (isDone bool, err error) {
 	ctx, _, done := tele.StartSpanWithLogger(ctx, "virtualmachines.AzureClient.IsDone")
@@ -204,8 +295,8 @@ func (ac *AzureClient) Result(ctx context.Context, future azureautorest.FutureAP
 		}
 		return createFuture.Result(ac.virtualmachines)
 
-	case infrav1.DeleteFuture:
-		// Delete does not return a result VM.
+	case infrav1.DeleteFuture, infrav1.PostFuture:
+		// Delete, deallocate and start do not return a result VM.
 		return nil, nil
 
 	default:
@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualmachines
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+// dataDiskArrayBoothookTemplate is a cloud-init boothook assembling the data disks of the VM with the logical unit
+// numbers given as the fourth format argument into a software RAID array of the level given as the second argument,
+// formatted with the filesystem given as the third argument and mounted at the path given as the first argument.
+// Boothooks run on every boot, so the array is only mounted when udev already assembled it, is assembled again from its
+// disks otherwise, and is only created on blank disks so that the data of disks which can't be assembled is never
+// overwritten. The data disks are found by their logical unit number through the symlinks of the Azure udev rules, for
+// both SCSI and NVMe disk controllers.
+const dataDiskArrayBoothookTemplate = `#cloud-boothook
+#!/bin/sh
+set -eu
+MOUNT_PATH='%s'
+LEVEL='%s'
+FILESYSTEM='%s'
+LUNS='%s'
+ARRAY=/dev/md/capz-data
+if mountpoint -q "$MOUNT_PATH"; then
+  exit 0
+fi
+# udev may already have assembled the array from its disks on this boot, possibly under another device name.
+ASSEMBLED=$(mdadm --detail --scan 2>/dev/null | awk '$0 ~ /name=([^ ]*:)?capz-data( |$)/ { print $2; exit }')
+if [ -n "$ASSEMBLED" ]; then
+  ARRAY="$ASSEMBLED"
+fi
+if [ ! -e "$ARRAY" ]; then
+  DISKS=""
+  for lun in $LUNS; do
+    disk=""
+    for attempt in $(seq 1 60); do
+      for link in "/dev/disk/azure/scsi1/lun$lun" "/dev/disk/azure/data/by-lun/$lun"; do
+        if [ -e "$link" ]; then
+          disk=$(readlink -f "$link")
+          break 2
+        fi
+      done
+      sleep 1
+    done
+    if [ -z "$disk" ]; then
+      echo "data disk with logical unit number $lun not found, skipping the data disk array setup" >&2
+      exit 1
+    fi
+    DISKS="$DISKS $disk"
+  done
+  if ! mdadm --assemble "$ARRAY" $DISKS 2>/dev/null; then
+    for disk in $DISKS; do
+      if [ -n "$(blkid -o value -s TYPE "$disk" 2>/dev/null || true)" ]; then
+        echo "data disk $disk is not blank, skipping the data disk array setup" >&2
+        exit 1
+      fi
+    done
+    set -- $DISKS
+    mdadm --create "$ARRAY" --level="$LEVEL" --raid-devices=$# --run "$@"
+    mkfs -t "$FILESYSTEM" "$ARRAY"
+  fi
+fi
+mkdir -p "$MOUNT_PATH"
+mount "$ARRAY" "$MOUNT_PATH"
+`
+
+// dataDiskArrayLevels are the mdadm RAID levels of the data disk array levels.
+var dataDiskArrayLevels = map[infrav1.DataDiskRAIDLevel]string{
+	infrav1.DataDiskRAID0:  "0",
+	infrav1.DataDiskRAID1:  "1",
+	infrav1.DataDiskRAID10: "10",
+}
+
+// dataDiskArrayBoothook returns the cloud-init boothook assembling the data disks of the array into a software RAID
+// array. It returns an error if a data disk of the array isn't a data disk of the VM or has no logical unit number.
+func dataDiskArrayBoothook(array *infrav1.DataDiskArray, dataDisks []infrav1.DataDisk) (string, error) {
+	raidLevel := array.Level
+	if raidLevel == "" {
+		raidLevel = infrav1.DataDiskRAID0
+	}
+	level, ok := dataDiskArrayLevels[raidLevel]
+	if !ok {
+		return "", errors.Errorf("unsupported data disk array level %q", raidLevel)
+	}
+	filesystem := array.Filesystem
+	if filesystem == "" {
+		filesystem = infrav1.DataDiskFilesystemExt4
+	}
+
+	luns := make([]string, 0, len(array.DataDisks))
+	for _, name := range array.DataDisks {
+		var lun *int32
+		for _, disk := range dataDisks {
+			if disk.NameSuffix == name {
+				lun = disk.Lun
+				break
+			}
+		}
+		if lun == nil {
+			return "", errors.Errorf("data disk %s of the data disk array is not a data disk of the VM with a logical unit number", name)
+		}
+		luns = append(luns, strconv.Itoa(int(*lun)))
+	}
+	return fmt.Sprintf(dataDiskArrayBoothookTemplate, array.MountPath, level, filesystem, strings.Join(luns, " ")), nil
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualmachines
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+func TestDataDiskArrayBoothook(t *testing.T) {
+	dataDisks := []infrav1.DataDisk{
+		{NameSuffix: "etcddisk", Lun: ptr.To[int32](0)},
+		{NameSuffix: "data-1", Lun: ptr.To[int32](1)},
+		{NameSuffix: "data-2", Lun: ptr.To[int32](2)},
+		{NameSuffix: "data-3", Lun: ptr.To[int32](5)},
+		{NameSuffix: "data-4", Lun: ptr.To[int32](6)},
+		{NameSuffix: "no-lun"},
+	}
+
+	testcases := []struct {
+		name             string
+		array            *infrav1.DataDiskArray
+		expectedSettings []string
+		expectedError    string
+	}{
+		{
+			name:  "RAID0 array with the default filesystem",
+			array: &infrav1.DataDiskArray{DataDisks: []string{"data-1", "data-2"}, Level: infrav1.DataDiskRAID0, MountPath: "/var/lib/data"},
+			expectedSettings: []string{
+				"MOUNT_PATH='/var/lib/data'",
+				"LEVEL='0'",
+				"FILESYSTEM='ext4'",
+				"LUNS='1 2'",
+			},
+		},
+		{
+			name:  "array without a level defaults to RAID0",
+			array: &infrav1.DataDiskArray{DataDisks: []string{"data-2", "data-1"}, MountPath: "/mnt/data"},
+			expectedSettings: []string{
+				"MOUNT_PATH='/mnt/data'",
+				"LEVEL='0'",
+				"LUNS='2 1'",
+			},
+		},
+		{
+			name: "RAID10 array formatted with xfs",
+			array: &infrav1.DataDiskArray{
+				DataDisks:  []string{"data-1", "data-2", "data-3", "data-4"},
+				Level:      infrav1.DataDiskRAID10,
+				Filesystem: infrav1.DataDiskFilesystemXFS,
+				MountPath:  "/var/lib/containerd",
+			},
+			expectedSettings: []string{
+				"MOUNT_PATH='/var/lib/containerd'",
+				"LEVEL='10'",
+				"FILESYSTEM='xfs'",
+				"LUNS='1 2 5 6'",
+			},
+		},
+		{
+			name:          "array with a disk which isn't a data disk of the VM",
+			array:         &infrav1.DataDiskArray{DataDisks: []string{"data-1", "missing"}, Level: infrav1.DataDiskRAID1, MountPath: "/mnt/data"},
+			expectedError: "data disk missing of the data disk array is not a data disk of the VM with a logical unit number",
+		},
+		{
+			name:          "array with a data disk without a logical unit number",
+			array:         &infrav1.DataDiskArray{DataDisks: []string{"data-1", "no-lun"}, Level: infrav1.DataDiskRAID1, MountPath: "/mnt/data"},
+			expectedError: "data disk no-lun of the data disk array is not a data disk of the VM with a logical unit number",
+		},
+		{
+			name:          "array with an unsupported level",
+			array:         &infrav1.DataDiskArray{DataDisks: []string{"data-1", "data-2"}, Level: "RAID5", MountPath: "/mnt/data"},
+			expectedError: `unsupported data disk array level "RAID5"`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			boothook, err := dataDiskArrayBoothook(tc.array, dataDisks)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(boothook).To(HavePrefix("#cloud-boothook\n#!/bin/sh\n"))
+			for _, setting := range tc.expectedSettings {
+				g.Expect(boothook).To(ContainSubstring(setting + "\n"))
+			}
+			g.Expect(boothook).To(ContainSubstring(`mdadm --detail --scan`))
+			g.Expect(boothook).To(ContainSubstring(`if [ ! -e "$ARRAY" ]; then`))
+			g.Expect(boothook).To(ContainSubstring(`mdadm --assemble "$ARRAY" $DISKS`))
+			g.Expect(boothook).To(ContainSubstring(`mdadm --create "$ARRAY" --level="$LEVEL"`))
+			g.Expect(boothook).To(ContainSubstring(`mkfs -t "$FILESYSTEM" "$ARRAY"`))
+			g.Expect(boothook).To(ContainSubstring(`mount "$ARRAY" "$MOUNT_PATH"`))
+		})
+	}
+}
+
+func TestCustomDataWithDataDiskArray(t *testing.T) {
+	g := NewWithT(t)
+
+	cloudConfig := []byte("#cloud-config\nruncmd:\n- kubeadm join\n")
+	array := &infrav1.DataDiskArray{DataDisks: []string{"data-1", "data-2"}, Level: infrav1.DataDiskRAID1, MountPath: "/mnt/data"}
+	dataDisks := []infrav1.DataDisk{
+		{NameSuffix: "data-1", Lun: ptr.To[int32](0)},
+		{NameSuffix: "data-2", Lun: ptr.To[int32](1)},
+	}
+	spec := &VMSpec{
+		Name:             "my-vm",
+		BootstrapData:    base64.StdEncoding.EncodeToString(cloudConfig),
+		DataDisks:        dataDisks,
+		DataDiskArray:    array,
+		LocalNVMeStorage: &infrav1.LocalNVMeStorage{MountPath: "/mnt/nvme"},
+	}
+	customData, err := spec.CustomData()
+	g.Expect(err).NotTo(HaveOccurred())
+	decoded, err := base64.StdEncoding.DecodeString(customData)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	msg, err := mail.ReadMessage(bytes.NewReader(decoded))
+	g.Expect(err).NotTo(HaveOccurred())
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	g.Expect(err).NotTo(HaveOccurred())
+	var contents []string
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		content, err := io.ReadAll(part)
+		g.Expect(err).NotTo(HaveOccurred())
+		contents = append(contents, string(content))
+	}
+	arrayBoothook, err := dataDiskArrayBoothook(array, dataDisks)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(contents).To(Equal([]string{localNVMeStorageBoothook("/mnt/nvme"), arrayBoothook, string(cloudConfig)}))
+
+	spec.LocalNVMeStorage = nil
+	spec.BootstrapData = base64.StdEncoding.EncodeToString([]byte(`{"ignition":{"version":"3.3.0"}}`))
+	_, err = spec.CustomData()
+	g.Expect(err).To(MatchError("reconcile error that cannot be recovered occurred: data disk array requires cloud-init cloud-config bootstrap data. Object will not be requeued"))
+}
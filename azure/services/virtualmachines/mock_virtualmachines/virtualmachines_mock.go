@@ -21,6 +21,7 @@ limitations under the License.
 package mock_virtualmachines
 
 import (
+	context "context"
 	reflect "reflect"
 
 	autorest "github.com/Azure/go-autorest/autorest"
@@ -164,6 +165,78 @@ func (mr *MockVMScopeMockRecorder) HashKey() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HashKey", reflect.TypeOf((*MockVMScope)(nil).HashKey))
 }
 
+// IsDryRun mocks base method.
+func (m *MockVMScope) IsDryRun() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsDryRun")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsDryRun indicates an expected call of IsDryRun.
+func (mr *MockVMScopeMockRecorder) IsDryRun() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDryRun", reflect.TypeOf((*MockVMScope)(nil).IsDryRun))
+}
+
+// KeyVaultAuthorizer mocks base method.
+func (m *MockVMScope) KeyVaultAuthorizer(ctx context.Context) (autorest.Authorizer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyVaultAuthorizer", ctx)
+	ret0, _ := ret[0].(autorest.Authorizer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KeyVaultAuthorizer indicates an expected call of KeyVaultAuthorizer.
+func (mr *MockVMScopeMockRecorder) KeyVaultAuthorizer(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyVaultAuthorizer", reflect.TypeOf((*MockVMScope)(nil).KeyVaultAuthorizer), ctx)
+}
+
+// KeyVaultDNSSuffix mocks base method.
+func (m *MockVMScope) KeyVaultDNSSuffix() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyVaultDNSSuffix")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// KeyVaultDNSSuffix indicates an expected call of KeyVaultDNSSuffix.
+func (mr *MockVMScopeMockRecorder) KeyVaultDNSSuffix() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyVaultDNSSuffix", reflect.TypeOf((*MockVMScope)(nil).KeyVaultDNSSuffix))
+}
+
+// RecordEvent mocks base method.
+func (m *MockVMScope) RecordEvent(eventType, reason, messageFmt string, args ...interface{}) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{eventType, reason, messageFmt}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "RecordEvent", varargs...)
+}
+
+// RecordEvent indicates an expected call of RecordEvent.
+func (mr *MockVMScopeMockRecorder) RecordEvent(eventType, reason, messageFmt interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{eventType, reason, messageFmt}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordEvent", reflect.TypeOf((*MockVMScope)(nil).RecordEvent), varargs...)
+}
+
+// RemoveAnnotation mocks base method.
+func (m *MockVMScope) RemoveAnnotation(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RemoveAnnotation", arg0)
+}
+
+// RemoveAnnotation indicates an expected call of RemoveAnnotation.
+func (mr *MockVMScopeMockRecorder) RemoveAnnotation(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAnnotation", reflect.TypeOf((*MockVMScope)(nil).RemoveAnnotation), arg0)
+}
+
 // SetAddresses mocks base method.
 func (m *MockVMScope) SetAddresses(arg0 []v1.NodeAddress) {
 	m.ctrl.T.Helper()
@@ -188,6 +261,18 @@ func (mr *MockVMScopeMockRecorder) SetAnnotation(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAnnotation", reflect.TypeOf((*MockVMScope)(nil).SetAnnotation), arg0, arg1)
 }
 
+// SetBootDiagnosticsURIs mocks base method.
+func (m *MockVMScope) SetBootDiagnosticsURIs(consoleURI, serialConsoleLogURI string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetBootDiagnosticsURIs", consoleURI, serialConsoleLogURI)
+}
+
+// SetBootDiagnosticsURIs indicates an expected call of SetBootDiagnosticsURIs.
+func (mr *MockVMScopeMockRecorder) SetBootDiagnosticsURIs(consoleURI, serialConsoleLogURI interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBootDiagnosticsURIs", reflect.TypeOf((*MockVMScope)(nil).SetBootDiagnosticsURIs), consoleURI, serialConsoleLogURI)
+}
+
 // SetConditionFalse mocks base method.
 func (m *MockVMScope) SetConditionFalse(arg0 v1beta10.ConditionType, arg1 string, arg2 v1beta10.ConditionSeverity, arg3 string) {
 	m.ctrl.T.Helper()
@@ -212,6 +297,18 @@ func (mr *MockVMScopeMockRecorder) SetLongRunningOperationState(arg0 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLongRunningOperationState", reflect.TypeOf((*MockVMScope)(nil).SetLongRunningOperationState), arg0)
 }
 
+// SetNodeLabels mocks base method.
+func (m *MockVMScope) SetNodeLabels(arg0 map[string]string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetNodeLabels", arg0)
+}
+
+// SetNodeLabels indicates an expected call of SetNodeLabels.
+func (mr *MockVMScopeMockRecorder) SetNodeLabels(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNodeLabels", reflect.TypeOf((*MockVMScope)(nil).SetNodeLabels), arg0)
+}
+
 // SetProviderID mocks base method.
 func (m *MockVMScope) SetProviderID(arg0 string) {
 	m.ctrl.T.Helper()
@@ -236,6 +333,18 @@ func (mr *MockVMScopeMockRecorder) SetVMState(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVMState", reflect.TypeOf((*MockVMScope)(nil).SetVMState), arg0)
 }
 
+// SetVMPrincipalID mocks base method.
+func (m *MockVMScope) SetVMPrincipalID(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetVMPrincipalID", arg0)
+}
+
+// SetVMPrincipalID indicates an expected call of SetVMPrincipalID.
+func (mr *MockVMScopeMockRecorder) SetVMPrincipalID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVMPrincipalID", reflect.TypeOf((*MockVMScope)(nil).SetVMPrincipalID), arg0)
+}
+
 // SubscriptionID mocks base method.
 func (m *MockVMScope) SubscriptionID() string {
 	m.ctrl.T.Helper()
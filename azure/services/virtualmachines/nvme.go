@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualmachines
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+
+	"github.com/pkg/errors"
+)
+
+// localNVMeStorageBoothookTemplate is a cloud-init boothook striping the local NVMe disks of the VM into a RAID 0 array
+// mounted at the path given as the format argument. Boothooks run on every boot, and the local NVMe disks are wiped
+// when the VM is deallocated or redeployed, so the array is created again when it can't be assembled. Only the local
+// NVMe disks are striped: the remote disks of VMs with an NVMe disk controller are attached with another model.
+const localNVMeStorageBoothookTemplate = `#cloud-boothook
+#!/bin/sh
+set -eu
+MOUNT_PATH='%s'
+ARRAY=/dev/md/capz-local-nvme
+if mountpoint -q "$MOUNT_PATH"; then
+  exit 0
+fi
+DISKS=""
+for model in /sys/block/nvme*n1/device/model; do
+  [ -e "$model" ] || continue
+  if grep -q "NVMe Direct Disk" "$model"; then
+    DISKS="$DISKS /dev/$(basename "$(dirname "$(dirname "$model")")")"
+  fi
+done
+if [ -z "$DISKS" ]; then
+  echo "no local NVMe disks found, skipping the local NVMe storage setup" >&2
+  exit 0
+fi
+if ! mdadm --assemble "$ARRAY" $DISKS 2>/dev/null; then
+  set -- $DISKS
+  mdadm --create "$ARRAY" --level=0 --raid-devices=$# --run --force "$@"
+  mkfs.ext4 -F "$ARRAY"
+fi
+mkdir -p "$MOUNT_PATH"
+mount "$ARRAY" "$MOUNT_PATH"
+`
+
+// localNVMeStorageBoothook returns the cloud-init boothook striping the local NVMe disks into a RAID 0 array mounted
+// at mountPath.
+func localNVMeStorageBoothook(mountPath string) string {
+	return fmt.Sprintf(localNVMeStorageBoothookTemplate, mountPath)
+}
+
+// isCloudConfig returns true if the bootstrap data is a cloud-init cloud-config, which may be a jinja template.
+func isCloudConfig(bootstrapData []byte) bool {
+	return bytes.HasPrefix(bootstrapData, []byte("#cloud-config")) || bytes.HasPrefix(bootstrapData, []byte("## template: jinja"))
+}
+
+// withBoothooks returns the bootstrap data preceded by cloud-init boothooks, as a cloud-init MIME multi-part archive.
+// The bootstrap data must be a cloud-init cloud-config, see isCloudConfig.
+func withBoothooks(bootstrapData []byte, boothooks []string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=%q\r\n\r\n", w.Boundary())
+	type mimePart struct {
+		contentType string
+		content     []byte
+	}
+	parts := make([]mimePart, 0, len(boothooks)+1)
+	for _, boothook := range boothooks {
+		parts = append(parts, mimePart{contentType: "text/cloud-boothook", content: []byte(boothook)})
+	}
+	// cloud-init infers the type of text/plain parts from their first line, which handles jinja templates.
+	parts = append(parts, mimePart{contentType: "text/plain", content: bootstrapData})
+	for _, part := range parts {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":        {part.contentType + `; charset="us-ascii"`},
+			"Content-Disposition": {"attachment"},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create cloud-init multi-part archive")
+		}
+		if _, err := pw.Write(part.content); err != nil {
+			return nil, errors.Wrap(err, "failed to create cloud-init multi-part archive")
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to create cloud-init multi-part archive")
+	}
+	return buf.Bytes(), nil
+}
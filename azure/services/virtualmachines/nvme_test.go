@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualmachines
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+func TestLocalNVMeStorageBoothook(t *testing.T) {
+	g := NewWithT(t)
+
+	boothook := localNVMeStorageBoothook("/var/lib/containerd")
+	g.Expect(boothook).To(HavePrefix("#cloud-boothook\n#!/bin/sh\n"))
+	g.Expect(boothook).To(ContainSubstring("MOUNT_PATH='/var/lib/containerd'"))
+	g.Expect(boothook).To(ContainSubstring(`mdadm --create "$ARRAY" --level=0`))
+	g.Expect(boothook).To(ContainSubstring(`mount "$ARRAY" "$MOUNT_PATH"`))
+}
+
+func TestCustomDataWithLocalNVMeStorage(t *testing.T) {
+	cloudConfig := []byte("## template: jinja\n#cloud-config\nruncmd:\n- kubeadm join\n")
+
+	testcases := []struct {
+		name          string
+		bootstrapData []byte
+		compress      bool
+		expectedError string
+	}{
+		{
+			name:          "cloud-config bootstrap data is preceded by the boothook",
+			bootstrapData: cloudConfig,
+		},
+		{
+			name:          "compressed cloud-config bootstrap data is preceded by the boothook",
+			bootstrapData: cloudConfig,
+			compress:      true,
+		},
+		{
+			name:          "ignition bootstrap data is not supported",
+			bootstrapData: []byte(`{"ignition":{"version":"3.3.0"}}`),
+			expectedError: "failed to generate OS Profile: reconcile error that cannot be recovered occurred: local NVMe storage requires cloud-init cloud-config bootstrap data. Object will not be requeued",
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			spec := &VMSpec{
+				Name:                  "my-vm",
+				Role:                  infrav1.Node,
+				NICIDs:                []string{"my-nic"},
+				SSHKeyData:            "fakesshpublickey",
+				Size:                  "Standard_L8s_v3",
+				Zone:                  "1",
+				Image:                 &infrav1.Image{ID: ptr.To("fake-image-id")},
+				SKU:                   validSKUWithLocalNVMeDisks,
+				BootstrapData:         base64.StdEncoding.EncodeToString(tc.bootstrapData),
+				CompressBootstrapData: tc.compress,
+				LocalNVMeStorage:      &infrav1.LocalNVMeStorage{MountPath: "/mnt/nvme"},
+			}
+			result, err := spec.Parameters(context.TODO(), nil)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+
+			customData, err := base64.StdEncoding.DecodeString(*result.(compute.VirtualMachine).OsProfile.CustomData)
+			g.Expect(err).NotTo(HaveOccurred())
+			if tc.compress {
+				r, err := gzip.NewReader(bytes.NewReader(customData))
+				g.Expect(err).NotTo(HaveOccurred())
+				customData, err = io.ReadAll(r)
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			msg, err := mail.ReadMessage(bytes.NewReader(customData))
+			g.Expect(err).NotTo(HaveOccurred())
+			mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(mediaType).To(Equal("multipart/mixed"))
+
+			var contentTypes []string
+			var contents [][]byte
+			r := multipart.NewReader(msg.Body, params["boundary"])
+			for {
+				part, err := r.NextPart()
+				if err == io.EOF {
+					break
+				}
+				g.Expect(err).NotTo(HaveOccurred())
+				content, err := io.ReadAll(part)
+				g.Expect(err).NotTo(HaveOccurred())
+				contentTypes = append(contentTypes, part.Header.Get("Content-Type"))
+				contents = append(contents, content)
+			}
+			g.Expect(contentTypes).To(Equal([]string{`text/cloud-boothook; charset="us-ascii"`, `text/plain; charset="us-ascii"`}))
+			g.Expect(string(contents[0])).To(Equal(localNVMeStorageBoothook("/mnt/nvme")))
+			g.Expect(contents[1]).To(Equal(tc.bootstrapData))
+		})
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualmachines
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+const (
+	// osDiskResizeDeallocating is the state of an OS disk resize while the VM is deallocated and its OS disk is grown.
+	osDiskResizeDeallocating = "Deallocating"
+	// osDiskResizeStarting is the state of an OS disk resize while the VM is started again after its OS disk was grown.
+	osDiskResizeStarting = "Starting"
+
+	// deallocatedPowerState is the code of the instance view status of a deallocated VM.
+	deallocatedPowerState = "PowerState/deallocated"
+)
+
+// errOSDiskResizeRequiresDeallocation is returned when computing the parameters of a VM whose OS disk must grow while
+// the VM isn't deallocated, as Azure only resizes the OS disk of a deallocated VM.
+var errOSDiskResizeRequiresDeallocation = errors.New("the VM must be deallocated to grow its OS disk")
+
+// osDiskGrowth returns the size of the OS disk of the spec and whether the OS disk of an existing VM is smaller.
+// Managed disks can't shrink, so a larger OS disk is left as is.
+func (s *VMSpec) osDiskGrowth(existing compute.VirtualMachine) (int32, bool) {
+	desired := ptr.Deref(s.OSDisk.DiskSizeGB, 0)
+	if desired == 0 || existing.VirtualMachineProperties == nil || existing.StorageProfile == nil || existing.StorageProfile.OsDisk == nil {
+		return 0, false
+	}
+	current := ptr.Deref(existing.StorageProfile.OsDisk.DiskSizeGB, 0)
+	return desired, current != 0 && current < desired
+}
+
+// isDeallocated returns true if the instance view of a VM reports it as deallocated.
+func isDeallocated(vm compute.VirtualMachine) bool {
+	if vm.VirtualMachineProperties == nil || vm.InstanceView == nil || vm.InstanceView.Statuses == nil {
+		return false
+	}
+	for _, status := range *vm.InstanceView.Statuses {
+		if strings.EqualFold(ptr.Deref(status.Code, ""), deallocatedPowerState) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForOSDiskResizeOperation waits for the deallocation or the start of a VM whose OS disk is being resized. It
+// returns a transient error while the operation is ongoing. The resize is over once the VM is started again.
+func (s *Service) waitForOSDiskResizeOperation(ctx context.Context, vmSpec azure.ResourceSpecGetter) error {
+	spec, ok := vmSpec.(*VMSpec)
+	if !ok || spec.OSDiskResizeState == "" {
+		return nil
+	}
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "virtualmachines.Service.waitForOSDiskResizeOperation")
+	defer done()
+
+	future := s.Scope.GetLongRunningOperationState(spec.Name, ServiceName, infrav1.PostFuture)
+	if future == nil {
+		return nil
+	}
+	sdkFuture, err := converters.FutureToSDK(*future)
+	if err != nil {
+		// Reset the future data to avoid getting stuck in a bad loop.
+		s.Scope.DeleteLongRunningOperationState(spec.Name, ServiceName, infrav1.PostFuture)
+		return errors.Wrap(err, "could not decode future data, resetting long-running operation state")
+	}
+	isDone, err := s.client.IsDone(ctx, sdkFuture)
+	if !isDone {
+		if err != nil {
+			return errors.Wrap(err, "failed checking if the operation was complete")
+		}
+		log.V(2).Info("long running operation is still ongoing", "service", ServiceName, "resource", spec.Name, "osDiskResize", spec.OSDiskResizeState)
+		return azure.WithTransientError(azure.NewOperationNotDoneError(future), reconciler.DefaultReconcilerRequeue)
+	}
+	// The operation is retried on the next reconciliation if it failed.
+	s.Scope.DeleteLongRunningOperationState(spec.Name, ServiceName, infrav1.PostFuture)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resize the OS disk of VM %s", spec.Name)
+	}
+	if spec.OSDiskResizeState == osDiskResizeStarting {
+		s.finishOSDiskResize(spec)
+	}
+	return nil
+}
+
+// deallocateForOSDiskResize deallocates a VM so that its OS disk can be grown. It returns a transient error so that the
+// OS disk is grown on the next reconciliation, once the VM is deallocated.
+func (s *Service) deallocateForOSDiskResize(ctx context.Context, vmSpec azure.ResourceSpecGetter) error {
+	spec, ok := vmSpec.(*VMSpec)
+	if !ok {
+		return errors.Errorf("%T is not a valid VM spec", vmSpec)
+	}
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "virtualmachines.Service.deallocateForOSDiskResize")
+	defer done()
+
+	if s.Scope.IsDryRun() {
+		s.Scope.RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason,
+			"Skipped deallocating VM %s to grow its OS disk to %d GB in dry-run mode", spec.Name, ptr.Deref(spec.OSDisk.DiskSizeGB, 0))
+		return nil
+	}
+
+	log.V(2).Info("deallocating VM to grow its OS disk", "resource", spec.Name, "resourceGroup", spec.ResourceGroup)
+	s.Scope.SetAnnotation(azure.OSDiskResizeAnnotation, osDiskResizeDeallocating)
+	s.Scope.RecordEvent(corev1.EventTypeNormal, infrav1.VMOSDiskResizingReason,
+		"Deallocating VM %s to grow its OS disk to %d GB", spec.Name, ptr.Deref(spec.OSDisk.DiskSizeGB, 0))
+	sdkFuture, err := s.client.DeallocateAsync(ctx, spec)
+	if sdkFuture != nil {
+		future, err := converters.SDKToFuture(sdkFuture, infrav1.PostFuture, ServiceName, spec.Name, spec.ResourceGroup)
+		if err != nil {
+			return errors.Wrapf(err, "failed to deallocate VM %s", spec.Name)
+		}
+		s.Scope.SetLongRunningOperationState(future)
+		return azure.WithTransientError(azure.NewOperationNotDoneError(future), reconciler.DefaultReconcilerRequeue)
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to deallocate VM %s to grow its OS disk", spec.Name)
+	}
+	return azure.WithTransientError(errors.Errorf("deallocated VM %s to grow its OS disk", spec.Name), reconciler.DefaultReconcilerRequeue)
+}
+
+// startAfterOSDiskResize starts a VM again once its OS disk was grown. It returns a transient error while the VM is
+// starting.
+func (s *Service) startAfterOSDiskResize(ctx context.Context, spec *VMSpec, vm compute.VirtualMachine) error {
+	if spec.OSDiskResizeState == "" {
+		return nil
+	}
+	if _, growing := spec.osDiskGrowth(vm); growing {
+		return nil
+	}
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "virtualmachines.Service.startAfterOSDiskResize")
+	defer done()
+
+	if s.Scope.IsDryRun() {
+		s.Scope.RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason,
+			"Skipped starting VM %s after growing its OS disk in dry-run mode", spec.Name)
+		return nil
+	}
+
+	log.V(2).Info("starting VM after growing its OS disk", "resource", spec.Name, "resourceGroup", spec.ResourceGroup)
+	s.Scope.SetAnnotation(azure.OSDiskResizeAnnotation, osDiskResizeStarting)
+	sdkFuture, err := s.client.StartAsync(ctx, spec)
+	if sdkFuture != nil {
+		future, err := converters.SDKToFuture(sdkFuture, infrav1.PostFuture, ServiceName, spec.Name, spec.ResourceGroup)
+		if err != nil {
+			return errors.Wrapf(err, "failed to start VM %s", spec.Name)
+		}
+		s.Scope.SetLongRunningOperationState(future)
+		return azure.WithTransientError(azure.NewOperationNotDoneError(future), reconciler.DefaultReconcilerRequeue)
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to start VM %s after growing its OS disk", spec.Name)
+	}
+	s.finishOSDiskResize(spec)
+	return nil
+}
+
+// finishOSDiskResize records that the OS disk of a VM was grown and the VM started again.
+func (s *Service) finishOSDiskResize(spec *VMSpec) {
+	s.Scope.RemoveAnnotation(azure.OSDiskResizeAnnotation)
+	spec.OSDiskResizeState = ""
+	s.Scope.RecordEvent(corev1.EventTypeNormal, infrav1.VMOSDiskResizedReason,
+		"Started VM %s after growing its OS disk to %d GB", spec.Name, ptr.Deref(spec.OSDisk.DiskSizeGB, 0))
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualmachines
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/preflight"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-azure/util/slice"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// checkPreflight checks the Azure resources a VM to be created depends on, rather than letting Azure reject the VM with
+// an opaque error: the existing disks it attaches, the disk encryption sets of its disks, the virtual machine scale set
+// it is placed into, the registration of the EncryptionAtHost feature and the features of its image. A resource that
+// can't be read, e.g. because the cluster identity isn't allowed to, is left to Azure to validate on VM creation.
+func (s *Service) checkPreflight(ctx context.Context, vmSpec azure.ResourceSpecGetter) error {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "virtualmachines.Service.checkPreflight")
+	defer done()
+
+	spec, ok := vmSpec.(*VMSpec)
+	if !ok || spec.ProviderID != "" {
+		return nil
+	}
+
+	var failures []string
+	failures = append(failures, s.checkExistingDisks(ctx, spec)...)
+	failures = append(failures, s.checkDiskEncryptionSets(ctx, spec)...)
+	failures = append(failures, s.checkVirtualMachineScaleSet(ctx, spec)...)
+	failures = append(failures, s.checkEncryptionAtHostFeature(ctx, spec)...)
+	failures = append(failures, s.checkImageFeatures(ctx, spec)...)
+	if len(failures) == 0 {
+		return nil
+	}
+
+	err := azure.WithTransientError(errors.Errorf("preflight checks of VM %s failed: %s", spec.Name, strings.Join(failures, "; ")), reconciler.DefaultReconcilerRequeue)
+	s.Scope.SetConditionFalse(infrav1.VMRunningCondition, infrav1.PreflightCheckFailedReason, clusterv1.ConditionSeverityError, err.Error())
+	return err
+}
+
+// checkExistingDisks checks the existing disks attached to a VM exist in its location, and that the zonal ones are in
+// its zone, as a zonal disk can only be attached to a VM in the same zone.
+func (s *Service) checkExistingDisks(ctx context.Context, spec *VMSpec) []string {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "virtualmachines.Service.checkExistingDisks")
+	defer done()
+
+	var failures []string
+	for _, dataDisk := range spec.DataDisks {
+		if dataDisk.AttachExistingDisk == nil {
+			continue
+		}
+		id := dataDisk.AttachExistingDisk.ID
+		disk, err := s.preflightClient.GetDisk(ctx, id)
+		if azure.ResourceNotFound(err) {
+			failures = append(failures, fmt.Sprintf("disk %s does not exist", id))
+			continue
+		} else if err != nil {
+			log.V(2).Info("unable to check the existing disk, skipping", "disk", id, "error", err.Error())
+			continue
+		}
+		if location := ptr.Deref(disk.Location, ""); !sameLocation(location, spec.Location) {
+			failures = append(failures, fmt.Sprintf("disk %s is in location %s, but the VM is in location %s", id, location, spec.Location))
+		}
+		zones := ptr.Deref(disk.Zones, nil)
+		if len(zones) == 0 || slice.Contains(zones, spec.Zone) {
+			continue
+		}
+		if spec.Zone == "" {
+			failures = append(failures, fmt.Sprintf("disk %s is in zone %s, but the VM has no availability zone", id, strings.Join(zones, ", ")))
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("disk %s is in zone %s, but the VM is in zone %s", id, strings.Join(zones, ", "), spec.Zone))
+	}
+	return failures
+}
+
+// checkDiskEncryptionSets checks the disk encryption sets of the disks of a VM exist in its location, as Azure requires
+// a disk encryption set to be in the location of the VM whose disks it encrypts with a customer-managed key.
+func (s *Service) checkDiskEncryptionSets(ctx context.Context, spec *VMSpec) []string {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "virtualmachines.Service.checkDiskEncryptionSets")
+	defer done()
+
+	var failures []string
+	for _, id := range diskEncryptionSetIDs(spec) {
+		diskEncryptionSet, err := s.preflightClient.GetDiskEncryptionSet(ctx, id)
+		if azure.ResourceNotFound(err) {
+			failures = append(failures, fmt.Sprintf("disk encryption set %s does not exist", id))
+			continue
+		} else if err != nil {
+			log.V(2).Info("unable to check the disk encryption set, skipping", "diskEncryptionSet", id, "error", err.Error())
+			continue
+		}
+		if location := ptr.Deref(diskEncryptionSet.Location, ""); !sameLocation(location, spec.Location) {
+			failures = append(failures, fmt.Sprintf("disk encryption set %s is in location %s, but the VM is in location %s", id, location, spec.Location))
+		}
+	}
+	return failures
+}
+
+// diskEncryptionSetIDs returns the distinct IDs of the disk encryption sets of the OS disk and the data disks of a VM.
+func diskEncryptionSetIDs(spec *VMSpec) []string {
+	var ids []string
+	add := func(ref *infrav1.DiskEncryptionSetParameters) {
+		if ref != nil && !slice.Contains(ids, ref.ID) {
+			ids = append(ids, ref.ID)
+		}
+	}
+	if managedDisk := spec.OSDisk.ManagedDisk; managedDisk != nil {
+		add(managedDisk.DiskEncryptionSet)
+		if managedDisk.SecurityProfile != nil {
+			add(managedDisk.SecurityProfile.DiskEncryptionSet)
+		}
+	}
+	for _, disk := range spec.DataDisks {
+		if disk.ManagedDisk != nil {
+			add(disk.ManagedDisk.DiskEncryptionSet)
+		}
+	}
+	return ids
+}
+
+// checkVirtualMachineScaleSet checks the virtual machine scale set a VM is placed into is in Flexible orchestration
+// mode, and that the fault domain of the VM exists in the scale set.
+func (s *Service) checkVirtualMachineScaleSet(ctx context.Context, spec *VMSpec) []string {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "virtualmachines.Service.checkVirtualMachineScaleSet")
+	defer done()
+
+	if spec.ScaleSetID == "" {
+		return nil
+	}
+	scaleSet, err := s.preflightClient.GetVirtualMachineScaleSet(ctx, spec.ScaleSetID)
+	if azure.ResourceNotFound(err) {
+		return []string{fmt.Sprintf("virtual machine scale set %s does not exist", spec.ScaleSetID)}
+	} else if err != nil {
+		log.V(2).Info("unable to check the virtual machine scale set, skipping", "scaleSet", spec.ScaleSetID, "error", err.Error())
+		return nil
+	}
+	if scaleSet.VirtualMachineScaleSetProperties == nil {
+		return nil
+	}
+
+	// Scale sets created without an orchestration mode are in Uniform orchestration mode.
+	if scaleSet.OrchestrationMode != compute.OrchestrationModeFlexible {
+		orchestrationMode := scaleSet.OrchestrationMode
+		if orchestrationMode == "" {
+			orchestrationMode = compute.OrchestrationModeUniform
+		}
+		return []string{fmt.Sprintf("VMs can only be placed into a virtual machine scale set in Flexible orchestration mode, but the orchestration mode of %s is %s", spec.ScaleSetID, orchestrationMode)}
+	}
+	count := ptr.Deref(scaleSet.PlatformFaultDomainCount, 0)
+	switch {
+	case spec.PlatformFaultDomain == nil || count == 0:
+	case count == 1:
+		return []string{fmt.Sprintf("a fault domain can't be set for a VM placed into virtual machine scale set %s, which has a single fault domain and spreads its VMs across fault domains", spec.ScaleSetID)}
+	case *spec.PlatformFaultDomain >= count:
+		return []string{fmt.Sprintf("fault domain %d doesn't exist in virtual machine scale set %s, which has %d fault domains", *spec.PlatformFaultDomain, spec.ScaleSetID, count)}
+	}
+	return nil
+}
+
+// checkEncryptionAtHostFeature checks the EncryptionAtHost feature is registered in the subscription when a VM requests
+// encryption at host.
+func (s *Service) checkEncryptionAtHostFeature(ctx context.Context, spec *VMSpec) []string {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "virtualmachines.Service.checkEncryptionAtHostFeature")
+	defer done()
+
+	if spec.SecurityProfile == nil || !ptr.Deref(spec.SecurityProfile.EncryptionAtHost, false) {
+		return nil
+	}
+	state, err := s.preflightClient.GetEncryptionAtHostFeatureState(ctx)
+	if err != nil {
+		log.V(2).Info("unable to check the registration of the EncryptionAtHost feature, skipping", "error", err.Error())
+		return nil
+	}
+	if state == "" || strings.EqualFold(state, preflight.FeatureRegistered) {
+		return nil
+	}
+	return []string{fmt.Sprintf("encryption at host requires the %s/%s feature to be registered in subscription %s, but its state is %s",
+		preflight.ComputeResourceProvider, preflight.EncryptionAtHostFeature, s.Scope.SubscriptionID(), state)}
+}
+
+// checkImageFeatures checks the image of a VM requesting trusted launch or a confidential VM is a Generation 2 image,
+// and that it supports confidential VMs for the latter.
+func (s *Service) checkImageFeatures(ctx context.Context, spec *VMSpec) []string {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "virtualmachines.Service.checkImageFeatures")
+	defer done()
+
+	if spec.SecurityProfile == nil {
+		return nil
+	}
+	var securityType string
+	switch spec.SecurityProfile.SecurityType {
+	case infrav1.SecurityTypesTrustedLaunch:
+		securityType = "trusted launch"
+	case infrav1.SecurityTypesConfidentialVM:
+		securityType = "confidential VMs"
+	default:
+		return nil
+	}
+
+	features, err := s.preflightClient.GetImageFeatures(ctx, spec.Image, spec.Location)
+	if err != nil {
+		log.V(2).Info("unable to check the features of the image, skipping", "error", err.Error())
+		return nil
+	}
+	switch {
+	case features.HyperVGeneration == "":
+		return nil
+	case !strings.EqualFold(features.HyperVGeneration, string(compute.HyperVGenerationV2)):
+		return []string{fmt.Sprintf("%s require a Generation 2 image, but the image of the VM is of hypervisor generation %s", securityType, features.HyperVGeneration)}
+	case spec.SecurityProfile.SecurityType == infrav1.SecurityTypesConfidentialVM && !strings.Contains(strings.ToLower(features.SecurityType), "confidentialvm"):
+		return []string{"confidential VMs require an image supporting them, e.g. an image with the ConfidentialVmSupported security type"}
+	}
+	return nil
+}
+
+// sameLocation returns true if two Azure location names are the same location, e.g. "West US" and "westus".
+func sameLocation(a, b string) bool {
+	normalize := func(location string) string {
+		return strings.ToLower(strings.ReplaceAll(location, " ", ""))
+	}
+	return normalize(a) == normalize(b)
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualmachines
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/mock/gomock"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/preflight"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/preflight/mock_preflight"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualmachines/mock_virtualmachines"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+func TestCheckPreflight(t *testing.T) {
+	const (
+		diskID              = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/my-disk"
+		diskEncryptionSetID = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/diskEncryptionSets/my-des"
+		scaleSetID          = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachineScaleSets/my-vmss"
+	)
+	notFoundError := autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusNotFound}, "Not Found")
+	existingDisk := []infrav1.DataDisk{{NameSuffix: "existing", AttachExistingDisk: &infrav1.AttachExistingDisk{ID: diskID}}}
+	encryptedOSDisk := infrav1.OSDisk{ManagedDisk: &infrav1.ManagedDiskParameters{
+		DiskEncryptionSet: &infrav1.DiskEncryptionSetParameters{ID: diskEncryptionSetID},
+	}}
+	encryptedDataDisks := []infrav1.DataDisk{{
+		NameSuffix:  "data",
+		ManagedDisk: &infrav1.ManagedDiskParameters{DiskEncryptionSet: &infrav1.DiskEncryptionSetParameters{ID: diskEncryptionSetID}},
+	}}
+	scaleSet := func(mode compute.OrchestrationMode, faultDomainCount int32) compute.VirtualMachineScaleSet {
+		return compute.VirtualMachineScaleSet{VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{
+			OrchestrationMode:        mode,
+			PlatformFaultDomainCount: ptr.To(faultDomainCount),
+		}}
+	}
+
+	testcases := []struct {
+		name          string
+		spec          VMSpec
+		expect        func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder)
+		expectedError string
+	}{
+		{
+			name: "existing VM is not checked",
+			spec: VMSpec{DataDisks: existingDisk, ProviderID: "azure:///subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm"},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+			},
+		},
+		{
+			name: "VM without referenced resources is not checked",
+			spec: VMSpec{Location: "westus", DataDisks: []infrav1.DataDisk{{NameSuffix: "data"}}},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+			},
+		},
+		{
+			name: "existing disk in the location and zone of the VM",
+			spec: VMSpec{Location: "westus2", Zone: "1", DataDisks: existingDisk},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetDisk(gomockinternal.AContext(), diskID).Return(compute.Disk{Location: ptr.To("West US 2"), Zones: &[]string{"1"}}, nil)
+			},
+		},
+		{
+			name: "existing disk that does not exist",
+			spec: VMSpec{Name: "my-vm", Location: "westus2", DataDisks: existingDisk},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetDisk(gomockinternal.AContext(), diskID).Return(compute.Disk{}, notFoundError)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.PreflightCheckFailedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "preflight checks of VM my-vm failed: disk " + diskID + " does not exist",
+		},
+		{
+			name: "existing disk in another location and zone",
+			spec: VMSpec{Name: "my-vm", Location: "westus2", Zone: "2", DataDisks: existingDisk},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetDisk(gomockinternal.AContext(), diskID).Return(compute.Disk{Location: ptr.To("eastus"), Zones: &[]string{"1"}}, nil)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.PreflightCheckFailedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "disk " + diskID + " is in location eastus, but the VM is in location westus2; disk " + diskID + " is in zone 1, but the VM is in zone 2",
+		},
+		{
+			name: "zonal existing disk for a VM without availability zone",
+			spec: VMSpec{Name: "my-vm", Location: "westus2", DataDisks: existingDisk},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetDisk(gomockinternal.AContext(), diskID).Return(compute.Disk{Location: ptr.To("westus2"), Zones: &[]string{"3"}}, nil)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.PreflightCheckFailedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "disk " + diskID + " is in zone 3, but the VM has no availability zone",
+		},
+		{
+			name: "existing disk that cannot be read is not checked",
+			spec: VMSpec{Location: "westus2", DataDisks: existingDisk},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetDisk(gomockinternal.AContext(), diskID).Return(compute.Disk{}, internalError)
+			},
+		},
+		{
+			name: "disk encryption set shared by the disks is checked once",
+			spec: VMSpec{Location: "westus2", OSDisk: encryptedOSDisk, DataDisks: encryptedDataDisks},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetDiskEncryptionSet(gomockinternal.AContext(), diskEncryptionSetID).Return(compute.DiskEncryptionSet{Location: ptr.To("westus2")}, nil)
+			},
+		},
+		{
+			name: "disk encryption set in another location",
+			spec: VMSpec{Name: "my-vm", Location: "westus2", OSDisk: encryptedOSDisk},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetDiskEncryptionSet(gomockinternal.AContext(), diskEncryptionSetID).Return(compute.DiskEncryptionSet{Location: ptr.To("eastus")}, nil)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.PreflightCheckFailedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "disk encryption set " + diskEncryptionSetID + " is in location eastus, but the VM is in location westus2",
+		},
+		{
+			name: "disk encryption set that does not exist",
+			spec: VMSpec{Name: "my-vm", Location: "westus2", DataDisks: encryptedDataDisks},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetDiskEncryptionSet(gomockinternal.AContext(), diskEncryptionSetID).Return(compute.DiskEncryptionSet{}, notFoundError)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.PreflightCheckFailedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "disk encryption set " + diskEncryptionSetID + " does not exist",
+		},
+		{
+			name: "virtual machine scale set in Flexible orchestration mode",
+			spec: VMSpec{ScaleSetID: scaleSetID, PlatformFaultDomain: ptr.To[int32](1)},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetVirtualMachineScaleSet(gomockinternal.AContext(), scaleSetID).Return(scaleSet(compute.OrchestrationModeFlexible, 3), nil)
+			},
+		},
+		{
+			name: "virtual machine scale set without orchestration mode",
+			spec: VMSpec{Name: "my-vm", ScaleSetID: scaleSetID},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetVirtualMachineScaleSet(gomockinternal.AContext(), scaleSetID).Return(scaleSet("", 5), nil)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.PreflightCheckFailedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "the orchestration mode of " + scaleSetID + " is Uniform",
+		},
+		{
+			name: "fault domain of a virtual machine scale set with a single fault domain",
+			spec: VMSpec{Name: "my-vm", ScaleSetID: scaleSetID, PlatformFaultDomain: ptr.To[int32](0)},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetVirtualMachineScaleSet(gomockinternal.AContext(), scaleSetID).Return(scaleSet(compute.OrchestrationModeFlexible, 1), nil)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.PreflightCheckFailedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "a fault domain can't be set for a VM placed into virtual machine scale set " + scaleSetID,
+		},
+		{
+			name: "fault domain beyond the fault domains of the virtual machine scale set",
+			spec: VMSpec{Name: "my-vm", ScaleSetID: scaleSetID, PlatformFaultDomain: ptr.To[int32](2)},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetVirtualMachineScaleSet(gomockinternal.AContext(), scaleSetID).Return(scaleSet(compute.OrchestrationModeFlexible, 2), nil)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.PreflightCheckFailedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "fault domain 2 doesn't exist in virtual machine scale set " + scaleSetID + ", which has 2 fault domains",
+		},
+		{
+			name: "encryption at host with the feature registered",
+			spec: VMSpec{SecurityProfile: &infrav1.SecurityProfile{EncryptionAtHost: ptr.To(true)}},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetEncryptionAtHostFeatureState(gomockinternal.AContext()).Return("Registered", nil)
+			},
+		},
+		{
+			name: "encryption at host with the feature not registered",
+			spec: VMSpec{Name: "my-vm", SecurityProfile: &infrav1.SecurityProfile{EncryptionAtHost: ptr.To(true)}},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetEncryptionAtHostFeatureState(gomockinternal.AContext()).Return("NotRegistered", nil)
+				s.SubscriptionID().Return("123")
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.PreflightCheckFailedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "encryption at host requires the Microsoft.Compute/EncryptionAtHost feature to be registered in subscription 123, but its state is NotRegistered",
+		},
+		{
+			name: "trusted launch with a Generation 2 image",
+			spec: VMSpec{Location: "westus2", SecurityProfile: &infrav1.SecurityProfile{SecurityType: infrav1.SecurityTypesTrustedLaunch}},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetImageFeatures(gomockinternal.AContext(), nil, "westus2").Return(preflight.ImageFeatures{HyperVGeneration: "V2"}, nil)
+			},
+		},
+		{
+			name: "trusted launch with a Generation 1 image",
+			spec: VMSpec{Name: "my-vm", Location: "westus2", SecurityProfile: &infrav1.SecurityProfile{SecurityType: infrav1.SecurityTypesTrustedLaunch}},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetImageFeatures(gomockinternal.AContext(), nil, "westus2").Return(preflight.ImageFeatures{HyperVGeneration: "V1"}, nil)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.PreflightCheckFailedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "trusted launch require a Generation 2 image, but the image of the VM is of hypervisor generation V1",
+		},
+		{
+			name: "confidential VM with an image not supporting confidential VMs",
+			spec: VMSpec{Name: "my-vm", Location: "westus2", SecurityProfile: &infrav1.SecurityProfile{SecurityType: infrav1.SecurityTypesConfidentialVM}},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetImageFeatures(gomockinternal.AContext(), nil, "westus2").Return(preflight.ImageFeatures{HyperVGeneration: "V2", SecurityType: "TrustedLaunchSupported"}, nil)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.PreflightCheckFailedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "confidential VMs require an image supporting them",
+		},
+		{
+			name: "confidential VM with an image supporting confidential VMs",
+			spec: VMSpec{Location: "westus2", SecurityProfile: &infrav1.SecurityProfile{SecurityType: infrav1.SecurityTypesConfidentialVM}},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetImageFeatures(gomockinternal.AContext(), nil, "westus2").Return(preflight.ImageFeatures{HyperVGeneration: "V2", SecurityType: "TrustedLaunchAndConfidentialVmSupported"}, nil)
+			},
+		},
+		{
+			name: "image whose features cannot be read is not checked",
+			spec: VMSpec{Location: "westus2", SecurityProfile: &infrav1.SecurityProfile{SecurityType: infrav1.SecurityTypesTrustedLaunch}},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_preflight.MockClientMockRecorder) {
+				m.GetImageFeatures(gomockinternal.AContext(), nil, "westus2").Return(preflight.ImageFeatures{}, internalError)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_virtualmachines.NewMockVMScope(mockCtrl)
+			preflightMock := mock_preflight.NewMockClient(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), preflightMock.EXPECT())
+			s := &Service{
+				Scope:           scopeMock,
+				preflightClient: preflightMock,
+			}
+
+			err := s.checkPreflight(context.TODO(), &tc.spec)
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tc.expectedError))
+				var reconcileErr azure.ReconcileError
+				g.Expect(errors.As(err, &reconcileErr)).To(BeTrue())
+				g.Expect(reconcileErr.IsTerminal()).To(BeFalse())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
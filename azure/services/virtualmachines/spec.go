@@ -17,32 +17,48 @@ limitations under the License.
 package virtualmachines
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/base64"
 	"fmt"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
+	azureutil "sigs.k8s.io/cluster-api-provider-azure/util/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/util/generators"
 )
 
+// MaxCustomDataLength is the maximum length of the base64-encoded custom data of a VM.
+const MaxCustomDataLength = 64 * 1024
+
+// maxDataDiskLun is the highest logical unit number of a data disk of a VM.
+const maxDataDiskLun = 63
+
 // VMSpec defines the specification for a Virtual Machine.
 type VMSpec struct {
 	Name                   string
 	ResourceGroup          string
+	SubscriptionID         string
 	Location               string
 	ExtendedLocation       *infrav1.ExtendedLocationSpec
 	ClusterName            string
 	Role                   string
 	NICIDs                 []string
 	SSHKeyData             string
+	SSHKeySecretRef        *infrav1.KeyVaultSecretReference
 	Size                   string
 	AvailabilitySetID      string
+	ScaleSetID             string
+	PlatformFaultDomain    *int32
 	Zone                   string
 	Identity               infrav1.VMIdentity
 	OSDisk                 infrav1.OSDisk
@@ -56,7 +72,22 @@ type VMSpec struct {
 	SKU                    resourceskus.SKU
 	Image                  *infrav1.Image
 	BootstrapData          string
+	CompressBootstrapData  bool
+	LocalNVMeStorage       *infrav1.LocalNVMeStorage
+	DataDiskArray          *infrav1.DataDiskArray
+	ReplicateGalleryImage  bool
 	ProviderID             string
+	OSDiskResizeState      string
+	NodeLabelTags          []string
+}
+
+// vmUpdate is a minimal update of an existing VM, which only sets the fields of the VM that drifted from the spec.
+type vmUpdate struct {
+	compute.VirtualMachineUpdate
+	// driftedFields are the fields of the VM that drifted from the spec and are reapplied by the update.
+	driftedFields []string
+	// attachedDataDisks are the names of the data disks of the spec that are attached to the VM by the update.
+	attachedDataDisks []string
 }
 
 // ResourceName returns the name of the virtual machine.
@@ -77,11 +108,12 @@ func (s *VMSpec) OwnerResourceName() string {
 // Parameters returns the parameters for the virtual machine.
 func (s *VMSpec) Parameters(ctx context.Context, existing interface{}) (params interface{}, err error) {
 	if existing != nil {
-		if _, ok := existing.(compute.VirtualMachine); !ok {
+		existingVM, ok := existing.(compute.VirtualMachine)
+		if !ok {
 			return nil, errors.Errorf("%T is not a compute.VirtualMachine", existing)
 		}
-		// vm already exists
-		return nil, nil
+		// vm already exists, only reapply the mutable fields that drifted from the spec.
+		return s.reapplyDrift(existingVM)
 	}
 
 	// VM got deleted outside of capz, do not recreate it as Machines are immutable.
@@ -89,6 +121,10 @@ func (s *VMSpec) Parameters(ctx context.Context, existing interface{}) (params i
 		return nil, azure.VMDeletedError{ProviderID: s.ProviderID}
 	}
 
+	if s.LocalNVMeStorage != nil && !s.SKU.HasLocalNVMeDisks() {
+		return nil, azure.WithTerminalError(errors.Errorf("local NVMe storage is not supported for VM type %s", s.Size))
+	}
+
 	storageProfile, err := s.generateStorageProfile()
 	if err != nil {
 		return nil, err
@@ -118,16 +154,12 @@ func (s *VMSpec) Parameters(ctx context.Context, existing interface{}) (params i
 		Plan:             converters.ImageToPlan(s.Image),
 		Location:         ptr.To(s.Location),
 		ExtendedLocation: converters.ExtendedLocationToComputeSDK(s.ExtendedLocation),
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
-			ClusterName: s.ClusterName,
-			Lifecycle:   infrav1.ResourceLifecycleOwned,
-			Name:        ptr.To(s.Name),
-			Role:        ptr.To(s.Role),
-			Additional:  s.AdditionalTags,
-		})),
+		Tags:             converters.TagsToMap(s.generateTags()),
 		VirtualMachineProperties: &compute.VirtualMachineProperties{
 			AdditionalCapabilities: s.generateAdditionalCapabilities(),
 			AvailabilitySet:        s.getAvailabilitySet(),
+			VirtualMachineScaleSet: s.getVirtualMachineScaleSet(),
+			PlatformFaultDomain:    s.PlatformFaultDomain,
 			HardwareProfile: &compute.HardwareProfile{
 				VMSize: compute.VirtualMachineSizeTypes(s.Size),
 			},
@@ -147,6 +179,236 @@ func (s *VMSpec) Parameters(ctx context.Context, existing interface{}) (params i
 	}, nil
 }
 
+// NodeLabels returns the labels of the node of the VM from the tags of the VM named in NodeLabelTags. Tag names are
+// matched case-insensitively like Azure does, and the tags with a value that isn't a valid label value are skipped.
+func (s *VMSpec) NodeLabels(vmTags map[string]*string) map[string]string {
+	labels := make(map[string]string, len(s.NodeLabelTags))
+	for _, tagName := range s.NodeLabelTags {
+		for name, value := range vmTags {
+			if !strings.EqualFold(name, tagName) || value == nil {
+				continue
+			}
+			if len(validation.IsValidLabelValue(*value)) == 0 {
+				labels[tagName] = *value
+			}
+		}
+	}
+	return labels
+}
+
+// generateTags generates the tags of the VM.
+func (s *VMSpec) generateTags() infrav1.Tags {
+	return tags.Merge(tags.MergeParams{
+		ClusterName:  s.ClusterName,
+		Lifecycle:    infrav1.ResourceLifecycleOwned,
+		Name:         ptr.To(s.Name),
+		Role:         ptr.To(s.Role),
+		ResourceTags: s.AdditionalTags,
+	})
+}
+
+// reapplyDrift compares the identities, boot diagnostics storage URI and data disks of an existing VM with the spec.
+// If any of them drifted, it returns a vmUpdate only setting these fields, otherwise it returns nil as there is nothing
+// to update. Identities and data disks added outside of CAPZ are preserved, and the tags of the VM are left to the tags
+// service. The OS disk is grown to the size of the spec once the VM is deallocated.
+func (s *VMSpec) reapplyDrift(existing compute.VirtualMachine) (interface{}, error) {
+	update := vmUpdate{
+		VirtualMachineUpdate: compute.VirtualMachineUpdate{
+			VirtualMachineProperties: &compute.VirtualMachineProperties{},
+		},
+	}
+
+	desiredIdentity, err := converters.VMIdentityToVMSDK(s.Identity, s.UserAssignedIdentities)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate VM identity")
+	}
+	if identity, drifted := mergeIdentity(existing.Identity, desiredIdentity); drifted {
+		update.Identity = identity
+		update.driftedFields = append(update.driftedFields, "identity")
+	}
+
+	if diagnosticsProfile, drifted := s.mergeBootDiagnosticsStorageURI(existing.VirtualMachineProperties); drifted {
+		update.DiagnosticsProfile = diagnosticsProfile
+		update.driftedFields = append(update.driftedFields, "boot diagnostics storage URI")
+	}
+
+	dataDisks, attachedDataDisks, err := s.attachNewDataDisks(existing)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachedDataDisks) > 0 {
+		update.StorageProfile = &compute.StorageProfile{DataDisks: &dataDisks}
+		update.attachedDataDisks = attachedDataDisks
+	}
+
+	osDiskSizeGB, osDiskGrowing := s.osDiskGrowth(existing)
+	if osDiskGrowing {
+		if !isDeallocated(existing) {
+			return nil, errors.Wrapf(errOSDiskResizeRequiresDeallocation, "OS disk of VM %s must grow to %d GB", s.Name, osDiskSizeGB)
+		}
+		if update.StorageProfile == nil {
+			update.StorageProfile = &compute.StorageProfile{}
+		}
+		update.StorageProfile.OsDisk = &compute.OSDisk{DiskSizeGB: ptr.To(osDiskSizeGB)}
+	}
+
+	if len(update.driftedFields) == 0 && len(update.attachedDataDisks) == 0 && !osDiskGrowing {
+		return nil, nil
+	}
+	return update, nil
+}
+
+// attachNewDataDisks returns the data disks of the existing VM with the data disks of the spec it is missing appended,
+// along with the names of the appended data disks, so that data disks added to an existing AzureMachine are created and
+// attached without recreating the VM. A new data disk whose LUN is already used by a data disk attached outside of
+// CAPZ, e.g. by the Azure Disk CSI driver, is attached with the lowest LUN that is neither used by the VM nor reserved
+// by the spec.
+func (s *VMSpec) attachNewDataDisks(existing compute.VirtualMachine) ([]compute.DataDisk, []string, error) {
+	if existing.VirtualMachineProperties == nil || existing.StorageProfile == nil {
+		return nil, nil, nil
+	}
+	var dataDisks []compute.DataDisk
+	if existing.StorageProfile.DataDisks != nil {
+		dataDisks = append(dataDisks, *existing.StorageProfile.DataDisks...)
+	}
+
+	attachedNames := make(map[string]struct{}, len(dataDisks))
+	usedLuns := make(map[int32]struct{}, len(dataDisks)+len(s.DataDisks))
+	for _, disk := range dataDisks {
+		attachedNames[strings.ToLower(ptr.Deref(disk.Name, ""))] = struct{}{}
+		if disk.Lun != nil {
+			usedLuns[*disk.Lun] = struct{}{}
+		}
+	}
+	reservedLuns := make(map[int32]struct{}, len(s.DataDisks))
+	for _, disk := range s.DataDisks {
+		if disk.Lun != nil {
+			reservedLuns[*disk.Lun] = struct{}{}
+		}
+	}
+
+	var newDataDisks []string
+	for _, disk := range s.DataDisks {
+		dataDisk, err := s.generateDataDisk(disk)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := attachedNames[strings.ToLower(ptr.Deref(dataDisk.Name, ""))]; ok {
+			continue
+		}
+		if _, ok := usedLuns[ptr.Deref(dataDisk.Lun, 0)]; ok || dataDisk.Lun == nil {
+			lun, found := freeLun(usedLuns, reservedLuns)
+			if !found {
+				return nil, nil, azure.WithTerminalError(errors.Errorf("no logical unit number is left on VM %s to attach data disk %s", s.Name, disk.NameSuffix))
+			}
+			dataDisk.Lun = ptr.To(lun)
+		}
+		usedLuns[*dataDisk.Lun] = struct{}{}
+		dataDisks = append(dataDisks, dataDisk)
+		newDataDisks = append(newDataDisks, ptr.Deref(dataDisk.Name, ""))
+	}
+	return dataDisks, newDataDisks, nil
+}
+
+// freeLun returns the lowest logical unit number of a data disk that is neither used nor reserved.
+func freeLun(used, reserved map[int32]struct{}) (int32, bool) {
+	for lun := int32(0); lun <= maxDataDiskLun; lun++ {
+		_, isUsed := used[lun]
+		_, isReserved := reserved[lun]
+		if !isUsed && !isReserved {
+			return lun, true
+		}
+	}
+	return 0, false
+}
+
+// mergeBootDiagnosticsStorageURI returns the diagnostics profile of the existing VM with the storage URI of the
+// user-managed boot diagnostics applied on top of it, and whether the storage URI of the existing VM was different,
+// e.g. because the storage account was rotated.
+func (s *VMSpec) mergeBootDiagnosticsStorageURI(existing *compute.VirtualMachineProperties) (*compute.DiagnosticsProfile, bool) {
+	if s.DiagnosticsProfile == nil || s.DiagnosticsProfile.Boot == nil ||
+		s.DiagnosticsProfile.Boot.StorageAccountType != infrav1.UserManagedDiagnosticsStorage ||
+		s.DiagnosticsProfile.Boot.UserManaged == nil || s.DiagnosticsProfile.Boot.UserManaged.StorageAccountURI == "" {
+		return nil, false
+	}
+	desiredURI := s.DiagnosticsProfile.Boot.UserManaged.StorageAccountURI
+
+	var diagnosticsProfile compute.DiagnosticsProfile
+	var bootDiagnostics compute.BootDiagnostics
+	if existing != nil && existing.DiagnosticsProfile != nil {
+		diagnosticsProfile = *existing.DiagnosticsProfile
+		if diagnosticsProfile.BootDiagnostics != nil {
+			bootDiagnostics = *diagnosticsProfile.BootDiagnostics
+		}
+	}
+	// Azure may normalize the trailing slash and the case of the host of the storage URI.
+	existingURI := ptr.Deref(bootDiagnostics.StorageURI, "")
+	if strings.EqualFold(strings.TrimSuffix(existingURI, "/"), strings.TrimSuffix(desiredURI, "/")) {
+		return existing.DiagnosticsProfile, false
+	}
+
+	bootDiagnostics.Enabled = ptr.To(true)
+	bootDiagnostics.StorageURI = ptr.To(desiredURI)
+	diagnosticsProfile.BootDiagnostics = &bootDiagnostics
+	return &diagnosticsProfile, true
+}
+
+// mergeIdentity returns the existing identity with the desired system-assigned and user-assigned identities added to it,
+// and whether any of the desired identities was missing.
+func mergeIdentity(existing, desired *compute.VirtualMachineIdentity) (*compute.VirtualMachineIdentity, bool) {
+	if desired == nil {
+		return existing, false
+	}
+
+	var existingType compute.ResourceIdentityType
+	existingIDs := map[string]struct{}{}
+	if existing != nil {
+		existingType = existing.Type
+		for id := range existing.UserAssignedIdentities {
+			existingIDs[strings.ToLower(id)] = struct{}{}
+		}
+	}
+	hasSystemAssigned := existingType == compute.ResourceIdentityTypeSystemAssigned || existingType == compute.ResourceIdentityTypeSystemAssignedUserAssigned
+
+	drifted := false
+	if desired.Type == compute.ResourceIdentityTypeSystemAssigned && !hasSystemAssigned {
+		drifted = true
+		hasSystemAssigned = true
+	}
+
+	// Azure rejects read-only identity properties such as the principal ID, so we send empty values.
+	userAssignedIdentities := map[string]*compute.VirtualMachineIdentityUserAssignedIdentitiesValue{}
+	if existing != nil {
+		for id := range existing.UserAssignedIdentities {
+			userAssignedIdentities[id] = &compute.VirtualMachineIdentityUserAssignedIdentitiesValue{}
+		}
+	}
+	for id := range desired.UserAssignedIdentities {
+		if _, ok := existingIDs[strings.ToLower(id)]; !ok {
+			drifted = true
+			userAssignedIdentities[id] = &compute.VirtualMachineIdentityUserAssignedIdentitiesValue{}
+		}
+	}
+
+	if !drifted {
+		return existing, false
+	}
+
+	merged := &compute.VirtualMachineIdentity{}
+	switch {
+	case hasSystemAssigned && len(userAssignedIdentities) > 0:
+		merged.Type = compute.ResourceIdentityTypeSystemAssignedUserAssigned
+	case hasSystemAssigned:
+		merged.Type = compute.ResourceIdentityTypeSystemAssigned
+	default:
+		merged.Type = compute.ResourceIdentityTypeUserAssigned
+	}
+	if len(userAssignedIdentities) > 0 {
+		merged.UserAssignedIdentities = userAssignedIdentities
+	}
+	return merged, true
+}
+
 // generateStorageProfile generates a pointer to a compute.StorageProfile which can utilized for VM creation.
 func (s *VMSpec) generateStorageProfile() (*compute.StorageProfile, error) {
 	storageProfile := &compute.StorageProfile{
@@ -214,28 +476,11 @@ func (s *VMSpec) generateStorageProfile() (*compute.StorageProfile, error) {
 
 	dataDisks := make([]compute.DataDisk, len(s.DataDisks))
 	for i, disk := range s.DataDisks {
-		dataDisks[i] = compute.DataDisk{
-			CreateOption: compute.DiskCreateOptionTypesEmpty,
-			DiskSizeGB:   ptr.To[int32](disk.DiskSizeGB),
-			Lun:          disk.Lun,
-			Name:         ptr.To(azure.GenerateDataDiskName(s.Name, disk.NameSuffix)),
-			Caching:      compute.CachingTypes(disk.CachingType),
-		}
-
-		if disk.ManagedDisk != nil {
-			dataDisks[i].ManagedDisk = &compute.ManagedDiskParameters{
-				StorageAccountType: compute.StorageAccountTypes(disk.ManagedDisk.StorageAccountType),
-			}
-
-			if disk.ManagedDisk.DiskEncryptionSet != nil {
-				dataDisks[i].ManagedDisk.DiskEncryptionSet = &compute.DiskEncryptionSetParameters{ID: ptr.To(disk.ManagedDisk.DiskEncryptionSet.ID)}
-			}
-
-			// check the support for ultra disks based on location and vm size
-			if disk.ManagedDisk.StorageAccountType == string(compute.StorageAccountTypesUltraSSDLRS) && !s.SKU.HasLocationCapability(resourceskus.UltraSSDAvailable, s.Location, s.Zone) {
-				return nil, azure.WithTerminalError(fmt.Errorf("VM size %s does not support ultra disks in location %s. Select a different VM size or disable ultra disks", s.Size, s.Location))
-			}
+		dataDisk, err := s.generateDataDisk(disk)
+		if err != nil {
+			return nil, err
 		}
+		dataDisks[i] = dataDisk
 	}
 	storageProfile.DataDisks = &dataDisks
 
@@ -249,16 +494,149 @@ func (s *VMSpec) generateStorageProfile() (*compute.StorageProfile, error) {
 	return storageProfile, nil
 }
 
+// generateDataDisk returns the data disk of the VM for a data disk of the spec.
+func (s *VMSpec) generateDataDisk(disk infrav1.DataDisk) (compute.DataDisk, error) {
+	dataDisk := compute.DataDisk{
+		CreateOption: compute.DiskCreateOptionTypesEmpty,
+		DiskSizeGB:   ptr.To[int32](disk.DiskSizeGB),
+		Lun:          disk.Lun,
+		Name:         ptr.To(azure.GenerateDataDiskName(s.Name, disk.NameSuffix)),
+		Caching:      compute.CachingTypes(disk.CachingType),
+	}
+
+	if disk.ManagedDisk != nil {
+		dataDisk.ManagedDisk = &compute.ManagedDiskParameters{
+			StorageAccountType: compute.StorageAccountTypes(disk.ManagedDisk.StorageAccountType),
+		}
+
+		if disk.ManagedDisk.DiskEncryptionSet != nil {
+			dataDisk.ManagedDisk.DiskEncryptionSet = &compute.DiskEncryptionSetParameters{ID: ptr.To(disk.ManagedDisk.DiskEncryptionSet.ID)}
+		}
+
+		// check the support for ultra disks based on location and vm size
+		if disk.ManagedDisk.StorageAccountType == string(compute.StorageAccountTypesUltraSSDLRS) && !s.SKU.HasLocationCapability(resourceskus.UltraSSDAvailable, s.Location, s.Zone) {
+			return compute.DataDisk{}, azure.WithTerminalError(fmt.Errorf("VM size %s does not support ultra disks in location %s. Select a different VM size or disable ultra disks", s.Size, s.Location))
+		}
+	}
+
+	// shared data disks are created once for the cluster by the disks service and attached by ID.
+	if disk.IsShared() {
+		name := azure.GenerateSharedDataDiskName(s.ClusterName, disk.NameSuffix)
+		dataDisk.CreateOption = compute.DiskCreateOptionTypesAttach
+		dataDisk.DiskSizeGB = nil
+		dataDisk.Name = ptr.To(name)
+		if dataDisk.ManagedDisk == nil {
+			dataDisk.ManagedDisk = &compute.ManagedDiskParameters{}
+		}
+		dataDisk.ManagedDisk.ID = ptr.To(azure.DiskID(s.SubscriptionID, s.ResourceGroup, name))
+	}
+
+	// data disks with a logical sector size are created by the disks service and attached by ID.
+	if disk.HasLogicalSectorSize() {
+		name := azure.GenerateDataDiskName(s.Name, disk.NameSuffix)
+		dataDisk.CreateOption = compute.DiskCreateOptionTypesAttach
+		dataDisk.DiskSizeGB = nil
+		dataDisk.ManagedDisk.ID = ptr.To(azure.DiskID(s.SubscriptionID, s.ResourceGroup, name))
+	}
+
+	// existing data disks are attached by ID.
+	if disk.AttachExistingDisk != nil {
+		resourceID, err := azureutil.ParseResourceID(disk.AttachExistingDisk.ID)
+		if err != nil {
+			return compute.DataDisk{}, azure.WithTerminalError(errors.Wrapf(err, "failed to parse the ID of existing data disk %s", disk.NameSuffix))
+		}
+		dataDisk.CreateOption = compute.DiskCreateOptionTypesAttach
+		dataDisk.DiskSizeGB = nil
+		dataDisk.Name = ptr.To(resourceID.Name)
+		if dataDisk.ManagedDisk == nil {
+			dataDisk.ManagedDisk = &compute.ManagedDiskParameters{}
+		}
+		dataDisk.ManagedDisk.ID = ptr.To(disk.AttachExistingDisk.ID)
+	}
+	return dataDisk, nil
+}
+
+// CustomData returns the base64-encoded custom data of the VM, i.e. the bootstrap data, preceded by the boothooks
+// striping the local NVMe disks if LocalNVMeStorage is set and assembling the data disk array if DataDiskArray is set,
+// and gzip compressed if CompressBootstrapData is set. It returns an error if the custom data exceeds the maximum
+// length allowed by Azure.
+func (s *VMSpec) CustomData() (string, error) {
+	boothooks, features, err := s.boothooks()
+	if err != nil {
+		return "", azure.WithTerminalError(err)
+	}
+	customData := s.BootstrapData
+	if len(boothooks) > 0 || s.CompressBootstrapData {
+		bootstrapData, err := base64.StdEncoding.DecodeString(s.BootstrapData)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to decode bootstrap data")
+		}
+		if len(boothooks) > 0 {
+			if !isCloudConfig(bootstrapData) {
+				return "", azure.WithTerminalError(errors.Errorf("%s requires cloud-init cloud-config bootstrap data", strings.Join(features, " and ")))
+			}
+			bootstrapData, err = withBoothooks(bootstrapData, boothooks)
+			if err != nil {
+				return "", err
+			}
+		}
+		if s.CompressBootstrapData {
+			var compressed bytes.Buffer
+			w := gzip.NewWriter(&compressed)
+			if _, err := w.Write(bootstrapData); err != nil {
+				return "", errors.Wrap(err, "failed to compress bootstrap data")
+			}
+			if err := w.Close(); err != nil {
+				return "", errors.Wrap(err, "failed to compress bootstrap data")
+			}
+			bootstrapData = compressed.Bytes()
+		}
+		customData = base64.StdEncoding.EncodeToString(bootstrapData)
+	}
+
+	if len(customData) > MaxCustomDataLength {
+		msg := fmt.Sprintf("bootstrap data of VM %s is %d bytes once base64 encoded, which exceeds the maximum custom data size of %d bytes",
+			s.Name, len(customData), MaxCustomDataLength)
+		if !s.CompressBootstrapData {
+			msg += ", consider enabling the compression of the bootstrap data"
+		}
+		return "", azure.WithTerminalError(errors.New(msg))
+	}
+	return customData, nil
+}
+
+// boothooks returns the cloud-init boothooks preceding the bootstrap data, along with the features requiring them.
+func (s *VMSpec) boothooks() (boothooks []string, features []string, err error) {
+	if s.LocalNVMeStorage != nil {
+		boothooks = append(boothooks, localNVMeStorageBoothook(s.LocalNVMeStorage.MountPath))
+		features = append(features, "local NVMe storage")
+	}
+	if s.DataDiskArray != nil {
+		boothook, err := dataDiskArrayBoothook(s.DataDiskArray, s.DataDisks)
+		if err != nil {
+			return nil, nil, err
+		}
+		boothooks = append(boothooks, boothook)
+		features = append(features, "data disk array")
+	}
+	return boothooks, features, nil
+}
+
 func (s *VMSpec) generateOSProfile() (*compute.OSProfile, error) {
 	sshKey, err := base64.StdEncoding.DecodeString(s.SSHKeyData)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to decode ssh public key")
 	}
 
+	customData, err := s.CustomData()
+	if err != nil {
+		return nil, err
+	}
+
 	osProfile := &compute.OSProfile{
 		ComputerName:  ptr.To(s.Name),
 		AdminUsername: ptr.To(azure.DefaultUserName),
-		CustomData:    ptr.To(s.BootstrapData),
+		CustomData:    ptr.To(customData),
 	}
 
 	switch s.OSDisk.OSType {
@@ -333,6 +711,10 @@ func (s *VMSpec) generateSecurityProfile(storageProfile *compute.StorageProfile)
 		return securityProfile, nil
 	}
 
+	if s.SecurityProfile.SecurityType == infrav1.SecurityTypesConfidentialVM {
+		return nil, azure.WithTerminalError(errors.Errorf("securityEncryptionType should be set on the OS disk when securityType is set to %s", infrav1.SecurityTypesConfidentialVM))
+	}
+
 	if s.SecurityProfile.EncryptionAtHost != nil {
 		if !s.SKU.HasCapability(resourceskus.EncryptionAtHost) && *s.SecurityProfile.EncryptionAtHost {
 			return nil, azure.WithTerminalError(errors.Errorf("encryption at host is not supported for VM type %s", s.Size))
@@ -373,6 +755,23 @@ func (s *VMSpec) generateSecurityProfile(storageProfile *compute.StorageProfile)
 		}
 	}
 
+	if s.SecurityProfile.SecurityType == infrav1.SecurityTypesTrustedLaunch {
+		if hasTrustedLaunchDisabled {
+			return nil, azure.WithTerminalError(errors.Errorf("trusted launch is not supported for VM type %s", s.Size))
+		}
+
+		securityProfile.SecurityType = compute.SecurityTypesTrustedLaunch
+		// Secure boot and vTPM explicitly disabled are not left to the platform default.
+		if s.SecurityProfile.UefiSettings != nil {
+			if s.SecurityProfile.UefiSettings.SecureBootEnabled != nil && !*s.SecurityProfile.UefiSettings.SecureBootEnabled {
+				securityProfile.UefiSettings.SecureBootEnabled = ptr.To(false)
+			}
+			if s.SecurityProfile.UefiSettings.VTpmEnabled != nil && !*s.SecurityProfile.UefiSettings.VTpmEnabled {
+				securityProfile.UefiSettings.VTpmEnabled = ptr.To(false)
+			}
+		}
+	}
+
 	return securityProfile, nil
 }
 
@@ -413,6 +812,10 @@ func (s *VMSpec) generateAdditionalCapabilities() *compute.AdditionalCapabilitie
 		if s.AdditionalCapabilities.UltraSSDEnabled != nil {
 			capabilities.UltraSSDEnabled = s.AdditionalCapabilities.UltraSSDEnabled
 		}
+		// Set HibernationEnabled if a specific value is set on the spec for it.
+		if s.AdditionalCapabilities.HibernationEnabled != nil {
+			capabilities.HibernationEnabled = s.AdditionalCapabilities.HibernationEnabled
+		}
 	}
 
 	return capabilities
@@ -426,6 +829,14 @@ func (s *VMSpec) getAvailabilitySet() *compute.SubResource {
 	return as
 }
 
+func (s *VMSpec) getVirtualMachineScaleSet() *compute.SubResource {
+	var vmss *compute.SubResource
+	if s.ScaleSetID != "" {
+		vmss = &compute.SubResource{ID: &s.ScaleSetID}
+	}
+	return vmss
+}
+
 func (s *VMSpec) getZones() *[]string {
 	var zones *[]string
 	if s.Zone != "" {
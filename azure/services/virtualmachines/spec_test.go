@@ -17,13 +17,20 @@ limitations under the License.
 package virtualmachines
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
 	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
@@ -50,6 +57,28 @@ var (
 		},
 	}
 
+	validSKUWithLocalNVMeDisks = resourceskus.SKU{
+		Name: ptr.To("Standard_L8s_v3"),
+		Kind: ptr.To(string(resourceskus.VirtualMachines)),
+		Locations: &[]string{
+			"test-location",
+		},
+		Capabilities: &[]compute.ResourceSkuCapabilities{
+			{
+				Name:  ptr.To(resourceskus.VCPUs),
+				Value: ptr.To("8"),
+			},
+			{
+				Name:  ptr.To(resourceskus.MemoryGB),
+				Value: ptr.To("64"),
+			},
+			{
+				Name:  ptr.To(resourceskus.NvmeDiskSizeInMiB),
+				Value: ptr.To("1831420"),
+			},
+		},
+	}
+
 	validSKUWithEncryptionAtHost = resourceskus.SKU{
 		Name: ptr.To("Standard_D2v3"),
 		Kind: ptr.To(string(resourceskus.VirtualMachines)),
@@ -230,9 +259,19 @@ func TestParameters(t *testing.T) {
 			expectedError: "network.VirtualNetwork is not a compute.VirtualMachine",
 		},
 		{
-			name:     "returns nil if vm already exists",
-			spec:     &VMSpec{},
-			existing: compute.VirtualMachine{},
+			name: "returns nil if vm already exists and has not drifted",
+			spec: &VMSpec{
+				Name:        "my-vm",
+				ClusterName: "my-cluster",
+				Role:        "node",
+			},
+			existing: compute.VirtualMachine{
+				Tags: map[string]*string{
+					"Name": ptr.To("my-vm"),
+					"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+					"sigs.k8s.io_cluster-api-provider-azure_role":               ptr.To("node"),
+				},
+			},
 			expect: func(g *WithT, result interface{}) {
 				g.Expect(result).To(BeNil())
 			},
@@ -392,6 +431,52 @@ func TestParameters(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "can create a vm with os and data disks encrypted with the same disk encryption set",
+			spec: &VMSpec{
+				Name:       "my-vm",
+				Role:       infrav1.Node,
+				NICIDs:     []string{"my-nic"},
+				SSHKeyData: "fakesshpublickey",
+				Size:       "Standard_D2v3",
+				Zone:       "1",
+				Image:      &infrav1.Image{ID: ptr.To("fake-image-id")},
+				OSDisk: infrav1.OSDisk{
+					ManagedDisk: &infrav1.ManagedDiskParameters{
+						StorageAccountType: "Premium_LRS",
+						DiskEncryptionSet: &infrav1.DiskEncryptionSetParameters{
+							ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/diskEncryptionSets/my-des",
+						},
+					},
+				},
+				DataDisks: []infrav1.DataDisk{
+					{
+						NameSuffix: "mydisk",
+						DiskSizeGB: 64,
+						Lun:        ptr.To[int32](0),
+						ManagedDisk: &infrav1.ManagedDiskParameters{
+							StorageAccountType: "Premium_LRS",
+							DiskEncryptionSet: &infrav1.DiskEncryptionSetParameters{
+								ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/diskEncryptionSets/my-des",
+							},
+						},
+					},
+				},
+				SKU: validSKU,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				storageProfile := result.(compute.VirtualMachine).VirtualMachineProperties.StorageProfile
+				expectedDiskEncryptionSet := &compute.DiskEncryptionSetParameters{
+					ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/diskEncryptionSets/my-des"),
+				}
+				g.Expect(storageProfile.OsDisk.ManagedDisk.DiskEncryptionSet).To(Equal(expectedDiskEncryptionSet))
+				g.Expect(*storageProfile.DataDisks).To(HaveLen(1))
+				g.Expect((*storageProfile.DataDisks)[0].ManagedDisk.DiskEncryptionSet).To(Equal(expectedDiskEncryptionSet))
+			},
+			expectedError: "",
+		},
 		{
 			name: "can create a vm with encryption at host",
 			spec: &VMSpec{
@@ -412,6 +497,54 @@ func TestParameters(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "can create a vm with encryption at host and customer-managed key disk encryption",
+			spec: &VMSpec{
+				Name:       "my-vm",
+				Role:       infrav1.Node,
+				NICIDs:     []string{"my-nic"},
+				SSHKeyData: "fakesshpublickey",
+				Size:       "Standard_D2v3",
+				Zone:       "1",
+				Image:      &infrav1.Image{ID: ptr.To("fake-image-id")},
+				OSDisk: infrav1.OSDisk{
+					ManagedDisk: &infrav1.ManagedDiskParameters{
+						StorageAccountType: "Premium_LRS",
+						DiskEncryptionSet: &infrav1.DiskEncryptionSetParameters{
+							ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/diskEncryptionSets/my-des",
+						},
+					},
+				},
+				DataDisks: []infrav1.DataDisk{
+					{
+						NameSuffix: "mydisk",
+						DiskSizeGB: 64,
+						Lun:        ptr.To[int32](0),
+						ManagedDisk: &infrav1.ManagedDiskParameters{
+							StorageAccountType: "Premium_LRS",
+							DiskEncryptionSet: &infrav1.DiskEncryptionSetParameters{
+								ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/diskEncryptionSets/my-des",
+							},
+						},
+					},
+				},
+				SecurityProfile: &infrav1.SecurityProfile{EncryptionAtHost: ptr.To(true)},
+				SKU:             validSKUWithEncryptionAtHost,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				vm := result.(compute.VirtualMachine)
+				g.Expect(vm.SecurityProfile.EncryptionAtHost).To(Equal(ptr.To(true)))
+				expectedDiskEncryptionSet := &compute.DiskEncryptionSetParameters{
+					ID: ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/diskEncryptionSets/my-des"),
+				}
+				g.Expect(vm.StorageProfile.OsDisk.ManagedDisk.DiskEncryptionSet).To(Equal(expectedDiskEncryptionSet))
+				g.Expect(*vm.StorageProfile.DataDisks).To(HaveLen(1))
+				g.Expect((*vm.StorageProfile.DataDisks)[0].ManagedDisk.DiskEncryptionSet).To(Equal(expectedDiskEncryptionSet))
+			},
+			expectedError: "",
+		},
 		{
 			name: "can create a vm and assign it to an availability set",
 			spec: &VMSpec{
@@ -433,6 +566,84 @@ func TestParameters(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "can create a vm and place it into a flexible virtual machine scale set",
+			spec: &VMSpec{
+				Name:                "my-vm",
+				Role:                infrav1.Node,
+				NICIDs:              []string{"my-nic"},
+				SSHKeyData:          "fakesshpublickey",
+				Size:                "Standard_D2v3",
+				ScaleSetID:          "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachineScaleSets/my-vmss",
+				PlatformFaultDomain: ptr.To[int32](1),
+				Zone:                "",
+				Image:               &infrav1.Image{ID: ptr.To("fake-image-id")},
+				SKU:                 validSKU,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				g.Expect(result.(compute.VirtualMachine).AvailabilitySet).To(BeNil())
+				g.Expect(result.(compute.VirtualMachine).VirtualMachineScaleSet.ID).To(Equal(ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachineScaleSets/my-vmss")))
+				g.Expect(result.(compute.VirtualMachine).PlatformFaultDomain).To(Equal(ptr.To[int32](1)))
+			},
+			expectedError: "",
+		},
+		{
+			name: "can create a vm and place it into a flexible virtual machine scale set without a fault domain",
+			spec: &VMSpec{
+				Name:       "my-vm",
+				Role:       infrav1.Node,
+				NICIDs:     []string{"my-nic"},
+				SSHKeyData: "fakesshpublickey",
+				Size:       "Standard_D2v3",
+				ScaleSetID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachineScaleSets/my-vmss",
+				Zone:       "1",
+				Image:      &infrav1.Image{ID: ptr.To("fake-image-id")},
+				SKU:        validSKU,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				g.Expect(result.(compute.VirtualMachine).Zones).To(Equal(&[]string{"1"}))
+				g.Expect(result.(compute.VirtualMachine).VirtualMachineScaleSet.ID).To(Equal(ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachineScaleSets/my-vmss")))
+				g.Expect(result.(compute.VirtualMachine).PlatformFaultDomain).To(BeNil())
+			},
+			expectedError: "",
+		},
+		{
+			name: "can create a zonal vm with its data disks created in its zone",
+			spec: &VMSpec{
+				Name:       "my-vm",
+				Role:       infrav1.Node,
+				NICIDs:     []string{"my-nic"},
+				SSHKeyData: "fakesshpublickey",
+				Size:       "Standard_D2v3",
+				Zone:       "1",
+				Image:      &infrav1.Image{ID: ptr.To("fake-image-id")},
+				SKU:        validSKU,
+				DataDisks: []infrav1.DataDisk{
+					{
+						NameSuffix:  "data",
+						DiskSizeGB:  128,
+						Lun:         ptr.To[int32](0),
+						CachingType: string(compute.CachingTypesReadWrite),
+						ManagedDisk: &infrav1.ManagedDiskParameters{StorageAccountType: string(compute.StorageAccountTypesPremiumLRS)},
+					},
+				},
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				vm := result.(compute.VirtualMachine)
+				g.Expect(vm.Zones).To(Equal(&[]string{"1"}))
+				// data disks created with the VM are created in the zone of the VM.
+				g.Expect(*vm.StorageProfile.DataDisks).To(HaveLen(1))
+				g.Expect((*vm.StorageProfile.DataDisks)[0].CreateOption).To(Equal(compute.DiskCreateOptionTypesEmpty))
+				g.Expect((*vm.StorageProfile.DataDisks)[0].ManagedDisk.ID).To(BeNil())
+			},
+			expectedError: "",
+		},
 		{
 			name: "can create a vm with EphemeralOSDisk",
 			spec: &VMSpec{
@@ -527,6 +738,92 @@ func TestParameters(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "can create a confidential vm with a customer-managed key encrypted OS disk",
+			spec: &VMSpec{
+				Name:              "my-vm",
+				Role:              infrav1.Node,
+				NICIDs:            []string{"my-nic"},
+				SSHKeyData:        "fakesshpublickey",
+				Size:              "Standard_DC2as_v5",
+				AvailabilitySetID: "fake-availability-set-id",
+				Zone:              "",
+				Image:             &infrav1.Image{ID: ptr.To("fake-image-id")},
+				OSDisk: infrav1.OSDisk{
+					OSType:     "Linux",
+					DiskSizeGB: ptr.To[int32](128),
+					ManagedDisk: &infrav1.ManagedDiskParameters{
+						StorageAccountType: "Premium_LRS",
+						SecurityProfile: &infrav1.VMDiskSecurityProfile{
+							DiskEncryptionSet: &infrav1.DiskEncryptionSetParameters{
+								ID: "my-diskencryptionset-id",
+							},
+							SecurityEncryptionType: infrav1.SecurityEncryptionTypeDiskWithVMGuestState,
+						},
+					},
+				},
+				SecurityProfile: &infrav1.SecurityProfile{
+					SecurityType: infrav1.SecurityTypesConfidentialVM,
+					UefiSettings: &infrav1.UefiSettings{
+						SecureBootEnabled: ptr.To(true),
+						VTpmEnabled:       ptr.To(true),
+					},
+				},
+				SKU: validSKUWithConfidentialComputingType,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				vm := result.(compute.VirtualMachine)
+				g.Expect(vm.StorageProfile.OsDisk.ManagedDisk).To(Equal(&compute.ManagedDiskParameters{
+					StorageAccountType: compute.StorageAccountTypesPremiumLRS,
+					SecurityProfile: &compute.VMDiskSecurityProfile{
+						SecurityEncryptionType: compute.SecurityEncryptionTypesDiskWithVMGuestState,
+						DiskEncryptionSet:      &compute.DiskEncryptionSetParameters{ID: ptr.To("my-diskencryptionset-id")},
+					},
+				}))
+				g.Expect(vm.SecurityProfile).To(Equal(&compute.SecurityProfile{
+					SecurityType: compute.SecurityTypesConfidentialVM,
+					UefiSettings: &compute.UefiSettings{
+						SecureBootEnabled: ptr.To(true),
+						VTpmEnabled:       ptr.To(true),
+					},
+				}))
+			},
+			expectedError: "",
+		},
+		{
+			name: "creating a confidential vm without securityEncryptionType fails",
+			spec: &VMSpec{
+				Name:              "my-vm",
+				Role:              infrav1.Node,
+				NICIDs:            []string{"my-nic"},
+				SSHKeyData:        "fakesshpublickey",
+				Size:              "Standard_DC2as_v5",
+				AvailabilitySetID: "fake-availability-set-id",
+				Zone:              "",
+				Image:             &infrav1.Image{ID: ptr.To("fake-image-id")},
+				OSDisk: infrav1.OSDisk{
+					OSType:     "Linux",
+					DiskSizeGB: ptr.To[int32](128),
+					ManagedDisk: &infrav1.ManagedDiskParameters{
+						StorageAccountType: "Premium_LRS",
+					},
+				},
+				SecurityProfile: &infrav1.SecurityProfile{
+					SecurityType: infrav1.SecurityTypesConfidentialVM,
+					UefiSettings: &infrav1.UefiSettings{
+						VTpmEnabled: ptr.To(true),
+					},
+				},
+				SKU: validSKUWithConfidentialComputingType,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+			expectedError: "reconcile error that cannot be recovered occurred: securityEncryptionType should be set on the OS disk when securityType is set to ConfidentialVM. Object will not be requeued",
+		},
 		{
 			name: "creating a confidential vm without the SecurityType set to ConfidentialVM fails",
 			spec: &VMSpec{
@@ -583,6 +880,25 @@ func TestParameters(t *testing.T) {
 			},
 			expectedError: "reconcile error that cannot be recovered occurred: encryption at host is not supported for VM type Standard_D2v3. Object will not be requeued",
 		},
+		{
+			name: "creating a vm with local NVMe storage for a VM type without local NVMe disks fails",
+			spec: &VMSpec{
+				Name:             "my-vm",
+				Role:             infrav1.Node,
+				NICIDs:           []string{"my-nic"},
+				SSHKeyData:       "fakesshpublickey",
+				Size:             "Standard_D2v3",
+				Zone:             "1",
+				Image:            &infrav1.Image{ID: ptr.To("fake-image-id")},
+				LocalNVMeStorage: &infrav1.LocalNVMeStorage{MountPath: "/mnt/nvme"},
+				SKU:              validSKU,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+			expectedError: "reconcile error that cannot be recovered occurred: local NVMe storage is not supported for VM type Standard_D2v3. Object will not be requeued",
+		},
 		{
 			name: "creating a trusted launch vm without the SecurityType set to TrustedLaunch fails",
 			spec: &VMSpec{
@@ -667,7 +983,7 @@ func TestParameters(t *testing.T) {
 			expectedError: "reconcile error that cannot be recovered occurred: vTPM is not supported for VM type Standard_D2v3. Object will not be requeued",
 		},
 		{
-			name: "creating a confidential vm with securityTypeEncryption DiskWithVMGuestState and encryption at host enabled fails",
+			name: "can create a trusted launch vm with secure boot and vTPM disabled",
 			spec: &VMSpec{
 				Name:              "my-vm",
 				Role:              infrav1.Node,
@@ -677,33 +993,30 @@ func TestParameters(t *testing.T) {
 				AvailabilitySetID: "fake-availability-set-id",
 				Zone:              "",
 				Image:             &infrav1.Image{ID: ptr.To("fake-image-id")},
-				OSDisk: infrav1.OSDisk{
-					OSType:     "Linux",
-					DiskSizeGB: ptr.To[int32](128),
-					ManagedDisk: &infrav1.ManagedDiskParameters{
-						StorageAccountType: "Premium_LRS",
-						SecurityProfile: &infrav1.VMDiskSecurityProfile{
-							SecurityEncryptionType: infrav1.SecurityEncryptionTypeDiskWithVMGuestState,
-						},
-					},
-				},
 				SecurityProfile: &infrav1.SecurityProfile{
-					EncryptionAtHost: ptr.To(true),
-					SecurityType:     infrav1.SecurityTypesConfidentialVM,
+					SecurityType: infrav1.SecurityTypesTrustedLaunch,
 					UefiSettings: &infrav1.UefiSettings{
-						VTpmEnabled: ptr.To(true),
+						SecureBootEnabled: ptr.To(false),
+						VTpmEnabled:       ptr.To(false),
 					},
 				},
-				SKU: validSKUWithConfidentialComputingType,
+				SKU: validSKU,
 			},
 			existing: nil,
 			expect: func(g *WithT, result interface{}) {
-				g.Expect(result).To(BeNil())
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				g.Expect(result.(compute.VirtualMachine).SecurityProfile).To(Equal(&compute.SecurityProfile{
+					SecurityType: compute.SecurityTypesTrustedLaunch,
+					UefiSettings: &compute.UefiSettings{
+						SecureBootEnabled: ptr.To(false),
+						VTpmEnabled:       ptr.To(false),
+					},
+				}))
 			},
-			expectedError: "reconcile error that cannot be recovered occurred: encryption at host is not supported when securityEncryptionType is set to DiskWithVMGuestState. Object will not be requeued",
+			expectedError: "",
 		},
 		{
-			name: "creating a confidential vm with DiskWithVMGuestState encryption type and secure boot disabled fails",
+			name: "can create a trusted launch vm without uefi settings",
 			spec: &VMSpec{
 				Name:              "my-vm",
 				Role:              infrav1.Node,
@@ -713,18 +1026,101 @@ func TestParameters(t *testing.T) {
 				AvailabilitySetID: "fake-availability-set-id",
 				Zone:              "",
 				Image:             &infrav1.Image{ID: ptr.To("fake-image-id")},
-				OSDisk: infrav1.OSDisk{
-					OSType:     "Linux",
-					DiskSizeGB: ptr.To[int32](128),
-					ManagedDisk: &infrav1.ManagedDiskParameters{
-						StorageAccountType: "Premium_LRS",
-						SecurityProfile: &infrav1.VMDiskSecurityProfile{
-							SecurityEncryptionType: infrav1.SecurityEncryptionTypeDiskWithVMGuestState,
-						},
-					},
-				},
 				SecurityProfile: &infrav1.SecurityProfile{
-					SecurityType: infrav1.SecurityTypesConfidentialVM,
+					SecurityType: infrav1.SecurityTypesTrustedLaunch,
+				},
+				SKU: validSKU,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				g.Expect(result.(compute.VirtualMachine).SecurityProfile).To(Equal(&compute.SecurityProfile{
+					SecurityType: compute.SecurityTypesTrustedLaunch,
+				}))
+			},
+			expectedError: "",
+		},
+		{
+			name: "creating a trusted launch vm on unsupported VM type fails",
+			spec: &VMSpec{
+				Name:              "my-vm",
+				Role:              infrav1.Node,
+				NICIDs:            []string{"my-nic"},
+				SSHKeyData:        "fakesshpublickey",
+				Size:              "Standard_D2v3",
+				AvailabilitySetID: "fake-availability-set-id",
+				Zone:              "",
+				Image:             &infrav1.Image{ID: ptr.To("fake-image-id")},
+				SecurityProfile: &infrav1.SecurityProfile{
+					SecurityType: infrav1.SecurityTypesTrustedLaunch,
+				},
+				SKU: validSKUWithTrustedLaunchDisabled,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+			expectedError: "reconcile error that cannot be recovered occurred: trusted launch is not supported for VM type Standard_D2v3. Object will not be requeued",
+		},
+		{
+			name: "creating a confidential vm with securityTypeEncryption DiskWithVMGuestState and encryption at host enabled fails",
+			spec: &VMSpec{
+				Name:              "my-vm",
+				Role:              infrav1.Node,
+				NICIDs:            []string{"my-nic"},
+				SSHKeyData:        "fakesshpublickey",
+				Size:              "Standard_D2v3",
+				AvailabilitySetID: "fake-availability-set-id",
+				Zone:              "",
+				Image:             &infrav1.Image{ID: ptr.To("fake-image-id")},
+				OSDisk: infrav1.OSDisk{
+					OSType:     "Linux",
+					DiskSizeGB: ptr.To[int32](128),
+					ManagedDisk: &infrav1.ManagedDiskParameters{
+						StorageAccountType: "Premium_LRS",
+						SecurityProfile: &infrav1.VMDiskSecurityProfile{
+							SecurityEncryptionType: infrav1.SecurityEncryptionTypeDiskWithVMGuestState,
+						},
+					},
+				},
+				SecurityProfile: &infrav1.SecurityProfile{
+					EncryptionAtHost: ptr.To(true),
+					SecurityType:     infrav1.SecurityTypesConfidentialVM,
+					UefiSettings: &infrav1.UefiSettings{
+						VTpmEnabled: ptr.To(true),
+					},
+				},
+				SKU: validSKUWithConfidentialComputingType,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+			expectedError: "reconcile error that cannot be recovered occurred: encryption at host is not supported when securityEncryptionType is set to DiskWithVMGuestState. Object will not be requeued",
+		},
+		{
+			name: "creating a confidential vm with DiskWithVMGuestState encryption type and secure boot disabled fails",
+			spec: &VMSpec{
+				Name:              "my-vm",
+				Role:              infrav1.Node,
+				NICIDs:            []string{"my-nic"},
+				SSHKeyData:        "fakesshpublickey",
+				Size:              "Standard_D2v3",
+				AvailabilitySetID: "fake-availability-set-id",
+				Zone:              "",
+				Image:             &infrav1.Image{ID: ptr.To("fake-image-id")},
+				OSDisk: infrav1.OSDisk{
+					OSType:     "Linux",
+					DiskSizeGB: ptr.To[int32](128),
+					ManagedDisk: &infrav1.ManagedDiskParameters{
+						StorageAccountType: "Premium_LRS",
+						SecurityProfile: &infrav1.VMDiskSecurityProfile{
+							SecurityEncryptionType: infrav1.SecurityEncryptionTypeDiskWithVMGuestState,
+						},
+					},
+				},
+				SecurityProfile: &infrav1.SecurityProfile{
+					SecurityType: infrav1.SecurityTypesConfidentialVM,
 					UefiSettings: &infrav1.UefiSettings{
 						SecureBootEnabled: ptr.To(false),
 						VTpmEnabled:       ptr.To(true),
@@ -935,6 +1331,33 @@ func TestParameters(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "can create a vm with a community gallery image",
+			spec: &VMSpec{
+				Name:       "my-vm",
+				Role:       infrav1.Node,
+				NICIDs:     []string{"my-nic"},
+				SSHKeyData: "fakesshpublickey",
+				Size:       "Standard_D2v3",
+				Image: &infrav1.Image{
+					ComputeGallery: &infrav1.AzureComputeGalleryImage{
+						Gallery: "fake-public-gallery",
+						Name:    "fake-name",
+						Version: "1.0",
+					},
+				},
+				SKU: validSKU,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				g.Expect(result.(compute.VirtualMachine).StorageProfile.ImageReference).To(Equal(&compute.ImageReference{
+					CommunityGalleryImageID: ptr.To("/CommunityGalleries/fake-public-gallery/Images/fake-name/Versions/1.0"),
+				}))
+				g.Expect(result.(compute.VirtualMachine).Plan).To(BeNil())
+			},
+			expectedError: "",
+		},
 		{
 			name: "can create a vm with ultra disk enabled",
 			spec: &VMSpec{
@@ -1189,6 +1612,210 @@ func TestParameters(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "creates a vm with the hibernation capability if AdditionalCapabilities.HibernationEnabled is true",
+			spec: &VMSpec{
+				Name:       "my-vm",
+				Role:       infrav1.Node,
+				NICIDs:     []string{"my-nic"},
+				SSHKeyData: "fakesshpublickey",
+				Size:       "Standard_D2v3",
+				Location:   "test-location",
+				Zone:       "1",
+				Image:      &infrav1.Image{ID: ptr.To("fake-image-id")},
+				AdditionalCapabilities: &infrav1.AdditionalCapabilities{
+					HibernationEnabled: ptr.To(true),
+				},
+				SKU: validSKU,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				g.Expect(result.(compute.VirtualMachine).AdditionalCapabilities).To(Equal(&compute.AdditionalCapabilities{
+					HibernationEnabled: ptr.To(true),
+				}))
+			},
+			expectedError: "",
+		},
+		{
+			name: "creates a vm with AdditionalCapabilities.UltraSSDEnabled true, if an ultra disk with provisioned IOPS and throughput is specified as data disk",
+			spec: &VMSpec{
+				Name:       "my-ultra-ssd-vm",
+				Role:       infrav1.Node,
+				NICIDs:     []string{"my-nic"},
+				SSHKeyData: "fakesshpublickey",
+				Size:       "Standard_D2v3",
+				Location:   "test-location",
+				Zone:       "1",
+				Image:      &infrav1.Image{ID: ptr.To("fake-image-id")},
+				DataDisks: []infrav1.DataDisk{
+					{
+						NameSuffix: "myDiskWithUltraDisk",
+						DiskSizeGB: 128,
+						Lun:        ptr.To[int32](1),
+						ManagedDisk: &infrav1.ManagedDiskParameters{
+							StorageAccountType: "UltraSSD_LRS",
+						},
+						DiskIOPSReadWrite: ptr.To[int64](5000),
+						DiskMBpsReadWrite: ptr.To[int64](200),
+					},
+				},
+				SKU: validSKUWithUltraSSD,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				g.Expect(result.(compute.VirtualMachine).AdditionalCapabilities.UltraSSDEnabled).To(Equal(ptr.To(true)))
+				// The provisioned IOPS and throughput are read-only on the data disks of a VM and are configured on the disks.
+				expectedDataDisks := &[]compute.DataDisk{
+					{
+						Lun:          ptr.To[int32](1),
+						Name:         ptr.To("my-ultra-ssd-vm_myDiskWithUltraDisk"),
+						CreateOption: "Empty",
+						DiskSizeGB:   ptr.To[int32](128),
+						ManagedDisk: &compute.ManagedDiskParameters{
+							StorageAccountType: "UltraSSD_LRS",
+						},
+					},
+				}
+				g.Expect(gomockinternal.DiffEq(expectedDataDisks).Matches(result.(compute.VirtualMachine).StorageProfile.DataDisks)).To(BeTrue(), cmp.Diff(expectedDataDisks, result.(compute.VirtualMachine).StorageProfile.DataDisks))
+			},
+			expectedError: "",
+		},
+		{
+			name: "can create a vm attaching a shared data disk by ID",
+			spec: &VMSpec{
+				Name:           "my-vm",
+				ResourceGroup:  "my-rg",
+				SubscriptionID: "123",
+				ClusterName:    "my-cluster",
+				Role:           infrav1.Node,
+				NICIDs:         []string{"my-nic"},
+				SSHKeyData:     "fakesshpublickey",
+				Size:           "Standard_D2v3",
+				Location:       "test-location",
+				Zone:           "1",
+				Image:          &infrav1.Image{ID: ptr.To("fake-image-id")},
+				DataDisks: []infrav1.DataDisk{
+					{
+						NameSuffix:  "shared",
+						DiskSizeGB:  256,
+						Lun:         ptr.To[int32](0),
+						CachingType: "None",
+						ManagedDisk: &infrav1.ManagedDiskParameters{
+							StorageAccountType: "Premium_LRS",
+						},
+						MaxShares: ptr.To[int32](3),
+					},
+				},
+				SKU: validSKU,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				expectedDataDisks := &[]compute.DataDisk{
+					{
+						Lun:          ptr.To[int32](0),
+						Name:         ptr.To("my-cluster_shared"),
+						CreateOption: "Attach",
+						Caching:      "None",
+						ManagedDisk: &compute.ManagedDiskParameters{
+							ID:                 ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/my-cluster_shared"),
+							StorageAccountType: "Premium_LRS",
+						},
+					},
+				}
+				g.Expect(gomockinternal.DiffEq(expectedDataDisks).Matches(result.(compute.VirtualMachine).StorageProfile.DataDisks)).To(BeTrue(), cmp.Diff(expectedDataDisks, result.(compute.VirtualMachine).StorageProfile.DataDisks))
+			},
+			expectedError: "",
+		},
+		{
+			name: "can create a vm attaching a data disk with a logical sector size by ID",
+			spec: &VMSpec{
+				Name:           "my-vm",
+				ResourceGroup:  "my-rg",
+				SubscriptionID: "123",
+				ClusterName:    "my-cluster",
+				Role:           infrav1.Node,
+				NICIDs:         []string{"my-nic"},
+				SSHKeyData:     "fakesshpublickey",
+				Size:           "Standard_D2v3",
+				Location:       "test-location",
+				Zone:           "1",
+				Image:          &infrav1.Image{ID: ptr.To("fake-image-id")},
+				DataDisks: []infrav1.DataDisk{
+					{
+						NameSuffix:  "sectors",
+						DiskSizeGB:  128,
+						Lun:         ptr.To[int32](0),
+						CachingType: "None",
+						ManagedDisk: &infrav1.ManagedDiskParameters{
+							StorageAccountType: "PremiumV2_LRS",
+							LogicalSectorSize:  ptr.To[int32](512),
+						},
+					},
+				},
+				SKU: validSKU,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				expectedDataDisks := &[]compute.DataDisk{
+					{
+						Lun:          ptr.To[int32](0),
+						Name:         ptr.To("my-vm_sectors"),
+						CreateOption: "Attach",
+						Caching:      "None",
+						ManagedDisk: &compute.ManagedDiskParameters{
+							ID:                 ptr.To("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/my-vm_sectors"),
+							StorageAccountType: "PremiumV2_LRS",
+						},
+					},
+				}
+				g.Expect(gomockinternal.DiffEq(expectedDataDisks).Matches(result.(compute.VirtualMachine).StorageProfile.DataDisks)).To(BeTrue(), cmp.Diff(expectedDataDisks, result.(compute.VirtualMachine).StorageProfile.DataDisks))
+			},
+			expectedError: "",
+		},
+		{
+			name: "can create a vm attaching an existing data disk by ID",
+			spec: &VMSpec{
+				Name:       "my-vm",
+				Role:       infrav1.Node,
+				NICIDs:     []string{"my-nic"},
+				SSHKeyData: "fakesshpublickey",
+				Size:       "Standard_D2v3",
+				Location:   "test-location",
+				Image:      &infrav1.Image{ID: ptr.To("fake-image-id")},
+				DataDisks: []infrav1.DataDisk{
+					{
+						NameSuffix:  "existing",
+						Lun:         ptr.To[int32](0),
+						CachingType: "ReadWrite",
+						AttachExistingDisk: &infrav1.AttachExistingDisk{
+							ID: "/subscriptions/456/resourceGroups/other-rg/providers/Microsoft.Compute/disks/my-existing-disk",
+						},
+					},
+				},
+				SKU: validSKU,
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(compute.VirtualMachine{}))
+				expectedDataDisks := &[]compute.DataDisk{
+					{
+						Lun:          ptr.To[int32](0),
+						Name:         ptr.To("my-existing-disk"),
+						CreateOption: "Attach",
+						Caching:      "ReadWrite",
+						ManagedDisk: &compute.ManagedDiskParameters{
+							ID: ptr.To("/subscriptions/456/resourceGroups/other-rg/providers/Microsoft.Compute/disks/my-existing-disk"),
+						},
+					},
+				}
+				g.Expect(gomockinternal.DiffEq(expectedDataDisks).Matches(result.(compute.VirtualMachine).StorageProfile.DataDisks)).To(BeTrue(), cmp.Diff(expectedDataDisks, result.(compute.VirtualMachine).StorageProfile.DataDisks))
+			},
+			expectedError: "",
+		},
 		{
 			name: "creates a vm with AdditionalCapabilities.UltraSSDEnabled true, if no ultra disk is specified as data disk and AdditionalCapabilities.UltraSSDEnabled is true",
 			spec: &VMSpec{
@@ -1363,3 +1990,542 @@ func TestParameters(t *testing.T) {
 		})
 	}
 }
+
+func TestParametersDrift(t *testing.T) {
+	identityID := "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity"
+	otherIdentityID := "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/other-identity"
+
+	testcases := []struct {
+		name     string
+		spec     *VMSpec
+		existing compute.VirtualMachine
+		expected interface{}
+	}{
+		{
+			name: "tags are left to the tags service",
+			spec: &VMSpec{
+				Name:           "my-vm",
+				ClusterName:    "my-cluster",
+				Role:           "node",
+				AdditionalTags: infrav1.Tags{"env": "prod"},
+			},
+			existing: compute.VirtualMachine{
+				Tags: map[string]*string{"env": ptr.To("dev")},
+			},
+			expected: nil,
+		},
+		{
+			name: "reapplies missing system assigned identity",
+			spec: &VMSpec{
+				Name:        "my-vm",
+				ClusterName: "my-cluster",
+				Role:        "node",
+				Identity:    infrav1.VMIdentitySystemAssigned,
+			},
+			existing: compute.VirtualMachine{
+				Tags:                     map[string]*string{"external": ptr.To("tag")},
+				Resources:                &[]compute.VirtualMachineExtension{{Name: ptr.To("ext")}},
+				VirtualMachineProperties: &compute.VirtualMachineProperties{InstanceView: &compute.VirtualMachineInstanceView{}},
+			},
+			expected: vmUpdate{
+				VirtualMachineUpdate: compute.VirtualMachineUpdate{
+					Identity:                 &compute.VirtualMachineIdentity{Type: compute.ResourceIdentityTypeSystemAssigned},
+					VirtualMachineProperties: &compute.VirtualMachineProperties{},
+				},
+				driftedFields: []string{"identity"},
+			},
+		},
+		{
+			name: "system assigned identity is up to date",
+			spec: &VMSpec{
+				Name:        "my-vm",
+				ClusterName: "my-cluster",
+				Role:        "node",
+				Identity:    infrav1.VMIdentitySystemAssigned,
+			},
+			existing: compute.VirtualMachine{
+				Identity: &compute.VirtualMachineIdentity{
+					Type:        compute.ResourceIdentityTypeSystemAssigned,
+					PrincipalID: ptr.To("principal-id"),
+				},
+			},
+			expected: nil,
+		},
+		{
+			name: "reapplies missing user assigned identity and keeps external identities",
+			spec: &VMSpec{
+				Name:                   "my-vm",
+				ClusterName:            "my-cluster",
+				Role:                   "node",
+				Identity:               infrav1.VMIdentityUserAssigned,
+				UserAssignedIdentities: []infrav1.UserAssignedIdentity{{ProviderID: "azure://" + identityID}},
+			},
+			existing: compute.VirtualMachine{
+				Identity: &compute.VirtualMachineIdentity{
+					Type: compute.ResourceIdentityTypeSystemAssignedUserAssigned,
+					UserAssignedIdentities: map[string]*compute.VirtualMachineIdentityUserAssignedIdentitiesValue{
+						otherIdentityID: {ClientID: ptr.To("other-client-id")},
+					},
+				},
+			},
+			expected: vmUpdate{
+				VirtualMachineUpdate: compute.VirtualMachineUpdate{
+					Identity: &compute.VirtualMachineIdentity{
+						Type: compute.ResourceIdentityTypeSystemAssignedUserAssigned,
+						UserAssignedIdentities: map[string]*compute.VirtualMachineIdentityUserAssignedIdentitiesValue{
+							identityID:      {},
+							otherIdentityID: {},
+						},
+					},
+					VirtualMachineProperties: &compute.VirtualMachineProperties{},
+				},
+				driftedFields: []string{"identity"},
+			},
+		},
+		{
+			name: "user assigned identity is up to date regardless of case",
+			spec: &VMSpec{
+				Name:                   "my-vm",
+				ClusterName:            "my-cluster",
+				Role:                   "node",
+				Identity:               infrav1.VMIdentityUserAssigned,
+				UserAssignedIdentities: []infrav1.UserAssignedIdentity{{ProviderID: "azure://" + identityID}},
+			},
+			existing: compute.VirtualMachine{
+				Identity: &compute.VirtualMachineIdentity{
+					Type: compute.ResourceIdentityTypeUserAssigned,
+					UserAssignedIdentities: map[string]*compute.VirtualMachineIdentityUserAssignedIdentitiesValue{
+						strings.ToLower(identityID): {ClientID: ptr.To("client-id")},
+					},
+				},
+			},
+			expected: nil,
+		},
+		{
+			name: "boot diagnostics storage URI is up to date regardless of trailing slash and case",
+			spec: &VMSpec{
+				Name:               "my-vm",
+				ClusterName:        "my-cluster",
+				Role:               "node",
+				DiagnosticsProfile: userManagedDiagnostics("https://fakestorage.blob.core.windows.net"),
+			},
+			existing: compute.VirtualMachine{
+				VirtualMachineProperties: &compute.VirtualMachineProperties{
+					DiagnosticsProfile: &compute.DiagnosticsProfile{
+						BootDiagnostics: &compute.BootDiagnostics{
+							Enabled:    ptr.To(true),
+							StorageURI: ptr.To("https://FakeStorage.blob.core.windows.net/"),
+						},
+					},
+				},
+			},
+			expected: nil,
+		},
+		{
+			name: "only updates the boot diagnostics storage URI after the storage account rotated",
+			spec: &VMSpec{
+				Name:               "my-vm",
+				ClusterName:        "my-cluster",
+				Role:               "node",
+				DiagnosticsProfile: userManagedDiagnostics("https://newstorage.blob.core.windows.net/"),
+			},
+			existing: compute.VirtualMachine{
+				VirtualMachineProperties: &compute.VirtualMachineProperties{
+					HardwareProfile: &compute.HardwareProfile{VMSize: "Standard_D2s_v3"},
+					DiagnosticsProfile: &compute.DiagnosticsProfile{
+						BootDiagnostics: &compute.BootDiagnostics{
+							Enabled:    ptr.To(true),
+							StorageURI: ptr.To("https://oldstorage.blob.core.windows.net/"),
+						},
+					},
+					InstanceView: &compute.VirtualMachineInstanceView{},
+				},
+			},
+			expected: vmUpdate{
+				VirtualMachineUpdate: compute.VirtualMachineUpdate{
+					VirtualMachineProperties: &compute.VirtualMachineProperties{
+						DiagnosticsProfile: &compute.DiagnosticsProfile{
+							BootDiagnostics: &compute.BootDiagnostics{
+								Enabled:    ptr.To(true),
+								StorageURI: ptr.To("https://newstorage.blob.core.windows.net/"),
+							},
+						},
+					},
+				},
+				driftedFields: []string{"boot diagnostics storage URI"},
+			},
+		},
+		{
+			name: "sets boot diagnostics storage URI missing on the existing vm",
+			spec: &VMSpec{
+				Name:               "my-vm",
+				ClusterName:        "my-cluster",
+				Role:               "node",
+				DiagnosticsProfile: userManagedDiagnostics("https://newstorage.blob.core.windows.net/"),
+			},
+			existing: compute.VirtualMachine{},
+			expected: vmUpdate{
+				VirtualMachineUpdate: compute.VirtualMachineUpdate{
+					VirtualMachineProperties: &compute.VirtualMachineProperties{
+						DiagnosticsProfile: &compute.DiagnosticsProfile{
+							BootDiagnostics: &compute.BootDiagnostics{
+								Enabled:    ptr.To(true),
+								StorageURI: ptr.To("https://newstorage.blob.core.windows.net/"),
+							},
+						},
+					},
+				},
+				driftedFields: []string{"boot diagnostics storage URI"},
+			},
+		},
+		{
+			name: "managed boot diagnostics are not considered drift",
+			spec: &VMSpec{
+				Name:        "my-vm",
+				ClusterName: "my-cluster",
+				Role:        "node",
+				DiagnosticsProfile: &infrav1.Diagnostics{
+					Boot: &infrav1.BootDiagnostics{StorageAccountType: infrav1.ManagedDiagnosticsStorage},
+				},
+			},
+			existing: compute.VirtualMachine{
+				VirtualMachineProperties: &compute.VirtualMachineProperties{
+					DiagnosticsProfile: &compute.DiagnosticsProfile{
+						BootDiagnostics: &compute.BootDiagnostics{Enabled: ptr.To(true)},
+					},
+				},
+			},
+			expected: nil,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			result, err := tc.spec.Parameters(context.TODO(), tc.existing)
+			g.Expect(err).NotTo(HaveOccurred())
+			if tc.expected == nil {
+				g.Expect(result).To(BeNil())
+			} else {
+				g.Expect(result).To(Equal(tc.expected))
+			}
+		})
+	}
+}
+
+func TestParametersOSDiskResize(t *testing.T) {
+	existingVM := func(diskSizeGB int32, powerState string) compute.VirtualMachine {
+		return compute.VirtualMachine{
+			Tags: map[string]*string{
+				"Name": ptr.To("my-vm"),
+				"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+				"sigs.k8s.io_cluster-api-provider-azure_role":               ptr.To("node"),
+			},
+			VirtualMachineProperties: &compute.VirtualMachineProperties{
+				StorageProfile: &compute.StorageProfile{
+					OsDisk: &compute.OSDisk{DiskSizeGB: ptr.To(diskSizeGB)},
+				},
+				InstanceView: &compute.VirtualMachineInstanceView{
+					Statuses: &[]compute.InstanceViewStatus{
+						{Code: ptr.To("ProvisioningState/succeeded")},
+						{Code: ptr.To(powerState)},
+					},
+				},
+			},
+		}
+	}
+
+	testcases := []struct {
+		name          string
+		diskSizeGB    *int32
+		existing      compute.VirtualMachine
+		expect        func(g *WithT, result interface{})
+		expectedError error
+	}{
+		{
+			name:       "OS disk of the expected size is not updated",
+			diskSizeGB: ptr.To[int32](128),
+			existing:   existingVM(128, "PowerState/running"),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name:       "OS disk larger than the spec is not shrunk",
+			diskSizeGB: ptr.To[int32](30),
+			existing:   existingVM(128, "PowerState/running"),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name:          "growing the OS disk of a running vm requires deallocating it",
+			diskSizeGB:    ptr.To[int32](256),
+			existing:      existingVM(128, "PowerState/running"),
+			expectedError: errOSDiskResizeRequiresDeallocation,
+		},
+		{
+			name:       "grows the OS disk of a deallocated vm",
+			diskSizeGB: ptr.To[int32](256),
+			existing:   existingVM(128, "PowerState/deallocated"),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(Equal(vmUpdate{
+					VirtualMachineUpdate: compute.VirtualMachineUpdate{
+						VirtualMachineProperties: &compute.VirtualMachineProperties{
+							StorageProfile: &compute.StorageProfile{
+								OsDisk: &compute.OSDisk{DiskSizeGB: ptr.To[int32](256)},
+							},
+						},
+					},
+				}))
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			spec := &VMSpec{
+				Name:        "my-vm",
+				ClusterName: "my-cluster",
+				Role:        "node",
+				OSDisk:      infrav1.OSDisk{DiskSizeGB: tc.diskSizeGB},
+			}
+			result, err := spec.Parameters(context.TODO(), tc.existing)
+			if tc.expectedError != nil {
+				g.Expect(errors.Is(err, tc.expectedError)).To(BeTrue())
+				g.Expect(result).To(BeNil())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			tc.expect(g, result)
+		})
+	}
+}
+
+func TestParametersDataDisks(t *testing.T) {
+	etcdDisk := infrav1.DataDisk{NameSuffix: "etcddisk", DiskSizeGB: 256, Lun: ptr.To[int32](0), CachingType: "ReadWrite"}
+	dataDisk := infrav1.DataDisk{NameSuffix: "datadisk", DiskSizeGB: 128, Lun: ptr.To[int32](1), CachingType: "ReadWrite"}
+	existingVM := func(dataDisks ...compute.DataDisk) compute.VirtualMachine {
+		return compute.VirtualMachine{
+			Tags: map[string]*string{
+				"Name": ptr.To("my-vm"),
+				"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": ptr.To("owned"),
+				"sigs.k8s.io_cluster-api-provider-azure_role":               ptr.To("node"),
+			},
+			VirtualMachineProperties: &compute.VirtualMachineProperties{
+				StorageProfile: &compute.StorageProfile{DataDisks: &dataDisks},
+			},
+		}
+	}
+	attachedEtcdDisk := compute.DataDisk{Name: ptr.To("my-vm_etcddisk"), Lun: ptr.To[int32](0), CreateOption: compute.DiskCreateOptionTypesEmpty}
+
+	testcases := []struct {
+		name          string
+		dataDisks     []infrav1.DataDisk
+		existing      compute.VirtualMachine
+		expect        func(g *WithT, result interface{})
+		expectedError string
+	}{
+		{
+			name:      "attached data disks are not updated",
+			dataDisks: []infrav1.DataDisk{etcdDisk},
+			existing:  existingVM(attachedEtcdDisk),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name:      "attaches a data disk added to the spec",
+			dataDisks: []infrav1.DataDisk{etcdDisk, dataDisk},
+			existing:  existingVM(attachedEtcdDisk),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(vmUpdate{}))
+				update := result.(vmUpdate)
+				g.Expect(update.attachedDataDisks).To(Equal([]string{"my-vm_datadisk"}))
+				g.Expect(*update.StorageProfile.DataDisks).To(Equal([]compute.DataDisk{
+					attachedEtcdDisk,
+					{
+						Name:         ptr.To("my-vm_datadisk"),
+						Lun:          ptr.To[int32](1),
+						CreateOption: compute.DiskCreateOptionTypesEmpty,
+						DiskSizeGB:   ptr.To[int32](128),
+						Caching:      compute.CachingTypesReadWrite,
+					},
+				}))
+				g.Expect(update.Identity).To(BeNil())
+				g.Expect(update.StorageProfile.OsDisk).To(BeNil())
+			},
+		},
+		{
+			name:      "attaches a data disk whose LUN is used by a disk attached outside of capz with a free LUN",
+			dataDisks: []infrav1.DataDisk{etcdDisk, dataDisk},
+			existing:  existingVM(attachedEtcdDisk, compute.DataDisk{Name: ptr.To("pvc-disk"), Lun: ptr.To[int32](1)}),
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(vmUpdate{}))
+				update := result.(vmUpdate)
+				g.Expect(update.attachedDataDisks).To(Equal([]string{"my-vm_datadisk"}))
+				g.Expect(*update.StorageProfile.DataDisks).To(HaveLen(3))
+				g.Expect((*update.StorageProfile.DataDisks)[1].Name).To(Equal(ptr.To("pvc-disk")))
+				g.Expect((*update.StorageProfile.DataDisks)[2].Name).To(Equal(ptr.To("my-vm_datadisk")))
+				g.Expect((*update.StorageProfile.DataDisks)[2].Lun).To(Equal(ptr.To[int32](2)))
+			},
+		},
+		{
+			name:      "fails to attach a data disk when no LUN is left",
+			dataDisks: []infrav1.DataDisk{dataDisk},
+			existing: func() compute.VirtualMachine {
+				var disks []compute.DataDisk
+				for lun := int32(0); lun <= maxDataDiskLun; lun++ {
+					disks = append(disks, compute.DataDisk{Name: ptr.To(fmt.Sprintf("pvc-disk-%d", lun)), Lun: ptr.To(lun)})
+				}
+				return existingVM(disks...)
+			}(),
+			expectedError: "reconcile error that cannot be recovered occurred: no logical unit number is left on VM my-vm to attach data disk datadisk. Object will not be requeued",
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			spec := &VMSpec{
+				Name:        "my-vm",
+				ClusterName: "my-cluster",
+				Role:        "node",
+				DataDisks:   tc.dataDisks,
+			}
+			result, err := spec.Parameters(context.TODO(), tc.existing)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			tc.expect(g, result)
+		})
+	}
+}
+
+func userManagedDiagnostics(storageAccountURI string) *infrav1.Diagnostics {
+	return &infrav1.Diagnostics{
+		Boot: &infrav1.BootDiagnostics{
+			StorageAccountType: infrav1.UserManagedDiagnosticsStorage,
+			UserManaged:        &infrav1.UserManagedBootDiagnostics{StorageAccountURI: storageAccountURI},
+		},
+	}
+}
+
+func TestCustomData(t *testing.T) {
+	// Random data does not compress, unlike repeated data.
+	smallData := []byte("#cloud-config\nruncmd:\n- kubeadm join\n")
+	largeData := []byte(strings.Repeat("write_files: [{path: /etc/kubernetes/pki/ca.crt}]\n", 2000))
+
+	testcases := []struct {
+		name             string
+		bootstrapData    []byte
+		compress         bool
+		expectCompressed bool
+		expectedError    string
+	}{
+		{
+			name:          "bootstrap data is passed as is",
+			bootstrapData: smallData,
+		},
+		{
+			name:          "bootstrap data exceeding the maximum custom data size",
+			bootstrapData: largeData,
+			expectedError: "reconcile error that cannot be recovered occurred: bootstrap data of VM my-vm is 133336 bytes once base64 encoded, which exceeds the maximum custom data size of 65536 bytes, consider enabling the compression of the bootstrap data. Object will not be requeued",
+		},
+		{
+			name:             "compressed bootstrap data",
+			bootstrapData:    smallData,
+			compress:         true,
+			expectCompressed: true,
+		},
+		{
+			name:             "large bootstrap data fits once compressed",
+			bootstrapData:    largeData,
+			compress:         true,
+			expectCompressed: true,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			spec := &VMSpec{
+				Name:                  "my-vm",
+				BootstrapData:         base64.StdEncoding.EncodeToString(tc.bootstrapData),
+				CompressBootstrapData: tc.compress,
+			}
+			customData, err := spec.CustomData()
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+				var reconcileErr azure.ReconcileError
+				g.Expect(errors.As(err, &reconcileErr)).To(BeTrue())
+				g.Expect(reconcileErr.IsTerminal()).To(BeTrue())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(len(customData)).To(BeNumerically("<=", MaxCustomDataLength))
+
+			decoded, err := base64.StdEncoding.DecodeString(customData)
+			g.Expect(err).NotTo(HaveOccurred())
+			if !tc.expectCompressed {
+				g.Expect(decoded).To(Equal(tc.bootstrapData))
+				return
+			}
+			r, err := gzip.NewReader(bytes.NewReader(decoded))
+			g.Expect(err).NotTo(HaveOccurred())
+			decompressed, err := io.ReadAll(r)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(decompressed).To(Equal(tc.bootstrapData))
+		})
+	}
+}
+
+func TestNodeLabels(t *testing.T) {
+	vmTags := map[string]*string{
+		"Workload":    ptr.To("gpu"),
+		"cost-center": ptr.To("1234"),
+		"owner":       ptr.To("team ml"),
+		"other":       ptr.To("ignored"),
+	}
+	testcases := []struct {
+		name           string
+		nodeLabelTags  []string
+		expectedLabels map[string]string
+	}{
+		{
+			name:           "no tags propagated",
+			expectedLabels: map[string]string{},
+		},
+		{
+			name:           "configured tags become node labels",
+			nodeLabelTags:  []string{"workload", "cost-center"},
+			expectedLabels: map[string]string{"workload": "gpu", "cost-center": "1234"},
+		},
+		{
+			name:           "tags missing from the VM or with an invalid label value are skipped",
+			nodeLabelTags:  []string{"owner", "missing"},
+			expectedLabels: map[string]string{},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			spec := &VMSpec{NodeLabelTags: tc.nodeLabelTags}
+			g.Expect(spec.NodeLabels(vmTags)).To(Equal(tc.expectedLabels))
+		})
+	}
+}
@@ -18,11 +18,15 @@ package virtualmachines
 
 import (
 	"context"
+	"encoding/base64"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
 	azprovider "sigs.k8s.io/cloud-provider-azure/pkg/provider"
@@ -30,8 +34,12 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/galleryimageversions"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/identities"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/keyvaults"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/marketplaceagreements"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/networkinterfaces"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/preflight"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicips"
 	azureutil "sigs.k8s.io/cluster-api-provider-azure/util/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
@@ -39,17 +47,30 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
-const serviceName = "virtualmachine"
+const (
+	// ServiceName is the name of this service.
+	ServiceName = "virtualmachine"
+
+	// imageReplicationRequeue is how long to wait before checking again whether a gallery image version is
+	// replicated to the region of the VM.
+	imageReplicationRequeue = 1 * time.Minute
+)
 
 // VMScope defines the scope interface for a virtual machines service.
 type VMScope interface {
 	azure.Authorizer
 	azure.AsyncStatusUpdater
+	azure.DryRunner
+	azure.KeyVaultAuthorizer
 	VMSpec() azure.ResourceSpecGetter
 	SetAnnotation(string, string)
+	RemoveAnnotation(string)
 	SetProviderID(string)
 	SetAddresses([]corev1.NodeAddress)
 	SetVMState(infrav1.ProvisioningState)
+	SetVMPrincipalID(string)
+	SetNodeLabels(map[string]string)
+	SetBootDiagnosticsURIs(consoleURI, serialConsoleLogURI string)
 	SetConditionFalse(clusterv1.ConditionType, string, clusterv1.ConditionSeverity, string)
 }
 
@@ -57,26 +78,64 @@ type VMScope interface {
 type Service struct {
 	Scope VMScope
 	async.Reconciler
-	interfacesGetter async.Getter
-	publicIPsGetter  async.Getter
-	identitiesGetter identities.Client
+	interfacesGetter    async.Getter
+	publicIPsGetter     async.Getter
+	identitiesGetter    identities.Client
+	secretsGetter       keyvaults.Client
+	imageVersionsGetter galleryimageversions.Client
+	agreementsGetter    marketplaceagreements.Client
+	instanceViewGetter  Client
+	preflightClient     preflight.Client
+	client              Client
 }
 
 // New creates a new service.
 func New(scope VMScope) *Service {
 	Client := NewClient(scope)
 	return &Service{
-		Scope:            scope,
-		interfacesGetter: networkinterfaces.NewClient(scope),
-		publicIPsGetter:  publicips.NewClient(scope),
-		identitiesGetter: identities.NewClient(scope),
-		Reconciler:       async.New(scope, Client, Client),
+		Scope:               scope,
+		interfacesGetter:    networkinterfaces.NewClient(scope),
+		publicIPsGetter:     publicips.NewClient(scope),
+		identitiesGetter:    identities.NewClient(scope),
+		secretsGetter:       keyvaults.NewClient(scope),
+		imageVersionsGetter: galleryimageversions.NewClient(scope),
+		agreementsGetter:    marketplaceagreements.NewClient(scope),
+		instanceViewGetter:  Client,
+		preflightClient:     preflight.NewClient(scope),
+		client:              Client,
+		Reconciler:          async.New(scope, &updateRecorder{Client: Client, scope: scope}, Client),
 	}
 }
 
+// updateRecorder records an event for the fields of an existing VM that drifted from the spec and the data disks
+// attached to it when an update of the VM is sent to Azure. Updates aren't sent in dry-run mode, so they are only
+// reported by async.Service.CreateOrUpdateResource then.
+type updateRecorder struct {
+	Client
+	scope VMScope
+}
+
+// CreateOrUpdateAsync creates or updates a VM and records an event when the update of an existing VM is accepted.
+func (r *updateRecorder) CreateOrUpdateAsync(ctx context.Context, spec azure.ResourceSpecGetter, parameters interface{}) (interface{}, azureautorest.FutureAPI, error) {
+	result, future, err := r.Client.CreateOrUpdateAsync(ctx, spec, parameters)
+	update, ok := parameters.(vmUpdate)
+	if !ok || (err != nil && future == nil) {
+		return result, future, err
+	}
+	if len(update.driftedFields) > 0 {
+		r.scope.RecordEvent(corev1.EventTypeNormal, infrav1.VMDriftCorrectedReason,
+			"Reapplied %s of VM %s that drifted from the spec", strings.Join(update.driftedFields, ", "), spec.ResourceName())
+	}
+	if len(update.attachedDataDisks) > 0 {
+		r.scope.RecordEvent(corev1.EventTypeNormal, infrav1.VMDataDisksAttachedReason,
+			"Attached data disks %s to VM %s", strings.Join(update.attachedDataDisks, ", "), spec.ResourceName())
+	}
+	return result, future, err
+}
+
 // Name returns the service name.
 func (s *Service) Name() string {
-	return serviceName
+	return ServiceName
 }
 
 // Reconcile idempotently creates or updates a virtual machine.
@@ -92,10 +151,40 @@ func (s *Service) Reconcile(ctx context.Context) error {
 		return nil
 	}
 
-	result, err := s.CreateOrUpdateResource(ctx, vmSpec, serviceName)
-	s.Scope.UpdatePutStatus(infrav1.VMRunningCondition, serviceName, err)
+	if err := s.resolveSSHKeyData(ctx, vmSpec); err != nil {
+		s.Scope.UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, err)
+		return err
+	}
+
+	if err := validateCustomData(vmSpec); err != nil {
+		s.Scope.SetConditionFalse(infrav1.VMRunningCondition, infrav1.BootstrapDataTooLargeReason, clusterv1.ConditionSeverityError, err.Error())
+		return err
+	}
+
+	if err := s.checkImageReplication(ctx, vmSpec); err != nil {
+		return err
+	}
+
+	if err := s.checkImagePlanAgreement(ctx, vmSpec); err != nil {
+		return err
+	}
+
+	if err := s.checkPreflight(ctx, vmSpec); err != nil {
+		return err
+	}
+
+	if err := s.waitForOSDiskResizeOperation(ctx, vmSpec); err != nil {
+		s.Scope.UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, err)
+		return err
+	}
+
+	result, err := s.CreateOrUpdateResource(ctx, vmSpec, ServiceName)
+	if errors.Is(err, errOSDiskResizeRequiresDeallocation) {
+		err = s.deallocateForOSDiskResize(ctx, vmSpec)
+	}
+	s.Scope.UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, err)
 	// Set the DiskReady condition here since the disk gets created with the VM.
-	s.Scope.UpdatePutStatus(infrav1.DisksReadyCondition, serviceName, err)
+	s.Scope.UpdatePutStatus(infrav1.DisksReadyCondition, ServiceName, err)
 	if err == nil && result != nil {
 		vm, ok := result.(compute.VirtualMachine)
 		if !ok {
@@ -117,20 +206,278 @@ func (s *Service) Reconcile(ctx context.Context) error {
 		}
 		s.Scope.SetAddresses(addresses)
 		s.Scope.SetVMState(infraVM.State)
+		// Remember the principal ID of the system-assigned identity so that the role assignments don't get the VM again.
+		if vm.Identity != nil && vm.Identity.PrincipalID != nil {
+			s.Scope.SetVMPrincipalID(*vm.Identity.PrincipalID)
+		}
 
 		spec, ok := vmSpec.(*VMSpec)
 		if !ok {
 			return errors.Errorf("%T is not a valid VM spec", vmSpec)
 		}
 
+		consoleURI, serialConsoleLogURI, err := s.getBootDiagnosticsURIs(ctx, spec)
+		if err != nil {
+			return errors.Wrap(err, "failed to fetch VM boot diagnostics")
+		}
+		s.Scope.SetBootDiagnosticsURIs(consoleURI, serialConsoleLogURI)
+
+		if len(spec.NodeLabelTags) > 0 {
+			s.Scope.SetNodeLabels(spec.NodeLabels(vm.Tags))
+		}
+
 		err = s.checkUserAssignedIdentities(ctx, spec.UserAssignedIdentities, infraVM.UserAssignedIdentities)
 		if err != nil {
 			return errors.Wrap(err, "failed to check user assigned identities")
 		}
+
+		if err := s.startAfterOSDiskResize(ctx, spec, vm); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// getBootDiagnosticsURIs returns the URIs of the console screenshot and serial console log of a VM from its instance
+// view. They are empty when boot diagnostics are disabled.
+func (s *Service) getBootDiagnosticsURIs(ctx context.Context, spec *VMSpec) (consoleURI, serialConsoleLogURI string, err error) {
+	diagnostics := spec.DiagnosticsProfile
+	if diagnostics == nil || diagnostics.Boot == nil || diagnostics.Boot.StorageAccountType == infrav1.DisabledDiagnosticsStorage {
+		return "", "", nil
+	}
+
+	instanceView, err := s.instanceViewGetter.InstanceView(ctx, spec)
+	if err != nil {
+		return "", "", err
+	}
+	if instanceView.BootDiagnostics == nil {
+		return "", "", nil
+	}
+	return ptr.Deref(instanceView.BootDiagnostics.ConsoleScreenshotBlobURI, ""), ptr.Deref(instanceView.BootDiagnostics.SerialConsoleLogBlobURI, ""), nil
+}
+
+// resolveSSHKeyData reads the SSH public key of a VM that is yet to be created from the Key Vault secret it references.
+func (s *Service) resolveSSHKeyData(ctx context.Context, vmSpec azure.ResourceSpecGetter) error {
+	spec, ok := vmSpec.(*VMSpec)
+	if !ok || spec.SSHKeySecretRef == nil || spec.ProviderID != "" {
+		return nil
+	}
+
+	ref := *spec.SSHKeySecretRef
+	secret, err := s.secretsGetter.GetSecret(ctx, ref)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get SSH public key from secret %s in Key Vault %s", ref.SecretName, ref.VaultName)
+	}
+	sshKeyData, err := encodeSSHPublicKey(secret)
+	if err != nil {
+		return azure.WithTerminalError(errors.Wrapf(err, "secret %s in Key Vault %s does not hold a valid SSH public key", ref.SecretName, ref.VaultName))
+	}
+	spec.SSHKeyData = sshKeyData
+	return nil
+}
+
+// validateCustomData checks the custom data of a VM to be created fits in the maximum custom data size, rather than
+// letting Azure reject the VM.
+func validateCustomData(vmSpec azure.ResourceSpecGetter) error {
+	spec, ok := vmSpec.(*VMSpec)
+	if !ok || spec.ProviderID != "" {
+		return nil
+	}
+	_, err := spec.CustomData()
+	return err
+}
+
+// checkImageReplication checks the gallery image version of a VM to be created is replicated to the region of the VM,
+// rather than letting Azure reject the VM with an opaque error. When ReplicateGalleryImage is set, the region is added
+// to the target regions of the image version if it is missing.
+func (s *Service) checkImageReplication(ctx context.Context, vmSpec azure.ResourceSpecGetter) error {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "virtualmachines.Service.checkImageReplication")
+	defer done()
+
+	spec, ok := vmSpec.(*VMSpec)
+	if !ok || spec.ProviderID != "" || spec.Image == nil {
+		return nil
+	}
+	ref, ok := galleryImageVersionRef(spec.Image)
+	if !ok {
+		return nil
+	}
+
+	version, err := s.imageVersionsGetter.Get(ctx, ref)
+	if err != nil {
+		// The gallery may not be readable by the cluster identity, leave it to Azure to validate the image on VM creation.
+		log.V(2).Info("unable to check the replication of the gallery image version, skipping", "image", ref.String(), "error", err.Error())
+		return nil
+	}
+
+	switch replicationState(version, spec.Location) {
+	case compute.ReplicationStateCompleted:
+		return nil
+	case compute.ReplicationStateFailed:
+		err = azure.WithTransientError(errors.Errorf("replication of gallery image version %s to region %s failed", ref, spec.Location), reconciler.DefaultReconcilerRequeue)
+		s.Scope.SetConditionFalse(infrav1.VMRunningCondition, infrav1.ImageNotReplicatedReason, clusterv1.ConditionSeverityError, err.Error())
+		return err
+	case compute.ReplicationStateReplicating, compute.ReplicationStateUnknown:
+		err = azure.WithTransientError(errors.Errorf("gallery image version %s is being replicated to region %s", ref, spec.Location), imageReplicationRequeue)
+		s.Scope.SetConditionFalse(infrav1.VMRunningCondition, infrav1.ImageNotReplicatedReason, clusterv1.ConditionSeverityInfo, err.Error())
+		return err
+	}
+
+	if !spec.ReplicateGalleryImage {
+		err = azure.WithTransientError(errors.Errorf("gallery image version %s is not replicated to region %s, add the region to the target regions of the image version", ref, spec.Location), reconciler.DefaultReconcilerRequeue)
+		s.Scope.SetConditionFalse(infrav1.VMRunningCondition, infrav1.ImageNotReplicatedReason, clusterv1.ConditionSeverityError, err.Error())
+		return err
+	}
+
+	var targetRegions []compute.TargetRegion
+	if version.GalleryImageVersionProperties != nil && version.PublishingProfile != nil && version.PublishingProfile.TargetRegions != nil {
+		targetRegions = append(targetRegions, *version.PublishingProfile.TargetRegions...)
+	}
+	targetRegions = append(targetRegions, compute.TargetRegion{Name: ptr.To(spec.Location)})
+	if s.Scope.IsDryRun() {
+		// The image version may be shared with other clusters, so its replication is only reported.
+		s.Scope.RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason,
+			"Dry run: gallery image version %s would be replicated to region %s", ref, spec.Location)
+		return nil
+	}
+	if err := s.imageVersionsGetter.UpdateTargetRegions(ctx, ref, version, targetRegions); err != nil {
+		err = errors.Wrapf(err, "failed to replicate gallery image version %s to region %s", ref, spec.Location)
+		s.Scope.SetConditionFalse(infrav1.VMRunningCondition, infrav1.ImageNotReplicatedReason, clusterv1.ConditionSeverityError, err.Error())
+		return err
+	}
+	err = azure.WithTransientError(errors.Errorf("replicating gallery image version %s to region %s", ref, spec.Location), imageReplicationRequeue)
+	s.Scope.SetConditionFalse(infrav1.VMRunningCondition, infrav1.ImageNotReplicatedReason, clusterv1.ConditionSeverityInfo, err.Error())
+	return err
+}
+
+// checkImagePlanAgreement checks the marketplace agreement of the purchase plan of the image of a VM to be created is
+// accepted in the subscription, rather than letting Azure reject the VM with an opaque error.
+func (s *Service) checkImagePlanAgreement(ctx context.Context, vmSpec azure.ResourceSpecGetter) error {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "virtualmachines.Service.checkImagePlanAgreement")
+	defer done()
+
+	spec, ok := vmSpec.(*VMSpec)
+	if !ok || spec.ProviderID != "" {
+		return nil
+	}
+	agreement := marketplaceagreements.NewAgreementSpec(spec.Image, false)
+	if agreement == nil {
+		return nil
+	}
+
+	terms, err := s.agreementsGetter.Get(ctx, agreement.Publisher, agreement.Offer, agreement.Plan)
+	if err != nil {
+		// The agreements may not be readable by the cluster identity, leave it to Azure to validate the plan on VM creation.
+		log.V(2).Info("unable to check the marketplace agreement of the image plan, skipping", "plan", agreement.PlanID(), "error", err.Error())
+		return nil
+	}
+	if terms.AgreementProperties != nil && ptr.Deref(terms.Accepted, false) {
+		return nil
 	}
+
+	err = azure.WithTransientError(errors.Errorf("marketplace agreement of image plan %s is not accepted in subscription %s, accept it with `az vm image terms accept --publisher %s --offer %s --plan %s`",
+		agreement.PlanID(), s.Scope.SubscriptionID(), agreement.Publisher, agreement.Offer, agreement.Plan), reconciler.DefaultReconcilerRequeue)
+	s.Scope.SetConditionFalse(infrav1.VMRunningCondition, infrav1.ImagePlanNotAcceptedReason, clusterv1.ConditionSeverityError, err.Error())
 	return err
 }
 
+// galleryImageVersionRef returns the gallery image version referenced by an image, if any. Images from community
+// galleries are not returned as they are replicated by their publisher, and neither are the latest versions as they
+// are resolved by Azure on VM creation.
+func galleryImageVersionRef(image *infrav1.Image) (galleryimageversions.ImageVersion, bool) {
+	var ref galleryimageversions.ImageVersion
+	switch {
+	case image.SharedGallery != nil:
+		ref = galleryimageversions.ImageVersion{
+			SubscriptionID: image.SharedGallery.SubscriptionID,
+			ResourceGroup:  image.SharedGallery.ResourceGroup,
+			Gallery:        image.SharedGallery.Gallery,
+			Image:          image.SharedGallery.Name,
+			Version:        image.SharedGallery.Version,
+		}
+	case image.ComputeGallery != nil:
+		if image.ComputeGallery.SubscriptionID == nil || image.ComputeGallery.ResourceGroup == nil {
+			return ref, false
+		}
+		ref = galleryimageversions.ImageVersion{
+			SubscriptionID: *image.ComputeGallery.SubscriptionID,
+			ResourceGroup:  *image.ComputeGallery.ResourceGroup,
+			Gallery:        image.ComputeGallery.Gallery,
+			Image:          image.ComputeGallery.Name,
+			Version:        image.ComputeGallery.Version,
+		}
+	case image.ID != nil:
+		id, err := azureutil.ParseResourceID(*image.ID)
+		if err != nil || !strings.EqualFold(id.ResourceType.String(), "Microsoft.Compute/galleries/images/versions") {
+			return ref, false
+		}
+		ref = galleryimageversions.ImageVersion{
+			SubscriptionID: id.SubscriptionID,
+			ResourceGroup:  id.ResourceGroupName,
+			Gallery:        id.Parent.Parent.Name,
+			Image:          id.Parent.Name,
+			Version:        id.Name,
+		}
+	default:
+		return ref, false
+	}
+	if strings.EqualFold(ref.Version, "latest") {
+		return ref, false
+	}
+	return ref, true
+}
+
+// replicationState returns the replication state of a gallery image version in a region, or an empty state if the
+// region is not one of the target regions of the image version.
+func replicationState(version compute.GalleryImageVersion, region string) compute.ReplicationState {
+	// Azure reports the display names of the regions, e.g. "East US" for eastus.
+	normalize := func(name string) string {
+		return strings.ToLower(strings.ReplaceAll(name, " ", ""))
+	}
+	region = normalize(region)
+
+	// The image version is always available in the region it was published in.
+	if normalize(ptr.Deref(version.Location, "")) == region {
+		return compute.ReplicationStateCompleted
+	}
+	if version.GalleryImageVersionProperties == nil || version.PublishingProfile == nil || version.PublishingProfile.TargetRegions == nil {
+		return ""
+	}
+
+	for _, targetRegion := range *version.PublishingProfile.TargetRegions {
+		if normalize(ptr.Deref(targetRegion.Name, "")) != region {
+			continue
+		}
+		if version.ReplicationStatus != nil && version.ReplicationStatus.Summary != nil {
+			for _, status := range *version.ReplicationStatus.Summary {
+				if normalize(ptr.Deref(status.Region, "")) == region && status.State != "" {
+					return status.State
+				}
+			}
+		}
+		return compute.ReplicationStateCompleted
+	}
+	return ""
+}
+
+// encodeSSHPublicKey returns the base64-encoded SSH public key held by a secret, which holds the key either in the
+// authorized_keys format or base64-encoded.
+func encodeSSHPublicKey(secret string) (string, error) {
+	key := []byte(strings.TrimSpace(secret))
+	if _, _, _, _, err := ssh.ParseAuthorizedKey(key); err == nil {
+		return base64.StdEncoding.EncodeToString(key), nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(string(key))
+	if err != nil {
+		return "", errors.New("the SSH public key is neither in the authorized_keys format nor base64 encoded")
+	}
+	if _, _, _, _, err := ssh.ParseAuthorizedKey(decoded); err != nil {
+		return "", errors.Wrap(err, "the SSH public key is not valid")
+	}
+	return string(key), nil
+}
+
 // Delete deletes the virtual machine with the provided name.
 func (s *Service) Delete(ctx context.Context) error {
 	ctx, _, done := tele.StartSpanWithLogger(ctx, "virtualmachines.Service.Delete")
@@ -144,13 +491,13 @@ func (s *Service) Delete(ctx context.Context) error {
 		return nil
 	}
 
-	err := s.DeleteResource(ctx, vmSpec, serviceName)
+	err := s.DeleteResource(ctx, vmSpec, ServiceName)
 	if err != nil {
 		s.Scope.SetVMState(infrav1.Deleting)
 	} else {
 		s.Scope.SetVMState(infrav1.Deleted)
 	}
-	s.Scope.UpdateDeleteStatus(infrav1.VMRunningCondition, serviceName, err)
+	s.Scope.UpdateDeleteStatus(infrav1.VMRunningCondition, ServiceName, err)
 	return err
 }
 
@@ -18,24 +18,35 @@ package virtualmachines
 
 import (
 	"context"
+	"encoding/base64"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/marketplaceordering/mgmt/2015-06-01/marketplaceordering"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
 	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async/mock_async"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/galleryimageversions"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/galleryimageversions/mock_galleryimageversions"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/identities/mock_identities"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/keyvaults/mock_keyvaults"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/marketplaceagreements/mock_marketplaceagreements"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/networkinterfaces"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicips"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualmachines/mock_virtualmachines"
 	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+	utilSSH "sigs.k8s.io/cluster-api-provider-azure/util/ssh"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
@@ -69,6 +80,14 @@ var (
 			},
 		},
 	}
+	fakeExistingVMWithIdentity = func() compute.VirtualMachine {
+		vm := fakeExistingVM
+		vm.Identity = &compute.VirtualMachineIdentity{
+			Type:        compute.ResourceIdentityTypeSystemAssigned,
+			PrincipalID: ptr.To("fake-principal-id"),
+		}
+		return vm
+	}()
 	fakeNetworkInterfaceGetterSpec = networkinterfaces.NICSpec{
 		Name:          "nic-1",
 		ResourceGroup: "test-group",
@@ -137,15 +156,66 @@ func TestReconcileVM(t *testing.T) {
 			expectedError: "",
 			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.VMSpec().Return(&fakeVMSpec)
-				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeVMSpec, serviceName).Return(fakeExistingVM, nil)
-				s.UpdatePutStatus(infrav1.VMRunningCondition, serviceName, nil)
-				s.UpdatePutStatus(infrav1.DisksReadyCondition, serviceName, nil)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeVMSpec, ServiceName).Return(fakeExistingVM, nil)
+				s.UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, nil)
+				s.UpdatePutStatus(infrav1.DisksReadyCondition, ServiceName, nil)
 				s.SetProviderID("azure://subscriptions/123/resourceGroups/my_resource_group/providers/Microsoft.Compute/virtualMachines/my-vm")
 				s.SetAnnotation("cluster-api-provider-azure", "true")
 				mnic.Get(gomockinternal.AContext(), &fakeNetworkInterfaceGetterSpec).Return(fakeNetworkInterface, nil)
 				mpip.Get(gomockinternal.AContext(), &fakePublicIPSpec).Return(fakePublicIPs, nil)
 				s.SetAddresses(fakeNodeAddresses)
 				s.SetVMState(infrav1.Succeeded)
+				s.SetBootDiagnosticsURIs("", "")
+			},
+		},
+		{
+			name:          "create vm with a system-assigned identity records its principal ID",
+			expectedError: "",
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				s.VMSpec().Return(&fakeVMSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeVMSpec, ServiceName).Return(fakeExistingVMWithIdentity, nil)
+				s.UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, nil)
+				s.UpdatePutStatus(infrav1.DisksReadyCondition, ServiceName, nil)
+				s.SetProviderID("azure://subscriptions/123/resourceGroups/my_resource_group/providers/Microsoft.Compute/virtualMachines/my-vm")
+				s.SetAnnotation("cluster-api-provider-azure", "true")
+				mnic.Get(gomockinternal.AContext(), &fakeNetworkInterfaceGetterSpec).Return(fakeNetworkInterface, nil)
+				mpip.Get(gomockinternal.AContext(), &fakePublicIPSpec).Return(fakePublicIPs, nil)
+				s.SetAddresses(fakeNodeAddresses)
+				s.SetVMState(infrav1.Succeeded)
+				s.SetVMPrincipalID("fake-principal-id")
+				s.SetBootDiagnosticsURIs("", "")
+			},
+		},
+		{
+			name:          "vm with node label tags sets the node labels from the vm tags",
+			expectedError: "",
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				nodeLabelsVMSpec := fakeVMSpec
+				nodeLabelsVMSpec.NodeLabelTags = []string{"workload"}
+				taggedVM := fakeExistingVM
+				taggedVM.Tags = map[string]*string{"workload": ptr.To("gpu"), "owner": ptr.To("ml")}
+				s.VMSpec().Return(&nodeLabelsVMSpec)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &nodeLabelsVMSpec, ServiceName).Return(taggedVM, nil)
+				s.UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, nil)
+				s.UpdatePutStatus(infrav1.DisksReadyCondition, ServiceName, nil)
+				s.SetProviderID("azure://subscriptions/123/resourceGroups/my_resource_group/providers/Microsoft.Compute/virtualMachines/my-vm")
+				s.SetAnnotation("cluster-api-provider-azure", "true")
+				mnic.Get(gomockinternal.AContext(), &fakeNetworkInterfaceGetterSpec).Return(fakeNetworkInterface, nil)
+				mpip.Get(gomockinternal.AContext(), &fakePublicIPSpec).Return(fakePublicIPs, nil)
+				s.SetAddresses(fakeNodeAddresses)
+				s.SetVMState(infrav1.Succeeded)
+				s.SetBootDiagnosticsURIs("", "")
+				s.SetNodeLabels(map[string]string{"workload": "gpu"})
+			},
+		},
+		{
+			name:          "bootstrap data exceeding the maximum custom data size fails",
+			expectedError: "reconcile error that cannot be recovered occurred: bootstrap data of VM test-vm is 65540 bytes once base64 encoded, which exceeds the maximum custom data size of 65536 bytes, consider enabling the compression of the bootstrap data. Object will not be requeued",
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				largeVMSpec := fakeVMSpec
+				largeVMSpec.BootstrapData = base64.StdEncoding.EncodeToString(make([]byte, 49155))
+				s.VMSpec().Return(&largeVMSpec)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.BootstrapDataTooLargeReason, clusterv1.ConditionSeverityError, gomock.Any())
 			},
 		},
 		{
@@ -153,9 +223,9 @@ func TestReconcileVM(t *testing.T) {
 			expectedError: "#: Internal Server Error: StatusCode=500",
 			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.VMSpec().Return(&fakeVMSpec)
-				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeVMSpec, serviceName).Return(nil, internalError)
-				s.UpdatePutStatus(infrav1.VMRunningCondition, serviceName, internalError)
-				s.UpdatePutStatus(infrav1.DisksReadyCondition, serviceName, internalError)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeVMSpec, ServiceName).Return(nil, internalError)
+				s.UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, internalError)
+				s.UpdatePutStatus(infrav1.DisksReadyCondition, ServiceName, internalError)
 			},
 		},
 		{
@@ -163,9 +233,9 @@ func TestReconcileVM(t *testing.T) {
 			expectedError: "failed to fetch VM addresses: #: Internal Server Error: StatusCode=500",
 			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.VMSpec().Return(&fakeVMSpec)
-				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeVMSpec, serviceName).Return(fakeExistingVM, nil)
-				s.UpdatePutStatus(infrav1.VMRunningCondition, serviceName, nil)
-				s.UpdatePutStatus(infrav1.DisksReadyCondition, serviceName, nil)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeVMSpec, ServiceName).Return(fakeExistingVM, nil)
+				s.UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, nil)
+				s.UpdatePutStatus(infrav1.DisksReadyCondition, ServiceName, nil)
 				s.SetProviderID("azure://subscriptions/123/resourceGroups/my_resource_group/providers/Microsoft.Compute/virtualMachines/my-vm")
 				s.SetAnnotation("cluster-api-provider-azure", "true")
 				mnic.Get(gomockinternal.AContext(), &fakeNetworkInterfaceGetterSpec).Return(network.Interface{}, internalError)
@@ -176,9 +246,9 @@ func TestReconcileVM(t *testing.T) {
 			expectedError: "failed to fetch VM addresses: #: Internal Server Error: StatusCode=500",
 			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.VMSpec().Return(&fakeVMSpec)
-				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeVMSpec, serviceName).Return(fakeExistingVM, nil)
-				s.UpdatePutStatus(infrav1.VMRunningCondition, serviceName, nil)
-				s.UpdatePutStatus(infrav1.DisksReadyCondition, serviceName, nil)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeVMSpec, ServiceName).Return(fakeExistingVM, nil)
+				s.UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, nil)
+				s.UpdatePutStatus(infrav1.DisksReadyCondition, ServiceName, nil)
 				s.SetProviderID("azure://subscriptions/123/resourceGroups/my_resource_group/providers/Microsoft.Compute/virtualMachines/my-vm")
 				s.SetAnnotation("cluster-api-provider-azure", "true")
 				mnic.Get(gomockinternal.AContext(), &fakeNetworkInterfaceGetterSpec).Return(fakeNetworkInterface, nil)
@@ -238,9 +308,9 @@ func TestDeleteVM(t *testing.T) {
 			expectedError: "",
 			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.VMSpec().AnyTimes().Return(&fakeVMSpec)
-				r.DeleteResource(gomockinternal.AContext(), &fakeVMSpec, serviceName).Return(nil)
+				r.DeleteResource(gomockinternal.AContext(), &fakeVMSpec, ServiceName).Return(nil)
 				s.SetVMState(infrav1.Deleted)
-				s.UpdateDeleteStatus(infrav1.VMRunningCondition, serviceName, nil)
+				s.UpdateDeleteStatus(infrav1.VMRunningCondition, ServiceName, nil)
 			},
 		},
 		{
@@ -248,9 +318,9 @@ func TestDeleteVM(t *testing.T) {
 			expectedError: "#: Internal Server Error: StatusCode=500",
 			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.VMSpec().AnyTimes().Return(&fakeVMSpec)
-				r.DeleteResource(gomockinternal.AContext(), &fakeVMSpec, serviceName).Return(internalError)
+				r.DeleteResource(gomockinternal.AContext(), &fakeVMSpec, ServiceName).Return(internalError)
 				s.SetVMState(infrav1.Deleting)
-				s.UpdateDeleteStatus(infrav1.VMRunningCondition, serviceName, internalError)
+				s.UpdateDeleteStatus(infrav1.VMRunningCondition, ServiceName, internalError)
 			},
 		},
 		{
@@ -258,9 +328,9 @@ func TestDeleteVM(t *testing.T) {
 			expectedError: "",
 			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
 				s.VMSpec().AnyTimes().Return(&fakeVMSpec)
-				r.DeleteResource(gomockinternal.AContext(), &fakeVMSpec, serviceName).Return(nil)
+				r.DeleteResource(gomockinternal.AContext(), &fakeVMSpec, ServiceName).Return(nil)
 				s.SetVMState(infrav1.Deleted)
-				s.UpdateDeleteStatus(infrav1.VMRunningCondition, serviceName, nil)
+				s.UpdateDeleteStatus(infrav1.VMRunningCondition, ServiceName, nil)
 			},
 		},
 	}
@@ -293,6 +363,67 @@ func TestDeleteVM(t *testing.T) {
 	}
 }
 
+func TestUpdateRecorder(t *testing.T) {
+	update := vmUpdate{
+		driftedFields:     []string{"identity", "boot diagnostics storage URI"},
+		attachedDataDisks: []string{"test-vm_datadisk"},
+	}
+
+	testcases := []struct {
+		name          string
+		parameters    interface{}
+		expectedError string
+		expect        func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_virtualmachines.MockClientMockRecorder)
+	}{
+		{
+			name:       "accepted update records the drifted fields and attached data disks",
+			parameters: update,
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_virtualmachines.MockClientMockRecorder) {
+				m.CreateOrUpdateAsync(gomockinternal.AContext(), &fakeVMSpec, update).Return(nil, &azureautorest.Future{}, nil)
+				s.RecordEvent(corev1.EventTypeNormal, infrav1.VMDriftCorrectedReason, "Reapplied %s of VM %s that drifted from the spec", "identity, boot diagnostics storage URI", "test-vm")
+				s.RecordEvent(corev1.EventTypeNormal, infrav1.VMDataDisksAttachedReason, "Attached data disks %s to VM %s", "test-vm_datadisk", "test-vm")
+			},
+		},
+		{
+			name:          "failed update records no event",
+			parameters:    update,
+			expectedError: "#: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_virtualmachines.MockClientMockRecorder) {
+				m.CreateOrUpdateAsync(gomockinternal.AContext(), &fakeVMSpec, update).Return(nil, nil, internalError)
+			},
+		},
+		{
+			name:       "creating a vm records no event",
+			parameters: compute.VirtualMachine{},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_virtualmachines.MockClientMockRecorder) {
+				m.CreateOrUpdateAsync(gomockinternal.AContext(), &fakeVMSpec, compute.VirtualMachine{}).Return(compute.VirtualMachine{}, nil, nil)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_virtualmachines.NewMockVMScope(mockCtrl)
+			clientMock := mock_virtualmachines.NewMockClient(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), clientMock.EXPECT())
+
+			r := &updateRecorder{Client: clientMock, scope: scopeMock}
+			_, _, err := r.CreateOrUpdateAsync(context.TODO(), &fakeVMSpec, tc.parameters)
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
 func TestCheckUserAssignedIdentities(t *testing.T) {
 	testcases := []struct {
 		name             string
@@ -396,3 +527,649 @@ func TestCheckUserAssignedIdentities(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveSSHKeyData(t *testing.T) {
+	_, publicKey, err := utilSSH.GenerateSSHKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
+	encodedKey := base64.StdEncoding.EncodeToString([]byte(authorizedKey))
+	secretRef := infrav1.KeyVaultSecretReference{VaultName: "my-vault", SecretName: "ssh-key"}
+
+	testcases := []struct {
+		name               string
+		spec               VMSpec
+		expect             func(k *mock_keyvaults.MockClientMockRecorder)
+		expectedSSHKeyData string
+		expectedError      string
+	}{
+		{
+			name:               "SSH key is not read from Key Vault",
+			spec:               VMSpec{SSHKeyData: encodedKey},
+			expect:             func(k *mock_keyvaults.MockClientMockRecorder) {},
+			expectedSSHKeyData: encodedKey,
+		},
+		{
+			name: "SSH key in the authorized_keys format is read from Key Vault",
+			spec: VMSpec{SSHKeySecretRef: &secretRef},
+			expect: func(k *mock_keyvaults.MockClientMockRecorder) {
+				k.GetSecret(gomockinternal.AContext(), secretRef).Return(authorizedKey+"\n", nil)
+			},
+			expectedSSHKeyData: encodedKey,
+		},
+		{
+			name: "base64-encoded SSH key is read from Key Vault",
+			spec: VMSpec{SSHKeySecretRef: &secretRef},
+			expect: func(k *mock_keyvaults.MockClientMockRecorder) {
+				k.GetSecret(gomockinternal.AContext(), secretRef).Return(encodedKey, nil)
+			},
+			expectedSSHKeyData: encodedKey,
+		},
+		{
+			name:   "SSH key is not read from Key Vault once the VM is created",
+			spec:   VMSpec{SSHKeySecretRef: &secretRef, ProviderID: "azure:///subscriptions/123/resourceGroups/test-rg/providers/Microsoft.Compute/virtualMachines/test-vm"},
+			expect: func(k *mock_keyvaults.MockClientMockRecorder) {},
+		},
+		{
+			name: "fail to read the secret",
+			spec: VMSpec{SSHKeySecretRef: &secretRef},
+			expect: func(k *mock_keyvaults.MockClientMockRecorder) {
+				k.GetSecret(gomockinternal.AContext(), secretRef).Return("", internalError)
+			},
+			expectedError: "failed to get SSH public key from secret ssh-key in Key Vault my-vault",
+		},
+		{
+			name: "secret does not hold an SSH key",
+			spec: VMSpec{SSHKeySecretRef: &secretRef},
+			expect: func(k *mock_keyvaults.MockClientMockRecorder) {
+				k.GetSecret(gomockinternal.AContext(), secretRef).Return("not an ssh key", nil)
+			},
+			expectedError: "secret ssh-key in Key Vault my-vault does not hold a valid SSH public key",
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			secretsMock := mock_keyvaults.NewMockClient(mockCtrl)
+
+			tc.expect(secretsMock.EXPECT())
+			s := &Service{
+				secretsGetter: secretsMock,
+			}
+
+			err := s.resolveSSHKeyData(context.TODO(), &tc.spec)
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(tc.spec.SSHKeyData).To(Equal(tc.expectedSSHKeyData))
+			}
+		})
+	}
+}
+
+func TestCheckImageReplication(t *testing.T) {
+	ref := galleryimageversions.ImageVersion{
+		SubscriptionID: "123",
+		ResourceGroup:  "gallery-rg",
+		Gallery:        "my-gallery",
+		Image:          "my-image",
+		Version:        "1.0.0",
+	}
+	computeGalleryImage := &infrav1.Image{
+		ComputeGallery: &infrav1.AzureComputeGalleryImage{
+			Gallery:        "my-gallery",
+			Name:           "my-image",
+			Version:        "1.0.0",
+			SubscriptionID: ptr.To("123"),
+			ResourceGroup:  ptr.To("gallery-rg"),
+		},
+	}
+	imageVersion := func(targetRegions ...compute.TargetRegion) compute.GalleryImageVersion {
+		return compute.GalleryImageVersion{
+			Location: ptr.To("westus"),
+			GalleryImageVersionProperties: &compute.GalleryImageVersionProperties{
+				PublishingProfile: &compute.GalleryImageVersionPublishingProfile{
+					TargetRegions: &targetRegions,
+				},
+			},
+		}
+	}
+
+	testcases := []struct {
+		name          string
+		spec          VMSpec
+		expect        func(s *mock_virtualmachines.MockVMScopeMockRecorder, v *mock_galleryimageversions.MockClientMockRecorder)
+		expectedError string
+	}{
+		{
+			name: "marketplace image is not checked",
+			spec: VMSpec{
+				Location: "eastus",
+				Image: &infrav1.Image{
+					Marketplace: &infrav1.AzureMarketplaceImage{
+						ImagePlan: infrav1.ImagePlan{Publisher: "publisher", Offer: "offer", SKU: "sku"},
+						Version:   "1.0.0",
+					},
+				},
+			},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, v *mock_galleryimageversions.MockClientMockRecorder) {
+			},
+		},
+		{
+			name: "community gallery image is not checked",
+			spec: VMSpec{
+				Location: "eastus",
+				Image: &infrav1.Image{
+					ComputeGallery: &infrav1.AzureComputeGalleryImage{Gallery: "community-gallery", Name: "my-image", Version: "1.0.0"},
+				},
+			},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, v *mock_galleryimageversions.MockClientMockRecorder) {
+			},
+		},
+		{
+			name: "latest image version is not checked",
+			spec: VMSpec{
+				Location: "eastus",
+				Image: &infrav1.Image{
+					ID: ptr.To("/subscriptions/123/resourceGroups/gallery-rg/providers/Microsoft.Compute/galleries/my-gallery/images/my-image/versions/latest"),
+				},
+			},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, v *mock_galleryimageversions.MockClientMockRecorder) {
+			},
+		},
+		{
+			name: "image is not checked once the VM is created",
+			spec: VMSpec{
+				Location:   "eastus",
+				Image:      computeGalleryImage,
+				ProviderID: "azure:///subscriptions/123/resourceGroups/test-rg/providers/Microsoft.Compute/virtualMachines/test-vm",
+			},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, v *mock_galleryimageversions.MockClientMockRecorder) {
+			},
+		},
+		{
+			name: "image version is published in the region of the VM",
+			spec: VMSpec{Location: "westus", Image: computeGalleryImage},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, v *mock_galleryimageversions.MockClientMockRecorder) {
+				v.Get(gomockinternal.AContext(), ref).Return(imageVersion(), nil)
+			},
+		},
+		{
+			name: "image version referenced by ID is replicated to the region of the VM",
+			spec: VMSpec{
+				Location: "eastus",
+				Image: &infrav1.Image{
+					ID: ptr.To("/subscriptions/123/resourceGroups/gallery-rg/providers/Microsoft.Compute/galleries/my-gallery/images/my-image/versions/1.0.0"),
+				},
+			},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, v *mock_galleryimageversions.MockClientMockRecorder) {
+				version := imageVersion(compute.TargetRegion{Name: ptr.To("West US")}, compute.TargetRegion{Name: ptr.To("East US")})
+				version.ReplicationStatus = &compute.ReplicationStatus{
+					Summary: &[]compute.RegionalReplicationStatus{
+						{Region: ptr.To("West US"), State: compute.ReplicationStateCompleted},
+						{Region: ptr.To("East US"), State: compute.ReplicationStateCompleted},
+					},
+				}
+				v.Get(gomockinternal.AContext(), ref).Return(version, nil)
+			},
+		},
+		{
+			name: "image version is being replicated to the region of the VM",
+			spec: VMSpec{Location: "eastus", Image: computeGalleryImage},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, v *mock_galleryimageversions.MockClientMockRecorder) {
+				version := imageVersion(compute.TargetRegion{Name: ptr.To("East US")})
+				version.ReplicationStatus = &compute.ReplicationStatus{
+					Summary: &[]compute.RegionalReplicationStatus{{Region: ptr.To("East US"), State: compute.ReplicationStateReplicating}},
+				}
+				v.Get(gomockinternal.AContext(), ref).Return(version, nil)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.ImageNotReplicatedReason, clusterv1.ConditionSeverityInfo, gomock.Any())
+			},
+			expectedError: "gallery image version my-gallery/my-image/1.0.0 is being replicated to region eastus",
+		},
+		{
+			name: "image version is not replicated to the region of the VM",
+			spec: VMSpec{Location: "eastus", Image: computeGalleryImage},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, v *mock_galleryimageversions.MockClientMockRecorder) {
+				v.Get(gomockinternal.AContext(), ref).Return(imageVersion(compute.TargetRegion{Name: ptr.To("West US")}), nil)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.ImageNotReplicatedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "gallery image version my-gallery/my-image/1.0.0 is not replicated to region eastus",
+		},
+		{
+			name: "image version is replicated to the region of the VM when enabled",
+			spec: VMSpec{Location: "eastus", Image: computeGalleryImage, ReplicateGalleryImage: true},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, v *mock_galleryimageversions.MockClientMockRecorder) {
+				version := imageVersion(compute.TargetRegion{Name: ptr.To("West US"), RegionalReplicaCount: ptr.To[int32](2)})
+				v.Get(gomockinternal.AContext(), ref).Return(version, nil)
+				s.IsDryRun().Return(false)
+				v.UpdateTargetRegions(gomockinternal.AContext(), ref, version, []compute.TargetRegion{
+					{Name: ptr.To("West US"), RegionalReplicaCount: ptr.To[int32](2)},
+					{Name: ptr.To("eastus")},
+				}).Return(nil)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.ImageNotReplicatedReason, clusterv1.ConditionSeverityInfo, gomock.Any())
+			},
+			expectedError: "replicating gallery image version my-gallery/my-image/1.0.0 to region eastus",
+		},
+		{
+			name: "image version is not replicated to the region of the VM in dry-run mode",
+			spec: VMSpec{Location: "eastus", Image: computeGalleryImage, ReplicateGalleryImage: true},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, v *mock_galleryimageversions.MockClientMockRecorder) {
+				v.Get(gomockinternal.AContext(), ref).Return(imageVersion(compute.TargetRegion{Name: ptr.To("West US")}), nil)
+				s.IsDryRun().Return(true)
+				s.RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason, gomock.Any(), ref, "eastus")
+			},
+		},
+		{
+			name: "fail to replicate the image version",
+			spec: VMSpec{Location: "eastus", Image: computeGalleryImage, ReplicateGalleryImage: true},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, v *mock_galleryimageversions.MockClientMockRecorder) {
+				v.Get(gomockinternal.AContext(), ref).Return(imageVersion(), nil)
+				s.IsDryRun().Return(false)
+				v.UpdateTargetRegions(gomockinternal.AContext(), ref, gomock.Any(), gomock.Any()).Return(internalError)
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.ImageNotReplicatedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "failed to replicate gallery image version my-gallery/my-image/1.0.0 to region eastus",
+		},
+		{
+			name: "image version that cannot be read is not checked",
+			spec: VMSpec{Location: "eastus", Image: computeGalleryImage},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, v *mock_galleryimageversions.MockClientMockRecorder) {
+				v.Get(gomockinternal.AContext(), ref).Return(compute.GalleryImageVersion{}, internalError)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_virtualmachines.NewMockVMScope(mockCtrl)
+			imageVersionsMock := mock_galleryimageversions.NewMockClient(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), imageVersionsMock.EXPECT())
+			s := &Service{
+				Scope:               scopeMock,
+				imageVersionsGetter: imageVersionsMock,
+			}
+
+			err := s.checkImageReplication(context.TODO(), &tc.spec)
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tc.expectedError))
+				var reconcileErr azure.ReconcileError
+				if errors.As(err, &reconcileErr) {
+					g.Expect(reconcileErr.IsTerminal()).To(BeFalse())
+				}
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestCheckImagePlanAgreement(t *testing.T) {
+	thirdPartyImage := &infrav1.Image{
+		Marketplace: &infrav1.AzureMarketplaceImage{
+			ImagePlan:       infrav1.ImagePlan{Publisher: "my-publisher", Offer: "my-offer", SKU: "my-sku"},
+			Version:         "1.0.0",
+			ThirdPartyImage: true,
+		},
+	}
+	agreement := func(accepted bool) marketplaceordering.AgreementTerms {
+		return marketplaceordering.AgreementTerms{AgreementProperties: &marketplaceordering.AgreementProperties{Accepted: ptr.To(accepted)}}
+	}
+
+	testcases := []struct {
+		name          string
+		spec          VMSpec
+		expect        func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder)
+		expectedError string
+	}{
+		{
+			name: "existing VM is not checked",
+			spec: VMSpec{Image: thirdPartyImage, ProviderID: "azure:///subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm"},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder) {
+			},
+		},
+		{
+			name: "image without a plan is not checked",
+			spec: VMSpec{Image: &infrav1.Image{
+				Marketplace: &infrav1.AzureMarketplaceImage{
+					ImagePlan: infrav1.ImagePlan{Publisher: "my-publisher", Offer: "my-offer", SKU: "my-sku"},
+					Version:   "1.0.0",
+				},
+			}},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder) {
+			},
+		},
+		{
+			name: "agreement of the image plan is accepted",
+			spec: VMSpec{Image: thirdPartyImage},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-publisher", "my-offer", "my-sku").Return(agreement(true), nil)
+			},
+		},
+		{
+			name: "agreement of the purchase plan of the image is checked",
+			spec: VMSpec{Image: &infrav1.Image{
+				Marketplace: &infrav1.AzureMarketplaceImage{
+					ImagePlan: infrav1.ImagePlan{Publisher: "my-publisher", Offer: "my-offer", SKU: "my-sku-gen2"},
+					Version:   "1.0.0",
+					Plan:      &infrav1.ImagePlan{Publisher: "my-publisher", Offer: "my-product", SKU: "my-plan"},
+				},
+			}},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-publisher", "my-product", "my-plan").Return(agreement(true), nil)
+			},
+		},
+		{
+			name: "agreement of the image plan is not accepted",
+			spec: VMSpec{Image: thirdPartyImage},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-publisher", "my-offer", "my-sku").Return(agreement(false), nil)
+				s.SubscriptionID().Return("123")
+				s.SetConditionFalse(infrav1.VMRunningCondition, infrav1.ImagePlanNotAcceptedReason, clusterv1.ConditionSeverityError, gomock.Any())
+			},
+			expectedError: "marketplace agreement of image plan my-publisher/my-offer/my-sku is not accepted in subscription 123, accept it with `az vm image terms accept --publisher my-publisher --offer my-offer --plan my-sku`",
+		},
+		{
+			name: "agreement that cannot be read is not checked",
+			spec: VMSpec{Image: thirdPartyImage},
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_marketplaceagreements.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-publisher", "my-offer", "my-sku").Return(marketplaceordering.AgreementTerms{}, internalError)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_virtualmachines.NewMockVMScope(mockCtrl)
+			agreementsMock := mock_marketplaceagreements.NewMockClient(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), agreementsMock.EXPECT())
+			s := &Service{
+				Scope:            scopeMock,
+				agreementsGetter: agreementsMock,
+			}
+
+			err := s.checkImagePlanAgreement(context.TODO(), &tc.spec)
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tc.expectedError))
+				var reconcileErr azure.ReconcileError
+				g.Expect(errors.As(err, &reconcileErr)).To(BeTrue())
+				g.Expect(reconcileErr.IsTerminal()).To(BeFalse())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestReconcileVMBootDiagnostics(t *testing.T) {
+	userManagedVMSpec := fakeVMSpec
+	userManagedVMSpec.DiagnosticsProfile = &infrav1.Diagnostics{
+		Boot: &infrav1.BootDiagnostics{
+			StorageAccountType: infrav1.UserManagedDiagnosticsStorage,
+			UserManaged: &infrav1.UserManagedBootDiagnostics{
+				StorageAccountURI: "https://fakestorageaccount.blob.core.windows.net/",
+			},
+		},
+	}
+	disabledVMSpec := fakeVMSpec
+	disabledVMSpec.DiagnosticsProfile = &infrav1.Diagnostics{
+		Boot: &infrav1.BootDiagnostics{
+			StorageAccountType: infrav1.DisabledDiagnosticsStorage,
+		},
+	}
+
+	testcases := []struct {
+		name          string
+		vmSpec        *VMSpec
+		expectedError string
+		expect        func(s *mock_virtualmachines.MockVMScopeMockRecorder, mvm *mock_virtualmachines.MockClientMockRecorder)
+	}{
+		{
+			name:   "boot diagnostics URIs are populated from the instance view",
+			vmSpec: &userManagedVMSpec,
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mvm *mock_virtualmachines.MockClientMockRecorder) {
+				mvm.InstanceView(gomockinternal.AContext(), &userManagedVMSpec).Return(compute.VirtualMachineInstanceView{
+					BootDiagnostics: &compute.BootDiagnosticsInstanceView{
+						ConsoleScreenshotBlobURI: ptr.To("https://fakestorageaccount.blob.core.windows.net/bootdiagnostics/test-vm.screenshot.bmp"),
+						SerialConsoleLogBlobURI:  ptr.To("https://fakestorageaccount.blob.core.windows.net/bootdiagnostics/test-vm.serialconsole.log"),
+					},
+				}, nil)
+				s.SetBootDiagnosticsURIs("https://fakestorageaccount.blob.core.windows.net/bootdiagnostics/test-vm.screenshot.bmp",
+					"https://fakestorageaccount.blob.core.windows.net/bootdiagnostics/test-vm.serialconsole.log")
+			},
+		},
+		{
+			name:   "boot diagnostics URIs are cleared when the instance view has no boot diagnostics",
+			vmSpec: &userManagedVMSpec,
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mvm *mock_virtualmachines.MockClientMockRecorder) {
+				mvm.InstanceView(gomockinternal.AContext(), &userManagedVMSpec).Return(compute.VirtualMachineInstanceView{}, nil)
+				s.SetBootDiagnosticsURIs("", "")
+			},
+		},
+		{
+			name:   "boot diagnostics URIs are cleared when boot diagnostics are disabled",
+			vmSpec: &disabledVMSpec,
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mvm *mock_virtualmachines.MockClientMockRecorder) {
+				s.SetBootDiagnosticsURIs("", "")
+			},
+		},
+		{
+			name:          "failure to get the instance view",
+			vmSpec:        &userManagedVMSpec,
+			expectedError: "failed to fetch VM boot diagnostics: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mvm *mock_virtualmachines.MockClientMockRecorder) {
+				mvm.InstanceView(gomockinternal.AContext(), &userManagedVMSpec).Return(compute.VirtualMachineInstanceView{}, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			scopeMock := mock_virtualmachines.NewMockVMScope(mockCtrl)
+			interfaceMock := mock_async.NewMockGetter(mockCtrl)
+			publicIPMock := mock_async.NewMockGetter(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+			vmMock := mock_virtualmachines.NewMockClient(mockCtrl)
+
+			scopeMock.EXPECT().VMSpec().Return(tc.vmSpec)
+			asyncMock.EXPECT().CreateOrUpdateResource(gomockinternal.AContext(), tc.vmSpec, ServiceName).Return(fakeExistingVM, nil)
+			scopeMock.EXPECT().UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, nil)
+			scopeMock.EXPECT().UpdatePutStatus(infrav1.DisksReadyCondition, ServiceName, nil)
+			scopeMock.EXPECT().SetProviderID("azure://subscriptions/123/resourceGroups/my_resource_group/providers/Microsoft.Compute/virtualMachines/my-vm")
+			scopeMock.EXPECT().SetAnnotation("cluster-api-provider-azure", "true")
+			interfaceMock.EXPECT().Get(gomockinternal.AContext(), &fakeNetworkInterfaceGetterSpec).Return(fakeNetworkInterface, nil)
+			publicIPMock.EXPECT().Get(gomockinternal.AContext(), &fakePublicIPSpec).Return(fakePublicIPs, nil)
+			scopeMock.EXPECT().SetAddresses(fakeNodeAddresses)
+			scopeMock.EXPECT().SetVMState(infrav1.Succeeded)
+			tc.expect(scopeMock.EXPECT(), vmMock.EXPECT())
+
+			s := &Service{
+				Scope:              scopeMock,
+				interfacesGetter:   interfaceMock,
+				publicIPsGetter:    publicIPMock,
+				instanceViewGetter: vmMock,
+				Reconciler:         asyncMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestReconcileVMOSDiskResize(t *testing.T) {
+	growingVMSpec := fakeVMSpec
+	growingVMSpec.OSDisk = infrav1.OSDisk{DiskSizeGB: ptr.To[int32](256)}
+	deallocatingVMSpec := growingVMSpec
+	deallocatingVMSpec.OSDiskResizeState = osDiskResizeDeallocating
+	startingVMSpec := growingVMSpec
+	startingVMSpec.OSDiskResizeState = osDiskResizeStarting
+	postFuture := &infrav1.Future{
+		Type:          infrav1.PostFuture,
+		ServiceName:   ServiceName,
+		Name:          "test-vm",
+		ResourceGroup: "test-group",
+		Data:          "eyJtZXRob2QiOiJQT1NUIiwicG9sbGluZ01ldGhvZCI6IkxvY2F0aW9uIiwibHJvU3RhdGUiOiJJblByb2dyZXNzIn0=",
+	}
+	resizeRequiresDeallocation := errors.Wrapf(errOSDiskResizeRequiresDeallocation, "OS disk of VM test-vm must grow to 256 GB")
+	expectVMSucceeded := func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder) {
+		s.UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, nil)
+		s.UpdatePutStatus(infrav1.DisksReadyCondition, ServiceName, nil)
+		s.SetProviderID("azure://subscriptions/123/resourceGroups/my_resource_group/providers/Microsoft.Compute/virtualMachines/my-vm")
+		s.SetAnnotation("cluster-api-provider-azure", "true")
+		mnic.Get(gomockinternal.AContext(), &fakeNetworkInterfaceGetterSpec).Return(fakeNetworkInterface, nil)
+		mpip.Get(gomockinternal.AContext(), &fakePublicIPSpec).Return(fakePublicIPs, nil)
+		s.SetAddresses(fakeNodeAddresses)
+		s.SetVMState(infrav1.Succeeded)
+		s.SetBootDiagnosticsURIs("", "")
+	}
+
+	testcases := []struct {
+		name          string
+		vmSpec        *VMSpec
+		expectedError string
+		expect        func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder, mvm *mock_virtualmachines.MockClientMockRecorder)
+	}{
+		{
+			name:          "deallocates a running vm to grow its OS disk",
+			vmSpec:        &growingVMSpec,
+			expectedError: "operation type POST on Azure resource test-group/test-vm is not done. Object will be requeued after 15s",
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder, mvm *mock_virtualmachines.MockClientMockRecorder) {
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &growingVMSpec, ServiceName).Return(nil, resizeRequiresDeallocation)
+				s.IsDryRun().Return(false)
+				s.SetAnnotation(azure.OSDiskResizeAnnotation, osDiskResizeDeallocating)
+				s.RecordEvent(corev1.EventTypeNormal, infrav1.VMOSDiskResizingReason, "Deallocating VM %s to grow its OS disk to %d GB", "test-vm", int32(256))
+				mvm.DeallocateAsync(gomockinternal.AContext(), &growingVMSpec).Return(&azureautorest.Future{}, nil)
+				s.SetLongRunningOperationState(gomock.AssignableToTypeOf(&infrav1.Future{}))
+				s.UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, gomock.Any())
+				s.UpdatePutStatus(infrav1.DisksReadyCondition, ServiceName, gomock.Any())
+			},
+		},
+		{
+			name:   "does not deallocate a running vm to grow its OS disk in dry-run mode",
+			vmSpec: &growingVMSpec,
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder, mvm *mock_virtualmachines.MockClientMockRecorder) {
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &growingVMSpec, ServiceName).Return(nil, resizeRequiresDeallocation)
+				s.IsDryRun().Return(true)
+				s.RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason, "Skipped deallocating VM %s to grow its OS disk to %d GB in dry-run mode", "test-vm", int32(256))
+				s.UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, nil)
+				s.UpdatePutStatus(infrav1.DisksReadyCondition, ServiceName, nil)
+			},
+		},
+		{
+			name:          "waits for the deallocation of the vm",
+			vmSpec:        &deallocatingVMSpec,
+			expectedError: "operation type POST on Azure resource test-group/test-vm is not done. Object will be requeued after 15s",
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder, mvm *mock_virtualmachines.MockClientMockRecorder) {
+				s.GetLongRunningOperationState("test-vm", ServiceName, infrav1.PostFuture).Return(postFuture)
+				mvm.IsDone(gomockinternal.AContext(), gomock.AssignableToTypeOf(&azureautorest.Future{})).Return(false, nil)
+				s.UpdatePutStatus(infrav1.VMRunningCondition, ServiceName, gomock.Any())
+			},
+		},
+		{
+			name:          "grows the OS disk of the deallocated vm and starts it",
+			vmSpec:        &deallocatingVMSpec,
+			expectedError: "operation type POST on Azure resource test-group/test-vm is not done. Object will be requeued after 15s",
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder, mvm *mock_virtualmachines.MockClientMockRecorder) {
+				s.GetLongRunningOperationState("test-vm", ServiceName, infrav1.PostFuture).Return(postFuture)
+				mvm.IsDone(gomockinternal.AContext(), gomock.AssignableToTypeOf(&azureautorest.Future{})).Return(true, nil)
+				s.DeleteLongRunningOperationState("test-vm", ServiceName, infrav1.PostFuture)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &deallocatingVMSpec, ServiceName).Return(fakeExistingVM, nil)
+				expectVMSucceeded(s, mnic, mpip)
+				s.IsDryRun().Return(false)
+				s.SetAnnotation(azure.OSDiskResizeAnnotation, osDiskResizeStarting)
+				mvm.StartAsync(gomockinternal.AContext(), &deallocatingVMSpec).Return(&azureautorest.Future{}, nil)
+				s.SetLongRunningOperationState(gomock.AssignableToTypeOf(&infrav1.Future{}))
+			},
+		},
+		{
+			name:   "does not start the vm after growing its OS disk in dry-run mode",
+			vmSpec: &deallocatingVMSpec,
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder, mvm *mock_virtualmachines.MockClientMockRecorder) {
+				s.GetLongRunningOperationState("test-vm", ServiceName, infrav1.PostFuture).Return(nil)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &deallocatingVMSpec, ServiceName).Return(fakeExistingVM, nil)
+				expectVMSucceeded(s, mnic, mpip)
+				s.IsDryRun().Return(true)
+				s.RecordEvent(corev1.EventTypeNormal, infrav1.DryRunChangeReason, "Skipped starting VM %s after growing its OS disk in dry-run mode", "test-vm")
+			},
+		},
+		{
+			name:   "completes the resize once the vm is started",
+			vmSpec: &startingVMSpec,
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, mnic *mock_async.MockGetterMockRecorder, mpip *mock_async.MockGetterMockRecorder, r *mock_async.MockReconcilerMockRecorder, mvm *mock_virtualmachines.MockClientMockRecorder) {
+				s.GetLongRunningOperationState("test-vm", ServiceName, infrav1.PostFuture).Return(postFuture)
+				mvm.IsDone(gomockinternal.AContext(), gomock.AssignableToTypeOf(&azureautorest.Future{})).Return(true, nil)
+				s.DeleteLongRunningOperationState("test-vm", ServiceName, infrav1.PostFuture)
+				s.RemoveAnnotation(azure.OSDiskResizeAnnotation)
+				s.RecordEvent(corev1.EventTypeNormal, infrav1.VMOSDiskResizedReason, "Started VM %s after growing its OS disk to %d GB", "test-vm", int32(256))
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &growingVMSpec, ServiceName).Return(fakeExistingVM, nil)
+				expectVMSucceeded(s, mnic, mpip)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			scopeMock := mock_virtualmachines.NewMockVMScope(mockCtrl)
+			interfaceMock := mock_async.NewMockGetter(mockCtrl)
+			publicIPMock := mock_async.NewMockGetter(mockCtrl)
+			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+			vmMock := mock_virtualmachines.NewMockClient(mockCtrl)
+
+			// The specs are shared between test cases and the resize state is reset once the resize completes.
+			vmSpec := *tc.vmSpec
+			scopeMock.EXPECT().VMSpec().Return(&vmSpec)
+			tc.expect(scopeMock.EXPECT(), interfaceMock.EXPECT(), publicIPMock.EXPECT(), asyncMock.EXPECT(), vmMock.EXPECT())
+
+			s := &Service{
+				Scope:            scopeMock,
+				interfacesGetter: interfaceMock,
+				publicIPsGetter:  publicIPMock,
+				client:           vmMock,
+				Reconciler:       asyncMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
@@ -18,22 +18,27 @@ package virtualnetworks
 
 import (
 	"context"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
+	"github.com/pkg/errors"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 )
 
 // VNetSpec defines the specification for a Virtual Network.
 type VNetSpec struct {
-	ResourceGroup    string
-	Name             string
-	CIDRs            []string
-	Location         string
-	ExtendedLocation *infrav1.ExtendedLocationSpec
-	ClusterName      string
-	AdditionalTags   infrav1.Tags
+	ResourceGroup        string
+	Name                 string
+	CIDRs                []string
+	Location             string
+	ExtendedLocation     *infrav1.ExtendedLocationSpec
+	FlowTimeoutInMinutes *int32
+	ClusterName          string
+	AdditionalTags       infrav1.Tags
 }
 
 // ResourceName returns the name of the vnet.
@@ -54,16 +59,37 @@ func (s *VNetSpec) OwnerResourceName() string {
 // Parameters returns the parameters for the vnet.
 func (s *VNetSpec) Parameters(ctx context.Context, existing interface{}) (interface{}, error) {
 	if existing != nil {
-		// vnet already exists, nothing to update.
-		return nil, nil
+		existingVnet, ok := existing.(network.VirtualNetwork)
+		if !ok {
+			return nil, errors.Errorf("%T is not a network.VirtualNetwork", existing)
+		}
+		// The subnets, NICs, load balancers and public IPs of the cluster are created in the extended location of the
+		// vnet, so an existing vnet must be in the extended location of the cluster.
+		if existingName, name := extendedLocationName(existingVnet.ExtendedLocation), s.extendedLocationName(); !strings.EqualFold(existingName, name) {
+			return nil, azure.WithTerminalError(errors.Errorf("vnet %s is in extended location %q, but the cluster is in extended location %q",
+				s.Name, existingName, name))
+		}
+		// Only the flow timeout of a vnet managed by this cluster is updated in place.
+		if s.FlowTimeoutInMinutes == nil || !converters.MapToTags(existingVnet.Tags).HasOwned(s.ClusterName) {
+			return nil, nil
+		}
+		if existingVnet.VirtualNetworkPropertiesFormat == nil {
+			existingVnet.VirtualNetworkPropertiesFormat = &network.VirtualNetworkPropertiesFormat{}
+		}
+		if ptr.Equal(existingVnet.FlowTimeoutInMinutes, s.FlowTimeoutInMinutes) {
+			return nil, nil
+		}
+		// The existing vnet is updated as a whole so that its subnets are not removed.
+		existingVnet.FlowTimeoutInMinutes = s.FlowTimeoutInMinutes
+		return existingVnet, nil
 	}
 	return network.VirtualNetwork{
-		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+		Tags: converters.TagsToMap(tags.Merge(tags.MergeParams{
 			ClusterName: s.ClusterName,
 			Lifecycle:   infrav1.ResourceLifecycleOwned,
 			Name:        ptr.To(s.Name),
 			Role:        ptr.To(infrav1.CommonRole),
-			Additional:  s.AdditionalTags,
+			ClusterTags: s.AdditionalTags,
 		})),
 		Location:         ptr.To(s.Location),
 		ExtendedLocation: converters.ExtendedLocationToNetworkSDK(s.ExtendedLocation),
@@ -71,6 +97,23 @@ func (s *VNetSpec) Parameters(ctx context.Context, existing interface{}) (interf
 			AddressSpace: &network.AddressSpace{
 				AddressPrefixes: &s.CIDRs,
 			},
+			FlowTimeoutInMinutes: s.FlowTimeoutInMinutes,
 		},
 	}, nil
 }
+
+// extendedLocationName returns the name of the extended location of the vnet, if any.
+func (s *VNetSpec) extendedLocationName() string {
+	if s.ExtendedLocation == nil {
+		return ""
+	}
+	return s.ExtendedLocation.Name
+}
+
+// extendedLocationName returns the name of an extended location of the network SDK, if any.
+func extendedLocationName(extendedLocation *network.ExtendedLocation) string {
+	if extendedLocation == nil {
+		return ""
+	}
+	return ptr.Deref(extendedLocation.Name, "")
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualnetworks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-08-01/network"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+)
+
+var (
+	ownedTags = map[string]*string{
+		"sigs.k8s.io_cluster-api-provider-azure_cluster_test-cluster": ptr.To("owned"),
+		"sigs.k8s.io_cluster-api-provider-azure_role":                 ptr.To("common"),
+		"Name": ptr.To("test-vnet"),
+	}
+	existingSubnets = &[]network.Subnet{
+		{
+			Name: ptr.To("test-subnet"),
+			SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+				AddressPrefix: ptr.To("10.0.0.0/16"),
+			},
+		},
+	}
+)
+
+func TestParameters(t *testing.T) {
+	testcases := []struct {
+		name          string
+		spec          *VNetSpec
+		existing      interface{}
+		expect        func(g *WithT, result interface{})
+		expectedError string
+	}{
+		{
+			name: "new vnet with flow timeout",
+			spec: &VNetSpec{
+				ResourceGroup:        "test-group",
+				Name:                 "test-vnet",
+				CIDRs:                []string{"10.0.0.0/8"},
+				Location:             "test-location",
+				FlowTimeoutInMinutes: ptr.To[int32](10),
+				ClusterName:          "test-cluster",
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(Equal(network.VirtualNetwork{
+					Tags:     ownedTags,
+					Location: ptr.To("test-location"),
+					VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+						AddressSpace: &network.AddressSpace{
+							AddressPrefixes: &[]string{"10.0.0.0/8"},
+						},
+						FlowTimeoutInMinutes: ptr.To[int32](10),
+					},
+				}))
+			},
+		},
+		{
+			name: "new vnet without flow timeout",
+			spec: &VNetSpec{
+				ResourceGroup: "test-group",
+				Name:          "test-vnet",
+				CIDRs:         []string{"10.0.0.0/8"},
+				Location:      "test-location",
+				ClusterName:   "test-cluster",
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.VirtualNetwork{}))
+				g.Expect(result.(network.VirtualNetwork).FlowTimeoutInMinutes).To(BeNil())
+			},
+		},
+		{
+			name: "existing vnet without flow timeout in spec doesn't need an update",
+			spec: &VNetSpec{
+				ResourceGroup: "test-group",
+				Name:          "test-vnet",
+				ClusterName:   "test-cluster",
+			},
+			existing: network.VirtualNetwork{
+				Tags: ownedTags,
+				VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+					FlowTimeoutInMinutes: ptr.To[int32](10),
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name: "existing vnet with the expected flow timeout doesn't need an update",
+			spec: &VNetSpec{
+				ResourceGroup:        "test-group",
+				Name:                 "test-vnet",
+				FlowTimeoutInMinutes: ptr.To[int32](10),
+				ClusterName:          "test-cluster",
+			},
+			existing: network.VirtualNetwork{
+				Tags: ownedTags,
+				VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+					FlowTimeoutInMinutes: ptr.To[int32](10),
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name: "existing vnet with a different flow timeout is updated in place",
+			spec: &VNetSpec{
+				ResourceGroup:        "test-group",
+				Name:                 "test-vnet",
+				FlowTimeoutInMinutes: ptr.To[int32](20),
+				ClusterName:          "test-cluster",
+			},
+			existing: network.VirtualNetwork{
+				ID:       ptr.To("test-vnet-id"),
+				Tags:     ownedTags,
+				Location: ptr.To("test-location"),
+				VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+					AddressSpace: &network.AddressSpace{
+						AddressPrefixes: &[]string{"10.0.0.0/8"},
+					},
+					Subnets:              existingSubnets,
+					FlowTimeoutInMinutes: ptr.To[int32](10),
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(Equal(network.VirtualNetwork{
+					ID:       ptr.To("test-vnet-id"),
+					Tags:     ownedTags,
+					Location: ptr.To("test-location"),
+					VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+						AddressSpace: &network.AddressSpace{
+							AddressPrefixes: &[]string{"10.0.0.0/8"},
+						},
+						Subnets:              existingSubnets,
+						FlowTimeoutInMinutes: ptr.To[int32](20),
+					},
+				}))
+			},
+		},
+		{
+			name: "existing vnet not managed by the cluster is not updated",
+			spec: &VNetSpec{
+				ResourceGroup:        "test-group",
+				Name:                 "test-vnet",
+				FlowTimeoutInMinutes: ptr.To[int32](20),
+				ClusterName:          "test-cluster",
+			},
+			existing: network.VirtualNetwork{
+				VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+					FlowTimeoutInMinutes: ptr.To[int32](10),
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name: "new vnet in an edge zone",
+			spec: &VNetSpec{
+				ResourceGroup:    "test-group",
+				Name:             "test-vnet",
+				CIDRs:            []string{"10.0.0.0/8"},
+				Location:         "test-location",
+				ExtendedLocation: &infrav1.ExtendedLocationSpec{Name: "test-edge-zone", Type: "EdgeZone"},
+				ClusterName:      "test-cluster",
+			},
+			existing: nil,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeAssignableToTypeOf(network.VirtualNetwork{}))
+				g.Expect(result.(network.VirtualNetwork).ExtendedLocation).To(Equal(&network.ExtendedLocation{
+					Name: ptr.To("test-edge-zone"),
+					Type: network.ExtendedLocationTypesEdgeZone,
+				}))
+			},
+		},
+		{
+			name: "existing vnet in the edge zone of the cluster doesn't need an update",
+			spec: &VNetSpec{
+				ResourceGroup:    "test-group",
+				Name:             "test-vnet",
+				ExtendedLocation: &infrav1.ExtendedLocationSpec{Name: "test-edge-zone", Type: "EdgeZone"},
+				ClusterName:      "test-cluster",
+			},
+			existing: network.VirtualNetwork{
+				Tags: ownedTags,
+				ExtendedLocation: &network.ExtendedLocation{
+					Name: ptr.To("Test-Edge-Zone"),
+					Type: network.ExtendedLocationTypesEdgeZone,
+				},
+			},
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name: "existing vnet in another edge zone than the cluster",
+			spec: &VNetSpec{
+				ResourceGroup:    "test-group",
+				Name:             "test-vnet",
+				ExtendedLocation: &infrav1.ExtendedLocationSpec{Name: "test-edge-zone", Type: "EdgeZone"},
+				ClusterName:      "test-cluster",
+			},
+			existing: network.VirtualNetwork{
+				Tags: ownedTags,
+				ExtendedLocation: &network.ExtendedLocation{
+					Name: ptr.To("other-edge-zone"),
+					Type: network.ExtendedLocationTypesEdgeZone,
+				},
+			},
+			expectedError: `reconcile error that cannot be recovered occurred: vnet test-vnet is in extended location "other-edge-zone", but the cluster is in extended location "test-edge-zone". Object will not be requeued`,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name: "existing vnet outside of the edge zone of the cluster",
+			spec: &VNetSpec{
+				ResourceGroup:    "test-group",
+				Name:             "test-vnet",
+				ExtendedLocation: &infrav1.ExtendedLocationSpec{Name: "test-edge-zone", Type: "EdgeZone"},
+				ClusterName:      "test-cluster",
+			},
+			existing: network.VirtualNetwork{
+				Tags: ownedTags,
+			},
+			expectedError: `reconcile error that cannot be recovered occurred: vnet test-vnet is in extended location "", but the cluster is in extended location "test-edge-zone". Object will not be requeued`,
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+		{
+			name: "existing is not a virtual network",
+			spec: &VNetSpec{
+				Name:        "test-vnet",
+				ClusterName: "test-cluster",
+			},
+			existing:      "not a vnet",
+			expectedError: "string is not a network.VirtualNetwork",
+			expect: func(g *WithT, result interface{}) {
+				g.Expect(result).To(BeNil())
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+
+			result, err := tc.spec.Parameters(context.TODO(), tc.existing)
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			tc.expect(g, result)
+		})
+	}
+}
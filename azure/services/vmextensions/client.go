@@ -56,6 +56,14 @@ func (ac *azureClient) Get(ctx context.Context, spec azure.ResourceSpecGetter) (
 	return ac.vmextensions.Get(ctx, spec.ResourceGroupName(), spec.OwnerResourceName(), spec.ResourceName(), "")
 }
 
+// GetWithInstanceView gets the specified virtual machine extension along with its instance view.
+func (ac *azureClient) GetWithInstanceView(ctx context.Context, spec azure.ResourceSpecGetter) (compute.VirtualMachineExtension, error) {
+	ctx, _, done := tele.StartSpanWithLogger(ctx, "vmextensions.AzureClient.GetWithInstanceView")
+	defer done()
+
+	return ac.vmextensions.Get(ctx, spec.ResourceGroupName(), spec.OwnerResourceName(), spec.ResourceName(), "instanceView")
+}
+
 // CreateOrUpdateAsync creates or updates a VM extension asynchronously.
 // It sends a PUT request to Azure and if accepted without error, the func will return a Future which can be used to track the ongoing
 // progress of the operation.
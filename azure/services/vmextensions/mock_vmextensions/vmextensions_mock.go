@@ -21,8 +21,10 @@ limitations under the License.
 package mock_vmextensions
 
 import (
+	context "context"
 	reflect "reflect"
 
+	compute "github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
 	autorest "github.com/Azure/go-autorest/autorest"
 	gomock "go.uber.org/mock/gomock"
 	v1beta1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
@@ -163,6 +165,30 @@ func (mr *MockVMExtensionScopeMockRecorder) HashKey() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HashKey", reflect.TypeOf((*MockVMExtensionScope)(nil).HashKey))
 }
 
+// SetConditionFalse mocks base method.
+func (m *MockVMExtensionScope) SetConditionFalse(arg0 v1beta10.ConditionType, arg1 string, arg2 v1beta10.ConditionSeverity, arg3 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetConditionFalse", arg0, arg1, arg2, arg3)
+}
+
+// SetConditionFalse indicates an expected call of SetConditionFalse.
+func (mr *MockVMExtensionScopeMockRecorder) SetConditionFalse(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConditionFalse", reflect.TypeOf((*MockVMExtensionScope)(nil).SetConditionFalse), arg0, arg1, arg2, arg3)
+}
+
+// SetConditionTrue mocks base method.
+func (m *MockVMExtensionScope) SetConditionTrue(arg0 v1beta10.ConditionType) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetConditionTrue", arg0)
+}
+
+// SetConditionTrue indicates an expected call of SetConditionTrue.
+func (mr *MockVMExtensionScopeMockRecorder) SetConditionTrue(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConditionTrue", reflect.TypeOf((*MockVMExtensionScope)(nil).SetConditionTrue), arg0)
+}
+
 // SetLongRunningOperationState mocks base method.
 func (m *MockVMExtensionScope) SetLongRunningOperationState(arg0 *v1beta1.Future) {
 	m.ctrl.T.Helper()
@@ -252,3 +278,41 @@ func (mr *MockVMExtensionScopeMockRecorder) VMExtensionSpecs() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VMExtensionSpecs", reflect.TypeOf((*MockVMExtensionScope)(nil).VMExtensionSpecs))
 }
+
+// MockinstanceViewGetter is a mock of instanceViewGetter interface.
+type MockinstanceViewGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockinstanceViewGetterMockRecorder
+}
+
+// MockinstanceViewGetterMockRecorder is the mock recorder for MockinstanceViewGetter.
+type MockinstanceViewGetterMockRecorder struct {
+	mock *MockinstanceViewGetter
+}
+
+// NewMockinstanceViewGetter creates a new mock instance.
+func NewMockinstanceViewGetter(ctrl *gomock.Controller) *MockinstanceViewGetter {
+	mock := &MockinstanceViewGetter{ctrl: ctrl}
+	mock.recorder = &MockinstanceViewGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockinstanceViewGetter) EXPECT() *MockinstanceViewGetterMockRecorder {
+	return m.recorder
+}
+
+// GetWithInstanceView mocks base method.
+func (m *MockinstanceViewGetter) GetWithInstanceView(ctx context.Context, spec azure.ResourceSpecGetter) (compute.VirtualMachineExtension, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithInstanceView", ctx, spec)
+	ret0, _ := ret[0].(compute.VirtualMachineExtension)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWithInstanceView indicates an expected call of GetWithInstanceView.
+func (mr *MockinstanceViewGetterMockRecorder) GetWithInstanceView(ctx, spec interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithInstanceView", reflect.TypeOf((*MockinstanceViewGetter)(nil).GetWithInstanceView), ctx, spec)
+}
@@ -18,36 +18,56 @@ package vmextensions
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
 	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async"
 	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
-const serviceName = "vmextensions"
+const (
+	serviceName = "vmextensions"
+
+	// maxStatusMessageLength is the maximum length of each instance view status message included in an extension
+	// condition. Longer messages, e.g. the standard error of a script, are truncated to their end.
+	maxStatusMessageLength = 512
+)
 
 // VMExtensionScope defines the scope interface for a vm extension service.
 type VMExtensionScope interface {
 	azure.Authorizer
 	azure.AsyncStatusUpdater
 	VMExtensionSpecs() []azure.ResourceSpecGetter
+	SetConditionTrue(clusterv1.ConditionType)
+	SetConditionFalse(clusterv1.ConditionType, string, clusterv1.ConditionSeverity, string)
+}
+
+// instanceViewGetter gets a VM extension along with its instance view.
+type instanceViewGetter interface {
+	GetWithInstanceView(ctx context.Context, spec azure.ResourceSpecGetter) (compute.VirtualMachineExtension, error)
 }
 
 // Service provides operations on Azure resources.
 type Service struct {
 	Scope VMExtensionScope
 	async.Reconciler
+	instanceViewGetter instanceViewGetter
 }
 
 // New creates a new vm extension service.
 func New(scope VMExtensionScope) *Service {
 	client := newClient(scope)
 	return &Service{
-		Scope:      scope,
-		Reconciler: async.New(scope, client, client),
+		Scope:              scope,
+		Reconciler:         async.New(scope, client, client),
+		instanceViewGetter: client,
 	}
 }
 
@@ -75,6 +95,7 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	var resultErr error
 	for _, extensionSpec := range specs {
 		_, err := s.CreateOrUpdateResource(ctx, extensionSpec, serviceName)
+		s.updateExtensionCondition(ctx, extensionSpec, err)
 		if err != nil {
 			if !azure.IsOperationNotDoneError(err) || resultErr == nil {
 				resultErr = err
@@ -92,6 +113,67 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	return resultErr
 }
 
+// updateExtensionCondition sets the Extension/<name>Ready condition reporting the result of the provisioning of a
+// VM extension. When the extension failed, the condition message includes the error statuses and the standard error
+// found in the instance view of the extension.
+func (s *Service) updateExtensionCondition(ctx context.Context, spec azure.ResourceSpecGetter, err error) {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "vmextensions.Service.updateExtensionCondition")
+	defer done()
+
+	condition := ExtensionReadyCondition(spec.ResourceName())
+	switch {
+	case err == nil:
+		s.Scope.SetConditionTrue(condition)
+		return
+	case azure.IsOperationNotDoneError(err):
+		s.Scope.SetConditionFalse(condition, infrav1.ExtensionProvisioningReason, clusterv1.ConditionSeverityInfo,
+			fmt.Sprintf("extension %s is provisioning", spec.ResourceName()))
+		return
+	}
+
+	message := fmt.Sprintf("extension %s failed: %s", spec.ResourceName(), err.Error())
+	extension, getErr := s.instanceViewGetter.GetWithInstanceView(ctx, spec)
+	if getErr != nil {
+		log.V(2).Info("failed to get the instance view of the extension", "extension", spec.ResourceName(), "error", getErr.Error())
+	} else if extension.VirtualMachineExtensionProperties != nil {
+		message = fmt.Sprintf("extension %s provisioning state is %s", spec.ResourceName(), ptr.Deref(extension.ProvisioningState, "unknown"))
+		if details := instanceViewDetails(extension.InstanceView); details != "" {
+			message += ": " + details
+		}
+	}
+	s.Scope.SetConditionFalse(condition, infrav1.ExtensionFailedReason, clusterv1.ConditionSeverityError, message)
+}
+
+// ExtensionReadyCondition returns the type of the condition reporting the status of a VM extension.
+func ExtensionReadyCondition(extensionName string) clusterv1.ConditionType {
+	return clusterv1.ConditionType(infrav1.ExtensionConditionPrefix + extensionName + "Ready")
+}
+
+// instanceViewDetails returns the messages of the error statuses and substatuses of an extension instance view, along
+// with the standard error reported by script extensions.
+func instanceViewDetails(instanceView *compute.VirtualMachineExtensionInstanceView) string {
+	if instanceView == nil {
+		return ""
+	}
+	var details []string
+	for _, statuses := range []*[]compute.InstanceViewStatus{instanceView.Statuses, instanceView.Substatuses} {
+		if statuses == nil {
+			continue
+		}
+		for _, status := range *statuses {
+			code, message := ptr.Deref(status.Code, ""), strings.TrimSpace(ptr.Deref(status.Message, ""))
+			if message == "" || (status.Level != compute.StatusLevelTypesError && !strings.Contains(code, "StdErr")) {
+				continue
+			}
+			if len(message) > maxStatusMessageLength {
+				message = "..." + message[len(message)-maxStatusMessageLength:]
+			}
+			details = append(details, fmt.Sprintf("%s: %s", code, message))
+		}
+	}
+	return strings.Join(details, "; ")
+}
+
 // Delete is a no-op. VM Extensions will be deleted as part of VM deletion.
 func (s *Service) Delete(_ context.Context) error {
 	return nil
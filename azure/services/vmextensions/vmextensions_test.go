@@ -19,17 +19,21 @@ package vmextensions
 import (
 	"context"
 	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
 	"github.com/Azure/go-autorest/autorest"
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
 	"go.uber.org/mock/gomock"
+	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/async/mock_async"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/vmextensions/mock_vmextensions"
 	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
 var (
@@ -60,58 +64,94 @@ var (
 
 	notDoneError          = azure.NewOperationNotDoneError(&infrav1.Future{})
 	extensionNotDoneError = errors.Wrapf(notDoneError, "extension is still in provisioning state. This likely means that bootstrapping has not yet completed on the VM")
+
+	failedExtension = compute.VirtualMachineExtension{
+		VirtualMachineExtensionProperties: &compute.VirtualMachineExtensionProperties{
+			ProvisioningState: ptr.To("Failed"),
+			InstanceView: &compute.VirtualMachineExtensionInstanceView{
+				Statuses: &[]compute.InstanceViewStatus{
+					{
+						Code:    ptr.To("ProvisioningState/failed/1"),
+						Level:   compute.StatusLevelTypesError,
+						Message: ptr.To("Enable failed: failed to execute command: command terminated with exit status=1"),
+					},
+				},
+				Substatuses: &[]compute.InstanceViewStatus{
+					{
+						Code:    ptr.To("ComponentStatus/StdOut/succeeded"),
+						Level:   compute.StatusLevelTypesInfo,
+						Message: ptr.To("bootstrapping node"),
+					},
+					{
+						Code:    ptr.To("ComponentStatus/StdErr/succeeded"),
+						Level:   compute.StatusLevelTypesInfo,
+						Message: ptr.To("kubeadm join: error execution phase preflight: couldn't validate the identity of the API Server"),
+					},
+				},
+			},
+		},
+	}
 )
 
 func TestReconcileVMExtension(t *testing.T) {
 	testcases := []struct {
 		name          string
 		expectedError string
-		expect        func(s *mock_vmextensions.MockVMExtensionScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder)
+		expect        func(s *mock_vmextensions.MockVMExtensionScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_vmextensions.MockinstanceViewGetterMockRecorder)
 	}{
 		{
 			name:          "extension is in succeeded state",
 			expectedError: "",
-			expect: func(s *mock_vmextensions.MockVMExtensionScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_vmextensions.MockVMExtensionScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_vmextensions.MockinstanceViewGetterMockRecorder) {
 				s.VMExtensionSpecs().Return([]azure.ResourceSpecGetter{&extensionSpec1})
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &extensionSpec1, serviceName).Return(nil, nil)
+				s.SetConditionTrue(ExtensionReadyCondition(extensionSpec1.Name))
 				s.UpdatePutStatus(infrav1.BootstrapSucceededCondition, serviceName, nil)
 			},
 		},
 		{
 			name:          "extension is in failed state",
 			expectedError: extensionFailedError.Error(),
-			expect: func(s *mock_vmextensions.MockVMExtensionScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_vmextensions.MockVMExtensionScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_vmextensions.MockinstanceViewGetterMockRecorder) {
 				s.VMExtensionSpecs().Return([]azure.ResourceSpecGetter{&extensionSpec1})
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &extensionSpec1, serviceName).Return(nil, internalError)
+				v.GetWithInstanceView(gomockinternal.AContext(), &extensionSpec1).Return(compute.VirtualMachineExtension{}, internalError)
+				s.SetConditionFalse(ExtensionReadyCondition(extensionSpec1.Name), infrav1.ExtensionFailedReason, clusterv1.ConditionSeverityError, "extension my-extension-1 failed: "+internalError.Error())
 				s.UpdatePutStatus(infrav1.BootstrapSucceededCondition, serviceName, gomockinternal.ErrStrEq(extensionFailedError.Error()))
 			},
 		},
 		{
 			name:          "extension is still creating",
 			expectedError: extensionNotDoneError.Error(),
-			expect: func(s *mock_vmextensions.MockVMExtensionScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_vmextensions.MockVMExtensionScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_vmextensions.MockinstanceViewGetterMockRecorder) {
 				s.VMExtensionSpecs().Return([]azure.ResourceSpecGetter{&extensionSpec1})
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &extensionSpec1, serviceName).Return(nil, notDoneError)
+				s.SetConditionFalse(ExtensionReadyCondition(extensionSpec1.Name), infrav1.ExtensionProvisioningReason, clusterv1.ConditionSeverityInfo, "extension my-extension-1 is provisioning")
 				s.UpdatePutStatus(infrav1.BootstrapSucceededCondition, serviceName, gomockinternal.ErrStrEq(extensionNotDoneError.Error()))
 			},
 		},
 		{
 			name:          "reconcile multiple extensions",
 			expectedError: "",
-			expect: func(s *mock_vmextensions.MockVMExtensionScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_vmextensions.MockVMExtensionScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_vmextensions.MockinstanceViewGetterMockRecorder) {
 				s.VMExtensionSpecs().Return([]azure.ResourceSpecGetter{&extensionSpec1, &extensionSpec2})
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &extensionSpec1, serviceName).Return(nil, nil)
+				s.SetConditionTrue(ExtensionReadyCondition(extensionSpec1.Name))
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &extensionSpec2, serviceName).Return(nil, nil)
+				s.SetConditionTrue(ExtensionReadyCondition(extensionSpec2.Name))
 				s.UpdatePutStatus(infrav1.BootstrapSucceededCondition, serviceName, nil)
 			},
 		},
 		{
 			name:          "error creating the first extension",
 			expectedError: extensionFailedError.Error(),
-			expect: func(s *mock_vmextensions.MockVMExtensionScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+			expect: func(s *mock_vmextensions.MockVMExtensionScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder, v *mock_vmextensions.MockinstanceViewGetterMockRecorder) {
 				s.VMExtensionSpecs().Return([]azure.ResourceSpecGetter{&extensionSpec1, &extensionSpec2})
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &extensionSpec1, serviceName).Return(nil, internalError)
+				v.GetWithInstanceView(gomockinternal.AContext(), &extensionSpec1).Return(failedExtension, nil)
+				s.SetConditionFalse(ExtensionReadyCondition(extensionSpec1.Name), infrav1.ExtensionFailedReason, clusterv1.ConditionSeverityError, gomock.Any())
 				r.CreateOrUpdateResource(gomockinternal.AContext(), &extensionSpec2, serviceName).Return(nil, nil)
+				s.SetConditionTrue(ExtensionReadyCondition(extensionSpec2.Name))
 				s.UpdatePutStatus(infrav1.BootstrapSucceededCondition, serviceName, gomockinternal.ErrStrEq(extensionFailedError.Error()))
 			},
 		},
@@ -126,12 +166,14 @@ func TestReconcileVMExtension(t *testing.T) {
 			defer mockCtrl.Finish()
 			scopeMock := mock_vmextensions.NewMockVMExtensionScope(mockCtrl)
 			asyncMock := mock_async.NewMockReconciler(mockCtrl)
+			instanceViewMock := mock_vmextensions.NewMockinstanceViewGetter(mockCtrl)
 
-			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT())
+			tc.expect(scopeMock.EXPECT(), asyncMock.EXPECT(), instanceViewMock.EXPECT())
 
 			s := &Service{
-				Scope:      scopeMock,
-				Reconciler: asyncMock,
+				Scope:              scopeMock,
+				Reconciler:         asyncMock,
+				instanceViewGetter: instanceViewMock,
 			}
 
 			err := s.Reconcile(context.TODO())
@@ -144,3 +186,48 @@ func TestReconcileVMExtension(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileVMExtensionFailedCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scopeMock := mock_vmextensions.NewMockVMExtensionScope(mockCtrl)
+	asyncMock := mock_async.NewMockReconciler(mockCtrl)
+	instanceViewMock := mock_vmextensions.NewMockinstanceViewGetter(mockCtrl)
+
+	var message string
+	scopeMock.EXPECT().VMExtensionSpecs().Return([]azure.ResourceSpecGetter{&extensionSpec1})
+	asyncMock.EXPECT().CreateOrUpdateResource(gomockinternal.AContext(), &extensionSpec1, serviceName).Return(nil, internalError)
+	instanceViewMock.EXPECT().GetWithInstanceView(gomockinternal.AContext(), &extensionSpec1).Return(failedExtension, nil)
+	scopeMock.EXPECT().SetConditionFalse(clusterv1.ConditionType("Extension/my-extension-1Ready"), infrav1.ExtensionFailedReason, clusterv1.ConditionSeverityError, gomock.Any()).
+		Do(func(_ clusterv1.ConditionType, _ string, _ clusterv1.ConditionSeverity, msg string) { message = msg })
+	scopeMock.EXPECT().UpdatePutStatus(infrav1.BootstrapSucceededCondition, serviceName, gomockinternal.ErrStrEq(extensionFailedError.Error()))
+
+	s := &Service{
+		Scope:              scopeMock,
+		Reconciler:         asyncMock,
+		instanceViewGetter: instanceViewMock,
+	}
+
+	err := s.Reconcile(context.TODO())
+	g.Expect(err).To(MatchError(extensionFailedError.Error()))
+	g.Expect(message).To(HavePrefix("extension my-extension-1 provisioning state is Failed"))
+	g.Expect(message).To(ContainSubstring("command terminated with exit status=1"))
+	g.Expect(message).To(ContainSubstring("couldn't validate the identity of the API Server"))
+	g.Expect(message).NotTo(ContainSubstring("bootstrapping node"))
+}
+
+func TestInstanceViewDetailsTruncatesLongMessages(t *testing.T) {
+	g := NewWithT(t)
+
+	stderr := strings.Repeat("a", 2*maxStatusMessageLength) + "the actual error"
+	details := instanceViewDetails(&compute.VirtualMachineExtensionInstanceView{
+		Substatuses: &[]compute.InstanceViewStatus{
+			{Code: ptr.To("ComponentStatus/StdErr/succeeded"), Message: ptr.To(stderr)},
+		},
+	})
+	g.Expect(details).To(HaveSuffix("the actual error"))
+	g.Expect(len(details)).To(BeNumerically("<", maxStatusMessageLength+len("ComponentStatus/StdErr/succeeded: ...")+1))
+	g.Expect(instanceViewDetails(nil)).To(BeEmpty())
+}
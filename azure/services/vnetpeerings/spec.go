@@ -37,6 +37,9 @@ type VnetPeeringSpec struct {
 	AllowGatewayTransit       *bool
 	AllowVirtualNetworkAccess *bool
 	UseRemoteGateways         *bool
+	// Reverse is true for the peering from the remote virtual network to the cluster's virtual network, which is
+	// skipped when the cluster identity is not authorized on the remote virtual network.
+	Reverse bool
 }
 
 // ResourceName returns the name of the virtual network peering.
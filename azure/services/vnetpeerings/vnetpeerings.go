@@ -58,7 +58,7 @@ func (s *Service) Name() string {
 
 // Reconcile idempotently creates or updates a peering.
 func (s *Service) Reconcile(ctx context.Context) error {
-	ctx, _, done := tele.StartSpanWithLogger(ctx, "vnetpeerings.Service.Reconcile")
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "vnetpeerings.Service.Reconcile")
 	defer done()
 
 	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureServiceReconcileTimeout)
@@ -75,6 +75,10 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	var result error
 	for _, peeringSpec := range specs {
 		if _, err := s.CreateOrUpdateResource(ctx, peeringSpec, ServiceName); err != nil {
+			if isForbiddenReversePeering(peeringSpec, err) {
+				log.V(2).Info("not authorized to create the reverse peering, peering is single-direction", "peering", peeringSpec.ResourceName())
+				continue
+			}
 			if !azure.IsOperationNotDoneError(err) || result == nil {
 				result = err
 			}
@@ -87,7 +91,7 @@ func (s *Service) Reconcile(ctx context.Context) error {
 
 // Delete deletes the peering with the provided name.
 func (s *Service) Delete(ctx context.Context) error {
-	ctx, _, done := tele.StartSpanWithLogger(ctx, "vnetpeerings.Service.Delete")
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "vnetpeerings.Service.Delete")
 	defer done()
 
 	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultAzureServiceReconcileTimeout)
@@ -104,6 +108,10 @@ func (s *Service) Delete(ctx context.Context) error {
 	var result error
 	for _, peeringSpec := range specs {
 		if err := s.DeleteResource(ctx, peeringSpec, ServiceName); err != nil {
+			if isForbiddenReversePeering(peeringSpec, err) {
+				log.V(2).Info("not authorized to delete the reverse peering, skipping", "peering", peeringSpec.ResourceName())
+				continue
+			}
 			if !azure.IsOperationNotDoneError(err) || result == nil {
 				result = err
 			}
@@ -113,6 +121,13 @@ func (s *Service) Delete(ctx context.Context) error {
 	return result
 }
 
+// isForbiddenReversePeering returns true if the error is caused by the cluster identity not being authorized on the
+// remote virtual network of a reverse peering, in which case the peering is single-direction.
+func isForbiddenReversePeering(spec azure.ResourceSpecGetter, err error) bool {
+	peeringSpec, ok := spec.(*VnetPeeringSpec)
+	return ok && peeringSpec.Reverse && azure.ResourceForbidden(err)
+}
+
 // IsManaged returns always returns true as CAPZ does not support BYO VNet peering.
 func (s *Service) IsManaged(ctx context.Context) (bool, error) {
 	return true, nil
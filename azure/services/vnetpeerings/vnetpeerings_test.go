@@ -98,9 +98,19 @@ var (
 		RemoteResourceGroup: "group4",
 		SubscriptionID:      "sub1",
 	}
+	fakeReversePeering2To1 = VnetPeeringSpec{
+		PeeringName:         "vnet2-to-vnet1",
+		SourceVnetName:      "vnet2",
+		SourceResourceGroup: "group2",
+		RemoteVnetName:      "vnet1",
+		RemoteResourceGroup: "group1",
+		SubscriptionID:      "sub1",
+		Reverse:             true,
+	}
 	fakePeeringSpecs      = []azure.ResourceSpecGetter{&fakePeering1To2, &fakePeering2To1, &fakePeering1To3, &fakePeering3To1, &fakePeeringHubToSpoke, &fakePeeringSpokeToHub}
 	fakePeeringExtraSpecs = []azure.ResourceSpecGetter{&fakePeering1To2, &fakePeering2To1, &fakePeeringExtra}
 	internalError         = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+	forbiddenError        = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusForbidden}, "Forbidden")
 	notDoneError          = azure.NewOperationNotDoneError(&infrav1.Future{})
 )
 
@@ -175,6 +185,26 @@ func TestReconcileVnetPeerings(t *testing.T) {
 				p.UpdatePutStatus(infrav1.VnetPeeringReadyCondition, ServiceName, internalError)
 			},
 		},
+		{
+			name:          "forbidden reverse peering is skipped",
+			expectedError: "",
+			expect: func(p *mock_vnetpeerings.MockVnetPeeringScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				p.VnetPeeringSpecs().Return([]azure.ResourceSpecGetter{&fakePeering1To2, &fakeReversePeering2To1})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakePeering1To2, ServiceName).Return(&fakePeering1To2, nil)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeReversePeering2To1, ServiceName).Return(nil, forbiddenError)
+				p.UpdatePutStatus(infrav1.VnetPeeringReadyCondition, ServiceName, nil)
+			},
+		},
+		{
+			name:          "forbidden forward peering is an error",
+			expectedError: "#: Forbidden: StatusCode=403",
+			expect: func(p *mock_vnetpeerings.MockVnetPeeringScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				p.VnetPeeringSpecs().Return([]azure.ResourceSpecGetter{&fakePeering1To2, &fakeReversePeering2To1})
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakePeering1To2, ServiceName).Return(nil, forbiddenError)
+				r.CreateOrUpdateResource(gomockinternal.AContext(), &fakeReversePeering2To1, ServiceName).Return(&fakeReversePeering2To1, nil)
+				p.UpdatePutStatus(infrav1.VnetPeeringReadyCondition, ServiceName, forbiddenError)
+			},
+		},
 		{
 			name:          "not done error in creating is ignored",
 			expectedError: "#: Internal Server Error: StatusCode=500",
@@ -319,6 +349,16 @@ func TestDeleteVnetPeerings(t *testing.T) {
 				p.UpdateDeleteStatus(infrav1.VnetPeeringReadyCondition, ServiceName, internalError)
 			},
 		},
+		{
+			name:          "forbidden reverse peering deletion is skipped",
+			expectedError: "",
+			expect: func(p *mock_vnetpeerings.MockVnetPeeringScopeMockRecorder, r *mock_async.MockReconcilerMockRecorder) {
+				p.VnetPeeringSpecs().Return([]azure.ResourceSpecGetter{&fakePeering1To2, &fakeReversePeering2To1})
+				r.DeleteResource(gomockinternal.AContext(), &fakePeering1To2, ServiceName).Return(nil)
+				r.DeleteResource(gomockinternal.AContext(), &fakeReversePeering2To1, ServiceName).Return(forbiddenError)
+				p.UpdateDeleteStatus(infrav1.VnetPeeringReadyCondition, ServiceName, nil)
+			},
+		},
 		{
 			name:          "not done error in deleting is ignored",
 			expectedError: "#: Internal Server Error: StatusCode=500",
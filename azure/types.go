@@ -17,6 +17,7 @@ limitations under the License.
 package azure
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 
@@ -43,6 +44,9 @@ const (
 
 	// VirtualMachineScaleSet ...
 	VirtualMachineScaleSet = "VirtualMachineScaleSet"
+
+	// ManagedCluster ...
+	ManagedCluster = "ManagedCluster"
 )
 
 // ScaleSetSpec defines the specification for a Scale Set.
@@ -83,6 +87,20 @@ type TagsSpec struct {
 	Annotation string
 }
 
+// MarketplaceAgreementSpec defines the specification for the marketplace agreement of the purchase plan of a VM image.
+type MarketplaceAgreementSpec struct {
+	Publisher string
+	Offer     string
+	Plan      string
+	// AcceptTerms accepts the terms of the agreement if they are not accepted yet.
+	AcceptTerms bool
+}
+
+// PlanID returns the publisher, offer and name of the purchase plan of the agreement.
+func (s *MarketplaceAgreementSpec) PlanID() string {
+	return fmt.Sprintf("%s/%s/%s", s.Publisher, s.Offer, s.Plan)
+}
+
 // ExtensionSpec defines the specification for a VM or VMSS extension.
 type ExtensionSpec struct {
 	Name              string
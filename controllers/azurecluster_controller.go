@@ -156,6 +156,7 @@ func (acr *AzureClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		Client:       acr.Client,
 		Cluster:      cluster,
 		AzureCluster: azureCluster,
+		Recorder:     acr.Recorder,
 	})
 	if err != nil {
 		err = errors.Wrap(err, "failed to create scope")
@@ -250,6 +251,12 @@ func (acr *AzureClusterReconciler) reconcileNormal(ctx context.Context, clusterS
 		azureCluster.Spec.ControlPlaneEndpoint.Port = clusterScope.APIServerPort()
 	}
 
+	if clusterScope.IsDryRun() {
+		// The changes were only reported, so the infrastructure of the cluster may not exist yet.
+		log.V(2).Info("not marking the AzureCluster ready in dry-run mode")
+		return reconcile.Result{}, nil
+	}
+
 	// No errors, so mark us ready so the Cluster API Cluster Controller can pull it
 	azureCluster.Status.Ready = true
 	conditions.MarkTrue(azureCluster, infrav1.NetworkInfrastructureReadyCondition)
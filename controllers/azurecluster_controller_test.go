@@ -32,6 +32,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 )
 
 var _ = Describe("AzureClusterReconciler", func() {
@@ -121,3 +122,15 @@ func TestAzureClusterReconcilePaused(t *testing.T) {
 
 	g.Eventually(recorder.Events).Should(Receive(Equal("Normal ClusterPaused AzureCluster or linked Cluster is marked as paused. Won't reconcile normally")))
 }
+
+func TestAzureClusterReconcilerSetupWithManager(t *testing.T) {
+	g := NewWithT(t)
+	mgr := newControllerRecordingManager(g)
+
+	// The --azurecluster-concurrency flag is passed to the controller through its options.
+	reconciler := NewAzureClusterReconciler(mgr.GetClient(), record.NewFakeRecorder(10), reconciler.DefaultLoopTimeout, "")
+	g.Expect(reconciler.SetupWithManager(context.Background(), mgr, Options{Options: controller.Options{MaxConcurrentReconciles: 7}})).To(Succeed())
+
+	g.Expect(mgr.controllers).To(HaveLen(1))
+	g.Expect(maxConcurrentReconciles(g, mgr.controllers[0], "azurecluster")).To(Equal(7))
+}
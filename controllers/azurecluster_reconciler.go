@@ -20,24 +20,30 @@ import (
 	"context"
 
 	"github.com/pkg/errors"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/bastionhosts"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/groups"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/loadbalancers"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/natgateways"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/orphanedresources"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/privatedns"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/privateendpoints"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicipprefixes"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicips"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourcelocks"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/routetables"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/securitygroups"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/subnets"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/trafficmanagerprofiles"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualnetworks"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/vnetpeerings"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 )
 
 // azureClusterService is the reconciler called by the AzureCluster controller.
@@ -59,18 +65,23 @@ func newAzureClusterService(scope *scope.ClusterScope) (*azureClusterService, er
 		scope: scope,
 		services: []azure.ServiceReconciler{
 			groups.New(scope),
+			// The orphaned resources are deleted after all the other resources, when the resource group isn't deleted.
+			orphanedresources.New(scope),
 			virtualnetworks.New(scope),
 			securitygroups.New(scope),
 			routetables.New(scope),
+			publicipprefixes.New(scope),
 			publicips.New(scope),
 			natgateways.New(scope),
 			subnets.New(scope),
 			vnetpeerings.New(scope),
 			loadbalancers.New(scope),
+			trafficmanagerprofiles.New(scope),
 			privatedns.New(scope),
 			bastionhosts.New(scope),
 			privateendpoints.New(scope),
 			tags.New(scope),
+			resourcelocks.New(scope),
 		},
 		skuCache: skuCache,
 	}, nil
@@ -89,6 +100,10 @@ func (s *azureClusterService) Reconcile(ctx context.Context) error {
 	s.scope.SetDNSName()
 	s.scope.SetControlPlaneSecurityRules()
 
+	if err := s.validateEgress(); err != nil {
+		return err
+	}
+
 	for _, service := range s.services {
 		if err := service.Reconcile(ctx); err != nil {
 			return errors.Wrapf(err, "failed to reconcile AzureCluster service %s", service.Name())
@@ -98,13 +113,28 @@ func (s *azureClusterService) Reconcile(ctx context.Context) error {
 	return nil
 }
 
+// validateEgress runs the egress pre-flight validation of the cluster, if enabled, and reflects its result in the
+// EgressValidated condition. A failed validation is terminal until the AzureCluster is updated.
+func (s *azureClusterService) validateEgress() error {
+	if s.scope.AzureCluster.Spec.NetworkSpec.EgressValidation == nil {
+		conditions.Delete(s.scope.AzureCluster, infrav1.EgressValidatedCondition)
+		return nil
+	}
+	if err := s.scope.ValidateEgress(); err != nil {
+		conditions.MarkFalse(s.scope.AzureCluster, infrav1.EgressValidatedCondition, infrav1.EgressBlockedReason, clusterv1.ConditionSeverityError, "%s", err.Error())
+		return azure.WithTerminalError(errors.Wrap(err, "egress pre-flight validation failed"))
+	}
+	conditions.MarkTrue(s.scope.AzureCluster, infrav1.EgressValidatedCondition)
+	return nil
+}
+
 // Pause pauses all components making up the cluster.
 func (s *azureClusterService) Pause(ctx context.Context) error {
 	ctx, _, done := tele.StartSpanWithLogger(ctx, "controllers.azureClusterService.Pause")
 	defer done()
 
 	for _, service := range s.services {
-		pauser, ok := service.(azure.Pauser)
+		pauser, ok := azure.ShouldPause(ctx, service)
 		if !ok {
 			continue
 		}
@@ -136,6 +166,16 @@ func (s *azureClusterService) Delete(ctx context.Context) error {
 	}
 	if managed {
 		// If the resource group is managed, delete it.
+		// The management lock protecting the resource group from deletion must be removed first.
+		if len(s.scope.ResourceLockSpecs()) > 0 {
+			resourceLocksSvc, err := s.getService(resourcelocks.ServiceName)
+			if err != nil {
+				return errors.Wrap(err, "failed to get resource locks service")
+			}
+			if err := resourceLocksSvc.Delete(ctx); err != nil {
+				return errors.Wrap(err, "failed to delete resource group lock")
+			}
+		}
 		// We need to explicitly delete vnet peerings, as it is not part of the resource group.
 		vnetPeeringsSvc, err := s.getService(vnetpeerings.ServiceName)
 		if err != nil {
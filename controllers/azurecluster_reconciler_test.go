@@ -24,15 +24,19 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/mock_azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/groups"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourcelocks"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/vnetpeerings"
 	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 )
 
 func TestAzureClusterServiceReconcile(t *testing.T) {
@@ -98,6 +102,84 @@ func TestAzureClusterServiceReconcile(t *testing.T) {
 	}
 }
 
+func TestAzureClusterServiceReconcileEgressValidation(t *testing.T) {
+	cases := map[string]struct {
+		natGateway      infrav1.NatGateway
+		expectReconcile bool
+		expectedError   string
+		expectedStatus  corev1.ConditionStatus
+	}{
+		"services are reconciled when the node subnet has an egress path": {
+			natGateway:      infrav1.NatGateway{NatGatewayClassSpec: infrav1.NatGatewayClassSpec{Name: "node-natgw"}},
+			expectReconcile: true,
+			expectedStatus:  corev1.ConditionTrue,
+		},
+		"services are not reconciled when the node subnet has no egress path": {
+			expectReconcile: false,
+			expectedError:   "egress pre-flight validation failed: the nodes have no egress path to their required destinations: node subnet node-subnet has no NAT gateway, node outbound load balancer or default route",
+			expectedStatus:  corev1.ConditionFalse,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			svcMock := mock_azure.NewMockServiceReconciler(mockCtrl)
+			if tc.expectReconcile {
+				svcMock.EXPECT().Reconcile(gomockinternal.AContext()).Return(nil)
+			}
+
+			azureCluster := &infrav1.AzureCluster{
+				Spec: infrav1.AzureClusterSpec{
+					NetworkSpec: infrav1.NetworkSpec{
+						APIServerLB: infrav1.LoadBalancerSpec{
+							LoadBalancerClassSpec: infrav1.LoadBalancerClassSpec{Type: infrav1.Internal},
+						},
+						Subnets: infrav1.Subnets{
+							{
+								SubnetClassSpec: infrav1.SubnetClassSpec{Role: infrav1.SubnetNode, Name: "node-subnet"},
+								NatGateway:      tc.natGateway,
+							},
+						},
+						EgressValidation: &infrav1.EgressValidationSpec{
+							RequiredDestinations: []string{"MicrosoftContainerRegistry", "AzureActiveDirectory"},
+						},
+					},
+				},
+			}
+			s := &azureClusterService{
+				scope: &scope.ClusterScope{
+					Cluster:      &clusterv1.Cluster{},
+					AzureCluster: azureCluster,
+				},
+				services: []azure.ServiceReconciler{svcMock},
+				skuCache: resourceskus.NewStaticCache([]compute.ResourceSku{}, ""),
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err.Error()).To(ContainSubstring(tc.expectedError))
+				var reconcileError azure.ReconcileError
+				g.Expect(errors.As(err, &reconcileError)).To(BeTrue())
+				g.Expect(reconcileError.IsTerminal()).To(BeTrue())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			condition := conditions.Get(azureCluster, infrav1.EgressValidatedCondition)
+			g.Expect(condition).NotTo(BeNil())
+			g.Expect(condition.Status).To(Equal(tc.expectedStatus))
+			if tc.expectedStatus == corev1.ConditionFalse {
+				g.Expect(condition.Reason).To(Equal(infrav1.EgressBlockedReason))
+			}
+		})
+	}
+}
+
 func TestAzureClusterServicePause(t *testing.T) {
 	type pausingServiceReconciler struct {
 		*mock_azure.MockServiceReconciler
@@ -271,3 +353,85 @@ func TestAzureClusterServiceDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestAzureClusterServiceDeleteResourceGroupLock(t *testing.T) {
+	cases := map[string]struct {
+		annotations   map[string]string
+		expectedError string
+		expect        func(grp *mock_azure.MockServiceReconcilerMockRecorder, vpr *mock_azure.MockServiceReconcilerMockRecorder, lck *mock_azure.MockServiceReconcilerMockRecorder)
+	}{
+		"resource group lock is removed before the resource group is deleted": {
+			annotations:   map[string]string{infrav1.RemoveResourceGroupLockAnnotation: "true"},
+			expectedError: "",
+			expect: func(grp *mock_azure.MockServiceReconcilerMockRecorder, vpr *mock_azure.MockServiceReconcilerMockRecorder, lck *mock_azure.MockServiceReconcilerMockRecorder) {
+				gomock.InOrder(
+					grp.Name().Return(groups.ServiceName),
+					grp.IsManaged(gomockinternal.AContext()).Return(true, nil),
+					grp.Name().Return(groups.ServiceName),
+					vpr.Name().Return(vnetpeerings.ServiceName),
+					lck.Name().Return(resourcelocks.ServiceName),
+					lck.Delete(gomockinternal.AContext()).Return(nil),
+					grp.Name().Return(groups.ServiceName),
+					vpr.Name().Return(vnetpeerings.ServiceName),
+					vpr.Delete(gomockinternal.AContext()).Return(nil),
+					grp.Delete(gomockinternal.AContext()).Return(nil))
+			},
+		},
+		"resource group is not deleted when the resource group lock can't be removed": {
+			expectedError: "failed to delete resource group lock: the resource group is protected from deletion",
+			expect: func(grp *mock_azure.MockServiceReconcilerMockRecorder, vpr *mock_azure.MockServiceReconcilerMockRecorder, lck *mock_azure.MockServiceReconcilerMockRecorder) {
+				gomock.InOrder(
+					grp.Name().Return(groups.ServiceName),
+					grp.IsManaged(gomockinternal.AContext()).Return(true, nil),
+					grp.Name().Return(groups.ServiceName),
+					vpr.Name().Return(vnetpeerings.ServiceName),
+					lck.Name().Return(resourcelocks.ServiceName),
+					lck.Delete(gomockinternal.AContext()).Return(errors.New("the resource group is protected from deletion")))
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			groupsMock := mock_azure.NewMockServiceReconciler(mockCtrl)
+			vnetpeeringsMock := mock_azure.NewMockServiceReconciler(mockCtrl)
+			resourcelocksMock := mock_azure.NewMockServiceReconciler(mockCtrl)
+
+			tc.expect(groupsMock.EXPECT(), vnetpeeringsMock.EXPECT(), resourcelocksMock.EXPECT())
+
+			s := &azureClusterService{
+				scope: &scope.ClusterScope{
+					Cluster: &clusterv1.Cluster{
+						ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"},
+					},
+					AzureCluster: &infrav1.AzureCluster{
+						ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+						Spec: infrav1.AzureClusterSpec{
+							ResourceGroupDeletionProtection: true,
+						},
+					},
+				},
+				services: []azure.ServiceReconciler{
+					groupsMock,
+					vnetpeeringsMock,
+					resourcelocksMock,
+				},
+				skuCache: resourceskus.NewStaticCache([]compute.ResourceSku{}, ""),
+			}
+
+			err := s.Delete(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
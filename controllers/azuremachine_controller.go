@@ -46,11 +46,17 @@ import (
 )
 
 // AzureMachineReconciler reconciles an AzureMachine object.
+// AdditionalServices are reconciled in addition to the built-in services, at their declared position.
+// CompressBootstrapData enables the gzip compression of the bootstrap data of Linux VMs.
+// ReplicateGalleryImages enables the replication of gallery image versions to the region of the VMs.
 type AzureMachineReconciler struct {
 	client.Client
 	Recorder                  record.EventRecorder
 	ReconcileTimeout          time.Duration
 	WatchFilterValue          string
+	AdditionalServices        []AzureMachineServiceRegistration
+	CompressBootstrapData     bool
+	ReplicateGalleryImages    bool
 	createAzureMachineService azureMachineServiceCreator
 }
 
@@ -65,7 +71,9 @@ func NewAzureMachineReconciler(client client.Client, recorder record.EventRecord
 		WatchFilterValue: watchFilterValue,
 	}
 
-	amr.createAzureMachineService = newAzureMachineService
+	amr.createAzureMachineService = func(machineScope *scope.MachineScope) (*azureMachineService, error) {
+		return newAzureMachineService(machineScope, amr.AdditionalServices...)
+	}
 
 	return amr
 }
@@ -208,6 +216,10 @@ func (amr *AzureMachineReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		Machine:      machine,
 		AzureMachine: azureMachine,
 		ClusterScope: clusterScope,
+		Recorder:     amr.Recorder,
+
+		CompressBootstrapData:  amr.CompressBootstrapData,
+		ReplicateGalleryImages: amr.ReplicateGalleryImages,
 	})
 	if err != nil {
 		amr.Recorder.Eventf(azureMachine, corev1.EventTypeWarning, "Error creating the machine scope", err.Error())
@@ -336,9 +348,23 @@ func (amr *AzureMachineReconciler) reconcileNormal(ctx context.Context, machineS
 		return reconcile.Result{}, errors.Wrap(err, "failed to reconcile AzureMachine")
 	}
 
+	if machineScope.IsDryRun() {
+		// The changes were only reported, so the VM of the machine may not exist yet.
+		log.V(2).Info("not marking the AzureMachine ready in dry-run mode")
+		return reconcile.Result{}, nil
+	}
+
 	machineScope.SetReady()
 
-	return reconcile.Result{}, nil
+	if labeler := newNodeLabeler(amr.Client, machineScope); labeler != nil {
+		if err := labeler.Label(ctx); err != nil {
+			amr.Recorder.Eventf(machineScope.AzureMachine, corev1.EventTypeWarning, "NodeLabelingFailed", err.Error())
+			return reconcile.Result{}, errors.Wrap(err, "failed to label the node of AzureMachine")
+		}
+	}
+
+	// Reconcile again when the performance tier of a data disk is due to change.
+	return reconcile.Result{RequeueAfter: machineScope.DataDiskTierRequeueAfter()}, nil
 }
 
 func (amr *AzureMachineReconciler) reconcilePause(ctx context.Context, machineScope *scope.MachineScope) (reconcile.Result, error) {
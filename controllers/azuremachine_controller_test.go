@@ -39,6 +39,7 @@ import (
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -755,3 +756,15 @@ func conditionsMatch(i, j clusterv1.Condition) bool {
 		i.Reason == j.Reason &&
 		i.Severity == j.Severity
 }
+
+func TestAzureMachineReconcilerSetupWithManager(t *testing.T) {
+	g := NewWithT(t)
+	mgr := newControllerRecordingManager(g)
+
+	// The --azuremachine-concurrency flag is passed to the controller through its options.
+	reconciler := NewAzureMachineReconciler(mgr.GetClient(), record.NewFakeRecorder(10), reconciler.DefaultLoopTimeout, "")
+	g.Expect(reconciler.SetupWithManager(context.Background(), mgr, Options{Options: controller.Options{MaxConcurrentReconciles: 7}})).To(Succeed())
+
+	g.Expect(mgr.controllers).To(HaveLen(1))
+	g.Expect(maxConcurrentReconciles(g, mgr.controllers[0], "azuremachine")).To(Equal(7))
+}
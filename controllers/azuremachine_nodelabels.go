@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const nodeLabelerName = "azuremachine-nodelabeler"
+
+// nodeLabeler propagates the tags of the VM of an AzureMachine named in its nodeLabelTags as labels of the Kubernetes
+// node of the machine.
+type nodeLabeler struct {
+	nodeName   string
+	labels     map[string]string
+	nodeClient func(context.Context) (client.Client, error)
+}
+
+// newNodeLabeler returns the node labeler of a machine, or nil if there are no labels to propagate or the machine has
+// no node yet.
+func newNodeLabeler(c client.Client, machineScope *scope.MachineScope) *nodeLabeler {
+	labels := machineScope.NodeLabels()
+	nodeRef := machineScope.Machine.Status.NodeRef
+	if len(labels) == 0 || nodeRef == nil {
+		return nil
+	}
+	clusterKey := client.ObjectKey{Namespace: machineScope.Machine.Namespace, Name: machineScope.ClusterName()}
+	return &nodeLabeler{
+		nodeName: nodeRef.Name,
+		labels:   labels,
+		nodeClient: func(ctx context.Context) (client.Client, error) {
+			return remote.NewClusterClient(ctx, nodeLabelerName, c, clusterKey)
+		},
+	}
+}
+
+// Label patches the labels of the node which are missing or have a different value.
+func (l *nodeLabeler) Label(ctx context.Context) error {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "controllers.nodeLabeler.Label")
+	defer done()
+
+	nodeClient, err := l.nodeClient(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the workload cluster client")
+	}
+
+	node := &corev1.Node{}
+	if err := nodeClient.Get(ctx, client.ObjectKey{Name: l.nodeName}, node); err != nil {
+		return errors.Wrapf(err, "failed to get node %s", l.nodeName)
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	updated := false
+	for name, value := range l.labels {
+		if current, ok := node.Labels[name]; ok && current == value {
+			continue
+		}
+		if node.Labels == nil {
+			node.Labels = map[string]string{}
+		}
+		node.Labels[name] = value
+		updated = true
+	}
+	if !updated {
+		return nil
+	}
+
+	log.V(2).Info("labeling node from the VM tags", "node", l.nodeName)
+	if err := nodeClient.Patch(ctx, node, patch); err != nil {
+		return errors.Wrapf(err, "failed to label node %s", l.nodeName)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNodeLabelerLabel(t *testing.T) {
+	tests := []struct {
+		name           string
+		node           *corev1.Node
+		labels         map[string]string
+		expectedLabels map[string]string
+		expectErr      bool
+	}{
+		{
+			name: "adds the labels from the VM tags to the node",
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "my-node",
+					Labels: map[string]string{"kubernetes.io/hostname": "my-node"},
+				},
+			},
+			labels: map[string]string{"workload": "gpu", "cost-center": "1234"},
+			expectedLabels: map[string]string{
+				"kubernetes.io/hostname": "my-node",
+				"workload":               "gpu",
+				"cost-center":            "1234",
+			},
+		},
+		{
+			name: "updates a label with a different value than the VM tag",
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "my-node",
+					Labels: map[string]string{"workload": "cpu", "team": "ml"},
+				},
+			},
+			labels:         map[string]string{"workload": "gpu"},
+			expectedLabels: map[string]string{"workload": "gpu", "team": "ml"},
+		},
+		{
+			name: "labels a node without labels",
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-node"},
+			},
+			labels:         map[string]string{"workload": "gpu"},
+			expectedLabels: map[string]string{"workload": "gpu"},
+		},
+		{
+			name: "fails if the node doesn't exist",
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "other-node"},
+			},
+			labels:    map[string]string{"workload": "gpu"},
+			expectErr: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			nodeClient := fake.NewClientBuilder().WithObjects(tc.node).Build()
+			labeler := &nodeLabeler{
+				nodeName: "my-node",
+				labels:   tc.labels,
+				nodeClient: func(context.Context) (client.Client, error) {
+					return nodeClient, nil
+				},
+			}
+
+			err := labeler.Label(context.Background())
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			node := &corev1.Node{}
+			g.Expect(nodeClient.Get(context.Background(), client.ObjectKey{Name: "my-node"}, node)).To(Succeed())
+			g.Expect(node.Labels).To(Equal(tc.expectedLabels))
+		})
+	}
+}
@@ -20,18 +20,23 @@ import (
 	"context"
 
 	"github.com/pkg/errors"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/availabilitysets"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/datacollectionruleassociations"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/disks"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/inboundnatrules"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/marketplaceagreements"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/networkinterfaces"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicips"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/roleassignments"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/runcommands"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualmachines"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/vmextensions"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
@@ -47,25 +52,44 @@ type azureMachineService struct {
 	Delete    func(context.Context) error
 }
 
+// AzureMachineServiceRegistration registers an additional service reconciled by the AzureMachine controller.
+type AzureMachineServiceRegistration struct {
+	// New creates the service for a machine.
+	New func(machineScope *scope.MachineScope) azure.ServiceReconciler
+	// Position declares where the service is reconciled relative to the built-in services, e.g.
+	// after "interfaces" (network interfaces) and before "virtualmachine".
+	Position azure.ServicePosition
+}
+
 // newAzureMachineService populates all the services based on input scope.
-func newAzureMachineService(machineScope *scope.MachineScope) (*azureMachineService, error) {
+// The additional services are injected at their declared position, in the given order.
+func newAzureMachineService(machineScope *scope.MachineScope, additionalServices ...AzureMachineServiceRegistration) (*azureMachineService, error) {
 	cache, err := resourceskus.GetCache(machineScope, machineScope.Location())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed creating a NewCache")
 	}
+	registry := azure.NewServiceRegistry(
+		publicips.New(machineScope),
+		inboundnatrules.New(machineScope),
+		networkinterfaces.New(machineScope, cache),
+		availabilitysets.New(machineScope, cache),
+		disks.New(machineScope),
+		marketplaceagreements.New(machineScope),
+		virtualmachines.New(machineScope),
+		roleassignments.New(machineScope),
+		vmextensions.New(machineScope),
+		datacollectionruleassociations.New(machineScope),
+		runcommands.New(machineScope),
+		tags.New(machineScope),
+	)
+	for _, additionalService := range additionalServices {
+		if err := registry.Inject(additionalService.New(machineScope), additionalService.Position); err != nil {
+			return nil, errors.Wrap(err, "failed to inject additional AzureMachine service")
+		}
+	}
 	ams := &azureMachineService{
-		scope: machineScope,
-		services: []azure.ServiceReconciler{
-			publicips.New(machineScope),
-			inboundnatrules.New(machineScope),
-			networkinterfaces.New(machineScope, cache),
-			availabilitysets.New(machineScope, cache),
-			disks.New(machineScope),
-			virtualmachines.New(machineScope),
-			roleassignments.New(machineScope),
-			vmextensions.New(machineScope),
-			tags.New(machineScope),
-		},
+		scope:    machineScope,
+		services: registry.Services(),
 		skuCache: cache,
 	}
 	ams.Reconcile = ams.reconcile
@@ -102,7 +126,7 @@ func (s *azureMachineService) pause(ctx context.Context) error {
 	defer done()
 
 	for _, service := range s.services {
-		pauser, ok := service.(azure.Pauser)
+		pauser, ok := azure.ShouldPause(ctx, service)
 		if !ok {
 			continue
 		}
@@ -121,10 +145,29 @@ func (s *azureMachineService) delete(ctx context.Context) error {
 
 	// Delete services in reverse order of creation.
 	for i := len(s.services) - 1; i >= 0; i-- {
+		isDisks := s.services[i].Name() == disks.ServiceName
+		if isDisks {
+			// Azure can't delete the disks of a VM until the VM is deleted.
+			if err := s.waitForVMDeletion(); err != nil {
+				return err
+			}
+		}
 		if err := s.services[i].Delete(ctx); err != nil {
+			if isDisks && azure.DiskAttached(err) {
+				// Azure can still report a disk as attached shortly after the deletion of its VM completes.
+				return azure.WithTransientError(errors.Wrapf(err, "failed to delete AzureMachine service %s", disks.ServiceName), reconciler.DefaultReconcilerRequeue)
+			}
 			return errors.Wrapf(err, "failed to delete AzureMachine service %s", s.services[i].Name())
 		}
 	}
 
 	return nil
 }
+
+// waitForVMDeletion returns a transient error while the long-running operation deleting the VM is in progress.
+func (s *azureMachineService) waitForVMDeletion() error {
+	if future := s.scope.GetLongRunningOperationState(s.scope.Name(), virtualmachines.ServiceName, infrav1.DeleteFuture); future != nil {
+		return azure.WithTransientError(errors.Errorf("waiting for VM %s to be deleted before deleting its disks", s.scope.Name()), reconciler.DefaultReconcilerRequeue)
+	}
+	return nil
+}
@@ -19,16 +19,22 @@ package controllers
 import (
 	"context"
 	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/mock_azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/disks"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualmachines"
 	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -174,6 +180,63 @@ func TestAzureMachineServicePause(t *testing.T) {
 	}
 }
 
+func TestAzureMachineServiceSelectivePause(t *testing.T) {
+	type selectivePausingServiceReconciler struct {
+		*mock_azure.MockServiceReconciler
+		*mock_azure.MockSelectivePauser
+	}
+
+	cases := map[string]struct {
+		shouldPause bool
+	}{
+		"service accepting to pause is paused": {
+			shouldPause: true,
+		},
+		"service declining to pause is skipped": {
+			shouldPause: false,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			pauserMock := mock_azure.NewMockPauser(mockCtrl)
+			alwaysPausing := struct {
+				*mock_azure.MockServiceReconciler
+				*mock_azure.MockPauser
+			}{mock_azure.NewMockServiceReconciler(mockCtrl), pauserMock}
+			selective := selectivePausingServiceReconciler{
+				mock_azure.NewMockServiceReconciler(mockCtrl),
+				mock_azure.NewMockSelectivePauser(mockCtrl),
+			}
+
+			selective.MockSelectivePauser.EXPECT().ShouldPause(gomockinternal.AContext()).Return(tc.shouldPause)
+			if tc.shouldPause {
+				gomock.InOrder(
+					selective.MockSelectivePauser.EXPECT().Pause(gomockinternal.AContext()).Return(nil),
+					pauserMock.EXPECT().Pause(gomockinternal.AContext()).Return(nil))
+			} else {
+				pauserMock.EXPECT().Pause(gomockinternal.AContext()).Return(nil)
+			}
+
+			s := &azureMachineService{
+				services: []azure.ServiceReconciler{
+					selective,
+					alwaysPausing,
+				},
+			}
+
+			g.Expect(s.pause(context.TODO())).To(Succeed())
+		})
+	}
+}
+
 func TestAzureMachineServiceDelete(t *testing.T) {
 	cases := map[string]struct {
 		expectedError string
@@ -193,8 +256,7 @@ func TestAzureMachineServiceDelete(t *testing.T) {
 			expect: func(one *mock_azure.MockServiceReconcilerMockRecorder, two *mock_azure.MockServiceReconcilerMockRecorder, three *mock_azure.MockServiceReconcilerMockRecorder) {
 				gomock.InOrder(
 					three.Delete(gomockinternal.AContext()).Return(nil),
-					two.Delete(gomockinternal.AContext()).Return(errors.New("some error happened")),
-					two.Name().Return("test-service-two"))
+					two.Delete(gomockinternal.AContext()).Return(errors.New("some error happened")))
 			},
 		},
 	}
@@ -211,6 +273,9 @@ func TestAzureMachineServiceDelete(t *testing.T) {
 			svcTwoMock := mock_azure.NewMockServiceReconciler(mockCtrl)
 			svcThreeMock := mock_azure.NewMockServiceReconciler(mockCtrl)
 
+			svcOneMock.EXPECT().Name().Return("test-service-one").AnyTimes()
+			svcTwoMock.EXPECT().Name().Return("test-service-two").AnyTimes()
+			svcThreeMock.EXPECT().Name().Return("test-service-three").AnyTimes()
 			tc.expect(svcOneMock.EXPECT(), svcTwoMock.EXPECT(), svcThreeMock.EXPECT())
 
 			s := &azureMachineService{
@@ -240,3 +305,158 @@ func TestAzureMachineServiceDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestAzureMachineServiceDeleteDisksAfterVM(t *testing.T) {
+	diskAttachedError := autorest.DetailedError{
+		StatusCode: http.StatusConflict,
+		Original: &azureautorest.RequestError{ServiceError: &azureautorest.ServiceError{
+			Code:    "OperationNotAllowed",
+			Message: "Disk my-vm_OSDisk is attached to VM /subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm.",
+		}},
+	}
+	vmDeleteFuture := infrav1.Future{
+		Type:        infrav1.DeleteFuture,
+		ServiceName: virtualmachines.ServiceName,
+		Name:        "my-vm",
+	}
+
+	cases := map[string]struct {
+		futures       infrav1.Futures
+		expectedError string
+		transient     bool
+		expect        func(disks *mock_azure.MockServiceReconcilerMockRecorder, vm *mock_azure.MockServiceReconcilerMockRecorder)
+	}{
+		"disks are not deleted while the VM is being deleted": {
+			futures:       infrav1.Futures{vmDeleteFuture},
+			expectedError: "waiting for VM my-vm to be deleted before deleting its disks. Object will be requeued after 15s",
+			transient:     true,
+			expect: func(disks *mock_azure.MockServiceReconcilerMockRecorder, vm *mock_azure.MockServiceReconcilerMockRecorder) {
+				vm.Delete(gomockinternal.AContext()).Return(nil)
+			},
+		},
+		"deletion of a disk still attached to the deleted VM is retried": {
+			expectedError: "failed to delete AzureMachine service disks: " + diskAttachedError.Error() + ". Object will be requeued after 15s",
+			transient:     true,
+			expect: func(disks *mock_azure.MockServiceReconcilerMockRecorder, vm *mock_azure.MockServiceReconcilerMockRecorder) {
+				gomock.InOrder(
+					vm.Delete(gomockinternal.AContext()).Return(nil),
+					disks.Delete(gomockinternal.AContext()).Return(diskAttachedError))
+			},
+		},
+		"disks are deleted once the VM is deleted": {
+			expect: func(disks *mock_azure.MockServiceReconcilerMockRecorder, vm *mock_azure.MockServiceReconcilerMockRecorder) {
+				gomock.InOrder(
+					vm.Delete(gomockinternal.AContext()).Return(nil),
+					disks.Delete(gomockinternal.AContext()).Return(nil))
+			},
+		},
+		"other disk errors are not retried": {
+			expectedError: "failed to delete AzureMachine service disks: some error happened",
+			expect: func(disks *mock_azure.MockServiceReconcilerMockRecorder, vm *mock_azure.MockServiceReconcilerMockRecorder) {
+				gomock.InOrder(
+					vm.Delete(gomockinternal.AContext()).Return(nil),
+					disks.Delete(gomockinternal.AContext()).Return(errors.New("some error happened")))
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			disksMock := mock_azure.NewMockServiceReconciler(mockCtrl)
+			vmMock := mock_azure.NewMockServiceReconciler(mockCtrl)
+
+			disksMock.EXPECT().Name().Return(disks.ServiceName).AnyTimes()
+			vmMock.EXPECT().Name().Return(virtualmachines.ServiceName).AnyTimes()
+			tc.expect(disksMock.EXPECT(), vmMock.EXPECT())
+
+			s := &azureMachineService{
+				scope: &scope.MachineScope{
+					ClusterScoper: &scope.ClusterScope{
+						AzureCluster: &infrav1.AzureCluster{},
+						Cluster:      &clusterv1.Cluster{},
+					},
+					Machine: &clusterv1.Machine{},
+					AzureMachine: &infrav1.AzureMachine{
+						ObjectMeta: metav1.ObjectMeta{Name: "my-vm"},
+						Status: infrav1.AzureMachineStatus{
+							LongRunningOperationStates: tc.futures,
+						},
+					},
+				},
+				services: []azure.ServiceReconciler{
+					disksMock,
+					vmMock,
+				},
+				skuCache: resourceskus.NewStaticCache([]compute.ResourceSku{}, ""),
+			}
+
+			err := s.delete(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+				var reconcileError azure.ReconcileError
+				g.Expect(errors.As(err, &reconcileError) && reconcileError.IsTransient()).To(Equal(tc.transient))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestAzureMachineServiceInjectedService(t *testing.T) {
+	g := NewWithT(t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	newNamedService := func(name string) *mock_azure.MockServiceReconciler {
+		svc := mock_azure.NewMockServiceReconciler(mockCtrl)
+		svc.EXPECT().Name().Return(name).AnyTimes()
+		return svc
+	}
+	interfacesMock := newNamedService("interfaces")
+	disksMock := newNamedService("disks")
+	vmMock := newNamedService("virtualmachine")
+	customMock := newNamedService("custom")
+
+	registry := azure.NewServiceRegistry(interfacesMock, disksMock, vmMock)
+	g.Expect(registry.Inject(customMock, azure.ServicePosition{After: "interfaces", Before: "virtualmachine"})).To(Succeed())
+
+	s := &azureMachineService{
+		scope: &scope.MachineScope{
+			ClusterScoper: &scope.ClusterScope{
+				AzureCluster: &infrav1.AzureCluster{},
+				Cluster:      &clusterv1.Cluster{},
+			},
+			Machine: &clusterv1.Machine{},
+			AzureMachine: &infrav1.AzureMachine{
+				Spec: infrav1.AzureMachineSpec{
+					SubnetName: "test-subnet",
+				},
+			},
+		},
+		services: registry.Services(),
+		skuCache: resourceskus.NewStaticCache([]compute.ResourceSku{}, ""),
+	}
+
+	gomock.InOrder(
+		interfacesMock.EXPECT().Reconcile(gomockinternal.AContext()).Return(nil),
+		disksMock.EXPECT().Reconcile(gomockinternal.AContext()).Return(nil),
+		customMock.EXPECT().Reconcile(gomockinternal.AContext()).Return(nil),
+		vmMock.EXPECT().Reconcile(gomockinternal.AContext()).Return(nil),
+	)
+	g.Expect(s.reconcile(context.TODO())).To(Succeed())
+
+	gomock.InOrder(
+		vmMock.EXPECT().Delete(gomockinternal.AContext()).Return(nil),
+		customMock.EXPECT().Delete(gomockinternal.AContext()).Return(nil),
+		disksMock.EXPECT().Delete(gomockinternal.AContext()).Return(nil),
+		interfacesMock.EXPECT().Delete(gomockinternal.AContext()).Return(nil),
+	)
+	g.Expect(s.delete(context.TODO())).To(Succeed())
+}
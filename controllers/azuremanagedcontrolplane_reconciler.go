@@ -26,6 +26,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/managedclusters"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/privateendpoints"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourcehealth"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/roleassignments"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/subnets"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualnetworks"
@@ -52,6 +53,7 @@ func newAzureManagedControlPlaneReconciler(scope *scope.ManagedControlPlaneScope
 			virtualnetworks.New(scope),
 			subnets.New(scope),
 			managedclusters.New(scope),
+			roleassignments.New(scope),
 			privateendpoints.New(scope),
 			tags.New(scope),
 			resourcehealth.New(scope),
@@ -83,7 +85,7 @@ func (r *azureManagedControlPlaneService) Pause(ctx context.Context) error {
 	defer done()
 
 	for _, service := range r.services {
-		pauser, ok := service.(azure.Pauser)
+		pauser, ok := azure.ShouldPause(ctx, service)
 		if !ok {
 			continue
 		}
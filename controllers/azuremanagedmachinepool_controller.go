@@ -291,6 +291,7 @@ func (ammpr *AzureManagedMachinePoolReconciler) reconcileNormal(ctx context.Cont
 
 	// No errors, so mark us ready so the Cluster API Cluster Controller can pull it
 	scope.SetAgentPoolReady(true)
+
 	return reconcile.Result{}, nil
 }
 
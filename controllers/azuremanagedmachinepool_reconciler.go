@@ -163,7 +163,7 @@ func (s *azureManagedMachinePoolService) Pause(ctx context.Context) error {
 	ctx, _, done := tele.StartSpanWithLogger(ctx, "controllers.azureManagedMachinePoolService.Pause")
 	defer done()
 
-	pauser, ok := s.agentPoolsSvc.(azure.Pauser)
+	pauser, ok := azure.ShouldPause(ctx, s.agentPoolsSvc)
 	if !ok {
 		return nil
 	}
@@ -0,0 +1,291 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// spotEvictionPollInterval is how often the nodes of a node pool with a spot eviction webhook are checked for
+	// scheduled evictions. Azure schedules the eviction of Spot VMs at least 30 seconds in advance.
+	spotEvictionPollInterval = 15 * time.Second
+
+	// vmEventScheduledCondition is the node condition set by the AKS node problem detector when Azure schedules an
+	// event for the VM of a node, e.g. its eviction.
+	vmEventScheduledCondition corev1.NodeConditionType = "VMEventScheduled"
+
+	// spotEvictionEventType is the type of the scheduled event of the eviction of a Spot VM.
+	spotEvictionEventType = "Preempt"
+
+	// agentPoolNodeLabel is the label of AKS nodes holding the name of their node pool.
+	agentPoolNodeLabel = "kubernetes.azure.com/agentpool"
+
+	// spotEvictionForwardedAnnotation is set on a node once its scheduled eviction is forwarded, to the time the
+	// eviction was scheduled at.
+	spotEvictionForwardedAnnotation = "azuremanagedmachinepool.infrastructure.cluster.x-k8s.io/spot-eviction-forwarded"
+
+	spotEvictionForwarderName = "azuremanagedmachinepool-spoteviction"
+)
+
+// spotEviction is the notification posted to the spot eviction webhook of a node pool.
+type spotEviction struct {
+	Cluster     string      `json:"cluster"`
+	Namespace   string      `json:"namespace"`
+	MachinePool string      `json:"machinePool"`
+	Node        string      `json:"node"`
+	ProviderID  string      `json:"providerID,omitempty"`
+	ScheduledAt metav1.Time `json:"scheduledAt"`
+	Message     string      `json:"message,omitempty"`
+}
+
+// SpotEvictionPoller polls the nodes of the AKS node pools with a spot eviction webhook for scheduled evictions and
+// forwards them to the webhooks. It runs in the manager next to the AzureManagedMachinePool controller so that the
+// node pools aren't reconciled again for every poll, and only forwards evictions to the allowed webhook hosts.
+type SpotEvictionPoller struct {
+	Client           client.Client
+	Recorder         record.EventRecorder
+	WatchFilterValue string
+	// AllowedHosts are the hosts of the spot eviction webhooks evictions are forwarded to. The evictions aren't
+	// forwarded if it is empty.
+	AllowedHosts  []string
+	httpClient    *http.Client
+	newNodeClient func(ctx context.Context, cluster client.ObjectKey) (client.Client, error)
+}
+
+// NewSpotEvictionPoller returns a new SpotEvictionPoller instance.
+func NewSpotEvictionPoller(c client.Client, recorder record.EventRecorder, watchFilterValue string, allowedHosts []string) *SpotEvictionPoller {
+	return &SpotEvictionPoller{
+		Client:           c,
+		Recorder:         recorder,
+		WatchFilterValue: watchFilterValue,
+		AllowedHosts:     allowedHosts,
+		httpClient:       &http.Client{Timeout: spotEvictionPollInterval},
+		newNodeClient: func(ctx context.Context, cluster client.ObjectKey) (client.Client, error) {
+			return remote.NewClusterClient(ctx, spotEvictionForwarderName, c, cluster)
+		},
+	}
+}
+
+// SetupWithManager adds the poller to the manager, unless no webhook host is allowed.
+func (p *SpotEvictionPoller) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	_, log, done := tele.StartSpanWithLogger(ctx, "controllers.SpotEvictionPoller.SetupWithManager")
+	defer done()
+
+	if len(p.AllowedHosts) == 0 {
+		log.V(2).Info("no spot eviction webhook host is allowed, scheduled spot evictions aren't forwarded")
+		return nil
+	}
+	return mgr.Add(p)
+}
+
+// Start polls the node pools with a spot eviction webhook until the context is done.
+func (p *SpotEvictionPoller) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, p.poll, spotEvictionPollInterval)
+	return nil
+}
+
+// poll forwards the scheduled evictions of the nodes of all the node pools with a spot eviction webhook.
+func (p *SpotEvictionPoller) poll(ctx context.Context) {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "controllers.SpotEvictionPoller.poll")
+	defer done()
+
+	var listOptions []client.ListOption
+	if p.WatchFilterValue != "" {
+		listOptions = append(listOptions, client.MatchingLabels{clusterv1.WatchLabel: p.WatchFilterValue})
+	}
+	pools := &infrav1.AzureManagedMachinePoolList{}
+	if err := p.Client.List(ctx, pools, listOptions...); err != nil {
+		log.Error(err, "failed to list AzureManagedMachinePools")
+		return
+	}
+
+	for i := range pools.Items {
+		pool := &pools.Items[i]
+		if pool.Spec.SpotEvictionWebhook == nil || !pool.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := p.forward(ctx, pool); err != nil {
+			log.Error(err, "failed to forward scheduled spot evictions", "namespace", pool.Namespace, "name", pool.Name)
+			p.Recorder.Event(pool, corev1.EventTypeWarning, "SpotEvictionForwardingFailed", err.Error())
+		}
+	}
+}
+
+// forward forwards the scheduled evictions of the nodes of a node pool to its webhook, if its host is allowed.
+func (p *SpotEvictionPoller) forward(ctx context.Context, pool *infrav1.AzureManagedMachinePool) error {
+	webhookURL, err := url.Parse(pool.Spec.SpotEvictionWebhook.URL)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the spot eviction webhook URL")
+	}
+	if !p.isAllowedHost(webhookURL.Hostname()) {
+		return errors.Errorf("spot eviction webhook host %s is not allowed", webhookURL.Hostname())
+	}
+
+	cluster, err := util.GetClusterFromMetadata(ctx, p.Client, pool.ObjectMeta)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the owner cluster")
+	}
+	if annotations.IsPaused(cluster, pool) {
+		return nil
+	}
+
+	forwarder := &spotEvictionForwarder{
+		webhookURL:    webhookURL.String(),
+		httpClient:    p.httpClient,
+		cluster:       cluster.Name,
+		namespace:     pool.Namespace,
+		machinePool:   pool.Name,
+		agentPoolName: ptr.Deref(pool.Spec.Name, pool.Name),
+		nodeClient: func(ctx context.Context) (client.Client, error) {
+			return p.newNodeClient(ctx, util.ObjectKey(cluster))
+		},
+	}
+	return forwarder.Forward(ctx)
+}
+
+// isAllowedHost returns true if evictions may be forwarded to a webhook host.
+func (p *SpotEvictionPoller) isAllowedHost(host string) bool {
+	for _, allowedHost := range p.AllowedHosts {
+		if strings.EqualFold(allowedHost, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// spotEvictionForwarder forwards the scheduled evictions of the Spot nodes of an AKS node pool, observed through the
+// conditions of the nodes, to the spot eviction webhook of the node pool.
+type spotEvictionForwarder struct {
+	webhookURL    string
+	httpClient    *http.Client
+	cluster       string
+	namespace     string
+	machinePool   string
+	agentPoolName string
+	nodeClient    func(context.Context) (client.Client, error)
+}
+
+// Forward posts the scheduled evictions of the nodes of the node pool which weren't forwarded yet to the webhook, and
+// records them on the nodes once the webhook accepted them.
+func (f *spotEvictionForwarder) Forward(ctx context.Context) error {
+	ctx, log, done := tele.StartSpanWithLogger(ctx, "controllers.spotEvictionForwarder.Forward")
+	defer done()
+
+	nodeClient, err := f.nodeClient(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the workload cluster client")
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := nodeClient.List(ctx, nodes, client.MatchingLabels{agentPoolNodeLabel: f.agentPoolName}); err != nil {
+		return errors.Wrapf(err, "failed to list the nodes of node pool %s", f.agentPoolName)
+	}
+
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		condition := scheduledSpotEviction(node)
+		if condition == nil {
+			continue
+		}
+		scheduledAt := condition.LastTransitionTime.UTC().Format(time.RFC3339)
+		if node.Annotations[spotEvictionForwardedAnnotation] == scheduledAt {
+			continue
+		}
+
+		log.Info("forwarding scheduled spot eviction", "node", node.Name)
+		if err := f.post(ctx, spotEviction{
+			Cluster:     f.cluster,
+			Namespace:   f.namespace,
+			MachinePool: f.machinePool,
+			Node:        node.Name,
+			ProviderID:  node.Spec.ProviderID,
+			ScheduledAt: condition.LastTransitionTime,
+			Message:     condition.Message,
+		}); err != nil {
+			return errors.Wrapf(err, "failed to forward the scheduled eviction of node %s", node.Name)
+		}
+
+		patch := client.MergeFrom(node.DeepCopy())
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+		node.Annotations[spotEvictionForwardedAnnotation] = scheduledAt
+		if err := nodeClient.Patch(ctx, node, patch); err != nil {
+			return errors.Wrapf(err, "failed to record the forwarded eviction of node %s", node.Name)
+		}
+	}
+
+	return nil
+}
+
+// post posts a scheduled eviction to the webhook.
+func (f *spotEvictionForwarder) post(ctx context.Context, eviction spotEviction) error {
+	body, err := json.Marshal(eviction)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the scheduled eviction")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create the webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to call the spot eviction webhook")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("spot eviction webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// scheduledSpotEviction returns the VMEventScheduled condition of a node if Azure scheduled the eviction of its VM,
+// nil otherwise.
+func scheduledSpotEviction(node *corev1.Node) *corev1.NodeCondition {
+	for i := range node.Status.Conditions {
+		condition := &node.Status.Conditions[i]
+		if condition.Type == vmEventScheduledCondition && condition.Status == corev1.ConditionTrue &&
+			strings.Contains(condition.Message, spotEvictionEventType) {
+			return condition
+		}
+	}
+	return nil
+}
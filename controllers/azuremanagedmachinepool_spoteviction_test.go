@@ -0,0 +1,291 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// spotEvictionWebhookServer records the scheduled evictions posted to it.
+type spotEvictionWebhookServer struct {
+	*httptest.Server
+	mu         sync.Mutex
+	evictions  []spotEviction
+	statusCode int
+}
+
+func newSpotEvictionWebhookServer(g *WithT, statusCode int) *spotEvictionWebhookServer {
+	s := &spotEvictionWebhookServer{statusCode: statusCode}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal(http.MethodPost))
+		g.Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+		var eviction spotEviction
+		g.Expect(json.NewDecoder(r.Body).Decode(&eviction)).To(Succeed())
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.evictions = append(s.evictions, eviction)
+		w.WriteHeader(s.statusCode)
+	}))
+	return s
+}
+
+func (s *spotEvictionWebhookServer) Evictions() []spotEviction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]spotEviction(nil), s.evictions...)
+}
+
+func newSpotEvictionTestNode(name, agentPool string, conditions ...corev1.NodeCondition) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{agentPoolNodeLabel: agentPool},
+		},
+		Spec: corev1.NodeSpec{
+			ProviderID: "azure:///subscriptions/123/resourceGroups/mc_rg/providers/Microsoft.Compute/virtualMachineScaleSets/aks-spot-123/virtualMachines/0",
+		},
+		Status: corev1.NodeStatus{
+			Conditions: conditions,
+		},
+	}
+}
+
+func newSpotEvictionTestForwarder(webhookURL string, nodeClient client.Client) *spotEvictionForwarder {
+	return &spotEvictionForwarder{
+		webhookURL:    webhookURL,
+		httpClient:    http.DefaultClient,
+		cluster:       "my-cluster",
+		namespace:     "default",
+		machinePool:   "my-machine-pool",
+		agentPoolName: "spot",
+		nodeClient: func(context.Context) (client.Client, error) {
+			return nodeClient, nil
+		},
+	}
+}
+
+func TestSpotEvictionForwarderForward(t *testing.T) {
+	scheduledAt := metav1.NewTime(time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC))
+	preempt := corev1.NodeCondition{
+		Type:               vmEventScheduledCondition,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: scheduledAt,
+		Message:            "VM Event Scheduled: Preempt",
+	}
+	reboot := corev1.NodeCondition{
+		Type:               vmEventScheduledCondition,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: scheduledAt,
+		Message:            "VM Event Scheduled: Reboot",
+	}
+	noEvent := corev1.NodeCondition{
+		Type:               vmEventScheduledCondition,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: scheduledAt,
+		Message:            "VM has no scheduled event",
+	}
+
+	tests := []struct {
+		name              string
+		nodes             []*corev1.Node
+		statusCode        int
+		expectedEvictions []string
+		expectedForwarded []string
+		expectErr         bool
+	}{
+		{
+			name: "forwards the scheduled evictions of the nodes of the node pool",
+			nodes: []*corev1.Node{
+				newSpotEvictionTestNode("evicted", "spot", preempt),
+				newSpotEvictionTestNode("rebooted", "spot", reboot),
+				newSpotEvictionTestNode("running", "spot", noEvent),
+				newSpotEvictionTestNode("other-pool", "other", preempt),
+			},
+			statusCode:        http.StatusOK,
+			expectedEvictions: []string{"evicted"},
+			expectedForwarded: []string{"evicted"},
+		},
+		{
+			name: "doesn't forward an eviction twice",
+			nodes: func() []*corev1.Node {
+				node := newSpotEvictionTestNode("evicted", "spot", preempt)
+				node.Annotations = map[string]string{spotEvictionForwardedAnnotation: "2023-09-01T10:00:00Z"}
+				return []*corev1.Node{node}
+			}(),
+			statusCode:        http.StatusOK,
+			expectedForwarded: []string{"evicted"},
+		},
+		{
+			name: "forwards a new eviction of a node",
+			nodes: func() []*corev1.Node {
+				node := newSpotEvictionTestNode("evicted", "spot", preempt)
+				node.Annotations = map[string]string{spotEvictionForwardedAnnotation: "2023-08-01T10:00:00Z"}
+				return []*corev1.Node{node}
+			}(),
+			statusCode:        http.StatusAccepted,
+			expectedEvictions: []string{"evicted"},
+			expectedForwarded: []string{"evicted"},
+		},
+		{
+			name: "doesn't record an eviction rejected by the webhook",
+			nodes: []*corev1.Node{
+				newSpotEvictionTestNode("evicted", "spot", preempt),
+			},
+			statusCode:        http.StatusInternalServerError,
+			expectedEvictions: []string{"evicted"},
+			expectErr:         true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			server := newSpotEvictionWebhookServer(g, tc.statusCode)
+			defer server.Close()
+
+			objects := make([]client.Object, 0, len(tc.nodes))
+			for _, node := range tc.nodes {
+				objects = append(objects, node)
+			}
+			nodeClient := fake.NewClientBuilder().WithObjects(objects...).Build()
+			forwarder := newSpotEvictionTestForwarder(server.URL, nodeClient)
+
+			err := forwarder.Forward(context.Background())
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			var evicted []string
+			for _, eviction := range server.Evictions() {
+				g.Expect(eviction.Cluster).To(Equal("my-cluster"))
+				g.Expect(eviction.Namespace).To(Equal("default"))
+				g.Expect(eviction.MachinePool).To(Equal("my-machine-pool"))
+				g.Expect(eviction.ProviderID).To(HavePrefix("azure:///"))
+				g.Expect(eviction.ScheduledAt.Equal(&scheduledAt)).To(BeTrue())
+				g.Expect(eviction.Message).To(ContainSubstring("Preempt"))
+				evicted = append(evicted, eviction.Node)
+			}
+			g.Expect(evicted).To(Equal(tc.expectedEvictions))
+
+			var forwarded []string
+			nodes := &corev1.NodeList{}
+			g.Expect(nodeClient.List(context.Background(), nodes)).To(Succeed())
+			for _, node := range nodes.Items {
+				if node.Annotations[spotEvictionForwardedAnnotation] == "2023-09-01T10:00:00Z" {
+					forwarded = append(forwarded, node.Name)
+				}
+			}
+			g.Expect(forwarded).To(Equal(tc.expectedForwarded))
+		})
+	}
+}
+
+func TestSpotEvictionForwarderForwardsOnce(t *testing.T) {
+	g := NewWithT(t)
+	server := newSpotEvictionWebhookServer(g, http.StatusOK)
+	defer server.Close()
+
+	nodeClient := fake.NewClientBuilder().WithObjects(newSpotEvictionTestNode("evicted", "spot", corev1.NodeCondition{
+		Type:               vmEventScheduledCondition,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Message:            "VM Event Scheduled: Preempt",
+	})).Build()
+	forwarder := newSpotEvictionTestForwarder(server.URL, nodeClient)
+
+	g.Expect(forwarder.Forward(context.Background())).To(Succeed())
+	g.Expect(forwarder.Forward(context.Background())).To(Succeed())
+	g.Expect(server.Evictions()).To(HaveLen(1))
+}
+
+func TestSpotEvictionPollerPoll(t *testing.T) {
+	g := NewWithT(t)
+	server := newSpotEvictionWebhookServer(g, http.StatusOK)
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	newPool := func(name, webhookURL string) *infrav1.AzureManagedMachinePool {
+		pool := &infrav1.AzureManagedMachinePool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{clusterv1.ClusterNameLabel: "my-cluster"},
+			},
+			Spec: infrav1.AzureManagedMachinePoolSpec{
+				Name: ptr.To(name),
+			},
+		}
+		if webhookURL != "" {
+			pool.Spec.SpotEvictionWebhook = &infrav1.SpotEvictionWebhook{URL: webhookURL}
+		}
+		return pool
+	}
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "default"}}
+	c := fake.NewClientBuilder().WithScheme(setupScheme(g)).WithObjects(
+		cluster,
+		newPool("spot", server.URL),
+		newPool("notallowed", "https://evictions.example.com/notify"),
+		newPool("regular", ""),
+	).Build()
+
+	preempt := corev1.NodeCondition{
+		Type:               vmEventScheduledCondition,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Message:            "VM Event Scheduled: Preempt",
+	}
+	nodeClient := fake.NewClientBuilder().WithObjects(
+		newSpotEvictionTestNode("spot-node", "spot", preempt),
+		newSpotEvictionTestNode("notallowed-node", "notallowed", preempt),
+		newSpotEvictionTestNode("regular-node", "regular", preempt),
+	).Build()
+
+	recorder := record.NewFakeRecorder(10)
+	poller := NewSpotEvictionPoller(c, recorder, "", []string{serverURL.Hostname()})
+	poller.newNodeClient = func(context.Context, client.ObjectKey) (client.Client, error) {
+		return nodeClient, nil
+	}
+
+	poller.poll(context.Background())
+
+	evictions := server.Evictions()
+	g.Expect(evictions).To(HaveLen(1))
+	g.Expect(evictions[0].Node).To(Equal("spot-node"))
+	g.Expect(evictions[0].MachinePool).To(Equal("spot"))
+	g.Expect(evictions[0].Cluster).To(Equal("my-cluster"))
+	g.Expect(recorder.Events).To(Receive(ContainSubstring("spot eviction webhook host evictions.example.com is not allowed")))
+}
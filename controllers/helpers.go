@@ -22,6 +22,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -68,6 +69,8 @@ const (
 	deprecatedManagerCredsWarning = "You're using deprecated functionality: " +
 		"Using Azure credentials from the manager environment is deprecated and will be removed in future releases. " +
 		"Please specify an AzureClusterIdentity for the AzureCluster instead, see: https://capz.sigs.k8s.io/topics/multitenancy.html "
+	// azureStackCloudEnvironmentKey is the key of the cloud provider secret holding the environment file of custom clouds.
+	azureStackCloudEnvironmentKey = "azurestackcloud.json"
 )
 
 type (
@@ -231,6 +234,16 @@ func GetCloudProviderSecret(d azure.ClusterScoper, namespace, name string, owner
 		"azure.json": controlPlaneData,
 	}
 
+	// The cloud provider and the VM identities of custom clouds read their endpoints and token audience from the
+	// environment file set in the AZURE_ENVIRONMENT_FILEPATH environment variable of the nodes.
+	if strings.EqualFold(d.CloudEnvironment(), infrav1.AzureStackCloud) {
+		environmentData, err := json.MarshalIndent(d.AzureEnvironment(), "", "    ")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed cloud environment json marshal")
+		}
+		secret.Data[azureStackCloudEnvironmentKey] = environmentData
+	}
+
 	return secret, nil
 }
 
@@ -18,23 +18,28 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	utilfeature "k8s.io/component-base/featuregate/testing"
 	"k8s.io/utils/ptr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
@@ -43,9 +48,12 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 	capifeature "sigs.k8s.io/cluster-api/feature"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -205,6 +213,50 @@ func TestGetCloudProviderConfig(t *testing.T) {
 	}
 }
 
+func TestGetCloudProviderSecretWithCustomCloud(t *testing.T) {
+	g := NewWithT(t)
+	scheme := runtime.NewScheme()
+	_ = clusterv1.AddToScheme(scheme)
+	_ = infrav1.AddToScheme(scheme)
+
+	environmentFile := filepath.Join(t.TempDir(), "azurestackcloud.json")
+	g.Expect(os.WriteFile(environmentFile, []byte(`{
+		"name": "AzureStackCloud",
+		"resourceManagerEndpoint": "https://management.local.azurestack.external/",
+		"activeDirectoryEndpoint": "https://login.microsoftonline.com/"
+	}`), 0600)).To(Succeed())
+	t.Setenv("AZURE_ENVIRONMENT_FILEPATH", environmentFile)
+	t.Setenv(auth.ClientID, "fooClient")
+	t.Setenv(auth.ClientSecret, "fooSecret")
+	t.Setenv(auth.TenantID, "fooTenant")
+
+	cluster := newCluster("foo")
+	azureCluster := newAzureCluster("bar")
+	azureCluster.Spec.AzureEnvironment = infrav1.AzureStackCloud
+	azureCluster.Spec.TokenAudience = "https://management.adfs.azurestack.local/4de154de-f8a8-4017-af41-df619da68155"
+	azureCluster.Default()
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(cluster, azureCluster).Build()
+
+	clusterScope, err := scope.NewClusterScope(context.Background(), scope.ClusterScopeParams{
+		AzureClients: scope.AzureClients{
+			Authorizer: autorest.NullAuthorizer{},
+		},
+		Cluster:      cluster,
+		AzureCluster: azureCluster,
+		Client:       fakeClient,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cloudConfig, err := GetCloudProviderSecret(clusterScope, "default", "foo", metav1.OwnerReference{}, infrav1.VMIdentitySystemAssigned, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cloudConfig.Data).To(HaveKey(azureStackCloudEnvironmentKey))
+
+	var environment azureautorest.Environment
+	g.Expect(json.Unmarshal(cloudConfig.Data[azureStackCloudEnvironmentKey], &environment)).To(Succeed())
+	g.Expect(environment.ResourceManagerEndpoint).To(Equal("https://management.local.azurestack.external/"))
+	g.Expect(environment.TokenAudience).To(Equal("https://management.adfs.azurestack.local/4de154de-f8a8-4017-af41-df619da68155"))
+}
+
 func TestReconcileAzureSecret(t *testing.T) {
 	g := NewWithT(t)
 
@@ -1522,3 +1574,68 @@ func TestClusterPauseChangeAndInfrastructureReady(t *testing.T) {
 		})
 	}
 }
+
+// controllerRecordingManager is a manager recording the controllers added to it, so that the options they are built
+// with can be checked without starting the manager.
+type controllerRecordingManager struct {
+	manager.Manager
+	client      client.Client
+	controllers []manager.Runnable
+}
+
+// Add records a controller instead of running it with the manager.
+func (m *controllerRecordingManager) Add(runnable manager.Runnable) error {
+	m.controllers = append(m.controllers, runnable)
+	return nil
+}
+
+// GetClient returns a fake client mapping all the kinds of the scheme of the manager.
+func (m *controllerRecordingManager) GetClient() client.Client {
+	return m.client
+}
+
+// newControllerRecordingManager returns a manager that records the controllers added to it. The manager isn't
+// started and its client is fake, so it doesn't need to reach an API server.
+func newControllerRecordingManager(g *WithT) *controllerRecordingManager {
+	scheme := setupScheme(g)
+	mgr, err := ctrl.NewManager(&rest.Config{Host: "https://127.0.0.1:6443"}, ctrl.Options{
+		Scheme:             scheme,
+		MetricsBindAddress: "0",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	restMapper := meta.NewDefaultRESTMapper(scheme.PrioritizedVersionsAllGroups())
+	for gvk := range scheme.AllKnownTypes() {
+		restMapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	return &controllerRecordingManager{
+		Manager: mgr,
+		client:  fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(restMapper).Build(),
+	}
+}
+
+// maxConcurrentReconciles returns the maximum number of concurrent reconciles of a controller added to a manager, as
+// reported by controller-runtime in its metrics. The controller is started with a cancelled context, so it records its
+// metrics and returns without watching anything.
+func maxConcurrentReconciles(g *WithT, c manager.Runnable, name string) int {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = c.Start(ctx)
+
+	families, err := metrics.Registry.Gather()
+	g.Expect(err).NotTo(HaveOccurred())
+	for _, family := range families {
+		if family.GetName() != "controller_runtime_max_concurrent_reconciles" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "controller" && label.GetValue() == name {
+					return int(metric.GetGauge().GetValue())
+				}
+			}
+		}
+	}
+	g.Expect(false).To(BeTrue(), "no maximum number of concurrent reconciles reported for controller %s", name)
+	return 0
+}
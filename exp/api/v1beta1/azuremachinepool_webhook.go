@@ -32,6 +32,7 @@ import (
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/feature"
 	"sigs.k8s.io/cluster-api-provider-azure/util/azure"
+	webhookutils "sigs.k8s.io/cluster-api-provider-azure/util/webhook"
 	capifeature "sigs.k8s.io/cluster-api/feature"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -110,6 +111,7 @@ func (amp *AzureMachinePool) Validate(old runtime.Object, client client.Client)
 		amp.ValidateSystemAssignedIdentity(old),
 		amp.ValidateSystemAssignedIdentityRole,
 		amp.ValidateNetwork,
+		amp.ValidateDataDisks,
 	}
 
 	var errs []error
@@ -127,9 +129,44 @@ func (amp *AzureMachinePool) ValidateNetwork() error {
 	if (amp.Spec.Template.NetworkInterfaces != nil) && len(amp.Spec.Template.NetworkInterfaces) > 0 && amp.Spec.Template.SubnetName != "" {
 		return errors.New("cannot set both NetworkInterfaces and machine SubnetName")
 	}
+	for i, nic := range amp.Spec.Template.NetworkInterfaces {
+		if len(nic.SecondaryIPConfigs) > 0 {
+			return field.Forbidden(field.NewPath("spec", "template", "networkInterfaces").Index(i).Child("secondaryIPConfigs"),
+				"secondary IP configurations with a static private IP address are not supported by the instances of a scale set")
+		}
+		if nic.VirtualNetworkTap != nil {
+			return field.Forbidden(field.NewPath("spec", "template", "networkInterfaces").Index(i).Child("virtualNetworkTap"),
+				"virtual network taps are not supported by the instances of a scale set")
+		}
+	}
 	return nil
 }
 
+// ValidateDataDisks validates that the data disks of an AzureMachinePool are neither shared nor existing disks, as the
+// instances of a scale set can only attach the data disks created with them.
+func (amp *AzureMachinePool) ValidateDataDisks() error {
+	var allErrs field.ErrorList
+	for i, disk := range amp.Spec.Template.DataDisks {
+		diskPath := field.NewPath("spec", "template", "dataDisks").Index(i)
+		if disk.IsShared() {
+			allErrs = append(allErrs, field.Forbidden(diskPath.Child("maxShares"), "shared data disks are not supported by AzureMachinePools"))
+		}
+		if disk.AttachExistingDisk != nil {
+			allErrs = append(allErrs, field.Forbidden(diskPath.Child("attachExistingDisk"), "attaching existing data disks is not supported by AzureMachinePools"))
+		}
+		if disk.BurstingEnabled != nil {
+			allErrs = append(allErrs, field.Forbidden(diskPath.Child("burstingEnabled"), "data disk bursting is not supported by AzureMachinePools"))
+		}
+		if disk.PerformanceTierSchedule != nil {
+			allErrs = append(allErrs, field.Forbidden(diskPath.Child("performanceTierSchedule"), "data disk performance tier schedules are not supported by AzureMachinePools"))
+		}
+		if disk.HasLogicalSectorSize() {
+			allErrs = append(allErrs, field.Forbidden(diskPath.Child("managedDisk", "logicalSectorSize"), "data disk logical sector sizes are not supported by AzureMachinePools"))
+		}
+	}
+	return allErrs.ToAggregate()
+}
+
 // ValidateImage of an AzureMachinePool.
 func (amp *AzureMachinePool) ValidateImage() error {
 	if amp.Spec.Template.Image != nil {
@@ -212,6 +249,17 @@ func (amp *AzureMachinePool) ValidateSystemAssignedIdentity(old runtime.Object)
 			if amp.Spec.SystemAssignedIdentityRole != nil {
 				oldRole = oldMachinePool.Spec.SystemAssignedIdentityRole.Name
 			}
+			// The additional roles can't change, the role assignments of removed roles wouldn't be deleted.
+			var oldAdditionalRoles, additionalRoles []infrav1.SystemAssignedIdentityAdditionalRole
+			if oldMachinePool.Spec.SystemAssignedIdentityRole != nil {
+				oldAdditionalRoles = oldMachinePool.Spec.SystemAssignedIdentityRole.AdditionalRoles
+			}
+			if amp.Spec.SystemAssignedIdentityRole != nil {
+				additionalRoles = amp.Spec.SystemAssignedIdentityRole.AdditionalRoles
+			}
+			if err := webhookutils.ValidateImmutable(field.NewPath("spec", "systemAssignedIdentityRole", "additionalRoles"), oldAdditionalRoles, additionalRoles); err != nil {
+				return err
+			}
 		}
 
 		roleAssignmentName := ""
@@ -245,6 +293,9 @@ func (amp *AzureMachinePool) ValidateSystemAssignedIdentityRole() error {
 	if amp.Spec.Identity != infrav1.VMIdentitySystemAssigned && amp.Spec.SystemAssignedIdentityRole != nil {
 		allErrs = append(allErrs, field.Invalid(field.NewPath("systemAssignedIdentityRole"), amp.Spec.SystemAssignedIdentityRole, "systemAssignedIdentityRole can only be set when identity is set to 'SystemAssigned'"))
 	}
+	if amp.Spec.SystemAssignedIdentityRole != nil {
+		allErrs = append(allErrs, infrav1.ValidateSystemAssignedIdentityAdditionalRoles(amp.Spec.SystemAssignedIdentityRole.AdditionalRoles, field.NewPath("systemAssignedIdentityRole", "additionalRoles"))...)
+	}
 
 	if len(allErrs) > 0 {
 		return kerrors.NewAggregate(allErrs.ToAggregate().Errors())
@@ -255,39 +306,7 @@ func (amp *AzureMachinePool) ValidateSystemAssignedIdentityRole() error {
 
 // ValidateDiagnostics validates the Diagnostic spec.
 func (amp *AzureMachinePool) ValidateDiagnostics() error {
-	var allErrs field.ErrorList
-	fieldPath := field.NewPath("diagnostics")
-
-	diagnostics := amp.Spec.Template.Diagnostics
-
-	if diagnostics != nil && diagnostics.Boot != nil {
-		switch diagnostics.Boot.StorageAccountType {
-		case infrav1.UserManagedDiagnosticsStorage:
-			if diagnostics.Boot.UserManaged == nil {
-				allErrs = append(allErrs, field.Required(fieldPath.Child("UserManaged"),
-					fmt.Sprintf("userManaged must be specified when storageAccountType is '%s'", infrav1.UserManagedDiagnosticsStorage)))
-			} else if diagnostics.Boot.UserManaged.StorageAccountURI == "" {
-				allErrs = append(allErrs, field.Required(fieldPath.Child("StorageAccountURI"),
-					fmt.Sprintf("StorageAccountURI cannot be empty when storageAccountType is '%s'", infrav1.UserManagedDiagnosticsStorage)))
-			}
-		case infrav1.ManagedDiagnosticsStorage:
-			if diagnostics.Boot.UserManaged != nil &&
-				diagnostics.Boot.UserManaged.StorageAccountURI != "" {
-				allErrs = append(allErrs, field.Invalid(fieldPath.Child("StorageAccountURI"), diagnostics.Boot.UserManaged.StorageAccountURI,
-					fmt.Sprintf("StorageAccountURI cannot be set when storageAccountType is '%s'",
-						infrav1.ManagedDiagnosticsStorage)))
-			}
-		case infrav1.DisabledDiagnosticsStorage:
-			if diagnostics.Boot.UserManaged != nil &&
-				diagnostics.Boot.UserManaged.StorageAccountURI != "" {
-				allErrs = append(allErrs, field.Invalid(fieldPath.Child("StorageAccountURI"), diagnostics.Boot.UserManaged.StorageAccountURI,
-					fmt.Sprintf("StorageAccountURI cannot be set when storageAccountType is '%s'",
-						infrav1.ManagedDiagnosticsStorage)))
-			}
-		}
-	}
-
-	if len(allErrs) > 0 {
+	if allErrs := infrav1.ValidateDiagnostics(amp.Spec.Template.Diagnostics, field.NewPath("diagnostics")); len(allErrs) > 0 {
 		return kerrors.NewAggregate(allErrs.ToAggregate().Errors())
 	}
 
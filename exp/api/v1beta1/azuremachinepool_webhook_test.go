@@ -165,7 +165,7 @@ func TestAzureMachinePool_ValidateCreate(t *testing.T) {
 		},
 		{
 			name:    "azuremachinepool with disabled diagnostics profile",
-			amp:     createMachinePoolWithDiagnostics(infrav1.ManagedDiagnosticsStorage, nil),
+			amp:     createMachinePoolWithDiagnostics(infrav1.DisabledDiagnosticsStorage, nil),
 			wantErr: false,
 		},
 		{
@@ -183,6 +183,11 @@ func TestAzureMachinePool_ValidateCreate(t *testing.T) {
 			amp:     createMachinePoolWithDiagnostics(infrav1.UserManagedDiagnosticsStorage, nil),
 			wantErr: true,
 		},
+		{
+			name:    "azuremachinepool with user managed diagnostics profile, but invalid storage account URI",
+			amp:     createMachinePoolWithDiagnostics(infrav1.UserManagedDiagnosticsStorage, &infrav1.UserManagedBootDiagnostics{StorageAccountURI: "fakeurl"}),
+			wantErr: true,
+		},
 		{
 			name: "azuremachinepool with invalid MaxSurge and MaxUnavailable rolling upgrade configuration",
 			amp: createMachinePoolWithStrategy(AzureMachinePoolDeploymentStrategy{
@@ -220,6 +225,84 @@ func TestAzureMachinePool_ValidateCreate(t *testing.T) {
 			amp:     createMachinePoolWithNetworkConfig("", []infrav1.NetworkInterface{{SubnetName: "testSubnet"}}),
 			wantErr: false,
 		},
+		{
+			name: "azuremachinepool with a data disk that is not shared",
+			amp: createMachinePoolWithDataDisks([]infrav1.DataDisk{
+				{NameSuffix: "data", DiskSizeGB: 128, MaxShares: ptr.To[int32](1)},
+			}),
+			wantErr: false,
+		},
+		{
+			name: "azuremachinepool with a shared data disk",
+			amp: createMachinePoolWithDataDisks([]infrav1.DataDisk{
+				{NameSuffix: "data", DiskSizeGB: 128, MaxShares: ptr.To[int32](2)},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "azuremachinepool with an existing data disk",
+			amp: createMachinePoolWithDataDisks([]infrav1.DataDisk{
+				{
+					NameSuffix: "data",
+					AttachExistingDisk: &infrav1.AttachExistingDisk{
+						ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/disks/my-disk",
+					},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "azuremachinepool with a data disk logical sector size",
+			amp: createMachinePoolWithDataDisks([]infrav1.DataDisk{
+				{
+					NameSuffix: "data",
+					DiskSizeGB: 128,
+					ManagedDisk: &infrav1.ManagedDiskParameters{
+						StorageAccountType: "PremiumV2_LRS",
+						LogicalSectorSize:  ptr.To[int32](512),
+					},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "azuremachinepool with a data disk performance tier schedule",
+			amp: createMachinePoolWithDataDisks([]infrav1.DataDisk{
+				{
+					NameSuffix: "data",
+					DiskSizeGB: 128,
+					PerformanceTierSchedule: &infrav1.DiskPerformanceTierSchedule{
+						Tier:          "P30",
+						OffHoursTier:  "P10",
+						OffHoursStart: "20:00",
+						OffHoursEnd:   "08:00",
+					},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "azuremachinepool with a secondary IP configuration",
+			amp: createMachinePoolWithNetworkInterfaces([]infrav1.NetworkInterface{
+				{
+					SubnetName:         "node-subnet",
+					PrivateIPConfigs:   1,
+					SecondaryIPConfigs: []infrav1.SecondaryIPConfig{{Name: "floating-vip", PrivateIP: "10.0.0.100"}},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "azuremachinepool with a virtual network tap",
+			amp: createMachinePoolWithNetworkInterfaces([]infrav1.NetworkInterface{
+				{
+					SubnetName:        "node-subnet",
+					PrivateIPConfigs:  1,
+					VirtualNetworkTap: &infrav1.VirtualNetworkTapReference{ID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworkTaps/my-tap"},
+				},
+			}),
+			wantErr: true,
+		},
 		{
 			name:    "azuremachinepool with Flexible orchestration mode",
 			amp:     createMachinePoolWithOrchestrationMode(compute.OrchestrationModeFlexible),
@@ -342,6 +425,30 @@ func TestAzureMachinePool_ValidateUpdate(t *testing.T) {
 			amp:     createMachinePoolWithSystemAssignedIdentity(string(uuid.NewUUID())),
 			wantErr: true,
 		},
+		{
+			name:    "azuremachinepool with additional roles unchanged",
+			oldAMP:  createMachinePoolWithAdditionalRoles("30a757d8-fcf0-4c8b-acf0-9253a7e093ea", "/subscriptions/123/resourceGroups/my-rg"),
+			amp:     createMachinePoolWithAdditionalRoles("30a757d8-fcf0-4c8b-acf0-9253a7e093ea", "/subscriptions/123/resourceGroups/my-rg"),
+			wantErr: false,
+		},
+		{
+			name:    "azuremachinepool with additional roles added",
+			oldAMP:  createMachinePoolWithSystemAssignedIdentity("30a757d8-fcf0-4c8b-acf0-9253a7e093ea"),
+			amp:     createMachinePoolWithAdditionalRoles("30a757d8-fcf0-4c8b-acf0-9253a7e093ea", "/subscriptions/123/resourceGroups/my-rg"),
+			wantErr: true,
+		},
+		{
+			name:    "azuremachinepool with additional roles changed",
+			oldAMP:  createMachinePoolWithAdditionalRoles("30a757d8-fcf0-4c8b-acf0-9253a7e093ea", "/subscriptions/123/resourceGroups/my-rg"),
+			amp:     createMachinePoolWithAdditionalRoles("30a757d8-fcf0-4c8b-acf0-9253a7e093ea", "/subscriptions/123/resourceGroups/other-rg"),
+			wantErr: true,
+		},
+		{
+			name:    "azuremachinepool with additional role at an invalid scope",
+			oldAMP:  createMachinePoolWithAdditionalRoles("30a757d8-fcf0-4c8b-acf0-9253a7e093ea", "my-rg"),
+			amp:     createMachinePoolWithAdditionalRoles("30a757d8-fcf0-4c8b-acf0-9253a7e093ea", "my-rg"),
+			wantErr: true,
+		},
 		{
 			name:   "azuremachinepool with invalid MaxSurge and MaxUnavailable rolling upgrade configuration",
 			oldAMP: createMachinePoolWithStrategy(AzureMachinePoolDeploymentStrategy{}),
@@ -544,6 +651,26 @@ func createMachinePoolWithNetworkConfig(subnetName string, interfaces []infrav1.
 	}
 }
 
+func createMachinePoolWithDataDisks(dataDisks []infrav1.DataDisk) *AzureMachinePool {
+	return &AzureMachinePool{
+		Spec: AzureMachinePoolSpec{
+			Template: AzureMachinePoolMachineTemplate{
+				DataDisks: dataDisks,
+			},
+		},
+	}
+}
+
+func createMachinePoolWithNetworkInterfaces(networkInterfaces []infrav1.NetworkInterface) *AzureMachinePool {
+	return &AzureMachinePool{
+		Spec: AzureMachinePoolSpec{
+			Template: AzureMachinePoolMachineTemplate{
+				NetworkInterfaces: networkInterfaces,
+			},
+		},
+	}
+}
+
 func createMachinePoolWithImageByID(imageID string, terminateNotificationTimeout *int) *AzureMachinePool {
 	image := infrav1.Image{
 		ID: &imageID,
@@ -573,6 +700,17 @@ func createMachinePoolWithSystemAssignedIdentity(role string) *AzureMachinePool
 	}
 }
 
+func createMachinePoolWithAdditionalRoles(role, scope string) *AzureMachinePool {
+	amp := createMachinePoolWithSystemAssignedIdentity(role)
+	amp.Spec.SystemAssignedIdentityRole.AdditionalRoles = []infrav1.SystemAssignedIdentityAdditionalRole{
+		{
+			Scope:        scope,
+			DefinitionID: "/subscriptions/123/providers/Microsoft.Authorization/roleDefinitions/acdd72a7-3385-48ef-bd42-f606fba81ae7",
+		},
+	}
+	return amp
+}
+
 func createMachinePoolWithDiagnostics(diagnosticsType infrav1.BootDiagnosticsStorageAccountType, userManaged *infrav1.UserManagedBootDiagnostics) *AzureMachinePool {
 	var diagnostics *infrav1.Diagnostics
 
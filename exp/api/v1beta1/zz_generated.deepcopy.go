@@ -337,7 +337,7 @@ func (in *AzureMachinePoolSpec) DeepCopyInto(out *AzureMachinePoolSpec) {
 	if in.SystemAssignedIdentityRole != nil {
 		in, out := &in.SystemAssignedIdentityRole, &out.SystemAssignedIdentityRole
 		*out = new(apiv1beta1.SystemAssignedIdentityRole)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.UserAssignedIdentities != nil {
 		in, out := &in.UserAssignedIdentities, &out.UserAssignedIdentities
@@ -79,7 +79,7 @@ func (s *azureMachinePoolService) Pause(ctx context.Context) error {
 	defer done()
 
 	for _, service := range s.services {
-		pauser, ok := service.(azure.Pauser)
+		pauser, ok := azure.ShouldPause(ctx, service)
 		if !ok {
 			continue
 		}
@@ -37,6 +37,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
 	"sigs.k8s.io/cluster-api-provider-azure/controllers"
 	infrav1exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1beta1"
 	infrav1controllersexp "sigs.k8s.io/cluster-api-provider-azure/exp/controllers"
@@ -107,6 +109,11 @@ var (
 	webhookPort                        int
 	reconcileTimeout                   time.Duration
 	enableTracing                      bool
+	compressBootstrapData              bool
+	replicateGalleryImages             bool
+	azureAPIQPS                        float32
+	azureAPIBurst                      int
+	spotEvictionWebhookAllowedHosts    []string
 )
 
 // InitFlags initializes all command-line flags.
@@ -233,6 +240,41 @@ func InitFlags(fs *pflag.FlagSet) {
 		"Enable tracing to the opentelemetry-collector service in the same namespace.",
 	)
 
+	fs.BoolVar(
+		&compressBootstrapData,
+		"compress-bootstrap-data",
+		false,
+		"Compress the bootstrap data of Linux AzureMachines with gzip to fit larger bootstrap data in the 64KB VM custom data limit.",
+	)
+
+	fs.BoolVar(
+		&replicateGalleryImages,
+		"replicate-gallery-images",
+		false,
+		"Replicate the gallery image versions of AzureMachines to the region of the cluster when they are not replicated to it yet.",
+	)
+
+	fs.Float32Var(
+		&azureAPIQPS,
+		"azure-api-qps",
+		0,
+		"Maximum number of requests per second sent to the Azure API for each subscription, shared by all controllers. Zero disables client-side rate limiting.",
+	)
+
+	fs.IntVar(
+		&azureAPIBurst,
+		"azure-api-burst",
+		10,
+		"Maximum burst of requests sent to the Azure API for each subscription when client-side rate limiting is enabled.",
+	)
+
+	fs.StringSliceVar(
+		&spotEvictionWebhookAllowedHosts,
+		"spot-eviction-webhook-allowed-hosts",
+		nil,
+		"Comma-separated list of the hosts of the spot eviction webhooks of AzureManagedMachinePools the scheduled spot evictions are forwarded to. Scheduled spot evictions aren't forwarded if empty.",
+	)
+
 	feature.MutableGates.AddFlag(fs)
 }
 
@@ -243,6 +285,12 @@ func main() {
 
 	ctrl.SetLogger(klogr.New())
 
+	if azureAPIQPS > 0 && azureAPIBurst < 1 {
+		setupLog.Error(fmt.Errorf("--azure-api-burst must be at least 1 when --azure-api-qps is set, got %d", azureAPIBurst), "invalid Azure API rate limits")
+		os.Exit(1)
+	}
+	azure.SetAPIRateLimits(azureAPIQPS, azureAPIBurst)
+
 	if watchNamespace != "" {
 		setupLog.Info("Watching cluster-api objects only in namespace for reconciliation", "namespace", watchNamespace)
 	}
@@ -329,11 +377,14 @@ func registerControllers(ctx context.Context, mgr manager.Manager) {
 	if err != nil {
 		setupLog.Error(err, "failed to build machineCache ReconcileCache")
 	}
-	if err := controllers.NewAzureMachineReconciler(mgr.GetClient(),
+	azureMachineReconciler := controllers.NewAzureMachineReconciler(mgr.GetClient(),
 		mgr.GetEventRecorderFor("azuremachine-reconciler"),
 		reconcileTimeout,
 		watchFilterValue,
-	).SetupWithManager(ctx, mgr, controllers.Options{Options: controller.Options{MaxConcurrentReconciles: azureMachineConcurrency}, Cache: machineCache}); err != nil {
+	)
+	azureMachineReconciler.CompressBootstrapData = compressBootstrapData
+	azureMachineReconciler.ReplicateGalleryImages = replicateGalleryImages
+	if err := azureMachineReconciler.SetupWithManager(ctx, mgr, controllers.Options{Options: controller.Options{MaxConcurrentReconciles: azureMachineConcurrency}, Cache: machineCache}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "AzureMachine")
 		os.Exit(1)
 	}
@@ -450,6 +501,16 @@ func registerControllers(ctx context.Context, mgr manager.Manager) {
 			os.Exit(1)
 		}
 
+		if err := controllers.NewSpotEvictionPoller(
+			mgr.GetClient(),
+			mgr.GetEventRecorderFor("azuremanagedmachinepool-spoteviction"),
+			watchFilterValue,
+			spotEvictionWebhookAllowedHosts,
+		).SetupWithManager(ctx, mgr); err != nil {
+			setupLog.Error(err, "unable to create runnable", "runnable", "SpotEvictionPoller")
+			os.Exit(1)
+		}
+
 		mcCache, err := coalescing.NewRequestCache(debouncingTimer)
 		if err != nil {
 			setupLog.Error(err, "failed to build mcCache ReconcileCache")
@@ -483,7 +544,7 @@ func registerControllers(ctx context.Context, mgr manager.Manager) {
 }
 
 func registerWebhooks(mgr manager.Manager) {
-	if err := (&infrav1.AzureCluster{}).SetupWebhookWithManager(mgr); err != nil {
+	if err := infrav1.SetupAzureClusterWebhookWithManager(mgr, &scope.AvailabilityZonesGetter{Client: mgr.GetClient()}); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "AzureCluster")
 		os.Exit(1)
 	}
@@ -520,7 +581,7 @@ func registerWebhooks(mgr manager.Manager) {
 		os.Exit(1)
 	}
 
-	if err := infrav1.SetupAzureMachineWebhookWithManager(mgr); err != nil {
+	if err := infrav1.SetupAzureMachineWebhookWithManager(mgr, &scope.VMSizeCapabilitiesGetter{Client: mgr.GetClient()}); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "AzureMachine")
 		os.Exit(1)
 	}
@@ -35,6 +35,9 @@ const (
 	DefaultReconcilerRequeue = 15 * time.Second
 	// DefaultHTTP429RetryAfter is a default backoff wait time when we get a HTTP 429 response with no Retry-After data.
 	DefaultHTTP429RetryAfter = 1 * time.Minute
+	// DefaultResourceLockedRequeue is how long to wait before trying again to change an Azure resource which couldn't be
+	// changed because of a management lock.
+	DefaultResourceLockedRequeue = 5 * time.Minute
 )
 
 // DefaultedLoopTimeout will default the timeout if it is zero-valued.